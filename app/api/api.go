@@ -6,12 +6,50 @@ import (
 	"go-hex/app"
 	"go-hex/configs"
 	"go-hex/docs"
+	"go-hex/internal/analytics"
+	"go-hex/internal/audit"
 	"go-hex/internal/auth"
-	"go-hex/internal/repository/mysql"
+	"go-hex/internal/broadcast"
+	"go-hex/internal/cachewarm"
+	"go-hex/internal/catalog"
+	"go-hex/internal/deliverability"
+	"go-hex/internal/deprecation"
+	"go-hex/internal/elevation"
+	"go-hex/internal/hosted"
+	"go-hex/internal/identityview"
+	"go-hex/internal/legalhold"
+	"go-hex/internal/lifecycle"
+	"go-hex/internal/migrations"
+	"go-hex/internal/notification"
+	"go-hex/internal/policy"
+	"go-hex/internal/provisioning"
+	"go-hex/internal/rbac"
+	"go-hex/internal/repository/dynamo"
+	"go-hex/internal/repository/memory"
+	"go-hex/internal/repository/mongo"
+	"go-hex/internal/repository/port"
+	"go-hex/internal/repository/redis"
+	"go-hex/internal/repository/sqlrepo"
+	"go-hex/internal/rollout"
+	"go-hex/internal/serviceaccount"
+	"go-hex/internal/siem"
+	"go-hex/internal/signup"
+	"go-hex/internal/sms"
+	"go-hex/internal/tenant"
+	"go-hex/internal/transport/graphql"
 	"go-hex/internal/user"
+	"go-hex/internal/usersync"
+	"go-hex/internal/verbosity"
+	"go-hex/pkg/auth/jwks"
 	"go-hex/pkg/db"
+	"go-hex/pkg/event"
+	"go-hex/pkg/health"
 	"go-hex/pkg/logger"
+	"go-hex/pkg/metrics"
 	"go-hex/pkg/otel"
+	"go-hex/pkg/ratelimit"
+	"go-hex/pkg/slo"
+	"go-hex/shared/response"
 	"net/http"
 	"os"
 	"os/signal"
@@ -22,6 +60,7 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	echoSwagger "github.com/swaggo/echo-swagger"
 	"github.com/uptrace/bun"
@@ -30,9 +69,31 @@ import (
 // API struct
 type API struct {
 	cfg    *configs.Config
+	watch  *configs.Watcher
 	router *echo.Echo
 	db     *bun.DB
 	log    logger.Logger
+	events event.Bus
+	notif  *notification.Dispatcher
+	usage  *analytics.Recorder
+	deprec *deprecation.Tracker
+	audits *serviceaccount.AuditWriter
+	trail  *audit.Writer
+	siem   *siem.Exporter
+	levels *verbosity.Service
+	syncer *verbosity.Syncer
+	casts  *broadcast.Service
+	caster *broadcast.Syncer
+	mails  *deliverability.Service
+	texts  *sms.Service
+	tmpls  *catalog.Service
+	slos   *slo.Tracker
+	redis  *redis.Client
+	stats  port.DeploymentStatsRepository
+	deploy *rollout.Recorder
+	grpc   *grpcServer
+	ready  *readiness
+	life   *lifecycle.Manager
 }
 
 // New inits a new api
@@ -46,18 +107,160 @@ func New() *API {
 
 	log := logger.New(cfg.Server.NAME, app.Version)
 	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetLevel(logrus.Level(cfg.Log.Level))
+	logger.SetDebugSampleRate(cfg.Log.DebugSampleRate)
+
+	// the log level, the rate limits and the token expirations are reloaded without restart
+	watch := configs.NewWatcher(cfg, configs.DefaultSources(), log, time.Duration(cfg.Reload.Interval)*time.Second)
+	go func(changes <-chan configs.Change) {
+		for change := range changes {
+			logger.SetLevel(logrus.Level(change.Config.Log.Level))
+		}
+	}(watch.Subscribe())
 
-	db, err := db.NewBunMySQLConn(cfg.Server.ENV, cfg.Database.Host, cfg.Database.Port, cfg.Database.Username, cfg.Database.Password, cfg.Database.DBName)
+	operationTimeouts := make(map[string]time.Duration, len(cfg.Database.OperationTimeouts))
+	for operation, timeout := range cfg.Database.OperationTimeouts {
+		operationTimeouts[operation] = time.Duration(timeout) * time.Millisecond
+	}
+	db, err := db.NewBunConn(cfg.Server.ENV, cfg.Database.Host, cfg.Database.Port, cfg.Database.Username, cfg.Database.Password, cfg.Database.DBName,
+		db.WithDriver(cfg.Database.Driver), db.WithStatementTimeout(time.Duration(cfg.Database.StatementTimeout)*time.Millisecond, operationTimeouts),
+		db.WithPool(db.Pool{
+			MaxOpenConns:    cfg.Database.MaxOpenConns,
+			MaxIdleConns:    cfg.Database.MaxIdleConns,
+			ConnMaxLifetime: time.Duration(cfg.Database.ConnMaxLifetime) * time.Second,
+			ConnMaxIdleTime: time.Duration(cfg.Database.ConnMaxIdleTime) * time.Second,
+		}))
 	if err != nil {
 		panic(err)
 	}
+	if status, err := migrations.NewRunner(db).Check(context.Background()); err != nil {
+		if cfg.Database.FailOnPendingMigrations || errors.Cause(err) != migrations.ErrPending {
+			log.Fatal(err)
+		}
+		log.WithParams(logger.Params{"pending": status.Pending}).Warn("the database schema is behind the build, run migrate up")
+	}
 	router := echo.New()
 
+	events := event.New()
+	events.Subscribe(event.All, func(ctx context.Context, e event.Event) {
+		log.WithParams(logger.Params{"type": "event", "event": e}).Info(e.Name)
+	})
+
+	exporter := siem.NewConfiguredExporter(cfg, log, app.Version)
+	exporter.Subscribe(events)
+
+	notifTimeout := time.Duration(cfg.Notification.Timeout) * time.Second
+	var push notification.Notifier = notification.NewLogNotifier(notification.ChannelPush, log)
+	if cfg.Notification.PushGatewayURL != "" {
+		push = notification.NewPushNotifier(cfg.Notification.PushGatewayURL, cfg.Notification.PushGatewayToken, notifTimeout)
+	}
+	webhookMaxAge := time.Duration(cfg.Email.WebhookMaxAge) * time.Second
+	sendgrid, err := deliverability.NewSendGrid(cfg.Email.SendGridWebhookPublicKey, webhookMaxAge)
+	if err != nil {
+		log.Fatal(err)
+	}
+	mails := deliverability.NewService(sqlrepo.NewRepositoryRegistry(db), log, events, deliverability.NewSES(cfg.Email.SESTopicARNs, webhookMaxAge, notifTimeout), sendgrid)
+
+	var email notification.Notifier = notification.NewLogNotifier(notification.ChannelEmail, log)
+	if cfg.Email.SMTPAddress != "" {
+		email = notification.NewSMTPNotifier(cfg.Email.SMTPAddress, cfg.Email.SMTPUsername, cfg.Email.SMTPPassword, cfg.Email.From, notifTimeout)
+	}
+
+	var providers []sms.Provider
+	if cfg.SMS.TwilioAccountSID != "" {
+		providers = append(providers, sms.NewTwilioProvider(cfg.SMS.TwilioAccountSID, cfg.SMS.TwilioAuthToken, cfg.SMS.TwilioFrom, cfg.SMS.TwilioStatusCallbackURL))
+	}
+	if cfg.SMS.SNSEnabled {
+		client, err := sms.NewSNSClient(context.Background(), cfg.SMS.SNSRegion)
+		if err != nil {
+			log.Fatal(err)
+		}
+		providers = append(providers, sms.NewSNSProvider(client, cfg.SMS.SNSSenderID))
+	}
+	texts := sms.NewService(sqlrepo.NewRepositoryRegistry(db), log, cfg.SMS.Routes, cfg.SMS.Prices, time.Duration(cfg.SMS.Timeout)*time.Second, providers...)
+	var text notification.Notifier = notification.NewLogNotifier(notification.ChannelSMS, log)
+	if len(providers) > 0 {
+		text = texts
+	}
+	notif := notification.NewDispatcher(push, notification.NewSuppressingNotifier(email, mails), text)
+	templates := catalog.NewService(cfg, sqlrepo.NewRepositoryRegistry(db), log, events, notif)
+	notif.WithRenderer(templates)
+
+	usage := analytics.NewRecorder(
+		sqlrepo.NewRepositoryRegistry(db).GetTokenUsageRepository(),
+		log,
+		cfg.JWTKeys(),
+		cfg.Analytics.TokenUsageSampleRate,
+		time.Duration(cfg.Analytics.TokenUsageFlushInterval)*time.Second,
+	)
+
+	deprec := deprecation.NewTracker(log, cfg.JWTKeys(), time.Duration(cfg.Deprecation.DigestInterval)*time.Hour, cfg.Deprecation.Routes)
+
+	audits := serviceaccount.NewAuditWriter(
+		sqlrepo.NewRepositoryRegistry(db),
+		log,
+		cfg.Audit.BufferSize,
+		cfg.Audit.BatchSize,
+		time.Duration(cfg.Audit.FlushInterval)*time.Millisecond,
+		cfg.Audit.Backpressure,
+	)
+
+	trail := audit.NewWriter(
+		sqlrepo.NewRepositoryRegistry(db),
+		log,
+		cfg.Audit.BufferSize,
+		cfg.Audit.BatchSize,
+		time.Duration(cfg.Audit.FlushInterval)*time.Millisecond,
+		cfg.Audit.Backpressure,
+	)
+	trail.Subscribe(events)
+
+	levels := verbosity.NewService(sqlrepo.NewRepositoryRegistry(db), log)
+	syncer := verbosity.NewSyncer(levels, log, time.Duration(cfg.Log.VerbositySyncInterval)*time.Second)
+
+	casts := broadcast.NewService(sqlrepo.NewRepositoryRegistry(db), log, events, time.Duration(cfg.Broadcast.KeepAliveInterval)*time.Second)
+	caster := broadcast.NewSyncer(casts, log, time.Duration(cfg.Broadcast.SyncInterval)*time.Second)
+
+	slos := slo.NewTracker(objectives(cfg))
+	metrics.Register(slos)
+
+	// the stats of the deployments are shared by the instances through Redis when configured
+	var redisClient *redis.Client
+	retention := time.Duration(cfg.DeployAnalysis.Retention) * time.Hour
+	stats := memory.NewDeploymentStatsRepository(retention)
+	if cfg.Redis.Address != "" {
+		redisClient = redis.NewClient(cfg.Redis.Address, cfg.Redis.Password, cfg.Redis.DB, time.Duration(cfg.Redis.Timeout)*time.Millisecond)
+		stats = redis.NewDeploymentStatsRepository(redisClient, retention)
+	}
+	deploy := rollout.NewRecorder(stats, log, app.Build().Version, unanalyzedRoute, time.Duration(cfg.DeployAnalysis.FlushInterval)*time.Second)
+
 	return &API{
 		cfg,
+		watch,
 		router,
 		db,
 		log,
+		events,
+		notif,
+		usage,
+		deprec,
+		audits,
+		trail,
+		exporter,
+		levels,
+		syncer,
+		casts,
+		caster,
+		mails,
+		texts,
+		templates,
+		slos,
+		redisClient,
+		stats,
+		deploy,
+		&grpcServer{},
+		&readiness{},
+		lifecycle.NewManager(log, time.Duration(cfg.Shutdown.DrainTimeout)*time.Second, time.Duration(cfg.Shutdown.StopTimeout)*time.Second),
 	}
 }
 
@@ -66,28 +269,274 @@ func (api API) BuildHandler() *echo.Echo {
 
 	api.configRouter()
 
+	build := app.Build()
+	err := otel.SetTraceProvider(api.cfg.OpenTelemetry.JaegerURL, api.cfg.Server.NAME, build.Version, api.cfg.Server.ENV.String(), api.cfg.OpenTelemetry.Sampled,
+		otel.AttributeServiceCommit.String(build.Commit), otel.AttributeServiceBuildDate.String(build.BuildDate))
+	if err != nil {
+		api.log.Fatal(err)
+	}
+
+	repoRegistry := sqlrepo.NewRepositoryRegistry(api.db)
+	// the dependencies checked by the readiness probe and the diagnostics
+	checks := health.NewRegistry()
+	checks.Register("database", api.db.PingContext)
+	if api.cfg.DynamoDB.Table != "" {
+		client, err := dynamo.NewClient(context.Background(), api.cfg.DynamoDB.Region, api.cfg.DynamoDB.Endpoint)
+		if err != nil {
+			api.log.Fatal(err)
+		}
+		repoRegistry = dynamo.NewRepositoryRegistry(repoRegistry, client, api.cfg.DynamoDB.Table)
+		checks.Register("dynamodb", func(ctx context.Context) error {
+			return dynamo.Ping(ctx, client, api.cfg.DynamoDB.Table)
+		})
+	}
+	if api.cfg.MongoDB.URI != "" {
+		client, err := mongo.NewClient(context.Background(), api.cfg.MongoDB.URI)
+		if err != nil {
+			api.log.Fatal(err)
+		}
+		users := mongo.Users(client, api.cfg.MongoDB.Database)
+		if err := mongo.EnsureIndexes(context.Background(), users); err != nil {
+			api.log.Fatal(err)
+		}
+		repoRegistry = mongo.NewRepositoryRegistry(repoRegistry, users, time.Duration(api.cfg.MongoDB.Timeout)*time.Second)
+		api.life.Register(lifecycle.PhaseConnections, "mongodb", client.Disconnect)
+		checks.Register("mongodb", func(ctx context.Context) error {
+			return mongo.Ping(ctx, client)
+		})
+	}
+
+	redisClient := api.redis
+	if redisClient != nil {
+		// the revoked and the opaque tokens are kept in redis, the logged in users are not served without it
+		checks.Register("redis", func(ctx context.Context) error {
+			return redis.Ping(ctx, redisClient)
+		})
+	}
+
+	auth.RegisterSocialProviderChecks(api.cfg, checks)
+
+	// the identity views are built from the data source and invalidated by the events of the instance
+	identities := identityview.NewService(repoRegistry, nil, 0, api.cfg.RBAC.DefaultRoles, api.log)
+	if api.cfg.IdentityView.Enabled {
+		maxStaleness := time.Duration(api.cfg.IdentityView.MaxStaleness) * time.Second
+		views := memory.NewIdentityViewRepository(maxStaleness)
+		if redisClient != nil {
+			views = redis.NewIdentityViewRepository(redisClient, maxStaleness)
+		}
+		identities = identityview.NewService(repoRegistry, views, maxStaleness, api.cfg.RBAC.DefaultRoles, api.log)
+		identities.Subscribe(api.events)
+	}
+
+	// the users read by id are shared by the instances in redis, in front of the data source
+	if api.cfg.RedisUserCache.Enabled && redisClient != nil {
+		repoRegistry = redis.NewRepositoryRegistry(repoRegistry, redisClient, time.Duration(api.cfg.RedisUserCache.TTL)*time.Second)
+	}
+
+	// the hot users are served from the memory of the instance, warmed by their logins from the data source
+	if api.cfg.UserCache.Enabled {
+		cache := memory.NewUserCache(api.cfg.UserCache.Size, time.Duration(api.cfg.UserCache.TTL)*time.Second)
+		warmer := cachewarm.NewWarmer(repoRegistry, cache, api.log, api.cfg.UserCache.Size)
+		warmer.Subscribe(api.events)
+		api.life.Register(lifecycle.PhaseWorkers, "user cache warmer", lifecycle.Func(warmer.Close))
+		repoRegistry = memory.NewRepositoryRegistry(repoRegistry, cache)
+	}
+
+	blacklist := memory.NewTokenBlacklistRepository()
+	opaque := memory.NewOpaqueTokenRepository()
+	var limits ratelimit.Store = ratelimit.NewMemoryStore()
+	if redisClient != nil {
+		blacklist = redis.NewTokenBlacklistRepository(redisClient)
+		opaque = redis.NewOpaqueTokenRepository(redisClient)
+		limits = redis.NewRateLimitStore(redisClient)
+	}
+
+	api.registerRoutes(repoRegistry, blacklist, opaque, limits, identities, checks)
+
+	// every route must declare its permission, so that the authorization coverage can be audited
+	if err := validatePermissions(api.router.Routes()); err != nil {
+		api.log.Fatal(err)
+	}
+
+	return api.router
+}
+
+// registerRoutes registers the routes of the api
+func (api API) registerRoutes(repoRegistry port.RepositoryRegistry, blacklist port.TokenBlacklistRepository, opaque port.OpaqueTokenRepository, limits ratelimit.Store, identities auth.IdentityViewReader, checks *health.Registry) {
+
 	// Endpoint for swagger documentations
 	api.router.GET("/swagger/*", echoSwagger.WrapHandler)
 
-	err := otel.SetTraceProvider(api.cfg.OpenTelemetry.JaegerURL, api.cfg.Server.NAME, app.Version, api.cfg.Server.ENV.String(), api.cfg.OpenTelemetry.Sampled)
-	if err != nil {
-		api.log.Fatal(err)
+	authService := auth.NewService(api.cfg, repoRegistry, blacklist, opaque, identities, api.log, api.events, api.notif, api.deprec)
+	api.router.Pre(customMiddleware.ResolveOpaqueTokens(authService))                 // middleware for replacing the opaque access tokens by the signed ones
+	api.router.Use(customMiddleware.VerifySession(api.cfg.JWTKeys(), authService))     // middleware for rejecting the access tokens of revoked sessions
+	api.router.Use(customMiddleware.RejectRevokedTokens(api.cfg.JWTKeys(), blacklist)) // middleware for rejecting the access tokens revoked by a logout
+
+	// the requests see the configuration as reloaded when they start, overridden by their tenant below
+	if api.watch != nil {
+		api.router.Use(customMiddleware.CurrentConfig(api.watch.Current))
 	}
 
-	repoRegistry := mysql.NewRepositoryRegistry(api.db)
+	// the login attempts are limited per IP address and username, the requests per identity of the access tokens
+	api.router.Use(customMiddleware.LimitLogins(limits, loginRoutes, api.cfg))
+	api.router.Use(customMiddleware.LimitIdentities(limits, api.cfg.JWTKeys(), api.cfg))
+
+	// the settings of the tenant named by the header of the request override the configuration for the request
+	tenants := tenant.NewResolver(api.cfg, repoRegistry, api.log, time.Duration(api.cfg.Tenant.CacheTTL)*time.Second)
+	tenants.Subscribe(api.events)
+	api.router.Use(tenants.Middleware(api.cfg.Tenant.Header))
 
 	auth.RegisterAPI(
 		*api.router.Group(""),
 		api.cfg,
-		auth.NewService(api.cfg, repoRegistry),
+		authService,
+	)
+
+	// the new users are registered inactive until they verify their address, their passwords hashed by the pool of the logins
+	userService := user.NewService(api.cfg, repoRegistry, api.log, api.events, api.notif, authService.Passwords())
+	signupService := signup.NewService(api.cfg, api.log, api.events, signup.NewGate(api.log, signup.ConfiguredChecks(api.cfg)...), userService)
+	signup.RegisterAPI(
+		*api.router.Group(""),
+		api.cfg,
+		signupService,
+	)
+
+	graphql.RegisterAPI(
+		*api.router.Group(""),
+		api.cfg,
+		authService,
+		signupService,
+		repoRegistry,
+		limits,
+		api.log,
+	)
+
+	serviceaccount.RegisterAPI(
+		*api.router.Group(""),
+		api.cfg,
+		serviceaccount.NewService(api.cfg, repoRegistry, api.events, api.audits),
+	)
+
+	elevation.RegisterAPI(
+		*api.router.Group(""),
+		api.cfg,
+		elevation.NewService(api.cfg, repoRegistry, api.log, api.events, api.notif),
+	)
+
+	rbac.RegisterAPI(
+		*api.router.Group(""),
+		api.cfg,
+		rbac.NewService(api.cfg, repoRegistry, api.log, api.events),
+	)
+
+	policy.RegisterAPI(
+		*api.router.Group(""),
+		api.cfg,
+		policy.NewService(repoRegistry),
+	)
+
+	audit.RegisterAPI(
+		*api.router.Group(""),
+		api.cfg,
+		audit.NewService(repoRegistry),
+	)
+
+	analytics.RegisterAPI(
+		*api.router.Group(""),
+		api.cfg,
+		analytics.NewService(api.cfg, repoRegistry),
 	)
 
 	user.RegisterAPI(
 		*api.router.Group(""),
 		api.cfg,
-		user.NewService(api.cfg, repoRegistry),
+		userService,
+	)
+
+	// the auth and user services are also served over gRPC
+	api.grpc.register(api.cfg, api.watch, authService, userService, limits, api.log)
+
+	verbosity.RegisterAPI(
+		*api.router.Group(""),
+		api.cfg,
+		api.levels,
+	)
+
+	legalhold.RegisterAPI(
+		*api.router.Group(""),
+		api.cfg,
+		legalhold.NewService(repoRegistry, api.log, api.events),
+	)
+
+	broadcast.RegisterAPI(
+		*api.router.Group(""),
+		api.cfg,
+		api.casts,
+	)
+
+	deliverability.RegisterAPI(
+		*api.router.Group(""),
+		api.cfg,
+		api.mails,
+	)
+
+	sms.RegisterAPI(
+		*api.router.Group(""),
+		api.cfg,
+		api.texts,
+	)
+
+	catalog.RegisterAPI(
+		*api.router.Group(""),
+		api.cfg,
+		api.tmpls,
+	)
+
+	tenantService := tenant.NewService(api.cfg, repoRegistry, api.log, api.events)
+	tenant.RegisterAPI(
+		*api.router.Group(""),
+		api.cfg,
+		tenantService,
+	)
+
+	hosted.RegisterAPI(
+		*api.router.Group(""),
+		api.cfg,
+		api.log,
+		authService,
+		tenantService,
+	)
+
+	sources, err := usersync.ConfiguredSources(api.cfg)
+	if err != nil {
+		api.log.Fatal(err)
+	}
+	usersync.RegisterAPI(
+		*api.router.Group(""),
+		api.cfg,
+		usersync.NewService(api.cfg, repoRegistry, api.log, api.events, sources...),
 	)
 
+	connectors, err := provisioning.ConfiguredConnectors(api.cfg)
+	if err != nil {
+		api.log.Fatal(err)
+	}
+	provisioning.RegisterAPI(
+		*api.router.Group(""),
+		api.cfg,
+		provisioning.NewService(api.cfg, repoRegistry, api.log, api.events, connectors...),
+	)
+
+	rollout.RegisterAPI(
+		*api.router.Group(""),
+		api.cfg,
+		rollout.NewService(api.cfg, api.stats, app.Build().Version),
+	)
+
+	api.router.GET("/metrics", echo.WrapHandler(metrics.Handler()), customMiddleware.InternalAPI(api.cfg.InternalAPI.User, api.cfg.InternalAPI.Password))
+	api.router.GET("/debug/diagnostics", api.diagnostics(checks), customMiddleware.InternalAPI(api.cfg.InternalAPI.User, api.cfg.InternalAPI.Password))
+	api.router.GET("/slo", api.sloReport, customMiddleware.InternalAPI(api.cfg.InternalAPI.User, api.cfg.InternalAPI.Password))
+
 	api.router.GET("/health", func(c echo.Context) error {
 		return c.JSON(http.StatusOK, map[string]string{
 			"api": api.cfg.Server.NAME,
@@ -95,44 +544,88 @@ func (api API) BuildHandler() *echo.Echo {
 		})
 	})
 
-	api.router.Any("", func(c echo.Context) error {
-		return echo.NotFoundHandler(c)
-	})
+	api.router.GET("/healthz", liveness)
 
-	api.router.Any("/*", func(c echo.Context) error {
-		return echo.NotFoundHandler(c)
-	})
+	// readiness probes, pass once the warmup completed and while the critical dependencies are up
+	api.router.GET("/ready", api.ready.handler(checks))
+	api.router.GET("/readyz", api.ready.handler(checks))
 
-	return api.router
+	api.router.GET("/version", version)
+	api.router.GET("/.well-known/jwks.json", jwks.Handler(api.cfg.JWTKeys()))
+	api.router.GET("/capabilities", api.capabilities)
+	api.router.GET("/errors", errorCatalog)
+
+	api.router.Any("", echo.NotFoundHandler)
+	api.router.Any("/*", echo.NotFoundHandler)
 }
 
 func (api API) configRouter() {
 
+	requestTimeout := time.Duration(api.cfg.Server.RequestTimeout) * time.Second
+	build := app.Build()
+	bulkhead := customMiddleware.Bulkhead(
+		time.Duration(api.cfg.Bulkhead.QueueTimeout)*time.Millisecond,
+		customMiddleware.BulkheadPartition{RouteGroup: authRoutes, Limit: api.cfg.Bulkhead.AuthLimit},
+		customMiddleware.BulkheadPartition{RouteGroup: adminRoutes, Limit: api.cfg.Bulkhead.AdminLimit},
+	)
+	adaptiveLimit := customMiddleware.AdaptiveLimit(customMiddleware.AdaptiveLimitConfig{
+		InitialLimit:  api.cfg.AdaptiveLimit.InitialLimit,
+		MinLimit:      api.cfg.AdaptiveLimit.MinLimit,
+		MaxLimit:      api.cfg.AdaptiveLimit.MaxLimit,
+		LatencyTarget: time.Duration(api.cfg.AdaptiveLimit.LatencyTarget) * time.Millisecond,
+		Backoff:       api.cfg.AdaptiveLimit.Backoff,
+	}, authRoutes, adminRoutes)
+
 	api.router.Pre(middleware.RemoveTrailingSlash())
 	// api.router.Use(middleware.RequestID())
 	api.router.Use(middleware.CORSWithConfig(middleware.CORSConfig{
 		AllowOrigins: []string{"*"},
 		AllowMethods: []string{http.MethodGet, http.MethodHead, http.MethodPut, http.MethodPatch, http.MethodPost, http.MethodDelete},
 	}))
-	api.router.Use(customMiddleware.RequestIDContext())                  // middleware for insert request id into context
-	api.router.Use(customMiddleware.HandlerTracing(api.cfg.Server.NAME)) // middleware for handling opentelemetry
+	api.router.Use(customMiddleware.RequestIDContext())                            // middleware for insert request id into context
+	api.router.Use(customMiddleware.Locale())                                      // middleware for insert the locale of the caller into context
+	api.router.Use(customMiddleware.RequestTimeout(requestTimeout))                // middleware for cancelling the statements of slow requests
+	api.router.Use(customMiddleware.HandlerTracing(api.cfg.Server.NAME))           // middleware for handling opentelemetry
+	api.router.Use(customMiddleware.AppVersion(build.Version, build.Commit))       // middleware for answering the build in the headers
+	api.router.Use(customMiddleware.ClientVersionGate(api.cfg.Client.MinVersions)) // middleware for rejecting the outdated clients
+	api.router.Use(customMiddleware.RequestMetrics(observedRoutes))                // middleware for observing the latency with trace exemplars
+	api.router.Use(customMiddleware.RequestOutcomes(api.slos, api.deploy))         // middleware for classifying the outcomes of the requests for the slo and the deployment analysis
+	api.router.Use(customMiddleware.RequestLog(api.log))                           // middleware for carrying the logger of the request and logging its summary
+	api.router.Use(bulkhead)                                                       // middleware for isolating the login traffic from the admin traffic
+	api.router.Use(api.usage.Middleware())                                         // middleware for sampling the token usage
+	api.router.Use(api.deprec.Middleware())                                        // middleware for tracking the deprecated routes and fields
+	if api.cfg.AdaptiveLimit.Enabled {
+		api.router.Use(adaptiveLimit) // middleware for shedding the requests of an overloaded route group
+	}
+
+	// Setup the IP address of the clients, read from X-Forwarded-For through the trusted proxies only
+	ipExtractor, err := customMiddleware.IPExtractor(api.cfg.Server.TrustedProxies)
+	if err != nil {
+		api.log.Fatal(err)
+	}
+	api.router.IPExtractor = ipExtractor
 
 	// Setup custom HTTP error handler
 	api.router.HTTPErrorHandler = CustomHTTPErrorHandler(api.cfg, api.log)
 
-	// Register middleware recover from panic
-
-	// Setup access log
-	api.router.Use(middleware.LoggerWithConfig(middleware.LoggerConfig{
-		Format: `{"time":"${time_rfc3339_nano}","request_id":"${id}","remote_ip":"${remote_ip}","host":"${host}",` +
-			`"method":"${method}","uri":"${uri}","status":${status},"error":"${error}","latency":"${latency}",` +
-			`"latency_human":"${latency_human}","bytes_in":${bytes_in},` +
-			`"service":"` + api.cfg.Server.NAME + `",` +
-			`"environment":"` + api.cfg.Server.ENV.String() + `",` +
-			`"type":"access",` +
-			`"bytes_out":${bytes_out}}` + "\n",
-	}))
+	// Setup the naming and the envelope of the JSON requests and responses, and the negotiated formats and encodings
+	codec := response.NewCodec(api.cfg.JSON.Naming, api.cfg.JSON.Envelope)
+	var formats []response.Format
+	if api.cfg.JSON.Hypermedia {
+		formats = append(formats, response.JSONAPI{}, response.NewHAL(codec))
+	}
+	var encodings []response.Encoding
+	if api.cfg.Encoding.MsgPack {
+		encodings = append(encodings, response.NewMsgPack(codec))
+	}
+	if api.cfg.Encoding.Protobuf {
+		encodings = append(encodings, response.Protobuf{})
+	}
+	registry := response.NewRegistry(codec, formats...).WithEncodings(encodings...)
+	api.router.JSONSerializer = registry
+	api.router.Binder = registry
 
+	// Register middleware recover from panic
 	api.router.Use(customMiddleware.Recover(api.log))
 
 }
@@ -149,6 +642,7 @@ func (api API) Start() {
 		Addr:    fmt.Sprintf(":%v", api.cfg.Server.PORT),
 		Handler: api.BuildHandler(),
 	}
+	server.RegisterOnShutdown(api.casts.Close)
 
 	go func() {
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -157,27 +651,45 @@ func (api API) Start() {
 	}()
 
 	api.log.Infof("server is running at port: %v [env: %v, version: %v]", api.cfg.Server.PORT, api.cfg.Server.ENV, app.Version)
+	api.grpc.serve(api.cfg, api.log)
 
-	gracefulShutdownServer(ctx, &server, api.log)
-}
-
-func gracefulShutdownServer(ctx context.Context, srv *http.Server, log logger.Logger) {
+	go api.warmup(ctx)
 
+	api.registerShutdown(&server)
 	<-ctx.Done()
 
-	log.Info("server stopped")
-
-	ctxShutDown, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer func() {
-		cancel()
-	}()
-
-	if err := srv.Shutdown(ctxShutDown); err != nil {
-		log.Fatalf("server shutdown failed:%+s", err)
+	api.log.Info("server stopped")
+	api.ready.stop()
+	if err := api.life.Shutdown(); err != nil {
+		api.log.Errorf("server shutdown failed:%+s", err)
+		return
 	}
+	api.log.Info("server exited properly")
+}
 
-	log.Info("server exited properly")
-
+// registerShutdown registers the components stopped on shutdown: the servers drain the requests in flight, then
+// the workers flush the token usage sampled, the deprecation digest, the buffered audit and security events, which
+// the exporters send with the spans before the connections are closed
+func (api API) registerShutdown(server *http.Server) {
+	api.life.Register(lifecycle.PhaseServers, "http", server.Shutdown)
+	api.life.Register(lifecycle.PhaseServers, "grpc", api.grpc.shutdown)
+
+	api.life.Register(lifecycle.PhaseWorkers, "token usage", lifecycle.Func(api.usage.Close))
+	api.life.Register(lifecycle.PhaseWorkers, "deprecations", lifecycle.Func(api.deprec.Close))
+	api.life.Register(lifecycle.PhaseWorkers, "service account audits", lifecycle.Func(api.audits.Close))
+	api.life.Register(lifecycle.PhaseWorkers, "audit trail", lifecycle.Func(api.trail.Close))
+	api.life.Register(lifecycle.PhaseWorkers, "log verbosity syncer", lifecycle.Func(api.syncer.Close))
+	api.life.Register(lifecycle.PhaseWorkers, "config watcher", lifecycle.Func(api.watch.Close))
+	api.life.Register(lifecycle.PhaseWorkers, "broadcast syncer", lifecycle.Func(api.caster.Close))
+	api.life.Register(lifecycle.PhaseWorkers, "deployment recorder", lifecycle.Func(api.deploy.Close))
+
+	api.life.Register(lifecycle.PhaseExporters, "siem", lifecycle.Func(api.siem.Close))
+	api.life.Register(lifecycle.PhaseExporters, "traces", otel.Shutdown)
+
+	api.life.Register(lifecycle.PhaseConnections, "database", lifecycle.Closer(api.db))
+	if api.redis != nil {
+		api.life.Register(lifecycle.PhaseConnections, "redis", lifecycle.Closer(api.redis))
+	}
 }
 
 func handleSigterm(exitFunc func()) {
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"go-hex/configs"
+	"go-hex/shared/response"
+	"sort"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Capabilities describes the optional subsystems enabled in the deployment
+type Capabilities struct {
+	MFA               Capability `json:"mfa"`                // the logins approved from a logged in device
+	SSO               Capability `json:"sso"`                // the sessions ended by the upstream identity provider
+	SCIM              Capability `json:"scim"`               // not available in this service
+	Webhooks          Capability `json:"webhooks"`           // not available in this service
+	Passwordless      Capability `json:"passwordless"`       // not available in this service
+	DeviceLogin       Capability `json:"device_login"`       // the devices logged in from another device
+	BackchannelLogout Capability `json:"backchannel_logout"` // the logouts notified to the relying parties
+	PushNotifications Capability `json:"push_notifications"`
+	Broadcasts        Capability `json:"broadcasts"`
+	Elevation         Capability `json:"elevation"`                                                  // the sensitive roles granted for a bounded time once approved
+	Signup            Capability `json:"signup"`                                                     // the public registration of the users
+	MediaTypes        []string   `json:"media_types" example:"application/json,application/msgpack"` // answered to the requests accepting them
+	ClientMinVersions []string   `json:"client_min_versions" example:"ios:2.3.0"`
+}
+
+// Capability tells whether a subsystem is enabled, and its endpoints when it is
+type Capability struct {
+	Enabled   bool     `json:"enabled"`
+	Endpoints []string `json:"endpoints,omitempty" example:"POST /auth/approvals/{id}/decision"`
+}
+
+// capabilitiesOf returns the capabilities of the deployment from its configuration
+func capabilitiesOf(cfg *configs.Config) Capabilities {
+
+	res := Capabilities{
+		DeviceLogin: Capability{true, []string{"POST /device-login/start", "POST /device-login/decision", "POST /device-login/poll"}},
+		Broadcasts:  Capability{true, []string{"GET /broadcasts/stream"}},
+		MediaTypes:  []string{response.MediaTypeJSON},
+	}
+	if cfg.LoginApproval.Enabled {
+		res.MFA = Capability{true, []string{"GET /auth/approvals", "POST /auth/approvals/{id}/decision", "POST /auth/approvals/{id}/token"}}
+	}
+	if len(cfg.Elevation.Roles) > 0 {
+		res.Elevation = Capability{true, []string{"POST /elevations", "GET /elevations", "GET /elevations/pending", "POST /elevations/{id}/decision"}}
+	}
+	if cfg.Signup.Enabled {
+		res.Signup = Capability{true, []string{"POST /auth/signup"}}
+	}
+	if cfg.OIDC.UpstreamIssuer != "" {
+		res.SSO = Capability{true, []string{"POST /auth/backchannel-logout"}}
+	}
+	if len(cfg.OIDC.BackchannelClients) > 0 {
+		res.BackchannelLogout.Enabled = true
+	}
+	if cfg.Notification.PushGatewayURL != "" {
+		res.PushNotifications.Enabled = true
+	}
+	if cfg.JSON.Hypermedia {
+		res.MediaTypes = append(res.MediaTypes, response.MediaTypeJSONAPI, response.MediaTypeHAL)
+	}
+	if cfg.Encoding.MsgPack {
+		res.MediaTypes = append(res.MediaTypes, response.MediaTypeMsgPack)
+	}
+	if cfg.Encoding.Protobuf {
+		res.MediaTypes = append(res.MediaTypes, response.MediaTypeProtobuf)
+	}
+	res.ClientMinVersions = []string{}
+	for clientType, version := range cfg.Client.MinVersions {
+		res.ClientMinVersions = append(res.ClientMinVersions, clientType+":"+version)
+	}
+	sort.Strings(res.ClientMinVersions)
+	return res
+}
+
+// capabilities godoc
+// @Router /capabilities [get]
+// @Tags Version
+// @Summary Get the capabilities
+// @Description Answer the optional subsystems enabled in the deployment, or for the tenant of the request, with their endpoints, the media types answered and the minimum versions of the clients, so that the clients adapt to the deployment
+// @Produce json
+// @Success 200 {object} response.Response{data=Capabilities} "Success"
+func (api API) capabilities(c echo.Context) error {
+	return response.SuccessOK(c, capabilitiesOf(configs.FromContext(c.Request().Context(), api.cfg)))
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"context"
+	"go-hex/app"
+	"go-hex/internal/migrations"
+	"go-hex/pkg/health"
+	"go-hex/shared/response"
+	"os"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// diagnosticsTimeout bounds every check of the diagnostics
+const diagnosticsTimeout = 2 * time.Second
+
+// Diagnostics describes the state of an instance
+type Diagnostics struct {
+	Instance     string                  `json:"instance" example:"go-hex-7d9c5b6f4-x2k8p"`
+	Build        app.BuildInfo           `json:"build"`
+	Config       ConfigDiagnostics       `json:"config"`
+	Migrations   MigrationDiagnostics    `json:"migrations"`
+	Dependencies []DependencyDiagnostics `json:"dependencies"`
+	Queues       map[string]int          `json:"queues"` // items waiting in the buffers of the instance
+	Database     DatabasePoolDiagnostics `json:"database"`
+}
+
+// ConfigDiagnostics is the configuration of the instance with its secrets redacted
+type ConfigDiagnostics struct {
+	Fingerprint string                 `json:"fingerprint"` // equal on the instances configured alike, secrets aside
+	Values      map[string]interface{} `json:"values"`
+}
+
+// MigrationDiagnostics is the migration status of the database
+type MigrationDiagnostics struct {
+	*migrations.Status
+	Error string `json:"error,omitempty"`
+}
+
+// DependencyDiagnostics is the outcome of the check of a dependency
+type DependencyDiagnostics struct {
+	Name      string  `json:"name" example:"database"`
+	Healthy   bool    `json:"healthy"`
+	LatencyMs float64 `json:"latency_ms" example:"1.2"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// DatabasePoolDiagnostics are the statistics of the connection pool of the database
+type DatabasePoolDiagnostics struct {
+	MaxOpen        int     `json:"max_open"`
+	Open           int     `json:"open"`
+	InUse          int     `json:"in_use"`
+	Idle           int     `json:"idle"`
+	WaitCount      int64   `json:"wait_count"`
+	WaitDurationMs float64 `json:"wait_duration_ms"`
+}
+
+// diagnostics godoc
+// @Router /debug/diagnostics [get]
+// @Tags Debug
+// @Summary Diagnose the instance
+// @Description Report the build, the configuration with its secrets redacted, the migration status, the latency of the dependencies and the depth of the buffers of the instance
+// @Produce json
+// @Security BasicAuth
+// @Success 200 {object} response.Response{data=Diagnostics} "Success"
+// @failure 401 {object} response.ErrorResponse401
+func (api API) diagnostics(checks *health.Registry) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx, cancel := context.WithTimeout(c.Request().Context(), diagnosticsTimeout)
+		defer cancel()
+
+		instance, _ := os.Hostname()
+		res := Diagnostics{
+			Instance: instance,
+			Build:    app.Build(),
+			Config:   ConfigDiagnostics{api.cfg.Fingerprint(), api.cfg.Redacted()},
+			Queues: map[string]int{
+				"audit_events":      api.audits.Buffered(),
+				"siem_events":       api.siem.Buffered(),
+				"broadcast_streams": api.casts.Streams(),
+			},
+		}
+
+		status, err := migrations.NewRunner(api.db).Status(ctx)
+		if err != nil {
+			res.Migrations.Error = err.Error()
+		} else {
+			res.Migrations.Status = &status
+		}
+
+		report := checks.Check(ctx)
+		res.Dependencies = make([]DependencyDiagnostics, len(report.Checks))
+		for i, result := range report.Checks {
+			res.Dependencies[i] = DependencyDiagnostics{
+				Name:      result.Name,
+				Healthy:   result.Status == health.StatusUp,
+				LatencyMs: result.LatencyMs,
+			}
+			if result.Err != nil {
+				res.Dependencies[i].Error = result.Err.Error()
+			}
+		}
+
+		stats := api.db.Stats()
+		res.Database = DatabasePoolDiagnostics{
+			MaxOpen:        stats.MaxOpenConnections,
+			Open:           stats.OpenConnections,
+			InUse:          stats.InUse,
+			Idle:           stats.Idle,
+			WaitCount:      stats.WaitCount,
+			WaitDurationMs: float64(stats.WaitDuration.Microseconds()) / 1e3,
+		}
+
+		return response.SuccessOK(c, res)
+	}
+}
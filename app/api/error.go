@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"go-hex/configs"
 	"go-hex/pkg/logger"
+	"go-hex/pkg/otel"
 	"go-hex/shared/ierr"
 	"go-hex/shared/response"
 	"net/http"
@@ -11,9 +12,6 @@ import (
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
-	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/codes"
-	"go.opentelemetry.io/otel/trace"
 )
 
 type stackTracer interface {
@@ -25,6 +23,13 @@ func CustomHTTPErrorHandler(cfg *configs.Config, log logger.Logger) echo.HTTPErr
 
 	return func(err error, c echo.Context) {
 
+		// answers the errors of the catalog returned as they are with their default status
+		if e, ok := errors.Cause(err).(ierr.Error); ok {
+			if definition, defined := ierr.Lookup(e); defined && definition.HTTPStatus != http.StatusInternalServerError {
+				err = response.HTTPError(err, definition.HTTPStatus, e.Code, e.Message)
+			}
+		}
+
 		// var internalErr error
 		if _, ok := err.(response.ErrorResponse); !ok {
 			err = response.ErrorResponse{
@@ -44,6 +49,12 @@ func CustomHTTPErrorHandler(cfg *configs.Config, log logger.Logger) echo.HTTPErr
 			err = response.HTTPError(internalErr, http.StatusNotFound, ierr.ErrResourceNotFound.Code, "requested endpoint is not registered")
 		}
 
+		// handles overloaded dependencies, such as a saturated password pool
+		if errors.Is(internalErr, ierr.ErrServiceUnavailable) {
+			c.Response().Header().Set(echo.HeaderRetryAfter, "1")
+			err = response.HTTPError(internalErr, http.StatusServiceUnavailable, ierr.ErrServiceUnavailable.Code, ierr.ErrServiceUnavailable.Message)
+		}
+
 		// Handles validation error
 		if errors.As(internalErr, &validation.Errors{}) || errors.As(internalErr, &validation.ErrorObject{}) {
 			err = response.HTTPError(internalErr, http.StatusBadRequest, ierr.ErrBadRequest.Code, internalErr.Error())
@@ -71,13 +82,16 @@ func CustomHTTPErrorHandler(cfg *configs.Config, log logger.Logger) echo.HTTPErr
 			log.With(c.Request().Context()).Error(err)
 		}
 
-		if span := trace.SpanFromContext(c.Request().Context()); span != nil {
-			span.SetStatus(codes.Code(resp.HTTPCode), resp.Internal.Error())
-			span.SetAttributes(attribute.Int("http.status_code", resp.HTTPCode))
-			span.RecordError(errors.Cause(resp.Internal))
-			span.SetAttributes(attribute.Bool("error", true))
-			span.SetAttributes(attribute.String("error_message", errors.Cause(resp.Internal).Error()))
-			span.SetAttributes(attribute.String("stack_trace", fmt.Sprintf("%+v", resp.Internal)))
+		otel.RecordHTTPError(c.Request().Context(), resp.Internal, resp.HTTPCode)
+
+		// tells the clients refused until a time, by a rate limit or a lockout, when to retry
+		if retryAfter := resp.RetryAfter(); retryAfter != "" {
+			c.Response().Header().Set(echo.HeaderRetryAfter, retryAfter)
+		}
+
+		// challenges the 401 and 403 with their reason, unless challenged by the route, e.g. with basic auth
+		if challenge := resp.Challenge(); challenge != "" && c.Response().Header().Get(echo.HeaderWWWAuthenticate) == "" {
+			c.Response().Header().Set(echo.HeaderWWWAuthenticate, challenge)
 		}
 
 		c.JSON(resp.HTTPCode, resp)
@@ -0,0 +1,19 @@
+package api
+
+import (
+	"go-hex/shared/ierr"
+	"go-hex/shared/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+// errorCatalog answers the catalog of the errors
+// @Router /errors [get]
+// @Tags Errors
+// @Summary Get the catalog of the errors
+// @Description Answer every error the api and the gRPC services may answer, by code, with its category, its default HTTP status and gRPC code and its description. The codes are stable, so that the clients handle every error by its code.
+// @Produce json
+// @Success 200 {object} response.Response{data=[]ierr.Definition} "Success"
+func errorCatalog(c echo.Context) error {
+	return response.SuccessOK(c, ierr.Catalog())
+}
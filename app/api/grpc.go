@@ -0,0 +1,85 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"go-hex/configs"
+	"go-hex/internal/auth"
+	"go-hex/internal/user"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/ratelimit"
+	grpctransport "go-hex/transport/grpc"
+	"net"
+	"sync"
+
+	"google.golang.org/grpc"
+)
+
+// grpcServer serves the auth and user services over gRPC next to the HTTP server, it is shared by the copies of the API.
+// The server is created with the services of the routes, and disabled without GRPC_PORT.
+type grpcServer struct {
+	mu     sync.Mutex
+	server *grpc.Server
+}
+
+// register creates the gRPC server of the services, the calls see the configuration as reloaded by watch and share the
+// login rate limits of the routes
+func (g *grpcServer) register(cfg *configs.Config, watch *configs.Watcher, authService auth.ServicePort, userService user.ServicePort, limits ratelimit.Store, log logger.Logger) {
+	if g == nil || cfg.GRPC.Port == "" {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.server = grpctransport.NewServer(cfg, watch.Current, authService, userService, limits, log)
+}
+
+// serve listens on the gRPC port, it does nothing when the gRPC server is disabled
+func (g *grpcServer) serve(cfg *configs.Config, log logger.Logger) {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	server := g.server
+	g.mu.Unlock()
+	if server == nil {
+		return
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%v", cfg.GRPC.Port))
+	if err != nil {
+		log.Fatalf("grpc listen:%+s\n", err)
+	}
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			log.Fatalf("grpc serve:%+s\n", err)
+		}
+	}()
+	log.Infof("grpc server is running at port: %v", cfg.GRPC.Port)
+}
+
+// shutdown waits for the calls in flight before stopping the gRPC server, the calls still in flight are cancelled
+// once ctx is done
+func (g *grpcServer) shutdown(ctx context.Context) error {
+	if g == nil {
+		return nil
+	}
+	g.mu.Lock()
+	server := g.server
+	g.mu.Unlock()
+	if server == nil {
+		return nil
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		server.Stop()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	_ "embed"
+	"fmt"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Permissions required by the routes
+const (
+	permissionPublic         = "public"
+	permissionCredentials    = "credentials"
+	permissionSignature      = "signature"
+	permissionLoggedIn       = "logged_in"
+	permissionSession        = "session"
+	permissionInternal       = "internal"
+	permissionInternalOrRole = "internal_or_role"
+	permissionGranted        = "permission"
+)
+
+// permissionsFile declares the permission required by every route, as METHOD /path: permission
+//
+//go:embed permissions.yaml
+var permissionsFile []byte
+
+// permissions returns the permissions declared by permissions.yaml, keyed by route
+func permissions() (map[string]string, error) {
+	declared := map[string]string{}
+	if err := yaml.Unmarshal(permissionsFile, &declared); err != nil {
+		return nil, errors.Wrap(err, "cannot read permissions.yaml")
+	}
+	return declared, nil
+}
+
+// validatePermissions checks that every registered route declares a known permission in permissions.yaml,
+// and that every declared route is registered, the catch-all routes answering 404 are skipped.
+func validatePermissions(routes []*echo.Route) error {
+	declared, err := permissions()
+	if err != nil {
+		return err
+	}
+
+	var problems []string
+	registered := map[string]bool{}
+	for _, route := range routes {
+		if route.Name == notFoundHandler {
+			continue
+		}
+		key := route.Method + " " + route.Path
+		registered[key] = true
+		permission, ok := declared[key]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s has no permission", key))
+			continue
+		}
+		if !isPermission(permission) {
+			problems = append(problems, fmt.Sprintf("%s has the unknown permission %q", key, permission))
+		}
+	}
+	for key := range declared {
+		if !registered[key] {
+			problems = append(problems, fmt.Sprintf("%s is not registered", key))
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return errors.Errorf("invalid permissions.yaml: %s", strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// notFoundHandler is the name of the routes registered by echo for the groups with middlewares
+var notFoundHandler = runtime.FuncForPC(reflect.ValueOf(echo.NotFoundHandler).Pointer()).Name()
+
+// isPermission checks whether the permission is known, internal_or_role requires a role and permission
+// requires the permission granted by the roles
+func isPermission(permission string) bool {
+	switch permission {
+	case permissionPublic, permissionCredentials, permissionSignature, permissionLoggedIn, permissionSession, permissionInternal:
+		return true
+	}
+	for _, prefix := range []string{permissionInternalOrRole, permissionGranted} {
+		value := strings.TrimPrefix(permission, prefix+":")
+		if value != permission && value != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"go-hex/configs"
+	"go-hex/internal/identityview"
+	"go-hex/internal/repository/memory"
+	"go-hex/internal/repository/sqlrepo"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/ratelimit"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRouter() *echo.Echo {
+	cfg := &configs.Config{}
+	cfg.InternalAPI.User, cfg.InternalAPI.Password = "internal", "secret"
+	cfg.JWT.SigningKey = "test-signing-key"
+	api := API{cfg: cfg, router: echo.New(), log: logger.New("test", "test"), events: event.New(), ready: &readiness{}}
+	api.router.HTTPErrorHandler = CustomHTTPErrorHandler(cfg, api.log)
+	registry := sqlrepo.NewRepositoryRegistry(nil)
+	api.registerRoutes(registry, memory.NewTokenBlacklistRepository(), memory.NewOpaqueTokenRepository(), ratelimit.NewMemoryStore(), identityview.NewService(registry, nil, 0, nil, api.log), nil)
+	return api.router
+}
+
+// TestPermissionsCoverEveryRoute fails when a route is added without declaring its permission in permissions.yaml
+func TestPermissionsCoverEveryRoute(t *testing.T) {
+	assert.NoError(t, validatePermissions(newTestRouter().Routes()))
+}
+
+// TestPermissionsAreEnforced checks that the routes declaring credentials reject the requests without them
+func TestPermissionsAreEnforced(t *testing.T) {
+	declared, err := permissions()
+	require.NoError(t, err)
+	router := newTestRouter()
+
+	for key, permission := range declared {
+		if permission != permissionLoggedIn && permission != permissionInternal && !strings.HasPrefix(permission, permissionInternalOrRole+":") && !strings.HasPrefix(permission, permissionGranted+":") {
+			continue
+		}
+		method, path, _ := strings.Cut(key, " ")
+		path = strings.NewReplacer(":", "", "*", "any").Replace(path)
+		t.Run(key, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		})
+	}
+}
+
+func TestValidatePermissions(t *testing.T) {
+	routes := []*echo.Route{
+		{Method: http.MethodGet, Path: "/health"},
+		{Method: http.MethodGet, Path: "/unmapped"},
+		{Method: http.MethodGet, Path: "/*", Name: notFoundHandler},
+	}
+	err := validatePermissions(routes)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "GET /unmapped has no permission")
+		assert.Contains(t, err.Error(), "POST /auth/login is not registered")
+		assert.NotContains(t, err.Error(), "GET /health ")
+		assert.NotContains(t, err.Error(), "GET /*")
+	}
+
+	assert.True(t, isPermission("internal_or_role:analytics:read"))
+	assert.False(t, isPermission("internal_or_role:"))
+	assert.True(t, isPermission("permission:roles:write"))
+	assert.False(t, isPermission("permission:"))
+	assert.False(t, isPermission("admin"))
+}
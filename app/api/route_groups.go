@@ -0,0 +1,22 @@
+package api
+
+import customMiddleware "go-hex/middleware"
+
+var (
+	// authRoutes are the latency sensitive routes issuing and refreshing the tokens
+	authRoutes = customMiddleware.RouteGroup{Name: "auth", Prefixes: []string{"/auth/", "/device-login/", "/service-accounts/token", "/graphql"}}
+	// adminRoutes are the internal routes used by the back office and the bulk jobs
+	adminRoutes = customMiddleware.RouteGroup{Name: "admin", Prefixes: []string{"/internal/", "/metrics", "/debug/", "/slo"}}
+	// probeRoutes are the routes called by the orchestrator and the monitoring, not by the clients
+	probeRoutes = customMiddleware.RouteGroup{Name: "probe", Prefixes: []string{"/health", "/ready", "/version"}}
+	// loginRoutes are the routes checking the credentials of the users, limited per IP address and username
+	loginRoutes = customMiddleware.RouteGroup{Name: "login", Prefixes: []string{"/auth/login", "/auth/social/", "/hosted/login"}}
+	// observedRoutes are the routes whose latency is observed with the exemplars of their traces
+	observedRoutes = customMiddleware.RouteGroup{Name: "observed", Prefixes: []string{"/auth/login", "/auth/token/refresh"}}
+)
+
+// unanalyzedRoute tells whether the requests of the route are left out of the deployment analysis, which compares
+// the versions on the traffic of the clients
+func unanalyzedRoute(route string) bool {
+	return adminRoutes.Match(route) || probeRoutes.Match(route)
+}
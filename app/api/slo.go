@@ -0,0 +1,41 @@
+package api
+
+import (
+	"go-hex/configs"
+	"go-hex/pkg/slo"
+	"go-hex/shared/response"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// objectives returns the objective of the routes, whose latency threshold depends on their route group
+func objectives(cfg *configs.Config) func(route string) slo.Objective {
+	return func(route string) slo.Objective {
+		threshold := cfg.SLO.LatencyThreshold
+		switch {
+		case authRoutes.Match(route):
+			threshold = cfg.SLO.AuthLatencyThreshold
+		case adminRoutes.Match(route):
+			threshold = cfg.SLO.AdminLatencyThreshold
+		}
+		return slo.Objective{
+			Availability:     cfg.SLO.AvailabilityTarget,
+			Latency:          cfg.SLO.LatencyTarget,
+			LatencyThreshold: time.Duration(threshold) * time.Millisecond,
+		}
+	}
+}
+
+// sloReport godoc
+// @Router /slo [get]
+// @Tags Debug
+// @Summary Report the SLOs
+// @Description Report the availability and latency SLIs of the endpoints served by the instance, with the burn rates of their error budgets, over the last 5 minutes, hour and 6 hours
+// @Produce json
+// @Security BasicAuth
+// @Success 200 {object} response.Response{data=slo.Report} "Success"
+// @failure 401 {object} response.ErrorResponse401
+func (api API) sloReport(c echo.Context) error {
+	return response.SuccessOK(c, api.slos.Report(time.Now()))
+}
@@ -0,0 +1,19 @@
+package api
+
+import (
+	"go-hex/app"
+	"go-hex/shared/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+// version answers the build of the application
+// @Router /version [get]
+// @Tags Version
+// @Summary Get the version
+// @Description Answer the version, the commit and the build date of the application, also answered in the X-App-Version and X-App-Commit headers of every response
+// @Produce json
+// @Success 200 {object} response.Response{data=app.BuildInfo} "Success"
+func version(c echo.Context) error {
+	return response.SuccessOK(c, app.Build())
+}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"context"
+	"go-hex/internal/auth"
+	"go-hex/internal/repository/sqlrepo"
+	"go-hex/pkg/health"
+	"go-hex/pkg/logger"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/uptrace/bun"
+)
+
+// warmupRetryInterval is the delay between two attempts of a failed warmup
+const warmupRetryInterval = time.Second
+
+// warmupStep prepares a dependency before the first request is served
+type warmupStep struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// readiness reports whether the warmup completed and the api is not shutting down, it is shared by the copies
+// of the API
+type readiness struct {
+	ready    int32
+	stopping int32
+}
+
+func (r *readiness) set() {
+	atomic.StoreInt32(&r.ready, 1)
+}
+
+// stop fails the readiness probe for the rest of the shutdown, so that no traffic is routed to the instance
+func (r *readiness) stop() {
+	atomic.StoreInt32(&r.stopping, 1)
+}
+
+func (r *readiness) isReady() bool {
+	return atomic.LoadInt32(&r.ready) == 1
+}
+
+// readinessTimeout bounds the checks of the dependencies of the readiness probe, below the timeout of the probes
+const readinessTimeout = time.Second
+
+// Readiness is the answer of the readiness probe
+type Readiness struct {
+	Status string          `json:"status" example:"ready" enums:"ready,degraded,unavailable,warming up,shutting down"`
+	Checks []health.Result `json:"checks,omitempty"`
+}
+
+// handler answers service unavailable until the warmup completed and once the shutdown started, so that no traffic
+// is routed to an instance still opening its connections or draining its requests. In between, it answers the
+// status and the latency of the checks of the dependencies, service unavailable while a critical one is down and
+// degraded while another one is.
+func (r *readiness) handler(checks *health.Registry) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if atomic.LoadInt32(&r.stopping) == 1 {
+			return c.JSON(http.StatusServiceUnavailable, Readiness{Status: "shutting down"})
+		}
+		if !r.isReady() {
+			return c.JSON(http.StatusServiceUnavailable, Readiness{Status: "warming up"})
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request().Context(), readinessTimeout)
+		defer cancel()
+		report := checks.Check(ctx)
+		switch report.Status {
+		case health.StatusDown:
+			return c.JSON(http.StatusServiceUnavailable, Readiness{Status: "unavailable", Checks: report.Checks})
+		case health.StatusDegraded:
+			return c.JSON(http.StatusOK, Readiness{Status: "degraded", Checks: report.Checks})
+		}
+		return c.JSON(http.StatusOK, Readiness{Status: "ready", Checks: report.Checks})
+	}
+}
+
+// liveness answers as soon as the server listens, whatever the state of the dependencies, so that the instance is
+// not restarted while a dependency is down
+func liveness(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]string{"status": "alive"})
+}
+
+// warmupSteps lists the dependencies prepared before the readiness probe passes
+func (api API) warmupSteps() []warmupStep {
+	return []warmupStep{
+		{"database connections", api.warmupDatabase},
+		{"access token budget", api.checkAccessTokenBudget},
+	}
+}
+
+// warmup runs every step, retrying the failed ones until they succeed or ctx is done,
+// and marks the API as ready once all of them completed
+func (api API) warmup(ctx context.Context) {
+
+	start := time.Now()
+	for _, step := range api.warmupSteps() {
+		for {
+			stepStart := time.Now()
+			err := step.run(ctx)
+			if err == nil {
+				api.log.WithParams(logger.Params{"step": step.name, "duration": time.Since(stepStart).String()}).Info("warmup step completed")
+				break
+			}
+			api.log.WithParams(logger.Params{"step": step.name}).Error(errors.Wrap(err, "warmup step failed"))
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(warmupRetryInterval):
+			}
+		}
+	}
+
+	api.ready.set()
+	api.log.WithParams(logger.Params{"duration": time.Since(start).String()}).Info("warmup completed")
+}
+
+// warmupDatabase opens the idle connections of the pool up front,
+// so that the first requests do not pay for the TCP and authentication handshakes
+func (api API) warmupDatabase(ctx context.Context) error {
+
+	count := api.cfg.Database.WarmupConnections
+	if maxOpen := api.cfg.Database.MaxOpenConns; maxOpen > 0 && count > maxOpen {
+		// the connections beyond the bound of the pool would wait forever
+		count = maxOpen
+	}
+	if count <= 0 {
+		return api.db.PingContext(ctx)
+	}
+	if count > api.cfg.Database.MaxIdleConns {
+		api.db.SetMaxIdleConns(count)
+	}
+
+	conns := make([]bun.Conn, 0, count)
+	defer func() {
+		// closing returns the connections to the idle pool
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}()
+
+	for i := 0; i < count; i++ {
+		conn, err := api.db.Conn(ctx)
+		if err != nil {
+			return errors.Wrap(err, "cannot open connection")
+		}
+		conns = append(conns, conn)
+		if err := conn.PingContext(ctx); err != nil {
+			return errors.Wrap(err, "cannot ping connection")
+		}
+	}
+	return nil
+}
+
+// checkAccessTokenBudget checks that the access token of a user granted every role fits the size budget,
+// an instance issuing tokens over the cookie and header limits never becomes ready
+func (api API) checkAccessTokenBudget(ctx context.Context) error {
+	return auth.CheckAccessTokenBudget(ctx, api.cfg, sqlrepo.NewRepositoryRegistry(api.db).GetRoleRepository())
+}
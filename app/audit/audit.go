@@ -0,0 +1,70 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"go-hex/app"
+	"go-hex/configs"
+	"go-hex/internal/auditchain"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/sqlrepo"
+	"go-hex/pkg/db"
+	"go-hex/pkg/logger"
+	"io/ioutil"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/uptrace/bun"
+)
+
+type Audit struct {
+	cfg *configs.Config
+	log logger.Logger
+	db  *bun.DB
+}
+
+func New() *Audit {
+	cfg := configs.LoadDefault()
+	log := logger.New(cfg.Server.NAME, app.Version)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	db, err := db.NewBunConn(cfg.Server.ENV, cfg.Database.Host, cfg.Database.Port, cfg.Database.Username, cfg.Database.Password, cfg.Database.DBName, db.WithDriver(cfg.Database.Driver))
+	if err != nil {
+		panic(err)
+	}
+	return &Audit{
+		cfg,
+		log,
+		db,
+	}
+}
+
+// Verify verifies the audit chain against the anchors read from the given files and prints the outcome,
+// it exits with a non zero status when the chain is broken.
+func (a *Audit) Verify(anchorFiles []string) {
+
+	anchors := make([]domain.AuditChainAnchor, 0, len(anchorFiles))
+	for _, file := range anchorFiles {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			a.log.Fatal(err)
+		}
+		var anchor domain.AuditChainAnchor
+		if err := json.Unmarshal(b, &anchor); err != nil {
+			a.log.Fatalf("cannot read anchor %s: %v", file, err)
+		}
+		anchors = append(anchors, anchor)
+	}
+
+	service := auditchain.NewService(a.cfg, a.log, sqlrepo.NewRepositoryRegistry(a.db), nil)
+	res, err := service.Verify(context.Background(), anchors)
+	if err != nil {
+		a.log.Fatal(err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(res)
+	if !res.Valid {
+		os.Exit(1)
+	}
+}
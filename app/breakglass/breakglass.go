@@ -0,0 +1,140 @@
+package breakglass
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-hex/app"
+	"go-hex/configs"
+	"go-hex/internal/breakglass"
+	"go-hex/internal/repository/dynamo"
+	"go-hex/internal/repository/mongo"
+	"go-hex/internal/repository/port"
+	"go-hex/internal/repository/sqlrepo"
+	"go-hex/internal/siem"
+	"go-hex/pkg/db"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/uptrace/bun"
+	"golang.org/x/term"
+)
+
+type BreakGlass struct {
+	cfg *configs.Config
+	log logger.Logger
+	db  *bun.DB
+}
+
+func New() *BreakGlass {
+	cfg := configs.LoadDefault()
+	log := logger.New(cfg.Server.NAME, app.Version)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	db, err := db.NewBunConn(cfg.Server.ENV, cfg.Database.Host, cfg.Database.Port, cfg.Database.Username, cfg.Database.Password, cfg.Database.DBName, db.WithDriver(cfg.Database.Driver))
+	if err != nil {
+		panic(err)
+	}
+	return &BreakGlass{
+		cfg,
+		log,
+		db,
+	}
+}
+
+// Seal seals the account of the user and prints the share of each custodian, they are never shown again.
+func (b *BreakGlass) Seal(username string, custodians []string) {
+	b.run(func(ctx context.Context, service *breakglass.Service) (interface{}, error) {
+		return service.Seal(ctx, breakglass.RequestSeal{Username: username, Custodians: custodians})
+	})
+}
+
+// Activate asks both custodians for their share, each typing their name and share in turn without echo,
+// and prints the password of the account once they match.
+func (b *BreakGlass) Activate(username string, reason string, duration int) {
+	reader := bufio.NewReader(os.Stdin)
+	shares := make([]breakglass.Share, 0, 2)
+	for i := 1; i <= 2; i++ {
+		custodian := b.prompt(reader, fmt.Sprintf("custodian %d name: ", i), false)
+		share := b.prompt(reader, fmt.Sprintf("custodian %d share: ", i), true)
+		shares = append(shares, breakglass.Share{Custodian: custodian, Share: share})
+	}
+
+	b.run(func(ctx context.Context, service *breakglass.Service) (interface{}, error) {
+		return service.Activate(ctx, breakglass.RequestActivate{Username: username, Shares: shares, Reason: reason, Duration: duration})
+	})
+}
+
+// Revoke ends the activation of the account before it expires.
+func (b *BreakGlass) Revoke(username string, revokedBy string) {
+	b.run(func(ctx context.Context, service *breakglass.Service) (interface{}, error) {
+		return nil, service.Revoke(ctx, breakglass.RequestRevoke{Username: username, RevokedBy: revokedBy})
+	})
+}
+
+// run runs the step and prints its outcome, the security events are sent to the SIEM before exiting.
+func (b *BreakGlass) run(step func(ctx context.Context, service *breakglass.Service) (interface{}, error)) {
+
+	events := event.New()
+	events.Subscribe(event.All, func(ctx context.Context, e event.Event) {
+		b.log.WithParams(logger.Params{"type": "event", "event": e}).Info(e.Name)
+	})
+	exporter := siem.NewConfiguredExporter(b.cfg, b.log, app.Version)
+	exporter.Subscribe(events)
+
+	service := breakglass.NewService(b.cfg, b.registry(), b.log, events)
+	res, err := step(context.Background(), service)
+	exporter.Close()
+	if err != nil {
+		b.log.Fatal(err)
+	}
+
+	if res != nil {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(res)
+	}
+}
+
+// registry serves the users from DynamoDB or MongoDB when the api does
+func (b *BreakGlass) registry() port.RepositoryRegistry {
+	repoRegistry := sqlrepo.NewRepositoryRegistry(b.db)
+	if b.cfg.MongoDB.URI != "" {
+		client, err := mongo.NewClient(context.Background(), b.cfg.MongoDB.URI)
+		if err != nil {
+			b.log.Fatal(err)
+		}
+		return mongo.NewRepositoryRegistry(repoRegistry, mongo.Users(client, b.cfg.MongoDB.Database), time.Duration(b.cfg.MongoDB.Timeout)*time.Second)
+	}
+	if b.cfg.DynamoDB.Table == "" {
+		return repoRegistry
+	}
+	client, err := dynamo.NewClient(context.Background(), b.cfg.DynamoDB.Region, b.cfg.DynamoDB.Endpoint)
+	if err != nil {
+		b.log.Fatal(err)
+	}
+	return dynamo.NewRepositoryRegistry(repoRegistry, client, b.cfg.DynamoDB.Table)
+}
+
+// prompt reads a line from the terminal, without echo when secret, or from the piped input
+func (b *BreakGlass) prompt(reader *bufio.Reader, label string, secret bool) string {
+	fmt.Fprint(os.Stderr, label)
+	fd := int(os.Stdin.Fd())
+	if secret && term.IsTerminal(fd) {
+		line, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			b.log.Fatal(err)
+		}
+		return strings.TrimSpace(string(line))
+	}
+	line, err := reader.ReadString('\n')
+	if err != nil && line == "" {
+		b.log.Fatal(err)
+	}
+	return strings.TrimSpace(line)
+}
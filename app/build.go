@@ -0,0 +1,38 @@
+package app
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// BuildInfo describes the binary of the application
+type BuildInfo struct {
+	Version    string `json:"version" example:"v1.0.0"`
+	Commit     string `json:"commit" example:"4f89869c1e0c3d5b2a8f3e9d7b6a5c4d3e2f1a0b"`
+	BuildDate  string `json:"build_date" example:"2026-10-14T08:30:00Z"`
+	CommitDate string `json:"commit_date" example:"2026-10-14T08:00:00Z"`
+	GoVersion  string `json:"go_version" example:"go1.18"`
+	Modified   bool   `json:"modified"` // the sources differed from the commit
+}
+
+// Build returns the information set when building, completed by the one the Go toolchain embedded in the binary
+func Build() BuildInfo {
+	res := BuildInfo{Version: Version, Commit: Commit, BuildDate: BuildDate, GoVersion: runtime.Version()}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return res
+	}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if res.Commit == "" {
+				res.Commit = setting.Value
+			}
+		case "vcs.time":
+			res.CommitDate = setting.Value
+		case "vcs.modified":
+			res.Modified = setting.Value == "true"
+		}
+	}
+	return res
+}
@@ -0,0 +1,216 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-hex/configs"
+	"go-hex/pkg/times"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// diffTimeout bounds the fetching of the diagnostics of both instances
+const diffTimeout = 10 * time.Second
+
+// Instance is the configuration of a running instance, read from its diagnostics
+type Instance struct {
+	URL         string                 `json:"url"`
+	Instance    string                 `json:"instance"`
+	Version     string                 `json:"version"`
+	Fingerprint string                 `json:"fingerprint"`
+	Values      map[string]interface{} `json:"-"`
+}
+
+// ResponseDiff is the configuration drift between two instances
+type ResponseDiff struct {
+	Left        Instance             `json:"left"`
+	Right       Instance             `json:"right"`
+	Differences []configs.Difference `json:"differences"`
+}
+
+type Config struct{}
+
+func New() *Config {
+	return &Config{}
+}
+
+// Schema prints the JSON Schema of the configuration
+func (c *Config) Schema() {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(configs.Schema())
+}
+
+// Validate prints the problems of the environment file, it exits with a non zero status when there is one
+func (c *Config) Validate(file string) {
+
+	errs, err := configs.ValidateFile(file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if len(errs) == 0 {
+		fmt.Printf("%s is valid\n", file)
+		return
+	}
+
+	for _, err := range errs {
+		fmt.Println(err)
+	}
+	fmt.Printf("%s has %d problem(s)\n", file, len(errs))
+	os.Exit(1)
+}
+
+// RotateSigningKey rotates the JWT_SIGNING_KEYS of the environment file, the one of the configuration when empty,
+// retiring the active key for the JWT_KEY_GRACE_PERIOD, and prints the rotation
+func (c *Config) RotateSigningKey(file string) {
+
+	sources := configs.DefaultSources()
+	if file != "" {
+		sources.File = file
+	}
+	cfg, err := configs.Load(sources)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	for _, name := range []string{"JWT_SIGNING_KEYS", "JWT_ACTIVE_KEY_ID", "JWT_KEY_EXPIRATIONS"} {
+		_, env := sources.Env[name]
+		_, flag := sources.Flags[name]
+		if env || flag {
+			fmt.Fprintf(os.Stderr, "%s is set by the environment or a flag, overriding the rotation of %s\n", name, sources.File)
+			os.Exit(2)
+		}
+	}
+
+	rotation, err := configs.RotateSigningKeys(sources.File, times.Now(), time.Duration(cfg.JWT.KeyGracePeriod)*time.Minute)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("the tokens are signed with the key %s\n", rotation.ActiveKeyID)
+	if rotation.RetiredKeyID != "" {
+		fmt.Printf("the key %s verifies the tokens it signed until %s\n", rotation.RetiredKeyID, rotation.ExpiresAt.Format(time.RFC3339))
+	}
+	if len(rotation.Removed) > 0 {
+		fmt.Printf("the keys %s are removed, their grace period is over\n", strings.Join(rotation.Removed, ", "))
+	}
+	fmt.Printf("%s is rotated, the instances apply it on their next reload of the configuration\n", sources.File)
+}
+
+// Diff prints the variables configured differently by the instances, fetched from the diagnostics of their
+// base URLs with the credentials of the internal api. It exits with a non zero status when they differ.
+func (c *Config) Diff(left, right, user, password string, asJSON bool) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), diffTimeout)
+	defer cancel()
+
+	res := ResponseDiff{Left: Instance{URL: left}, Right: Instance{URL: right}}
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() error { return fetchInstance(gctx, &res.Left, user, password) })
+	g.Go(func() error { return fetchInstance(gctx, &res.Right, user, password) })
+	if err := g.Wait(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	res.Differences = configs.Diff(res.Left.Values, res.Right.Values)
+
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(res)
+	} else {
+		printDiff(res)
+	}
+	if len(res.Differences) > 0 {
+		os.Exit(1)
+	}
+}
+
+// fetchInstance reads the configuration of the instance from GET /debug/diagnostics
+func fetchInstance(ctx context.Context, instance *Instance, user, password string) error {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(instance.URL, "/")+"/debug/diagnostics", nil)
+	if err != nil {
+		return fmt.Errorf("invalid url %s: %v", instance.URL, err)
+	}
+	if req.URL.User == nil {
+		req.SetBasicAuth(user, password)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot fetch the diagnostics of %s: %v", instance.URL, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("cannot fetch the diagnostics of %s: status %d", instance.URL, res.StatusCode)
+	}
+
+	// both envelopes answer the diagnostics in data
+	var body struct {
+		Data struct {
+			Instance string `json:"instance"`
+			Build    struct {
+				Version string `json:"version"`
+			} `json:"build"`
+			Config struct {
+				Fingerprint string                 `json:"fingerprint"`
+				Values      map[string]interface{} `json:"values"`
+			} `json:"config"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return fmt.Errorf("cannot read the diagnostics of %s: %v", instance.URL, err)
+	}
+	if body.Data.Config.Values == nil {
+		return fmt.Errorf("cannot read the diagnostics of %s: no configuration", instance.URL)
+	}
+
+	// the password of the url is not printed
+	instance.URL = strings.TrimSuffix(instance.URL, "/")
+	if u, err := url.Parse(instance.URL); err == nil {
+		instance.URL = u.Redacted()
+	}
+	instance.Instance = body.Data.Instance
+	instance.Version = body.Data.Build.Version
+	instance.Fingerprint = body.Data.Config.Fingerprint
+	instance.Values = body.Data.Config.Values
+	return nil
+}
+
+func printDiff(res ResponseDiff) {
+	for _, instance := range []Instance{res.Left, res.Right} {
+		fmt.Printf("%s: instance %s, version %s, fingerprint %s\n", instance.URL, instance.Instance, instance.Version, instance.Fingerprint)
+	}
+	if len(res.Differences) == 0 {
+		fmt.Println("same configuration")
+		return
+	}
+
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VARIABLE\tSTATUS\tLEFT\tRIGHT")
+	for _, diff := range res.Differences {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", diff.Variable, diff.Status, formatValue(diff.Left), formatValue(diff.Right))
+	}
+	_ = w.Flush()
+	fmt.Printf("\n%d variable(s) differ\n", len(res.Differences))
+}
+
+// formatValue prints the value in JSON, - when missing
+func formatValue(value interface{}) string {
+	if value == nil {
+		return "-"
+	}
+	b, _ := json.Marshal(value)
+	return string(b)
+}
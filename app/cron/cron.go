@@ -1,12 +1,26 @@
 package cron
 
 import (
+	"context"
 	"fmt"
 	"go-hex/app"
 	"go-hex/configs"
+	"go-hex/internal/audit"
+	"go-hex/internal/auditarchive"
+	"go-hex/internal/auditchain"
+	"go-hex/internal/breakglass"
+	"go-hex/internal/cleanup"
+	"go-hex/internal/provisioning"
+	"go-hex/internal/rbac"
+	"go-hex/internal/repository/sqlrepo"
+	"go-hex/internal/serviceaccount"
+	"go-hex/internal/siem"
+	"go-hex/internal/usersync"
 	"go-hex/pkg/db"
+	"go-hex/pkg/event"
 	"go-hex/pkg/logger"
 	"go-hex/pkg/otel"
+	"go-hex/pkg/storage"
 	"os"
 	"os/signal"
 	"sync"
@@ -19,7 +33,13 @@ import (
 )
 
 const (
-	CRON_TYPE_CLEANUP = "cleanup"
+	CRON_TYPE_CLEANUP       = "cleanup"
+	CRON_TYPE_AUDIT_ANCHOR  = "audit-anchor"
+	CRON_TYPE_AUDIT_ARCHIVE = "audit-archive"
+	CRON_TYPE_ROLE_EXPIRY   = "role-expiry"
+	CRON_TYPE_BREAK_GLASS   = "break-glass"
+	CRON_TYPE_USER_SYNC     = "user-sync"
+	CRON_TYPE_PROVISIONING  = "provisioning"
 )
 
 type Cron struct {
@@ -32,7 +52,8 @@ func New() *Cron {
 	cfg := configs.LoadDefault()
 	log := logger.New(cfg.Server.NAME, app.Version)
 	logger.SetFormatter(&logrus.JSONFormatter{})
-	db, err := db.NewBunMySQLConn(cfg.Server.ENV, "", "", "", "", "")
+	logger.SetLevel(logrus.Level(cfg.Log.Level))
+	db, err := db.NewBunConn(cfg.Server.ENV, cfg.Database.Host, cfg.Database.Port, cfg.Database.Username, cfg.Database.Password, cfg.Database.DBName, db.WithDriver(cfg.Database.Driver))
 	if err != nil {
 		panic(err)
 	}
@@ -47,12 +68,14 @@ func New() *Cron {
 func (c *Cron) Start(cronType string) {
 
 	service := fmt.Sprintf("%s-cron-%s", c.cfg.Server.NAME, cronType)
-	err := otel.SetTraceProvider(c.cfg.OpenTelemetry.JaegerURL, service, app.Version, c.cfg.Server.ENV.String(), c.cfg.OpenTelemetry.Sampled)
+	build := app.Build()
+	err := otel.SetTraceProvider(c.cfg.OpenTelemetry.JaegerURL, service, build.Version, c.cfg.Server.ENV.String(), c.cfg.OpenTelemetry.Sampled,
+		otel.AttributeServiceCommit.String(build.Commit), otel.AttributeServiceBuildDate.String(build.BuildDate))
 	if err != nil {
 		c.log.Fatal(err)
 	}
 
-	// repoRegistry := postgres.NewRepositoryRegistry(c.db)
+	repoRegistry := sqlrepo.NewRepositoryRegistry(c.db)
 
 	// new scheduler
 	cron := gocron.NewScheduler(time.Local)
@@ -60,9 +83,114 @@ func (c *Cron) Start(cronType string) {
 
 	switch cronType {
 	case CRON_TYPE_CLEANUP:
-		// cleanUpSvc := cleanup.NewService(c.cfg, c.log, repoRegistry, httplog.NewHTTPLog(c.db))
-		// // register scheduler
-		// cleanup.RegisterScheduler(c.cfg, c.log, cleanUpSvc, cron, wg)
+		cleanUpSvc := cleanup.NewService(c.cfg, c.log, repoRegistry)
+		// register scheduler
+		cleanup.RegisterScheduler(c.cfg, c.log, cleanUpSvc, cron, wg)
+
+	case CRON_TYPE_AUDIT_ANCHOR:
+		if c.cfg.Audit.AnchorBucket == "" {
+			c.log.Fatalf("AUDIT_ANCHOR_BUCKET is required to anchor the audit chain")
+		}
+		store, err := storage.New()
+		if err != nil {
+			c.log.Fatal(err)
+		}
+		auditChainSvc := auditchain.NewService(c.cfg, c.log, repoRegistry, store)
+		// register scheduler
+		auditchain.RegisterScheduler(c.cfg, c.log, auditChainSvc, cron, wg)
+
+	case CRON_TYPE_AUDIT_ARCHIVE:
+		if c.cfg.Audit.ArchiveBucket == "" {
+			c.log.Fatalf("AUDIT_ARCHIVE_BUCKET is required to archive the audit events")
+		}
+		store, err := storage.New()
+		if err != nil {
+			c.log.Fatal(err)
+		}
+		auditArchiveSvc := auditarchive.NewService(c.cfg, c.log, repoRegistry, store)
+		// register scheduler
+		auditarchive.RegisterScheduler(c.cfg, c.log, auditArchiveSvc, cron, wg)
+
+	case CRON_TYPE_ROLE_EXPIRY:
+		audits := serviceaccount.NewAuditWriter(
+			repoRegistry,
+			c.log,
+			c.cfg.Audit.BufferSize,
+			c.cfg.Audit.BatchSize,
+			time.Duration(c.cfg.Audit.FlushInterval)*time.Millisecond,
+			c.cfg.Audit.Backpressure,
+		)
+		defer audits.Close()
+		events := event.New()
+		events.Subscribe(event.All, func(ctx context.Context, e event.Event) {
+			c.log.WithParams(logger.Params{"type": "event", "event": e}).Info(e.Name)
+		})
+		serviceAccountSvc := serviceaccount.NewService(c.cfg, repoRegistry, events, audits)
+		// the expiry of the roles of the users is recorded in the audit trail
+		trail := audit.NewWriter(
+			repoRegistry,
+			c.log,
+			c.cfg.Audit.BufferSize,
+			c.cfg.Audit.BatchSize,
+			time.Duration(c.cfg.Audit.FlushInterval)*time.Millisecond,
+			c.cfg.Audit.Backpressure,
+		)
+		defer trail.Close()
+		trail.Subscribe(events)
+		rbacSvc := rbac.NewService(c.cfg, repoRegistry, c.log, events)
+		// register scheduler
+		serviceaccount.RegisterScheduler(c.cfg, c.log, serviceAccountSvc, cron, wg)
+		rbac.RegisterScheduler(c.cfg, c.log, rbacSvc, cron, wg)
+
+	case CRON_TYPE_BREAK_GLASS:
+		events := event.New()
+		events.Subscribe(event.All, func(ctx context.Context, e event.Event) {
+			c.log.WithParams(logger.Params{"type": "event", "event": e}).Info(e.Name)
+		})
+		exporter := siem.NewConfiguredExporter(c.cfg, c.log, app.Version)
+		defer exporter.Close()
+		exporter.Subscribe(events)
+		breakGlassSvc := breakglass.NewService(c.cfg, repoRegistry, c.log, events)
+		// register scheduler
+		breakglass.RegisterScheduler(c.cfg, c.log, breakGlassSvc, cron, wg)
+
+	case CRON_TYPE_USER_SYNC:
+		sources, err := usersync.ConfiguredSources(c.cfg)
+		if err != nil {
+			c.log.Fatal(err)
+		}
+		if len(sources) == 0 {
+			c.log.Fatalf("USER_SYNC_SFTP_ADDRESS or USER_SYNC_HR_URL is required to sync the users")
+		}
+		events := event.New()
+		events.Subscribe(event.All, func(ctx context.Context, e event.Event) {
+			c.log.WithParams(logger.Params{"type": "event", "event": e}).Info(e.Name)
+		})
+		exporter := siem.NewConfiguredExporter(c.cfg, c.log, app.Version)
+		defer exporter.Close()
+		exporter.Subscribe(events)
+		userSyncSvc := usersync.NewService(c.cfg, repoRegistry, c.log, events, sources...)
+		// register scheduler
+		usersync.RegisterScheduler(c.cfg, c.log, userSyncSvc, cron, wg)
+
+	case CRON_TYPE_PROVISIONING:
+		connectors, err := provisioning.ConfiguredConnectors(c.cfg)
+		if err != nil {
+			c.log.Fatal(err)
+		}
+		if len(connectors) == 0 {
+			c.log.Fatalf("PROVISIONING_LDAP_ADDRESS, PROVISIONING_GOOGLE_CREDENTIALS or PROVISIONING_SLACK_TOKEN is required to provision the users")
+		}
+		events := event.New()
+		events.Subscribe(event.All, func(ctx context.Context, e event.Event) {
+			c.log.WithParams(logger.Params{"type": "event", "event": e}).Info(e.Name)
+		})
+		exporter := siem.NewConfiguredExporter(c.cfg, c.log, app.Version)
+		defer exporter.Close()
+		exporter.Subscribe(events)
+		provisioningSvc := provisioning.NewService(c.cfg, repoRegistry, c.log, events, connectors...)
+		// register scheduler
+		provisioning.RegisterScheduler(c.cfg, c.log, provisioningSvc, cron, wg)
 
 	default:
 		c.log.Fatalf("no cron type available")
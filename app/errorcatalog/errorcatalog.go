@@ -0,0 +1,20 @@
+package errorcatalog
+
+import (
+	"encoding/json"
+	"go-hex/shared/ierr"
+	"os"
+)
+
+type Catalog struct{}
+
+func New() *Catalog {
+	return &Catalog{}
+}
+
+// Print prints the catalog of the errors in JSON, as answered by GET /errors
+func (c *Catalog) Print() {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(ierr.Catalog())
+}
@@ -1,13 +1,17 @@
 package migration
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"go-hex/app"
 	"go-hex/configs"
+	"go-hex/internal/migrations"
 	"go-hex/pkg/db"
 	"go-hex/pkg/logger"
+	"os"
+	"text/tabwriter"
 
-	migrate "github.com/rubenv/sql-migrate"
 	"github.com/sirupsen/logrus"
 	"github.com/uptrace/bun"
 )
@@ -28,7 +32,7 @@ func New() *Migration {
 	cfg := configs.LoadDefault()
 	log := logger.New(cfg.Server.NAME, app.Version)
 	logger.SetFormatter(&logrus.JSONFormatter{})
-	db, err := db.NewBunMySQLConn(cfg.Server.ENV, cfg.Database.Host, cfg.Database.Port, cfg.Database.Username, cfg.Database.Password, cfg.Database.DBName)
+	db, err := db.NewBunConn(cfg.Server.ENV, cfg.Database.Host, cfg.Database.Port, cfg.Database.Username, cfg.Database.Password, cfg.Database.DBName, db.WithDriver(cfg.Database.Driver))
 	if err != nil {
 		panic(err)
 	}
@@ -39,7 +43,8 @@ func New() *Migration {
 	}
 }
 
-func (m *Migration) Start(migrationType string) {
+// Start applies the migrations embedded in the build, down rolls back the last steps migrations, every one when 0
+func (m *Migration) Start(migrationType string, steps int) {
 
 	m.log.Infof("start migration %s", migrationType)
 	if migrationType == MIGRATION_TYPE_FRESH {
@@ -48,33 +53,68 @@ func (m *Migration) Start(migrationType string) {
 			return
 		}
 		m.log.Info("drop database")
-		_, err := m.db.Exec(fmt.Sprintf("DROP DATABASE %s; CREATE DATABASE %s;", m.cfg.Database.DBName, m.cfg.Database.DBName))
+		drop := fmt.Sprintf("DROP DATABASE %s; CREATE DATABASE %s;", m.cfg.Database.DBName, m.cfg.Database.DBName)
+		if m.cfg.Database.Driver.IsCockroachDB() {
+			// CockroachDB drops the database of the connection, but not its public schema
+			drop = fmt.Sprintf("DROP DATABASE %s CASCADE; CREATE DATABASE %s;", m.cfg.Database.DBName, m.cfg.Database.DBName)
+		} else if m.cfg.Database.Driver.IsPostgres() {
+			// PostgreSQL cannot drop the database of the connection, its schema is dropped instead
+			drop = "DROP SCHEMA public CASCADE; CREATE SCHEMA public;"
+		}
+		_, err := m.db.Exec(drop)
 		if err != nil {
 			panic(err)
 		}
-		m.db, err = db.NewBunMySQLConn(m.cfg.Server.ENV, m.cfg.Database.Host, m.cfg.Database.Port, m.cfg.Database.Username, m.cfg.Database.Password, m.cfg.Database.DBName)
+		m.db, err = db.NewBunConn(m.cfg.Server.ENV, m.cfg.Database.Host, m.cfg.Database.Port, m.cfg.Database.Username, m.cfg.Database.Password, m.cfg.Database.DBName, db.WithDriver(m.cfg.Database.Driver))
 		if err != nil {
 			panic(err)
 		}
 	}
 
-	migrations := &migrate.FileMigrationSource{
-		Dir: "./scripts/migrations/mysql",
-	}
-
-	var direction migrate.MigrationDirection
+	runner := migrations.NewRunner(m.db)
+	var count int
+	var err error
 	switch migrationType {
-	case MIGRATION_TYPE_UP:
-		direction = migrate.Up
+	case MIGRATION_TYPE_UP, MIGRATION_TYPE_FRESH:
+		count, err = runner.Up()
 	case MIGRATION_TYPE_DOWN:
-		direction = migrate.Down
-	case MIGRATION_TYPE_FRESH:
-		direction = migrate.Up
+		count, err = runner.Down(steps)
 	}
-
-	count, err := migrate.Exec(m.db.DB, "mysql", migrations, direction)
 	if err != nil {
 		panic(err)
 	}
 	m.log.Infof("applied %d migrations", count)
 }
+
+// Status prints the applied and the pending migrations, it exits with a non zero status when some are pending
+func (m *Migration) Status(asJSON bool) {
+
+	status, err := migrations.NewRunner(m.db).Status(context.Background())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(status)
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "applied\t%d\n", status.Applied)
+		if status.LastAppliedAt != nil {
+			fmt.Fprintf(w, "last\t%s at %s\n", status.Last, status.LastAppliedAt.Format("2006-01-02 15:04:05"))
+		}
+		for _, id := range status.Pending {
+			fmt.Fprintf(w, "pending\t%s\n", id)
+		}
+		for _, id := range status.Unknown {
+			fmt.Fprintf(w, "unknown\t%s\n", id)
+		}
+		_ = w.Flush()
+	}
+
+	if len(status.Pending) > 0 {
+		os.Exit(1)
+	}
+}
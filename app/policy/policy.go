@@ -0,0 +1,93 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"go-hex/app"
+	"go-hex/configs"
+	"go-hex/internal/policy"
+	"go-hex/internal/repository/dynamo"
+	"go-hex/internal/repository/mongo"
+	"go-hex/internal/repository/port"
+	"go-hex/internal/repository/sqlrepo"
+	"go-hex/pkg/db"
+	"go-hex/pkg/logger"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/uptrace/bun"
+)
+
+type Policy struct {
+	cfg *configs.Config
+	log logger.Logger
+	db  *bun.DB
+}
+
+func New() *Policy {
+	cfg := configs.LoadDefault()
+	log := logger.New(cfg.Server.NAME, app.Version)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	db, err := db.NewBunConn(cfg.Server.ENV, cfg.Database.Host, cfg.Database.Port, cfg.Database.Username, cfg.Database.Password, cfg.Database.DBName, db.WithDriver(cfg.Database.Driver))
+	if err != nil {
+		panic(err)
+	}
+	return &Policy{
+		cfg,
+		log,
+		db,
+	}
+}
+
+// Simulate prints whether the principal is granted the action with the matched rules,
+// it exits with a non zero status when the action is denied.
+func (p *Policy) Simulate(principalType string, principalID string, action string, resource string, at string) {
+
+	req := policy.RequestSimulate{
+		PrincipalType: principalType,
+		PrincipalID:   principalID,
+		Action:        action,
+		Resource:      resource,
+	}
+	if at != "" {
+		t, err := time.Parse(time.RFC3339, at)
+		if err != nil {
+			p.log.Fatalf("cannot read --at: %v", err)
+		}
+		req.At = &t
+	}
+
+	service := policy.NewService(p.registry())
+	res, err := service.Simulate(context.Background(), req)
+	if err != nil {
+		p.log.Fatal(err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(res)
+	if !res.Allowed {
+		os.Exit(1)
+	}
+}
+
+// registry serves the users from DynamoDB or MongoDB when the api does
+func (p *Policy) registry() port.RepositoryRegistry {
+	repoRegistry := sqlrepo.NewRepositoryRegistry(p.db)
+	if p.cfg.MongoDB.URI != "" {
+		client, err := mongo.NewClient(context.Background(), p.cfg.MongoDB.URI)
+		if err != nil {
+			p.log.Fatal(err)
+		}
+		return mongo.NewRepositoryRegistry(repoRegistry, mongo.Users(client, p.cfg.MongoDB.Database), time.Duration(p.cfg.MongoDB.Timeout)*time.Second)
+	}
+	if p.cfg.DynamoDB.Table == "" {
+		return repoRegistry
+	}
+	client, err := dynamo.NewClient(context.Background(), p.cfg.DynamoDB.Region, p.cfg.DynamoDB.Endpoint)
+	if err != nil {
+		p.log.Fatal(err)
+	}
+	return dynamo.NewRepositoryRegistry(repoRegistry, client, p.cfg.DynamoDB.Table)
+}
@@ -0,0 +1,99 @@
+package probe
+
+import (
+	"context"
+	"fmt"
+	"go-hex/app"
+	"go-hex/configs"
+	"go-hex/internal/probe"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/metrics"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type Probe struct {
+	cfg *configs.Config
+	log logger.Logger
+}
+
+func New() *Probe {
+	cfg := configs.LoadDefault()
+	log := logger.New(cfg.Server.NAME+"-probe", app.Version)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetLevel(logrus.Level(cfg.Log.Level))
+	if cfg.Probe.Username == "" || cfg.Probe.Password == "" {
+		log.Fatal("the probe requires the canary user: PROBE_USERNAME and PROBE_PASSWORD")
+	}
+	return &Probe{cfg, log}
+}
+
+// Start runs a cycle every PROBE_INTERVAL until stopped by a signal, the metrics are served on PROBE_METRICS_PORT
+func (p *Probe) Start() {
+
+	server := http.Server{Addr: fmt.Sprintf(":%v", p.cfg.Probe.MetricsPort), Handler: metrics.Handler()}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			p.log.Fatalf("listen:%+s\n", err)
+		}
+	}()
+
+	prober, alerter := p.prober()
+	ticker := time.NewTicker(time.Duration(p.cfg.Probe.Interval) * time.Second)
+	defer ticker.Stop()
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
+	p.log.Infof("probe of %s is running every %ds, metrics at port: %v", p.cfg.Probe.TargetURL, p.cfg.Probe.Interval, p.cfg.Probe.MetricsPort)
+
+	for {
+		alerter.Observe(context.Background(), p.run(prober), time.Now())
+		select {
+		case <-ticker.C:
+		case <-signalChan:
+			p.log.Info("got signal to exit probe")
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			_ = server.Shutdown(ctx)
+			cancel()
+			return
+		}
+	}
+}
+
+// Once runs a single cycle and exits with 1 when it failed, e.g. after a deployment
+func (p *Probe) Once() {
+	prober, _ := p.prober()
+	if !p.run(prober).Passed() {
+		os.Exit(1)
+	}
+}
+
+func (p *Probe) prober() (*probe.Prober, *probe.Alerter) {
+	client := &http.Client{Timeout: time.Duration(p.cfg.Probe.Timeout) * time.Second}
+	prober := probe.NewProber(client, p.cfg.Probe.TargetURL, p.cfg.Probe.Username, p.cfg.Probe.Password, p.cfg.JSON.Naming.IsCamelCase())
+	alerter := probe.NewAlerter(client, p.cfg.Probe.AlertWebhookURL, p.cfg.Probe.TargetURL, p.cfg.Probe.AlertThreshold, p.log)
+	return prober, alerter
+}
+
+// run runs a cycle within PROBE_TIMEOUT and logs its result
+func (p *Probe) run(prober *probe.Prober) probe.Result {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(p.cfg.Probe.Timeout)*time.Second)
+	defer cancel()
+
+	res := prober.Run(ctx)
+	durations := logger.Params{}
+	for step, duration := range res.Durations {
+		durations[step] = duration.Milliseconds()
+	}
+	log := p.log.WithParam("durations_ms", durations)
+	if res.Passed() {
+		log.Info("synthetic cycle passed")
+	} else {
+		log.WithParam("step", res.Step).Warnf("synthetic cycle failed: %s", res.Err)
+	}
+	return res
+}
@@ -1,5 +1,9 @@
 package app
 
-const (
-	Version = "v1.0.0"
+// Version, Commit and BuildDate are set when building, with
+// -ldflags "-X go-hex/app.Version=... -X go-hex/app.Commit=... -X go-hex/app.BuildDate=..."
+var (
+	Version   = "v1.0.0"
+	Commit    = ""
+	BuildDate = ""
 )
@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"go-hex/app/audit"
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+var auditCmd = &cobra.Command{
+	Use: "audit",
+	Run: func(_ *cobra.Command, _ []string) {
+		log.Println("use -h to show available commands")
+	},
+}
+
+var auditVerifyCmd = &cobra.Command{
+	Use:   "verify [anchor files]",
+	Short: "Verify the hash chain of the audit events, optionally against anchors downloaded from the object storage",
+	Run: func(_ *cobra.Command, args []string) {
+		a := audit.New()
+		a.Verify(args)
+	},
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"go-hex/app/breakglass"
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+var breakGlassCmd = &cobra.Command{
+	Use: "break-glass",
+	Run: func(_ *cobra.Command, _ []string) {
+		log.Println("use -h to show available commands")
+	},
+}
+
+var breakGlassSealCmd = &cobra.Command{
+	Use:   "seal [username]",
+	Short: "Seal the break-glass account of the user, its random password is split between the two custodians",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		custodians, _ := cmd.Flags().GetStringSlice("custodian")
+		breakglass.New().Seal(args[0], custodians)
+	},
+}
+
+var breakGlassActivateCmd = &cobra.Command{
+	Use:   "activate [username]",
+	Short: "Activate the break-glass account with the shares of both custodians and print its password",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		reason, _ := cmd.Flags().GetString("reason")
+		duration, _ := cmd.Flags().GetInt("duration")
+		breakglass.New().Activate(args[0], reason, duration)
+	},
+}
+
+var breakGlassRevokeCmd = &cobra.Command{
+	Use:   "revoke [username]",
+	Short: "Revoke the activation of the break-glass account before it expires",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		revokedBy, _ := cmd.Flags().GetString("by")
+		breakglass.New().Revoke(args[0], revokedBy)
+	},
+}
+
+func init() {
+	breakGlassSealCmd.Flags().StringSlice("custodian", nil, "custodian receiving a share, given twice")
+	breakGlassActivateCmd.Flags().String("reason", "", "reason of the activation, e.g. the incident")
+	breakGlassActivateCmd.Flags().Int("duration", 60, "in minutes, the activation is revoked afterwards")
+	breakGlassRevokeCmd.Flags().String("by", "", "person revoking the activation")
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"go-hex/app/config"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use: "config",
+	Run: func(_ *cobra.Command, _ []string) {
+		log.Println("use -h to show available commands")
+	},
+}
+
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema of the configuration, by environment variable",
+	Run: func(_ *cobra.Command, _ []string) {
+		config.New().Schema()
+	},
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate [env file]",
+	Short: "Validate an environment file against the configuration, exits with 1 when it has a problem",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		file := ".env"
+		if len(args) > 0 {
+			file = args[0]
+		}
+		config.New().Validate(file)
+	},
+}
+
+var configRotateSigningKeyCmd = &cobra.Command{
+	Use:   "rotate-signing-key [env file]",
+	Short: "Rotate the JWT_SIGNING_KEYS of an environment file, the previous key verifying its tokens for the JWT_KEY_GRACE_PERIOD",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(_ *cobra.Command, args []string) {
+		file := ""
+		if len(args) > 0 {
+			file = args[0]
+		}
+		config.New().RotateSigningKey(file)
+	},
+}
+
+var configDiffCmd = &cobra.Command{
+	Use:   "diff [left base url] [right base url]",
+	Short: "Print the configuration drift between two running instances, read from their diagnostics, exits with 1 when they differ",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		user, _ := cmd.Flags().GetString("user")
+		password, _ := cmd.Flags().GetString("password")
+		asJSON, _ := cmd.Flags().GetBool("json")
+		config.New().Diff(args[0], args[1], user, password, asJSON)
+	},
+}
+
+func init() {
+	configDiffCmd.Flags().String("user", os.Getenv("API_INTERNAL_USER"), "user of the internal api, API_INTERNAL_USER by default, or set in the urls")
+	configDiffCmd.Flags().String("password", os.Getenv("API_INTERNAL_PASSWORD"), "password of the internal api, API_INTERNAL_PASSWORD by default, or set in the urls")
+	configDiffCmd.Flags().Bool("json", false, "print the diff in JSON")
+}
@@ -8,9 +8,15 @@ import (
 )
 
 const (
-	CRON_TYPE_TRANSACTION = "transaction"
-	CRON_TYPE_RECONCILE   = "reconcile"
-	CRON_TYPE_CLEANUP     = "cleanup"
+	CRON_TYPE_TRANSACTION   = "transaction"
+	CRON_TYPE_RECONCILE     = "reconcile"
+	CRON_TYPE_CLEANUP       = "cleanup"
+	CRON_TYPE_AUDIT_ANCHOR  = "audit-anchor"
+	CRON_TYPE_AUDIT_ARCHIVE = "audit-archive"
+	CRON_TYPE_ROLE_EXPIRY   = "role-expiry"
+	CRON_TYPE_BREAK_GLASS   = "break-glass"
+	CRON_TYPE_USER_SYNC     = "user-sync"
+	CRON_TYPE_PROVISIONING  = "provisioning"
 )
 
 var cronCmd = &cobra.Command{
@@ -41,6 +47,48 @@ var cronCleanUpCmd = &cobra.Command{
 	},
 }
 
+var cronAuditAnchorCmd = &cobra.Command{
+	Use: CRON_TYPE_AUDIT_ANCHOR,
+	Run: func(_ *cobra.Command, _ []string) {
+		startCron(CRON_TYPE_AUDIT_ANCHOR)
+	},
+}
+
+var cronAuditArchiveCmd = &cobra.Command{
+	Use: CRON_TYPE_AUDIT_ARCHIVE,
+	Run: func(_ *cobra.Command, _ []string) {
+		startCron(CRON_TYPE_AUDIT_ARCHIVE)
+	},
+}
+
+var cronRoleExpiryCmd = &cobra.Command{
+	Use: CRON_TYPE_ROLE_EXPIRY,
+	Run: func(_ *cobra.Command, _ []string) {
+		startCron(CRON_TYPE_ROLE_EXPIRY)
+	},
+}
+
+var cronBreakGlassCmd = &cobra.Command{
+	Use: CRON_TYPE_BREAK_GLASS,
+	Run: func(_ *cobra.Command, _ []string) {
+		startCron(CRON_TYPE_BREAK_GLASS)
+	},
+}
+
+var cronUserSyncCmd = &cobra.Command{
+	Use: CRON_TYPE_USER_SYNC,
+	Run: func(_ *cobra.Command, _ []string) {
+		startCron(CRON_TYPE_USER_SYNC)
+	},
+}
+
+var cronProvisioningCmd = &cobra.Command{
+	Use: CRON_TYPE_PROVISIONING,
+	Run: func(_ *cobra.Command, _ []string) {
+		startCron(CRON_TYPE_PROVISIONING)
+	},
+}
+
 func startCron(cronType string) {
 	c := cron.New()
 	c.Start(cronType)
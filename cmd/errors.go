@@ -0,0 +1,15 @@
+package cmd
+
+import (
+	"go-hex/app/errorcatalog"
+
+	"github.com/spf13/cobra"
+)
+
+var errorsCmd = &cobra.Command{
+	Use:   "errors",
+	Short: "Print the catalog of the errors in JSON, by code, with their category, HTTP status, gRPC code and description",
+	Run: func(_ *cobra.Command, _ []string) {
+		errorcatalog.New().Print()
+	},
+}
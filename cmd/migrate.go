@@ -15,27 +15,45 @@ var migrateCmd = &cobra.Command{
 }
 
 var migrateUpCmd = &cobra.Command{
-	Use: "up",
+	Use:   "up",
+	Short: "Apply the pending migrations embedded in the build",
 	Run: func(_ *cobra.Command, _ []string) {
-		startMigrate("up")
+		startMigrate("up", 0)
 	},
 }
 
 var migrateDownCmd = &cobra.Command{
-	Use: "down",
-	Run: func(_ *cobra.Command, _ []string) {
-		startMigrate("down")
+	Use:   "down",
+	Short: "Roll back the last migrations applied, every migration unless --steps is set",
+	Run: func(cmd *cobra.Command, _ []string) {
+		steps, _ := cmd.Flags().GetInt("steps")
+		startMigrate("down", steps)
 	},
 }
 
 var migrateFreshCmd = &cobra.Command{
-	Use: "fresh",
+	Use:   "fresh",
+	Short: "Drop the database and apply every migration, refused in production",
 	Run: func(_ *cobra.Command, _ []string) {
-		startMigrate("fresh")
+		startMigrate("fresh", 0)
+	},
+}
+
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Print the applied and the pending migrations, exits with 1 when some are pending",
+	Run: func(cmd *cobra.Command, _ []string) {
+		asJSON, _ := cmd.Flags().GetBool("json")
+		migration.New().Status(asJSON)
 	},
 }
 
-func startMigrate(migrationType string) {
+func init() {
+	migrateDownCmd.Flags().Int("steps", 0, "number of migrations to roll back, 0 rolls back every migration")
+	migrateStatusCmd.Flags().Bool("json", false, "print the status in JSON")
+}
+
+func startMigrate(migrationType string, steps int) {
 	m := migration.New()
-	m.Start(migrationType)
+	m.Start(migrationType, steps)
 }
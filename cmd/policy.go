@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"go-hex/app/policy"
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+var policyCmd = &cobra.Command{
+	Use: "policy",
+	Run: func(_ *cobra.Command, _ []string) {
+		log.Println("use -h to show available commands")
+	},
+}
+
+var policySimulateCmd = &cobra.Command{
+	Use:   "simulate [user|service_account] [principal id] [action]",
+	Short: "Print whether the principal is granted the action with the matched rules, exits with 1 when denied",
+	Args:  cobra.ExactArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		resource, _ := cmd.Flags().GetString("resource")
+		at, _ := cmd.Flags().GetString("at")
+		policy.New().Simulate(args[0], args[1], args[2], resource, at)
+	},
+}
+
+func init() {
+	policySimulateCmd.Flags().String("resource", "", "resource of the action, e.g. GET /internal/analytics/clients")
+	policySimulateCmd.Flags().String("at", "", "RFC 3339 time of the evaluation, now by default")
+}
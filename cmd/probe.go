@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"go-hex/app/probe"
+
+	"github.com/spf13/cobra"
+)
+
+var probeCmd = &cobra.Command{
+	Use:   "probe",
+	Short: "Run the login, refresh, authorized call and logout cycle of the canary user against the api periodically",
+	Run: func(cmd *cobra.Command, _ []string) {
+		once, _ := cmd.Flags().GetBool("once")
+		if once {
+			probe.New().Once()
+			return
+		}
+		probe.New().Start()
+	},
+}
+
+func init() {
+	probeCmd.Flags().Bool("once", false, "run a single cycle and exit with 1 when it failed")
+}
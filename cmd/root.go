@@ -1,7 +1,10 @@
 package cmd
 
 import (
+	"fmt"
+	"go-hex/configs"
 	"log"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -11,6 +14,25 @@ var rootCmd = &cobra.Command{
 	Run: func(_ *cobra.Command, _ []string) {
 		log.Println("use -h to show available commands")
 	},
+	PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+		file, _ := cmd.Flags().GetString("config")
+		set, _ := cmd.Flags().GetStringArray("set")
+		flags := make(map[string]string, len(set))
+		for _, pair := range set {
+			name, value, ok := strings.Cut(pair, "=")
+			if !ok || name == "" {
+				return fmt.Errorf("invalid --set %q: expected VARIABLE=value", pair)
+			}
+			flags[name] = value
+		}
+		configs.Override(file, flags)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.PersistentFlags().String("config", "", "environment file of the configuration, .env by default, overridden by the environment")
+	rootCmd.PersistentFlags().StringArray("set", nil, "set a variable of the configuration, e.g. --set LOG_LEVEL=debug, overriding the file and the environment")
 }
 
 func Run() {
@@ -22,14 +44,48 @@ func Run() {
 	migrateCmd.AddCommand(migrateUpCmd)
 	migrateCmd.AddCommand(migrateDownCmd)
 	migrateCmd.AddCommand(migrateFreshCmd)
+	migrateCmd.AddCommand(migrateStatusCmd)
 	rootCmd.AddCommand(migrateCmd)
 
 	//cron
 	cronCmd.AddCommand(cronTransactionCmd)
 	cronCmd.AddCommand(cronReconcileCmd)
 	cronCmd.AddCommand(cronCleanUpCmd)
+	cronCmd.AddCommand(cronAuditAnchorCmd)
+	cronCmd.AddCommand(cronAuditArchiveCmd)
+	cronCmd.AddCommand(cronRoleExpiryCmd)
+	cronCmd.AddCommand(cronBreakGlassCmd)
+	cronCmd.AddCommand(cronUserSyncCmd)
+	cronCmd.AddCommand(cronProvisioningCmd)
 	rootCmd.AddCommand(cronCmd)
 
+	// audit
+	auditCmd.AddCommand(auditVerifyCmd)
+	rootCmd.AddCommand(auditCmd)
+
+	// break-glass
+	breakGlassCmd.AddCommand(breakGlassSealCmd)
+	breakGlassCmd.AddCommand(breakGlassActivateCmd)
+	breakGlassCmd.AddCommand(breakGlassRevokeCmd)
+	rootCmd.AddCommand(breakGlassCmd)
+
+	// policy
+	policyCmd.AddCommand(policySimulateCmd)
+	rootCmd.AddCommand(policyCmd)
+
+	// probe
+	rootCmd.AddCommand(probeCmd)
+
+	// config
+	configCmd.AddCommand(configSchemaCmd)
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configDiffCmd)
+	configCmd.AddCommand(configRotateSigningKeyCmd)
+	rootCmd.AddCommand(configCmd)
+
+	// errors
+	rootCmd.AddCommand(errorsCmd)
+
 	if err := rootCmd.Execute(); err != nil {
 		panic(err)
 	}
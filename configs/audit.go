@@ -0,0 +1,46 @@
+package configs
+
+import "fmt"
+
+// Policies applied when the audit buffer is full
+const (
+	AuditBackpressureBlock = "block"
+	AuditBackpressureDrop  = "drop"
+)
+
+// AuditBackpressure is the policy applied when an audit event is written while the buffer is full:
+// block waits for room until the request is cancelled, drop discards the event and counts it as lost.
+// Unknown policies are rejected when the configuration is loaded.
+type AuditBackpressure string
+
+// Decode implements envconfig.Decoder
+func (p *AuditBackpressure) Decode(value string) error {
+	switch value {
+	case AuditBackpressureBlock, AuditBackpressureDrop:
+		*p = AuditBackpressure(value)
+		return nil
+	}
+	return fmt.Errorf("invalid audit backpressure policy %q: expected %s or %s", value, AuditBackpressureBlock, AuditBackpressureDrop)
+}
+
+// Holds placed on the exported audit archives
+const (
+	AuditArchiveHoldNone       = "none"
+	AuditArchiveHoldTemporary  = "temporary"
+	AuditArchiveHoldEventBased = "event_based"
+)
+
+// AuditArchiveHold is the object hold placed on the exported audit archives, on top of the retention policy
+// of the bucket: an object under hold cannot be deleted or replaced until the hold is removed from the bucket.
+// Unknown holds are rejected when the configuration is loaded.
+type AuditArchiveHold string
+
+// Decode implements envconfig.Decoder
+func (h *AuditArchiveHold) Decode(value string) error {
+	switch value {
+	case AuditArchiveHoldNone, AuditArchiveHoldTemporary, AuditArchiveHoldEventBased:
+		*h = AuditArchiveHold(value)
+		return nil
+	}
+	return fmt.Errorf("invalid audit archive hold %q: expected %s, %s or %s", value, AuditArchiveHoldNone, AuditArchiveHoldTemporary, AuditArchiveHoldEventBased)
+}
@@ -0,0 +1,31 @@
+package configs
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+)
+
+// BrandColor matches the colors of the branding, in hexadecimal
+var BrandColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// validateBranding checks the colors, the logo and the support address of the branding when they are set
+func (c *Config) validateBranding() error {
+	for name, color := range map[string]string{"BRANDING_PRIMARY_COLOR": c.Branding.PrimaryColor, "BRANDING_ACCENT_COLOR": c.Branding.AccentColor} {
+		if color != "" && !BrandColor.MatchString(color) {
+			return fmt.Errorf("invalid %s %q: expected a hexadecimal color, e.g. #1a73e8", name, color)
+		}
+	}
+	if c.Branding.LogoURL != "" {
+		if u, err := url.Parse(c.Branding.LogoURL); err != nil || u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("invalid BRANDING_LOGO_URL %q: expected an absolute https url", c.Branding.LogoURL)
+		}
+	}
+	if c.Branding.SupportEmail != "" {
+		if _, err := mail.ParseAddress(c.Branding.SupportEmail); err != nil {
+			return fmt.Errorf("invalid BRANDING_SUPPORT_EMAIL %q: %v", c.Branding.SupportEmail, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package configs
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// ClientMinVersions are the minimum versions of the clients by client type, e.g. ios:2.3.0,android:2.1.0.
+// The types are case insensitive and the versions semantic versions, with or without the v prefix.
+// Invalid versions are rejected when the configuration is loaded.
+type ClientMinVersions map[string]string
+
+// Decode implements envconfig.Decoder
+func (c *ClientMinVersions) Decode(value string) error {
+	versions := ClientMinVersions{}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		clientType, version, ok := strings.Cut(item, ":")
+		clientType, version = strings.ToLower(strings.TrimSpace(clientType)), strings.TrimSpace(version)
+		if !ok || clientType == "" || !semver.IsValid("v"+strings.TrimPrefix(version, "v")) {
+			return fmt.Errorf("invalid client minimum version %q: expected type:semantic version, e.g. ios:2.3.0", item)
+		}
+		versions[clientType] = version
+	}
+	*c = versions
+	return nil
+}
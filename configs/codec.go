@@ -0,0 +1,51 @@
+package configs
+
+import "fmt"
+
+// Naming strategies of the JSON fields
+const (
+	JSONNamingSnakeCase = "snake_case"
+	JSONNamingCamelCase = "camelCase"
+)
+
+// JSONNaming is the naming strategy of the fields of the JSON requests and responses.
+// The DTOs are tagged in snake_case, camelCase renames their fields when encoding and decoding.
+// Unknown strategies are rejected when the configuration is loaded.
+type JSONNaming string
+
+// Decode implements envconfig.Decoder
+func (n *JSONNaming) Decode(value string) error {
+	switch value {
+	case JSONNamingSnakeCase, JSONNamingCamelCase:
+		*n = JSONNaming(value)
+		return nil
+	}
+	return fmt.Errorf("invalid json naming %q: expected %s or %s", value, JSONNamingSnakeCase, JSONNamingCamelCase)
+}
+
+// IsCamelCase checks whether the fields are renamed to camelCase
+func (n JSONNaming) IsCamelCase() bool {
+	return n == JSONNamingCamelCase
+}
+
+// Envelopes of the JSON responses
+const (
+	JSONEnvelopeLegacy = "legacy"
+	JSONEnvelopeData   = "data"
+)
+
+// JSONEnvelope is the envelope wrapping the JSON responses:
+// legacy answers {"success":..., "message":..., "data":...} and {"success":false, "message":..., "error_code":...},
+// data answers {"data":..., "meta":{"message":...}} and {"error":{"code":..., "message":...}}.
+// Unknown envelopes are rejected when the configuration is loaded.
+type JSONEnvelope string
+
+// Decode implements envconfig.Decoder
+func (e *JSONEnvelope) Decode(value string) error {
+	switch value {
+	case JSONEnvelopeLegacy, JSONEnvelopeData:
+		*e = JSONEnvelope(value)
+		return nil
+	}
+	return fmt.Errorf("invalid json envelope %q: expected %s or %s", value, JSONEnvelopeLegacy, JSONEnvelopeData)
+}
@@ -1,12 +1,16 @@
 package configs
 
 import (
+	"fmt"
+	"go-hex/pkg/auth"
+	"go-hex/pkg/ratelimit"
+	"go-hex/pkg/redirect"
 	"log"
 	"path"
 	"runtime"
+	"time"
 
 	"github.com/joho/godotenv"
-	"github.com/kelseyhightower/envconfig"
 )
 
 // Config represents configuration variables
@@ -17,6 +21,121 @@ type Config struct {
 		NAME    string `envconfig:"APP_NAME" required:"true"`
 		PORT    string `envconfig:"APP_PORT" required:"true"`
 		DEBUG   bool   `envconfig:"APP_DEBUG" default:"false"`
+		// RequestTimeout in seconds, the statements of a request are cancelled once reached
+		RequestTimeout int `envconfig:"APP_REQUEST_TIMEOUT" default:"30"`
+		// TrustedProxies are the CIDR ranges of the proxies whose X-Forwarded-For is trusted to tell the IP address
+		// of the clients, the address of the connection is used when empty
+		TrustedProxies []string `envconfig:"APP_TRUSTED_PROXIES"`
+	}
+
+	// Shutdown bounds the graceful shutdown on SIGTERM: the requests in flight are drained for DrainTimeout seconds,
+	// then the workers, the exporters and the connections are each given StopTimeout seconds to stop
+	Shutdown struct {
+		DrainTimeout int `envconfig:"SHUTDOWN_DRAIN_TIMEOUT" default:"20"`
+		StopTimeout  int `envconfig:"SHUTDOWN_STOP_TIMEOUT" default:"5"`
+	}
+
+	// Log sets the steady state log level and the share of the requests logged at the debug level,
+	// the log verbosities raising the level of some requests are reloaded every VerbositySyncInterval.
+	Log struct {
+		Level                 LogLevel `envconfig:"LOG_LEVEL" default:"info"`
+		DebugSampleRate       float64  `envconfig:"LOG_DEBUG_SAMPLE_RATE" default:"0"`
+		VerbositySyncInterval int      `envconfig:"LOG_VERBOSITY_SYNC_INTERVAL" default:"10"` // in seconds, 0 disables the sync
+	}
+
+	// Reload reloads the configuration every Interval seconds, and on SIGHUP, applying the log level, the rate limits,
+	// the expiration of the tokens and the signing key without restart, 0 only reloads it on SIGHUP
+	Reload struct {
+		Interval int `envconfig:"CONFIG_RELOAD_INTERVAL" default:"30"`
+	}
+
+	// JSON selects the conventions of the JSON requests and responses, see JSONNaming and JSONEnvelope.
+	// Hypermedia also answers JSON:API and HAL to the requests accepting them.
+	JSON struct {
+		Naming     JSONNaming   `envconfig:"JSON_NAMING" default:"snake_case"`
+		Envelope   JSONEnvelope `envconfig:"JSON_ENVELOPE" default:"legacy"`
+		Hypermedia bool         `envconfig:"JSON_HYPERMEDIA" default:"false"`
+	}
+
+	// Encoding enables the binary encodings negotiated through the Accept and Content-Type headers
+	Encoding struct {
+		MsgPack  bool `envconfig:"ENCODING_MSGPACK" default:"false"`
+		Protobuf bool `envconfig:"ENCODING_PROTOBUF" default:"false"`
+	}
+
+	// Client rejects the requests of the clients older than the minimum version of their type, sent in the
+	// X-Client-Type and X-Client-Version headers, the clients of the other types are not checked
+	Client struct {
+		MinVersions ClientMinVersions `envconfig:"CLIENT_MIN_VERSIONS"`
+	}
+
+	// Bulkhead bounds the requests handled concurrently per route group, 0 leaves a group unbounded
+	Bulkhead struct {
+		AuthLimit    int `envconfig:"BULKHEAD_AUTH_LIMIT" default:"200"`
+		AdminLimit   int `envconfig:"BULKHEAD_ADMIN_LIMIT" default:"20"`
+		QueueTimeout int `envconfig:"BULKHEAD_QUEUE_TIMEOUT" default:"100"` // in milliseconds
+	}
+
+	// GRPC serves the auth and user services over gRPC on Port, next to the HTTP api, empty disables it
+	GRPC struct {
+		Port string `envconfig:"GRPC_PORT" default:""`
+	}
+
+	// Probe runs the synthetic login, refresh, authorized call and logout cycle of the probe command against
+	// TargetURL with the canary user, every Interval. AlertWebhookURL is notified once AlertThreshold cycles failed
+	// in a row, and once the cycles pass again.
+	Probe struct {
+		TargetURL       string `envconfig:"PROBE_TARGET_URL" default:"http://localhost:3000"`
+		Username        string `envconfig:"PROBE_USERNAME"`
+		Password        string `envconfig:"PROBE_PASSWORD"`
+		Interval        int    `envconfig:"PROBE_INTERVAL" default:"60"` // in seconds
+		Timeout         int    `envconfig:"PROBE_TIMEOUT" default:"10"`  // in seconds, of a whole cycle
+		MetricsPort     string `envconfig:"PROBE_METRICS_PORT" default:"9102"`
+		AlertWebhookURL string `envconfig:"PROBE_ALERT_WEBHOOK_URL"` // empty disables the alerts
+		AlertThreshold  int    `envconfig:"PROBE_ALERT_THRESHOLD" default:"3"`
+	}
+
+	// SLO sets the objectives of the endpoints, whose SLIs and burn rates are exposed by /metrics and /slo.
+	// The latency threshold of the auth and admin route groups overrides the one of the other routes.
+	SLO struct {
+		AvailabilityTarget    float64 `envconfig:"SLO_AVAILABILITY_TARGET" default:"0.999"`
+		LatencyTarget         float64 `envconfig:"SLO_LATENCY_TARGET" default:"0.99"`
+		LatencyThreshold      int     `envconfig:"SLO_LATENCY_THRESHOLD" default:"500"`        // in milliseconds
+		AuthLatencyThreshold  int     `envconfig:"SLO_AUTH_LATENCY_THRESHOLD" default:"300"`   // in milliseconds
+		AdminLatencyThreshold int     `envconfig:"SLO_ADMIN_LATENCY_THRESHOLD" default:"2000"` // in milliseconds
+	}
+
+	// DeployAnalysis records the outcomes of the requests per version of the api, so that the rollouts compare the
+	// error rate and the latency of a canary with the ones of its baseline. The stats are kept for Retention in Redis,
+	// or in the memory of the instance without REDIS_ADDRESS. A canary is rolled back above MaxErrorRate, or above the
+	// error rate or the p95 latency of its baseline by more than MaxErrorRateDelta or MaxLatencyDelta.
+	DeployAnalysis struct {
+		FlushInterval     int     `envconfig:"DEPLOY_ANALYSIS_FLUSH_INTERVAL" default:"10"` // in seconds, 0 disables the recording
+		Retention         int     `envconfig:"DEPLOY_ANALYSIS_RETENTION" default:"24"`      // in hours
+		MinRequests       int     `envconfig:"DEPLOY_ANALYSIS_MIN_REQUESTS" default:"100"`
+		MaxErrorRate      float64 `envconfig:"DEPLOY_ANALYSIS_MAX_ERROR_RATE" default:"0.01"`
+		MaxErrorRateDelta float64 `envconfig:"DEPLOY_ANALYSIS_MAX_ERROR_RATE_DELTA" default:"0.005"`
+		MaxLatencyDelta   int     `envconfig:"DEPLOY_ANALYSIS_MAX_LATENCY_DELTA" default:"100"` // in milliseconds
+	}
+
+	// AdaptiveLimit sheds the requests of a route group above a concurrency limit adjusted to its latency
+	AdaptiveLimit struct {
+		Enabled       bool    `envconfig:"ADAPTIVE_LIMIT_ENABLED" default:"false"`
+		InitialLimit  int     `envconfig:"ADAPTIVE_LIMIT_INITIAL" default:"100"`
+		MinLimit      int     `envconfig:"ADAPTIVE_LIMIT_MIN" default:"10"`
+		MaxLimit      int     `envconfig:"ADAPTIVE_LIMIT_MAX" default:"1000"`
+		LatencyTarget int     `envconfig:"ADAPTIVE_LIMIT_LATENCY_TARGET" default:"500"` // in milliseconds
+		Backoff       float64 `envconfig:"ADAPTIVE_LIMIT_BACKOFF" default:"0.9"`
+	}
+
+	// RateLimit limits the login attempts per IP address and per username, and the requests of the identities of the
+	// access tokens over every route and per route, in requests per minute, 0 disables a limit. The token buckets are
+	// shared by the instances through Redis when REDIS_ADDRESS is set.
+	RateLimit struct {
+		LoginPerIP       int                `envconfig:"RATE_LIMIT_LOGIN_PER_IP" default:"30"`
+		LoginPerUsername int                `envconfig:"RATE_LIMIT_LOGIN_PER_USERNAME" default:"10"`
+		PerIdentity      int                `envconfig:"RATE_LIMIT_PER_IDENTITY" default:"600"`
+		Routes           ratelimit.Policies `envconfig:"RATE_LIMIT_ROUTES"` // per identity, e.g. POST /users=30,GET /users=120
 	}
 
 	InternalAPI struct {
@@ -25,27 +144,434 @@ type Config struct {
 	}
 
 	JWT struct {
-		SigningKey      string `envconfig:"JWT_SIGNING_KEY" required:"true"`
-		SigningKeyCRM   string `envconfig:"JWT_SIGNING_KEY_CRM" required:"true"`
-		TokenExpiration int    `envconfig:"JWT_TOKEN_EXPIRATION" required:"true"`
+		SigningKey             string `envconfig:"JWT_SIGNING_KEY" required:"true"`
+		SigningKeyCRM          string `envconfig:"JWT_SIGNING_KEY_CRM" required:"true"`
+		TokenExpiration        int    `envconfig:"JWT_TOKEN_EXPIRATION" required:"true"`
+		RefreshTokenExpiration int    `envconfig:"JWT_REFRESH_TOKEN_EXPIRATION" default:"43200"` // in minutes, 0 keeps the refresh tokens valid until rotated
+		// RefreshFingerprintKey keys the HMAC-SHA256 fingerprints of the refresh tokens stored on the sessions and the
+		// users before the refresh tokens were referenced by their jti, derived from SigningKey when empty
+		RefreshFingerprintKey string `envconfig:"JWT_REFRESH_FINGERPRINT_KEY" secret:"true"`
+		// SubjectOnly leaves the username out of the access tokens of the users, the services read it from /userinfo
+		SubjectOnly bool `envconfig:"JWT_SUBJECT_ONLY" default:"false"`
+
+		// Algorithm signs the tokens with SigningKey (HS256) or with PrivateKey (RS256, ES256), whose public key
+		// verifies them. The tokens signed with SigningKey keep being verified after switching to a private key.
+		Algorithm  JWTAlgorithm  `envconfig:"JWT_ALGORITHM" default:"HS256"`
+		PrivateKey JWTPrivateKey `envconfig:"JWT_PRIVATE_KEY" secret:"true"` // PEM block or path of a PEM file
+		KeyID      string        `envconfig:"JWT_KEY_ID"`                    // kid header of the tokens, defaults to a digest of the public key
+		// public keys of the previous private keys, PEM blocks or paths of PEM files, still verifying the tokens they signed
+		PreviousPublicKeys []JWTPublicKey `envconfig:"JWT_PREVIOUS_PUBLIC_KEYS"`
+
+		// SigningKeys replace SigningKey for HS256 once set: the tokens are signed with the key of ActiveKeyID and carry
+		// its ID in their kid header, the other keys verify the tokens they signed until their expiration, the end of
+		// their grace period. SigningKey keeps verifying the tokens without kid. config rotate-signing-key retires the
+		// active key with an expiration KeyGracePeriod minutes later.
+		SigningKeys    JWTSigningKeys    `envconfig:"JWT_SIGNING_KEYS" secret:"true"`
+		ActiveKeyID    string            `envconfig:"JWT_ACTIVE_KEY_ID"`
+		KeyExpirations JWTKeyExpirations `envconfig:"JWT_KEY_EXPIRATIONS"`
+		KeyGracePeriod int               `envconfig:"JWT_KEY_GRACE_PERIOD" default:"43200"`
+
+		// CompactPermissions drops the permissions granted by a wildcard from the access tokens and groups the
+		// actions of each resource, RoleCatalog encodes the roles it lists as a bitmap of their positions. The roles
+		// are appended to the catalog, never removed or reordered, which is shared by the services verifying the tokens.
+		CompactPermissions bool     `envconfig:"JWT_COMPACT_PERMISSIONS" default:"false"`
+		RoleCatalog        []string `envconfig:"JWT_ROLE_CATALOG"`
+		// MaxTokenBytes is the size budget of the signed access tokens, for the cookie and header limits, 0 disables it
+		MaxTokenBytes int `envconfig:"JWT_MAX_TOKEN_BYTES" default:"4096"`
+	}
+
+	// AccessToken hands the clients opaque access tokens instead of the signed ones with the opaque format, the services
+	// introspect them; the opaque tokens are stored in Redis, or in the memory of the instance without REDIS_ADDRESS
+	AccessToken struct {
+		Format AccessTokenFormat `envconfig:"ACCESS_TOKEN_FORMAT" default:"jwt"`
+	}
+
+	// PasswordHash hashes the new passwords, a password hashed otherwise is rehashed when its user logs in with it
+	PasswordHash struct {
+		Algorithm        PasswordHashAlgorithm `envconfig:"PASSWORD_HASH_ALGORITHM" default:"argon2id"`
+		BcryptCost       int                   `envconfig:"PASSWORD_BCRYPT_COST" default:"10"`
+		Argon2Memory     uint32                `envconfig:"PASSWORD_ARGON2_MEMORY" default:"19456"` // in KiB
+		Argon2Time       uint32                `envconfig:"PASSWORD_ARGON2_TIME" default:"2"`
+		Argon2Threads    uint8                 `envconfig:"PASSWORD_ARGON2_THREADS" default:"1"`
+		Argon2SaltLength uint32                `envconfig:"PASSWORD_ARGON2_SALT_LENGTH" default:"16"` // in bytes
+		Argon2KeyLength  uint32                `envconfig:"PASSWORD_ARGON2_KEY_LENGTH" default:"32"`  // in bytes
+	}
+
+	// PasswordPolicy validates the passwords chosen at the registration, the signup and the reset
+	PasswordPolicy struct {
+		MinLength        int  `envconfig:"PASSWORD_POLICY_MIN_LENGTH" default:"8"`
+		MinClasses       int  `envconfig:"PASSWORD_POLICY_MIN_CLASSES" default:"1"` // among lowercase, uppercase, digits and symbols
+		BanCommon        bool `envconfig:"PASSWORD_POLICY_BAN_COMMON" default:"true"`
+		DisallowUsername bool `envconfig:"PASSWORD_POLICY_DISALLOW_USERNAME" default:"true"`
+	}
+
+	// PasswordPool bounds the password hashes and compares running concurrently, 0 workers uses one per CPU
+	PasswordPool struct {
+		Workers      int `envconfig:"PASSWORD_POOL_WORKERS" default:"0"`
+		QueueTimeout int `envconfig:"PASSWORD_POOL_QUEUE_TIMEOUT" default:"1000"` // in milliseconds
+	}
+
+	Session struct {
+		MaxConcurrent int                `envconfig:"SESSION_MAX_CONCURRENT" default:"0"`
+		LimitPolicy   SessionLimitPolicy `envconfig:"SESSION_LIMIT_POLICY" default:"evict_oldest"`
+	}
+
+	LoginApproval struct {
+		Enabled bool `envconfig:"LOGIN_APPROVAL_ENABLED" default:"false"`
+		Timeout int  `envconfig:"LOGIN_APPROVAL_TIMEOUT" default:"120"`
+	}
+
+	DeviceLogin struct {
+		VerificationURI string `envconfig:"DEVICE_LOGIN_VERIFICATION_URI" required:"true"`
+		Timeout         int    `envconfig:"DEVICE_LOGIN_TIMEOUT" default:"600"`
+		PollInterval    int    `envconfig:"DEVICE_LOGIN_POLL_INTERVAL" default:"5"`
+		StartRateLimit  int    `envconfig:"DEVICE_LOGIN_START_RATE_LIMIT" default:"10"` // per minute and IP address
+	}
+
+	// Elevation grants the sensitive roles to the users for a bounded time once their request is approved
+	// by one of the approvers, no role can be requested when Roles is empty
+	Elevation struct {
+		Roles          []string `envconfig:"ELEVATION_ROLES"`
+		ApproverIDs    []string `envconfig:"ELEVATION_APPROVER_IDS"`                   // user ids
+		MaxDuration    int      `envconfig:"ELEVATION_MAX_DURATION" default:"60"`      // in minutes
+		RequestTimeout int      `envconfig:"ELEVATION_REQUEST_TIMEOUT" default:"3600"` // in seconds, pending requests expire afterwards
+	}
+
+	// PasswordReset sends the users who forgot their password a one-time token, by email or sms, to set a new one
+	PasswordReset struct {
+		TokenExpiration int    `envconfig:"PASSWORD_RESET_TOKEN_EXPIRATION" default:"15"` // in minutes
+		URL             string `envconfig:"PASSWORD_RESET_URL"`                           // page of the client setting the password, the token is appended as its token query parameter
+		RateLimit       int    `envconfig:"PASSWORD_RESET_RATE_LIMIT" default:"5"`        // per minute and IP address
+	}
+
+	// Registration creates the users from the internal api, inactive until they confirm their email address with an emailed one-time token
+	Registration struct {
+		TokenExpiration int    `envconfig:"REGISTRATION_TOKEN_EXPIRATION" default:"1440"` // in minutes
+		VerifyURL       string `envconfig:"REGISTRATION_VERIFY_URL"`                      // page of the client confirming the address, the token is appended as its token query parameter
+	}
+
+	// Redirect allows the redirect_uri of the social logins, the post-logout redirect of the hosted pages and the
+	// return_to of the emailed links per client id, e.g. web=https://app.example.com/*|https://*.example.com/callback.
+	// A client sending no url is redirected to the configured default, a client not listed is allowed none.
+	Redirect struct {
+		Allowlist redirect.Allowlist `envconfig:"REDIRECT_ALLOWLIST"`
+	}
+
+	// Enumeration hardens the login, the signup and the password reset so that their responses do not tell whether an account exists:
+	// in strict mode they answer the same message for the existing and unknown accounts within the same duration
+	Enumeration struct {
+		Strict      bool `envconfig:"ENUMERATION_STRICT" default:"false"`
+		MinDuration int  `envconfig:"ENUMERATION_MIN_DURATION" default:"400"` // in milliseconds, the responses are delayed up to it in strict mode
+	}
+
+	// Signup opens the public registration of the users, behind the checks of its gate
+	Signup struct {
+		Enabled                bool     `envconfig:"SIGNUP_ENABLED" default:"false"`
+		DisposableEmailCheck   bool     `envconfig:"SIGNUP_DISPOSABLE_EMAIL_CHECK" default:"true"`
+		DisposableEmailDomains []string `envconfig:"SIGNUP_DISPOSABLE_EMAIL_DOMAINS"`       // rejected besides the embedded list
+		VelocityLimit          int      `envconfig:"SIGNUP_VELOCITY_LIMIT" default:"5"`     // signups per IP address within the window, 0 disables the check
+		VelocityWindow         int      `envconfig:"SIGNUP_VELOCITY_WINDOW" default:"3600"` // in seconds
+		MXCheck                bool     `envconfig:"SIGNUP_MX_CHECK" default:"false"`
+		MXTimeout              int      `envconfig:"SIGNUP_MX_TIMEOUT" default:"2"` // in seconds
+	}
+
+	// BreakGlass bounds the activations of the break-glass accounts
+	BreakGlass struct {
+		MaxDuration int `envconfig:"BREAK_GLASS_MAX_DURATION" default:"240"` // in minutes
+	}
+
+	Cleanup struct {
+		Retention int `envconfig:"CLEANUP_RETENTION" default:"24"` // in hours, expired records are kept for auditing
+	}
+
+	ServiceAccount struct {
+		TokenAudience   string `envconfig:"SERVICE_ACCOUNT_TOKEN_AUDIENCE"`
+		TokenExpiration int    `envconfig:"SERVICE_ACCOUNT_TOKEN_EXPIRATION" default:"15"`
+		AssertionMaxAge int    `envconfig:"SERVICE_ACCOUNT_ASSERTION_MAX_AGE" default:"300"`
+	}
+
+	// Audit buffers the audit events and writes them in batches, out of the requests
+	Audit struct {
+		BufferSize    int               `envconfig:"AUDIT_BUFFER_SIZE" default:"10000"`
+		BatchSize     int               `envconfig:"AUDIT_BATCH_SIZE" default:"100"`
+		FlushInterval int               `envconfig:"AUDIT_FLUSH_INTERVAL" default:"1000"` // in milliseconds
+		Backpressure  AuditBackpressure `envconfig:"AUDIT_BACKPRESSURE" default:"block"`
+		AnchorBucket  string            `envconfig:"AUDIT_ANCHOR_BUCKET"`               // object storage bucket of the audit chain anchors
+		LogRetention  int               `envconfig:"AUDIT_LOG_RETENTION" default:"365"` // in days, the audit log events are kept in the database
+
+		ArchiveBucket    string           `envconfig:"AUDIT_ARCHIVE_BUCKET"`                 // object storage bucket of the audit archives
+		ArchiveRetention int              `envconfig:"AUDIT_ARCHIVE_RETENTION" default:"90"` // in days, the archived events are kept in the database
+		ArchiveHold      AuditArchiveHold `envconfig:"AUDIT_ARCHIVE_HOLD" default:"none"`
+	}
+
+	Analytics struct {
+		TokenUsageSampleRate    float64 `envconfig:"ANALYTICS_TOKEN_USAGE_SAMPLE_RATE" default:"0.1"`
+		TokenUsageFlushInterval int     `envconfig:"ANALYTICS_TOKEN_USAGE_FLUSH_INTERVAL" default:"30"`
+	}
+
+	Deprecation struct {
+		DigestInterval int              `envconfig:"DEPRECATION_DIGEST_INTERVAL" default:"24"` // in hours, 0 disables the digest
+		Routes         DeprecatedRoutes `envconfig:"DEPRECATION_ROUTES"`
+	}
+
+	Notification struct {
+		PushGatewayURL   string `envconfig:"NOTIFICATION_PUSH_GATEWAY_URL"`
+		PushGatewayToken string `envconfig:"NOTIFICATION_PUSH_GATEWAY_TOKEN"`
+		Timeout          int    `envconfig:"NOTIFICATION_TIMEOUT" default:"5"`
+	}
+
+	// Email sends the emails through a SMTP relay, to the addresses not suppressed after a permanent bounce
+	// or a complaint reported by the webhooks of SES (through SNS) and SendGrid
+	Email struct {
+		From                     string   `envconfig:"EMAIL_FROM" default:"no-reply@localhost"`
+		SMTPAddress              string   `envconfig:"EMAIL_SMTP_ADDRESS"` // host:port, empty logs the emails instead
+		SMTPUsername             string   `envconfig:"EMAIL_SMTP_USERNAME"`
+		SMTPPassword             string   `envconfig:"EMAIL_SMTP_PASSWORD"`
+		SESTopicARNs             []string `envconfig:"EMAIL_SES_TOPIC_ARNS"`                // SNS topics accepted, empty rejects the SES webhooks
+		SendGridWebhookPublicKey string   `envconfig:"EMAIL_SENDGRID_WEBHOOK_PUBLIC_KEY"`   // base64 verification key, empty rejects the SendGrid webhooks
+		WebhookMaxAge            int      `envconfig:"EMAIL_WEBHOOK_MAX_AGE" default:"600"` // in seconds, older signed webhooks are rejected
+	}
+
+	// SMS sends the text messages through the providers of the route of the recipient, failing over to the
+	// next provider of the route when a provider fails or times out
+	SMS struct {
+		Routes                  SMSRoutes `envconfig:"SMS_ROUTES" default:"*:twilio|sns"`
+		Prices                  SMSPrices `envconfig:"SMS_PRICES"`
+		Timeout                 int       `envconfig:"SMS_TIMEOUT" default:"5"` // in seconds, per provider
+		TwilioAccountSID        string    `envconfig:"SMS_TWILIO_ACCOUNT_SID"`  // empty disables twilio
+		TwilioAuthToken         string    `envconfig:"SMS_TWILIO_AUTH_TOKEN"`
+		TwilioFrom              string    `envconfig:"SMS_TWILIO_FROM"`                // phone number or messaging service sid
+		TwilioStatusCallbackURL string    `envconfig:"SMS_TWILIO_STATUS_CALLBACK_URL"` // public url of /webhooks/sms/twilio
+		SNSEnabled              bool      `envconfig:"SMS_SNS_ENABLED" default:"false"`
+		SNSRegion               string    `envconfig:"SMS_SNS_REGION" default:"us-east-1"`
+		SNSSenderID             string    `envconfig:"SMS_SNS_SENDER_ID"`
+	}
+
+	// Broadcast pushes the messages of the admins to the sessions streaming them, the broadcasts stored by
+	// another instance reach the streams of this instance at the next sync.
+	Broadcast struct {
+		SyncInterval      int `envconfig:"BROADCAST_SYNC_INTERVAL" default:"5"`       // in seconds, 0 disables the sync
+		KeepAliveInterval int `envconfig:"BROADCAST_KEEPALIVE_INTERVAL" default:"15"` // in seconds
+	}
+
+	// SIEM exports the security events to a syslog destination in CEF and to a Splunk HTTP Event Collector,
+	// each destination is enabled by setting its address. Events lists the exported event names, all
+	// the security events by default.
+	SIEM struct {
+		Events        []string      `envconfig:"SIEM_EVENTS"`
+		BufferSize    int           `envconfig:"SIEM_BUFFER_SIZE" default:"10000"`
+		BatchSize     int           `envconfig:"SIEM_BATCH_SIZE" default:"100"`
+		FlushInterval int           `envconfig:"SIEM_FLUSH_INTERVAL" default:"1000"` // in milliseconds
+		Timeout       int           `envconfig:"SIEM_TIMEOUT" default:"5"`           // in seconds, per batch and destination
+		SyslogNetwork SyslogNetwork `envconfig:"SIEM_SYSLOG_NETWORK" default:"udp"`
+		SyslogAddress string        `envconfig:"SIEM_SYSLOG_ADDRESS"` // host:port
+		HECURL        string        `envconfig:"SIEM_HEC_URL"`        // e.g. https://splunk:8088/services/collector/event
+		HECToken      string        `envconfig:"SIEM_HEC_TOKEN"`
+		HECIndex      string        `envconfig:"SIEM_HEC_INDEX"`
+		HECSourceType string        `envconfig:"SIEM_HEC_SOURCETYPE" default:"go-hex:security"`
+	}
+
+	OIDC struct {
+		Issuer                    string             `envconfig:"OIDC_ISSUER"`
+		UpstreamIssuer            string             `envconfig:"OIDC_UPSTREAM_ISSUER"`
+		UpstreamClientID          string             `envconfig:"OIDC_UPSTREAM_CLIENT_ID"`
+		UpstreamSigningKey        string             `envconfig:"OIDC_UPSTREAM_SIGNING_KEY"`
+		BackchannelClients        BackchannelClients `envconfig:"OIDC_BACKCHANNEL_CLIENTS" secret:"true"`
+		BackchannelLogoutTimeout  int                `envconfig:"OIDC_BACKCHANNEL_LOGOUT_TIMEOUT" default:"5"`
+		BackchannelLogoutTokenTTL int                `envconfig:"OIDC_BACKCHANNEL_LOGOUT_TOKEN_TTL" default:"120"`
+	}
+
+	// Social logs the users in with their account at Google, GitHub or a generic OpenID provider, each provider is
+	// enabled by setting its client id
+	Social struct {
+		RedirectURL        string `envconfig:"SOCIAL_REDIRECT_URL"`                  // the redirect_uri of the authorization requests of the clients
+		AutoProvision      bool   `envconfig:"SOCIAL_AUTO_PROVISION" default:"true"` // creates the users not linked yet
+		Timeout            int    `envconfig:"SOCIAL_TIMEOUT" default:"10"`          // in seconds, per request to a provider
+		GoogleClientID     string `envconfig:"SOCIAL_GOOGLE_CLIENT_ID"`
+		GoogleClientSecret string `envconfig:"SOCIAL_GOOGLE_CLIENT_SECRET" secret:"true"`
+		GitHubClientID     string `envconfig:"SOCIAL_GITHUB_CLIENT_ID"`
+		GitHubClientSecret string `envconfig:"SOCIAL_GITHUB_CLIENT_SECRET" secret:"true"`
+		OIDCIssuer         string `envconfig:"SOCIAL_OIDC_ISSUER"` // e.g. https://login.example.com, its discovery document is read
+		OIDCClientID       string `envconfig:"SOCIAL_OIDC_CLIENT_ID"`
+		OIDCClientSecret   string `envconfig:"SOCIAL_OIDC_CLIENT_SECRET" secret:"true"`
+	}
+
+	// Secrets reads the variables set to a reference secret://path#key from the secret store of Provider when the
+	// configuration is loaded: JWT_SIGNING_KEY, the keys of JWT_SIGNING_KEYS, DB_USERNAME and DB_PASSWORD. The secrets are cached for CacheTTL
+	// seconds, a JWT_SIGNING_KEY rotated in the store being applied by the first reload once its cache expires.
+	Secrets struct {
+		Provider     SecretsProvider `envconfig:"SECRETS_PROVIDER" default:"none"`
+		CacheTTL     int             `envconfig:"SECRETS_CACHE_TTL" default:"300"`
+		Timeout      int             `envconfig:"SECRETS_TIMEOUT" default:"10"` // in seconds, per secret read
+		VaultAddress string          `envconfig:"VAULT_ADDR"`                   // e.g. https://vault.example.com:8200
+		VaultToken   string          `envconfig:"VAULT_TOKEN"`
+		VaultMount   string          `envconfig:"VAULT_MOUNT" default:"secret"` // mount of the KV version 2 engine
+		AWSRegion    string          `envconfig:"SECRETS_AWS_REGION" default:"us-east-1"`
+		AWSEndpoint  string          `envconfig:"SECRETS_AWS_ENDPOINT"` // e.g. http://localhost:4566 for a local Secrets Manager
 	}
 
 	Database struct {
-		Host     string `envconfig:"DB_HOST" required:"true"`
-		Port     string `envconfig:"DB_PORT" required:"true"`
-		Username string `envconfig:"DB_USERNAME" required:"true"`
-		Password string `envconfig:"DB_PASSWORD" required:"true"`
-		DBName   string `envconfig:"DB_NAME" required:"true"`
+		Driver   DatabaseDriver `envconfig:"DB_DRIVER" default:"mysql"`
+		Host     string         `envconfig:"DB_HOST" required:"true"`
+		Port     string         `envconfig:"DB_PORT" required:"true"`
+		Username string         `envconfig:"DB_USERNAME" required:"true"`
+		Password string         `envconfig:"DB_PASSWORD" required:"true"`
+		DBName   string         `envconfig:"DB_NAME" required:"true"`
+		// StatementTimeout in milliseconds applied to every statement, 0 disables it
+		StatementTimeout int `envconfig:"DB_STATEMENT_TIMEOUT" default:"5000"`
+		// OperationTimeouts in milliseconds per table and operation, e.g. users.select:500,sessions.update:1000
+		OperationTimeouts map[string]int `envconfig:"DB_OPERATION_TIMEOUTS"`
+		// WarmupConnections opened before the readiness probe passes, 0 only checks the database is reachable
+		WarmupConnections int `envconfig:"DB_WARMUP_CONNECTIONS" default:"5"`
+		// MaxOpenConns bounds the connections of each instance, 0 leaves them unbounded
+		MaxOpenConns int `envconfig:"DB_MAX_OPEN_CONNS" default:"0"`
+		// MaxIdleConns kept open between the requests, raised to DB_WARMUP_CONNECTIONS; 0 keeps the default of 2
+		MaxIdleConns int `envconfig:"DB_MAX_IDLE_CONNS" default:"0"`
+		// ConnMaxLifetime in seconds after which a connection is replaced, 0 keeps the connections;
+		// keep it below the idle timeout of the server and of the proxies in between
+		ConnMaxLifetime int `envconfig:"DB_CONN_MAX_LIFETIME" default:"0"`
+		// ConnMaxIdleTime in seconds after which an idle connection is closed, 0 keeps the idle connections
+		ConnMaxIdleTime int `envconfig:"DB_CONN_MAX_IDLE_TIME" default:"0"`
+		// FailOnPendingMigrations stops the startup when migrations embedded in the build are not applied,
+		// otherwise they are logged as a warning
+		FailOnPendingMigrations bool `envconfig:"DB_FAIL_ON_PENDING_MIGRATIONS" default:"false"`
+	}
+
+	// DynamoDB stores the users and the sessions in the given table instead of the database when set
+	DynamoDB struct {
+		Table    string `envconfig:"DYNAMODB_TABLE"`
+		Region   string `envconfig:"DYNAMODB_REGION" default:"us-east-1"`
+		Endpoint string `envconfig:"DYNAMODB_ENDPOINT"` // e.g. http://localhost:8000 for a local DynamoDB
+	}
+
+	// MongoDB stores the users in the users collection of the given database instead of the database when the uri
+	// is set, the unique index of the usernames is created at startup
+	MongoDB struct {
+		URI      string `envconfig:"MONGODB_URI"` // e.g. mongodb://localhost:27017
+		Database string `envconfig:"MONGODB_DATABASE" default:"go_hex"`
+		Timeout  int    `envconfig:"MONGODB_TIMEOUT" default:"5"` // in seconds, per operation
+	}
+
+	// Redis stores the revoked access tokens and the identity views instead of the memory of the instance when set,
+	// so that a logout revokes the access token on every instance
+	Redis struct {
+		Address  string `envconfig:"REDIS_ADDRESS"` // host:port
+		Password string `envconfig:"REDIS_PASSWORD"`
+		DB       int    `envconfig:"REDIS_DB" default:"0"`
+		Timeout  int    `envconfig:"REDIS_TIMEOUT" default:"500"` // in milliseconds
+	}
+
+	// RedisUserCache serves the users read by ID, as by every token refresh, from Redis for TTL seconds, shared by
+	// every instance. The writes of the users invalidate them on every instance. It requires REDIS_ADDRESS.
+	RedisUserCache struct {
+		Enabled bool `envconfig:"REDIS_USER_CACHE_ENABLED"`
+		TTL     int  `envconfig:"REDIS_USER_CACHE_TTL" default:"300"` // in seconds
+	}
+
+	// UserCache keeps the users logging in most often, with the roles of their active elevations, in the memory
+	// of the instance. The users are warmed by their logins and admitted once they log in more often than the least
+	// frequent user cached, when the cache is full. The writes of the instance invalidate its cache, the writes of
+	// the other instances are only seen once the entries expire after TTL seconds.
+	UserCache struct {
+		Enabled bool `envconfig:"USER_CACHE_ENABLED"`
+		Size    int  `envconfig:"USER_CACHE_SIZE" default:"1000"`
+		TTL     int  `envconfig:"USER_CACHE_TTL" default:"60"` // in seconds
+	}
+
+	// Tenant layers the settings of the tenant named by the Header of the request over the configuration, the
+	// effective configuration of a tenant being cached for CacheTTL seconds. The requests without the header, or
+	// every request when Header is empty, use the configuration as is.
+	Tenant struct {
+		Header   string `envconfig:"TENANT_HEADER" default:"X-Tenant-ID"`
+		CacheTTL int    `envconfig:"TENANT_CACHE_TTL" default:"60"` // in seconds
+	}
+
+	// Hosted serves the login, login approval, device verification and consent pages under /hosted, for the
+	// deployments without a front end of their own. SecureCookies is only disabled to try them over plain http.
+	// The pages are served with a strict content security policy, whose img-src lists the CSPImageSources of the
+	// logos; its violations are reported to CSPReportURI, and only reported with CSPReportOnly.
+	Hosted struct {
+		Enabled         bool     `envconfig:"HOSTED_PAGES_ENABLED" default:"false"`
+		SecureCookies   bool     `envconfig:"HOSTED_PAGES_SECURE_COOKIES" default:"true"`
+		CSPImageSources []string `envconfig:"HOSTED_PAGES_CSP_IMG_SRC" default:"https:"`
+		CSPReportURI    string   `envconfig:"HOSTED_PAGES_CSP_REPORT_URI"` // empty disables the reports
+		CSPReportOnly   bool     `envconfig:"HOSTED_PAGES_CSP_REPORT_ONLY" default:"false"`
+	}
+
+	// Branding of the emails and of the hosted pages, overridden per tenant. The product is named by APP_NAME.
+	Branding struct {
+		LogoURL      string `envconfig:"BRANDING_LOGO_URL"` // e.g. https://cdn.example.com/logo.png
+		PrimaryColor string `envconfig:"BRANDING_PRIMARY_COLOR" default:"#1a73e8"`
+		AccentColor  string `envconfig:"BRANDING_ACCENT_COLOR" default:"#fbbc04"`
+		SupportEmail string `envconfig:"BRANDING_SUPPORT_EMAIL"` // named in the footer of the emails
+	}
+
+	// RBAC grants the default roles to every user on top of the roles assigned to them
+	RBAC struct {
+		DefaultRoles []string `envconfig:"RBAC_DEFAULT_ROLES" default:"user"`
+	}
+
+	// IdentityView serves the roles and the break-glass activation read to issue the tokens from a view of each user,
+	// stored in Redis when REDIS_ADDRESS is set and in the memory of the instance otherwise, instead of reading each repository
+	IdentityView struct {
+		Enabled      bool `envconfig:"IDENTITY_VIEW_ENABLED"`
+		MaxStaleness int  `envconfig:"IDENTITY_VIEW_MAX_STALENESS" default:"30"` // in seconds, the older views are built again
+	}
+
+	// UserSync syncs the users from the external sources, each source is enabled by setting its address:
+	// a CSV file read over SFTP and a REST HR API answering the users in JSON.
+	UserSync struct {
+		Mapping      UserSyncMapping `envconfig:"USER_SYNC_MAPPING" default:"external_id:id,username:username,full_name:full_name,email:email,phone:phone"`
+		SFTPAddress  string          `envconfig:"USER_SYNC_SFTP_ADDRESS"` // host:port
+		SFTPUser     string          `envconfig:"USER_SYNC_SFTP_USER"`
+		SFTPPassword string          `envconfig:"USER_SYNC_SFTP_PASSWORD"`
+		SFTPHostKey  string          `envconfig:"USER_SYNC_SFTP_HOST_KEY"` // authorized_keys format, the server is not trusted otherwise
+		SFTPPath     string          `envconfig:"USER_SYNC_SFTP_PATH"`     // CSV file with a header row
+		HRURL        string          `envconfig:"USER_SYNC_HR_URL"`
+		HRToken      string          `envconfig:"USER_SYNC_HR_TOKEN"`
+		Timeout      int             `envconfig:"USER_SYNC_TIMEOUT" default:"60"` // in seconds, per sync
+	}
+
+	// Provisioning pushes the users and their roles to the downstream directories, each connector is enabled
+	// by setting its address or its credentials. The failed pushes are retried with an exponential backoff
+	// from RetryBackoff, up to MaxAttempts.
+	Provisioning struct {
+		LDAPAddress       string              `envconfig:"PROVISIONING_LDAP_ADDRESS"` // ldap://host:389 or ldaps://host:636
+		LDAPBindDN        string              `envconfig:"PROVISIONING_LDAP_BIND_DN"`
+		LDAPPassword      string              `envconfig:"PROVISIONING_LDAP_PASSWORD"`
+		LDAPBaseDN        string              `envconfig:"PROVISIONING_LDAP_BASE_DN"` // the accounts are the entries <rdn>=<key>,<base dn>
+		LDAPRDN           string              `envconfig:"PROVISIONING_LDAP_RDN" default:"uid"`
+		LDAPObjectClasses []string            `envconfig:"PROVISIONING_LDAP_OBJECT_CLASSES" default:"top,person,organizationalPerson,inetOrgPerson"`
+		LDAPMapping       ProvisioningMapping `envconfig:"PROVISIONING_LDAP_MAPPING" default:"uid:username,cn:full_name,givenName:given_name,sn:family_name,mail:email,telephoneNumber:phone"`
+		GoogleCredentials string              `envconfig:"PROVISIONING_GOOGLE_CREDENTIALS"` // service account key file, with domain-wide delegation
+		GoogleSubject     string              `envconfig:"PROVISIONING_GOOGLE_SUBJECT"`     // admin impersonated by the service account
+		GoogleCustomer    string              `envconfig:"PROVISIONING_GOOGLE_CUSTOMER" default:"my_customer"`
+		GoogleMapping     ProvisioningMapping `envconfig:"PROVISIONING_GOOGLE_MAPPING" default:"primaryEmail:email,name.givenName:given_name,name.familyName:family_name"`
+		SlackURL          string              `envconfig:"PROVISIONING_SLACK_URL" default:"https://api.slack.com/scim/v2"`
+		SlackToken        string              `envconfig:"PROVISIONING_SLACK_TOKEN"`
+		SlackMapping      ProvisioningMapping `envconfig:"PROVISIONING_SLACK_MAPPING" default:"userName:username,displayName:full_name,name.givenName:given_name,name.familyName:family_name,emails:email"`
+		Timeout           int                 `envconfig:"PROVISIONING_TIMEOUT" default:"10"`       // in seconds, per request
+		MaxAttempts       int                 `envconfig:"PROVISIONING_MAX_ATTEMPTS" default:"10"`  // then the push waits for a manual retry
+		RetryBackoff      int                 `envconfig:"PROVISIONING_RETRY_BACKOFF" default:"60"` // in seconds, doubled at each attempt
 	}
 
 	Scheduler struct {
-		CleanUpPattern string `envconfig:"SCHEDULER_CLEANUP_PATTERN" required:"TRUE"`
+		CleanUpPattern      string `envconfig:"SCHEDULER_CLEANUP_PATTERN" required:"TRUE"`
+		AuditAnchorPattern  string `envconfig:"SCHEDULER_AUDIT_ANCHOR_PATTERN" default:"0 * * * *"`
+		AuditArchivePattern string `envconfig:"SCHEDULER_AUDIT_ARCHIVE_PATTERN" default:"0 3 * * *"`
+		RoleExpiryPattern   string `envconfig:"SCHEDULER_ROLE_EXPIRY_PATTERN" default:"*/5 * * * *"`
+		BreakGlassPattern   string `envconfig:"SCHEDULER_BREAK_GLASS_PATTERN" default:"* * * * *"`
+		UserSyncPattern     string `envconfig:"SCHEDULER_USER_SYNC_PATTERN" default:"0 * * * *"`
+		ProvisioningPattern string `envconfig:"SCHEDULER_PROVISIONING_PATTERN" default:"*/15 * * * *"`
 	}
 
 	OpenTelemetry struct {
 		JaegerURL string `envconfig:"OTEL_JAEGER_URL" required:"TRUE"`
 		Sampled   bool   `envconfig:"OTEL_SAMPLED"`
 	}
+
+	// rotation is the rotation of JWT_SIGNING_KEY, shared by the copies of the configuration, see Watcher
+	rotation *auth.Rotation
 }
 
 // LoadTest loads test config
@@ -58,20 +584,147 @@ func LoadDefault() *Config {
 	return load("default", ".env")
 }
 
-// load config and populate to config struct
+// load config and populate to config struct, it panics with every problem of the configuration
 func load(file string, env string) *Config {
-	var config Config
-
-	readEnv(&config, env)
-	err := envconfig.Process("", &config)
+	sources := sources(env)
+	config, err := Load(sources)
 	if err != nil {
 		panic(err)
 	}
-	return &config
+	readEnv(sources.File)
+	return config
+}
+
+// validate checks the values which depend on each other and cannot be checked by envconfig alone
+func (c *Config) validate() error {
+	if c.Audit.BufferSize <= 0 || c.Audit.BatchSize <= 0 || c.Audit.FlushInterval <= 0 {
+		return fmt.Errorf("invalid audit writer: expected positive AUDIT_BUFFER_SIZE, AUDIT_BATCH_SIZE and AUDIT_FLUSH_INTERVAL")
+	}
+	if c.SIEM.BufferSize <= 0 || c.SIEM.BatchSize <= 0 || c.SIEM.FlushInterval <= 0 {
+		return fmt.Errorf("invalid siem exporter: expected positive SIEM_BUFFER_SIZE, SIEM_BATCH_SIZE and SIEM_FLUSH_INTERVAL")
+	}
+	if _, err := c.jwtKeys(); err != nil {
+		return fmt.Errorf("invalid jwt keys for JWT_ALGORITHM %s: %v", c.JWT.Algorithm, err)
+	}
+	if err := c.validateSigningKeys(); err != nil {
+		return err
+	}
+	if c.JWT.MaxTokenBytes < 0 {
+		return fmt.Errorf("invalid JWT_MAX_TOKEN_BYTES %d: expected a positive budget, or 0 to disable it", c.JWT.MaxTokenBytes)
+	}
+	catalog := make(map[string]bool, len(c.JWT.RoleCatalog))
+	for _, role := range c.JWT.RoleCatalog {
+		if role == "" || catalog[role] {
+			return fmt.Errorf("invalid JWT_ROLE_CATALOG: expected distinct role names, got %q", role)
+		}
+		catalog[role] = true
+	}
+	if c.PasswordPolicy.MinClasses < 0 || c.PasswordPolicy.MinClasses > 4 {
+		return fmt.Errorf("invalid PASSWORD_POLICY_MIN_CLASSES %d: expected between 0 and 4 character classes", c.PasswordPolicy.MinClasses)
+	}
+	if c.PasswordHash.BcryptCost < 4 || c.PasswordHash.BcryptCost > 31 {
+		return fmt.Errorf("invalid PASSWORD_BCRYPT_COST %d: expected a cost between 4 and 31", c.PasswordHash.BcryptCost)
+	}
+	if c.PasswordHash.Argon2Memory < 8*uint32(c.PasswordHash.Argon2Threads) || c.PasswordHash.Argon2Time == 0 || c.PasswordHash.Argon2Threads == 0 ||
+		c.PasswordHash.Argon2SaltLength < 8 || c.PasswordHash.Argon2KeyLength < 16 {
+		return fmt.Errorf("invalid argon2id parameters: expected positive PASSWORD_ARGON2_TIME and PASSWORD_ARGON2_THREADS, PASSWORD_ARGON2_MEMORY of at least 8 KiB per thread, PASSWORD_ARGON2_SALT_LENGTH of at least 8 and PASSWORD_ARGON2_KEY_LENGTH of at least 16")
+	}
+	if c.Broadcast.KeepAliveInterval <= 0 {
+		return fmt.Errorf("invalid BROADCAST_KEEPALIVE_INTERVAL %d: expected a positive interval", c.Broadcast.KeepAliveInterval)
+	}
+	if c.RedisUserCache.Enabled && (c.Redis.Address == "" || c.RedisUserCache.TTL <= 0) {
+		return fmt.Errorf("invalid redis user cache: REDIS_ADDRESS and a positive REDIS_USER_CACHE_TTL are required with REDIS_USER_CACHE_ENABLED")
+	}
+	if c.Tenant.CacheTTL < 0 {
+		return fmt.Errorf("invalid TENANT_CACHE_TTL %d: expected a positive duration, or 0 to disable the cache", c.Tenant.CacheTTL)
+	}
+	if c.MongoDB.URI != "" && c.DynamoDB.Table != "" {
+		return fmt.Errorf("invalid user storage: MONGODB_URI and DYNAMODB_TABLE cannot both be set")
+	}
+	if err := c.validateBranding(); err != nil {
+		return err
+	}
+	if err := c.validateHosted(); err != nil {
+		return err
+	}
+	if c.UserCache.Enabled && (c.UserCache.Size <= 0 || c.UserCache.TTL <= 0) {
+		return fmt.Errorf("invalid user cache: expected positive USER_CACHE_SIZE and USER_CACHE_TTL")
+	}
+	if (c.Social.GoogleClientID != "" || c.Social.GitHubClientID != "" || c.Social.OIDCClientID != "") && c.Social.RedirectURL == "" {
+		return fmt.Errorf("invalid social login: SOCIAL_REDIRECT_URL is required with a provider")
+	}
+	if c.Social.OIDCClientID != "" && c.Social.OIDCIssuer == "" {
+		return fmt.Errorf("invalid social login: SOCIAL_OIDC_ISSUER is required with SOCIAL_OIDC_CLIENT_ID")
+	}
+	if c.IdentityView.Enabled && c.IdentityView.MaxStaleness <= 0 {
+		return fmt.Errorf("invalid IDENTITY_VIEW_MAX_STALENESS %d: expected a positive duration", c.IdentityView.MaxStaleness)
+	}
+	if c.UserSync.SFTPAddress != "" && (c.UserSync.SFTPHostKey == "" || c.UserSync.SFTPPath == "") {
+		return fmt.Errorf("invalid user sync sftp source: USER_SYNC_SFTP_HOST_KEY and USER_SYNC_SFTP_PATH are required with USER_SYNC_SFTP_ADDRESS")
+	}
+	if c.Provisioning.LDAPAddress != "" && (c.Provisioning.LDAPBaseDN == "" || !c.Provisioning.LDAPMapping.Has(c.Provisioning.LDAPRDN)) {
+		return fmt.Errorf("invalid ldap provisioning: PROVISIONING_LDAP_BASE_DN is required and PROVISIONING_LDAP_MAPPING must map the PROVISIONING_LDAP_RDN attribute")
+	}
+	if c.Provisioning.GoogleCredentials != "" && !c.Provisioning.GoogleMapping.Has("primaryEmail") {
+		return fmt.Errorf("invalid google provisioning: PROVISIONING_GOOGLE_MAPPING must map the primaryEmail attribute")
+	}
+	if c.Provisioning.SlackToken != "" && !c.Provisioning.SlackMapping.Has("userName") {
+		return fmt.Errorf("invalid slack provisioning: PROVISIONING_SLACK_MAPPING must map the userName attribute")
+	}
+	if c.Provisioning.MaxAttempts <= 0 || c.Provisioning.RetryBackoff <= 0 {
+		return fmt.Errorf("invalid provisioning retries: expected positive PROVISIONING_MAX_ATTEMPTS and PROVISIONING_RETRY_BACKOFF")
+	}
+	if c.SLO.AvailabilityTarget <= 0 || c.SLO.AvailabilityTarget >= 1 || c.SLO.LatencyTarget <= 0 || c.SLO.LatencyTarget >= 1 {
+		return fmt.Errorf("invalid slo: expected SLO_AVAILABILITY_TARGET and SLO_LATENCY_TARGET between 0 and 1")
+	}
+	if c.SLO.LatencyThreshold <= 0 || c.SLO.AuthLatencyThreshold <= 0 || c.SLO.AdminLatencyThreshold <= 0 {
+		return fmt.Errorf("invalid slo: expected positive SLO_LATENCY_THRESHOLD, SLO_AUTH_LATENCY_THRESHOLD and SLO_ADMIN_LATENCY_THRESHOLD")
+	}
+	if c.DeployAnalysis.FlushInterval < 0 || c.DeployAnalysis.Retention <= 0 || c.DeployAnalysis.MinRequests < 0 || c.DeployAnalysis.MaxLatencyDelta < 0 {
+		return fmt.Errorf("invalid deploy analysis: expected positive DEPLOY_ANALYSIS_RETENTION, DEPLOY_ANALYSIS_FLUSH_INTERVAL, DEPLOY_ANALYSIS_MIN_REQUESTS and DEPLOY_ANALYSIS_MAX_LATENCY_DELTA not negative")
+	}
+	if c.DeployAnalysis.MaxErrorRate <= 0 || c.DeployAnalysis.MaxErrorRate >= 1 || c.DeployAnalysis.MaxErrorRateDelta < 0 || c.DeployAnalysis.MaxErrorRateDelta >= 1 {
+		return fmt.Errorf("invalid deploy analysis: expected DEPLOY_ANALYSIS_MAX_ERROR_RATE between 0 and 1, DEPLOY_ANALYSIS_MAX_ERROR_RATE_DELTA between 0 included and 1")
+	}
+	if c.Probe.Interval <= 0 || c.Probe.Timeout <= 0 || c.Probe.AlertThreshold <= 0 {
+		return fmt.Errorf("invalid probe: expected positive PROBE_INTERVAL, PROBE_TIMEOUT and PROBE_ALERT_THRESHOLD")
+	}
+	if c.Probe.Timeout > c.Probe.Interval {
+		return fmt.Errorf("invalid probe: PROBE_TIMEOUT must not exceed PROBE_INTERVAL")
+	}
+	if err := c.validateSecrets(); err != nil {
+		return err
+	}
+	if c.Reload.Interval < 0 {
+		return fmt.Errorf("invalid CONFIG_RELOAD_INTERVAL %d: expected a positive interval, or 0 to only reload on SIGHUP", c.Reload.Interval)
+	}
+	if c.Shutdown.DrainTimeout <= 0 || c.Shutdown.StopTimeout <= 0 {
+		return fmt.Errorf("invalid shutdown timeouts: SHUTDOWN_DRAIN_TIMEOUT and SHUTDOWN_STOP_TIMEOUT must be positive")
+	}
+	if c.AdaptiveLimit.Enabled {
+		limit := c.AdaptiveLimit
+		if limit.MinLimit <= 0 || limit.MinLimit > limit.InitialLimit || limit.InitialLimit > limit.MaxLimit {
+			return fmt.Errorf("invalid adaptive limits: expected 0 < ADAPTIVE_LIMIT_MIN <= ADAPTIVE_LIMIT_INITIAL <= ADAPTIVE_LIMIT_MAX")
+		}
+		if limit.Backoff <= 0 || limit.Backoff >= 1 {
+			return fmt.Errorf("invalid ADAPTIVE_LIMIT_BACKOFF %v: expected a factor between 0 and 1", limit.Backoff)
+		}
+	}
+	return nil
+}
+
+// EnumerationMinDuration returns the duration the login, the signup and the forgotten passwords are delayed up to, zero unless in strict enumeration mode
+func (c *Config) EnumerationMinDuration() time.Duration {
+	if !c.Enumeration.Strict {
+		return 0
+	}
+	return time.Duration(c.Enumeration.MinDuration) * time.Millisecond
 }
 
-func readEnv(cfg *Config, env string) {
-	err := godotenv.Overload(getSourcePath() + "/../" + env)
+// readEnv exports the variables of the environment file which the environment does not set, for the libraries
+// reading them from the environment
+func readEnv(file string) {
+	err := godotenv.Load(file)
 	if err != nil {
 		log.Print(err)
 	}
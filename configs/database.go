@@ -0,0 +1,41 @@
+package configs
+
+import "fmt"
+
+// Database servers supported by the sqlrepo repositories
+const (
+	DatabaseDriverMySQL    = "mysql"
+	DatabaseDriverMariaDB  = "mariadb"
+	DatabaseDriverPostgres = "postgres"
+	// CockroachDB speaks PostgreSQL, it shares its queries and migrations
+	DatabaseDriverCockroachDB = "cockroachdb"
+)
+
+// DatabaseDriver is the database server the repositories are connected to.
+// Unknown drivers are rejected when the configuration is loaded.
+type DatabaseDriver string
+
+// Decode implements envconfig.Decoder
+func (d *DatabaseDriver) Decode(value string) error {
+	switch value {
+	case DatabaseDriverMySQL, DatabaseDriverMariaDB, DatabaseDriverPostgres, DatabaseDriverCockroachDB:
+		*d = DatabaseDriver(value)
+		return nil
+	}
+	return fmt.Errorf("invalid database driver %q: expected %s, %s, %s or %s", value, DatabaseDriverMySQL, DatabaseDriverMariaDB, DatabaseDriverPostgres, DatabaseDriverCockroachDB)
+}
+
+// IsMariaDB checks whether the server is MariaDB
+func (d DatabaseDriver) IsMariaDB() bool {
+	return d == DatabaseDriverMariaDB
+}
+
+// IsPostgres checks whether the server speaks PostgreSQL, CockroachDB included
+func (d DatabaseDriver) IsPostgres() bool {
+	return d == DatabaseDriverPostgres || d == DatabaseDriverCockroachDB
+}
+
+// IsCockroachDB checks whether the server is CockroachDB
+func (d DatabaseDriver) IsCockroachDB() bool {
+	return d == DatabaseDriverCockroachDB
+}
@@ -0,0 +1,57 @@
+package configs
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DeprecatedRoute is a route announced as deprecated to its clients.
+type DeprecatedRoute struct {
+	// Method and Path identify the route as registered in the router, e.g. GET /users/:id
+	Method string
+	Path   string
+	// Sunset is the date after which the route may be removed, zero when not planned yet
+	Sunset time.Time
+	// Successor is the link to the replacing route or documentation
+	Successor string
+}
+
+// DeprecatedRoutes is decoded from a comma separated list of METHOD /path|sunset|successor entries,
+// the sunset (YYYY-MM-DD) and the successor are optional.
+type DeprecatedRoutes []DeprecatedRoute
+
+// Decode implements envconfig.Decoder
+func (d *DeprecatedRoutes) Decode(value string) error {
+	routes := DeprecatedRoutes{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		fields := strings.Split(entry, "|")
+		if len(fields) > 3 {
+			return fmt.Errorf("invalid deprecated route %q: expected METHOD /path|sunset|successor", entry)
+		}
+		methodPath := strings.Fields(fields[0])
+		if len(methodPath) != 2 || !strings.HasPrefix(methodPath[1], "/") {
+			return fmt.Errorf("invalid deprecated route %q: expected METHOD /path", entry)
+		}
+
+		route := DeprecatedRoute{Method: strings.ToUpper(methodPath[0]), Path: methodPath[1]}
+		if len(fields) > 1 && strings.TrimSpace(fields[1]) != "" {
+			sunset, err := time.Parse("2006-01-02", strings.TrimSpace(fields[1]))
+			if err != nil {
+				return fmt.Errorf("invalid sunset of deprecated route %q: %v", entry, err)
+			}
+			route.Sunset = sunset
+		}
+		if len(fields) > 2 {
+			route.Successor = strings.TrimSpace(fields[2])
+		}
+		routes = append(routes, route)
+	}
+	*d = routes
+	return nil
+}
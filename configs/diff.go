@@ -0,0 +1,47 @@
+package configs
+
+import (
+	"reflect"
+	"sort"
+)
+
+// Statuses of a variable configured differently
+const (
+	DiffChanged   = "changed"    // set to another value
+	DiffOnlyLeft  = "only_left"  // unknown to the right configuration, e.g. added by a newer version
+	DiffOnlyRight = "only_right" // unknown to the left configuration
+)
+
+// Difference is a variable configured differently by two configurations
+type Difference struct {
+	Variable string      `json:"variable"`
+	Status   string      `json:"status"`
+	Left     interface{} `json:"left,omitempty"`
+	Right    interface{} `json:"right,omitempty"`
+}
+
+// Diff returns the variables configured differently by the configurations, as answered by Redacted, sorted by
+// variable. The secrets set on both sides are redacted alike and cannot be compared, only a secret set on a
+// single side differs.
+func Diff(left, right map[string]interface{}) []Difference {
+	res := []Difference{}
+	for name, l := range left {
+		r, ok := right[name]
+		switch {
+		case !ok:
+			res = append(res, Difference{Variable: name, Status: DiffOnlyLeft, Left: l})
+		case !reflect.DeepEqual(l, r):
+			res = append(res, Difference{Variable: name, Status: DiffChanged, Left: l, Right: r})
+		}
+	}
+	for name, r := range right {
+		if _, ok := left[name]; !ok {
+			res = append(res, Difference{Variable: name, Status: DiffOnlyRight, Right: r})
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Variable < res[j].Variable
+	})
+	return res
+}
@@ -0,0 +1,35 @@
+package configs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	left := map[string]interface{}{
+		"APP_NAME":         "go-hex",
+		"DB_PASSWORD":      RedactedValue,
+		"JWT_ROLE_CATALOG": []interface{}{"admin", "support"},
+		"LOG_LEVEL":        "info",
+		"SIEM_HEC_TOKEN":   "",
+		"GRPC_PORT":        "9090",
+	}
+	right := map[string]interface{}{
+		"APP_NAME":              "go-hex",
+		"DB_PASSWORD":           RedactedValue,
+		"JWT_ROLE_CATALOG":      []interface{}{"admin"},
+		"LOG_LEVEL":             "debug",
+		"SIEM_HEC_TOKEN":        RedactedValue,
+		"SLO_LATENCY_THRESHOLD": float64(300),
+	}
+
+	assert.Equal(t, []Difference{
+		{Variable: "GRPC_PORT", Status: DiffOnlyLeft, Left: "9090"},
+		{Variable: "JWT_ROLE_CATALOG", Status: DiffChanged, Left: []interface{}{"admin", "support"}, Right: []interface{}{"admin"}},
+		{Variable: "LOG_LEVEL", Status: DiffChanged, Left: "info", Right: "debug"},
+		{Variable: "SIEM_HEC_TOKEN", Status: DiffChanged, Left: "", Right: RedactedValue},
+		{Variable: "SLO_LATENCY_THRESHOLD", Status: DiffOnlyRight, Right: float64(300)},
+	}, Diff(left, right))
+	assert.Empty(t, Diff(left, left))
+}
@@ -0,0 +1,28 @@
+package configs
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// cspSource matches a source of the content security policy of the hosted pages, a host or scheme source, 'self'
+// or 'none', so that a source cannot add a directive or a keyword relaxing the policy
+var cspSource = regexp.MustCompile(`^('self'|'none'|[a-zA-Z0-9.:/*+_-]+)$`)
+
+// validateHosted checks the sources and the report uri of the content security policy of the hosted pages
+func (c *Config) validateHosted() error {
+	for _, source := range c.Hosted.CSPImageSources {
+		if !cspSource.MatchString(source) {
+			return fmt.Errorf("invalid HOSTED_PAGES_CSP_IMG_SRC source %q: expected a host or scheme source, 'self' or 'none'", source)
+		}
+	}
+	if c.Hosted.CSPReportURI != "" {
+		u, err := url.Parse(c.Hosted.CSPReportURI)
+		if err != nil || !u.IsAbs() || u.Host == "" || strings.ContainsAny(c.Hosted.CSPReportURI, " ;,'\"") {
+			return fmt.Errorf("invalid HOSTED_PAGES_CSP_REPORT_URI %q: expected an absolute url", c.Hosted.CSPReportURI)
+		}
+	}
+	return nil
+}
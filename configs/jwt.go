@@ -0,0 +1,312 @@
+package configs
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"go-hex/pkg/auth"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Algorithms signing the access and refresh tokens
+const (
+	JWTAlgorithmHS256 = "HS256" // with the shared JWT_SIGNING_KEY
+	JWTAlgorithmRS256 = "RS256" // with the RSA JWT_PRIVATE_KEY
+	JWTAlgorithmES256 = "ES256" // with the P-256 JWT_PRIVATE_KEY
+)
+
+// JWTAlgorithm is the algorithm signing the access and refresh tokens.
+// Unknown algorithms are rejected when the configuration is loaded.
+type JWTAlgorithm string
+
+// Decode implements envconfig.Decoder
+func (a *JWTAlgorithm) Decode(value string) error {
+	switch value {
+	case JWTAlgorithmHS256, JWTAlgorithmRS256, JWTAlgorithmES256:
+		*a = JWTAlgorithm(value)
+		return nil
+	}
+	return fmt.Errorf("invalid jwt algorithm %q: expected %s, %s or %s", value, JWTAlgorithmHS256, JWTAlgorithmRS256, JWTAlgorithmES256)
+}
+
+// Formats of the access tokens handed to the clients
+const (
+	AccessTokenFormatJWT    = "jwt"    // the signed tokens, verified by the other services with the JWKS
+	AccessTokenFormatOpaque = "opaque" // random tokens referencing the signed ones in the opaque token store
+)
+
+// AccessTokenFormat is the format of the access tokens handed to the clients.
+// Unknown formats are rejected when the configuration is loaded.
+type AccessTokenFormat string
+
+// Decode implements envconfig.Decoder
+func (f *AccessTokenFormat) Decode(value string) error {
+	switch value {
+	case AccessTokenFormatJWT, AccessTokenFormatOpaque:
+		*f = AccessTokenFormat(value)
+		return nil
+	}
+	return fmt.Errorf("invalid access token format %q: expected %s or %s", value, AccessTokenFormatJWT, AccessTokenFormatOpaque)
+}
+
+// JWTSigningKeys are the HS256 keys by key ID, decoded from comma separated kid:secret pairs. The secret is
+// everything after the first colon, so that it can be a reference to a secret, e.g. 2026-10-15:secret://go-hex/jwt#key.
+type JWTSigningKeys map[string]string
+
+// Decode implements envconfig.Decoder
+func (k *JWTSigningKeys) Decode(value string) error {
+	keys := JWTSigningKeys{}
+	for _, pair := range splitList(value) {
+		id, secret, ok := strings.Cut(pair, ":")
+		if !ok || id == "" || secret == "" {
+			return fmt.Errorf("invalid jwt signing key %q: expected kid:secret", redactPair(pair))
+		}
+		if _, found := keys[id]; found {
+			return fmt.Errorf("invalid jwt signing keys: the key id %q is duplicated", id)
+		}
+		keys[id] = secret
+	}
+	*k = keys
+	return nil
+}
+
+// encode returns the keys in the format of Decode, sorted by ID
+func (k JWTSigningKeys) encode() string {
+	pairs := make([]string, 0, len(k))
+	for _, id := range sortedKeys(k) {
+		pairs = append(pairs, id+":"+k[id])
+	}
+	return strings.Join(pairs, ",")
+}
+
+// JWTKeyExpirations are the ends of the grace periods of the retired signing keys by key ID, decoded from comma
+// separated kid:time pairs, the times in RFC 3339, e.g. 2026-10-01:2026-11-14T10:00:00Z
+type JWTKeyExpirations map[string]time.Time
+
+// Decode implements envconfig.Decoder
+func (e *JWTKeyExpirations) Decode(value string) error {
+	expirations := JWTKeyExpirations{}
+	for _, pair := range splitList(value) {
+		id, at, ok := strings.Cut(pair, ":")
+		if !ok || id == "" {
+			return fmt.Errorf("invalid jwt key expiration %q: expected kid:time", pair)
+		}
+		t, err := time.Parse(time.RFC3339, at)
+		if err != nil {
+			return fmt.Errorf("invalid jwt key expiration %q: expected a RFC 3339 time", pair)
+		}
+		expirations[id] = t
+	}
+	*e = expirations
+	return nil
+}
+
+func (e JWTKeyExpirations) String() string {
+	pairs := make([]string, 0, len(e))
+	for _, id := range sortedKeys(e) {
+		pairs = append(pairs, id+":"+e[id].UTC().Format(time.RFC3339))
+	}
+	return strings.Join(pairs, ",")
+}
+
+// splitList returns the comma separated items of the value, none when it is blank
+func splitList(value string) []string {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// redactPair returns the kid of a kid:secret pair without its secret
+func redactPair(pair string) string {
+	id, _, ok := strings.Cut(pair, ":")
+	if !ok {
+		return RedactedValue
+	}
+	return id + ":" + RedactedValue
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// JWTPrivateKey is the private key signing the tokens with an asymmetric algorithm.
+// It is decoded from a PEM block (PKCS #1, PKCS #8 or SEC 1) or from the path of a PEM file.
+type JWTPrivateKey struct {
+	crypto.Signer
+}
+
+// Decode implements envconfig.Decoder
+func (k *JWTPrivateKey) Decode(value string) error {
+	if value == "" {
+		return nil
+	}
+	data, err := readPEM(value)
+	if err != nil {
+		return fmt.Errorf("cannot read the jwt private key: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return fmt.Errorf("invalid jwt private key: expected a PEM block")
+	}
+	var key interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid jwt private key: %w", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return fmt.Errorf("invalid jwt private key: unsupported key type %T", key)
+	}
+	k.Signer = signer
+	return nil
+}
+
+// JWTPublicKey is a previous public key verifying the tokens signed before a rotation.
+// It is decoded from a PEM block (PKIX or PKCS #1) or from the path of a PEM file.
+type JWTPublicKey struct {
+	crypto.PublicKey
+}
+
+// Decode implements envconfig.Decoder
+func (k *JWTPublicKey) Decode(value string) error {
+	data, err := readPEM(value)
+	if err != nil {
+		return fmt.Errorf("cannot read the jwt public key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return fmt.Errorf("invalid jwt public key: expected a PEM block")
+	}
+	var key interface{}
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid jwt public key: %w", err)
+	}
+	k.PublicKey = key
+	return nil
+}
+
+// readPEM returns the PEM block of the value, or the content of the file of its path
+func readPEM(value string) ([]byte, error) {
+	if strings.HasPrefix(strings.TrimSpace(value), "-----BEGIN") {
+		return []byte(value), nil
+	}
+	return os.ReadFile(value)
+}
+
+// RefreshFingerprintKey returns the key of the fingerprints of the refresh tokens, derived from the signing key when
+// JWT_REFRESH_FINGERPRINT_KEY is not set so that the signing key itself is not used for another purpose
+func (c *Config) RefreshFingerprintKey() []byte {
+	if c.JWT.RefreshFingerprintKey != "" {
+		return []byte(c.JWT.RefreshFingerprintKey)
+	}
+	mac := hmac.New(sha256.New, []byte(c.JWT.SigningKey))
+	mac.Write([]byte("go-hex refresh token fingerprint"))
+	return mac.Sum(nil)
+}
+
+// JWTKeys returns the keys signing and verifying the access and refresh tokens
+func (c *Config) JWTKeys() *auth.Keys {
+	// the algorithm and the private key are checked together when the configuration is loaded
+	keys, _ := c.jwtKeys()
+	return keys
+}
+
+func (c *Config) jwtKeys() (*auth.Keys, error) {
+	var keys *auth.Keys
+	var err error
+	switch c.JWT.Algorithm {
+	case JWTAlgorithmRS256:
+		keys, err = auth.NewAsymmetricKeys(jwt.SigningMethodRS256, c.JWT.PrivateKey.Signer, c.JWT.KeyID, c.JWT.SigningKey)
+	case JWTAlgorithmES256:
+		keys, err = auth.NewAsymmetricKeys(jwt.SigningMethodES256, c.JWT.PrivateKey.Signer, c.JWT.KeyID, c.JWT.SigningKey)
+	default:
+		keys = auth.NewHS256Keys(c.JWT.SigningKey)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	previous := make([]auth.PublicKey, 0, len(c.JWT.PreviousPublicKeys))
+	for _, key := range c.JWT.PreviousPublicKeys {
+		publicKey, err := auth.NewPublicKey(key.PublicKey, "")
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWT_PREVIOUS_PUBLIC_KEYS: %w", err)
+		}
+		previous = append(previous, publicKey)
+	}
+	compaction := auth.Compaction{Permissions: c.JWT.CompactPermissions, RoleCatalog: c.JWT.RoleCatalog}
+	keys = keys.WithPrevious(previous...).WithCompaction(compaction)
+
+	rotation := c.rotation
+	if rotation == nil {
+		rotation = auth.NewRotation(c.signingKeys())
+	}
+	// the keys of every copy of the configuration sign with the signing keys of the last reload
+	return keys.WithRotation(rotation), nil
+}
+
+// signingKeys returns the active HS256 key and the other ones: the key of JWT_ACTIVE_KEY_ID and the other
+// JWT_SIGNING_KEYS until their expiration, then JWT_SIGNING_KEY verifying the tokens without kid, or JWT_SIGNING_KEY
+// alone when there is no key set
+func (c *Config) signingKeys() (auth.SigningKey, []auth.SigningKey) {
+	legacy := auth.SigningKey{Secret: []byte(c.JWT.SigningKey)}
+	if len(c.JWT.SigningKeys) == 0 {
+		return legacy, nil
+	}
+
+	active := auth.SigningKey{ID: c.JWT.ActiveKeyID, Secret: []byte(c.JWT.SigningKeys[c.JWT.ActiveKeyID])}
+	var others []auth.SigningKey
+	for _, id := range sortedKeys(c.JWT.SigningKeys) {
+		if id != c.JWT.ActiveKeyID {
+			others = append(others, auth.SigningKey{ID: id, Secret: []byte(c.JWT.SigningKeys[id]), ExpiresAt: c.JWT.KeyExpirations[id]})
+		}
+	}
+	return active, append(others, legacy)
+}
+
+func (c *Config) validateSigningKeys() error {
+	if len(c.JWT.SigningKeys) == 0 {
+		if c.JWT.ActiveKeyID != "" || len(c.JWT.KeyExpirations) > 0 {
+			return fmt.Errorf("invalid jwt signing keys: JWT_ACTIVE_KEY_ID and JWT_KEY_EXPIRATIONS require JWT_SIGNING_KEYS")
+		}
+	} else if _, ok := c.JWT.SigningKeys[c.JWT.ActiveKeyID]; !ok {
+		return fmt.Errorf("invalid JWT_ACTIVE_KEY_ID %q: expected the id of one of the JWT_SIGNING_KEYS", c.JWT.ActiveKeyID)
+	}
+	for id := range c.JWT.KeyExpirations {
+		if _, ok := c.JWT.SigningKeys[id]; !ok || id == c.JWT.ActiveKeyID {
+			return fmt.Errorf("invalid JWT_KEY_EXPIRATIONS: %q is not a retired key of JWT_SIGNING_KEYS", id)
+		}
+	}
+	if c.JWT.KeyGracePeriod < c.JWT.TokenExpiration {
+		return fmt.Errorf("invalid JWT_KEY_GRACE_PERIOD %d: expected at least the JWT_TOKEN_EXPIRATION of %d minutes, so that the access tokens stay valid until they expire", c.JWT.KeyGracePeriod, c.JWT.TokenExpiration)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package configs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"go-hex/pkg/auth"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWTKeys(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	block := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	path := filepath.Join(t.TempDir(), "jwt.pem")
+	require.NoError(t, os.WriteFile(path, block, 0600))
+
+	for _, value := range []string{string(block), path} {
+		var cfg Config
+		cfg.JWT.SigningKey = "signing-key"
+		require.NoError(t, cfg.JWT.Algorithm.Decode(JWTAlgorithmES256))
+		require.NoError(t, cfg.JWT.PrivateKey.Decode(value))
+
+		keys, err := cfg.jwtKeys()
+		if assert.NoError(t, err) {
+			assert.Equal(t, "ES256", keys.Method().Alg())
+			assert.Equal(t, key.Public(), keys.PublicKey())
+		}
+		assert.Equal(t, RedactedValue, cfg.Redacted()["JWT_PRIVATE_KEY"])
+	}
+
+	// a RS256 configuration requires a RSA key
+	var cfg Config
+	cfg.JWT.Algorithm = JWTAlgorithmRS256
+	cfg.JWT.PrivateKey.Signer = key
+	_, err = cfg.jwtKeys()
+	assert.Error(t, err)
+
+	// the configurations built without algorithm sign with the shared secret
+	assert.Equal(t, "HS256", (&Config{}).JWTKeys().Method().Alg())
+}
+
+func TestJWTPreviousPublicKeys(t *testing.T) {
+	previous, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(previous.Public())
+	require.NoError(t, err)
+	block := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	var cfg Config
+	cfg.JWT.PreviousPublicKeys = make([]JWTPublicKey, 1)
+	require.NoError(t, cfg.JWT.PreviousPublicKeys[0].Decode(string(block)))
+
+	keys, err := cfg.jwtKeys()
+	if assert.NoError(t, err) && assert.Len(t, keys.PublicKeys(), 1) {
+		assert.Equal(t, previous.Public(), keys.PublicKeys()[0].Key)
+		assert.Equal(t, "ES256", keys.PublicKeys()[0].Method.Alg())
+	}
+
+	var key JWTPublicKey
+	assert.Error(t, key.Decode("-----BEGIN PUBLIC KEY-----\n-----END PUBLIC KEY-----"))
+}
+
+func TestJWTSigningKeys(t *testing.T) {
+	var keys JWTSigningKeys
+	require.NoError(t, keys.Decode("20261001T000000Z:first-signing-key,20261015T000000Z:secret://go-hex/jwt#second"))
+	assert.Equal(t, JWTSigningKeys{"20261001T000000Z": "first-signing-key", "20261015T000000Z": "secret://go-hex/jwt#second"}, keys)
+	assert.EqualError(t, keys.Decode("20261001T000000Z"), `invalid jwt signing key "[REDACTED]": expected kid:secret`)
+	assert.EqualError(t, keys.Decode("a:first,a:second"), `invalid jwt signing keys: the key id "a" is duplicated`)
+
+	var expirations JWTKeyExpirations
+	require.NoError(t, expirations.Decode("20261001T000000Z:2026-11-14T10:00:00Z"))
+	assert.Equal(t, "20261001T000000Z:2026-11-14T10:00:00Z", expirations.String())
+	assert.Error(t, expirations.Decode("20261001T000000Z:tomorrow"))
+
+	file := writeEnvFile(t, map[string]string{
+		"JWT_SIGNING_KEYS":    "20261001T000000Z:first-signing-key,20261015T000000Z:second-signing-key",
+		"JWT_ACTIVE_KEY_ID":   "20261015T000000Z",
+		"JWT_KEY_EXPIRATIONS": "20261001T000000Z:" + time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+	})
+	cfg, err := Load(Sources{File: file})
+	require.NoError(t, err)
+	signer := cfg.JWTKeys().Signer()
+	token, err := signer.Sign(jwt.MapClaims{"id": "01GB2QKZ5X3ZJXK8V1M6D7Y9QF"})
+	require.NoError(t, err)
+	parsed, err := auth.NewHS256Keys("second-signing-key").WithRotation(auth.NewRotation(auth.SigningKey{ID: "20261015T000000Z", Secret: []byte("second-signing-key")}, nil)).Verify(token)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "20261015T000000Z", parsed.Header["kid"], "the tokens are signed with the active key")
+	}
+	assert.Equal(t, RedactedValue, cfg.Redacted()["JWT_SIGNING_KEYS"])
+
+	for name, value := range map[string]string{
+		"JWT_ACTIVE_KEY_ID":    "20261101T000000Z",
+		"JWT_KEY_EXPIRATIONS":  "20261015T000000Z:2026-11-14T10:00:00Z",
+		"JWT_KEY_GRACE_PERIOD": "30",
+	} {
+		_, err := Load(Sources{File: file, Flags: map[string]string{name: value}})
+		assert.Error(t, err, name)
+	}
+}
@@ -0,0 +1,25 @@
+package configs
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LogLevel is the steady state level of the logs, one of the logrus levels (error, warn, info, debug...).
+// Unknown levels are rejected when the configuration is loaded.
+type LogLevel logrus.Level
+
+// Decode implements envconfig.Decoder
+func (l *LogLevel) Decode(value string) error {
+	level, err := logrus.ParseLevel(value)
+	if err != nil {
+		return fmt.Errorf("invalid log level %q: %v", value, err)
+	}
+	*l = LogLevel(level)
+	return nil
+}
+
+func (l LogLevel) String() string {
+	return logrus.Level(l).String()
+}
@@ -0,0 +1,41 @@
+package configs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BackchannelClient is a client notified through the OpenID Connect backchannel on logout.
+type BackchannelClient struct {
+	// URI is the backchannel logout URI of the client
+	URI string
+	// Secret is the client secret signing the logout tokens sent to the client (HS256),
+	// so that the client can verify them without knowing the key signing the access tokens
+	Secret string
+}
+
+// BackchannelClients maps a registered client ID to its backchannel logout configuration.
+// It is decoded from a comma separated list of client_id=secret@uri entries, the secret cannot contain an @.
+type BackchannelClients map[string]BackchannelClient
+
+// Decode implements envconfig.Decoder
+func (b *BackchannelClients) Decode(value string) error {
+	clients := BackchannelClients{}
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return fmt.Errorf("invalid backchannel client: %q", pair)
+		}
+		secretURI := strings.SplitN(kv[1], "@", 2)
+		if len(secretURI) != 2 || secretURI[0] == "" || secretURI[1] == "" {
+			return fmt.Errorf("invalid backchannel client %q: expected client_id=secret@uri", kv[0])
+		}
+		clients[kv[0]] = BackchannelClient{URI: secretURI[1], Secret: secretURI[0]}
+	}
+	*b = clients
+	return nil
+}
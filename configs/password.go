@@ -0,0 +1,51 @@
+package configs
+
+import (
+	"fmt"
+	"go-hex/pkg/password"
+	"go-hex/pkg/password/policy"
+)
+
+// Algorithms hashing the passwords
+const (
+	PasswordHashBcrypt   = "bcrypt"
+	PasswordHashArgon2id = "argon2id"
+)
+
+// PasswordHashAlgorithm is the algorithm hashing the new passwords, the hashes of the other algorithm are still verified.
+// Unknown algorithms are rejected when the configuration is loaded.
+type PasswordHashAlgorithm string
+
+// Decode implements envconfig.Decoder
+func (a *PasswordHashAlgorithm) Decode(value string) error {
+	switch value {
+	case PasswordHashBcrypt, PasswordHashArgon2id:
+		*a = PasswordHashAlgorithm(value)
+		return nil
+	}
+	return fmt.Errorf("invalid password hash algorithm %q: expected %s or %s", value, PasswordHashBcrypt, PasswordHashArgon2id)
+}
+
+// PasswordHasher returns the hasher of the configured algorithm and parameters
+func (c *Config) PasswordHasher() password.Hasher {
+	if c.PasswordHash.Algorithm != PasswordHashArgon2id {
+		return password.Bcrypt{Cost: c.PasswordHash.BcryptCost}
+	}
+	return password.Argon2id{
+		Memory:     c.PasswordHash.Argon2Memory,
+		Time:       c.PasswordHash.Argon2Time,
+		Threads:    c.PasswordHash.Argon2Threads,
+		SaltLength: c.PasswordHash.Argon2SaltLength,
+		KeyLength:  c.PasswordHash.Argon2KeyLength,
+	}
+}
+
+// PasswordValidator returns the configured password policy
+func (c *Config) PasswordValidator() policy.Policy {
+	return policy.Policy{
+		MinLength:        c.PasswordPolicy.MinLength,
+		MinClasses:       c.PasswordPolicy.MinClasses,
+		BanCommon:        c.PasswordPolicy.BanCommon,
+		DisallowUsername: c.PasswordPolicy.DisallowUsername,
+	}
+}
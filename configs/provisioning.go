@@ -0,0 +1,53 @@
+package configs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Fields of the users exported to the downstream accounts
+const (
+	ProvisioningFieldID         = "id"
+	ProvisioningFieldUsername   = "username"
+	ProvisioningFieldFullName   = "full_name"
+	ProvisioningFieldGivenName  = "given_name"  // the full name but its last word
+	ProvisioningFieldFamilyName = "family_name" // the last word of the full name
+	ProvisioningFieldEmail      = "email"
+	ProvisioningFieldPhone      = "phone"
+	ProvisioningFieldRoles      = "roles" // the roles granted by the active elevations, multi-valued
+)
+
+// ProvisioningMapping maps the attributes of the downstream accounts from the fields of the users, by attribute,
+// e.g. uid:username,cn:full_name,mail:email. The attributes of the JSON connectors are dotted paths, e.g. name.givenName.
+type ProvisioningMapping map[string]string
+
+// Decode implements envconfig.Decoder
+func (m *ProvisioningMapping) Decode(value string) error {
+	mapping := ProvisioningMapping{}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		attribute, field, ok := strings.Cut(item, ":")
+		attribute, field = strings.TrimSpace(attribute), strings.TrimSpace(field)
+		if !ok || attribute == "" {
+			return fmt.Errorf("invalid provisioning rule %q: expected attribute:field, e.g. mail:email", item)
+		}
+		switch field {
+		case ProvisioningFieldID, ProvisioningFieldUsername, ProvisioningFieldFullName, ProvisioningFieldGivenName,
+			ProvisioningFieldFamilyName, ProvisioningFieldEmail, ProvisioningFieldPhone, ProvisioningFieldRoles:
+		default:
+			return fmt.Errorf("invalid provisioning rule %q: unknown field %s", item, field)
+		}
+		mapping[attribute] = field
+	}
+	*m = mapping
+	return nil
+}
+
+// Has checks whether the mapping maps the attribute
+func (m ProvisioningMapping) Has(attribute string) bool {
+	_, ok := m[attribute]
+	return ok
+}
@@ -0,0 +1,86 @@
+package configs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// RedactedValue replaces the values of the secrets in Redacted
+const RedactedValue = "[REDACTED]"
+
+// secretSuffixes are the suffixes of the environment variables holding a secret, the other secrets are tagged secret:"true"
+var secretSuffixes = []string{"_PASSWORD", "_TOKEN", "_SECRET", "_SIGNING_KEY"}
+
+// Redacted returns the configuration by environment variable, the secrets replaced by RedactedValue when they are set
+// and the credentials of the URLs removed, so that the configuration of an instance can be shown to the operators.
+func (c *Config) Redacted() map[string]interface{} {
+	res := map[string]interface{}{}
+	redact(reflect.ValueOf(*c), res)
+	return res
+}
+
+// Fingerprint returns the SHA-256 of the redacted configuration, the instances with the same fingerprint
+// have the same configuration except for their secrets
+func (c *Config) Fingerprint() string {
+	// encoding/json sorts the keys of the maps, so that the fingerprint only changes with the configuration
+	b, _ := json.Marshal(c.Redacted())
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func redact(v reflect.Value, res map[string]interface{}) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		name := field.Tag.Get("envconfig")
+		if name == "" {
+			if field.Type.Kind() == reflect.Struct {
+				redact(v.Field(i), res)
+			}
+			continue
+		}
+
+		value := v.Field(i)
+		switch {
+		case isSecret(name, field):
+			if value.IsZero() {
+				res[name] = ""
+			} else {
+				res[name] = RedactedValue
+			}
+		case value.Kind() == reflect.String:
+			res[name] = redactURL(value.String())
+		default:
+			if stringer, ok := value.Interface().(fmt.Stringer); ok {
+				res[name] = stringer.String()
+			} else {
+				res[name] = value.Interface()
+			}
+		}
+	}
+}
+
+func isSecret(name string, field reflect.StructField) bool {
+	if field.Tag.Get("secret") == "true" {
+		return true
+	}
+	for _, suffix := range secretSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactURL masks the password of the URLs, the other values are returned unchanged
+func redactURL(value string) string {
+	u, err := url.Parse(value)
+	if err != nil || u.User == nil {
+		return value
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,108 @@
+package configs
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"go-hex/configs/secrets"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// signingKeyBytes is the size of the random signing keys, 384 bits being beyond the 256 bits of HS256
+const signingKeyBytes = 48
+
+// KeyRotation is a rotation of the signing keys of an environment file
+type KeyRotation struct {
+	ActiveKeyID  string    `json:"active_key_id"`
+	RetiredKeyID string    `json:"retired_key_id,omitempty"` // empty for the first key of the file
+	ExpiresAt    time.Time `json:"expires_at,omitempty"`     // the end of the grace period of the retired key
+	Removed      []string  `json:"removed,omitempty"`        // the retired keys whose grace period is over
+}
+
+// RotateSigningKeys rotates the JWT_SIGNING_KEYS of the environment file: a random key becomes the active one, the
+// active key verifies the tokens it signed until the end of the grace period, and the keys whose grace period is over
+// are removed. The other lines of the file are kept. The instances reading the file apply the rotation once they
+// reload their configuration.
+func RotateSigningKeys(file string, now time.Time, grace time.Duration) (KeyRotation, error) {
+	values, err := godotenv.Read(file)
+	if err != nil {
+		return KeyRotation{}, fmt.Errorf("cannot read %s: %v", file, err)
+	}
+	var keys JWTSigningKeys
+	if err := keys.Decode(values["JWT_SIGNING_KEYS"]); err != nil {
+		return KeyRotation{}, fmt.Errorf("invalid JWT_SIGNING_KEYS: %v", err)
+	}
+	var expirations JWTKeyExpirations
+	if err := expirations.Decode(values["JWT_KEY_EXPIRATIONS"]); err != nil {
+		return KeyRotation{}, fmt.Errorf("invalid JWT_KEY_EXPIRATIONS: %v", err)
+	}
+	for id, secret := range keys {
+		if _, ok := secrets.ParseRef(secret); ok {
+			return KeyRotation{}, fmt.Errorf("the signing key %s is a reference to a secret, rotate the keys of the secret store instead", id)
+		}
+	}
+
+	rotation := KeyRotation{ActiveKeyID: now.UTC().Format("20060102T150405Z")}
+	if _, ok := keys[rotation.ActiveKeyID]; ok {
+		return KeyRotation{}, fmt.Errorf("the signing key %s already exists", rotation.ActiveKeyID)
+	}
+	if active := values["JWT_ACTIVE_KEY_ID"]; active != "" {
+		rotation.RetiredKeyID, rotation.ExpiresAt = active, now.Add(grace).UTC().Truncate(time.Second)
+		expirations[active] = rotation.ExpiresAt
+	}
+	for id, expiresAt := range expirations {
+		if !now.Before(expiresAt) {
+			delete(keys, id)
+			delete(expirations, id)
+			rotation.Removed = append(rotation.Removed, id)
+		}
+	}
+	sort.Strings(rotation.Removed)
+
+	secret := make([]byte, signingKeyBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return KeyRotation{}, fmt.Errorf("cannot generate the signing key: %v", err)
+	}
+	keys[rotation.ActiveKeyID] = base64.RawURLEncoding.EncodeToString(secret)
+
+	err = setFileVariables(file, map[string]string{
+		"JWT_SIGNING_KEYS":    keys.encode(),
+		"JWT_ACTIVE_KEY_ID":   rotation.ActiveKeyID,
+		"JWT_KEY_EXPIRATIONS": expirations.String(),
+	})
+	return rotation, err
+}
+
+// setFileVariables sets the variables of the environment file, replacing their lines or appending them, the other
+// lines being kept
+func setFileVariables(file string, values map[string]string) error {
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	set := map[string]bool{}
+	for i, line := range lines {
+		name, _, ok := strings.Cut(strings.TrimPrefix(strings.TrimSpace(line), "export "), "=")
+		if value, found := values[strings.TrimSpace(name)]; ok && found {
+			lines[i] = strings.TrimSpace(name) + "=" + value
+			set[strings.TrimSpace(name)] = true
+		}
+	}
+	for _, name := range sortedKeys(values) {
+		if !set[name] {
+			lines = append(lines, name+"="+values[name])
+		}
+	}
+	return os.WriteFile(file, []byte(strings.Join(lines, "\n")+"\n"), info.Mode())
+}
@@ -0,0 +1,62 @@
+package configs
+
+import (
+	"go-hex/pkg/auth"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotateSigningKeys(t *testing.T) {
+	file := filepath.Join(t.TempDir(), ".env")
+	require.NoError(t, os.WriteFile(file, []byte("# signing keys\nJWT_SIGNING_KEY=legacy-signing-key\nJWT_ACTIVE_KEY_ID=\n"), 0600))
+	now := time.Date(2026, 10, 15, 10, 0, 0, 0, time.UTC)
+
+	rotation, err := RotateSigningKeys(file, now, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, KeyRotation{ActiveKeyID: "20261015T100000Z"}, rotation)
+
+	first, err := godotenv.Read(file)
+	require.NoError(t, err)
+	assert.Equal(t, "20261015T100000Z", first["JWT_ACTIVE_KEY_ID"])
+	assert.Equal(t, "legacy-signing-key", first["JWT_SIGNING_KEY"])
+	content, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(content), "# signing keys\n"), "the other lines are kept")
+
+	rotation, err = RotateSigningKeys(file, now.Add(time.Minute), time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, KeyRotation{ActiveKeyID: "20261015T100100Z", RetiredKeyID: "20261015T100000Z", ExpiresAt: now.Add(time.Hour + time.Minute)}, rotation)
+
+	second, err := godotenv.Read(file)
+	require.NoError(t, err)
+	var keys JWTSigningKeys
+	require.NoError(t, keys.Decode(second["JWT_SIGNING_KEYS"]))
+	assert.Len(t, keys, 2)
+	assert.Equal(t, "20261015T100000Z:2026-10-15T11:01:00Z", second["JWT_KEY_EXPIRATIONS"])
+
+	// the tokens of the retired key are verified with the keys of the file
+	retired := auth.NewRotation(auth.SigningKey{ID: "20261015T100000Z", Secret: []byte(keys["20261015T100000Z"])}, nil)
+	token, err := auth.NewHS256Keys("").WithRotation(retired).Signer().Sign(jwt.MapClaims{"id": "01GB2QKZ5X3ZJXK8V1M6D7Y9QF"})
+	require.NoError(t, err)
+	cfg := Config{}
+	cfg.JWT.SigningKey, cfg.JWT.SigningKeys, cfg.JWT.ActiveKeyID = second["JWT_SIGNING_KEY"], keys, second["JWT_ACTIVE_KEY_ID"]
+	require.NoError(t, cfg.JWT.KeyExpirations.Decode(second["JWT_KEY_EXPIRATIONS"]))
+	_, err = cfg.JWTKeys().Verify(token)
+	assert.NoError(t, err)
+
+	rotation, err = RotateSigningKeys(file, now.Add(2*time.Hour), time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"20261015T100000Z"}, rotation.Removed, "the keys whose grace period is over are removed")
+
+	require.NoError(t, os.WriteFile(file, []byte("JWT_SIGNING_KEYS=20261015T100000Z:secret://go-hex/jwt#key\nJWT_ACTIVE_KEY_ID=20261015T100000Z\n"), 0600))
+	_, err = RotateSigningKeys(file, now, time.Hour)
+	assert.EqualError(t, err, "the signing key 20261015T100000Z is a reference to a secret, rotate the keys of the secret store instead")
+}
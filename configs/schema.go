@@ -0,0 +1,348 @@
+package configs
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"github.com/kelseyhightower/envconfig"
+	"github.com/sirupsen/logrus"
+)
+
+// schemaDraft is the JSON Schema dialect of Schema
+const schemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// schemaEnums are the values accepted by the Decode of the enumerated types
+var schemaEnums = map[reflect.Type][]string{
+	reflect.TypeOf(AccessTokenFormat("")):     {AccessTokenFormatJWT, AccessTokenFormatOpaque},
+	reflect.TypeOf(AuditArchiveHold("")):      {AuditArchiveHoldNone, AuditArchiveHoldTemporary, AuditArchiveHoldEventBased},
+	reflect.TypeOf(AuditBackpressure("")):     {AuditBackpressureBlock, AuditBackpressureDrop},
+	reflect.TypeOf(DatabaseDriver("")):        {DatabaseDriverMySQL, DatabaseDriverMariaDB, DatabaseDriverPostgres, DatabaseDriverCockroachDB},
+	reflect.TypeOf(JSONEnvelope("")):          {JSONEnvelopeLegacy, JSONEnvelopeData},
+	reflect.TypeOf(JSONNaming("")):            {JSONNamingSnakeCase, JSONNamingCamelCase},
+	reflect.TypeOf(JWTAlgorithm("")):          {JWTAlgorithmHS256, JWTAlgorithmRS256, JWTAlgorithmES256},
+	reflect.TypeOf(LogLevel(0)):               logLevels(),
+	reflect.TypeOf(PasswordHashAlgorithm("")): {PasswordHashBcrypt, PasswordHashArgon2id},
+	reflect.TypeOf(SecretsProvider("")):       {SecretsProviderNone, SecretsProviderVault, SecretsProviderAWS},
+	reflect.TypeOf(SessionLimitPolicy("")):    {SessionLimitPolicyReject, SessionLimitPolicyEvictOldest},
+	reflect.TypeOf(SyslogNetwork("")):         {SyslogNetworkUDP, SyslogNetworkTCP, SyslogNetworkTLS},
+}
+
+// Schema returns the JSON Schema of the configuration, an object of the environment variables as Redacted answers
+// it: the type, default and accepted values of every variable, the required ones, and no other variable.
+// The variables decoded from a custom format, such as the keys or the mappings, are strings.
+func Schema() map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := []string{}
+	eachVariable(reflect.ValueOf(&Config{}).Elem(), func(name string, field reflect.StructField, _ reflect.Value) {
+		property := schemaType(field.Type)
+		if def := field.Tag.Get("default"); def != "" {
+			property["default"] = schemaDefault(field.Type, def)
+		}
+		if isSecret(name, field) {
+			property["writeOnly"] = true
+		}
+		properties[name] = property
+		if isRequired(field) {
+			required = append(required, name)
+		}
+	})
+	sort.Strings(required)
+
+	return map[string]interface{}{
+		"$schema":              schemaDraft,
+		"title":                "go-hex configuration",
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+// ValidateFile validates the environment file, in the format of .env.example, against the configuration.
+// It returns every problem found: the unknown variables with the closest known one, the missing required
+// variables and the values which cannot be decoded, then the values which depend on each other once they all decode.
+func ValidateFile(file string) ([]error, error) {
+	values, err := godotenv.Read(file)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %v", file, err)
+	}
+	return Validate(values), nil
+}
+
+// Validate validates the environment variables against the configuration, see ValidateFile
+func Validate(values map[string]string) []error {
+	_, errs := parse(values)
+	return errs
+}
+
+// parse decodes the environment variables into the configuration as envconfig does, with every problem found
+func parse(values map[string]string) (*Config, []error) {
+	var errs []error
+
+	var config Config
+	known := map[string]bool{}
+	eachVariable(reflect.ValueOf(&config).Elem(), func(name string, field reflect.StructField, value reflect.Value) {
+		known[name] = true
+
+		text, ok := values[name]
+		def := field.Tag.Get("default")
+		if !ok && def == "" {
+			if isRequired(field) {
+				errs = append(errs, fmt.Errorf("missing required variable %s", name))
+			}
+			return
+		}
+		if !ok {
+			text = def
+		}
+		if err := decodeValue(text, value); err != nil {
+			errs = append(errs, decodeError(name, text, field.Type, err))
+		}
+	})
+
+	unknown := []string{}
+	for name := range values {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		if closest := closestVariable(name, known); closest != "" {
+			errs = append(errs, fmt.Errorf("unknown variable %s, did you mean %s?", name, closest))
+		} else {
+			errs = append(errs, fmt.Errorf("unknown variable %s", name))
+		}
+	}
+
+	if len(errs) == 0 {
+		if err := config.validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return &config, errs
+}
+
+// eachVariable calls fn with every field of the configuration read from an environment variable
+func eachVariable(v reflect.Value, fn func(name string, field reflect.StructField, value reflect.Value)) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		name := field.Tag.Get("envconfig")
+		if name == "" {
+			if field.Type.Kind() == reflect.Struct {
+				eachVariable(v.Field(i), fn)
+			}
+			continue
+		}
+		fn(name, field, v.Field(i))
+	}
+}
+
+func isRequired(field reflect.StructField) bool {
+	required, _ := strconv.ParseBool(field.Tag.Get("required"))
+	return required && field.Tag.Get("default") == ""
+}
+
+// decodeValue decodes the value into the field as envconfig does: with the Decode of the field when it has one,
+// the lists being comma separated and the maps comma separated key:value pairs
+func decodeValue(value string, field reflect.Value) error {
+	if decoder, ok := field.Addr().Interface().(envconfig.Decoder); ok {
+		return decoder.Decode(value)
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 0, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 0, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(u)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		items := []string{}
+		if strings.TrimSpace(value) != "" {
+			items = strings.Split(value, ",")
+		}
+		slice := reflect.MakeSlice(field.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := decodeValue(item, slice.Index(i)); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+	case reflect.Map:
+		m := reflect.MakeMap(field.Type())
+		if strings.TrimSpace(value) != "" {
+			for _, pair := range strings.Split(value, ",") {
+				kv := strings.Split(pair, ":")
+				if len(kv) != 2 {
+					return fmt.Errorf("invalid map item: %q", pair)
+				}
+				k := reflect.New(field.Type().Key()).Elem()
+				if err := decodeValue(kv[0], k); err != nil {
+					return err
+				}
+				v := reflect.New(field.Type().Elem()).Elem()
+				if err := decodeValue(kv[1], v); err != nil {
+					return err
+				}
+				m.SetMapIndex(k, v)
+			}
+		}
+		field.Set(m)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+// decodeError describes a value which cannot be decoded by the error of its Decode, or else by the expected type
+func decodeError(name, value string, typ reflect.Type, err error) error {
+	switch {
+	case isDecoder(typ):
+		return fmt.Errorf("invalid %s: %v", name, err)
+	case typ.Kind() == reflect.Slice || typ.Kind() == reflect.Map:
+		return fmt.Errorf("invalid %s %q: expected %s: %v", name, value, schemaTypeName(typ), err)
+	}
+	return fmt.Errorf("invalid %s %q: expected %s", name, value, schemaTypeName(typ))
+}
+
+func isDecoder(typ reflect.Type) bool {
+	return reflect.PtrTo(typ).Implements(reflect.TypeOf((*envconfig.Decoder)(nil)).Elem())
+}
+
+// schemaType returns the schema of the values of the type
+func schemaType(typ reflect.Type) map[string]interface{} {
+	if enum, ok := schemaEnums[typ]; ok {
+		return map[string]interface{}{"type": "string", "enum": enum}
+	}
+	if isDecoder(typ) {
+		return map[string]interface{}{"type": "string"}
+	}
+
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": schemaType(typ.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaType(typ.Elem())}
+	}
+	return map[string]interface{}{"type": "string"}
+}
+
+// schemaTypeName names the schema type of the type in the validation errors, "integer" or "array of string"
+func schemaTypeName(typ reflect.Type) string {
+	schema := schemaType(typ)
+	if enum, ok := schema["enum"].([]string); ok {
+		return "one of " + strings.Join(enum, ", ")
+	}
+	switch schema["type"] {
+	case "array":
+		return "comma separated " + schemaTypeName(typ.Elem()) + " values"
+	case "object":
+		return "comma separated key:" + schemaTypeName(typ.Elem()) + " pairs"
+	}
+	return schema["type"].(string)
+}
+
+// schemaDefault returns the default of the field as a value of its schema type, "10" is 10 for an integer
+func schemaDefault(typ reflect.Type, def string) interface{} {
+	value := reflect.New(typ).Elem()
+	if err := decodeValue(def, value); err != nil {
+		return def
+	}
+	switch schemaType(typ)["type"] {
+	case "integer":
+		if value.Kind() >= reflect.Uint && value.Kind() <= reflect.Uint64 {
+			return value.Uint()
+		}
+		return value.Int()
+	case "number":
+		return value.Float()
+	case "boolean":
+		return value.Bool()
+	case "array", "object":
+		return value.Interface()
+	}
+	return def
+}
+
+// closestVariable returns the known variable closest to the name, a typo, empty when none is close enough
+func closestVariable(name string, known map[string]bool) string {
+	closest, distance := "", len(name)/3+1
+	for candidate := range known {
+		d := levenshtein(name, candidate)
+		if d < distance || (d == distance && closest != "" && candidate < closest) {
+			closest, distance = candidate, d
+		}
+	}
+	return closest
+}
+
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr := make([]int, len(b)+1)
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev = curr
+	}
+	return prev[len(b)]
+}
+
+func min(values ...int) int {
+	res := values[0]
+	for _, v := range values[1:] {
+		if v < res {
+			res = v
+		}
+	}
+	return res
+}
+
+func logLevels() []string {
+	levels := make([]string, 0, len(logrus.AllLevels))
+	for _, level := range logrus.AllLevels {
+		levels = append(levels, level.String())
+	}
+	return levels
+}
@@ -0,0 +1,47 @@
+package configs
+
+import (
+	"testing"
+
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchema(t *testing.T) {
+	schema := Schema()
+	properties := schema["properties"].(map[string]interface{})
+
+	assert.Equal(t, map[string]interface{}{"type": "string", "enum": []string{AuditBackpressureBlock, AuditBackpressureDrop}, "default": "block"}, properties["AUDIT_BACKPRESSURE"])
+	assert.Equal(t, map[string]interface{}{"type": "integer", "default": int64(30)}, properties["APP_REQUEST_TIMEOUT"])
+	assert.Equal(t, true, properties["DB_PASSWORD"].(map[string]interface{})["writeOnly"])
+	assert.Contains(t, schema["required"], "APP_ENV")
+	assert.NotContains(t, schema["required"], "APP_REQUEST_TIMEOUT")
+}
+
+func TestValidate(t *testing.T) {
+	values, err := godotenv.Read("../.env.example")
+	assert.NoError(t, err)
+	assert.Empty(t, Validate(values))
+
+	values["APP_NAM"] = values["APP_NAME"]
+	delete(values, "APP_NAME")
+	values["JWT_TOKEN_EXPIRATION"] = "1h"
+	values["JSON_NAMING"] = "kebab-case"
+
+	var messages []string
+	for _, err := range Validate(values) {
+		messages = append(messages, err.Error())
+	}
+	assert.Equal(t, []string{
+		"missing required variable APP_NAME",
+		`invalid JSON_NAMING: invalid json naming "kebab-case": expected snake_case or camelCase`,
+		`invalid JWT_TOKEN_EXPIRATION "1h": expected integer`,
+		"unknown variable APP_NAM, did you mean APP_NAME?",
+	}, messages)
+
+	// the values depending on each other are checked once every value decodes
+	values, _ = godotenv.Read("../.env.example")
+	values["PROBE_TIMEOUT"] = "120"
+	values["PROBE_INTERVAL"] = "60"
+	assert.EqualError(t, Validate(values)[0], "invalid probe: PROBE_TIMEOUT must not exceed PROBE_INTERVAL")
+}
@@ -0,0 +1,129 @@
+package configs
+
+import (
+	"context"
+	"fmt"
+	"go-hex/configs/secrets"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Secret stores of the variables set to a reference
+const (
+	SecretsProviderNone  = "none"  // the variables are set to their value
+	SecretsProviderVault = "vault" // the KV version 2 engine of HashiCorp Vault
+	SecretsProviderAWS   = "aws"   // AWS Secrets Manager
+)
+
+// SecretsProvider is the secret store the references of the variables are read from.
+// Unknown providers are rejected when the configuration is loaded.
+type SecretsProvider string
+
+// Decode implements envconfig.Decoder
+func (p *SecretsProvider) Decode(value string) error {
+	switch value {
+	case SecretsProviderNone, SecretsProviderVault, SecretsProviderAWS:
+		*p = SecretsProvider(value)
+		return nil
+	}
+	return fmt.Errorf("invalid secrets provider %q: expected %s, %s or %s", value, SecretsProviderNone, SecretsProviderVault, SecretsProviderAWS)
+}
+
+// secretVariable is a variable, or a key of a variable, which can be set to a reference to a secret
+type secretVariable struct {
+	name  string
+	value string
+	set   func(string)
+}
+
+// secretVariables returns the variables which can be set to a reference to a secret
+func (c *Config) secretVariables() []secretVariable {
+	res := []secretVariable{
+		{"JWT_SIGNING_KEY", c.JWT.SigningKey, func(v string) { c.JWT.SigningKey = v }},
+		{"DB_USERNAME", c.Database.Username, func(v string) { c.Database.Username = v }},
+		{"DB_PASSWORD", c.Database.Password, func(v string) { c.Database.Password = v }},
+	}
+	for _, id := range sortedKeys(c.JWT.SigningKeys) {
+		id := id
+		res = append(res, secretVariable{"JWT_SIGNING_KEYS " + id, c.JWT.SigningKeys[id], func(v string) { c.JWT.SigningKeys[id] = v }})
+	}
+	return res
+}
+
+func (c *Config) validateSecrets() error {
+	if c.Secrets.CacheTTL < 0 || c.Secrets.Timeout <= 0 {
+		return fmt.Errorf("invalid secrets: expected a positive SECRETS_TIMEOUT and SECRETS_CACHE_TTL not negative")
+	}
+	switch c.Secrets.Provider {
+	case SecretsProviderVault:
+		if c.Secrets.VaultAddress == "" || c.Secrets.VaultToken == "" {
+			return fmt.Errorf("invalid vault secrets: VAULT_ADDR and VAULT_TOKEN are required with SECRETS_PROVIDER %s", SecretsProviderVault)
+		}
+	case SecretsProviderNone:
+		for _, v := range c.secretVariables() {
+			if _, ok := secrets.ParseRef(v.value); ok {
+				return fmt.Errorf("invalid %s: a reference to a secret requires SECRETS_PROVIDER %s or %s", v.name, SecretsProviderVault, SecretsProviderAWS)
+			}
+		}
+	}
+	return nil
+}
+
+// secretStore is the store of the secrets, kept between the loads so that the reloads of a Watcher read the secrets
+// from its cache. It is replaced once the settings of the secrets change.
+var secretStore struct {
+	mu       sync.Mutex
+	settings interface{}
+	store    *secrets.Store
+}
+
+// resolveSecrets replaces the references of the secret variables by their value read from the secret store, it
+// returns every secret which cannot be read
+func (c *Config) resolveSecrets() []error {
+	var store *secrets.Store
+	var errs []error
+	for _, v := range c.secretVariables() {
+		ref, ok := secrets.ParseRef(v.value)
+		if !ok {
+			continue
+		}
+		if store == nil {
+			var err error
+			if store, err = c.secretStore(); err != nil {
+				return []error{fmt.Errorf("cannot create the secrets provider %s: %v", c.Secrets.Provider, err)}
+			}
+		}
+		value, err := store.Get(context.Background(), ref)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("cannot read the secret of %s: %v", v.name, err))
+			continue
+		}
+		v.set(value)
+	}
+	return errs
+}
+
+func (c *Config) secretStore() (*secrets.Store, error) {
+	secretStore.mu.Lock()
+	defer secretStore.mu.Unlock()
+	if secretStore.store != nil && reflect.DeepEqual(secretStore.settings, c.Secrets) {
+		return secretStore.store, nil
+	}
+
+	timeout := time.Duration(c.Secrets.Timeout) * time.Second
+	var provider secrets.Provider
+	switch c.Secrets.Provider {
+	case SecretsProviderVault:
+		provider = secrets.NewVault(c.Secrets.VaultAddress, c.Secrets.VaultToken, c.Secrets.VaultMount, timeout)
+	default:
+		aws, err := secrets.NewAWS(context.Background(), c.Secrets.AWSRegion, c.Secrets.AWSEndpoint, timeout)
+		if err != nil {
+			return nil, err
+		}
+		provider = aws
+	}
+	secretStore.settings = c.Secrets
+	secretStore.store = secrets.NewStore(provider, time.Duration(c.Secrets.CacheTTL)*time.Second)
+	return secretStore.store, nil
+}
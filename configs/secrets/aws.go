@@ -0,0 +1,103 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"go-hex/pkg/otel"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/pkg/errors"
+)
+
+// AWS reads the secrets of AWS Secrets Manager with the credentials of the default AWS configuration chain.
+// The GetSecretValue calls are signed with Signature Version 4, as the SDK of Secrets Manager does.
+type AWS struct {
+	endpoint    string
+	region      string
+	credentials aws.CredentialsProvider
+	signer      *v4.Signer
+	client      *http.Client
+}
+
+// NewAWS creates a provider of the secrets of the region from the default AWS configuration chain.
+// A non empty endpoint replaces the AWS endpoint, e.g. to reach a local Secrets Manager.
+func NewAWS(ctx context.Context, region, endpoint string, timeout time.Duration) (*AWS, error) {
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+	if err != nil {
+		return nil, err
+	}
+	if endpoint == "" {
+		endpoint = "https://secretsmanager." + region + ".amazonaws.com"
+	}
+	return &AWS{strings.TrimSuffix(endpoint, "/"), region, cfg.Credentials, v4.NewSigner(), &http.Client{Timeout: timeout}}, nil
+}
+
+// Secret reads the current version of the secret of the name or ARN, the fields of its string when it is a JSON
+// object, or else the field of the empty key
+func (a *AWS) Secret(ctx context.Context, name string) (map[string]string, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	body, err := json.Marshal(map[string]string{"SecretId": name})
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot marshal secrets manager request")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create secrets manager request")
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+
+	credentials, err := a.credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot retrieve aws credentials")
+	}
+	hash := sha256.Sum256(body)
+	if err := a.signer.SignHTTP(ctx, credentials, req, hex.EncodeToString(hash[:]), "secretsmanager", a.region, time.Now()); err != nil {
+		return nil, errors.Wrap(err, "cannot sign secrets manager request")
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot reach secrets manager")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		var failure struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&failure)
+		if strings.HasSuffix(failure.Type, "ResourceNotFoundException") {
+			return nil, ErrNotFound
+		}
+		return nil, errors.Errorf("secrets manager responded with status %d: %s %s", resp.StatusCode, failure.Type, failure.Message)
+	}
+
+	var secret struct {
+		SecretString *string `json:"SecretString"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return nil, errors.Wrap(err, "cannot decode secrets manager secret")
+	}
+	if secret.SecretString == nil {
+		return nil, errors.Errorf("the secret %s is binary, expected a string", name)
+	}
+
+	var object map[string]interface{}
+	if err := json.Unmarshal([]byte(*secret.SecretString), &object); err == nil && object != nil {
+		return stringFields(object)
+	}
+	return map[string]string{"": *secret.SecretString}, nil
+}
@@ -0,0 +1,90 @@
+// Package secrets reads the secrets of the configuration from a secret store, HashiCorp Vault or AWS Secrets Manager,
+// so that the signing keys and the credentials do not live in the configuration files. A variable holding a secret
+// is set to a reference secret://path#key, resolved from the store when the configuration is loaded.
+package secrets
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Scheme prefixes the references to the secrets
+const Scheme = "secret://"
+
+// ErrNotFound is returned by a provider when the secret does not exist
+var ErrNotFound = errors.New("secret not found")
+
+// Provider reads the secrets of a secret store
+type Provider interface {
+	// Secret returns the fields of the secret at the path, ErrNotFound when it does not exist
+	Secret(ctx context.Context, path string) (map[string]string, error)
+}
+
+// Ref is a reference to a field of a secret, secret://path#key. A secret of AWS Secrets Manager which is not a JSON
+// object is the field of the empty key, referenced without #key.
+type Ref struct {
+	Path string
+	Key  string
+}
+
+// ParseRef parses the reference of the value, it returns false when the value is not a reference
+func ParseRef(value string) (Ref, bool) {
+	if !strings.HasPrefix(value, Scheme) {
+		return Ref{}, false
+	}
+	path, key, _ := strings.Cut(strings.TrimPrefix(value, Scheme), "#")
+	return Ref{Path: path, Key: key}, true
+}
+
+func (r Ref) String() string {
+	if r.Key == "" {
+		return Scheme + r.Path
+	}
+	return Scheme + r.Path + "#" + r.Key
+}
+
+// Store caches the secrets read from its provider. A secret is only read once it is first referenced, then again
+// once its TTL is over, so that a secret rotated in the store is picked up without reading it on every reload.
+type Store struct {
+	provider Provider
+	ttl      time.Duration
+
+	mu      sync.Mutex
+	entries map[string]entry
+}
+
+type entry struct {
+	fields    map[string]string
+	expiresAt time.Time
+}
+
+// NewStore creates a store caching the secrets of the provider for the TTL, 0 reading them every time
+func NewStore(provider Provider, ttl time.Duration) *Store {
+	return &Store{provider: provider, ttl: ttl, entries: map[string]entry{}}
+}
+
+// Get returns the value of the field referenced, from the cache while it is not expired
+func (s *Store) Get(ctx context.Context, ref Ref) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[ref.Path]
+	if !ok || !time.Now().Before(e.expiresAt) {
+		fields, err := s.provider.Secret(ctx, ref.Path)
+		if err != nil {
+			return "", errors.Wrapf(err, "cannot read %s", ref)
+		}
+		e = entry{fields: fields, expiresAt: time.Now().Add(s.ttl)}
+		s.entries[ref.Path] = e
+	}
+
+	value, ok := e.fields[ref.Key]
+	if !ok {
+		return "", errors.Errorf("cannot read %s: the secret has no key %q", ref, ref.Key)
+	}
+	return value, nil
+}
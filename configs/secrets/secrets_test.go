@@ -0,0 +1,135 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingProvider struct {
+	fields map[string]string
+	reads  int
+}
+
+func (p *countingProvider) Secret(_ context.Context, path string) (map[string]string, error) {
+	p.reads++
+	if path != "go-hex/jwt" {
+		return nil, ErrNotFound
+	}
+	return p.fields, nil
+}
+
+func TestParseRef(t *testing.T) {
+	ref, ok := ParseRef("secret://go-hex/jwt#signing_key")
+	assert.True(t, ok)
+	assert.Equal(t, Ref{Path: "go-hex/jwt", Key: "signing_key"}, ref)
+	assert.Equal(t, "secret://go-hex/jwt#signing_key", ref.String())
+
+	ref, ok = ParseRef("secret://go-hex/db-password")
+	assert.True(t, ok)
+	assert.Equal(t, Ref{Path: "go-hex/db-password"}, ref)
+
+	_, ok = ParseRef("zDgKZG9vVZGFumVP5fQQ")
+	assert.False(t, ok)
+}
+
+func TestStore(t *testing.T) {
+	provider := &countingProvider{fields: map[string]string{"signing_key": "first"}}
+	store := NewStore(provider, time.Hour)
+	ref := Ref{Path: "go-hex/jwt", Key: "signing_key"}
+
+	value, err := store.Get(context.Background(), ref)
+	require.NoError(t, err)
+	assert.Equal(t, "first", value)
+
+	provider.fields = map[string]string{"signing_key": "second"}
+	value, err = store.Get(context.Background(), ref)
+	require.NoError(t, err)
+	assert.Equal(t, "first", value, "the secret is cached")
+	assert.Equal(t, 1, provider.reads)
+
+	_, err = store.Get(context.Background(), Ref{Path: "go-hex/jwt", Key: "missing"})
+	assert.EqualError(t, err, `cannot read secret://go-hex/jwt#missing: the secret has no key "missing"`)
+	_, err = store.Get(context.Background(), Ref{Path: "go-hex/db", Key: "password"})
+	assert.Equal(t, ErrNotFound, errors.Cause(err))
+
+	store = NewStore(provider, 0)
+	value, err = store.Get(context.Background(), ref)
+	require.NoError(t, err)
+	assert.Equal(t, "second", value, "the secrets are read every time without TTL")
+}
+
+func TestVault(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Path != "/v1/kv/data/go-hex/jwt" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"data":{"signing_key":"zDgKZG9vVZGFumVP","version":2},"metadata":{"version":3}}}`))
+	}))
+	defer server.Close()
+
+	vault := NewVault(server.URL, "root", "kv", time.Second)
+	fields, err := vault.Secret(context.Background(), "go-hex/jwt")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"signing_key": "zDgKZG9vVZGFumVP", "version": "2"}, fields)
+
+	_, err = vault.Secret(context.Background(), "go-hex/db")
+	assert.Equal(t, ErrNotFound, err)
+
+	_, err = NewVault(server.URL, "expired", "kv", time.Second).Secret(context.Background(), "go-hex/jwt")
+	assert.EqualError(t, err, "vault responded with status 403")
+}
+
+func TestAWS(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY")
+	t.Setenv("AWS_CONFIG_FILE", "/nonexistent")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "/nonexistent")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") ||
+			r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		var req struct{ SecretId string }
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		switch req.SecretId {
+		case "go-hex/db":
+			_, _ = w.Write([]byte(`{"Name":"go-hex/db","SecretString":"{\"username\":\"go_hex\",\"password\":\"s3cr3t\"}"}`))
+		case "go-hex/jwt":
+			_, _ = w.Write([]byte(`{"Name":"go-hex/jwt","SecretString":"zDgKZG9vVZGFumVP"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type":"ResourceNotFoundException","message":"Secrets Manager can't find the specified secret."}`))
+		}
+	}))
+	defer server.Close()
+
+	aws, err := NewAWS(context.Background(), "eu-west-1", server.URL, time.Second)
+	require.NoError(t, err)
+
+	fields, err := aws.Secret(context.Background(), "go-hex/db")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"username": "go_hex", "password": "s3cr3t"}, fields)
+
+	fields, err = aws.Secret(context.Background(), "go-hex/jwt")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"": "zDgKZG9vVZGFumVP"}, fields, "a secret which is not a JSON object is the field of the empty key")
+
+	_, err = aws.Secret(context.Background(), "go-hex/missing")
+	assert.Equal(t, ErrNotFound, err)
+}
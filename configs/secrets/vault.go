@@ -0,0 +1,85 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-hex/pkg/otel"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Vault reads the secrets of the KV version 2 secrets engine of HashiCorp Vault, authenticated with a token
+type Vault struct {
+	address string
+	token   string
+	mount   string
+	client  *http.Client
+}
+
+// NewVault creates a provider of the secrets of the KV engine mounted at the mount of the Vault server
+func NewVault(address, token, mount string, timeout time.Duration) *Vault {
+	return &Vault{strings.TrimSuffix(address, "/"), token, strings.Trim(mount, "/"), &http.Client{Timeout: timeout}}
+}
+
+// Secret reads the latest version of the secret at the path of the engine, its values which are not strings being
+// encoded in JSON
+func (v *Vault) Secret(ctx context.Context, path string) (map[string]string, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	url := fmt.Sprintf("%s/v1/%s/data/%s", v.address, v.mount, strings.TrimPrefix(path, "/"))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot create vault request")
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot reach vault")
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrNotFound
+	case resp.StatusCode >= http.StatusMultipleChoices:
+		return nil, errors.Errorf("vault responded with status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, errors.Wrap(err, "cannot decode vault secret")
+	}
+	if body.Data.Data == nil {
+		// the latest version of the secret is deleted
+		return nil, ErrNotFound
+	}
+	return stringFields(body.Data.Data)
+}
+
+// stringFields returns the fields of a JSON object, the values which are not strings encoded in JSON
+func stringFields(object map[string]interface{}) (map[string]string, error) {
+	fields := make(map[string]string, len(object))
+	for key, value := range object {
+		if s, ok := value.(string); ok {
+			fields[key] = s
+			continue
+		}
+		b, err := json.Marshal(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot encode the field %s", key)
+		}
+		fields[key] = string(b)
+	}
+	return fields, nil
+}
@@ -0,0 +1,23 @@
+package configs
+
+import "fmt"
+
+// Policies applied when a login exceeds the maximum number of concurrent sessions
+const (
+	SessionLimitPolicyReject      = "reject"
+	SessionLimitPolicyEvictOldest = "evict_oldest"
+)
+
+// SessionLimitPolicy is the policy applied when a login exceeds the maximum number of concurrent sessions.
+// Unknown policies are rejected when the configuration is loaded.
+type SessionLimitPolicy string
+
+// Decode implements envconfig.Decoder
+func (p *SessionLimitPolicy) Decode(value string) error {
+	switch value {
+	case SessionLimitPolicyReject, SessionLimitPolicyEvictOldest:
+		*p = SessionLimitPolicy(value)
+		return nil
+	}
+	return fmt.Errorf("invalid session limit policy %q: expected %s or %s", value, SessionLimitPolicyReject, SessionLimitPolicyEvictOldest)
+}
@@ -0,0 +1,25 @@
+package configs
+
+import "fmt"
+
+// Networks of the syslog destination
+const (
+	SyslogNetworkUDP = "udp"
+	SyslogNetworkTCP = "tcp"
+	SyslogNetworkTLS = "tls"
+)
+
+// SyslogNetwork is the transport of the syslog destination of the security events: udp sends a datagram
+// per event, tcp and tls a newline terminated message per event on a persistent connection.
+// Unknown networks are rejected when the configuration is loaded.
+type SyslogNetwork string
+
+// Decode implements envconfig.Decoder
+func (n *SyslogNetwork) Decode(value string) error {
+	switch value {
+	case SyslogNetworkUDP, SyslogNetworkTCP, SyslogNetworkTLS:
+		*n = SyslogNetwork(value)
+		return nil
+	}
+	return fmt.Errorf("invalid syslog network %q: expected %s, %s or %s", value, SyslogNetworkUDP, SyslogNetworkTCP, SyslogNetworkTLS)
+}
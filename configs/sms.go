@@ -0,0 +1,88 @@
+package configs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SMS providers
+const (
+	SMSProviderTwilio = "twilio"
+	SMSProviderSNS    = "sns"
+)
+
+// SMSRouteDefault is the prefix of the route of the phone numbers matching no other route
+const SMSRouteDefault = "*"
+
+// SMSRoutes are the providers of the text messages by calling code prefix of the recipient, in failover
+// order, decoded from a comma separated list of prefix:provider|provider entries, e.g. +1:twilio|sns,*:sns.
+// The longest prefix matching the phone number routes it, * routes the numbers matching no other prefix.
+type SMSRoutes map[string][]string
+
+// Decode implements envconfig.Decoder
+func (r *SMSRoutes) Decode(value string) error {
+	routes := SMSRoutes{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		prefix, providers, ok := strings.Cut(entry, ":")
+		prefix = strings.TrimSpace(prefix)
+		if !ok || !isSMSPrefix(prefix) {
+			return fmt.Errorf("invalid sms route %q: expected +calling code or * followed by :provider|provider", entry)
+		}
+		for _, provider := range strings.Split(providers, "|") {
+			provider = strings.TrimSpace(provider)
+			if provider != SMSProviderTwilio && provider != SMSProviderSNS {
+				return fmt.Errorf("invalid provider %q of sms route %q: expected %s or %s", provider, entry, SMSProviderTwilio, SMSProviderSNS)
+			}
+			routes[prefix] = append(routes[prefix], provider)
+		}
+	}
+	*r = routes
+	return nil
+}
+
+// SMSPrices are the prices in USD of a segment of a text message by provider and calling code prefix,
+// decoded from a comma separated list of provider:prefix:price entries, e.g. twilio:+1:0.0079,sns:*:0.05.
+// They are only used to account the cost of the messages sent.
+type SMSPrices map[string]map[string]float64
+
+// Decode implements envconfig.Decoder
+func (p *SMSPrices) Decode(value string) error {
+	prices := SMSPrices{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		fields := strings.Split(entry, ":")
+		if len(fields) != 3 || !isSMSPrefix(fields[1]) {
+			return fmt.Errorf("invalid sms price %q: expected provider:prefix:price", entry)
+		}
+		price, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil || price < 0 {
+			return fmt.Errorf("invalid sms price %q: expected a positive price", entry)
+		}
+		if prices[fields[0]] == nil {
+			prices[fields[0]] = map[string]float64{}
+		}
+		prices[fields[0]][fields[1]] = price
+	}
+	*p = prices
+	return nil
+}
+
+// isSMSPrefix checks whether the prefix is the default route or a + followed by digits
+func isSMSPrefix(prefix string) bool {
+	if prefix == SMSRouteDefault {
+		return true
+	}
+	if len(prefix) < 2 || prefix[0] != '+' {
+		return false
+	}
+	_, err := strconv.ParseUint(prefix[1:], 10, 64)
+	return err == nil
+}
@@ -0,0 +1,133 @@
+package configs
+
+import (
+	"fmt"
+	"go-hex/pkg/auth"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/joho/godotenv"
+)
+
+// Sources are the layers of the configuration, each one overriding the variables of the previous ones: the defaults
+// of the variables, the environment file, the environment variables of the process, then the --set flags of the
+// command line. Only the variables of the configuration are read from the file and the environment, the other ones
+// being left to the libraries, while an unknown flag is a problem.
+type Sources struct {
+	File  string            // environment file in the format of .env.example, skipped when missing
+	Env   map[string]string // environment variables of the process
+	Flags map[string]string // --set flags, by environment variable
+}
+
+// ValidationError lists every problem of the configuration, so that they are all fixed at once
+type ValidationError []error
+
+func (e ValidationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "invalid configuration, %d problem(s):", len(e))
+	for _, err := range e {
+		b.WriteString("\n  - ")
+		b.WriteString(err.Error())
+	}
+	return b.String()
+}
+
+// overrides are the environment file and the --set flags of the command line, see Override
+var overrides struct {
+	mu    sync.Mutex
+	file  string
+	flags map[string]string
+}
+
+// processEnv is the environment of the process before the variables of the environment file are exported
+var processEnv struct {
+	once   sync.Once
+	values map[string]string
+}
+
+// Override replaces the environment file of LoadDefault and LoadTest, when not empty, and sets the --set flags
+// overriding the file and the environment
+func Override(file string, flags map[string]string) {
+	overrides.mu.Lock()
+	defer overrides.mu.Unlock()
+	overrides.file = file
+	overrides.flags = flags
+}
+
+// DefaultSources returns the sources of LoadDefault, read again by a Watcher
+func DefaultSources() Sources {
+	return sources(".env")
+}
+
+// sources returns the sources of the environment file, unless overridden by the command line
+func sources(env string) Sources {
+	processEnv.once.Do(func() {
+		processEnv.values = map[string]string{}
+		for _, pair := range os.Environ() {
+			if name, value, ok := strings.Cut(pair, "="); ok {
+				processEnv.values[name] = value
+			}
+		}
+	})
+
+	overrides.mu.Lock()
+	defer overrides.mu.Unlock()
+	s := Sources{File: getSourcePath() + "/../" + env, Env: processEnv.values, Flags: overrides.flags}
+	if overrides.file != "" {
+		s.File = overrides.file
+	}
+	return s
+}
+
+// Values returns the variables of the configuration set by the sources, the variable of the highest layer winning
+func (s Sources) Values() (map[string]string, error) {
+	known := map[string]bool{}
+	eachVariable(reflect.ValueOf(&Config{}).Elem(), func(name string, _ reflect.StructField, _ reflect.Value) {
+		known[name] = true
+	})
+
+	values := map[string]string{}
+	if s.File != "" {
+		file, err := godotenv.Read(s.File)
+		switch {
+		case os.IsNotExist(err):
+		case err != nil:
+			return nil, fmt.Errorf("cannot read %s: %v", s.File, err)
+		}
+		for name, value := range file {
+			if known[name] {
+				values[name] = value
+			}
+		}
+	}
+	for name, value := range s.Env {
+		if known[name] {
+			values[name] = value
+		}
+	}
+	for name, value := range s.Flags {
+		values[name] = value
+	}
+	return values, nil
+}
+
+// Load loads the configuration from the sources, it returns a ValidationError with every problem found: the unknown
+// flags, the missing required variables, the values which cannot be decoded, then the values depending on each other,
+// then the secrets referenced which cannot be read from the secret store
+func Load(s Sources) (*Config, error) {
+	values, err := s.Values()
+	if err != nil {
+		return nil, err
+	}
+	config, errs := parse(values)
+	if len(errs) > 0 {
+		return nil, ValidationError(errs)
+	}
+	if errs := config.resolveSecrets(); len(errs) > 0 {
+		return nil, ValidationError(errs)
+	}
+	config.rotation = auth.NewRotation(config.signingKeys())
+	return config, nil
+}
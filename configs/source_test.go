@@ -0,0 +1,63 @@
+package configs
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/joho/godotenv"
+	"github.com/kelseyhightower/envconfig"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeEnvFile writes the variables of .env.example overridden by the values to an environment file
+func writeEnvFile(t *testing.T, values map[string]string) string {
+	example, err := godotenv.Read("../.env.example")
+	require.NoError(t, err)
+	for name, value := range values {
+		example[name] = value
+	}
+	file := filepath.Join(t.TempDir(), ".env")
+	require.NoError(t, godotenv.Write(example, file))
+	return file
+}
+
+func TestLoad(t *testing.T) {
+	file := writeEnvFile(t, map[string]string{"APP_NAME": "file", "APP_PORT": "3000", "LOG_LEVEL": "debug", "GOOGLE_APPLICATION_CREDENTIALS": "key.json"})
+
+	cfg, err := Load(Sources{
+		File:  file,
+		Env:   map[string]string{"APP_PORT": "4000", "LOG_LEVEL": "warn", "PATH": "/usr/bin"},
+		Flags: map[string]string{"LOG_LEVEL": "error"},
+	})
+	require.NoError(t, err, "the variables unknown to the configuration are left to the libraries")
+	assert.Equal(t, "file", cfg.Server.NAME)
+	assert.Equal(t, "4000", cfg.Server.PORT, "the environment overrides the file")
+	assert.Equal(t, LogLevel(logrus.ErrorLevel), cfg.Log.Level, "the flags override the environment")
+	assert.Equal(t, 30, cfg.Server.RequestTimeout, "the defaults are the lowest layer")
+
+	_, err = Load(Sources{File: filepath.Join(t.TempDir(), "missing.env"), Env: map[string]string{"APP_ENV": "local"}})
+	assert.IsType(t, ValidationError{}, err, "a missing file is skipped")
+
+	_, err = Load(Sources{File: file, Flags: map[string]string{"LOG_LEVL": "debug", "JWT_TOKEN_EXPIRATION": "1h"}})
+	assert.EqualError(t, err, "invalid configuration, 2 problem(s):\n"+
+		"  - invalid JWT_TOKEN_EXPIRATION \"1h\": expected integer\n"+
+		"  - unknown variable LOG_LEVL, did you mean LOG_LEVEL?")
+}
+
+func TestLoadDecodesAsEnvconfig(t *testing.T) {
+	values, err := godotenv.Read("../.env.example")
+	require.NoError(t, err)
+	for name, value := range values {
+		t.Setenv(name, value)
+	}
+	var expected Config
+	require.NoError(t, envconfig.Process("", &expected))
+
+	cfg, err := Load(Sources{Env: values})
+	require.NoError(t, err)
+	assert.NotNil(t, cfg.rotation)
+	cfg.rotation = nil // the rotation of the signing key is created by Load alone
+	assert.Equal(t, &expected, cfg)
+}
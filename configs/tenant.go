@@ -0,0 +1,19 @@
+package configs
+
+import "context"
+
+type contextKey struct{}
+
+// WithContext returns a context carrying the effective configuration of its request, such as the configuration
+// overridden by the settings of a tenant
+func WithContext(ctx context.Context, cfg *Config) context.Context {
+	return context.WithValue(ctx, contextKey{}, cfg)
+}
+
+// FromContext returns the effective configuration of the context, the fallback when the context carries none
+func FromContext(ctx context.Context, fallback *Config) *Config {
+	if cfg, ok := ctx.Value(contextKey{}).(*Config); ok {
+		return cfg
+	}
+	return fallback
+}
@@ -0,0 +1,73 @@
+package configs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Fields of the users mapped from the records of a user sync source
+const (
+	UserSyncFieldExternalID = "external_id"
+	UserSyncFieldUsername   = "username"
+	UserSyncFieldFullName   = "full_name"
+	UserSyncFieldEmail      = "email"
+	UserSyncFieldPhone      = "phone"
+	UserSyncFieldActive     = "active"
+)
+
+// UserSyncRule maps a field of the users from a field of the source records.
+// The value is lower or upper cased by Transform, and the active field compares it to Equals.
+type UserSyncRule struct {
+	Source    string `json:"source"`
+	Transform string `json:"transform,omitempty"` // lower or upper
+	Equals    string `json:"equals,omitempty"`    // active field only
+}
+
+// UserSyncMapping are the rules mapping the fields of the users by user field, e.g.
+// external_id:employee_id,username:work_email|lower,active:status=active.
+// The external_id and the username rules are required, the users are active when there is no active rule.
+type UserSyncMapping map[string]UserSyncRule
+
+// Decode implements envconfig.Decoder
+func (m *UserSyncMapping) Decode(value string) error {
+	mapping := UserSyncMapping{}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		field, source, ok := strings.Cut(item, ":")
+		field, source = strings.TrimSpace(field), strings.TrimSpace(source)
+		if !ok || source == "" {
+			return fmt.Errorf("invalid user sync rule %q: expected field:source, e.g. username:work_email|lower", item)
+		}
+
+		var rule UserSyncRule
+		switch field {
+		case UserSyncFieldActive:
+			source, equals, ok := strings.Cut(source, "=")
+			if !ok {
+				return fmt.Errorf("invalid user sync rule %q: expected active:source=value, e.g. active:status=active", item)
+			}
+			rule = UserSyncRule{Source: strings.TrimSpace(source), Equals: strings.TrimSpace(equals)}
+		case UserSyncFieldExternalID, UserSyncFieldUsername, UserSyncFieldFullName, UserSyncFieldEmail, UserSyncFieldPhone:
+			source, transform, _ := strings.Cut(source, "|")
+			rule = UserSyncRule{Source: strings.TrimSpace(source), Transform: strings.TrimSpace(transform)}
+			if rule.Transform != "" && rule.Transform != "lower" && rule.Transform != "upper" {
+				return fmt.Errorf("invalid user sync rule %q: unknown transform %s, expected lower or upper", item, rule.Transform)
+			}
+		default:
+			return fmt.Errorf("invalid user sync rule %q: unknown field %s", item, field)
+		}
+		mapping[field] = rule
+	}
+
+	if _, ok := mapping[UserSyncFieldExternalID]; !ok {
+		return fmt.Errorf("invalid user sync mapping: the %s rule is required", UserSyncFieldExternalID)
+	}
+	if _, ok := mapping[UserSyncFieldUsername]; !ok {
+		return fmt.Errorf("invalid user sync mapping: the %s rule is required", UserSyncFieldUsername)
+	}
+	*m = mapping
+	return nil
+}
@@ -0,0 +1,207 @@
+package configs
+
+import (
+	"go-hex/pkg/logger"
+	"os"
+	"os/signal"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// reloadable are the variables a Watcher applies without restart, the other ones are read once on startup
+var reloadable = map[string]bool{
+	"LOG_LEVEL":                     true,
+	"RATE_LIMIT_LOGIN_PER_IP":       true,
+	"RATE_LIMIT_LOGIN_PER_USERNAME": true,
+	"RATE_LIMIT_PER_IDENTITY":       true,
+	"RATE_LIMIT_ROUTES":             true,
+	"JWT_TOKEN_EXPIRATION":          true,
+	"JWT_REFRESH_TOKEN_EXPIRATION":  true,
+	"JWT_SIGNING_KEY":               true,
+	"JWT_SIGNING_KEYS":              true,
+	"JWT_ACTIVE_KEY_ID":             true,
+	"JWT_KEY_EXPIRATIONS":           true,
+}
+
+// Change is a reload of the configuration changing reloadable variables
+type Change struct {
+	Config    *Config  // the configuration once reloaded
+	Variables []string // the reloadable variables changed, sorted
+}
+
+// Watcher reloads the configuration from its sources every interval and on SIGHUP, and applies the changes of the
+// reloadable variables: the log level, the rate limits, the expiration of the tokens and the signing keys, which are
+// rotated so that the tokens signed with the keys replaced stay valid, see auth.Rotation. The changes of the other
+// variables are logged as waiting for a restart, and an invalid configuration is logged and keeps the current one.
+type Watcher struct {
+	sources Sources
+	log     logger.Logger
+	current atomic.Value // *Config
+
+	mu          sync.Mutex // serializes the reloads
+	subscribers []chan Change
+	restart     []string // the variables waiting for a restart, logged once they change
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewWatcher creates a watcher of the configuration loaded from the sources, reloading it every interval until it is
+// closed. A zero interval only reloads it on SIGHUP.
+func NewWatcher(cfg *Config, sources Sources, log logger.Logger, interval time.Duration) *Watcher {
+	w := &Watcher{
+		sources: sources,
+		log:     log,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	w.current.Store(cfg)
+	go w.run(interval)
+	return w
+}
+
+// Current returns the configuration with the reloadable variables of the last reload, it must not be modified
+func (w *Watcher) Current() *Config {
+	return w.current.Load().(*Config)
+}
+
+// Subscribe returns a channel receiving the changes of the configuration, closed once the watcher is closed.
+// A subscriber which is late only receives the last change, which carries the whole configuration.
+func (w *Watcher) Subscribe() <-chan Change {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	ch := make(chan Change, 1)
+	w.subscribers = append(w.subscribers, ch)
+	return ch
+}
+
+// Reload loads the configuration from the sources and applies its reloadable variables. It returns the change
+// notified to the subscribers, without variables when none changed.
+func (w *Watcher) Reload() (Change, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	loaded, err := Load(w.sources)
+	if err != nil {
+		return Change{}, err
+	}
+
+	current := w.Current()
+	var applied, restart []string
+	for _, name := range changedVariables(current, loaded) {
+		if reloadable[name] {
+			applied = append(applied, name)
+		} else {
+			restart = append(restart, name)
+		}
+	}
+	if !reflect.DeepEqual(restart, w.restart) && len(restart) > 0 {
+		w.log.WithParams(logger.Params{"type": "config", "variables": restart}).Warn("configuration changed, restart to apply it")
+	}
+	w.restart = restart
+
+	if len(applied) == 0 {
+		return Change{Config: current}, nil
+	}
+	next := *current
+	setVariables(&next, loaded, applied)
+	if next.rotation != nil {
+		next.rotation.Update(next.signingKeys())
+	}
+	w.current.Store(&next)
+
+	change := Change{Config: &next, Variables: applied}
+	for _, ch := range w.subscribers {
+		select {
+		case ch <- change:
+		default:
+			// the pending change is replaced by the last one, the watcher being the only sender
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- change
+		}
+	}
+	w.log.WithParams(logger.Params{"type": "config", "variables": applied}).Info("configuration reloaded")
+	return change, nil
+}
+
+// Close stops the watcher and closes the channels of the subscribers
+func (w *Watcher) Close() {
+	close(w.stop)
+	<-w.done
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, ch := range w.subscribers {
+		close(ch)
+	}
+	w.subscribers = nil
+}
+
+func (w *Watcher) run(interval time.Duration) {
+	defer close(w.done)
+
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-tick:
+		case <-hangup:
+		case <-w.stop:
+			return
+		}
+		if _, err := w.Reload(); err != nil {
+			w.log.WithParam("type", "config").Error(err)
+		}
+	}
+}
+
+// changedVariables returns the variables configured differently by the configurations, sorted
+func changedVariables(left, right *Config) []string {
+	values := map[string]interface{}{}
+	eachVariable(reflect.ValueOf(right).Elem(), func(name string, _ reflect.StructField, value reflect.Value) {
+		values[name] = value.Interface()
+	})
+
+	var res []string
+	eachVariable(reflect.ValueOf(left).Elem(), func(name string, _ reflect.StructField, value reflect.Value) {
+		if !reflect.DeepEqual(value.Interface(), values[name]) {
+			res = append(res, name)
+		}
+	})
+	sort.Strings(res)
+	return res
+}
+
+// setVariables sets the variables of dst to their value in src
+func setVariables(dst, src *Config, names []string) {
+	values := map[string]reflect.Value{}
+	eachVariable(reflect.ValueOf(src).Elem(), func(name string, _ reflect.StructField, value reflect.Value) {
+		values[name] = value
+	})
+
+	set := map[string]bool{}
+	for _, name := range names {
+		set[name] = true
+	}
+	eachVariable(reflect.ValueOf(dst).Elem(), func(name string, _ reflect.StructField, value reflect.Value) {
+		if set[name] {
+			value.Set(values[name])
+		}
+	})
+}
@@ -0,0 +1,110 @@
+package configs
+
+import (
+	"encoding/json"
+	"go-hex/pkg/auth"
+	"go-hex/pkg/logger"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/joho/godotenv"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcherReload(t *testing.T) {
+	file := writeEnvFile(t, nil)
+	sources := Sources{File: file}
+	cfg, err := Load(sources)
+	require.NoError(t, err)
+
+	watcher := NewWatcher(cfg, sources, logger.New("test", "test"), 0)
+	changes := watcher.Subscribe()
+
+	change, err := watcher.Reload()
+	require.NoError(t, err)
+	assert.Empty(t, change.Variables)
+	assert.Same(t, cfg, watcher.Current())
+
+	values, err := godotenv.Read(file)
+	require.NoError(t, err)
+	values["LOG_LEVEL"] = "debug"
+	values["RATE_LIMIT_ROUTES"] = "POST /users=30"
+	values["APP_PORT"] = "4000"
+	require.NoError(t, godotenv.Write(values, file))
+
+	change, err = watcher.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"LOG_LEVEL", "RATE_LIMIT_ROUTES"}, change.Variables)
+	current := watcher.Current()
+	assert.Equal(t, LogLevel(logrus.DebugLevel), current.Log.Level)
+	assert.Equal(t, 30, current.RateLimit.Routes["POST /users"])
+	assert.Equal(t, cfg.Server.PORT, current.Server.PORT, "the other variables wait for a restart")
+	assert.Equal(t, LogLevel(logrus.InfoLevel), cfg.Log.Level, "the configuration loaded is not modified")
+	assert.Equal(t, change, <-changes)
+
+	values["LOG_LEVEL"] = "verbose"
+	require.NoError(t, godotenv.Write(values, file))
+	_, err = watcher.Reload()
+	assert.IsType(t, ValidationError{}, err)
+	assert.Same(t, current, watcher.Current(), "an invalid configuration keeps the current one")
+
+	watcher.Close()
+	_, ok := <-changes
+	assert.False(t, ok, "the subscriptions are closed with the watcher")
+}
+
+func TestWatcherRotatesTheSigningKey(t *testing.T) {
+	var signingKey atomic.Value
+	signingKey.Store("first-signing-key")
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"data": map[string]string{"signing_key": signingKey.Load().(string)}},
+		})
+	}))
+	defer vault.Close()
+
+	file := writeEnvFile(t, map[string]string{
+		"SECRETS_PROVIDER":  SecretsProviderVault,
+		"SECRETS_CACHE_TTL": "0",
+		"VAULT_ADDR":        vault.URL,
+		"VAULT_TOKEN":       "root",
+		"JWT_SIGNING_KEY":   "secret://go-hex/jwt#signing_key",
+	})
+	sources := Sources{File: file}
+	cfg, err := Load(sources)
+	require.NoError(t, err)
+	assert.Equal(t, "first-signing-key", cfg.JWT.SigningKey, "the reference is read from the secret store")
+
+	keys := cfg.JWTKeys()
+	signer := keys.Signer()
+	first, err := signer.Sign(jwt.MapClaims{"id": "01GB2QKZ5X3ZJXK8V1M6D7Y9QF"})
+	require.NoError(t, err)
+
+	watcher := NewWatcher(cfg, sources, logger.New("test", "test"), 0)
+	defer watcher.Close()
+	signingKey.Store("second-signing-key")
+	change, err := watcher.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"JWT_SIGNING_KEY"}, change.Variables)
+
+	second, err := signer.Sign(jwt.MapClaims{"id": "01GB2QKZ5X3ZJXK8V1M6D7Y9QF"})
+	require.NoError(t, err)
+	_, err = auth.NewHS256Keys("second-signing-key").Verify(second)
+	assert.NoError(t, err, "the new tokens are signed with the rotated key")
+	_, err = keys.Verify(first)
+	assert.NoError(t, err, "the tokens signed before the rotation stay valid")
+	_, err = watcher.Current().JWTKeys().Verify(second)
+	assert.NoError(t, err)
+}
+
+func TestLoadRejectsSecretsWithoutProvider(t *testing.T) {
+	file := writeEnvFile(t, map[string]string{"DB_PASSWORD": "secret://go-hex/db#password"})
+	_, err := Load(Sources{File: file})
+	assert.EqualError(t, err, "invalid configuration, 1 problem(s):\n"+
+		"  - invalid DB_PASSWORD: a reference to a secret requires SECRETS_PROVIDER vault or aws")
+}
@@ -0,0 +1,89 @@
+package analytics
+
+import (
+	"go-hex/configs"
+	"go-hex/middleware"
+	"go-hex/shared/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RegisterAPI registers a new analytics api
+func RegisterAPI(r echo.Group, cfg *configs.Config, service ServicePort) {
+	handler := handler{cfg, service}
+
+	// Internal endpoints, also reachable by the service accounts holding the read scope
+	internal := r.Group("/internal/analytics",
+		middleware.InternalAPIOrRole(cfg.InternalAPI.User, cfg.InternalAPI.Password, cfg.JWTKeys(), ScopeRead),
+		UsesScope(ScopeRead),
+	)
+	internal.GET("/token-usage/endpoints", handler.endpointUsage)
+	internal.GET("/token-usage/scopes", handler.scopeUsage)
+}
+
+type handler struct {
+	cfg     *configs.Config
+	service ServicePort
+}
+
+// endpointUsage godoc
+// @Router /internal/analytics/token-usage/endpoints [get]
+// @Tags Analytics
+// @Summary Token usage per client and endpoint
+// @Description Sampled calls per client and endpoint, the counts are not extrapolated from the sample rate
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Security BearerToken
+// @Param client_id query string false "client id"
+// @Param from query string false "first day (YYYY-MM-DD), defaults to 30 days ago"
+// @Param to query string false "last day (YYYY-MM-DD), defaults to today"
+// @Success 200 {object} response.Response{data=ResponseEndpointUsage} "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 403 {object} response.ErrorResponse403
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) endpointUsage(c echo.Context) error {
+	var req RequestReport
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.EndpointUsage(c.Request().Context(), req)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessOK(c, res)
+}
+
+// scopeUsage godoc
+// @Router /internal/analytics/token-usage/scopes [get]
+// @Tags Analytics
+// @Summary Token scope usage per client
+// @Description Scopes carried by the tokens of each client and whether they were required by the endpoints called, to find unused scopes and over-privileged clients
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Security BearerToken
+// @Param client_id query string false "client id"
+// @Param from query string false "first day (YYYY-MM-DD), defaults to 30 days ago"
+// @Param to query string false "last day (YYYY-MM-DD), defaults to today"
+// @Success 200 {object} response.Response{data=ResponseScopeUsage} "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 403 {object} response.ErrorResponse403
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) scopeUsage(c echo.Context) error {
+	var req RequestReport
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.ScopeUsage(c.Request().Context(), req)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessOK(c, res)
+}
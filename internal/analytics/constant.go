@@ -0,0 +1,6 @@
+package analytics
+
+const (
+	// ScopeRead is the role a service account needs to read the token usage analytics
+	ScopeRead = "analytics:read"
+)
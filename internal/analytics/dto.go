@@ -0,0 +1,53 @@
+package analytics
+
+import (
+	"go-hex/internal/domain"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+const dateLayout = "2006-01-02"
+
+// RequestReport request query
+type RequestReport struct {
+	ClientID string `query:"client_id" example:"1f7a6f2e-3c3e-4a8e-9d4b-8f5d1f2a9c10"`
+	From     string `query:"from" example:"2022-01-01"` // defaults to 30 days ago
+	To       string `query:"to" example:"2022-01-31"`   // defaults to today
+}
+
+func (r *RequestReport) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.From, validation.Date(dateLayout)),
+		validation.Field(&r.To, validation.Date(dateLayout)),
+	)
+}
+
+// ResponseEndpointUsage struct
+type ResponseEndpointUsage struct {
+	From       string                      `json:"from" example:"2022-01-01"`
+	To         string                      `json:"to" example:"2022-01-31"`
+	SampleRate float64                     `json:"sample_rate" example:"0.1"`
+	Endpoints  []domain.TokenUsageEndpoint `json:"endpoints"`
+}
+
+// ResponseScopeUsage struct
+type ResponseScopeUsage struct {
+	From       string                `json:"from" example:"2022-01-01"`
+	To         string                `json:"to" example:"2022-01-31"`
+	SampleRate float64               `json:"sample_rate" example:"0.1"`
+	Clients    []ResponseClientScope `json:"clients"`
+}
+
+// ResponseClientScope lists the scopes carried by the tokens of a client
+type ResponseClientScope struct {
+	ClientID string `json:"client_id" example:"1f7a6f2e-3c3e-4a8e-9d4b-8f5d1f2a9c10"`
+	// OverPrivileged is set when at least one scope of the client has never been used
+	OverPrivileged bool                  `json:"over_privileged" example:"true"`
+	Scopes         []ResponseClientUsage `json:"scopes"`
+}
+
+// ResponseClientUsage struct
+type ResponseClientUsage struct {
+	domain.TokenUsageScope
+	Unused bool `json:"unused" example:"false"`
+}
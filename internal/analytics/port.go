@@ -0,0 +1,13 @@
+package analytics
+
+import (
+	"context"
+)
+
+// ServicePort encapsulates the token usage analytics logic.
+type ServicePort interface {
+	// EndpointUsage returns which client called which endpoint
+	EndpointUsage(ctx context.Context, req RequestReport) (ResponseEndpointUsage, error)
+	// ScopeUsage returns the scopes carried by the clients and whether they are used
+	ScopeUsage(ctx context.Context, req RequestReport) (ResponseScopeUsage, error)
+}
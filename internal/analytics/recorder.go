@@ -0,0 +1,242 @@
+package analytics
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/auth"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/times"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo/v4"
+)
+
+// contextKeyScopes is the echo context key holding the scopes required by the matched route
+const contextKeyScopes = "analytics.scopes"
+
+type endpointKey struct {
+	clientID      string
+	principalType string
+	method        string
+	route         string
+	day           time.Time
+}
+
+type scopeKey struct {
+	clientID string
+	scope    string
+	day      time.Time
+}
+
+// Recorder samples the authenticated requests and aggregates which client called which endpoint
+// with which scopes. The aggregates are kept in memory and flushed periodically
+// so that recording never adds a database round trip to the request.
+type Recorder struct {
+	repo       port.TokenUsageRepository
+	log        logger.Logger
+	keys       *auth.Keys
+	sampleRate float64
+
+	mu        sync.Mutex
+	endpoints map[endpointKey]*domain.TokenUsageEndpoint
+	scopes    map[scopeKey]*domain.TokenUsageScope
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewRecorder creates a recorder flushing its aggregates every flushInterval until it is closed.
+// A zero flush interval disables the recording.
+func NewRecorder(repo port.TokenUsageRepository, log logger.Logger, keys *auth.Keys, sampleRate float64, flushInterval time.Duration) *Recorder {
+	r := &Recorder{
+		repo:       repo,
+		log:        log,
+		keys:       keys,
+		sampleRate: sampleRate,
+		endpoints:  map[endpointKey]*domain.TokenUsageEndpoint{},
+		scopes:     map[scopeKey]*domain.TokenUsageScope{},
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	if flushInterval <= 0 {
+		r.sampleRate = 0
+		close(r.done)
+		return r
+	}
+	go r.run(flushInterval)
+	return r
+}
+
+// Middleware records a sample of the requests carrying a valid access token
+func (r *Recorder) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			err := next(c)
+
+			if r.sampleRate <= 0 || (r.sampleRate < 1 && rand.Float64() >= r.sampleRate) {
+				return err
+			}
+
+			token, verr := auth.VerifyTokenFromRequest(c, r.keys)
+			if verr != nil {
+				return err
+			}
+			claims := token.Claims.(jwt.MapClaims)
+			if tokenType, _ := claims["token_type"].(string); tokenType != "access" {
+				return err
+			}
+
+			required, _ := c.Get(contextKeyScopes).([]string)
+			r.Record(clientID(claims), principalType(claims), c.Request().Method, c.Path(), tokenScopes(claims), required)
+			return err
+		}
+	}
+}
+
+// UsesScope declares the scopes required by a route so that the analytics can tell
+// the scopes granted to a client apart from the scopes it actually uses.
+func UsesScope(scopes ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set(contextKeyScopes, scopes)
+			return next(c)
+		}
+	}
+}
+
+// Record adds one sampled call to the in-memory aggregates
+func (r *Recorder) Record(clientID, principalType, method, route string, granted []string, required []string) {
+	now := times.Now().UTC()
+	day := now.Truncate(24 * time.Hour)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ekey := endpointKey{clientID, principalType, method, route, day}
+	endpoint, ok := r.endpoints[ekey]
+	if !ok {
+		endpoint = &domain.TokenUsageEndpoint{
+			ClientID:      clientID,
+			PrincipalType: principalType,
+			Method:        method,
+			Route:         route,
+			Day:           day,
+		}
+		r.endpoints[ekey] = endpoint
+	}
+	endpoint.Calls++
+	endpoint.LastSeenAt = now
+
+	for _, scope := range granted {
+		skey := scopeKey{clientID, scope, day}
+		usage, ok := r.scopes[skey]
+		if !ok {
+			usage = &domain.TokenUsageScope{
+				ClientID: clientID,
+				Scope:    scope,
+				Day:      day,
+			}
+			r.scopes[skey] = usage
+		}
+		usage.GrantedCalls++
+		if contains(required, scope) {
+			usage.UsedCalls++
+			lastUsedAt := now
+			usage.LastUsedAt = &lastUsedAt
+		}
+	}
+}
+
+// Close flushes the remaining aggregates and stops the recorder
+func (r *Recorder) Close() {
+	close(r.stop)
+	<-r.done
+}
+
+func (r *Recorder) run(flushInterval time.Duration) {
+	defer close(r.done)
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.flush()
+		case <-r.stop:
+			r.flush()
+			return
+		}
+	}
+}
+
+// flush writes the aggregates collected since the last flush.
+// The aggregates are dropped when they cannot be written, analytics are best effort.
+func (r *Recorder) flush() {
+	r.mu.Lock()
+	endpoints := make([]domain.TokenUsageEndpoint, 0, len(r.endpoints))
+	for _, item := range r.endpoints {
+		endpoints = append(endpoints, *item)
+	}
+	scopes := make([]domain.TokenUsageScope, 0, len(r.scopes))
+	for _, item := range r.scopes {
+		scopes = append(scopes, *item)
+	}
+	r.endpoints = map[endpointKey]*domain.TokenUsageEndpoint{}
+	r.scopes = map[scopeKey]*domain.TokenUsageScope{}
+	r.mu.Unlock()
+
+	ctx := context.Background()
+	if err := r.repo.IncrementEndpoints(ctx, endpoints); err != nil {
+		r.log.WithParam("type", "token_usage").Error(err)
+	}
+	if err := r.repo.IncrementScopes(ctx, scopes); err != nil {
+		r.log.WithParam("type", "token_usage").Error(err)
+	}
+}
+
+// clientID returns the client the token was issued to, or the principal itself when the token has no client
+func clientID(claims jwt.MapClaims) string {
+	if val, ok := claims["client_id"].(string); ok && val != "" {
+		return val
+	}
+	val, _ := claims["id"].(string)
+	return val
+}
+
+func principalType(claims jwt.MapClaims) string {
+	if val, ok := claims["principal_type"].(string); ok && val != "" {
+		return val
+	}
+	return domain.PrincipalTypeUser
+}
+
+// tokenScopes returns the scopes of the space separated scope claim and the roles claim
+func tokenScopes(claims jwt.MapClaims) []string {
+	var scopes []string
+	if val, ok := claims["scope"].(string); ok {
+		scopes = append(scopes, strings.Fields(val)...)
+	}
+	if val, ok := claims["roles"].([]interface{}); ok {
+		for _, item := range val {
+			if role, ok := item.(string); ok && role != "" {
+				scopes = append(scopes, role)
+			}
+		}
+	}
+	return scopes
+}
+
+func contains(items []string, item string) bool {
+	for _, val := range items {
+		if val == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package analytics
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/auth"
+	"go-hex/pkg/logger"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingTokenUsageRepository struct {
+	port.TokenUsageRepository
+	endpoints []domain.TokenUsageEndpoint
+	scopes    []domain.TokenUsageScope
+}
+
+func (r *recordingTokenUsageRepository) IncrementEndpoints(ctx context.Context, usages []domain.TokenUsageEndpoint) error {
+	r.endpoints = append(r.endpoints, usages...)
+	return nil
+}
+
+func (r *recordingTokenUsageRepository) IncrementScopes(ctx context.Context, usages []domain.TokenUsageScope) error {
+	r.scopes = append(r.scopes, usages...)
+	return nil
+}
+
+func TestRecorderCountsTheScopesUsedByTheRoute(t *testing.T) {
+	repo := &recordingTokenUsageRepository{}
+	recorder := NewRecorder(repo, logger.New("test", "test"), auth.NewHS256Keys("secret"), 1, time.Hour)
+
+	router := echo.New()
+	router.Use(recorder.Middleware())
+	router.GET("/reports", func(c echo.Context) error { return c.NoContent(http.StatusOK) }, UsesScope(ScopeRead))
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"id":             "sa-1",
+		"principal_type": domain.PrincipalTypeServiceAccount,
+		"roles":          []string{ScopeRead, "billing:write"},
+		"token_type":     "access",
+	}).SignedString([]byte("secret"))
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/reports", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	recorder.Close()
+
+	assert.Len(t, repo.endpoints, 1)
+	assert.Equal(t, "/reports", repo.endpoints[0].Route)
+
+	used := map[string]int64{}
+	for _, usage := range repo.scopes {
+		assert.Equal(t, int64(1), usage.GrantedCalls)
+		used[usage.Scope] = usage.UsedCalls
+	}
+	assert.Equal(t, map[string]int64{ScopeRead: 1, "billing:write": 0}, used)
+}
+
+func TestRecorderDisabledWithoutFlushInterval(t *testing.T) {
+	repo := &recordingTokenUsageRepository{}
+	recorder := NewRecorder(repo, logger.New("test", "test"), auth.NewHS256Keys("secret"), 1, 0)
+
+	recorder.Record("sa-1", domain.PrincipalTypeServiceAccount, http.MethodGet, "/reports", []string{ScopeRead}, []string{ScopeRead})
+	recorder.Close()
+
+	assert.Empty(t, repo.endpoints)
+	assert.Empty(t, repo.scopes)
+}
@@ -0,0 +1,104 @@
+package analytics
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/times"
+	"time"
+)
+
+// Service encapsulates the token usage analytics logic.
+type Service struct {
+	cfg         *configs.Config
+	repoRegitry port.RepositoryRegistry
+}
+
+// NewService creates and returns a new analytics service
+func NewService(cfg *configs.Config, repoRegitry port.RepositoryRegistry) *Service {
+	return &Service{cfg, repoRegitry}
+}
+
+// EndpointUsage returns the sampled calls per client and endpoint over the requested days.
+func (s *Service) EndpointUsage(ctx context.Context, req RequestReport) (ResponseEndpointUsage, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	var res ResponseEndpointUsage
+
+	err := req.Validate()
+	if err != nil {
+		return res, err
+	}
+	from, to := reportWindow(req)
+
+	endpoints, err := s.repoRegitry.GetTokenUsageRepository().ListEndpoints(ctx, req.ClientID, from, to)
+	if err != nil {
+		return res, err
+	}
+
+	return ResponseEndpointUsage{
+		From:       from.Format(dateLayout),
+		To:         to.Format(dateLayout),
+		SampleRate: s.cfg.Analytics.TokenUsageSampleRate,
+		Endpoints:  endpoints,
+	}, nil
+}
+
+// ScopeUsage returns, per client, the scopes carried by its tokens over the requested days.
+// A scope is unused when none of the sampled calls reached an endpoint requiring it,
+// a client holding unused scopes is reported as over-privileged.
+func (s *Service) ScopeUsage(ctx context.Context, req RequestReport) (ResponseScopeUsage, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	var res ResponseScopeUsage
+
+	err := req.Validate()
+	if err != nil {
+		return res, err
+	}
+	from, to := reportWindow(req)
+
+	usages, err := s.repoRegitry.GetTokenUsageRepository().ListScopes(ctx, req.ClientID, from, to)
+	if err != nil {
+		return res, err
+	}
+
+	// usages are ordered by client
+	clients := []ResponseClientScope{}
+	for _, usage := range usages {
+		if len(clients) == 0 || clients[len(clients)-1].ClientID != usage.ClientID {
+			clients = append(clients, ResponseClientScope{ClientID: usage.ClientID})
+		}
+		client := &clients[len(clients)-1]
+
+		unused := usage.UsedCalls == 0
+		client.OverPrivileged = client.OverPrivileged || unused
+		client.Scopes = append(client.Scopes, ResponseClientUsage{usage, unused})
+	}
+
+	return ResponseScopeUsage{
+		From:       from.Format(dateLayout),
+		To:         to.Format(dateLayout),
+		SampleRate: s.cfg.Analytics.TokenUsageSampleRate,
+		Clients:    clients,
+	}, nil
+}
+
+// reportWindow returns the requested days, the last 30 days by default
+func reportWindow(req RequestReport) (time.Time, time.Time) {
+	to := times.Now().UTC().Truncate(24 * time.Hour)
+	if req.To != "" {
+		to, _ = time.Parse(dateLayout, req.To)
+	}
+
+	from := to.AddDate(0, 0, -30)
+	if req.From != "" {
+		from, _ = time.Parse(dateLayout, req.From)
+	}
+	return from, to
+}
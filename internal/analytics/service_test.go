@@ -0,0 +1,89 @@
+package analytics
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/memory"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScopeUsage(t *testing.T) {
+	tests := []struct {
+		name   string
+		scopes []domain.TokenUsageScope
+		want   []ResponseClientScope
+	}{
+		{
+			name:   "no usage",
+			scopes: nil,
+			want:   []ResponseClientScope{},
+		},
+		{
+			name: "every scope used",
+			scopes: []domain.TokenUsageScope{
+				{ClientID: "a", Scope: "analytics:read", GrantedCalls: 10, UsedCalls: 10},
+			},
+			want: []ResponseClientScope{
+				{ClientID: "a", Scopes: []ResponseClientUsage{
+					{domain.TokenUsageScope{ClientID: "a", Scope: "analytics:read", GrantedCalls: 10, UsedCalls: 10}, false},
+				}},
+			},
+		},
+		{
+			name: "clients are grouped and flagged independently",
+			scopes: []domain.TokenUsageScope{
+				{ClientID: "a", Scope: "analytics:read", GrantedCalls: 10, UsedCalls: 4},
+				{ClientID: "a", Scope: "billing:write", GrantedCalls: 10, UsedCalls: 0},
+				{ClientID: "b", Scope: "analytics:read", GrantedCalls: 3, UsedCalls: 3},
+			},
+			want: []ResponseClientScope{
+				{ClientID: "a", OverPrivileged: true, Scopes: []ResponseClientUsage{
+					{domain.TokenUsageScope{ClientID: "a", Scope: "analytics:read", GrantedCalls: 10, UsedCalls: 4}, false},
+					{domain.TokenUsageScope{ClientID: "a", Scope: "billing:write", GrantedCalls: 10, UsedCalls: 0}, true},
+				}},
+				{ClientID: "b", Scopes: []ResponseClientUsage{
+					{domain.TokenUsageScope{ClientID: "b", Scope: "analytics:read", GrantedCalls: 3, UsedCalls: 3}, false},
+				}},
+			},
+		},
+		{
+			name: "an unused scope first still flags the client",
+			scopes: []domain.TokenUsageScope{
+				{ClientID: "a", Scope: "analytics:read", GrantedCalls: 2, UsedCalls: 0},
+				{ClientID: "a", Scope: "users:read", GrantedCalls: 2, UsedCalls: 2},
+			},
+			want: []ResponseClientScope{
+				{ClientID: "a", OverPrivileged: true, Scopes: []ResponseClientUsage{
+					{domain.TokenUsageScope{ClientID: "a", Scope: "analytics:read", GrantedCalls: 2, UsedCalls: 0}, true},
+					{domain.TokenUsageScope{ClientID: "a", Scope: "users:read", GrantedCalls: 2, UsedCalls: 2}, false},
+				}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &configs.Config{}
+			cfg.Analytics.TokenUsageSampleRate = 0.5
+			store := memory.NewStore()
+			day := time.Date(2022, 1, 15, 0, 0, 0, 0, time.UTC)
+			for _, scope := range tt.scopes {
+				scope.Day = day
+				require.NoError(t, store.GetTokenUsageRepository().IncrementScopes(context.Background(), []domain.TokenUsageScope{scope}))
+			}
+			svc := NewService(cfg, store)
+
+			res, err := svc.ScopeUsage(context.Background(), RequestReport{From: "2022-01-01", To: "2022-01-31"})
+			assert.NoError(t, err)
+			assert.Equal(t, "2022-01-01", res.From)
+			assert.Equal(t, "2022-01-31", res.To)
+			assert.Equal(t, 0.5, res.SampleRate)
+			assert.Equal(t, tt.want, res.Clients)
+		})
+	}
+}
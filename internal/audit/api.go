@@ -0,0 +1,59 @@
+package audit
+
+import (
+	"go-hex/configs"
+	"go-hex/middleware"
+	"go-hex/shared/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RegisterAPI registers a new audit api
+func RegisterAPI(r echo.Group, cfg *configs.Config, service ServicePort) {
+	handler := handler{cfg, service}
+
+	// Internal endpoints, also reachable by the service accounts holding the read scope
+	internal := r.Group("/internal/audit-events",
+		middleware.InternalAPIOrRole(cfg.InternalAPI.User, cfg.InternalAPI.Password, cfg.JWTKeys(), ScopeRead),
+	)
+	internal.GET("", handler.list)
+}
+
+type handler struct {
+	cfg     *configs.Config
+	service ServicePort
+}
+
+// list godoc
+// @Router /internal/audit-events [get]
+// @Tags Audit
+// @Summary Get the audit trail
+// @Description Get the logins, token refreshes, password resets and user updates, filtered by actor, action and time range, the newest first
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Security BearerToken
+// @Param actor_id query string false "ID of the actor, the username tried by a failed login"
+// @Param action query string false "action, e.g. auth.login_failed"
+// @Param from query string false "first time included (RFC 3339), defaults to 30 days before to"
+// @Param to query string false "last time excluded (RFC 3339), defaults to now"
+// @Param limit query int false "maximum number of events, 100 by default and up to 1000"
+// @Param offset query int false "number of newer events to skip"
+// @Success 200 {object} response.Response{data=[]domain.AuditEvent} "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 403 {object} response.ErrorResponse403
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) list(c echo.Context) error {
+	var req RequestListAuditEvents
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.List(c.Request().Context(), req)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessOK(c, response.Page{Items: res.Events, Offset: res.Offset, Limit: res.Limit, More: res.More})
+}
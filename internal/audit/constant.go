@@ -0,0 +1,32 @@
+package audit
+
+import "go-hex/internal/domain"
+
+const (
+	// ScopeRead is the role a service account needs to read the audit trail
+	ScopeRead = "audit:read"
+
+	defaultListLimit = 100
+	maxListLimit     = 1000
+	// defaultListDays is the time range listed when the request does not start it
+	defaultListDays = 30
+	// maxUserAgentLength is the size of the user agent column, the longer user agents are truncated
+	maxUserAgentLength = 512
+)
+
+// auditedActions are the domain events recorded in the audit trail
+var auditedActions = []string{
+	domain.EventLoginSucceeded,
+	domain.EventLoginFailed,
+	domain.EventTokenRefreshed,
+	domain.EventPasswordResetCompleted,
+	domain.EventUserUpdated,
+	domain.EventRoleExpired,
+	domain.EventTenantSettingsUpdated,
+	domain.EventTenantSettingsDeleted,
+}
+
+// failedActions are the audited actions whose outcome is a failure
+var failedActions = map[string]bool{
+	domain.EventLoginFailed: true,
+}
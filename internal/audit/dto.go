@@ -0,0 +1,38 @@
+package audit
+
+import (
+	"go-hex/internal/domain"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// RequestListAuditEvents request query
+type RequestListAuditEvents struct {
+	ActorID string `query:"actor_id" example:"1f7a6f2e-3c3e-4a8e-9d4b-8f5d1f2a9c10"`
+	Action  string `query:"action" example:"auth.login_failed"`
+	From    string `query:"from" example:"2026-10-01T00:00:00Z"` // RFC 3339, defaults to 30 days before to
+	To      string `query:"to" example:"2026-10-15T00:00:00Z"`   // RFC 3339, excluded, defaults to now
+	Limit   int    `query:"limit" example:"100"`
+	Offset  int    `query:"offset" example:"0"`
+}
+
+func (r *RequestListAuditEvents) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.ActorID, validation.Length(0, 255)),
+		validation.Field(&r.Action, validation.Length(0, 100)),
+		validation.Field(&r.From, validation.Date(time.RFC3339)),
+		validation.Field(&r.To, validation.Date(time.RFC3339)),
+		validation.Field(&r.Limit, validation.Min(0), validation.Max(maxListLimit)),
+		validation.Field(&r.Offset, validation.Min(0)),
+	)
+}
+
+// ResponseAuditEvents is a page of the audit trail
+type ResponseAuditEvents struct {
+	Events []domain.AuditEvent
+	Offset int
+	Limit  int
+	// More tells whether older events follow
+	More bool
+}
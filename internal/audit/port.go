@@ -0,0 +1,11 @@
+package audit
+
+import (
+	"context"
+)
+
+// ServicePort encapsulates the audit trail logic.
+type ServicePort interface {
+	// List returns the audit events filtered by actor, action and time range, the newest first
+	List(ctx context.Context, req RequestListAuditEvents) (ResponseAuditEvents, error)
+}
@@ -0,0 +1,57 @@
+package audit
+
+import (
+	"context"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/times"
+	"time"
+)
+
+// Service encapsulates the audit trail logic.
+type Service struct {
+	repoRegitry port.RepositoryRegistry
+}
+
+// NewService creates and returns a new audit service
+func NewService(repoRegitry port.RepositoryRegistry) *Service {
+	return &Service{repoRegitry}
+}
+
+// List returns the audit events of the actor and of the action, any when empty, created in the requested time range,
+// the last 30 days by default, the newest first.
+func (s *Service) List(ctx context.Context, req RequestListAuditEvents) (ResponseAuditEvents, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return ResponseAuditEvents{}, err
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultListLimit
+	}
+
+	to := times.Now()
+	if req.To != "" {
+		to, _ = time.Parse(time.RFC3339, req.To)
+	}
+	from := to.AddDate(0, 0, -defaultListDays)
+	if req.From != "" {
+		from, _ = time.Parse(time.RFC3339, req.From)
+	}
+
+	// one more event is read to know whether another page follows
+	auditEvents, err := s.repoRegitry.GetAuditRepository().List(ctx, req.ActorID, req.Action, from, to, req.Limit+1, req.Offset)
+	if err != nil {
+		return ResponseAuditEvents{}, err
+	}
+
+	res := ResponseAuditEvents{Events: auditEvents, Offset: req.Offset, Limit: req.Limit}
+	if len(auditEvents) > req.Limit {
+		res.Events = auditEvents[:req.Limit]
+		res.More = true
+	}
+	return res, nil
+}
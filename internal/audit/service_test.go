@@ -0,0 +1,73 @@
+package audit
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/memory"
+	"go-hex/pkg/times"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceList(t *testing.T) {
+
+	store := memory.NewStore()
+	now := times.Now().Truncate(time.Second)
+	require.NoError(t, store.GetAuditRepository().Record(context.Background(), []domain.AuditEvent{
+		{ID: "1", ActorID: "user-1", Action: domain.EventLoginFailed, CreatedAt: now.Add(-3 * time.Hour)},
+		{ID: "2", ActorID: "user-1", Action: domain.EventLoginFailed, CreatedAt: now.Add(-2 * time.Hour)},
+		{ID: "3", ActorID: "user-1", Action: domain.EventLoginFailed, CreatedAt: now.Add(-time.Hour)},
+		{ID: "4", ActorID: "user-2", Action: domain.EventLoginFailed, CreatedAt: now.Add(-time.Hour)},
+		{ID: "5", ActorID: "user-1", Action: domain.EventLoginFailed, CreatedAt: now.AddDate(0, 0, -defaultListDays-1)},
+	}))
+	service := NewService(store)
+
+	res, err := service.List(context.Background(), RequestListAuditEvents{
+		ActorID: "user-1",
+		Action:  domain.EventLoginFailed,
+		To:      now.Format(time.RFC3339),
+		Limit:   2,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"3", "2"}, ids(res.Events))
+	assert.True(t, res.More)
+
+	// the last days are listed by default, the newest first
+	res, err = service.List(context.Background(), RequestListAuditEvents{Offset: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2", "1"}, ids(res.Events))
+	assert.False(t, res.More)
+	assert.Equal(t, defaultListLimit, res.Limit)
+}
+
+func TestServiceListValidation(t *testing.T) {
+
+	service := NewService(memory.NewStore())
+
+	tests := []struct {
+		name string
+		req  RequestListAuditEvents
+	}{
+		{name: "malformed from", req: RequestListAuditEvents{From: "2026-10-01"}},
+		{name: "limit above the maximum", req: RequestListAuditEvents{Limit: maxListLimit + 1}},
+		{name: "negative offset", req: RequestListAuditEvents{Offset: -1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.List(context.Background(), tt.req)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func ids(auditEvents []domain.AuditEvent) []string {
+	res := []string{}
+	for _, auditEvent := range auditEvents {
+		res = append(res, auditEvent.ID)
+	}
+	return res
+}
@@ -0,0 +1,151 @@
+package audit
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/batch"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/metrics"
+	"go-hex/pkg/utils"
+	"go-hex/shared/ctxutil"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ErrWriterClosed is returned when an audit event is written after the writer was closed
+var ErrWriterClosed = errors.New("audit log writer is closed")
+
+var auditMetrics = batch.Metrics{
+	Buffered: metrics.NewGauge(prometheus.GaugeOpts{
+		Name: "audit_log_events_buffered",
+		Help: "Number of audit log events waiting to be written.",
+	}),
+	Written: metrics.NewCounter(prometheus.CounterOpts{
+		Name: "audit_log_events_written_total",
+		Help: "Number of audit log events written to the database.",
+	}),
+	Lost: metrics.NewCounterVec(prometheus.CounterOpts{
+		Name: "audit_log_events_lost_total",
+		Help: "Number of audit log events that were never written, by reason.",
+	}, "reason"),
+}
+
+// Writer records the audited actions published on the event bus in the audit trail. The events are buffered and
+// written in batches by a batch.Writer, so that auditing does not add an insert to the requests. When the buffer
+// is full the backpressure policy either blocks the publisher or drops the event, the events dropped or failing
+// to be written are counted in audit_log_events_lost_total.
+//
+// Unlike the service account audit events, the audit log is neither hash-chained nor archived: its events
+// belong to the actions of a user and are deleted per user by the cleanup, after AUDIT_LOG_RETENTION days
+// unless the user is under a legal hold, which a chain would report as tampering.
+type Writer struct {
+	log    logger.Logger
+	writer *batch.Writer[domain.AuditEvent]
+}
+
+// NewWriter creates a writer buffering up to bufferSize events until it is closed
+func NewWriter(repoRegitry port.RepositoryRegistry, log logger.Logger, bufferSize, batchSize int, flushInterval time.Duration, backpressure configs.AuditBackpressure) *Writer {
+	flush := func(ctx context.Context, auditEvents []domain.AuditEvent) error {
+		return repoRegitry.GetAuditRepository().Record(ctx, auditEvents)
+	}
+	return &Writer{
+		log: log,
+		writer: batch.NewWriter("audit_log", log, auditMetrics, bufferSize, batchSize, flushInterval,
+			backpressure == configs.AuditBackpressureDrop, flush),
+	}
+}
+
+// Subscribe records the audited actions published on the bus
+func (w *Writer) Subscribe(bus event.Bus) {
+	for _, action := range auditedActions {
+		bus.Subscribe(action, w.handle)
+	}
+}
+
+func (w *Writer) handle(ctx context.Context, e event.Event) {
+	if err := w.Write(ctx, newAuditEvent(ctx, e)); err != nil {
+		w.log.With(ctx).WithParams(logger.Params{"type": "audit_log", "action": e.Name}).Warn(err)
+	}
+}
+
+// Write queues the audit event. With the block policy it waits for room in the buffer
+// and fails when ctx is done first, with the drop policy a full buffer discards the event.
+func (w *Writer) Write(ctx context.Context, auditEvent domain.AuditEvent) error {
+	err := w.writer.Write(ctx, auditEvent)
+	if err == batch.ErrWriterClosed {
+		return ErrWriterClosed
+	}
+	return err
+}
+
+// Close stops accepting events and returns once the buffered events were written
+func (w *Writer) Close() {
+	w.writer.Close()
+}
+
+// newAuditEvent creates the audit event of the domain event. The actor is the one of the event, or else the principal
+// of the access token of the request, the internal api without one. The actor of a failed login is the username tried,
+// the one of the scheduled jobs is the scheduler.
+func newAuditEvent(ctx context.Context, e event.Event) domain.AuditEvent {
+	auditEvent := domain.AuditEvent{
+		ID:         utils.GenerateID(),
+		Action:     e.Name,
+		Outcome:    domain.AuditOutcomeSuccess,
+		ActorType:  domain.PrincipalTypeUser,
+		ActorID:    e.ActorID,
+		SubjectID:  e.SubjectID,
+		Attributes: map[string]interface{}{},
+		CreatedAt:  e.OccurredAt.Truncate(time.Second),
+	}
+	if failedActions[e.Name] {
+		auditEvent.Outcome = domain.AuditOutcomeFailure
+	}
+
+	// the address and the user agent have their own columns
+	for key, value := range e.Attributes {
+		switch key {
+		case "ip_address":
+			auditEvent.IPAddress, _ = value.(string)
+		case "user_agent":
+			auditEvent.UserAgent, _ = value.(string)
+		default:
+			auditEvent.Attributes[key] = value
+		}
+	}
+	if len(auditEvent.UserAgent) > maxUserAgentLength {
+		auditEvent.UserAgent = auditEvent.UserAgent[:maxUserAgentLength]
+	}
+
+	switch {
+	case auditEvent.ActorID == domain.ActorTypeScheduler:
+		auditEvent.ActorType = domain.ActorTypeScheduler
+	case auditEvent.ActorID != "":
+	case e.Name == domain.EventLoginFailed:
+		auditEvent.ActorType = domain.ActorTypeAnonymous
+		auditEvent.ActorID, _ = e.Attributes["username"].(string)
+	default:
+		auditEvent.ActorType, auditEvent.ActorID = principal(ctx)
+	}
+	return auditEvent
+}
+
+// principal returns the type and the ID of the principal of the access token of the request
+func principal(ctx context.Context) (string, string) {
+	token, ok := ctxutil.Principal(ctx)
+	if !ok {
+		return domain.ActorTypeInternalAPI, domain.ActorTypeInternalAPI
+	}
+	claims, _ := token.Claims.(jwt.MapClaims)
+	id, _ := claims["id"].(string)
+	principalType, _ := claims["principal_type"].(string)
+	if principalType == "" {
+		principalType = domain.PrincipalTypeUser
+	}
+	return principalType, id
+}
@@ -0,0 +1,158 @@
+package audit
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/memory"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/times"
+	"go-hex/shared/ctxutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorded returns the audit events saved in the store, the newest first
+func recorded(t *testing.T, store *memory.Store) []domain.AuditEvent {
+	t.Helper()
+	auditEvents, err := store.GetAuditRepository().List(context.Background(), "", "", time.Time{}, times.Now().Add(time.Hour), 0, 0)
+	require.NoError(t, err)
+	return auditEvents
+}
+
+func TestWriterRecordsAuditedActions(t *testing.T) {
+	store := memory.NewStore()
+	w := NewWriter(store, logger.New("test", "test"), 10, 2, time.Hour, configs.AuditBackpressureBlock)
+	bus := event.New()
+	w.Subscribe(bus)
+
+	bus.Publish(context.Background(), event.Event{Name: domain.EventLoginSucceeded, ActorID: "user-1", SubjectID: "session-1"})
+	bus.Publish(context.Background(), event.Event{Name: domain.EventTokenRefreshed, ActorID: "user-1", SubjectID: "session-1"})
+	bus.Publish(context.Background(), event.Event{Name: domain.EventUserSignedUp, ActorID: "user-1"})
+	w.Close()
+
+	actions := []string{}
+	for _, auditEvent := range recorded(t, store) {
+		actions = append(actions, auditEvent.Action)
+	}
+	assert.ElementsMatch(t, []string{domain.EventLoginSucceeded, domain.EventTokenRefreshed}, actions)
+	assert.Equal(t, ErrWriterClosed, w.Write(context.Background(), domain.AuditEvent{}))
+}
+
+func TestWriterFlushInterval(t *testing.T) {
+	store := memory.NewStore()
+	w := NewWriter(store, logger.New("test", "test"), 10, 100, 10*time.Millisecond, configs.AuditBackpressureBlock)
+	defer w.Close()
+
+	assert.NoError(t, w.Write(context.Background(), domain.AuditEvent{ID: "event"}))
+	assert.Eventually(t, func() bool { return len(recorded(t, store)) == 1 }, time.Second, 5*time.Millisecond)
+}
+
+func TestNewAuditEvent(t *testing.T) {
+
+	serviceAccount := ctxutil.WithPrincipal(context.Background(),
+		&jwt.Token{Claims: jwt.MapClaims{"id": "sa-1", "principal_type": domain.PrincipalTypeServiceAccount}})
+
+	tests := []struct {
+		name        string
+		ctx         context.Context
+		event       event.Event
+		wantOutcome string
+		wantType    string
+		wantActor   string
+	}{
+		{
+			name:        "the actor of the event",
+			ctx:         context.Background(),
+			event:       event.Event{Name: domain.EventLoginSucceeded, ActorID: "user-1"},
+			wantOutcome: domain.AuditOutcomeSuccess,
+			wantType:    domain.PrincipalTypeUser,
+			wantActor:   "user-1",
+		},
+		{
+			name:        "a failed login is anonymous",
+			ctx:         context.Background(),
+			event:       event.Event{Name: domain.EventLoginFailed, Attributes: map[string]interface{}{"username": "alice"}},
+			wantOutcome: domain.AuditOutcomeFailure,
+			wantType:    domain.ActorTypeAnonymous,
+			wantActor:   "alice",
+		},
+		{
+			name:        "the principal of the access token",
+			ctx:         serviceAccount,
+			event:       event.Event{Name: domain.EventUserUpdated, SubjectID: "user-1"},
+			wantOutcome: domain.AuditOutcomeSuccess,
+			wantType:    domain.PrincipalTypeServiceAccount,
+			wantActor:   "sa-1",
+		},
+		{
+			name:        "the internal api without access token",
+			ctx:         context.Background(),
+			event:       event.Event{Name: domain.EventUserUpdated, SubjectID: "user-1"},
+			wantOutcome: domain.AuditOutcomeSuccess,
+			wantType:    domain.ActorTypeInternalAPI,
+			wantActor:   domain.ActorTypeInternalAPI,
+		},
+		{
+			name:        "the scheduler",
+			ctx:         context.Background(),
+			event:       event.Event{Name: domain.EventRoleExpired, ActorID: domain.ActorTypeScheduler, SubjectID: "user-1"},
+			wantOutcome: domain.AuditOutcomeSuccess,
+			wantType:    domain.ActorTypeScheduler,
+			wantActor:   domain.ActorTypeScheduler,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auditEvent := newAuditEvent(tt.ctx, tt.event)
+			assert.Equal(t, tt.event.Name, auditEvent.Action)
+			assert.Equal(t, tt.wantOutcome, auditEvent.Outcome)
+			assert.Equal(t, tt.wantType, auditEvent.ActorType)
+			assert.Equal(t, tt.wantActor, auditEvent.ActorID)
+		})
+	}
+}
+
+func TestNewAuditEventRequestAttributes(t *testing.T) {
+	auditEvent := newAuditEvent(context.Background(), event.Event{
+		Name:    domain.EventLoginSucceeded,
+		ActorID: "user-1",
+		Attributes: map[string]interface{}{
+			"ip_address": "10.0.0.1",
+			"user_agent": strings.Repeat("a", maxUserAgentLength+10),
+			"username":   "alice",
+		},
+	})
+
+	assert.Equal(t, "10.0.0.1", auditEvent.IPAddress)
+	assert.Len(t, auditEvent.UserAgent, maxUserAgentLength)
+	assert.Equal(t, map[string]interface{}{"username": "alice"}, auditEvent.Attributes)
+}
+
+// TestWriterIsNotChained checks that the audit log stays out of the hash chain of the service account audit events,
+// so that deleting the events of a user does not break the chain verified by audit verify
+func TestWriterIsNotChained(t *testing.T) {
+	store := memory.NewStore()
+	repoServiceAccount := store.GetServiceAccountRepository()
+	before, err := repoServiceAccount.GetAuditChainHead(context.Background(), domain.AuditChainServiceAccounts)
+	require.NoError(t, err)
+
+	w := NewWriter(store, logger.New("test", "test"), 10, 2, time.Hour, configs.AuditBackpressureBlock)
+	assert.NoError(t, w.Write(context.Background(), domain.AuditEvent{ID: "event", Action: domain.EventLoginSucceeded, ActorID: "user-1"}))
+	w.Close()
+	require.Len(t, recorded(t, store), 1)
+
+	after, err := repoServiceAccount.GetAuditChainHead(context.Background(), domain.AuditChainServiceAccounts)
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+	chained, err := repoServiceAccount.ListChainedAuditEvents(context.Background(), 0, 0)
+	require.NoError(t, err)
+	assert.Empty(t, chained)
+}
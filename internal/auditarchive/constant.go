@@ -0,0 +1,14 @@
+package auditarchive
+
+import "time"
+
+const (
+	// exportPageSize is the number of audit events read at once by the export
+	exportPageSize = 1000
+	// closeGrace delays the export of a day after its end, so that the events still buffered by the audit writers are part of it
+	closeGrace = time.Hour
+	// archivePrefix prefixes the objects of the archives in the bucket
+	archivePrefix = "audit-archives/"
+	// contentType of the archives, gzip compressed NDJSON
+	contentType = "application/gzip"
+)
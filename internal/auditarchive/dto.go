@@ -0,0 +1,8 @@
+package auditarchive
+
+// ResponseArchive is the outcome of an archival run
+type ResponseArchive struct {
+	Days   int   `json:"days"`   // number of days exported
+	Events int   `json:"events"` // number of events exported
+	Pruned int64 `json:"pruned"` // number of events deleted from the database
+}
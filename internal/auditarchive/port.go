@@ -0,0 +1,11 @@
+package auditarchive
+
+import (
+	"context"
+)
+
+// ServicePort encapsulates the audit archival logic.
+type ServicePort interface {
+	// Archive exports the closed days of audit events to the object storage, then prunes the exported events past the retention
+	Archive(ctx context.Context) (ResponseArchive, error)
+}
@@ -0,0 +1,197 @@
+package auditarchive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/storage"
+	"go-hex/pkg/times"
+	"go-hex/shared/ierr"
+	"hash/crc32"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const day = 24 * time.Hour
+
+// Service encapsulates the audit archival logic. The audit events are exported by UTC day once the day is closed,
+// each day to its own write-once object, and only the exported days are pruned from the database.
+type Service struct {
+	cfg         *configs.Config
+	log         logger.Logger
+	repoRegitry port.RepositoryRegistry
+	storage     storage.IStorage
+}
+
+// NewService creates and returns a new audit archival service
+func NewService(cfg *configs.Config, log logger.Logger, repoRegitry port.RepositoryRegistry, storage storage.IStorage) *Service {
+	return &Service{cfg, log, repoRegitry, storage}
+}
+
+// Archive exports the closed days of audit events to the object storage, then prunes the exported events past the retention
+func (s *Service) Archive(ctx context.Context) (ResponseArchive, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	var res ResponseArchive
+	repoServiceAccount := s.repoRegitry.GetServiceAccountRepository()
+
+	next, err := s.nextDay(ctx)
+	if err != nil {
+		return res, err
+	}
+
+	closed := times.Now().UTC().Add(-closeGrace).Truncate(day)
+	for ; !next.IsZero() && next.Before(closed); next = next.Add(day) {
+		archive, err := s.export(ctx, next)
+		if err != nil {
+			return res, err
+		}
+		err = repoServiceAccount.RecordAuditArchive(ctx, archive)
+		if err != nil {
+			return res, err
+		}
+		res.Days++
+		res.Events += archive.Events
+	}
+
+	res.Pruned, err = s.prune(ctx, next)
+	if err != nil {
+		return res, err
+	}
+
+	s.log.WithParams(logger.Params{"type": "audit_archive", "archive": res}).Info("audit events archived")
+	return res, nil
+}
+
+// nextDay returns the first day to export, zero when there is no audit event at all
+func (s *Service) nextDay(ctx context.Context) (time.Time, error) {
+	repoServiceAccount := s.repoRegitry.GetServiceAccountRepository()
+
+	last, err := repoServiceAccount.GetLastAuditArchive(ctx)
+	if err == nil {
+		// the day is read back as a date, in the location of the connection
+		return time.Date(last.Day.Year(), last.Day.Month(), last.Day.Day(), 0, 0, 0, 0, time.UTC).Add(day), nil
+	}
+	if errors.Cause(err) != ierr.ErrResourceNotFound {
+		return time.Time{}, err
+	}
+
+	oldest, err := repoServiceAccount.GetOldestAuditEvent(ctx)
+	if err != nil {
+		if errors.Cause(err) == ierr.ErrResourceNotFound {
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+	return oldest.CreatedAt.UTC().Truncate(day), nil
+}
+
+// export writes the audit events of the day to the object storage as gzip compressed NDJSON.
+// The object of a day is never replaced: when a previous run wrote it without recording it, the same export is recorded.
+func (s *Service) export(ctx context.Context, from time.Time) (domain.ServiceAccountAuditArchive, error) {
+	archive := domain.ServiceAccountAuditArchive{Day: from, ArchivedAt: times.Now()}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	encoder := json.NewEncoder(zw)
+	for {
+		auditEvents, err := s.repoRegitry.GetServiceAccountRepository().ListAuditEventsCreatedBetween(ctx, from, from.Add(day), exportPageSize, archive.Events)
+		if err != nil {
+			return archive, err
+		}
+		for _, auditEvent := range auditEvents {
+			if err := encoder.Encode(auditEvent); err != nil {
+				return archive, errors.Wrap(err, "cannot encode audit event")
+			}
+		}
+		archive.Events += len(auditEvents)
+		if len(auditEvents) < exportPageSize {
+			break
+		}
+	}
+	if archive.Events == 0 {
+		return archive, nil
+	}
+	if err := zw.Close(); err != nil {
+		return archive, errors.Wrap(err, "cannot compress audit events")
+	}
+
+	sum := sha256.Sum256(buf.Bytes())
+	archive.Checksum = hex.EncodeToString(sum[:])
+	archive.Object = fmt.Sprintf("%s%s/%s.ndjson.gz", archivePrefix, domain.AuditChainServiceAccounts, from.Format("2006/01/02"))
+
+	err := s.storage.Put(ctx, s.cfg.Audit.ArchiveBucket, archive.Object, bytes.NewReader(buf.Bytes()), storage.PutOptions{
+		ContentType: contentType,
+		Metadata: map[string]string{
+			"sha256": archive.Checksum,
+			"events": strconv.Itoa(archive.Events),
+		},
+		CreateOnly:     true,
+		TemporaryHold:  s.cfg.Audit.ArchiveHold == configs.AuditArchiveHoldTemporary,
+		EventBasedHold: s.cfg.Audit.ArchiveHold == configs.AuditArchiveHoldEventBased,
+		CRC32C:         crc32.Checksum(buf.Bytes(), crc32.MakeTable(crc32.Castagnoli)),
+	})
+	if err != nil && err != storage.ErrObjectExists {
+		return archive, err
+	}
+	return archive, nil
+}
+
+// prune deletes the audit events older than the retention, among the days exported before the given one, except
+// the ones of the users under legal hold, deleted by the first pruning after the hold is released.
+// The chain head keeps the position and the hash of the last pruned event, so that the rest of the chain can still be verified:
+// the held events left before it are ignored by the verification, their archives verify them.
+func (s *Service) prune(ctx context.Context, exportedBefore time.Time) (int64, error) {
+	if s.cfg.Audit.ArchiveRetention <= 0 || exportedBefore.IsZero() {
+		return 0, nil
+	}
+
+	before := times.Now().UTC().Truncate(day).Add(-time.Duration(s.cfg.Audit.ArchiveRetention) * day)
+	if exportedBefore.Before(before) {
+		before = exportedBefore
+	}
+
+	heldUserIDs, err := s.repoRegitry.GetLegalHoldRepository().ListHeldUserIDs(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	pruned, err := s.repoRegitry.DoInTransaction(ctx, func(ctx context.Context, repoRegistry port.RepositoryRegistry) (interface{}, error) {
+		repoServiceAccount := repoRegistry.GetServiceAccountRepository()
+		head, err := repoServiceAccount.GetAuditChainHead(ctx, domain.AuditChainServiceAccounts)
+		if err != nil {
+			return nil, err
+		}
+
+		last, err := repoServiceAccount.GetLastAuditEventBefore(ctx, before)
+		if err != nil && errors.Cause(err) != ierr.ErrResourceNotFound {
+			return nil, err
+		}
+		if err == nil {
+			head.PrunedSeq, head.PrunedHash = *last.Seq, last.Hash
+		}
+
+		deleted, err := repoServiceAccount.DeleteAuditEvents(ctx, head.PrunedSeq, before, heldUserIDs)
+		if err != nil {
+			return nil, err
+		}
+		return deleted, repoServiceAccount.UpdateAuditChainHead(ctx, head)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return pruned.(int64), nil
+}
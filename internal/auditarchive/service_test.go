@@ -0,0 +1,175 @@
+package auditarchive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"go-hex/configs"
+	"go-hex/internal/auditchain"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/memory"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/storage"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newStore returns a store holding the chain of the audit events
+func newStore(t *testing.T, auditEvents []domain.ServiceAccountAuditEvent) *memory.Store {
+	t.Helper()
+	head := domain.AuditChainHead{Name: domain.AuditChainServiceAccounts}
+	require.NoError(t, head.Chain(auditEvents))
+	store := memory.NewStore()
+	repo := store.GetServiceAccountRepository()
+	require.NoError(t, repo.RecordAuditEvents(context.Background(), auditEvents))
+	require.NoError(t, repo.UpdateAuditChainHead(context.Background(), head))
+	return store
+}
+
+type fakeStorage struct {
+	storage.IStorage
+	objects map[string][]byte
+	options map[string]storage.PutOptions
+}
+
+func (s *fakeStorage) Put(ctx context.Context, bucket string, object string, file io.Reader, opts storage.PutOptions) error {
+	if _, ok := s.objects[object]; ok {
+		return storage.ErrObjectExists
+	}
+	b, err := ioutil.ReadAll(file)
+	if err != nil {
+		return err
+	}
+	s.objects[object], s.options[object] = b, opts
+	return nil
+}
+
+func TestArchive(t *testing.T) {
+	now := time.Now().UTC()
+	store := newStore(t, []domain.ServiceAccountAuditEvent{
+		{ID: "e1", CreatedAt: now.Add(-72 * time.Hour)},
+		{ID: "e2", CreatedAt: now.Add(-72 * time.Hour)},
+		{ID: "e3", CreatedAt: now.Add(-48 * time.Hour)},
+		{ID: "e4", CreatedAt: now},
+	})
+	objects := &fakeStorage{objects: map[string][]byte{}, options: map[string]storage.PutOptions{}}
+
+	cfg := &configs.Config{}
+	cfg.Audit.ArchiveRetention = 1
+	cfg.Audit.ArchiveHold = configs.AuditArchiveHoldTemporary
+	svc := NewService(cfg, logger.New("test", "test"), store, objects)
+
+	res, err := svc.Archive(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, res.Events)
+	assert.Equal(t, int64(3), res.Pruned)
+	assert.Len(t, objects.objects, 2)
+
+	// the days without events are recorded without an object
+	repo := store.GetServiceAccountRepository()
+	archive, err := repo.GetLastAuditArchive(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, archive.Events)
+	assert.Empty(t, archive.Object)
+
+	lines := 0
+	for object, opts := range objects.options {
+		assert.True(t, opts.TemporaryHold)
+		assert.True(t, opts.CreateOnly)
+		sum := sha256.Sum256(objects.objects[object])
+		assert.Equal(t, hex.EncodeToString(sum[:]), opts.Metadata["sha256"])
+		lines += countLines(t, objects.objects[object])
+	}
+	assert.Equal(t, 3, lines)
+
+	// the chain kept in the database starts after the pruned events
+	head, err := repo.GetAuditChainHead(context.Background(), domain.AuditChainServiceAccounts)
+	require.NoError(t, err)
+	kept, err := repo.ListChainedAuditEvents(context.Background(), 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), head.PrunedSeq)
+	if assert.Len(t, kept, 1) {
+		assert.Equal(t, kept[0].PrevHash, head.PrunedHash)
+	}
+
+	// the days already exported are not exported again
+	res, err = svc.Archive(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, res.Events)
+	assert.Equal(t, int64(0), res.Pruned)
+}
+
+func TestArchiveRecordsUnrecordedExport(t *testing.T) {
+	createdAt := time.Now().UTC().Truncate(day).Add(-time.Hour)
+	store := newStore(t, []domain.ServiceAccountAuditEvent{{ID: "e1", CreatedAt: createdAt}})
+	objects := &fakeStorage{objects: map[string][]byte{}, options: map[string]storage.PutOptions{}}
+	svc := NewService(&configs.Config{}, logger.New("test", "test"), store, objects)
+
+	// a previous run wrote the object but failed before recording it
+	archive, err := svc.export(context.Background(), createdAt.UTC().Truncate(day))
+	assert.NoError(t, err)
+
+	res, err := svc.Archive(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, res.Events)
+	recorded, err := store.GetServiceAccountRepository().GetLastAuditArchive(context.Background())
+	if assert.NoError(t, err) {
+		assert.Equal(t, archive.Checksum, recorded.Checksum)
+	}
+}
+
+func TestArchiveKeepsHeldEvents(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	store := newStore(t, []domain.ServiceAccountAuditEvent{
+		{ID: "e1", ActorID: "held", CreatedAt: now.Add(-72 * time.Hour)},
+		{ID: "e2", ActorID: "admin", CreatedAt: now.Add(-72 * time.Hour)},
+		{ID: "e3", ActorID: "admin", SubjectID: "held", CreatedAt: now.Add(-48 * time.Hour)},
+		{ID: "e4", ActorID: "held", CreatedAt: now},
+	})
+	require.NoError(t, store.GetLegalHoldRepository().Create(ctx, domain.LegalHold{ID: "hold", UserID: "held", PlacedAt: now}))
+	objects := &fakeStorage{objects: map[string][]byte{}, options: map[string]storage.PutOptions{}}
+
+	cfg := &configs.Config{}
+	cfg.Audit.ArchiveRetention = 1
+	svc := NewService(cfg, logger.New("test", "test"), store, objects)
+
+	res, err := svc.Archive(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, res.Events)
+	assert.Equal(t, int64(1), res.Pruned)
+
+	// the held events are kept before the pruned position, the chain still verifies
+	kept, err := store.GetServiceAccountRepository().ListChainedAuditEvents(ctx, 0, 0)
+	require.NoError(t, err)
+	ids := []string{}
+	for _, auditEvent := range kept {
+		ids = append(ids, auditEvent.ID)
+	}
+	assert.Equal(t, []string{"e1", "e3", "e4"}, ids)
+	verification, err := auditchain.NewService(cfg, logger.New("test", "test"), store, nil).Verify(ctx, nil)
+	require.NoError(t, err)
+	assert.True(t, verification.Valid)
+	assert.Equal(t, int64(3), verification.Pruned)
+}
+
+func countLines(t *testing.T, object []byte) int {
+	zr, err := gzip.NewReader(bytes.NewReader(object))
+	if !assert.NoError(t, err) {
+		return 0
+	}
+	lines := 0
+	scanner := bufio.NewScanner(zr)
+	for scanner.Scan() {
+		lines++
+	}
+	return lines
+}
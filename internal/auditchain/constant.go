@@ -0,0 +1,17 @@
+package auditchain
+
+const (
+	// verifyPageSize is the number of audit events read at once by the verification
+	verifyPageSize = 1000
+	// anchorPrefix prefixes the objects of the anchors in the bucket
+	anchorPrefix = "audit-anchors/"
+)
+
+// Reasons of a broken chain
+const (
+	reasonMissingEvent  = "missing_event"
+	reasonPrevHash      = "prev_hash_mismatch"
+	reasonAlteredEvent  = "altered_event"
+	reasonAnchor        = "anchor_mismatch"
+	reasonTruncatedTail = "truncated_tail"
+)
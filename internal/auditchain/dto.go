@@ -0,0 +1,13 @@
+package auditchain
+
+// ResponseVerification is the outcome of the verification of an audit chain
+type ResponseVerification struct {
+	Chain    string `json:"chain"`
+	Valid    bool   `json:"valid"`
+	Pruned   int64  `json:"pruned"`              // number of events archived then pruned, left out of the verification
+	Events   int64  `json:"events"`              // position of the last event verified
+	Hash     string `json:"hash"`                // hash of the last event verified
+	Anchors  int    `json:"anchors"`             // number of anchors checked against the chain
+	BrokenAt int64  `json:"broken_at,omitempty"` // position of the first event failing the verification
+	Reason   string `json:"reason,omitempty"`
+}
@@ -0,0 +1,14 @@
+package auditchain
+
+import (
+	"context"
+	"go-hex/internal/domain"
+)
+
+// ServicePort encapsulates the audit chain logic.
+type ServicePort interface {
+	// Anchor copies the head of the audit chain to the object storage
+	Anchor(ctx context.Context) (domain.AuditChainAnchor, error)
+	// Verify computes the hashes of the audit chain again and checks them against its head and the given anchors
+	Verify(ctx context.Context, anchors []domain.AuditChainAnchor) (ResponseVerification, error)
+}
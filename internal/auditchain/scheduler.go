@@ -0,0 +1,27 @@
+package auditchain
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/pkg/logger"
+	"sync"
+
+	"github.com/go-co-op/gocron"
+)
+
+// RegisterScheduler schedules the anchoring of the audit chain with the configured cron pattern.
+func RegisterScheduler(cfg *configs.Config, log logger.Logger, service ServicePort, cron *gocron.Scheduler, wg *sync.WaitGroup) {
+
+	_, err := cron.Cron(cfg.Scheduler.AuditAnchorPattern).SingletonMode().Do(func() {
+		wg.Add(1)
+		defer wg.Done()
+
+		_, err := service.Anchor(context.Background())
+		if err != nil {
+			log.WithStack(err).Error(err)
+		}
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,144 @@
+package auditchain
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/storage"
+	"go-hex/pkg/times"
+
+	"github.com/pkg/errors"
+)
+
+// Service encapsulates the audit chain logic. The chain is written by the audit writer of the service accounts,
+// the service only verifies it and anchors it to the object storage.
+type Service struct {
+	cfg         *configs.Config
+	log         logger.Logger
+	repoRegitry port.RepositoryRegistry
+	storage     storage.IStorage
+}
+
+// NewService creates and returns a new audit chain service, the storage is only required to anchor the chain
+func NewService(cfg *configs.Config, log logger.Logger, repoRegitry port.RepositoryRegistry, storage storage.IStorage) *Service {
+	return &Service{cfg, log, repoRegitry, storage}
+}
+
+// Anchor copies the head of the audit chain to the object storage.
+// The anchors are never overwritten, each one is written to a new object.
+func (s *Service) Anchor(ctx context.Context) (domain.AuditChainAnchor, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	head, err := s.repoRegitry.GetServiceAccountRepository().GetAuditChainHead(ctx, domain.AuditChainServiceAccounts)
+	if err != nil {
+		return domain.AuditChainAnchor{}, err
+	}
+
+	anchor := domain.AuditChainAnchor{
+		Name:       head.Name,
+		Seq:        head.Seq,
+		Hash:       head.Hash,
+		AnchoredAt: times.Now().UTC(),
+	}
+	b, err := json.Marshal(anchor)
+	if err != nil {
+		return domain.AuditChainAnchor{}, errors.Wrap(err, "cannot encode audit chain anchor")
+	}
+
+	object := fmt.Sprintf("%s%s/%020d-%s.json", anchorPrefix, anchor.Name, anchor.Seq, anchor.AnchoredAt.Format("20060102T150405Z"))
+	err = s.storage.Put(ctx, s.cfg.Audit.AnchorBucket, object, bytes.NewReader(b), storage.PutOptions{ContentType: "application/json", CreateOnly: true})
+	if err != nil {
+		return domain.AuditChainAnchor{}, err
+	}
+
+	s.log.WithParams(logger.Params{"type": "audit_chain", "anchor": anchor, "object": object}).Info("audit chain anchored")
+	return anchor, nil
+}
+
+// Verify computes the hashes of the audit chain again and checks them against its head and the given anchors.
+// The events recorded after the head was read are left to the next verification.
+func (s *Service) Verify(ctx context.Context, anchors []domain.AuditChainAnchor) (ResponseVerification, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	repoServiceAccount := s.repoRegitry.GetServiceAccountRepository()
+	head, err := repoServiceAccount.GetAuditChainHead(ctx, domain.AuditChainServiceAccounts)
+	if err != nil {
+		return ResponseVerification{}, err
+	}
+
+	anchored := map[int64]string{}
+	for _, anchor := range anchors {
+		if anchor.Name == head.Name {
+			anchored[anchor.Seq] = anchor.Hash
+		}
+	}
+
+	// the pruned events are verified against their archives, the chain kept in the database starts after them
+	res := ResponseVerification{Chain: head.Name, Pruned: head.PrunedSeq, Events: head.PrunedSeq, Hash: head.PrunedHash}
+	broken := func(seq int64, reason string) (ResponseVerification, error) {
+		res.BrokenAt, res.Reason = seq, reason
+		s.log.WithParams(logger.Params{"type": "audit_chain", "verification": res}).Error("audit chain is broken")
+		return res, nil
+	}
+
+	for res.Events < head.Seq {
+		auditEvents, err := repoServiceAccount.ListChainedAuditEvents(ctx, res.Events, verifyPageSize)
+		if err != nil {
+			return ResponseVerification{}, err
+		}
+		if len(auditEvents) == 0 {
+			break
+		}
+
+		for _, auditEvent := range auditEvents {
+			seq := *auditEvent.Seq
+			if seq > head.Seq {
+				break
+			}
+			if seq != res.Events+1 {
+				return broken(res.Events+1, reasonMissingEvent)
+			}
+			if auditEvent.PrevHash != res.Hash {
+				return broken(seq, reasonPrevHash)
+			}
+			hash, err := auditEvent.ComputeHash()
+			if err != nil {
+				return ResponseVerification{}, err
+			}
+			if hash != auditEvent.Hash {
+				return broken(seq, reasonAlteredEvent)
+			}
+			if anchorHash, ok := anchored[seq]; ok {
+				if anchorHash != hash {
+					return broken(seq, reasonAnchor)
+				}
+				res.Anchors++
+			}
+			res.Events, res.Hash = seq, hash
+		}
+	}
+
+	if res.Events != head.Seq || res.Hash != head.Hash {
+		return broken(res.Events+1, reasonTruncatedTail)
+	}
+	// an anchor beyond the head means that the newest events were deleted together with the head
+	for seq := range anchored {
+		if seq > head.Seq {
+			return broken(seq, reasonAnchor)
+		}
+	}
+
+	res.Valid = true
+	s.log.WithParams(logger.Params{"type": "audit_chain", "verification": res}).Info("audit chain verified")
+	return res, nil
+}
@@ -0,0 +1,137 @@
+package auditchain
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/memory"
+	"go-hex/pkg/logger"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// chain is a chain of audit events, tampered with before being saved
+type chain struct {
+	head        domain.AuditChainHead
+	auditEvents []domain.ServiceAccountAuditEvent
+}
+
+// newChain returns a chain of n events
+func newChain(t *testing.T, n int) *chain {
+	c := &chain{head: domain.AuditChainHead{Name: domain.AuditChainServiceAccounts}}
+	for i := 0; i < n; i++ {
+		c.auditEvents = append(c.auditEvents, domain.ServiceAccountAuditEvent{
+			ID:         string(rune('a' + i)),
+			Event:      domain.EventServiceAccountTokenIssued,
+			Attributes: map[string]interface{}{"jti": float64(i)},
+			CreatedAt:  time.Now(),
+		})
+	}
+	assert.NoError(t, c.head.Chain(c.auditEvents))
+	return c
+}
+
+// save returns a store holding the chain
+func (c *chain) save(t *testing.T) *memory.Store {
+	store := memory.NewStore()
+	repo := store.GetServiceAccountRepository()
+	require.NoError(t, repo.RecordAuditEvents(context.Background(), c.auditEvents))
+	require.NoError(t, repo.UpdateAuditChainHead(context.Background(), c.head))
+	return store
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name       string
+		tamper     func(c *chain)
+		anchors    func(c *chain) []domain.AuditChainAnchor
+		wantReason string
+		wantAt     int64
+	}{
+		{name: "intact"},
+		{
+			name: "intact with anchor",
+			anchors: func(c *chain) []domain.AuditChainAnchor {
+				return []domain.AuditChainAnchor{anchorOf(c, 2)}
+			},
+		},
+		{
+			name: "pruned events",
+			tamper: func(c *chain) {
+				c.head.PrunedSeq, c.head.PrunedHash = 1, c.auditEvents[0].Hash
+				c.auditEvents = c.auditEvents[1:]
+			},
+		},
+		{
+			name:       "altered event",
+			tamper:     func(c *chain) { c.auditEvents[1].ActorID = "someone else" },
+			wantReason: reasonAlteredEvent, wantAt: 2,
+		},
+		{
+			name: "deleted event",
+			tamper: func(c *chain) {
+				c.auditEvents = append(c.auditEvents[:1], c.auditEvents[2:]...)
+			},
+			wantReason: reasonMissingEvent, wantAt: 2,
+		},
+		{
+			name: "rehashed event",
+			tamper: func(c *chain) {
+				c.auditEvents[1].ActorID = "someone else"
+				c.auditEvents[1].Hash, _ = c.auditEvents[1].ComputeHash()
+			},
+			wantReason: reasonPrevHash, wantAt: 3,
+		},
+		{
+			name: "truncated tail",
+			tamper: func(c *chain) {
+				c.auditEvents = c.auditEvents[:2]
+			},
+			wantReason: reasonTruncatedTail, wantAt: 3,
+		},
+		{
+			name: "rewritten chain",
+			tamper: func(c *chain) {
+				c.auditEvents[0].ActorID = "someone else"
+				c.head = domain.AuditChainHead{Name: domain.AuditChainServiceAccounts}
+				assert.NoError(t, c.head.Chain(c.auditEvents))
+			},
+			anchors: func(c *chain) []domain.AuditChainAnchor {
+				return []domain.AuditChainAnchor{anchorOf(c, 2)}
+			},
+			wantReason: reasonAnchor, wantAt: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newChain(t, 3)
+			var anchors []domain.AuditChainAnchor
+			if tt.anchors != nil {
+				anchors = tt.anchors(c)
+			}
+			if tt.tamper != nil {
+				tt.tamper(c)
+			}
+
+			svc := NewService(&configs.Config{}, logger.New("test", "test"), c.save(t), nil)
+			res, err := svc.Verify(context.Background(), anchors)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantReason == "", res.Valid)
+			assert.Equal(t, tt.wantReason, res.Reason)
+			assert.Equal(t, tt.wantAt, res.BrokenAt)
+			if res.Valid {
+				assert.Equal(t, int64(3), res.Events)
+				assert.Equal(t, len(anchors), res.Anchors)
+			}
+		})
+	}
+}
+
+func anchorOf(c *chain, seq int64) domain.AuditChainAnchor {
+	return domain.AuditChainAnchor{Name: c.head.Name, Seq: seq, Hash: c.auditEvents[seq-1].Hash}
+}
@@ -2,8 +2,10 @@ package auth
 
 import (
 	"go-hex/configs"
+	"go-hex/middleware"
 	"go-hex/shared/ierr"
 	"go-hex/shared/response"
+	"net/http"
 
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
@@ -13,8 +15,23 @@ import (
 func RegisterAPI(r echo.Group, cfg *configs.Config, service ServicePort) {
 	handler := handler{cfg, service}
 
-	r.POST("/auth/login", handler.login)
+	r.POST("/auth/login", handler.login, middleware.MinDuration(cfg.EnumerationMinDuration()))
+	r.POST("/auth/social/:provider", handler.socialLogin)
+	r.POST("/auth/social/:provider/link", handler.linkSocialAccount, middleware.MustLoggedIn(cfg.JWTKeys()))
 	r.POST("/auth/token/refresh", handler.refreshToken)
+	r.POST("/auth/password/forgot", handler.forgotPassword, middleware.RateLimit(cfg.PasswordReset.RateLimit), middleware.MinDuration(cfg.EnumerationMinDuration()))
+	r.POST("/auth/password/reset", handler.resetPassword)
+	r.POST("/auth/logout", handler.logout, middleware.MustLoggedIn(cfg.JWTKeys()))
+	r.GET("/auth/sessions", handler.listSessions, middleware.MustLoggedIn(cfg.JWTKeys()))
+	r.DELETE("/auth/sessions/:id", handler.revokeSession, middleware.MustLoggedIn(cfg.JWTKeys()))
+	r.GET("/auth/approvals", handler.listLoginApprovals, middleware.MustLoggedIn(cfg.JWTKeys()))
+	r.POST("/auth/approvals/:id/decision", handler.decideLoginApproval, middleware.MustLoggedIn(cfg.JWTKeys()))
+	r.POST("/auth/approvals/:id/token", handler.exchangeLoginApproval)
+	r.POST("/device-login/start", handler.startDeviceLogin, middleware.RateLimit(cfg.DeviceLogin.StartRateLimit))
+	r.POST("/device-login/decision", handler.decideDeviceLogin, middleware.MustLoggedIn(cfg.JWTKeys()))
+	r.POST("/device-login/poll", handler.pollDeviceLogin)
+	r.POST("/auth/backchannel-logout", handler.backchannelLogout)
+	r.POST("/internal/tokens/introspect", handler.introspect, middleware.InternalAPI(cfg.InternalAPI.User, cfg.InternalAPI.Password))
 }
 
 type handler struct {
@@ -31,8 +48,12 @@ type handler struct {
 // @Produce json
 // @Param payload body RequestLogin false " "
 // @Success 200 {object} response.Response{data=ResponseLogin} "Success"
+// @Success 202 {object} response.Response{data=ResponseLoginApproval} "Login approval required"
 // @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 403 {object} response.ErrorResponse403
 // @failure 500 {object} response.ErrorResponse500
+// @failure 503 {object} response.ErrorResponse503
 func (h handler) login(c echo.Context) error {
 
 	ctx := c.Request().Context()
@@ -41,21 +62,123 @@ func (h handler) login(c echo.Context) error {
 	if err != nil {
 		return response.ErrBadRequest(err)
 	}
+	req.IPAddress = c.RealIP()
+	req.UserAgent = c.Request().UserAgent()
+	req.Client = middleware.ClientInfo(c.Request())
 
 	resp, err := h.service.Login(ctx, req)
 	if err != nil {
 		switch errors.Cause(err) {
-		case ierr.ErrUserAlreadyRegistered, ierr.ErrUserIsNotActive:
+		case ierr.ErrUserAlreadyRegistered:
 			return response.ErrBadRequest(err)
 		case ierr.ErrInvalidCreds:
 			return response.ErrUnauthorized(err)
+		case ierr.ErrUserIsNotActive, ierr.ErrSessionLimitReached:
+			return response.ErrForbidden(err)
+		}
+		return err
+	}
+
+	if resp.Approval != nil {
+		return response.Success(c, http.StatusAccepted, resp.Approval, "login approval required")
+	}
+
+	return response.SuccessOK(c, resp, "user authenticated")
+}
+
+// socialLogin godoc
+// @Router /auth/social/{provider} [post]
+// @Tags Auth
+// @Summary Login with a social login provider
+// @Description Exchange the authorization code of Google, GitHub or the OpenID provider, the account is linked to a
+// @Description user with the same email address verified by both the provider and the user, or provisioned, on its first login.
+// @Description The accounts of the users who did not verify their address are linked by POST /auth/social/{provider}/link.
+// @Accept json
+// @Produce json
+// @Param provider path string true "google, github or oidc"
+// @Param payload body RequestSocialLogin false " "
+// @Success 200 {object} response.Response{data=ResponseLogin} "Success"
+// @Success 202 {object} response.Response{data=ResponseLoginApproval} "Login approval required"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 403 {object} response.ErrorResponse403
+// @failure 404 {object} response.ErrorResponse404
+// @failure 500 {object} response.ErrorResponse500
+// @failure 503 {object} response.ErrorResponse503
+func (h handler) socialLogin(c echo.Context) error {
+
+	ctx := c.Request().Context()
+	req := RequestSocialLogin{}
+	err := c.Bind(&req)
+	if err != nil {
+		return response.ErrBadRequest(err)
+	}
+	req.IPAddress = c.RealIP()
+	req.UserAgent = c.Request().UserAgent()
+	req.Client = middleware.ClientInfo(c.Request())
+
+	resp, err := h.service.LoginWithProvider(ctx, req)
+	if err != nil {
+		switch errors.Cause(err) {
+		case ierr.ErrSocialProviderUnknown:
+			return response.ErrNotFound(err)
+		case ierr.ErrUserAlreadyRegistered, ierr.ErrRedirectNotAllowed:
+			return response.ErrBadRequest(err)
+		case ierr.ErrInvalidToken, ierr.ErrInvalidCreds, ierr.ErrSocialAccountUnlinked:
+			return response.ErrUnauthorized(err)
+		case ierr.ErrUserIsNotActive, ierr.ErrSessionLimitReached:
+			return response.ErrForbidden(err)
 		}
 		return err
 	}
 
+	if resp.Approval != nil {
+		return response.Success(c, http.StatusAccepted, resp.Approval, "login approval required")
+	}
+
 	return response.SuccessOK(c, resp, "user authenticated")
 }
 
+// linkSocialAccount godoc
+// @Router /auth/social/{provider}/link [post]
+// @Tags Auth
+// @Summary Link a social login account
+// @Description Exchange the authorization code of Google, GitHub or the OpenID provider and link the account to the logged in user,
+// @Description who then logs in with the provider.
+// @Accept json
+// @Produce json
+// @Security BearerToken
+// @Param provider path string true "google, github or oidc"
+// @Param payload body RequestSocialLogin false " "
+// @Success 200 {object} response.Response "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 404 {object} response.ErrorResponse404
+// @failure 409 {object} response.ErrorResponse409
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) linkSocialAccount(c echo.Context) error {
+	req := RequestSocialLogin{}
+	err := c.Bind(&req)
+	if err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	err = h.service.LinkProvider(c.Request().Context(), req)
+	if err != nil {
+		switch errors.Cause(err) {
+		case ierr.ErrSocialProviderUnknown:
+			return response.ErrNotFound(err)
+		case ierr.ErrInvalidToken, ierr.ErrRedirectNotAllowed:
+			return response.ErrBadRequest(err)
+		case ierr.ErrConflict:
+			return response.HTTPError(err, http.StatusConflict, ierr.ErrConflict.Code, "social account is already linked to a user")
+		}
+		return err
+	}
+
+	return response.SuccessOK(c, nil, "social account linked")
+}
+
 // refreshToken godoc
 // @Router /auth/token/refresh [post]
 // @Tags Auth
@@ -68,6 +191,7 @@ func (h handler) login(c echo.Context) error {
 // @failure 400 {object} response.ErrorResponse400
 // @failure 403 {object} response.ErrorResponse403
 // @failure 500 {object} response.ErrorResponse500
+// @failure 503 {object} response.ErrorResponse503
 func (h handler) refreshToken(c echo.Context) error {
 	var req RequestRefreshToken
 	if err := c.Bind(&req); err != nil {
@@ -87,3 +211,396 @@ func (h handler) refreshToken(c echo.Context) error {
 
 	return response.SuccessOK(c, res, "token refreshed")
 }
+
+// forgotPassword godoc
+// @Router /auth/password/forgot [post]
+// @Tags Auth
+// @Summary Forgot password
+// @Description Send a one-time token setting a new password to the user by email or by sms
+// @Accept json
+// @Produce json
+// @Param payload body RequestForgotPassword false " "
+// @Success 200 {object} response.Response "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 404 {object} response.ErrorResponse404
+// @failure 429 {object} response.ErrorResponse429
+// @failure 500 {object} response.ErrorResponse500
+// @failure 503 {object} response.ErrorResponse503
+func (h handler) forgotPassword(c echo.Context) error {
+	var req RequestForgotPassword
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+	req.IPAddress = c.RealIP()
+	req.UserAgent = c.Request().UserAgent()
+
+	err := h.service.ForgotPassword(c.Request().Context(), req)
+	if err != nil {
+		switch errors.Cause(err) {
+		case ierr.ErrUserIsNotActive, ierr.ErrPasswordResetNoAddress, ierr.ErrRedirectNotAllowed:
+			return response.ErrBadRequest(err)
+		case ierr.ErrResourceNotFound:
+			return response.ErrNotFound(err)
+		}
+		return err
+	}
+
+	return response.SuccessOK(c, nil, "password reset sent")
+}
+
+// resetPassword godoc
+// @Router /auth/password/reset [post]
+// @Tags Auth
+// @Summary Reset password
+// @Description Set a new password with a token sent by the forgot password, every session of the user is revoked
+// @Accept json
+// @Produce json
+// @Param payload body RequestResetPassword false " "
+// @Success 200 {object} response.Response "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 403 {object} response.ErrorResponse403
+// @failure 500 {object} response.ErrorResponse500
+// @failure 503 {object} response.ErrorResponse503
+func (h handler) resetPassword(c echo.Context) error {
+	var req RequestResetPassword
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	err := h.service.ResetPassword(c.Request().Context(), req)
+	if err != nil {
+		switch errors.Cause(err) {
+		case ierr.ErrInvalidToken, ierr.ErrPasswordTooShort, ierr.ErrPasswordTooSimple, ierr.ErrPasswordTooCommon, ierr.ErrPasswordContainsUsername:
+			return response.ErrBadRequest(err)
+		case ierr.ErrExpiredToken:
+			return response.ErrForbidden(err)
+		}
+		return err
+	}
+
+	return response.SuccessOK(c, nil, "password reset")
+}
+
+// logout godoc
+// @Router /auth/logout [post]
+// @Tags Auth
+// @Summary Logout
+// @Description Revoke the current session and notify the registered clients
+// @Accept json
+// @Produce json
+// @Security BearerToken
+// @Success 200 {object} response.Response "Logout success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) logout(c echo.Context) error {
+	err := h.service.Logout(c.Request().Context())
+	if err != nil {
+		switch errors.Cause(err) {
+		case ierr.ErrInvalidToken:
+			return response.ErrBadRequest(err)
+		}
+		return err
+	}
+
+	return response.SuccessOK(c, nil, "user logged out")
+}
+
+// listSessions godoc
+// @Router /auth/sessions [get]
+// @Tags Auth
+// @Summary List sessions
+// @Description List the active sessions of the user from the oldest, one per device it logged in on
+// @Accept json
+// @Produce json
+// @Security BearerToken
+// @Success 200 {object} response.Response{data=[]ResponseSession} "Success"
+// @failure 401 {object} response.ErrorResponse401
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) listSessions(c echo.Context) error {
+	res, err := h.service.ListSessions(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessOK(c, res)
+}
+
+// revokeSession godoc
+// @Router /auth/sessions/{id} [delete]
+// @Tags Auth
+// @Summary Revoke a session
+// @Description Revoke a session of the user, e.g. of a lost device, and notify the registered clients. Revoking the current session logs the user out.
+// @Accept json
+// @Produce json
+// @Security BearerToken
+// @Param id path string true "Session ID"
+// @Success 200 {object} response.Response "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 404 {object} response.ErrorResponse404
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) revokeSession(c echo.Context) error {
+	var req RequestRevokeSession
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	err := h.service.RevokeSession(c.Request().Context(), req)
+	if err != nil {
+		switch errors.Cause(err) {
+		case ierr.ErrInvalidToken:
+			return response.ErrBadRequest(err)
+		case ierr.ErrResourceNotFound:
+			return response.ErrNotFound(err)
+		}
+		return err
+	}
+
+	return response.SuccessOK(c, nil, "session revoked")
+}
+
+// introspect godoc
+// @Router /internal/tokens/introspect [post]
+// @Tags Auth
+// @Summary Introspect an access token
+// @Description Tell whether an access token, opaque or signed, is active and return its claims, for the services receiving the opaque tokens. An expired or revoked token is answered with active false.
+// @Accept json,x-www-form-urlencoded
+// @Produce json
+// @Security BasicAuth
+// @Param payload body RequestIntrospect false " "
+// @Success 200 {object} response.Response{data=ResponseIntrospect} "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) introspect(c echo.Context) error {
+	var req RequestIntrospect
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.Introspect(c.Request().Context(), req)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessOK(c, res)
+}
+
+// backchannelLogout godoc
+// @Router /auth/backchannel-logout [post]
+// @Tags Auth
+// @Summary OpenID Connect backchannel logout
+// @Description Receive a logout token from the upstream OpenID provider and revoke the corresponding sessions
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param logout_token formData string true "Logout token"
+// @Success 200 {object} response.Response "Logout success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) backchannelLogout(c echo.Context) error {
+	var req RequestBackchannelLogout
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	// logout responses must not be cached by the provider
+	c.Response().Header().Set("Cache-Control", "no-store")
+
+	err := h.service.BackchannelLogout(c.Request().Context(), req)
+	if err != nil {
+		switch errors.Cause(err) {
+		case ierr.ErrInvalidToken, ierr.ErrExpiredToken:
+			return response.ErrBadRequest(err)
+		}
+		return err
+	}
+
+	return response.SuccessOK(c, nil, "sessions logged out")
+}
+
+// listLoginApprovals godoc
+// @Router /auth/approvals [get]
+// @Tags Auth
+// @Summary List pending login approvals
+// @Description List the logins waiting to be approved from this device
+// @Accept json
+// @Produce json
+// @Security BearerToken
+// @Success 200 {object} response.Response{data=[]ResponsePendingLoginApproval} "Success"
+// @failure 401 {object} response.ErrorResponse401
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) listLoginApprovals(c echo.Context) error {
+	res, err := h.service.ListLoginApprovals(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessOK(c, res)
+}
+
+// decideLoginApproval godoc
+// @Router /auth/approvals/{id}/decision [post]
+// @Tags Auth
+// @Summary Approve or deny a login
+// @Description Approve a pending login by selecting the number shown on the login screen, or deny it
+// @Accept json
+// @Produce json
+// @Security BearerToken
+// @Param id path string true "Login approval ID"
+// @Param payload body RequestDecideLoginApproval false " "
+// @Success 200 {object} response.Response "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 404 {object} response.ErrorResponse404
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) decideLoginApproval(c echo.Context) error {
+	var req RequestDecideLoginApproval
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	err := h.service.DecideLoginApproval(c.Request().Context(), req)
+	if err != nil {
+		switch errors.Cause(err) {
+		case ierr.ErrLoginApprovalExpired, ierr.ErrLoginApprovalDenied:
+			return response.ErrBadRequest(err)
+		case ierr.ErrResourceNotFound:
+			return response.ErrNotFound(err)
+		}
+		return err
+	}
+
+	if req.Approve {
+		return response.SuccessOK(c, nil, "login approved")
+	}
+	return response.SuccessOK(c, nil, "login denied")
+}
+
+// exchangeLoginApproval godoc
+// @Router /auth/approvals/{id}/token [post]
+// @Tags Auth
+// @Summary Get the tokens of an approved login
+// @Description Poll the login approval, the tokens are returned once the login is approved
+// @Accept json
+// @Produce json
+// @Param id path string true "Login approval ID"
+// @Param payload body RequestLoginApprovalToken false " "
+// @Success 200 {object} response.Response{data=ResponseLogin} "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 403 {object} response.ErrorResponse403
+// @failure 404 {object} response.ErrorResponse404
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) exchangeLoginApproval(c echo.Context) error {
+	var req RequestLoginApprovalToken
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+	req.Client = middleware.ClientInfo(c.Request())
+
+	res, err := h.service.ExchangeLoginApproval(c.Request().Context(), req)
+	if err != nil {
+		switch errors.Cause(err) {
+		case ierr.ErrLoginApprovalExpired:
+			return response.ErrBadRequest(err)
+		case ierr.ErrLoginApprovalPending, ierr.ErrLoginApprovalDenied, ierr.ErrUserIsNotActive, ierr.ErrSessionLimitReached:
+			return response.ErrForbidden(err)
+		case ierr.ErrResourceNotFound:
+			return response.ErrNotFound(err)
+		}
+		return err
+	}
+
+	return response.SuccessOK(c, res, "user authenticated")
+}
+
+// startDeviceLogin godoc
+// @Router /device-login/start [post]
+// @Tags Auth
+// @Summary Start a device login
+// @Description Start a cross-device login from a TV or console, display the user code or QR code and poll for the tokens
+// @Accept json
+// @Produce json
+// @Success 200 {object} response.Response{data=ResponseDeviceLoginStart} "Success"
+// @failure 429 {object} response.ErrorResponse429
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) startDeviceLogin(c echo.Context) error {
+	res, err := h.service.StartDeviceLogin(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessOK(c, res, "device login started")
+}
+
+// decideDeviceLogin godoc
+// @Router /device-login/decision [post]
+// @Tags Auth
+// @Summary Approve or deny a device login
+// @Description Approve or deny a device login from an authenticated session using the displayed user code
+// @Accept json
+// @Produce json
+// @Security BearerToken
+// @Param payload body RequestDeviceLoginDecision false " "
+// @Success 200 {object} response.Response "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 404 {object} response.ErrorResponse404
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) decideDeviceLogin(c echo.Context) error {
+	var req RequestDeviceLoginDecision
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	err := h.service.DecideDeviceLogin(c.Request().Context(), req)
+	if err != nil {
+		switch errors.Cause(err) {
+		case ierr.ErrDeviceLoginExpired:
+			return response.ErrBadRequest(err)
+		case ierr.ErrResourceNotFound:
+			return response.ErrNotFound(err)
+		}
+		return err
+	}
+
+	if req.Approve {
+		return response.SuccessOK(c, nil, "device login approved")
+	}
+	return response.SuccessOK(c, nil, "device login denied")
+}
+
+// pollDeviceLogin godoc
+// @Router /device-login/poll [post]
+// @Tags Auth
+// @Summary Poll a device login
+// @Description Poll the device login with the device code, the tokens are returned once the login is approved
+// @Accept json
+// @Produce json
+// @Param payload body RequestDeviceLoginPoll false " "
+// @Success 200 {object} response.Response{data=ResponseLogin} "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 403 {object} response.ErrorResponse403
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) pollDeviceLogin(c echo.Context) error {
+	var req RequestDeviceLoginPoll
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+	req.IPAddress = c.RealIP()
+	req.UserAgent = c.Request().UserAgent()
+	req.Client = middleware.ClientInfo(c.Request())
+
+	res, err := h.service.PollDeviceLogin(c.Request().Context(), req)
+	if err != nil {
+		switch errors.Cause(err) {
+		case ierr.ErrDeviceLoginPending, ierr.ErrDeviceLoginSlowDown, ierr.ErrDeviceLoginExpired, ierr.ErrInvalidToken:
+			return response.ErrBadRequest(err)
+		case ierr.ErrDeviceLoginDenied, ierr.ErrUserIsNotActive, ierr.ErrSessionLimitReached:
+			return response.ErrForbidden(err)
+		}
+		return err
+	}
+
+	return response.SuccessOK(c, res, "user authenticated")
+}
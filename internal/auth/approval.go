@@ -0,0 +1,321 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"go-hex/configs"
+	"go-hex/internal/catalog"
+	"go-hex/internal/domain"
+	"go-hex/internal/notification"
+	"go-hex/pkg/auth"
+	"go-hex/pkg/event"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/times"
+	"go-hex/pkg/utils"
+	"go-hex/shared/ierr"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// number of choices shown on the approving device, only one of them matches the login screen
+const loginApprovalChoices = 3
+
+// requestLoginApproval creates a pending login approval and pushes it to the devices of the user.
+func (s *Service) requestLoginApproval(ctx context.Context, identity Identity, req RequestLogin) (ResponseLoginApproval, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	var res ResponseLoginApproval
+
+	choices, err := randomTwoDigitNumbers(loginApprovalChoices)
+	if err != nil {
+		return res, err
+	}
+	number := choices[0]
+	shuffle(choices)
+
+	clientSecret, err := randomClientSecret()
+	if err != nil {
+		return res, err
+	}
+
+	now := times.Now()
+	approval := domain.LoginApproval{
+		ID:           utils.GenerateID(),
+		UserID:       identity.GetID(),
+		ClientSecret: utils.HashSHA256(clientSecret),
+		Number:       number,
+		Choices:      joinNumbers(choices),
+		Status:       domain.LoginApprovalStatusPending,
+		IPAddress:    req.IPAddress,
+		UserAgent:    req.UserAgent,
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(time.Duration(s.cfg.LoginApproval.Timeout) * time.Second),
+	}
+
+	err = s.repoRegitry.GetLoginApprovalRepository().Create(ctx, approval)
+	if err != nil {
+		return res, err
+	}
+
+	err = s.notifier.Send(ctx, notification.ChannelPush, notification.Message{
+		UserID: identity.GetID(),
+		Title:  "Approve sign in",
+		Body:   "Someone is trying to sign in to your account. Open the app and select the number shown on the sign in screen.",
+		Data: map[string]string{
+			"type":        "login_approval",
+			"approval_id": approval.ID,
+		},
+		Template: catalog.MessageLoginApproval,
+		Variables: map[string]string{
+			"AppName":   configs.FromContext(ctx, s.cfg).Server.NAME,
+			"IPAddress": approval.IPAddress,
+			"UserAgent": approval.UserAgent,
+			"ExpiresAt": approval.ExpiresAt.Format(time.RFC3339),
+		},
+	})
+	if err != nil {
+		return res, err
+	}
+
+	s.events.Publish(ctx, event.Event{
+		Name:      domain.EventLoginApprovalRequested,
+		ActorID:   identity.GetID(),
+		SubjectID: approval.ID,
+		Attributes: map[string]interface{}{
+			"ip_address": approval.IPAddress,
+		},
+	})
+
+	return ResponseLoginApproval{
+		ID:           approval.ID,
+		ClientSecret: clientSecret,
+		Number:       approval.Number,
+		ExpiresAt:    approval.ExpiresAt.Format(time.RFC3339),
+	}, nil
+}
+
+// ListLoginApprovals returns the pending login approvals of the logged in user.
+func (s *Service) ListLoginApprovals(ctx context.Context) ([]ResponsePendingLoginApproval, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	user := auth.GetLoggedInUser(ctx)
+	approvals, err := s.repoRegitry.GetLoginApprovalRepository().ListPendingByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]ResponsePendingLoginApproval, 0, len(approvals))
+	for _, approval := range approvals {
+		choices, err := splitNumbers(approval.Choices)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, ResponsePendingLoginApproval{
+			ID:        approval.ID,
+			Choices:   choices,
+			IPAddress: approval.IPAddress,
+			UserAgent: approval.UserAgent,
+			ExpiresAt: approval.ExpiresAt.Format(time.RFC3339),
+		})
+	}
+	return res, nil
+}
+
+// DecideLoginApproval approves or denies a pending login of the logged in user.
+// Selecting a number which doesn't match the login screen denies the login.
+func (s *Service) DecideLoginApproval(ctx context.Context, req RequestDecideLoginApproval) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return err
+	}
+
+	user := auth.GetLoggedInUser(ctx)
+	repoApproval := s.repoRegitry.GetLoginApprovalRepository()
+	approval, err := repoApproval.GetByID(ctx, req.ID)
+	if err != nil {
+		return err
+	}
+
+	if approval.UserID != user.ID {
+		return ierr.ErrResourceNotFound
+	}
+	if approval.Status != domain.LoginApprovalStatusPending || approval.IsExpired(times.Now()) {
+		return s.authFailed(ctx, failureApprovalExpired, ierr.ErrLoginApprovalExpired)
+	}
+
+	status, eventName := domain.LoginApprovalStatusDenied, domain.EventLoginApprovalDenied
+	if req.Approve && req.Number == approval.Number {
+		status, eventName = domain.LoginApprovalStatusApproved, domain.EventLoginApprovalApproved
+	}
+
+	ok, err := repoApproval.UpdateStatus(ctx, approval.ID, domain.LoginApprovalStatusPending, status)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return s.authFailed(ctx, failureApprovalExpired, ierr.ErrLoginApprovalExpired)
+	}
+	otel.Event(ctx, otel.EventLoginApprovalDecided, otel.AttributeApprovalStatus.String(status))
+
+	s.events.Publish(ctx, event.Event{
+		Name:      eventName,
+		ActorID:   user.ID,
+		SubjectID: approval.ID,
+		Attributes: map[string]interface{}{
+			"number_matched": req.Number == approval.Number,
+		},
+	})
+
+	if req.Approve && status == domain.LoginApprovalStatusDenied {
+		return ierr.ErrLoginApprovalDenied
+	}
+	return nil
+}
+
+// ExchangeLoginApproval issues the tokens of an approved login. It is polled by the client waiting for the approval
+// with the client secret returned by the login, and succeeds only once per approval.
+func (s *Service) ExchangeLoginApproval(ctx context.Context, req RequestLoginApprovalToken) (ResponseLogin, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	var res ResponseLogin
+
+	err := req.Validate()
+	if err != nil {
+		return res, err
+	}
+
+	repoApproval := s.repoRegitry.GetLoginApprovalRepository()
+	approval, err := repoApproval.GetByID(ctx, req.ID)
+	if err != nil {
+		return res, err
+	}
+
+	// an approval polled with a wrong secret is reported as not found so that its existence is not disclosed
+	if subtle.ConstantTimeCompare([]byte(approval.ClientSecret), []byte(utils.HashSHA256(req.ClientSecret))) != 1 {
+		return res, s.authFailed(ctx, failureApprovalSecret, ierr.ErrResourceNotFound)
+	}
+
+	if approval.IsExpired(times.Now()) {
+		return res, s.authFailed(ctx, failureApprovalExpired, ierr.ErrLoginApprovalExpired)
+	}
+
+	switch approval.Status {
+	case domain.LoginApprovalStatusPending:
+		return res, s.authFailed(ctx, failureApprovalPending, ierr.ErrLoginApprovalPending)
+	case domain.LoginApprovalStatusDenied:
+		return res, s.authFailed(ctx, failureApprovalDenied, ierr.ErrLoginApprovalDenied)
+	case domain.LoginApprovalStatusApproved:
+	default:
+		return res, s.authFailed(ctx, failureApprovalExpired, ierr.ErrLoginApprovalExpired)
+	}
+
+	// consume the approval so the tokens are issued only once
+	ok, err := repoApproval.UpdateStatus(ctx, approval.ID, domain.LoginApprovalStatusApproved, domain.LoginApprovalStatusConsumed)
+	if err != nil {
+		return res, err
+	}
+	if !ok {
+		return res, s.authFailed(ctx, failureApprovalExpired, ierr.ErrLoginApprovalExpired)
+	}
+
+	user, err := s.repoRegitry.GetUserRepository().GetByID(ctx, approval.UserID)
+	if err != nil {
+		return res, err
+	}
+	if !user.IsActive {
+		return res, s.authFailed(ctx, failureInactiveUser, ierr.ErrUserIsNotActive)
+	}
+
+	// the session is on the device which requested the login, not on the one which approved it, the device exchanging
+	// the approval with its secret
+	sessionID, err := s.startSession(ctx, user, sessionDevice{approval.IPAddress, approval.UserAgent, req.Client}, upstreamSession{})
+	if err != nil {
+		return res, err
+	}
+
+	accessToken, expiresAt, refreshToken, err := s.generateJWT(ctx, user, sessionID, "")
+	return ResponseLogin{
+		AccessToken:  accessToken,
+		ExpiresAt:    expiresAt.Format(time.RFC3339),
+		RefreshToken: refreshToken,
+	}, err
+}
+
+// randomClientSecret returns a high entropy secret only known by the client waiting for the approval
+func randomClientSecret() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", errors.Wrap(err, "cannot generate client secret")
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// randomTwoDigitNumbers returns n distinct random numbers between 10 and 99
+func randomTwoDigitNumbers(n int) ([]int, error) {
+	numbers := make([]int, 0, n)
+	seen := map[int]bool{}
+	for len(numbers) < n {
+		v, err := rand.Int(rand.Reader, big.NewInt(90))
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot generate random number")
+		}
+		number := int(v.Int64()) + 10
+		if seen[number] {
+			continue
+		}
+		seen[number] = true
+		numbers = append(numbers, number)
+	}
+	return numbers, nil
+}
+
+// shuffle shuffles the numbers in place using the Fisher-Yates algorithm
+func shuffle(numbers []int) {
+	for i := len(numbers) - 1; i > 0; i-- {
+		v, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			continue
+		}
+		j := int(v.Int64())
+		numbers[i], numbers[j] = numbers[j], numbers[i]
+	}
+}
+
+func joinNumbers(numbers []int) string {
+	items := make([]string, len(numbers))
+	for i, number := range numbers {
+		items[i] = strconv.Itoa(number)
+	}
+	return strings.Join(items, ",")
+}
+
+func splitNumbers(s string) ([]int, error) {
+	items := strings.Split(s, ",")
+	numbers := make([]int, len(items))
+	for i, item := range items {
+		number, err := strconv.Atoi(item)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("invalid login approval choices %q", s))
+		}
+		numbers[i] = number
+	}
+	return numbers, nil
+}
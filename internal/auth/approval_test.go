@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/notification"
+	"go-hex/internal/repository/memory"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/times"
+	"go-hex/shared/ctxutil"
+	"go-hex/shared/ierr"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecideLoginApproval(t *testing.T) {
+	now := times.Now()
+	loggedIn := ctxutil.WithPrincipal(context.Background(), &jwt.Token{
+		Claims: jwt.MapClaims{"id": "user-1", "token_type": TokenTypeAccess},
+	})
+
+	tests := []struct {
+		name       string
+		approval   domain.LoginApproval
+		req        RequestDecideLoginApproval
+		wantErr    error
+		wantStatus string
+	}{
+		{
+			name:       "matching number approves",
+			approval:   domain.LoginApproval{ID: "a1", UserID: "user-1", Number: 42, Status: domain.LoginApprovalStatusPending, ExpiresAt: now.Add(time.Minute)},
+			req:        RequestDecideLoginApproval{ID: "a1", Number: 42, Approve: true},
+			wantStatus: domain.LoginApprovalStatusApproved,
+		},
+		{
+			name:       "number mismatch denies",
+			approval:   domain.LoginApproval{ID: "a1", UserID: "user-1", Number: 42, Status: domain.LoginApprovalStatusPending, ExpiresAt: now.Add(time.Minute)},
+			req:        RequestDecideLoginApproval{ID: "a1", Number: 17, Approve: true},
+			wantErr:    ierr.ErrLoginApprovalDenied,
+			wantStatus: domain.LoginApprovalStatusDenied,
+		},
+		{
+			name:       "explicit deny",
+			approval:   domain.LoginApproval{ID: "a1", UserID: "user-1", Number: 42, Status: domain.LoginApprovalStatusPending, ExpiresAt: now.Add(time.Minute)},
+			req:        RequestDecideLoginApproval{ID: "a1", Approve: false},
+			wantStatus: domain.LoginApprovalStatusDenied,
+		},
+		{
+			name:       "expired approval",
+			approval:   domain.LoginApproval{ID: "a1", UserID: "user-1", Number: 42, Status: domain.LoginApprovalStatusPending, ExpiresAt: now.Add(-time.Second)},
+			req:        RequestDecideLoginApproval{ID: "a1", Number: 42, Approve: true},
+			wantErr:    ierr.ErrLoginApprovalExpired,
+			wantStatus: domain.LoginApprovalStatusPending,
+		},
+		{
+			name:       "already decided",
+			approval:   domain.LoginApproval{ID: "a1", UserID: "user-1", Number: 42, Status: domain.LoginApprovalStatusDenied, ExpiresAt: now.Add(time.Minute)},
+			req:        RequestDecideLoginApproval{ID: "a1", Number: 42, Approve: true},
+			wantErr:    ierr.ErrLoginApprovalExpired,
+			wantStatus: domain.LoginApprovalStatusDenied,
+		},
+		{
+			name:       "approval of another user",
+			approval:   domain.LoginApproval{ID: "a1", UserID: "user-2", Number: 42, Status: domain.LoginApprovalStatusPending, ExpiresAt: now.Add(time.Minute)},
+			req:        RequestDecideLoginApproval{ID: "a1", Number: 42, Approve: true},
+			wantErr:    ierr.ErrResourceNotFound,
+			wantStatus: domain.LoginApprovalStatusPending,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := memory.NewStore()
+			require.NoError(t, store.GetLoginApprovalRepository().Create(context.Background(), tt.approval))
+			svc := NewService(&configs.Config{}, store, memory.NewTokenBlacklistRepository(), memory.NewOpaqueTokenRepository(), newIdentityViews(store), logger.New("test", "test"), event.New(), notification.NewDispatcher(), noDeprecations{})
+
+			err := svc.DecideLoginApproval(loggedIn, tt.req)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, errors.Cause(err))
+			} else {
+				assert.NoError(t, err)
+			}
+			approval, err := store.GetLoginApprovalRepository().GetByID(context.Background(), tt.approval.ID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, approval.Status)
+		})
+	}
+}
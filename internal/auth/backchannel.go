@@ -0,0 +1,189 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"go-hex/configs"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/times"
+	"go-hex/pkg/utils"
+	"go-hex/shared/ctxutil"
+	"go-hex/shared/ierr"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/pkg/errors"
+)
+
+// BackchannelLogoutEvent is the event member which must be present in a logout token.
+// See https://openid.net/specs/openid-connect-backchannel-1_0.html#LogoutToken
+const BackchannelLogoutEvent = "http://schemas.openid.net/event/backchannel-logout"
+
+// logoutTokenClaims contains the validated claims of a logout token.
+type logoutTokenClaims struct {
+	Subject   string
+	SessionID string
+	TokenID   string
+	ExpiresAt time.Time // iat + OIDC_BACKCHANNEL_LOGOUT_TOKEN_TTL, the token is rejected afterwards
+}
+
+// parseLogoutToken validates a logout token issued by the upstream OpenID provider
+// as described in section 2.6 of the OpenID Connect Back-Channel Logout spec.
+func parseLogoutToken(cfg *configs.Config, logoutToken string) (logoutTokenClaims, error) {
+
+	var res logoutTokenClaims
+
+	if cfg.OIDC.UpstreamSigningKey == "" {
+		return res, errors.Wrap(ierr.ErrInvalidToken, "backchannel logout is not configured")
+	}
+
+	token, err := jwt.Parse(logoutToken, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(cfg.OIDC.UpstreamSigningKey), nil
+	})
+	if err != nil {
+		return res, errors.Wrap(ierr.ErrInvalidToken, err.Error())
+	}
+
+	claims := token.Claims.(jwt.MapClaims)
+	if !claims.VerifyIssuer(cfg.OIDC.UpstreamIssuer, true) {
+		return res, errors.Wrap(ierr.ErrInvalidToken, "invalid issuer")
+	}
+	if !verifyAudience(claims, cfg.OIDC.UpstreamClientID) {
+		return res, errors.Wrap(ierr.ErrInvalidToken, "invalid audience")
+	}
+
+	iat, ok := claims["iat"].(float64)
+	if !ok {
+		return res, errors.Wrap(ierr.ErrInvalidToken, "missing iat")
+	}
+	res.ExpiresAt = time.Unix(int64(iat), 0).Add(time.Duration(cfg.OIDC.BackchannelLogoutTokenTTL) * time.Second)
+	if times.Now().After(res.ExpiresAt) {
+		return res, errors.Wrap(ierr.ErrExpiredToken, "logout token is too old")
+	}
+
+	res.TokenID, _ = claims["jti"].(string)
+	if res.TokenID == "" {
+		return res, errors.Wrap(ierr.ErrInvalidToken, "missing jti")
+	}
+	if _, ok := claims["nonce"]; ok {
+		return res, errors.Wrap(ierr.ErrInvalidToken, "nonce is prohibited")
+	}
+
+	events, _ := claims["events"].(map[string]interface{})
+	if _, ok := events[BackchannelLogoutEvent]; !ok {
+		return res, errors.Wrap(ierr.ErrInvalidToken, "missing backchannel logout event")
+	}
+
+	res.Subject, _ = claims["sub"].(string)
+	res.SessionID, _ = claims["sid"].(string)
+	if res.Subject == "" && res.SessionID == "" {
+		return res, errors.Wrap(ierr.ErrInvalidToken, "either sub or sid is required")
+	}
+
+	return res, nil
+}
+
+// verifyAudience checks the aud claim which can either be a string or an array of strings.
+func verifyAudience(claims jwt.MapClaims, clientID string) bool {
+	switch aud := claims["aud"].(type) {
+	case string:
+		return aud == clientID
+	case []interface{}:
+		for _, item := range aud {
+			if val, ok := item.(string); ok && val == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// backchannelNotifier sends logout tokens to the registered clients.
+type backchannelNotifier struct {
+	cfg    *configs.Config
+	log    logger.Logger
+	client *http.Client
+}
+
+func newBackchannelNotifier(cfg *configs.Config, log logger.Logger) *backchannelNotifier {
+	return &backchannelNotifier{
+		cfg:    cfg,
+		log:    log,
+		client: &http.Client{Timeout: time.Duration(cfg.OIDC.BackchannelLogoutTimeout) * time.Second},
+	}
+}
+
+// notify sends a logout token for the given session to every registered client.
+// Delivery runs in the background so that the local logout is not blocked by slow clients, with the values of the
+// request of the context, in the trace of the request.
+func (n *backchannelNotifier) notify(ctx context.Context, userID, sessionID string) {
+
+	if len(n.cfg.OIDC.BackchannelClients) == 0 {
+		return
+	}
+
+	go func() {
+		ctx, span := otel.StartOperation(ctxutil.Detach(ctx), "backchannelNotifier.notify")
+		defer span.End()
+
+		wg := sync.WaitGroup{}
+		for clientID, client := range n.cfg.OIDC.BackchannelClients {
+			wg.Add(1)
+			go func(clientID string, client configs.BackchannelClient) {
+				defer wg.Done()
+				if err := n.send(ctx, clientID, client, userID, sessionID); err != nil {
+					n.log.WithParam("client_id", clientID).Error(err)
+				}
+			}(clientID, client)
+		}
+		wg.Wait()
+	}()
+}
+
+// send posts a logout token signed with the secret of the client, never with the key signing the access tokens.
+func (n *backchannelNotifier) send(ctx context.Context, clientID string, client configs.BackchannelClient, userID, sessionID string) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	logoutToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"iss": n.cfg.OIDC.Issuer,
+		"aud": clientID,
+		"iat": times.Now().Unix(),
+		"jti": utils.GenerateID(),
+		"sub": userID,
+		"sid": sessionID,
+		"events": map[string]interface{}{
+			BackchannelLogoutEvent: map[string]interface{}{},
+		},
+	}).SignedString([]byte(client.Secret))
+	if err != nil {
+		return errors.Wrap(err, "cannot generate logout token")
+	}
+
+	form := url.Values{"logout_token": []string{logoutToken}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, client.URI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return errors.Wrap(err, "cannot create backchannel logout request")
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "cannot send backchannel logout request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("backchannel logout rejected with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package auth
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/notification"
+	"go-hex/internal/repository/memory"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/times"
+	"go-hex/shared/ierr"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLogoutToken(t *testing.T) {
+	cfg := &configs.Config{}
+	cfg.OIDC.UpstreamSigningKey = "upstream-secret"
+	cfg.OIDC.UpstreamIssuer = "https://idp.example.com"
+	cfg.OIDC.UpstreamClientID = "go-hex"
+	cfg.OIDC.BackchannelLogoutTokenTTL = 120
+
+	validClaims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":    "https://idp.example.com",
+			"aud":    "go-hex",
+			"iat":    times.Now().Unix(),
+			"jti":    "logout-1",
+			"sub":    "upstream-user",
+			"sid":    "upstream-session",
+			"events": map[string]interface{}{BackchannelLogoutEvent: map[string]interface{}{}},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		key     string
+		mutate  func(claims jwt.MapClaims)
+		want    logoutTokenClaims
+		wantErr error
+	}{
+		{
+			name: "valid token",
+			want: logoutTokenClaims{Subject: "upstream-user", SessionID: "upstream-session"},
+		},
+		{
+			name:   "audience array",
+			mutate: func(claims jwt.MapClaims) { claims["aud"] = []interface{}{"other", "go-hex"} },
+			want:   logoutTokenClaims{Subject: "upstream-user", SessionID: "upstream-session"},
+		},
+		{
+			name:   "sub only",
+			mutate: func(claims jwt.MapClaims) { delete(claims, "sid") },
+			want:   logoutTokenClaims{Subject: "upstream-user"},
+		},
+		{
+			name:    "wrong signing key",
+			key:     "another-secret",
+			wantErr: ierr.ErrInvalidToken,
+		},
+		{
+			name:    "wrong issuer",
+			mutate:  func(claims jwt.MapClaims) { claims["iss"] = "https://evil.example.com" },
+			wantErr: ierr.ErrInvalidToken,
+		},
+		{
+			name:    "wrong audience",
+			mutate:  func(claims jwt.MapClaims) { claims["aud"] = []interface{}{"other"} },
+			wantErr: ierr.ErrInvalidToken,
+		},
+		{
+			name:    "missing iat",
+			mutate:  func(claims jwt.MapClaims) { delete(claims, "iat") },
+			wantErr: ierr.ErrInvalidToken,
+		},
+		{
+			name:    "iat too old",
+			mutate:  func(claims jwt.MapClaims) { claims["iat"] = times.Now().Add(-time.Hour).Unix() },
+			wantErr: ierr.ErrExpiredToken,
+		},
+		{
+			name:    "missing jti",
+			mutate:  func(claims jwt.MapClaims) { delete(claims, "jti") },
+			wantErr: ierr.ErrInvalidToken,
+		},
+		{
+			name:    "nonce present",
+			mutate:  func(claims jwt.MapClaims) { claims["nonce"] = "abc" },
+			wantErr: ierr.ErrInvalidToken,
+		},
+		{
+			name: "missing logout event",
+			mutate: func(claims jwt.MapClaims) {
+				claims["events"] = map[string]interface{}{"other": map[string]interface{}{}}
+			},
+			wantErr: ierr.ErrInvalidToken,
+		},
+		{
+			name: "missing sub and sid",
+			mutate: func(claims jwt.MapClaims) {
+				delete(claims, "sub")
+				delete(claims, "sid")
+			},
+			wantErr: ierr.ErrInvalidToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := validClaims()
+			if tt.mutate != nil {
+				tt.mutate(claims)
+			}
+			key := tt.key
+			if key == "" {
+				key = cfg.OIDC.UpstreamSigningKey
+			}
+			token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(key))
+			assert.NoError(t, err)
+
+			got, err := parseLogoutToken(cfg, token)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, errors.Cause(err))
+				return
+			}
+			assert.NoError(t, err)
+			tt.want.TokenID = "logout-1"
+			tt.want.ExpiresAt = time.Unix(claims["iat"].(int64), 0).Add(2 * time.Minute)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestBackchannelLogoutRejectsReplays(t *testing.T) {
+	cfg := &configs.Config{}
+	cfg.OIDC.UpstreamSigningKey = "upstream-secret"
+	cfg.OIDC.UpstreamIssuer = "https://idp.example.com"
+	cfg.OIDC.UpstreamClientID = "go-hex"
+	cfg.OIDC.BackchannelLogoutTokenTTL = 120
+	store := memory.NewStore()
+	upstreamSessionID := "upstream-session"
+	require.NoError(t, store.GetSessionRepository().Create(context.Background(), domain.Session{UserID: "u1", UpstreamSessionID: &upstreamSessionID, ExpiresAt: times.Now().Add(time.Hour)}))
+	var revocations int
+	store.SetHook(func(ctx context.Context, op string) error {
+		if op == "SessionRepository.RevokeByUpstreamSessionID" {
+			revocations++
+		}
+		return nil
+	})
+	blacklist := memory.NewTokenBlacklistRepository()
+	svc := NewService(cfg, store, blacklist, memory.NewOpaqueTokenRepository(), newIdentityViews(store), logger.New("test", "test"), event.New(), notification.NewDispatcher(), noDeprecations{})
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"iss":    "https://idp.example.com",
+		"aud":    "go-hex",
+		"iat":    times.Now().Unix(),
+		"jti":    "logout-1",
+		"sid":    "upstream-session",
+		"events": map[string]interface{}{BackchannelLogoutEvent: map[string]interface{}{}},
+	}).SignedString([]byte(cfg.OIDC.UpstreamSigningKey))
+	require.NoError(t, err)
+
+	require.NoError(t, svc.BackchannelLogout(context.Background(), RequestBackchannelLogout{LogoutToken: token}))
+	sessions, err := store.GetSessionRepository().ListActiveByUserID(context.Background(), "u1")
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+	err = svc.BackchannelLogout(context.Background(), RequestBackchannelLogout{LogoutToken: token})
+	assert.Equal(t, ierr.ErrInvalidToken, errors.Cause(err))
+	assert.Equal(t, 1, revocations, "the replay revokes nothing")
+
+	revoked, err := blacklist.IsRevoked(context.Background(), "logout-1")
+	require.NoError(t, err)
+	assert.False(t, revoked, "the jti of the logout token does not revoke a local token")
+}
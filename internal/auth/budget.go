@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/utils"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/pkg/errors"
+)
+
+// CheckAccessTokenBudget checks that the largest access token fits the size budget of the configuration.
+// The largest token is the one of a user of the longest username granted every role, compacted and signed as issued.
+// The opaque access tokens handed to the clients are not checked, nor is any token without a budget.
+func CheckAccessTokenBudget(ctx context.Context, cfg *configs.Config, roles port.RoleRepository) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	budget := cfg.JWT.MaxTokenBytes
+	if budget <= 0 || cfg.AccessToken.Format == configs.AccessTokenFormatOpaque {
+		return nil
+	}
+
+	all, err := roles.List(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot list roles")
+	}
+	names := make([]string, 0, len(all))
+	granted := map[string]bool{}
+	permissions := []string{}
+	for _, role := range all {
+		names = append(names, role.Name)
+		for _, permission := range role.Permissions {
+			if !granted[permission] {
+				granted[permission] = true
+				permissions = append(permissions, permission)
+			}
+		}
+	}
+	sort.Strings(names)
+	sort.Strings(permissions)
+
+	claims := jwt.MapClaims{
+		"id":         utils.GenerateID(),
+		"sid":        utils.GenerateID(),
+		"jti":        utils.GenerateID(),
+		"token_type": TokenTypeAccess,
+		"exp":        time.Now().Add(time.Duration(cfg.JWT.TokenExpiration) * time.Minute).Unix(),
+	}
+	if !cfg.JWT.SubjectOnly {
+		claims["username"] = strings.Repeat("x", maxUsernameLength)
+	}
+	if len(names) > 0 {
+		claims["roles"] = names
+	}
+	if len(permissions) > 0 {
+		claims["permissions"] = permissions
+	}
+
+	keys := cfg.JWTKeys()
+	keys.Compaction().Compact(claims)
+	token, err := keys.Signer().Sign(claims)
+	if err != nil {
+		return errors.Wrap(err, "cannot generate token")
+	}
+	if len(token) > budget {
+		return errors.Errorf("the largest access token, granted the %d roles, is %d bytes, over the JWT_MAX_TOKEN_BYTES budget of %d bytes: "+
+			"set JWT_COMPACT_PERMISSIONS and JWT_ROLE_CATALOG to compact its claims", len(names), len(token), budget)
+	}
+	return nil
+}
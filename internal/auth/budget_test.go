@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/identityview"
+	"go-hex/internal/notification"
+	"go-hex/internal/repository/memory"
+	"go-hex/pkg/auth"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/shared/ctxutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckAccessTokenBudget(t *testing.T) {
+	store := memory.NewStore()
+	roles := store.GetRoleRepository()
+	cfg := &configs.Config{}
+	cfg.JWT.SigningKey = "test-signing-key"
+	cfg.JWT.TokenExpiration = 60
+
+	// the seed roles fit the default budget
+	cfg.JWT.MaxTokenBytes = 4096
+	assert.NoError(t, CheckAccessTokenBudget(context.Background(), cfg, roles))
+
+	// the many roles do not, until their claims are compacted
+	for i := 0; i < 40; i++ {
+		store.PutRole(domain.Role{Name: fmt.Sprintf("team-%02d-maintainer", i), Permissions: []string{fmt.Sprintf("team-%02d:read", i), fmt.Sprintf("team-%02d:write", i)}})
+	}
+	cfg.JWT.MaxTokenBytes = 1024
+	assert.Error(t, CheckAccessTokenBudget(context.Background(), cfg, roles))
+	cfg.JWT.CompactPermissions = true
+	for i := 0; i < 40; i++ {
+		cfg.JWT.RoleCatalog = append(cfg.JWT.RoleCatalog, fmt.Sprintf("team-%02d-maintainer", i))
+	}
+	assert.NoError(t, CheckAccessTokenBudget(context.Background(), cfg, roles))
+
+	// no budget, no check
+	cfg.JWT.MaxTokenBytes = 0
+	cfg.JWT.CompactPermissions, cfg.JWT.RoleCatalog = false, nil
+	assert.NoError(t, CheckAccessTokenBudget(context.Background(), cfg, roles))
+}
+
+func TestCompactedAccessTokens(t *testing.T) {
+	user := domain.User{ID: "u1", Username: "jane", IsActive: true}
+	store := newTestStore(t, user)
+	store.PutRole(domain.Role{Name: "support", Permissions: []string{"users:read", "users:write", "profile:read"}})
+	require.NoError(t, store.GetRoleRepository().Assign(context.Background(), domain.UserRole{UserID: "u1", Role: "support"}))
+	cfg := &configs.Config{}
+	cfg.JWT.SigningKey = "test-signing-key"
+	cfg.JWT.TokenExpiration = 60
+	cfg.JWT.CompactPermissions = true
+	cfg.JWT.RoleCatalog = []string{domain.RoleAdmin, domain.RoleUser, "support"}
+	identities := identityview.NewService(store, nil, 0, []string{domain.RoleUser}, logger.New("test", "test"))
+	svc := NewService(cfg, store, memory.NewTokenBlacklistRepository(), memory.NewOpaqueTokenRepository(), identities, logger.New("test", "test"), event.New(), notification.NewDispatcher(), noDeprecations{})
+
+	accessToken, _, err := svc.generateAccessToken(context.Background(), user, "s1")
+	require.NoError(t, err)
+
+	// the compacted claims are expanded by the keys of the configuration
+	token, err := cfg.JWTKeys().Verify(accessToken)
+	require.NoError(t, err)
+	loggedIn := auth.GetLoggedInUser(ctxutil.WithPrincipal(context.Background(), token))
+	assert.Equal(t, []string{"support", domain.RoleUser}, loggedIn.Roles)
+	assert.Equal(t, []string{"profile:read", "profile:write", "users:read", "users:write"}, loggedIn.Permissions)
+}
@@ -1,6 +1,58 @@
 package auth
 
+import "github.com/pkg/errors"
+
 const (
 	TokenTypeAccess  = "access"
 	TokenTypeRefresh = "refresh"
 )
+
+// logoutTokenPrefix prefixes the jti of the logout tokens recorded in the token blacklist, so that an upstream jti
+// cannot revoke a local token
+const logoutTokenPrefix = "logout_token:"
+
+// passwordResetTokenSize is the number of random bytes of the password reset tokens
+const passwordResetTokenSize = 32
+
+// Categories of the authentication failures recorded on the spans
+const (
+	failureUnknownUser     = "unknown_user"
+	failureWrongPassword   = "wrong_password"
+	failureInactiveUser    = "inactive_user"
+	failureInvalidToken    = "invalid_token"
+	failureWrongTokenType  = "wrong_token_type"
+	failureUnknownSession  = "unknown_session"
+	failureSessionMismatch = "session_mismatch"
+	failureExpiredSession  = "expired_session"
+	failureRefreshMismatch = "refresh_token_mismatch"
+	failureRefreshReused   = "refresh_token_reused"
+	failureUnknownRefresh  = "unknown_refresh_token"
+	failureSessionLimit    = "session_limit_reached"
+	failureApprovalPending = "login_approval_pending"
+	failureApprovalDenied  = "login_approval_denied"
+	failureApprovalExpired = "login_approval_expired"
+	failureApprovalSecret  = "login_approval_wrong_secret"
+	failureSealedAccount   = "break_glass_not_active"
+	failureUnknownReset    = "unknown_password_reset"
+	failureUsedReset       = "password_reset_used"
+)
+
+// Outcomes of the password logins counted in auth_login_attempts_total
+const (
+	loginOutcomeSuccess          = "success"
+	loginOutcomeFailure          = "failure"
+	loginOutcomeApprovalRequired = "approval_required"
+	loginOutcomeError            = "error"
+)
+
+// Grants of the tokens observed in auth_token_issuance_duration_seconds
+const (
+	grantLogin        = "login"
+	grantRefreshToken = "refresh_token"
+)
+
+// maxUsernameLength is the length of the username column, the longest username of the largest access token
+const maxUsernameLength = 50
+
+// errRotatedConcurrently rolls back the refresh token issued for a token rotated concurrently
+var errRotatedConcurrently = errors.New("refresh token rotated concurrently")
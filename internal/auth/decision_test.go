@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/notification"
+	"go-hex/internal/repository/memory"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/password"
+	"go-hex/shared/ierr"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gootel "go.opentelemetry.io/otel"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestLoginRecordsFailureReason(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	gootel.SetTracerProvider(tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(recorder)))
+	defer gootel.SetTracerProvider(trace.NewNoopTracerProvider())
+
+	hashed, err := password.HashAndSalt([]byte("correct-password"))
+	require.NoError(t, err)
+
+	expiredAt := time.Now().Add(-time.Minute)
+	tests := []struct {
+		name       string
+		user       domain.User
+		breakGlass *domain.BreakGlassAccount
+		req        RequestLogin
+		wantErr    error
+		wantReason string
+	}{
+		{
+			name:       "unknown user",
+			user:       domain.User{Username: "jane", Password: hashed, IsActive: true},
+			req:        RequestLogin{Username: "john", Password: "correct-password"},
+			wantErr:    ierr.ErrInvalidCreds,
+			wantReason: failureUnknownUser,
+		},
+		{
+			name:       "wrong password",
+			user:       domain.User{Username: "jane", Password: hashed, IsActive: true},
+			req:        RequestLogin{Username: "jane", Password: "wrong-password"},
+			wantErr:    ierr.ErrInvalidCreds,
+			wantReason: failureWrongPassword,
+		},
+		{
+			name:       "inactive user",
+			user:       domain.User{Username: "jane", Password: hashed},
+			req:        RequestLogin{Username: "jane", Password: "correct-password"},
+			wantErr:    ierr.ErrUserIsNotActive,
+			wantReason: failureInactiveUser,
+		},
+		{
+			name:       "sealed break-glass account",
+			user:       domain.User{ID: "u1", Username: "jane", Password: hashed, IsActive: true},
+			breakGlass: &domain.BreakGlassAccount{UserID: "u1", Status: domain.BreakGlassStatusSealed},
+			req:        RequestLogin{Username: "jane", Password: "correct-password"},
+			wantErr:    ierr.ErrInvalidCreds,
+			wantReason: failureSealedAccount,
+		},
+		{
+			name:       "expired break-glass activation",
+			user:       domain.User{ID: "u1", Username: "jane", Password: hashed, IsActive: true},
+			breakGlass: &domain.BreakGlassAccount{UserID: "u1", Status: domain.BreakGlassStatusActive, ExpiresAt: &expiredAt},
+			req:        RequestLogin{Username: "jane", Password: "correct-password"},
+			wantErr:    ierr.ErrInvalidCreds,
+			wantReason: failureSealedAccount,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &configs.Config{}
+			cfg.PasswordPool.QueueTimeout = 1000
+			store := newTestStore(t, tt.user)
+			if tt.breakGlass != nil {
+				require.NoError(t, store.GetBreakGlassAccountRepository().Seal(context.Background(), *tt.breakGlass))
+			}
+			svc := NewService(cfg, store, memory.NewTokenBlacklistRepository(), memory.NewOpaqueTokenRepository(), newIdentityViews(store), logger.New("test", "test"), event.New(), notification.NewDispatcher(), noDeprecations{})
+
+			_, err := svc.Login(context.Background(), tt.req)
+			assert.Equal(t, tt.wantErr, err)
+
+			spans := recorder.Ended()
+			var reasons []string
+			for _, span := range spans[len(spans)-2:] {
+				for _, attr := range span.Attributes() {
+					if attr.Key == otel.AttributeFailureReason {
+						reasons = append(reasons, attr.Value.AsString())
+					}
+				}
+				for _, ev := range span.Events() {
+					assert.Equal(t, otel.EventAuthFailed, ev.Name)
+				}
+			}
+			assert.Equal(t, []string{tt.wantReason}, reasons)
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"go-hex/internal/domain"
+	"go-hex/pkg/auth"
+	"go-hex/pkg/event"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/times"
+	"go-hex/pkg/utils"
+	"go-hex/shared/ierr"
+	"math/big"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// userCodeAlphabet excludes vowels and look-alike characters as recommended by RFC 8628 section 6.1
+const userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+
+// number of user codes drawn before giving up when they collide with existing ones
+const userCodeAttempts = 5
+
+// StartDeviceLogin starts a cross-device login. The device displays the user code (or the QR code)
+// and polls PollDeviceLogin with the device code until the user approves it from an authenticated session.
+func (s *Service) StartDeviceLogin(ctx context.Context) (ResponseDeviceLoginStart, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	var res ResponseDeviceLoginStart
+
+	deviceCode, err := randomDeviceCode()
+	if err != nil {
+		return res, err
+	}
+
+	now := times.Now()
+	deviceLogin := domain.DeviceLogin{
+		ID:         utils.GenerateID(),
+		DeviceCode: utils.HashSHA256(deviceCode),
+		Status:     domain.DeviceLoginStatusPending,
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(time.Duration(s.cfg.DeviceLogin.Timeout) * time.Second),
+	}
+
+	// user codes are short enough to collide with the codes kept until the cleanup, a new one is drawn then
+	for attempt := 1; ; attempt++ {
+		deviceLogin.UserCode, err = randomUserCode()
+		if err != nil {
+			return res, err
+		}
+
+		err = s.repoRegitry.GetDeviceLoginRepository().Create(ctx, deviceLogin)
+		if errors.Cause(err) != ierr.ErrConflict || attempt == userCodeAttempts {
+			break
+		}
+	}
+	if err != nil {
+		return res, err
+	}
+	userCode := deviceLogin.UserCode
+
+	verificationURI := s.cfg.DeviceLogin.VerificationURI
+	verificationURIComplete := verificationURI + "?" + url.Values{"user_code": []string{userCode}}.Encode()
+	png, err := qrcode.Encode(verificationURIComplete, qrcode.Medium, 256)
+	if err != nil {
+		return res, errors.Wrap(err, "cannot generate qr code")
+	}
+
+	return ResponseDeviceLoginStart{
+		DeviceCode:              deviceCode,
+		UserCode:                userCode,
+		VerificationURI:         verificationURI,
+		VerificationURIComplete: verificationURIComplete,
+		QRCode:                  "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+		ExpiresAt:               deviceLogin.ExpiresAt.Format(time.RFC3339),
+		Interval:                s.cfg.DeviceLogin.PollInterval,
+	}, nil
+}
+
+// DecideDeviceLogin approves or denies a device login on behalf of the logged in user.
+func (s *Service) DecideDeviceLogin(ctx context.Context, req RequestDeviceLoginDecision) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return err
+	}
+
+	user := auth.GetLoggedInUser(ctx)
+	repoDeviceLogin := s.repoRegitry.GetDeviceLoginRepository()
+	deviceLogin, err := repoDeviceLogin.GetByUserCode(ctx, normalizeUserCode(req.UserCode))
+	if err != nil {
+		return err
+	}
+
+	if deviceLogin.Status != domain.DeviceLoginStatusPending || deviceLogin.IsExpired(times.Now()) {
+		return ierr.ErrDeviceLoginExpired
+	}
+
+	status, eventName := domain.DeviceLoginStatusDenied, domain.EventDeviceLoginDenied
+	if req.Approve {
+		status, eventName = domain.DeviceLoginStatusApproved, domain.EventDeviceLoginApproved
+	}
+
+	ok, err := repoDeviceLogin.Decide(ctx, deviceLogin.ID, user.ID, status)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ierr.ErrDeviceLoginExpired
+	}
+
+	s.events.Publish(ctx, event.Event{
+		Name:      eventName,
+		ActorID:   user.ID,
+		SubjectID: deviceLogin.ID,
+	})
+	return nil
+}
+
+// PollDeviceLogin issues the tokens of an approved device login.
+// The tokens are issued only once and the device must respect the polling interval.
+func (s *Service) PollDeviceLogin(ctx context.Context, req RequestDeviceLoginPoll) (ResponseLogin, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	var res ResponseLogin
+
+	err := req.Validate()
+	if err != nil {
+		return res, err
+	}
+
+	repoDeviceLogin := s.repoRegitry.GetDeviceLoginRepository()
+	deviceLogin, err := repoDeviceLogin.GetByDeviceCode(ctx, utils.HashSHA256(req.DeviceCode))
+	if err != nil {
+		if err == ierr.ErrResourceNotFound {
+			return res, ierr.ErrInvalidToken
+		}
+		return res, err
+	}
+
+	now := times.Now()
+	if deviceLogin.IsExpired(now) {
+		return res, ierr.ErrDeviceLoginExpired
+	}
+
+	interval := time.Duration(s.cfg.DeviceLogin.PollInterval) * time.Second
+	tooFrequent := deviceLogin.LastPolledAt != nil && now.Sub(*deviceLogin.LastPolledAt) < interval
+	err = repoDeviceLogin.UpdatePolledAt(ctx, deviceLogin.ID, now)
+	if err != nil {
+		return res, err
+	}
+
+	switch deviceLogin.Status {
+	case domain.DeviceLoginStatusPending:
+		if tooFrequent {
+			// the poll counts, the device waits a whole interval from it
+			return res, ierr.Limited(ierr.ErrDeviceLoginSlowDown, now.Add(interval))
+		}
+		return res, ierr.ErrDeviceLoginPending
+	case domain.DeviceLoginStatusDenied:
+		return res, ierr.ErrDeviceLoginDenied
+	case domain.DeviceLoginStatusApproved:
+	default:
+		return res, ierr.ErrDeviceLoginExpired
+	}
+
+	// consume the device login so the tokens are issued only once
+	ok, err := repoDeviceLogin.UpdateStatus(ctx, deviceLogin.ID, domain.DeviceLoginStatusApproved, domain.DeviceLoginStatusConsumed)
+	if err != nil {
+		return res, err
+	}
+	if !ok || deviceLogin.UserID == nil {
+		return res, ierr.ErrDeviceLoginExpired
+	}
+
+	user, err := s.repoRegitry.GetUserRepository().GetByID(ctx, *deviceLogin.UserID)
+	if err != nil {
+		return res, err
+	}
+	if !user.IsActive {
+		return res, ierr.ErrUserIsNotActive
+	}
+
+	sessionID, err := s.startSession(ctx, user, sessionDevice{req.IPAddress, req.UserAgent, req.Client}, upstreamSession{})
+	if err != nil {
+		return res, err
+	}
+
+	accessToken, expiresAt, refreshToken, err := s.generateJWT(ctx, user, sessionID, "")
+	return ResponseLogin{
+		AccessToken:  accessToken,
+		ExpiresAt:    expiresAt.Format(time.RFC3339),
+		RefreshToken: refreshToken,
+	}, err
+}
+
+// randomDeviceCode returns a high entropy code only known by the device
+func randomDeviceCode() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", errors.Wrap(err, "cannot generate device code")
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// randomUserCode returns a code formatted as XXXX-XXXX which is easy to type on a phone
+func randomUserCode() (string, error) {
+	b := make([]byte, 0, 9)
+	for i := 0; i < 8; i++ {
+		if i == 4 {
+			b = append(b, '-')
+		}
+		v, err := rand.Int(rand.Reader, big.NewInt(int64(len(userCodeAlphabet))))
+		if err != nil {
+			return "", errors.Wrap(err, "cannot generate user code")
+		}
+		b = append(b, userCodeAlphabet[v.Int64()])
+	}
+	return string(b), nil
+}
+
+// normalizeUserCode accepts user codes typed in lower case and without the dash
+func normalizeUserCode(userCode string) string {
+	userCode = strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(userCode))
+	if len(userCode) != 8 {
+		return userCode
+	}
+	return userCode[:4] + "-" + userCode[4:]
+}
@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/notification"
+	"go-hex/internal/repository/memory"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/shared/ierr"
+	"regexp"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeUserCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		userCode string
+		want     string
+	}{
+		{name: "formatted", userCode: "BCDF-GHJK", want: "BCDF-GHJK"},
+		{name: "lower case", userCode: "bcdf-ghjk", want: "BCDF-GHJK"},
+		{name: "without dash", userCode: "bcdfghjk", want: "BCDF-GHJK"},
+		{name: "with spaces", userCode: " BCDF GHJK ", want: "BCDF-GHJK"},
+		{name: "too short is left as is", userCode: "bcd-fgh", want: "BCDFGH"},
+		{name: "too long is left as is", userCode: "BCDFGHJKL", want: "BCDFGHJKL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, normalizeUserCode(tt.userCode))
+		})
+	}
+}
+
+func TestRandomUserCode(t *testing.T) {
+	format := regexp.MustCompile("^[" + userCodeAlphabet + "]{4}-[" + userCodeAlphabet + "]{4}$")
+
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		userCode, err := randomUserCode()
+		assert.NoError(t, err)
+		assert.Regexp(t, format, userCode)
+		assert.Equal(t, userCode, normalizeUserCode(userCode))
+		seen[userCode] = true
+	}
+	assert.Greater(t, len(seen), 90)
+}
+
+func TestStartDeviceLoginUserCodeCollision(t *testing.T) {
+	tests := []struct {
+		name      string
+		conflicts int
+		wantErr   error
+		wantTries int
+	}{
+		{name: "no collision", conflicts: 0, wantTries: 1},
+		{name: "collision is retried", conflicts: 2, wantTries: 3},
+		{name: "gives up after the attempts", conflicts: userCodeAttempts, wantErr: ierr.ErrConflict, wantTries: userCodeAttempts},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &configs.Config{}
+			cfg.DeviceLogin.VerificationURI = "http://localhost:3000/device"
+			cfg.DeviceLogin.Timeout = 600
+			store := memory.NewStore()
+			tries := 0
+			store.SetHook(func(ctx context.Context, op string) error {
+				if op != "DeviceLoginRepository.Create" {
+					return nil
+				}
+				tries++
+				if tries <= tt.conflicts {
+					return ierr.ErrConflict
+				}
+				return nil
+			})
+			svc := NewService(cfg, store, memory.NewTokenBlacklistRepository(), memory.NewOpaqueTokenRepository(), newIdentityViews(store), logger.New("test", "test"), event.New(), notification.NewDispatcher(), noDeprecations{})
+
+			res, err := svc.StartDeviceLogin(context.Background())
+			assert.Equal(t, tt.wantTries, tries)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, errors.Cause(err))
+				return
+			}
+			assert.NoError(t, err)
+			_, err = store.GetDeviceLoginRepository().GetByUserCode(context.Background(), res.UserCode)
+			assert.NoError(t, err)
+		})
+	}
+}
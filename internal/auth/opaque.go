@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/pkg/auth"
+	"go-hex/pkg/otel"
+	"go-hex/shared/ctxutil"
+	"go-hex/shared/ierr"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/pkg/errors"
+)
+
+// issueOpaqueToken stores the signed access token and returns the opaque token referencing it, which the clients
+// receive instead. The opaque token expires with the access token, and is deleted by the logout.
+func (s *Service) issueOpaqueToken(ctx context.Context, accessToken string, expiresAt time.Time) (string, error) {
+	opaqueToken, err := auth.NewOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	err = s.opaque.Put(ctx, auth.OpaqueTokenDigest(opaqueToken), accessToken, expiresAt)
+	if err != nil {
+		return "", err
+	}
+	return opaqueToken, nil
+}
+
+// ResolveAccessToken returns the signed access token referenced by the opaque access token,
+// ierr.ErrResourceNotFound when the opaque token is unknown, expired or deleted.
+func (s *Service) ResolveAccessToken(ctx context.Context, opaqueToken string) (string, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	return s.opaque.Get(ctx, auth.OpaqueTokenDigest(opaqueToken))
+}
+
+// Introspect tells the other services whether an access token, opaque or signed, is active and returns its claims.
+// A token is inactive once expired, revoked by a logout or when its session is revoked.
+func (s *Service) Introspect(ctx context.Context, req RequestIntrospect) (ResponseIntrospect, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return ResponseIntrospect{}, err
+	}
+
+	tokenString := req.Token
+	if auth.IsOpaqueToken(tokenString) {
+		tokenString, err = s.ResolveAccessToken(ctx, tokenString)
+		if err != nil {
+			if errors.Cause(err) == ierr.ErrResourceNotFound {
+				return ResponseIntrospect{}, nil
+			}
+			return ResponseIntrospect{}, err
+		}
+	}
+
+	token, err := auth.VerifyToken(tokenString, s.keys)
+	if err != nil {
+		return ResponseIntrospect{}, nil
+	}
+	claims := token.Claims.(jwt.MapClaims)
+	if tokenType, _ := claims["token_type"].(string); tokenType != TokenTypeAccess {
+		return ResponseIntrospect{}, nil
+	}
+
+	ctx = ctxutil.WithPrincipal(ctx, token)
+	user := auth.GetLoggedInUser(ctx)
+	if user.TokenID != "" {
+		revoked, err := s.blacklist.IsRevoked(ctx, user.TokenID)
+		if err != nil || revoked {
+			return ResponseIntrospect{}, err
+		}
+	}
+	if user.SessionID != "" {
+		active, err := s.IsSessionActive(ctx, user.SessionID)
+		if err != nil || !active {
+			return ResponseIntrospect{}, err
+		}
+	}
+
+	principalType, _ := claims["principal_type"].(string)
+	if principalType == "" {
+		principalType = domain.PrincipalTypeUser
+	}
+	return ResponseIntrospect{
+		Active:        true,
+		Subject:       user.ID,
+		Username:      user.Username,
+		PrincipalType: principalType,
+		SessionID:     user.SessionID,
+		TokenID:       user.TokenID,
+		ExpiresAt:     user.ExpiresAt.Unix(),
+		Roles:         user.Roles,
+		Permissions:   user.Permissions,
+	}, nil
+}
@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/notification"
+	"go-hex/internal/repository/memory"
+	"go-hex/pkg/auth"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/shared/ctxutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpaqueAccessTokens(t *testing.T) {
+	user := domain.User{ID: "u1", Username: "jane", IsActive: true}
+	store := newTestStore(t, user)
+	cfg := &configs.Config{}
+	cfg.JWT.SigningKey = "test-signing-key"
+	cfg.JWT.TokenExpiration = 60
+	cfg.AccessToken.Format = configs.AccessTokenFormatOpaque
+	svc := NewService(cfg, store, memory.NewTokenBlacklistRepository(), memory.NewOpaqueTokenRepository(), newIdentityViews(store), logger.New("test", "test"), event.New(), notification.NewDispatcher(), noDeprecations{})
+
+	sessionID, err := svc.startSession(context.Background(), user, sessionDevice{}, upstreamSession{})
+	require.NoError(t, err)
+	opaqueToken, _, err := svc.generateAccessToken(context.Background(), user, sessionID)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(opaqueToken, auth.OpaqueTokenPrefix), opaqueToken)
+	_, err = auth.VerifyToken(opaqueToken, cfg.JWTKeys())
+	assert.Error(t, err, "the clients do not receive the signed token")
+
+	// the opaque token references the signed token verified by the middlewares
+	accessToken, err := svc.ResolveAccessToken(context.Background(), opaqueToken)
+	require.NoError(t, err)
+	token, err := auth.VerifyToken(accessToken, cfg.JWTKeys())
+	require.NoError(t, err)
+
+	res, err := svc.Introspect(context.Background(), RequestIntrospect{Token: opaqueToken})
+	require.NoError(t, err)
+	assert.True(t, res.Active)
+	assert.Equal(t, "u1", res.Subject)
+	assert.Equal(t, "jane", res.Username)
+	assert.Equal(t, domain.PrincipalTypeUser, res.PrincipalType)
+	assert.Equal(t, sessionID, res.SessionID)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), time.Unix(res.ExpiresAt, 0), time.Minute)
+
+	res, err = svc.Introspect(context.Background(), RequestIntrospect{Token: accessToken})
+	require.NoError(t, err)
+	assert.True(t, res.Active, "the signed tokens are introspected as well")
+
+	res, err = svc.Introspect(context.Background(), RequestIntrospect{Token: auth.OpaqueTokenPrefix + "unknown"})
+	require.NoError(t, err)
+	assert.Equal(t, ResponseIntrospect{}, res)
+
+	// the logout deletes the opaque token at once
+	ctx := ctxutil.WithPrincipal(context.Background(), token)
+	ctx = ctxutil.WithOpaqueToken(ctx, opaqueToken)
+	require.NoError(t, svc.Logout(ctx))
+
+	_, err = svc.ResolveAccessToken(context.Background(), opaqueToken)
+	assert.Error(t, err)
+	res, err = svc.Introspect(context.Background(), RequestIntrospect{Token: opaqueToken})
+	require.NoError(t, err)
+	assert.False(t, res.Active)
+	res, err = svc.Introspect(context.Background(), RequestIntrospect{Token: accessToken})
+	require.NoError(t, err)
+	assert.False(t, res.Active, "the signed token is revoked with its session")
+}
@@ -0,0 +1,244 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"go-hex/configs"
+	"go-hex/internal/catalog"
+	"go-hex/internal/domain"
+	"go-hex/internal/notification"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/times"
+	"go-hex/pkg/utils"
+	"go-hex/shared/ierr"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ForgotPassword sends a one-time token setting a new password to the user, by email or by sms. A new token
+// replaces the unused ones of the user. In strict enumeration mode the unknown and inactive users, and the users
+// without an address on the channel, are answered as if the token was sent, and so is a failed delivery.
+func (s *Service) ForgotPassword(ctx context.Context, req RequestForgotPassword) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return err
+	}
+	// checked first, a refused url tells nothing about the user
+	if req.ReturnTo != "" {
+		req.ReturnTo, err = configs.FromContext(ctx, s.cfg).Redirect.Allowlist.Check(req.ClientID, req.ReturnTo)
+		if err != nil {
+			return err
+		}
+	}
+	channel := notification.ChannelEmail
+	if req.Channel != "" {
+		channel = notification.Channel(req.Channel)
+	}
+
+	err = s.forgotPassword(ctx, req, channel)
+	if err != nil && s.cfg.Enumeration.Strict {
+		if e, ok := errors.Cause(err).(ierr.Error); !ok || e == ierr.ErrServiceUnavailable {
+			s.log.With(ctx).WithParam("username", req.Username).Error(errors.Wrap(err, "cannot send password reset"))
+		}
+		return nil
+	}
+	return err
+}
+
+func (s *Service) forgotPassword(ctx context.Context, req RequestForgotPassword, channel notification.Channel) error {
+	// read from the data source, so that a user deactivated on another instance is not sent a reset
+	user, err := s.repoRegitry.GetUserRepository().GetByUsername(port.BypassCache(ctx), req.Username)
+	if err != nil {
+		return err
+	}
+	if !user.IsActive {
+		return ierr.ErrUserIsNotActive
+	}
+	to := user.GetEmail()
+	if channel == notification.ChannelSMS {
+		to = user.GetPhone()
+	}
+	if to == "" {
+		return ierr.ErrPasswordResetNoAddress
+	}
+
+	b := make([]byte, passwordResetTokenSize)
+	if _, err := rand.Read(b); err != nil {
+		return errors.Wrap(err, "cannot generate password reset token")
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	now := times.Now()
+	reset := domain.PasswordReset{
+		ID:        utils.GenerateID(),
+		UserID:    user.ID,
+		TokenHash: utils.HashSHA256(token),
+		Channel:   string(channel),
+		CreatedAt: now,
+		ExpiresAt: now.Add(time.Duration(s.cfg.PasswordReset.TokenExpiration) * time.Minute),
+	}
+	_, err = s.repoRegitry.DoInTransaction(ctx, func(ctx context.Context, repoRegistry port.RepositoryRegistry) (interface{}, error) {
+		repoReset := repoRegistry.GetPasswordResetRepository()
+		if err := repoReset.InvalidateByUserID(ctx, user.ID, now); err != nil {
+			return nil, err
+		}
+		return nil, repoReset.Create(ctx, reset)
+	})
+	if err != nil {
+		return err
+	}
+
+	resetURL := ""
+	if s.cfg.PasswordReset.URL != "" {
+		u, err := url.Parse(s.cfg.PasswordReset.URL)
+		if err != nil {
+			return errors.Wrap(err, "invalid password reset url")
+		}
+		query := u.Query()
+		query.Set("token", token)
+		if req.ReturnTo != "" {
+			query.Set("return_to", req.ReturnTo)
+		}
+		u.RawQuery = query.Encode()
+		resetURL = u.String()
+	}
+	err = s.notifier.Send(ctx, channel, notification.Message{
+		UserID: user.ID,
+		To:     to,
+		Title:  "Reset your password",
+		Body:   "Set a new password with the token " + token + " before " + reset.ExpiresAt.Format(time.RFC3339) + ".",
+		Data: map[string]string{
+			"type": "password_reset",
+		},
+		Template: catalog.MessagePasswordReset,
+		Variables: map[string]string{
+			"AppName":   configs.FromContext(ctx, s.cfg).Server.NAME,
+			"Token":     token,
+			"ResetURL":  resetURL,
+			"ExpiresAt": reset.ExpiresAt.Format(time.RFC3339),
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "cannot send password reset")
+	}
+
+	s.events.Publish(ctx, event.Event{
+		Name:      domain.EventPasswordResetRequested,
+		ActorID:   user.ID,
+		SubjectID: reset.ID,
+		Attributes: map[string]interface{}{
+			"channel":    string(channel),
+			"ip_address": req.IPAddress,
+			"user_agent": req.UserAgent,
+			"expires_at": reset.ExpiresAt.Format(time.RFC3339),
+		},
+	})
+	return nil
+}
+
+// ResetPassword sets a new password with a token sent by ForgotPassword, the token is used once. Every session
+// of the user is revoked, so that the refresh tokens issued before, possibly to an attacker, cannot refresh anymore.
+func (s *Service) ResetPassword(ctx context.Context, req RequestResetPassword) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return err
+	}
+
+	reset, err := s.repoRegitry.GetPasswordResetRepository().GetByTokenHash(ctx, utils.HashSHA256(req.Token))
+	if err != nil {
+		if err == ierr.ErrResourceNotFound {
+			return s.authFailed(ctx, failureUnknownReset, ierr.ErrInvalidToken)
+		}
+		return err
+	}
+	now := times.Now()
+	if !reset.IsUsableAt(now) {
+		return s.authFailed(ctx, failureUsedReset, ierr.ErrExpiredToken)
+	}
+
+	// the token is not used by a password breaking the policy, so that the user can choose another one. The user is
+	// read from the data source, its username possibly changed on another instance.
+	user, err := s.repoRegitry.GetUserRepository().GetByID(port.BypassCache(ctx), reset.UserID)
+	if err != nil {
+		return err
+	}
+	err = configs.FromContext(ctx, s.cfg).PasswordValidator().Validate(req.Password, user.Username)
+	if err != nil {
+		return err
+	}
+
+	hashedPassword, err := s.passwords.HashAndSalt(ctx, []byte(req.Password))
+	if err != nil {
+		return passwordPoolError(err)
+	}
+
+	out, err := s.repoRegitry.DoInTransaction(ctx, func(ctx context.Context, repoRegistry port.RepositoryRegistry) (interface{}, error) {
+		used, err := repoRegistry.GetPasswordResetRepository().Use(ctx, reset.ID, now)
+		if err != nil {
+			return nil, err
+		}
+		if !used {
+			return nil, s.authFailed(ctx, failureUsedReset, ierr.ErrExpiredToken)
+		}
+
+		repoUser := repoRegistry.GetUserRepository()
+		user, err := repoUser.GetByID(ctx, reset.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if err := repoUser.Update(ctx, user.ID, domain.User{Password: hashedPassword, UpdatedAt: now}); err != nil {
+			return nil, err
+		}
+		// the refresh token stored on the user by the logins before the sessions
+		if user.RefreshToken != nil {
+			if _, err := repoUser.ClearRefreshToken(ctx, user.ID, *user.RefreshToken); err != nil {
+				return nil, err
+			}
+		}
+
+		repoSession := repoRegistry.GetSessionRepository()
+		sessions, err := repoSession.ListActiveByUserID(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, session := range sessions {
+			if err := repoSession.Revoke(ctx, session.ID); err != nil {
+				return nil, err
+			}
+		}
+		return sessions, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sessions := out.([]domain.Session)
+	for _, session := range sessions {
+		s.backchannel.notify(ctx, reset.UserID, session.ID)
+	}
+	s.events.Publish(ctx, event.Event{
+		Name:      domain.EventPasswordResetCompleted,
+		ActorID:   reset.UserID,
+		SubjectID: reset.ID,
+		Attributes: map[string]interface{}{
+			"channel":          reset.Channel,
+			"sessions_revoked": len(sessions),
+		},
+	})
+	s.log.With(ctx).WithParams(logger.Params{"user_id": reset.UserID, "sessions_revoked": len(sessions)}).Info("password reset")
+	return nil
+}
@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/notification"
+	"go-hex/internal/repository/memory"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/password"
+	"go-hex/pkg/utils"
+	"go-hex/shared/ierr"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPasswordResetService(t *testing.T, user domain.User, strict bool) (*Service, *memory.Store, *[]notification.Message, *[]event.Event) {
+	store := newTestStore(t, user)
+	for _, id := range []string{"s1", "s2"} {
+		require.NoError(t, store.GetSessionRepository().Create(context.Background(), domain.Session{ID: id, UserID: user.ID, ExpiresAt: time.Now().Add(time.Hour)}))
+	}
+	cfg := &configs.Config{}
+	cfg.Enumeration.Strict = strict
+	cfg.PasswordPool.QueueTimeout = 1000
+	cfg.PasswordReset.TokenExpiration = 15
+	cfg.PasswordReset.URL = "https://example.com/reset?lang=en"
+	events := event.New()
+	var published []event.Event
+	for _, name := range []string{domain.EventPasswordResetRequested, domain.EventPasswordResetCompleted} {
+		events.Subscribe(name, func(ctx context.Context, e event.Event) {
+			published = append(published, e)
+		})
+	}
+	var emails []notification.Message
+	notifier := notification.NewDispatcher(recordingNotifier{notification.ChannelEmail, &emails})
+	svc := NewService(cfg, store, memory.NewTokenBlacklistRepository(), memory.NewOpaqueTokenRepository(), newIdentityViews(store), logger.New("test", "test"), events, notifier, noDeprecations{})
+	return svc, store, &emails, &published
+}
+
+func TestPasswordReset(t *testing.T) {
+	email := "jane@example.com"
+	user := domain.User{ID: "u1", Username: "jane", Email: &email, IsActive: true}
+	svc, store, emails, published := newPasswordResetService(t, user, false)
+
+	require.NoError(t, svc.ForgotPassword(context.Background(), RequestForgotPassword{Username: "jane"}))
+	require.Len(t, *emails, 1)
+	msg := (*emails)[0]
+	assert.Equal(t, email, msg.To)
+	token := msg.Variables["Token"]
+	assert.NotEmpty(t, token)
+	resetURL, err := url.Parse(msg.Variables["ResetURL"])
+	require.NoError(t, err)
+	assert.Equal(t, token, resetURL.Query().Get("token"))
+	assert.Equal(t, "en", resetURL.Query().Get("lang"))
+
+	// only the hash of the token is stored
+	resets := store.GetPasswordResetRepository()
+	_, err = resets.GetByTokenHash(context.Background(), token)
+	assert.Equal(t, ierr.ErrResourceNotFound, err)
+	reset, err := resets.GetByTokenHash(context.Background(), utils.HashSHA256(token))
+	require.NoError(t, err)
+	assert.Equal(t, "u1", reset.UserID)
+	assert.WithinDuration(t, time.Now().Add(15*time.Minute), reset.ExpiresAt, time.Minute)
+
+	// a new token invalidates the previous one
+	require.NoError(t, svc.ForgotPassword(context.Background(), RequestForgotPassword{Username: "jane"}))
+	require.Len(t, *emails, 2)
+	assert.Equal(t, ierr.ErrExpiredToken, svc.ResetPassword(context.Background(), RequestResetPassword{Token: token, Password: "new-password"}))
+	token = (*emails)[1].Variables["Token"]
+
+	// a password breaking the policy does not use the token
+	svc.cfg.PasswordPolicy.BanCommon = true
+	svc.cfg.PasswordPolicy.DisallowUsername = true
+	assert.Equal(t, ierr.ErrPasswordTooCommon, svc.ResetPassword(context.Background(), RequestResetPassword{Token: token, Password: "password123"}))
+	assert.Equal(t, ierr.ErrPasswordContainsUsername, svc.ResetPassword(context.Background(), RequestResetPassword{Token: token, Password: "jane-password"}))
+	assert.Empty(t, getUser(t, store, "u1").Password)
+
+	require.NoError(t, svc.ResetPassword(context.Background(), RequestResetPassword{Token: token, Password: "new-password"}))
+	assert.True(t, password.ComparePasswords(getUser(t, store, "u1").Password, []byte("new-password")))
+	active, err := store.GetSessionRepository().ListActiveByUserID(context.Background(), "u1")
+	require.NoError(t, err)
+	assert.Empty(t, active)
+	if assert.Len(t, *published, 3) {
+		assert.Equal(t, domain.EventPasswordResetCompleted, (*published)[2].Name)
+		assert.Equal(t, 2, (*published)[2].Attributes["sessions_revoked"])
+	}
+
+	// the token is used once
+	assert.Equal(t, ierr.ErrExpiredToken, svc.ResetPassword(context.Background(), RequestResetPassword{Token: token, Password: "other-password"}))
+	assert.Equal(t, ierr.ErrInvalidToken, svc.ResetPassword(context.Background(), RequestResetPassword{Token: "unknown", Password: "other-password"}))
+}
+
+func TestForgotPasswordReturnTo(t *testing.T) {
+	email := "jane@example.com"
+	user := domain.User{ID: "u1", Username: "jane", Email: &email, IsActive: true}
+
+	for _, strict := range []bool{false, true} {
+		svc, _, emails, _ := newPasswordResetService(t, user, strict)
+		require.NoError(t, svc.cfg.Redirect.Allowlist.Decode("web=https://app.example.com/*"))
+
+		// refused even in strict mode, the url tells nothing about the user
+		err := svc.ForgotPassword(context.Background(), RequestForgotPassword{Username: "john", ClientID: "web", ReturnTo: "https://evil.example.com/login"})
+		assert.Equal(t, ierr.ErrRedirectNotAllowed, errors.Cause(err))
+
+		require.NoError(t, svc.ForgotPassword(context.Background(), RequestForgotPassword{Username: "jane", ClientID: "web", ReturnTo: "https://app.example.com/login?next=%2Fbilling"}))
+		require.Len(t, *emails, 1)
+		resetURL, err := url.Parse((*emails)[0].Variables["ResetURL"])
+		require.NoError(t, err)
+		assert.Equal(t, "https://app.example.com/login?next=%2Fbilling", resetURL.Query().Get("return_to"))
+	}
+}
+
+func TestForgotPasswordEnumeration(t *testing.T) {
+	email := "jane@example.com"
+	tests := []struct {
+		name    string
+		user    domain.User
+		req     RequestForgotPassword
+		wantErr error
+	}{
+		{"unknown user", domain.User{ID: "u1", Username: "jane", Email: &email, IsActive: true}, RequestForgotPassword{Username: "john"}, ierr.ErrResourceNotFound},
+		{"inactive user", domain.User{ID: "u1", Username: "jane", Email: &email}, RequestForgotPassword{Username: "jane"}, ierr.ErrUserIsNotActive},
+		{"no phone number", domain.User{ID: "u1", Username: "jane", Email: &email, IsActive: true}, RequestForgotPassword{Username: "jane", Channel: "sms"}, ierr.ErrPasswordResetNoAddress},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _, _, _ := newPasswordResetService(t, tt.user, false)
+			assert.Equal(t, tt.wantErr, svc.ForgotPassword(context.Background(), tt.req))
+
+			svc, store, emails, _ := newPasswordResetService(t, tt.user, true)
+			created := 0
+			store.SetHook(func(ctx context.Context, op string) error {
+				if op == "PasswordResetRepository.Create" {
+					created++
+				}
+				return nil
+			})
+			assert.NoError(t, svc.ForgotPassword(context.Background(), tt.req))
+			assert.Empty(t, *emails)
+			assert.Zero(t, created)
+		})
+	}
+}
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"context"
+	"go-hex/internal/domain"
 )
 
 // ServicePort encapsulates the authentication logic.
@@ -11,6 +12,40 @@ type ServicePort interface {
 	Login(ctx context.Context, req RequestLogin) (ResponseLogin, error)
 	// RefreshToken refresh the access token
 	RefreshToken(ctx context.Context, req RequestRefreshToken) (ResponseLogin, error)
+	// ListLoginApprovals returns the pending login approvals of the logged in user
+	ListLoginApprovals(ctx context.Context) ([]ResponsePendingLoginApproval, error)
+	// DecideLoginApproval approves or denies a pending login of the logged in user
+	DecideLoginApproval(ctx context.Context, req RequestDecideLoginApproval) error
+	// ExchangeLoginApproval issues the tokens of an approved login
+	ExchangeLoginApproval(ctx context.Context, req RequestLoginApprovalToken) (ResponseLogin, error)
+	// StartDeviceLogin starts a cross-device login
+	StartDeviceLogin(ctx context.Context) (ResponseDeviceLoginStart, error)
+	// DecideDeviceLogin approves or denies a device login on behalf of the logged in user
+	DecideDeviceLogin(ctx context.Context, req RequestDeviceLoginDecision) error
+	// PollDeviceLogin issues the tokens of an approved device login
+	PollDeviceLogin(ctx context.Context, req RequestDeviceLoginPoll) (ResponseLogin, error)
+	// Logout revokes the session of the logged in user
+	Logout(ctx context.Context) error
+	// ListSessions returns the active sessions of the logged in user, one per device
+	ListSessions(ctx context.Context) ([]ResponseSession, error)
+	// RevokeSession revokes a session of the logged in user, e.g. of a lost device
+	RevokeSession(ctx context.Context, req RequestRevokeSession) error
+	// BackchannelLogout revokes the sessions referenced by an OpenID Connect logout token
+	BackchannelLogout(ctx context.Context, req RequestBackchannelLogout) error
+	// ForgotPassword sends a one-time token setting a new password to the user
+	ForgotPassword(ctx context.Context, req RequestForgotPassword) error
+	// ResetPassword sets a new password with a token sent by ForgotPassword and signs the user out everywhere
+	ResetPassword(ctx context.Context, req RequestResetPassword) error
+	// LoginWithProvider logs in with the authorization code of a social login provider
+	LoginWithProvider(ctx context.Context, req RequestSocialLogin) (ResponseLogin, error)
+	// LinkProvider links the account at a social login provider to the logged in user
+	LinkProvider(ctx context.Context, req RequestSocialLogin) error
+	// IsSessionActive checks whether the session of an access token is neither revoked nor expired
+	IsSessionActive(ctx context.Context, sessionID string) (bool, error)
+	// ResolveAccessToken returns the signed access token referenced by an opaque access token
+	ResolveAccessToken(ctx context.Context, opaqueToken string) (string, error)
+	// Introspect tells whether an access token, opaque or signed, is active and returns its claims
+	Introspect(ctx context.Context, req RequestIntrospect) (ResponseIntrospect, error)
 }
 
 // Identity represents an authenticated user iddomain.
@@ -21,4 +56,23 @@ type Identity interface {
 	GetUsername() string
 	// GetPassword returns password
 	GetPassword() string
+	// GetMaxSessions returns the maximum number of concurrent sessions, nil means the default applies.
+	GetMaxSessions() *int
+}
+
+// IdentityViewReader reads the identity view of a user, read to issue its tokens.
+type IdentityViewReader interface {
+	Get(ctx context.Context, userID string) (domain.IdentityView, error)
+}
+
+// DeprecationRecorder records the use of deprecated fields or behaviours during a request.
+type DeprecationRecorder interface {
+	Field(ctx context.Context, name string)
+}
+
+// SocialProvider authenticates the users with an external identity provider, e.g. Google or GitHub.
+type SocialProvider interface {
+	// Exchange exchanges the authorization code for the account of the user, checking the nonce when given.
+	// The code was requested with redirectURI, the configured redirect url when empty.
+	Exchange(ctx context.Context, code, codeVerifier, nonce, redirectURI string) (SocialAccount, error)
 }
@@ -3,28 +3,73 @@ package auth
 import (
 	"context"
 	"go-hex/configs"
+	"go-hex/internal/catalog"
 	"go-hex/internal/domain"
+	"go-hex/internal/notification"
 	"go-hex/internal/repository/port"
 	"go-hex/pkg/auth"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/metrics"
 	"go-hex/pkg/otel"
 	"go-hex/pkg/password"
 	"go-hex/pkg/times"
+	"go-hex/pkg/utils"
+	"go-hex/shared/ctxutil"
 	"go-hex/shared/ierr"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/errgroup"
+)
+
+var (
+	loginAttempts = metrics.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_login_attempts_total",
+		Help: "Number of password logins, by outcome (success, failure, approval_required or error).",
+	}, "outcome")
+	tokenIssuance = metrics.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "auth_token_issuance_duration_seconds",
+		Help:    "Duration of the issuance of the access and refresh tokens, by grant (login or refresh_token).",
+		Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, "grant", "result")
+	sessionsStarted = metrics.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_sessions_started_total",
+		Help: "Number of sessions started, by client app, version and platform of the X-Client-Info header of their login.",
+	}, "client_app", "client_version", "client_platform")
 )
 
 // Service encapsulates the authentication logic.
 type Service struct {
-	cfg         *configs.Config
-	repoRegitry port.RepositoryRegistry
+	cfg          *configs.Config
+	repoRegitry  port.RepositoryRegistry
+	backchannel  *backchannelNotifier
+	providers    map[string]SocialProvider
+	events       event.Bus
+	notifier     *notification.Dispatcher
+	deprecations DeprecationRecorder
+	keys         *auth.Keys
+	signer       *auth.Signer
+	passwords    *password.Pool
+	dummyHash    string
+	blacklist    port.TokenBlacklistRepository
+	opaque       port.OpaqueTokenRepository
+	identities   IdentityViewReader
+	log          logger.Logger
 }
 
 // NewService creates and returns a new auth service
-func NewService(cfg *configs.Config, repoRegitry port.RepositoryRegistry) *Service {
-	return &Service{cfg, repoRegitry}
+func NewService(cfg *configs.Config, repoRegitry port.RepositoryRegistry, blacklist port.TokenBlacklistRepository, opaque port.OpaqueTokenRepository, identities IdentityViewReader, log logger.Logger, events event.Bus, notifier *notification.Dispatcher, deprecations DeprecationRecorder) *Service {
+	keys := cfg.JWTKeys()
+	return &Service{cfg, repoRegitry, newBackchannelNotifier(cfg, log), newSocialProviders(cfg), events, notifier, deprecations, keys, keys.Signer(), newPasswordPool(cfg), newDummyHash(cfg), blacklist, opaque, identities, log}
+}
+
+// Passwords returns the pool hashing and comparing the passwords of the logins, shared with the services hashing the
+// passwords of the new users so that together they are bounded by its workers.
+func (s *Service) Passwords() *password.Pool {
+	return s.passwords
 }
 
 // Login authenticates a user and generates a JWT token if authentication succeeds.
@@ -34,24 +79,83 @@ func (s *Service) Login(ctx context.Context, req RequestLogin) (ResponseLogin, e
 	ctx, span := otel.Start(ctx)
 	defer span.End()
 
+	start := time.Now()
 	var res ResponseLogin
 
+	outcome := loginOutcomeError
+	defer func() { loginAttempts.WithLabelValues(outcome).Inc() }()
+
 	err := req.Validate()
 	if err != nil {
+		outcome = loginOutcomeFailure
 		return res, err
 	}
 
 	identity, err := s.authenticate(ctx, req.Username, req.Password)
 	if err != nil {
+		if e, ok := errors.Cause(err).(ierr.Error); ok {
+			outcome = loginOutcomeFailure
+			s.events.Publish(ctx, event.Event{
+				Name:      domain.EventLoginFailed,
+				SubjectID: req.Username,
+				Attributes: map[string]interface{}{
+					"username":   req.Username,
+					"ip_address": req.IPAddress,
+					"user_agent": req.UserAgent,
+					"code":       e.Code,
+					"reason":     e.Message,
+				},
+			})
+		}
 		return res, err
 	}
 
-	accessToken, expiresAt, refreshToken, err := s.generateJWT(ctx, identity)
+	if configs.FromContext(ctx, s.cfg).LoginApproval.Enabled {
+		// only users signed in on another device can approve the login, the others get a normal login
+		enrolled, err := s.repoRegitry.GetSessionRepository().CountActiveByUserID(ctx, identity.GetID())
+		if err != nil {
+			return res, err
+		}
+		if enrolled > 0 {
+			otel.Event(ctx, otel.EventLoginApprovalRequired, otel.AttributeActiveSessions.Int(enrolled))
+			approval, err := s.requestLoginApproval(ctx, identity, req)
+			if err != nil {
+				return res, err
+			}
+			outcome = loginOutcomeApprovalRequired
+			return ResponseLogin{Approval: &approval}, nil
+		}
+	}
+
+	// always start a new session on login so that a session id can never be fixated by the client
+	sessionID, err := s.startSession(ctx, identity, sessionDevice{req.IPAddress, req.UserAgent, req.Client}, upstreamSession{})
+	if err != nil {
+		return res, err
+	}
+
+	accessToken, expiresAt, refreshToken, err := s.generateJWT(ctx, identity, sessionID, "")
+	if err != nil {
+		return res, err
+	}
+
+	s.events.Publish(ctx, event.Event{
+		Name:      domain.EventLoginSucceeded,
+		ActorID:   identity.GetID(),
+		SubjectID: sessionID,
+		Attributes: map[string]interface{}{
+			"username":    req.Username,
+			"ip_address":  req.IPAddress,
+			"user_agent":  req.UserAgent,
+			"duration_ms": float64(time.Since(start).Microseconds()) / 1000, // compared by the user cache between warm and cold users
+		},
+	})
+	outcome = loginOutcomeSuccess
+
 	return ResponseLogin{
 		AccessToken:  accessToken,
 		ExpiresAt:    expiresAt.Format(time.RFC3339),
 		RefreshToken: refreshToken,
-	}, err
+	}, nil
 
 }
 
@@ -68,9 +172,9 @@ func (s *Service) RefreshToken(ctx context.Context, req RequestRefreshToken) (Re
 		return res, err
 	}
 
-	token, err := auth.VerifyToken(req.RefreshToken, s.cfg.JWT.SigningKey)
+	token, err := auth.VerifyToken(req.RefreshToken, s.keys)
 	if err != nil {
-		return res, ierr.ErrInvalidToken
+		return res, s.authFailed(ctx, failureInvalidToken, ierr.ErrInvalidToken)
 	}
 	claims := token.Claims.(jwt.MapClaims)
 	var tokenType string
@@ -79,7 +183,7 @@ func (s *Service) RefreshToken(ctx context.Context, req RequestRefreshToken) (Re
 	}
 
 	if tokenType != TokenTypeRefresh {
-		return res, ierr.ErrInvalidToken
+		return res, s.authFailed(ctx, failureWrongTokenType, ierr.ErrInvalidToken)
 	}
 
 	var id string
@@ -93,16 +197,341 @@ func (s *Service) RefreshToken(ctx context.Context, req RequestRefreshToken) (Re
 		return res, err
 	}
 
-	if !password.ComparePasswords(*user.RefreshToken, []byte(req.RefreshToken)) {
-		return res, ierr.ErrExpiredToken
+	var sessionID, rotatedID string
+	if val, ok := claims["sid"].(string); ok {
+		sessionID = val
+	}
+
+	if sessionID == "" {
+		// refresh tokens issued before sessions were introduced are stored on the user
+		s.deprecations.Field(ctx, "refresh_token_without_session")
+		otel.Event(ctx, otel.EventLegacyRefreshToken)
+		if user.RefreshToken == nil {
+			return res, s.authFailed(ctx, failureRefreshReused, ierr.ErrExpiredToken)
+		}
+		match, err := s.compareRefreshToken(ctx, *user.RefreshToken, req.RefreshToken)
+		if err != nil {
+			return res, err
+		}
+		if !match {
+			return res, s.authFailed(ctx, failureRefreshMismatch, ierr.ErrExpiredToken)
+		}
+		// the token is migrated to a session, so it is cleared in the same transaction to be usable only once
+		legacyToken := *user.RefreshToken
+		sessionID, err = s.startSessionWith(ctx, user, sessionDevice{}, upstreamSession{}, func(ctx context.Context, repoRegistry port.RepositoryRegistry) error {
+			cleared, err := repoRegistry.GetUserRepository().ClearRefreshToken(ctx, user.ID, legacyToken)
+			if err != nil {
+				return err
+			}
+			if !cleared {
+				return s.authFailed(ctx, failureRefreshReused, ierr.ErrExpiredToken)
+			}
+			return nil
+		})
+		if err != nil {
+			return res, err
+		}
+	} else {
+		session, err := s.repoRegitry.GetSessionRepository().GetByID(ctx, sessionID)
+		if err != nil {
+			if err == ierr.ErrResourceNotFound {
+				return res, s.authFailed(ctx, failureUnknownSession, ierr.ErrInvalidToken)
+			}
+			return res, err
+		}
+		if session.UserID != user.ID {
+			return res, s.authFailed(ctx, failureSessionMismatch, ierr.ErrInvalidToken)
+		}
+		now := times.Now()
+		if !session.IsActive(now) {
+			return res, s.authFailed(ctx, failureExpiredSession, ierr.ErrExpiredToken)
+		}
+		if val, ok := claims["jti"].(string); ok {
+			rotatedID = val
+		}
+		if rotatedID == "" {
+			// refresh tokens issued before the rotation are only checked against the hash stored on the session,
+			// which is cleared so that they are used once
+			s.deprecations.Field(ctx, "refresh_token_without_jti")
+			if session.RefreshToken == nil {
+				return res, s.authFailed(ctx, failureRefreshReused, ierr.ErrExpiredToken)
+			}
+			match, err := s.compareRefreshToken(ctx, *session.RefreshToken, req.RefreshToken)
+			if err != nil {
+				return res, err
+			}
+			if !match {
+				return res, s.authFailed(ctx, failureRefreshMismatch, ierr.ErrExpiredToken)
+			}
+			cleared, err := s.repoRegitry.GetSessionRepository().ClearRefreshToken(ctx, sessionID, *session.RefreshToken)
+			if err != nil {
+				return res, err
+			}
+			if !cleared {
+				return res, s.authFailed(ctx, failureRefreshReused, ierr.ErrExpiredToken)
+			}
+		} else {
+			token, err := s.repoRegitry.GetRefreshTokenRepository().GetByID(ctx, rotatedID)
+			if err != nil {
+				if err == ierr.ErrResourceNotFound {
+					return res, s.authFailed(ctx, failureUnknownRefresh, ierr.ErrInvalidToken)
+				}
+				return res, err
+			}
+			if token.SessionID != sessionID {
+				return res, s.authFailed(ctx, failureSessionMismatch, ierr.ErrInvalidToken)
+			}
+			if token.IsRotated() {
+				return res, s.revokeFamily(ctx, user, token)
+			}
+			if token.IsExpiredAt(now) {
+				return res, s.authFailed(ctx, failureExpiredSession, ierr.ErrExpiredToken)
+			}
+		}
+	}
+
+	accessToken, expiresAt, refreshToken, err := s.generateJWT(ctx, user, sessionID, rotatedID)
+	if err != nil {
+		return res, err
 	}
 
-	accessToken, expiresAt, refreshToken, err := s.generateJWT(ctx, user)
+	s.events.Publish(ctx, event.Event{
+		Name:      domain.EventTokenRefreshed,
+		ActorID:   user.ID,
+		SubjectID: sessionID,
+		Attributes: map[string]interface{}{
+			"username": user.Username,
+		},
+	})
+
 	return ResponseLogin{
 		AccessToken:  accessToken,
 		ExpiresAt:    expiresAt.Format(time.RFC3339),
 		RefreshToken: refreshToken,
-	}, err
+	}, nil
+}
+
+// Logout revokes the session of the logged in user, clearing its refresh token, and the access token until it
+// expires, then notifies the registered clients through the OpenID Connect backchannel.
+func (s *Service) Logout(ctx context.Context) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	user := auth.GetLoggedInUser(ctx)
+	if user.SessionID == "" {
+		return ierr.ErrInvalidToken
+	}
+
+	err := s.repoRegitry.GetSessionRepository().Revoke(ctx, user.SessionID)
+	if err != nil {
+		return err
+	}
+
+	// the access tokens issued before the jti claim are only rejected through their revoked session
+	if user.TokenID != "" {
+		err = s.blacklist.Revoke(ctx, user.TokenID, user.ExpiresAt)
+		if err != nil {
+			return err
+		}
+	}
+	if opaqueToken, ok := ctxutil.OpaqueToken(ctx); ok {
+		err = s.opaque.Delete(ctx, auth.OpaqueTokenDigest(opaqueToken))
+		if err != nil {
+			return err
+		}
+	}
+
+	s.backchannel.notify(ctx, user.ID, user.SessionID)
+	return nil
+}
+
+// BackchannelLogout validates a logout token sent by the upstream OpenID provider
+// and revokes the corresponding local sessions.
+// A sid claim revokes the sessions started from that upstream session,
+// a sub claim without sid revokes every session started for that upstream user.
+// The jti of the token is recorded in the token blacklist until the token expires, so that it is accepted once; it is
+// recorded once the sessions are revoked so that the provider can retry a failed logout, a replay received meanwhile
+// revoking the same sessions again.
+func (s *Service) BackchannelLogout(ctx context.Context, req RequestBackchannelLogout) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return err
+	}
+
+	claims, err := parseLogoutToken(s.cfg, req.LogoutToken)
+	if err != nil {
+		return err
+	}
+	replayed, err := s.blacklist.IsRevoked(ctx, logoutTokenPrefix+claims.TokenID)
+	if err != nil {
+		return err
+	}
+	if replayed {
+		return errors.Wrap(ierr.ErrInvalidToken, "logout token replayed")
+	}
+
+	// the sid and sub of the logout token are identifiers of the upstream provider,
+	// they only match the local sessions which recorded them when they were started
+	repoSession := s.repoRegitry.GetSessionRepository()
+	if claims.SessionID != "" {
+		err = repoSession.RevokeByUpstreamSessionID(ctx, claims.SessionID, claims.Subject)
+	} else {
+		err = repoSession.RevokeByUpstreamSubject(ctx, claims.Subject)
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.blacklist.Revoke(ctx, logoutTokenPrefix+claims.TokenID, claims.ExpiresAt)
+}
+
+// IsSessionActive checks whether the session of an access token is neither revoked nor expired.
+func (s *Service) IsSessionActive(ctx context.Context, sessionID string) (bool, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	session, err := s.repoRegitry.GetSessionRepository().GetByID(ctx, sessionID)
+	if err != nil {
+		if err == ierr.ErrResourceNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return session.IsActive(times.Now()), nil
+}
+
+// upstreamSession identifies the session of an upstream OpenID provider a local session is started from.
+// It is empty for the sessions started by a local login.
+type upstreamSession struct {
+	SessionID string
+	Subject   string
+}
+
+// sessionDevice describes the device a session is started on, read from the request of its login.
+// It is empty when the session is not started by a request of the device.
+type sessionDevice struct {
+	IPAddress string
+	UserAgent string
+	Client    domain.ClientInfo
+}
+
+// startSession creates a new session for the given identity on the device and returns the session id.
+// Federated logins pass the upstream session so that a backchannel logout of the provider can revoke it.
+// When the identity already reached its maximum number of concurrent sessions,
+// the login is either rejected or the oldest sessions are evicted according to the configured policy.
+func (s *Service) startSession(ctx context.Context, identity Identity, device sessionDevice, upstream upstreamSession) (string, error) {
+	return s.startSessionWith(ctx, identity, device, upstream, nil)
+}
+
+// startSessionWith starts a session like startSession, running before in the same transaction first when given.
+func (s *Service) startSessionWith(ctx context.Context, identity Identity, device sessionDevice, upstream upstreamSession, before func(ctx context.Context, repoRegistry port.RepositoryRegistry) error) (string, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	now := times.Now()
+	session := domain.Session{
+		ID:        utils.GenerateID(),
+		UserID:    identity.GetID(),
+		CreatedAt: now,
+		ExpiresAt: now.AddDate(1000, 0, 0),
+	}
+	if upstream.SessionID != "" {
+		session.UpstreamSessionID = &upstream.SessionID
+	}
+	if upstream.Subject != "" {
+		session.UpstreamSubject = &upstream.Subject
+	}
+	if device.IPAddress != "" {
+		session.IPAddress = &device.IPAddress
+	}
+	if device.UserAgent != "" {
+		session.UserAgent = &device.UserAgent
+	}
+	if device.Client.App != "" {
+		session.ClientApp = &device.Client.App
+	}
+	if device.Client.Version != "" {
+		session.ClientVersion = &device.Client.Version
+	}
+	if device.Client.Platform != "" {
+		session.ClientPlatform = &device.Client.Platform
+	}
+
+	limit := s.cfg.Session.MaxConcurrent
+	if userLimit := identity.GetMaxSessions(); userLimit != nil {
+		limit = *userLimit
+	}
+
+	out, err := s.repoRegitry.DoInTransaction(ctx, func(ctx context.Context, repoRegistry port.RepositoryRegistry) (interface{}, error) {
+		if before != nil {
+			if err := before(ctx, repoRegistry); err != nil {
+				return nil, err
+			}
+		}
+
+		repoSession := repoRegistry.GetSessionRepository()
+
+		var evicted []domain.Session
+		if limit > 0 {
+			active, err := repoSession.ListActiveByUserID(ctx, identity.GetID())
+			if err != nil {
+				return nil, err
+			}
+
+			if len(active) >= limit {
+				if s.cfg.Session.LimitPolicy == configs.SessionLimitPolicyReject {
+					otel.Event(ctx, otel.EventSessionLimitReached, otel.AttributeSessionLimit.Int(limit), otel.AttributeSessionLimitPolicy.String(string(s.cfg.Session.LimitPolicy)))
+					return nil, s.authFailed(ctx, failureSessionLimit, ierr.ErrSessionLimitReached)
+				}
+
+				evicted = sessionsToEvict(active, limit)
+				otel.Event(ctx, otel.EventSessionLimitReached, otel.AttributeSessionLimit.Int(limit), otel.AttributeSessionLimitPolicy.String(string(s.cfg.Session.LimitPolicy)), otel.AttributeSessionsEvicted.Int(len(evicted)))
+				for _, item := range evicted {
+					err = repoSession.Revoke(ctx, item.ID)
+					if err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+
+		return evicted, repoSession.Create(ctx, session)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	for _, item := range out.([]domain.Session) {
+		s.events.Publish(ctx, event.Event{
+			Name:      domain.EventSessionEvicted,
+			ActorID:   identity.GetID(),
+			SubjectID: item.ID,
+			Attributes: map[string]interface{}{
+				"user_id":        identity.GetID(),
+				"new_session_id": session.ID,
+				"limit":          limit,
+			},
+		})
+		s.backchannel.notify(ctx, identity.GetID(), item.ID)
+	}
+	sessionsStarted.WithLabelValues(clientLabel(device.Client.App), clientLabel(device.Client.Version), clientLabel(device.Client.Platform)).Inc()
+
+	return session.ID, nil
+}
+
+// clientLabel returns the part of the client info labelling the metrics, unknown when not sent
+func clientLabel(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+	return value
 }
 
 // authenticate authenticates a user using username and password.
@@ -112,88 +541,408 @@ func (s *Service) authenticate(ctx context.Context, username, plainPwd string) (
 	ctx, span := otel.Start(ctx)
 	defer span.End()
 
+	// the credentials are read from the data source, the caches of this instance missing the password changed or the
+	// user deactivated on another one
 	repoUser := s.repoRegitry.GetUserRepository()
-	user, err := repoUser.GetByUsername(ctx, username)
+	user, err := repoUser.GetByUsername(port.BypassCache(ctx), username)
 	if err != nil {
 		if err == ierr.ErrResourceNotFound {
-			return nil, ierr.ErrInvalidCreds
+			if s.cfg.Enumeration.Strict {
+				// compare anyway so that an unknown user takes as long as a wrong password
+				if _, err := s.comparePassword(ctx, s.dummyHash, []byte(plainPwd)); err != nil {
+					return nil, err
+				}
+			}
+			return nil, s.authFailed(ctx, failureUnknownUser, ierr.ErrInvalidCreds)
 		}
 		return nil, err
 	}
 
-	if username == user.GetUsername() && password.ComparePasswords(user.GetPassword(), []byte(plainPwd)) {
+	match, err := s.comparePassword(ctx, user.GetPassword(), []byte(plainPwd))
+	if err != nil {
+		return nil, err
+	}
+
+	if username == user.GetUsername() && match {
 		// user is not active
 		if !user.IsActive {
-			return nil, ierr.ErrUserIsNotActive
+			if s.cfg.Enumeration.Strict {
+				return nil, s.authFailed(ctx, failureInactiveUser, ierr.ErrInvalidCreds)
+			}
+			return nil, s.authFailed(ctx, failureInactiveUser, ierr.ErrUserIsNotActive)
 		}
+		// the break-glass accounts only log in while activated
+		until, err := s.breakGlassUntil(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		if until != nil {
+			s.events.Publish(ctx, event.Event{
+				Name:      domain.EventBreakGlassLogin,
+				ActorID:   user.ID,
+				SubjectID: user.ID,
+				Attributes: map[string]interface{}{
+					"username":   user.Username,
+					"expires_at": until.Format(time.RFC3339),
+				},
+			})
+		}
+		s.upgradePassword(ctx, user, plainPwd)
 		// authentication successful
 		return user, nil
 	}
 
 	// authentication failed
-	return nil, ierr.ErrInvalidCreds
+	return nil, s.authFailed(ctx, failureWrongPassword, ierr.ErrInvalidCreds)
 
 }
 
-// generateJWT generates a JWT
-func (s *Service) generateJWT(ctx context.Context, identity Identity) (accessToken string, expiresAt time.Time, refreshToken string, err error) {
+// authFailed records the reason of the authentication failure on the span, and logs it with the code of the error
+// through the logger of the request
+func (s *Service) authFailed(ctx context.Context, reason string, err error) error {
+	params := logger.Params{"type": "auth_failure", "reason": reason}
+	if e, ok := errors.Cause(err).(ierr.Error); ok {
+		params["error_code"] = e.Code
+	}
+	logger.FromContext(ctx, s.log).WithParams(params).Infof("authentication failed: %s", reason)
+	return otel.AuthFailed(ctx, reason, err)
+}
+
+// generateJWT generates a JWT, rotating the given refresh token of the session into the new one when not empty.
+// A refresh token rotated concurrently is reused, so the family of the session is revoked.
+//
+// The access token and the refresh token are generated concurrently. The given refresh token is only rotated once
+// both succeeded, so that a failure does not burn it and its retry is not taken for a reuse. The refresh token
+// itself is not stored: it references by its jti the record of its session and its expiry, which the refresh checks.
+func (s *Service) generateJWT(ctx context.Context, identity Identity, sessionID string, rotatedID string) (accessToken string, expiresAt time.Time, refreshToken string, err error) {
 
 	ctx, span := otel.Start(ctx)
 	defer span.End()
 
-	//generate access token
-	accessToken, expiresAt, err = s.generateAccessToken(ctx, identity)
-	if err != nil {
-		return
-	}
-	// generate refresh token
-	refreshToken, err = s.generateRefreshToken(ctx, identity)
-	if err != nil {
-		return
+	defer func(start time.Time) {
+		grant, result := grantLogin, "ok"
+		if rotatedID != "" {
+			grant = grantRefreshToken
+		}
+		if err != nil {
+			result = "error"
+		}
+		tokenIssuance.WithLabelValues(grant, result).Observe(time.Since(start).Seconds())
+	}(time.Now())
+
+	// the tokens are signed concurrently, then their records written at once
+	var token domain.RefreshToken
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() (err error) {
+		accessToken, expiresAt, err = s.generateAccessToken(gctx, identity, sessionID)
+		return err
+	})
+	g.Go(func() (err error) {
+		refreshToken, token, err = s.generateRefreshToken(gctx, identity, sessionID)
+		return err
+	})
+	if err = g.Wait(); err != nil {
+		return "", time.Time{}, "", err
 	}
 
-	// hash refresh token
-	hashedRefreshToken, err := password.HashAndSalt([]byte(refreshToken))
-	user := domain.User{
-		ID:           identity.GetID(),
-		RefreshToken: &refreshToken,
+	// the refresh token is only recorded with the rotation of the presented one, a token rotated concurrently
+	// rolls the new one back before revoking the family out of the transaction
+	_, err = s.repoRegitry.DoInTransaction(ctx, func(ctx context.Context, repoRegistry port.RepositoryRegistry) (interface{}, error) {
+		repoToken := repoRegistry.GetRefreshTokenRepository()
+		if err := repoToken.Create(ctx, token); err != nil {
+			return nil, err
+		}
+		if err := repoRegistry.GetSessionRepository().Touch(ctx, sessionID, times.Now()); err != nil {
+			return nil, err
+		}
+		if rotatedID == "" {
+			return nil, nil
+		}
+		rotated, err := repoToken.Rotate(ctx, rotatedID, token.ID, times.Now())
+		if err == nil && !rotated {
+			err = errRotatedConcurrently
+		}
+		return nil, err
+	})
+	if errors.Cause(err) == errRotatedConcurrently {
+		err = s.revokeRotated(ctx, identity, rotatedID)
 	}
 	if err != nil {
-		return
+		return "", time.Time{}, "", err
 	}
-	user.RefreshToken = &hashedRefreshToken
-	repoUser := s.repoRegitry.GetUserRepository()
-	err = repoUser.Update(ctx, identity.GetID(), user)
 	return
 }
 
-func (s *Service) generateAccessToken(ctx context.Context, identity Identity) (accessToken string, expiresAt time.Time, err error) {
+func (s *Service) generateAccessToken(ctx context.Context, identity Identity, sessionID string) (accessToken string, expiresAt time.Time, err error) {
 
-	_, span := otel.Start(ctx)
+	ctx, span := otel.Start(ctx)
 	defer span.End()
 
-	expiresAt = times.Now().Add(time.Duration(s.cfg.JWT.TokenExpiration) * time.Minute)
-	expiresAtUnix := times.Now().Add(time.Duration(s.cfg.JWT.TokenExpiration) * time.Minute).Unix()
-	accessToken, err = jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	now := times.Now()
+	expiresAt = now.Add(time.Duration(configs.FromContext(ctx, s.cfg).JWT.TokenExpiration) * time.Minute)
+	claims := jwt.MapClaims{
 		"id":         identity.GetID(),
-		"username":   identity.GetUsername(),
-		"exp":        expiresAtUnix,
+		"sid":        sessionID,
+		"jti":        utils.GenerateID(), // revoked by the logout until the token expires
 		"token_type": TokenTypeAccess,
-	}).SignedString([]byte(s.cfg.JWT.SigningKey))
-	err = errors.Wrap(err, "cannot generate token")
+	}
+	// the subject-only tokens do not spread the username to the logs of the services, which read it from /userinfo
+	if !s.cfg.JWT.SubjectOnly {
+		claims["username"] = identity.GetUsername()
+	}
+
+	// the roles and the break-glass activation are read at once from the identity view of the user
+	view, err := s.identities.Get(ctx, identity.GetID())
+	if err != nil {
+		return
+	}
+
+	// the tokens of a break-glass account expire with its activation, and are refused once it ended
+	if view.BreakGlass != nil {
+		if !view.BreakGlass.IsActiveAt(now) {
+			err = s.authFailed(ctx, failureSealedAccount, ierr.ErrInvalidCreds)
+			return
+		}
+		if view.BreakGlass.ExpiresAt.Before(expiresAt) {
+			expiresAt = *view.BreakGlass.ExpiresAt
+		}
+	}
+
+	// the roles of the approved elevations are granted until the first of them ends, the token expires then
+	for _, elevation := range view.ActiveElevations(now) {
+		if elevation.EndsAt.Before(expiresAt) {
+			expiresAt = *elevation.EndsAt
+		}
+	}
+
+	// the time-boxed roles of the user are granted until the first of their windows ends, the token expires then
+	for _, window := range view.ActiveRoleWindows(now) {
+		if window.EndsAt != nil && window.EndsAt.Before(expiresAt) {
+			expiresAt = *window.EndsAt
+		}
+	}
+
+	// the roles of the user and of its elevations are embedded with their permissions, checked by authz
+	roles, permissions := view.Grants(now)
+	if len(roles) > 0 {
+		claims["roles"] = roles
+	}
+	if len(permissions) > 0 {
+		claims["permissions"] = permissions
+	}
+
+	claims["exp"] = expiresAt.Unix()
+	s.keys.Compaction().Compact(claims)
+	accessToken, err = s.signer.Sign(claims)
+	if err != nil {
+		err = errors.Wrap(err, "cannot generate token")
+		return
+	}
+	// the token is still issued, the roles granted since the startup check can outgrow the budget
+	if budget := s.cfg.JWT.MaxTokenBytes; budget > 0 && len(accessToken) > budget {
+		s.log.With(ctx).WithParams(logger.Params{"user_id": identity.GetID(), "size": len(accessToken), "budget": budget}).Warn("access token over the size budget")
+	}
+
+	if s.cfg.AccessToken.Format == configs.AccessTokenFormatOpaque {
+		accessToken, err = s.issueOpaqueToken(ctx, accessToken, expiresAt)
+	}
 	return
 }
 
-func (s *Service) generateRefreshToken(ctx context.Context, identity Identity) (refreshToken string, err error) {
+// generateRefreshToken signs a new refresh token of the session and returns it with its record, to be created
+func (s *Service) generateRefreshToken(ctx context.Context, identity Identity, sessionID string) (refreshToken string, token domain.RefreshToken, err error) {
 
-	_, span := otel.Start(ctx)
+	ctx, span := otel.Start(ctx)
 	defer span.End()
 
-	refreshToken, err = jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	now := times.Now()
+	token = domain.RefreshToken{
+		ID:        utils.GenerateID(),
+		SessionID: sessionID,
+		IssuedAt:  now,
+	}
+	exp := now.AddDate(1000, 0, 0)
+	if expiration := configs.FromContext(ctx, s.cfg).JWT.RefreshTokenExpiration; expiration > 0 {
+		exp = now.Add(time.Duration(expiration) * time.Minute)
+		token.ExpiresAt = &exp
+	}
+
+	refreshToken, err = s.signer.Sign(jwt.MapClaims{
 		"id":         identity.GetID(),
-		"exp":        times.Now().AddDate(1000, 0, 0).Unix(),
+		"sid":        sessionID,
+		"jti":        token.ID,
+		"exp":        exp.Unix(),
 		"token_type": TokenTypeRefresh,
-	}).SignedString([]byte(s.cfg.JWT.SigningKey))
-	err = errors.Wrap(err, "cannot generate token")
-	return
+	})
+	if err != nil {
+		err = errors.Wrap(err, "cannot generate token")
+		return
+	}
+
+	return refreshToken, token, nil
+}
+
+// revokeRotated revokes the family of the refresh token rotated concurrently with its exchange for a new one
+func (s *Service) revokeRotated(ctx context.Context, identity Identity, rotatedID string) error {
+	token, err := s.repoRegitry.GetRefreshTokenRepository().GetByID(ctx, rotatedID)
+	if err != nil {
+		return err
+	}
+	return s.revokeFamily(ctx, identity, token)
+}
+
+// revokeFamily revokes the session of a refresh token presented again after its rotation, the token may have been
+// stolen so that neither its holder nor the legitimate client can refresh anymore, and the user has to log in again.
+// The account is flagged as compromised and the user is alerted on every channel.
+func (s *Service) revokeFamily(ctx context.Context, identity Identity, token domain.RefreshToken) error {
+	err := s.repoRegitry.GetSessionRepository().Revoke(ctx, token.SessionID)
+	if err != nil {
+		return err
+	}
+	s.backchannel.notify(ctx, identity.GetID(), token.SessionID)
+
+	now := times.Now()
+	err = s.repoRegitry.GetUserRepository().Update(ctx, identity.GetID(), domain.User{CompromisedAt: &now, UpdatedAt: now})
+	if err != nil {
+		return err
+	}
+
+	attributes := map[string]interface{}{
+		"session_id":  token.SessionID,
+		"token_id":    token.ID,
+		"replaced_by": token.ReplacedBy,
+		"flagged_at":  now.Format(time.RFC3339),
+	}
+	if token.RotatedAt != nil {
+		attributes["rotated_at"] = token.RotatedAt.Format(time.RFC3339)
+	}
+	s.events.Publish(ctx, event.Event{
+		Name:       domain.EventRefreshTokenReused,
+		ActorID:    identity.GetID(),
+		SubjectID:  identity.GetID(),
+		Attributes: attributes,
+	})
+
+	s.alertRefreshTokenReused(ctx, identity.GetID(), now)
+	return s.authFailed(ctx, failureRefreshReused, ierr.ErrExpiredToken)
+}
+
+// alertRefreshTokenReused warns the user of the stolen refresh token by push, and by email and sms when the user
+// has an address and a phone number. A channel failing does not keep the others from being alerted, it is logged.
+func (s *Service) alertRefreshTokenReused(ctx context.Context, userID string, detectedAt time.Time) {
+	user, err := s.repoRegitry.GetUserRepository().GetByID(ctx, userID)
+	if err != nil {
+		s.log.With(ctx).WithParam("user_id", userID).Error(errors.Wrap(err, "cannot alert the reuse of a refresh token"))
+		return
+	}
+
+	channels := []notification.Channel{notification.ChannelPush, notification.ChannelEmail, notification.ChannelSMS}
+	recipients := map[notification.Channel]string{notification.ChannelEmail: user.GetEmail(), notification.ChannelSMS: user.GetPhone()}
+	for _, channel := range channels {
+		to, ok := recipients[channel]
+		if ok && to == "" {
+			continue
+		}
+		err := s.notifier.Send(ctx, channel, notification.Message{
+			UserID: user.ID,
+			To:     to,
+			Title:  "Your account was signed out",
+			Body:   "A sign in of your account was used from another device and has been signed out. Sign in again and change your password.",
+			Data: map[string]string{
+				"type": "refresh_token_reused",
+			},
+			Template: catalog.MessageRefreshTokenReused,
+			Variables: map[string]string{
+				"AppName":    configs.FromContext(ctx, s.cfg).Server.NAME,
+				"DetectedAt": detectedAt.Format(time.RFC3339),
+			},
+		})
+		if err != nil {
+			s.log.With(ctx).WithParams(logger.Params{"user_id": user.ID, "channel": channel}).Error(errors.Wrap(err, "cannot alert the reuse of a refresh token"))
+		}
+	}
+}
+
+// breakGlassUntil returns the end of the activation of a break-glass account, or nil when the user
+// is not a break-glass account. The break-glass accounts which are not activated are rejected.
+func (s *Service) breakGlassUntil(ctx context.Context, userID string) (*time.Time, error) {
+	account, err := s.repoRegitry.GetBreakGlassAccountRepository().GetByUserID(ctx, userID)
+	if err == ierr.ErrResourceNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !account.IsActiveAt(times.Now()) {
+		return nil, s.authFailed(ctx, failureSealedAccount, ierr.ErrInvalidCreds)
+	}
+	return account.ExpiresAt, nil
+}
+
+func newPasswordPool(cfg *configs.Config) *password.Pool {
+	return password.NewPool(cfg.PasswordHasher(), cfg.PasswordPool.Workers, time.Duration(cfg.PasswordPool.QueueTimeout)*time.Millisecond)
+}
+
+// newDummyHash returns the hash compared with the passwords of the unknown users in strict enumeration mode,
+// hashed by the configured hasher so that it takes as long to compare as the passwords of the users
+func newDummyHash(cfg *configs.Config) string {
+	if !cfg.Enumeration.Strict {
+		return ""
+	}
+	hash, _ := cfg.PasswordHasher().Hash([]byte("go-hex dummy password"))
+	return hash
+}
+
+// upgradePassword rehashes the password of the user who just authenticated with it when its hash does not match
+// the configured algorithm and parameters, e.g. the bcrypt hashes once Argon2id is configured.
+// A failure is logged and does not fail the login, the password is rehashed at a later login.
+func (s *Service) upgradePassword(ctx context.Context, user domain.User, plainPwd string) {
+	if !s.passwords.NeedsRehash(user.Password) {
+		return
+	}
+
+	hash, err := s.passwords.HashAndSalt(ctx, []byte(plainPwd))
+	if err != nil {
+		s.log.With(ctx).WithParam("user_id", user.ID).Error(errors.Wrap(err, "cannot rehash password"))
+		return
+	}
+	// the password changed meanwhile is kept
+	if _, err := s.repoRegitry.GetUserRepository().ReplacePassword(ctx, user.ID, user.Password, hash); err != nil {
+		s.log.With(ctx).WithParam("user_id", user.ID).Error(errors.Wrap(err, "cannot rehash password"))
+	}
+}
+
+// compareRefreshToken compares the refresh token with the hash stored before the jti records, a fingerprint or a
+// bcrypt hash stored before the fingerprints
+func (s *Service) compareRefreshToken(ctx context.Context, hashedRefreshToken string, refreshToken string) (bool, error) {
+	if password.IsFingerprint(hashedRefreshToken) {
+		return password.CompareFingerprint(configs.FromContext(ctx, s.cfg).RefreshFingerprintKey(), hashedRefreshToken, []byte(refreshToken)), nil
+	}
+	return s.comparePassword(ctx, hashedRefreshToken, []byte(refreshToken))
+}
+
+// comparePassword compares the passwords on the password pool
+func (s *Service) comparePassword(ctx context.Context, hashedPwd string, plainPwd []byte) (bool, error) {
+	match, err := s.passwords.ComparePasswords(ctx, hashedPwd, plainPwd)
+	if err == password.ErrPoolSaturated {
+		otel.Event(ctx, otel.EventPasswordPoolSaturated)
+	}
+	return match, passwordPoolError(err)
+}
+
+// passwordPoolError reports a saturated password pool as the service being unavailable
+func passwordPoolError(err error) error {
+	if err == password.ErrPoolSaturated {
+		return errors.Wrap(ierr.ErrServiceUnavailable, err.Error())
+	}
+	return err
+}
+
+// sessionsToEvict returns the oldest active sessions to revoke so that a new session fits in the limit.
+// The active sessions are expected to be ordered from the oldest.
+func sessionsToEvict(active []domain.Session, limit int) []domain.Session {
+	if limit <= 0 || len(active) < limit {
+		return nil
+	}
+	return active[:len(active)-limit+1]
 }
@@ -0,0 +1,581 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/identityview"
+	"go-hex/internal/notification"
+	"go-hex/internal/repository/memory"
+	"go-hex/internal/repository/port"
+	"go-hex/internal/repository/sqlrepo"
+	"go-hex/pkg/auth"
+	"go-hex/pkg/authz"
+	"go-hex/pkg/db"
+	"go-hex/pkg/db/dbtest"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/password"
+	"go-hex/shared/ctxutil"
+	"go-hex/shared/ierr"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/mysqldialect"
+)
+
+func newBlockingService(t *testing.T, hook bun.QueryHook) (*Service, *dbtest.BlockingDriver) {
+	drv := dbtest.NewBlockingDriver()
+	bunDB := bun.NewDB(drv.DB(), mysqldialect.New())
+	bunDB.AddQueryHook(hook)
+	t.Cleanup(func() { bunDB.Close() })
+
+	log := logger.New("test", "test")
+	cfg := &configs.Config{}
+	registry := sqlrepo.NewRepositoryRegistry(bunDB)
+	return NewService(cfg, registry, memory.NewTokenBlacklistRepository(), memory.NewOpaqueTokenRepository(), newIdentityViews(registry), log, event.New(), notification.NewDispatcher(), noDeprecations{}), drv
+}
+
+func TestLoginCancelledLeavesNoQueryInFlight(t *testing.T) {
+	service, drv := newBlockingService(t, db.NewStatementTimeoutHook(time.Minute, nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		// the client goes away while the user is being looked up
+		drv.WaitStarted()
+		cancel()
+	}()
+
+	_, err := service.Login(ctx, RequestLogin{Username: "admin", Password: "password1234"})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, drv.Started())
+	assert.Equal(t, 1, drv.Cancelled())
+	assert.Equal(t, 0, drv.InFlight())
+}
+
+func TestLoginOperationTimeoutLeavesNoQueryInFlight(t *testing.T) {
+	service, drv := newBlockingService(t, db.NewStatementTimeoutHook(time.Minute, map[string]time.Duration{
+		"users.select": 20 * time.Millisecond,
+	}))
+
+	_, err := service.Login(context.Background(), RequestLogin{Username: "admin", Password: "password1234"})
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 1, drv.Cancelled())
+	assert.Equal(t, 0, drv.InFlight())
+}
+
+func TestSessionsToEvict(t *testing.T) {
+	sessions := func(ids ...string) []domain.Session {
+		res := make([]domain.Session, 0, len(ids))
+		for _, id := range ids {
+			res = append(res, domain.Session{ID: id})
+		}
+		return res
+	}
+
+	tests := []struct {
+		name   string
+		active []domain.Session
+		limit  int
+		want   []string
+	}{
+		{name: "unlimited", active: sessions("a", "b", "c"), limit: 0, want: nil},
+		{name: "below limit", active: sessions("a", "b"), limit: 3, want: nil},
+		{name: "no active session", active: nil, limit: 1, want: nil},
+		{name: "at limit evicts the oldest", active: sessions("a", "b", "c"), limit: 3, want: []string{"a"}},
+		{name: "limit of one evicts every session", active: sessions("a", "b"), limit: 1, want: []string{"a", "b"}},
+		{name: "above lowered limit", active: sessions("a", "b", "c", "d"), limit: 2, want: []string{"a", "b", "c"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, item := range sessionsToEvict(tt.active, tt.limit) {
+				got = append(got, item.ID)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// newIdentityViews builds the identity views from the registry at every read
+func newIdentityViews(registry port.RepositoryRegistry) IdentityViewReader {
+	return identityview.NewService(registry, nil, 0, nil, logger.New("test", "test"))
+}
+
+// noDeprecations ignores the deprecated fields used by the tests
+type noDeprecations struct{}
+
+func (noDeprecations) Field(ctx context.Context, name string) {}
+
+// newTestStore creates a store holding the users
+func newTestStore(t testing.TB, users ...domain.User) *memory.Store {
+	t.Helper()
+	store := memory.NewStore()
+	for _, user := range users {
+		require.NoError(t, store.GetUserRepository().Create(context.Background(), user))
+	}
+	return store
+}
+
+// getUser reads the user from the store
+func getUser(t *testing.T, store *memory.Store, userID string) domain.User {
+	t.Helper()
+	user, err := store.GetUserRepository().GetByID(context.Background(), userID)
+	require.NoError(t, err)
+	return user
+}
+
+// getSession reads the session from the store
+func getSession(t *testing.T, store *memory.Store, sessionID string) domain.Session {
+	t.Helper()
+	session, err := store.GetSessionRepository().GetByID(context.Background(), sessionID)
+	require.NoError(t, err)
+	return session
+}
+
+// recordingNotifier records the messages sent on its channel
+type recordingNotifier struct {
+	channel notification.Channel
+	sent    *[]notification.Message
+}
+
+func (n recordingNotifier) Channel() notification.Channel {
+	return n.channel
+}
+
+func (n recordingNotifier) Notify(ctx context.Context, msg notification.Message) error {
+	*n.sent = append(*n.sent, msg)
+	return nil
+}
+
+func TestAccessTokenEmbedsRolesAndPermissions(t *testing.T) {
+	user := domain.User{ID: "u1", Username: "jane", IsActive: true}
+	store := newTestStore(t, user)
+	store.PutRole(domain.Role{Name: "support", Permissions: []string{"users:read", "profile:read"}})
+	require.NoError(t, store.GetRoleRepository().Assign(context.Background(), domain.UserRole{UserID: "u1", Role: "support"}))
+	cfg := &configs.Config{}
+	cfg.JWT.SigningKey = "test-signing-key"
+	cfg.JWT.TokenExpiration = 60
+	identities := identityview.NewService(store, nil, 0, []string{domain.RoleUser}, logger.New("test", "test"))
+	svc := NewService(cfg, store, memory.NewTokenBlacklistRepository(), memory.NewOpaqueTokenRepository(), identities, logger.New("test", "test"), event.New(), notification.NewDispatcher(), noDeprecations{})
+
+	accessToken, _, err := svc.generateAccessToken(context.Background(), user, "s1")
+	require.NoError(t, err)
+
+	token, err := cfg.JWTKeys().Verify(accessToken)
+	require.NoError(t, err)
+	ctx := ctxutil.WithPrincipal(context.Background(), token)
+	loggedIn := auth.GetLoggedInUser(ctx)
+	assert.Equal(t, []string{"support", domain.RoleUser}, loggedIn.Roles)
+	assert.Equal(t, []string{"profile:read", "profile:write", "users:read"}, loggedIn.Permissions)
+	assert.NoError(t, authz.Check(ctx, "users:read"))
+	assert.Equal(t, ierr.ErrForbidden, authz.Check(ctx, "users:write"))
+}
+
+func TestRefreshTokenRotationRevokesReusedFamily(t *testing.T) {
+	email := "jane@example.com"
+	user := domain.User{ID: "u1", Username: "jane", Email: &email, IsActive: true}
+	store := newTestStore(t, user)
+	require.NoError(t, store.GetSessionRepository().Create(context.Background(), domain.Session{ID: "s1", UserID: "u1", ExpiresAt: time.Now().Add(time.Hour)}))
+	rotations := 0
+	store.SetHook(func(ctx context.Context, op string) error {
+		if op == "RefreshTokenRepository.Rotate" {
+			rotations++
+		}
+		return nil
+	})
+	cfg := &configs.Config{}
+	cfg.JWT.SigningKey = "test-signing-key"
+	cfg.JWT.TokenExpiration = 60
+	cfg.JWT.RefreshTokenExpiration = 60
+	cfg.PasswordPool.QueueTimeout = 1000
+	events := event.New()
+	var reused []event.Event
+	events.Subscribe(domain.EventRefreshTokenReused, func(ctx context.Context, e event.Event) {
+		reused = append(reused, e)
+	})
+	var pushes, emails, texts []notification.Message
+	notifier := notification.NewDispatcher(
+		recordingNotifier{notification.ChannelPush, &pushes},
+		recordingNotifier{notification.ChannelEmail, &emails},
+		recordingNotifier{notification.ChannelSMS, &texts},
+	)
+	svc := NewService(cfg, store, memory.NewTokenBlacklistRepository(), memory.NewOpaqueTokenRepository(), newIdentityViews(store), logger.New("test", "test"), events, notifier, noDeprecations{})
+
+	_, _, first, err := svc.generateJWT(context.Background(), user, "s1", "")
+	assert.NoError(t, err)
+	assert.Nil(t, getSession(t, store, "s1").RefreshToken, "the refresh tokens are not stored")
+	assert.NotNil(t, getSession(t, store, "s1").LastUsedAt)
+
+	// each refresh rotates the token into a new one
+	res, err := svc.RefreshToken(context.Background(), RequestRefreshToken{RefreshToken: first})
+	assert.NoError(t, err)
+	assert.NotEqual(t, first, res.RefreshToken)
+	second := res.RefreshToken
+	assert.Equal(t, 1, rotations)
+
+	// the rotated token presented again revokes the session, and its last token with it
+	_, err = svc.RefreshToken(context.Background(), RequestRefreshToken{RefreshToken: first})
+	assert.Equal(t, ierr.ErrExpiredToken, err)
+	assert.NotNil(t, getSession(t, store, "s1").RevokedAt)
+	if assert.Len(t, reused, 1) {
+		assert.Equal(t, "s1", reused[0].Attributes["session_id"])
+	}
+
+	// the account is flagged and the user alerted on the channels it can be reached on
+	assert.NotNil(t, getUser(t, store, "u1").CompromisedAt)
+	assert.Len(t, pushes, 1)
+	if assert.Len(t, emails, 1) {
+		assert.Equal(t, email, emails[0].To)
+	}
+	assert.Empty(t, texts, "the user has no phone number")
+
+	_, err = svc.RefreshToken(context.Background(), RequestRefreshToken{RefreshToken: second})
+	assert.Equal(t, ierr.ErrExpiredToken, err)
+}
+
+func TestGenerateJWTRollsBackConcurrentRotation(t *testing.T) {
+	user := domain.User{ID: "u1", Username: "jane", IsActive: true}
+	store := newTestStore(t, user)
+	rotatedAt := time.Now()
+	require.NoError(t, store.GetRefreshTokenRepository().Create(context.Background(), domain.RefreshToken{ID: "t1", SessionID: "s1", RotatedAt: &rotatedAt, ReplacedBy: "t2"}))
+	require.NoError(t, store.GetSessionRepository().Create(context.Background(), domain.Session{ID: "s1", UserID: "u1", ExpiresAt: time.Now().Add(time.Hour)}))
+	cfg := &configs.Config{}
+	cfg.JWT.SigningKey = "test-signing-key"
+	cfg.JWT.TokenExpiration = 60
+	cfg.PasswordPool.QueueTimeout = 1000
+	svc := NewService(cfg, store, memory.NewTokenBlacklistRepository(), memory.NewOpaqueTokenRepository(), newIdentityViews(store), logger.New("test", "test"), event.New(), notification.NewDispatcher(), noDeprecations{})
+
+	// t1 was rotated by a concurrent refresh between its check and its rotation
+	_, _, _, err := svc.generateJWT(context.Background(), user, "s1", "t1")
+	assert.Equal(t, ierr.ErrExpiredToken, err)
+	session := getSession(t, store, "s1")
+	assert.Nil(t, session.LastUsedAt, "the refresh token issued is rolled back with the touch of the session")
+	assert.NotNil(t, session.RevokedAt, "the family is revoked")
+	assert.NotNil(t, getUser(t, store, "u1").CompromisedAt)
+}
+
+func TestRefreshTokenRecords(t *testing.T) {
+	user := domain.User{ID: "u1", Username: "jane", IsActive: true}
+	store := newTestStore(t, user)
+	sessions := store.GetSessionRepository()
+	cfg := &configs.Config{}
+	cfg.JWT.SigningKey = "test-signing-key"
+	cfg.JWT.TokenExpiration = 60
+	cfg.PasswordPool.QueueTimeout = 1000
+	svc := NewService(cfg, store, memory.NewTokenBlacklistRepository(), memory.NewOpaqueTokenRepository(), newIdentityViews(store), logger.New("test", "test"), event.New(), notification.NewDispatcher(), noDeprecations{})
+
+	t.Run("a refresh token is checked against the record of its jti", func(t *testing.T) {
+		require.NoError(t, sessions.Create(context.Background(), domain.Session{ID: "s1", UserID: "u1", ExpiresAt: time.Now().Add(time.Hour)}))
+		_, _, refreshToken, err := svc.generateJWT(context.Background(), user, "s1", "")
+		require.NoError(t, err)
+		assert.Nil(t, getSession(t, store, "s1").RefreshToken)
+		_, err = svc.RefreshToken(context.Background(), RequestRefreshToken{RefreshToken: refreshToken})
+		assert.NoError(t, err)
+
+		expiredAt := time.Now().Add(-time.Second)
+		require.NoError(t, store.GetRefreshTokenRepository().Create(context.Background(), domain.RefreshToken{ID: "expired", SessionID: "s1", ExpiresAt: &expiredAt}))
+		expiredToken, err := svc.signer.Sign(jwt.MapClaims{"id": "u1", "sid": "s1", "jti": "expired", "token_type": TokenTypeRefresh})
+		require.NoError(t, err)
+		_, err = svc.RefreshToken(context.Background(), RequestRefreshToken{RefreshToken: expiredToken})
+		assert.Equal(t, ierr.ErrExpiredToken, err, "the record expired")
+
+		unknownToken, err := svc.signer.Sign(jwt.MapClaims{"id": "u1", "sid": "s1", "jti": "unknown", "token_type": TokenTypeRefresh})
+		require.NoError(t, err)
+		_, err = svc.RefreshToken(context.Background(), RequestRefreshToken{RefreshToken: unknownToken})
+		assert.Equal(t, ierr.ErrInvalidToken, err, "the record is unknown")
+	})
+
+	t.Run("a refresh token issued before the records is accepted once", func(t *testing.T) {
+		// the refresh tokens issued before the rotation have no jti, their bcrypt hash is stored on the session
+		legacyToken, err := svc.signer.Sign(jwt.MapClaims{"id": "u1", "sid": "s2", "token_type": TokenTypeRefresh})
+		require.NoError(t, err)
+		legacyHash, err := password.HashAndSalt([]byte(legacyToken))
+		require.NoError(t, err)
+		require.NoError(t, sessions.Create(context.Background(), domain.Session{ID: "s2", UserID: "u1", RefreshToken: &legacyHash, ExpiresAt: time.Now().Add(time.Hour)}))
+
+		res, err := svc.RefreshToken(context.Background(), RequestRefreshToken{RefreshToken: legacyToken})
+		require.NoError(t, err)
+		assert.Nil(t, getSession(t, store, "s2").RefreshToken, "the hash is cleared")
+
+		_, err = svc.RefreshToken(context.Background(), RequestRefreshToken{RefreshToken: legacyToken})
+		assert.Equal(t, ierr.ErrExpiredToken, err)
+		_, err = svc.RefreshToken(context.Background(), RequestRefreshToken{RefreshToken: res.RefreshToken})
+		assert.NoError(t, err, "the token replacing it is checked by its jti")
+	})
+
+	t.Run("a refresh token fingerprinted before the records is accepted once", func(t *testing.T) {
+		legacyToken, err := svc.signer.Sign(jwt.MapClaims{"id": "u1", "sid": "s3", "token_type": TokenTypeRefresh})
+		require.NoError(t, err)
+		fingerprint := password.Fingerprint(cfg.RefreshFingerprintKey(), []byte(legacyToken))
+		require.NoError(t, sessions.Create(context.Background(), domain.Session{ID: "s3", UserID: "u1", RefreshToken: &fingerprint, ExpiresAt: time.Now().Add(time.Hour)}))
+
+		_, err = svc.RefreshToken(context.Background(), RequestRefreshToken{RefreshToken: legacyToken})
+		require.NoError(t, err)
+		assert.Nil(t, getSession(t, store, "s3").RefreshToken, "the fingerprint is cleared")
+
+		// s4 holds the fingerprint of legacyToken too
+		otherToken, err := svc.signer.Sign(jwt.MapClaims{"id": "u1", "sid": "s4", "token_type": TokenTypeRefresh, "iat": 1})
+		require.NoError(t, err)
+		require.NoError(t, sessions.Create(context.Background(), domain.Session{ID: "s4", UserID: "u1", RefreshToken: &fingerprint, ExpiresAt: time.Now().Add(time.Hour)}))
+		_, err = svc.RefreshToken(context.Background(), RequestRefreshToken{RefreshToken: otherToken})
+		assert.Equal(t, ierr.ErrExpiredToken, err, "the fingerprint is of another token")
+	})
+}
+
+func TestAuthenticateRehashesThePassword(t *testing.T) {
+	argon2id := password.Argon2id{Memory: 64, Time: 1, Threads: 1, SaltLength: 16, KeyLength: 32}
+	bcryptHash, err := password.HashAndSalt([]byte("correct-password"))
+	require.NoError(t, err)
+	argon2idHash, err := argon2id.Hash([]byte("correct-password"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		hash     string
+		password string
+		rehashed bool
+	}{
+		{"bcrypt hash", bcryptHash, "correct-password", true},
+		{"argon2id hash of the configured parameters", argon2idHash, "correct-password", false},
+		{"wrong password", bcryptHash, "wrong-password", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestStore(t, domain.User{ID: "u1", Username: "jane", Password: tt.hash, IsActive: true})
+			cfg := &configs.Config{}
+			cfg.PasswordPool.QueueTimeout = 1000
+			cfg.PasswordHash.Algorithm = configs.PasswordHashArgon2id
+			cfg.PasswordHash.Argon2Memory, cfg.PasswordHash.Argon2Time, cfg.PasswordHash.Argon2Threads = argon2id.Memory, argon2id.Time, argon2id.Threads
+			cfg.PasswordHash.Argon2SaltLength, cfg.PasswordHash.Argon2KeyLength = argon2id.SaltLength, argon2id.KeyLength
+			svc := NewService(cfg, store, memory.NewTokenBlacklistRepository(), memory.NewOpaqueTokenRepository(), newIdentityViews(store), logger.New("test", "test"), event.New(), notification.NewDispatcher(), noDeprecations{})
+
+			_, err := svc.authenticate(context.Background(), "jane", tt.password)
+			if tt.password != "correct-password" {
+				assert.Equal(t, ierr.ErrInvalidCreds, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			hash := getUser(t, store, "u1").Password
+			if !tt.rehashed {
+				assert.Equal(t, tt.hash, hash)
+				return
+			}
+			assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$"), hash)
+			assert.True(t, password.ComparePasswords(hash, []byte("correct-password")))
+			assert.False(t, argon2id.NeedsRehash(hash))
+		})
+	}
+}
+
+func TestAuthenticateBypassesTheUserCache(t *testing.T) {
+	oldHash, err := password.HashAndSalt([]byte("old-password"))
+	require.NoError(t, err)
+	newHash, err := password.HashAndSalt([]byte("new-password"))
+	require.NoError(t, err)
+
+	// the password was changed on another instance, whose write did not invalidate the cache of this one
+	user := domain.User{ID: "u1", Username: "jane", Password: newHash, IsActive: true}
+	cache := memory.NewUserCache(10, time.Hour)
+	stale := user
+	stale.Password = oldHash
+	require.Equal(t, memory.AdmissionAdmitted, cache.Admit(cache.Version(), stale, nil))
+
+	registry := memory.NewRepositoryRegistry(newTestStore(t, user), cache)
+	cfg := &configs.Config{}
+	cfg.PasswordPool.QueueTimeout = 1000
+	svc := NewService(cfg, registry, memory.NewTokenBlacklistRepository(), memory.NewOpaqueTokenRepository(), newIdentityViews(registry), logger.New("test", "test"), event.New(), notification.NewDispatcher(), noDeprecations{})
+
+	_, err = svc.authenticate(context.Background(), "jane", "old-password")
+	assert.Equal(t, ierr.ErrInvalidCreds, err)
+	_, err = svc.authenticate(context.Background(), "jane", "new-password")
+	assert.NoError(t, err)
+}
+
+func TestLoginStrictEnumerationAnswersTheSame(t *testing.T) {
+	hashed, err := password.HashAndSalt([]byte("correct-password"))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name string
+		user domain.User
+		req  RequestLogin
+	}{
+		{"unknown user", domain.User{ID: "u1", Username: "jane", Password: hashed, IsActive: true}, RequestLogin{Username: "john", Password: "correct-password"}},
+		{"wrong password", domain.User{ID: "u1", Username: "jane", Password: hashed, IsActive: true}, RequestLogin{Username: "jane", Password: "wrong-password"}},
+		{"inactive user", domain.User{ID: "u1", Username: "jane", Password: hashed}, RequestLogin{Username: "jane", Password: "correct-password"}},
+	}
+
+	failures := testutil.ToFloat64(loginAttempts.WithLabelValues(loginOutcomeFailure))
+	var answers []string
+	for _, tt := range tests {
+		cfg := &configs.Config{}
+		cfg.Enumeration.Strict = true
+		cfg.PasswordPool.QueueTimeout = 1000
+		store := newTestStore(t, tt.user)
+		svc := NewService(cfg, store, memory.NewTokenBlacklistRepository(), memory.NewOpaqueTokenRepository(), newIdentityViews(store), logger.New("test", "test"), event.New(), notification.NewDispatcher(), noDeprecations{})
+
+		res, err := svc.Login(context.Background(), tt.req)
+		assert.Equal(t, ResponseLogin{}, res, tt.name)
+		assert.Equal(t, ierr.ErrInvalidCreds, err, tt.name)
+		answers = append(answers, err.Error())
+	}
+	assert.Equal(t, []string{answers[0], answers[0], answers[0]}, answers)
+	assert.Equal(t, failures+3, testutil.ToFloat64(loginAttempts.WithLabelValues(loginOutcomeFailure)))
+}
+
+func TestLoginFailuresAreLoggedWithTheirReason(t *testing.T) {
+	hashed, err := password.HashAndSalt([]byte("correct-password"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		user   domain.User
+		req    RequestLogin
+		reason string
+		code   string
+	}{
+		{"unknown user", domain.User{ID: "u1", Username: "jane", Password: hashed, IsActive: true}, RequestLogin{Username: "john", Password: "correct-password"}, failureUnknownUser, ierr.ErrInvalidCreds.Code},
+		{"wrong password", domain.User{ID: "u1", Username: "jane", Password: hashed, IsActive: true}, RequestLogin{Username: "jane", Password: "wrong-password"}, failureWrongPassword, ierr.ErrInvalidCreds.Code},
+		{"inactive user", domain.User{ID: "u1", Username: "jane", Password: hashed}, RequestLogin{Username: "jane", Password: "correct-password"}, failureInactiveUser, ierr.ErrUserIsNotActive.Code},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			log := logger.New("test", "test")
+			logger.SetOutput(out)
+			logger.SetFormatter(&logrus.JSONFormatter{})
+			defer logger.SetFormatter(&logrus.TextFormatter{})
+
+			cfg := &configs.Config{}
+			cfg.PasswordPool.QueueTimeout = 1000
+			store := newTestStore(t, tt.user)
+			svc := NewService(cfg, store, memory.NewTokenBlacklistRepository(), memory.NewOpaqueTokenRepository(), newIdentityViews(store), log, event.New(), notification.NewDispatcher(), noDeprecations{})
+
+			// the failures are logged through the logger of the request
+			ctx := logger.NewContext(context.Background(), log.WithParam("route", "/auth/login"))
+			_, err := svc.Login(ctx, tt.req)
+			require.Error(t, err)
+
+			var entry map[string]interface{}
+			require.NoError(t, json.Unmarshal(out.Bytes(), &entry))
+			assert.Equal(t, "auth_failure", entry["type"])
+			assert.Equal(t, tt.reason, entry["reason"])
+			assert.Equal(t, tt.code, entry["error_code"])
+			assert.Equal(t, "/auth/login", entry["route"])
+		})
+	}
+}
+
+func TestSubjectOnlyAccessTokens(t *testing.T) {
+	user := domain.User{ID: "u1", Username: "jane", IsActive: true}
+	store := newTestStore(t, user)
+
+	for _, subjectOnly := range []bool{false, true} {
+		cfg := &configs.Config{}
+		cfg.JWT.SigningKey = "test-signing-key"
+		cfg.JWT.TokenExpiration = 60
+		cfg.JWT.SubjectOnly = subjectOnly
+		svc := NewService(cfg, store, memory.NewTokenBlacklistRepository(), memory.NewOpaqueTokenRepository(), newIdentityViews(store), logger.New("test", "test"), event.New(), notification.NewDispatcher(), noDeprecations{})
+
+		accessToken, _, err := svc.generateAccessToken(context.Background(), user, "s1")
+		require.NoError(t, err)
+		token, err := auth.VerifyToken(accessToken, cfg.JWTKeys())
+		require.NoError(t, err)
+		claims := token.Claims.(jwt.MapClaims)
+		assert.Equal(t, "u1", claims["id"])
+		_, ok := claims["username"]
+		assert.Equal(t, !subjectOnly, ok, "subject only %v", subjectOnly)
+	}
+}
+
+func TestLogoutRevokesTheAccessToken(t *testing.T) {
+	user := domain.User{ID: "u1", Username: "jane", IsActive: true}
+	hashed := "hashed-refresh-token"
+	store := newTestStore(t, user)
+	require.NoError(t, store.GetSessionRepository().Create(context.Background(), domain.Session{ID: "s1", UserID: "u1", RefreshToken: &hashed, ExpiresAt: time.Now().Add(time.Hour)}))
+	cfg := &configs.Config{}
+	cfg.JWT.SigningKey = "test-signing-key"
+	cfg.JWT.TokenExpiration = 60
+	blacklist := memory.NewTokenBlacklistRepository()
+	svc := NewService(cfg, store, blacklist, memory.NewOpaqueTokenRepository(), newIdentityViews(store), logger.New("test", "test"), event.New(), notification.NewDispatcher(), noDeprecations{})
+
+	accessToken, _, err := svc.generateAccessToken(context.Background(), user, "s1")
+	assert.NoError(t, err)
+	token, err := auth.VerifyToken(accessToken, cfg.JWTKeys())
+	assert.NoError(t, err)
+	ctx := ctxutil.WithPrincipal(context.Background(), token)
+	tokenID := auth.GetLoggedInUser(ctx).TokenID
+	assert.NotEmpty(t, tokenID)
+
+	assert.NoError(t, svc.Logout(ctx))
+
+	revoked, err := blacklist.IsRevoked(context.Background(), tokenID)
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+	session := getSession(t, store, "s1")
+	assert.NotNil(t, session.RevokedAt)
+	assert.Nil(t, session.RefreshToken)
+}
+
+// addLatency adds the latency of a database round trip to the operations of the store issuing the tokens
+func addLatency(ctx context.Context, op string) error {
+	switch op {
+	case "SessionRepository.Touch", "RefreshTokenRepository.Create", "RefreshTokenRepository.Rotate", "ElevationRepository.ListActiveByUserID":
+		time.Sleep(time.Millisecond)
+	}
+	return nil
+}
+
+// BenchmarkGenerateJWT issues the token pairs of a new session and of a rotation, each repository call taking 1ms
+func BenchmarkGenerateJWT(b *testing.B) {
+	user := domain.User{ID: "u1", Username: "jane", IsActive: true}
+	store := newTestStore(b, user)
+	require.NoError(b, store.GetSessionRepository().Create(context.Background(), domain.Session{ID: "s1", UserID: "u1", ExpiresAt: time.Now().Add(time.Hour)}))
+	store.SetHook(addLatency)
+	cfg := &configs.Config{}
+	cfg.JWT.SigningKey = "test-signing-key"
+	cfg.JWT.TokenExpiration = 60
+	cfg.PasswordPool.QueueTimeout = 1000
+	svc := NewService(cfg, store, memory.NewTokenBlacklistRepository(), memory.NewOpaqueTokenRepository(), newIdentityViews(store), logger.New("test", "test"), event.New(), notification.NewDispatcher(), noDeprecations{})
+
+	b.Run("new_session", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, _, _, err := svc.generateJWT(context.Background(), user, "s1", ""); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	rotated := 0
+	b.Run("rotation", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			// every iteration rotates a token not rotated yet, across the runs of the benchmark
+			rotated++
+			b.StopTimer()
+			if err := store.GetRefreshTokenRepository().Create(context.Background(), domain.RefreshToken{ID: strconv.Itoa(rotated), SessionID: "s1"}); err != nil {
+				b.Fatal(err)
+			}
+			b.StartTimer()
+			if _, _, _, err := svc.generateJWT(context.Background(), user, "s1", strconv.Itoa(rotated)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/pkg/auth"
+	"go-hex/pkg/event"
+	"go-hex/pkg/otel"
+	"go-hex/shared/ierr"
+	"time"
+)
+
+// ListSessions returns the active sessions of the logged in user from the oldest, one per device it logged in on.
+func (s *Service) ListSessions(ctx context.Context) ([]ResponseSession, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	user := auth.GetLoggedInUser(ctx)
+	sessions, err := s.repoRegitry.GetSessionRepository().ListActiveByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]ResponseSession, 0, len(sessions))
+	for _, session := range sessions {
+		item := ResponseSession{
+			ID:             session.ID,
+			IPAddress:      session.IPAddress,
+			UserAgent:      session.UserAgent,
+			ClientApp:      session.ClientApp,
+			ClientVersion:  session.ClientVersion,
+			ClientPlatform: session.ClientPlatform,
+			CreatedAt:      session.CreatedAt.Format(time.RFC3339),
+			Current:        session.ID == user.SessionID,
+		}
+		if session.LastUsedAt != nil {
+			lastUsedAt := session.LastUsedAt.Format(time.RFC3339)
+			item.LastUsedAt = &lastUsedAt
+		}
+		res = append(res, item)
+	}
+	return res, nil
+}
+
+// RevokeSession revokes a session of the logged in user, so that its refresh token cannot refresh anymore and its
+// access tokens are rejected, and notifies the registered clients through the backchannel.
+// Revoking the current session logs the user out.
+func (s *Service) RevokeSession(ctx context.Context, req RequestRevokeSession) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return err
+	}
+
+	user := auth.GetLoggedInUser(ctx)
+	if req.ID == user.SessionID {
+		return s.Logout(ctx)
+	}
+
+	repoSession := s.repoRegitry.GetSessionRepository()
+	session, err := repoSession.GetByID(ctx, req.ID)
+	if err != nil {
+		return err
+	}
+	// the sessions of the other users are not found, so that their ids cannot be probed
+	if session.UserID != user.ID {
+		return ierr.ErrResourceNotFound
+	}
+
+	err = repoSession.Revoke(ctx, session.ID)
+	if err != nil {
+		return err
+	}
+
+	s.events.Publish(ctx, event.Event{
+		Name:      domain.EventSessionRevoked,
+		ActorID:   user.ID,
+		SubjectID: session.ID,
+		Attributes: map[string]interface{}{
+			"user_id":       user.ID,
+			"by_session_id": user.SessionID, // the session of the device revoking it
+		},
+	})
+	s.backchannel.notify(ctx, user.ID, session.ID)
+	return nil
+}
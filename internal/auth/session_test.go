@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/notification"
+	"go-hex/internal/repository/memory"
+	"go-hex/pkg/auth"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/shared/ctxutil"
+	"go-hex/shared/ierr"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionsOfTheDevices(t *testing.T) {
+	user := domain.User{ID: "u1", Username: "jane", IsActive: true}
+	store := newTestStore(t, user)
+	cfg := &configs.Config{}
+	cfg.JWT.SigningKey = "test-signing-key"
+	cfg.JWT.TokenExpiration = 60
+	events := event.New()
+	var revoked []event.Event
+	events.Subscribe(domain.EventSessionRevoked, func(ctx context.Context, e event.Event) {
+		revoked = append(revoked, e)
+	})
+	svc := NewService(cfg, store, memory.NewTokenBlacklistRepository(), memory.NewOpaqueTokenRepository(), newIdentityViews(store), logger.New("test", "test"), events, notification.NewDispatcher(), noDeprecations{})
+
+	// each device logging in gets its own session
+	now := time.Now()
+	require.NoError(t, store.GetSessionRepository().Create(context.Background(), domain.Session{ID: "phone", UserID: "u1", CreatedAt: now.Add(-time.Hour), ExpiresAt: now.Add(time.Hour)}))
+	require.NoError(t, store.GetSessionRepository().Create(context.Background(), domain.Session{ID: "other-user", UserID: "u2", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}))
+	laptopID, err := svc.startSession(context.Background(), user, sessionDevice{"10.0.0.1", "Mozilla/5.0", domain.ClientInfo{App: "checkout", Version: "3.2.1"}}, upstreamSession{})
+	require.NoError(t, err)
+	_, _, _, err = svc.generateJWT(context.Background(), user, laptopID, "")
+	require.NoError(t, err)
+
+	accessToken, _, err := svc.generateAccessToken(context.Background(), user, laptopID)
+	require.NoError(t, err)
+	token, err := auth.VerifyToken(accessToken, cfg.JWTKeys())
+	require.NoError(t, err)
+	ctx := ctxutil.WithPrincipal(context.Background(), token)
+
+	res, err := svc.ListSessions(ctx)
+	require.NoError(t, err)
+	if assert.Len(t, res, 2) {
+		assert.Equal(t, "phone", res[0].ID)
+		assert.False(t, res[0].Current)
+		assert.Equal(t, laptopID, res[1].ID)
+		assert.True(t, res[1].Current)
+		assert.Equal(t, "10.0.0.1", *res[1].IPAddress)
+		assert.Equal(t, "Mozilla/5.0", *res[1].UserAgent)
+		assert.Equal(t, "checkout", *res[1].ClientApp)
+		assert.Equal(t, "3.2.1", *res[1].ClientVersion)
+		assert.Nil(t, res[1].ClientPlatform)
+		assert.Nil(t, res[0].ClientApp)
+	}
+
+	// the sessions of the other users cannot be revoked nor probed
+	assert.Equal(t, ierr.ErrResourceNotFound, svc.RevokeSession(ctx, RequestRevokeSession{ID: "other-user"}))
+	assert.Nil(t, getSession(t, store, "other-user").RevokedAt)
+
+	require.NoError(t, svc.RevokeSession(ctx, RequestRevokeSession{ID: "phone"}))
+	assert.NotNil(t, getSession(t, store, "phone").RevokedAt)
+	assert.Nil(t, getSession(t, store, laptopID).RevokedAt, "the other devices stay logged in")
+	if assert.Len(t, revoked, 1) {
+		assert.Equal(t, "phone", revoked[0].SubjectID)
+	}
+
+	// revoking the current session logs out
+	require.NoError(t, svc.RevokeSession(ctx, RequestRevokeSession{ID: laptopID}))
+	assert.NotNil(t, getSession(t, store, laptopID).RevokedAt)
+}
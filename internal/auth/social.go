@@ -0,0 +1,288 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/auth"
+	"go-hex/pkg/event"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/times"
+	"go-hex/pkg/utils"
+	"go-hex/shared/ierr"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// LoginWithProvider logs in with the authorization code of a social login provider and issues our own tokens.
+//
+// The account at the provider is linked to a local user on its first login: to the only user with the same email
+// address when both the provider and the user verified it, otherwise to a new active user when auto provisioning is
+// enabled. The accounts of the users who did not verify their address are linked by LinkProvider.
+// A session of the upstream OpenID provider is recorded, so that its backchannel logout revokes the local session.
+func (s *Service) LoginWithProvider(ctx context.Context, req RequestSocialLogin) (ResponseLogin, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	start := time.Now()
+	var res ResponseLogin
+
+	err := req.Validate()
+	if err != nil {
+		return res, err
+	}
+
+	account, err := s.exchangeCode(ctx, req)
+	if err != nil {
+		if errors.Cause(err) == ierr.ErrInvalidToken {
+			return res, s.authFailed(ctx, failureInvalidToken, err)
+		}
+		return res, err
+	}
+
+	user, err := s.linkedUser(ctx, req.Provider, account)
+	if err != nil {
+		return res, err
+	}
+	if !user.IsActive {
+		return res, s.authFailed(ctx, failureInactiveUser, ierr.ErrUserIsNotActive)
+	}
+	// the break-glass accounts only log in with their password while activated
+	if _, err := s.repoRegitry.GetBreakGlassAccountRepository().GetByUserID(ctx, user.ID); err != ierr.ErrResourceNotFound {
+		if err != nil {
+			return res, err
+		}
+		return res, s.authFailed(ctx, failureSealedAccount, ierr.ErrInvalidCreds)
+	}
+
+	err = s.repoRegitry.GetUserIdentityRepository().Touch(ctx, req.Provider, account.Subject, times.Now())
+	if err != nil {
+		return res, err
+	}
+
+	if configs.FromContext(ctx, s.cfg).LoginApproval.Enabled {
+		// only users signed in on another device can approve the login, the others get a normal login
+		enrolled, err := s.repoRegitry.GetSessionRepository().CountActiveByUserID(ctx, user.ID)
+		if err != nil {
+			return res, err
+		}
+		if enrolled > 0 {
+			otel.Event(ctx, otel.EventLoginApprovalRequired, otel.AttributeActiveSessions.Int(enrolled))
+			approval, err := s.requestLoginApproval(ctx, user, RequestLogin{IPAddress: req.IPAddress, UserAgent: req.UserAgent})
+			if err != nil {
+				return res, err
+			}
+			return ResponseLogin{Approval: &approval}, nil
+		}
+	}
+
+	var upstream upstreamSession
+	if account.Issuer != "" && account.Issuer == s.cfg.OIDC.UpstreamIssuer {
+		upstream = upstreamSession{SessionID: account.SessionID, Subject: account.Subject}
+	}
+	sessionID, err := s.startSession(ctx, user, sessionDevice{req.IPAddress, req.UserAgent, req.Client}, upstream)
+	if err != nil {
+		return res, err
+	}
+
+	accessToken, expiresAt, refreshToken, err := s.generateJWT(ctx, user, sessionID, "")
+	if err != nil {
+		return res, err
+	}
+
+	s.events.Publish(ctx, event.Event{
+		Name:      domain.EventLoginSucceeded,
+		ActorID:   user.ID,
+		SubjectID: sessionID,
+		Attributes: map[string]interface{}{
+			"username":    user.Username,
+			"provider":    req.Provider,
+			"ip_address":  req.IPAddress,
+			"user_agent":  req.UserAgent,
+			"duration_ms": float64(time.Since(start).Microseconds()) / 1000,
+		},
+	})
+
+	return ResponseLogin{
+		AccessToken:  accessToken,
+		ExpiresAt:    expiresAt.Format(time.RFC3339),
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// LinkProvider links the account at a social login provider, of the authorization code, to the logged in user, so that
+// the user logs in with the provider whether its email address is verified or not.
+// It returns ierr.ErrConflict when the account is already linked.
+func (s *Service) LinkProvider(ctx context.Context, req RequestSocialLogin) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return err
+	}
+
+	account, err := s.exchangeCode(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	now := times.Now()
+	identity := domain.UserIdentity{
+		Provider:    req.Provider,
+		Subject:     account.Subject,
+		UserID:      auth.GetLoggedInUser(ctx).ID,
+		CreatedAt:   now,
+		LastLoginAt: now,
+	}
+	if account.Email != "" && account.EmailVerified {
+		identity.Email = &account.Email
+	}
+	err = s.repoRegitry.GetUserIdentityRepository().Create(ctx, identity)
+	if err != nil {
+		return err
+	}
+	s.publishLinked(ctx, identity, false)
+	return nil
+}
+
+// exchangeCode exchanges the authorization code of the request for the account at its provider
+func (s *Service) exchangeCode(ctx context.Context, req RequestSocialLogin) (SocialAccount, error) {
+	provider, ok := s.providers[req.Provider]
+	if !ok {
+		return SocialAccount{}, ierr.ErrSocialProviderUnknown
+	}
+
+	redirectURI := ""
+	if req.RedirectURI != "" {
+		var err error
+		redirectURI, err = configs.FromContext(ctx, s.cfg).Redirect.Allowlist.Check(req.ClientID, req.RedirectURI)
+		if err != nil {
+			return SocialAccount{}, err
+		}
+	}
+
+	return provider.Exchange(ctx, req.Code, req.CodeVerifier, req.Nonce, redirectURI)
+}
+
+// linkedUser returns the user linked to the account at the provider, linking it on its first login
+func (s *Service) linkedUser(ctx context.Context, provider string, account SocialAccount) (domain.User, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	repoUser := s.repoRegitry.GetUserRepository()
+	identity, err := s.repoRegitry.GetUserIdentityRepository().Get(ctx, provider, account.Subject)
+	if err == nil {
+		return repoUser.GetByID(ctx, identity.UserID)
+	}
+	if err != ierr.ErrResourceNotFound {
+		return domain.User{}, err
+	}
+
+	now := times.Now()
+	identity = domain.UserIdentity{
+		Provider:    provider,
+		Subject:     account.Subject,
+		CreatedAt:   now,
+		LastLoginAt: now,
+	}
+
+	// an unverified email address could be claimed by anyone at the provider, so it is never linked
+	if account.Email != "" && account.EmailVerified {
+		identity.Email = &account.Email
+		users, err := repoUser.ListByEmail(ctx, account.Email)
+		if err != nil {
+			return domain.User{}, err
+		}
+		// a local address is claimed by anyone signing up with it until the user verifies it, so linking an unverified
+		// one would hand the account at the provider over to whoever signed up first
+		if len(users) == 1 && !users[0].IsEmailVerified() {
+			return domain.User{}, ierr.ErrSocialAccountUnlinked
+		}
+		if len(users) == 1 {
+			identity.UserID = users[0].ID
+			err = s.repoRegitry.GetUserIdentityRepository().Create(ctx, identity)
+			if err != nil {
+				return domain.User{}, err
+			}
+			s.publishLinked(ctx, identity, false)
+			return users[0], nil
+		}
+		if len(users) > 1 {
+			// the address is ambiguous, the user links the account with LinkProvider
+			return domain.User{}, ierr.ErrSocialAccountUnlinked
+		}
+	}
+
+	if !s.cfg.Social.AutoProvision {
+		return domain.User{}, ierr.ErrSocialAccountUnlinked
+	}
+
+	user, err := s.provisionUser(ctx, provider, account, now)
+	if err != nil {
+		return user, err
+	}
+	identity.UserID = user.ID
+	_, err = s.repoRegitry.DoInTransaction(ctx, func(ctx context.Context, repoRegistry port.RepositoryRegistry) (interface{}, error) {
+		if err := repoRegistry.GetUserRepository().Create(ctx, user); err != nil {
+			return nil, err
+		}
+		return nil, repoRegistry.GetUserIdentityRepository().Create(ctx, identity)
+	})
+	if err != nil {
+		return domain.User{}, err
+	}
+	s.publishLinked(ctx, identity, true)
+	return user, nil
+}
+
+// provisionUser returns a new active user for the account, named after its verified email address. Its password is
+// random, the user logs in with the provider or sets a password with ForgotPassword.
+func (s *Service) provisionUser(ctx context.Context, provider string, account SocialAccount, now time.Time) (domain.User, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return domain.User{}, errors.Wrap(err, "cannot generate password")
+	}
+	hash, err := s.passwords.HashAndSalt(ctx, []byte(base64.RawURLEncoding.EncodeToString(b)))
+	if err != nil {
+		return domain.User{}, passwordPoolError(err)
+	}
+
+	user := domain.User{
+		ID:        utils.GenerateID(),
+		Username:  provider + ":" + account.Subject,
+		Password:  hash,
+		IsActive:  true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if account.Email != "" && account.EmailVerified {
+		user.Username = account.Email
+		user.Email = &account.Email
+		user.VerifiedEmail = &account.Email
+	}
+	if account.Name != "" {
+		user.FullName = &account.Name
+	}
+	return user, nil
+}
+
+func (s *Service) publishLinked(ctx context.Context, identity domain.UserIdentity, provisioned bool) {
+	s.events.Publish(ctx, event.Event{
+		Name:      domain.EventSocialAccountLinked,
+		ActorID:   identity.UserID,
+		SubjectID: identity.UserID,
+		Attributes: map[string]interface{}{
+			"provider":    identity.Provider,
+			"subject":     identity.Subject,
+			"provisioned": provisioned,
+		},
+	})
+}
@@ -0,0 +1,352 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/pkg/auth/jwks"
+	"go-hex/pkg/health"
+	"go-hex/pkg/times"
+	"go-hex/shared/ierr"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/github"
+)
+
+// googleIssuers are the issuers of the Google ID tokens, the first one publishes the discovery document
+var googleIssuers = []string{"https://accounts.google.com", "accounts.google.com"}
+
+// githubAPIURL is the base URL of the GitHub REST API
+var githubAPIURL = "https://api.github.com"
+
+// minKeysRefresh bounds how often the key set of a provider is read again for an unknown kid
+const minKeysRefresh = time.Minute
+
+// SocialAccount is the account of a user authenticated by a social login provider
+type SocialAccount struct {
+	Issuer        string // iss of the ID token, empty when the provider has none
+	Subject       string // id of the account at the provider
+	Email         string
+	EmailVerified bool
+	Name          string
+	SessionID     string // sid of the ID token, empty when the provider has none
+}
+
+// newSocialProviders returns the social login providers enabled by their client id, by name
+func newSocialProviders(cfg *configs.Config) map[string]SocialProvider {
+	client := &http.Client{Timeout: time.Duration(cfg.Social.Timeout) * time.Second}
+	providers := map[string]SocialProvider{}
+	if cfg.Social.GoogleClientID != "" {
+		providers[domain.ProviderGoogle] = newOIDCProvider(googleIssuers, oauth2.Config{
+			ClientID:     cfg.Social.GoogleClientID,
+			ClientSecret: cfg.Social.GoogleClientSecret,
+			RedirectURL:  cfg.Social.RedirectURL,
+		}, client)
+	}
+	if cfg.Social.GitHubClientID != "" {
+		providers[domain.ProviderGitHub] = newGitHubProvider(oauth2.Config{
+			ClientID:     cfg.Social.GitHubClientID,
+			ClientSecret: cfg.Social.GitHubClientSecret,
+			RedirectURL:  cfg.Social.RedirectURL,
+			Endpoint:     github.Endpoint,
+		}, githubAPIURL, client)
+	}
+	if cfg.Social.OIDCClientID != "" {
+		providers[domain.ProviderOIDC] = newOIDCProvider([]string{strings.TrimSuffix(cfg.Social.OIDCIssuer, "/")}, oauth2.Config{
+			ClientID:     cfg.Social.OIDCClientID,
+			ClientSecret: cfg.Social.OIDCClientSecret,
+			RedirectURL:  cfg.Social.RedirectURL,
+		}, client)
+	}
+	return providers
+}
+
+// RegisterSocialProviderChecks registers the checks of the OpenID providers configured, reading their discovery
+// document. They are optional, the other logins work without them. GitHub is not checked, its API limits the calls
+// without token too much to be probed.
+func RegisterSocialProviderChecks(cfg *configs.Config, checks *health.Registry) {
+	client := &http.Client{Timeout: time.Duration(cfg.Social.Timeout) * time.Second}
+	if cfg.Social.GoogleClientID != "" {
+		checks.RegisterOptional("google", health.HTTP(client, googleIssuers[0]+"/.well-known/openid-configuration"))
+	}
+	if cfg.Social.OIDCClientID != "" {
+		checks.RegisterOptional("oidc", health.HTTP(client, strings.TrimSuffix(cfg.Social.OIDCIssuer, "/")+"/.well-known/openid-configuration"))
+	}
+}
+
+// oidcMetadata is the part of the discovery document of an OpenID provider read to log in
+type oidcMetadata struct {
+	Issuer        string `json:"issuer"`
+	TokenEndpoint string `json:"token_endpoint"`
+	JWKSURI       string `json:"jwks_uri"`
+}
+
+// oidcProvider logs in with an OpenID Connect provider, e.g. Google: the code is exchanged at the token endpoint
+// of its discovery document and the ID token validated with the keys of its key set.
+type oidcProvider struct {
+	issuers []string
+	oauth   oauth2.Config
+	client  *http.Client
+
+	mu       sync.Mutex
+	metadata *oidcMetadata
+	keys     map[string]crypto.PublicKey
+	keysAt   time.Time
+}
+
+// newOIDCProvider creates a provider accepting the ID tokens of the issuers, the first one publishes the discovery
+// document. The endpoint of oauth is read from the discovery document.
+func newOIDCProvider(issuers []string, oauth oauth2.Config, client *http.Client) *oidcProvider {
+	return &oidcProvider{issuers: issuers, oauth: oauth, client: client}
+}
+
+// Exchange exchanges the authorization code and returns the account of its validated ID token
+func (p *oidcProvider) Exchange(ctx context.Context, code, codeVerifier, nonce, redirectURI string) (SocialAccount, error) {
+	metadata, err := p.discover(ctx)
+	if err != nil {
+		return SocialAccount{}, err
+	}
+
+	conf := p.oauth
+	conf.Endpoint = oauth2.Endpoint{TokenURL: metadata.TokenEndpoint}
+	token, err := exchange(ctx, p.client, conf, code, codeVerifier, redirectURI)
+	if err != nil {
+		return SocialAccount{}, err
+	}
+
+	rawIDToken, _ := token.Extra("id_token").(string)
+	if rawIDToken == "" {
+		return SocialAccount{}, errors.Wrap(ierr.ErrInvalidToken, "missing id_token")
+	}
+	return p.verify(ctx, rawIDToken, nonce)
+}
+
+// verify validates the signature and the claims of the ID token, see section 3.1.3.7 of OpenID Connect Core
+func (p *oidcProvider) verify(ctx context.Context, rawIDToken, nonce string) (SocialAccount, error) {
+	token, err := jwt.Parse(rawIDToken, func(token *jwt.Token) (interface{}, error) {
+		switch token.Method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA:
+		default:
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		kid, _ := token.Header["kid"].(string)
+		return p.key(ctx, kid)
+	})
+	if err != nil {
+		return SocialAccount{}, errors.Wrap(ierr.ErrInvalidToken, err.Error())
+	}
+
+	claims := token.Claims.(jwt.MapClaims)
+	issuer, _ := claims["iss"].(string)
+	if !contains(p.issuers, issuer) {
+		return SocialAccount{}, errors.Wrap(ierr.ErrInvalidToken, "invalid issuer")
+	}
+	if !verifyAudience(claims, p.oauth.ClientID) {
+		return SocialAccount{}, errors.Wrap(ierr.ErrInvalidToken, "invalid audience")
+	}
+	if azp, ok := claims["azp"].(string); ok && azp != p.oauth.ClientID {
+		return SocialAccount{}, errors.Wrap(ierr.ErrInvalidToken, "invalid authorized party")
+	}
+	if _, ok := claims["exp"]; !ok {
+		return SocialAccount{}, errors.Wrap(ierr.ErrInvalidToken, "missing exp")
+	}
+	if claimed, _ := claims["nonce"].(string); nonce != "" && claimed != nonce {
+		return SocialAccount{}, errors.Wrap(ierr.ErrInvalidToken, "invalid nonce")
+	}
+
+	account := SocialAccount{Issuer: issuer}
+	account.Subject, _ = claims["sub"].(string)
+	if account.Subject == "" {
+		return SocialAccount{}, errors.Wrap(ierr.ErrInvalidToken, "missing sub")
+	}
+	account.Email, _ = claims["email"].(string)
+	account.Name, _ = claims["name"].(string)
+	account.SessionID, _ = claims["sid"].(string)
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		account.EmailVerified = verified
+	case string:
+		account.EmailVerified = verified == "true"
+	}
+	return account, nil
+}
+
+// discover reads the discovery document of the provider once
+func (p *oidcProvider) discover(ctx context.Context) (oidcMetadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.metadata != nil {
+		return *p.metadata, nil
+	}
+
+	var metadata oidcMetadata
+	if err := getJSON(ctx, p.client, p.issuers[0]+"/.well-known/openid-configuration", "", &metadata); err != nil {
+		return metadata, errors.Wrap(err, "cannot read openid configuration")
+	}
+	if metadata.Issuer != p.issuers[0] || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return metadata, errors.Errorf("invalid openid configuration of %s", p.issuers[0])
+	}
+	p.metadata = &metadata
+	return metadata, nil
+}
+
+// key returns the key of the key set of the provider, read again for an unknown kid at most every minKeysRefresh.
+// A token without kid is verified with the only key of the set.
+func (p *oidcProvider) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.lookup(kid); ok {
+		return key, nil
+	}
+	if p.keys != nil && times.Now().Sub(p.keysAt) < minKeysRefresh {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+
+	var set jwks.Set
+	if err := getJSON(ctx, p.client, p.metadata.JWKSURI, "", &set); err != nil {
+		return nil, errors.Wrap(err, "cannot read key set")
+	}
+	keys := map[string]crypto.PublicKey{}
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.PublicKey()
+		if err != nil {
+			continue // the keys of the other types are not used to sign the ID tokens
+		}
+		keys[jwk.KeyID] = key
+	}
+	p.keys, p.keysAt = keys, times.Now()
+
+	if key, ok := p.lookup(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown key %q", kid)
+}
+
+func (p *oidcProvider) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+// githubProvider logs in with GitHub, an OAuth2 provider without ID token: the account is read from its API with
+// the access token, and its email address is the primary address of the account once verified.
+type githubProvider struct {
+	oauth  oauth2.Config
+	apiURL string
+	client *http.Client
+}
+
+func newGitHubProvider(oauth oauth2.Config, apiURL string, client *http.Client) *githubProvider {
+	return &githubProvider{oauth, apiURL, client}
+}
+
+// Exchange exchanges the authorization code and reads the account, GitHub has no nonce
+func (p *githubProvider) Exchange(ctx context.Context, code, codeVerifier, nonce, redirectURI string) (SocialAccount, error) {
+	token, err := exchange(ctx, p.client, p.oauth, code, codeVerifier, redirectURI)
+	if err != nil {
+		return SocialAccount{}, err
+	}
+
+	var user struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+		Name  string `json:"name"`
+	}
+	if err := getJSON(ctx, p.client, p.apiURL+"/user", token.AccessToken, &user); err != nil {
+		return SocialAccount{}, errors.Wrap(err, "cannot read github user")
+	}
+	if user.ID == 0 {
+		return SocialAccount{}, errors.New("cannot read github user: missing id")
+	}
+
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := getJSON(ctx, p.client, p.apiURL+"/user/emails", token.AccessToken, &emails); err != nil {
+		return SocialAccount{}, errors.Wrap(err, "cannot read github user emails")
+	}
+
+	account := SocialAccount{Subject: strconv.FormatInt(user.ID, 10), Name: user.Name}
+	if account.Name == "" {
+		account.Name = user.Login
+	}
+	for _, email := range emails {
+		if email.Primary {
+			account.Email, account.EmailVerified = email.Email, email.Verified
+		}
+	}
+	return account, nil
+}
+
+// exchange exchanges the authorization code for the tokens of the provider, a code refused by the provider is an
+// invalid token. The redirect uri must be the one the code was requested with.
+func exchange(ctx context.Context, client *http.Client, conf oauth2.Config, code, codeVerifier, redirectURI string) (*oauth2.Token, error) {
+	if redirectURI != "" {
+		conf.RedirectURL = redirectURI
+	}
+	var opts []oauth2.AuthCodeOption
+	if codeVerifier != "" {
+		opts = append(opts, oauth2.SetAuthURLParam("code_verifier", codeVerifier))
+	}
+	token, err := conf.Exchange(context.WithValue(ctx, oauth2.HTTPClient, client), code, opts...)
+	if err != nil {
+		if _, ok := err.(*oauth2.RetrieveError); ok {
+			return nil, errors.Wrap(ierr.ErrInvalidToken, err.Error())
+		}
+		return nil, errors.Wrap(err, "cannot exchange authorization code")
+	}
+	return token, nil
+}
+
+// getJSON decodes the JSON answered to a GET of the url, authorized by the bearer token when given
+func getJSON(ctx context.Context, client *http.Client, url string, bearer string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
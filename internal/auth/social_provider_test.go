@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"go-hex/pkg/auth"
+	"go-hex/pkg/auth/jwks"
+	"go-hex/shared/ierr"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+// newOIDCServer serves the discovery document, the token endpoint answering the ID token of claims signed by signer
+// for the code "valid-code", and the key set of key.
+func newOIDCServer(t *testing.T, key, signer *ecdsa.PrivateKey, claims func(issuer string) jwt.MapClaims) *httptest.Server {
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(oidcMetadata{Issuer: server.URL, TokenEndpoint: server.URL + "/token", JWKSURI: server.URL + "/jwks"})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("code") != "valid-code" || r.FormValue("code_verifier") != "verifier" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodES256, claims(server.URL))
+		token.Header["kid"] = "k1"
+		idToken, err := token.SignedString(signer)
+		require.NoError(t, err)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "at", "token_type": "Bearer", "id_token": idToken})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		jwk, err := jwks.NewKey(auth.PublicKey{KeyID: "k1", Method: jwt.SigningMethodES256, Key: &key.PublicKey})
+		require.NoError(t, err)
+		_ = json.NewEncoder(w).Encode(jwks.Set{Keys: []jwks.Key{jwk}})
+	})
+	server = httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestOIDCProviderExchange(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	validClaims := func(issuer string) jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":            issuer,
+			"aud":            "go-hex",
+			"exp":            time.Now().Add(time.Minute).Unix(),
+			"iat":            time.Now().Unix(),
+			"sub":            "upstream-user",
+			"email":          "jane@example.com",
+			"email_verified": "true",
+			"name":           "Jane",
+			"nonce":          "n1",
+			"sid":            "upstream-session",
+		}
+	}
+
+	tests := []struct {
+		name    string
+		signer  *ecdsa.PrivateKey
+		code    string
+		mutate  func(claims jwt.MapClaims)
+		wantErr error
+	}{
+		{name: "valid id token"},
+		{name: "code refused", code: "wrong-code", wantErr: ierr.ErrInvalidToken},
+		{name: "other audience", mutate: func(claims jwt.MapClaims) { claims["aud"] = "other" }, wantErr: ierr.ErrInvalidToken},
+		{name: "other authorized party", mutate: func(claims jwt.MapClaims) { claims["azp"] = "other" }, wantErr: ierr.ErrInvalidToken},
+		{name: "other issuer", mutate: func(claims jwt.MapClaims) { claims["iss"] = "https://evil.example.com" }, wantErr: ierr.ErrInvalidToken},
+		{name: "nonce mismatch", mutate: func(claims jwt.MapClaims) { claims["nonce"] = "n2" }, wantErr: ierr.ErrInvalidToken},
+		{name: "expired", mutate: func(claims jwt.MapClaims) { claims["exp"] = time.Now().Add(-time.Minute).Unix() }, wantErr: ierr.ErrInvalidToken},
+		{name: "missing subject", mutate: func(claims jwt.MapClaims) { delete(claims, "sub") }, wantErr: ierr.ErrInvalidToken},
+		{name: "signed with another key", signer: otherKey, wantErr: ierr.ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer := key
+			if tt.signer != nil {
+				signer = tt.signer
+			}
+			server := newOIDCServer(t, key, signer, func(issuer string) jwt.MapClaims {
+				claims := validClaims(issuer)
+				if tt.mutate != nil {
+					tt.mutate(claims)
+				}
+				return claims
+			})
+			provider := newOIDCProvider([]string{server.URL}, oauth2.Config{ClientID: "go-hex", ClientSecret: "secret"}, server.Client())
+
+			code := "valid-code"
+			if tt.code != "" {
+				code = tt.code
+			}
+			account, err := provider.Exchange(context.Background(), code, "verifier", "n1", "")
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, errors.Cause(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, SocialAccount{
+				Issuer:        server.URL,
+				Subject:       "upstream-user",
+				Email:         "jane@example.com",
+				EmailVerified: true,
+				Name:          "Jane",
+				SessionID:     "upstream-session",
+			}, account)
+		})
+	}
+}
+
+func TestGitHubProviderExchange(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.FormValue("code") != "valid-code" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"bad_verification_code"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"gh-token","token_type":"bearer"}`))
+	})
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer gh-token", r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(`{"id":42,"login":"jane","name":""}`))
+	})
+	mux.HandleFunc("/user/emails", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"email":"old@example.com","primary":false,"verified":true},{"email":"jane@example.com","primary":true,"verified":true}]`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	provider := newGitHubProvider(oauth2.Config{
+		ClientID:     "go-hex",
+		ClientSecret: "secret",
+		Endpoint:     oauth2.Endpoint{TokenURL: server.URL + "/login/oauth/access_token"},
+	}, server.URL, server.Client())
+
+	account, err := provider.Exchange(context.Background(), "valid-code", "", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, SocialAccount{Subject: "42", Email: "jane@example.com", EmailVerified: true, Name: "jane"}, account)
+
+	_, err = provider.Exchange(context.Background(), "wrong-code", "", "", "")
+	assert.Equal(t, ierr.ErrInvalidToken, errors.Cause(err))
+}
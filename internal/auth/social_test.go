@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/notification"
+	"go-hex/internal/repository/memory"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/shared/ctxutil"
+	"go-hex/shared/ierr"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSocialProvider struct {
+	account     SocialAccount
+	redirectURI string // the one the valid code was requested with
+}
+
+func (p fakeSocialProvider) Exchange(ctx context.Context, code, codeVerifier, nonce, redirectURI string) (SocialAccount, error) {
+	if code != "valid-code" || redirectURI != p.redirectURI {
+		return SocialAccount{}, ierr.ErrInvalidToken
+	}
+	return p.account, nil
+}
+
+func TestLoginWithProvider(t *testing.T) {
+	email := "jane@example.com"
+	jane := domain.User{ID: "u1", Username: "jane", Email: &email, VerifiedEmail: &email, IsActive: true}
+	account := SocialAccount{Subject: "g-1", Email: email, EmailVerified: true, Name: "Jane"}
+
+	tests := []struct {
+		name          string
+		users         []domain.User
+		account       SocialAccount
+		autoProvision bool
+		wantErr       error
+		wantUser      func(t *testing.T, user domain.User)
+	}{
+		{
+			name:     "links the user with the verified email address",
+			users:    []domain.User{jane},
+			account:  account,
+			wantUser: func(t *testing.T, user domain.User) { assert.Equal(t, jane, user) },
+		},
+		{
+			name:    "never links an unverified email address",
+			users:   []domain.User{jane},
+			account: SocialAccount{Subject: "g-1", Email: email},
+			wantErr: ierr.ErrSocialAccountUnlinked,
+		},
+		{
+			name:          "never links an email address the user did not verify",
+			users:         []domain.User{{ID: "u1", Username: "jane", Email: &email, IsActive: true}},
+			account:       account,
+			autoProvision: true,
+			wantErr:       ierr.ErrSocialAccountUnlinked,
+		},
+		{
+			name:          "provisions an unknown user",
+			account:       account,
+			autoProvision: true,
+			wantUser: func(t *testing.T, user domain.User) {
+				assert.Equal(t, email, user.Username)
+				assert.Equal(t, email, user.GetEmail())
+				assert.True(t, user.IsEmailVerified(), "verified by the provider")
+				assert.True(t, user.IsActive)
+				assert.NotEmpty(t, user.Password)
+			},
+		},
+		{
+			name:          "provisions an account without email after the provider",
+			account:       SocialAccount{Subject: "g-1"},
+			autoProvision: true,
+			wantUser:      func(t *testing.T, user domain.User) { assert.Equal(t, "google:g-1", user.Username) },
+		},
+		{
+			name:    "refuses an unknown user without auto provisioning",
+			account: account,
+			wantErr: ierr.ErrSocialAccountUnlinked,
+		},
+		{
+			name:    "refuses an inactive user",
+			users:   []domain.User{{ID: "u1", Username: "jane", Email: &email, VerifiedEmail: &email}},
+			account: account,
+			wantErr: ierr.ErrUserIsNotActive,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestStore(t, tt.users...)
+			cfg := &configs.Config{}
+			cfg.JWT.SigningKey = "test-signing-key"
+			cfg.JWT.TokenExpiration = 60
+			cfg.JWT.RefreshTokenExpiration = 60
+			cfg.PasswordPool.QueueTimeout = 1000
+			cfg.Social.AutoProvision = tt.autoProvision
+			events := event.New()
+			var linked []event.Event
+			events.Subscribe(domain.EventSocialAccountLinked, func(ctx context.Context, e event.Event) {
+				linked = append(linked, e)
+			})
+			svc := NewService(cfg, store, memory.NewTokenBlacklistRepository(), memory.NewOpaqueTokenRepository(), newIdentityViews(store), logger.New("test", "test"), events, notification.NewDispatcher(), noDeprecations{})
+			svc.providers = map[string]SocialProvider{domain.ProviderGoogle: fakeSocialProvider{account: tt.account}}
+
+			req := RequestSocialLogin{Provider: domain.ProviderGoogle, Code: "valid-code"}
+			res, err := svc.LoginWithProvider(context.Background(), req)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotEmpty(t, res.AccessToken)
+			assert.NotEmpty(t, res.RefreshToken)
+
+			identity, err := store.GetUserIdentityRepository().Get(context.Background(), domain.ProviderGoogle, "g-1")
+			require.NoError(t, err)
+			tt.wantUser(t, getUser(t, store, identity.UserID))
+			assert.Len(t, linked, 1)
+
+			// the link is reused by the next logins
+			_, err = svc.LoginWithProvider(context.Background(), req)
+			require.NoError(t, err)
+			assert.Len(t, linked, 1)
+			users, err := store.GetUserRepository().List(context.Background(), "", 0)
+			require.NoError(t, err)
+			assert.Len(t, users, 1)
+		})
+	}
+
+	t.Run("unknown provider", func(t *testing.T) {
+		svc := &Service{cfg: &configs.Config{}}
+		_, err := svc.LoginWithProvider(context.Background(), RequestSocialLogin{Provider: "facebook", Code: "valid-code"})
+		assert.Equal(t, ierr.ErrSocialProviderUnknown, err)
+	})
+	t.Run("code refused by the provider", func(t *testing.T) {
+		svc := &Service{cfg: &configs.Config{}, providers: map[string]SocialProvider{domain.ProviderGoogle: fakeSocialProvider{account: account}}, log: logger.New("test", "test")}
+		_, err := svc.LoginWithProvider(context.Background(), RequestSocialLogin{Provider: domain.ProviderGoogle, Code: "wrong-code"})
+		assert.Equal(t, ierr.ErrInvalidToken, err)
+	})
+	t.Run("redirect uri of the client", func(t *testing.T) {
+		cfg := &configs.Config{}
+		require.NoError(t, cfg.Redirect.Allowlist.Decode("web=https://app.example.com/auth/*"))
+		provider := fakeSocialProvider{account: account, redirectURI: "https://app.example.com/auth/callback"}
+		svc := &Service{cfg: cfg, repoRegitry: memory.NewStore(), providers: map[string]SocialProvider{domain.ProviderGoogle: provider}, log: logger.New("test", "test")}
+
+		_, err := svc.LoginWithProvider(context.Background(), RequestSocialLogin{Provider: domain.ProviderGoogle, Code: "valid-code", ClientID: "web", RedirectURI: "https://evil.example.com/auth/callback"})
+		assert.Equal(t, ierr.ErrRedirectNotAllowed, errors.Cause(err))
+		_, err = svc.LoginWithProvider(context.Background(), RequestSocialLogin{Provider: domain.ProviderGoogle, Code: "valid-code", ClientID: "ios", RedirectURI: provider.redirectURI})
+		assert.Equal(t, ierr.ErrRedirectNotAllowed, errors.Cause(err))
+		// the allowed redirect uri is sent to the provider, which accepts the code requested with it
+		_, err = svc.LoginWithProvider(context.Background(), RequestSocialLogin{Provider: domain.ProviderGoogle, Code: "valid-code", ClientID: "web", RedirectURI: provider.redirectURI})
+		assert.Equal(t, ierr.ErrSocialAccountUnlinked, errors.Cause(err))
+	})
+}
+
+func TestLinkProvider(t *testing.T) {
+	store := memory.NewStore()
+	account := SocialAccount{Subject: "g-1", Email: "jane@example.com"}
+	svc := &Service{cfg: &configs.Config{}, repoRegitry: store, providers: map[string]SocialProvider{domain.ProviderGoogle: fakeSocialProvider{account: account}}, events: event.New()}
+	loggedIn := ctxutil.WithPrincipal(context.Background(), &jwt.Token{
+		Claims: jwt.MapClaims{"id": "u1", "token_type": TokenTypeAccess},
+	})
+
+	err := svc.LinkProvider(loggedIn, RequestSocialLogin{Provider: domain.ProviderGoogle, Code: "wrong-code"})
+	assert.Equal(t, ierr.ErrInvalidToken, err)
+
+	// the address is unverified at the provider, the account is linked as the user authenticated
+	require.NoError(t, svc.LinkProvider(loggedIn, RequestSocialLogin{Provider: domain.ProviderGoogle, Code: "valid-code"}))
+	identity, err := store.GetUserIdentityRepository().Get(context.Background(), domain.ProviderGoogle, "g-1")
+	require.NoError(t, err)
+	assert.Equal(t, "u1", identity.UserID)
+	assert.Nil(t, identity.Email)
+
+	err = svc.LinkProvider(loggedIn, RequestSocialLogin{Provider: domain.ProviderGoogle, Code: "valid-code"})
+	assert.Equal(t, ierr.ErrConflict, err, "an account is linked to a single user")
+}
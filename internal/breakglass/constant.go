@@ -0,0 +1,11 @@
+package breakglass
+
+const (
+	// ActorScheduler is the actor of the activations revoked once expired
+	ActorScheduler = "scheduler"
+
+	// number of custodians sharing the password of an account, all of them are needed to activate it
+	custodians = 2
+	// size in bytes of the passwords and of their shares
+	secretSize = 32
+)
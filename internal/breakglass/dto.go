@@ -0,0 +1,93 @@
+package breakglass
+
+import (
+	"regexp"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pkg/errors"
+)
+
+var custodianName = regexp.MustCompile(`^[a-zA-Z0-9._@-]+$`)
+
+var custodianRules = []validation.Rule{validation.Required, validation.Length(1, 100), validation.Match(custodianName)}
+
+// RequestSeal request params
+type RequestSeal struct {
+	Username   string   `json:"username" example:"break-glass-1"`
+	Custodians []string `json:"custodians" example:"alice,bob"`
+}
+
+func (r *RequestSeal) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Username, validation.Required),
+		validation.Field(&r.Custodians, validation.Required, validation.Length(custodians, custodians), validation.Each(custodianRules...), validation.By(distinct)),
+	)
+}
+
+// Share is the share of the password of a break-glass account held by a custodian
+type Share struct {
+	Custodian string `json:"custodian" example:"alice"`
+	Share     string `json:"share" example:"Y2hhbmdlLW1lLWNoYW5nZS1tZS1jaGFuZ2UtbWUtY2hh"` // base64url
+}
+
+// ResponseSeal struct
+type ResponseSeal struct {
+	Username string  `json:"username" example:"break-glass-1"`
+	Shares   []Share `json:"shares"` // only returned once, to be handed over to each custodian
+}
+
+// RequestActivate request params
+type RequestActivate struct {
+	Username string  `json:"username" example:"break-glass-1"`
+	Shares   []Share `json:"shares"`
+	Reason   string  `json:"reason" example:"identity provider down, INC-1234"`
+	Duration int     `json:"duration" example:"60"` // in minutes
+}
+
+func (r *RequestActivate) Validate() error {
+	names := make([]string, 0, len(r.Shares))
+	for _, share := range r.Shares {
+		names = append(names, share.Custodian)
+	}
+	return validation.Errors{
+		"username":   validation.Validate(r.Username, validation.Required),
+		"shares":     validation.Validate(r.Shares, validation.Required, validation.Length(custodians, custodians)),
+		"custodians": validation.Validate(names, validation.Each(custodianRules...), validation.By(distinct)),
+		"reason":     validation.Validate(r.Reason, validation.Required, validation.Length(10, 255)),
+		"duration":   validation.Validate(r.Duration, validation.Required, validation.Min(1)),
+	}.Filter()
+}
+
+// ResponseActivate struct
+type ResponseActivate struct {
+	Username  string    `json:"username" example:"break-glass-1"`
+	Password  string    `json:"password" example:"Y2hhbmdlLW1lLWNoYW5nZS1tZS1jaGFuZ2UtbWUtY2hh"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// RequestRevoke request params
+type RequestRevoke struct {
+	Username  string `json:"username" example:"break-glass-1"`
+	RevokedBy string `json:"revoked_by" example:"alice"`
+}
+
+func (r *RequestRevoke) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Username, validation.Required),
+		validation.Field(&r.RevokedBy, custodianRules...),
+	)
+}
+
+// distinct checks that the custodians are different persons
+func distinct(value interface{}) error {
+	names, _ := value.([]string)
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if seen[name] {
+			return errors.New("must be different persons")
+		}
+		seen[name] = true
+	}
+	return nil
+}
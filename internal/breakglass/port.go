@@ -0,0 +1,15 @@
+package breakglass
+
+import "context"
+
+// ServicePort encapsulates the break-glass account logic.
+type ServicePort interface {
+	// Seal replaces the password of the user with a random one split between two custodians
+	Seal(ctx context.Context, req RequestSeal) (ResponseSeal, error)
+	// Activate reveals the password of a sealed account from the shares of its two custodians
+	Activate(ctx context.Context, req RequestActivate) (ResponseActivate, error)
+	// Revoke ends the activation of an account before it expires
+	Revoke(ctx context.Context, req RequestRevoke) error
+	// ExpireActivations revokes the activations which expired and returns how many were revoked
+	ExpireActivations(ctx context.Context) (int, error)
+}
@@ -0,0 +1,29 @@
+package breakglass
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/pkg/logger"
+	"sync"
+
+	"github.com/go-co-op/gocron"
+)
+
+// RegisterScheduler schedules the revocation of the expired activations with the configured cron pattern.
+func RegisterScheduler(cfg *configs.Config, log logger.Logger, service ServicePort, cron *gocron.Scheduler, wg *sync.WaitGroup) {
+
+	_, err := cron.Cron(cfg.Scheduler.BreakGlassPattern).SingletonMode().Do(func() {
+		wg.Add(1)
+		defer wg.Done()
+
+		revoked, err := service.ExpireActivations(context.Background())
+		if err != nil {
+			log.WithStack(err).Error(err)
+			return
+		}
+		log.WithParams(logger.Params{"break_glass_accounts": revoked}).Info("expired break-glass activations revoked")
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
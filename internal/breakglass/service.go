@@ -0,0 +1,311 @@
+package breakglass
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/password"
+	"go-hex/pkg/times"
+	"go-hex/shared/ierr"
+	"sort"
+	"strings"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pkg/errors"
+)
+
+// Service encapsulates the break-glass account logic. The password of a sealed account is random and only
+// returned as two shares, one per custodian, whose XOR is the password: it is revealed by an activation only
+// when both custodians enter their share, for a bounded time after which the activation is revoked and the
+// account must be sealed again. Every step is logged at the error level and published on the event bus so
+// that it reaches the security events.
+type Service struct {
+	cfg         *configs.Config
+	repoRegitry port.RepositoryRegistry
+	log         logger.Logger
+	events      event.Bus
+}
+
+// NewService creates and returns a new break-glass account service
+func NewService(cfg *configs.Config, repoRegitry port.RepositoryRegistry, log logger.Logger, events event.Bus) *Service {
+	return &Service{cfg, repoRegitry, log, events}
+}
+
+// Seal replaces the password of the user with a random one split between two custodians and revokes its
+// sessions, the account cannot log in until it is activated.
+func (s *Service) Seal(ctx context.Context, req RequestSeal) (ResponseSeal, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return ResponseSeal{}, err
+	}
+
+	user, err := s.repoRegitry.GetUserRepository().GetByUsername(ctx, req.Username)
+	if err != nil {
+		return ResponseSeal{}, err
+	}
+
+	secret, err := randomSecret()
+	if err != nil {
+		return ResponseSeal{}, err
+	}
+	share, err := randomSecret()
+	if err != nil {
+		return ResponseSeal{}, err
+	}
+	hashed, err := s.cfg.PasswordHasher().Hash([]byte(encode(secret)))
+	if err != nil {
+		return ResponseSeal{}, err
+	}
+
+	account := domain.BreakGlassAccount{
+		UserID:     user.ID,
+		Status:     domain.BreakGlassStatusSealed,
+		Custodians: strings.Join(req.Custodians, ","),
+		SealedAt:   times.Now(),
+	}
+	_, err = s.repoRegitry.DoInTransaction(ctx, func(ctx context.Context, repoRegistry port.RepositoryRegistry) (interface{}, error) {
+		err := repoRegistry.GetUserRepository().Update(ctx, user.ID, domain.User{Password: hashed})
+		if err != nil {
+			return nil, err
+		}
+		err = repoRegistry.GetBreakGlassAccountRepository().Seal(ctx, account)
+		if err != nil {
+			return nil, err
+		}
+		return nil, repoRegistry.GetSessionRepository().RevokeByUserID(ctx, user.ID)
+	})
+	if err != nil {
+		return ResponseSeal{}, err
+	}
+
+	s.publish(ctx, domain.EventBreakGlassSealed, account.Custodians, account, "break-glass account sealed")
+
+	return ResponseSeal{
+		Username: user.Username,
+		Shares: []Share{
+			{Custodian: req.Custodians[0], Share: encode(share)},
+			{Custodian: req.Custodians[1], Share: encode(xor(secret, share))},
+		},
+	}, nil
+}
+
+// Activate reveals the password of a sealed account from the shares of its two custodians, the account
+// can log in with it until the activation expires.
+func (s *Service) Activate(ctx context.Context, req RequestActivate) (ResponseActivate, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return ResponseActivate{}, err
+	}
+	if req.Duration > s.cfg.BreakGlass.MaxDuration {
+		return ResponseActivate{}, validation.Errors{"duration": errors.Errorf("must be no greater than %d", s.cfg.BreakGlass.MaxDuration)}
+	}
+
+	user, err := s.repoRegitry.GetUserRepository().GetByUsername(ctx, req.Username)
+	if err != nil {
+		return ResponseActivate{}, err
+	}
+	repoAccount := s.repoRegitry.GetBreakGlassAccountRepository()
+	account, err := repoAccount.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return ResponseActivate{}, err
+	}
+	if account.Status != domain.BreakGlassStatusSealed {
+		return ResponseActivate{}, ierr.ErrBreakGlassNotSealed
+	}
+
+	operators := []string{req.Shares[0].Custodian, req.Shares[1].Custodian}
+	account.ActivatedBy = strings.Join(operators, ",")
+	account.Reason = req.Reason
+
+	plain, ok := s.reveal(account, req.Shares)
+	if !ok || !password.ComparePasswords(user.Password, []byte(plain)) {
+		s.publish(ctx, domain.EventBreakGlassRejected, account.ActivatedBy, account, "break-glass activation rejected")
+		return ResponseActivate{}, ierr.ErrInvalidCreds
+	}
+
+	now := times.Now()
+	expiresAt := now.Add(time.Duration(req.Duration) * time.Minute)
+	account.ActivatedAt = &now
+	account.ExpiresAt = &expiresAt
+	activated, err := repoAccount.Activate(ctx, account)
+	if err != nil {
+		return ResponseActivate{}, err
+	}
+	if !activated {
+		return ResponseActivate{}, ierr.ErrBreakGlassNotSealed
+	}
+
+	s.publish(ctx, domain.EventBreakGlassActivated, account.ActivatedBy, account, "BREAK-GLASS ACCOUNT ACTIVATED")
+
+	return ResponseActivate{
+		Username:  user.Username,
+		Password:  plain,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// Revoke ends the activation of an account before it expires
+func (s *Service) Revoke(ctx context.Context, req RequestRevoke) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return err
+	}
+
+	user, err := s.repoRegitry.GetUserRepository().GetByUsername(ctx, req.Username)
+	if err != nil {
+		return err
+	}
+	account, err := s.repoRegitry.GetBreakGlassAccountRepository().GetByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	return s.revoke(ctx, account, req.RevokedBy)
+}
+
+// ExpireActivations revokes the activations which expired and returns how many were revoked
+func (s *Service) ExpireActivations(ctx context.Context) (int, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	accounts, err := s.repoRegitry.GetBreakGlassAccountRepository().ListExpired(ctx, times.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	revoked := 0
+	for _, account := range accounts {
+		err := s.revoke(ctx, account, ActorScheduler)
+		if errors.Cause(err) == ierr.ErrBreakGlassNotActive {
+			// revoked meanwhile
+			continue
+		}
+		if err != nil {
+			return revoked, err
+		}
+		revoked++
+	}
+	return revoked, nil
+}
+
+// revoke marks the activation as spent, revokes the sessions of the account and replaces its password
+// with a random one nobody knows, so that the revealed password stops working.
+func (s *Service) revoke(ctx context.Context, account domain.BreakGlassAccount, actor string) error {
+
+	secret, err := randomSecret()
+	if err != nil {
+		return err
+	}
+	hashed, err := s.cfg.PasswordHasher().Hash([]byte(encode(secret)))
+	if err != nil {
+		return err
+	}
+
+	now := times.Now()
+	_, err = s.repoRegitry.DoInTransaction(ctx, func(ctx context.Context, repoRegistry port.RepositoryRegistry) (interface{}, error) {
+		revoked, err := repoRegistry.GetBreakGlassAccountRepository().Revoke(ctx, account.UserID, now)
+		if err != nil {
+			return nil, err
+		}
+		if !revoked {
+			return nil, ierr.ErrBreakGlassNotActive
+		}
+		err = repoRegistry.GetUserRepository().Update(ctx, account.UserID, domain.User{Password: hashed})
+		if err != nil {
+			return nil, err
+		}
+		return nil, repoRegistry.GetSessionRepository().RevokeByUserID(ctx, account.UserID)
+	})
+	if err != nil {
+		return err
+	}
+
+	account.Status = domain.BreakGlassStatusSpent
+	account.RevokedAt = &now
+	s.publish(ctx, domain.EventBreakGlassRevoked, actor, account, "break-glass account revoked")
+	return nil
+}
+
+// reveal combines the shares into the password when they are the shares of the custodians of the account
+func (s *Service) reveal(account domain.BreakGlassAccount, shares []Share) (string, bool) {
+
+	names := make([]string, 0, len(shares))
+	for _, share := range shares {
+		names = append(names, share.Custodian)
+	}
+	sort.Strings(names)
+	expected := strings.Split(account.Custodians, ",")
+	sort.Strings(expected)
+	if strings.Join(names, ",") != strings.Join(expected, ",") {
+		return "", false
+	}
+
+	first, err := base64.RawURLEncoding.DecodeString(shares[0].Share)
+	if err != nil || len(first) != secretSize {
+		return "", false
+	}
+	second, err := base64.RawURLEncoding.DecodeString(shares[1].Share)
+	if err != nil || len(second) != secretSize {
+		return "", false
+	}
+	return encode(xor(first, second)), true
+}
+
+// publish logs the step at the error level, so that it pages whoever watches the logs, and publishes it on the event bus
+func (s *Service) publish(ctx context.Context, name string, actor string, account domain.BreakGlassAccount, msg string) {
+	s.log.WithParams(logger.Params{"type": "break_glass", "event": name, "actor": actor, "account": account}).Error(msg)
+	attributes := map[string]interface{}{
+		"custodians": account.Custodians,
+	}
+	if account.Reason != "" {
+		attributes["reason"] = account.Reason
+	}
+	if account.ExpiresAt != nil {
+		attributes["expires_at"] = account.ExpiresAt.Format(time.RFC3339)
+	}
+	s.events.Publish(ctx, event.Event{
+		Name:       name,
+		ActorID:    actor,
+		SubjectID:  account.UserID,
+		Attributes: attributes,
+	})
+}
+
+func randomSecret() ([]byte, error) {
+	b := make([]byte, secretSize)
+	if _, err := rand.Read(b); err != nil {
+		return nil, errors.Wrap(err, "cannot generate break-glass secret")
+	}
+	return b, nil
+}
+
+func xor(a, b []byte) []byte {
+	res := make([]byte, len(a))
+	for i := range a {
+		res[i] = a[i] ^ b[i]
+	}
+	return res
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,132 @@
+package breakglass
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/memory"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/password"
+	"go-hex/shared/ierr"
+	"testing"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestService(t *testing.T) (*Service, *memory.Store, *[]string) {
+	cfg := &configs.Config{}
+	cfg.BreakGlass.MaxDuration = 240
+
+	store := memory.NewStore()
+	require.NoError(t, store.GetUserRepository().Create(context.Background(), domain.User{ID: "u1", Username: "break-glass-1", Password: "unknown", IsActive: true}))
+	events := event.New()
+	published := &[]string{}
+	events.Subscribe(event.All, func(ctx context.Context, e event.Event) {
+		*published = append(*published, e.Name)
+	})
+	return NewService(cfg, store, logger.New("test", "test"), events), store, published
+}
+
+func TestSealActivateAndExpire(t *testing.T) {
+	svc, store, published := newTestService(t)
+	ctx := context.Background()
+	var revocations int
+	store.SetHook(func(ctx context.Context, op string) error {
+		if op == "SessionRepository.RevokeByUserID" {
+			revocations++
+		}
+		return nil
+	})
+	getUser := func() domain.User {
+		user, err := store.GetUserRepository().GetByID(ctx, "u1")
+		require.NoError(t, err)
+		return user
+	}
+	getAccount := func() domain.BreakGlassAccount {
+		account, err := store.GetBreakGlassAccountRepository().GetByUserID(ctx, "u1")
+		require.NoError(t, err)
+		return account
+	}
+
+	sealed, err := svc.Seal(ctx, RequestSeal{Username: "break-glass-1", Custodians: []string{"alice", "bob"}})
+	require.NoError(t, err)
+	require.Len(t, sealed.Shares, 2)
+	assert.Equal(t, domain.BreakGlassStatusSealed, getAccount().Status)
+	assert.Equal(t, 1, revocations)
+
+	// a single share does not reveal the password
+	for _, share := range sealed.Shares {
+		assert.False(t, password.ComparePasswords(getUser().Password, []byte(share.Share)))
+	}
+
+	// the shares must come from both custodians
+	_, err = svc.Activate(ctx, RequestActivate{
+		Username: "break-glass-1",
+		Shares:   []Share{sealed.Shares[0], {Custodian: "mallory", Share: sealed.Shares[1].Share}},
+		Reason:   "identity provider down, INC-1234",
+		Duration: 60,
+	})
+	assert.Equal(t, ierr.ErrInvalidCreds, err)
+
+	// in any order
+	activated, err := svc.Activate(ctx, RequestActivate{
+		Username: "break-glass-1",
+		Shares:   []Share{sealed.Shares[1], sealed.Shares[0]},
+		Reason:   "identity provider down, INC-1234",
+		Duration: 60,
+	})
+	require.NoError(t, err)
+	assert.True(t, password.ComparePasswords(getUser().Password, []byte(activated.Password)))
+	account := getAccount()
+	assert.True(t, account.IsActiveAt(time.Now()))
+	assert.Equal(t, "bob,alice", account.ActivatedBy)
+
+	// an active account cannot be activated again
+	_, err = svc.Activate(ctx, RequestActivate{Username: "break-glass-1", Shares: sealed.Shares, Reason: "identity provider down, INC-1234", Duration: 60})
+	assert.Equal(t, ierr.ErrBreakGlassNotSealed, err)
+
+	// nothing expired yet
+	revoked, err := svc.ExpireActivations(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, revoked)
+
+	// backdate the end of the activation, sealing replaces the record as is
+	expiredAt := time.Now().Add(-time.Second)
+	account.ExpiresAt = &expiredAt
+	require.NoError(t, store.GetBreakGlassAccountRepository().Seal(ctx, account))
+	revoked, err = svc.ExpireActivations(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, revoked)
+	assert.Equal(t, domain.BreakGlassStatusSpent, getAccount().Status)
+	assert.Equal(t, 2, revocations)
+
+	// the revealed password stops working
+	assert.False(t, password.ComparePasswords(getUser().Password, []byte(activated.Password)))
+
+	assert.Equal(t, []string{
+		domain.EventBreakGlassSealed,
+		domain.EventBreakGlassRejected,
+		domain.EventBreakGlassActivated,
+		domain.EventBreakGlassRevoked,
+	}, *published)
+}
+
+func TestRequestValidation(t *testing.T) {
+	svc, _, _ := newTestService(t)
+	ctx := context.Background()
+
+	_, err := svc.Seal(ctx, RequestSeal{Username: "break-glass-1", Custodians: []string{"alice", "alice"}})
+	assert.IsType(t, validation.Errors{}, err)
+
+	_, err = svc.Activate(ctx, RequestActivate{
+		Username: "break-glass-1",
+		Shares:   []Share{{Custodian: "alice", Share: "a"}, {Custodian: "bob", Share: "b"}},
+		Reason:   "identity provider down, INC-1234",
+		Duration: 241,
+	})
+	assert.IsType(t, validation.Errors{}, err)
+}
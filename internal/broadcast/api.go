@@ -0,0 +1,210 @@
+package broadcast
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-hex/configs"
+	"go-hex/middleware"
+	"go-hex/shared/ierr"
+	"go-hex/shared/response"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+// RegisterAPI registers a new broadcast api
+func RegisterAPI(r echo.Group, cfg *configs.Config, service ServicePort) {
+	handler := handler{cfg, service}
+
+	// Private endpoint
+	r.GET("/broadcasts/stream", handler.stream, middleware.MustLoggedIn(cfg.JWTKeys()))
+
+	// Internal endpoints
+	internal := r.Group("/internal/broadcasts", middleware.InternalAPI(cfg.InternalAPI.User, cfg.InternalAPI.Password))
+	internal.POST("", handler.create)
+	internal.GET("", handler.list)
+	internal.GET("/:id", handler.get)
+	internal.GET("/:id/deliveries", handler.listDeliveries)
+}
+
+type handler struct {
+	cfg     *configs.Config
+	service ServicePort
+}
+
+// stream godoc
+// @Router /broadcasts/stream [get]
+// @Tags Broadcast
+// @Summary Stream the broadcasts
+// @Description Stream the broadcasts targeting the session as server-sent events named broadcast, each broadcast is sent once per session. The stream is closed once the request timeout elapsed, the clients reconnect after the retry delay.
+// @Produce text/event-stream
+// @Security BearerToken
+// @Success 200 {object} Message "Success"
+// @failure 401 {object} response.ErrorResponse401
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) stream(c echo.Context) error {
+	sender := &eventStream{res: c.Response()}
+	err := h.service.Stream(c.Request().Context(), sender)
+	if sender.opened {
+		// the status is sent already, the stream was closed by the client or the request timeout
+		return nil
+	}
+	if err != nil {
+		if errors.Cause(err) == ierr.ErrInvalidToken {
+			return response.ErrUnauthorized(err)
+		}
+		return err
+	}
+	return nil
+}
+
+// create godoc
+// @Router /internal/broadcasts [post]
+// @Tags Broadcast
+// @Summary Broadcast a message
+// @Description Broadcast a message to every active session, or to the sessions of some users or user types, until the ttl elapsed
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Param payload body RequestCreateBroadcast true " "
+// @Success 201 {object} response.Response{data=domain.Broadcast} "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) create(c echo.Context) error {
+	var req RequestCreateBroadcast
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.Create(c.Request().Context(), req)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessCreated(c, res, "message broadcast")
+}
+
+// list godoc
+// @Router /internal/broadcasts [get]
+// @Tags Broadcast
+// @Summary List the broadcasts
+// @Description List the broadcasts which did not expire yet
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Success 200 {object} response.Response{data=[]domain.Broadcast} "Success"
+// @failure 401 {object} response.ErrorResponse401
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) list(c echo.Context) error {
+	res, err := h.service.List(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessOK(c, res)
+}
+
+// get godoc
+// @Router /internal/broadcasts/{id} [get]
+// @Tags Broadcast
+// @Summary Get a broadcast
+// @Description Get a broadcast with the number of sessions it was delivered to
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Param id path string true "broadcast id"
+// @Success 200 {object} response.Response{data=ResponseBroadcast} "Success"
+// @failure 401 {object} response.ErrorResponse401
+// @failure 404 {object} response.ErrorResponse404
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) get(c echo.Context) error {
+	var req RequestBroadcastID
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.Get(c.Request().Context(), req)
+	if err != nil {
+		if errors.Cause(err) == ierr.ErrResourceNotFound {
+			return response.ErrNotFound(err)
+		}
+		return err
+	}
+
+	return response.SuccessOK(c, res)
+}
+
+// listDeliveries godoc
+// @Router /internal/broadcasts/{id}/deliveries [get]
+// @Tags Broadcast
+// @Summary List the deliveries of a broadcast
+// @Description List the sessions a broadcast was delivered to, the newest first
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Param id path string true "broadcast id"
+// @Param limit query int false "maximum number of deliveries, 100 by default and up to 1000"
+// @Param offset query int false "number of newer deliveries to skip"
+// @Success 200 {object} response.Response{data=[]domain.BroadcastDelivery} "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 404 {object} response.ErrorResponse404
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) listDeliveries(c echo.Context) error {
+	var req RequestListDeliveries
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.ListDeliveries(c.Request().Context(), req)
+	if err != nil {
+		if errors.Cause(err) == ierr.ErrResourceNotFound {
+			return response.ErrNotFound(err)
+		}
+		return err
+	}
+
+	return response.SuccessOK(c, response.Page{Items: res.Deliveries, Offset: res.Offset, Limit: res.Limit, More: res.More})
+}
+
+// eventStream writes the broadcasts as server-sent events
+type eventStream struct {
+	res    *echo.Response
+	opened bool
+}
+
+// Open sends the headers of the stream and the reconnection delay
+func (s *eventStream) Open() error {
+	header := s.res.Header()
+	header.Set(echo.HeaderContentType, "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("X-Accel-Buffering", "no") // the proxies must not buffer the stream
+	s.res.WriteHeader(http.StatusOK)
+	s.opened = true
+	return s.write(fmt.Sprintf("retry: %d\n\n", retryInterval))
+}
+
+// Send writes the broadcast as an event named broadcast
+func (s *eventStream) Send(msg Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return errors.Wrap(err, "cannot marshal broadcast")
+	}
+	return s.write(fmt.Sprintf("id: %s\nevent: broadcast\ndata: %s\n\n", msg.ID, data))
+}
+
+// KeepAlive writes a comment, which the clients ignore
+func (s *eventStream) KeepAlive() error {
+	return s.write(": keepalive\n\n")
+}
+
+func (s *eventStream) write(msg string) error {
+	_, err := s.res.Write([]byte(msg))
+	if err != nil {
+		return errors.Wrap(err, "cannot write to broadcast stream")
+	}
+	s.res.Flush()
+	return nil
+}
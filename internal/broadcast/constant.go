@@ -0,0 +1,17 @@
+package broadcast
+
+import "go-hex/internal/domain"
+
+const (
+	// maxTTL bounds the lifetime of a broadcast, in seconds
+	maxTTL = 7 * 24 * 60 * 60
+
+	defaultDeliveriesLimit = 100
+	maxDeliveriesLimit     = 1000
+
+	// retryInterval is the delay after which the clients reconnect once the stream is closed, in milliseconds
+	retryInterval = 3000
+)
+
+// kinds lists the kinds of the broadcasts
+var kinds = []interface{}{domain.BroadcastKindNotice, domain.BroadcastKindMaintenance, domain.BroadcastKindRelogin}
@@ -0,0 +1,79 @@
+package broadcast
+
+import (
+	"go-hex/internal/domain"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// RequestCreateBroadcast request body, the broadcast targets every active session when no user nor role is given
+type RequestCreateBroadcast struct {
+	Kind      string   `json:"kind" example:"maintenance"`
+	Message   string   `json:"message" example:"The service will be unavailable from 22:00 to 22:30 UTC"`
+	UserIDs   []string `json:"user_ids" example:"1f7a6f2e-3c3e-4a8e-9d4b-8f5d1f2a9c10"`
+	Roles     []string `json:"roles" example:"admin"`
+	TTL       int      `json:"ttl" example:"3600"`                                // in seconds, sessions streaming until then receive the broadcast
+	CreatedBy string   `json:"created_by" example:"jane.doe@support.example.com"` // admin sending the broadcast
+}
+
+func (r *RequestCreateBroadcast) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Kind, validation.Required, validation.In(kinds...)),
+		validation.Field(&r.Message, validation.Required, validation.Length(1, 2000)),
+		validation.Field(&r.UserIDs, validation.Each(validation.Required, validation.Length(1, 36))),
+		validation.Field(&r.Roles, validation.Each(validation.Required, validation.Length(1, 50))),
+		validation.Field(&r.TTL, validation.Required, validation.Min(1), validation.Max(maxTTL)),
+		validation.Field(&r.CreatedBy, validation.Required, validation.Length(1, 100)),
+	)
+}
+
+// RequestBroadcastID request params
+type RequestBroadcastID struct {
+	ID string `json:"-" param:"id"`
+}
+
+func (r *RequestBroadcastID) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.ID, validation.Required),
+	)
+}
+
+// RequestListDeliveries request params
+type RequestListDeliveries struct {
+	ID     string `json:"-" param:"id"`
+	Limit  int    `json:"-" query:"limit" example:"100"`
+	Offset int    `json:"-" query:"offset" example:"0"`
+}
+
+func (r *RequestListDeliveries) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.ID, validation.Required),
+		validation.Field(&r.Limit, validation.Min(0), validation.Max(maxDeliveriesLimit)),
+		validation.Field(&r.Offset, validation.Min(0)),
+	)
+}
+
+// ResponseBroadcast is a broadcast with the number of sessions it was delivered to
+type ResponseBroadcast struct {
+	domain.Broadcast
+	Delivered int `json:"delivered" example:"42"`
+}
+
+// ResponseDeliveries is a page of the deliveries of a broadcast
+type ResponseDeliveries struct {
+	Deliveries []domain.BroadcastDelivery
+	Offset     int
+	Limit      int
+	// More tells whether older deliveries follow
+	More bool
+}
+
+// Message is the broadcast streamed to a session, without its targets
+type Message struct {
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind"`
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
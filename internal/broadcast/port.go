@@ -0,0 +1,30 @@
+package broadcast
+
+import (
+	"context"
+	"go-hex/internal/domain"
+)
+
+// ServicePort encapsulates the broadcast logic.
+type ServicePort interface {
+	// Create broadcasts a message to the active sessions targeted until the ttl elapsed
+	Create(ctx context.Context, req RequestCreateBroadcast) (domain.Broadcast, error)
+	// Get returns a broadcast with the number of sessions it was delivered to
+	Get(ctx context.Context, req RequestBroadcastID) (ResponseBroadcast, error)
+	// List returns the broadcasts which did not expire yet
+	List(ctx context.Context) ([]domain.Broadcast, error)
+	// ListDeliveries returns a page of the deliveries of a broadcast, the newest first
+	ListDeliveries(ctx context.Context, req RequestListDeliveries) (ResponseDeliveries, error)
+	// Stream pushes the broadcasts targeting the session of the logged in user to the sender until ctx is done
+	Stream(ctx context.Context, sender Sender) error
+}
+
+// Sender writes the broadcasts to the stream of a session.
+type Sender interface {
+	// Open starts the stream, before the first broadcast is sent.
+	Open() error
+	// Send writes the broadcast.
+	Send(msg Message) error
+	// KeepAlive writes a message keeping the stream open through the proxies.
+	KeepAlive() error
+}
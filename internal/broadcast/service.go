@@ -0,0 +1,307 @@
+package broadcast
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/auth"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/metrics"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/times"
+	"go-hex/pkg/utils"
+	"go-hex/shared/ierr"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	broadcastStreams = metrics.NewGauge(prometheus.GaugeOpts{
+		Name: "broadcast_streams",
+		Help: "Number of sessions streaming the broadcasts from this instance.",
+	})
+	broadcastDelivered = metrics.NewCounterVec(prometheus.CounterOpts{
+		Name: "broadcast_deliveries_total",
+		Help: "Number of broadcasts delivered to a session, by kind.",
+	}, "kind")
+)
+
+// Service encapsulates the broadcast logic. The broadcasts are stored so that the sessions streaming
+// from every instance receive them: the instance serving a broadcast pushes it at once, the others at
+// their next Sync. Each broadcast is delivered once per session, the deliveries are stored to track them.
+type Service struct {
+	repoRegitry port.RepositoryRegistry
+	log         logger.Logger
+	events      event.Bus
+	keepAlive   time.Duration
+
+	mu      sync.RWMutex
+	active  []domain.Broadcast
+	streams map[chan struct{}]struct{}
+	closed  chan struct{}
+	once    sync.Once
+}
+
+// NewService creates and returns a new broadcast service, the streams are kept alive every keepAlive
+func NewService(repoRegitry port.RepositoryRegistry, log logger.Logger, events event.Bus, keepAlive time.Duration) *Service {
+	return &Service{
+		repoRegitry: repoRegitry,
+		log:         log,
+		events:      events,
+		keepAlive:   keepAlive,
+		streams:     map[chan struct{}]struct{}{},
+		closed:      make(chan struct{}),
+	}
+}
+
+// Create broadcasts a message to the active sessions targeted until the ttl elapsed
+func (s *Service) Create(ctx context.Context, req RequestCreateBroadcast) (domain.Broadcast, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return domain.Broadcast{}, err
+	}
+
+	now := times.Now()
+	broadcast := domain.Broadcast{
+		ID:        utils.GenerateID(),
+		Kind:      req.Kind,
+		Message:   req.Message,
+		UserIDs:   req.UserIDs,
+		Roles:     req.Roles,
+		CreatedBy: req.CreatedBy,
+		CreatedAt: now,
+		ExpiresAt: now.Add(time.Duration(req.TTL) * time.Second),
+	}
+	err = s.repoRegitry.GetBroadcastRepository().Create(ctx, broadcast)
+	if err != nil {
+		return domain.Broadcast{}, err
+	}
+
+	s.log.WithParams(logger.Params{"type": "broadcast", "broadcast": broadcast}).Info("message broadcast")
+	s.events.Publish(ctx, event.Event{
+		Name:      domain.EventBroadcastCreated,
+		ActorID:   broadcast.CreatedBy,
+		SubjectID: broadcast.ID,
+		Attributes: map[string]interface{}{
+			"kind":     broadcast.Kind,
+			"user_ids": broadcast.UserIDs,
+			"roles":    broadcast.Roles,
+		},
+	})
+	return broadcast, s.Sync(ctx)
+}
+
+// Get returns a broadcast with the number of sessions it was delivered to
+func (s *Service) Get(ctx context.Context, req RequestBroadcastID) (ResponseBroadcast, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return ResponseBroadcast{}, err
+	}
+
+	repoBroadcast := s.repoRegitry.GetBroadcastRepository()
+	broadcast, err := repoBroadcast.GetByID(ctx, req.ID)
+	if err != nil {
+		return ResponseBroadcast{}, err
+	}
+
+	delivered, err := repoBroadcast.CountDeliveries(ctx, req.ID)
+	if err != nil {
+		return ResponseBroadcast{}, err
+	}
+	return ResponseBroadcast{broadcast, delivered}, nil
+}
+
+// List returns the broadcasts which did not expire yet
+func (s *Service) List(ctx context.Context) ([]domain.Broadcast, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	return s.repoRegitry.GetBroadcastRepository().ListActive(ctx, times.Now())
+}
+
+// ListDeliveries returns a page of the deliveries of a broadcast, the newest first
+func (s *Service) ListDeliveries(ctx context.Context, req RequestListDeliveries) (ResponseDeliveries, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return ResponseDeliveries{}, err
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultDeliveriesLimit
+	}
+
+	repoBroadcast := s.repoRegitry.GetBroadcastRepository()
+	_, err = repoBroadcast.GetByID(ctx, req.ID)
+	if err != nil {
+		return ResponseDeliveries{}, err
+	}
+
+	// one more delivery is read to know whether another page follows
+	deliveries, err := repoBroadcast.ListDeliveries(ctx, req.ID, req.Limit+1, req.Offset)
+	if err != nil {
+		return ResponseDeliveries{}, err
+	}
+
+	res := ResponseDeliveries{Deliveries: deliveries, Offset: req.Offset, Limit: req.Limit}
+	if len(deliveries) > req.Limit {
+		res.Deliveries = deliveries[:req.Limit]
+		res.More = true
+	}
+	return res, nil
+}
+
+// Stream pushes the broadcasts targeting the session of the logged in user to the sender until ctx is done,
+// the broadcasts already delivered to the session, on another stream or before a reconnection, are skipped.
+func (s *Service) Stream(ctx context.Context, sender Sender) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	user := auth.GetLoggedInUser(ctx)
+	if user.SessionID == "" {
+		return ierr.ErrInvalidToken
+	}
+
+	notify := s.subscribe()
+	defer s.unsubscribe(notify)
+
+	err := sender.Open()
+	if err != nil {
+		return err
+	}
+	broadcastStreams.Inc()
+	defer broadcastStreams.Dec()
+
+	ticker := time.NewTicker(s.keepAlive)
+	defer ticker.Stop()
+
+	sent := map[string]bool{}
+	for {
+		err = s.deliver(ctx, user, sender, sent)
+		if err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.closed:
+			return nil
+		case <-notify:
+		case <-ticker.C:
+			err = sender.KeepAlive()
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Close ends the streams, so that they do not hold the shutdown of the server back
+func (s *Service) Close() {
+	s.once.Do(func() {
+		close(s.closed)
+	})
+}
+
+// Streams returns the number of streams open on this instance
+func (s *Service) Streams() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.streams)
+}
+
+// Sync reloads the broadcasts which did not expire yet and pushes the new ones to the streams of this instance
+func (s *Service) Sync(ctx context.Context) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	broadcasts, err := s.repoRegitry.GetBroadcastRepository().ListActive(ctx, times.Now())
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.active = broadcasts
+	for notify := range s.streams {
+		select {
+		case notify <- struct{}{}:
+		default:
+			// already notified, the stream reads the broadcasts once woken up
+		}
+	}
+	return nil
+}
+
+// deliver sends the active broadcasts targeting the user which were not sent on the stream yet. A broadcast
+// whose delivery cannot be recorded is retried on the next wake up, instead of closing the stream.
+func (s *Service) deliver(ctx context.Context, user auth.User, sender Sender, sent map[string]bool) error {
+	s.mu.RLock()
+	broadcasts := s.active
+	s.mu.RUnlock()
+
+	now := times.Now()
+	repoBroadcast := s.repoRegitry.GetBroadcastRepository()
+	for _, broadcast := range broadcasts {
+		if sent[broadcast.ID] || broadcast.IsExpired(now) || !broadcast.Targets(user.ID, user.Role) {
+			continue
+		}
+
+		claimed, err := repoBroadcast.RecordDelivery(ctx, domain.BroadcastDelivery{
+			BroadcastID: broadcast.ID,
+			SessionID:   user.SessionID,
+			UserID:      user.ID,
+			DeliveredAt: now,
+		})
+		if err != nil {
+			s.log.WithParams(logger.Params{"type": "broadcast", "broadcast_id": broadcast.ID}).Error(err)
+			continue
+		}
+		sent[broadcast.ID] = true
+		if !claimed {
+			continue
+		}
+
+		err = sender.Send(Message{broadcast.ID, broadcast.Kind, broadcast.Message, broadcast.CreatedAt, broadcast.ExpiresAt})
+		if err != nil {
+			return err
+		}
+		broadcastDelivered.WithLabelValues(broadcast.Kind).Inc()
+	}
+	return nil
+}
+
+func (s *Service) subscribe() chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	notify := make(chan struct{}, 1)
+	s.streams[notify] = struct{}{}
+	return notify
+}
+
+func (s *Service) unsubscribe(notify chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.streams, notify)
+}
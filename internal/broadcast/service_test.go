@@ -0,0 +1,88 @@
+package broadcast
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/memory"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/shared/ctxutil"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSender struct {
+	messages chan Message
+}
+
+func (s fakeSender) Open() error {
+	return nil
+}
+
+func (s fakeSender) Send(msg Message) error {
+	s.messages <- msg
+	return nil
+}
+
+func (s fakeSender) KeepAlive() error {
+	return nil
+}
+
+func loggedIn(userID, role, sessionID string) context.Context {
+	token := &jwt.Token{Claims: jwt.MapClaims{"id": userID, "user_type": role, "sid": sessionID}}
+	return ctxutil.WithPrincipal(context.Background(), token)
+}
+
+func TestStream(t *testing.T) {
+	store := memory.NewStore()
+	svc := NewService(store, logger.New("test", "test"), event.New(), time.Hour)
+	defer svc.Close()
+
+	// a second stream of the same session, e.g. another tab, and a stream of another user
+	streams := map[string]fakeSender{}
+	for _, stream := range []struct{ name, userID, role, sessionID string }{
+		{"john", "u1", "admin", "s1"},
+		{"john-tab", "u1", "admin", "s1"},
+		{"jane", "u2", "member", "s2"},
+	} {
+		sender := fakeSender{make(chan Message, 10)}
+		streams[stream.name] = sender
+		ctx, cancel := context.WithCancel(loggedIn(stream.userID, stream.role, stream.sessionID))
+		defer cancel()
+		go svc.Stream(ctx, sender)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	all, err := svc.Create(context.Background(), RequestCreateBroadcast{Kind: domain.BroadcastKindMaintenance, Message: "maintenance at 22:00", TTL: 60, CreatedBy: "ops"})
+	assert.NoError(t, err)
+	admins, err := svc.Create(context.Background(), RequestCreateBroadcast{Kind: domain.BroadcastKindRelogin, Message: "log in again", Roles: []string{"admin"}, TTL: 60, CreatedBy: "ops"})
+	assert.NoError(t, err)
+
+	received := func(sender fakeSender) []string {
+		ids := []string{}
+		for {
+			select {
+			case msg := <-sender.messages:
+				ids = append(ids, msg.ID)
+			case <-time.After(100 * time.Millisecond):
+				return ids
+			}
+		}
+	}
+	john := append(received(streams["john"]), received(streams["john-tab"])...)
+	assert.ElementsMatch(t, []string{all.ID, admins.ID}, john)
+	assert.Equal(t, []string{all.ID}, received(streams["jane"]))
+
+	// the deliveries are recorded once per session
+	for broadcastID, sessions := range map[string]int{all.ID: 2, admins.ID: 1} {
+		deliveries, err := store.GetBroadcastRepository().CountDeliveries(context.Background(), broadcastID)
+		assert.NoError(t, err)
+		assert.Equal(t, sessions, deliveries)
+	}
+
+	_, err = svc.Create(context.Background(), RequestCreateBroadcast{Kind: "unknown", Message: "hello", TTL: 60, CreatedBy: "ops"})
+	assert.Error(t, err)
+}
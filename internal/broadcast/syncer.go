@@ -0,0 +1,62 @@
+package broadcast
+
+import (
+	"context"
+	"go-hex/pkg/logger"
+	"time"
+)
+
+// Syncer periodically pushes the broadcasts stored by the other instances to the streams of this instance
+type Syncer struct {
+	service *Service
+	log     logger.Logger
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewSyncer creates a syncer pushing the broadcasts every interval until it is closed.
+// A zero interval disables the sync, the broadcasts only reach the streams of the instance serving them.
+func NewSyncer(service *Service, log logger.Logger, interval time.Duration) *Syncer {
+	s := &Syncer{
+		service: service,
+		log:     log,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	if interval <= 0 {
+		close(s.done)
+		return s
+	}
+	go s.run(interval)
+	return s
+}
+
+// Close stops the syncer
+func (s *Syncer) Close() {
+	close(s.stop)
+	<-s.done
+}
+
+func (s *Syncer) run(interval time.Duration) {
+	defer close(s.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.sync()
+		select {
+		case <-ticker.C:
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// sync keeps the previous broadcasts when the stored ones cannot be read, they are skipped once expired
+func (s *Syncer) sync() {
+	if err := s.service.Sync(context.Background()); err != nil {
+		s.log.WithParam("type", "broadcast").Error(err)
+	}
+}
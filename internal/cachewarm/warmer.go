@@ -0,0 +1,146 @@
+// Package cachewarm warms the user cache from the logins, so that the users logging in most often are
+// served from the memory of the instance.
+package cachewarm
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/memory"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/metrics"
+	"go-hex/pkg/times"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// warmTimeout bounds the loading of a user to warm
+const warmTimeout = 5 * time.Second
+
+var (
+	loginDuration = metrics.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "login_duration_seconds",
+		Help:    "Duration of the successful logins, by cache (warm when the user was served from the user cache, cold otherwise).",
+		Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, "cache")
+	warmsDropped = metrics.NewCounter(prometheus.CounterOpts{
+		Name: "user_cache_warms_dropped_total",
+		Help: "Number of user cache warmings dropped because the queue was full.",
+	})
+)
+
+// Warmer consumes the login events: it counts the logins of every user in the cache, and loads the users
+// logging in often enough to be admitted, with the elevations granting their roles, out of the requests.
+// The cached users are loaded again past half their ttl, so that the hot users never turn cold.
+type Warmer struct {
+	repoRegitry port.RepositoryRegistry
+	cache       *memory.UserCache
+	log         logger.Logger
+
+	mu      sync.RWMutex
+	closed  bool
+	pending map[string]bool
+	queue   chan string
+	done    chan struct{}
+}
+
+// NewWarmer creates a warmer loading the users from repoRegitry, which must not be served by the cache,
+// queuing up to queueSize users to warm
+func NewWarmer(repoRegitry port.RepositoryRegistry, cache *memory.UserCache, log logger.Logger, queueSize int) *Warmer {
+	w := &Warmer{
+		repoRegitry: repoRegitry,
+		cache:       cache,
+		log:         log,
+		pending:     map[string]bool{},
+		queue:       make(chan string, queueSize),
+		done:        make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Subscribe subscribes the warmer to the login events of the bus
+func (w *Warmer) Subscribe(events event.Bus) {
+	events.Subscribe(domain.EventLoginSucceeded, w.Handle)
+}
+
+// Handle records the latency of the login, then counts it and queues the user when it is due for a warming
+func (w *Warmer) Handle(ctx context.Context, e event.Event) {
+	if e.ActorID == "" {
+		return
+	}
+
+	cached, due := w.cache.Touch(e.ActorID)
+	if ms, ok := e.Attributes["duration_ms"].(float64); ok {
+		label := "cold"
+		if cached {
+			label = "warm"
+		}
+		loginDuration.WithLabelValues(label).Observe(ms / 1000)
+	}
+	if !due {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed || w.pending[e.ActorID] {
+		return
+	}
+	select {
+	case w.queue <- e.ActorID:
+		w.pending[e.ActorID] = true
+	default:
+		warmsDropped.Inc()
+	}
+}
+
+// Close stops the warmer once the queued users are warmed
+func (w *Warmer) Close() {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return
+	}
+	w.closed = true
+	close(w.queue)
+	w.mu.Unlock()
+	<-w.done
+}
+
+func (w *Warmer) run() {
+	defer close(w.done)
+	for userID := range w.queue {
+		w.warm(userID)
+
+		w.mu.Lock()
+		delete(w.pending, userID)
+		w.mu.Unlock()
+	}
+}
+
+// warm loads the user with its active elevations and offers it to the cache, the inactive users are not cached
+func (w *Warmer) warm(userID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), warmTimeout)
+	defer cancel()
+
+	version := w.cache.Version()
+	user, err := w.repoRegitry.GetUserRepository().GetByID(ctx, userID)
+	if err != nil {
+		w.log.WithParams(logger.Params{"user_id": userID}).WithStack(err).Warn("cannot warm user cache")
+		return
+	}
+	if !user.IsActive {
+		return
+	}
+	// the elevations active now are kept, the cache drops them from its lookups as they end
+	elevations, err := w.repoRegitry.GetElevationRepository().ListActiveByUserID(ctx, userID, times.Now())
+	if err != nil {
+		w.log.WithParams(logger.Params{"user_id": userID}).WithStack(err).Warn("cannot warm user cache")
+		return
+	}
+	w.cache.Admit(version, user, elevations)
+}
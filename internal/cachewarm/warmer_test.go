@@ -0,0 +1,40 @@
+package cachewarm
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/memory"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWarmer(t *testing.T) {
+	cache := memory.NewUserCache(10, time.Minute)
+	store := memory.NewStore()
+	for _, user := range []domain.User{{ID: "alice", Username: "alice", IsActive: true}, {ID: "bob", Username: "bob", IsActive: false}} {
+		require.NoError(t, store.GetUserRepository().Create(context.Background(), user))
+	}
+	events := event.New()
+	warmer := NewWarmer(store, cache, logger.New("test", "test"), 10)
+	warmer.Subscribe(events)
+
+	login := func(userID string) {
+		events.Publish(context.Background(), event.Event{Name: domain.EventLoginSucceeded, ActorID: userID,
+			Attributes: map[string]interface{}{"duration_ms": 12.5}})
+	}
+	login("alice")
+	login("bob")
+	warmer.Close()
+
+	assert.True(t, cache.Contains("alice"))
+	assert.False(t, cache.Contains("bob"), "the inactive users are not cached")
+
+	// the logins after the close are still counted
+	login("alice")
+	assert.True(t, cache.Contains("alice"))
+}
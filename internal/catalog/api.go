@@ -0,0 +1,242 @@
+package catalog
+
+import (
+	"go-hex/configs"
+	"go-hex/middleware"
+	"go-hex/shared/ierr"
+	"go-hex/shared/response"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+// RegisterAPI registers a new transactional message catalog api
+func RegisterAPI(r echo.Group, cfg *configs.Config, service ServicePort) {
+	handler := handler{cfg, service}
+
+	// Internal endpoints
+	internal := r.Group("/internal/message-templates", middleware.InternalAPI(cfg.InternalAPI.User, cfg.InternalAPI.Password))
+	internal.GET("", handler.list)
+	internal.GET("/:name/:channel", handler.get)
+	internal.PUT("/:name/:channel", handler.update)
+	internal.GET("/:name/:channel/versions", handler.listVersions)
+	internal.POST("/:name/:channel/reset", handler.reset)
+	internal.POST("/:name/:channel/preview", handler.preview)
+	internal.POST("/:name/:channel/test", handler.testSend)
+}
+
+type handler struct {
+	cfg     *configs.Config
+	service ServicePort
+}
+
+// list godoc
+// @Router /internal/message-templates [get]
+// @Tags Message Templates
+// @Summary List the message templates
+// @Description List the template applying to every transactional message on every channel, with its variables and whether it is the default or an override
+// @Produce json
+// @Security BasicAuth
+// @Success 200 {object} response.Response{data=[]ResponseTemplate} "Success"
+// @failure 401 {object} response.ErrorResponse401
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) list(c echo.Context) error {
+	res, err := h.service.List(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessOK(c, res)
+}
+
+// get godoc
+// @Router /internal/message-templates/{name}/{channel} [get]
+// @Tags Message Templates
+// @Summary Get a message template
+// @Description Get the template applying to a transactional message on a channel
+// @Produce json
+// @Security BasicAuth
+// @Param name path string true "message name"
+// @Param channel path string true "push, email or sms"
+// @Success 200 {object} response.Response{data=ResponseTemplate} "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 404 {object} response.ErrorResponse404
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) get(c echo.Context) error {
+	var req RequestTemplate
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.Get(c.Request().Context(), req)
+	if err != nil {
+		return templateError(err)
+	}
+
+	return response.SuccessOK(c, res)
+}
+
+// update godoc
+// @Router /internal/message-templates/{name}/{channel} [put]
+// @Tags Message Templates
+// @Summary Override a message template
+// @Description Override the template of a transactional message on a channel with a new version. The subject and the body are Go text templates which may only refer to the variables of the message, the sms channel has no subject.
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Param name path string true "message name"
+// @Param channel path string true "push, email or sms"
+// @Param payload body RequestUpdateTemplate true " "
+// @Success 200 {object} response.Response{data=domain.MessageTemplate} "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 404 {object} response.ErrorResponse404
+// @failure 409 {object} response.ErrorResponse409
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) update(c echo.Context) error {
+	var req RequestUpdateTemplate
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.Update(c.Request().Context(), req)
+	if err != nil {
+		return templateError(err)
+	}
+
+	return response.SuccessOK(c, res, "message template updated")
+}
+
+// listVersions godoc
+// @Router /internal/message-templates/{name}/{channel}/versions [get]
+// @Tags Message Templates
+// @Summary List the versions of a message template
+// @Description List the versions of the override of a transactional message on a channel, the latest first
+// @Produce json
+// @Security BasicAuth
+// @Param name path string true "message name"
+// @Param channel path string true "push, email or sms"
+// @Success 200 {object} response.Response{data=[]domain.MessageTemplate} "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 404 {object} response.ErrorResponse404
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) listVersions(c echo.Context) error {
+	var req RequestTemplate
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.ListVersions(c.Request().Context(), req)
+	if err != nil {
+		return templateError(err)
+	}
+
+	return response.SuccessOK(c, res)
+}
+
+// reset godoc
+// @Router /internal/message-templates/{name}/{channel}/reset [post]
+// @Tags Message Templates
+// @Summary Reset a message template
+// @Description Restore the default template of a transactional message on a channel with a new version, the previous versions are kept
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Param name path string true "message name"
+// @Param channel path string true "push, email or sms"
+// @Param payload body RequestResetTemplate true " "
+// @Success 200 {object} response.Response{data=domain.MessageTemplate} "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 404 {object} response.ErrorResponse404
+// @failure 409 {object} response.ErrorResponse409
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) reset(c echo.Context) error {
+	var req RequestResetTemplate
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.Reset(c.Request().Context(), req)
+	if err != nil {
+		return templateError(err)
+	}
+
+	return response.SuccessOK(c, res, "message template reset")
+}
+
+// preview godoc
+// @Router /internal/message-templates/{name}/{channel}/preview [post]
+// @Tags Message Templates
+// @Summary Preview a message template
+// @Description Render a template of a transactional message on a channel without saving it, or the template applying to it when the subject and the body are blank. The variables not given take their example.
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Param name path string true "message name"
+// @Param channel path string true "push, email or sms"
+// @Param payload body RequestPreview true " "
+// @Success 200 {object} response.Response{data=ResponsePreview} "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 404 {object} response.ErrorResponse404
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) preview(c echo.Context) error {
+	var req RequestPreview
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.Preview(c.Request().Context(), req)
+	if err != nil {
+		return templateError(err)
+	}
+
+	return response.SuccessOK(c, res)
+}
+
+// testSend godoc
+// @Router /internal/message-templates/{name}/{channel}/test [post]
+// @Tags Message Templates
+// @Summary Test send a message template
+// @Description Render a template of a transactional message on a channel as previewed and send it to the user on the push channel, or to the address or phone number on the email and sms channels
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Param name path string true "message name"
+// @Param channel path string true "push, email or sms"
+// @Param payload body RequestTestSend true " "
+// @Success 200 {object} response.Response{data=ResponsePreview} "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 404 {object} response.ErrorResponse404
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) testSend(c echo.Context) error {
+	var req RequestTestSend
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.TestSend(c.Request().Context(), req)
+	if err != nil {
+		return templateError(err)
+	}
+
+	return response.SuccessOK(c, res, "message template sent")
+}
+
+// templateError answers the errors of the message templates
+func templateError(err error) error {
+	switch errors.Cause(err) {
+	case ierr.ErrResourceNotFound:
+		return response.ErrNotFound(err)
+	case ierr.ErrConflict:
+		return response.HTTPError(err, http.StatusConflict, ierr.ErrConflict.Code, "message template was updated concurrently, please try again")
+	case ierr.ErrBadRequest, ierr.ErrEmailUndeliverable, ierr.ErrInvalidPhoneNumber:
+		return response.ErrBadRequest(err)
+	}
+	return err
+}
@@ -0,0 +1,278 @@
+// Package catalog renders the transactional messages of every channel from their templates. The admins override
+// the embedded default of a message on a channel with a new version of its template, validated against the
+// variables of the message, and restore the default with a reset version.
+package catalog
+
+import (
+	"bytes"
+	"embed"
+	"go-hex/internal/notification"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pkg/errors"
+)
+
+// Names of the transactional messages
+const (
+	MessageLoginApproval      = "login_approval"
+	MessageElevationRequest   = "elevation_request"
+	MessageElevationDecision  = "elevation_decision"
+	MessageRefreshTokenReused = "refresh_token_reused"
+	MessagePasswordReset      = "password_reset"
+	MessageEmailVerification  = "email_verification"
+)
+
+// Variable is a variable of the templates of a message
+type Variable struct {
+	Name        string `json:"name" example:"IPAddress"`
+	Description string `json:"description" example:"ip address of the sign in"`
+	Example     string `json:"example" example:"203.0.113.7"` // used by the previews and the test sends
+}
+
+// Message is a transactional message and the channels it is sent through
+type Message struct {
+	Name        string                 `json:"name" example:"login_approval"`
+	Description string                 `json:"description" example:"asks the user to approve a sign in from a logged in device"`
+	Channels    []notification.Channel `json:"channels" example:"push,email,sms"`
+	Variables   []Variable             `json:"variables"`
+}
+
+// brandingVariables are the variables of every message, the branding of the tenant of the recipient, or the
+// configured one, filled in by Render
+var brandingVariables = []Variable{
+	{"LogoURL", "url of the logo, empty unless set", "https://cdn.example.com/logo.png"},
+	{"PrimaryColor", "primary color, in hexadecimal", "#1a73e8"},
+	{"AccentColor", "accent color, in hexadecimal", "#fbbc04"},
+	{"SupportEmail", "address of the support, empty unless set", "support@example.com"},
+}
+
+// messages lists the transactional messages, their defaults are embedded as defaults/<name>.<channel>.tmpl
+var messages = []Message{
+	{
+		Name:        MessageLoginApproval,
+		Description: "asks the user to approve a sign in from a logged in device",
+		Channels:    []notification.Channel{notification.ChannelPush, notification.ChannelEmail, notification.ChannelSMS},
+		Variables: append([]Variable{
+			{"AppName", "name of the application", "go-hex"},
+			{"IPAddress", "ip address of the sign in", "203.0.113.7"},
+			{"UserAgent", "user agent of the sign in", "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_0)"},
+			{"ExpiresAt", "time the approval expires at, in RFC 3339", "2026-10-14T12:02:00Z"},
+		}, brandingVariables...),
+	},
+	{
+		Name:        MessageElevationRequest,
+		Description: "asks the approvers to decide on the request of a user to be granted a sensitive role",
+		Channels:    []notification.Channel{notification.ChannelPush},
+		Variables: append([]Variable{
+			{"AppName", "name of the application", "go-hex"},
+			{"Username", "username of the requester", "jane.doe"},
+			{"Role", "requested role", "analytics:read"},
+			{"Reason", "reason given by the requester", "investigating the incident INC-1234"},
+			{"Duration", "requested duration, in minutes", "30"},
+			{"ExpiresAt", "time the request expires at, in RFC 3339", "2026-10-14T13:00:00Z"},
+		}, brandingVariables...),
+	},
+	{
+		Name:        MessageElevationDecision,
+		Description: "tells the requester whether their request to be granted a sensitive role was approved",
+		Channels:    []notification.Channel{notification.ChannelPush},
+		Variables: append([]Variable{
+			{"AppName", "name of the application", "go-hex"},
+			{"Role", "requested role", "analytics:read"},
+			{"Status", "approved or denied", "approved"},
+			{"Comment", "comment of the approver", "approved for INC-1234"},
+			{"EndsAt", "time the role is granted until when approved, in RFC 3339", "2026-10-14T12:30:00Z"},
+		}, brandingVariables...),
+	},
+	{
+		Name:        MessageRefreshTokenReused,
+		Description: "warns the user that a stolen refresh token of their account was used and the session signed out",
+		Channels:    []notification.Channel{notification.ChannelPush, notification.ChannelEmail, notification.ChannelSMS},
+		Variables: append([]Variable{
+			{"AppName", "name of the application", "go-hex"},
+			{"DetectedAt", "time the reuse was detected at, in RFC 3339", "2026-10-15T09:30:00Z"},
+		}, brandingVariables...),
+	},
+	{
+		Name:        MessagePasswordReset,
+		Description: "sends the one-time token setting a new password to the user who forgot theirs",
+		Channels:    []notification.Channel{notification.ChannelEmail, notification.ChannelSMS},
+		Variables: append([]Variable{
+			{"AppName", "name of the application", "go-hex"},
+			{"Token", "one-time token setting the new password", "pQ3v8kX2mN7rT1wY5zB9cF4hJ6lA0sD8eG2iK5oU3qE"},
+			{"ResetURL", "page setting the new password with the token, empty unless PASSWORD_RESET_URL is set", "https://app.example.com/reset-password?token=pQ3v8kX2mN7rT1wY5zB9cF4hJ6lA0sD8eG2iK5oU3qE"},
+			{"ExpiresAt", "time the token expires at, in RFC 3339", "2026-10-15T09:45:00Z"},
+		}, brandingVariables...),
+	},
+	{
+		Name:        MessageEmailVerification,
+		Description: "sends the one-time token confirming the email address of a registered user, which activates the user",
+		Channels:    []notification.Channel{notification.ChannelEmail},
+		Variables: append([]Variable{
+			{"AppName", "name of the application", "go-hex"},
+			{"Username", "username of the registered user", "jane"},
+			{"Token", "one-time token confirming the email address", "Zk4rT8vB1nQ6wX3yM9cL2hF7jD5sA0pE4gU8iO1kR6t"},
+			{"VerifyURL", "page confirming the email address with the token, empty unless REGISTRATION_VERIFY_URL is set", "https://app.example.com/verify-email?token=Zk4rT8vB1nQ6wX3yM9cL2hF7jD5sA0pE4gU8iO1kR6t"},
+			{"ExpiresAt", "time the token expires at, in RFC 3339", "2026-10-16T09:30:00Z"},
+		}, brandingVariables...),
+	},
+}
+
+//go:embed defaults/*.tmpl
+var defaults embed.FS
+
+// subjectPrefix starts the first line of the defaults holding a subject
+const subjectPrefix = "Subject: "
+
+// lookup returns the message sent through the channel
+func lookup(name string, channel notification.Channel) (Message, bool) {
+	for _, msg := range messages {
+		if msg.Name != name {
+			continue
+		}
+		for _, c := range msg.Channels {
+			if c == channel {
+				return msg, true
+			}
+		}
+	}
+	return Message{}, false
+}
+
+// defaultTemplate returns the embedded subject and body of the message on the channel
+func defaultTemplate(name string, channel notification.Channel) (string, string, error) {
+	b, err := defaults.ReadFile("defaults/" + name + "." + string(channel) + ".tmpl")
+	if err != nil {
+		return "", "", errors.Wrapf(err, "cannot read default of message %s on %s", name, channel)
+	}
+
+	subject, body := "", string(b)
+	if strings.HasPrefix(body, subjectPrefix) {
+		subject, body, _ = strings.Cut(strings.TrimPrefix(body, subjectPrefix), "\n")
+	}
+	return strings.TrimSpace(subject), strings.TrimSpace(body), nil
+}
+
+// validate checks the subject and the body of a template of the message on the channel: both must parse
+// and only refer to the variables of the message, the sms channel has no subject and the others require one.
+func validate(msg Message, channel notification.Channel, subject string, body string) error {
+	errs := validation.Errors{}
+	switch {
+	case channel == notification.ChannelSMS && subject != "":
+		errs["subject"] = errors.New("must be blank on the sms channel")
+	case channel != notification.ChannelSMS && subject == "":
+		errs["subject"] = errors.New("cannot be blank")
+	case len(subject) > maxSubjectLength:
+		errs["subject"] = errors.Errorf("the length must be no more than %d", maxSubjectLength)
+	default:
+		errs["subject"] = checkVariables(msg, subject)
+	}
+	switch {
+	case body == "":
+		errs["body"] = errors.New("cannot be blank")
+	case len(body) > maxBodyLength:
+		errs["body"] = errors.Errorf("the length must be no more than %d", maxBodyLength)
+	default:
+		errs["body"] = checkVariables(msg, body)
+	}
+	return errs.Filter()
+}
+
+// checkVariables parses the template and checks that it only refers to the variables of the message
+func checkVariables(msg Message, text string) error {
+	tmpl, err := template.New("").Parse(text)
+	if err != nil {
+		return err
+	}
+	if tmpl.Tree == nil {
+		return nil
+	}
+
+	known := make(map[string]bool, len(msg.Variables))
+	for _, v := range msg.Variables {
+		known[v.Name] = true
+	}
+	var unknown error
+	walk(tmpl.Tree.Root, func(field string) {
+		if !known[field] && unknown == nil {
+			unknown = errors.Errorf("unknown variable .%s", field)
+		}
+	})
+	return unknown
+}
+
+// walk calls fn with the top level variable of every field of the node and its children
+func walk(node parse.Node, fn func(field string)) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			walk(child, fn)
+		}
+	case *parse.ActionNode:
+		walk(n.Pipe, fn)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			walk(cmd, fn)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			walk(arg, fn)
+		}
+	case *parse.FieldNode:
+		fn(n.Ident[0])
+	case *parse.ChainNode:
+		walk(n.Node, fn)
+	case *parse.IfNode:
+		walk(n.Pipe, fn)
+		walk(n.List, fn)
+		walk(n.ElseList, fn)
+	case *parse.RangeNode:
+		walk(n.Pipe, fn)
+		walk(n.List, fn)
+		walk(n.ElseList, fn)
+	case *parse.WithNode:
+		walk(n.Pipe, fn)
+		walk(n.List, fn)
+		walk(n.ElseList, fn)
+	case *parse.TemplateNode:
+		walk(n.Pipe, fn)
+	}
+}
+
+// render executes the subject and the body with the variables, a missing variable fails the rendering
+func render(subject string, body string, variables map[string]string) (string, string, error) {
+	renderedSubject, err := execute(subject, variables)
+	if err != nil {
+		return "", "", errors.Wrap(err, "cannot render subject")
+	}
+	renderedBody, err := execute(body, variables)
+	if err != nil {
+		return "", "", errors.Wrap(err, "cannot render body")
+	}
+	return renderedSubject, renderedBody, nil
+}
+
+// execute executes the template text with the variables
+func execute(text string, variables map[string]string) (string, error) {
+	tmpl, err := template.New("").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	if variables == nil {
+		variables = map[string]string{}
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, variables); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
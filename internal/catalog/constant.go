@@ -0,0 +1,13 @@
+package catalog
+
+// Bounds of the templates, in bytes
+const (
+	maxSubjectLength = 255
+	maxBodyLength    = 10000
+)
+
+// Sources of the template of a message
+const (
+	SourceDefault  = "default"  // embedded in the service
+	SourceOverride = "override" // latest version stored by an admin
+)
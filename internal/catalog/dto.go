@@ -0,0 +1,114 @@
+package catalog
+
+import (
+	"go-hex/internal/notification"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+var (
+	nameRules    = []validation.Rule{validation.Required, validation.Length(1, 100)}
+	channelRules = []validation.Rule{validation.Required, validation.In(notification.ChannelPush, notification.ChannelEmail, notification.ChannelSMS)}
+)
+
+// RequestTemplate request params
+type RequestTemplate struct {
+	Name    string               `json:"-" param:"name"`
+	Channel notification.Channel `json:"-" param:"channel"`
+}
+
+func (r *RequestTemplate) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Name, nameRules...),
+		validation.Field(&r.Channel, channelRules...),
+	)
+}
+
+// RequestUpdateTemplate request body
+type RequestUpdateTemplate struct {
+	Name      string               `json:"-" param:"name"`
+	Channel   notification.Channel `json:"-" param:"channel"`
+	Subject   string               `json:"subject" example:"Approve the sign in to your {{.AppName}} account"` // blank on the sms channel
+	Body      string               `json:"body" example:"Someone is trying to sign in from {{.IPAddress}}."`
+	UpdatedBy string               `json:"updated_by" example:"jane.doe@example.com"` // admin updating the template
+}
+
+func (r *RequestUpdateTemplate) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Name, nameRules...),
+		validation.Field(&r.Channel, channelRules...),
+		validation.Field(&r.UpdatedBy, validation.Required, validation.Length(1, 100)),
+	)
+}
+
+// RequestResetTemplate request body
+type RequestResetTemplate struct {
+	Name      string               `json:"-" param:"name"`
+	Channel   notification.Channel `json:"-" param:"channel"`
+	UpdatedBy string               `json:"updated_by" example:"jane.doe@example.com"` // admin resetting the template
+}
+
+func (r *RequestResetTemplate) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Name, nameRules...),
+		validation.Field(&r.Channel, channelRules...),
+		validation.Field(&r.UpdatedBy, validation.Required, validation.Length(1, 100)),
+	)
+}
+
+// RequestPreview request body, a blank subject and body preview the template applying to the message
+type RequestPreview struct {
+	Name      string               `json:"-" param:"name"`
+	Channel   notification.Channel `json:"-" param:"channel"`
+	Subject   string               `json:"subject" example:"Approve the sign in to your {{.AppName}} account"`
+	Body      string               `json:"body" example:"Someone is trying to sign in from {{.IPAddress}}."`
+	Variables map[string]string    `json:"variables"` // the missing variables take their example
+}
+
+func (r *RequestPreview) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Name, nameRules...),
+		validation.Field(&r.Channel, channelRules...),
+	)
+}
+
+// RequestTestSend request body, the message is rendered as previewed and sent to the recipient
+type RequestTestSend struct {
+	Name      string               `json:"-" param:"name"`
+	Channel   notification.Channel `json:"-" param:"channel"`
+	Subject   string               `json:"subject" example:"Approve the sign in to your {{.AppName}} account"`
+	Body      string               `json:"body" example:"Someone is trying to sign in from {{.IPAddress}}."`
+	Variables map[string]string    `json:"variables"`                                              // the missing variables take their example
+	UserID    string               `json:"user_id" example:"3f2c6b1e-8d2a-4c7e-9b1a-2f4d6e8a0c1b"` // recipient on the push channel
+	To        string               `json:"to" example:"jane.doe@example.com"`                      // recipient on the email and sms channels
+}
+
+func (r *RequestTestSend) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Name, nameRules...),
+		validation.Field(&r.Channel, channelRules...),
+		validation.Field(&r.UserID, validation.When(r.Channel == notification.ChannelPush, validation.Required), validation.Length(0, 36)),
+		validation.Field(&r.To, validation.When(r.Channel != notification.ChannelPush, validation.Required), validation.Length(0, 255)),
+	)
+}
+
+// ResponseTemplate is the template applying to a message on a channel
+type ResponseTemplate struct {
+	Name        string               `json:"name" example:"login_approval"`
+	Channel     notification.Channel `json:"channel" example:"email"`
+	Description string               `json:"description" example:"asks the user to approve a sign in from a logged in device"`
+	Variables   []Variable           `json:"variables"`
+	Source      string               `json:"source" example:"override"` // default or override
+	Version     int                  `json:"version" example:"3"`       // latest version stored, 0 when never overridden
+	Subject     string               `json:"subject" example:"Approve the sign in to your {{.AppName}} account"`
+	Body        string               `json:"body" example:"Someone is trying to sign in from {{.IPAddress}}."`
+	UpdatedBy   *string              `json:"updated_by" example:"jane.doe@example.com"` // Nullable, set once overridden or reset
+	UpdatedAt   *time.Time           `json:"updated_at"`                                // Nullable, set once overridden or reset
+}
+
+// ResponsePreview is a rendered message
+type ResponsePreview struct {
+	Subject string `json:"subject" example:"Approve the sign in to your go-hex account"`
+	Body    string `json:"body" example:"Someone is trying to sign in from 203.0.113.7."`
+}
@@ -0,0 +1,43 @@
+package catalog
+
+import (
+	"bytes"
+	_ "embed"
+	"html/template"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+//go:embed layout.html
+var layoutHTML string
+
+// emailLayout lays out the emails in HTML: the logo, or else the name of the application, on the primary
+// color, the paragraphs of the body, then the support address above the accent color
+var emailLayout = template.Must(template.New("layout").Parse(layoutHTML))
+
+// layout returns the HTML of the email rendered with the subject and the body, the branding being read
+// from the variables
+func layout(subject string, body string, variables map[string]string) (string, error) {
+	paragraphs := []string{}
+	for _, paragraph := range strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n\n") {
+		if paragraph = strings.TrimSpace(paragraph); paragraph != "" {
+			paragraphs = append(paragraphs, paragraph)
+		}
+	}
+
+	var buf bytes.Buffer
+	err := emailLayout.Execute(&buf, map[string]interface{}{
+		"Subject":      subject,
+		"Paragraphs":   paragraphs,
+		"AppName":      variables["AppName"],
+		"LogoURL":      variables["LogoURL"],
+		"PrimaryColor": variables["PrimaryColor"],
+		"AccentColor":  variables["AccentColor"],
+		"SupportEmail": variables["SupportEmail"],
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "cannot lay out email")
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,24 @@
+package catalog
+
+import (
+	"context"
+	"go-hex/internal/domain"
+)
+
+// ServicePort encapsulates the transactional message catalog logic.
+type ServicePort interface {
+	// List returns the templates applying to every message on every channel
+	List(ctx context.Context) ([]ResponseTemplate, error)
+	// Get returns the template applying to a message on a channel
+	Get(ctx context.Context, req RequestTemplate) (ResponseTemplate, error)
+	// ListVersions returns the versions of the override of a message on a channel, the latest first
+	ListVersions(ctx context.Context, req RequestTemplate) ([]domain.MessageTemplate, error)
+	// Update overrides the template of a message on a channel with a new version
+	Update(ctx context.Context, req RequestUpdateTemplate) (domain.MessageTemplate, error)
+	// Reset restores the default template of a message on a channel with a new version
+	Reset(ctx context.Context, req RequestResetTemplate) (domain.MessageTemplate, error)
+	// Preview renders a template of a message on a channel without saving it
+	Preview(ctx context.Context, req RequestPreview) (ResponsePreview, error)
+	// TestSend renders a template of a message on a channel and sends it to a recipient
+	TestSend(ctx context.Context, req RequestTestSend) (ResponsePreview, error)
+}
@@ -0,0 +1,361 @@
+package catalog
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/notification"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/times"
+	"go-hex/pkg/utils"
+	"go-hex/shared/ierr"
+
+	"github.com/pkg/errors"
+)
+
+// Service renders the transactional messages from the latest override of their template, or from their
+// embedded default when they were never overridden or were reset. It is the renderer of the notifications,
+// an override failing to render at send time falls back to the default so that the message is still sent.
+type Service struct {
+	cfg         *configs.Config
+	repoRegitry port.RepositoryRegistry
+	log         logger.Logger
+	events      event.Bus
+	notifier    *notification.Dispatcher
+}
+
+// NewService creates and returns a new transactional message catalog service, test sending through the notifier
+func NewService(cfg *configs.Config, repoRegitry port.RepositoryRegistry, log logger.Logger, events event.Bus, notifier *notification.Dispatcher) *Service {
+	return &Service{cfg, repoRegitry, log, events, notifier}
+}
+
+// Render returns the subject and the body of the message on the channel, with the branding of the tenant of the
+// request.
+func (s *Service) Render(ctx context.Context, name string, channel notification.Channel, variables map[string]string) (string, string, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	variables = s.branded(ctx, variables)
+
+	msg, ok := lookup(name, channel)
+	if !ok {
+		return "", "", errors.Errorf("message %s is not sent on %s", name, channel)
+	}
+
+	tmpl, err := s.template(ctx, msg, channel)
+	if err != nil {
+		return "", "", err
+	}
+	subject, body, err := render(tmpl.Subject, tmpl.Body, variables)
+	if err == nil || tmpl.Source == SourceDefault {
+		return subject, body, err
+	}
+
+	s.log.WithParams(logger.Params{"type": "message_template", "name": name, "channel": channel, "version": tmpl.Version, "error": err.Error()}).
+		Warn("cannot render message template override, rendering the default")
+	subject, body, err = defaultTemplate(name, channel)
+	if err != nil {
+		return "", "", err
+	}
+	return render(subject, body, variables)
+}
+
+// RenderHTML returns the HTML of the email rendered with the subject and the body, laid out with the branding of
+// the tenant of the request.
+func (s *Service) RenderHTML(ctx context.Context, subject string, body string, variables map[string]string) (string, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	return layout(subject, body, s.branded(ctx, variables))
+}
+
+// branded returns the variables completed by the branding of the tenant of the request, the variables given
+// by the sender win
+func (s *Service) branded(ctx context.Context, variables map[string]string) map[string]string {
+	cfg := configs.FromContext(ctx, s.cfg)
+	res := map[string]string{
+		"AppName":      cfg.Server.NAME,
+		"LogoURL":      cfg.Branding.LogoURL,
+		"PrimaryColor": cfg.Branding.PrimaryColor,
+		"AccentColor":  cfg.Branding.AccentColor,
+		"SupportEmail": cfg.Branding.SupportEmail,
+	}
+	for name, value := range variables {
+		res[name] = value
+	}
+	return res
+}
+
+// List returns the templates applying to every message on every channel
+func (s *Service) List(ctx context.Context) ([]ResponseTemplate, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	res := []ResponseTemplate{}
+	for _, msg := range messages {
+		for _, channel := range msg.Channels {
+			tmpl, err := s.template(ctx, msg, channel)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, tmpl)
+		}
+	}
+	return res, nil
+}
+
+// Get returns the template applying to a message on a channel
+func (s *Service) Get(ctx context.Context, req RequestTemplate) (ResponseTemplate, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return ResponseTemplate{}, err
+	}
+
+	msg, ok := lookup(req.Name, req.Channel)
+	if !ok {
+		return ResponseTemplate{}, ierr.ErrResourceNotFound
+	}
+	return s.template(ctx, msg, req.Channel)
+}
+
+// ListVersions returns the versions of the override of a message on a channel, the latest first
+func (s *Service) ListVersions(ctx context.Context, req RequestTemplate) ([]domain.MessageTemplate, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := lookup(req.Name, req.Channel); !ok {
+		return nil, ierr.ErrResourceNotFound
+	}
+	return s.repoRegitry.GetMessageTemplateRepository().ListVersions(ctx, req.Name, string(req.Channel))
+}
+
+// Update overrides the template of a message on a channel with a new version
+func (s *Service) Update(ctx context.Context, req RequestUpdateTemplate) (domain.MessageTemplate, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return domain.MessageTemplate{}, err
+	}
+
+	msg, ok := lookup(req.Name, req.Channel)
+	if !ok {
+		return domain.MessageTemplate{}, ierr.ErrResourceNotFound
+	}
+	err = validate(msg, req.Channel, req.Subject, req.Body)
+	if err != nil {
+		return domain.MessageTemplate{}, err
+	}
+
+	tmpl, err := s.createVersion(ctx, domain.MessageTemplate{
+		Name:      req.Name,
+		Channel:   string(req.Channel),
+		Subject:   req.Subject,
+		Body:      req.Body,
+		CreatedBy: req.UpdatedBy,
+	})
+	if err != nil {
+		return domain.MessageTemplate{}, err
+	}
+
+	s.publish(ctx, domain.EventMessageTemplateUpdated, tmpl)
+	return tmpl, nil
+}
+
+// Reset restores the default template of a message on a channel with a new version
+func (s *Service) Reset(ctx context.Context, req RequestResetTemplate) (domain.MessageTemplate, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return domain.MessageTemplate{}, err
+	}
+
+	if _, ok := lookup(req.Name, req.Channel); !ok {
+		return domain.MessageTemplate{}, ierr.ErrResourceNotFound
+	}
+
+	tmpl, err := s.createVersion(ctx, domain.MessageTemplate{
+		Name:      req.Name,
+		Channel:   string(req.Channel),
+		Reset:     true,
+		CreatedBy: req.UpdatedBy,
+	})
+	if err != nil {
+		return domain.MessageTemplate{}, err
+	}
+
+	s.publish(ctx, domain.EventMessageTemplateReset, tmpl)
+	return tmpl, nil
+}
+
+// Preview renders a template of a message on a channel without saving it
+func (s *Service) Preview(ctx context.Context, req RequestPreview) (ResponsePreview, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return ResponsePreview{}, err
+	}
+
+	return s.preview(ctx, req)
+}
+
+// TestSend renders a template of a message on a channel and sends it to a recipient
+func (s *Service) TestSend(ctx context.Context, req RequestTestSend) (ResponsePreview, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return ResponsePreview{}, err
+	}
+
+	res, err := s.preview(ctx, RequestPreview{req.Name, req.Channel, req.Subject, req.Body, req.Variables})
+	if err != nil {
+		return ResponsePreview{}, err
+	}
+
+	err = s.notifier.Send(ctx, req.Channel, notification.Message{
+		UserID: req.UserID,
+		To:     req.To,
+		Title:  res.Subject,
+		Body:   res.Body,
+		Data: map[string]string{
+			"type": req.Name,
+			"test": "true",
+		},
+	})
+	if err != nil {
+		return ResponsePreview{}, err
+	}
+
+	s.log.WithParams(logger.Params{"type": "message_template", "name": req.Name, "channel": req.Channel}).Info("message template test sent")
+	return res, nil
+}
+
+// preview renders the template of the request, or the template applying to the message when the request has none,
+// with the variables of the request completed by the examples of the message
+func (s *Service) preview(ctx context.Context, req RequestPreview) (ResponsePreview, error) {
+	msg, ok := lookup(req.Name, req.Channel)
+	if !ok {
+		return ResponsePreview{}, ierr.ErrResourceNotFound
+	}
+
+	subject, body := req.Subject, req.Body
+	if subject == "" && body == "" {
+		tmpl, err := s.template(ctx, msg, req.Channel)
+		if err != nil {
+			return ResponsePreview{}, err
+		}
+		subject, body = tmpl.Subject, tmpl.Body
+	} else if err := validate(msg, req.Channel, subject, body); err != nil {
+		return ResponsePreview{}, err
+	}
+
+	variables := make(map[string]string, len(msg.Variables))
+	for _, v := range msg.Variables {
+		variables[v.Name] = v.Example
+		if value, ok := req.Variables[v.Name]; ok {
+			variables[v.Name] = value
+		}
+	}
+
+	subject, body, err := render(subject, body, variables)
+	if err != nil {
+		return ResponsePreview{}, errors.Wrap(ierr.ErrBadRequest, err.Error())
+	}
+	return ResponsePreview{subject, body}, nil
+}
+
+// template returns the template applying to the message on the channel
+func (s *Service) template(ctx context.Context, msg Message, channel notification.Channel) (ResponseTemplate, error) {
+	res := ResponseTemplate{
+		Name:        msg.Name,
+		Channel:     channel,
+		Description: msg.Description,
+		Variables:   msg.Variables,
+		Source:      SourceDefault,
+	}
+
+	override, err := s.repoRegitry.GetMessageTemplateRepository().GetLatest(ctx, msg.Name, string(channel))
+	if err != nil && errors.Cause(err) != ierr.ErrResourceNotFound {
+		return ResponseTemplate{}, err
+	}
+	if err == nil {
+		res.Version, res.UpdatedBy, res.UpdatedAt = override.Version, &override.CreatedBy, &override.CreatedAt
+		if !override.Reset {
+			res.Source, res.Subject, res.Body = SourceOverride, override.Subject, override.Body
+			return res, nil
+		}
+	}
+
+	res.Subject, res.Body, err = defaultTemplate(msg.Name, channel)
+	if err != nil {
+		return ResponseTemplate{}, err
+	}
+	return res, nil
+}
+
+// createVersion saves the template as the version following the latest one of the message on the channel,
+// two admins saving a version concurrently get ierr.ErrConflict for one of them
+func (s *Service) createVersion(ctx context.Context, tmpl domain.MessageTemplate) (domain.MessageTemplate, error) {
+	out, err := s.repoRegitry.DoInTransaction(ctx, func(ctx context.Context, repoRegistry port.RepositoryRegistry) (interface{}, error) {
+		repoTemplate := repoRegistry.GetMessageTemplateRepository()
+		latest, err := repoTemplate.GetLatest(ctx, tmpl.Name, tmpl.Channel)
+		if err != nil && errors.Cause(err) != ierr.ErrResourceNotFound {
+			return nil, err
+		}
+		if tmpl.Reset && (err != nil || latest.Reset) {
+			// the default already applies
+			return nil, ierr.ErrResourceNotFound
+		}
+
+		tmpl.ID = utils.GenerateID()
+		tmpl.Version = latest.Version + 1
+		tmpl.CreatedAt = times.Now()
+		return tmpl, repoTemplate.Create(ctx, tmpl)
+	})
+	if err != nil {
+		return domain.MessageTemplate{}, err
+	}
+	return out.(domain.MessageTemplate), nil
+}
+
+// publish logs the change of a message template and publishes it on the event bus
+func (s *Service) publish(ctx context.Context, name string, tmpl domain.MessageTemplate) {
+	s.log.WithParams(logger.Params{"type": "message_template", "event": name, "name": tmpl.Name, "channel": tmpl.Channel, "version": tmpl.Version}).Info("message template changed")
+	s.events.Publish(ctx, event.Event{
+		Name:      name,
+		ActorID:   tmpl.CreatedBy,
+		SubjectID: tmpl.ID,
+		Attributes: map[string]interface{}{
+			"name":    tmpl.Name,
+			"channel": tmpl.Channel,
+			"version": tmpl.Version,
+		},
+	})
+}
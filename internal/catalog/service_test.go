@@ -0,0 +1,141 @@
+package catalog
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/notification"
+	"go-hex/internal/repository/memory"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/shared/ierr"
+	"strings"
+	"testing"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeNotifier struct {
+	sent []notification.Message
+}
+
+func (n *fakeNotifier) Channel() notification.Channel {
+	return notification.ChannelEmail
+}
+
+func (n *fakeNotifier) Notify(ctx context.Context, msg notification.Message) error {
+	n.sent = append(n.sent, msg)
+	return nil
+}
+
+var variables = map[string]string{"AppName": "go-hex", "IPAddress": "203.0.113.7", "UserAgent": "curl/8.0", "ExpiresAt": "2026-10-14T12:02:00Z"}
+
+func TestDefaults(t *testing.T) {
+	for _, msg := range messages {
+		for _, channel := range msg.Channels {
+			subject, body, err := defaultTemplate(msg.Name, channel)
+			if assert.NoError(t, err, "%s on %s", msg.Name, channel) {
+				assert.NoError(t, validate(msg, channel, subject, body), "%s on %s", msg.Name, channel)
+			}
+		}
+	}
+}
+
+func TestUpdateAndReset(t *testing.T) {
+	svc := NewService(&configs.Config{}, memory.NewStore(), logger.New("test", "test"), event.New(), notification.NewDispatcher())
+	ctx := context.Background()
+
+	_, err := svc.Update(ctx, RequestUpdateTemplate{Name: MessageLoginApproval, Channel: notification.ChannelEmail, Subject: "Sign in", Body: "from {{.Country}}", UpdatedBy: "admin"})
+	assert.True(t, errors.As(err, &validation.Errors{}))
+	_, err = svc.Update(ctx, RequestUpdateTemplate{Name: MessageLoginApproval, Channel: notification.ChannelSMS, Subject: "Sign in", Body: "{{.AppName}}", UpdatedBy: "admin"})
+	assert.True(t, errors.As(err, &validation.Errors{}))
+	_, err = svc.Update(ctx, RequestUpdateTemplate{Name: "unknown", Channel: notification.ChannelSMS, Body: "{{.AppName}}", UpdatedBy: "admin"})
+	assert.Equal(t, ierr.ErrResourceNotFound, errors.Cause(err))
+
+	tmpl, err := svc.Update(ctx, RequestUpdateTemplate{Name: MessageLoginApproval, Channel: notification.ChannelEmail, Subject: "{{.AppName}} sign in", Body: "{{if .UserAgent}}from {{.IPAddress}}{{end}}", UpdatedBy: "admin"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, tmpl.Version)
+
+	subject, body, err := svc.Render(ctx, MessageLoginApproval, notification.ChannelEmail, variables)
+	assert.NoError(t, err)
+	assert.Equal(t, "go-hex sign in", subject)
+	assert.Equal(t, "from 203.0.113.7", body)
+
+	tmpl, err = svc.Reset(ctx, RequestResetTemplate{Name: MessageLoginApproval, Channel: notification.ChannelEmail, UpdatedBy: "admin"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, tmpl.Version)
+	_, err = svc.Reset(ctx, RequestResetTemplate{Name: MessageLoginApproval, Channel: notification.ChannelEmail, UpdatedBy: "admin"})
+	assert.Equal(t, ierr.ErrResourceNotFound, errors.Cause(err))
+
+	res, err := svc.Get(ctx, RequestTemplate{Name: MessageLoginApproval, Channel: notification.ChannelEmail})
+	assert.NoError(t, err)
+	assert.Equal(t, SourceDefault, res.Source)
+	assert.Equal(t, 2, res.Version)
+	subject, _, err = svc.Render(ctx, MessageLoginApproval, notification.ChannelEmail, variables)
+	assert.NoError(t, err)
+	assert.Equal(t, "Approve the sign in to your go-hex account", subject)
+}
+
+func TestRenderFallsBackToDefault(t *testing.T) {
+	store := memory.NewStore()
+	require.NoError(t, store.GetMessageTemplateRepository().Create(context.Background(), domain.MessageTemplate{
+		Name: MessageLoginApproval, Channel: string(notification.ChannelSMS), Version: 1, Body: "{{index .AppName 99}}",
+	}))
+	svc := NewService(&configs.Config{}, store, logger.New("test", "test"), event.New(), notification.NewDispatcher())
+
+	_, body, err := svc.Render(context.Background(), MessageLoginApproval, notification.ChannelSMS, variables)
+	assert.NoError(t, err)
+	assert.Contains(t, body, "go-hex: someone is signing in to your account from 203.0.113.7")
+}
+
+func TestRenderBranding(t *testing.T) {
+	cfg := &configs.Config{}
+	cfg.Server.NAME = "go-hex"
+	cfg.Branding.PrimaryColor = "#1a73e8"
+	cfg.Branding.AccentColor = "#fbbc04"
+	notifier := &fakeNotifier{}
+	dispatcher := notification.NewDispatcher(notifier)
+	svc := NewService(cfg, memory.NewStore(), logger.New("test", "test"), event.New(), dispatcher)
+	dispatcher.WithRenderer(svc)
+
+	tenant := *cfg
+	tenant.Branding.LogoURL = "https://cdn.example.com/acme.png"
+	tenant.Branding.SupportEmail = "support@acme.example.com"
+	ctx := configs.WithContext(context.Background(), &tenant)
+
+	err := dispatcher.Send(ctx, notification.ChannelEmail, notification.Message{
+		Template:  MessageLoginApproval,
+		Variables: map[string]string{"AppName": "Acme", "IPAddress": "203.0.113.7", "UserAgent": "<script>", "ExpiresAt": "2026-10-14T12:02:00Z"},
+	})
+	require.NoError(t, err)
+	if assert.Len(t, notifier.sent, 1) {
+		html := notifier.sent[0].HTML
+		assert.Contains(t, html, `<img src="https://cdn.example.com/acme.png" alt="Acme"`)
+		assert.Contains(t, html, "background: #1a73e8")
+		assert.Contains(t, html, "border-top: 4px solid #fbbc04")
+		assert.Contains(t, html, "mailto:support@acme.example.com")
+		assert.Contains(t, html, "(&lt;script&gt;)")
+		assert.Equal(t, 2, strings.Count(html, "<p "))
+	}
+}
+
+func TestPreviewAndTestSend(t *testing.T) {
+	notifier := &fakeNotifier{}
+	svc := NewService(&configs.Config{}, memory.NewStore(), logger.New("test", "test"), event.New(), notification.NewDispatcher(notifier))
+	ctx := context.Background()
+
+	res, err := svc.Preview(ctx, RequestPreview{Name: MessageLoginApproval, Channel: notification.ChannelEmail, Subject: "{{.AppName}}", Body: "{{.IPAddress}}", Variables: map[string]string{"IPAddress": "198.51.100.1"}})
+	assert.NoError(t, err)
+	assert.Equal(t, ResponsePreview{"go-hex", "198.51.100.1"}, res)
+
+	res, err = svc.TestSend(ctx, RequestTestSend{Name: MessageLoginApproval, Channel: notification.ChannelEmail, To: "jane.doe@example.com"})
+	assert.NoError(t, err)
+	if assert.Len(t, notifier.sent, 1) {
+		assert.Equal(t, "jane.doe@example.com", notifier.sent[0].To)
+		assert.Equal(t, res.Subject, notifier.sent[0].Title)
+		assert.Contains(t, notifier.sent[0].Body, "203.0.113.7")
+	}
+}
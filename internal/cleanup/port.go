@@ -0,0 +1,11 @@
+package cleanup
+
+import (
+	"context"
+)
+
+// ServicePort encapsulates the cleanup logic.
+type ServicePort interface {
+	// PurgeExpired deletes the short lived authentication records which expired before the retention period
+	PurgeExpired(ctx context.Context) error
+}
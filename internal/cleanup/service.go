@@ -0,0 +1,79 @@
+package cleanup
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/times"
+	"time"
+)
+
+// Service encapsulates the cleanup logic.
+type Service struct {
+	cfg         *configs.Config
+	log         logger.Logger
+	repoRegitry port.RepositoryRegistry
+}
+
+// NewService creates and returns a new cleanup service
+func NewService(cfg *configs.Config, log logger.Logger, repoRegitry port.RepositoryRegistry) *Service {
+	return &Service{cfg, log, repoRegitry}
+}
+
+// PurgeExpired deletes the device logins, login approvals and log verbosities expired before the retention period,
+// which also frees their user codes, the service account assertions which cannot be replayed anymore and the audit
+// log events older than their retention. The device logins, login approvals and audit log events of the users under
+// legal hold are kept until the hold is released.
+func (s *Service) PurgeExpired(ctx context.Context) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	now := times.Now()
+	before := now.Add(-time.Duration(s.cfg.Cleanup.Retention) * time.Hour)
+
+	heldUserIDs, err := s.repoRegitry.GetLegalHoldRepository().ListHeldUserIDs(ctx)
+	if err != nil {
+		return err
+	}
+
+	deviceLogins, err := s.repoRegitry.GetDeviceLoginRepository().DeleteExpired(ctx, before, heldUserIDs)
+	if err != nil {
+		return err
+	}
+
+	approvals, err := s.repoRegitry.GetLoginApprovalRepository().DeleteExpired(ctx, before, heldUserIDs)
+	if err != nil {
+		return err
+	}
+
+	assertions, err := s.repoRegitry.GetServiceAccountRepository().DeleteExpiredAssertions(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	verbosities, err := s.repoRegitry.GetLogVerbosityRepository().DeleteExpired(ctx, before)
+	if err != nil {
+		return err
+	}
+
+	var auditEvents int64
+	if s.cfg.Audit.LogRetention > 0 {
+		auditEvents, err = s.repoRegitry.GetAuditRepository().DeleteExpired(ctx, now.Add(-time.Duration(s.cfg.Audit.LogRetention)*24*time.Hour), heldUserIDs)
+		if err != nil {
+			return err
+		}
+	}
+
+	s.log.WithParams(logger.Params{
+		"device_logins":              deviceLogins,
+		"login_approvals":            approvals,
+		"service_account_assertions": assertions,
+		"log_verbosities":            verbosities,
+		"audit_events":               auditEvents,
+		"held_users":                 len(heldUserIDs),
+	}).Info("expired records purged")
+	return nil
+}
@@ -0,0 +1,51 @@
+package cleanup
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/memory"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/times"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPurgeExpiredKeepsHeldAuditEvents(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	expired := times.Now().Add(-48 * time.Hour)
+	require.NoError(t, store.GetAuditRepository().Record(ctx, []domain.AuditEvent{
+		{ID: "expired", ActorID: "user-1", CreatedAt: expired},
+		{ID: "held-actor", ActorID: "held", CreatedAt: expired},
+		{ID: "held-subject", ActorID: domain.ActorTypeInternalAPI, SubjectID: "held", CreatedAt: expired},
+		{ID: "recent", ActorID: "user-1", CreatedAt: times.Now()},
+	}))
+	require.NoError(t, store.GetLegalHoldRepository().Create(ctx, domain.LegalHold{ID: "hold", UserID: "held", PlacedAt: times.Now()}))
+
+	cfg := &configs.Config{}
+	cfg.Audit.LogRetention = 1
+	svc := NewService(cfg, logger.New("test", "test"), store)
+	require.NoError(t, svc.PurgeExpired(ctx))
+
+	auditEvents, err := store.GetAuditRepository().List(ctx, "", "", time.Time{}, times.Now().Add(time.Hour), 0, 0)
+	require.NoError(t, err)
+	ids := []string{}
+	for _, auditEvent := range auditEvents {
+		ids = append(ids, auditEvent.ID)
+	}
+	assert.ElementsMatch(t, []string{"held-actor", "held-subject", "recent"}, ids)
+
+	// the events of the user are deleted once the hold is released
+	_, err = store.GetLegalHoldRepository().Release(ctx, "hold", "admin", "case closed", times.Now())
+	require.NoError(t, err)
+	require.NoError(t, svc.PurgeExpired(ctx))
+	auditEvents, err = store.GetAuditRepository().List(ctx, "", "", time.Time{}, times.Now().Add(time.Hour), 0, 0)
+	require.NoError(t, err)
+	if assert.Len(t, auditEvents, 1) {
+		assert.Equal(t, "recent", auditEvents[0].ID)
+	}
+}
@@ -0,0 +1,191 @@
+package deliverability
+
+import (
+	"go-hex/configs"
+	"go-hex/middleware"
+	"go-hex/shared/ierr"
+	"go-hex/shared/response"
+	"io"
+	"io/ioutil"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+// Headers of the signature of the SendGrid Event Webhook
+const (
+	headerSendGridSignature = "X-Twilio-Email-Event-Webhook-Signature"
+	headerSendGridTimestamp = "X-Twilio-Email-Event-Webhook-Timestamp"
+)
+
+// RegisterAPI registers a new email deliverability api
+func RegisterAPI(r echo.Group, cfg *configs.Config, service ServicePort) {
+	handler := handler{cfg, service}
+
+	// Webhooks of the email providers, authenticated by their signature
+	r.POST("/webhooks/email/ses", handler.ses)
+	r.POST("/webhooks/email/sendgrid", handler.sendgrid)
+
+	// Internal endpoints
+	internal := r.Group("/internal/email-suppressions", middleware.InternalAPI(cfg.InternalAPI.User, cfg.InternalAPI.Password))
+	internal.GET("", handler.list)
+	internal.GET("/:address", handler.get)
+	internal.DELETE("/:address", handler.delete)
+}
+
+type handler struct {
+	cfg     *configs.Config
+	service ServicePort
+}
+
+// ses godoc
+// @Router /webhooks/email/ses [post]
+// @Tags Email Deliverability
+// @Summary Receive the SES feedbacks
+// @Description Receive the bounces and the complaints of SES published to an accepted SNS topic, and suppress the addresses which bounced permanently or complained. The subscriptions to the accepted topics are confirmed.
+// @Accept json
+// @Produce json
+// @Success 200 {object} response.Response{data=ResponseWebhook} "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) ses(c echo.Context) error {
+	body, err := ioutil.ReadAll(io.LimitReader(c.Request().Body, maxWebhookSize))
+	if err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.HandleSES(c.Request().Context(), body)
+	if err != nil {
+		return webhookError(err)
+	}
+
+	return response.SuccessOK(c, res)
+}
+
+// sendgrid godoc
+// @Router /webhooks/email/sendgrid [post]
+// @Tags Email Deliverability
+// @Summary Receive the SendGrid events
+// @Description Receive the events of the SendGrid Event Webhook, signed with its verification key, and suppress the addresses which bounced or reported a message as spam
+// @Accept json
+// @Produce json
+// @Param X-Twilio-Email-Event-Webhook-Signature header string true "signature of the events"
+// @Param X-Twilio-Email-Event-Webhook-Timestamp header string true "timestamp of the signature"
+// @Success 200 {object} response.Response{data=ResponseWebhook} "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) sendgrid(c echo.Context) error {
+	body, err := ioutil.ReadAll(io.LimitReader(c.Request().Body, maxWebhookSize))
+	if err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.HandleSendGrid(c.Request().Context(), RequestSendGridWebhook{
+		Body:      body,
+		Signature: c.Request().Header.Get(headerSendGridSignature),
+		Timestamp: c.Request().Header.Get(headerSendGridTimestamp),
+	})
+	if err != nil {
+		return webhookError(err)
+	}
+
+	return response.SuccessOK(c, res)
+}
+
+// list godoc
+// @Router /internal/email-suppressions [get]
+// @Tags Email Deliverability
+// @Summary List the email suppressions
+// @Description List the undeliverable addresses, the most recently updated first
+// @Produce json
+// @Security BasicAuth
+// @Param limit query int false "page size, 100 by default and 1000 at most"
+// @Param offset query int false "offset of the page"
+// @Success 200 {object} response.Response{data=[]domain.EmailSuppression} "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) list(c echo.Context) error {
+	var req RequestListSuppressions
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.List(c.Request().Context(), req)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessOK(c, res)
+}
+
+// get godoc
+// @Router /internal/email-suppressions/{address} [get]
+// @Tags Email Deliverability
+// @Summary Get the suppression of an address
+// @Description Get why and since when an address is undeliverable
+// @Produce json
+// @Security BasicAuth
+// @Param address path string true "email address"
+// @Success 200 {object} response.Response{data=domain.EmailSuppression} "Success"
+// @failure 401 {object} response.ErrorResponse401
+// @failure 404 {object} response.ErrorResponse404
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) get(c echo.Context) error {
+	var req RequestAddress
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.Get(c.Request().Context(), req)
+	if err != nil {
+		if errors.Cause(err) == ierr.ErrResourceNotFound {
+			return response.ErrNotFound(err)
+		}
+		return err
+	}
+
+	return response.SuccessOK(c, res)
+}
+
+// delete godoc
+// @Router /internal/email-suppressions/{address} [delete]
+// @Tags Email Deliverability
+// @Summary Delete the suppression of an address
+// @Description Delete the suppression of an address once it is known to be deliverable again, the emails to the address are sent again
+// @Produce json
+// @Security BasicAuth
+// @Param address path string true "email address"
+// @Success 200 {object} response.Response "Success"
+// @failure 401 {object} response.ErrorResponse401
+// @failure 404 {object} response.ErrorResponse404
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) delete(c echo.Context) error {
+	var req RequestAddress
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	err := h.service.Delete(c.Request().Context(), req)
+	if err != nil {
+		if errors.Cause(err) == ierr.ErrResourceNotFound {
+			return response.ErrNotFound(err)
+		}
+		return err
+	}
+
+	return response.SuccessOK(c, nil, "email suppression deleted")
+}
+
+// webhookError answers the errors of the webhooks
+func webhookError(err error) error {
+	switch errors.Cause(err) {
+	case ierr.ErrInvalidWebhook:
+		return response.ErrUnauthorized(err)
+	case ierr.ErrBadRequest:
+		return response.ErrBadRequest(err)
+	}
+	return err
+}
@@ -0,0 +1,23 @@
+package deliverability
+
+import "regexp"
+
+// Email providers reporting the feedbacks
+const (
+	ProviderSES      = "ses"
+	ProviderSendGrid = "sendgrid"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+
+	// maxWebhookSize bounds the body of a webhook, in bytes
+	maxWebhookSize = 1 << 20
+
+	// maxDetailLength bounds the diagnostic of a provider kept with a suppression
+	maxDetailLength = 1000
+)
+
+// snsHost matches the hosts of the signing certificates and the subscription urls of SNS
+var snsHost = regexp.MustCompile(`^sns\.[a-z0-9-]+\.amazonaws\.com(\.cn)?$`)
@@ -0,0 +1,41 @@
+package deliverability
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// RequestSendGridWebhook request of the SendGrid Event Webhook
+type RequestSendGridWebhook struct {
+	Body      []byte `json:"-"`
+	Signature string `json:"-"`
+	Timestamp string `json:"-"`
+}
+
+// RequestAddress request params
+type RequestAddress struct {
+	Address string `json:"-" param:"address"`
+}
+
+func (r *RequestAddress) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Address, validation.Required, validation.Length(1, 255)),
+	)
+}
+
+// RequestListSuppressions request params
+type RequestListSuppressions struct {
+	Limit  int `json:"-" query:"limit" example:"100"`
+	Offset int `json:"-" query:"offset" example:"0"`
+}
+
+func (r *RequestListSuppressions) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Limit, validation.Min(0), validation.Max(maxLimit)),
+		validation.Field(&r.Offset, validation.Min(0)),
+	)
+}
+
+// ResponseWebhook is the outcome of a webhook
+type ResponseWebhook struct {
+	Suppressed int `json:"suppressed" example:"1"` // addresses suppressed or suppressed again
+}
@@ -0,0 +1,16 @@
+package deliverability
+
+import "strings"
+
+// Feedback is a bounce or a complaint reported by an email provider for some addresses
+type Feedback struct {
+	Provider  string
+	Reason    string // domain.EmailSuppressionBounce or domain.EmailSuppressionComplaint
+	Addresses []string
+	Detail    string
+}
+
+// NormalizeAddress returns the address as stored in the suppressions
+func NormalizeAddress(address string) string {
+	return strings.ToLower(strings.TrimSpace(address))
+}
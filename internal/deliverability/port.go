@@ -0,0 +1,22 @@
+package deliverability
+
+import (
+	"context"
+	"go-hex/internal/domain"
+)
+
+// ServicePort encapsulates the email deliverability logic.
+type ServicePort interface {
+	// HandleSES suppresses the addresses of the permanent bounces and the complaints of a SES notification published to SNS
+	HandleSES(ctx context.Context, body []byte) (ResponseWebhook, error)
+	// HandleSendGrid suppresses the addresses of the bounces and the spam reports of the SendGrid Event Webhook
+	HandleSendGrid(ctx context.Context, req RequestSendGridWebhook) (ResponseWebhook, error)
+	// Get returns the suppression of an address
+	Get(ctx context.Context, req RequestAddress) (domain.EmailSuppression, error)
+	// List returns the suppressions, the most recently updated first
+	List(ctx context.Context, req RequestListSuppressions) ([]domain.EmailSuppression, error)
+	// Delete deletes the suppression of an address, the emails to the address are sent again
+	Delete(ctx context.Context, req RequestAddress) error
+	// IsSuppressed checks whether no email must be sent to the address
+	IsSuppressed(ctx context.Context, address string) (bool, error)
+}
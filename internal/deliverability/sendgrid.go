@@ -0,0 +1,101 @@
+package deliverability
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"go-hex/internal/domain"
+	"go-hex/shared/ierr"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// sendGridEvent is an event of the SendGrid Event Webhook, see
+// https://docs.sendgrid.com/for-developers/tracking-events/event
+type sendGridEvent struct {
+	Email  string `json:"email"`
+	Event  string `json:"event"`
+	Type   string `json:"type"` // bounce or blocked for the bounce events
+	Reason string `json:"reason"`
+	Status string `json:"status"`
+}
+
+// SendGrid reads the bounces and the spam reports of the SendGrid Event Webhook, once their signature
+// verified with the verification key of the webhook. The blocked messages do not suppress an address.
+type SendGrid struct {
+	key    *ecdsa.PublicKey
+	maxAge time.Duration
+}
+
+// NewSendGrid creates a new SendGrid webhook reader verifying the events with the base64 verification key
+func NewSendGrid(publicKey string, maxAge time.Duration) (*SendGrid, error) {
+	s := &SendGrid{maxAge: maxAge}
+	if publicKey == "" {
+		return s, nil
+	}
+
+	der, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot decode sendgrid webhook public key")
+	}
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot parse sendgrid webhook public key")
+	}
+	ecKey, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.New("sendgrid webhook public key is not an ecdsa key")
+	}
+	s.key = ecKey
+	return s, nil
+}
+
+// Feedbacks verifies the events and returns the feedbacks they report
+func (s *SendGrid) Feedbacks(body []byte, signature string, timestamp string) ([]Feedback, error) {
+
+	if s.key == nil {
+		return nil, errors.Wrap(ierr.ErrInvalidWebhook, "sendgrid webhooks are not accepted")
+	}
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || time.Since(time.Unix(unix, 0)) > s.maxAge {
+		return nil, errors.Wrapf(ierr.ErrInvalidWebhook, "timestamp %q is invalid or too old", timestamp)
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return nil, errors.Wrap(ierr.ErrInvalidWebhook, "signature is not base64")
+	}
+	digest := sha256.Sum256(append([]byte(timestamp), body...))
+	if !ecdsa.VerifyASN1(s.key, digest[:], sig) {
+		return nil, errors.Wrap(ierr.ErrInvalidWebhook, "signature does not match")
+	}
+
+	var events []sendGridEvent
+	if err := json.Unmarshal(body, &events); err != nil {
+		return nil, errors.Wrap(ierr.ErrBadRequest, err.Error())
+	}
+
+	var feedbacks []Feedback
+	for _, e := range events {
+		switch {
+		case e.Event == "bounce" && e.Type != "blocked":
+			feedbacks = append(feedbacks, Feedback{
+				Provider:  ProviderSendGrid,
+				Reason:    domain.EmailSuppressionBounce,
+				Addresses: []string{e.Email},
+				Detail:    strings.TrimSpace(e.Status + " " + e.Reason),
+			})
+		case e.Event == "spamreport":
+			feedbacks = append(feedbacks, Feedback{
+				Provider:  ProviderSendGrid,
+				Reason:    domain.EmailSuppressionComplaint,
+				Addresses: []string{e.Email},
+			})
+		}
+	}
+	return feedbacks, nil
+}
@@ -0,0 +1,171 @@
+package deliverability
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/times"
+	"go-hex/shared/ierr"
+
+	"github.com/pkg/errors"
+)
+
+// Service encapsulates the email deliverability logic: the addresses reported by the webhooks of the
+// email providers are suppressed, and no email is sent to them until an admin deletes their suppression.
+type Service struct {
+	repoRegitry port.RepositoryRegistry
+	log         logger.Logger
+	events      event.Bus
+	ses         *SES
+	sendgrid    *SendGrid
+}
+
+// NewService creates and returns a new email deliverability service
+func NewService(repoRegitry port.RepositoryRegistry, log logger.Logger, events event.Bus, ses *SES, sendgrid *SendGrid) *Service {
+	return &Service{repoRegitry, log, events, ses, sendgrid}
+}
+
+// HandleSES suppresses the addresses of the permanent bounces and the complaints of a SES notification published to SNS
+func (s *Service) HandleSES(ctx context.Context, body []byte) (ResponseWebhook, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	feedbacks, err := s.ses.Feedbacks(ctx, body)
+	if err != nil {
+		return ResponseWebhook{}, err
+	}
+	return s.suppress(ctx, feedbacks)
+}
+
+// HandleSendGrid suppresses the addresses of the bounces and the spam reports of the SendGrid Event Webhook
+func (s *Service) HandleSendGrid(ctx context.Context, req RequestSendGridWebhook) (ResponseWebhook, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	feedbacks, err := s.sendgrid.Feedbacks(req.Body, req.Signature, req.Timestamp)
+	if err != nil {
+		return ResponseWebhook{}, err
+	}
+	return s.suppress(ctx, feedbacks)
+}
+
+// Get returns the suppression of an address
+func (s *Service) Get(ctx context.Context, req RequestAddress) (domain.EmailSuppression, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return domain.EmailSuppression{}, err
+	}
+
+	return s.repoRegitry.GetEmailSuppressionRepository().GetByAddress(ctx, NormalizeAddress(req.Address))
+}
+
+// List returns the suppressions, the most recently updated first
+func (s *Service) List(ctx context.Context, req RequestListSuppressions) ([]domain.EmailSuppression, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return nil, err
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultLimit
+	}
+
+	return s.repoRegitry.GetEmailSuppressionRepository().List(ctx, req.Limit, req.Offset)
+}
+
+// Delete deletes the suppression of an address, the emails to the address are sent again
+func (s *Service) Delete(ctx context.Context, req RequestAddress) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return err
+	}
+
+	address := NormalizeAddress(req.Address)
+	err = s.repoRegitry.GetEmailSuppressionRepository().Delete(ctx, address)
+	if err != nil {
+		return err
+	}
+
+	s.publish(ctx, domain.EventEmailUnsuppressed, address, nil)
+	return nil
+}
+
+// IsSuppressed checks whether no email must be sent to the address
+func (s *Service) IsSuppressed(ctx context.Context, address string) (bool, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := s.repoRegitry.GetEmailSuppressionRepository().GetByAddress(ctx, NormalizeAddress(address))
+	if err != nil {
+		if errors.Cause(err) == ierr.ErrResourceNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// suppress records the suppression of the addresses of the feedbacks
+func (s *Service) suppress(ctx context.Context, feedbacks []Feedback) (ResponseWebhook, error) {
+
+	res := ResponseWebhook{}
+	repoSuppression := s.repoRegitry.GetEmailSuppressionRepository()
+	for _, feedback := range feedbacks {
+		detail := feedback.Detail
+		if len(detail) > maxDetailLength {
+			detail = detail[:maxDetailLength]
+		}
+		for _, address := range feedback.Addresses {
+			address = NormalizeAddress(address)
+			if address == "" {
+				continue
+			}
+			now := times.Now()
+			err := repoSuppression.Record(ctx, domain.EmailSuppression{
+				Address:   address,
+				Reason:    feedback.Reason,
+				Provider:  feedback.Provider,
+				Detail:    detail,
+				Feedbacks: 1,
+				CreatedAt: now,
+				UpdatedAt: now,
+			})
+			if err != nil {
+				return res, err
+			}
+			res.Suppressed++
+			s.publish(ctx, domain.EventEmailSuppressed, address, map[string]interface{}{
+				"reason":   feedback.Reason,
+				"provider": feedback.Provider,
+			})
+		}
+	}
+	return res, nil
+}
+
+// publish logs the change of a suppression and publishes it on the event bus
+func (s *Service) publish(ctx context.Context, name string, address string, attributes map[string]interface{}) {
+	s.log.WithParams(logger.Params{"type": "email_suppression", "event": name, "address": address, "attributes": attributes}).Info("email suppression changed")
+	s.events.Publish(ctx, event.Event{
+		Name:       name,
+		SubjectID:  address,
+		Attributes: attributes,
+	})
+}
@@ -0,0 +1,64 @@
+package deliverability
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/internal/notification"
+	"go-hex/internal/repository/memory"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/shared/ierr"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeNotifier struct {
+	sent []notification.Message
+}
+
+func (n *fakeNotifier) Channel() notification.Channel {
+	return notification.ChannelEmail
+}
+
+func (n *fakeNotifier) Notify(ctx context.Context, msg notification.Message) error {
+	n.sent = append(n.sent, msg)
+	return nil
+}
+
+func TestSuppress(t *testing.T) {
+	ctx := context.Background()
+
+	var published []event.Event
+	events := event.New()
+	events.Subscribe(event.All, func(ctx context.Context, e event.Event) {
+		published = append(published, e)
+	})
+	svc := NewService(memory.NewStore(), logger.New("test", "test"), events, nil, nil)
+
+	bounce := Feedback{Provider: ProviderSES, Reason: domain.EmailSuppressionBounce, Addresses: []string{" Jane@Example.com"}, Detail: "550 user unknown"}
+	res, err := svc.suppress(ctx, []Feedback{bounce, bounce})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, res.Suppressed)
+	assert.Len(t, published, 2)
+
+	suppression, err := svc.Get(ctx, RequestAddress{Address: "jane@example.com"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2, suppression.Feedbacks)
+		assert.Equal(t, domain.EmailSuppressionBounce, suppression.Reason)
+	}
+
+	// the emails to the suppressed addresses are not sent
+	sender := &fakeNotifier{}
+	notifier := notification.NewSuppressingNotifier(sender, svc)
+	assert.Equal(t, ierr.ErrEmailUndeliverable, notifier.Notify(ctx, notification.Message{To: "JANE@example.com"}))
+	assert.NoError(t, notifier.Notify(ctx, notification.Message{To: "john@example.com"}))
+	assert.Len(t, sender.sent, 1)
+
+	// until their suppression is deleted
+	assert.NoError(t, svc.Delete(ctx, RequestAddress{Address: "Jane@Example.com"}))
+	assert.NoError(t, notifier.Notify(ctx, notification.Message{To: "jane@example.com"}))
+	assert.Len(t, sender.sent, 2)
+	assert.Equal(t, ierr.ErrResourceNotFound, errors.Cause(svc.Delete(ctx, RequestAddress{Address: "jane@example.com"})))
+}
@@ -0,0 +1,254 @@
+package deliverability
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"go-hex/internal/domain"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/utils"
+	"go-hex/shared/ierr"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Types of the SNS messages
+const (
+	snsNotification             = "Notification"
+	snsSubscriptionConfirmation = "SubscriptionConfirmation"
+	snsUnsubscribeConfirmation  = "UnsubscribeConfirmation"
+)
+
+// snsMessage is a message of SNS, see https://docs.aws.amazon.com/sns/latest/dg/sns-message-and-json-formats.html
+type snsMessage struct {
+	Type             string `json:"Type"`
+	MessageID        string `json:"MessageId"`
+	Token            string `json:"Token"`
+	TopicArn         string `json:"TopicArn"`
+	Subject          string `json:"Subject"`
+	Message          string `json:"Message"`
+	SubscribeURL     string `json:"SubscribeURL"`
+	Timestamp        string `json:"Timestamp"`
+	SignatureVersion string `json:"SignatureVersion"`
+	Signature        string `json:"Signature"`
+	SigningCertURL   string `json:"SigningCertURL"`
+}
+
+// signed returns the string signed by SNS
+func (m snsMessage) signed() string {
+	var fields []string
+	if m.Type == snsNotification {
+		fields = []string{"Message", m.Message, "MessageId", m.MessageID}
+		if m.Subject != "" {
+			fields = append(fields, "Subject", m.Subject)
+		}
+		fields = append(fields, "Timestamp", m.Timestamp, "TopicArn", m.TopicArn, "Type", m.Type)
+	} else {
+		fields = []string{"Message", m.Message, "MessageId", m.MessageID, "SubscribeURL", m.SubscribeURL,
+			"Timestamp", m.Timestamp, "Token", m.Token, "TopicArn", m.TopicArn, "Type", m.Type}
+	}
+	return strings.Join(fields, "\n") + "\n"
+}
+
+// sesNotification is a notification of SES, see https://docs.aws.amazon.com/ses/latest/dg/notification-contents.html
+type sesNotification struct {
+	NotificationType string `json:"notificationType"`
+	Bounce           struct {
+		BounceType        string `json:"bounceType"`
+		BounceSubType     string `json:"bounceSubType"`
+		BouncedRecipients []struct {
+			EmailAddress   string `json:"emailAddress"`
+			DiagnosticCode string `json:"diagnosticCode"`
+		} `json:"bouncedRecipients"`
+	} `json:"bounce"`
+	Complaint struct {
+		ComplaintFeedbackType string `json:"complaintFeedbackType"`
+		ComplainedRecipients  []struct {
+			EmailAddress string `json:"emailAddress"`
+		} `json:"complainedRecipients"`
+	} `json:"complaint"`
+}
+
+// SES reads the bounces and the complaints of SES published to SNS topics. The messages are accepted
+// from the given topics only, once their signature verified against the certificate of SNS, and the
+// subscriptions of these topics are confirmed. Only the permanent bounces suppress an address.
+type SES struct {
+	topics []string
+	maxAge time.Duration
+	client *http.Client
+	certs  sync.Map // signing certificate url to its *rsa.PublicKey
+}
+
+// NewSES creates a new SES webhook reader accepting the messages of the given topics
+func NewSES(topics []string, maxAge time.Duration, timeout time.Duration) *SES {
+	return &SES{topics: topics, maxAge: maxAge, client: &http.Client{Timeout: timeout}}
+}
+
+// Feedbacks verifies the SNS message and returns the feedbacks it reports, a subscription confirmation is confirmed.
+func (s *SES) Feedbacks(ctx context.Context, body []byte) ([]Feedback, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	var msg snsMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, errors.Wrap(ierr.ErrBadRequest, err.Error())
+	}
+	if !utils.StringInSlice(msg.TopicArn, s.topics) {
+		return nil, errors.Wrapf(ierr.ErrInvalidWebhook, "topic %q is not accepted", msg.TopicArn)
+	}
+	if err := s.verify(ctx, msg); err != nil {
+		return nil, err
+	}
+
+	switch msg.Type {
+	case snsSubscriptionConfirmation:
+		return nil, s.confirm(ctx, msg.SubscribeURL)
+	case snsNotification:
+		return parseSESNotification(msg.Message)
+	}
+	return nil, nil
+}
+
+// verify checks the signature and the age of the message
+func (s *SES) verify(ctx context.Context, msg snsMessage) error {
+
+	timestamp, err := time.Parse(time.RFC3339, msg.Timestamp)
+	if err != nil || time.Since(timestamp) > s.maxAge {
+		return errors.Wrapf(ierr.ErrInvalidWebhook, "message timestamp %q is invalid or too old", msg.Timestamp)
+	}
+
+	var hash crypto.Hash
+	var digest []byte
+	switch msg.SignatureVersion {
+	case "1":
+		sum := sha1.Sum([]byte(msg.signed()))
+		hash, digest = crypto.SHA1, sum[:]
+	case "2":
+		sum := sha256.Sum256([]byte(msg.signed()))
+		hash, digest = crypto.SHA256, sum[:]
+	default:
+		return errors.Wrapf(ierr.ErrInvalidWebhook, "signature version %q is not supported", msg.SignatureVersion)
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(msg.Signature)
+	if err != nil {
+		return errors.Wrap(ierr.ErrInvalidWebhook, "signature is not base64")
+	}
+	key, err := s.certificate(ctx, msg.SigningCertURL)
+	if err != nil {
+		return err
+	}
+	if err := rsa.VerifyPKCS1v15(key, hash, digest, signature); err != nil {
+		return errors.Wrap(ierr.ErrInvalidWebhook, "signature does not match")
+	}
+	return nil
+}
+
+// certificate returns the public key of the SNS signing certificate at the url
+func (s *SES) certificate(ctx context.Context, certURL string) (*rsa.PublicKey, error) {
+
+	if key, ok := s.certs.Load(certURL); ok {
+		return key.(*rsa.PublicKey), nil
+	}
+	if !isSNSURL(certURL) || !strings.HasSuffix(certURL, ".pem") {
+		return nil, errors.Wrapf(ierr.ErrInvalidWebhook, "signing certificate %q is not a certificate of SNS", certURL)
+	}
+
+	b, err := s.get(ctx, certURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get sns signing certificate")
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, errors.New("cannot decode sns signing certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot parse sns signing certificate")
+	}
+	key, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("sns signing certificate has no rsa key")
+	}
+	s.certs.Store(certURL, key)
+	return key, nil
+}
+
+// confirm confirms the subscription of an accepted topic
+func (s *SES) confirm(ctx context.Context, subscribeURL string) error {
+	if !isSNSURL(subscribeURL) {
+		return errors.Wrapf(ierr.ErrInvalidWebhook, "subscribe url %q is not an url of SNS", subscribeURL)
+	}
+	_, err := s.get(ctx, subscribeURL)
+	return errors.Wrap(err, "cannot confirm sns subscription")
+}
+
+func (s *SES) get(ctx context.Context, u string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("sns responded with status %d", resp.StatusCode)
+	}
+	return ioutil.ReadAll(io.LimitReader(resp.Body, maxWebhookSize))
+}
+
+// isSNSURL checks whether the url is an https url of SNS
+func isSNSURL(u string) bool {
+	parsed, err := url.Parse(u)
+	return err == nil && parsed.Scheme == "https" && snsHost.MatchString(parsed.Host)
+}
+
+// parseSESNotification returns the feedbacks of a SES notification, the transient bounces report none
+func parseSESNotification(message string) ([]Feedback, error) {
+	var notification sesNotification
+	if err := json.Unmarshal([]byte(message), &notification); err != nil {
+		return nil, errors.Wrap(ierr.ErrBadRequest, err.Error())
+	}
+
+	var feedbacks []Feedback
+	switch notification.NotificationType {
+	case "Bounce":
+		if notification.Bounce.BounceType != "Permanent" {
+			return nil, nil
+		}
+		for _, recipient := range notification.Bounce.BouncedRecipients {
+			feedbacks = append(feedbacks, Feedback{
+				Provider:  ProviderSES,
+				Reason:    domain.EmailSuppressionBounce,
+				Addresses: []string{recipient.EmailAddress},
+				Detail:    strings.TrimSpace(notification.Bounce.BounceSubType + " " + recipient.DiagnosticCode),
+			})
+		}
+	case "Complaint":
+		if notification.Complaint.ComplaintFeedbackType == "not-spam" {
+			return nil, nil
+		}
+		feedback := Feedback{Provider: ProviderSES, Reason: domain.EmailSuppressionComplaint, Detail: notification.Complaint.ComplaintFeedbackType}
+		for _, recipient := range notification.Complaint.ComplainedRecipients {
+			feedback.Addresses = append(feedback.Addresses, recipient.EmailAddress)
+		}
+		feedbacks = append(feedbacks, feedback)
+	}
+	return feedbacks, nil
+}
@@ -0,0 +1,126 @@
+package deliverability
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"go-hex/internal/domain"
+	"go-hex/shared/ierr"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testTopic   = "arn:aws:sns:us-east-1:123456789012:ses-feedbacks"
+	testCertURL = "https://sns.us-east-1.amazonaws.com/SimpleNotificationService-test.pem"
+)
+
+func signedSNSMessage(t *testing.T, key *rsa.PrivateKey, msg snsMessage) []byte {
+	digest := sha256.Sum256([]byte(msg.signed()))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	assert.NoError(t, err)
+	msg.Signature = base64.StdEncoding.EncodeToString(signature)
+	body, err := json.Marshal(msg)
+	assert.NoError(t, err)
+	return body
+}
+
+func TestSESFeedbacks(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	ses := NewSES([]string{testTopic}, time.Minute, time.Second)
+	ses.certs.Store(testCertURL, &key.PublicKey)
+
+	msg := snsMessage{
+		Type:             snsNotification,
+		MessageID:        "5c7f8c7e-1f2a-4b3c-9d8e-7f6a5b4c3d2e",
+		TopicArn:         testTopic,
+		Message:          `{"notificationType":"Bounce","bounce":{"bounceType":"Permanent","bounceSubType":"General","bouncedRecipients":[{"emailAddress":"Jane@Example.com","diagnosticCode":"smtp; 550 5.1.1 user unknown"}]}}`,
+		Timestamp:        time.Now().UTC().Format(time.RFC3339),
+		SignatureVersion: "2",
+		SigningCertURL:   testCertURL,
+	}
+	feedbacks, err := ses.Feedbacks(context.Background(), signedSNSMessage(t, key, msg))
+	assert.NoError(t, err)
+	if assert.Len(t, feedbacks, 1) {
+		assert.Equal(t, domain.EmailSuppressionBounce, feedbacks[0].Reason)
+		assert.Equal(t, []string{"Jane@Example.com"}, feedbacks[0].Addresses)
+		assert.Equal(t, "General smtp; 550 5.1.1 user unknown", feedbacks[0].Detail)
+	}
+
+	// the transient bounces do not suppress the address
+	transient := msg
+	transient.Message = `{"notificationType":"Bounce","bounce":{"bounceType":"Transient","bouncedRecipients":[{"emailAddress":"jane@example.com"}]}}`
+	feedbacks, err = ses.Feedbacks(context.Background(), signedSNSMessage(t, key, transient))
+	assert.NoError(t, err)
+	assert.Empty(t, feedbacks)
+
+	// a message altered after being signed is rejected
+	var tampered snsMessage
+	assert.NoError(t, json.Unmarshal(signedSNSMessage(t, key, msg), &tampered))
+	tampered.Message = `{"notificationType":"Complaint","complaint":{"complainedRecipients":[{"emailAddress":"john@example.com"}]}}`
+	body, _ := json.Marshal(tampered)
+	_, err = ses.Feedbacks(context.Background(), body)
+	assert.Equal(t, ierr.ErrInvalidWebhook, errors.Cause(err))
+
+	// so is a message of another topic and a replayed message
+	other := msg
+	other.TopicArn = "arn:aws:sns:us-east-1:123456789012:other"
+	_, err = ses.Feedbacks(context.Background(), signedSNSMessage(t, key, other))
+	assert.Equal(t, ierr.ErrInvalidWebhook, errors.Cause(err))
+	old := msg
+	old.Timestamp = time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	_, err = ses.Feedbacks(context.Background(), signedSNSMessage(t, key, old))
+	assert.Equal(t, ierr.ErrInvalidWebhook, errors.Cause(err))
+
+	// the signing certificates are only fetched from SNS
+	foreign := msg
+	foreign.SigningCertURL = "https://attacker.example.com/SimpleNotificationService-test.pem"
+	_, err = ses.Feedbacks(context.Background(), signedSNSMessage(t, key, foreign))
+	assert.Equal(t, ierr.ErrInvalidWebhook, errors.Cause(err))
+}
+
+func TestSendGridFeedbacks(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	assert.NoError(t, err)
+	sendgrid, err := NewSendGrid(base64.StdEncoding.EncodeToString(der), time.Minute)
+	assert.NoError(t, err)
+
+	body := []byte(`[{"email":"jane@example.com","event":"bounce","type":"bounce","status":"5.1.1","reason":"user unknown"},` +
+		`{"email":"john@example.com","event":"bounce","type":"blocked"},` +
+		`{"email":"joe@example.com","event":"spamreport"},` +
+		`{"email":"ann@example.com","event":"delivered"}]`)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	digest := sha256.Sum256(append([]byte(timestamp), body...))
+	signature, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	assert.NoError(t, err)
+
+	feedbacks, err := sendgrid.Feedbacks(body, base64.StdEncoding.EncodeToString(signature), timestamp)
+	assert.NoError(t, err)
+	if assert.Len(t, feedbacks, 2) {
+		assert.Equal(t, Feedback{ProviderSendGrid, domain.EmailSuppressionBounce, []string{"jane@example.com"}, "5.1.1 user unknown"}, feedbacks[0])
+		assert.Equal(t, Feedback{ProviderSendGrid, domain.EmailSuppressionComplaint, []string{"joe@example.com"}, ""}, feedbacks[1])
+	}
+
+	_, err = sendgrid.Feedbacks(append(body, ' '), base64.StdEncoding.EncodeToString(signature), timestamp)
+	assert.Equal(t, ierr.ErrInvalidWebhook, errors.Cause(err))
+
+	// the webhooks are rejected without verification key
+	unverified, err := NewSendGrid("", time.Minute)
+	assert.NoError(t, err)
+	_, err = unverified.Feedbacks(body, base64.StdEncoding.EncodeToString(signature), timestamp)
+	assert.Equal(t, ierr.ErrInvalidWebhook, errors.Cause(err))
+}
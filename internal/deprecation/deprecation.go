@@ -0,0 +1,289 @@
+package deprecation
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/pkg/auth"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/metrics"
+	"go-hex/pkg/times"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Kinds of deprecated items
+const (
+	KindRoute = "route"
+	KindField = "field"
+)
+
+// digestTopClients is the number of clients listed per item in the digest
+const digestTopClients = 10
+
+type contextKey struct{}
+
+var requests = metrics.NewCounterVec(prometheus.CounterOpts{
+	Name: "deprecated_requests_total",
+	Help: "Number of requests using a deprecated route or field.",
+}, "kind", "name", "principal_type", "client")
+
+// Info describes a deprecated route
+type Info struct {
+	// Sunset is the date after which the route may be removed, zero when not planned yet
+	Sunset time.Time
+	// Successor is the link to the replacing route or documentation
+	Successor string
+}
+
+// item aggregates the usage of a deprecated item since the last digest
+type item struct {
+	kind     string
+	name     string
+	calls    int64
+	lastSeen time.Time
+	clients  map[string]int64
+}
+
+// usage holds the deprecated items used during a request
+type usage struct {
+	mu    sync.Mutex
+	items [][2]string
+}
+
+func (u *usage) add(kind, name string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.items = append(u.items, [2]string{kind, name})
+}
+
+// Tracker records the requests hitting deprecated routes and fields with the identity of the caller,
+// exports them as metrics and logs a periodic digest so that the team knows when an item is safe to remove.
+type Tracker struct {
+	log     logger.Logger
+	keys    *auth.Keys
+	routes  map[string]Info // keyed by method and route path
+	digests bool
+
+	mu    sync.Mutex
+	since time.Time
+	items map[[2]string]*item
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewTracker creates a tracker logging its digest every digestInterval until it is closed.
+// The given routes are marked as deprecated like with Route, a zero digest interval disables the digest
+// and only the metrics are exported.
+func NewTracker(log logger.Logger, keys *auth.Keys, digestInterval time.Duration, routes []configs.DeprecatedRoute) *Tracker {
+	t := &Tracker{
+		log:     log,
+		keys:    keys,
+		routes:  map[string]Info{},
+		digests: digestInterval > 0,
+		since:   times.Now(),
+		items:   map[[2]string]*item{},
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	for _, route := range routes {
+		t.routes[route.Method+" "+route.Path] = Info{Sunset: route.Sunset, Successor: route.Successor}
+	}
+
+	if !t.digests {
+		close(t.done)
+		return t
+	}
+	go t.run(digestInterval)
+	return t
+}
+
+// Middleware makes the request context able to record the deprecated fields with Field,
+// and records every deprecated item used once the request is handled
+func (t *Tracker) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			u := &usage{}
+			ctx := context.WithValue(c.Request().Context(), contextKey{}, u)
+			c.SetRequest(c.Request().WithContext(ctx))
+
+			if info, ok := t.routes[c.Request().Method+" "+c.Path()]; ok {
+				markRoute(c, info)
+			}
+
+			err := next(c)
+
+			if len(u.items) > 0 {
+				principalType, client := t.identify(c)
+				for _, it := range u.items {
+					t.record(it[0], it[1], principalType, client, c.Request().UserAgent())
+				}
+			}
+			return err
+		}
+	}
+}
+
+// Route marks a route as deprecated. The Deprecation, Sunset and Link headers of RFC 8594
+// are added to the responses so that the clients are warned as well.
+func Route(info Info) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			markRoute(c, info)
+			return next(c)
+		}
+	}
+}
+
+func markRoute(c echo.Context, info Info) {
+	header := c.Response().Header()
+	header.Set("Deprecation", "true")
+	if !info.Sunset.IsZero() {
+		header.Set("Sunset", info.Sunset.UTC().Format(http.TimeFormat))
+	}
+	if info.Successor != "" {
+		header.Add("Link", "<"+info.Successor+`>; rel="successor-version"`)
+	}
+
+	if u, ok := c.Request().Context().Value(contextKey{}).(*usage); ok {
+		u.add(KindRoute, c.Request().Method+" "+c.Path())
+	}
+}
+
+// Field records the use of a deprecated request field or behaviour during the request held by ctx
+func Field(ctx context.Context, name string) {
+	if u, ok := ctx.Value(contextKey{}).(*usage); ok {
+		u.add(KindField, name)
+	}
+}
+
+// Field records the use of a deprecated request field or behaviour during the request held by ctx,
+// it lets the services depend on a small interface instead of this package.
+func (t *Tracker) Field(ctx context.Context, name string) {
+	Field(ctx, name)
+}
+
+// Close logs the last digest and stops the tracker
+func (t *Tracker) Close() {
+	close(t.stop)
+	<-t.done
+}
+
+// identify returns the principal type and the client of the request.
+// Users are not listed one by one in the metrics to keep the cardinality bounded,
+// the digest keeps the detailed client identity.
+func (t *Tracker) identify(c echo.Context) (string, string) {
+	token, err := auth.VerifyTokenFromRequest(c, t.keys)
+	if err != nil {
+		return "anonymous", "anonymous"
+	}
+	claims := token.Claims.(jwt.MapClaims)
+
+	if clientID, ok := claims["client_id"].(string); ok && clientID != "" {
+		principalType, _ := claims["principal_type"].(string)
+		if principalType == "" {
+			principalType = domain.PrincipalTypeUser
+		}
+		return principalType, clientID
+	}
+	if principalType, _ := claims["principal_type"].(string); principalType == domain.PrincipalTypeServiceAccount {
+		id, _ := claims["id"].(string)
+		return principalType, id
+	}
+	return domain.PrincipalTypeUser, domain.PrincipalTypeUser
+}
+
+func (t *Tracker) record(kind, name, principalType, client, userAgent string) {
+	requests.WithLabelValues(kind, name, principalType, client).Inc()
+	if !t.digests {
+		return
+	}
+
+	// users are grouped per user agent in the digest, it tells which app versions still use the item
+	digestClient := principalType + ":" + client
+	if client == domain.PrincipalTypeUser || client == "anonymous" {
+		digestClient = client + ":" + userAgent
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := [2]string{kind, name}
+	it, ok := t.items[key]
+	if !ok {
+		it = &item{kind: kind, name: name, clients: map[string]int64{}}
+		t.items[key] = it
+	}
+	it.calls++
+	it.lastSeen = times.Now()
+	it.clients[digestClient]++
+}
+
+func (t *Tracker) run(digestInterval time.Duration) {
+	defer close(t.done)
+
+	ticker := time.NewTicker(digestInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			t.digest()
+		case <-t.stop:
+			t.digest()
+			return
+		}
+	}
+}
+
+// digest logs the deprecated items used since the last digest with their top clients.
+// An empty digest is logged as well, several empty digests in a row mean the items can be removed.
+func (t *Tracker) digest() {
+	t.mu.Lock()
+	items := t.items
+	since := t.since
+	t.items = map[[2]string]*item{}
+	t.since = times.Now()
+	t.mu.Unlock()
+
+	report := make([]logger.Params, 0, len(items))
+	for _, it := range items {
+		clients := make([]logger.Params, 0, len(it.clients))
+		for client, calls := range it.clients {
+			clients = append(clients, logger.Params{"client": client, "calls": calls})
+		}
+		sort.Slice(clients, func(i, j int) bool {
+			return clients[i]["calls"].(int64) > clients[j]["calls"].(int64)
+		})
+		distinct := len(clients)
+		if len(clients) > digestTopClients {
+			clients = clients[:digestTopClients]
+		}
+
+		report = append(report, logger.Params{
+			"kind":             it.kind,
+			"name":             it.name,
+			"calls":            it.calls,
+			"distinct_clients": distinct,
+			"last_seen_at":     it.lastSeen,
+			"top_clients":      clients,
+		})
+	}
+	sort.Slice(report, func(i, j int) bool {
+		return report[i]["calls"].(int64) > report[j]["calls"].(int64)
+	})
+
+	t.log.WithParams(logger.Params{
+		"type":  "deprecation_digest",
+		"since": since,
+		"until": times.Now(),
+		"items": report,
+	}).Info("deprecation digest")
+}
@@ -0,0 +1,79 @@
+package deprecation
+
+import (
+	"go-hex/configs"
+	"go-hex/pkg/auth"
+	"go-hex/pkg/logger"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrackerMarksConfiguredRoutes(t *testing.T) {
+	var routes configs.DeprecatedRoutes
+	err := routes.Decode("GET /users/:id|2027-01-01|https://example.com/v2/users, POST /legacy")
+	assert.NoError(t, err)
+
+	tracker := NewTracker(logger.New("test", "test"), auth.NewHS256Keys("secret"), time.Hour, routes)
+	defer tracker.Close()
+
+	router := echo.New()
+	router.Use(tracker.Middleware())
+	ok := func(c echo.Context) error { return c.NoContent(http.StatusOK) }
+	router.GET("/users/:id", ok)
+	router.POST("/legacy", ok)
+	router.GET("/current", ok)
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		deprecated bool
+		sunset     string
+		link       string
+	}{
+		{name: "deprecated with sunset and successor", method: http.MethodGet, target: "/users/42", deprecated: true, sunset: "Fri, 01 Jan 2027 00:00:00 GMT", link: `<https://example.com/v2/users>; rel="successor-version"`},
+		{name: "deprecated without sunset", method: http.MethodPost, target: "/legacy", deprecated: true},
+		{name: "current route", method: http.MethodGet, target: "/current"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, nil))
+
+			if !tt.deprecated {
+				assert.Empty(t, rec.Header().Get("Deprecation"))
+				return
+			}
+			assert.Equal(t, "true", rec.Header().Get("Deprecation"))
+			assert.Equal(t, tt.sunset, rec.Header().Get("Sunset"))
+			assert.Equal(t, tt.link, rec.Header().Get("Link"))
+		})
+	}
+
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	assert.Len(t, tracker.items, 2)
+	assert.Equal(t, int64(1), tracker.items[[2]string{KindRoute, "GET /users/:id"}].calls)
+	assert.Equal(t, int64(1), tracker.items[[2]string{KindRoute, "POST /legacy"}].calls)
+}
+
+func TestDeprecatedRoutesDecodeRejectsInvalidEntries(t *testing.T) {
+	for _, value := range []string{"/users", "GET users", "GET /users|tomorrow", "GET /users|2027-01-01|https://a|b"} {
+		var routes configs.DeprecatedRoutes
+		assert.Error(t, routes.Decode(value), value)
+	}
+}
+
+func TestTrackerWithoutDigestInterval(t *testing.T) {
+	tracker := NewTracker(logger.New("test", "test"), auth.NewHS256Keys("secret"), 0, nil)
+	tracker.record(KindField, "legacy_field", "user", "user", "test")
+	tracker.Close()
+
+	assert.Empty(t, tracker.items)
+}
@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// AuditChainServiceAccounts names the chain of the service account audit events
+const AuditChainServiceAccounts = "service_account_audit_events"
+
+// AuditChainHead is the last link of a hash chain of audit events. Every event stores the hash of
+// the previous one, so that altering, inserting or deleting an event breaks the hashes of the following
+// ones, and the head tells whether the newest events were deleted.
+// The events archived then pruned are left out of the chain kept in the database, which starts after the pruned position.
+type AuditChainHead struct {
+	Name       string    `json:"name" bun:",pk"`
+	Seq        int64     `json:"seq"`
+	Hash       string    `json:"hash"`
+	PrunedSeq  int64     `json:"pruned_seq"`  // position of the last pruned event
+	PrunedHash string    `json:"pruned_hash"` // hash of the last pruned event
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// AuditChainAnchor is a copy of a chain head kept out of the database, so that the chain cannot be
+// rewritten as a whole without the anchors disagreeing with it.
+type AuditChainAnchor struct {
+	Name       string    `json:"name"`
+	Seq        int64     `json:"seq"`
+	Hash       string    `json:"hash"`
+	AnchoredAt time.Time `json:"anchored_at"`
+}
+
+// Chain links the audit events to the chain, in their order, and moves the head to the last one.
+// The creation times are truncated to the second, as stored, so that the hashes can be computed again.
+func (h *AuditChainHead) Chain(auditEvents []ServiceAccountAuditEvent) error {
+	for i := range auditEvents {
+		seq := h.Seq + 1
+		auditEvents[i].Seq = &seq
+		auditEvents[i].PrevHash = h.Hash
+		auditEvents[i].CreatedAt = auditEvents[i].CreatedAt.Truncate(time.Second)
+
+		hash, err := auditEvents[i].ComputeHash()
+		if err != nil {
+			return err
+		}
+		auditEvents[i].Hash = hash
+		h.Seq, h.Hash = seq, hash
+	}
+	return nil
+}
+
+// ComputeHash returns the hex encoded SHA-256 of the audit event and of the hash of the previous one.
+// The attributes are hashed in their canonical JSON form, as read back from the storage.
+func (e ServiceAccountAuditEvent) ComputeHash() (string, error) {
+	attributes, err := json.Marshal(e.Attributes)
+	if err != nil {
+		return "", errors.Wrap(err, "cannot hash audit event")
+	}
+	var canonical interface{}
+	if err := json.Unmarshal(attributes, &canonical); err != nil {
+		return "", errors.Wrap(err, "cannot hash audit event")
+	}
+
+	var seq int64
+	if e.Seq != nil {
+		seq = *e.Seq
+	}
+	b, err := json.Marshal([]interface{}{
+		seq,
+		e.PrevHash,
+		e.ID,
+		e.ServiceAccountID,
+		e.Event,
+		e.ActorType,
+		e.ActorID,
+		e.SubjectID,
+		canonical,
+		e.CreatedAt.Unix(),
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "cannot hash audit event")
+	}
+
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestAuditChainHeadChain(t *testing.T) {
+	head := AuditChainHead{Name: AuditChainServiceAccounts}
+	auditEvents := []ServiceAccountAuditEvent{
+		{ID: "e1", Event: EventServiceAccountCreated, CreatedAt: time.Now()},
+		{ID: "e2", Event: EventServiceAccountDisabled, CreatedAt: time.Now()},
+	}
+	if err := head.Chain(auditEvents); err != nil {
+		t.Fatal(err)
+	}
+
+	if *auditEvents[0].Seq != 1 || auditEvents[0].PrevHash != "" {
+		t.Errorf("first event = %d %q, want 1 and no previous hash", *auditEvents[0].Seq, auditEvents[0].PrevHash)
+	}
+	if *auditEvents[1].Seq != 2 || auditEvents[1].PrevHash != auditEvents[0].Hash {
+		t.Errorf("second event = %d %q, want 2 and the hash of the first one", *auditEvents[1].Seq, auditEvents[1].PrevHash)
+	}
+	if head.Seq != 2 || head.Hash != auditEvents[1].Hash {
+		t.Errorf("head = %d %q, want the second event", head.Seq, head.Hash)
+	}
+	if auditEvents[0].CreatedAt.Nanosecond() != 0 {
+		t.Errorf("CreatedAt = %v, want it truncated to the second", auditEvents[0].CreatedAt)
+	}
+}
+
+func TestServiceAccountAuditEventComputeHash(t *testing.T) {
+	seq := int64(1)
+	written := ServiceAccountAuditEvent{
+		ID:         "e1",
+		Event:      EventServiceAccountDisabled,
+		Attributes: map[string]interface{}{"diff": map[string]FieldChange{"is_active": {Before: true, After: false}}, "count": 1},
+		CreatedAt:  time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC),
+		Seq:        &seq,
+	}
+	hash, err := written.ComputeHash()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the attributes read back from the storage are plain maps with float numbers
+	b, _ := json.Marshal(written.Attributes)
+	read := written
+	read.Attributes = nil
+	_ = json.Unmarshal(b, &read.Attributes)
+	read.CreatedAt = written.CreatedAt.In(time.FixedZone("WIB", 7*60*60))
+	if got, _ := read.ComputeHash(); got != hash {
+		t.Errorf("ComputeHash() of the event read back = %s, want %s", got, hash)
+	}
+
+	altered := read
+	altered.ActorID = "someone else"
+	if got, _ := altered.ComputeHash(); got == hash {
+		t.Error("ComputeHash() of an altered event did not change")
+	}
+}
@@ -0,0 +1,41 @@
+package domain
+
+import "time"
+
+// Outcomes of the audited actions
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeFailure = "failure"
+)
+
+// Types of the actors of the audited actions, besides the principal types
+const (
+	ActorTypeAnonymous   = "anonymous"    // not authenticated, e.g. a failed login whose actor is the username tried
+	ActorTypeInternalAPI = "internal_api" // an operator or a back office calling the internal api
+	ActorTypeScheduler   = "scheduler"    // a scheduled job, e.g. the expiry of the time-boxed roles
+)
+
+// AuditEvent is an entry of the audit trail of the security relevant actions of the users, such as their logins,
+// their token refreshes and the changes of their accounts.
+type AuditEvent struct {
+	ID         string                 `json:"id"`
+	Action     string                 `json:"action"`  // name of the domain event, e.g. auth.login_failed
+	Outcome    string                 `json:"outcome"` // success or failure
+	ActorType  string                 `json:"actor_type"`
+	ActorID    string                 `json:"actor_id"`
+	SubjectID  string                 `json:"subject_id"`
+	IPAddress  string                 `json:"ip_address"`
+	UserAgent  string                 `json:"user_agent"`
+	Attributes map[string]interface{} `json:"attributes" bun:"type:json"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+// ResourceType returns the type of the audit event in the hypermedia responses.
+func (e AuditEvent) ResourceType() string {
+	return "audit_events"
+}
+
+// ResourceID returns the ID of the audit event in the hypermedia responses.
+func (e AuditEvent) ResourceID() string {
+	return e.ID
+}
@@ -0,0 +1,30 @@
+package domain
+
+import "time"
+
+// Statuses of a break-glass account
+const (
+	BreakGlassStatusSealed = "sealed" // the password is split between the custodians, the account cannot log in
+	BreakGlassStatusActive = "active" // the custodians revealed the password, the account can log in until it expires
+	BreakGlassStatusSpent  = "spent"  // the activation ended, the account must be sealed again
+)
+
+// BreakGlassAccount represents a user kept for the incidents, e.g. when the identity provider is down.
+// Its password is only known by combining the shares of its two custodians, and it can only log in for
+// the bounded time of an activation.
+type BreakGlassAccount struct {
+	UserID      string     `json:"user_id" bun:",pk"`
+	Status      string     `json:"status"`
+	Custodians  string     `json:"custodians"` // comma separated, each of them holds one share of the password
+	SealedAt    time.Time  `json:"sealed_at"`
+	ActivatedBy string     `json:"activated_by,omitempty"` // comma separated custodians who revealed their share
+	Reason      string     `json:"reason,omitempty"`
+	ActivatedAt *time.Time `json:"activated_at,omitempty"` // Nullable
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`   // Nullable
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`   // Nullable
+}
+
+// IsActiveAt checks whether the account can log in at the given time.
+func (a BreakGlassAccount) IsActiveAt(t time.Time) bool {
+	return a.Status == BreakGlassStatusActive && a.ExpiresAt != nil && t.Before(*a.ExpiresAt)
+}
@@ -0,0 +1,50 @@
+package domain
+
+import "time"
+
+// Kinds of the broadcast messages
+const (
+	BroadcastKindNotice      = "notice"
+	BroadcastKindMaintenance = "maintenance"
+	BroadcastKindRelogin     = "relogin" // warns the users that they will have to log in again
+)
+
+// Broadcast is a message of an admin pushed to the active sessions, all of them or the ones of some users or roles,
+// until it expires. The sessions streaming the broadcasts after the broadcast was created receive it too.
+type Broadcast struct {
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind"`
+	Message   string    `json:"message"`
+	UserIDs   []string  `json:"user_ids" bun:"type:json"` // targeted users, empty targets every user
+	Roles     []string  `json:"roles" bun:"type:json"`    // targeted user types, empty targets every user type
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// IsExpired checks whether the broadcast expired at the given time.
+func (b Broadcast) IsExpired(now time.Time) bool {
+	return !now.Before(b.ExpiresAt)
+}
+
+// Targets checks whether the broadcast is addressed to the sessions of the given user.
+func (b Broadcast) Targets(userID, role string) bool {
+	return (len(b.UserIDs) == 0 || contains(b.UserIDs, userID)) && (len(b.Roles) == 0 || contains(b.Roles, role))
+}
+
+// BroadcastDelivery records that a broadcast was pushed to a session, a broadcast is pushed once per session.
+type BroadcastDelivery struct {
+	BroadcastID string    `json:"broadcast_id" bun:",pk"`
+	SessionID   string    `json:"session_id" bun:",pk"`
+	UserID      string    `json:"user_id"`
+	DeliveredAt time.Time `json:"delivered_at"`
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
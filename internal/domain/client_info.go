@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"regexp"
+	"strings"
+)
+
+// clientInfoValue matches the values kept from the X-Client-Info header, so that they are safe to display and to
+// label the metrics with
+var clientInfoValue = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9 ._+-]{0,63}$`)
+
+// ClientInfo describes the client app a session is logged in from, read from the X-Client-Info header of its login,
+// e.g. app=checkout; version=3.2.1; platform=ios. Each part is empty when not sent or invalid.
+type ClientInfo struct {
+	App      string
+	Version  string
+	Platform string
+}
+
+// ParseClientInfo parses the semicolon separated key=value pairs of an X-Client-Info header, the keys being case
+// insensitive and the values optionally quoted. The unknown keys and the invalid values are ignored.
+func ParseClientInfo(header string) ClientInfo {
+	var info ClientInfo
+	for _, pair := range strings.Split(header, ";") {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+		if !clientInfoValue.MatchString(value) {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "app":
+			info.App = value
+		case "version":
+			info.Version = value
+		case "platform":
+			info.Platform = value
+		}
+	}
+	return info
+}
@@ -0,0 +1,24 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseClientInfo(t *testing.T) {
+	tests := []struct {
+		header string
+		want   ClientInfo
+	}{
+		{"app=checkout; version=3.2.1; platform=ios", ClientInfo{App: "checkout", Version: "3.2.1", Platform: "ios"}},
+		{` App="Back Office" ;VERSION=2024.10.1-rc.1 `, ClientInfo{App: "Back Office", Version: "2024.10.1-rc.1"}},
+		{"app=checkout; channel=beta; version", ClientInfo{App: "checkout"}},
+		{"app=<script>; version=" + strings.Repeat("1", 65) + "; platform=android", ClientInfo{Platform: "android"}},
+		{"", ClientInfo{}},
+	}
+	for _, tt := range tests {
+		if got := ParseClientInfo(tt.header); got != tt.want {
+			t.Errorf("ParseClientInfo(%q) = %+v, want %+v", tt.header, got, tt.want)
+		}
+	}
+}
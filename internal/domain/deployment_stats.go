@@ -0,0 +1,66 @@
+package domain
+
+import "time"
+
+// DeploymentLatencyBounds are the upper bounds of the latency buckets of the deployment stats, the last bucket
+// counting the requests slower than the last bound
+var DeploymentLatencyBounds = []time.Duration{
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// DeploymentStats counts the outcomes of the requests served by a version of the api during a minute
+type DeploymentStats struct {
+	Version  string
+	Minute   time.Time
+	Requests uint64
+	Errors   uint64 // failed by the service or its dependencies
+	// Latencies counts the successful requests per latency bucket, len(DeploymentLatencyBounds)+1 buckets
+	Latencies []uint64
+}
+
+// NewDeploymentStats creates the empty stats of the version during the minute
+func NewDeploymentStats(version string, minute time.Time) DeploymentStats {
+	return DeploymentStats{Version: version, Minute: minute, Latencies: make([]uint64, len(DeploymentLatencyBounds)+1)}
+}
+
+// Observe counts a request, failed or answered successfully after latency
+func (s *DeploymentStats) Observe(failed, success bool, latency time.Duration) {
+	s.Requests++
+	if failed {
+		s.Errors++
+	}
+	if success {
+		s.Latencies[LatencyBucket(latency)]++
+	}
+}
+
+// Add adds the counts of other to the stats
+func (s *DeploymentStats) Add(other DeploymentStats) {
+	s.Requests += other.Requests
+	s.Errors += other.Errors
+	for i := range s.Latencies {
+		if i < len(other.Latencies) {
+			s.Latencies[i] += other.Latencies[i]
+		}
+	}
+}
+
+// LatencyBucket returns the index of the bucket of the latency
+func LatencyBucket(latency time.Duration) int {
+	for i, bound := range DeploymentLatencyBounds {
+		if latency <= bound {
+			return i
+		}
+	}
+	return len(DeploymentLatencyBounds)
+}
@@ -0,0 +1,30 @@
+package domain
+
+import "time"
+
+// Statuses of a device login
+const (
+	DeviceLoginStatusPending  = "pending"
+	DeviceLoginStatusApproved = "approved"
+	DeviceLoginStatusDenied   = "denied"
+	DeviceLoginStatusConsumed = "consumed"
+)
+
+// DeviceLogin represents a cross-device login started on an input constrained device (TV, console)
+// and approved by the user from an authenticated session on another device.
+type DeviceLogin struct {
+	ID           string     `json:"id"`
+	DeviceCode   string     `json:"-"` // hashed, the plain device code is only known by the device
+	UserCode     string     `json:"user_code"`
+	UserID       *string    `json:"-"` // Nullable, set once approved
+	Status       string     `json:"status"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	DecidedAt    *time.Time `json:"-"` // Nullable
+	LastPolledAt *time.Time `json:"-"` // Nullable
+}
+
+// IsExpired checks whether the device login expired at the given time.
+func (d DeviceLogin) IsExpired(now time.Time) bool {
+	return !now.Before(d.ExpiresAt)
+}
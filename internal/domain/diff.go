@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Masked replaces the values of the sensitive fields in the diffs
+const Masked = "[MASKED]"
+
+// FieldChange is the change of a field between two versions of an entity
+type FieldChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// Diff returns the changes of the fields of an entity between two of its versions, by field name.
+// The fields are named after their json name, or after their Go name in snake_case when hidden from json.
+// The fields tagged audit:"masked" are reported when they change but with their values masked,
+// the fields tagged audit:"-" are ignored. Embedded structs contribute their own fields.
+func Diff(before, after interface{}) map[string]FieldChange {
+	changes := map[string]FieldChange{}
+	diffStruct(reflect.Indirect(reflect.ValueOf(before)), reflect.Indirect(reflect.ValueOf(after)), changes)
+	return changes
+}
+
+func diffStruct(before, after reflect.Value, changes map[string]FieldChange) {
+	if before.Kind() != reflect.Struct || before.Type() != after.Type() {
+		return
+	}
+
+	typ := before.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get("audit")
+		if tag == "-" || field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			diffStruct(before.Field(i), after.Field(i), changes)
+			continue
+		}
+
+		b, a := value(before.Field(i)), value(after.Field(i))
+		if equal(b, a) {
+			continue
+		}
+		if tag == "masked" {
+			b, a = mask(b), mask(a)
+		}
+		changes[fieldName(field)] = FieldChange{Before: b, After: a}
+	}
+}
+
+// value returns the value of the field, nil for the nil pointers
+func value(v reflect.Value) interface{} {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	return v.Interface()
+}
+
+func equal(a, b interface{}) bool {
+	if ta, ok := a.(time.Time); ok {
+		tb, ok := b.(time.Time)
+		return ok && ta.Equal(tb)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// mask hides a sensitive value, an unset value stays unset so that its setting and clearing remain visible
+func mask(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	return Masked
+}
+
+func fieldName(field reflect.StructField) string {
+	if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name != "" && name != "-" {
+		return name
+	}
+	var b strings.Builder
+	for i, r := range field.Name {
+		if unicode.IsUpper(r) {
+			if i > 0 && !unicode.IsUpper(rune(field.Name[i-1])) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	createdAt := time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)
+	before := ServiceAccount{
+		ID:        "sa-1",
+		Name:      "billing",
+		PublicKey: "old key",
+		IsActive:  true,
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}
+	after := before
+	after.IsActive = false
+	after.PublicKey = "new key"
+	after.CreatedAt = createdAt.In(time.FixedZone("WIB", 7*60*60))
+	after.UpdatedAt = createdAt.Add(time.Hour)
+
+	got := Diff(before, after)
+	want := map[string]FieldChange{
+		"is_active":  {Before: true, After: false},
+		"public_key": {Before: Masked, After: Masked},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() = %v, want %v", got, want)
+	}
+}
+
+func TestDiffEmbedded(t *testing.T) {
+	type account struct {
+		ServiceAccount
+		Roles []string `json:"roles"`
+	}
+
+	before := account{ServiceAccount{ID: "sa-1"}, []string{}}
+	after := account{ServiceAccount{ID: "sa-1", Description: "billing jobs"}, []string{"billing:write"}}
+
+	got := Diff(&before, &after)
+	want := map[string]FieldChange{
+		"description": {Before: "", After: "billing jobs"},
+		"roles":       {Before: []string{}, After: []string{"billing:write"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() = %v, want %v", got, want)
+	}
+}
+
+func TestDiffPointers(t *testing.T) {
+	fullName := "John Doe"
+	refreshToken := "token"
+	before := User{ID: "user-1"}
+	after := User{ID: "user-1", FullName: &fullName, RefreshToken: &refreshToken}
+
+	got := Diff(before, after)
+	want := map[string]FieldChange{
+		"full_name":     {Before: nil, After: "John Doe"},
+		"refresh_token": {Before: nil, After: Masked},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() = %v, want %v", got, want)
+	}
+
+	if got := Diff(after, after); len(got) != 0 {
+		t.Errorf("Diff() = %v, want no change", got)
+	}
+}
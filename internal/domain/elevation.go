@@ -0,0 +1,37 @@
+package domain
+
+import "time"
+
+// Statuses of an elevation
+const (
+	ElevationStatusPending  = "pending"
+	ElevationStatusApproved = "approved"
+	ElevationStatusDenied   = "denied"
+)
+
+// Elevation represents the request of a user to be granted a sensitive role for a bounded time,
+// the role is granted once an approver other than the user approves the request.
+type Elevation struct {
+	ID          string     `json:"id"`
+	UserID      string     `json:"user_id"`
+	Role        string     `json:"role"`
+	Reason      string     `json:"reason"`
+	Duration    int        `json:"duration"` // in minutes, the role is granted for this long once approved
+	Status      string     `json:"status"`
+	RequestedAt time.Time  `json:"requested_at"`
+	ExpiresAt   time.Time  `json:"expires_at"`           // the request cannot be decided afterwards
+	DecidedBy   string     `json:"decided_by,omitempty"` // user id of the approver
+	DecidedAt   *time.Time `json:"decided_at,omitempty"` // Nullable
+	Comment     string     `json:"comment,omitempty"`
+	EndsAt      *time.Time `json:"ends_at,omitempty"` // Nullable, set once approved
+}
+
+// IsExpired checks whether the request expired undecided at the given time.
+func (e Elevation) IsExpired(now time.Time) bool {
+	return e.Status == ElevationStatusPending && !now.Before(e.ExpiresAt)
+}
+
+// IsActiveAt checks whether the role is granted at the given time.
+func (e Elevation) IsActiveAt(t time.Time) bool {
+	return e.Status == ElevationStatusApproved && e.EndsAt != nil && t.Before(*e.EndsAt)
+}
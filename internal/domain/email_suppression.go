@@ -0,0 +1,21 @@
+package domain
+
+import "time"
+
+// Reasons of the email suppressions
+const (
+	EmailSuppressionBounce    = "bounce"    // the address bounced permanently
+	EmailSuppressionComplaint = "complaint" // the recipient reported a message as spam
+)
+
+// EmailSuppression marks an address as undeliverable after the email provider reported a permanent bounce
+// or a complaint, no email is sent to the address until the suppression is deleted.
+type EmailSuppression struct {
+	Address   string    `json:"address" bun:",pk"` // lower case
+	Reason    string    `json:"reason"`
+	Provider  string    `json:"provider"` // provider reporting the last feedback, ses or sendgrid
+	Detail    string    `json:"detail"`   // diagnostic of the provider for the last feedback
+	Feedbacks int       `json:"feedbacks"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,20 @@
+package domain
+
+import "time"
+
+// EmailVerification is a one-time token sent to the email address of a registered user. Only the SHA-256 of the
+// token is stored, the token confirms the address, and activates the user, once before it expires.
+type EmailVerification struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"user_id"`
+	Email      string     `json:"email"` // the address the token was sent to
+	TokenHash  string     `json:"-"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	VerifiedAt *time.Time `json:"verified_at"` // Nullable, set once confirmed
+}
+
+// IsUsableAt checks whether the token can still confirm the address at the given time.
+func (v EmailVerification) IsUsableAt(t time.Time) bool {
+	return v.VerifiedAt == nil && t.Before(v.ExpiresAt)
+}
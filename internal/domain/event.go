@@ -0,0 +1,57 @@
+package domain
+
+// Domain event names published on the event bus.
+const (
+	EventLoginSucceeded         = "auth.login_succeeded"
+	EventLoginFailed            = "auth.login_failed"
+	EventTokenRefreshed         = "auth.token_refreshed"
+	EventUserSignedUp           = "user.signed_up"
+	EventUserRegistered         = "user.registered"
+	EventUserVerified           = "user.verified"
+	EventUserUpdated            = "user.updated"
+	EventUserDeactivated        = "user.deactivated"
+	EventSignupRejected         = "signup.rejected"
+	EventSessionEvicted         = "session.evicted"
+	EventSessionRevoked         = "session.revoked"
+	EventRefreshTokenReused     = "refresh_token.reused"
+	EventLoginApprovalRequested = "login_approval.requested"
+	EventLoginApprovalApproved  = "login_approval.approved"
+	EventLoginApprovalDenied    = "login_approval.denied"
+	EventDeviceLoginApproved    = "device_login.approved"
+	EventDeviceLoginDenied      = "device_login.denied"
+	EventLegalHoldPlaced        = "legal_hold.placed"
+	EventLegalHoldReleased      = "legal_hold.released"
+	EventBroadcastCreated       = "broadcast.created"
+	EventEmailSuppressed        = "email.suppressed"
+	EventEmailUnsuppressed      = "email.unsuppressed"
+	EventMessageTemplateUpdated = "message_template.updated"
+	EventMessageTemplateReset   = "message_template.reset"
+	EventElevationRequested     = "elevation.requested"
+	EventElevationApproved      = "elevation.approved"
+	EventElevationDenied        = "elevation.denied"
+	EventBreakGlassSealed       = "break_glass.sealed"
+	EventBreakGlassActivated    = "break_glass.activated"
+	EventBreakGlassRejected     = "break_glass.activation_rejected"
+	EventBreakGlassLogin        = "break_glass.login"
+	EventBreakGlassRevoked      = "break_glass.revoked"
+	EventUserSyncCompleted      = "user_sync.completed"
+	EventProvisioningCompleted  = "provisioning.completed"
+	EventPasswordResetRequested = "password_reset.requested"
+	EventPasswordResetCompleted = "password_reset.completed"
+	EventRoleAssigned           = "role.assigned"
+	EventRoleUnassigned         = "role.unassigned"
+	EventRoleExpired            = "role.expired"
+	EventSocialAccountLinked    = "social_account.linked"
+	EventTenantSettingsUpdated  = "tenant_settings.updated"
+	EventTenantSettingsDeleted  = "tenant_settings.deleted"
+
+	// service account events are kept apart from the user events so that their audit trail can be followed separately
+	EventServiceAccountCreated     = "service_account.created"
+	EventServiceAccountKeyRotated  = "service_account.key_rotated"
+	EventServiceAccountEnabled     = "service_account.enabled"
+	EventServiceAccountDisabled    = "service_account.disabled"
+	EventServiceAccountRoleBound   = "service_account.role_bound"
+	EventServiceAccountRoleUnbound = "service_account.role_unbound"
+	EventServiceAccountRoleExpired = "service_account.role_expired"
+	EventServiceAccountTokenIssued = "service_account.token_issued"
+)
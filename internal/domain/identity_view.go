@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"sort"
+	"time"
+)
+
+// IdentityView is the denormalized identity of a user read to issue its tokens: its roles, the elevations granting
+// more roles and its break-glass account, so that the issuance reads it at once instead of reading each repository.
+// It is built from the repositories and kept until a write changing it or until it is too stale.
+type IdentityView struct {
+	UserID        string             `json:"user_id"`
+	Roles         []Role             `json:"roles"`                 // assigned to the user or by default, with their permissions
+	RoleWindows   []UserRole         `json:"role_windows"`          // the time-boxed assignments of the roles not ended when built
+	Elevations    []Elevation        `json:"elevations"`            // approved and not ended when built
+	ElevatedRoles []Role             `json:"elevated_roles"`        // the roles of the elevations defined with permissions
+	BreakGlass    *BreakGlassAccount `json:"break_glass,omitempty"` // nil unless the user is a break-glass account
+	BuiltAt       time.Time          `json:"built_at"`
+}
+
+// ActiveElevations returns the elevations of the view granting their role at the given time.
+func (v IdentityView) ActiveElevations(at time.Time) []Elevation {
+	elevations := []Elevation{}
+	for _, elevation := range v.Elevations {
+		if elevation.IsActiveAt(at) {
+			elevations = append(elevations, elevation)
+		}
+	}
+	return elevations
+}
+
+// ActiveRoleWindows returns the time-boxed assignments of the view granting their role at the given time.
+func (v IdentityView) ActiveRoleWindows(at time.Time) []UserRole {
+	windows := []UserRole{}
+	for _, window := range v.RoleWindows {
+		if window.IsActiveAt(at) {
+			windows = append(windows, window)
+		}
+	}
+	return windows
+}
+
+// Grants returns the names of the roles granted by the view at the given time, the roles of the user within their
+// window and the roles of its active elevations, and the permissions of these roles, both sorted without duplicates.
+func (v IdentityView) Grants(at time.Time) (roles []string, permissions []string) {
+	outside := map[string]bool{}
+	for _, window := range v.RoleWindows {
+		outside[window.Role] = !window.IsActiveAt(at)
+	}
+
+	granted := map[string]bool{}
+	permitted := map[string]bool{}
+	for _, role := range v.Roles {
+		if outside[role.Name] {
+			continue
+		}
+		granted[role.Name] = true
+		for _, permission := range role.Permissions {
+			permitted[permission] = true
+		}
+	}
+	for _, elevation := range v.ActiveElevations(at) {
+		granted[elevation.Role] = true
+		for _, role := range v.ElevatedRoles {
+			if role.Name != elevation.Role {
+				continue
+			}
+			for _, permission := range role.Permissions {
+				permitted[permission] = true
+			}
+		}
+	}
+	return sortedKeys(granted), sortedKeys(permitted)
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,21 @@
+package domain
+
+import "time"
+
+// LegalHold preserves the records of a user, they cannot be deleted or anonymized until the hold is released.
+// The holds are never deleted, so that they remain the audit trail of who held and released the user and why.
+type LegalHold struct {
+	ID            string     `json:"id"`
+	UserID        string     `json:"user_id"`
+	Reason        string     `json:"reason"`
+	PlacedBy      string     `json:"placed_by"`
+	PlacedAt      time.Time  `json:"placed_at"`
+	ReleasedBy    *string    `json:"released_by"`    // Nullable, set once released
+	ReleaseReason *string    `json:"release_reason"` // Nullable, set once released
+	ReleasedAt    *time.Time `json:"released_at"`    // Nullable, set once released
+}
+
+// IsActive checks whether the legal hold is not released yet.
+func (h LegalHold) IsActive() bool {
+	return h.ReleasedAt == nil
+}
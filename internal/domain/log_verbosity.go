@@ -0,0 +1,20 @@
+package domain
+
+import "time"
+
+// LogVerbosity temporarily raises the log level of the requests of a user, or of the requests
+// whose request id matches a pattern, so that an issue can be investigated without redeploying.
+type LogVerbosity struct {
+	ID               string    `json:"id"`
+	UserID           *string   `json:"user_id"`            // Nullable, set when the requests of a user are raised
+	RequestIDPattern *string   `json:"request_id_pattern"` // Nullable, regular expression matching the request ids
+	Level            string    `json:"level"`
+	Reason           string    `json:"reason"`
+	CreatedAt        time.Time `json:"created_at"`
+	ExpiresAt        time.Time `json:"expires_at"`
+}
+
+// IsExpired checks whether the log verbosity expired at the given time.
+func (v LogVerbosity) IsExpired(now time.Time) bool {
+	return !now.Before(v.ExpiresAt)
+}
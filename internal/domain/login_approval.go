@@ -0,0 +1,31 @@
+package domain
+
+import "time"
+
+// Statuses of a login approval
+const (
+	LoginApprovalStatusPending  = "pending"
+	LoginApprovalStatusApproved = "approved"
+	LoginApprovalStatusDenied   = "denied"
+	LoginApprovalStatusConsumed = "consumed"
+)
+
+// LoginApproval represents a pending login waiting to be approved out of band from an authenticated device.
+type LoginApproval struct {
+	ID           string     `json:"id"`
+	UserID       string     `json:"-"`
+	ClientSecret string     `json:"-"` // hashed, the plain secret is only known by the client waiting for the approval
+	Number       int        `json:"-"`
+	Choices      string     `json:"-"` // comma separated numbers shown on the approving device
+	Status       string     `json:"status"`
+	IPAddress    string     `json:"ip_address"`
+	UserAgent    string     `json:"user_agent"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	DecidedAt    *time.Time `json:"-"` // Nullable
+}
+
+// IsExpired checks whether the approval expired at the given time.
+func (a LoginApproval) IsExpired(now time.Time) bool {
+	return !now.Before(a.ExpiresAt)
+}
@@ -0,0 +1,17 @@
+package domain
+
+import "time"
+
+// MessageTemplate is a version of the override of a transactional message on a channel. The versions are
+// never updated nor deleted: the latest one applies, and a reset version restores the embedded default.
+type MessageTemplate struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Channel   string    `json:"channel"`
+	Version   int       `json:"version"`
+	Subject   string    `json:"subject"` // empty for the sms channel
+	Body      string    `json:"body"`
+	Reset     bool      `json:"reset"` // restores the default, the subject and the body are empty
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
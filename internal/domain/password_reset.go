@@ -0,0 +1,20 @@
+package domain
+
+import "time"
+
+// PasswordReset is a one-time token sent to a user who forgot their password. Only the SHA-256 of the token
+// is stored, the token changes the password once, before it expires.
+type PasswordReset struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"user_id"`
+	TokenHash string     `json:"-"`
+	Channel   string     `json:"channel"` // email or sms, the token was sent through
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"` // Nullable, set once used or replaced by a newer token
+}
+
+// IsUsableAt checks whether the token can still change the password at the given time.
+func (r PasswordReset) IsUsableAt(t time.Time) bool {
+	return r.UsedAt == nil && t.Before(r.ExpiresAt)
+}
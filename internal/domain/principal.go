@@ -0,0 +1,7 @@
+package domain
+
+// Principal types carried by the principal_type claim of the access tokens, tokens without the claim belong to users.
+const (
+	PrincipalTypeUser           = "user"
+	PrincipalTypeServiceAccount = "service_account"
+)
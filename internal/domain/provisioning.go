@@ -0,0 +1,67 @@
+package domain
+
+import "time"
+
+// Kinds of drift found by the reconciliation of a downstream directory
+const (
+	ProvisioningDriftMissing    = "missing"    // the active user has no account downstream
+	ProvisioningDriftOrphaned   = "orphaned"   // the active account downstream has no active user
+	ProvisioningDriftMismatched = "mismatched" // the account downstream differs from the user
+)
+
+// Statuses of a provisioning run
+const (
+	ProvisioningRunSucceeded = "succeeded"
+	ProvisioningRunFailed    = "failed"
+)
+
+// ProvisioningState is the last account pushed for a user to a downstream directory,
+// the user is pushed again once its account differs from the hash.
+type ProvisioningState struct {
+	Connector  string    `json:"connector" bun:",pk"`
+	UserID     string    `json:"user_id" bun:",pk"`
+	AccountKey string    `json:"account_key"` // e.g. the uid or the email of the account downstream
+	Hash       string    `json:"hash"`        // SHA-256 of the account
+	Active     bool      `json:"active"`
+	PushedAt   time.Time `json:"pushed_at"`
+}
+
+// ProvisioningError is the failed push of a user to a downstream directory, queued to be retried.
+// The push is retried at the next run after NextAttemptAt, until it succeeds or the attempts run out.
+type ProvisioningError struct {
+	Connector     string    `json:"connector" bun:",pk"`
+	UserID        string    `json:"user_id" bun:",pk"`
+	Error         string    `json:"error"`
+	Attempts      int       `json:"attempts"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// ProvisioningRun is the report of a push of the users to a downstream directory, with the drift
+// between the directory and the users found by its reconciliation.
+type ProvisioningRun struct {
+	ID          string              `json:"id" bun:",pk"`
+	Connector   string              `json:"connector"`
+	Status      string              `json:"status"`
+	Pushed      int                 `json:"pushed"`      // accounts created or updated
+	Deactivated int                 `json:"deactivated"` // accounts suspended or deleted
+	Failed      int                 `json:"failed"`      // pushes queued to be retried
+	Deferred    int                 `json:"deferred"`    // pushes waiting for their retry
+	Unchanged   int                 `json:"unchanged"`
+	Missing     int                 `json:"missing"`
+	Orphaned    int                 `json:"orphaned"`
+	Mismatched  int                 `json:"mismatched"`
+	Drifts      []ProvisioningDrift `json:"drifts,omitempty" bun:"type:json"`
+	Error       *string             `json:"error,omitempty"` // Nullable, why the run failed
+	StartedAt   time.Time           `json:"started_at"`
+	FinishedAt  time.Time           `json:"finished_at"`
+}
+
+// ProvisioningDrift is a difference between an account downstream and its user
+type ProvisioningDrift struct {
+	Kind       string   `json:"kind"`
+	AccountKey string   `json:"account_key"`
+	UserID     string   `json:"user_id,omitempty"`
+	Attributes []string `json:"attributes,omitempty"` // the attributes which differ
+}
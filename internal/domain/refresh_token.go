@@ -0,0 +1,25 @@
+package domain
+
+import "time"
+
+// RefreshToken records a refresh token issued to a session, referenced by the jti claim of the token so that the
+// token itself is never stored. The refresh tokens of a session form its family:
+// each refresh rotates the token presented into a new one, and presenting a rotated token again revokes the family.
+type RefreshToken struct {
+	ID         string     `json:"id"` // jti claim of the token
+	SessionID  string     `json:"session_id"`
+	IssuedAt   time.Time  `json:"issued_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`  // Nullable, the token does not expire when unset
+	RotatedAt  *time.Time `json:"rotated_at"`  // Nullable
+	ReplacedBy string     `json:"replaced_by"` // ID of the token issued when rotated
+}
+
+// IsRotated checks whether the token was already exchanged for a new one.
+func (t RefreshToken) IsRotated() bool {
+	return t.RotatedAt != nil
+}
+
+// IsExpiredAt checks whether the token expired at the given time.
+func (t RefreshToken) IsExpiredAt(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
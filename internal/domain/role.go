@@ -0,0 +1,52 @@
+package domain
+
+import "time"
+
+// Seed roles created by the migrations
+const (
+	RoleAdmin = "admin" // granted every permission
+	RoleUser  = "user"  // granted to every user by default
+)
+
+// PermissionAll grants every permission to the roles holding it
+const PermissionAll = "*"
+
+// Role is a named set of permissions granted to the users it is assigned to.
+type Role struct {
+	Name        string    `json:"name" bun:",pk"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	Permissions []string  `json:"permissions" bun:"-"` // resource:action, e.g. users:write
+}
+
+// Permission is an action on a resource granted by the roles, named resource:action, e.g. users:write.
+type Permission struct {
+	Name        string `json:"name" bun:",pk"`
+	Description string `json:"description"`
+}
+
+// RolePermission grants a permission to a role.
+type RolePermission struct {
+	Role       string `json:"role" bun:",pk"`
+	Permission string `json:"permission" bun:",pk"`
+}
+
+// UserRole assigns a role to a user, for a time window when it is time-boxed.
+type UserRole struct {
+	UserID     string     `json:"user_id" bun:",pk"`
+	Role       string     `json:"role" bun:",pk"`
+	AssignedBy string     `json:"assigned_by,omitempty"` // user id of the assigner, empty when seeded
+	CreatedAt  time.Time  `json:"created_at"`
+	StartsAt   *time.Time `json:"starts_at"` // Nullable, granted from the assignment when unset
+	EndsAt     *time.Time `json:"ends_at"`   // Nullable, granted until unassigned when unset
+}
+
+// IsTimeBoxed checks whether the role is only granted for a time window.
+func (r UserRole) IsTimeBoxed() bool {
+	return r.StartsAt != nil || r.EndsAt != nil
+}
+
+// IsActiveAt checks whether the role is granted at the given time, the end of the window is exclusive.
+func (r UserRole) IsActiveAt(t time.Time) bool {
+	return (r.StartsAt == nil || !t.Before(*r.StartsAt)) && (r.EndsAt == nil || t.Before(*r.EndsAt))
+}
@@ -0,0 +1,78 @@
+package domain
+
+import "time"
+
+// ServiceAccount represents a non-human identity used by backend services.
+// It authenticates with JWT assertions signed by its private key (private_key_jwt) instead of a password.
+type ServiceAccount struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	PublicKey   string     `json:"-" audit:"masked"` // PEM encoded RSA public key verifying the assertions
+	IsActive    bool       `json:"is_active"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" audit:"-"`
+	LastUsedAt  *time.Time `json:"last_used_at" audit:"-"` // Nullable
+}
+
+// ServiceAccountRole binds a role to a service account, for a time window when it is time-boxed.
+type ServiceAccountRole struct {
+	ServiceAccountID string     `json:"-"`
+	Role             string     `json:"role"`
+	CreatedAt        time.Time  `json:"created_at"`
+	StartsAt         *time.Time `json:"starts_at"` // Nullable, granted from the binding when unset
+	EndsAt           *time.Time `json:"ends_at"`   // Nullable, granted until unbound when unset
+}
+
+// IsTimeBoxed checks whether the role is only granted for a time window.
+func (r ServiceAccountRole) IsTimeBoxed() bool {
+	return r.StartsAt != nil || r.EndsAt != nil
+}
+
+// IsActiveAt checks whether the role is granted at the given time, the end of the window is exclusive.
+func (r ServiceAccountRole) IsActiveAt(t time.Time) bool {
+	return (r.StartsAt == nil || !t.Before(*r.StartsAt)) && (r.EndsAt == nil || t.Before(*r.EndsAt))
+}
+
+// ServiceAccountAssertion records a consumed JWT assertion so that it cannot be replayed.
+type ServiceAccountAssertion struct {
+	JTI              string    `bun:"jti"`
+	ServiceAccountID string    `bun:"service_account_id"`
+	ExpiresAt        time.Time `bun:"expires_at"`
+}
+
+// ServiceAccountAuditEvent records a change made to a service account or a token issued to it.
+// It is stored apart from the events of the users so that the service accounts have their own audit trail.
+type ServiceAccountAuditEvent struct {
+	ID               string                 `json:"id"`
+	ServiceAccountID string                 `json:"service_account_id"`
+	Event            string                 `json:"event"`
+	ActorType        string                 `json:"actor_type"`
+	ActorID          string                 `json:"actor_id"`
+	SubjectID        string                 `json:"subject_id"`
+	Attributes       map[string]interface{} `json:"attributes" bun:"type:json"`
+	CreatedAt        time.Time              `json:"created_at"`
+	Seq              *int64                 `json:"seq"`       // Nullable, position in the hash chain, unset for the events recorded before the chaining
+	PrevHash         string                 `json:"prev_hash"` // hash of the previous event of the chain
+	Hash             string                 `json:"hash"`
+}
+
+// ResourceType returns the type of the audit event in the hypermedia responses.
+func (e ServiceAccountAuditEvent) ResourceType() string {
+	return "service_account_audit_events"
+}
+
+// ResourceID returns the ID of the audit event in the hypermedia responses.
+func (e ServiceAccountAuditEvent) ResourceID() string {
+	return e.ID
+}
+
+// ServiceAccountAuditArchive records the export of the audit events of a day to the object storage.
+// Only the days already exported can be pruned from the database.
+type ServiceAccountAuditArchive struct {
+	Day        time.Time `json:"day" bun:",pk"` // UTC day of the creation of the events
+	Object     string    `json:"object"`        // empty when the day had no event
+	Events     int       `json:"events"`
+	Checksum   string    `json:"checksum"` // hex encoded SHA-256 of the compressed object
+	ArchivedAt time.Time `json:"archived_at"`
+}
@@ -0,0 +1,30 @@
+package domain
+
+import "time"
+
+// Session represents an authenticated login session of a user.
+// Sessions started through an upstream OpenID provider keep the upstream sid and sub
+// so that a backchannel logout of the provider revokes the matching local sessions.
+// Each device the user logs in on has its own session, described by the address, the user agent and the client app
+// of its login.
+type Session struct {
+	ID                string     `json:"id"`
+	UserID            string     `json:"-"`
+	RefreshToken      *string    `json:"-"`               // Nullable
+	UpstreamSessionID *string    `json:"-"`               // Nullable, sid of the upstream OpenID provider
+	UpstreamSubject   *string    `json:"-"`               // Nullable, sub of the upstream OpenID provider
+	IPAddress         *string    `json:"ip_address"`      // Nullable
+	UserAgent         *string    `json:"user_agent"`      // Nullable
+	ClientApp         *string    `json:"client_app"`      // Nullable, from the X-Client-Info header of its login
+	ClientVersion     *string    `json:"client_version"`  // Nullable
+	ClientPlatform    *string    `json:"client_platform"` // Nullable
+	CreatedAt         time.Time  `json:"created_at"`
+	LastUsedAt        *time.Time `json:"last_used_at"` // Nullable, set when its refresh token is issued
+	ExpiresAt         time.Time  `json:"expires_at"`
+	RevokedAt         *time.Time `json:"-"` // Nullable
+}
+
+// IsActive checks whether the session is neither revoked nor expired at the given time.
+func (s Session) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
@@ -0,0 +1,27 @@
+package domain
+
+import "time"
+
+// Statuses of the text messages
+const (
+	SMSStatusSent        = "sent"        // accepted by the provider
+	SMSStatusDelivered   = "delivered"   // delivered to the handset, as reported by the provider
+	SMSStatusUndelivered = "undelivered" // rejected by the carrier, as reported by the provider
+	SMSStatusFailed      = "failed"      // not sent by any provider of the route
+)
+
+// SMSMessage records a text message sent to a user and its delivery, the phone number is not kept.
+type SMSMessage struct {
+	ID                string    `json:"id"`
+	UserID            string    `json:"user_id"`
+	Provider          string    `json:"provider"`            // provider which accepted the message, empty when all failed
+	ProviderMessageID string    `json:"provider_message_id"` // id of the message at the provider
+	Route             string    `json:"route"`               // calling code prefix routing the message
+	Attempts          int       `json:"attempts"`            // providers tried, more than one after a failover
+	Segments          int       `json:"segments"`
+	Cost              float64   `json:"cost"` // in USD, from the configured prices
+	Status            string    `json:"status"`
+	ErrorCode         string    `json:"error_code"` // code of the provider for the last failure
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
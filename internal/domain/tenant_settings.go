@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// TenantSettings overrides the global configuration for the requests of a tenant, a nil setting inherits
+// the configured value. The settings are replaced as a whole, the latest write wins.
+type TenantSettings struct {
+	TenantID               string    `json:"tenant_id" bun:",pk"`
+	TokenExpiration        *int      `json:"token_expiration"`         // Nullable, in minutes
+	RefreshTokenExpiration *int      `json:"refresh_token_expiration"` // Nullable, in minutes, 0 keeps the refresh tokens valid until rotated
+	LoginApprovalRequired  *bool     `json:"login_approval_required"`  // Nullable, enforces the approval of the logins from a new device
+	PasswordMinLength      *int      `json:"password_min_length"`      // Nullable
+	PasswordMinClasses     *int      `json:"password_min_classes"`     // Nullable, among lowercase, uppercase, digits and symbols
+	AppName                *string   `json:"app_name"`                 // Nullable, named in the messages sent to the users
+	LogoURL                *string   `json:"logo_url"`                 // Nullable, shown by the emails and the hosted pages
+	PrimaryColor           *string   `json:"primary_color"`            // Nullable, in hexadecimal
+	AccentColor            *string   `json:"accent_color"`             // Nullable, in hexadecimal
+	SupportEmail           *string   `json:"support_email"`            // Nullable, named in the footer of the emails
+	UpdatedBy              string    `json:"updated_by"`
+	CreatedAt              time.Time `json:"created_at"`
+	UpdatedAt              time.Time `json:"updated_at"`
+}
@@ -0,0 +1,25 @@
+package domain
+
+import "time"
+
+// TokenUsageEndpoint aggregates the sampled calls made by a client to an endpoint on a given day.
+type TokenUsageEndpoint struct {
+	ClientID      string    `json:"client_id"`
+	PrincipalType string    `json:"principal_type"`
+	Method        string    `json:"method"`
+	Route         string    `json:"route"`
+	Day           time.Time `json:"-"`
+	Calls         int64     `json:"calls"`
+	LastSeenAt    time.Time `json:"last_seen_at"`
+}
+
+// TokenUsageScope aggregates, for a client and a day, how many sampled calls carried a scope
+// and how many of them reached an endpoint actually requiring that scope.
+type TokenUsageScope struct {
+	ClientID     string     `json:"client_id"`
+	Scope        string     `json:"scope"`
+	Day          time.Time  `json:"-"`
+	GrantedCalls int64      `json:"granted_calls"`
+	UsedCalls    int64      `json:"used_calls"`
+	LastUsedAt   *time.Time `json:"last_used_at"` // Nullable
+}
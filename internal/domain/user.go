@@ -4,14 +4,19 @@ import "time"
 
 // User represents a user domain.
 type User struct {
-	ID           string    `json:"id"`
-	Username     string    `json:"username"`
-	Password     string    `json:"-"`
-	FullName     *string   `json:"full_name"` // Nullable
-	RefreshToken *string   `json:"-"`         // Nullable
-	IsActive     bool      `json:"-"`
-	CreatedAt    time.Time `json:"-"`
-	UpdatedAt    time.Time `json:"-"`
+	ID            string     `json:"id"`
+	Username      string     `json:"username"`
+	Password      string     `json:"-" audit:"masked"`
+	FullName      *string    `json:"full_name"`            // Nullable
+	Email         *string    `json:"email" audit:"masked"` // Nullable
+	VerifiedEmail *string    `json:"-" audit:"masked"`     // Nullable, the email address confirmed by the user
+	Phone         *string    `json:"phone" audit:"masked"` // Nullable, E.164
+	RefreshToken  *string    `json:"-" audit:"masked"`     // Nullable
+	IsActive      bool       `json:"-"`
+	MaxSessions   *int       `json:"-"`              // Nullable
+	CompromisedAt *time.Time `json:"compromised_at"` // Nullable, set once a rotated refresh token was used again
+	CreatedAt     time.Time  `json:"-" audit:"-"`
+	UpdatedAt     time.Time  `json:"-" audit:"-"`
 }
 
 // GetID returns the user ID.
@@ -28,3 +33,39 @@ func (u User) GetUsername() string {
 func (u User) GetPassword() string {
 	return u.Password
 }
+
+// ResourceType returns the type of the user in the hypermedia responses.
+func (u User) ResourceType() string {
+	return "users"
+}
+
+// ResourceID returns the ID of the user in the hypermedia responses.
+func (u User) ResourceID() string {
+	return u.ID
+}
+
+// GetMaxSessions returns the maximum number of concurrent sessions of the user, nil means the default applies.
+func (u User) GetMaxSessions() *int {
+	return u.MaxSessions
+}
+
+// GetEmail returns the email address of the user, empty when the user has none.
+func (u User) GetEmail() string {
+	if u.Email == nil {
+		return ""
+	}
+	return *u.Email
+}
+
+// IsEmailVerified tells whether the user confirmed its current email address, a changed address is unverified.
+func (u User) IsEmailVerified() bool {
+	return u.Email != nil && u.VerifiedEmail != nil && *u.Email == *u.VerifiedEmail
+}
+
+// GetPhone returns the E.164 phone number of the user, empty when the user has none.
+func (u User) GetPhone() string {
+	if u.Phone == nil {
+		return ""
+	}
+	return *u.Phone
+}
@@ -0,0 +1,21 @@
+package domain
+
+import "time"
+
+// Social login providers
+const (
+	ProviderGoogle = "google"
+	ProviderGitHub = "github"
+	ProviderOIDC   = "oidc"
+)
+
+// UserIdentity links the account of a user at a social login provider to the local user, so that the user logs in
+// with the provider.
+type UserIdentity struct {
+	Provider    string    `json:"provider" bun:",pk"`
+	Subject     string    `json:"subject" bun:",pk"` // id of the account at the provider
+	UserID      string    `json:"user_id"`
+	Email       *string   `json:"email"` // Nullable, verified by the provider when linked
+	CreatedAt   time.Time `json:"created_at"`
+	LastLoginAt time.Time `json:"last_login_at"`
+}
@@ -0,0 +1,61 @@
+package domain
+
+import "time"
+
+// Actions of a user sync change
+const (
+	UserSyncActionCreate     = "create"     // the user is missing and is created
+	UserSyncActionUpdate     = "update"     // the fields of the linked user differ from the source
+	UserSyncActionDeactivate = "deactivate" // the linked user is not in the source anymore
+	UserSyncActionSkip       = "skip"       // the record of the source cannot be applied, see the reason
+)
+
+// Statuses of a user sync run
+const (
+	UserSyncRunSucceeded = "succeeded"
+	UserSyncRunFailed    = "failed"
+)
+
+// UserSyncLink links a user to its identity in an external source, e.g. the employee ID of the HR system.
+// Only the linked users are updated and deactivated by the sync of the source.
+type UserSyncLink struct {
+	Source     string    `json:"source" bun:",pk"`
+	ExternalID string    `json:"external_id" bun:",pk"`
+	UserID     string    `json:"user_id"`
+	SyncedAt   time.Time `json:"synced_at"`
+}
+
+// UserSyncRun is the history of a sync of the users from an external source.
+// The changes of a dry run are only computed, they are not applied.
+type UserSyncRun struct {
+	ID          string           `json:"id" bun:",pk"`
+	Source      string           `json:"source"`
+	DryRun      bool             `json:"dry_run"`
+	Status      string           `json:"status"`
+	Created     int              `json:"created"`
+	Updated     int              `json:"updated"`
+	Deactivated int              `json:"deactivated"`
+	Skipped     int              `json:"skipped"`
+	Unchanged   int              `json:"unchanged"`
+	Changes     []UserSyncChange `json:"changes,omitempty" bun:"type:json"`
+	Error       *string          `json:"error,omitempty"` // Nullable, why the run failed
+	StartedAt   time.Time        `json:"started_at"`
+	FinishedAt  time.Time        `json:"finished_at"`
+}
+
+// UserSyncChange is the diff of one user in a sync run
+type UserSyncChange struct {
+	Action     string                `json:"action"`
+	ExternalID string                `json:"external_id"`
+	UserID     string                `json:"user_id,omitempty"`
+	Username   string                `json:"username,omitempty"`
+	Fields     []UserSyncFieldChange `json:"fields,omitempty"`
+	Reason     string                `json:"reason,omitempty"` // why the record was skipped
+}
+
+// UserSyncFieldChange is the diff of one field of a user
+type UserSyncFieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
@@ -0,0 +1,139 @@
+package elevation
+
+import (
+	"go-hex/configs"
+	"go-hex/middleware"
+	"go-hex/shared/ierr"
+	"go-hex/shared/response"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+// RegisterAPI registers a new elevation api
+func RegisterAPI(r echo.Group, cfg *configs.Config, service ServicePort) {
+	handler := handler{cfg, service}
+
+	elevations := r.Group("/elevations", middleware.MustLoggedIn(cfg.JWTKeys()))
+	elevations.POST("", handler.request)
+	elevations.GET("", handler.list)
+	elevations.GET("/pending", handler.listPending)
+	elevations.POST("/:id/decision", handler.decide)
+}
+
+type handler struct {
+	cfg     *configs.Config
+	service ServicePort
+}
+
+// request godoc
+// @Router /elevations [post]
+// @Tags Elevation
+// @Summary Request a sensitive role
+// @Description Request one of the sensitive roles for a bounded time, the approvers are notified and the role is granted to the access tokens issued once the request is approved
+// @Accept json
+// @Produce json
+// @Security BearerToken
+// @Param payload body RequestElevation true " "
+// @Success 201 {object} response.Response{data=domain.Elevation} "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) request(c echo.Context) error {
+	var req RequestElevation
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.Request(c.Request().Context(), req)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessCreated(c, res, "elevation requested")
+}
+
+// list godoc
+// @Router /elevations [get]
+// @Tags Elevation
+// @Summary List my elevations
+// @Description List the elevations requested by the logged in user, the newest first
+// @Accept json
+// @Produce json
+// @Security BearerToken
+// @Success 200 {object} response.Response{data=[]domain.Elevation} "Success"
+// @failure 401 {object} response.ErrorResponse401
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) list(c echo.Context) error {
+	res, err := h.service.List(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessOK(c, res)
+}
+
+// listPending godoc
+// @Router /elevations/pending [get]
+// @Tags Elevation
+// @Summary List the pending elevations
+// @Description List the pending requests of the other users the logged in approver can decide on, the oldest first
+// @Accept json
+// @Produce json
+// @Security BearerToken
+// @Success 200 {object} response.Response{data=[]domain.Elevation} "Success"
+// @failure 401 {object} response.ErrorResponse401
+// @failure 403 {object} response.ErrorResponse403
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) listPending(c echo.Context) error {
+	res, err := h.service.ListPending(c.Request().Context())
+	if err != nil {
+		if errors.Cause(err) == ierr.ErrForbidden {
+			return response.ErrForbidden(err)
+		}
+		return err
+	}
+
+	return response.SuccessOK(c, res)
+}
+
+// decide godoc
+// @Router /elevations/{id}/decision [post]
+// @Tags Elevation
+// @Summary Approve or deny an elevation
+// @Description Approve or deny the pending request of another user, an approved request grants its role from now on for the requested duration
+// @Accept json
+// @Produce json
+// @Security BearerToken
+// @Param id path string true "elevation id"
+// @Param payload body RequestDecideElevation false " "
+// @Success 200 {object} response.Response{data=domain.Elevation} "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 403 {object} response.ErrorResponse403
+// @failure 404 {object} response.ErrorResponse404
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) decide(c echo.Context) error {
+	var req RequestDecideElevation
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.Decide(c.Request().Context(), req)
+	if err != nil {
+		switch errors.Cause(err) {
+		case ierr.ErrElevationNotPending:
+			return response.ErrBadRequest(err)
+		case ierr.ErrForbidden:
+			return response.ErrForbidden(err)
+		case ierr.ErrResourceNotFound:
+			return response.ErrNotFound(err)
+		}
+		return err
+	}
+
+	if req.Approve {
+		return response.SuccessOK(c, res, "elevation approved")
+	}
+	return response.SuccessOK(c, res, "elevation denied")
+}
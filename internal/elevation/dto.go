@@ -0,0 +1,34 @@
+package elevation
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// RequestElevation request body
+type RequestElevation struct {
+	Role     string `json:"role" example:"analytics:read"`
+	Reason   string `json:"reason" example:"investigating the incident INC-1234"`
+	Duration int    `json:"duration" example:"30"` // in minutes
+}
+
+func (r *RequestElevation) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Role, validation.Required, validation.Length(1, 100)),
+		validation.Field(&r.Reason, validation.Required, validation.Length(10, 255)),
+		validation.Field(&r.Duration, validation.Required, validation.Min(1)),
+	)
+}
+
+// RequestDecideElevation request body
+type RequestDecideElevation struct {
+	ID      string `json:"-" param:"id"`
+	Approve bool   `json:"approve" example:"true"`
+	Comment string `json:"comment" example:"approved for INC-1234"`
+}
+
+func (r *RequestDecideElevation) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.ID, validation.Required),
+		validation.Field(&r.Comment, validation.Length(0, 255)),
+	)
+}
@@ -0,0 +1,18 @@
+package elevation
+
+import (
+	"context"
+	"go-hex/internal/domain"
+)
+
+// ServicePort encapsulates the elevation logic.
+type ServicePort interface {
+	// Request asks the approvers to grant a sensitive role to the logged in user
+	Request(ctx context.Context, req RequestElevation) (domain.Elevation, error)
+	// List returns the elevations requested by the logged in user
+	List(ctx context.Context) ([]domain.Elevation, error)
+	// ListPending returns the requests the logged in approver can decide on
+	ListPending(ctx context.Context) ([]domain.Elevation, error)
+	// Decide approves or denies a pending request as the logged in approver
+	Decide(ctx context.Context, req RequestDecideElevation) (domain.Elevation, error)
+}
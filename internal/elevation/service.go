@@ -0,0 +1,288 @@
+package elevation
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/catalog"
+	"go-hex/internal/domain"
+	"go-hex/internal/notification"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/auth"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/times"
+	"go-hex/pkg/utils"
+	"go-hex/shared/ierr"
+	"strconv"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pkg/errors"
+)
+
+// Service encapsulates the elevation logic. A user requests one of the sensitive roles for a bounded time,
+// the approvers are notified and one of them other than the user approves or denies the request. The roles
+// of the approved elevations are issued in the access tokens of the user until the elevations end, and every
+// step is logged and published on the event bus so that it reaches the security events.
+type Service struct {
+	cfg         *configs.Config
+	repoRegitry port.RepositoryRegistry
+	log         logger.Logger
+	events      event.Bus
+	notifier    *notification.Dispatcher
+}
+
+// NewService creates and returns a new elevation service
+func NewService(cfg *configs.Config, repoRegitry port.RepositoryRegistry, log logger.Logger, events event.Bus, notifier *notification.Dispatcher) *Service {
+	return &Service{cfg, repoRegitry, log, events, notifier}
+}
+
+// Request asks the approvers to grant a sensitive role to the logged in user
+func (s *Service) Request(ctx context.Context, req RequestElevation) (domain.Elevation, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return domain.Elevation{}, err
+	}
+
+	user := auth.GetLoggedInUser(ctx)
+	approvers := s.approvers(user.ID)
+	errs := validation.Errors{}
+	switch {
+	case !s.isSensitive(req.Role):
+		errs["role"] = errors.New("cannot be requested")
+	case len(approvers) == 0:
+		errs["role"] = errors.New("has no approver besides the requester")
+	}
+	if req.Duration > s.cfg.Elevation.MaxDuration {
+		errs["duration"] = errors.Errorf("must be no greater than %d", s.cfg.Elevation.MaxDuration)
+	}
+	if err := errs.Filter(); err != nil {
+		return domain.Elevation{}, err
+	}
+
+	now := times.Now()
+	elevation := domain.Elevation{
+		ID:          utils.GenerateID(),
+		UserID:      user.ID,
+		Role:        req.Role,
+		Reason:      req.Reason,
+		Duration:    req.Duration,
+		Status:      domain.ElevationStatusPending,
+		RequestedAt: now,
+		ExpiresAt:   now.Add(time.Duration(s.cfg.Elevation.RequestTimeout) * time.Second),
+	}
+	err = s.repoRegitry.GetElevationRepository().Create(ctx, elevation)
+	if err != nil {
+		return domain.Elevation{}, err
+	}
+
+	s.publish(ctx, domain.EventElevationRequested, user.ID, elevation)
+
+	// the request stays listed to the approvers when some of them cannot be notified
+	for _, approverID := range approvers {
+		s.notify(ctx, approverID, notification.Message{
+			Title: "Approve the elevation of " + user.Username,
+			Body:  user.Username + " requests the " + elevation.Role + " role.",
+			Data: map[string]string{
+				"type":         "elevation_request",
+				"elevation_id": elevation.ID,
+			},
+			Template: catalog.MessageElevationRequest,
+			Variables: map[string]string{
+				"AppName":   configs.FromContext(ctx, s.cfg).Server.NAME,
+				"Username":  user.Username,
+				"Role":      elevation.Role,
+				"Reason":    elevation.Reason,
+				"Duration":  strconv.Itoa(elevation.Duration),
+				"ExpiresAt": elevation.ExpiresAt.Format(time.RFC3339),
+			},
+		})
+	}
+
+	return elevation, nil
+}
+
+// List returns the elevations requested by the logged in user, the newest first
+func (s *Service) List(ctx context.Context) ([]domain.Elevation, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	user := auth.GetLoggedInUser(ctx)
+	return s.repoRegitry.GetElevationRepository().ListByUserID(ctx, user.ID)
+}
+
+// ListPending returns the pending requests the logged in approver can decide on, the oldest first
+func (s *Service) ListPending(ctx context.Context) ([]domain.Elevation, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	user := auth.GetLoggedInUser(ctx)
+	if !s.isApprover(user.ID) {
+		return nil, ierr.ErrForbidden
+	}
+
+	elevations, err := s.repoRegitry.GetElevationRepository().ListPending(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]domain.Elevation, 0, len(elevations))
+	for _, elevation := range elevations {
+		if elevation.UserID != user.ID {
+			res = append(res, elevation)
+		}
+	}
+	return res, nil
+}
+
+// Decide approves or denies a pending request as the logged in approver, the approvers cannot decide
+// on their own requests. An approved request grants its role from now on for the requested duration.
+func (s *Service) Decide(ctx context.Context, req RequestDecideElevation) (domain.Elevation, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return domain.Elevation{}, err
+	}
+
+	user := auth.GetLoggedInUser(ctx)
+	if !s.isApprover(user.ID) {
+		return domain.Elevation{}, ierr.ErrForbidden
+	}
+
+	repoElevation := s.repoRegitry.GetElevationRepository()
+	elevation, err := repoElevation.GetByID(ctx, req.ID)
+	if err != nil {
+		return domain.Elevation{}, err
+	}
+	if elevation.UserID == user.ID {
+		return domain.Elevation{}, ierr.ErrForbidden
+	}
+
+	now := times.Now()
+	if elevation.Status != domain.ElevationStatusPending || elevation.IsExpired(now) {
+		return domain.Elevation{}, ierr.ErrElevationNotPending
+	}
+
+	elevation.Status = domain.ElevationStatusDenied
+	elevation.DecidedBy = user.ID
+	elevation.DecidedAt = &now
+	elevation.Comment = req.Comment
+	eventName := domain.EventElevationDenied
+	if req.Approve {
+		// the maximum duration may have been lowered since the request
+		duration := elevation.Duration
+		if duration > s.cfg.Elevation.MaxDuration {
+			duration = s.cfg.Elevation.MaxDuration
+		}
+		endsAt := now.Add(time.Duration(duration) * time.Minute)
+		elevation.Status = domain.ElevationStatusApproved
+		elevation.EndsAt = &endsAt
+		eventName = domain.EventElevationApproved
+	}
+
+	ok, err := repoElevation.Decide(ctx, elevation)
+	if err != nil {
+		return domain.Elevation{}, err
+	}
+	if !ok {
+		return domain.Elevation{}, ierr.ErrElevationNotPending
+	}
+
+	s.publish(ctx, eventName, user.ID, elevation)
+
+	endsAt := ""
+	if elevation.EndsAt != nil {
+		endsAt = elevation.EndsAt.Format(time.RFC3339)
+	}
+	s.notify(ctx, elevation.UserID, notification.Message{
+		Title: "Elevation " + elevation.Status,
+		Body:  "Your request for the " + elevation.Role + " role has been " + elevation.Status + ".",
+		Data: map[string]string{
+			"type":         "elevation_decision",
+			"elevation_id": elevation.ID,
+		},
+		Template: catalog.MessageElevationDecision,
+		Variables: map[string]string{
+			"AppName": configs.FromContext(ctx, s.cfg).Server.NAME,
+			"Role":    elevation.Role,
+			"Status":  elevation.Status,
+			"Comment": elevation.Comment,
+			"EndsAt":  endsAt,
+		},
+	})
+
+	return elevation, nil
+}
+
+// approvers returns the approvers who can decide on the requests of the user
+func (s *Service) approvers(userID string) []string {
+	approvers := []string{}
+	for _, approverID := range s.cfg.Elevation.ApproverIDs {
+		if approverID != userID {
+			approvers = append(approvers, approverID)
+		}
+	}
+	return approvers
+}
+
+// isApprover checks whether the user can decide on the requests of the other users
+func (s *Service) isApprover(userID string) bool {
+	for _, approverID := range s.cfg.Elevation.ApproverIDs {
+		if approverID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// isSensitive checks whether the role can be requested
+func (s *Service) isSensitive(role string) bool {
+	for _, sensitive := range s.cfg.Elevation.Roles {
+		if sensitive == role {
+			return true
+		}
+	}
+	return false
+}
+
+// notify pushes the message to the user, a failure is logged without failing the step
+func (s *Service) notify(ctx context.Context, userID string, msg notification.Message) {
+	msg.UserID = userID
+	err := s.notifier.Send(ctx, notification.ChannelPush, msg)
+	if err != nil {
+		s.log.WithParams(logger.Params{"type": "elevation", "user_id": userID, "error": err.Error()}).Warn("elevation notification failed")
+	}
+}
+
+// publish logs the step of an elevation and publishes it on the event bus
+func (s *Service) publish(ctx context.Context, name string, actorID string, elevation domain.Elevation) {
+	s.log.WithParams(logger.Params{"type": "elevation", "event": name, "actor_id": actorID, "elevation": elevation}).Warn("elevation changed")
+	attributes := map[string]interface{}{
+		"elevation_id": elevation.ID,
+		"role":         elevation.Role,
+		"reason":       elevation.Reason,
+		"duration":     elevation.Duration,
+	}
+	if elevation.Comment != "" {
+		attributes["comment"] = elevation.Comment
+	}
+	if elevation.EndsAt != nil {
+		attributes["ends_at"] = elevation.EndsAt.Format(time.RFC3339)
+	}
+	s.events.Publish(ctx, event.Event{
+		Name:       name,
+		ActorID:    actorID,
+		SubjectID:  elevation.UserID,
+		Attributes: attributes,
+	})
+}
@@ -0,0 +1,151 @@
+package elevation
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/notification"
+	"go-hex/internal/repository/memory"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/shared/ctxutil"
+	"go-hex/shared/ierr"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeNotifier struct {
+	sent []notification.Message
+}
+
+func (n *fakeNotifier) Channel() notification.Channel {
+	return notification.ChannelPush
+}
+
+func (n *fakeNotifier) Notify(ctx context.Context, msg notification.Message) error {
+	n.sent = append(n.sent, msg)
+	return nil
+}
+
+func loggedIn(userID string) context.Context {
+	token := &jwt.Token{Claims: jwt.MapClaims{"id": userID, "username": userID}}
+	return ctxutil.WithPrincipal(context.Background(), token)
+}
+
+func newTestService() (*Service, *memory.Store, *fakeNotifier, *[]event.Event) {
+	cfg := &configs.Config{}
+	cfg.Elevation.Roles = []string{"analytics:read"}
+	cfg.Elevation.ApproverIDs = []string{"alice", "bob"}
+	cfg.Elevation.MaxDuration = 60
+	cfg.Elevation.RequestTimeout = 3600
+
+	store := memory.NewStore()
+	notifier := &fakeNotifier{}
+	events := event.New()
+	published := &[]event.Event{}
+	events.Subscribe(event.All, func(ctx context.Context, e event.Event) {
+		*published = append(*published, e)
+	})
+	return NewService(cfg, store, logger.New("test", "test"), events, notification.NewDispatcher(notifier)), store, notifier, published
+}
+
+func TestRequestValidation(t *testing.T) {
+	svc, _, _, _ := newTestService()
+
+	tests := []struct {
+		name  string
+		user  string
+		req   RequestElevation
+		field string
+	}{
+		{"role not sensitive", "carol", RequestElevation{Role: "admin", Reason: "investigating INC-1234", Duration: 30}, "role"},
+		{"duration too long", "carol", RequestElevation{Role: "analytics:read", Reason: "investigating INC-1234", Duration: 61}, "duration"},
+		{"reason too short", "carol", RequestElevation{Role: "analytics:read", Reason: "because", Duration: 30}, "reason"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.Request(loggedIn(tt.user), tt.req)
+			var errs validation.Errors
+			if assert.True(t, errors.As(err, &errs), "%v", err) {
+				assert.Contains(t, errs, tt.field)
+			}
+		})
+	}
+}
+
+func TestRequestAndApprove(t *testing.T) {
+	svc, store, notifier, published := newTestService()
+
+	elevation, err := svc.Request(loggedIn("alice"), RequestElevation{Role: "analytics:read", Reason: "investigating INC-1234", Duration: 30})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ElevationStatusPending, elevation.Status)
+
+	// only the other approver is notified
+	if assert.Len(t, notifier.sent, 1) {
+		assert.Equal(t, "bob", notifier.sent[0].UserID)
+		assert.Equal(t, elevation.ID, notifier.sent[0].Data["elevation_id"])
+	}
+
+	// the requester cannot approve their own request, nor see it among the pending ones
+	_, err = svc.Decide(loggedIn("alice"), RequestDecideElevation{ID: elevation.ID, Approve: true})
+	assert.Equal(t, ierr.ErrForbidden, err)
+	pending, err := svc.ListPending(loggedIn("alice"))
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+
+	// the users who are not approvers cannot decide
+	_, err = svc.Decide(loggedIn("carol"), RequestDecideElevation{ID: elevation.ID, Approve: true})
+	assert.Equal(t, ierr.ErrForbidden, err)
+
+	approved, err := svc.Decide(loggedIn("bob"), RequestDecideElevation{ID: elevation.ID, Approve: true, Comment: "ok"})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ElevationStatusApproved, approved.Status)
+	assert.Equal(t, "bob", approved.DecidedBy)
+	if assert.NotNil(t, approved.EndsAt) {
+		assert.WithinDuration(t, approved.DecidedAt.Add(30*time.Minute), *approved.EndsAt, time.Second)
+		assert.True(t, approved.IsActiveAt(*approved.DecidedAt))
+		assert.False(t, approved.IsActiveAt(*approved.EndsAt))
+	}
+	saved, err := store.GetElevationRepository().GetByID(context.Background(), elevation.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, approved, saved)
+
+	// the requester is notified of the decision
+	if assert.Len(t, notifier.sent, 2) {
+		assert.Equal(t, "alice", notifier.sent[1].UserID)
+	}
+
+	// a decided request cannot be decided again
+	_, err = svc.Decide(loggedIn("bob"), RequestDecideElevation{ID: elevation.ID, Approve: false})
+	assert.Equal(t, ierr.ErrElevationNotPending, err)
+
+	names := []string{}
+	for _, e := range *published {
+		names = append(names, e.Name)
+	}
+	assert.Equal(t, []string{domain.EventElevationRequested, domain.EventElevationApproved}, names)
+}
+
+func TestDecideExpired(t *testing.T) {
+	svc, store, _, _ := newTestService()
+
+	assert.NoError(t, store.GetElevationRepository().Create(context.Background(), domain.Elevation{
+		ID:        "e1",
+		UserID:    "carol",
+		Role:      "analytics:read",
+		Duration:  30,
+		Status:    domain.ElevationStatusPending,
+		ExpiresAt: time.Now().Add(-time.Minute),
+	}))
+
+	_, err := svc.Decide(loggedIn("bob"), RequestDecideElevation{ID: "e1", Approve: true})
+	assert.Equal(t, ierr.ErrElevationNotPending, err)
+	elevation, err := store.GetElevationRepository().GetByID(context.Background(), "e1")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ElevationStatusPending, elevation.Status)
+}
@@ -0,0 +1,334 @@
+package hosted
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/auth"
+	"go-hex/internal/domain"
+	customMiddleware "go-hex/middleware"
+	pkgauth "go-hex/pkg/auth"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/redirect"
+	"go-hex/shared/ctxutil"
+	"go-hex/shared/ierr"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/pkg/errors"
+)
+
+// csrfContextKey stores the csrf token of the request, rendered in the forms
+const csrfContextKey = "csrf"
+
+// RegisterAPI registers the hosted pages, answering 404 unless they are enabled
+func RegisterAPI(r echo.Group, cfg *configs.Config, log logger.Logger, authenticator Authenticator, brander Brander) {
+	handler := handler{cfg, log, authenticator, brander}
+
+	pages := r.Group(prefix,
+		handler.enabled,
+		handler.renderErrors,
+		middleware.CSRFWithConfig(middleware.CSRFConfig{
+			TokenLookup:    "form:csrf",
+			ContextKey:     csrfContextKey,
+			CookieName:     cookieCSRF,
+			CookiePath:     prefix,
+			CookieSecure:   cfg.Hosted.SecureCookies,
+			CookieHTTPOnly: true,
+			CookieSameSite: http.SameSiteStrictMode,
+		}),
+	)
+	pages.GET("", handler.home, handler.session)
+	pages.GET("/login", handler.loginPage)
+	pages.POST("/login", handler.login, customMiddleware.MinDuration(cfg.EnumerationMinDuration()))
+	pages.GET("/login/challenge", handler.challengePage)
+	pages.POST("/login/challenge", handler.challenge)
+	pages.GET("/device", handler.device, handler.session)
+	pages.POST("/device", handler.consent, handler.session)
+	pages.POST("/logout", handler.logout, handler.session)
+	pages.GET("/assets/:name", handler.serveAsset)
+}
+
+type handler struct {
+	cfg     *configs.Config
+	log     logger.Logger
+	auth    Authenticator
+	brander Brander
+}
+
+// enabled answers 404 when the hosted pages are disabled
+func (h handler) enabled(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if !configs.FromContext(c.Request().Context(), h.cfg).Hosted.Enabled {
+			return echo.ErrNotFound
+		}
+		return next(c)
+	}
+}
+
+// renderErrors answers the errors of the pages, e.g. a missing csrf token, with the error page
+func (h handler) renderErrors(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		err := next(c)
+		if err == nil || c.Response().Committed {
+			return err
+		}
+		return h.renderError(c, err)
+	}
+}
+
+// session signs in the user of the session cookie, the requests without a valid session are redirected to the
+// login page, back to the page once signed in
+func (h handler) session(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx, ok, err := h.signedIn(c)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			h.clearCookie(c, cookieSession)
+			returnTo := prefix
+			if c.Request().Method == http.MethodGet {
+				returnTo = c.Request().URL.RequestURI()
+			}
+			return c.Redirect(http.StatusSeeOther, prefix+"/login?return_to="+url.QueryEscape(returnTo))
+		}
+		c.SetRequest(c.Request().WithContext(ctx))
+		return next(c)
+	}
+}
+
+// signedIn returns the context of the user signed in by the session cookie, false when the cookie is missing
+// or its access token is invalid, expired or of a revoked session
+func (h handler) signedIn(c echo.Context) (context.Context, bool, error) {
+	cookie, err := c.Cookie(cookieSession)
+	if err != nil || cookie.Value == "" {
+		return nil, false, nil
+	}
+
+	ctx := c.Request().Context()
+	accessToken := cookie.Value
+	if pkgauth.IsOpaqueToken(accessToken) {
+		accessToken, err = h.auth.ResolveAccessToken(ctx, cookie.Value)
+		if errors.Cause(err) == ierr.ErrResourceNotFound {
+			return nil, false, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		ctx = ctxutil.WithOpaqueToken(ctx, cookie.Value)
+	}
+
+	token, err := h.cfg.JWTKeys().Verify(accessToken)
+	if err != nil {
+		return nil, false, nil
+	}
+	claims := token.Claims.(jwt.MapClaims)
+	tokenType, _ := claims["token_type"].(string)
+	principalType, _ := claims["principal_type"].(string)
+	sessionID, _ := claims["sid"].(string)
+	if tokenType != auth.TokenTypeAccess || principalType == domain.PrincipalTypeServiceAccount || sessionID == "" {
+		return nil, false, nil
+	}
+	active, err := h.auth.IsSessionActive(ctx, sessionID)
+	if err != nil || !active {
+		return nil, false, err
+	}
+
+	ctx = ctxutil.WithPrincipal(ctx, token)
+	return logger.WithUserID(ctx, pkgauth.GetLoggedInUser(ctx).ID), true, nil
+}
+
+func (h handler) home(c echo.Context) error {
+	username := pkgauth.GetLoggedInUser(c.Request().Context()).Username
+	return h.render(c, http.StatusOK, pageMessage, view{Title: "Signed in", Username: username, Message: "You are signed in as " + username + "."})
+}
+
+func (h handler) loginPage(c echo.Context) error {
+	return h.render(c, http.StatusOK, pageLogin, view{Title: "Sign in", ReturnTo: returnTo(c.QueryParam("return_to"))})
+}
+
+func (h handler) login(c echo.Context) error {
+	req := auth.RequestLogin{
+		Username:  c.FormValue("username"),
+		Password:  c.FormValue("password"),
+		IPAddress: c.RealIP(),
+		UserAgent: c.Request().UserAgent(),
+		Client:    customMiddleware.ClientInfo(c.Request()),
+	}
+	v := view{Title: "Sign in", ReturnTo: returnTo(c.FormValue("return_to")), Username: req.Username}
+
+	res, err := h.auth.Login(c.Request().Context(), req)
+	if err != nil {
+		var invalid validation.Errors
+		switch {
+		case errors.Cause(err) == ierr.ErrInvalidCreds || errors.As(err, &invalid):
+			v.Error = "Invalid username or password."
+			return h.render(c, http.StatusUnauthorized, pageLogin, v)
+		case errors.Cause(err) == ierr.ErrUserIsNotActive:
+			v.Error = "Your account is not active."
+			return h.render(c, http.StatusBadRequest, pageLogin, v)
+		case errors.Cause(err) == ierr.ErrSessionLimitReached:
+			v.Error = "You are signed in on too many devices, sign out of one of them first."
+			return h.render(c, http.StatusForbidden, pageLogin, v)
+		}
+		return err
+	}
+
+	if res.Approval != nil {
+		h.setCookie(c, cookieApproval, url.Values{
+			"id":         {res.Approval.ID},
+			"secret":     {res.Approval.ClientSecret},
+			"number":     {strconv.Itoa(res.Approval.Number)},
+			"expires_at": {res.Approval.ExpiresAt},
+		}.Encode(), approvalCookieMaxAge)
+		return c.Redirect(http.StatusSeeOther, prefix+"/login/challenge?return_to="+url.QueryEscape(v.ReturnTo))
+	}
+	return h.signIn(c, res, v.ReturnTo)
+}
+
+func (h handler) challengePage(c echo.Context) error {
+	approval, ok := approvalFromCookie(c)
+	if !ok {
+		return c.Redirect(http.StatusSeeOther, prefix+"/login")
+	}
+	return h.render(c, http.StatusOK, pageChallenge, challengeView(approval, returnTo(c.QueryParam("return_to"))))
+}
+
+func (h handler) challenge(c echo.Context) error {
+	approval, ok := approvalFromCookie(c)
+	if !ok {
+		return c.Redirect(http.StatusSeeOther, prefix+"/login")
+	}
+	returnTo := returnTo(c.FormValue("return_to"))
+
+	res, err := h.auth.ExchangeLoginApproval(c.Request().Context(), auth.RequestLoginApprovalToken{ID: approval.Get("id"), ClientSecret: approval.Get("secret")})
+	if err != nil {
+		switch errors.Cause(err) {
+		case ierr.ErrLoginApprovalPending:
+			v := challengeView(approval, returnTo)
+			v.Error = "The sign in is not approved yet."
+			return h.render(c, http.StatusBadRequest, pageChallenge, v)
+		case ierr.ErrLoginApprovalDenied:
+			h.clearCookie(c, cookieApproval)
+			return h.render(c, http.StatusForbidden, pageLogin, view{Title: "Sign in", ReturnTo: returnTo, Error: "The sign in was denied."})
+		case ierr.ErrLoginApprovalExpired, ierr.ErrResourceNotFound, ierr.ErrUserIsNotActive, ierr.ErrSessionLimitReached:
+			h.clearCookie(c, cookieApproval)
+			return h.render(c, http.StatusBadRequest, pageLogin, view{Title: "Sign in", ReturnTo: returnTo, Error: "The sign in expired, sign in again."})
+		}
+		return err
+	}
+
+	h.clearCookie(c, cookieApproval)
+	return h.signIn(c, res, returnTo)
+}
+
+func (h handler) device(c echo.Context) error {
+	userCode := strings.TrimSpace(c.QueryParam("user_code"))
+	if userCode == "" {
+		return h.render(c, http.StatusOK, pageDevice, view{Title: "Sign in a device"})
+	}
+	username := pkgauth.GetLoggedInUser(c.Request().Context()).Username
+	return h.render(c, http.StatusOK, pageConsent, view{Title: "Sign in a device", UserCode: strings.ToUpper(userCode), Username: username})
+}
+
+func (h handler) consent(c echo.Context) error {
+	req := auth.RequestDeviceLoginDecision{UserCode: strings.TrimSpace(c.FormValue("user_code")), Approve: c.FormValue("decision") == "approve"}
+
+	err := h.auth.DecideDeviceLogin(c.Request().Context(), req)
+	if err != nil {
+		var invalid validation.Errors
+		v := view{Title: "Sign in a device", UserCode: req.UserCode}
+		switch {
+		case errors.Cause(err) == ierr.ErrResourceNotFound || errors.As(err, &invalid):
+			v.Error = "Unknown code, check the code displayed by the device."
+			return h.render(c, http.StatusNotFound, pageDevice, v)
+		case errors.Cause(err) == ierr.ErrDeviceLoginExpired:
+			v.Error = "The code expired, start the sign in on the device again."
+			return h.render(c, http.StatusBadRequest, pageDevice, v)
+		}
+		return err
+	}
+
+	if req.Approve {
+		return h.render(c, http.StatusOK, pageMessage, view{Title: "Device signed in", Message: "The device is signed in, you can go back to it."})
+	}
+	return h.render(c, http.StatusOK, pageMessage, view{Title: "Device denied", Message: "The sign in of the device was denied."})
+}
+
+// logout signs the user out and redirects to the post_logout_redirect_uri of the client when its allowlist allows
+// it, to the login page otherwise
+func (h handler) logout(c echo.Context) error {
+	if err := h.auth.Logout(c.Request().Context()); err != nil {
+		return err
+	}
+	h.clearCookie(c, cookieSession)
+
+	location := prefix + "/login"
+	if uri := c.FormValue("post_logout_redirect_uri"); uri != "" {
+		allowed, err := configs.FromContext(c.Request().Context(), h.cfg).Redirect.Allowlist.Check(c.FormValue("client_id"), uri)
+		if err != nil {
+			h.log.With(c.Request().Context()).Warnf("post logout redirect refused: %v", err)
+		} else {
+			location = allowed
+		}
+	}
+	return c.Redirect(http.StatusSeeOther, location)
+}
+
+// signIn keeps the access token in the session cookie until it expires, then redirects back to the page
+// which required the login. The refresh token is not kept, the user signs in again once it expires.
+func (h handler) signIn(c echo.Context, res auth.ResponseLogin, returnTo string) error {
+	maxAge := 0
+	if expiresAt, err := time.Parse(time.RFC3339, res.ExpiresAt); err == nil {
+		maxAge = int(time.Until(expiresAt).Seconds())
+	}
+	h.setCookie(c, cookieSession, res.AccessToken, maxAge)
+	return c.Redirect(http.StatusSeeOther, returnTo)
+}
+
+func (h handler) setCookie(c echo.Context, name, value string, maxAge int) {
+	c.SetCookie(&http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     prefix,
+		MaxAge:   maxAge,
+		Secure:   h.cfg.Hosted.SecureCookies,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func (h handler) clearCookie(c echo.Context, name string) {
+	h.setCookie(c, name, "", -1)
+}
+
+// approvalFromCookie returns the login waiting for its approval, false without one
+func approvalFromCookie(c echo.Context) (url.Values, bool) {
+	cookie, err := c.Cookie(cookieApproval)
+	if err != nil {
+		return nil, false
+	}
+	approval, err := url.ParseQuery(cookie.Value)
+	if err != nil || approval.Get("id") == "" || approval.Get("secret") == "" {
+		return nil, false
+	}
+	return approval, true
+}
+
+func challengeView(approval url.Values, returnTo string) view {
+	number, _ := strconv.Atoi(approval.Get("number"))
+	return view{Title: "Approve the sign in", ReturnTo: returnTo, Number: number, ExpiresAt: approval.Get("expires_at")}
+}
+
+// returnTo returns the page to go back to once signed in, only the hosted pages are accepted so that the login
+// page cannot redirect elsewhere
+func returnTo(path string) string {
+	return redirect.Local(path, prefix)
+}
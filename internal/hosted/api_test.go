@@ -0,0 +1,265 @@
+package hosted
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/auth"
+	"go-hex/internal/tenant"
+	pkgauth "go-hex/pkg/auth"
+	"go-hex/pkg/logger"
+	"go-hex/shared/ierr"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAuthenticator struct {
+	Authenticator
+	cfg      *configs.Config
+	approval *auth.ResponseLoginApproval
+	approved bool
+	decided  []auth.RequestDeviceLoginDecision
+	deciders []string
+}
+
+func (a *fakeAuthenticator) tokens() auth.ResponseLogin {
+	expiresAt := time.Now().Add(15 * time.Minute)
+	token, _ := a.cfg.JWTKeys().Signer().Sign(jwt.MapClaims{
+		"id": "user-1", "username": "jane", "token_type": auth.TokenTypeAccess, "sid": "session-1", "exp": expiresAt.Unix(),
+	})
+	return auth.ResponseLogin{AccessToken: token, ExpiresAt: expiresAt.Format(time.RFC3339)}
+}
+
+func (a *fakeAuthenticator) Login(ctx context.Context, req auth.RequestLogin) (auth.ResponseLogin, error) {
+	if req.Password != "password1234" {
+		return auth.ResponseLogin{}, ierr.ErrInvalidCreds
+	}
+	if a.approval != nil {
+		return auth.ResponseLogin{Approval: a.approval}, nil
+	}
+	return a.tokens(), nil
+}
+
+func (a *fakeAuthenticator) ExchangeLoginApproval(ctx context.Context, req auth.RequestLoginApprovalToken) (auth.ResponseLogin, error) {
+	if req.ID != a.approval.ID || req.ClientSecret != a.approval.ClientSecret {
+		return auth.ResponseLogin{}, ierr.ErrResourceNotFound
+	}
+	if !a.approved {
+		return auth.ResponseLogin{}, ierr.ErrLoginApprovalPending
+	}
+	return a.tokens(), nil
+}
+
+func (a *fakeAuthenticator) DecideDeviceLogin(ctx context.Context, req auth.RequestDeviceLoginDecision) error {
+	a.decided = append(a.decided, req)
+	a.deciders = append(a.deciders, pkgauth.GetLoggedInUser(ctx).ID)
+	return nil
+}
+
+func (a *fakeAuthenticator) Logout(ctx context.Context) error {
+	return nil
+}
+
+func (a *fakeAuthenticator) IsSessionActive(ctx context.Context, sessionID string) (bool, error) {
+	return sessionID == "session-1", nil
+}
+
+type fakeBrander struct{}
+
+func (fakeBrander) Branding(ctx context.Context) tenant.ResponseBranding {
+	return tenant.ResponseBranding{ProductName: "Acme", PrimaryColor: "#1a73e8", AccentColor: "#fbbc04"}
+}
+
+// browser keeps the cookies of the hosted pages across its requests
+type browser struct {
+	t       *testing.T
+	router  *echo.Echo
+	cookies map[string]*http.Cookie
+}
+
+func newBrowser(t *testing.T, cfg *configs.Config, authenticator Authenticator) *browser {
+	router := echo.New()
+	RegisterAPI(*router.Group(""), cfg, logger.New("test", "test"), authenticator, fakeBrander{})
+	return &browser{t, router, map[string]*http.Cookie{}}
+}
+
+func (b *browser) do(method, target string, form url.Values) *httptest.ResponseRecorder {
+	var req *http.Request
+	if form != nil {
+		req = httptest.NewRequest(method, target, strings.NewReader(form.Encode()))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	} else {
+		req = httptest.NewRequest(method, target, nil)
+	}
+	for _, cookie := range b.cookies {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	b.router.ServeHTTP(rec, req)
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.MaxAge < 0 {
+			delete(b.cookies, cookie.Name)
+		} else {
+			b.cookies[cookie.Name] = cookie
+		}
+	}
+	return rec
+}
+
+var csrfField = regexp.MustCompile(`name="csrf" value="([^"]+)"`)
+
+// csrf returns the csrf token of the form of the page
+func (b *browser) csrf(rec *httptest.ResponseRecorder) string {
+	match := csrfField.FindStringSubmatch(rec.Body.String())
+	require.Len(b.t, match, 2, "the page has no form")
+	return match[1]
+}
+
+func testConfig() *configs.Config {
+	cfg := &configs.Config{}
+	cfg.JWT.SigningKey = "test-signing-key"
+	cfg.Hosted.Enabled = true
+	cfg.Hosted.CSPImageSources = []string{"https:"}
+	return cfg
+}
+
+func TestPagesDisabled(t *testing.T) {
+	cfg := testConfig()
+	cfg.Hosted.Enabled = false
+	rec := newBrowser(t, cfg, &fakeAuthenticator{cfg: cfg}).do(http.MethodGet, "/hosted/login", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestLoginAndDeviceConsent(t *testing.T) {
+	cfg := testConfig()
+	authenticator := &fakeAuthenticator{cfg: cfg}
+	b := newBrowser(t, cfg, authenticator)
+
+	// the device page requires a session, the login page returns to it
+	rec := b.do(http.MethodGet, "/hosted/device?user_code=wdjb-mjht", nil)
+	require.Equal(t, http.StatusSeeOther, rec.Code)
+	assert.Equal(t, "/hosted/login?return_to=%2Fhosted%2Fdevice%3Fuser_code%3Dwdjb-mjht", rec.Header().Get(echo.HeaderLocation))
+
+	rec = b.do(http.MethodGet, rec.Header().Get(echo.HeaderLocation), nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "<title>Sign in - Acme</title>")
+	assert.Contains(t, rec.Body.String(), "--primary: #1a73e8")
+	assert.Equal(t, "no-store", rec.Header().Get(echo.HeaderCacheControl))
+	csrf := b.csrf(rec)
+
+	// the forms require the csrf token
+	rec = b.do(http.MethodPost, "/hosted/login", url.Values{"username": {"jane"}, "password": {"password1234"}})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "missing csrf token")
+	rec = b.do(http.MethodPost, "/hosted/login", url.Values{"csrf": {"forged"}, "username": {"jane"}, "password": {"password1234"}})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = b.do(http.MethodPost, "/hosted/login", url.Values{"csrf": {csrf}, "username": {"jane"}, "password": {"wrong-password"}, "return_to": {"/hosted/device?user_code=wdjb-mjht"}})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Invalid username or password.")
+
+	rec = b.do(http.MethodPost, "/hosted/login", url.Values{"csrf": {csrf}, "username": {"jane"}, "password": {"password1234"}, "return_to": {"/hosted/device?user_code=wdjb-mjht"}})
+	require.Equal(t, http.StatusSeeOther, rec.Code)
+	assert.Equal(t, "/hosted/device?user_code=wdjb-mjht", rec.Header().Get(echo.HeaderLocation))
+	if assert.Contains(t, b.cookies, cookieSession) {
+		assert.True(t, b.cookies[cookieSession].HttpOnly)
+		assert.Equal(t, "/hosted", b.cookies[cookieSession].Path)
+	}
+
+	rec = b.do(http.MethodGet, "/hosted/device?user_code=wdjb-mjht", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "<strong>WDJB-MJHT</strong>")
+	assert.Contains(t, rec.Body.String(), "<strong>jane</strong>")
+
+	rec = b.do(http.MethodPost, "/hosted/device", url.Values{"csrf": {b.csrf(rec)}, "user_code": {"WDJB-MJHT"}, "decision": {"approve"}})
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "The device is signed in")
+	assert.Equal(t, []auth.RequestDeviceLoginDecision{{UserCode: "WDJB-MJHT", Approve: true}}, authenticator.decided)
+	assert.Equal(t, []string{"user-1"}, authenticator.deciders)
+}
+
+func TestLoginApprovalChallenge(t *testing.T) {
+	cfg := testConfig()
+	authenticator := &fakeAuthenticator{cfg: cfg, approval: &auth.ResponseLoginApproval{ID: "approval-1", ClientSecret: "secret", Number: 42, ExpiresAt: "2026-10-15T12:02:00Z"}}
+	b := newBrowser(t, cfg, authenticator)
+
+	rec := b.do(http.MethodGet, "/hosted/login", nil)
+	rec = b.do(http.MethodPost, "/hosted/login", url.Values{"csrf": {b.csrf(rec)}, "username": {"jane"}, "password": {"password1234"}})
+	require.Equal(t, http.StatusSeeOther, rec.Code)
+	assert.Equal(t, "/hosted/login/challenge?return_to=%2Fhosted", rec.Header().Get(echo.HeaderLocation))
+	assert.NotContains(t, b.cookies, cookieSession)
+
+	rec = b.do(http.MethodGet, rec.Header().Get(echo.HeaderLocation), nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `<p class="number">42</p>`)
+
+	rec = b.do(http.MethodPost, "/hosted/login/challenge", url.Values{"csrf": {b.csrf(rec)}, "return_to": {"/hosted"}})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "The sign in is not approved yet.")
+
+	authenticator.approved = true
+	rec = b.do(http.MethodPost, "/hosted/login/challenge", url.Values{"csrf": {b.csrf(rec)}, "return_to": {"/hosted"}})
+	require.Equal(t, http.StatusSeeOther, rec.Code)
+	assert.Contains(t, b.cookies, cookieSession)
+	assert.NotContains(t, b.cookies, cookieApproval)
+
+	rec = b.do(http.MethodGet, "/hosted", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "You are signed in as jane.")
+}
+
+func TestLogoutRedirect(t *testing.T) {
+	cfg := testConfig()
+	require.NoError(t, cfg.Redirect.Allowlist.Decode("web=https://app.example.com/*"))
+
+	tests := []struct {
+		name string
+		form url.Values
+		want string
+	}{
+		{"default", url.Values{}, "/hosted/login"},
+		{"allowed", url.Values{"client_id": {"web"}, "post_logout_redirect_uri": {"https://app.example.com/signed-out"}}, "https://app.example.com/signed-out"},
+		{"other client", url.Values{"client_id": {"ios"}, "post_logout_redirect_uri": {"https://app.example.com/signed-out"}}, "/hosted/login"},
+		{"not allowed", url.Values{"client_id": {"web"}, "post_logout_redirect_uri": {"https://evil.example.com"}}, "/hosted/login"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBrowser(t, cfg, &fakeAuthenticator{cfg: cfg})
+			rec := b.do(http.MethodGet, "/hosted/login", nil)
+			rec = b.do(http.MethodPost, "/hosted/login", url.Values{"csrf": {b.csrf(rec)}, "username": {"jane"}, "password": {"password1234"}})
+			require.Equal(t, http.StatusSeeOther, rec.Code)
+
+			rec = b.do(http.MethodGet, "/hosted", nil)
+			tt.form.Set("csrf", b.csrf(rec))
+			rec = b.do(http.MethodPost, "/hosted/logout", tt.form)
+			require.Equal(t, http.StatusSeeOther, rec.Code)
+			assert.Equal(t, tt.want, rec.Header().Get(echo.HeaderLocation))
+			assert.NotContains(t, b.cookies, cookieSession)
+		})
+	}
+}
+
+func TestReturnTo(t *testing.T) {
+	tests := map[string]string{
+		"":                              "/hosted",
+		"/hosted/device?user_code=WDJB": "/hosted/device?user_code=WDJB",
+		"https://evil.example.com":      "/hosted",
+		"//evil.example.com/hosted":     "/hosted",
+		"/hosted//evil.example.com":     "/hosted",
+		`/hosted/\evil.example.com`:     "/hosted",
+		"/hostedevil":                   "/hosted",
+	}
+	for path, want := range tests {
+		assert.Equal(t, want, returnTo(path), path)
+	}
+}
@@ -0,0 +1,25 @@
+package hosted
+
+const (
+	// prefix of the paths of the hosted pages, the path of their cookies
+	prefix = "/hosted"
+
+	// cookieSession holds the access token of the user signed in by the pages
+	cookieSession = "hosted_session"
+	// cookieApproval holds the id and the client secret of the login waiting for its approval
+	cookieApproval = "hosted_login_approval"
+	// cookieCSRF holds the token of the forms, submitted back in their csrf field
+	cookieCSRF = "hosted_csrf"
+
+	// approvalCookieMaxAge bounds the login approval cookie, the approval expiring before
+	approvalCookieMaxAge = 3600 // in seconds
+)
+
+// Pages, rendered in the layout from templates/<page>.html
+const (
+	pageLogin     = "login"
+	pageChallenge = "challenge"
+	pageDevice    = "device"
+	pageConsent   = "consent"
+	pageMessage   = "message"
+)
@@ -0,0 +1,158 @@
+package hosted
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha512"
+	"embed"
+	"encoding/base64"
+	"go-hex/configs"
+	"go-hex/internal/tenant"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+//go:embed templates/*.html
+var templates embed.FS
+
+//go:embed assets
+var assets embed.FS
+
+// stylesheet is the stylesheet of the pages, served by serveAsset and loaded with its integrity
+var stylesheet = loadAsset("hosted.css")
+
+// asset is an embedded file served under /hosted/assets
+type asset struct {
+	name      string
+	content   []byte
+	integrity string // subresource integrity of the content
+}
+
+func loadAsset(name string) asset {
+	content, err := assets.ReadFile("assets/" + name)
+	if err != nil {
+		panic(err)
+	}
+	digest := sha512.Sum384(content)
+	return asset{name: name, content: content, integrity: "sha384-" + base64.StdEncoding.EncodeToString(digest[:])}
+}
+
+// serveAsset answers an embedded asset, cached by the browsers since the pages check its integrity
+func (h handler) serveAsset(c echo.Context) error {
+	if c.Param("name") != stylesheet.name {
+		return echo.ErrNotFound
+	}
+	header := c.Response().Header()
+	header.Set(echo.HeaderCacheControl, "public, max-age=3600")
+	header.Set(echo.HeaderXContentTypeOptions, "nosniff")
+	return c.Blob(http.StatusOK, "text/css; charset=utf-8", stylesheet.content)
+}
+
+// pages are the templates of the pages, each laid out by templates/layout.html
+var pages = parsePages(pageLogin, pageChallenge, pageDevice, pageConsent, pageMessage)
+
+func parsePages(names ...string) map[string]*template.Template {
+	res := make(map[string]*template.Template, len(names))
+	for _, name := range names {
+		res[name] = template.Must(template.ParseFS(templates, "templates/layout.html", "templates/"+name+".html"))
+	}
+	return res
+}
+
+// view is rendered by the pages, each using the fields it needs
+type view struct {
+	Title     string
+	Branding  tenant.ResponseBranding
+	CSRF      string
+	Nonce     string // of the content security policy, allowing the styles of the page
+	Integrity string // of the stylesheet
+	Error     string
+	Message   string
+
+	ReturnTo  string
+	Username  string
+	Number    int
+	ExpiresAt string
+	UserCode  string
+}
+
+// render answers the page with the status code, the view completed by the branding and the csrf token of the
+// request. Each page is served with its own nonce, the only styles it applies are the ones carrying it.
+func (h handler) render(c echo.Context, code int, page string, v view) error {
+	nonce, err := newNonce()
+	if err != nil {
+		return err
+	}
+	v.Branding = h.brander.Branding(c.Request().Context())
+	v.CSRF, _ = c.Get(csrfContextKey).(string)
+	v.Nonce, v.Integrity = nonce, stylesheet.integrity
+
+	var buf bytes.Buffer
+	if err := pages[page].ExecuteTemplate(&buf, "layout", v); err != nil {
+		return errors.Wrapf(err, "cannot render page %s", page)
+	}
+
+	header := c.Response().Header()
+	header.Set(echo.HeaderCacheControl, "no-store")
+	header.Set("X-Frame-Options", "DENY")
+	header.Set(echo.HeaderXContentTypeOptions, "nosniff")
+	header.Set(echo.HeaderReferrerPolicy, "no-referrer")
+	cfg := configs.FromContext(c.Request().Context(), h.cfg)
+	if cfg.Hosted.CSPReportOnly {
+		header.Set(echo.HeaderContentSecurityPolicyReportOnly, contentSecurityPolicy(cfg, nonce))
+	} else {
+		header.Set(echo.HeaderContentSecurityPolicy, contentSecurityPolicy(cfg, nonce))
+	}
+	return c.HTMLBlob(code, buf.Bytes())
+}
+
+// contentSecurityPolicy is the policy of a page: no script, only the styles and the stylesheet of the nonce, the
+// images of the configured sources, and the forms posted to the pages only
+func contentSecurityPolicy(cfg *configs.Config, nonce string) string {
+	imageSources := "'none'"
+	if len(cfg.Hosted.CSPImageSources) > 0 {
+		imageSources = strings.Join(cfg.Hosted.CSPImageSources, " ")
+	}
+	policy := []string{
+		"default-src 'none'",
+		"script-src 'none'",
+		"style-src 'nonce-" + nonce + "'",
+		"img-src " + imageSources,
+		"form-action 'self'",
+		"frame-ancestors 'none'",
+		"base-uri 'none'",
+	}
+	if cfg.Hosted.CSPReportURI != "" {
+		policy = append(policy, "report-uri "+cfg.Hosted.CSPReportURI)
+	}
+	return strings.Join(policy, "; ")
+}
+
+// newNonce returns a random nonce of the content security policy
+func newNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "cannot generate nonce")
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// renderError answers the error page, the message of an echo error being shown as is and the others hidden
+func (h handler) renderError(c echo.Context, err error) error {
+	code, message := http.StatusInternalServerError, "Something went wrong, please try again later."
+	if he, ok := err.(*echo.HTTPError); ok {
+		code = he.Code
+		message = http.StatusText(he.Code)
+		if text, ok := he.Message.(string); ok {
+			message = text
+		}
+	}
+	if code >= http.StatusInternalServerError {
+		h.log.With(c.Request().Context()).Error(err)
+	}
+	return h.render(c, code, pageMessage, view{Title: "Error", Error: message})
+}
@@ -0,0 +1,98 @@
+package hosted
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"go-hex/pkg/logger"
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	policyNonce   = regexp.MustCompile(`style-src 'nonce-([^']+)'`)
+	stylesheetTag = regexp.MustCompile(`<link rel="stylesheet" href="([^"]+)" integrity="([^"]+)" nonce="([^"]+)">`)
+	styleTag      = regexp.MustCompile(`<style nonce="([^"]+)">`)
+)
+
+// assertPolicy checks the headers of a rendered page and that its styles carry the nonce of its policy,
+// returning the nonce
+func assertPolicy(t *testing.T, rec *httptest.ResponseRecorder, header string) string {
+	policy := rec.Header().Get(header)
+	for _, directive := range []string{"default-src 'none'", "script-src 'none'", "img-src https:", "form-action 'self'", "frame-ancestors 'none'", "base-uri 'none'"} {
+		assert.Contains(t, policy, directive)
+	}
+	assert.NotContains(t, policy, "unsafe-inline")
+	assert.Equal(t, "nosniff", rec.Header().Get(echo.HeaderXContentTypeOptions))
+	assert.Equal(t, "no-referrer", rec.Header().Get(echo.HeaderReferrerPolicy))
+	assert.Equal(t, "DENY", rec.Header().Get("X-Frame-Options"))
+	assert.Equal(t, "no-store", rec.Header().Get(echo.HeaderCacheControl))
+
+	match := policyNonce.FindStringSubmatch(policy)
+	require.Len(t, match, 2, policy)
+	nonce := match[1]
+	body := html.UnescapeString(rec.Body.String())
+	if link := stylesheetTag.FindStringSubmatch(body); assert.Len(t, link, 4, "the page has no stylesheet") {
+		assert.Equal(t, "/hosted/assets/hosted.css", link[1])
+		assert.Equal(t, stylesheet.integrity, link[2])
+		assert.Equal(t, nonce, link[3])
+	}
+	for _, style := range styleTag.FindAllStringSubmatch(body, -1) {
+		assert.Equal(t, nonce, style[1])
+	}
+	return nonce
+}
+
+func TestPagesContentSecurityPolicy(t *testing.T) {
+	cfg := testConfig()
+	h := handler{cfg, logger.New("test", "test"), &fakeAuthenticator{cfg: cfg}, fakeBrander{}}
+
+	render := func(page func(c echo.Context) error) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		require.NoError(t, page(echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/hosted", nil), rec)))
+		return rec
+	}
+
+	nonces := map[string]bool{}
+	for name := range pages {
+		rec := render(func(c echo.Context) error { return h.render(c, http.StatusOK, name, view{Title: name}) })
+		nonces[assertPolicy(t, rec, echo.HeaderContentSecurityPolicy)] = true
+	}
+	rec := render(func(c echo.Context) error { return h.renderError(c, errors.New("failure")) })
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	nonces[assertPolicy(t, rec, echo.HeaderContentSecurityPolicy)] = true
+	assert.Len(t, nonces, len(pages)+1, "each response has its own nonce")
+
+	// the policy is only reported in report only mode
+	cfg.Hosted.CSPReportOnly = true
+	cfg.Hosted.CSPReportURI = "https://csp.example.com/report"
+	rec = render(func(c echo.Context) error { return h.render(c, http.StatusOK, pageLogin, view{}) })
+	assert.Empty(t, rec.Header().Get(echo.HeaderContentSecurityPolicy))
+	assertPolicy(t, rec, echo.HeaderContentSecurityPolicyReportOnly)
+	assert.Contains(t, rec.Header().Get(echo.HeaderContentSecurityPolicyReportOnly), "; report-uri https://csp.example.com/report")
+}
+
+func TestPagesStylesheet(t *testing.T) {
+	cfg := testConfig()
+	b := newBrowser(t, cfg, &fakeAuthenticator{cfg: cfg})
+
+	first := assertPolicy(t, b.do(http.MethodGet, "/hosted/login", nil), echo.HeaderContentSecurityPolicy)
+	second := assertPolicy(t, b.do(http.MethodGet, "/hosted/login", nil), echo.HeaderContentSecurityPolicy)
+	assert.NotEqual(t, first, second)
+
+	// the integrity of the pages is the one of the stylesheet served
+	rec := b.do(http.MethodGet, "/hosted/assets/hosted.css", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/css; charset=utf-8", rec.Header().Get(echo.HeaderContentType))
+	digest := sha512.Sum384(rec.Body.Bytes())
+	assert.Equal(t, "sha384-"+base64.StdEncoding.EncodeToString(digest[:]), stylesheet.integrity)
+
+	assert.Equal(t, http.StatusNotFound, b.do(http.MethodGet, "/hosted/assets/unknown.js", nil).Code)
+}
@@ -0,0 +1,22 @@
+package hosted
+
+import (
+	"context"
+	"go-hex/internal/auth"
+	"go-hex/internal/tenant"
+)
+
+// Authenticator signs in the users of the pages and decides their device logins, see auth.ServicePort.
+type Authenticator interface {
+	Login(ctx context.Context, req auth.RequestLogin) (auth.ResponseLogin, error)
+	ExchangeLoginApproval(ctx context.Context, req auth.RequestLoginApprovalToken) (auth.ResponseLogin, error)
+	DecideDeviceLogin(ctx context.Context, req auth.RequestDeviceLoginDecision) error
+	Logout(ctx context.Context) error
+	IsSessionActive(ctx context.Context, sessionID string) (bool, error)
+	ResolveAccessToken(ctx context.Context, opaqueToken string) (string, error)
+}
+
+// Brander reads the branding of the tenant of the request, see tenant.ServicePort.
+type Brander interface {
+	Branding(ctx context.Context) tenant.ResponseBranding
+}
@@ -0,0 +1,189 @@
+// Package identityview maintains the identity views read to issue the tokens: the view of a user is built from
+// the repositories at its first read, then read at once until a write changing it invalidates it or it is too stale.
+package identityview
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/metrics"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/times"
+	"go-hex/shared/ierr"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	viewReads = metrics.NewCounterVec(prometheus.CounterOpts{
+		Name: "identity_view_reads_total",
+		Help: "Number of reads of the identity views, by result (hit, miss or stale when built from the repositories, or error when the store failed).",
+	}, "result")
+	viewInvalidations = metrics.NewCounterVec(prometheus.CounterOpts{
+		Name: "identity_view_invalidations_total",
+		Help: "Number of identity views invalidated, by event.",
+	}, "event")
+)
+
+// invalidatingEvents are the events of the writes changing the identity view of their subject user
+var invalidatingEvents = []string{
+	domain.EventElevationApproved,
+	domain.EventBreakGlassSealed,
+	domain.EventBreakGlassActivated,
+	domain.EventBreakGlassRevoked,
+	domain.EventRoleAssigned,
+	domain.EventRoleUnassigned,
+	domain.EventRoleExpired,
+}
+
+// Service reads the identity views, building them from the repositories when they are not stored
+type Service struct {
+	repoRegitry  port.RepositoryRegistry
+	views        port.IdentityViewRepository
+	maxStaleness time.Duration
+	defaultRoles []string
+	log          logger.Logger
+}
+
+// NewService creates a service storing the views built from repoRegitry in views, and reading them until they
+// are older than maxStaleness. Without views every read builds the view. The default roles are granted to every user.
+func NewService(repoRegitry port.RepositoryRegistry, views port.IdentityViewRepository, maxStaleness time.Duration, defaultRoles []string, log logger.Logger) *Service {
+	return &Service{repoRegitry, views, maxStaleness, defaultRoles, log}
+}
+
+// Get returns the identity view of the user. A view not stored or older than the max staleness is built from the
+// repositories and stored; a store failing is logged and the view built, so that the tokens are still issued.
+func (s *Service) Get(ctx context.Context, userID string) (domain.IdentityView, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	if s.views != nil {
+		view, err := s.views.Get(ctx, userID)
+		switch {
+		case err == nil && times.Now().Sub(view.BuiltAt) < s.maxStaleness:
+			viewReads.WithLabelValues("hit").Inc()
+			return view, nil
+		case err == nil:
+			viewReads.WithLabelValues("stale").Inc()
+		case err == ierr.ErrResourceNotFound:
+			viewReads.WithLabelValues("miss").Inc()
+		default:
+			viewReads.WithLabelValues("error").Inc()
+			s.log.With(ctx).WithParam("user_id", userID).Error(errors.Wrap(err, "cannot read identity view"))
+		}
+	}
+
+	view, err := s.Build(ctx, userID)
+	if err != nil {
+		return view, err
+	}
+	if s.views != nil {
+		if err := s.views.Put(ctx, view); err != nil {
+			s.log.With(ctx).WithParam("user_id", userID).Error(errors.Wrap(err, "cannot store identity view"))
+		}
+	}
+	return view, nil
+}
+
+// Build builds the identity view of the user from the repositories
+func (s *Service) Build(ctx context.Context, userID string) (domain.IdentityView, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	now := times.Now()
+	view := domain.IdentityView{UserID: userID, BuiltAt: now}
+	elevations, err := s.repoRegitry.GetElevationRepository().ListActiveByUserID(ctx, userID, now)
+	if err != nil {
+		return view, err
+	}
+	view.Elevations = elevations
+
+	// the roles are read with their permissions, the default roles are granted without being assigned. The time-boxed
+	// roles are kept with their window until it ended, so that the view grants them within it only.
+	roles := s.repoRegitry.GetRoleRepository()
+	assignments, err := roles.ListAssignments(ctx, userID)
+	if err != nil {
+		return view, err
+	}
+	names := append([]string{}, s.defaultRoles...)
+	view.RoleWindows = []domain.UserRole{}
+	for _, assignment := range assignments {
+		if assignment.EndsAt != nil && !now.Before(*assignment.EndsAt) {
+			continue
+		}
+		names = append(names, assignment.Role)
+		if assignment.IsTimeBoxed() && !contains(s.defaultRoles, assignment.Role) {
+			view.RoleWindows = append(view.RoleWindows, assignment)
+		}
+	}
+	assigned := append([]string{}, names...)
+	for _, elevation := range elevations {
+		names = append(names, elevation.Role)
+	}
+	defined, err := roles.ListByNames(ctx, names)
+	if err != nil {
+		return view, err
+	}
+	view.Roles = []domain.Role{}
+	view.ElevatedRoles = []domain.Role{}
+	for _, role := range defined {
+		if contains(assigned, role.Name) {
+			view.Roles = append(view.Roles, role)
+		}
+		if containsElevation(elevations, role.Name) {
+			view.ElevatedRoles = append(view.ElevatedRoles, role)
+		}
+	}
+
+	account, err := s.repoRegitry.GetBreakGlassAccountRepository().GetByUserID(ctx, userID)
+	if err != nil && err != ierr.ErrResourceNotFound {
+		return view, err
+	}
+	if err == nil {
+		view.BreakGlass = &account
+	}
+	return view, nil
+}
+
+// Subscribe subscribes the service to the events invalidating the identity views
+func (s *Service) Subscribe(events event.Bus) {
+	for _, name := range invalidatingEvents {
+		events.Subscribe(name, s.Handle)
+	}
+}
+
+// Handle invalidates the identity view of the subject user of the event
+func (s *Service) Handle(ctx context.Context, e event.Event) {
+	if s.views == nil || e.SubjectID == "" {
+		return
+	}
+	if err := s.views.Invalidate(ctx, e.SubjectID); err != nil {
+		s.log.With(ctx).WithParams(logger.Params{"user_id": e.SubjectID, "event": e.Name}).Error(errors.Wrap(err, "cannot invalidate identity view"))
+		return
+	}
+	viewInvalidations.WithLabelValues(e.Name).Inc()
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsElevation(elevations []domain.Elevation, role string) bool {
+	for _, elevation := range elevations {
+		if elevation.Role == role {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,164 @@
+package identityview
+
+import (
+	"context"
+	"errors"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/memory"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingViews fails every operation, e.g. when Redis is down
+type failingViews struct{}
+
+func (failingViews) Get(ctx context.Context, userID string) (domain.IdentityView, error) {
+	return domain.IdentityView{}, errors.New("connection refused")
+}
+
+func (failingViews) Put(ctx context.Context, view domain.IdentityView) error {
+	return errors.New("connection refused")
+}
+
+func (failingViews) Invalidate(ctx context.Context, userID string) error {
+	return errors.New("connection refused")
+}
+
+// newStore returns a store where u1 is assigned the support role and elevated to admin and u2 is an active
+// break-glass account, counting the reads of the elevations
+func newStore(t *testing.T, reads *int) *memory.Store {
+	t.Helper()
+	ctx := context.Background()
+	endsAt := time.Now().Add(time.Hour)
+	expiresAt := time.Now().Add(30 * time.Minute)
+	store := memory.NewStore()
+	store.PutRole(domain.Role{Name: "support", Permissions: []string{"profile:read", "users:read"}})
+	require.NoError(t, store.GetRoleRepository().Assign(ctx, domain.UserRole{UserID: "u1", Role: "support"}))
+	require.NoError(t, store.GetElevationRepository().Create(ctx, domain.Elevation{ID: "e1", UserID: "u1", Role: "admin", Status: domain.ElevationStatusApproved, EndsAt: &endsAt}))
+	require.NoError(t, store.GetBreakGlassAccountRepository().Seal(ctx, domain.BreakGlassAccount{UserID: "u2", Status: domain.BreakGlassStatusActive, ExpiresAt: &expiresAt}))
+	store.SetHook(func(ctx context.Context, op string) error {
+		if op == "ElevationRepository.ListActiveByUserID" {
+			*reads++
+		}
+		return nil
+	})
+	return store
+}
+
+func TestGetReadsThroughAndInvalidates(t *testing.T) {
+	reads := 0
+	events := event.New()
+	svc := NewService(newStore(t, &reads), memory.NewIdentityViewRepository(time.Minute), time.Minute, nil, logger.New("test", "test"))
+	svc.Subscribe(events)
+	ctx := context.Background()
+
+	view, err := svc.Get(ctx, "u1")
+	require.NoError(t, err)
+	assert.Len(t, view.ActiveElevations(time.Now()), 1)
+	assert.Nil(t, view.BreakGlass)
+	_, err = svc.Get(ctx, "u1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, reads, "the second read is served by the view")
+
+	view, err = svc.Get(ctx, "u2")
+	require.NoError(t, err)
+	if assert.NotNil(t, view.BreakGlass) {
+		assert.True(t, view.BreakGlass.IsActiveAt(time.Now()))
+	}
+
+	// a write changing the roles of the user invalidates its view
+	events.Publish(ctx, event.Event{Name: domain.EventElevationApproved, ActorID: "approver", SubjectID: "u1"})
+	_, err = svc.Get(ctx, "u1")
+	require.NoError(t, err)
+	assert.Equal(t, 3, reads)
+
+	// the other events leave the views
+	events.Publish(ctx, event.Event{Name: domain.EventLoginSucceeded, ActorID: "u1", SubjectID: "u1"})
+	_, err = svc.Get(ctx, "u1")
+	require.NoError(t, err)
+	assert.Equal(t, 3, reads)
+}
+
+func TestGetRebuildsStaleViews(t *testing.T) {
+	reads := 0
+	views := memory.NewIdentityViewRepository(time.Hour)
+	svc := NewService(newStore(t, &reads), views, time.Minute, nil, logger.New("test", "test"))
+	ctx := context.Background()
+
+	require.NoError(t, views.Put(ctx, domain.IdentityView{UserID: "u1", BuiltAt: time.Now().Add(-2 * time.Minute)}))
+	view, err := svc.Get(ctx, "u1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, reads)
+	assert.Len(t, view.Elevations, 1)
+	assert.WithinDuration(t, time.Now(), view.BuiltAt, time.Second)
+}
+
+func TestGetBuildsWhenTheStoreFails(t *testing.T) {
+	reads := 0
+	svc := NewService(newStore(t, &reads), failingViews{}, time.Minute, nil, logger.New("test", "test"))
+
+	view, err := svc.Get(context.Background(), "u1")
+	require.NoError(t, err)
+	assert.Len(t, view.Elevations, 1)
+	svc.Handle(context.Background(), event.Event{Name: domain.EventElevationApproved, SubjectID: "u1"})
+}
+
+func TestBuildGrantsRoles(t *testing.T) {
+	reads := 0
+	svc := NewService(newStore(t, &reads), nil, 0, []string{domain.RoleUser, "undefined"}, logger.New("test", "test"))
+	ctx := context.Background()
+
+	view, err := svc.Build(ctx, "u1")
+	require.NoError(t, err)
+	roles, permissions := view.Grants(time.Now())
+	assert.Equal(t, []string{domain.RoleAdmin, "support", domain.RoleUser}, roles)
+	assert.Equal(t, []string{domain.PermissionAll, "profile:read", "profile:write", "users:read"}, permissions)
+
+	// the permissions of the elevated roles end with their elevation
+	roles, permissions = view.Grants(time.Now().Add(2 * time.Hour))
+	assert.Equal(t, []string{"support", domain.RoleUser}, roles)
+	assert.Equal(t, []string{"profile:read", "profile:write", "users:read"}, permissions)
+
+	view, err = svc.Build(ctx, "u3")
+	require.NoError(t, err)
+	roles, permissions = view.Grants(time.Now())
+	assert.Equal(t, []string{domain.RoleUser}, roles)
+	assert.Equal(t, []string{"profile:read", "profile:write"}, permissions)
+}
+
+func TestBuildGrantsRolesWithinTheirWindow(t *testing.T) {
+	store := memory.NewStore()
+	ctx := context.Background()
+	now := time.Now()
+	startsAt, endsAt := now.Add(time.Hour), now.Add(2*time.Hour)
+	require.NoError(t, store.GetRoleRepository().Assign(ctx, domain.UserRole{UserID: "u1", Role: domain.RoleAdmin, StartsAt: &startsAt, EndsAt: &endsAt}))
+	svc := NewService(store, nil, 0, []string{domain.RoleUser}, logger.New("test", "test"))
+
+	// the view built before the window grants the role within it only
+	view, err := svc.Build(ctx, "u1")
+	require.NoError(t, err)
+	roles, _ := view.Grants(now)
+	assert.Equal(t, []string{domain.RoleUser}, roles)
+	roles, permissions := view.Grants(startsAt)
+	assert.Equal(t, []string{domain.RoleAdmin, domain.RoleUser}, roles)
+	assert.Contains(t, permissions, domain.PermissionAll)
+	roles, _ = view.Grants(endsAt)
+	assert.Equal(t, []string{domain.RoleUser}, roles)
+	if windows := view.ActiveRoleWindows(startsAt); assert.Len(t, windows, 1) {
+		assert.Equal(t, endsAt, *windows[0].EndsAt)
+	}
+
+	// the roles whose window ended are left out
+	endedAt := now.Add(-time.Hour)
+	require.NoError(t, store.GetRoleRepository().Assign(ctx, domain.UserRole{UserID: "u1", Role: domain.RoleAdmin, EndsAt: &endedAt}))
+	view, err = svc.Build(ctx, "u1")
+	require.NoError(t, err)
+	assert.Empty(t, view.RoleWindows)
+	roles, _ = view.Grants(now)
+	assert.Equal(t, []string{domain.RoleUser}, roles)
+}
@@ -0,0 +1,124 @@
+package legalhold
+
+import (
+	"go-hex/configs"
+	"go-hex/middleware"
+	"go-hex/shared/ierr"
+	"go-hex/shared/response"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+// RegisterAPI registers a new legal hold api
+func RegisterAPI(r echo.Group, cfg *configs.Config, service ServicePort) {
+	handler := handler{cfg, service}
+
+	// Internal endpoints
+	internal := r.Group("/internal", middleware.InternalAPI(cfg.InternalAPI.User, cfg.InternalAPI.Password))
+	internal.POST("/users/:id/legal-holds", handler.place)
+	internal.GET("/users/:id/legal-holds", handler.list)
+	internal.POST("/legal-holds/:id/release", handler.release)
+}
+
+type handler struct {
+	cfg     *configs.Config
+	service ServicePort
+}
+
+// place godoc
+// @Router /internal/users/{id}/legal-holds [post]
+// @Tags Legal Hold
+// @Summary Place a legal hold on a user
+// @Description Place a legal hold on a user, the records of the user are not deleted or anonymized until every hold of the user is released
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Param id path string true "user id"
+// @Param payload body RequestPlaceLegalHold true " "
+// @Success 201 {object} response.Response{data=domain.LegalHold} "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 404 {object} response.ErrorResponse404
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) place(c echo.Context) error {
+	var req RequestPlaceLegalHold
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.Place(c.Request().Context(), req)
+	if err != nil {
+		if errors.Cause(err) == ierr.ErrResourceNotFound {
+			return response.ErrNotFound(err)
+		}
+		return err
+	}
+
+	return response.SuccessCreated(c, res, "legal hold placed")
+}
+
+// list godoc
+// @Router /internal/users/{id}/legal-holds [get]
+// @Tags Legal Hold
+// @Summary List the legal holds of a user
+// @Description List the legal holds of a user, released or not, the newest first
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Param id path string true "user id"
+// @Success 200 {object} response.Response{data=[]domain.LegalHold} "Success"
+// @failure 401 {object} response.ErrorResponse401
+// @failure 404 {object} response.ErrorResponse404
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) list(c echo.Context) error {
+	var req RequestUserID
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.List(c.Request().Context(), req)
+	if err != nil {
+		if errors.Cause(err) == ierr.ErrResourceNotFound {
+			return response.ErrNotFound(err)
+		}
+		return err
+	}
+
+	return response.SuccessOK(c, res)
+}
+
+// release godoc
+// @Router /internal/legal-holds/{id}/release [post]
+// @Tags Legal Hold
+// @Summary Release a legal hold
+// @Description Release a legal hold, the records of the user can be deleted again once all its holds are released
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Param id path string true "legal hold id"
+// @Param payload body RequestReleaseLegalHold true " "
+// @Success 200 {object} response.Response{data=domain.LegalHold} "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 404 {object} response.ErrorResponse404
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) release(c echo.Context) error {
+	var req RequestReleaseLegalHold
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.Release(c.Request().Context(), req)
+	if err != nil {
+		switch errors.Cause(err) {
+		case ierr.ErrLegalHoldReleased:
+			return response.ErrBadRequest(err)
+		case ierr.ErrResourceNotFound:
+			return response.ErrNotFound(err)
+		}
+		return err
+	}
+
+	return response.SuccessOK(c, res, "legal hold released")
+}
@@ -0,0 +1,46 @@
+package legalhold
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// RequestPlaceLegalHold request body
+type RequestPlaceLegalHold struct {
+	UserID   string `json:"-" param:"id"`
+	Reason   string `json:"reason" example:"LIT-2026-042 discovery request"`
+	PlacedBy string `json:"placed_by" example:"jane.doe@legal.example.com"` // admin placing the hold
+}
+
+func (r *RequestPlaceLegalHold) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.UserID, validation.Required, validation.Length(1, 36)),
+		validation.Field(&r.Reason, validation.Required, validation.Length(1, 255)),
+		validation.Field(&r.PlacedBy, validation.Required, validation.Length(1, 100)),
+	)
+}
+
+// RequestReleaseLegalHold request body
+type RequestReleaseLegalHold struct {
+	ID         string `json:"-" param:"id"`
+	Reason     string `json:"reason" example:"LIT-2026-042 settled"`
+	ReleasedBy string `json:"released_by" example:"jane.doe@legal.example.com"` // admin releasing the hold
+}
+
+func (r *RequestReleaseLegalHold) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.ID, validation.Required),
+		validation.Field(&r.Reason, validation.Required, validation.Length(1, 255)),
+		validation.Field(&r.ReleasedBy, validation.Required, validation.Length(1, 100)),
+	)
+}
+
+// RequestUserID request params
+type RequestUserID struct {
+	ID string `json:"-" param:"id"`
+}
+
+func (r *RequestUserID) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.ID, validation.Required),
+	)
+}
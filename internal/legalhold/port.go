@@ -0,0 +1,19 @@
+package legalhold
+
+import (
+	"context"
+	"go-hex/internal/domain"
+)
+
+// ServicePort encapsulates the legal hold logic.
+type ServicePort interface {
+	// Place places a legal hold on a user, its records are kept until every hold of the user is released
+	Place(ctx context.Context, req RequestPlaceLegalHold) (domain.LegalHold, error)
+	// Release releases a legal hold
+	Release(ctx context.Context, req RequestReleaseLegalHold) (domain.LegalHold, error)
+	// List returns the legal holds of a user, released or not, the newest first
+	List(ctx context.Context, req RequestUserID) ([]domain.LegalHold, error)
+	// EnsureNotHeld returns ierr.ErrUserUnderLegalHold when a legal hold of the user is not released yet,
+	// the workflows deleting or anonymizing the records of a user must check it first
+	EnsureNotHeld(ctx context.Context, userID string) error
+}
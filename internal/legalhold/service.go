@@ -0,0 +1,145 @@
+package legalhold
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/times"
+	"go-hex/pkg/utils"
+	"go-hex/shared/ierr"
+)
+
+// Service encapsulates the legal hold logic. The holds are kept once released, so that they
+// remain the audit trail of the user, and every change is also logged and published on the event bus.
+type Service struct {
+	repoRegitry port.RepositoryRegistry
+	log         logger.Logger
+	events      event.Bus
+}
+
+// NewService creates and returns a new legal hold service
+func NewService(repoRegitry port.RepositoryRegistry, log logger.Logger, events event.Bus) *Service {
+	return &Service{repoRegitry, log, events}
+}
+
+// Place places a legal hold on a user, its records are kept until every hold of the user is released
+func (s *Service) Place(ctx context.Context, req RequestPlaceLegalHold) (domain.LegalHold, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return domain.LegalHold{}, err
+	}
+
+	_, err = s.repoRegitry.GetUserRepository().GetByID(ctx, req.UserID)
+	if err != nil {
+		return domain.LegalHold{}, err
+	}
+
+	hold := domain.LegalHold{
+		ID:       utils.GenerateID(),
+		UserID:   req.UserID,
+		Reason:   req.Reason,
+		PlacedBy: req.PlacedBy,
+		PlacedAt: times.Now(),
+	}
+	err = s.repoRegitry.GetLegalHoldRepository().Create(ctx, hold)
+	if err != nil {
+		return domain.LegalHold{}, err
+	}
+
+	s.publish(ctx, domain.EventLegalHoldPlaced, hold.PlacedBy, hold, hold.Reason)
+	return hold, nil
+}
+
+// Release releases a legal hold
+func (s *Service) Release(ctx context.Context, req RequestReleaseLegalHold) (domain.LegalHold, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return domain.LegalHold{}, err
+	}
+
+	repoLegalHold := s.repoRegitry.GetLegalHoldRepository()
+	hold, err := repoLegalHold.GetByID(ctx, req.ID)
+	if err != nil {
+		return domain.LegalHold{}, err
+	}
+
+	now := times.Now()
+	released, err := repoLegalHold.Release(ctx, req.ID, req.ReleasedBy, req.Reason, now)
+	if err != nil {
+		return domain.LegalHold{}, err
+	}
+	if !released {
+		return domain.LegalHold{}, ierr.ErrLegalHoldReleased
+	}
+
+	hold.ReleasedBy = &req.ReleasedBy
+	hold.ReleaseReason = &req.Reason
+	hold.ReleasedAt = &now
+
+	s.publish(ctx, domain.EventLegalHoldReleased, req.ReleasedBy, hold, req.Reason)
+	return hold, nil
+}
+
+// List returns the legal holds of a user, released or not, the newest first
+func (s *Service) List(ctx context.Context, req RequestUserID) ([]domain.LegalHold, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.repoRegitry.GetUserRepository().GetByID(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repoRegitry.GetLegalHoldRepository().ListByUserID(ctx, req.ID)
+}
+
+// EnsureNotHeld returns ierr.ErrUserUnderLegalHold when a legal hold of the user is not released yet,
+// the workflows deleting or anonymizing the records of a user must check it first
+func (s *Service) EnsureNotHeld(ctx context.Context, userID string) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	holds, err := s.repoRegitry.GetLegalHoldRepository().ListByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, hold := range holds {
+		if hold.IsActive() {
+			return ierr.ErrUserUnderLegalHold
+		}
+	}
+	return nil
+}
+
+// publish logs the change of a legal hold and publishes it on the event bus
+func (s *Service) publish(ctx context.Context, name string, actorID string, hold domain.LegalHold, reason string) {
+	s.log.WithParams(logger.Params{"type": "legal_hold", "event": name, "hold": hold}).Info("legal hold changed")
+	s.events.Publish(ctx, event.Event{
+		Name:      name,
+		ActorID:   actorID,
+		SubjectID: hold.UserID,
+		Attributes: map[string]interface{}{
+			"legal_hold_id": hold.ID,
+			"reason":        reason,
+		},
+	})
+}
@@ -0,0 +1,77 @@
+package legalhold
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/memory"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/shared/ierr"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestPlaceLegalHoldValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     RequestPlaceLegalHold
+		wantErr bool
+	}{
+		{"valid", RequestPlaceLegalHold{UserID: "user-1", Reason: "LIT-1", PlacedBy: "jane"}, false},
+		{"no reason", RequestPlaceLegalHold{UserID: "user-1", PlacedBy: "jane"}, true},
+		{"no admin", RequestPlaceLegalHold{UserID: "user-1", Reason: "LIT-1"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			assert.Equal(t, tt.wantErr, err != nil, err)
+		})
+	}
+}
+
+func TestPlaceAndRelease(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	assert.NoError(t, store.GetUserRepository().Create(ctx, domain.User{ID: "user-1", Username: "alice"}))
+
+	var published []event.Event
+	events := event.New()
+	events.Subscribe(event.All, func(ctx context.Context, e event.Event) {
+		published = append(published, e)
+	})
+	svc := NewService(store, logger.New("test", "test"), events)
+
+	_, err := svc.Place(ctx, RequestPlaceLegalHold{UserID: "user-2", Reason: "LIT-1", PlacedBy: "jane"})
+	assert.Equal(t, ierr.ErrResourceNotFound, errors.Cause(err))
+
+	hold, err := svc.Place(ctx, RequestPlaceLegalHold{UserID: "user-1", Reason: "LIT-1", PlacedBy: "jane"})
+	assert.NoError(t, err)
+	assert.Equal(t, ierr.ErrUserUnderLegalHold, svc.EnsureNotHeld(ctx, "user-1"))
+
+	// a hold which cannot be read does not let the records of the user be deleted
+	failure := errors.New("failure")
+	store.SetHook(memory.FailOn(failure, "LegalHoldRepository.ListByUserID"))
+	assert.Equal(t, failure, svc.EnsureNotHeld(ctx, "user-1"))
+	store.SetHook(nil)
+
+	released, err := svc.Release(ctx, RequestReleaseLegalHold{ID: hold.ID, Reason: "settled", ReleasedBy: "john"})
+	if assert.NoError(t, err) {
+		assert.False(t, released.IsActive())
+		assert.Equal(t, "john", *released.ReleasedBy)
+	}
+	assert.NoError(t, svc.EnsureNotHeld(ctx, "user-1"))
+
+	_, err = svc.Release(ctx, RequestReleaseLegalHold{ID: hold.ID, Reason: "settled", ReleasedBy: "john"})
+	assert.Equal(t, ierr.ErrLegalHoldReleased, err)
+
+	if assert.Len(t, published, 2) {
+		assert.Equal(t, domain.EventLegalHoldPlaced, published[0].Name)
+		assert.Equal(t, "jane", published[0].ActorID)
+		assert.Equal(t, domain.EventLegalHoldReleased, published[1].Name)
+		assert.Equal(t, "john", published[1].ActorID)
+		assert.Equal(t, "user-1", published[1].SubjectID)
+	}
+}
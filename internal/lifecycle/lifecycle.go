@@ -0,0 +1,155 @@
+// Package lifecycle stops the components of the api in the order they depend on each other when it shuts down.
+//
+// The components are stopped phase by phase: the servers stop accepting the requests and drain those in flight,
+// then the background workers flush their buffers, then the exporters send the events and the spans to their
+// collectors, and at last the connections of the repositories are closed, once nothing uses them anymore.
+// The components of a phase are stopped together, and a phase is over once they all stopped or its deadline passed.
+package lifecycle
+
+import (
+	"context"
+	"go-hex/pkg/logger"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Phase is a step of the shutdown, the phases are run in their order
+type Phase int
+
+const (
+	// PhaseServers stop accepting the requests and drain those in flight
+	PhaseServers Phase = iota
+	// PhaseWorkers stop the background workers and flush their buffers, e.g. into the database or onto the events
+	PhaseWorkers
+	// PhaseExporters send the events and the spans left by the workers to their collectors
+	PhaseExporters
+	// PhaseConnections close the pools of connections of the repositories
+	PhaseConnections
+
+	phases = PhaseConnections + 1
+)
+
+var phaseNames = [phases]string{"servers", "workers", "exporters", "connections"}
+
+func (p Phase) String() string {
+	return phaseNames[p]
+}
+
+// StopFunc stops a component, it returns once stopped or once ctx is done
+type StopFunc func(ctx context.Context) error
+
+// Func stops a component with a function which cannot fail, such as the Close of the workers
+func Func(stop func()) StopFunc {
+	return func(ctx context.Context) error {
+		stop()
+		return nil
+	}
+}
+
+// Closer stops a component by closing it, such as a pool of connections
+func Closer(c io.Closer) StopFunc {
+	return func(ctx context.Context) error {
+		return c.Close()
+	}
+}
+
+type component struct {
+	name string
+	stop StopFunc
+}
+
+// Manager stops the registered components on shutdown
+type Manager struct {
+	log          logger.Logger
+	drainTimeout time.Duration
+	stopTimeout  time.Duration
+
+	mu         sync.Mutex
+	components [phases][]component
+}
+
+// NewManager creates a manager draining the servers for drainTimeout and giving stopTimeout to each other phase
+func NewManager(log logger.Logger, drainTimeout, stopTimeout time.Duration) *Manager {
+	return &Manager{log: log, drainTimeout: drainTimeout, stopTimeout: stopTimeout}
+}
+
+// Register registers the component to stop in the phase, the names of the components of a phase are unique
+func (m *Manager) Register(phase Phase, name string, stop StopFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.components[phase] = append(m.components[phase], component{name, stop})
+}
+
+// Shutdown stops the components phase by phase, it logs the components which failed or did not stop in time and
+// returns the first error. A component still stopping past the deadline of its phase is left behind.
+func (m *Manager) Shutdown() error {
+	m.mu.Lock()
+	components := m.components
+	m.mu.Unlock()
+
+	var first error
+	for phase := PhaseServers; phase < phases; phase++ {
+		timeout := m.stopTimeout
+		if phase == PhaseServers {
+			timeout = m.drainTimeout
+		}
+		start := time.Now()
+		if err := m.stop(phase, components[phase], timeout); err != nil && first == nil {
+			first = err
+		}
+		m.log.Infof("shutdown: %s stopped in %s", phase, time.Since(start).Round(time.Millisecond))
+	}
+	return first
+}
+
+// stop stops the components of the phase together and waits for them until the timeout
+func (m *Manager) stop(phase Phase, components []component, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	type result struct {
+		name string
+		err  error
+	}
+	results := make(chan result, len(components))
+	pending := make(map[string]bool, len(components))
+	for _, c := range components {
+		pending[c.name] = true
+		go func(c component) {
+			results <- result{c.name, c.stop(ctx)}
+		}(c)
+	}
+
+	var first error
+	for len(pending) > 0 {
+		select {
+		case res := <-results:
+			delete(pending, res.name)
+			if res.err != nil {
+				err := errors.Wrapf(res.err, "cannot stop %s", res.name)
+				m.log.Error(err)
+				if first == nil {
+					first = err
+				}
+			}
+		case <-ctx.Done():
+			names := make([]string, 0, len(pending))
+			for name := range pending {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			err := errors.Errorf("%s did not stop within %s: %s", phase, timeout, strings.Join(names, ", "))
+			m.log.Error(err)
+			if first == nil {
+				first = err
+			}
+			return first
+		}
+	}
+	return first
+}
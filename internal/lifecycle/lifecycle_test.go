@@ -0,0 +1,83 @@
+package lifecycle
+
+import (
+	"context"
+	"go-hex/pkg/logger"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder records the components in the order they stopped
+type recorder struct {
+	mu      sync.Mutex
+	stopped []string
+}
+
+func (r *recorder) stop(name string) StopFunc {
+	return func(ctx context.Context) error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.stopped = append(r.stopped, name)
+		return nil
+	}
+}
+
+func TestManagerShutdown(t *testing.T) {
+	r := &recorder{}
+	m := NewManager(logger.New("test", "test"), time.Second, time.Second)
+	m.Register(PhaseConnections, "database", r.stop("database"))
+	m.Register(PhaseExporters, "traces", r.stop("traces"))
+	m.Register(PhaseWorkers, "audit trail", r.stop("audit trail"))
+	m.Register(PhaseServers, "http", r.stop("http"))
+
+	assert.NoError(t, m.Shutdown())
+	assert.Equal(t, []string{"http", "audit trail", "traces", "database"}, r.stopped, "stopped in dependency order")
+}
+
+func TestManagerShutdownConcurrently(t *testing.T) {
+	m := NewManager(logger.New("test", "test"), time.Second, time.Second)
+	// each server waits for the other one, they are only stopped when stopped together
+	http, grpc := make(chan struct{}), make(chan struct{})
+	m.Register(PhaseServers, "http", func(ctx context.Context) error {
+		close(http)
+		<-grpc
+		return nil
+	})
+	m.Register(PhaseServers, "grpc", func(ctx context.Context) error {
+		close(grpc)
+		<-http
+		return nil
+	})
+
+	assert.NoError(t, m.Shutdown())
+}
+
+func TestManagerShutdownDeadline(t *testing.T) {
+	r := &recorder{}
+	m := NewManager(logger.New("test", "test"), 50*time.Millisecond, time.Second)
+	m.Register(PhaseServers, "http", func(ctx context.Context) error {
+		<-ctx.Done()
+		time.Sleep(time.Second) // a stuck request
+		return ctx.Err()
+	})
+	m.Register(PhaseWorkers, "token usage", func(ctx context.Context) error {
+		return errors.New("cannot flush")
+	})
+	m.Register(PhaseConnections, "database", r.stop("database"))
+
+	start := time.Now()
+	err := m.Shutdown()
+	assert.Less(t, time.Since(start), time.Second, "the stuck server is left behind")
+	assert.EqualError(t, err, "servers did not stop within 50ms: http")
+	assert.Equal(t, []string{"database"}, r.stopped, "the next phases still run after a deadline or an error")
+}
+
+func TestFunc(t *testing.T) {
+	closed := false
+	assert.NoError(t, Func(func() { closed = true })(context.Background()))
+	assert.True(t, closed)
+}
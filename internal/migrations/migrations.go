@@ -0,0 +1,158 @@
+// Package migrations applies the migrations of the database schema, embedded in the binary so that the schema
+// always matches the build, and checks at startup that the database is up to date.
+package migrations
+
+import (
+	"context"
+	"embed"
+	"io/fs"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	migrate "github.com/rubenv/sql-migrate"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
+)
+
+// Directories of the migration files in the source tree, where sql-migrate new creates them.
+// Every migration is written for MySQL, MariaDB included, and for PostgreSQL under the same name.
+const (
+	Dir         = "internal/migrations/mysql"
+	PostgresDir = "internal/migrations/postgres"
+)
+
+// table is the table recording the applied migrations
+const table = "gorp_migrations"
+
+//go:embed mysql/*.sql postgres/*.sql
+var files embed.FS
+
+// ErrPending is returned by Check when migrations are not applied yet
+var ErrPending = errors.New("pending migrations")
+
+// Status compares the applied migrations to the migrations embedded in the build
+type Status struct {
+	Applied int `json:"applied" example:"24"`
+	// Pending lists the migrations not applied yet, from the oldest
+	Pending []string `json:"pending"`
+	// Unknown lists the migrations applied but missing from the build, e.g. after a rollback of the application
+	Unknown       []string   `json:"unknown"`
+	Last          string     `json:"last" example:"20261014220000-create_table_broadcasts.sql"`
+	LastAppliedAt *time.Time `json:"last_applied_at"`
+}
+
+// record is a migration applied to the database
+type record struct {
+	ID        string    `bun:"id"`
+	AppliedAt time.Time `bun:"applied_at"`
+}
+
+// Runner applies the embedded migrations to the database
+type Runner struct {
+	db      *bun.DB
+	dialect dialect.Name
+}
+
+// NewRunner creates a migration runner of the database, the migrations are those of the dialect of the connection
+func NewRunner(db *bun.DB) *Runner {
+	return &Runner{db, db.Dialect().Name()}
+}
+
+// Up applies the pending migrations, from the oldest, and returns how many were applied
+func (r *Runner) Up() (int, error) {
+	return r.exec(migrate.Up, 0)
+}
+
+// Down rolls back the last steps migrations applied, every migration when steps is 0, and returns how many
+// were rolled back
+func (r *Runner) Down(steps int) (int, error) {
+	return r.exec(migrate.Down, steps)
+}
+
+func (r *Runner) exec(direction migrate.MigrationDirection, max int) (int, error) {
+	migrate.SetTable(table)
+	count, err := migrate.ExecMax(r.db.DB, dialectDir(r.dialect), Source(r.dialect), direction, max)
+	if err != nil {
+		return count, errors.Wrap(err, "cannot apply migrations")
+	}
+	return count, nil
+}
+
+// Status reads the applied migrations, without creating the migration table when it is missing
+func (r *Runner) Status(ctx context.Context) (Status, error) {
+
+	migrations, err := Source(r.dialect).FindMigrations()
+	if err != nil {
+		return Status{}, errors.Wrap(err, "cannot read migration files")
+	}
+
+	var records []record
+	err = r.db.NewSelect().
+		TableExpr("?", bun.Ident(table)).
+		Column("id", "applied_at").
+		OrderExpr("id ASC").
+		Scan(ctx, &records)
+	if err != nil {
+		return Status{}, errors.Wrap(err, "cannot read applied migrations")
+	}
+	return compare(migrations, records), nil
+}
+
+// Check returns ErrPending, with the pending migrations, when the database is behind the build.
+// The migrations unknown to the build are tolerated, the schema of a newer build being backward compatible.
+func (r *Runner) Check(ctx context.Context) (Status, error) {
+	status, err := r.Status(ctx)
+	if err != nil {
+		return status, err
+	}
+	if len(status.Pending) > 0 {
+		return status, errors.Wrap(ErrPending, strings.Join(status.Pending, ", "))
+	}
+	return status, nil
+}
+
+// Source returns the embedded migrations of the dialect
+func Source(name dialect.Name) migrate.MigrationSource {
+	sub, err := fs.Sub(files, dialectDir(name))
+	if err != nil {
+		// the directory is embedded, it cannot be missing
+		panic(err)
+	}
+	return migrate.HttpFileSystemMigrationSource{FileSystem: http.FS(sub)}
+}
+
+// dialectDir returns the directory of the migrations of the dialect, named after the dialect of sql-migrate
+func dialectDir(name dialect.Name) string {
+	if name == dialect.PG {
+		return "postgres"
+	}
+	return "mysql"
+}
+
+// compare returns the status of the applied migrations against the embedded ones
+func compare(migrations []*migrate.Migration, records []record) Status {
+	status := Status{Applied: len(records), Pending: []string{}, Unknown: []string{}}
+	applied := make(map[string]bool, len(records))
+	for i, record := range records {
+		applied[record.ID] = true
+		if i == len(records)-1 {
+			status.Last, status.LastAppliedAt = record.ID, &records[i].AppliedAt
+		}
+	}
+
+	files := make(map[string]bool, len(migrations))
+	for _, m := range migrations {
+		files[m.Id] = true
+		if !applied[m.Id] {
+			status.Pending = append(status.Pending, m.Id)
+		}
+	}
+	for _, record := range records {
+		if !files[record.ID] {
+			status.Unknown = append(status.Unknown, record.ID)
+		}
+	}
+	return status
+}
@@ -0,0 +1,60 @@
+package migrations
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	migrate "github.com/rubenv/sql-migrate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun/dialect"
+)
+
+func TestSourceEmbedsEveryMigration(t *testing.T) {
+	ids := map[dialect.Name][]string{}
+	for _, name := range []dialect.Name{dialect.MySQL, dialect.PG} {
+		migrations, err := Source(name).FindMigrations()
+		if assert.NoError(t, err) && assert.NotEmpty(t, migrations) {
+			for _, m := range migrations {
+				assert.NotEmpty(t, m.Up, m.Id)
+				assert.NotEmpty(t, m.Down, m.Id)
+				ids[name] = append(ids[name], m.Id)
+			}
+		}
+	}
+	assert.Equal(t, ids[dialect.MySQL], ids[dialect.PG], "every migration is written for both dialects")
+}
+
+func TestCompare(t *testing.T) {
+	migrations := []*migrate.Migration{{Id: "1-a.sql"}, {Id: "2-b.sql"}, {Id: "3-c.sql"}}
+	appliedAt := time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)
+
+	status := compare(migrations, []record{{ID: "1-a.sql"}, {ID: "4-d.sql", AppliedAt: appliedAt}})
+	assert.Equal(t, 2, status.Applied)
+	assert.Equal(t, []string{"2-b.sql", "3-c.sql"}, status.Pending)
+	assert.Equal(t, []string{"4-d.sql"}, status.Unknown)
+	assert.Equal(t, "4-d.sql", status.Last)
+	assert.Equal(t, appliedAt, *status.LastAppliedAt)
+
+	status = compare(migrations, nil)
+	assert.Len(t, status.Pending, 3)
+	assert.Nil(t, status.LastAppliedAt)
+}
+
+func TestPostgresMigrationsRunOnCockroachDB(t *testing.T) {
+	migrations, err := Source(dialect.PG).FindMigrations()
+	require.NoError(t, err)
+	for _, m := range migrations {
+		var addsColumn, updates bool
+		for _, statement := range m.Up {
+			statement = strings.ToUpper(strings.TrimSpace(statement))
+			addsColumn = addsColumn || strings.HasPrefix(statement, "ALTER TABLE") && strings.Contains(statement, "ADD COLUMN")
+			updates = updates || strings.HasPrefix(statement, "UPDATE")
+		}
+		// CockroachDB cannot write a column added in the same transaction
+		if addsColumn && updates {
+			assert.True(t, m.DisableTransactionUp, "%s updates the column it adds out of a transaction", m.Id)
+		}
+	}
+}
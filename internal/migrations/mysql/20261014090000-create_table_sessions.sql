@@ -0,0 +1,13 @@
+-- +migrate Up
+CREATE TABLE sessions (
+    id varchar(36) NOT NULL PRIMARY KEY,
+    user_id varchar(36) NOT NULL,
+    created_at timestamp(0) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at datetime(0) NOT NULL,
+    revoked_at timestamp(0) NULL,
+    CONSTRAINT sessions_user_fk FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    INDEX sessions_user_idx (user_id)
+);
+
+-- +migrate Down
+DROP TABLE sessions;
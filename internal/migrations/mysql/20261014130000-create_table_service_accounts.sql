@@ -0,0 +1,34 @@
+-- +migrate Up
+CREATE TABLE service_accounts (
+    id varchar(36) NOT NULL PRIMARY KEY,
+    name varchar(100) NOT NULL,
+    description varchar(255) NOT NULL DEFAULT '',
+    public_key text NOT NULL,
+    is_active tinyint(1) NOT NULL DEFAULT 1,
+    created_at timestamp(0) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp(0) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used_at timestamp(0) NULL,
+    CONSTRAINT service_accounts_name_uq UNIQUE (name)
+);
+
+CREATE TABLE service_account_roles (
+    service_account_id varchar(36) NOT NULL,
+    role varchar(100) NOT NULL,
+    created_at timestamp(0) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (service_account_id, role),
+    CONSTRAINT service_account_roles_account_fk FOREIGN KEY (service_account_id) REFERENCES service_accounts (id) ON DELETE CASCADE
+);
+
+CREATE TABLE service_account_assertions (
+    jti varchar(255) NOT NULL,
+    service_account_id varchar(36) NOT NULL,
+    expires_at timestamp(0) NOT NULL,
+    PRIMARY KEY (service_account_id, jti),
+    CONSTRAINT service_account_assertions_account_fk FOREIGN KEY (service_account_id) REFERENCES service_accounts (id) ON DELETE CASCADE,
+    INDEX service_account_assertions_expires_idx (expires_at)
+);
+
+-- +migrate Down
+DROP TABLE service_account_assertions;
+DROP TABLE service_account_roles;
+DROP TABLE service_accounts;
@@ -0,0 +1,27 @@
+-- +migrate Up
+CREATE TABLE token_usage_endpoints (
+    client_id varchar(36) NOT NULL,
+    principal_type varchar(20) NOT NULL,
+    method varchar(10) NOT NULL,
+    route varchar(255) NOT NULL,
+    day date NOT NULL,
+    calls bigint NOT NULL DEFAULT 0,
+    last_seen_at timestamp(0) NOT NULL,
+    PRIMARY KEY (client_id, method, route, day),
+    INDEX token_usage_endpoints_day_idx (day)
+);
+
+CREATE TABLE token_usage_scopes (
+    client_id varchar(36) NOT NULL,
+    scope varchar(100) NOT NULL,
+    day date NOT NULL,
+    granted_calls bigint NOT NULL DEFAULT 0,
+    used_calls bigint NOT NULL DEFAULT 0,
+    last_used_at timestamp(0) NULL,
+    PRIMARY KEY (client_id, scope, day),
+    INDEX token_usage_scopes_day_idx (day)
+);
+
+-- +migrate Down
+DROP TABLE token_usage_scopes;
+DROP TABLE token_usage_endpoints;
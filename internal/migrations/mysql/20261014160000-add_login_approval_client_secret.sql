@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE login_approvals ADD COLUMN client_secret varchar(64) NOT NULL DEFAULT '' AFTER user_id;
+
+-- +migrate Down
+ALTER TABLE login_approvals DROP COLUMN client_secret;
@@ -0,0 +1,15 @@
+-- +migrate Up
+CREATE TABLE service_account_audit_events (
+    id varchar(36) NOT NULL PRIMARY KEY,
+    service_account_id varchar(36) NOT NULL,
+    event varchar(100) NOT NULL,
+    actor_type varchar(50) NOT NULL,
+    actor_id varchar(100) NOT NULL,
+    subject_id varchar(100) NOT NULL DEFAULT '',
+    attributes json NULL,
+    created_at timestamp(0) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX service_account_audit_events_account_idx (service_account_id, created_at)
+);
+
+-- +migrate Down
+DROP TABLE service_account_audit_events;
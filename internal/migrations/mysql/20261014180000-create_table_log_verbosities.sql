@@ -0,0 +1,14 @@
+-- +migrate Up
+CREATE TABLE log_verbosities (
+    id varchar(36) NOT NULL PRIMARY KEY,
+    user_id varchar(36) NULL,
+    request_id_pattern varchar(255) NULL,
+    level varchar(10) NOT NULL,
+    reason varchar(255) NOT NULL,
+    created_at timestamp(0) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at timestamp(0) NOT NULL,
+    INDEX log_verbosities_expires_idx (expires_at)
+);
+
+-- +migrate Down
+DROP TABLE log_verbosities;
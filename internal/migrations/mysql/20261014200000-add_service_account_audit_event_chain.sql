@@ -0,0 +1,24 @@
+-- +migrate Up
+ALTER TABLE service_account_audit_events
+    ADD COLUMN seq bigint NULL,
+    ADD COLUMN prev_hash char(64) NOT NULL DEFAULT '',
+    ADD COLUMN hash char(64) NOT NULL DEFAULT '',
+    ADD UNIQUE INDEX service_account_audit_events_seq_idx (seq);
+
+CREATE TABLE audit_chain_heads (
+    name varchar(100) NOT NULL PRIMARY KEY,
+    seq bigint NOT NULL DEFAULT 0,
+    hash char(64) NOT NULL DEFAULT '',
+    updated_at timestamp(0) NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO audit_chain_heads (name) VALUES ('service_account_audit_events');
+
+-- +migrate Down
+DROP TABLE audit_chain_heads;
+
+ALTER TABLE service_account_audit_events
+    DROP INDEX service_account_audit_events_seq_idx,
+    DROP COLUMN seq,
+    DROP COLUMN prev_hash,
+    DROP COLUMN hash;
@@ -0,0 +1,25 @@
+-- +migrate Up
+CREATE TABLE service_account_audit_archives (
+    day date NOT NULL PRIMARY KEY,
+    object varchar(255) NOT NULL DEFAULT '',
+    events int NOT NULL DEFAULT 0,
+    checksum char(64) NOT NULL DEFAULT '',
+    archived_at timestamp(0) NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE audit_chain_heads
+    ADD COLUMN pruned_seq bigint NOT NULL DEFAULT 0,
+    ADD COLUMN pruned_hash char(64) NOT NULL DEFAULT '';
+
+ALTER TABLE service_account_audit_events
+    ADD INDEX service_account_audit_events_created_idx (created_at);
+
+-- +migrate Down
+ALTER TABLE service_account_audit_events
+    DROP INDEX service_account_audit_events_created_idx;
+
+ALTER TABLE audit_chain_heads
+    DROP COLUMN pruned_seq,
+    DROP COLUMN pruned_hash;
+
+DROP TABLE service_account_audit_archives;
@@ -0,0 +1,16 @@
+-- +migrate Up
+CREATE TABLE message_templates (
+    id varchar(36) NOT NULL PRIMARY KEY,
+    name varchar(100) NOT NULL,
+    channel varchar(20) NOT NULL,
+    version int NOT NULL,
+    subject varchar(255) NOT NULL DEFAULT '',
+    body text NOT NULL,
+    reset tinyint(1) NOT NULL DEFAULT 0,
+    created_by varchar(100) NOT NULL,
+    created_at timestamp(0) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE INDEX message_templates_version_idx (name, channel, version)
+);
+
+-- +migrate Down
+DROP TABLE message_templates;
@@ -0,0 +1,11 @@
+-- +migrate Up
+ALTER TABLE service_account_roles
+    ADD COLUMN starts_at timestamp(0) NULL,
+    ADD COLUMN ends_at timestamp(0) NULL,
+    ADD INDEX service_account_roles_ends_at_idx (ends_at);
+
+-- +migrate Down
+ALTER TABLE service_account_roles
+    DROP INDEX service_account_roles_ends_at_idx,
+    DROP COLUMN starts_at,
+    DROP COLUMN ends_at;
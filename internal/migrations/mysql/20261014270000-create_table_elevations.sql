@@ -0,0 +1,21 @@
+-- +migrate Up
+CREATE TABLE elevations (
+    id varchar(36) NOT NULL PRIMARY KEY,
+    user_id varchar(36) NOT NULL,
+    role varchar(100) NOT NULL,
+    reason varchar(255) NOT NULL,
+    duration int NOT NULL,
+    status varchar(10) NOT NULL,
+    requested_at timestamp(0) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at timestamp(0) NOT NULL,
+    decided_by varchar(36) NOT NULL DEFAULT '',
+    decided_at timestamp(0) NULL,
+    comment varchar(255) NOT NULL DEFAULT '',
+    ends_at timestamp(0) NULL,
+    CONSTRAINT elevations_user_fk FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    INDEX elevations_user_status_idx (user_id, status),
+    INDEX elevations_status_idx (status, expires_at)
+);
+
+-- +migrate Down
+DROP TABLE elevations;
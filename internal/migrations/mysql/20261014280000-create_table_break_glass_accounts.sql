@@ -0,0 +1,17 @@
+-- +migrate Up
+CREATE TABLE break_glass_accounts (
+    user_id varchar(36) NOT NULL PRIMARY KEY,
+    status varchar(10) NOT NULL,
+    custodians varchar(255) NOT NULL,
+    sealed_at timestamp(0) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    activated_by varchar(255) NOT NULL DEFAULT '',
+    reason varchar(255) NOT NULL DEFAULT '',
+    activated_at timestamp(0) NULL,
+    expires_at timestamp(0) NULL,
+    revoked_at timestamp(0) NULL,
+    CONSTRAINT break_glass_accounts_user_fk FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    INDEX break_glass_accounts_status_idx (status, expires_at)
+);
+
+-- +migrate Down
+DROP TABLE break_glass_accounts;
@@ -0,0 +1,14 @@
+-- +migrate Up
+CREATE TABLE refresh_tokens (
+    id varchar(36) NOT NULL PRIMARY KEY,
+    session_id varchar(36) NOT NULL,
+    issued_at timestamp(0) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at datetime(0) NULL,
+    rotated_at timestamp(0) NULL,
+    replaced_by varchar(36) NOT NULL DEFAULT '',
+    CONSTRAINT refresh_tokens_session_fk FOREIGN KEY (session_id) REFERENCES sessions (id) ON DELETE CASCADE,
+    INDEX refresh_tokens_session_idx (session_id)
+);
+
+-- +migrate Down
+DROP TABLE refresh_tokens;
@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE users ADD COLUMN compromised_at timestamp(0) NULL AFTER max_sessions;
+
+-- +migrate Down
+ALTER TABLE users DROP COLUMN compromised_at;
@@ -0,0 +1,16 @@
+-- +migrate Up
+CREATE TABLE password_resets (
+    id varchar(36) NOT NULL PRIMARY KEY,
+    user_id varchar(36) NOT NULL,
+    token_hash char(64) NOT NULL,
+    channel varchar(10) NOT NULL,
+    created_at timestamp(0) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at timestamp(0) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    used_at timestamp(0) NULL,
+    UNIQUE INDEX password_resets_token_hash_idx (token_hash),
+    INDEX password_resets_user_idx (user_id),
+    CONSTRAINT password_resets_user_fk FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+-- +migrate Down
+DROP TABLE password_resets;
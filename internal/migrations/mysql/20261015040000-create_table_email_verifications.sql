@@ -0,0 +1,16 @@
+-- +migrate Up
+CREATE TABLE email_verifications (
+    id varchar(36) NOT NULL PRIMARY KEY,
+    user_id varchar(36) NOT NULL,
+    email varchar(255) NOT NULL,
+    token_hash char(64) NOT NULL,
+    created_at timestamp(0) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at timestamp(0) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    verified_at timestamp(0) NULL,
+    UNIQUE INDEX email_verifications_token_hash_idx (token_hash),
+    INDEX email_verifications_user_idx (user_id),
+    CONSTRAINT email_verifications_user_fk FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+-- +migrate Down
+DROP TABLE email_verifications;
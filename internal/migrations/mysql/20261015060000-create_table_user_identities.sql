@@ -0,0 +1,15 @@
+-- +migrate Up
+CREATE TABLE user_identities (
+    provider varchar(50) NOT NULL,
+    subject varchar(255) NOT NULL,
+    user_id varchar(36) NOT NULL,
+    email varchar(255) NULL,
+    created_at timestamp(0) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_login_at timestamp(0) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (provider, subject),
+    INDEX user_identities_user_idx (user_id),
+    CONSTRAINT user_identities_user_fk FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+-- +migrate Down
+DROP TABLE user_identities;
@@ -0,0 +1,9 @@
+-- +migrate Up
+ALTER TABLE sessions ADD COLUMN ip_address varchar(45) NULL AFTER upstream_subject;
+ALTER TABLE sessions ADD COLUMN user_agent varchar(512) NULL AFTER ip_address;
+ALTER TABLE sessions ADD COLUMN last_used_at datetime NULL AFTER created_at;
+
+-- +migrate Down
+ALTER TABLE sessions DROP COLUMN last_used_at;
+ALTER TABLE sessions DROP COLUMN user_agent;
+ALTER TABLE sessions DROP COLUMN ip_address;
@@ -0,0 +1,19 @@
+-- +migrate Up
+CREATE TABLE audit_events (
+    id varchar(36) NOT NULL PRIMARY KEY,
+    action varchar(100) NOT NULL,
+    outcome varchar(20) NOT NULL,
+    actor_type varchar(50) NOT NULL,
+    actor_id varchar(255) NOT NULL DEFAULT '',
+    subject_id varchar(255) NOT NULL DEFAULT '',
+    ip_address varchar(45) NOT NULL DEFAULT '',
+    user_agent varchar(512) NOT NULL DEFAULT '',
+    attributes json NULL,
+    created_at timestamp(0) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX audit_events_created_idx (created_at),
+    INDEX audit_events_actor_idx (actor_id, created_at),
+    INDEX audit_events_action_idx (action, created_at)
+);
+
+-- +migrate Down
+DROP TABLE audit_events;
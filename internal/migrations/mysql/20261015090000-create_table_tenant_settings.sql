@@ -0,0 +1,16 @@
+-- +migrate Up
+CREATE TABLE tenant_settings (
+    tenant_id varchar(100) NOT NULL PRIMARY KEY,
+    token_expiration int NULL,
+    refresh_token_expiration int NULL,
+    login_approval_required tinyint(1) NULL,
+    password_min_length int NULL,
+    password_min_classes int NULL,
+    app_name varchar(100) NULL,
+    updated_by varchar(100) NOT NULL,
+    created_at timestamp(0) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp(0) NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- +migrate Down
+DROP TABLE tenant_settings;
@@ -0,0 +1,13 @@
+-- +migrate Up
+ALTER TABLE tenant_settings
+    ADD COLUMN logo_url varchar(2048) NULL AFTER app_name,
+    ADD COLUMN primary_color char(7) NULL AFTER logo_url,
+    ADD COLUMN accent_color char(7) NULL AFTER primary_color,
+    ADD COLUMN support_email varchar(255) NULL AFTER accent_color;
+
+-- +migrate Down
+ALTER TABLE tenant_settings
+    DROP COLUMN logo_url,
+    DROP COLUMN primary_color,
+    DROP COLUMN accent_color,
+    DROP COLUMN support_email;
@@ -0,0 +1,9 @@
+-- +migrate Up
+ALTER TABLE sessions ADD COLUMN client_app varchar(64) NULL AFTER user_agent;
+ALTER TABLE sessions ADD COLUMN client_version varchar(64) NULL AFTER client_app;
+ALTER TABLE sessions ADD COLUMN client_platform varchar(64) NULL AFTER client_version;
+
+-- +migrate Down
+ALTER TABLE sessions DROP COLUMN client_platform;
+ALTER TABLE sessions DROP COLUMN client_version;
+ALTER TABLE sessions DROP COLUMN client_app;
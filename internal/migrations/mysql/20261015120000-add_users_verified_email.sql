@@ -0,0 +1,8 @@
+-- +migrate Up
+ALTER TABLE users ADD COLUMN verified_email varchar(255) NULL AFTER email;
+
+UPDATE users u JOIN email_verifications v ON v.user_id = u.id AND v.email = u.email AND v.verified_at IS NOT NULL
+SET u.verified_email = u.email;
+
+-- +migrate Down
+ALTER TABLE users DROP COLUMN verified_email;
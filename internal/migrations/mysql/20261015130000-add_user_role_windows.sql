@@ -0,0 +1,11 @@
+-- +migrate Up
+ALTER TABLE user_roles
+    ADD COLUMN starts_at timestamp(0) NULL,
+    ADD COLUMN ends_at timestamp(0) NULL,
+    ADD INDEX user_roles_ends_at_idx (ends_at);
+
+-- +migrate Down
+ALTER TABLE user_roles
+    DROP INDEX user_roles_ends_at_idx,
+    DROP COLUMN starts_at,
+    DROP COLUMN ends_at;
@@ -0,0 +1,7 @@
+-- +migrate Up
+ALTER TABLE sessions ADD COLUMN refresh_token varchar(255) NULL;
+ALTER TABLE users ADD COLUMN max_sessions int NULL;
+
+-- +migrate Down
+ALTER TABLE users DROP COLUMN max_sessions;
+ALTER TABLE sessions DROP COLUMN refresh_token;
@@ -0,0 +1,18 @@
+-- +migrate Up
+CREATE TABLE login_approvals (
+    id varchar(36) NOT NULL PRIMARY KEY,
+    user_id varchar(36) NOT NULL,
+    number int NOT NULL,
+    choices varchar(20) NOT NULL,
+    status varchar(10) NOT NULL,
+    ip_address varchar(45) NOT NULL DEFAULT '',
+    user_agent varchar(255) NOT NULL DEFAULT '',
+    created_at timestamptz(0) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at timestamptz(0) NOT NULL,
+    decided_at timestamptz(0) NULL,
+    CONSTRAINT login_approvals_user_fk FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+CREATE INDEX login_approvals_user_status_idx ON login_approvals (user_id, status);
+
+-- +migrate Down
+DROP TABLE login_approvals;
@@ -0,0 +1,18 @@
+-- +migrate Up
+CREATE TABLE device_logins (
+    id varchar(36) NOT NULL PRIMARY KEY,
+    device_code varchar(64) NOT NULL,
+    user_code varchar(9) NOT NULL,
+    user_id varchar(36) NULL,
+    status varchar(10) NOT NULL,
+    created_at timestamptz(0) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at timestamptz(0) NOT NULL,
+    decided_at timestamptz(0) NULL,
+    last_polled_at timestamptz(0) NULL,
+    CONSTRAINT device_logins_device_code_unique UNIQUE (device_code),
+    CONSTRAINT device_logins_user_code_unique UNIQUE (user_code),
+    CONSTRAINT device_logins_user_fk FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+-- +migrate Down
+DROP TABLE device_logins;
@@ -0,0 +1,11 @@
+-- +migrate Up
+ALTER TABLE sessions ADD COLUMN upstream_session_id varchar(255) NULL;
+ALTER TABLE sessions ADD COLUMN upstream_subject varchar(255) NULL;
+CREATE INDEX sessions_upstream_session_idx ON sessions (upstream_session_id);
+CREATE INDEX sessions_upstream_subject_idx ON sessions (upstream_subject);
+
+-- +migrate Down
+DROP INDEX sessions_upstream_subject_idx;
+DROP INDEX sessions_upstream_session_idx;
+ALTER TABLE sessions DROP COLUMN upstream_subject;
+ALTER TABLE sessions DROP COLUMN upstream_session_id;
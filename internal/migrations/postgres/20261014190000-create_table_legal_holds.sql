@@ -0,0 +1,16 @@
+-- +migrate Up
+CREATE TABLE legal_holds (
+    id varchar(36) NOT NULL PRIMARY KEY,
+    user_id varchar(36) NOT NULL,
+    reason varchar(255) NOT NULL,
+    placed_by varchar(100) NOT NULL,
+    placed_at timestamptz(0) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    released_by varchar(100) NULL,
+    release_reason varchar(255) NULL,
+    released_at timestamptz(0) NULL
+);
+CREATE INDEX legal_holds_user_idx ON legal_holds (user_id, placed_at);
+CREATE INDEX legal_holds_released_idx ON legal_holds (released_at);
+
+-- +migrate Down
+DROP TABLE legal_holds;
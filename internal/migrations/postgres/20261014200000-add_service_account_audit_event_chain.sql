@@ -0,0 +1,24 @@
+-- +migrate Up
+ALTER TABLE service_account_audit_events
+    ADD COLUMN seq bigint NULL,
+    ADD COLUMN prev_hash varchar(64) NOT NULL DEFAULT '',
+    ADD COLUMN hash varchar(64) NOT NULL DEFAULT '';
+CREATE UNIQUE INDEX service_account_audit_events_seq_idx ON service_account_audit_events (seq);
+
+CREATE TABLE audit_chain_heads (
+    name varchar(100) NOT NULL PRIMARY KEY,
+    seq bigint NOT NULL DEFAULT 0,
+    hash varchar(64) NOT NULL DEFAULT '',
+    updated_at timestamptz(0) NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO audit_chain_heads (name) VALUES ('service_account_audit_events');
+
+-- +migrate Down
+DROP TABLE audit_chain_heads;
+
+DROP INDEX service_account_audit_events_seq_idx;
+ALTER TABLE service_account_audit_events
+    DROP COLUMN seq,
+    DROP COLUMN prev_hash,
+    DROP COLUMN hash;
@@ -0,0 +1,23 @@
+-- +migrate Up
+CREATE TABLE service_account_audit_archives (
+    day date NOT NULL PRIMARY KEY,
+    object varchar(255) NOT NULL DEFAULT '',
+    events int NOT NULL DEFAULT 0,
+    checksum varchar(64) NOT NULL DEFAULT '',
+    archived_at timestamptz(0) NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE audit_chain_heads
+    ADD COLUMN pruned_seq bigint NOT NULL DEFAULT 0,
+    ADD COLUMN pruned_hash varchar(64) NOT NULL DEFAULT '';
+
+CREATE INDEX service_account_audit_events_created_idx ON service_account_audit_events (created_at);
+
+-- +migrate Down
+DROP INDEX service_account_audit_events_created_idx;
+
+ALTER TABLE audit_chain_heads
+    DROP COLUMN pruned_seq,
+    DROP COLUMN pruned_hash;
+
+DROP TABLE service_account_audit_archives;
@@ -0,0 +1,24 @@
+-- +migrate Up
+CREATE TABLE broadcasts (
+    id varchar(36) NOT NULL PRIMARY KEY,
+    kind varchar(32) NOT NULL,
+    message text NOT NULL,
+    user_ids json NULL,
+    roles json NULL,
+    created_by varchar(100) NOT NULL,
+    created_at timestamptz(0) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at timestamptz(0) NOT NULL
+);
+CREATE INDEX broadcasts_expires_idx ON broadcasts (expires_at);
+
+CREATE TABLE broadcast_deliveries (
+    broadcast_id varchar(36) NOT NULL,
+    session_id varchar(36) NOT NULL,
+    user_id varchar(36) NOT NULL,
+    delivered_at timestamptz(0) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (broadcast_id, session_id)
+);
+
+-- +migrate Down
+DROP TABLE broadcast_deliveries;
+DROP TABLE broadcasts;
@@ -0,0 +1,19 @@
+-- +migrate Up
+ALTER TABLE users ADD COLUMN email varchar(255) NULL;
+CREATE INDEX users_email_idx ON users (email);
+
+CREATE TABLE email_suppressions (
+    address varchar(255) NOT NULL PRIMARY KEY,
+    reason varchar(20) NOT NULL,
+    provider varchar(20) NOT NULL,
+    detail varchar(1000) NOT NULL DEFAULT '',
+    feedbacks int NOT NULL DEFAULT 1,
+    created_at timestamptz(0) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamptz(0) NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX email_suppressions_updated_idx ON email_suppressions (updated_at);
+
+-- +migrate Down
+DROP TABLE email_suppressions;
+DROP INDEX users_email_idx;
+ALTER TABLE users DROP COLUMN email;
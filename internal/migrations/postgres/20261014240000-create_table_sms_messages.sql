@@ -0,0 +1,23 @@
+-- +migrate Up
+ALTER TABLE users ADD COLUMN phone varchar(20) NULL;
+
+CREATE TABLE sms_messages (
+    id varchar(36) NOT NULL PRIMARY KEY,
+    user_id varchar(36) NOT NULL,
+    provider varchar(20) NOT NULL DEFAULT '',
+    provider_message_id varchar(100) NOT NULL DEFAULT '',
+    route varchar(10) NOT NULL,
+    attempts int NOT NULL,
+    segments int NOT NULL,
+    cost numeric(12, 6) NOT NULL DEFAULT 0,
+    status varchar(20) NOT NULL,
+    error_code varchar(50) NOT NULL DEFAULT '',
+    created_at timestamptz(0) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamptz(0) NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX sms_messages_provider_idx ON sms_messages (provider, provider_message_id);
+CREATE INDEX sms_messages_user_idx ON sms_messages (user_id, created_at);
+
+-- +migrate Down
+DROP TABLE sms_messages;
+ALTER TABLE users DROP COLUMN phone;
@@ -0,0 +1,16 @@
+-- +migrate Up
+CREATE TABLE message_templates (
+    id varchar(36) NOT NULL PRIMARY KEY,
+    name varchar(100) NOT NULL,
+    channel varchar(20) NOT NULL,
+    version int NOT NULL,
+    subject varchar(255) NOT NULL DEFAULT '',
+    body text NOT NULL,
+    reset boolean NOT NULL DEFAULT FALSE,
+    created_by varchar(100) NOT NULL,
+    created_at timestamptz(0) NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX message_templates_version_idx ON message_templates (name, channel, version);
+
+-- +migrate Down
+DROP TABLE message_templates;
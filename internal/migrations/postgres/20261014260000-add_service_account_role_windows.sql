@@ -0,0 +1,11 @@
+-- +migrate Up
+ALTER TABLE service_account_roles
+    ADD COLUMN starts_at timestamptz(0) NULL,
+    ADD COLUMN ends_at timestamptz(0) NULL;
+CREATE INDEX service_account_roles_ends_at_idx ON service_account_roles (ends_at);
+
+-- +migrate Down
+DROP INDEX service_account_roles_ends_at_idx;
+ALTER TABLE service_account_roles
+    DROP COLUMN starts_at,
+    DROP COLUMN ends_at;
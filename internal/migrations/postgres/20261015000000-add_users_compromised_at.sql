@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE users ADD COLUMN compromised_at timestamptz(0) NULL;
+
+-- +migrate Down
+ALTER TABLE users DROP COLUMN compromised_at;
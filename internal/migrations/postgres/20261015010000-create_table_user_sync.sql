@@ -0,0 +1,30 @@
+-- +migrate Up
+CREATE TABLE user_sync_links (
+    source varchar(20) NOT NULL,
+    external_id varchar(100) NOT NULL,
+    user_id varchar(36) NOT NULL,
+    synced_at timestamptz(0) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (source, external_id),
+    CONSTRAINT user_sync_links_user_fk FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE TABLE user_sync_runs (
+    id varchar(36) NOT NULL PRIMARY KEY,
+    source varchar(20) NOT NULL,
+    dry_run boolean NOT NULL DEFAULT FALSE,
+    status varchar(10) NOT NULL,
+    created int NOT NULL DEFAULT 0,
+    updated int NOT NULL DEFAULT 0,
+    deactivated int NOT NULL DEFAULT 0,
+    skipped int NOT NULL DEFAULT 0,
+    unchanged int NOT NULL DEFAULT 0,
+    changes json NULL,
+    error varchar(1000) NULL,
+    started_at timestamptz(0) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finished_at timestamptz(0) NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX user_sync_runs_source_idx ON user_sync_runs (source, started_at);
+
+-- +migrate Down
+DROP TABLE user_sync_runs;
+DROP TABLE user_sync_links;
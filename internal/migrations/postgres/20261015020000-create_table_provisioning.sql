@@ -0,0 +1,45 @@
+-- +migrate Up
+CREATE TABLE provisioning_states (
+    connector varchar(20) NOT NULL,
+    user_id varchar(36) NOT NULL,
+    account_key varchar(255) NOT NULL,
+    hash varchar(64) NOT NULL,
+    active boolean NOT NULL DEFAULT FALSE,
+    pushed_at timestamptz(0) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (connector, user_id)
+);
+
+CREATE TABLE provisioning_errors (
+    connector varchar(20) NOT NULL,
+    user_id varchar(36) NOT NULL,
+    error varchar(1000) NOT NULL,
+    attempts int NOT NULL DEFAULT 0,
+    next_attempt_at timestamptz(0) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at timestamptz(0) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamptz(0) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (connector, user_id)
+);
+
+CREATE TABLE provisioning_runs (
+    id varchar(36) NOT NULL PRIMARY KEY,
+    connector varchar(20) NOT NULL,
+    status varchar(10) NOT NULL,
+    pushed int NOT NULL DEFAULT 0,
+    deactivated int NOT NULL DEFAULT 0,
+    failed int NOT NULL DEFAULT 0,
+    deferred int NOT NULL DEFAULT 0,
+    unchanged int NOT NULL DEFAULT 0,
+    missing int NOT NULL DEFAULT 0,
+    orphaned int NOT NULL DEFAULT 0,
+    mismatched int NOT NULL DEFAULT 0,
+    drifts json NULL,
+    error varchar(1000) NULL,
+    started_at timestamptz(0) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finished_at timestamptz(0) NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX provisioning_runs_connector_idx ON provisioning_runs (connector, started_at);
+
+-- +migrate Down
+DROP TABLE provisioning_runs;
+DROP TABLE provisioning_errors;
+DROP TABLE provisioning_states;
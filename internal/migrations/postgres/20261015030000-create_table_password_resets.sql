@@ -0,0 +1,16 @@
+-- +migrate Up
+CREATE TABLE password_resets (
+    id varchar(36) NOT NULL PRIMARY KEY,
+    user_id varchar(36) NOT NULL,
+    token_hash varchar(64) NOT NULL,
+    channel varchar(10) NOT NULL,
+    created_at timestamptz(0) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at timestamptz(0) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    used_at timestamptz(0) NULL,
+    CONSTRAINT password_resets_user_fk FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX password_resets_token_hash_idx ON password_resets (token_hash);
+CREATE INDEX password_resets_user_idx ON password_resets (user_id);
+
+-- +migrate Down
+DROP TABLE password_resets;
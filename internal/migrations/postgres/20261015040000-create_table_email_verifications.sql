@@ -0,0 +1,16 @@
+-- +migrate Up
+CREATE TABLE email_verifications (
+    id varchar(36) NOT NULL PRIMARY KEY,
+    user_id varchar(36) NOT NULL,
+    email varchar(255) NOT NULL,
+    token_hash varchar(64) NOT NULL,
+    created_at timestamptz(0) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at timestamptz(0) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    verified_at timestamptz(0) NULL,
+    CONSTRAINT email_verifications_user_fk FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX email_verifications_token_hash_idx ON email_verifications (token_hash);
+CREATE INDEX email_verifications_user_idx ON email_verifications (user_id);
+
+-- +migrate Down
+DROP TABLE email_verifications;
@@ -0,0 +1,56 @@
+-- +migrate Up
+CREATE TABLE roles (
+    name varchar(100) NOT NULL PRIMARY KEY,
+    description varchar(255) NOT NULL DEFAULT '',
+    created_at timestamptz(0) NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE permissions (
+    name varchar(100) NOT NULL PRIMARY KEY,
+    description varchar(255) NOT NULL DEFAULT ''
+);
+
+CREATE TABLE role_permissions (
+    role varchar(100) NOT NULL,
+    permission varchar(100) NOT NULL,
+    PRIMARY KEY (role, permission),
+    CONSTRAINT role_permissions_role_fk FOREIGN KEY (role) REFERENCES roles (name) ON DELETE CASCADE,
+    CONSTRAINT role_permissions_permission_fk FOREIGN KEY (permission) REFERENCES permissions (name) ON DELETE CASCADE
+);
+
+CREATE TABLE user_roles (
+    user_id varchar(36) NOT NULL,
+    role varchar(100) NOT NULL,
+    assigned_by varchar(36) NOT NULL DEFAULT '',
+    created_at timestamptz(0) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, role),
+    CONSTRAINT user_roles_user_fk FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    CONSTRAINT user_roles_role_fk FOREIGN KEY (role) REFERENCES roles (name) ON DELETE CASCADE
+);
+CREATE INDEX user_roles_role_idx ON user_roles (role);
+
+INSERT INTO permissions (name, description) VALUES
+    ('*', 'every permission'),
+    ('users:read', 'read the users'),
+    ('users:write', 'create and update the users'),
+    ('roles:read', 'read the roles and the roles of the users'),
+    ('roles:write', 'assign and unassign the roles of the users'),
+    ('profile:read', 'read its own profile'),
+    ('profile:write', 'update its own profile');
+
+INSERT INTO roles (name, description) VALUES
+    ('admin', 'administrators, granted every permission'),
+    ('user', 'every user, granted by default');
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', '*'),
+    ('user', 'profile:read'),
+    ('user', 'profile:write');
+
+INSERT INTO user_roles (user_id, role) SELECT id, 'admin' FROM users WHERE username = 'admin';
+
+-- +migrate Down
+DROP TABLE user_roles;
+DROP TABLE role_permissions;
+DROP TABLE permissions;
+DROP TABLE roles;
@@ -0,0 +1,9 @@
+-- +migrate Up
+ALTER TABLE sessions ADD COLUMN ip_address varchar(45) NULL;
+ALTER TABLE sessions ADD COLUMN user_agent varchar(512) NULL;
+ALTER TABLE sessions ADD COLUMN last_used_at timestamptz(0) NULL;
+
+-- +migrate Down
+ALTER TABLE sessions DROP COLUMN last_used_at;
+ALTER TABLE sessions DROP COLUMN user_agent;
+ALTER TABLE sessions DROP COLUMN ip_address;
@@ -0,0 +1,13 @@
+-- +migrate Up
+ALTER TABLE tenant_settings
+    ADD COLUMN logo_url varchar(2048) NULL,
+    ADD COLUMN primary_color varchar(7) NULL,
+    ADD COLUMN accent_color varchar(7) NULL,
+    ADD COLUMN support_email varchar(255) NULL;
+
+-- +migrate Down
+ALTER TABLE tenant_settings
+    DROP COLUMN logo_url,
+    DROP COLUMN primary_color,
+    DROP COLUMN accent_color,
+    DROP COLUMN support_email;
@@ -0,0 +1,9 @@
+-- +migrate Up
+ALTER TABLE sessions ADD COLUMN client_app varchar(64) NULL;
+ALTER TABLE sessions ADD COLUMN client_version varchar(64) NULL;
+ALTER TABLE sessions ADD COLUMN client_platform varchar(64) NULL;
+
+-- +migrate Down
+ALTER TABLE sessions DROP COLUMN client_platform;
+ALTER TABLE sessions DROP COLUMN client_version;
+ALTER TABLE sessions DROP COLUMN client_app;
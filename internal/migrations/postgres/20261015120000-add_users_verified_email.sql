@@ -0,0 +1,9 @@
+-- +migrate Up notransaction
+-- CockroachDB cannot update a column added in the same transaction
+ALTER TABLE users ADD COLUMN IF NOT EXISTS verified_email varchar(255) NULL;
+
+UPDATE users u SET verified_email = u.email FROM email_verifications v
+WHERE v.user_id = u.id AND v.email = u.email AND v.verified_at IS NOT NULL;
+
+-- +migrate Down
+ALTER TABLE users DROP COLUMN verified_email;
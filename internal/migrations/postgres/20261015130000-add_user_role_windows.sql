@@ -0,0 +1,11 @@
+-- +migrate Up
+ALTER TABLE user_roles
+    ADD COLUMN starts_at timestamptz(0) NULL,
+    ADD COLUMN ends_at timestamptz(0) NULL;
+CREATE INDEX user_roles_ends_at_idx ON user_roles (ends_at);
+
+-- +migrate Down
+DROP INDEX user_roles_ends_at_idx;
+ALTER TABLE user_roles
+    DROP COLUMN starts_at,
+    DROP COLUMN ends_at;
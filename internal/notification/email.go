@@ -0,0 +1,162 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"go-hex/pkg/otel"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// SMTPNotifier delivers the emails through a SMTP relay, the connection is upgraded with
+// STARTTLS when the relay supports it and authenticated when a username is given.
+type SMTPNotifier struct {
+	address string
+	from    string
+	auth    smtp.Auth
+	timeout time.Duration
+}
+
+// NewSMTPNotifier creates a new SMTP notifier sending from the given address
+func NewSMTPNotifier(address, username, password, from string, timeout time.Duration) *SMTPNotifier {
+	var auth smtp.Auth
+	if username != "" {
+		host, _, _ := net.SplitHostPort(address)
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTPNotifier{address, from, auth, timeout}
+}
+
+// Channel returns the channel the notifier delivers to.
+func (n *SMTPNotifier) Channel() Channel {
+	return ChannelEmail
+}
+
+// Notify sends the message as a plain text email to its recipient.
+func (n *SMTPNotifier) Notify(ctx context.Context, msg Message) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return errors.Wrap(err, "cannot parse email recipient")
+	}
+	from, err := mail.ParseAddress(n.from)
+	if err != nil {
+		return errors.Wrap(err, "cannot parse email sender")
+	}
+	body, err := n.message(from, to, msg)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, n.timeout)
+	defer cancel()
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", n.address)
+	if err != nil {
+		return errors.Wrap(err, "cannot connect to smtp relay")
+	}
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return errors.Wrap(err, "cannot connect to smtp relay")
+	}
+
+	host, _, _ := net.SplitHostPort(n.address)
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return errors.Wrap(err, "cannot connect to smtp relay")
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return errors.Wrap(err, "cannot start tls with smtp relay")
+		}
+	}
+	if n.auth != nil {
+		if err := c.Auth(n.auth); err != nil {
+			return errors.Wrap(err, "cannot authenticate to smtp relay")
+		}
+	}
+	if err := c.Mail(from.Address); err != nil {
+		return errors.Wrap(err, "cannot send email")
+	}
+	if err := c.Rcpt(to.Address); err != nil {
+		return errors.Wrap(err, "cannot send email")
+	}
+	w, err := c.Data()
+	if err != nil {
+		return errors.Wrap(err, "cannot send email")
+	}
+	if _, err := w.Write(body); err != nil {
+		return errors.Wrap(err, "cannot send email")
+	}
+	if err := w.Close(); err != nil {
+		return errors.Wrap(err, "cannot send email")
+	}
+	return c.Quit()
+}
+
+// message returns the email of the message, its body encoded quoted-printable, with its HTML alternative when set
+func (n *SMTPNotifier) message(from, to *mail.Address, msg Message) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", to.String())
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Title))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	if msg.HTML == "" {
+		buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+		buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+		if err := encode(&buf, msg.Body); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	w := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", w.Boundary())
+	for _, part := range []struct{ contentType, content string }{{"text/plain", msg.Body}, {"text/html", msg.HTML}} {
+		pw, err := w.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType + "; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot encode email")
+		}
+		if err := encode(pw, part.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, errors.Wrap(err, "cannot encode email")
+	}
+	return buf.Bytes(), nil
+}
+
+// encode writes the content encoded quoted-printable
+func encode(w io.Writer, content string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(content)); err != nil {
+		return errors.Wrap(err, "cannot encode email")
+	}
+	if err := qp.Close(); err != nil {
+		return errors.Wrap(err, "cannot encode email")
+	}
+	return nil
+}
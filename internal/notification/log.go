@@ -0,0 +1,33 @@
+package notification
+
+import (
+	"context"
+	"go-hex/pkg/logger"
+)
+
+// LogNotifier writes the messages to the log instead of delivering them, it is meant for local development.
+type LogNotifier struct {
+	channel Channel
+	log     logger.Logger
+}
+
+// NewLogNotifier creates a new log notifier for the given channel
+func NewLogNotifier(channel Channel, log logger.Logger) *LogNotifier {
+	return &LogNotifier{channel, log}
+}
+
+// Channel returns the channel the notifier delivers to.
+func (n *LogNotifier) Channel() Channel {
+	return n.channel
+}
+
+// Notify writes the message to the log.
+func (n *LogNotifier) Notify(ctx context.Context, msg Message) error {
+	n.log.WithParams(logger.Params{
+		"type":    "notification",
+		"channel": n.channel,
+		"user_id": msg.UserID,
+		"title":   msg.Title,
+	}).Info(msg.Body)
+	return nil
+}
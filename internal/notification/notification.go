@@ -0,0 +1,103 @@
+// Package notification delivers messages to users through the configured channels.
+package notification
+
+import (
+	"context"
+	"go-hex/pkg/otel"
+
+	"github.com/pkg/errors"
+)
+
+// Channel is a delivery channel of a notification
+type Channel string
+
+// Available notification channels
+const (
+	ChannelPush  Channel = "push"
+	ChannelEmail Channel = "email"
+	ChannelSMS   Channel = "sms"
+)
+
+// Message is a notification addressed to a user.
+type Message struct {
+	UserID string            `json:"user_id"`
+	To     string            `json:"to,omitempty"` // address of the recipient, for the email and sms channels
+	Title  string            `json:"title"`
+	Body   string            `json:"body"`
+	Data   map[string]string `json:"data,omitempty"`
+	// HTML is the HTML alternative of the body of an email, laid out by the renderer
+	HTML string `json:"-"`
+
+	// Template is the name of the transactional message rendering the title and the body on the channel,
+	// from the variables, when the dispatcher has a renderer
+	Template  string            `json:"-"`
+	Variables map[string]string `json:"-"`
+}
+
+// Renderer renders the transactional messages.
+type Renderer interface {
+	// Render returns the subject and the body of the message on the channel.
+	Render(ctx context.Context, name string, channel Channel, variables map[string]string) (subject string, body string, err error)
+}
+
+// HTMLRenderer is a Renderer also laying out the emails in HTML.
+type HTMLRenderer interface {
+	Renderer
+	// RenderHTML returns the HTML of the email rendered with the subject and the body.
+	RenderHTML(ctx context.Context, subject string, body string, variables map[string]string) (string, error)
+}
+
+// Notifier sends a message through a single channel.
+type Notifier interface {
+	// Channel returns the channel the notifier delivers to.
+	Channel() Channel
+	// Notify sends the message.
+	Notify(ctx context.Context, msg Message) error
+}
+
+// Dispatcher sends messages through the registered notifiers.
+type Dispatcher struct {
+	notifiers map[Channel]Notifier
+	renderer  Renderer
+}
+
+// NewDispatcher creates a new dispatcher for the given notifiers
+func NewDispatcher(notifiers ...Notifier) *Dispatcher {
+	d := &Dispatcher{notifiers: map[Channel]Notifier{}}
+	for _, n := range notifiers {
+		d.notifiers[n.Channel()] = n
+	}
+	return d
+}
+
+// WithRenderer renders the templates of the messages with the given renderer
+func (d *Dispatcher) WithRenderer(renderer Renderer) *Dispatcher {
+	d.renderer = renderer
+	return d
+}
+
+// Send sends the message through the given channel, the template of the message replaces its title and body.
+func (d *Dispatcher) Send(ctx context.Context, channel Channel, msg Message) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	n, ok := d.notifiers[channel]
+	if !ok {
+		return errors.Errorf("notification channel %s is not registered", channel)
+	}
+	if msg.Template != "" && d.renderer != nil {
+		subject, body, err := d.renderer.Render(ctx, msg.Template, channel, msg.Variables)
+		if err != nil {
+			return errors.Wrapf(err, "cannot render message %s", msg.Template)
+		}
+		msg.Title, msg.Body = subject, body
+		if html, ok := d.renderer.(HTMLRenderer); ok && channel == ChannelEmail {
+			msg.HTML, err = html.RenderHTML(ctx, subject, body, msg.Variables)
+			if err != nil {
+				return errors.Wrapf(err, "cannot render message %s", msg.Template)
+			}
+		}
+	}
+	return n.Notify(ctx, msg)
+}
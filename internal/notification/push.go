@@ -0,0 +1,62 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"go-hex/pkg/otel"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// PushNotifier delivers push notifications through an HTTP push gateway
+// which resolves the devices of the user (e.g. a FCM/APNs relay).
+type PushNotifier struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// NewPushNotifier creates a new push notifier
+func NewPushNotifier(url, token string, timeout time.Duration) *PushNotifier {
+	return &PushNotifier{url, token, &http.Client{Timeout: timeout}}
+}
+
+// Channel returns the channel the notifier delivers to.
+func (n *PushNotifier) Channel() Channel {
+	return ChannelPush
+}
+
+// Notify posts the message to the push gateway.
+func (n *PushNotifier) Notify(ctx context.Context, msg Message) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return errors.Wrap(err, "cannot marshal push notification")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "cannot create push request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.token)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "cannot send push notification")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return errors.Errorf("push gateway responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package notification
+
+import (
+	"context"
+	"go-hex/pkg/metrics"
+	"go-hex/pkg/otel"
+	"go-hex/shared/ierr"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var suppressed = metrics.NewCounterVec(prometheus.CounterOpts{
+	Name: "notifications_suppressed_total",
+	Help: "Number of notifications not sent because their recipient is suppressed, by channel.",
+}, "channel")
+
+// Suppressions tells whether the messages to an address must not be sent
+type Suppressions interface {
+	// IsSuppressed checks whether the address is suppressed.
+	IsSuppressed(ctx context.Context, address string) (bool, error)
+}
+
+// SuppressingNotifier does not send the messages whose recipient is suppressed, e.g. the emails to the addresses
+// which bounced, and returns ierr.ErrEmailUndeliverable instead. The messages without recipient are sent.
+type SuppressingNotifier struct {
+	Notifier
+	suppressions Suppressions
+}
+
+// NewSuppressingNotifier creates a notifier suppressing the messages of the given notifier
+func NewSuppressingNotifier(n Notifier, suppressions Suppressions) *SuppressingNotifier {
+	return &SuppressingNotifier{n, suppressions}
+}
+
+// Notify sends the message unless its recipient is suppressed.
+func (n *SuppressingNotifier) Notify(ctx context.Context, msg Message) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	if msg.To != "" {
+		ok, err := n.suppressions.IsSuppressed(ctx, msg.To)
+		if err != nil {
+			return errors.Wrap(err, "cannot check notification suppression")
+		}
+		if ok {
+			suppressed.WithLabelValues(string(n.Channel())).Inc()
+			return ierr.ErrEmailUndeliverable
+		}
+	}
+	return n.Notifier.Notify(ctx, msg)
+}
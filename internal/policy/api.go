@@ -0,0 +1,56 @@
+package policy
+
+import (
+	"go-hex/configs"
+	"go-hex/middleware"
+	"go-hex/shared/ierr"
+	"go-hex/shared/response"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+// RegisterAPI registers a new policy simulation api
+func RegisterAPI(r echo.Group, cfg *configs.Config, service ServicePort) {
+	handler := handler{cfg, service}
+
+	// Internal endpoints
+	internal := r.Group("/internal", middleware.InternalAPI(cfg.InternalAPI.User, cfg.InternalAPI.Password))
+	internal.POST("/policy/simulate", handler.simulate)
+}
+
+type handler struct {
+	cfg     *configs.Config
+	service ServicePort
+}
+
+// simulate godoc
+// @Router /internal/policy/simulate [post]
+// @Tags Policy
+// @Summary Simulate an authorization
+// @Description Evaluate whether a user or a service account is granted the role required by a route at a given time, with the matched rules, without changing anything
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Param payload body RequestSimulate true " "
+// @Success 200 {object} response.Response{data=ResponseSimulate} "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 404 {object} response.ErrorResponse404
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) simulate(c echo.Context) error {
+	var req RequestSimulate
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.Simulate(c.Request().Context(), req)
+	if err != nil {
+		if errors.Cause(err) == ierr.ErrResourceNotFound {
+			return response.ErrNotFound(err)
+		}
+		return err
+	}
+
+	return response.SuccessOK(c, res, "authorization simulated")
+}
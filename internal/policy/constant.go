@@ -0,0 +1,16 @@
+package policy
+
+// Effects of the rules
+const (
+	EffectAllow = "allow"
+	EffectDeny  = "deny"
+)
+
+// Sources of the rules matched by a simulation
+const (
+	SourceServiceAccountRole     = "service_account_role"
+	SourceElevation              = "elevation"
+	SourceServiceAccountDisabled = "service_account_disabled"
+	SourceUserInactive           = "user_inactive"
+	SourceBreakGlassNotActive    = "break_glass_not_active"
+)
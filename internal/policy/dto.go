@@ -0,0 +1,49 @@
+package policy
+
+import (
+	"go-hex/internal/domain"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// RequestSimulate request body
+type RequestSimulate struct {
+	PrincipalType string     `json:"principal_type" example:"service_account"` // user or service_account
+	PrincipalID   string     `json:"principal_id" example:"8d8ac610-566d-4ef0-9c22-186b2a5ed793"`
+	Action        string     `json:"action" example:"analytics:read"`                    // role required by the route
+	Resource      string     `json:"resource" example:"GET /internal/analytics/clients"` // informative, the roles apply to every resource
+	At            *time.Time `json:"at" example:"2026-10-14T12:00:00Z"`                  // evaluated now when unset
+}
+
+func (r *RequestSimulate) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.PrincipalType, validation.Required, validation.In(domain.PrincipalTypeUser, domain.PrincipalTypeServiceAccount)),
+		validation.Field(&r.PrincipalID, validation.Required),
+		validation.Field(&r.Action, validation.Required, validation.Length(1, 100)),
+		validation.Field(&r.Resource, validation.Length(0, 255)),
+	)
+}
+
+// Rule is a rule of the authorization matched by a simulation
+type Rule struct {
+	Effect   string     `json:"effect" example:"allow"`
+	Source   string     `json:"source" example:"service_account_role"`
+	Role     string     `json:"role,omitempty" example:"analytics:read"`
+	ID       string     `json:"id,omitempty"` // of the elevation granting the role
+	Detail   string     `json:"detail" example:"role bound to the service account"`
+	StartsAt *time.Time `json:"starts_at,omitempty"`
+	EndsAt   *time.Time `json:"ends_at,omitempty"`
+}
+
+// ResponseSimulate struct
+type ResponseSimulate struct {
+	Allowed       bool      `json:"allowed" example:"true"`
+	Reason        string    `json:"reason" example:"granted by 1 rule"`
+	PrincipalType string    `json:"principal_type" example:"service_account"`
+	PrincipalID   string    `json:"principal_id" example:"8d8ac610-566d-4ef0-9c22-186b2a5ed793"`
+	Action        string    `json:"action" example:"analytics:read"`
+	Resource      string    `json:"resource,omitempty" example:"GET /internal/analytics/clients"`
+	At            time.Time `json:"at"`
+	Rules         []Rule    `json:"rules"` // matched, the deny rules override the allow rules
+}
@@ -0,0 +1,9 @@
+package policy
+
+import "context"
+
+// ServicePort encapsulates the policy simulation logic.
+type ServicePort interface {
+	// Simulate evaluates whether the principal is granted the action at the given time
+	Simulate(ctx context.Context, req RequestSimulate) (ResponseSimulate, error)
+}
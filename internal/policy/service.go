@@ -0,0 +1,173 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/times"
+	"go-hex/shared/ierr"
+	"time"
+)
+
+// Service simulates the authorization of the routes guarded by a role, as done by middleware.InternalAPIOrRole
+// with the roles issued in the access tokens: the roles bound to the service accounts, within their window,
+// and the roles of the approved elevations of the users. The simulation reads the stored grants and changes
+// nothing, so that it can be run safely against the production configuration.
+type Service struct {
+	repoRegitry port.RepositoryRegistry
+}
+
+// NewService creates and returns a new policy simulation service
+func NewService(repoRegitry port.RepositoryRegistry) *Service {
+	return &Service{repoRegitry}
+}
+
+// Simulate evaluates whether the principal is granted the action at the given time and returns the matched
+// rules, a deny rule, e.g. a disabled service account, overrides the rules granting the role.
+func (s *Service) Simulate(ctx context.Context, req RequestSimulate) (ResponseSimulate, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return ResponseSimulate{}, err
+	}
+
+	at := times.Now()
+	if req.At != nil {
+		at = *req.At
+	}
+
+	var rules []Rule
+	switch req.PrincipalType {
+	case domain.PrincipalTypeServiceAccount:
+		rules, err = s.serviceAccountRules(ctx, req.PrincipalID, req.Action, at)
+	default:
+		rules, err = s.userRules(ctx, req.PrincipalID, req.Action, at)
+	}
+	if err != nil {
+		return ResponseSimulate{}, err
+	}
+
+	res := ResponseSimulate{
+		PrincipalType: req.PrincipalType,
+		PrincipalID:   req.PrincipalID,
+		Action:        req.Action,
+		Resource:      req.Resource,
+		At:            at,
+		Rules:         rules,
+	}
+	res.Allowed, res.Reason = decide(rules)
+	return res, nil
+}
+
+// serviceAccountRules returns the rules of the service account matching the action at the given time:
+// the bindings of the role active then, and a deny rule when the service account is disabled.
+func (s *Service) serviceAccountRules(ctx context.Context, accountID string, action string, at time.Time) ([]Rule, error) {
+	repo := s.repoRegitry.GetServiceAccountRepository()
+	account, err := repo.GetByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	bindings, err := repo.ListRoles(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := []Rule{}
+	if !account.IsActive {
+		rules = append(rules, Rule{
+			Effect: EffectDeny,
+			Source: SourceServiceAccountDisabled,
+			Detail: "the service account is disabled and cannot obtain tokens",
+		})
+	}
+	for _, binding := range bindings {
+		if binding.Role != action || !binding.IsActiveAt(at) {
+			continue
+		}
+		rules = append(rules, Rule{
+			Effect:   EffectAllow,
+			Source:   SourceServiceAccountRole,
+			Role:     binding.Role,
+			Detail:   "role bound to the service account",
+			StartsAt: binding.StartsAt,
+			EndsAt:   binding.EndsAt,
+		})
+	}
+	return rules, nil
+}
+
+// userRules returns the rules of the user matching the action at the given time: the approved elevations
+// to the role active then, and the deny rules of the users that cannot log in.
+func (s *Service) userRules(ctx context.Context, userID string, action string, at time.Time) ([]Rule, error) {
+	user, err := s.repoRegitry.GetUserRepository().GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	elevations, err := s.repoRegitry.GetElevationRepository().ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := []Rule{}
+	if !user.IsActive {
+		rules = append(rules, Rule{
+			Effect: EffectDeny,
+			Source: SourceUserInactive,
+			Detail: "the user is inactive and cannot log in",
+		})
+	}
+
+	account, err := s.repoRegitry.GetBreakGlassAccountRepository().GetByUserID(ctx, userID)
+	switch {
+	case err == ierr.ErrResourceNotFound:
+	case err != nil:
+		return nil, err
+	case !account.IsActiveAt(at):
+		rules = append(rules, Rule{
+			Effect: EffectDeny,
+			Source: SourceBreakGlassNotActive,
+			Detail: fmt.Sprintf("the break-glass account is %s and cannot log in", account.Status),
+		})
+	}
+
+	for _, elevation := range elevations {
+		if elevation.Role != action || !grantedAt(elevation, at) {
+			continue
+		}
+		rules = append(rules, Rule{
+			Effect:   EffectAllow,
+			Source:   SourceElevation,
+			Role:     elevation.Role,
+			ID:       elevation.ID,
+			Detail:   fmt.Sprintf("elevation approved by %s", elevation.DecidedBy),
+			StartsAt: elevation.DecidedAt,
+			EndsAt:   elevation.EndsAt,
+		})
+	}
+	return rules, nil
+}
+
+// grantedAt checks whether the elevation was approved and had not ended at the given time
+func grantedAt(elevation domain.Elevation, at time.Time) bool {
+	return elevation.IsActiveAt(at) && elevation.DecidedAt != nil && !at.Before(*elevation.DecidedAt)
+}
+
+// decide returns the decision of the matched rules, any deny rule overrides the allow rules
+func decide(rules []Rule) (bool, string) {
+	allows := 0
+	for _, rule := range rules {
+		if rule.Effect == EffectDeny {
+			return false, "denied by " + rule.Source
+		}
+		allows++
+	}
+	if allows == 0 {
+		return false, "no rule grants the action"
+	}
+	return true, fmt.Sprintf("granted by %d rule(s)", allows)
+}
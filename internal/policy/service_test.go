@@ -0,0 +1,105 @@
+package policy
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/memory"
+	"go-hex/shared/ierr"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestStore returns a store holding the active service account sa1 and the active user u1
+func newTestStore(t *testing.T) *memory.Store {
+	t.Helper()
+	store := memory.NewStore()
+	require.NoError(t, store.GetServiceAccountRepository().Create(context.Background(), domain.ServiceAccount{ID: "sa1", IsActive: true}))
+	require.NoError(t, store.GetUserRepository().Create(context.Background(), domain.User{ID: "u1", Username: "jane", IsActive: true}))
+	return store
+}
+
+func TestSimulateServiceAccount(t *testing.T) {
+	now := time.Now()
+	later := now.Add(time.Hour)
+	store := newTestStore(t)
+	for _, binding := range []domain.ServiceAccountRole{
+		{ServiceAccountID: "sa1", Role: "analytics:read"},
+		{ServiceAccountID: "sa1", Role: "audit:read", StartsAt: &later},
+		{ServiceAccountID: "sa1", Role: "users:write"},
+	} {
+		require.NoError(t, store.GetServiceAccountRepository().BindRole(context.Background(), binding))
+	}
+	svc := NewService(store)
+
+	res, err := svc.Simulate(context.Background(), RequestSimulate{PrincipalType: domain.PrincipalTypeServiceAccount, PrincipalID: "sa1", Action: "analytics:read", At: &now})
+	assert.NoError(t, err)
+	assert.True(t, res.Allowed)
+	if assert.Len(t, res.Rules, 1) {
+		assert.Equal(t, SourceServiceAccountRole, res.Rules[0].Source)
+	}
+
+	// the window of a role is granted later on
+	res, err = svc.Simulate(context.Background(), RequestSimulate{PrincipalType: domain.PrincipalTypeServiceAccount, PrincipalID: "sa1", Action: "audit:read"})
+	assert.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Empty(t, res.Rules)
+
+	at := later.Add(time.Minute)
+	res, err = svc.Simulate(context.Background(), RequestSimulate{PrincipalType: domain.PrincipalTypeServiceAccount, PrincipalID: "sa1", Action: "audit:read", At: &at})
+	assert.NoError(t, err)
+	assert.True(t, res.Allowed)
+	assert.Len(t, res.Rules, 1)
+
+	// a disabled service account is denied whatever its roles
+	require.NoError(t, store.GetServiceAccountRepository().UpdateActive(context.Background(), "sa1", false))
+	res, err = svc.Simulate(context.Background(), RequestSimulate{PrincipalType: domain.PrincipalTypeServiceAccount, PrincipalID: "sa1", Action: "analytics:read", At: &now})
+	assert.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Len(t, res.Rules, 2)
+	assert.Equal(t, "denied by "+SourceServiceAccountDisabled, res.Reason)
+
+	_, err = svc.Simulate(context.Background(), RequestSimulate{PrincipalType: domain.PrincipalTypeServiceAccount, PrincipalID: "sa2", Action: "analytics:read"})
+	assert.Equal(t, ierr.ErrResourceNotFound, err)
+}
+
+func TestSimulateUser(t *testing.T) {
+	now := time.Now()
+	decidedAt, endsAt := now.Add(-10*time.Minute), now.Add(20*time.Minute)
+	store := newTestStore(t)
+	for _, elevation := range []domain.Elevation{
+		{ID: "e1", UserID: "u1", Role: "analytics:read", Status: domain.ElevationStatusApproved, DecidedBy: "u2", DecidedAt: &decidedAt, EndsAt: &endsAt},
+		{ID: "e2", UserID: "u1", Role: "analytics:read", Status: domain.ElevationStatusDenied, DecidedAt: &decidedAt},
+	} {
+		require.NoError(t, store.GetElevationRepository().Create(context.Background(), elevation))
+	}
+	svc := NewService(store)
+
+	res, err := svc.Simulate(context.Background(), RequestSimulate{PrincipalType: domain.PrincipalTypeUser, PrincipalID: "u1", Action: "analytics:read", At: &now})
+	assert.NoError(t, err)
+	assert.True(t, res.Allowed)
+	if assert.Len(t, res.Rules, 1) {
+		assert.Equal(t, "e1", res.Rules[0].ID)
+	}
+
+	// the elevation was not approved yet
+	before := decidedAt.Add(-time.Minute)
+	res, err = svc.Simulate(context.Background(), RequestSimulate{PrincipalType: domain.PrincipalTypeUser, PrincipalID: "u1", Action: "analytics:read", At: &before})
+	assert.NoError(t, err)
+	assert.False(t, res.Allowed)
+
+	// a sealed break-glass account cannot log in
+	require.NoError(t, store.GetBreakGlassAccountRepository().Seal(context.Background(), domain.BreakGlassAccount{UserID: "u1", Status: domain.BreakGlassStatusSealed}))
+	res, err = svc.Simulate(context.Background(), RequestSimulate{PrincipalType: domain.PrincipalTypeUser, PrincipalID: "u1", Action: "analytics:read", At: &now})
+	assert.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, SourceBreakGlassNotActive, res.Rules[0].Source)
+}
+
+func TestSimulateValidation(t *testing.T) {
+	svc := NewService(newTestStore(t))
+	_, err := svc.Simulate(context.Background(), RequestSimulate{PrincipalType: "group", PrincipalID: "g1", Action: "analytics:read"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,107 @@
+package probe
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-hex/pkg/logger"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Statuses of the alerts
+const (
+	AlertFiring   = "firing"
+	AlertResolved = "resolved"
+)
+
+// Alert is posted to the webhook as JSON once the cycles failed threshold times in a row, and once they pass again
+type Alert struct {
+	Status   string    `json:"status"`
+	Check    string    `json:"check"`
+	Target   string    `json:"target"`
+	Failures int       `json:"failures"`
+	Step     string    `json:"step,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	At       time.Time `json:"at"`
+}
+
+// Alerter counts the cycles failed in a row and notifies the webhook when the alert fires and when it is resolved,
+// a firing alert is not sent again until resolved. An empty webhook URL only logs the alerts.
+type Alerter struct {
+	client    *http.Client
+	url       string
+	target    string
+	threshold int
+	log       logger.Logger
+
+	failures int
+	firing   bool
+}
+
+// NewAlerter creates an alerter firing after threshold failed cycles of the probe of target
+func NewAlerter(client *http.Client, url, target string, threshold int, log logger.Logger) *Alerter {
+	return &Alerter{client: client, url: url, target: target, threshold: threshold, log: log}
+}
+
+// Observe counts the result of a cycle and sends the alert it fires or resolves, if any
+func (a *Alerter) Observe(ctx context.Context, res Result, at time.Time) {
+
+	if res.Passed() {
+		a.failures = 0
+		if a.firing {
+			a.firing = false
+			a.send(ctx, Alert{Status: AlertResolved, At: at})
+		}
+		return
+	}
+
+	a.failures++
+	if a.firing || a.failures < a.threshold {
+		return
+	}
+	a.firing = true
+	a.send(ctx, Alert{Status: AlertFiring, Failures: a.failures, Step: res.Step, Error: res.Err.Error(), At: at})
+}
+
+// send posts the alert to the webhook, a failure is logged and not retried: the metrics still report the cycles
+func (a *Alerter) send(ctx context.Context, alert Alert) {
+	alert.Check, alert.Target = "synthetic_login_cycle", a.target
+
+	log := a.log.With(ctx).WithParams(logger.Params{"alert": alert.Status, "step": alert.Step, "failures": alert.Failures})
+	if alert.Status == AlertFiring {
+		log.Errorf("synthetic probe failing: %s", alert.Error)
+	} else {
+		log.Info("synthetic probe passing again")
+	}
+	if a.url == "" {
+		return
+	}
+	if err := a.post(ctx, alert); err != nil {
+		log.Error(errors.Wrap(err, "cannot send the alert of the synthetic probe"))
+	}
+}
+
+func (a *Alerter) post(ctx context.Context, alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("the webhook answered %d", res.StatusCode)
+	}
+	return nil
+}
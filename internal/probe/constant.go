@@ -0,0 +1,9 @@
+package probe
+
+import "time"
+
+// userAgent identifies the requests of the probe in the logs and the analytics of the api
+const userAgent = "go-hex-probe"
+
+// cleanupTimeout bounds the logout of the session left by a failed cycle, whose context may have expired
+const cleanupTimeout = 5 * time.Second
@@ -0,0 +1,250 @@
+// Package probe runs the synthetic checks of the service: the login, refresh, authorized call and logout cycle of
+// a canary user against the running api, the way the clients use it, reported as metrics and alerts.
+package probe
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-hex/pkg/metrics"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	probeRuns = metrics.NewCounterVec(prometheus.CounterOpts{
+		Name: "synthetic_probe_runs_total",
+		Help: "Number of synthetic cycles run, by result and by the step failing them.",
+	}, "result", "step")
+	probeStepDuration = metrics.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "synthetic_probe_step_duration_seconds",
+		Help:    "Duration of the steps of the synthetic cycles.",
+		Buckets: prometheus.DefBuckets,
+	}, "step")
+	probeSuccess = metrics.NewGauge(prometheus.GaugeOpts{
+		Name: "synthetic_probe_success",
+		Help: "Whether the last synthetic cycle passed, 1, or failed, 0.",
+	})
+	probeLastSuccess = metrics.NewGauge(prometheus.GaugeOpts{
+		Name: "synthetic_probe_last_success_timestamp_seconds",
+		Help: "Unix time of the last synthetic cycle passed.",
+	})
+)
+
+// Steps of a cycle
+const (
+	StepLogin   = "login"
+	StepRefresh = "refresh"
+	StepCall    = "authorized_call"
+	StepLogout  = "logout"
+)
+
+// Result is the outcome of a cycle, Step and Err are set when a step failed
+type Result struct {
+	Step      string
+	Err       error
+	Durations map[string]time.Duration
+}
+
+// Passed tells whether every step of the cycle passed
+func (r Result) Passed() bool {
+	return r.Err == nil
+}
+
+// Prober runs the cycles of the canary user against the api at baseURL
+type Prober struct {
+	client    *http.Client
+	baseURL   string
+	username  string
+	password  string
+	camelCase bool
+}
+
+// NewProber creates a prober logging in the canary user, camelCase names the fields of the bodies like JSON_NAMING
+func NewProber(client *http.Client, baseURL, username, password string, camelCase bool) *Prober {
+	return &Prober{
+		client:    client,
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		username:  username,
+		password:  password,
+		camelCase: camelCase,
+	}
+}
+
+// Run runs a cycle: it logs the canary user in, refreshes its tokens, reads its profile with the refreshed access
+// token and logs it out, stopping at the first step failing. The session of a failed cycle is still logged out.
+// The result is recorded in the metrics.
+func (p *Prober) Run(ctx context.Context) Result {
+
+	res := Result{Durations: map[string]time.Duration{}}
+	var accessToken string
+	defer func() {
+		// the session is not left behind by a failed cycle
+		if accessToken != "" && res.Step != StepLogout && !res.Passed() {
+			ctx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+			_ = p.logout(ctx, accessToken)
+			cancel()
+		}
+		p.record(res)
+	}()
+
+	step := func(name string, run func() error) bool {
+		start := time.Now()
+		err := run()
+		res.Durations[name] = time.Since(start)
+		if err != nil {
+			res.Step, res.Err = name, err
+			return false
+		}
+		return true
+	}
+
+	var refreshToken string
+	ok := step(StepLogin, func() (err error) {
+		accessToken, refreshToken, err = p.login(ctx)
+		return err
+	}) && step(StepRefresh, func() error {
+		refreshed, _, err := p.refresh(ctx, refreshToken)
+		if err == nil {
+			accessToken = refreshed
+		}
+		return err
+	}) && step(StepCall, func() error {
+		return p.call(ctx, accessToken)
+	}) && step(StepLogout, func() error {
+		return p.logout(ctx, accessToken)
+	})
+	if ok {
+		accessToken = ""
+	}
+	return res
+}
+
+// record counts the result of the cycle
+func (p *Prober) record(res Result) {
+	for step, duration := range res.Durations {
+		probeStepDuration.WithLabelValues(step).Observe(duration.Seconds())
+	}
+	if res.Passed() {
+		probeRuns.WithLabelValues("success", "").Inc()
+		probeSuccess.Set(1)
+		probeLastSuccess.SetToCurrentTime()
+		return
+	}
+	probeRuns.WithLabelValues("failure", res.Step).Inc()
+	probeSuccess.Set(0)
+}
+
+func (p *Prober) login(ctx context.Context) (string, string, error) {
+	var data map[string]interface{}
+	status, err := p.do(ctx, http.MethodPost, "/auth/login", "", map[string]string{"username": p.username, "password": p.password}, &data)
+	if err != nil {
+		return "", "", err
+	}
+	if status == http.StatusAccepted {
+		return "", "", errors.New("the login of the canary user waits for an approval")
+	}
+	return p.tokens(data)
+}
+
+func (p *Prober) refresh(ctx context.Context, refreshToken string) (string, string, error) {
+	var data map[string]interface{}
+	_, err := p.do(ctx, http.MethodPost, "/auth/token/refresh", "", map[string]string{p.field("refresh_token"): refreshToken}, &data)
+	if err != nil {
+		return "", "", err
+	}
+	return p.tokens(data)
+}
+
+func (p *Prober) call(ctx context.Context, accessToken string) error {
+	var data map[string]interface{}
+	_, err := p.do(ctx, http.MethodGet, "/me", accessToken, nil, &data)
+	if err != nil {
+		return err
+	}
+	if username, _ := data["username"].(string); username != p.username {
+		return fmt.Errorf("/me answered the user %q instead of the canary user", username)
+	}
+	return nil
+}
+
+func (p *Prober) logout(ctx context.Context, accessToken string) error {
+	_, err := p.do(ctx, http.MethodPost, "/auth/logout", accessToken, nil, nil)
+	return err
+}
+
+// tokens reads the access and refresh tokens of a login
+func (p *Prober) tokens(data map[string]interface{}) (string, string, error) {
+	accessToken, _ := data[p.field("access_token")].(string)
+	refreshToken, _ := data[p.field("refresh_token")].(string)
+	if accessToken == "" || refreshToken == "" {
+		return "", "", errors.New("the tokens are missing from the response")
+	}
+	return accessToken, refreshToken, nil
+}
+
+// field names the field of a body, in camelCase when the api does
+func (p *Prober) field(name string) string {
+	if !p.camelCase {
+		return name
+	}
+	parts := strings.Split(name, "_")
+	for i := 1; i < len(parts); i++ {
+		parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+	}
+	return strings.Join(parts, "")
+}
+
+// do sends a request to the api and decodes the data of its response into data, the responses out of 2xx fail
+func (p *Prober) do(ctx context.Context, method, path, accessToken string, body interface{}, data interface{}) (int, error) {
+
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reader = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, p.baseURL+path, reader)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return 0, errors.Wrapf(err, "cannot %s %s", method, path)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("%s %s answered %d", method, path, res.StatusCode)
+	}
+	if data == nil {
+		return res.StatusCode, nil
+	}
+	envelope := struct {
+		Data json.RawMessage `json:"data"`
+	}{}
+	if err := json.NewDecoder(res.Body).Decode(&envelope); err != nil {
+		return res.StatusCode, errors.Wrapf(err, "cannot decode the response of %s %s", method, path)
+	}
+	if err := json.Unmarshal(envelope.Data, data); err != nil {
+		return res.StatusCode, errors.Wrapf(err, "cannot decode the data of %s %s", method, path)
+	}
+	return res.StatusCode, nil
+}
@@ -0,0 +1,98 @@
+package probe
+
+import (
+	"context"
+	"encoding/json"
+	"go-hex/pkg/logger"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAPI answers the routes of the cycle in the legacy envelope, failing the routes of failing
+func fakeAPI(t *testing.T, failing map[string]bool, calls *[]string) *httptest.Server {
+	answer := func(w http.ResponseWriter, data interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "data": data})
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls = append(*calls, r.URL.Path)
+		assert.Equal(t, userAgent, r.UserAgent())
+		if failing[r.URL.Path] {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		switch r.URL.Path {
+		case "/auth/login":
+			var body map[string]string
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, "canary", body["username"])
+			answer(w, map[string]string{"access_token": "access-1", "refresh_token": "refresh-1"})
+		case "/auth/token/refresh":
+			var body map[string]string
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, "refresh-1", body["refresh_token"])
+			answer(w, map[string]string{"access_token": "access-2", "refresh_token": "refresh-2"})
+		case "/me":
+			assert.Equal(t, "Bearer access-2", r.Header.Get("Authorization"))
+			answer(w, map[string]string{"username": "canary"})
+		case "/auth/logout":
+			answer(w, nil)
+		}
+	}))
+}
+
+func TestProber(t *testing.T) {
+	var calls []string
+	server := fakeAPI(t, nil, &calls)
+	defer server.Close()
+
+	res := NewProber(server.Client(), server.URL+"/", "canary", "password1234", false).Run(context.Background())
+	require.NoError(t, res.Err)
+	assert.Equal(t, []string{"/auth/login", "/auth/token/refresh", "/me", "/auth/logout"}, calls)
+	assert.Len(t, res.Durations, 4)
+
+	// the session of a failed cycle is logged out
+	calls = nil
+	server = fakeAPI(t, map[string]bool{"/me": true}, &calls)
+	defer server.Close()
+	res = NewProber(server.Client(), server.URL, "canary", "password1234", false).Run(context.Background())
+	assert.False(t, res.Passed())
+	assert.Equal(t, StepCall, res.Step)
+	assert.Equal(t, []string{"/auth/login", "/auth/token/refresh", "/me", "/auth/logout"}, calls)
+
+	assert.Equal(t, "refreshToken", (&Prober{camelCase: true}).field("refresh_token"))
+}
+
+func TestAlerter(t *testing.T) {
+	var alerts []Alert
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert Alert
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&alert))
+		alerts = append(alerts, alert)
+	}))
+	defer webhook.Close()
+
+	alerter := NewAlerter(webhook.Client(), webhook.URL, "http://api", 2, logger.New("test", "test"))
+	failed := Result{Step: StepLogin, Err: errors.New("POST /auth/login answered 503")}
+	ctx, now := context.Background(), time.Now()
+
+	alerter.Observe(ctx, failed, now)
+	assert.Empty(t, alerts, "the alert fires after threshold failed cycles")
+	alerter.Observe(ctx, failed, now)
+	alerter.Observe(ctx, failed, now)
+	require.Len(t, alerts, 1, "a firing alert is sent once")
+	assert.Equal(t, AlertFiring, alerts[0].Status)
+	assert.Equal(t, 2, alerts[0].Failures)
+	assert.Equal(t, StepLogin, alerts[0].Step)
+
+	alerter.Observe(ctx, Result{}, now)
+	alerter.Observe(ctx, Result{}, now)
+	require.Len(t, alerts, 2)
+	assert.Equal(t, AlertResolved, alerts[1].Status)
+}
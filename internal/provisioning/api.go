@@ -0,0 +1,176 @@
+package provisioning
+
+import (
+	"go-hex/configs"
+	"go-hex/middleware"
+	"go-hex/shared/ierr"
+	"go-hex/shared/response"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+// RegisterAPI registers a new provisioning api
+func RegisterAPI(r echo.Group, cfg *configs.Config, service ServicePort) {
+	handler := handler{cfg, service}
+
+	// Internal endpoints
+	internal := r.Group("/internal", middleware.InternalAPI(cfg.InternalAPI.User, cfg.InternalAPI.Password))
+	internal.POST("/provisioning/runs", handler.run)
+	internal.GET("/provisioning/runs", handler.listRuns)
+	internal.GET("/provisioning/runs/:id", handler.getRun)
+	internal.GET("/provisioning/errors", handler.listErrors)
+	internal.POST("/provisioning/errors/:connector/:user_id/retry", handler.retryError)
+}
+
+type handler struct {
+	cfg     *configs.Config
+	service ServicePort
+}
+
+// run godoc
+// @Router /internal/provisioning/runs [post]
+// @Tags Provisioning
+// @Summary Push the users to a directory
+// @Description Push the users which changed since their last push to a downstream directory, then report the drift of the directory from the users
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Param payload body RequestRun true " "
+// @Success 201 {object} response.Response{data=domain.ProvisioningRun} "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) run(c echo.Context) error {
+	var req RequestRun
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.Run(c.Request().Context(), req)
+	if err != nil {
+		if errors.Cause(err) == ierr.ErrConnectorUnknown {
+			return response.ErrBadRequest(err)
+		}
+		return err
+	}
+
+	return response.SuccessCreated(c, res, "users provisioned")
+}
+
+// listRuns godoc
+// @Router /internal/provisioning/runs [get]
+// @Tags Provisioning
+// @Summary List the provisioning runs
+// @Description List the latest provisioning runs without their drifts, the newest first
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Param connector query string false "connector, every connector when empty" Enums(ldap, google, slack)
+// @Param limit query int false "number of runs, 20 by default and 100 at most"
+// @Success 200 {object} response.Response{data=[]domain.ProvisioningRun} "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) listRuns(c echo.Context) error {
+	var req RequestListRuns
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.ListRuns(c.Request().Context(), req)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessOK(c, res)
+}
+
+// getRun godoc
+// @Router /internal/provisioning/runs/{id} [get]
+// @Tags Provisioning
+// @Summary Get a provisioning run
+// @Description Get a provisioning run with its reconciliation report, the missing, orphaned and mismatched accounts
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Param id path string true "run id"
+// @Success 200 {object} response.Response{data=domain.ProvisioningRun} "Success"
+// @failure 401 {object} response.ErrorResponse401
+// @failure 404 {object} response.ErrorResponse404
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) getRun(c echo.Context) error {
+	var req RequestRunID
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.GetRun(c.Request().Context(), req)
+	if err != nil {
+		if errors.Cause(err) == ierr.ErrResourceNotFound {
+			return response.ErrNotFound(err)
+		}
+		return err
+	}
+
+	return response.SuccessOK(c, res)
+}
+
+// listErrors godoc
+// @Router /internal/provisioning/errors [get]
+// @Tags Provisioning
+// @Summary List the provisioning error queue
+// @Description List the failed pushes waiting for their retry, the oldest first
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Param connector query string false "connector, every connector when empty" Enums(ldap, google, slack)
+// @Success 200 {object} response.Response{data=[]domain.ProvisioningError} "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) listErrors(c echo.Context) error {
+	var req RequestListErrors
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.ListErrors(c.Request().Context(), req)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessOK(c, res)
+}
+
+// retryError godoc
+// @Router /internal/provisioning/errors/{connector}/{user_id}/retry [post]
+// @Tags Provisioning
+// @Summary Retry a failed push
+// @Description Reset the attempts of a failed push, including one which ran out of attempts, so that the next run retries it
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Param connector path string true "connector" Enums(ldap, google, slack)
+// @Param user_id path string true "user id"
+// @Success 200 {object} response.Response "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 404 {object} response.ErrorResponse404
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) retryError(c echo.Context) error {
+	var req RequestRetryError
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	err := h.service.RetryError(c.Request().Context(), req)
+	if err != nil {
+		if errors.Cause(err) == ierr.ErrResourceNotFound {
+			return response.ErrNotFound(err)
+		}
+		return err
+	}
+
+	return response.SuccessOK(c, nil, "push queued for retry")
+}
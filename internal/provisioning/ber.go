@@ -0,0 +1,152 @@
+package provisioning
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// BER tags of the LDAP messages, see RFC 4511
+const (
+	berBoolean     = 0x01
+	berInteger     = 0x02
+	berOctetString = 0x04
+	berEnumerated  = 0x0a
+	berSequence    = 0x30
+	berSet         = 0x31
+
+	// berMaxLength bounds the length of the elements read
+	berMaxLength = 16 << 20
+)
+
+// berElement is a decoded BER element, the content of a constructed element holds its children
+type berElement struct {
+	Tag     byte
+	Content []byte
+}
+
+// ber encodes an element from its tag and its content
+func ber(tag byte, content []byte) []byte {
+	b := []byte{tag}
+	n := len(content)
+	switch {
+	case n < 0x80:
+		b = append(b, byte(n))
+	case n <= 0xff:
+		b = append(b, 0x81, byte(n))
+	case n <= 0xffff:
+		b = append(b, 0x82, byte(n>>8), byte(n))
+	default:
+		b = append(b, 0x84, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	}
+	return append(b, content...)
+}
+
+// berConstructed encodes a constructed element from its encoded children
+func berConstructed(tag byte, children ...[]byte) []byte {
+	var content []byte
+	for _, child := range children {
+		content = append(content, child...)
+	}
+	return ber(tag, content)
+}
+
+func berString(tag byte, s string) []byte {
+	return ber(tag, []byte(s))
+}
+
+func berInt(tag byte, v int) []byte {
+	// minimal two's complement encoding
+	var b []byte
+	for {
+		b = append([]byte{byte(v)}, b...)
+		if (v < 0x80 && v >= -0x80) || len(b) == 8 {
+			break
+		}
+		v >>= 8
+	}
+	return ber(tag, b)
+}
+
+func berBool(v bool) []byte {
+	if v {
+		return ber(berBoolean, []byte{0xff})
+	}
+	return ber(berBoolean, []byte{0x00})
+}
+
+// readBER reads an element from r
+func readBER(r *bufio.Reader) (berElement, error) {
+	tag, err := r.ReadByte()
+	if err != nil {
+		return berElement{}, err
+	}
+	first, err := r.ReadByte()
+	if err != nil {
+		return berElement{}, err
+	}
+	n := int(first)
+	if first&0x80 != 0 {
+		size := int(first & 0x7f)
+		if size == 0 || size > 4 {
+			return berElement{}, fmt.Errorf("unsupported ber length of %d bytes", size)
+		}
+		n = 0
+		for i := 0; i < size; i++ {
+			b, err := r.ReadByte()
+			if err != nil {
+				return berElement{}, err
+			}
+			n = n<<8 | int(b)
+		}
+	}
+	if n > berMaxLength {
+		return berElement{}, fmt.Errorf("ber element of %d bytes is too large", n)
+	}
+	content := make([]byte, n)
+	if _, err := io.ReadFull(r, content); err != nil {
+		return berElement{}, err
+	}
+	return berElement{tag, content}, nil
+}
+
+// children decodes the children of a constructed element
+func (e berElement) children() ([]berElement, error) {
+	var children []berElement
+	for rest := e.Content; len(rest) > 0; {
+		if len(rest) < 2 {
+			return nil, errors.New("truncated ber element")
+		}
+		tag, n, header := rest[0], int(rest[1]), 2
+		if rest[1]&0x80 != 0 {
+			size := int(rest[1] & 0x7f)
+			if size == 0 || size > 4 || len(rest) < 2+size {
+				return nil, errors.New("invalid ber length")
+			}
+			n = 0
+			for _, b := range rest[2 : 2+size] {
+				n = n<<8 | int(b)
+			}
+			header += size
+		}
+		if n < 0 || len(rest) < header+n {
+			return nil, errors.New("truncated ber element")
+		}
+		children = append(children, berElement{tag, rest[header : header+n]})
+		rest = rest[header+n:]
+	}
+	return children, nil
+}
+
+// int decodes the content of an integer or an enumerated element
+func (e berElement) int() int {
+	if len(e.Content) == 0 {
+		return 0
+	}
+	v := int(int8(e.Content[0]))
+	for _, b := range e.Content[1:] {
+		v = v<<8 | int(b)
+	}
+	return v
+}
@@ -0,0 +1,179 @@
+package provisioning
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Account is the account of a user in a downstream directory. The attributes are named by the directory,
+// a value is a string or, for the multi-valued attributes such as the roles, a []string.
+type Account struct {
+	Key        string
+	Active     bool
+	Attributes map[string]interface{}
+}
+
+// Connector adapts a downstream directory. The accounts are addressed by their key, the value of the key attribute.
+type Connector interface {
+	// Name returns the name of the connector
+	Name() string
+	// Mapping returns the attributes of the accounts by the fields of the users
+	Mapping() configs.ProvisioningMapping
+	// KeyAttribute returns the attribute keying the accounts
+	KeyAttribute() string
+	// Push creates or updates the account found by key, renaming it when the account has another key,
+	// or deactivates it when the account is not active
+	Push(ctx context.Context, key string, account Account) error
+	// List returns the accounts of the directory with the mapped attributes, for the reconciliation
+	List(ctx context.Context) ([]Account, error)
+}
+
+// ConfiguredConnectors returns the connectors enabled by the configuration
+func ConfiguredConnectors(cfg *configs.Config) ([]Connector, error) {
+	p := cfg.Provisioning
+	timeout := time.Duration(p.Timeout) * time.Second
+
+	var connectors []Connector
+	if p.LDAPAddress != "" {
+		connectors = append(connectors, NewLDAPConnector(p.LDAPAddress, p.LDAPBindDN, p.LDAPPassword, p.LDAPBaseDN, p.LDAPRDN, p.LDAPObjectClasses, p.LDAPMapping, timeout))
+	}
+	if p.GoogleCredentials != "" {
+		connector, err := NewGoogleConnector(p.GoogleCredentials, p.GoogleSubject, p.GoogleCustomer, p.GoogleMapping, timeout)
+		if err != nil {
+			return nil, err
+		}
+		connectors = append(connectors, connector)
+	}
+	if p.SlackToken != "" {
+		connectors = append(connectors, NewSCIMConnector(ConnectorSlack, p.SlackURL, p.SlackToken, p.SlackMapping, timeout))
+	}
+	return connectors, nil
+}
+
+// newAccount maps a user and its roles to its account in a directory, the empty fields are not mapped
+func newAccount(connector Connector, user domain.User, roles []string) Account {
+	given, family := splitName(user.FullName)
+	fields := map[string]string{
+		configs.ProvisioningFieldID:         user.ID,
+		configs.ProvisioningFieldUsername:   user.Username,
+		configs.ProvisioningFieldFullName:   stringValue(user.FullName),
+		configs.ProvisioningFieldGivenName:  given,
+		configs.ProvisioningFieldFamilyName: family,
+		configs.ProvisioningFieldEmail:      user.GetEmail(),
+		configs.ProvisioningFieldPhone:      user.GetPhone(),
+	}
+
+	account := Account{Active: user.IsActive, Attributes: map[string]interface{}{}}
+	for attribute, field := range connector.Mapping() {
+		if field == configs.ProvisioningFieldRoles {
+			sorted := append([]string{}, roles...)
+			sort.Strings(sorted)
+			account.Attributes[attribute] = sorted
+			continue
+		}
+		if value := fields[field]; value != "" {
+			account.Attributes[attribute] = value
+		}
+	}
+	account.Key, _ = account.Attributes[connector.KeyAttribute()].(string)
+	return account
+}
+
+// hash returns the SHA-256 of the account, the account is pushed again once it changes
+func (a Account) hash() string {
+	// encoding/json sorts the keys of the maps, so that the hash only changes with the account
+	b, _ := json.Marshal(a)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// diff returns the attributes of the account which differ from the other account
+func (a Account) diff(other Account) []string {
+	var attributes []string
+	for attribute, value := range a.Attributes {
+		if !equalValues(value, other.Attributes[attribute]) {
+			attributes = append(attributes, attribute)
+		}
+	}
+	if a.Active != other.Active {
+		attributes = append(attributes, "active")
+	}
+	sort.Strings(attributes)
+	return attributes
+}
+
+func equalValues(a, b interface{}) bool {
+	x, y := values(a), values(b)
+	if len(x) != len(y) {
+		return false
+	}
+	sort.Strings(x)
+	sort.Strings(y)
+	for i := range x {
+		if !strings.EqualFold(x[i], y[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// values returns the values of an attribute
+func values(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return append([]string{}, v...)
+	}
+	return nil
+}
+
+// splitName splits the full name in its given names and its family name, the last word
+func splitName(fullName *string) (string, string) {
+	words := strings.Fields(stringValue(fullName))
+	if len(words) == 0 {
+		return "", ""
+	}
+	return strings.Join(words[:len(words)-1], " "), words[len(words)-1]
+}
+
+func stringValue(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
+
+// setPath sets the value at the dotted path of a JSON object, e.g. name.givenName
+func setPath(object map[string]interface{}, path string, value interface{}) {
+	parts := strings.Split(path, ".")
+	for _, part := range parts[:len(parts)-1] {
+		child, ok := object[part].(map[string]interface{})
+		if !ok {
+			child = map[string]interface{}{}
+			object[part] = child
+		}
+		object = child
+	}
+	object[parts[len(parts)-1]] = value
+}
+
+// getPath returns the value at the dotted path of a JSON object, nil when it is missing
+func getPath(object map[string]interface{}, path string) interface{} {
+	var value interface{} = object
+	for _, part := range strings.Split(path, ".") {
+		child, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = child[part]
+	}
+	return value
+}
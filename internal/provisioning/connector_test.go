@@ -0,0 +1,242 @@
+package provisioning
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"go-hex/configs"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLDAPServer answers the requests of a connection with the entries it holds, by dn
+type fakeLDAPServer struct {
+	entries  map[string]map[string][]string
+	requests []byte
+}
+
+func (s *fakeLDAPServer) serve(t *testing.T, conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		message, err := readBER(reader)
+		if err != nil {
+			return
+		}
+		parts, err := message.children()
+		require.NoError(t, err)
+		id, op := parts[0].int(), parts[1]
+		s.requests = append(s.requests, op.Tag)
+		fields, _ := op.children()
+
+		reply := func(tag byte, code int) {
+			_, _ = conn.Write(berConstructed(berSequence, berInt(berInteger, id), berConstructed(tag,
+				berInt(berEnumerated, code), berString(berOctetString, ""), berString(berOctetString, ""))))
+		}
+		switch op.Tag {
+		case ldapBindRequest:
+			reply(ldapBindResponse, ldapSuccess)
+		case ldapUnbindRequest:
+			return
+		case ldapDelRequest:
+			if _, ok := s.entries[string(op.Content)]; !ok {
+				reply(ldapDelResponse, ldapNoSuchObject)
+				continue
+			}
+			delete(s.entries, string(op.Content))
+			reply(ldapDelResponse, ldapSuccess)
+		case ldapModifyRequest:
+			entry, ok := s.entries[string(fields[0].Content)]
+			if !ok {
+				reply(ldapModifyResponse, ldapNoSuchObject)
+				continue
+			}
+			changes, _ := fields[1].children()
+			for _, change := range changes {
+				c, _ := change.children()
+				name, vals := decodeAttribute(c[1])
+				entry[name] = vals
+			}
+			reply(ldapModifyResponse, ldapSuccess)
+		case ldapAddRequest:
+			entry := map[string][]string{}
+			attributes, _ := fields[1].children()
+			for _, attribute := range attributes {
+				name, vals := decodeAttribute(attribute)
+				entry[name] = vals
+			}
+			s.entries[string(fields[0].Content)] = entry
+			reply(ldapAddResponse, ldapSuccess)
+		case ldapSearchRequest:
+			for dn, entry := range s.entries {
+				var attributes [][]byte
+				for name, vals := range entry {
+					if name != "objectClass" {
+						attributes = append(attributes, ldapAttribute(name, vals))
+					}
+				}
+				_, _ = conn.Write(berConstructed(berSequence, berInt(berInteger, id), berConstructed(ldapSearchEntry,
+					berString(berOctetString, dn), berConstructed(berSequence, attributes...))))
+			}
+			reply(ldapSearchDone, ldapSuccess)
+		}
+	}
+}
+
+func decodeAttribute(e berElement) (string, []string) {
+	pair, _ := e.children()
+	set, _ := pair[1].children()
+	var vals []string
+	for _, v := range set {
+		vals = append(vals, string(v.Content))
+	}
+	return string(pair[0].Content), vals
+}
+
+func TestLDAPConnector(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	server := &fakeLDAPServer{entries: map[string]map[string][]string{}}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			server.serve(t, conn)
+		}
+	}()
+
+	var mapping configs.ProvisioningMapping
+	require.NoError(t, mapping.Decode("uid:username,mail:email,memberOf:roles"))
+	connector := NewLDAPConnector("ldap://"+listener.Addr().String(), "cn=admin,dc=example,dc=com", "secret",
+		"ou=people,dc=example,dc=com", "uid", []string{"top", "inetOrgPerson"}, mapping, time.Second)
+	ctx := context.Background()
+
+	account := Account{Key: "alice", Active: true, Attributes: map[string]interface{}{"uid": "alice", "mail": "alice@example.com", "memberOf": []string{"auditor", "operator"}}}
+	require.NoError(t, connector.Push(ctx, "alice", account))
+	assert.Equal(t, []byte{ldapBindRequest, ldapModifyRequest, ldapAddRequest}, server.requests)
+	assert.Equal(t, []string{"top", "inetOrgPerson"}, server.entries["uid=alice,ou=people,dc=example,dc=com"]["objectClass"])
+
+	account.Attributes["mail"] = "alice@example.org"
+	require.NoError(t, connector.Push(ctx, "alice", account))
+	assert.Equal(t, []string{"alice@example.org"}, server.entries["uid=alice,ou=people,dc=example,dc=com"]["mail"])
+
+	accounts, err := connector.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []Account{account}, accounts)
+
+	// a missing entry is deactivated already
+	require.NoError(t, connector.Push(ctx, "bob", Account{Key: "bob"}))
+	require.NoError(t, connector.Push(ctx, "alice", Account{Key: "alice"}))
+	assert.Empty(t, server.entries)
+	require.NoError(t, connector.Close())
+}
+
+func TestSCIMConnector(t *testing.T) {
+	users := map[string]map[string]interface{}{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		switch {
+		case r.Method == http.MethodGet && r.URL.Query().Get("filter") != "":
+			resources := []map[string]interface{}{}
+			for _, user := range users {
+				if `userName eq "`+user["userName"].(string)+`"` == r.URL.Query().Get("filter") {
+					resources = append(resources, user)
+				}
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"totalResults": len(resources), "Resources": resources})
+		case r.Method == http.MethodGet:
+			resources := []map[string]interface{}{}
+			for _, user := range users {
+				resources = append(resources, user)
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"totalResults": len(resources), "Resources": resources})
+		case r.Method == http.MethodPost:
+			body["id"] = "U1"
+			users["U1"] = body
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodPut && r.URL.Path == "/Users/U1":
+			body["id"] = "U1"
+			users["U1"] = body
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	var mapping configs.ProvisioningMapping
+	require.NoError(t, mapping.Decode("userName:username,name.givenName:given_name,emails:email"))
+	connector := NewSCIMConnector(ConnectorSlack, server.URL, "token", mapping, time.Second)
+	ctx := context.Background()
+
+	account := Account{Key: "alice", Active: true, Attributes: map[string]interface{}{"userName": "alice", "name.givenName": "Alice", "emails": "alice@example.com"}}
+	require.NoError(t, connector.Push(ctx, "alice", account))
+	assert.Equal(t, []interface{}{map[string]interface{}{"value": "alice@example.com", "primary": true}}, users["U1"]["emails"])
+
+	accounts, err := connector.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []Account{account}, accounts)
+
+	require.NoError(t, connector.Push(ctx, "alice", Account{Key: "alice", Attributes: map[string]interface{}{"userName": "alice"}}))
+	assert.Equal(t, false, users["U1"]["active"])
+
+	// a missing user is not created to be deactivated
+	require.NoError(t, connector.Push(ctx, "bob", Account{Key: "bob"}))
+	assert.Len(t, users, 1)
+}
+
+func TestGoogleConnector(t *testing.T) {
+	users := map[string]map[string]interface{}{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		switch {
+		case r.Method == http.MethodGet:
+			list := []map[string]interface{}{}
+			for _, user := range users {
+				list = append(list, user)
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"users": list})
+		case r.Method == http.MethodPost:
+			assert.NotEmpty(t, body["password"])
+			delete(body, "password")
+			users[body["primaryEmail"].(string)] = body
+		case r.Method == http.MethodPut:
+			key := r.URL.Path[len("/users/"):]
+			if _, ok := users[key]; !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			delete(users, key)
+			users[body["primaryEmail"].(string)] = body
+		}
+	}))
+	defer server.Close()
+
+	var mapping configs.ProvisioningMapping
+	require.NoError(t, mapping.Decode("primaryEmail:email,name.familyName:family_name"))
+	connector := newGoogleConnector(server.Client(), server.URL, "my_customer", mapping)
+	ctx := context.Background()
+
+	account := Account{Key: "alice@example.com", Active: true, Attributes: map[string]interface{}{"primaryEmail": "alice@example.com", "name.familyName": "Smith"}}
+	require.NoError(t, connector.Push(ctx, "alice@example.com", account))
+
+	// a new email renames the user
+	renamed := Account{Key: "alice@example.org", Active: false, Attributes: map[string]interface{}{"primaryEmail": "alice@example.org", "name.familyName": "Smith"}}
+	require.NoError(t, connector.Push(ctx, "alice@example.com", renamed))
+	assert.Equal(t, true, users["alice@example.org"]["suspended"])
+
+	accounts, err := connector.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []Account{renamed}, accounts)
+}
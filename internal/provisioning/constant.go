@@ -0,0 +1,23 @@
+package provisioning
+
+// Names of the connectors
+const (
+	ConnectorLDAP   = "ldap"   // LDAP write-back
+	ConnectorGoogle = "google" // Google Workspace directory
+	ConnectorSlack  = "slack"  // Slack SCIM API
+)
+
+const (
+	// usersPageSize is the number of users read at once by a run
+	usersPageSize = 500
+	// maxErrorLength is the length of the error column of the queue
+	maxErrorLength = 1000
+	// maxRunDrifts bounds the drifts recorded by a run, the drifts past it are counted only
+	maxRunDrifts = 1000
+	// defaultRunsLimit is the number of runs listed when the request sets no limit
+	defaultRunsLimit = 20
+	// maxRunsLimit bounds the number of runs listed at once
+	maxRunsLimit = 100
+	// maxResponseSize bounds the responses read from the directories
+	maxResponseSize = 16 << 20
+)
@@ -0,0 +1,66 @@
+package provisioning
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+var connectors = []interface{}{ConnectorLDAP, ConnectorGoogle, ConnectorSlack}
+
+// RequestRun request body
+type RequestRun struct {
+	Connector string `json:"connector" example:"ldap"`
+}
+
+func (r *RequestRun) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Connector, validation.Required, validation.In(connectors...)),
+	)
+}
+
+// RequestRunID request params
+type RequestRunID struct {
+	ID string `json:"-" param:"id"`
+}
+
+func (r *RequestRunID) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.ID, validation.Required),
+	)
+}
+
+// RequestListRuns request params
+type RequestListRuns struct {
+	Connector string `json:"-" query:"connector" example:"ldap"` // every connector when empty
+	Limit     int    `json:"-" query:"limit" example:"20"`
+}
+
+func (r *RequestListRuns) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Connector, validation.In(connectors...)),
+		validation.Field(&r.Limit, validation.Min(0), validation.Max(maxRunsLimit)),
+	)
+}
+
+// RequestListErrors request params
+type RequestListErrors struct {
+	Connector string `json:"-" query:"connector" example:"ldap"` // every connector when empty
+}
+
+func (r *RequestListErrors) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Connector, validation.In(connectors...)),
+	)
+}
+
+// RequestRetryError request params
+type RequestRetryError struct {
+	Connector string `json:"-" param:"connector"`
+	UserID    string `json:"-" param:"user_id"`
+}
+
+func (r *RequestRetryError) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Connector, validation.Required, validation.In(connectors...)),
+		validation.Field(&r.UserID, validation.Required),
+	)
+}
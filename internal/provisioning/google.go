@@ -0,0 +1,167 @@
+package provisioning
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"go-hex/configs"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2/google"
+)
+
+const googleDirectoryScope = "https://www.googleapis.com/auth/admin.directory.user"
+
+// googleDirectoryURL is the base URL of the Admin SDK Directory API
+var googleDirectoryURL = "https://admin.googleapis.com/admin/directory/v1"
+
+// GoogleConnector pushes the accounts to the users of a Google Workspace, keyed by their primary email.
+// The users are suspended rather than deleted when they are deactivated.
+type GoogleConnector struct {
+	client   *http.Client
+	baseURL  string
+	customer string
+	mapping  configs.ProvisioningMapping
+}
+
+// NewGoogleConnector creates a connector authenticated by the service account of the credentials file,
+// impersonating subject, an administrator of the workspace, through the domain-wide delegation
+func NewGoogleConnector(credentialsFile, subject, customer string, mapping configs.ProvisioningMapping, timeout time.Duration) (*GoogleConnector, error) {
+	b, err := os.ReadFile(credentialsFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot read google credentials")
+	}
+	jwt, err := google.JWTConfigFromJSON(b, googleDirectoryScope)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid google credentials")
+	}
+	jwt.Subject = subject
+
+	client := jwt.Client(context.Background())
+	client.Timeout = timeout
+	return newGoogleConnector(client, googleDirectoryURL, customer, mapping), nil
+}
+
+func newGoogleConnector(client *http.Client, baseURL, customer string, mapping configs.ProvisioningMapping) *GoogleConnector {
+	return &GoogleConnector{client, baseURL, customer, mapping}
+}
+
+// Name returns the name of the connector
+func (c *GoogleConnector) Name() string {
+	return ConnectorGoogle
+}
+
+// Mapping returns the properties of the users by the fields of the users, dotted for the nested ones
+func (c *GoogleConnector) Mapping() configs.ProvisioningMapping {
+	return c.mapping
+}
+
+// KeyAttribute returns the property keying the users
+func (c *GoogleConnector) KeyAttribute() string {
+	return "primaryEmail"
+}
+
+// Push updates the user found by key, creating it with a random password when it does not exist
+func (c *GoogleConnector) Push(ctx context.Context, key string, account Account) error {
+	body := map[string]interface{}{"suspended": !account.Active}
+	for attribute, value := range account.Attributes {
+		setPath(body, attribute, value)
+	}
+
+	err := doJSON(ctx, c.client, http.MethodPut, c.baseURL+"/users/"+url.PathEscape(key), nil, body, nil)
+	if !isStatus(err, http.StatusNotFound) {
+		return err
+	}
+	if !account.Active {
+		return nil
+	}
+
+	// the users are created with a password nobody knows, they sign in through the SSO of the workspace
+	password := make([]byte, 24)
+	if _, err := rand.Read(password); err != nil {
+		return errors.Wrap(err, "cannot generate password")
+	}
+	body["password"] = base64.RawURLEncoding.EncodeToString(password)
+	return doJSON(ctx, c.client, http.MethodPost, c.baseURL+"/users", nil, body, nil)
+}
+
+// List reads every page of users of the customer
+func (c *GoogleConnector) List(ctx context.Context) ([]Account, error) {
+	var accounts []Account
+	pageToken := ""
+	for {
+		query := url.Values{"customer": {c.customer}, "maxResults": {"500"}}
+		if pageToken != "" {
+			query.Set("pageToken", pageToken)
+		}
+		var page struct {
+			Users         []map[string]interface{} `json:"users"`
+			NextPageToken string                   `json:"nextPageToken"`
+		}
+		if err := doJSON(ctx, c.client, http.MethodGet, c.baseURL+"/users?"+query.Encode(), nil, nil, &page); err != nil {
+			return nil, err
+		}
+
+		for _, user := range page.Users {
+			suspended, _ := user["suspended"].(bool)
+			account := Account{Active: !suspended, Attributes: map[string]interface{}{}}
+			for attribute, field := range c.mapping {
+				if value := jsonValue(getPath(user, attribute), field); value != nil {
+					account.Attributes[attribute] = value
+				}
+			}
+			account.Key, _ = user["primaryEmail"].(string)
+			accounts = append(accounts, account)
+		}
+
+		if page.NextPageToken == "" {
+			return accounts, nil
+		}
+		pageToken = page.NextPageToken
+	}
+}
+
+// jsonValue converts a decoded JSON value to the value of an attribute mapped to field.
+// The multi-valued attributes, arrays of strings or of objects with a value, are read as a string,
+// the primary value, unless the attribute is mapped to the roles.
+func jsonValue(v interface{}, field string) interface{} {
+	var vals []string
+	primary := ""
+	switch v := v.(type) {
+	case string:
+		vals = []string{v}
+	case []interface{}:
+		for _, item := range v {
+			switch item := item.(type) {
+			case string:
+				vals = append(vals, item)
+			case map[string]interface{}:
+				value, _ := item["value"].(string)
+				if value == "" {
+					continue
+				}
+				vals = append(vals, value)
+				if p, _ := item["primary"].(bool); p {
+					primary = value
+				}
+			}
+		}
+	default:
+		return nil
+	}
+
+	if field == configs.ProvisioningFieldRoles {
+		return vals
+	}
+	if primary != "" {
+		return primary
+	}
+	if len(vals) > 0 {
+		return vals[0]
+	}
+	return nil
+}
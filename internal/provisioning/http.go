@@ -0,0 +1,73 @@
+package provisioning
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// HTTPError is the status of a failed call to a directory API
+type HTTPError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e HTTPError) Error() string {
+	return fmt.Sprintf("status %d: %s", e.StatusCode, e.Body)
+}
+
+// isStatus reports whether the error is an HTTPError with the given status
+func isStatus(err error, status int) bool {
+	httpErr, ok := errors.Cause(err).(HTTPError)
+	return ok && httpErr.StatusCode == status
+}
+
+// doJSON sends the body encoded as JSON and decodes the JSON response into out when it is not nil
+func doJSON(ctx context.Context, client *http.Client, method, url string, header http.Header, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return errors.Wrap(err, "cannot encode request")
+		}
+		reader = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return errors.Wrap(err, "cannot create request")
+	}
+	for name, vals := range header {
+		req.Header[name] = vals
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "cannot call %s %s", method, req.URL.Path)
+	}
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(io.LimitReader(res.Body, maxResponseSize))
+	if err != nil {
+		return errors.Wrap(err, "cannot read response")
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		if len(b) > maxErrorLength {
+			b = b[:maxErrorLength]
+		}
+		return errors.Wrapf(HTTPError{res.StatusCode, string(bytes.TrimSpace(b))}, "cannot call %s %s", method, req.URL.Path)
+	}
+	if out == nil || len(b) == 0 {
+		return nil
+	}
+	return errors.Wrap(json.Unmarshal(b, out), "cannot decode response")
+}
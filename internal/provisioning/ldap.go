@@ -0,0 +1,387 @@
+package provisioning
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"go-hex/configs"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// LDAP operations, see RFC 4511
+const (
+	ldapBindRequest      = 0x60
+	ldapBindResponse     = 0x61
+	ldapUnbindRequest    = 0x42
+	ldapSearchRequest    = 0x63
+	ldapSearchEntry      = 0x64
+	ldapSearchDone       = 0x65
+	ldapSearchReference  = 0x73
+	ldapModifyRequest    = 0x66
+	ldapModifyResponse   = 0x67
+	ldapAddRequest       = 0x68
+	ldapAddResponse      = 0x69
+	ldapDelRequest       = 0x4a
+	ldapDelResponse      = 0x6b
+	ldapModifyDNRequest  = 0x6c
+	ldapModifyDNResponse = 0x6d
+
+	ldapSimpleAuth    = 0x80
+	ldapEqualityMatch = 0xa3
+	ldapScopeOneLevel = 1
+	ldapModReplace    = 2
+
+	ldapSuccess      = 0
+	ldapNoSuchObject = 32
+)
+
+// LDAPError is the result of a failed LDAP operation
+type LDAPError struct {
+	Code    int
+	Message string
+}
+
+func (e LDAPError) Error() string {
+	return fmt.Sprintf("ldap result %d: %s", e.Code, e.Message)
+}
+
+// LDAPConnector writes the accounts back to an LDAP directory, as the entries <rdn>=<key>,<base dn>.
+// The entries are replaced attribute by attribute, and deleted when their user is deactivated.
+// The connection is opened on the first operation of a run and closed by Close.
+type LDAPConnector struct {
+	address       string
+	bindDN        string
+	password      string
+	baseDN        string
+	rdn           string
+	objectClasses []string
+	mapping       configs.ProvisioningMapping
+	timeout       time.Duration
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+	id     int
+}
+
+// NewLDAPConnector creates a connector to the LDAP server at address, ldap://host:port or ldaps://host:port
+func NewLDAPConnector(address, bindDN, password, baseDN, rdn string, objectClasses []string, mapping configs.ProvisioningMapping, timeout time.Duration) *LDAPConnector {
+	return &LDAPConnector{address: address, bindDN: bindDN, password: password, baseDN: baseDN, rdn: rdn, objectClasses: objectClasses, mapping: mapping, timeout: timeout}
+}
+
+// Name returns the name of the connector
+func (c *LDAPConnector) Name() string {
+	return ConnectorLDAP
+}
+
+// Mapping returns the attributes of the entries by the fields of the users
+func (c *LDAPConnector) Mapping() configs.ProvisioningMapping {
+	return c.mapping
+}
+
+// KeyAttribute returns the attribute naming the entries
+func (c *LDAPConnector) KeyAttribute() string {
+	return c.rdn
+}
+
+// Push replaces the attributes of the entry, adding it when it does not exist, or deletes it when the account is not active
+func (c *LDAPConnector) Push(ctx context.Context, key string, account Account) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !account.Active {
+		err := c.do(ctx, berString(ldapDelRequest, c.dn(key)), ldapDelResponse)
+		return ignoreNoSuchObject(err)
+	}
+
+	if key != account.Key {
+		err := c.do(ctx, berConstructed(ldapModifyDNRequest,
+			berString(berOctetString, c.dn(key)),
+			berString(berOctetString, c.rdn+"="+escapeDN(account.Key)),
+			berBool(true),
+		), ldapModifyDNResponse)
+		if err = ignoreNoSuchObject(err); err != nil {
+			return err
+		}
+	}
+
+	var changes [][]byte
+	for attribute, value := range account.Attributes {
+		changes = append(changes, berConstructed(berSequence,
+			berInt(berEnumerated, ldapModReplace),
+			ldapAttribute(attribute, values(value)),
+		))
+	}
+	err := c.do(ctx, berConstructed(ldapModifyRequest,
+		berString(berOctetString, c.dn(account.Key)),
+		berConstructed(berSequence, changes...),
+	), ldapModifyResponse)
+	if ldapErr, ok := errors.Cause(err).(LDAPError); !ok || ldapErr.Code != ldapNoSuchObject {
+		return err
+	}
+
+	attributes := [][]byte{ldapAttribute("objectClass", c.objectClasses)}
+	for attribute, value := range account.Attributes {
+		if v := values(value); len(v) > 0 {
+			attributes = append(attributes, ldapAttribute(attribute, v))
+		}
+	}
+	return c.do(ctx, berConstructed(ldapAddRequest,
+		berString(berOctetString, c.dn(account.Key)),
+		berConstructed(berSequence, attributes...),
+	), ldapAddResponse)
+}
+
+// List searches the entries right under the base dn of the last object class
+func (c *LDAPConnector) List(ctx context.Context) ([]Account, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var attributes [][]byte
+	for attribute := range c.mapping {
+		attributes = append(attributes, berString(berOctetString, attribute))
+	}
+	objectClass := c.objectClasses[len(c.objectClasses)-1]
+	search := berConstructed(ldapSearchRequest,
+		berString(berOctetString, c.baseDN),
+		berInt(berEnumerated, ldapScopeOneLevel),
+		berInt(berEnumerated, 0), // never dereference aliases
+		berInt(berInteger, 0),    // no size limit
+		berInt(berInteger, 0),    // no time limit
+		berBool(false),
+		berConstructed(ldapEqualityMatch, berString(berOctetString, "objectClass"), berString(berOctetString, objectClass)),
+		berConstructed(berSequence, attributes...),
+	)
+
+	id, err := c.send(ctx, search)
+	if err != nil {
+		return nil, err
+	}
+	var accounts []Account
+	for {
+		op, err := c.receive(id)
+		if err != nil {
+			return nil, err
+		}
+		switch op.Tag {
+		case ldapSearchEntry:
+			account, err := c.entry(op)
+			if err != nil {
+				return nil, err
+			}
+			accounts = append(accounts, account)
+		case ldapSearchReference:
+		case ldapSearchDone:
+			return accounts, ldapResult(op)
+		default:
+			return nil, fmt.Errorf("unexpected ldap operation %#x", op.Tag)
+		}
+	}
+}
+
+// Close unbinds and closes the connection, it is opened again by the next operation
+func (c *LDAPConnector) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		return nil
+	}
+	c.id++
+	_, _ = c.conn.Write(berConstructed(berSequence, berInt(berInteger, c.id), ber(ldapUnbindRequest, nil)))
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+// entry decodes the account of a search entry
+func (c *LDAPConnector) entry(op berElement) (Account, error) {
+	parts, err := op.children()
+	if err != nil || len(parts) < 2 {
+		return Account{}, errors.New("invalid ldap search entry")
+	}
+	attributes, err := parts[1].children()
+	if err != nil {
+		return Account{}, errors.New("invalid ldap search entry")
+	}
+
+	account := Account{Active: true, Attributes: map[string]interface{}{}}
+	for _, attribute := range attributes {
+		pair, err := attribute.children()
+		if err != nil || len(pair) != 2 {
+			return Account{}, errors.New("invalid ldap search entry")
+		}
+		set, err := pair[1].children()
+		if err != nil {
+			return Account{}, errors.New("invalid ldap search entry")
+		}
+		var vals []string
+		for _, v := range set {
+			vals = append(vals, string(v.Content))
+		}
+
+		// the attribute names are case insensitive, they are answered as mapped
+		name := string(pair[0].Content)
+		for mapped := range c.mapping {
+			if strings.EqualFold(mapped, name) {
+				name = mapped
+			}
+		}
+		if c.mapping[name] == configs.ProvisioningFieldRoles {
+			account.Attributes[name] = vals
+		} else if len(vals) > 0 {
+			account.Attributes[name] = vals[0]
+		}
+	}
+	account.Key, _ = account.Attributes[c.rdn].(string)
+	return account, nil
+}
+
+// dn returns the dn of the entry with the given key
+func (c *LDAPConnector) dn(key string) string {
+	return c.rdn + "=" + escapeDN(key) + "," + c.baseDN
+}
+
+// do sends a request and returns the error of its result
+func (c *LDAPConnector) do(ctx context.Context, op []byte, response byte) error {
+	id, err := c.send(ctx, op)
+	if err != nil {
+		return err
+	}
+	res, err := c.receive(id)
+	if err != nil {
+		return err
+	}
+	if res.Tag != response {
+		return fmt.Errorf("unexpected ldap operation %#x", res.Tag)
+	}
+	return ldapResult(res)
+}
+
+// send sends a request on the connection, connecting and binding first when needed, and returns its message id
+func (c *LDAPConnector) send(ctx context.Context, op []byte) (int, error) {
+	if c.conn == nil {
+		if err := c.connect(ctx); err != nil {
+			return 0, err
+		}
+	}
+	deadline := time.Now().Add(c.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = c.conn.SetDeadline(deadline)
+
+	c.id++
+	_, err := c.conn.Write(berConstructed(berSequence, berInt(berInteger, c.id), op))
+	if err != nil {
+		c.reset()
+		return 0, errors.Wrap(err, "cannot write ldap request")
+	}
+	return c.id, nil
+}
+
+// receive reads the operation of the next response, which must answer the given message id
+func (c *LDAPConnector) receive(id int) (berElement, error) {
+	message, err := readBER(c.reader)
+	if err != nil {
+		c.reset()
+		return berElement{}, errors.Wrap(err, "cannot read ldap response")
+	}
+	parts, err := message.children()
+	if err != nil || len(parts) < 2 || parts[0].int() != id {
+		c.reset()
+		return berElement{}, errors.New("invalid ldap response")
+	}
+	return parts[1], nil
+}
+
+// connect opens the connection and binds with the configured dn
+func (c *LDAPConnector) connect(ctx context.Context) error {
+	u, err := url.Parse(c.address)
+	if err != nil {
+		return errors.Wrap(err, "invalid ldap address")
+	}
+
+	dialer := &net.Dialer{Timeout: c.timeout}
+	var conn net.Conn
+	switch u.Scheme {
+	case "ldaps":
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: u.Hostname()}}).DialContext(ctx, "tcp", u.Host)
+	case "ldap":
+		conn, err = dialer.DialContext(ctx, "tcp", u.Host)
+	default:
+		return fmt.Errorf("invalid ldap address scheme %q: expected ldap or ldaps", u.Scheme)
+	}
+	if err != nil {
+		return errors.Wrap(err, "cannot connect to ldap server")
+	}
+	c.conn, c.reader, c.id = conn, bufio.NewReader(conn), 0
+
+	err = c.do(ctx, berConstructed(ldapBindRequest,
+		berInt(berInteger, 3),
+		berString(berOctetString, c.bindDN),
+		berString(ldapSimpleAuth, c.password),
+	), ldapBindResponse)
+	if err != nil {
+		c.reset()
+		return errors.Wrap(err, "cannot bind to ldap server")
+	}
+	return nil
+}
+
+// reset drops a broken connection, the next operation connects again
+func (c *LDAPConnector) reset() {
+	if c.conn != nil {
+		_ = c.conn.Close()
+		c.conn = nil
+	}
+}
+
+// ldapResult returns the error of an LDAPResult, nil on success
+func ldapResult(op berElement) error {
+	parts, err := op.children()
+	if err != nil || len(parts) < 3 {
+		return errors.New("invalid ldap result")
+	}
+	if code := parts[0].int(); code != ldapSuccess {
+		return LDAPError{code, string(parts[2].Content)}
+	}
+	return nil
+}
+
+func ignoreNoSuchObject(err error) error {
+	if ldapErr, ok := errors.Cause(err).(LDAPError); ok && ldapErr.Code == ldapNoSuchObject {
+		return nil
+	}
+	return err
+}
+
+func ldapAttribute(name string, vals []string) []byte {
+	var set [][]byte
+	for _, v := range vals {
+		set = append(set, berString(berOctetString, v))
+	}
+	return berConstructed(berSequence, berString(berOctetString, name), berConstructed(berSet, set...))
+}
+
+// escapeDN escapes a value of a dn, see RFC 4514
+func escapeDN(value string) string {
+	var b strings.Builder
+	for i, r := range value {
+		switch {
+		case strings.ContainsRune(`,+"\<>;=`, r),
+			(i == 0 && (r == ' ' || r == '#')),
+			(i == len(value)-1 && r == ' '):
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
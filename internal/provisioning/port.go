@@ -0,0 +1,22 @@
+package provisioning
+
+import (
+	"context"
+	"go-hex/internal/domain"
+)
+
+// ServicePort encapsulates the provisioning logic.
+type ServicePort interface {
+	// Run pushes the users to a downstream directory and reconciles it
+	Run(ctx context.Context, req RequestRun) (domain.ProvisioningRun, error)
+	// RunAll pushes the users to every configured downstream directory
+	RunAll(ctx context.Context) error
+	// GetRun returns a run with its drifts
+	GetRun(ctx context.Context, req RequestRunID) (domain.ProvisioningRun, error)
+	// ListRuns returns the latest runs without their drifts, the newest first
+	ListRuns(ctx context.Context, req RequestListRuns) ([]domain.ProvisioningRun, error)
+	// ListErrors returns the queued pushes, the oldest first
+	ListErrors(ctx context.Context, req RequestListErrors) ([]domain.ProvisioningError, error)
+	// RetryError resets the attempts of a queued push, it is retried by the next run
+	RetryError(ctx context.Context, req RequestRetryError) error
+}
@@ -0,0 +1,27 @@
+package provisioning
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/pkg/logger"
+	"sync"
+
+	"github.com/go-co-op/gocron"
+)
+
+// RegisterScheduler schedules the push of the users to every configured directory with the configured cron pattern.
+func RegisterScheduler(cfg *configs.Config, log logger.Logger, service ServicePort, cron *gocron.Scheduler, wg *sync.WaitGroup) {
+
+	_, err := cron.Cron(cfg.Scheduler.ProvisioningPattern).SingletonMode().Do(func() {
+		wg.Add(1)
+		defer wg.Done()
+
+		err := service.RunAll(context.Background())
+		if err != nil {
+			log.WithStack(err).Error(err)
+		}
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
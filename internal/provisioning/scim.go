@@ -0,0 +1,148 @@
+package provisioning
+
+import (
+	"context"
+	"fmt"
+	"go-hex/configs"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	scimUserSchema = "urn:ietf:params:scim:schemas:core:2.0:User"
+	scimPageSize   = 1000
+)
+
+// SCIMConnector pushes the accounts to the users of a SCIM 2.0 API, such as the Slack one, keyed by their userName.
+// The users are deactivated through their active attribute.
+type SCIMConnector struct {
+	name    string
+	baseURL string
+	token   string
+	client  *http.Client
+	mapping configs.ProvisioningMapping
+}
+
+// NewSCIMConnector creates a connector named name to the SCIM API at baseURL, authenticated by the bearer token
+func NewSCIMConnector(name, baseURL, token string, mapping configs.ProvisioningMapping, timeout time.Duration) *SCIMConnector {
+	return &SCIMConnector{name, strings.TrimSuffix(baseURL, "/"), token, &http.Client{Timeout: timeout}, mapping}
+}
+
+// Name returns the name of the connector
+func (c *SCIMConnector) Name() string {
+	return c.name
+}
+
+// Mapping returns the attributes of the users by the fields of the users, dotted for the nested ones
+func (c *SCIMConnector) Mapping() configs.ProvisioningMapping {
+	return c.mapping
+}
+
+// KeyAttribute returns the attribute keying the users
+func (c *SCIMConnector) KeyAttribute() string {
+	return "userName"
+}
+
+// Push replaces the user found by key, creating it when it does not exist
+func (c *SCIMConnector) Push(ctx context.Context, key string, account Account) error {
+	id, err := c.find(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	body := map[string]interface{}{"schemas": []string{scimUserSchema}, "active": account.Active}
+	for attribute, value := range account.Attributes {
+		setPath(body, attribute, scimValue(attribute, value))
+	}
+
+	if id != "" {
+		return doJSON(ctx, c.client, http.MethodPut, c.baseURL+"/Users/"+url.PathEscape(id), c.header(), body, nil)
+	}
+	if !account.Active {
+		return nil
+	}
+	return doJSON(ctx, c.client, http.MethodPost, c.baseURL+"/Users", c.header(), body, nil)
+}
+
+// List reads every page of users
+func (c *SCIMConnector) List(ctx context.Context) ([]Account, error) {
+	var accounts []Account
+	for start := 1; ; start += scimPageSize {
+		query := url.Values{"startIndex": {strconv.Itoa(start)}, "count": {strconv.Itoa(scimPageSize)}}
+		page, err := c.users(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, user := range page.Resources {
+			active, ok := user["active"].(bool)
+			account := Account{Active: active || !ok, Attributes: map[string]interface{}{}}
+			for attribute, field := range c.mapping {
+				if value := jsonValue(getPath(user, attribute), field); value != nil {
+					account.Attributes[attribute] = value
+				}
+			}
+			account.Key, _ = user["userName"].(string)
+			accounts = append(accounts, account)
+		}
+
+		if len(page.Resources) == 0 || start+len(page.Resources) > page.TotalResults {
+			return accounts, nil
+		}
+	}
+}
+
+type scimListResponse struct {
+	TotalResults int                      `json:"totalResults"`
+	Resources    []map[string]interface{} `json:"Resources"`
+}
+
+// find returns the id of the user with the given userName, empty when it does not exist
+func (c *SCIMConnector) find(ctx context.Context, userName string) (string, error) {
+	filter := fmt.Sprintf(`userName eq "%s"`, strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(userName))
+	page, err := c.users(ctx, url.Values{"filter": {filter}})
+	if err != nil {
+		return "", err
+	}
+	if len(page.Resources) == 0 {
+		return "", nil
+	}
+	id, _ := page.Resources[0]["id"].(string)
+	if id == "" {
+		return "", errors.New("invalid scim user: missing id")
+	}
+	return id, nil
+}
+
+func (c *SCIMConnector) users(ctx context.Context, query url.Values) (scimListResponse, error) {
+	var page scimListResponse
+	err := doJSON(ctx, c.client, http.MethodGet, c.baseURL+"/Users?"+query.Encode(), c.header(), nil, &page)
+	return page, err
+}
+
+func (c *SCIMConnector) header() http.Header {
+	return http.Header{"Authorization": {"Bearer " + c.token}}
+}
+
+// scimValue converts the value of a multi-valued attribute, emails, phoneNumbers or a list of values,
+// to the SCIM complex values
+func scimValue(attribute string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		if attribute == "emails" || attribute == "phoneNumbers" {
+			return []map[string]interface{}{{"value": v, "primary": true}}
+		}
+	case []string:
+		items := make([]map[string]interface{}, 0, len(v))
+		for _, s := range v {
+			items = append(items, map[string]interface{}{"value": s})
+		}
+		return items
+	}
+	return value
+}
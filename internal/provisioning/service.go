@@ -0,0 +1,390 @@
+package provisioning
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/times"
+	"go-hex/pkg/utils"
+	"go-hex/shared/ierr"
+	"io"
+	"sort"
+	"time"
+)
+
+// Service encapsulates the provisioning logic. A run maps every user to its account in a downstream directory
+// and pushes the accounts which changed since their last push, the deactivated users are deactivated downstream.
+// The failed pushes are queued and retried by the next runs with an exponential backoff. The run then reads the
+// accounts of the directory back and reports their drift from the users.
+type Service struct {
+	cfg         *configs.Config
+	repoRegitry port.RepositoryRegistry
+	log         logger.Logger
+	events      event.Bus
+	connectors  map[string]Connector
+}
+
+// NewService creates and returns a new provisioning service of the given connectors
+func NewService(cfg *configs.Config, repoRegitry port.RepositoryRegistry, log logger.Logger, events event.Bus, connectors ...Connector) *Service {
+	byName := make(map[string]Connector, len(connectors))
+	for _, connector := range connectors {
+		byName[connector.Name()] = connector
+	}
+	return &Service{cfg, repoRegitry, log, events, byName}
+}
+
+// Run pushes the users to a downstream directory and reconciles it
+func (s *Service) Run(ctx context.Context, req RequestRun) (domain.ProvisioningRun, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return domain.ProvisioningRun{}, err
+	}
+
+	connector, ok := s.connectors[req.Connector]
+	if !ok {
+		return domain.ProvisioningRun{}, ierr.ErrConnectorUnknown
+	}
+	return s.run(ctx, connector)
+}
+
+// RunAll pushes the users to every configured downstream directory
+func (s *Service) RunAll(ctx context.Context) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	var failed error
+	for _, connector := range s.connectors {
+		_, err := s.run(ctx, connector)
+		if err != nil {
+			failed = err
+		}
+	}
+	return failed
+}
+
+// GetRun returns a run with its drifts
+func (s *Service) GetRun(ctx context.Context, req RequestRunID) (domain.ProvisioningRun, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return domain.ProvisioningRun{}, err
+	}
+
+	return s.repoRegitry.GetProvisioningRepository().GetRun(ctx, req.ID)
+}
+
+// ListRuns returns the latest runs without their drifts, the newest first
+func (s *Service) ListRuns(ctx context.Context, req RequestListRuns) ([]domain.ProvisioningRun, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return nil, err
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultRunsLimit
+	}
+
+	return s.repoRegitry.GetProvisioningRepository().ListRuns(ctx, req.Connector, req.Limit)
+}
+
+// ListErrors returns the queued pushes, the oldest first
+func (s *Service) ListErrors(ctx context.Context, req RequestListErrors) ([]domain.ProvisioningError, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repoRegitry.GetProvisioningRepository().ListErrors(ctx, req.Connector)
+}
+
+// RetryError resets the attempts of a queued push, it is retried by the next run
+func (s *Service) RetryError(ctx context.Context, req RequestRetryError) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return err
+	}
+
+	found, err := s.repoRegitry.GetProvisioningRepository().RetryError(ctx, req.Connector, req.UserID, times.Now())
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ierr.ErrResourceNotFound
+	}
+	return nil
+}
+
+// run pushes the users to the directory, reconciles it and records the run, failed or not
+func (s *Service) run(ctx context.Context, connector Connector) (domain.ProvisioningRun, error) {
+
+	run := domain.ProvisioningRun{
+		ID:        utils.GenerateID(),
+		Connector: connector.Name(),
+		Status:    domain.ProvisioningRunSucceeded,
+		StartedAt: times.Now(),
+	}
+	err := s.push(ctx, connector, &run)
+	if closer, ok := connector.(io.Closer); ok {
+		if cErr := closer.Close(); cErr != nil {
+			s.log.WithStack(cErr).Error(cErr)
+		}
+	}
+	run.FinishedAt = times.Now()
+	if err != nil {
+		run.Status = domain.ProvisioningRunFailed
+		reason := err.Error()
+		run.Error = &reason
+	}
+
+	if rErr := s.repoRegitry.GetProvisioningRepository().CreateRun(ctx, run); rErr != nil {
+		if err != nil {
+			s.log.WithStack(rErr).Error(rErr)
+			return run, err
+		}
+		return run, rErr
+	}
+
+	params := logger.Params{"type": "provisioning", "run": run.ID, "connector": run.Connector, "status": run.Status,
+		"pushed": run.Pushed, "deactivated": run.Deactivated, "failed": run.Failed, "deferred": run.Deferred,
+		"missing": run.Missing, "orphaned": run.Orphaned, "mismatched": run.Mismatched}
+	if err != nil {
+		s.log.WithParams(params).WithStack(err).Error("provisioning failed")
+		otel.RecordErrorChain(ctx, err)
+	} else {
+		s.log.WithParams(params).Info("users provisioned")
+	}
+	s.events.Publish(ctx, event.Event{
+		Name:    domain.EventProvisioningCompleted,
+		ActorID: "provisioning:" + run.Connector,
+		Attributes: map[string]interface{}{
+			"run_id":      run.ID,
+			"status":      run.Status,
+			"pushed":      run.Pushed,
+			"deactivated": run.Deactivated,
+			"failed":      run.Failed,
+			"missing":     run.Missing,
+			"orphaned":    run.Orphaned,
+			"mismatched":  run.Mismatched,
+		},
+	})
+	return run, err
+}
+
+// push pushes the accounts which changed since their last push, deactivates the accounts of the deleted users
+// and reconciles the directory with the accounts
+func (s *Service) push(ctx context.Context, connector Connector, run *domain.ProvisioningRun) error {
+
+	repo := s.repoRegitry.GetProvisioningRepository()
+	states, err := repo.ListStates(ctx, connector.Name())
+	if err != nil {
+		return err
+	}
+	stateByUser := make(map[string]domain.ProvisioningState, len(states))
+	for _, state := range states {
+		stateByUser[state.UserID] = state
+	}
+	queued, err := repo.ListErrors(ctx, connector.Name())
+	if err != nil {
+		return err
+	}
+	errorByUser := make(map[string]domain.ProvisioningError, len(queued))
+	for _, e := range queued {
+		errorByUser[e.UserID] = e
+	}
+
+	// desired are the accounts expected downstream by their key, with their user
+	desired := map[string]Account{}
+	userByKey := map[string]string{}
+	seen := map[string]bool{}
+	mapsRoles := false
+	for _, field := range connector.Mapping() {
+		mapsRoles = mapsRoles || field == configs.ProvisioningFieldRoles
+	}
+
+	afterID := ""
+	for {
+		users, err := s.repoRegitry.GetUserRepository().List(ctx, afterID, usersPageSize)
+		if err != nil {
+			return err
+		}
+		for _, user := range users {
+			seen[user.ID] = true
+
+			var roles []string
+			if mapsRoles && user.IsActive {
+				elevations, err := s.repoRegitry.GetElevationRepository().ListActiveByUserID(ctx, user.ID, times.Now())
+				if err != nil {
+					return err
+				}
+				for _, elevation := range elevations {
+					roles = append(roles, elevation.Role)
+				}
+			}
+
+			account := newAccount(connector, user, roles)
+			if account.Key == "" {
+				continue
+			}
+			if account.Active {
+				desired[account.Key] = account
+				userByKey[account.Key] = user.ID
+			}
+			if err := s.pushAccount(ctx, connector, user.ID, account, stateByUser, errorByUser, run); err != nil {
+				return err
+			}
+		}
+		if len(users) < usersPageSize {
+			break
+		}
+		afterID = users[len(users)-1].ID
+	}
+
+	// the users deleted since their last push are deactivated downstream
+	for userID, state := range stateByUser {
+		if seen[userID] || !state.Active {
+			continue
+		}
+		account := Account{Key: state.AccountKey, Active: false}
+		if err := s.pushAccount(ctx, connector, userID, account, stateByUser, errorByUser, run); err != nil {
+			return err
+		}
+	}
+
+	return s.reconcile(ctx, connector, desired, userByKey, run)
+}
+
+// pushAccount pushes the account of a user unless it did not change or its retry is not due yet.
+// A failed push is queued, the error returned is the one of the repository only.
+func (s *Service) pushAccount(ctx context.Context, connector Connector, userID string, account Account,
+	stateByUser map[string]domain.ProvisioningState, errorByUser map[string]domain.ProvisioningError, run *domain.ProvisioningRun) error {
+
+	repo := s.repoRegitry.GetProvisioningRepository()
+	state, pushed := stateByUser[userID]
+	hash := account.hash()
+	if (pushed && state.Hash == hash) || (!pushed && !account.Active) {
+		run.Unchanged++
+		return nil
+	}
+
+	now := times.Now()
+	queued, failed := errorByUser[userID]
+	if failed && (queued.Attempts >= s.cfg.Provisioning.MaxAttempts || now.Before(queued.NextAttemptAt)) {
+		run.Deferred++
+		return nil
+	}
+
+	key := account.Key
+	if pushed {
+		key = state.AccountKey
+	}
+	pushCtx, cancel := context.WithTimeout(ctx, time.Duration(s.cfg.Provisioning.Timeout)*time.Second)
+	err := connector.Push(pushCtx, key, account)
+	cancel()
+	if err != nil {
+		run.Failed++
+		reason := err.Error()
+		if len(reason) > maxErrorLength {
+			reason = reason[:maxErrorLength]
+		}
+		if !failed {
+			queued = domain.ProvisioningError{Connector: connector.Name(), UserID: userID, CreatedAt: now}
+		}
+		backoff := time.Duration(s.cfg.Provisioning.RetryBackoff) * time.Second << queued.Attempts
+		queued.Error = reason
+		queued.Attempts++
+		queued.NextAttemptAt = now.Add(backoff)
+		queued.UpdatedAt = now
+		s.log.WithParams(logger.Params{"connector": connector.Name(), "user_id": userID, "attempts": queued.Attempts}).Warn(err)
+		return repo.SaveError(ctx, queued)
+	}
+
+	if failed {
+		if err := repo.DeleteError(ctx, connector.Name(), userID); err != nil {
+			return err
+		}
+	}
+	if account.Active {
+		run.Pushed++
+	} else {
+		run.Deactivated++
+	}
+	if account.Key == "" {
+		account.Key = key
+	}
+	return repo.SaveState(ctx, domain.ProvisioningState{
+		Connector:  connector.Name(),
+		UserID:     userID,
+		AccountKey: account.Key,
+		Hash:       hash,
+		Active:     account.Active,
+		PushedAt:   now,
+	})
+}
+
+// reconcile compares the accounts of the directory with the desired accounts and records their drift
+func (s *Service) reconcile(ctx context.Context, connector Connector, desired map[string]Account, userByKey map[string]string, run *domain.ProvisioningRun) error {
+
+	listCtx, cancel := context.WithTimeout(ctx, time.Duration(s.cfg.Provisioning.Timeout)*time.Second)
+	accounts, err := connector.List(listCtx)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	drift := func(d domain.ProvisioningDrift) {
+		if len(run.Drifts) < maxRunDrifts {
+			run.Drifts = append(run.Drifts, d)
+		}
+	}
+	found := make(map[string]bool, len(accounts))
+	for _, account := range accounts {
+		want, ok := desired[account.Key]
+		if !ok {
+			if account.Active {
+				run.Orphaned++
+				drift(domain.ProvisioningDrift{Kind: domain.ProvisioningDriftOrphaned, AccountKey: account.Key})
+			}
+			continue
+		}
+		found[account.Key] = true
+		if attributes := want.diff(account); len(attributes) > 0 {
+			run.Mismatched++
+			drift(domain.ProvisioningDrift{Kind: domain.ProvisioningDriftMismatched, AccountKey: account.Key, UserID: userByKey[account.Key], Attributes: attributes})
+		}
+	}
+	keys := make([]string, 0, len(desired))
+	for key := range desired {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !found[key] {
+			run.Missing++
+			drift(domain.ProvisioningDrift{Kind: domain.ProvisioningDriftMissing, AccountKey: key, UserID: userByKey[key]})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package provisioning
+
+import (
+	"context"
+	"errors"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/memory"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/shared/ierr"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeConnector struct {
+	mapping  configs.ProvisioningMapping
+	accounts map[string]Account
+	failing  map[string]bool
+	pushes   []string
+}
+
+func (fakeConnector) Name() string {
+	return ConnectorLDAP
+}
+
+func (c *fakeConnector) Mapping() configs.ProvisioningMapping {
+	return c.mapping
+}
+
+func (fakeConnector) KeyAttribute() string {
+	return "uid"
+}
+
+func (c *fakeConnector) Push(ctx context.Context, key string, account Account) error {
+	if c.failing[key] {
+		return errors.New("directory unavailable")
+	}
+	c.pushes = append(c.pushes, key)
+	delete(c.accounts, key)
+	if account.Active {
+		c.accounts[account.Key] = account
+	}
+	return nil
+}
+
+func (c *fakeConnector) List(ctx context.Context) ([]Account, error) {
+	accounts := []Account{}
+	for _, account := range c.accounts {
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
+}
+
+func optional(s string) *string {
+	return &s
+}
+
+func newTestService(t *testing.T) (*Service, *memory.Store, *fakeConnector) {
+	cfg := &configs.Config{}
+	cfg.Provisioning.Timeout = 5
+	cfg.Provisioning.MaxAttempts = 3
+	cfg.Provisioning.RetryBackoff = 60
+
+	connector := &fakeConnector{
+		accounts: map[string]Account{"ghost": {Key: "ghost", Active: true, Attributes: map[string]interface{}{"uid": "ghost"}}},
+		failing:  map[string]bool{},
+	}
+	require.NoError(t, connector.mapping.Decode("uid:username,mail:email,memberOf:roles"))
+
+	ctx := context.Background()
+	store := memory.NewStore()
+	for _, user := range []domain.User{
+		{ID: "alice", Username: "alice", Email: optional("alice@example.com"), IsActive: true},
+		{ID: "bob", Username: "bob", IsActive: true},
+		{ID: "carol", Username: "carol", IsActive: false},
+	} {
+		require.NoError(t, store.GetUserRepository().Create(ctx, user))
+	}
+	endsAt := time.Now().Add(time.Hour)
+	for _, role := range []string{"operator", "auditor"} {
+		require.NoError(t, store.GetElevationRepository().Create(ctx, domain.Elevation{UserID: "alice", Role: role, Status: domain.ElevationStatusApproved, EndsAt: &endsAt}))
+	}
+	return NewService(cfg, store, logger.New("test", "test"), event.New(), connector), store, connector
+}
+
+// provisioningErrors returns the errors of the LDAP connector by user
+func provisioningErrors(t *testing.T, store *memory.Store) map[string]domain.ProvisioningError {
+	t.Helper()
+	list, err := store.GetProvisioningRepository().ListErrors(context.Background(), ConnectorLDAP)
+	require.NoError(t, err)
+	res := map[string]domain.ProvisioningError{}
+	for _, e := range list {
+		res[e.UserID] = e
+	}
+	return res
+}
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	service, store, connector := newTestService(t)
+	connector.failing["bob"] = true
+
+	// the failed push of bob is queued, carol was never pushed and stays away
+	run, err := service.Run(ctx, RequestRun{Connector: ConnectorLDAP})
+	require.NoError(t, err)
+	assert.Equal(t, domain.ProvisioningRunSucceeded, run.Status)
+	assert.Equal(t, 1, run.Pushed)
+	assert.Equal(t, 1, run.Failed)
+	assert.Equal(t, 1, run.Unchanged)
+	assert.Equal(t, []string{"auditor", "operator"}, connector.accounts["alice"].Attributes["memberOf"])
+	assert.Equal(t, 1, provisioningErrors(t, store)["bob"].Attempts)
+	assert.Equal(t, []domain.ProvisioningDrift{
+		{Kind: domain.ProvisioningDriftOrphaned, AccountKey: "ghost"},
+		{Kind: domain.ProvisioningDriftMissing, AccountKey: "bob", UserID: "bob"},
+	}, run.Drifts)
+
+	// the retry of bob is not due yet
+	run, err = service.Run(ctx, RequestRun{Connector: ConnectorLDAP})
+	require.NoError(t, err)
+	assert.Equal(t, 0, run.Pushed)
+	assert.Equal(t, 1, run.Deferred)
+	assert.Equal(t, 2, run.Unchanged)
+
+	// a manual retry is pushed by the next run
+	connector.failing["bob"] = false
+	require.NoError(t, service.RetryError(ctx, RequestRetryError{Connector: ConnectorLDAP, UserID: "bob"}))
+	run, err = service.Run(ctx, RequestRun{Connector: ConnectorLDAP})
+	require.NoError(t, err)
+	assert.Equal(t, 1, run.Pushed)
+	assert.Empty(t, provisioningErrors(t, store))
+	assert.Equal(t, 0, run.Missing)
+
+	// a deactivated user and a deleted user are deactivated downstream
+	require.NoError(t, store.GetUserRepository().SetActive(ctx, "alice", false))
+	store.DeleteUser("bob")
+	connector.accounts["carol"] = Account{Key: "carol", Active: true, Attributes: map[string]interface{}{"uid": "carol", "mail": "old@example.com"}}
+	run, err = service.Run(ctx, RequestRun{Connector: ConnectorLDAP})
+	require.NoError(t, err)
+	assert.Equal(t, 2, run.Deactivated)
+	assert.NotContains(t, connector.accounts, "alice")
+	assert.NotContains(t, connector.accounts, "bob")
+	assert.Equal(t, 2, run.Orphaned)
+	runs, err := store.GetProvisioningRepository().ListRuns(ctx, ConnectorLDAP, 0)
+	require.NoError(t, err)
+	assert.Len(t, runs, 4)
+}
+
+func TestRunMismatch(t *testing.T) {
+	ctx := context.Background()
+	service, _, connector := newTestService(t)
+
+	_, err := service.Run(ctx, RequestRun{Connector: ConnectorLDAP})
+	require.NoError(t, err)
+
+	// an account edited downstream is reported until the user changes
+	edited := connector.accounts["alice"]
+	edited.Attributes = map[string]interface{}{"uid": "alice", "mail": "ALICE@example.com", "memberOf": []string{"operator"}}
+	connector.accounts["alice"] = edited
+	run, err := service.Run(ctx, RequestRun{Connector: ConnectorLDAP})
+	require.NoError(t, err)
+	assert.Equal(t, 0, run.Pushed)
+	assert.Equal(t, 1, run.Mismatched)
+	assert.Contains(t, run.Drifts, domain.ProvisioningDrift{Kind: domain.ProvisioningDriftMismatched, AccountKey: "alice", UserID: "alice", Attributes: []string{"memberOf"}})
+}
+
+func TestRunUnknownConnector(t *testing.T) {
+	service, _, _ := newTestService(t)
+
+	_, err := service.Run(context.Background(), RequestRun{Connector: ConnectorSlack})
+	assert.Equal(t, ierr.ErrConnectorUnknown, err)
+
+	err = service.RetryError(context.Background(), RequestRetryError{Connector: ConnectorLDAP, UserID: "nobody"})
+	assert.Equal(t, ierr.ErrResourceNotFound, err)
+}
@@ -0,0 +1,157 @@
+package rbac
+
+import (
+	"go-hex/configs"
+	"go-hex/middleware"
+	"go-hex/pkg/authz"
+	"go-hex/shared/ierr"
+	"go-hex/shared/response"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+// RegisterAPI registers a new rbac api
+func RegisterAPI(r echo.Group, cfg *configs.Config, service ServicePort) {
+	handler := handler{cfg, service}
+
+	loggedIn := r.Group("", middleware.MustLoggedIn(cfg.JWTKeys()))
+	loggedIn.GET("/roles", handler.list, authz.Require(PermissionRolesRead))
+	loggedIn.GET("/users/:id/roles", handler.listUserRoles)
+	loggedIn.PUT("/users/:id/roles/:role", handler.assign, authz.Require(PermissionRolesWrite))
+	loggedIn.DELETE("/users/:id/roles/:role", handler.unassign, authz.Require(PermissionRolesWrite))
+}
+
+type handler struct {
+	cfg     *configs.Config
+	service ServicePort
+}
+
+// list godoc
+// @Router /roles [get]
+// @Tags RBAC
+// @Summary List the roles
+// @Description List every role with its permissions, requires the roles:read permission
+// @Accept json
+// @Produce json
+// @Security BearerToken
+// @Success 200 {object} response.Response{data=[]domain.Role} "Success"
+// @failure 401 {object} response.ErrorResponse401
+// @failure 403 {object} response.ErrorResponse403
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) list(c echo.Context) error {
+	res, err := h.service.List(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessOK(c, res)
+}
+
+// listUserRoles godoc
+// @Router /users/{id}/roles [get]
+// @Tags RBAC
+// @Summary List the roles of a user
+// @Description List the roles assigned to a user with their permissions, the roles of the other users require the roles:read permission
+// @Accept json
+// @Produce json
+// @Security BearerToken
+// @Param id path string true "user id"
+// @Success 200 {object} response.Response{data=[]domain.Role} "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 403 {object} response.ErrorResponse403
+// @failure 404 {object} response.ErrorResponse404
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) listUserRoles(c echo.Context) error {
+	var req RequestUserID
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.ListUserRoles(c.Request().Context(), req)
+	if err != nil {
+		switch errors.Cause(err) {
+		case ierr.ErrForbidden:
+			return response.ErrForbidden(err)
+		case ierr.ErrResourceNotFound:
+			return response.ErrNotFound(err)
+		}
+		return err
+	}
+
+	return response.SuccessOK(c, res)
+}
+
+// assign godoc
+// @Router /users/{id}/roles/{role} [put]
+// @Tags RBAC
+// @Summary Assign a role to a user
+// @Description Assign a role to another user, granted with its permissions to the access tokens issued afterwards, requires the roles:write permission; the optional starts_at and ends_at bound the window the role is granted in, assigning the role again replaces its window
+// @Accept json
+// @Produce json
+// @Security BearerToken
+// @Param id path string true "user id"
+// @Param role path string true "role"
+// @Param payload body RequestUserRole false " "
+// @Success 200 {object} response.Response{data=domain.UserRole} "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 403 {object} response.ErrorResponse403
+// @failure 404 {object} response.ErrorResponse404
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) assign(c echo.Context) error {
+	var req RequestUserRole
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.Assign(c.Request().Context(), req)
+	if err != nil {
+		switch errors.Cause(err) {
+		case ierr.ErrForbidden, ierr.ErrRoleElevationOnly:
+			return response.ErrForbidden(err)
+		case ierr.ErrResourceNotFound:
+			return response.ErrNotFound(err)
+		}
+		return err
+	}
+
+	return response.SuccessOK(c, res, "role assigned")
+}
+
+// unassign godoc
+// @Router /users/{id}/roles/{role} [delete]
+// @Tags RBAC
+// @Summary Unassign a role from a user
+// @Description Unassign a role from another user, the access tokens already issued keep it until they expire, requires the roles:write permission
+// @Accept json
+// @Produce json
+// @Security BearerToken
+// @Param id path string true "user id"
+// @Param role path string true "role"
+// @Success 200 {object} response.Response "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 403 {object} response.ErrorResponse403
+// @failure 404 {object} response.ErrorResponse404
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) unassign(c echo.Context) error {
+	var req RequestUserRole
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	err := h.service.Unassign(c.Request().Context(), req)
+	if err != nil {
+		switch errors.Cause(err) {
+		case ierr.ErrForbidden:
+			return response.ErrForbidden(err)
+		case ierr.ErrResourceNotFound:
+			return response.ErrNotFound(err)
+		}
+		return err
+	}
+
+	return response.SuccessOK(c, nil, "role unassigned")
+}
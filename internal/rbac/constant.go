@@ -0,0 +1,7 @@
+package rbac
+
+// Permissions checked by the rbac routes
+const (
+	PermissionRolesRead  = "roles:read"
+	PermissionRolesWrite = "roles:write"
+)
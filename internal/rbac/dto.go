@@ -0,0 +1,40 @@
+package rbac
+
+import (
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pkg/errors"
+)
+
+// RequestUserID request params
+type RequestUserID struct {
+	ID string `json:"-" param:"id"`
+}
+
+func (r *RequestUserID) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.ID, validation.Required, validation.Length(1, 36)),
+	)
+}
+
+// RequestUserRole request params, with the time window of the role in the body of an assignment
+type RequestUserRole struct {
+	UserID   string     `json:"-" param:"id"`
+	Role     string     `json:"-" param:"role"`
+	StartsAt *time.Time `json:"starts_at" example:"2026-10-19T09:00:00Z"` // granted from now when empty
+	EndsAt   *time.Time `json:"ends_at" example:"2026-10-26T09:00:00Z"`   // granted until unassigned when empty
+}
+
+func (r *RequestUserRole) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.UserID, validation.Required, validation.Length(1, 36)),
+		validation.Field(&r.Role, validation.Required, validation.Length(1, 100)),
+		validation.Field(&r.EndsAt, validation.When(r.StartsAt != nil && r.EndsAt != nil, validation.By(func(interface{}) error {
+			if !r.EndsAt.After(*r.StartsAt) {
+				return errors.New("must be after starts_at")
+			}
+			return nil
+		}))),
+	)
+}
@@ -0,0 +1,20 @@
+package rbac
+
+import (
+	"context"
+	"go-hex/internal/domain"
+)
+
+// ServicePort encapsulates the role-based access control logic.
+type ServicePort interface {
+	// List returns every role with its permissions
+	List(ctx context.Context) ([]domain.Role, error)
+	// ListUserRoles returns the roles granted now to a user, the users can read their own roles without roles:read
+	ListUserRoles(ctx context.Context, req RequestUserID) ([]domain.Role, error)
+	// Assign assigns a role to another user, granted to the access tokens issued afterwards within its time window
+	Assign(ctx context.Context, req RequestUserRole) (domain.UserRole, error)
+	// Unassign removes a role from another user
+	Unassign(ctx context.Context, req RequestUserRole) error
+	// ExpireRoles removes the time-boxed roles whose window ended and returns how many were removed
+	ExpireRoles(ctx context.Context) (int, error)
+}
@@ -0,0 +1,30 @@
+package rbac
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/pkg/logger"
+	"sync"
+
+	"github.com/go-co-op/gocron"
+)
+
+// RegisterScheduler schedules the expiry of the time-boxed roles of the users with the configured cron pattern,
+// the one of the roles of the service accounts.
+func RegisterScheduler(cfg *configs.Config, log logger.Logger, service ServicePort, cron *gocron.Scheduler, wg *sync.WaitGroup) {
+
+	_, err := cron.Cron(cfg.Scheduler.RoleExpiryPattern).SingletonMode().Do(func() {
+		wg.Add(1)
+		defer wg.Done()
+
+		expired, err := service.ExpireRoles(context.Background())
+		if err != nil {
+			log.WithStack(err).Error(err)
+			return
+		}
+		log.WithParams(logger.Params{"user_roles": expired}).Info("expired user roles removed")
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,240 @@
+package rbac
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/auth"
+	"go-hex/pkg/authz"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/times"
+	"go-hex/shared/ierr"
+	"time"
+)
+
+// Service encapsulates the role-based access control logic. The roles and their permissions are seeded by the
+// migrations, the service assigns them to the users; the access tokens embed the permissions checked by authz.
+type Service struct {
+	cfg         *configs.Config
+	repoRegitry port.RepositoryRegistry
+	log         logger.Logger
+	events      event.Bus
+}
+
+// NewService creates and returns a new rbac service
+func NewService(cfg *configs.Config, repoRegitry port.RepositoryRegistry, log logger.Logger, events event.Bus) *Service {
+	return &Service{cfg, repoRegitry, log, events}
+}
+
+// List returns every role with its permissions
+func (s *Service) List(ctx context.Context) ([]domain.Role, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	return s.repoRegitry.GetRoleRepository().List(ctx)
+}
+
+// ListUserRoles returns the roles granted now to a user, the users can read their own roles without roles:read
+func (s *Service) ListUserRoles(ctx context.Context, req RequestUserID) ([]domain.Role, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	if req.ID != auth.GetLoggedInUser(ctx).ID {
+		if err := authz.Check(ctx, PermissionRolesRead); err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = s.repoRegitry.GetUserRepository().GetByID(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repoRegitry.GetRoleRepository().ListByUserID(ctx, req.ID, times.Now())
+}
+
+// Assign assigns a role to another user, granted to the access tokens issued afterwards, within its time window when
+// it is time-boxed; assigning the role again replaces its window. The roles of the elevations are refused with
+// ierr.ErrRoleElevationOnly, they are requested and approved instead; they can still be unassigned.
+func (s *Service) Assign(ctx context.Context, req RequestUserRole) (domain.UserRole, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	assignerID, err := s.check(ctx, req)
+	if err != nil {
+		return domain.UserRole{}, err
+	}
+	// the elevation roles are only granted for a bounded time by an approved elevation, never for good
+	for _, role := range configs.FromContext(ctx, s.cfg).Elevation.Roles {
+		if req.Role == role {
+			return domain.UserRole{}, ierr.ErrRoleElevationOnly
+		}
+	}
+
+	before, err := s.userRoles(ctx, req.UserID)
+	if err != nil {
+		return domain.UserRole{}, err
+	}
+	assignment := domain.UserRole{
+		UserID:     req.UserID,
+		Role:       req.Role,
+		AssignedBy: assignerID,
+		CreatedAt:  times.Now(),
+		StartsAt:   req.StartsAt,
+		EndsAt:     req.EndsAt,
+	}
+	err = s.repoRegitry.GetRoleRepository().Assign(ctx, assignment)
+	if err != nil {
+		return domain.UserRole{}, err
+	}
+
+	return assignment, s.publish(ctx, domain.EventRoleAssigned, assignerID, req, before)
+}
+
+// Unassign removes a role from another user
+func (s *Service) Unassign(ctx context.Context, req RequestUserRole) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	assignerID, err := s.check(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	before, err := s.userRoles(ctx, req.UserID)
+	if err != nil {
+		return err
+	}
+	err = s.repoRegitry.GetRoleRepository().Unassign(ctx, req.UserID, req.Role)
+	if err != nil {
+		return err
+	}
+
+	return s.publish(ctx, domain.EventRoleUnassigned, assignerID, req, before)
+}
+
+// ExpireRoles removes the time-boxed roles whose window ended and publishes their expiry, recorded in the audit
+// trail. The roles are no longer granted once their window ended, removing them only tidies the assignments, so a
+// role assigned again in the meantime is kept.
+func (s *Service) ExpireRoles(ctx context.Context) (int, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	repoRole := s.repoRegitry.GetRoleRepository()
+	assignments, err := repoRole.ListExpired(ctx, times.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, assignment := range assignments {
+		removed, err := repoRole.DeleteExpired(ctx, assignment)
+		if err != nil {
+			return expired, err
+		}
+		if !removed {
+			continue
+		}
+		expired++
+
+		attributes := map[string]interface{}{"role": assignment.Role, "ends_at": assignment.EndsAt}
+		if assignment.StartsAt != nil {
+			attributes["starts_at"] = assignment.StartsAt
+		}
+		s.log.With(ctx).WithParams(logger.Params{"type": "role", "event": domain.EventRoleExpired, "user_id": assignment.UserID, "role": assignment.Role}).Info("user roles changed")
+		s.events.Publish(ctx, event.Event{
+			Name:       domain.EventRoleExpired,
+			ActorID:    domain.ActorTypeScheduler,
+			SubjectID:  assignment.UserID,
+			Attributes: attributes,
+		})
+	}
+	return expired, nil
+}
+
+// check validates the assignment and returns the id of the logged in assigner. The users cannot change their own
+// roles, so that a role is never granted without a second user, and the role and the user must exist.
+func (s *Service) check(ctx context.Context, req RequestUserRole) (string, error) {
+	err := req.Validate()
+	if err != nil {
+		return "", err
+	}
+
+	assignerID := auth.GetLoggedInUser(ctx).ID
+	if req.UserID == assignerID {
+		return "", ierr.ErrForbidden
+	}
+
+	roles, err := s.repoRegitry.GetRoleRepository().ListByNames(ctx, []string{req.Role})
+	if err != nil {
+		return "", err
+	}
+	if len(roles) == 0 {
+		return "", ierr.ErrResourceNotFound
+	}
+
+	_, err = s.repoRegitry.GetUserRepository().GetByID(ctx, req.UserID)
+	if err != nil {
+		return "", err
+	}
+	return assignerID, nil
+}
+
+// userRoles are the roles assigned to a user with their time window, diffed in the events of their changes
+type userRoles struct {
+	Roles []roleWindow `json:"roles"`
+}
+
+// roleWindow is a role assigned to a user, granted within the window when it is time-boxed
+type roleWindow struct {
+	Role     string     `json:"role"`
+	StartsAt *time.Time `json:"starts_at,omitempty"`
+	EndsAt   *time.Time `json:"ends_at,omitempty"`
+}
+
+// userRoles returns the roles assigned to the user, granted now or later
+func (s *Service) userRoles(ctx context.Context, userID string) (userRoles, error) {
+	assignments, err := s.repoRegitry.GetRoleRepository().ListAssignments(ctx, userID)
+	if err != nil {
+		return userRoles{}, err
+	}
+	roles := userRoles{Roles: []roleWindow{}}
+	for _, assignment := range assignments {
+		roles.Roles = append(roles.Roles, roleWindow{Role: assignment.Role, StartsAt: assignment.StartsAt, EndsAt: assignment.EndsAt})
+	}
+	return roles, nil
+}
+
+// publish logs the change of the roles of a user and publishes it on the event bus, with the diff of the roles of
+// the user
+func (s *Service) publish(ctx context.Context, name string, actorID string, req RequestUserRole, before userRoles) error {
+	after, err := s.userRoles(ctx, req.UserID)
+	if err != nil {
+		return err
+	}
+
+	s.log.With(ctx).WithParams(logger.Params{"type": "role", "event": name, "user_id": req.UserID, "role": req.Role}).Info("user roles changed")
+	s.events.Publish(ctx, event.Event{
+		Name:      name,
+		ActorID:   actorID,
+		SubjectID: req.UserID,
+		Attributes: map[string]interface{}{
+			"role": req.Role,
+			"diff": domain.Diff(before, after),
+		},
+	})
+	return nil
+}
@@ -0,0 +1,193 @@
+package rbac
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/memory"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/shared/ctxutil"
+	"go-hex/shared/ierr"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// loggedIn returns a context logged in as the user with an access token granting the permissions
+func loggedIn(userID string, permissions ...string) context.Context {
+	granted := []interface{}{}
+	for _, permission := range permissions {
+		granted = append(granted, permission)
+	}
+	token := &jwt.Token{Claims: jwt.MapClaims{"id": userID, "permissions": granted}}
+	return ctxutil.WithPrincipal(context.Background(), token)
+}
+
+// newService returns a service on a store holding the users admin-1, assigned the admin role, and user-1
+func newService(t *testing.T, events event.Bus) (*Service, *memory.Store) {
+	t.Helper()
+	store := memory.NewStore()
+	store.PutRole(domain.Role{Name: "auditor", Permissions: []string{"audit:read"}})
+	for _, userID := range []string{"admin-1", "user-1"} {
+		require.NoError(t, store.GetUserRepository().Create(context.Background(), domain.User{ID: userID, Username: userID}))
+	}
+	require.NoError(t, store.GetRoleRepository().Assign(context.Background(), domain.UserRole{UserID: "admin-1", Role: domain.RoleAdmin}))
+	cfg := &configs.Config{}
+	cfg.Elevation.Roles = []string{"auditor"}
+	return NewService(cfg, store, logger.New("test", "test"), events), store
+}
+
+// assigned returns the names of the roles assigned to the user
+func assigned(t *testing.T, store *memory.Store, userID string) []string {
+	t.Helper()
+	assignments, err := store.GetRoleRepository().ListAssignments(context.Background(), userID)
+	require.NoError(t, err)
+	names := []string{}
+	for _, assignment := range assignments {
+		names = append(names, assignment.Role)
+	}
+	return names
+}
+
+func TestAssignAndUnassign(t *testing.T) {
+	events := event.New()
+	var published []event.Event
+	for _, name := range []string{domain.EventRoleAssigned, domain.EventRoleUnassigned} {
+		events.Subscribe(name, func(ctx context.Context, e event.Event) {
+			published = append(published, e)
+		})
+	}
+	svc, store := newService(t, events)
+	ctx := loggedIn("admin-1", domain.PermissionAll)
+
+	assignment, err := svc.Assign(ctx, RequestUserRole{UserID: "user-1", Role: domain.RoleAdmin})
+	require.NoError(t, err)
+	assert.Equal(t, "admin-1", assignment.AssignedBy)
+	assert.Equal(t, []string{domain.RoleAdmin}, assigned(t, store, "user-1"))
+
+	roles, err := svc.ListUserRoles(loggedIn("user-1"), RequestUserID{ID: "user-1"})
+	require.NoError(t, err)
+	if assert.Len(t, roles, 1) {
+		assert.Equal(t, domain.RoleAdmin, roles[0].Name)
+	}
+
+	require.NoError(t, svc.Unassign(ctx, RequestUserRole{UserID: "user-1", Role: domain.RoleAdmin}))
+	assert.Empty(t, assigned(t, store, "user-1"))
+	assert.Equal(t, ierr.ErrResourceNotFound, svc.Unassign(ctx, RequestUserRole{UserID: "user-1", Role: domain.RoleAdmin}))
+
+	if assert.Len(t, published, 2) {
+		assert.Equal(t, domain.EventRoleAssigned, published[0].Name)
+		assert.Equal(t, "user-1", published[0].SubjectID)
+		assert.Equal(t, domain.EventRoleUnassigned, published[1].Name)
+
+		// the events record the roles of the user before and after the change
+		assert.Equal(t, map[string]domain.FieldChange{"roles": {Before: []roleWindow{}, After: []roleWindow{{Role: domain.RoleAdmin}}}}, published[0].Attributes["diff"])
+		assert.Equal(t, map[string]domain.FieldChange{"roles": {Before: []roleWindow{{Role: domain.RoleAdmin}}, After: []roleWindow{}}}, published[1].Attributes["diff"])
+	}
+}
+
+func TestAssignRejects(t *testing.T) {
+	svc, store := newService(t, event.New())
+	ctx := loggedIn("admin-1", domain.PermissionAll)
+
+	tests := []struct {
+		name string
+		req  RequestUserRole
+		err  error
+	}{
+		{"own roles", RequestUserRole{UserID: "admin-1", Role: domain.RoleUser}, ierr.ErrForbidden},
+		{"unknown role", RequestUserRole{UserID: "user-1", Role: "owner"}, ierr.ErrResourceNotFound},
+		{"unknown user", RequestUserRole{UserID: "user-2", Role: domain.RoleUser}, ierr.ErrResourceNotFound},
+		{"elevation role", RequestUserRole{UserID: "user-1", Role: "auditor"}, ierr.ErrRoleElevationOnly},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.Assign(ctx, tt.req)
+			assert.Equal(t, tt.err, err)
+		})
+	}
+	assert.Empty(t, assigned(t, store, "user-1"))
+	assert.Len(t, assigned(t, store, "admin-1"), 1)
+}
+
+func TestUnassignElevationRole(t *testing.T) {
+	svc, store := newService(t, event.New())
+	require.NoError(t, store.GetRoleRepository().Assign(context.Background(), domain.UserRole{UserID: "user-1", Role: "auditor"}))
+
+	// assigned before the role was managed by the elevations, it is removed as any other role
+	require.NoError(t, svc.Unassign(loggedIn("admin-1", domain.PermissionAll), RequestUserRole{UserID: "user-1", Role: "auditor"}))
+	assert.Empty(t, assigned(t, store, "user-1"))
+}
+
+func TestListUserRolesOfOtherUsersRequiresPermission(t *testing.T) {
+	svc, _ := newService(t, event.New())
+
+	_, err := svc.ListUserRoles(loggedIn("user-1", "profile:read"), RequestUserID{ID: "admin-1"})
+	assert.Equal(t, ierr.ErrForbidden, err)
+
+	assigned, err := svc.ListUserRoles(loggedIn("user-1", PermissionRolesRead), RequestUserID{ID: "admin-1"})
+	require.NoError(t, err)
+	assert.Len(t, assigned, 1)
+}
+
+func TestTimeBoxedRoles(t *testing.T) {
+	events := event.New()
+	var published []event.Event
+	events.Subscribe(event.All, func(ctx context.Context, e event.Event) {
+		published = append(published, e)
+	})
+	svc, store := newService(t, events)
+	ctx := loggedIn("admin-1", domain.PermissionAll)
+	now := time.Now().Truncate(time.Second)
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+
+	// the window must end after it starts
+	_, err := svc.Assign(ctx, RequestUserRole{UserID: "user-1", Role: domain.RoleAdmin, StartsAt: at(time.Hour), EndsAt: at(time.Hour)})
+	assert.Error(t, err)
+
+	// a role is only granted within its window, assigning it again replaces the window
+	assignment, err := svc.Assign(ctx, RequestUserRole{UserID: "user-1", Role: domain.RoleAdmin, StartsAt: at(time.Hour), EndsAt: at(2 * time.Hour)})
+	require.NoError(t, err)
+	assert.Equal(t, at(time.Hour), assignment.StartsAt)
+	roles, err := svc.ListUserRoles(ctx, RequestUserID{ID: "user-1"})
+	require.NoError(t, err)
+	assert.Empty(t, roles)
+
+	_, err = svc.Assign(ctx, RequestUserRole{UserID: "user-1", Role: domain.RoleAdmin, EndsAt: at(time.Hour)})
+	require.NoError(t, err)
+	roles, err = svc.ListUserRoles(ctx, RequestUserID{ID: "user-1"})
+	require.NoError(t, err)
+	if assert.Len(t, roles, 1) {
+		assert.Equal(t, domain.RoleAdmin, roles[0].Name)
+	}
+	roles, err = store.GetRoleRepository().ListByUserID(context.Background(), "user-1", now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, roles, "the end of the window is exclusive")
+
+	// the roles whose window ended are removed by the scheduler, their expiry is published
+	expired, err := svc.ExpireRoles(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, expired)
+	_, err = svc.Assign(ctx, RequestUserRole{UserID: "user-1", Role: domain.RoleAdmin, StartsAt: at(-2 * time.Hour), EndsAt: at(-time.Hour)})
+	require.NoError(t, err)
+	published = nil
+	expired, err = svc.ExpireRoles(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, expired)
+	assignments, err := store.GetRoleRepository().ListAssignments(context.Background(), "user-1")
+	require.NoError(t, err)
+	assert.Empty(t, assignments)
+	if assert.Len(t, published, 1) {
+		assert.Equal(t, domain.EventRoleExpired, published[0].Name)
+		assert.Equal(t, domain.ActorTypeScheduler, published[0].ActorID)
+		assert.Equal(t, "user-1", published[0].SubjectID)
+		assert.Equal(t, map[string]interface{}{"role": domain.RoleAdmin, "starts_at": at(-2 * time.Hour), "ends_at": at(-time.Hour)}, published[0].Attributes)
+	}
+}
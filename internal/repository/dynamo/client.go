@@ -0,0 +1,114 @@
+// Package dynamo stores the users and their sessions in a single DynamoDB table.
+//
+// Every item is keyed by PK and SK, the secondary indexes are overloaded:
+//
+//	item     PK                SK        GSI1PK              GSI1SK                     GSI2PK               GSI3PK
+//	user     USER#<id>         USER      USERNAME#<username> USER
+//	session  SESSION#<id>      SESSION   USER#<user id>      SESSION#<created at>#<id>  UPSTREAM_SID#<sid>   UPSTREAM_SUB#<sub>
+//
+// The sessions carry a ttl attribute, their expiry, so that DynamoDB deletes the expired sessions on its own.
+// The users carry a version attribute incremented on every write to detect the concurrent updates.
+package dynamo
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	attrPK      = "PK"
+	attrSK      = "SK"
+	attrVersion = "version"
+
+	indexGSI1 = "GSI1"
+	indexGSI2 = "GSI2"
+	indexGSI3 = "GSI3"
+)
+
+// Client is the part of the DynamoDB API used by the repositories
+type Client interface {
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error)
+	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
+	Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+	Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+}
+
+// NewClient creates a DynamoDB client from the default AWS configuration chain.
+// A non empty endpoint replaces the AWS endpoint, e.g. to reach a local DynamoDB.
+func NewClient(ctx context.Context, region, endpoint string) (*dynamodb.Client, error) {
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+	if err != nil {
+		return nil, err
+	}
+	return dynamodb.NewFromConfig(cfg, func(o *dynamodb.Options) {
+		if endpoint != "" {
+			o.EndpointResolver = dynamodb.EndpointResolverFromURL(endpoint)
+		}
+	}), nil
+}
+
+func key(pk, sk string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		attrPK: &types.AttributeValueMemberS{Value: pk},
+		attrSK: &types.AttributeValueMemberS{Value: sk},
+	}
+}
+
+func stringValue(value string) types.AttributeValue {
+	return &types.AttributeValueMemberS{Value: value}
+}
+
+// isConditionFailed checks whether the condition of a write did not hold
+func isConditionFailed(err error) bool {
+	var conditionErr *types.ConditionalCheckFailedException
+	return errors.As(err, &conditionErr)
+}
+
+// queryAll returns the items of every page of the query
+func queryAll(ctx context.Context, client Client, input *dynamodb.QueryInput) ([]map[string]types.AttributeValue, error) {
+	var items []map[string]types.AttributeValue
+	for {
+		out, err := client.Query(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, out.Items...)
+		if len(out.LastEvaluatedKey) == 0 {
+			return items, nil
+		}
+		input.ExclusiveStartKey = out.LastEvaluatedKey
+	}
+}
+
+// queryIndex queries the items of an index partition, optionally restricted to the sort keys with the given prefix
+func queryIndex(ctx context.Context, client Client, table, index, partition, sortPrefix string) ([]map[string]types.AttributeValue, error) {
+	names := map[string]string{"#pk": index + attrPK}
+	values := map[string]types.AttributeValue{":pk": stringValue(partition)}
+	condition := "#pk = :pk"
+	if sortPrefix != "" {
+		names["#sk"] = index + attrSK
+		values[":sk"] = stringValue(sortPrefix)
+		condition += " AND begins_with(#sk, :sk)"
+	}
+	return queryAll(ctx, client, &dynamodb.QueryInput{
+		TableName:                 aws.String(table),
+		IndexName:                 aws.String(index),
+		KeyConditionExpression:    aws.String(condition),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+		ScanIndexForward:          aws.Bool(true),
+	})
+}
+
+// keyPart returns the identifier of a prefixed key, e.g. the ID of USER#<id>
+func keyPart(value, prefix string) string {
+	return strings.TrimPrefix(value, prefix)
+}
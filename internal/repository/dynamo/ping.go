@@ -0,0 +1,24 @@
+package dynamo
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/pkg/errors"
+)
+
+// probeKey is the key read by Ping, no item is ever written with it
+const probeKey = "PROBE"
+
+// Ping reads a key which is never written, so that the latency of the table can be measured without side effect
+func Ping(ctx context.Context, client Client, table string) error {
+	_, err := client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(table),
+		Key:       key(probeKey, probeKey),
+	})
+	if err != nil {
+		return errors.Wrap(err, "cannot ping dynamodb table")
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package dynamo
+
+import (
+	"context"
+	"go-hex/internal/repository/port"
+)
+
+// RepositoryRegistry serves the users and the sessions from DynamoDB and the other repositories from sql.
+// The DynamoDB writes are not part of the SQL transactions: they are applied immediately and are not
+// rolled back, and the sessions are not locked, so the concurrent session limit is only best effort.
+type RepositoryRegistry struct {
+	port.RepositoryRegistry
+	client Client
+	table  string
+}
+
+// NewRepositoryRegistry creates a registry storing the users and the sessions in the given table
+func NewRepositoryRegistry(sql port.RepositoryRegistry, client Client, table string) port.RepositoryRegistry {
+	return &RepositoryRegistry{sql, client, table}
+}
+
+// DoInTransaction runs txFunc in a transaction of the sql registry, the users and the sessions
+// used by txFunc keep being served from DynamoDB.
+func (r *RepositoryRegistry) DoInTransaction(ctx context.Context, txFunc port.InTransaction) (out interface{}, err error) {
+	return r.RepositoryRegistry.DoInTransaction(ctx, func(ctx context.Context, repoRegistry port.RepositoryRegistry) (interface{}, error) {
+		return txFunc(ctx, &RepositoryRegistry{repoRegistry, r.client, r.table})
+	})
+}
+
+func (r *RepositoryRegistry) GetUserRepository() port.UserRepository {
+	return NewUserRepository(r.client, r.table)
+}
+
+func (r *RepositoryRegistry) GetSessionRepository() port.SessionRepository {
+	return NewSessionRepository(r.client, r.table)
+}
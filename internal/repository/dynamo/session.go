@@ -0,0 +1,362 @@
+package dynamo
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/times"
+	"go-hex/shared/ierr"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	sessionPrefix         = "SESSION#"
+	sessionSortKey        = "SESSION"
+	upstreamSessionPrefix = "UPSTREAM_SID#"
+	upstreamSubjectPrefix = "UPSTREAM_SUB#"
+
+	// sessionTimeLayout has a fixed width so that the sessions of a user sort by creation time
+	sessionTimeLayout = "2006-01-02T15:04:05.000000000Z"
+)
+
+// sessionItem is the item of a session in the table, TTL is the expiry in unix seconds
+type sessionItem struct {
+	PK     string `dynamodbav:"PK"`
+	SK     string `dynamodbav:"SK"`
+	GSI1PK string `dynamodbav:"GSI1PK"`
+	GSI1SK string `dynamodbav:"GSI1SK"`
+	GSI2PK string `dynamodbav:"GSI2PK,omitempty"`
+	GSI3PK string `dynamodbav:"GSI3PK,omitempty"`
+	TTL    int64  `dynamodbav:"ttl"`
+
+	UserID            string     `dynamodbav:"user_id"`
+	RefreshToken      *string    `dynamodbav:"refresh_token,omitempty"`
+	UpstreamSessionID *string    `dynamodbav:"upstream_session_id,omitempty"`
+	UpstreamSubject   *string    `dynamodbav:"upstream_subject,omitempty"`
+	IPAddress         *string    `dynamodbav:"ip_address,omitempty"`
+	UserAgent         *string    `dynamodbav:"user_agent,omitempty"`
+	ClientApp         *string    `dynamodbav:"client_app,omitempty"`
+	ClientVersion     *string    `dynamodbav:"client_version,omitempty"`
+	ClientPlatform    *string    `dynamodbav:"client_platform,omitempty"`
+	CreatedAt         time.Time  `dynamodbav:"created_at"`
+	LastUsedAt        *time.Time `dynamodbav:"last_used_at,omitempty"`
+	ExpiresAt         time.Time  `dynamodbav:"expires_at"`
+	RevokedAt         *time.Time `dynamodbav:"revoked_at,omitempty"`
+}
+
+func newSessionItem(session domain.Session) sessionItem {
+	item := sessionItem{
+		PK:                sessionPrefix + session.ID,
+		SK:                sessionSortKey,
+		GSI1PK:            userPrefix + session.UserID,
+		GSI1SK:            sessionPrefix + session.CreatedAt.UTC().Format(sessionTimeLayout) + "#" + session.ID,
+		TTL:               session.ExpiresAt.Unix(),
+		UserID:            session.UserID,
+		RefreshToken:      session.RefreshToken,
+		UpstreamSessionID: session.UpstreamSessionID,
+		UpstreamSubject:   session.UpstreamSubject,
+		IPAddress:         session.IPAddress,
+		UserAgent:         session.UserAgent,
+		ClientApp:         session.ClientApp,
+		ClientVersion:     session.ClientVersion,
+		ClientPlatform:    session.ClientPlatform,
+		CreatedAt:         session.CreatedAt,
+		LastUsedAt:        session.LastUsedAt,
+		ExpiresAt:         session.ExpiresAt,
+		RevokedAt:         session.RevokedAt,
+	}
+	if session.UpstreamSessionID != nil {
+		item.GSI2PK = upstreamSessionPrefix + *session.UpstreamSessionID
+	}
+	if session.UpstreamSubject != nil {
+		item.GSI3PK = upstreamSubjectPrefix + *session.UpstreamSubject
+	}
+	return item
+}
+
+func (i sessionItem) session() domain.Session {
+	return domain.Session{
+		ID:                keyPart(i.PK, sessionPrefix),
+		UserID:            i.UserID,
+		RefreshToken:      i.RefreshToken,
+		UpstreamSessionID: i.UpstreamSessionID,
+		UpstreamSubject:   i.UpstreamSubject,
+		IPAddress:         i.IPAddress,
+		UserAgent:         i.UserAgent,
+		ClientApp:         i.ClientApp,
+		ClientVersion:     i.ClientVersion,
+		ClientPlatform:    i.ClientPlatform,
+		CreatedAt:         i.CreatedAt,
+		LastUsedAt:        i.LastUsedAt,
+		ExpiresAt:         i.ExpiresAt,
+		RevokedAt:         i.RevokedAt,
+	}
+}
+
+// SessionRepository encapsulates the logic to access sessions from a DynamoDB table.
+// The expired sessions are deleted by the TTL of the table, they are filtered out until DynamoDB
+// actually deletes them. The lists read the indexes, which are eventually consistent.
+type SessionRepository struct {
+	client Client
+	table  string
+}
+
+// NewSessionRepository creates a new session repository
+func NewSessionRepository(client Client, table string) *SessionRepository {
+	return &SessionRepository{client, table}
+}
+
+// Create saves a new session in the storage.
+func (r *SessionRepository) Create(ctx context.Context, session domain.Session) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	av, err := attributevalue.MarshalMap(newSessionItem(session))
+	if err != nil {
+		return errors.Wrap(err, "cannot create session")
+	}
+
+	_, err = r.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                aws.String(r.table),
+		Item:                     av,
+		ConditionExpression:      aws.String("attribute_not_exists(#pk)"),
+		ExpressionAttributeNames: map[string]string{"#pk": attrPK},
+	})
+	if err != nil {
+		if isConditionFailed(err) {
+			return errors.Wrap(ierr.ErrConflict, "session already exists")
+		}
+		return errors.Wrap(err, "cannot create session")
+	}
+	return nil
+}
+
+// GetByID returns the session with the specified session ID.
+func (r *SessionRepository) GetByID(ctx context.Context, sessionID string) (domain.Session, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	out, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(r.table),
+		Key:            key(sessionPrefix+sessionID, sessionSortKey),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return domain.Session{}, errors.Wrap(err, "cannot get session")
+	}
+	if len(out.Item) == 0 {
+		return domain.Session{}, ierr.ErrResourceNotFound
+	}
+
+	var item sessionItem
+	if err := attributevalue.UnmarshalMap(out.Item, &item); err != nil {
+		return domain.Session{}, errors.Wrap(err, "cannot get session")
+	}
+	return item.session(), nil
+}
+
+// ListActiveByUserID returns the active sessions of the specified user ordered from the oldest.
+// DynamoDB has no row locks, the sessions are not locked inside a transaction.
+func (r *SessionRepository) ListActiveByUserID(ctx context.Context, userID string) ([]domain.Session, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	sessions, err := r.query(ctx, indexGSI1, userPrefix+userID, sessionPrefix)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list sessions")
+	}
+	return sessions, nil
+}
+
+// CountActiveByUserID returns the number of active sessions of the specified user.
+func (r *SessionRepository) CountActiveByUserID(ctx context.Context, userID string) (int, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	sessions, err := r.query(ctx, indexGSI1, userPrefix+userID, sessionPrefix)
+	if err != nil {
+		return 0, errors.Wrap(err, "cannot count sessions")
+	}
+	return len(sessions), nil
+}
+
+// Touch records the use of the session, when its refresh token was last issued.
+func (r *SessionRepository) Touch(ctx context.Context, sessionID string, at time.Time) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(r.table),
+		Key:                 key(sessionPrefix+sessionID, sessionSortKey),
+		UpdateExpression:    aws.String("SET #used = :used"),
+		ConditionExpression: aws.String("attribute_exists(#pk)"),
+		ExpressionAttributeNames: map[string]string{
+			"#pk":   attrPK,
+			"#used": "last_used_at",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":used": stringValue(at.Format(time.RFC3339Nano)),
+		},
+	})
+	if err != nil {
+		// like the SQL repository, updating a missing session is not an error
+		if isConditionFailed(err) {
+			return nil
+		}
+		return errors.Wrap(err, "cannot update session")
+	}
+	return nil
+}
+
+// ClearRefreshToken clears the hashed refresh token of the session if it still equals the given hash.
+// It returns false when the token has already been cleared.
+func (r *SessionRepository) ClearRefreshToken(ctx context.Context, sessionID string, hashedRefreshToken string) (bool, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(r.table),
+		Key:                 key(sessionPrefix+sessionID, sessionSortKey),
+		UpdateExpression:    aws.String("REMOVE #token"),
+		ConditionExpression: aws.String("#token = :token"),
+		ExpressionAttributeNames: map[string]string{
+			"#token": "refresh_token",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":token": stringValue(hashedRefreshToken),
+		},
+	})
+	if err != nil {
+		if isConditionFailed(err) {
+			return false, nil
+		}
+		return false, errors.Wrap(err, "cannot clear refresh token")
+	}
+	return true, nil
+}
+
+// Revoke revokes the session with the specified session ID.
+func (r *SessionRepository) Revoke(ctx context.Context, sessionID string) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	if err := r.revoke(ctx, sessionID); err != nil {
+		return errors.Wrap(err, "cannot revoke session")
+	}
+	return nil
+}
+
+// RevokeByUserID revokes all active sessions of the specified user.
+func (r *SessionRepository) RevokeByUserID(ctx context.Context, userID string) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	sessions, err := r.query(ctx, indexGSI1, userPrefix+userID, sessionPrefix)
+	if err != nil {
+		return errors.Wrap(err, "cannot revoke sessions")
+	}
+	return r.revokeAll(ctx, sessions)
+}
+
+// RevokeByUpstreamSessionID revokes the active sessions started with the specified upstream sid.
+// A non empty upstream subject must match as well.
+func (r *SessionRepository) RevokeByUpstreamSessionID(ctx context.Context, upstreamSessionID string, upstreamSubject string) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	sessions, err := r.query(ctx, indexGSI2, upstreamSessionPrefix+upstreamSessionID, "")
+	if err != nil {
+		return errors.Wrap(err, "cannot revoke sessions")
+	}
+
+	matching := sessions[:0]
+	for _, session := range sessions {
+		if upstreamSubject == "" || (session.UpstreamSubject != nil && *session.UpstreamSubject == upstreamSubject) {
+			matching = append(matching, session)
+		}
+	}
+	return r.revokeAll(ctx, matching)
+}
+
+// RevokeByUpstreamSubject revokes the active sessions started with the specified upstream sub.
+func (r *SessionRepository) RevokeByUpstreamSubject(ctx context.Context, upstreamSubject string) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	sessions, err := r.query(ctx, indexGSI3, upstreamSubjectPrefix+upstreamSubject, "")
+	if err != nil {
+		return errors.Wrap(err, "cannot revoke sessions")
+	}
+	return r.revokeAll(ctx, sessions)
+}
+
+// query returns the active sessions of an index partition ordered by the sort key of the index
+func (r *SessionRepository) query(ctx context.Context, index, partition, sortPrefix string) ([]domain.Session, error) {
+
+	items, err := queryIndex(ctx, r.client, r.table, index, partition, sortPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var sessionItems []sessionItem
+	if err := attributevalue.UnmarshalListOfMaps(items, &sessionItems); err != nil {
+		return nil, err
+	}
+
+	now := times.Now()
+	sessions := make([]domain.Session, 0, len(sessionItems))
+	for _, item := range sessionItems {
+		if session := item.session(); session.IsActive(now) {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, nil
+}
+
+// revoke sets the revocation time of the session and clears its refresh token unless it is missing or already revoked
+func (r *SessionRepository) revoke(ctx context.Context, sessionID string) error {
+
+	_, err := r.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(r.table),
+		Key:                 key(sessionPrefix+sessionID, sessionSortKey),
+		UpdateExpression:    aws.String("SET #revoked = :revoked REMOVE #token"),
+		ConditionExpression: aws.String("attribute_exists(#pk) AND attribute_not_exists(#revoked)"),
+		ExpressionAttributeNames: map[string]string{
+			"#pk":      attrPK,
+			"#revoked": "revoked_at",
+			"#token":   "refresh_token",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":revoked": stringValue(times.Now().Format(time.RFC3339Nano)),
+		},
+	})
+	if err != nil && !isConditionFailed(err) {
+		return err
+	}
+	return nil
+}
+
+func (r *SessionRepository) revokeAll(ctx context.Context, sessions []domain.Session) error {
+	for _, session := range sessions {
+		if err := r.revoke(ctx, session.ID); err != nil {
+			return errors.Wrap(err, "cannot revoke sessions")
+		}
+	}
+	return nil
+}
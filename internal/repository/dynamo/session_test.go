@@ -0,0 +1,78 @@
+package dynamo
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/pkg/times"
+	"go-hex/shared/ierr"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionRepositoryCreate(t *testing.T) {
+
+	sid, userAgent := "upstream-sid", "Mozilla/5.0"
+	session := domain.Session{ID: "session-1", UserID: "user-1", UpstreamSessionID: &sid, UserAgent: &userAgent, CreatedAt: times.Now(), ExpiresAt: times.Now().Add(time.Hour)}
+
+	client := &fakeClient{}
+	require.NoError(t, NewSessionRepository(client, "table").Create(context.Background(), session))
+
+	item := client.puts[0].Item
+	assert.Equal(t, stringValue("USER#user-1"), item["GSI1PK"])
+	assert.Equal(t, stringValue("UPSTREAM_SID#upstream-sid"), item["GSI2PK"])
+	assert.NotContains(t, item, "GSI3PK")
+	assert.IsType(t, &types.AttributeValueMemberN{}, item["ttl"])
+	assert.Equal(t, stringValue("Mozilla/5.0"), item["user_agent"])
+	assert.NotContains(t, item, "ip_address")
+
+	client = &fakeClient{writeErrs: []error{errConditionFailed}}
+	err := NewSessionRepository(client, "table").Create(context.Background(), session)
+	assert.Equal(t, ierr.ErrConflict, errors.Cause(err))
+}
+
+func TestSessionRepositoryListActiveByUserID(t *testing.T) {
+
+	now := times.Now()
+	revokedAt := now.Add(-time.Minute)
+	active := domain.Session{ID: "active", UserID: "user-1", CreatedAt: now.Add(-time.Hour), ExpiresAt: now.Add(time.Hour)}
+	expired := domain.Session{ID: "expired", UserID: "user-1", CreatedAt: now.Add(-2 * time.Hour), ExpiresAt: now.Add(-time.Hour)}
+	revoked := domain.Session{ID: "revoked", UserID: "user-1", CreatedAt: now.Add(-time.Hour), ExpiresAt: now.Add(time.Hour), RevokedAt: &revokedAt}
+
+	// the sessions are returned over two pages
+	client := &fakeClient{pages: []*dynamodb.QueryOutput{
+		{Items: []map[string]types.AttributeValue{marshal(t, newSessionItem(expired))}, LastEvaluatedKey: key("SESSION#expired", sessionSortKey)},
+		{Items: []map[string]types.AttributeValue{marshal(t, newSessionItem(active)), marshal(t, newSessionItem(revoked))}},
+	}}
+
+	sessions, err := NewSessionRepository(client, "table").ListActiveByUserID(context.Background(), "user-1")
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "active", sessions[0].ID)
+	assert.Equal(t, key("SESSION#expired", sessionSortKey), client.queries[1].ExclusiveStartKey)
+}
+
+func TestSessionRepositoryRevokeByUpstreamSessionID(t *testing.T) {
+
+	now := times.Now()
+	sid, sub, otherSub := "sid", "sub", "other"
+	matching := domain.Session{ID: "matching", UpstreamSessionID: &sid, UpstreamSubject: &sub, ExpiresAt: now.Add(time.Hour)}
+	other := domain.Session{ID: "other", UpstreamSessionID: &sid, UpstreamSubject: &otherSub, ExpiresAt: now.Add(time.Hour)}
+
+	// the session revoked concurrently fails its condition, which is not an error
+	client := &fakeClient{
+		pages:     []*dynamodb.QueryOutput{{Items: []map[string]types.AttributeValue{marshal(t, newSessionItem(matching)), marshal(t, newSessionItem(other))}}},
+		writeErrs: []error{errConditionFailed},
+	}
+
+	err := NewSessionRepository(client, "table").RevokeByUpstreamSessionID(context.Background(), sid, sub)
+	require.NoError(t, err)
+	assert.Equal(t, indexGSI2, *client.queries[0].IndexName)
+	require.Len(t, client.updates, 1)
+	assert.Equal(t, stringValue("SESSION#matching"), client.updates[0].Key[attrPK])
+}
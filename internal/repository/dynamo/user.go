@@ -0,0 +1,523 @@
+package dynamo
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/times"
+	"go-hex/shared/ierr"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	userPrefix     = "USER#"
+	usernamePrefix = "USERNAME#"
+	userSortKey    = "USER"
+
+	// userUpdateAttempts bounds the optimistic updates of a user modified concurrently
+	userUpdateAttempts = 3
+
+	// batchGetLimit is the maximum number of keys read by a BatchGetItem
+	batchGetLimit = 100
+)
+
+// userItem is the item of a user in the table
+type userItem struct {
+	PK     string `dynamodbav:"PK"`
+	SK     string `dynamodbav:"SK"`
+	GSI1PK string `dynamodbav:"GSI1PK"`
+	GSI1SK string `dynamodbav:"GSI1SK"`
+
+	Version       int        `dynamodbav:"version"`
+	Username      string     `dynamodbav:"username"`
+	Password      string     `dynamodbav:"password"`
+	FullName      *string    `dynamodbav:"full_name,omitempty"`
+	Email         *string    `dynamodbav:"email,omitempty"`
+	VerifiedEmail *string    `dynamodbav:"verified_email,omitempty"`
+	Phone         *string    `dynamodbav:"phone,omitempty"`
+	RefreshToken  *string    `dynamodbav:"refresh_token,omitempty"`
+	IsActive      bool       `dynamodbav:"is_active"`
+	MaxSessions   *int       `dynamodbav:"max_sessions,omitempty"`
+	CompromisedAt *time.Time `dynamodbav:"compromised_at,omitempty"`
+	CreatedAt     time.Time  `dynamodbav:"created_at"`
+	UpdatedAt     time.Time  `dynamodbav:"updated_at"`
+}
+
+func newUserItem(user domain.User, version int) userItem {
+	return userItem{
+		PK:            userPrefix + user.ID,
+		SK:            userSortKey,
+		GSI1PK:        usernamePrefix + user.Username,
+		GSI1SK:        userSortKey,
+		Version:       version,
+		Username:      user.Username,
+		Password:      user.Password,
+		FullName:      user.FullName,
+		Email:         user.Email,
+		VerifiedEmail: user.VerifiedEmail,
+		Phone:         user.Phone,
+		RefreshToken:  user.RefreshToken,
+		IsActive:      user.IsActive,
+		MaxSessions:   user.MaxSessions,
+		CompromisedAt: user.CompromisedAt,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+	}
+}
+
+func (i userItem) user() domain.User {
+	return domain.User{
+		ID:            keyPart(i.PK, userPrefix),
+		Username:      i.Username,
+		Password:      i.Password,
+		FullName:      i.FullName,
+		Email:         i.Email,
+		VerifiedEmail: i.VerifiedEmail,
+		Phone:         i.Phone,
+		RefreshToken:  i.RefreshToken,
+		IsActive:      i.IsActive,
+		MaxSessions:   i.MaxSessions,
+		CompromisedAt: i.CompromisedAt,
+		CreatedAt:     i.CreatedAt,
+		UpdatedAt:     i.UpdatedAt,
+	}
+}
+
+// UserRepository encapsulates the logic to access users from a DynamoDB table.
+type UserRepository struct {
+	client Client
+	table  string
+}
+
+// NewUserRepository creates a new user repository
+func NewUserRepository(client Client, table string) *UserRepository {
+	return &UserRepository{client, table}
+}
+
+// GetByID returns the user with the specified user ID.
+func (r *UserRepository) GetByID(ctx context.Context, userID string) (domain.User, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	item, err := r.get(ctx, userID)
+	if err != nil {
+		return domain.User{}, err
+	}
+	return item.user(), nil
+}
+
+// GetByUsername returns the user with the specified username.
+func (r *UserRepository) GetByUsername(ctx context.Context, username string) (domain.User, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	items, err := queryIndex(ctx, r.client, r.table, indexGSI1, usernamePrefix+username, userSortKey)
+	if err != nil {
+		return domain.User{}, errors.Wrap(err, "cannot get user")
+	}
+	if len(items) == 0 {
+		return domain.User{}, ierr.ErrResourceNotFound
+	}
+
+	var item userItem
+	if err := attributevalue.UnmarshalMap(items[0], &item); err != nil {
+		return domain.User{}, errors.Wrap(err, "cannot get user")
+	}
+	return item.user(), nil
+}
+
+// ListByIDs returns the users with the specified IDs, the IDs without user are skipped.
+// The users are read in batches of batchGetLimit keys, the keys left unprocessed by DynamoDB are read again.
+func (r *UserRepository) ListByIDs(ctx context.Context, userIDs []string) ([]domain.User, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	users := []domain.User{}
+	seen := map[string]bool{}
+	var keys []map[string]types.AttributeValue
+	for _, userID := range userIDs {
+		if !seen[userID] {
+			seen[userID] = true
+			keys = append(keys, key(userPrefix+userID, userSortKey))
+		}
+	}
+
+	for len(keys) > 0 {
+		batch := keys
+		if len(batch) > batchGetLimit {
+			batch = batch[:batchGetLimit]
+		}
+		keys = keys[len(batch):]
+
+		out, err := r.client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{
+			RequestItems: map[string]types.KeysAndAttributes{r.table: {Keys: batch}},
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot list users")
+		}
+		for _, av := range out.Responses[r.table] {
+			var item userItem
+			if err := attributevalue.UnmarshalMap(av, &item); err != nil {
+				return nil, errors.Wrap(err, "cannot list users")
+			}
+			users = append(users, item.user())
+		}
+		keys = append(keys, out.UnprocessedKeys[r.table].Keys...)
+	}
+	return users, nil
+}
+
+// ListByEmail returns the users with the specified email address, the email addresses are not indexed so the table is scanned.
+func (r *UserRepository) ListByEmail(ctx context.Context, email string) ([]domain.User, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	input := &dynamodb.ScanInput{
+		TableName:                 aws.String(r.table),
+		FilterExpression:          aws.String("#sk = :sk AND #email = :email"),
+		ExpressionAttributeNames:  map[string]string{"#sk": attrSK, "#email": "email"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{":sk": stringValue(userSortKey), ":email": stringValue(email)},
+	}
+
+	users := []domain.User{}
+	for {
+		out, err := r.client.Scan(ctx, input)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot list users")
+		}
+		for _, av := range out.Items {
+			var item userItem
+			if err := attributevalue.UnmarshalMap(av, &item); err != nil {
+				return nil, errors.Wrap(err, "cannot list users")
+			}
+			users = append(users, item.user())
+		}
+		if len(out.LastEvaluatedKey) == 0 {
+			return users, nil
+		}
+		input.ExclusiveStartKey = out.LastEvaluatedKey
+	}
+}
+
+// List returns a page of users in the order of the table scan, starting after the user with the specified ID when set.
+func (r *UserRepository) List(ctx context.Context, afterID string, limit int) ([]domain.User, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	input := &dynamodb.ScanInput{
+		TableName:                 aws.String(r.table),
+		FilterExpression:          aws.String("#sk = :sk"),
+		ExpressionAttributeNames:  map[string]string{"#sk": attrSK},
+		ExpressionAttributeValues: map[string]types.AttributeValue{":sk": stringValue(userSortKey)},
+	}
+	if afterID != "" {
+		// the scan resumes after the position of the user, it does not need to be a key returned by the previous scan
+		input.ExclusiveStartKey = key(userPrefix+afterID, userSortKey)
+	}
+
+	users := []domain.User{}
+	for {
+		out, err := r.client.Scan(ctx, input)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot list users")
+		}
+		for _, av := range out.Items {
+			var item userItem
+			if err := attributevalue.UnmarshalMap(av, &item); err != nil {
+				return nil, errors.Wrap(err, "cannot list users")
+			}
+			users = append(users, item.user())
+			if len(users) == limit {
+				return users, nil
+			}
+		}
+		if len(out.LastEvaluatedKey) == 0 {
+			return users, nil
+		}
+		input.ExclusiveStartKey = out.LastEvaluatedKey
+	}
+}
+
+// IsUserExistByID checks wether user exists
+func (r *UserRepository) IsUserExistByID(ctx context.Context, userID string) (bool, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.GetByID(ctx, userID)
+	if err != nil {
+		if err == ierr.ErrResourceNotFound {
+			return false, nil
+		}
+		return false, errors.Wrap(err, "cannot check user")
+	}
+	return true, nil
+}
+
+// IsUserExistByUsername checks whether user exists by username
+func (r *UserRepository) IsUserExistByUsername(ctx context.Context, username string) (bool, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.GetByUsername(ctx, username)
+	if err != nil {
+		if err == ierr.ErrResourceNotFound {
+			return false, nil
+		}
+		return false, errors.Wrap(err, "cannot check user")
+	}
+	return true, nil
+}
+
+// Create saves a new user in the storage.
+// It returns ierr.ErrUserAlreadyRegistered when the username is taken. The usernames are only unique through
+// their index, so that two users created concurrently with the same username are both saved.
+func (r *UserRepository) Create(ctx context.Context, user domain.User) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	exist, err := r.IsUserExistByUsername(ctx, user.Username)
+	if err != nil {
+		return errors.Wrap(err, "cannot create user")
+	}
+	if exist {
+		return ierr.ErrUserAlreadyRegistered
+	}
+
+	av, err := attributevalue.MarshalMap(newUserItem(user, 1))
+	if err != nil {
+		return errors.Wrap(err, "cannot create user")
+	}
+
+	_, err = r.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                aws.String(r.table),
+		Item:                     av,
+		ConditionExpression:      aws.String("attribute_not_exists(#pk)"),
+		ExpressionAttributeNames: map[string]string{"#pk": attrPK},
+	})
+	if err != nil {
+		if isConditionFailed(err) {
+			return errors.Wrap(ierr.ErrConflict, "user already exists")
+		}
+		return errors.Wrap(err, "cannot create user")
+	}
+	return nil
+}
+
+// Update updates the user with given ID in the storage.
+// Like the SQL repository only the non zero fields are written. The item is replaced on the condition
+// that its version did not change since it was read, a user modified concurrently is read again
+// and ierr.ErrConflict is returned once the attempts are exhausted.
+func (r *UserRepository) Update(ctx context.Context, userID string, user domain.User) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	for attempt := 0; attempt < userUpdateAttempts; attempt++ {
+		current, err := r.get(ctx, userID)
+		if err != nil {
+			if err == ierr.ErrResourceNotFound {
+				// the SQL repository silently updates no row as well
+				return nil
+			}
+			return errors.Wrap(err, "cannot update user")
+		}
+
+		item := newUserItem(mergeUser(current.user(), user), current.Version+1)
+		av, err := attributevalue.MarshalMap(item)
+		if err != nil {
+			return errors.Wrap(err, "cannot update user")
+		}
+
+		_, err = r.client.PutItem(ctx, &dynamodb.PutItemInput{
+			TableName:                aws.String(r.table),
+			Item:                     av,
+			ConditionExpression:      aws.String("#version = :version"),
+			ExpressionAttributeNames: map[string]string{"#version": attrVersion},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":version": &types.AttributeValueMemberN{Value: strconv.Itoa(current.Version)},
+			},
+		})
+		if err == nil {
+			return nil
+		}
+		if !isConditionFailed(err) {
+			return errors.Wrap(err, "cannot update user")
+		}
+	}
+	return errors.Wrap(ierr.ErrConflict, "user was modified concurrently")
+}
+
+// SetActive activates or deactivates the user with given ID, Update cannot deactivate a user as it skips the zero fields.
+func (r *UserRepository) SetActive(ctx context.Context, userID string, active bool) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(r.table),
+		Key:                 key(userPrefix+userID, userSortKey),
+		UpdateExpression:    aws.String("SET #active = :active, #updated = :updated ADD #version :one"),
+		ConditionExpression: aws.String("attribute_exists(PK)"),
+		ExpressionAttributeNames: map[string]string{
+			"#active":  "is_active",
+			"#updated": "updated_at",
+			"#version": attrVersion,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":active":  &types.AttributeValueMemberBOOL{Value: active},
+			":updated": stringValue(times.Now().Format(time.RFC3339Nano)),
+			":one":     &types.AttributeValueMemberN{Value: "1"},
+		},
+	})
+	if err != nil {
+		if isConditionFailed(err) {
+			// the SQL repository silently updates no row as well
+			return nil
+		}
+		return errors.Wrap(err, "cannot set user active")
+	}
+	return nil
+}
+
+// ClearRefreshToken clears the refresh token of the user if it still equals the given hashed token.
+// It returns false when the token has already been cleared or replaced.
+func (r *UserRepository) ClearRefreshToken(ctx context.Context, userID string, hashedToken string) (bool, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(r.table),
+		Key:                 key(userPrefix+userID, userSortKey),
+		UpdateExpression:    aws.String("REMOVE #token SET #updated = :updated ADD #version :one"),
+		ConditionExpression: aws.String("#token = :token"),
+		ExpressionAttributeNames: map[string]string{
+			"#token":   "refresh_token",
+			"#updated": "updated_at",
+			"#version": attrVersion,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":token":   stringValue(hashedToken),
+			":updated": stringValue(times.Now().Format(time.RFC3339Nano)),
+			":one":     &types.AttributeValueMemberN{Value: "1"},
+		},
+	})
+	if err != nil {
+		if isConditionFailed(err) {
+			return false, nil
+		}
+		return false, errors.Wrap(err, "cannot clear refresh token")
+	}
+	return true, nil
+}
+
+// ReplacePassword replaces the hashed password of the user if it still equals the given hashed password.
+// It returns false when the password has been changed meanwhile.
+func (r *UserRepository) ReplacePassword(ctx context.Context, userID string, hashedPwd string, newHashedPwd string) (bool, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(r.table),
+		Key:                 key(userPrefix+userID, userSortKey),
+		UpdateExpression:    aws.String("SET #password = :new, #updated = :updated ADD #version :one"),
+		ConditionExpression: aws.String("#password = :password"),
+		ExpressionAttributeNames: map[string]string{
+			"#password": "password",
+			"#updated":  "updated_at",
+			"#version":  attrVersion,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":password": stringValue(hashedPwd),
+			":new":      stringValue(newHashedPwd),
+			":updated":  stringValue(times.Now().Format(time.RFC3339Nano)),
+			":one":      &types.AttributeValueMemberN{Value: "1"},
+		},
+	})
+	if err != nil {
+		if isConditionFailed(err) {
+			return false, nil
+		}
+		return false, errors.Wrap(err, "cannot replace password")
+	}
+	return true, nil
+}
+
+// get reads the item of the user consistently, so that an update never starts from a stale version
+func (r *UserRepository) get(ctx context.Context, userID string) (userItem, error) {
+
+	out, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(r.table),
+		Key:            key(userPrefix+userID, userSortKey),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return userItem{}, errors.Wrap(err, "cannot get user")
+	}
+	if len(out.Item) == 0 {
+		return userItem{}, ierr.ErrResourceNotFound
+	}
+
+	var item userItem
+	if err := attributevalue.UnmarshalMap(out.Item, &item); err != nil {
+		return userItem{}, errors.Wrap(err, "cannot get user")
+	}
+	return item, nil
+}
+
+// mergeUser applies the non zero fields of the update to the user, like OmitZero of the SQL repository
+func mergeUser(user, update domain.User) domain.User {
+	if update.Username != "" {
+		user.Username = update.Username
+	}
+	if update.Password != "" {
+		user.Password = update.Password
+	}
+	if update.FullName != nil {
+		user.FullName = update.FullName
+	}
+	if update.Email != nil {
+		user.Email = update.Email
+	}
+	if update.VerifiedEmail != nil {
+		user.VerifiedEmail = update.VerifiedEmail
+	}
+	if update.Phone != nil {
+		user.Phone = update.Phone
+	}
+	if update.RefreshToken != nil {
+		user.RefreshToken = update.RefreshToken
+	}
+	if update.IsActive {
+		user.IsActive = true
+	}
+	if update.MaxSessions != nil {
+		user.MaxSessions = update.MaxSessions
+	}
+	if update.CompromisedAt != nil {
+		user.CompromisedAt = update.CompromisedAt
+	}
+	if !update.CreatedAt.IsZero() {
+		user.CreatedAt = update.CreatedAt
+	}
+	if !update.UpdatedAt.IsZero() {
+		user.UpdatedAt = update.UpdatedAt
+	}
+	return user
+}
@@ -0,0 +1,173 @@
+package dynamo
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/shared/ierr"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient answers the reads with the given items and fails the writes with the given errors in turn
+type fakeClient struct {
+	Client
+
+	item      map[string]types.AttributeValue
+	pages     []*dynamodb.QueryOutput
+	scans     []*dynamodb.ScanOutput
+	batches   []*dynamodb.BatchGetItemOutput
+	writeErrs []error
+
+	puts    []*dynamodb.PutItemInput
+	updates []*dynamodb.UpdateItemInput
+	queries []*dynamodb.QueryInput
+	gets    []*dynamodb.BatchGetItemInput
+}
+
+func (c *fakeClient) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	return &dynamodb.GetItemOutput{Item: c.item}, nil
+}
+
+func (c *fakeClient) BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	c.gets = append(c.gets, params)
+	batch := c.batches[0]
+	c.batches = c.batches[1:]
+	return batch, nil
+}
+
+func (c *fakeClient) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	c.puts = append(c.puts, params)
+	return &dynamodb.PutItemOutput{}, c.writeErr()
+}
+
+func (c *fakeClient) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	c.updates = append(c.updates, params)
+	return &dynamodb.UpdateItemOutput{}, c.writeErr()
+}
+
+func (c *fakeClient) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	c.queries = append(c.queries, params)
+	page := c.pages[0]
+	c.pages = c.pages[1:]
+	return page, nil
+}
+
+func (c *fakeClient) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	page := c.scans[0]
+	c.scans = c.scans[1:]
+	return page, nil
+}
+
+func (c *fakeClient) writeErr() error {
+	if len(c.writeErrs) == 0 {
+		return nil
+	}
+	err := c.writeErrs[0]
+	c.writeErrs = c.writeErrs[1:]
+	return err
+}
+
+var errConditionFailed = &types.ConditionalCheckFailedException{}
+
+func marshal(t *testing.T, item interface{}) map[string]types.AttributeValue {
+	av, err := attributevalue.MarshalMap(item)
+	require.NoError(t, err)
+	return av
+}
+
+func TestUserRepositoryUpdate(t *testing.T) {
+
+	fullName := "Jane Doe"
+	stored := newUserItem(domain.User{ID: "user-1", Username: "jane", Password: "hashed", IsActive: true}, 4)
+
+	tests := []struct {
+		name      string
+		writeErrs []error
+		wantPuts  int
+		wantErr   error
+	}{
+		{name: "writes the next version", wantPuts: 1},
+		{name: "retries a concurrent update", writeErrs: []error{errConditionFailed}, wantPuts: 2},
+		{name: "gives up after the attempts", writeErrs: []error{errConditionFailed, errConditionFailed, errConditionFailed}, wantPuts: userUpdateAttempts, wantErr: ierr.ErrConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeClient{item: marshal(t, stored), writeErrs: tt.writeErrs}
+			err := NewUserRepository(client, "table").Update(context.Background(), "user-1", domain.User{FullName: &fullName})
+
+			assert.Equal(t, tt.wantErr, errors.Cause(err))
+			require.Len(t, client.puts, tt.wantPuts)
+
+			put := client.puts[len(client.puts)-1]
+			assert.Equal(t, &types.AttributeValueMemberN{Value: "4"}, put.ExpressionAttributeValues[":version"])
+
+			var written userItem
+			require.NoError(t, attributevalue.UnmarshalMap(put.Item, &written))
+			assert.Equal(t, 5, written.Version)
+			assert.Equal(t, domain.User{ID: "user-1", Username: "jane", Password: "hashed", FullName: &fullName, IsActive: true}, written.user())
+		})
+	}
+}
+
+func TestUserRepositoryClearRefreshToken(t *testing.T) {
+
+	client := &fakeClient{}
+	cleared, err := NewUserRepository(client, "table").ClearRefreshToken(context.Background(), "user-1", "token")
+	assert.NoError(t, err)
+	assert.True(t, cleared)
+
+	client = &fakeClient{writeErrs: []error{errConditionFailed}}
+	cleared, err = NewUserRepository(client, "table").ClearRefreshToken(context.Background(), "user-1", "token")
+	assert.NoError(t, err)
+	assert.False(t, cleared)
+}
+
+func TestUserRepositoryGetByIDNotFound(t *testing.T) {
+	_, err := NewUserRepository(&fakeClient{}, "table").GetByID(context.Background(), "user-1")
+	assert.Equal(t, ierr.ErrResourceNotFound, err)
+}
+
+func TestUserRepositoryList(t *testing.T) {
+
+	item := func(id string) map[string]types.AttributeValue {
+		return marshal(t, newUserItem(domain.User{ID: id, Username: id}, 1))
+	}
+	client := &fakeClient{scans: []*dynamodb.ScanOutput{
+		{Items: []map[string]types.AttributeValue{item("user-1")}, LastEvaluatedKey: key(userPrefix+"user-1", userSortKey)},
+		{Items: []map[string]types.AttributeValue{item("user-2"), item("user-3")}},
+	}}
+
+	users, err := NewUserRepository(client, "table").List(context.Background(), "", 2)
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	assert.Equal(t, "user-2", users[1].ID)
+	assert.Empty(t, client.scans, "the scan must go on until the page is full")
+}
+
+func TestUserRepositoryListByIDs(t *testing.T) {
+
+	item := func(id string) map[string]types.AttributeValue {
+		return marshal(t, newUserItem(domain.User{ID: id, Username: id}, 1))
+	}
+	client := &fakeClient{batches: []*dynamodb.BatchGetItemOutput{
+		{
+			Responses:       map[string][]map[string]types.AttributeValue{"table": {item("user-1")}},
+			UnprocessedKeys: map[string]types.KeysAndAttributes{"table": {Keys: []map[string]types.AttributeValue{key(userPrefix+"user-2", userSortKey)}}},
+		},
+		{Responses: map[string][]map[string]types.AttributeValue{"table": {item("user-2")}}},
+	}}
+
+	users, err := NewUserRepository(client, "table").ListByIDs(context.Background(), []string{"user-1", "user-2", "user-1", "user-3"})
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	assert.Equal(t, "user-2", users[1].ID)
+	assert.Len(t, client.gets[0].RequestItems["table"].Keys, 3, "the IDs must be read once")
+	assert.Len(t, client.gets[1].RequestItems["table"].Keys, 1, "the unprocessed keys must be read again")
+}
@@ -0,0 +1,70 @@
+package memory
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"sort"
+	"time"
+)
+
+type auditRepository struct {
+	store *Store
+}
+
+func (r *auditRepository) Record(ctx context.Context, auditEvents []domain.AuditEvent) error {
+	unlock, err := r.store.begin(ctx, "AuditRepository.Record")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for _, auditEvent := range auditEvents {
+		auditEvent.ID = r.store.nextID(auditEvent.ID)
+		r.store.records.auditEvents = append(r.store.records.auditEvents, auditEvent)
+	}
+	return nil
+}
+
+func (r *auditRepository) List(ctx context.Context, actorID string, action string, from time.Time, to time.Time, limit int, offset int) ([]domain.AuditEvent, error) {
+	unlock, err := r.store.begin(ctx, "AuditRepository.List")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	auditEvents := []domain.AuditEvent{}
+	for _, auditEvent := range r.store.records.auditEvents {
+		if auditEvent.CreatedAt.Before(from) || !auditEvent.CreatedAt.Before(to) ||
+			actorID != "" && auditEvent.ActorID != actorID || action != "" && auditEvent.Action != action {
+			continue
+		}
+		auditEvents = append(auditEvents, auditEvent)
+	}
+	sort.SliceStable(auditEvents, func(i, j int) bool {
+		if !auditEvents[i].CreatedAt.Equal(auditEvents[j].CreatedAt) {
+			return auditEvents[i].CreatedAt.After(auditEvents[j].CreatedAt)
+		}
+		return auditEvents[i].ID > auditEvents[j].ID
+	})
+	start, end := bounds(len(auditEvents), limit, offset)
+	return auditEvents[start:end], nil
+}
+
+func (r *auditRepository) DeleteExpired(ctx context.Context, before time.Time, heldUserIDs []string) (int64, error) {
+	unlock, err := r.store.begin(ctx, "AuditRepository.DeleteExpired")
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	kept := []domain.AuditEvent{}
+	for _, auditEvent := range r.store.records.auditEvents {
+		held := contains(heldUserIDs, auditEvent.ActorID) || contains(heldUserIDs, auditEvent.SubjectID)
+		if !auditEvent.CreatedAt.Before(before) || held {
+			kept = append(kept, auditEvent)
+		}
+	}
+	deleted := len(r.store.records.auditEvents) - len(kept)
+	r.store.records.auditEvents = kept
+	return int64(deleted), nil
+}
@@ -0,0 +1,97 @@
+package memory
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/shared/ierr"
+	"time"
+)
+
+type breakGlassAccountRepository struct {
+	store *Store
+}
+
+func (r *breakGlassAccountRepository) Seal(ctx context.Context, account domain.BreakGlassAccount) error {
+	unlock, err := r.store.begin(ctx, "BreakGlassAccountRepository.Seal")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for i, existing := range r.store.records.breakGlassAccounts {
+		if existing.UserID == account.UserID {
+			r.store.records.breakGlassAccounts[i] = account
+			return nil
+		}
+	}
+	r.store.records.breakGlassAccounts = append(r.store.records.breakGlassAccounts, account)
+	return nil
+}
+
+func (r *breakGlassAccountRepository) GetByUserID(ctx context.Context, userID string) (domain.BreakGlassAccount, error) {
+	unlock, err := r.store.begin(ctx, "BreakGlassAccountRepository.GetByUserID")
+	if err != nil {
+		return domain.BreakGlassAccount{}, err
+	}
+	defer unlock()
+
+	for _, account := range r.store.records.breakGlassAccounts {
+		if account.UserID == userID {
+			return account, nil
+		}
+	}
+	return domain.BreakGlassAccount{}, ierr.ErrResourceNotFound
+}
+
+func (r *breakGlassAccountRepository) Activate(ctx context.Context, account domain.BreakGlassAccount) (bool, error) {
+	unlock, err := r.store.begin(ctx, "BreakGlassAccountRepository.Activate")
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	for i, existing := range r.store.records.breakGlassAccounts {
+		if existing.UserID == account.UserID && existing.Status == domain.BreakGlassStatusSealed {
+			r.store.records.breakGlassAccounts[i].Status = domain.BreakGlassStatusActive
+			r.store.records.breakGlassAccounts[i].ActivatedBy = account.ActivatedBy
+			r.store.records.breakGlassAccounts[i].Reason = account.Reason
+			r.store.records.breakGlassAccounts[i].ActivatedAt = account.ActivatedAt
+			r.store.records.breakGlassAccounts[i].ExpiresAt = account.ExpiresAt
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *breakGlassAccountRepository) Revoke(ctx context.Context, userID string, at time.Time) (bool, error) {
+	unlock, err := r.store.begin(ctx, "BreakGlassAccountRepository.Revoke")
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	for i, existing := range r.store.records.breakGlassAccounts {
+		if existing.UserID == userID && existing.Status == domain.BreakGlassStatusActive {
+			r.store.records.breakGlassAccounts[i].Status = domain.BreakGlassStatusSpent
+			r.store.records.breakGlassAccounts[i].RevokedAt = &at
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *breakGlassAccountRepository) ListExpired(ctx context.Context, before time.Time) ([]domain.BreakGlassAccount, error) {
+	unlock, err := r.store.begin(ctx, "BreakGlassAccountRepository.ListExpired")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	accounts := []domain.BreakGlassAccount{}
+	for _, account := range r.store.records.breakGlassAccounts {
+		if account.Status == domain.BreakGlassStatusActive && account.ExpiresAt != nil && !account.ExpiresAt.After(before) {
+			accounts = append(accounts, account)
+		}
+	}
+	return accounts, nil
+}
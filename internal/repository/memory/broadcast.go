@@ -0,0 +1,119 @@
+package memory
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/shared/ierr"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type broadcastRepository struct {
+	store *Store
+}
+
+func (r *broadcastRepository) Create(ctx context.Context, broadcast domain.Broadcast) error {
+	unlock, err := r.store.begin(ctx, "BroadcastRepository.Create")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for _, existing := range r.store.records.broadcasts {
+		if existing.ID == broadcast.ID {
+			return errors.Wrap(ierr.ErrConflict, "cannot create broadcast")
+		}
+	}
+	broadcast.ID = r.store.nextID(broadcast.ID)
+	r.store.records.broadcasts = append(r.store.records.broadcasts, broadcast)
+	return nil
+}
+
+func (r *broadcastRepository) GetByID(ctx context.Context, id string) (domain.Broadcast, error) {
+	unlock, err := r.store.begin(ctx, "BroadcastRepository.GetByID")
+	if err != nil {
+		return domain.Broadcast{}, err
+	}
+	defer unlock()
+
+	for _, broadcast := range r.store.records.broadcasts {
+		if broadcast.ID == id {
+			return broadcast, nil
+		}
+	}
+	return domain.Broadcast{}, ierr.ErrResourceNotFound
+}
+
+func (r *broadcastRepository) ListActive(ctx context.Context, now time.Time) ([]domain.Broadcast, error) {
+	unlock, err := r.store.begin(ctx, "BroadcastRepository.ListActive")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	broadcasts := []domain.Broadcast{}
+	for _, broadcast := range r.store.records.broadcasts {
+		if broadcast.ExpiresAt.After(now) {
+			broadcasts = append(broadcasts, broadcast)
+		}
+	}
+	sort.SliceStable(broadcasts, func(i, j int) bool { return broadcasts[i].CreatedAt.Before(broadcasts[j].CreatedAt) })
+	return broadcasts, nil
+}
+
+func (r *broadcastRepository) RecordDelivery(ctx context.Context, delivery domain.BroadcastDelivery) (bool, error) {
+	unlock, err := r.store.begin(ctx, "BroadcastRepository.RecordDelivery")
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	for _, existing := range r.store.records.broadcastDeliveries {
+		if existing.BroadcastID == delivery.BroadcastID && existing.SessionID == delivery.SessionID {
+			return false, nil
+		}
+	}
+	r.store.records.broadcastDeliveries = append(r.store.records.broadcastDeliveries, delivery)
+	return true, nil
+}
+
+func (r *broadcastRepository) CountDeliveries(ctx context.Context, broadcastID string) (int, error) {
+	unlock, err := r.store.begin(ctx, "BroadcastRepository.CountDeliveries")
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	count := 0
+	for _, delivery := range r.store.records.broadcastDeliveries {
+		if delivery.BroadcastID == broadcastID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *broadcastRepository) ListDeliveries(ctx context.Context, broadcastID string, limit int, offset int) ([]domain.BroadcastDelivery, error) {
+	unlock, err := r.store.begin(ctx, "BroadcastRepository.ListDeliveries")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	deliveries := []domain.BroadcastDelivery{}
+	for _, delivery := range r.store.records.broadcastDeliveries {
+		if delivery.BroadcastID == broadcastID {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	sort.SliceStable(deliveries, func(i, j int) bool {
+		if !deliveries[i].DeliveredAt.Equal(deliveries[j].DeliveredAt) {
+			return deliveries[i].DeliveredAt.After(deliveries[j].DeliveredAt)
+		}
+		return deliveries[i].SessionID > deliveries[j].SessionID
+	})
+	from, to := bounds(len(deliveries), limit, offset)
+	return deliveries[from:to], nil
+}
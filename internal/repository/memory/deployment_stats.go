@@ -0,0 +1,78 @@
+package memory
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/times"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DeploymentStatsRepository stores the deployment stats in memory for retention. The stats are only those of the
+// requests served by the instance.
+type DeploymentStatsRepository struct {
+	retention time.Duration
+	now       func() time.Time
+
+	mu    sync.Mutex
+	stats map[string]map[int64]*domain.DeploymentStats
+}
+
+// NewDeploymentStatsRepository creates an empty in-memory store of the deployment stats, each kept for retention
+func NewDeploymentStatsRepository(retention time.Duration) port.DeploymentStatsRepository {
+	return &DeploymentStatsRepository{retention: retention, now: times.Now, stats: map[string]map[int64]*domain.DeploymentStats{}}
+}
+
+// Add adds the counts of the stats to the ones stored for their version and minute
+func (r *DeploymentStatsRepository) Add(ctx context.Context, stats []domain.DeploymentStats) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// the expired stats are pruned on write, so that the store stays bounded by the retention
+	expired := r.now().Add(-r.retention).Unix()
+	for version, minutes := range r.stats {
+		for minute := range minutes {
+			if minute < expired {
+				delete(minutes, minute)
+			}
+		}
+		if len(minutes) == 0 {
+			delete(r.stats, version)
+		}
+	}
+
+	for _, s := range stats {
+		minutes, ok := r.stats[s.Version]
+		if !ok {
+			minutes = map[int64]*domain.DeploymentStats{}
+			r.stats[s.Version] = minutes
+		}
+		stored, ok := minutes[s.Minute.Unix()]
+		if !ok {
+			created := domain.NewDeploymentStats(s.Version, s.Minute)
+			stored = &created
+			minutes[s.Minute.Unix()] = stored
+		}
+		stored.Add(s)
+	}
+	return nil
+}
+
+// List returns the stats of the version whose minute is between from and to included
+func (r *DeploymentStatsRepository) List(ctx context.Context, version string, from, to time.Time) ([]domain.DeploymentStats, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	res := []domain.DeploymentStats{}
+	for minute, s := range r.stats[version] {
+		if minute >= from.Unix() && minute <= to.Unix() {
+			stats := domain.NewDeploymentStats(s.Version, s.Minute)
+			stats.Add(*s)
+			res = append(res, stats)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Minute.Before(res[j].Minute) })
+	return res, nil
+}
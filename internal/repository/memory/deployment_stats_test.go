@@ -0,0 +1,39 @@
+package memory
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeploymentStatsRepository(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	repo := NewDeploymentStatsRepository(time.Hour).(*DeploymentStatsRepository)
+	repo.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	stats := domain.NewDeploymentStats("v1.3.0", now)
+	stats.Observe(false, true, 80*time.Millisecond)
+	require.NoError(t, repo.Add(ctx, []domain.DeploymentStats{stats, stats}))
+	later := domain.NewDeploymentStats("v1.3.0", now.Add(2*time.Minute))
+	later.Observe(true, false, 0)
+	require.NoError(t, repo.Add(ctx, []domain.DeploymentStats{later}))
+
+	got, err := repo.List(ctx, "v1.3.0", now, now.Add(time.Minute))
+	require.NoError(t, err)
+	if assert.Len(t, got, 1) {
+		assert.Equal(t, uint64(2), got[0].Requests)
+		assert.Equal(t, uint64(2), got[0].Latencies[domain.LatencyBucket(80*time.Millisecond)])
+	}
+	got, _ = repo.List(ctx, "v1.3.0", now, now.Add(2*time.Minute))
+	assert.Len(t, got, 2)
+
+	// the stats are pruned once past the retention
+	now = now.Add(2 * time.Hour)
+	require.NoError(t, repo.Add(ctx, nil))
+	assert.Empty(t, repo.stats)
+}
@@ -0,0 +1,125 @@
+package memory
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/shared/ierr"
+	"time"
+)
+
+type deviceLoginRepository struct {
+	store *Store
+}
+
+func (r *deviceLoginRepository) Create(ctx context.Context, deviceLogin domain.DeviceLogin) error {
+	unlock, err := r.store.begin(ctx, "DeviceLoginRepository.Create")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for _, existing := range r.store.records.deviceLogins {
+		if existing.ID == deviceLogin.ID || existing.DeviceCode == deviceLogin.DeviceCode || existing.UserCode == deviceLogin.UserCode {
+			return ierr.ErrConflict
+		}
+	}
+	deviceLogin.ID = r.store.nextID(deviceLogin.ID)
+	r.store.records.deviceLogins = append(r.store.records.deviceLogins, deviceLogin)
+	return nil
+}
+
+func (r *deviceLoginRepository) GetByDeviceCode(ctx context.Context, hashedDeviceCode string) (domain.DeviceLogin, error) {
+	return r.get(ctx, "DeviceLoginRepository.GetByDeviceCode", func(deviceLogin domain.DeviceLogin) bool {
+		return deviceLogin.DeviceCode == hashedDeviceCode
+	})
+}
+
+func (r *deviceLoginRepository) GetByUserCode(ctx context.Context, userCode string) (domain.DeviceLogin, error) {
+	return r.get(ctx, "DeviceLoginRepository.GetByUserCode", func(deviceLogin domain.DeviceLogin) bool {
+		return deviceLogin.UserCode == userCode
+	})
+}
+
+func (r *deviceLoginRepository) get(ctx context.Context, op string, match func(domain.DeviceLogin) bool) (domain.DeviceLogin, error) {
+	unlock, err := r.store.begin(ctx, op)
+	if err != nil {
+		return domain.DeviceLogin{}, err
+	}
+	defer unlock()
+
+	for _, deviceLogin := range r.store.records.deviceLogins {
+		if match(deviceLogin) {
+			return deviceLogin, nil
+		}
+	}
+	return domain.DeviceLogin{}, ierr.ErrResourceNotFound
+}
+
+func (r *deviceLoginRepository) Decide(ctx context.Context, deviceLoginID string, userID string, status string) (bool, error) {
+	unlock, err := r.store.begin(ctx, "DeviceLoginRepository.Decide")
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	for i, deviceLogin := range r.store.records.deviceLogins {
+		if deviceLogin.ID == deviceLoginID && deviceLogin.Status == domain.DeviceLoginStatusPending {
+			now := r.store.now()
+			r.store.records.deviceLogins[i].Status = status
+			r.store.records.deviceLogins[i].UserID = &userID
+			r.store.records.deviceLogins[i].DecidedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *deviceLoginRepository) UpdateStatus(ctx context.Context, deviceLoginID string, from string, to string) (bool, error) {
+	unlock, err := r.store.begin(ctx, "DeviceLoginRepository.UpdateStatus")
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	for i, deviceLogin := range r.store.records.deviceLogins {
+		if deviceLogin.ID == deviceLoginID && deviceLogin.Status == from {
+			r.store.records.deviceLogins[i].Status = to
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *deviceLoginRepository) UpdatePolledAt(ctx context.Context, deviceLoginID string, polledAt time.Time) error {
+	unlock, err := r.store.begin(ctx, "DeviceLoginRepository.UpdatePolledAt")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for i, deviceLogin := range r.store.records.deviceLogins {
+		if deviceLogin.ID == deviceLoginID {
+			r.store.records.deviceLogins[i].LastPolledAt = &polledAt
+		}
+	}
+	return nil
+}
+
+func (r *deviceLoginRepository) DeleteExpired(ctx context.Context, before time.Time, heldUserIDs []string) (int64, error) {
+	unlock, err := r.store.begin(ctx, "DeviceLoginRepository.DeleteExpired")
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	kept := []domain.DeviceLogin{}
+	for _, deviceLogin := range r.store.records.deviceLogins {
+		held := deviceLogin.UserID != nil && contains(heldUserIDs, *deviceLogin.UserID)
+		if !deviceLogin.ExpiresAt.Before(before) || held {
+			kept = append(kept, deviceLogin)
+		}
+	}
+	deleted := len(r.store.records.deviceLogins) - len(kept)
+	r.store.records.deviceLogins = kept
+	return int64(deleted), nil
+}
@@ -0,0 +1,120 @@
+package memory
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/shared/ierr"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type elevationRepository struct {
+	store *Store
+}
+
+func (r *elevationRepository) Create(ctx context.Context, elevation domain.Elevation) error {
+	unlock, err := r.store.begin(ctx, "ElevationRepository.Create")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for _, existing := range r.store.records.elevations {
+		if existing.ID == elevation.ID {
+			return errors.Wrap(ierr.ErrConflict, "cannot create elevation")
+		}
+	}
+	elevation.ID = r.store.nextID(elevation.ID)
+	r.store.records.elevations = append(r.store.records.elevations, elevation)
+	return nil
+}
+
+func (r *elevationRepository) GetByID(ctx context.Context, elevationID string) (domain.Elevation, error) {
+	unlock, err := r.store.begin(ctx, "ElevationRepository.GetByID")
+	if err != nil {
+		return domain.Elevation{}, err
+	}
+	defer unlock()
+
+	for _, elevation := range r.store.records.elevations {
+		if elevation.ID == elevationID {
+			return elevation, nil
+		}
+	}
+	return domain.Elevation{}, ierr.ErrResourceNotFound
+}
+
+func (r *elevationRepository) ListByUserID(ctx context.Context, userID string) ([]domain.Elevation, error) {
+	unlock, err := r.store.begin(ctx, "ElevationRepository.ListByUserID")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	elevations := []domain.Elevation{}
+	for _, elevation := range r.store.records.elevations {
+		if elevation.UserID == userID {
+			elevations = append(elevations, elevation)
+		}
+	}
+	sort.SliceStable(elevations, func(i, j int) bool { return elevations[i].RequestedAt.After(elevations[j].RequestedAt) })
+	return elevations, nil
+}
+
+func (r *elevationRepository) ListPending(ctx context.Context) ([]domain.Elevation, error) {
+	unlock, err := r.store.begin(ctx, "ElevationRepository.ListPending")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	now := r.store.now()
+	elevations := []domain.Elevation{}
+	for _, elevation := range r.store.records.elevations {
+		if elevation.Status == domain.ElevationStatusPending && elevation.ExpiresAt.After(now) {
+			elevations = append(elevations, elevation)
+		}
+	}
+	sort.SliceStable(elevations, func(i, j int) bool { return elevations[i].RequestedAt.Before(elevations[j].RequestedAt) })
+	return elevations, nil
+}
+
+func (r *elevationRepository) ListActiveByUserID(ctx context.Context, userID string, at time.Time) ([]domain.Elevation, error) {
+	unlock, err := r.store.begin(ctx, "ElevationRepository.ListActiveByUserID")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	elevations := []domain.Elevation{}
+	for _, elevation := range r.store.records.elevations {
+		if elevation.UserID == userID && elevation.Status == domain.ElevationStatusApproved && elevation.EndsAt != nil && elevation.EndsAt.After(at) {
+			elevations = append(elevations, elevation)
+		}
+	}
+	sort.SliceStable(elevations, func(i, j int) bool { return elevations[i].EndsAt.Before(*elevations[j].EndsAt) })
+	return elevations, nil
+}
+
+func (r *elevationRepository) Decide(ctx context.Context, elevation domain.Elevation) (bool, error) {
+	unlock, err := r.store.begin(ctx, "ElevationRepository.Decide")
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	for i, existing := range r.store.records.elevations {
+		if existing.ID == elevation.ID && existing.Status == domain.ElevationStatusPending &&
+			elevation.DecidedAt != nil && existing.ExpiresAt.After(*elevation.DecidedAt) {
+			r.store.records.elevations[i].Status = elevation.Status
+			r.store.records.elevations[i].DecidedBy = elevation.DecidedBy
+			r.store.records.elevations[i].DecidedAt = elevation.DecidedAt
+			r.store.records.elevations[i].Comment = elevation.Comment
+			r.store.records.elevations[i].EndsAt = elevation.EndsAt
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,83 @@
+package memory
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/shared/ierr"
+	"sort"
+)
+
+type emailSuppressionRepository struct {
+	store *Store
+}
+
+func (r *emailSuppressionRepository) Record(ctx context.Context, suppression domain.EmailSuppression) error {
+	unlock, err := r.store.begin(ctx, "EmailSuppressionRepository.Record")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for i, existing := range r.store.records.emailSuppressions {
+		if existing.Address == suppression.Address {
+			r.store.records.emailSuppressions[i].Reason = suppression.Reason
+			r.store.records.emailSuppressions[i].Provider = suppression.Provider
+			r.store.records.emailSuppressions[i].Detail = suppression.Detail
+			r.store.records.emailSuppressions[i].Feedbacks++
+			r.store.records.emailSuppressions[i].UpdatedAt = suppression.UpdatedAt
+			return nil
+		}
+	}
+	r.store.records.emailSuppressions = append(r.store.records.emailSuppressions, suppression)
+	return nil
+}
+
+func (r *emailSuppressionRepository) GetByAddress(ctx context.Context, address string) (domain.EmailSuppression, error) {
+	unlock, err := r.store.begin(ctx, "EmailSuppressionRepository.GetByAddress")
+	if err != nil {
+		return domain.EmailSuppression{}, err
+	}
+	defer unlock()
+
+	for _, suppression := range r.store.records.emailSuppressions {
+		if suppression.Address == address {
+			return suppression, nil
+		}
+	}
+	return domain.EmailSuppression{}, ierr.ErrResourceNotFound
+}
+
+func (r *emailSuppressionRepository) List(ctx context.Context, limit int, offset int) ([]domain.EmailSuppression, error) {
+	unlock, err := r.store.begin(ctx, "EmailSuppressionRepository.List")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	suppressions := append([]domain.EmailSuppression{}, r.store.records.emailSuppressions...)
+	sort.SliceStable(suppressions, func(i, j int) bool {
+		if !suppressions[i].UpdatedAt.Equal(suppressions[j].UpdatedAt) {
+			return suppressions[i].UpdatedAt.After(suppressions[j].UpdatedAt)
+		}
+		return suppressions[i].Address < suppressions[j].Address
+	})
+	from, to := bounds(len(suppressions), limit, offset)
+	return suppressions[from:to], nil
+}
+
+func (r *emailSuppressionRepository) Delete(ctx context.Context, address string) error {
+	unlock, err := r.store.begin(ctx, "EmailSuppressionRepository.Delete")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for i, suppression := range r.store.records.emailSuppressions {
+		if suppression.Address == address {
+			suppressions := r.store.records.emailSuppressions
+			r.store.records.emailSuppressions = append(append([]domain.EmailSuppression{}, suppressions[:i]...), suppressions[i+1:]...)
+			return nil
+		}
+	}
+	return ierr.ErrResourceNotFound
+}
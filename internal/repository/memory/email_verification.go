@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/shared/ierr"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type emailVerificationRepository struct {
+	store *Store
+}
+
+func (r *emailVerificationRepository) Create(ctx context.Context, verification domain.EmailVerification) error {
+	unlock, err := r.store.begin(ctx, "EmailVerificationRepository.Create")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for _, existing := range r.store.records.emailVerifications {
+		if existing.ID == verification.ID || existing.TokenHash == verification.TokenHash {
+			return errors.Wrap(ierr.ErrConflict, "cannot create email verification")
+		}
+	}
+	verification.ID = r.store.nextID(verification.ID)
+	r.store.records.emailVerifications = append(r.store.records.emailVerifications, verification)
+	return nil
+}
+
+func (r *emailVerificationRepository) GetByTokenHash(ctx context.Context, tokenHash string) (domain.EmailVerification, error) {
+	unlock, err := r.store.begin(ctx, "EmailVerificationRepository.GetByTokenHash")
+	if err != nil {
+		return domain.EmailVerification{}, err
+	}
+	defer unlock()
+
+	for _, verification := range r.store.records.emailVerifications {
+		if verification.TokenHash == tokenHash {
+			return verification, nil
+		}
+	}
+	return domain.EmailVerification{}, ierr.ErrResourceNotFound
+}
+
+func (r *emailVerificationRepository) Verify(ctx context.Context, verificationID string, at time.Time) (bool, error) {
+	unlock, err := r.store.begin(ctx, "EmailVerificationRepository.Verify")
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	for i, verification := range r.store.records.emailVerifications {
+		if verification.ID == verificationID && verification.VerifiedAt == nil && verification.ExpiresAt.After(at) {
+			r.store.records.emailVerifications[i].VerifiedAt = &at
+			return true, nil
+		}
+	}
+	return false, nil
+}
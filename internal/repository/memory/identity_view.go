@@ -0,0 +1,63 @@
+package memory
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/times"
+	"go-hex/shared/ierr"
+	"sync"
+	"time"
+)
+
+// IdentityViewRepository stores the identity views in memory for ttl. The views are only seen, and invalidated,
+// by the instance which stored them.
+type IdentityViewRepository struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu    sync.RWMutex
+	views map[string]domain.IdentityView
+}
+
+// NewIdentityViewRepository creates an empty in-memory store of the identity views, each kept for ttl
+func NewIdentityViewRepository(ttl time.Duration) port.IdentityViewRepository {
+	return &IdentityViewRepository{ttl: ttl, now: times.Now, views: map[string]domain.IdentityView{}}
+}
+
+// Get returns the view of the specified user, ierr.ErrResourceNotFound when it is not stored.
+func (r *IdentityViewRepository) Get(ctx context.Context, userID string) (domain.IdentityView, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	view, ok := r.views[userID]
+	if !ok || r.now().Sub(view.BuiltAt) >= r.ttl {
+		return domain.IdentityView{}, ierr.ErrResourceNotFound
+	}
+	return view, nil
+}
+
+// Put stores the view of its user, replacing the previous one.
+func (r *IdentityViewRepository) Put(ctx context.Context, view domain.IdentityView) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// the expired views are pruned on write, so that the store stays bounded by the users issued a token within ttl
+	now := r.now()
+	for id, v := range r.views {
+		if now.Sub(v.BuiltAt) >= r.ttl {
+			delete(r.views, id)
+		}
+	}
+	r.views[view.UserID] = view
+	return nil
+}
+
+// Invalidate removes the view of the specified user, it is built again by its next read.
+func (r *IdentityViewRepository) Invalidate(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.views, userID)
+	return nil
+}
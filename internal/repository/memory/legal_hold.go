@@ -0,0 +1,98 @@
+package memory
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/shared/ierr"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type legalHoldRepository struct {
+	store *Store
+}
+
+func (r *legalHoldRepository) Create(ctx context.Context, hold domain.LegalHold) error {
+	unlock, err := r.store.begin(ctx, "LegalHoldRepository.Create")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for _, existing := range r.store.records.legalHolds {
+		if existing.ID == hold.ID {
+			return errors.Wrap(ierr.ErrConflict, "cannot create legal hold")
+		}
+	}
+	hold.ID = r.store.nextID(hold.ID)
+	r.store.records.legalHolds = append(r.store.records.legalHolds, hold)
+	return nil
+}
+
+func (r *legalHoldRepository) GetByID(ctx context.Context, id string) (domain.LegalHold, error) {
+	unlock, err := r.store.begin(ctx, "LegalHoldRepository.GetByID")
+	if err != nil {
+		return domain.LegalHold{}, err
+	}
+	defer unlock()
+
+	for _, hold := range r.store.records.legalHolds {
+		if hold.ID == id {
+			return hold, nil
+		}
+	}
+	return domain.LegalHold{}, ierr.ErrResourceNotFound
+}
+
+func (r *legalHoldRepository) ListByUserID(ctx context.Context, userID string) ([]domain.LegalHold, error) {
+	unlock, err := r.store.begin(ctx, "LegalHoldRepository.ListByUserID")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	holds := []domain.LegalHold{}
+	for _, hold := range r.store.records.legalHolds {
+		if hold.UserID == userID {
+			holds = append(holds, hold)
+		}
+	}
+	sort.SliceStable(holds, func(i, j int) bool { return holds[i].PlacedAt.After(holds[j].PlacedAt) })
+	return holds, nil
+}
+
+func (r *legalHoldRepository) ListHeldUserIDs(ctx context.Context) ([]string, error) {
+	unlock, err := r.store.begin(ctx, "LegalHoldRepository.ListHeldUserIDs")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	userIDs := []string{}
+	for _, hold := range r.store.records.legalHolds {
+		if hold.ReleasedAt == nil && !contains(userIDs, hold.UserID) {
+			userIDs = append(userIDs, hold.UserID)
+		}
+	}
+	return userIDs, nil
+}
+
+func (r *legalHoldRepository) Release(ctx context.Context, id string, releasedBy string, reason string, releasedAt time.Time) (bool, error) {
+	unlock, err := r.store.begin(ctx, "LegalHoldRepository.Release")
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	for i, hold := range r.store.records.legalHolds {
+		if hold.ID == id && hold.ReleasedAt == nil {
+			r.store.records.legalHolds[i].ReleasedBy = &releasedBy
+			r.store.records.legalHolds[i].ReleaseReason = &reason
+			r.store.records.legalHolds[i].ReleasedAt = &releasedAt
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,84 @@
+package memory
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/shared/ierr"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type logVerbosityRepository struct {
+	store *Store
+}
+
+func (r *logVerbosityRepository) Create(ctx context.Context, verbosity domain.LogVerbosity) error {
+	unlock, err := r.store.begin(ctx, "LogVerbosityRepository.Create")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for _, existing := range r.store.records.logVerbosities {
+		if existing.ID == verbosity.ID {
+			return errors.Wrap(ierr.ErrConflict, "cannot create log verbosity")
+		}
+	}
+	verbosity.ID = r.store.nextID(verbosity.ID)
+	r.store.records.logVerbosities = append(r.store.records.logVerbosities, verbosity)
+	return nil
+}
+
+func (r *logVerbosityRepository) ListActive(ctx context.Context, now time.Time) ([]domain.LogVerbosity, error) {
+	unlock, err := r.store.begin(ctx, "LogVerbosityRepository.ListActive")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	verbosities := []domain.LogVerbosity{}
+	for _, verbosity := range r.store.records.logVerbosities {
+		if verbosity.ExpiresAt.After(now) {
+			verbosities = append(verbosities, verbosity)
+		}
+	}
+	sort.SliceStable(verbosities, func(i, j int) bool { return verbosities[i].CreatedAt.Before(verbosities[j].CreatedAt) })
+	return verbosities, nil
+}
+
+func (r *logVerbosityRepository) Delete(ctx context.Context, id string) error {
+	unlock, err := r.store.begin(ctx, "LogVerbosityRepository.Delete")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for i, verbosity := range r.store.records.logVerbosities {
+		if verbosity.ID == id {
+			verbosities := r.store.records.logVerbosities
+			r.store.records.logVerbosities = append(append([]domain.LogVerbosity{}, verbosities[:i]...), verbosities[i+1:]...)
+			return nil
+		}
+	}
+	return ierr.ErrResourceNotFound
+}
+
+func (r *logVerbosityRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	unlock, err := r.store.begin(ctx, "LogVerbosityRepository.DeleteExpired")
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	kept := []domain.LogVerbosity{}
+	for _, verbosity := range r.store.records.logVerbosities {
+		if !verbosity.ExpiresAt.Before(before) {
+			kept = append(kept, verbosity)
+		}
+	}
+	deleted := len(r.store.records.logVerbosities) - len(kept)
+	r.store.records.logVerbosities = kept
+	return int64(deleted), nil
+}
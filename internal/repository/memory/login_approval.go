@@ -0,0 +1,101 @@
+package memory
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/shared/ierr"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type loginApprovalRepository struct {
+	store *Store
+}
+
+func (r *loginApprovalRepository) Create(ctx context.Context, approval domain.LoginApproval) error {
+	unlock, err := r.store.begin(ctx, "LoginApprovalRepository.Create")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for _, existing := range r.store.records.loginApprovals {
+		if existing.ID == approval.ID {
+			return errors.Wrap(ierr.ErrConflict, "login approval already exists")
+		}
+	}
+	approval.ID = r.store.nextID(approval.ID)
+	r.store.records.loginApprovals = append(r.store.records.loginApprovals, approval)
+	return nil
+}
+
+func (r *loginApprovalRepository) GetByID(ctx context.Context, approvalID string) (domain.LoginApproval, error) {
+	unlock, err := r.store.begin(ctx, "LoginApprovalRepository.GetByID")
+	if err != nil {
+		return domain.LoginApproval{}, err
+	}
+	defer unlock()
+
+	for _, approval := range r.store.records.loginApprovals {
+		if approval.ID == approvalID {
+			return approval, nil
+		}
+	}
+	return domain.LoginApproval{}, ierr.ErrResourceNotFound
+}
+
+func (r *loginApprovalRepository) ListPendingByUserID(ctx context.Context, userID string) ([]domain.LoginApproval, error) {
+	unlock, err := r.store.begin(ctx, "LoginApprovalRepository.ListPendingByUserID")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	now := r.store.now()
+	approvals := []domain.LoginApproval{}
+	for _, approval := range r.store.records.loginApprovals {
+		if approval.UserID == userID && approval.Status == domain.LoginApprovalStatusPending && approval.ExpiresAt.After(now) {
+			approvals = append(approvals, approval)
+		}
+	}
+	sort.SliceStable(approvals, func(i, j int) bool { return approvals[i].CreatedAt.After(approvals[j].CreatedAt) })
+	return approvals, nil
+}
+
+func (r *loginApprovalRepository) UpdateStatus(ctx context.Context, approvalID string, from string, to string) (bool, error) {
+	unlock, err := r.store.begin(ctx, "LoginApprovalRepository.UpdateStatus")
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	for i, approval := range r.store.records.loginApprovals {
+		if approval.ID == approvalID && approval.Status == from {
+			now := r.store.now()
+			r.store.records.loginApprovals[i].Status = to
+			r.store.records.loginApprovals[i].DecidedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *loginApprovalRepository) DeleteExpired(ctx context.Context, before time.Time, heldUserIDs []string) (int64, error) {
+	unlock, err := r.store.begin(ctx, "LoginApprovalRepository.DeleteExpired")
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	kept := []domain.LoginApproval{}
+	for _, approval := range r.store.records.loginApprovals {
+		if !approval.ExpiresAt.Before(before) || contains(heldUserIDs, approval.UserID) {
+			kept = append(kept, approval)
+		}
+	}
+	deleted := len(r.store.records.loginApprovals) - len(kept)
+	r.store.records.loginApprovals = kept
+	return int64(deleted), nil
+}
@@ -0,0 +1,65 @@
+package memory
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/shared/ierr"
+	"sort"
+)
+
+type messageTemplateRepository struct {
+	store *Store
+}
+
+func (r *messageTemplateRepository) Create(ctx context.Context, tmpl domain.MessageTemplate) error {
+	unlock, err := r.store.begin(ctx, "MessageTemplateRepository.Create")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for _, existing := range r.store.records.messageTemplates {
+		if existing.ID == tmpl.ID || existing.Name == tmpl.Name && existing.Channel == tmpl.Channel && existing.Version == tmpl.Version {
+			return ierr.ErrConflict
+		}
+	}
+	tmpl.ID = r.store.nextID(tmpl.ID)
+	r.store.records.messageTemplates = append(r.store.records.messageTemplates, tmpl)
+	return nil
+}
+
+func (r *messageTemplateRepository) GetLatest(ctx context.Context, name string, channel string) (domain.MessageTemplate, error) {
+	unlock, err := r.store.begin(ctx, "MessageTemplateRepository.GetLatest")
+	if err != nil {
+		return domain.MessageTemplate{}, err
+	}
+	defer unlock()
+
+	versions := r.versions(name, channel)
+	if len(versions) == 0 {
+		return domain.MessageTemplate{}, ierr.ErrResourceNotFound
+	}
+	return versions[0], nil
+}
+
+func (r *messageTemplateRepository) ListVersions(ctx context.Context, name string, channel string) ([]domain.MessageTemplate, error) {
+	unlock, err := r.store.begin(ctx, "MessageTemplateRepository.ListVersions")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return r.versions(name, channel), nil
+}
+
+// versions returns the versions of the template, the latest first; the records must be locked
+func (r *messageTemplateRepository) versions(name string, channel string) []domain.MessageTemplate {
+	versions := []domain.MessageTemplate{}
+	for _, tmpl := range r.store.records.messageTemplates {
+		if tmpl.Name == name && tmpl.Channel == channel {
+			versions = append(versions, tmpl)
+		}
+	}
+	sort.SliceStable(versions, func(i, j int) bool { return versions[i].Version > versions[j].Version })
+	return versions
+}
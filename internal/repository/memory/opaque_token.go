@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"context"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/times"
+	"go-hex/shared/ierr"
+	"sync"
+	"time"
+)
+
+type opaqueToken struct {
+	accessToken string
+	expiresAt   time.Time
+}
+
+// OpaqueTokenRepository stores the opaque tokens in memory, the tokens are forgotten once expired.
+// The tokens are only resolved by the instance which issued them and are lost on restart.
+type OpaqueTokenRepository struct {
+	mu     sync.RWMutex
+	tokens map[string]opaqueToken
+	now    func() time.Time
+}
+
+// NewOpaqueTokenRepository creates an empty in-memory store of the opaque tokens
+func NewOpaqueTokenRepository() port.OpaqueTokenRepository {
+	return &OpaqueTokenRepository{tokens: map[string]opaqueToken{}, now: times.Now}
+}
+
+// Put stores the access token referenced by the opaque token of the specified digest until it expires.
+func (r *OpaqueTokenRepository) Put(ctx context.Context, digest string, accessToken string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// the expired tokens are pruned on write, so that the store stays bounded by the live tokens
+	now := r.now()
+	for key, token := range r.tokens {
+		if !token.expiresAt.After(now) {
+			delete(r.tokens, key)
+		}
+	}
+	if expiresAt.After(now) {
+		r.tokens[digest] = opaqueToken{accessToken, expiresAt}
+	}
+	return nil
+}
+
+// Get returns the access token referenced by the opaque token of the specified digest.
+func (r *OpaqueTokenRepository) Get(ctx context.Context, digest string) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	token, ok := r.tokens[digest]
+	if !ok || !token.expiresAt.After(r.now()) {
+		return "", ierr.ErrResourceNotFound
+	}
+	return token.accessToken, nil
+}
+
+// Delete removes the opaque token of the specified digest.
+func (r *OpaqueTokenRepository) Delete(ctx context.Context, digest string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.tokens, digest)
+	return nil
+}
@@ -0,0 +1,35 @@
+package memory
+
+import (
+	"context"
+	"go-hex/shared/ierr"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpaqueTokenRepository(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	repo := NewOpaqueTokenRepository().(*OpaqueTokenRepository)
+	repo.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	assert.NoError(t, repo.Put(ctx, "d1", "access-token-1", now.Add(time.Minute)))
+	assert.NoError(t, repo.Put(ctx, "d2", "access-token-2", now.Add(time.Minute)))
+
+	accessToken, err := repo.Get(ctx, "d1")
+	assert.NoError(t, err)
+	assert.Equal(t, "access-token-1", accessToken)
+
+	assert.NoError(t, repo.Delete(ctx, "d2"))
+	_, err = repo.Get(ctx, "d2")
+	assert.Equal(t, ierr.ErrResourceNotFound, err, "a deleted token stops working at once")
+
+	// the token is forgotten once expired
+	now = now.Add(2 * time.Minute)
+	_, err = repo.Get(ctx, "d1")
+	assert.Equal(t, ierr.ErrResourceNotFound, err)
+	assert.NoError(t, repo.Put(ctx, "d3", "access-token-3", now.Add(time.Minute)))
+	assert.Len(t, repo.tokens, 1)
+}
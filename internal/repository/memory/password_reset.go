@@ -0,0 +1,77 @@
+package memory
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/shared/ierr"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type passwordResetRepository struct {
+	store *Store
+}
+
+func (r *passwordResetRepository) Create(ctx context.Context, reset domain.PasswordReset) error {
+	unlock, err := r.store.begin(ctx, "PasswordResetRepository.Create")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for _, existing := range r.store.records.passwordResets {
+		if existing.ID == reset.ID || existing.TokenHash == reset.TokenHash {
+			return errors.Wrap(ierr.ErrConflict, "cannot create password reset")
+		}
+	}
+	reset.ID = r.store.nextID(reset.ID)
+	r.store.records.passwordResets = append(r.store.records.passwordResets, reset)
+	return nil
+}
+
+func (r *passwordResetRepository) GetByTokenHash(ctx context.Context, tokenHash string) (domain.PasswordReset, error) {
+	unlock, err := r.store.begin(ctx, "PasswordResetRepository.GetByTokenHash")
+	if err != nil {
+		return domain.PasswordReset{}, err
+	}
+	defer unlock()
+
+	for _, reset := range r.store.records.passwordResets {
+		if reset.TokenHash == tokenHash {
+			return reset, nil
+		}
+	}
+	return domain.PasswordReset{}, ierr.ErrResourceNotFound
+}
+
+func (r *passwordResetRepository) Use(ctx context.Context, resetID string, at time.Time) (bool, error) {
+	unlock, err := r.store.begin(ctx, "PasswordResetRepository.Use")
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	for i, reset := range r.store.records.passwordResets {
+		if reset.ID == resetID && reset.UsedAt == nil && reset.ExpiresAt.After(at) {
+			r.store.records.passwordResets[i].UsedAt = &at
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *passwordResetRepository) InvalidateByUserID(ctx context.Context, userID string, at time.Time) error {
+	unlock, err := r.store.begin(ctx, "PasswordResetRepository.InvalidateByUserID")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for i, reset := range r.store.records.passwordResets {
+		if reset.UserID == userID && reset.UsedAt == nil {
+			r.store.records.passwordResets[i].UsedAt = &at
+		}
+	}
+	return nil
+}
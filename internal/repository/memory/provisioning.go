@@ -0,0 +1,171 @@
+package memory
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/shared/ierr"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type provisioningRepository struct {
+	store *Store
+}
+
+func (r *provisioningRepository) ListStates(ctx context.Context, connector string) ([]domain.ProvisioningState, error) {
+	unlock, err := r.store.begin(ctx, "ProvisioningRepository.ListStates")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	states := []domain.ProvisioningState{}
+	for _, state := range r.store.records.provisioningStates {
+		if state.Connector == connector {
+			states = append(states, state)
+		}
+	}
+	return states, nil
+}
+
+func (r *provisioningRepository) SaveState(ctx context.Context, state domain.ProvisioningState) error {
+	unlock, err := r.store.begin(ctx, "ProvisioningRepository.SaveState")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for i, existing := range r.store.records.provisioningStates {
+		if existing.Connector == state.Connector && existing.UserID == state.UserID {
+			r.store.records.provisioningStates[i] = state
+			return nil
+		}
+	}
+	r.store.records.provisioningStates = append(r.store.records.provisioningStates, state)
+	return nil
+}
+
+func (r *provisioningRepository) ListErrors(ctx context.Context, connector string) ([]domain.ProvisioningError, error) {
+	unlock, err := r.store.begin(ctx, "ProvisioningRepository.ListErrors")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	errs := []domain.ProvisioningError{}
+	for _, e := range r.store.records.provisioningErrors {
+		if connector == "" || e.Connector == connector {
+			errs = append(errs, e)
+		}
+	}
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].CreatedAt.Before(errs[j].CreatedAt) })
+	return errs, nil
+}
+
+func (r *provisioningRepository) SaveError(ctx context.Context, e domain.ProvisioningError) error {
+	unlock, err := r.store.begin(ctx, "ProvisioningRepository.SaveError")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for i, existing := range r.store.records.provisioningErrors {
+		if existing.Connector == e.Connector && existing.UserID == e.UserID {
+			r.store.records.provisioningErrors[i].Error = e.Error
+			r.store.records.provisioningErrors[i].Attempts = e.Attempts
+			r.store.records.provisioningErrors[i].NextAttemptAt = e.NextAttemptAt
+			r.store.records.provisioningErrors[i].UpdatedAt = e.UpdatedAt
+			return nil
+		}
+	}
+	r.store.records.provisioningErrors = append(r.store.records.provisioningErrors, e)
+	return nil
+}
+
+func (r *provisioningRepository) DeleteError(ctx context.Context, connector string, userID string) error {
+	unlock, err := r.store.begin(ctx, "ProvisioningRepository.DeleteError")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	kept := []domain.ProvisioningError{}
+	for _, e := range r.store.records.provisioningErrors {
+		if e.Connector != connector || e.UserID != userID {
+			kept = append(kept, e)
+		}
+	}
+	r.store.records.provisioningErrors = kept
+	return nil
+}
+
+func (r *provisioningRepository) RetryError(ctx context.Context, connector string, userID string, at time.Time) (bool, error) {
+	unlock, err := r.store.begin(ctx, "ProvisioningRepository.RetryError")
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	for i, e := range r.store.records.provisioningErrors {
+		if e.Connector == connector && e.UserID == userID {
+			r.store.records.provisioningErrors[i].Attempts = 0
+			r.store.records.provisioningErrors[i].NextAttemptAt = at
+			r.store.records.provisioningErrors[i].UpdatedAt = at
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *provisioningRepository) CreateRun(ctx context.Context, run domain.ProvisioningRun) error {
+	unlock, err := r.store.begin(ctx, "ProvisioningRepository.CreateRun")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for _, existing := range r.store.records.provisioningRuns {
+		if existing.ID == run.ID {
+			return errors.Wrap(ierr.ErrConflict, "cannot create provisioning run")
+		}
+	}
+	run.ID = r.store.nextID(run.ID)
+	r.store.records.provisioningRuns = append(r.store.records.provisioningRuns, run)
+	return nil
+}
+
+func (r *provisioningRepository) GetRun(ctx context.Context, id string) (domain.ProvisioningRun, error) {
+	unlock, err := r.store.begin(ctx, "ProvisioningRepository.GetRun")
+	if err != nil {
+		return domain.ProvisioningRun{}, err
+	}
+	defer unlock()
+
+	for _, run := range r.store.records.provisioningRuns {
+		if run.ID == id {
+			return run, nil
+		}
+	}
+	return domain.ProvisioningRun{}, ierr.ErrResourceNotFound
+}
+
+func (r *provisioningRepository) ListRuns(ctx context.Context, connector string, limit int) ([]domain.ProvisioningRun, error) {
+	unlock, err := r.store.begin(ctx, "ProvisioningRepository.ListRuns")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	runs := []domain.ProvisioningRun{}
+	for _, run := range r.store.records.provisioningRuns {
+		if connector == "" || run.Connector == connector {
+			run.Drifts = nil
+			runs = append(runs, run)
+		}
+	}
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].StartedAt.After(runs[j].StartedAt) })
+	from, to := bounds(len(runs), limit, 0)
+	return runs[from:to], nil
+}
@@ -0,0 +1,63 @@
+package memory
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/shared/ierr"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type refreshTokenRepository struct {
+	store *Store
+}
+
+func (r *refreshTokenRepository) Create(ctx context.Context, token domain.RefreshToken) error {
+	unlock, err := r.store.begin(ctx, "RefreshTokenRepository.Create")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for _, existing := range r.store.records.refreshTokens {
+		if existing.ID == token.ID {
+			return errors.Wrap(ierr.ErrConflict, "cannot create refresh token")
+		}
+	}
+	token.ID = r.store.nextID(token.ID)
+	r.store.records.refreshTokens = append(r.store.records.refreshTokens, token)
+	return nil
+}
+
+func (r *refreshTokenRepository) GetByID(ctx context.Context, tokenID string) (domain.RefreshToken, error) {
+	unlock, err := r.store.begin(ctx, "RefreshTokenRepository.GetByID")
+	if err != nil {
+		return domain.RefreshToken{}, err
+	}
+	defer unlock()
+
+	for _, token := range r.store.records.refreshTokens {
+		if token.ID == tokenID {
+			return token, nil
+		}
+	}
+	return domain.RefreshToken{}, ierr.ErrResourceNotFound
+}
+
+func (r *refreshTokenRepository) Rotate(ctx context.Context, tokenID string, replacedBy string, at time.Time) (bool, error) {
+	unlock, err := r.store.begin(ctx, "RefreshTokenRepository.Rotate")
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	for i, token := range r.store.records.refreshTokens {
+		if token.ID == tokenID && token.RotatedAt == nil {
+			r.store.records.refreshTokens[i].RotatedAt = &at
+			r.store.records.refreshTokens[i].ReplacedBy = replacedBy
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,126 @@
+package memory
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/port"
+	"time"
+)
+
+// RepositoryRegistry serves the users and the active elevations of the cached users from the cache, and the
+// rest from the decorated registry. The writes of the users and the elevations invalidate their user.
+// The transactions read through the cache, so that the reads locking or depending on their writes are served
+// by the data source, as do the reads of a context bypassing the caches (port.BypassCache).
+type RepositoryRegistry struct {
+	port.RepositoryRegistry
+	cache *UserCache
+	read  bool
+}
+
+// NewRepositoryRegistry creates a registry serving the cached users of cache in front of next
+func NewRepositoryRegistry(next port.RepositoryRegistry, cache *UserCache) port.RepositoryRegistry {
+	return &RepositoryRegistry{next, cache, true}
+}
+
+// DoInTransaction runs txFunc in a transaction of the decorated registry, the writes of txFunc still
+// invalidate the cache.
+func (r *RepositoryRegistry) DoInTransaction(ctx context.Context, txFunc port.InTransaction) (out interface{}, err error) {
+	return r.RepositoryRegistry.DoInTransaction(ctx, func(ctx context.Context, repoRegistry port.RepositoryRegistry) (interface{}, error) {
+		return txFunc(ctx, &RepositoryRegistry{repoRegistry, r.cache, false})
+	})
+}
+
+func (r *RepositoryRegistry) GetUserRepository() port.UserRepository {
+	return &cachedUserRepository{r.RepositoryRegistry.GetUserRepository(), r.cache, r.read}
+}
+
+func (r *RepositoryRegistry) GetElevationRepository() port.ElevationRepository {
+	return &cachedElevationRepository{r.RepositoryRegistry.GetElevationRepository(), r.cache, r.read}
+}
+
+type cachedUserRepository struct {
+	port.UserRepository
+	cache *UserCache
+	read  bool
+}
+
+func (r *cachedUserRepository) GetByID(ctx context.Context, userID string) (domain.User, error) {
+	if r.read && !port.CacheBypassed(ctx) {
+		if user, ok := r.cache.user(userID); ok {
+			return user, nil
+		}
+	}
+	return r.UserRepository.GetByID(ctx, userID)
+}
+
+func (r *cachedUserRepository) GetByUsername(ctx context.Context, username string) (domain.User, error) {
+	if r.read && !port.CacheBypassed(ctx) {
+		if user, ok := r.cache.userByUsername(username); ok {
+			return user, nil
+		}
+	}
+	return r.UserRepository.GetByUsername(ctx, username)
+}
+
+func (r *cachedUserRepository) ListByIDs(ctx context.Context, userIDs []string) ([]domain.User, error) {
+	if !r.read || port.CacheBypassed(ctx) {
+		return r.UserRepository.ListByIDs(ctx, userIDs)
+	}
+	users := []domain.User{}
+	var missed []string
+	for _, userID := range userIDs {
+		if user, ok := r.cache.user(userID); ok {
+			users = append(users, user)
+		} else {
+			missed = append(missed, userID)
+		}
+	}
+	if len(missed) == 0 {
+		return users, nil
+	}
+	loaded, err := r.UserRepository.ListByIDs(ctx, missed)
+	if err != nil {
+		return nil, err
+	}
+	return append(users, loaded...), nil
+}
+
+func (r *cachedUserRepository) Update(ctx context.Context, userID string, user domain.User) error {
+	defer r.cache.Invalidate(userID)
+	return r.UserRepository.Update(ctx, userID, user)
+}
+
+func (r *cachedUserRepository) SetActive(ctx context.Context, userID string, active bool) error {
+	defer r.cache.Invalidate(userID)
+	return r.UserRepository.SetActive(ctx, userID, active)
+}
+
+func (r *cachedUserRepository) ClearRefreshToken(ctx context.Context, userID string, hashedToken string) (bool, error) {
+	defer r.cache.Invalidate(userID)
+	return r.UserRepository.ClearRefreshToken(ctx, userID, hashedToken)
+}
+
+func (r *cachedUserRepository) ReplacePassword(ctx context.Context, userID string, hashedPwd string, newHashedPwd string) (bool, error) {
+	defer r.cache.Invalidate(userID)
+	return r.UserRepository.ReplacePassword(ctx, userID, hashedPwd, newHashedPwd)
+}
+
+type cachedElevationRepository struct {
+	port.ElevationRepository
+	cache *UserCache
+	read  bool
+}
+
+func (r *cachedElevationRepository) ListActiveByUserID(ctx context.Context, userID string, at time.Time) ([]domain.Elevation, error) {
+	if r.read && !port.CacheBypassed(ctx) {
+		if elevations, ok := r.cache.activeElevations(userID, at); ok {
+			return elevations, nil
+		}
+	}
+	return r.ElevationRepository.ListActiveByUserID(ctx, userID, at)
+}
+
+func (r *cachedElevationRepository) Decide(ctx context.Context, elevation domain.Elevation) (bool, error) {
+	defer r.cache.Invalidate(elevation.UserID)
+	return r.ElevationRepository.Decide(ctx, elevation)
+}
@@ -0,0 +1,149 @@
+package memory
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/shared/ierr"
+	"sort"
+	"time"
+)
+
+type roleRepository struct {
+	store *Store
+}
+
+func (r *roleRepository) List(ctx context.Context) ([]domain.Role, error) {
+	unlock, err := r.store.begin(ctx, "RoleRepository.List")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return r.roles(func(role domain.Role) bool { return true }), nil
+}
+
+func (r *roleRepository) ListByNames(ctx context.Context, names []string) ([]domain.Role, error) {
+	unlock, err := r.store.begin(ctx, "RoleRepository.ListByNames")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return r.roles(func(role domain.Role) bool { return contains(names, role.Name) }), nil
+}
+
+func (r *roleRepository) ListByUserID(ctx context.Context, userID string, at time.Time) ([]domain.Role, error) {
+	unlock, err := r.store.begin(ctx, "RoleRepository.ListByUserID")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	names := []string{}
+	for _, assignment := range r.store.records.userRoles {
+		if assignment.UserID == userID && assignment.IsActiveAt(at) {
+			names = append(names, assignment.Role)
+		}
+	}
+	return r.roles(func(role domain.Role) bool { return contains(names, role.Name) }), nil
+}
+
+func (r *roleRepository) ListAssignments(ctx context.Context, userID string) ([]domain.UserRole, error) {
+	unlock, err := r.store.begin(ctx, "RoleRepository.ListAssignments")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	assignments := []domain.UserRole{}
+	for _, assignment := range r.store.records.userRoles {
+		if assignment.UserID == userID {
+			assignments = append(assignments, assignment)
+		}
+	}
+	sort.SliceStable(assignments, func(i, j int) bool { return assignments[i].Role < assignments[j].Role })
+	return assignments, nil
+}
+
+func (r *roleRepository) Assign(ctx context.Context, assignment domain.UserRole) error {
+	unlock, err := r.store.begin(ctx, "RoleRepository.Assign")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for i, existing := range r.store.records.userRoles {
+		if existing.UserID == assignment.UserID && existing.Role == assignment.Role {
+			r.store.records.userRoles[i].StartsAt = assignment.StartsAt
+			r.store.records.userRoles[i].EndsAt = assignment.EndsAt
+			return nil
+		}
+	}
+	r.store.records.userRoles = append(r.store.records.userRoles, assignment)
+	return nil
+}
+
+func (r *roleRepository) Unassign(ctx context.Context, userID string, role string) error {
+	unlock, err := r.store.begin(ctx, "RoleRepository.Unassign")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for i, existing := range r.store.records.userRoles {
+		if existing.UserID == userID && existing.Role == role {
+			assignments := r.store.records.userRoles
+			r.store.records.userRoles = append(append([]domain.UserRole{}, assignments[:i]...), assignments[i+1:]...)
+			return nil
+		}
+	}
+	return ierr.ErrResourceNotFound
+}
+
+func (r *roleRepository) ListExpired(ctx context.Context, before time.Time) ([]domain.UserRole, error) {
+	unlock, err := r.store.begin(ctx, "RoleRepository.ListExpired")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	assignments := []domain.UserRole{}
+	for _, assignment := range r.store.records.userRoles {
+		if assignment.EndsAt != nil && !assignment.EndsAt.After(before) {
+			assignments = append(assignments, assignment)
+		}
+	}
+	sort.SliceStable(assignments, func(i, j int) bool { return assignments[i].EndsAt.Before(*assignments[j].EndsAt) })
+	return assignments, nil
+}
+
+func (r *roleRepository) DeleteExpired(ctx context.Context, assignment domain.UserRole) (bool, error) {
+	unlock, err := r.store.begin(ctx, "RoleRepository.DeleteExpired")
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	for i, existing := range r.store.records.userRoles {
+		if existing.UserID == assignment.UserID && existing.Role == assignment.Role &&
+			existing.EndsAt != nil && assignment.EndsAt != nil && existing.EndsAt.Equal(*assignment.EndsAt) {
+			assignments := r.store.records.userRoles
+			r.store.records.userRoles = append(append([]domain.UserRole{}, assignments[:i]...), assignments[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// roles returns the matching roles by name, with a copy of their permissions; the records must be locked
+func (r *roleRepository) roles(match func(domain.Role) bool) []domain.Role {
+	roles := []domain.Role{}
+	for _, role := range r.store.records.roles {
+		if match(role) {
+			role.Permissions = append([]string{}, role.Permissions...)
+			roles = append(roles, role)
+		}
+	}
+	sort.SliceStable(roles, func(i, j int) bool { return roles[i].Name < roles[j].Name })
+	return roles
+}
@@ -0,0 +1,440 @@
+package memory
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/shared/ierr"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type serviceAccountRepository struct {
+	store *Store
+}
+
+func (r *serviceAccountRepository) Create(ctx context.Context, account domain.ServiceAccount) error {
+	unlock, err := r.store.begin(ctx, "ServiceAccountRepository.Create")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for _, existing := range r.store.records.serviceAccounts {
+		if existing.ID == account.ID || existing.Name == account.Name {
+			return errors.Wrap(ierr.ErrConflict, "cannot create service account")
+		}
+	}
+	account.ID = r.store.nextID(account.ID)
+	r.store.records.serviceAccounts = append(r.store.records.serviceAccounts, account)
+	return nil
+}
+
+func (r *serviceAccountRepository) GetByID(ctx context.Context, accountID string) (domain.ServiceAccount, error) {
+	unlock, err := r.store.begin(ctx, "ServiceAccountRepository.GetByID")
+	if err != nil {
+		return domain.ServiceAccount{}, err
+	}
+	defer unlock()
+
+	for _, account := range r.store.records.serviceAccounts {
+		if account.ID == accountID {
+			return account, nil
+		}
+	}
+	return domain.ServiceAccount{}, ierr.ErrResourceNotFound
+}
+
+func (r *serviceAccountRepository) List(ctx context.Context) ([]domain.ServiceAccount, error) {
+	unlock, err := r.store.begin(ctx, "ServiceAccountRepository.List")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	accounts := append([]domain.ServiceAccount{}, r.store.records.serviceAccounts...)
+	sort.SliceStable(accounts, func(i, j int) bool { return accounts[i].Name < accounts[j].Name })
+	return accounts, nil
+}
+
+func (r *serviceAccountRepository) UpdatePublicKey(ctx context.Context, accountID string, publicKey string) error {
+	return r.update(ctx, "ServiceAccountRepository.UpdatePublicKey", accountID, func(account *domain.ServiceAccount) {
+		account.PublicKey = publicKey
+		account.UpdatedAt = r.store.now()
+	})
+}
+
+func (r *serviceAccountRepository) UpdateActive(ctx context.Context, accountID string, isActive bool) error {
+	return r.update(ctx, "ServiceAccountRepository.UpdateActive", accountID, func(account *domain.ServiceAccount) {
+		account.IsActive = isActive
+		account.UpdatedAt = r.store.now()
+	})
+}
+
+func (r *serviceAccountRepository) UpdateLastUsedAt(ctx context.Context, accountID string, lastUsedAt time.Time) error {
+	return r.update(ctx, "ServiceAccountRepository.UpdateLastUsedAt", accountID, func(account *domain.ServiceAccount) {
+		account.LastUsedAt = &lastUsedAt
+	})
+}
+
+func (r *serviceAccountRepository) update(ctx context.Context, op string, accountID string, apply func(*domain.ServiceAccount)) error {
+	unlock, err := r.store.begin(ctx, op)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for i := range r.store.records.serviceAccounts {
+		if r.store.records.serviceAccounts[i].ID == accountID {
+			apply(&r.store.records.serviceAccounts[i])
+		}
+	}
+	return nil
+}
+
+func (r *serviceAccountRepository) ListRoles(ctx context.Context, accountID string) ([]domain.ServiceAccountRole, error) {
+	unlock, err := r.store.begin(ctx, "ServiceAccountRepository.ListRoles")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	roles := []domain.ServiceAccountRole{}
+	for _, role := range r.store.records.serviceAccountRoles {
+		if role.ServiceAccountID == accountID {
+			roles = append(roles, role)
+		}
+	}
+	sort.SliceStable(roles, func(i, j int) bool { return roles[i].Role < roles[j].Role })
+	return roles, nil
+}
+
+func (r *serviceAccountRepository) BindRole(ctx context.Context, role domain.ServiceAccountRole) error {
+	unlock, err := r.store.begin(ctx, "ServiceAccountRepository.BindRole")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for i, existing := range r.store.records.serviceAccountRoles {
+		if existing.ServiceAccountID == role.ServiceAccountID && existing.Role == role.Role {
+			r.store.records.serviceAccountRoles[i].StartsAt = role.StartsAt
+			r.store.records.serviceAccountRoles[i].EndsAt = role.EndsAt
+			return nil
+		}
+	}
+	r.store.records.serviceAccountRoles = append(r.store.records.serviceAccountRoles, role)
+	return nil
+}
+
+func (r *serviceAccountRepository) UnbindRole(ctx context.Context, accountID string, role string) error {
+	unlock, err := r.store.begin(ctx, "ServiceAccountRepository.UnbindRole")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	kept := []domain.ServiceAccountRole{}
+	for _, existing := range r.store.records.serviceAccountRoles {
+		if existing.ServiceAccountID != accountID || existing.Role != role {
+			kept = append(kept, existing)
+		}
+	}
+	r.store.records.serviceAccountRoles = kept
+	return nil
+}
+
+func (r *serviceAccountRepository) ListExpiredRoles(ctx context.Context, before time.Time) ([]domain.ServiceAccountRole, error) {
+	unlock, err := r.store.begin(ctx, "ServiceAccountRepository.ListExpiredRoles")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	roles := []domain.ServiceAccountRole{}
+	for _, role := range r.store.records.serviceAccountRoles {
+		if role.EndsAt != nil && !role.EndsAt.After(before) {
+			roles = append(roles, role)
+		}
+	}
+	sort.SliceStable(roles, func(i, j int) bool { return roles[i].EndsAt.Before(*roles[j].EndsAt) })
+	return roles, nil
+}
+
+func (r *serviceAccountRepository) DeleteExpiredRole(ctx context.Context, role domain.ServiceAccountRole) (bool, error) {
+	unlock, err := r.store.begin(ctx, "ServiceAccountRepository.DeleteExpiredRole")
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	for i, existing := range r.store.records.serviceAccountRoles {
+		if existing.ServiceAccountID == role.ServiceAccountID && existing.Role == role.Role &&
+			existing.EndsAt != nil && role.EndsAt != nil && existing.EndsAt.Equal(*role.EndsAt) {
+			roles := r.store.records.serviceAccountRoles
+			r.store.records.serviceAccountRoles = append(append([]domain.ServiceAccountRole{}, roles[:i]...), roles[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *serviceAccountRepository) RecordAssertion(ctx context.Context, assertion domain.ServiceAccountAssertion) (bool, error) {
+	unlock, err := r.store.begin(ctx, "ServiceAccountRepository.RecordAssertion")
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	for _, existing := range r.store.records.serviceAccountAssertions {
+		if existing.JTI == assertion.JTI {
+			return false, nil
+		}
+	}
+	r.store.records.serviceAccountAssertions = append(r.store.records.serviceAccountAssertions, assertion)
+	return true, nil
+}
+
+func (r *serviceAccountRepository) DeleteExpiredAssertions(ctx context.Context, before time.Time) (int64, error) {
+	unlock, err := r.store.begin(ctx, "ServiceAccountRepository.DeleteExpiredAssertions")
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	kept := []domain.ServiceAccountAssertion{}
+	for _, assertion := range r.store.records.serviceAccountAssertions {
+		if !assertion.ExpiresAt.Before(before) {
+			kept = append(kept, assertion)
+		}
+	}
+	deleted := len(r.store.records.serviceAccountAssertions) - len(kept)
+	r.store.records.serviceAccountAssertions = kept
+	return int64(deleted), nil
+}
+
+func (r *serviceAccountRepository) RecordAuditEvents(ctx context.Context, auditEvents []domain.ServiceAccountAuditEvent) error {
+	unlock, err := r.store.begin(ctx, "ServiceAccountRepository.RecordAuditEvents")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for _, auditEvent := range auditEvents {
+		auditEvent.ID = r.store.nextID(auditEvent.ID)
+		r.store.records.serviceAccountAuditEvents = append(r.store.records.serviceAccountAuditEvents, auditEvent)
+	}
+	return nil
+}
+
+func (r *serviceAccountRepository) ListAuditEvents(ctx context.Context, accountID string, limit int, offset int) ([]domain.ServiceAccountAuditEvent, error) {
+	unlock, err := r.store.begin(ctx, "ServiceAccountRepository.ListAuditEvents")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	auditEvents := []domain.ServiceAccountAuditEvent{}
+	for _, auditEvent := range r.store.records.serviceAccountAuditEvents {
+		if auditEvent.ServiceAccountID == accountID {
+			auditEvents = append(auditEvents, auditEvent)
+		}
+	}
+	sort.SliceStable(auditEvents, func(i, j int) bool {
+		if !auditEvents[i].CreatedAt.Equal(auditEvents[j].CreatedAt) {
+			return auditEvents[i].CreatedAt.After(auditEvents[j].CreatedAt)
+		}
+		return auditEvents[i].ID > auditEvents[j].ID
+	})
+	from, to := bounds(len(auditEvents), limit, offset)
+	return auditEvents[from:to], nil
+}
+
+func (r *serviceAccountRepository) ListChainedAuditEvents(ctx context.Context, afterSeq int64, limit int) ([]domain.ServiceAccountAuditEvent, error) {
+	unlock, err := r.store.begin(ctx, "ServiceAccountRepository.ListChainedAuditEvents")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	auditEvents := []domain.ServiceAccountAuditEvent{}
+	for _, auditEvent := range r.store.records.serviceAccountAuditEvents {
+		if auditEvent.Seq != nil && *auditEvent.Seq > afterSeq {
+			auditEvents = append(auditEvents, auditEvent)
+		}
+	}
+	sort.SliceStable(auditEvents, func(i, j int) bool { return *auditEvents[i].Seq < *auditEvents[j].Seq })
+	from, to := bounds(len(auditEvents), limit, 0)
+	return auditEvents[from:to], nil
+}
+
+func (r *serviceAccountRepository) GetAuditChainHead(ctx context.Context, name string) (domain.AuditChainHead, error) {
+	unlock, err := r.store.begin(ctx, "ServiceAccountRepository.GetAuditChainHead")
+	if err != nil {
+		return domain.AuditChainHead{}, err
+	}
+	defer unlock()
+
+	for _, head := range r.store.records.auditChainHeads {
+		if head.Name == name {
+			return head, nil
+		}
+	}
+	return domain.AuditChainHead{}, ierr.ErrResourceNotFound
+}
+
+func (r *serviceAccountRepository) UpdateAuditChainHead(ctx context.Context, head domain.AuditChainHead) error {
+	unlock, err := r.store.begin(ctx, "ServiceAccountRepository.UpdateAuditChainHead")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for i, existing := range r.store.records.auditChainHeads {
+		if existing.Name == head.Name {
+			r.store.records.auditChainHeads[i] = head
+		}
+	}
+	return nil
+}
+
+func (r *serviceAccountRepository) ListAuditEventsCreatedBetween(ctx context.Context, from time.Time, to time.Time, limit int, offset int) ([]domain.ServiceAccountAuditEvent, error) {
+	unlock, err := r.store.begin(ctx, "ServiceAccountRepository.ListAuditEventsCreatedBetween")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	auditEvents := []domain.ServiceAccountAuditEvent{}
+	for _, auditEvent := range r.store.records.serviceAccountAuditEvents {
+		if !auditEvent.CreatedAt.Before(from) && auditEvent.CreatedAt.Before(to) {
+			auditEvents = append(auditEvents, auditEvent)
+		}
+	}
+	sort.SliceStable(auditEvents, func(i, j int) bool {
+		a, b := auditEvents[i], auditEvents[j]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		if seqA, seqB := seqOf(a), seqOf(b); seqA != seqB {
+			return seqA < seqB
+		}
+		return a.ID < b.ID
+	})
+	start, end := bounds(len(auditEvents), limit, offset)
+	return auditEvents[start:end], nil
+}
+
+func (r *serviceAccountRepository) GetOldestAuditEvent(ctx context.Context) (domain.ServiceAccountAuditEvent, error) {
+	unlock, err := r.store.begin(ctx, "ServiceAccountRepository.GetOldestAuditEvent")
+	if err != nil {
+		return domain.ServiceAccountAuditEvent{}, err
+	}
+	defer unlock()
+
+	oldest := -1
+	for i, auditEvent := range r.store.records.serviceAccountAuditEvents {
+		if oldest < 0 || auditEvent.CreatedAt.Before(r.store.records.serviceAccountAuditEvents[oldest].CreatedAt) {
+			oldest = i
+		}
+	}
+	if oldest < 0 {
+		return domain.ServiceAccountAuditEvent{}, ierr.ErrResourceNotFound
+	}
+	return r.store.records.serviceAccountAuditEvents[oldest], nil
+}
+
+func (r *serviceAccountRepository) GetLastAuditEventBefore(ctx context.Context, before time.Time) (domain.ServiceAccountAuditEvent, error) {
+	unlock, err := r.store.begin(ctx, "ServiceAccountRepository.GetLastAuditEventBefore")
+	if err != nil {
+		return domain.ServiceAccountAuditEvent{}, err
+	}
+	defer unlock()
+
+	// the chain stops before the first chained event created at or after the time, like the subquery of the SQL repository
+	var firstAfter *int64
+	for _, auditEvent := range r.store.records.serviceAccountAuditEvents {
+		if auditEvent.Seq != nil && !auditEvent.CreatedAt.Before(before) && (firstAfter == nil || *auditEvent.Seq < *firstAfter) {
+			firstAfter = auditEvent.Seq
+		}
+	}
+
+	last := -1
+	for i, auditEvent := range r.store.records.serviceAccountAuditEvents {
+		if auditEvent.Seq == nil || firstAfter != nil && *auditEvent.Seq >= *firstAfter {
+			continue
+		}
+		if last < 0 || *auditEvent.Seq > *r.store.records.serviceAccountAuditEvents[last].Seq {
+			last = i
+		}
+	}
+	if last < 0 {
+		return domain.ServiceAccountAuditEvent{}, ierr.ErrResourceNotFound
+	}
+	return r.store.records.serviceAccountAuditEvents[last], nil
+}
+
+func (r *serviceAccountRepository) DeleteAuditEvents(ctx context.Context, maxSeq int64, before time.Time, heldUserIDs []string) (int64, error) {
+	unlock, err := r.store.begin(ctx, "ServiceAccountRepository.DeleteAuditEvents")
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	kept := []domain.ServiceAccountAuditEvent{}
+	for _, auditEvent := range r.store.records.serviceAccountAuditEvents {
+		chained := auditEvent.Seq != nil && *auditEvent.Seq <= maxSeq
+		unchained := auditEvent.Seq == nil && auditEvent.CreatedAt.Before(before)
+		held := contains(heldUserIDs, auditEvent.ActorID) || contains(heldUserIDs, auditEvent.SubjectID)
+		if !chained && !unchained || held {
+			kept = append(kept, auditEvent)
+		}
+	}
+	deleted := len(r.store.records.serviceAccountAuditEvents) - len(kept)
+	r.store.records.serviceAccountAuditEvents = kept
+	return int64(deleted), nil
+}
+
+func (r *serviceAccountRepository) GetLastAuditArchive(ctx context.Context) (domain.ServiceAccountAuditArchive, error) {
+	unlock, err := r.store.begin(ctx, "ServiceAccountRepository.GetLastAuditArchive")
+	if err != nil {
+		return domain.ServiceAccountAuditArchive{}, err
+	}
+	defer unlock()
+
+	last := -1
+	for i, archive := range r.store.records.auditArchives {
+		if last < 0 || archive.Day.After(r.store.records.auditArchives[last].Day) {
+			last = i
+		}
+	}
+	if last < 0 {
+		return domain.ServiceAccountAuditArchive{}, ierr.ErrResourceNotFound
+	}
+	return r.store.records.auditArchives[last], nil
+}
+
+func (r *serviceAccountRepository) RecordAuditArchive(ctx context.Context, archive domain.ServiceAccountAuditArchive) error {
+	unlock, err := r.store.begin(ctx, "ServiceAccountRepository.RecordAuditArchive")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for _, existing := range r.store.records.auditArchives {
+		if existing.Day.Equal(archive.Day) {
+			return errors.Wrap(ierr.ErrConflict, "cannot record service account audit archive")
+		}
+	}
+	r.store.records.auditArchives = append(r.store.records.auditArchives, archive)
+	return nil
+}
+
+// seqOf returns the position of the event in the chain, the unchained events sort first like NULL in MySQL
+func seqOf(auditEvent domain.ServiceAccountAuditEvent) int64 {
+	if auditEvent.Seq == nil {
+		return -1
+	}
+	return *auditEvent.Seq
+}
@@ -0,0 +1,155 @@
+package memory
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/shared/ierr"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type sessionRepository struct {
+	store *Store
+}
+
+func (r *sessionRepository) Create(ctx context.Context, session domain.Session) error {
+	unlock, err := r.store.begin(ctx, "SessionRepository.Create")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for _, existing := range r.store.records.sessions {
+		if existing.ID == session.ID {
+			return errors.Wrap(ierr.ErrConflict, "session already exists")
+		}
+	}
+	session.ID = r.store.nextID(session.ID)
+	r.store.records.sessions = append(r.store.records.sessions, session)
+	return nil
+}
+
+func (r *sessionRepository) GetByID(ctx context.Context, sessionID string) (domain.Session, error) {
+	unlock, err := r.store.begin(ctx, "SessionRepository.GetByID")
+	if err != nil {
+		return domain.Session{}, err
+	}
+	defer unlock()
+
+	for _, session := range r.store.records.sessions {
+		if session.ID == sessionID {
+			return session, nil
+		}
+	}
+	return domain.Session{}, ierr.ErrResourceNotFound
+}
+
+func (r *sessionRepository) ListActiveByUserID(ctx context.Context, userID string) ([]domain.Session, error) {
+	unlock, err := r.store.begin(ctx, "SessionRepository.ListActiveByUserID")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	sessions := r.active(userID)
+	sort.SliceStable(sessions, func(i, j int) bool { return sessions[i].CreatedAt.Before(sessions[j].CreatedAt) })
+	return sessions, nil
+}
+
+func (r *sessionRepository) CountActiveByUserID(ctx context.Context, userID string) (int, error) {
+	unlock, err := r.store.begin(ctx, "SessionRepository.CountActiveByUserID")
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	return len(r.active(userID)), nil
+}
+
+func (r *sessionRepository) Touch(ctx context.Context, sessionID string, at time.Time) error {
+	unlock, err := r.store.begin(ctx, "SessionRepository.Touch")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for i := range r.store.records.sessions {
+		if r.store.records.sessions[i].ID == sessionID {
+			r.store.records.sessions[i].LastUsedAt = &at
+		}
+	}
+	return nil
+}
+
+func (r *sessionRepository) ClearRefreshToken(ctx context.Context, sessionID string, hashedRefreshToken string) (bool, error) {
+	unlock, err := r.store.begin(ctx, "SessionRepository.ClearRefreshToken")
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	for i, session := range r.store.records.sessions {
+		if session.ID == sessionID && session.RefreshToken != nil && *session.RefreshToken == hashedRefreshToken {
+			r.store.records.sessions[i].RefreshToken = nil
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *sessionRepository) Revoke(ctx context.Context, sessionID string) error {
+	return r.revoke(ctx, "SessionRepository.Revoke", func(session domain.Session) bool {
+		return session.ID == sessionID
+	})
+}
+
+func (r *sessionRepository) RevokeByUserID(ctx context.Context, userID string) error {
+	return r.revoke(ctx, "SessionRepository.RevokeByUserID", func(session domain.Session) bool {
+		return session.UserID == userID
+	})
+}
+
+func (r *sessionRepository) RevokeByUpstreamSessionID(ctx context.Context, upstreamSessionID string, upstreamSubject string) error {
+	return r.revoke(ctx, "SessionRepository.RevokeByUpstreamSessionID", func(session domain.Session) bool {
+		return session.UpstreamSessionID != nil && *session.UpstreamSessionID == upstreamSessionID &&
+			(upstreamSubject == "" || session.UpstreamSubject != nil && *session.UpstreamSubject == upstreamSubject)
+	})
+}
+
+func (r *sessionRepository) RevokeByUpstreamSubject(ctx context.Context, upstreamSubject string) error {
+	return r.revoke(ctx, "SessionRepository.RevokeByUpstreamSubject", func(session domain.Session) bool {
+		return session.UpstreamSubject != nil && *session.UpstreamSubject == upstreamSubject
+	})
+}
+
+// revoke revokes the sessions not revoked yet which match, clearing their refresh token
+func (r *sessionRepository) revoke(ctx context.Context, op string, match func(domain.Session) bool) error {
+	unlock, err := r.store.begin(ctx, op)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	now := r.store.now()
+	for i, session := range r.store.records.sessions {
+		if session.RevokedAt == nil && match(session) {
+			r.store.records.sessions[i].RevokedAt = &now
+			r.store.records.sessions[i].RefreshToken = nil
+		}
+	}
+	return nil
+}
+
+// active returns the sessions of the user neither revoked nor expired; the records must be locked
+func (r *sessionRepository) active(userID string) []domain.Session {
+	now := r.store.now()
+	sessions := []domain.Session{}
+	for _, session := range r.store.records.sessions {
+		if session.UserID == userID && session.RevokedAt == nil && session.ExpiresAt.After(now) {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions
+}
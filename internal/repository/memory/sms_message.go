@@ -0,0 +1,53 @@
+package memory
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/shared/ierr"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type smsMessageRepository struct {
+	store *Store
+}
+
+func (r *smsMessageRepository) Create(ctx context.Context, msg domain.SMSMessage) error {
+	unlock, err := r.store.begin(ctx, "SMSMessageRepository.Create")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for _, existing := range r.store.records.smsMessages {
+		if existing.ID == msg.ID {
+			return errors.Wrap(ierr.ErrConflict, "cannot create sms message")
+		}
+	}
+	msg.ID = r.store.nextID(msg.ID)
+	r.store.records.smsMessages = append(r.store.records.smsMessages, msg)
+	return nil
+}
+
+func (r *smsMessageRepository) UpdateStatus(ctx context.Context, provider string, providerMessageID string, status string, errorCode string, updatedAt time.Time) error {
+	unlock, err := r.store.begin(ctx, "SMSMessageRepository.UpdateStatus")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	updated := false
+	for i, msg := range r.store.records.smsMessages {
+		if msg.Provider == provider && msg.ProviderMessageID == providerMessageID {
+			r.store.records.smsMessages[i].Status = status
+			r.store.records.smsMessages[i].ErrorCode = errorCode
+			r.store.records.smsMessages[i].UpdatedAt = updatedAt
+			updated = true
+		}
+	}
+	if !updated {
+		return ierr.ErrResourceNotFound
+	}
+	return nil
+}
@@ -0,0 +1,380 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/times"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Store keeps the records of every repository of port.RepositoryRegistry in memory, so that the unit tests of the
+// services need neither a database nor hand-rolled mocks. The repositories follow the SQL ones: the same errors,
+// orders and conditional writes, and the store is seeded like the migrations with the admin and user roles and
+// the head of the audit chain.
+//
+// The store is safe for concurrent use. The transactions are serialized, and rolled back by restoring the records
+// as they were at their start, which also discards the writes made meanwhile outside of the transaction; the
+// operations outside of a transaction see the writes of the transaction in progress. A transaction must therefore
+// use the registry it is given, a transaction started from the store during another one waits for its end.
+//
+// The records created without an ID are given the next of a deterministic sequence of UUIDs (see ID), and the
+// time of the writes which the SQL repositories read from the database clock is read from SetClock.
+// SetHook injects failures into the operations.
+type Store struct {
+	*state
+	tx bool
+}
+
+var _ port.RepositoryRegistry = (*Store)(nil)
+
+type state struct {
+	txMu sync.Mutex // held by the transactions
+
+	mu      sync.Mutex // guards the fields below
+	records records
+	seq     int
+	hook    Hook
+	now     func() time.Time
+}
+
+// Hook is called before every operation of the store with its name, the repository and the method such as
+// "UserRepository.Create", or "DoInTransaction". The operation fails with the error returned, the records untouched.
+type Hook func(ctx context.Context, op string) error
+
+// FailOn returns a hook failing the specified operations with err
+func FailOn(err error, ops ...string) Hook {
+	return func(ctx context.Context, op string) error {
+		for _, failed := range ops {
+			if op == failed {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// ID returns the nth ID given by a store to the records created without one, from 1
+func ID(n int) string {
+	return fmt.Sprintf("00000000-0000-0000-0000-%012d", n)
+}
+
+// NewStore creates a store holding the records seeded by the migrations
+func NewStore() *Store {
+	s := &Store{state: &state{now: times.Now}}
+	s.records.roles = []domain.Role{
+		{Name: "admin", Description: "administrators, granted every permission", Permissions: []string{"*"}},
+		{Name: "user", Description: "every user, granted by default", Permissions: []string{"profile:read", "profile:write"}},
+	}
+	s.records.auditChainHeads = []domain.AuditChainHead{{Name: domain.AuditChainServiceAccounts}}
+	return s
+}
+
+// SetHook sets the hook called before every operation, nil removes it
+func (s *Store) SetHook(hook Hook) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hook = hook
+}
+
+// SetClock sets the clock of the writes stamped by the database, such as the revocation of the sessions,
+// and of the reads comparing with its current time, such as the active sessions
+func (s *Store) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+// PutRole saves the role with its permissions, replacing the role of the same name.
+// The roles are only seeded by the migrations, the RoleRepository cannot create them.
+func (s *Store) PutRole(role domain.Role) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	role.Permissions = append([]string{}, role.Permissions...)
+	sort.Strings(role.Permissions)
+	for i := range s.records.roles {
+		if s.records.roles[i].Name == role.Name {
+			s.records.roles[i] = role
+			return
+		}
+	}
+	s.records.roles = append(s.records.roles, role)
+}
+
+// DeleteUser deletes the user, as done outside of the application since the UserRepository cannot delete them
+func (s *Store) DeleteUser(userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	users := []domain.User{}
+	for _, user := range s.records.users {
+		if user.ID != userID {
+			users = append(users, user)
+		}
+	}
+	s.records.users = users
+}
+
+// SMSMessages returns the text messages saved, in the order of their creation.
+// The SMSMessageRepository only writes them, the provider reports their delivery.
+func (s *Store) SMSMessages() []domain.SMSMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]domain.SMSMessage{}, s.records.smsMessages...)
+}
+
+// DoInTransaction runs txFunc in a transaction, rolled back when txFunc fails or panics.
+// Nested calls join the outer transaction.
+func (s *Store) DoInTransaction(ctx context.Context, txFunc port.InTransaction) (out interface{}, err error) {
+	if err := s.fail(ctx, "DoInTransaction"); err != nil {
+		return nil, err
+	}
+	if s.tx {
+		return txFunc(ctx, s)
+	}
+
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	s.mu.Lock()
+	snapshot := s.records.clone()
+	s.mu.Unlock()
+
+	defer func() {
+		if p := recover(); p != nil {
+			s.rollback(snapshot)
+			panic(p) // re-throw panic after the rollback
+		} else if err != nil {
+			s.rollback(snapshot)
+		}
+	}()
+	return txFunc(ctx, &Store{s.state, true})
+}
+
+func (s *Store) rollback(snapshot records) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = snapshot
+}
+
+func (s *Store) GetUserRepository() port.UserRepository {
+	return &userRepository{s}
+}
+
+func (s *Store) GetSessionRepository() port.SessionRepository {
+	return &sessionRepository{s}
+}
+
+func (s *Store) GetLoginApprovalRepository() port.LoginApprovalRepository {
+	return &loginApprovalRepository{s}
+}
+
+func (s *Store) GetDeviceLoginRepository() port.DeviceLoginRepository {
+	return &deviceLoginRepository{s}
+}
+
+func (s *Store) GetServiceAccountRepository() port.ServiceAccountRepository {
+	return &serviceAccountRepository{s}
+}
+
+func (s *Store) GetTokenUsageRepository() port.TokenUsageRepository {
+	return &tokenUsageRepository{s}
+}
+
+func (s *Store) GetLogVerbosityRepository() port.LogVerbosityRepository {
+	return &logVerbosityRepository{s}
+}
+
+func (s *Store) GetLegalHoldRepository() port.LegalHoldRepository {
+	return &legalHoldRepository{s}
+}
+
+func (s *Store) GetBroadcastRepository() port.BroadcastRepository {
+	return &broadcastRepository{s}
+}
+
+func (s *Store) GetEmailSuppressionRepository() port.EmailSuppressionRepository {
+	return &emailSuppressionRepository{s}
+}
+
+func (s *Store) GetSMSMessageRepository() port.SMSMessageRepository {
+	return &smsMessageRepository{s}
+}
+
+func (s *Store) GetMessageTemplateRepository() port.MessageTemplateRepository {
+	return &messageTemplateRepository{s}
+}
+
+func (s *Store) GetElevationRepository() port.ElevationRepository {
+	return &elevationRepository{s}
+}
+
+func (s *Store) GetBreakGlassAccountRepository() port.BreakGlassAccountRepository {
+	return &breakGlassAccountRepository{s}
+}
+
+func (s *Store) GetRefreshTokenRepository() port.RefreshTokenRepository {
+	return &refreshTokenRepository{s}
+}
+
+func (s *Store) GetUserSyncRepository() port.UserSyncRepository {
+	return &userSyncRepository{s}
+}
+
+func (s *Store) GetProvisioningRepository() port.ProvisioningRepository {
+	return &provisioningRepository{s}
+}
+
+func (s *Store) GetPasswordResetRepository() port.PasswordResetRepository {
+	return &passwordResetRepository{s}
+}
+
+func (s *Store) GetEmailVerificationRepository() port.EmailVerificationRepository {
+	return &emailVerificationRepository{s}
+}
+
+func (s *Store) GetRoleRepository() port.RoleRepository {
+	return &roleRepository{s}
+}
+
+func (s *Store) GetUserIdentityRepository() port.UserIdentityRepository {
+	return &userIdentityRepository{s}
+}
+
+func (s *Store) GetAuditRepository() port.AuditRepository {
+	return &auditRepository{s}
+}
+
+func (s *Store) GetTenantSettingsRepository() port.TenantSettingsRepository {
+	return &tenantSettingsRepository{s}
+}
+
+// begin starts the operation: it calls the hook, then locks the records until the returned function is called
+func (s *Store) begin(ctx context.Context, op string) (func(), error) {
+	if err := s.fail(ctx, op); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	return s.mu.Unlock, nil
+}
+
+// fail calls the hook out of the lock, so that it may use the store
+func (s *Store) fail(ctx context.Context, op string) error {
+	s.mu.Lock()
+	hook := s.hook
+	s.mu.Unlock()
+	if hook == nil {
+		return nil
+	}
+	return hook(ctx, op)
+}
+
+// nextID returns the id, or the next of the sequence when empty; the records must be locked
+func (s *Store) nextID(id string) string {
+	if id != "" {
+		return id
+	}
+	s.seq++
+	return ID(s.seq)
+}
+
+// bounds returns the bounds of the page of n records skipping the offset first ones and holding up to limit,
+// every record when limit is not positive as bun does
+func bounds(n int, limit int, offset int) (int, int) {
+	if offset > n {
+		offset = n
+	}
+	if limit <= 0 || offset+limit > n {
+		return offset, n
+	}
+	return offset, offset + limit
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// records are the tables of the store, each kept in the order of its inserts
+type records struct {
+	users                     []domain.User
+	sessions                  []domain.Session
+	loginApprovals            []domain.LoginApproval
+	deviceLogins              []domain.DeviceLogin
+	serviceAccounts           []domain.ServiceAccount
+	serviceAccountRoles       []domain.ServiceAccountRole
+	serviceAccountAssertions  []domain.ServiceAccountAssertion
+	serviceAccountAuditEvents []domain.ServiceAccountAuditEvent
+	auditChainHeads           []domain.AuditChainHead
+	auditArchives             []domain.ServiceAccountAuditArchive
+	endpointUsages            []domain.TokenUsageEndpoint
+	scopeUsages               []domain.TokenUsageScope
+	logVerbosities            []domain.LogVerbosity
+	legalHolds                []domain.LegalHold
+	broadcasts                []domain.Broadcast
+	broadcastDeliveries       []domain.BroadcastDelivery
+	emailSuppressions         []domain.EmailSuppression
+	smsMessages               []domain.SMSMessage
+	messageTemplates          []domain.MessageTemplate
+	elevations                []domain.Elevation
+	breakGlassAccounts        []domain.BreakGlassAccount
+	refreshTokens             []domain.RefreshToken
+	userSyncLinks             []domain.UserSyncLink
+	userSyncRuns              []domain.UserSyncRun
+	provisioningStates        []domain.ProvisioningState
+	provisioningErrors        []domain.ProvisioningError
+	provisioningRuns          []domain.ProvisioningRun
+	passwordResets            []domain.PasswordReset
+	emailVerifications        []domain.EmailVerification
+	roles                     []domain.Role
+	userRoles                 []domain.UserRole
+	userIdentities            []domain.UserIdentity
+	auditEvents               []domain.AuditEvent
+	tenantSettings            []domain.TenantSettings
+}
+
+// clone copies the tables, the records are updated in place so the copies must not share them
+func (r records) clone() records {
+	return records{
+		users:                     append([]domain.User(nil), r.users...),
+		sessions:                  append([]domain.Session(nil), r.sessions...),
+		loginApprovals:            append([]domain.LoginApproval(nil), r.loginApprovals...),
+		deviceLogins:              append([]domain.DeviceLogin(nil), r.deviceLogins...),
+		serviceAccounts:           append([]domain.ServiceAccount(nil), r.serviceAccounts...),
+		serviceAccountRoles:       append([]domain.ServiceAccountRole(nil), r.serviceAccountRoles...),
+		serviceAccountAssertions:  append([]domain.ServiceAccountAssertion(nil), r.serviceAccountAssertions...),
+		serviceAccountAuditEvents: append([]domain.ServiceAccountAuditEvent(nil), r.serviceAccountAuditEvents...),
+		auditChainHeads:           append([]domain.AuditChainHead(nil), r.auditChainHeads...),
+		auditArchives:             append([]domain.ServiceAccountAuditArchive(nil), r.auditArchives...),
+		endpointUsages:            append([]domain.TokenUsageEndpoint(nil), r.endpointUsages...),
+		scopeUsages:               append([]domain.TokenUsageScope(nil), r.scopeUsages...),
+		logVerbosities:            append([]domain.LogVerbosity(nil), r.logVerbosities...),
+		legalHolds:                append([]domain.LegalHold(nil), r.legalHolds...),
+		broadcasts:                append([]domain.Broadcast(nil), r.broadcasts...),
+		broadcastDeliveries:       append([]domain.BroadcastDelivery(nil), r.broadcastDeliveries...),
+		emailSuppressions:         append([]domain.EmailSuppression(nil), r.emailSuppressions...),
+		smsMessages:               append([]domain.SMSMessage(nil), r.smsMessages...),
+		messageTemplates:          append([]domain.MessageTemplate(nil), r.messageTemplates...),
+		elevations:                append([]domain.Elevation(nil), r.elevations...),
+		breakGlassAccounts:        append([]domain.BreakGlassAccount(nil), r.breakGlassAccounts...),
+		refreshTokens:             append([]domain.RefreshToken(nil), r.refreshTokens...),
+		userSyncLinks:             append([]domain.UserSyncLink(nil), r.userSyncLinks...),
+		userSyncRuns:              append([]domain.UserSyncRun(nil), r.userSyncRuns...),
+		provisioningStates:        append([]domain.ProvisioningState(nil), r.provisioningStates...),
+		provisioningErrors:        append([]domain.ProvisioningError(nil), r.provisioningErrors...),
+		provisioningRuns:          append([]domain.ProvisioningRun(nil), r.provisioningRuns...),
+		passwordResets:            append([]domain.PasswordReset(nil), r.passwordResets...),
+		emailVerifications:        append([]domain.EmailVerification(nil), r.emailVerifications...),
+		roles:                     append([]domain.Role(nil), r.roles...),
+		userRoles:                 append([]domain.UserRole(nil), r.userRoles...),
+		userIdentities:            append([]domain.UserIdentity(nil), r.userIdentities...),
+		auditEvents:               append([]domain.AuditEvent(nil), r.auditEvents...),
+		tenantSettings:            append([]domain.TenantSettings(nil), r.tenantSettings...),
+	}
+}
@@ -0,0 +1,190 @@
+package memory
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/port"
+	"go-hex/shared/ierr"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStoreTransaction(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore()
+	users := store.GetUserRepository()
+	assert.NoError(t, users.Create(ctx, domain.User{Username: "alice"}))
+
+	// a failed transaction is rolled back, nested transactions included
+	failure := errors.New("failure")
+	_, err := store.DoInTransaction(ctx, func(ctx context.Context, registry port.RepositoryRegistry) (interface{}, error) {
+		if err := registry.GetUserRepository().Create(ctx, domain.User{Username: "bob"}); err != nil {
+			return nil, err
+		}
+		return registry.DoInTransaction(ctx, func(ctx context.Context, registry port.RepositoryRegistry) (interface{}, error) {
+			if err := registry.GetUserRepository().SetActive(ctx, ID(1), true); err != nil {
+				return nil, err
+			}
+			return nil, failure
+		})
+	})
+	assert.Equal(t, failure, err)
+	exists, _ := users.IsUserExistByUsername(ctx, "bob")
+	assert.False(t, exists)
+	alice, _ := users.GetByID(ctx, ID(1))
+	assert.False(t, alice.IsActive)
+
+	// so is a panicking one
+	assert.Panics(t, func() {
+		store.DoInTransaction(ctx, func(ctx context.Context, registry port.RepositoryRegistry) (interface{}, error) {
+			registry.GetUserRepository().Create(ctx, domain.User{Username: "carol"})
+			panic("boom")
+		})
+	})
+	exists, _ = users.IsUserExistByUsername(ctx, "carol")
+	assert.False(t, exists)
+
+	out, err := store.DoInTransaction(ctx, func(ctx context.Context, registry port.RepositoryRegistry) (interface{}, error) {
+		return "done", registry.GetUserRepository().Create(ctx, domain.User{Username: "dave"})
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "done", out)
+	dave, err := users.GetByUsername(ctx, "dave")
+	assert.NoError(t, err)
+	assert.Equal(t, ID(4), dave.ID, "the ids given in the rolled back transactions are not reused")
+}
+
+func TestStoreHook(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore()
+	failure := errors.New("failure")
+	store.SetHook(FailOn(failure, "SessionRepository.Create", "DoInTransaction"))
+
+	err := store.GetSessionRepository().Create(ctx, domain.Session{UserID: "u1"})
+	assert.Equal(t, failure, err)
+	sessions, err := store.GetSessionRepository().ListActiveByUserID(ctx, "u1")
+	assert.NoError(t, err)
+	assert.Empty(t, sessions, "the failed operation leaves the records untouched")
+
+	called := false
+	_, err = store.DoInTransaction(ctx, func(ctx context.Context, registry port.RepositoryRegistry) (interface{}, error) {
+		called = true
+		return nil, nil
+	})
+	assert.Equal(t, failure, err)
+	assert.False(t, called)
+
+	store.SetHook(nil)
+	assert.NoError(t, store.GetSessionRepository().Create(ctx, domain.Session{UserID: "u1", ExpiresAt: time.Now().Add(time.Hour)}))
+}
+
+func TestStoreSeed(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore()
+	store.PutRole(domain.Role{Name: "auditor", Permissions: []string{"users:read", "audit:read"}})
+
+	roles, err := store.GetRoleRepository().ListByNames(ctx, []string{"user", "auditor", "unknown"})
+	assert.NoError(t, err)
+	if assert.Len(t, roles, 2) {
+		assert.Equal(t, "auditor", roles[0].Name)
+		assert.Equal(t, []string{"audit:read", "users:read"}, roles[0].Permissions)
+		assert.Equal(t, []string{"profile:read", "profile:write"}, roles[1].Permissions)
+	}
+
+	assert.NoError(t, store.GetRoleRepository().Assign(ctx, domain.UserRole{UserID: "u1", Role: "admin"}))
+	assert.NoError(t, store.GetRoleRepository().Assign(ctx, domain.UserRole{UserID: "u1", Role: "admin"}))
+	roles, _ = store.GetRoleRepository().ListByUserID(ctx, "u1", time.Now())
+	assert.Len(t, roles, 1)
+	assert.NoError(t, store.GetRoleRepository().Unassign(ctx, "u1", "admin"))
+	assert.Equal(t, ierr.ErrResourceNotFound, store.GetRoleRepository().Unassign(ctx, "u1", "admin"))
+
+	head, err := store.GetServiceAccountRepository().GetAuditChainHead(ctx, domain.AuditChainServiceAccounts)
+	assert.NoError(t, err)
+	assert.Zero(t, head.Seq)
+}
+
+func TestStoreSessions(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	store := NewStore()
+	store.SetClock(func() time.Time { return now })
+	repo := store.GetSessionRepository()
+
+	token := "hash"
+	assert.NoError(t, repo.Create(ctx, domain.Session{UserID: "u1", RefreshToken: &token, CreatedAt: now.Add(-time.Minute), ExpiresAt: now.Add(time.Hour)}))
+	assert.NoError(t, repo.Create(ctx, domain.Session{UserID: "u1", CreatedAt: now.Add(-time.Hour), ExpiresAt: now.Add(time.Hour)}))
+	assert.NoError(t, repo.Create(ctx, domain.Session{UserID: "u1", CreatedAt: now.Add(-time.Hour), ExpiresAt: now}))
+	assert.True(t, errors.Is(repo.Create(ctx, domain.Session{ID: ID(1)}), ierr.ErrConflict))
+
+	sessions, err := repo.ListActiveByUserID(ctx, "u1")
+	assert.NoError(t, err)
+	if assert.Len(t, sessions, 2, "the expired session is not active") {
+		assert.Equal(t, ID(2), sessions[0].ID, "the oldest first")
+	}
+
+	cleared, _ := repo.ClearRefreshToken(ctx, ID(1), "other")
+	assert.False(t, cleared)
+	assert.NoError(t, repo.Revoke(ctx, ID(1)))
+	session, _ := repo.GetByID(ctx, ID(1))
+	assert.Equal(t, now, *session.RevokedAt)
+	assert.Nil(t, session.RefreshToken)
+	count, _ := repo.CountActiveByUserID(ctx, "u1")
+	assert.Equal(t, 1, count)
+}
+
+func TestStoreDeleteExpired(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	store := NewStore()
+	repo := store.GetDeviceLoginRepository()
+
+	held := "u1"
+	other := "u2"
+	assert.NoError(t, repo.Create(ctx, domain.DeviceLogin{DeviceCode: "d1", UserCode: "U1", UserID: &held, ExpiresAt: now.Add(-time.Hour)}))
+	assert.NoError(t, repo.Create(ctx, domain.DeviceLogin{DeviceCode: "d2", UserCode: "U2", UserID: &other, ExpiresAt: now.Add(-time.Hour)}))
+	assert.NoError(t, repo.Create(ctx, domain.DeviceLogin{DeviceCode: "d3", UserCode: "U3", ExpiresAt: now.Add(-time.Hour)}))
+	assert.NoError(t, repo.Create(ctx, domain.DeviceLogin{DeviceCode: "d4", UserCode: "U4", ExpiresAt: now.Add(time.Hour)}))
+	assert.Equal(t, ierr.ErrConflict, repo.Create(ctx, domain.DeviceLogin{DeviceCode: "d4", UserCode: "U5"}))
+
+	deleted, err := repo.DeleteExpired(ctx, now, []string{held})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+	_, err = repo.GetByUserCode(ctx, "U1")
+	assert.NoError(t, err, "the device login of the held user is kept")
+	_, err = repo.GetByUserCode(ctx, "U3")
+	assert.Equal(t, ierr.ErrResourceNotFound, err)
+}
+
+func TestStoreAuditChain(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)
+	repo := NewStore().GetServiceAccountRepository()
+
+	seq := func(n int64) *int64 { return &n }
+	assert.NoError(t, repo.RecordAuditEvents(ctx, []domain.ServiceAccountAuditEvent{
+		{CreatedAt: start},
+		{CreatedAt: start.Add(time.Hour), Seq: seq(1)},
+		{CreatedAt: start.Add(3 * time.Hour), Seq: seq(2)},
+		{CreatedAt: start.Add(2 * time.Hour), Seq: seq(3)},
+	}))
+
+	last, err := repo.GetLastAuditEventBefore(ctx, start.Add(150*time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), *last.Seq, "the chain stops before the first event created afterwards")
+	_, err = repo.GetLastAuditEventBefore(ctx, start)
+	assert.Equal(t, ierr.ErrResourceNotFound, err)
+
+	events, _ := repo.ListChainedAuditEvents(ctx, 1, 1)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, int64(2), *events[0].Seq)
+	}
+
+	deleted, err := repo.DeleteAuditEvents(ctx, 1, start.Add(time.Minute), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+	oldest, _ := repo.GetOldestAuditEvent(ctx)
+	assert.Equal(t, ID(4), oldest.ID)
+}
@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/shared/ierr"
+	"sort"
+)
+
+type tenantSettingsRepository struct {
+	store *Store
+}
+
+func (r *tenantSettingsRepository) Get(ctx context.Context, tenantID string) (domain.TenantSettings, error) {
+	unlock, err := r.store.begin(ctx, "TenantSettingsRepository.Get")
+	if err != nil {
+		return domain.TenantSettings{}, err
+	}
+	defer unlock()
+
+	for _, settings := range r.store.records.tenantSettings {
+		if settings.TenantID == tenantID {
+			return settings, nil
+		}
+	}
+	return domain.TenantSettings{}, ierr.ErrResourceNotFound
+}
+
+func (r *tenantSettingsRepository) List(ctx context.Context, limit int, offset int) ([]domain.TenantSettings, error) {
+	unlock, err := r.store.begin(ctx, "TenantSettingsRepository.List")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	tenants := append([]domain.TenantSettings{}, r.store.records.tenantSettings...)
+	sort.SliceStable(tenants, func(i, j int) bool { return tenants[i].TenantID < tenants[j].TenantID })
+	from, to := bounds(len(tenants), limit, offset)
+	return tenants[from:to], nil
+}
+
+func (r *tenantSettingsRepository) Upsert(ctx context.Context, settings domain.TenantSettings) error {
+	unlock, err := r.store.begin(ctx, "TenantSettingsRepository.Upsert")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for i, existing := range r.store.records.tenantSettings {
+		if existing.TenantID == settings.TenantID {
+			settings.CreatedAt = existing.CreatedAt
+			r.store.records.tenantSettings[i] = settings
+			return nil
+		}
+	}
+	r.store.records.tenantSettings = append(r.store.records.tenantSettings, settings)
+	return nil
+}
+
+func (r *tenantSettingsRepository) Delete(ctx context.Context, tenantID string) error {
+	unlock, err := r.store.begin(ctx, "TenantSettingsRepository.Delete")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for i, settings := range r.store.records.tenantSettings {
+		if settings.TenantID == tenantID {
+			tenants := r.store.records.tenantSettings
+			r.store.records.tenantSettings = append(append([]domain.TenantSettings{}, tenants[:i]...), tenants[i+1:]...)
+			return nil
+		}
+	}
+	return ierr.ErrResourceNotFound
+}
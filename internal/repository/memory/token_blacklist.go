@@ -0,0 +1,52 @@
+// Package memory stores the records only needed until they expire in the memory of the instance,
+// for the deployments of a single instance or without shared store.
+// Its Store also keeps every repository in memory for the unit tests of the services.
+package memory
+
+import (
+	"context"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/times"
+	"sync"
+	"time"
+)
+
+// TokenBlacklistRepository records the revoked tokens in memory, the tokens are forgotten once expired.
+// The revocations are only seen by the instance which recorded them and are lost on restart.
+type TokenBlacklistRepository struct {
+	mu      sync.RWMutex
+	revoked map[string]time.Time
+	now     func() time.Time
+}
+
+// NewTokenBlacklistRepository creates an empty in-memory blacklist
+func NewTokenBlacklistRepository() port.TokenBlacklistRepository {
+	return &TokenBlacklistRepository{revoked: map[string]time.Time{}, now: times.Now}
+}
+
+// Revoke records the token with the specified ID as revoked until it expires.
+func (r *TokenBlacklistRepository) Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// the expired tokens are pruned on write, so that the blacklist stays bounded by the live tokens
+	now := r.now()
+	for id, until := range r.revoked {
+		if !until.After(now) {
+			delete(r.revoked, id)
+		}
+	}
+	if expiresAt.After(now) {
+		r.revoked[tokenID] = expiresAt
+	}
+	return nil
+}
+
+// IsRevoked returns whether the token with the specified ID has been revoked.
+func (r *TokenBlacklistRepository) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	until, ok := r.revoked[tokenID]
+	return ok && until.After(r.now()), nil
+}
@@ -0,0 +1,34 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBlacklistRepository(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	repo := NewTokenBlacklistRepository().(*TokenBlacklistRepository)
+	repo.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	assert.NoError(t, repo.Revoke(ctx, "t1", now.Add(time.Minute)))
+	assert.NoError(t, repo.Revoke(ctx, "t2", now.Add(-time.Minute)))
+
+	revoked, err := repo.IsRevoked(ctx, "t1")
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+	revoked, _ = repo.IsRevoked(ctx, "t2")
+	assert.False(t, revoked, "an expired token is not recorded")
+	revoked, _ = repo.IsRevoked(ctx, "t3")
+	assert.False(t, revoked)
+
+	// the token is forgotten once expired
+	now = now.Add(2 * time.Minute)
+	revoked, _ = repo.IsRevoked(ctx, "t1")
+	assert.False(t, revoked)
+	assert.NoError(t, repo.Revoke(ctx, "t3", now.Add(time.Minute)))
+	assert.Len(t, repo.revoked, 1)
+}
@@ -0,0 +1,132 @@
+package memory
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"sort"
+	"time"
+)
+
+type tokenUsageRepository struct {
+	store *Store
+}
+
+func (r *tokenUsageRepository) IncrementEndpoints(ctx context.Context, usages []domain.TokenUsageEndpoint) error {
+	unlock, err := r.store.begin(ctx, "TokenUsageRepository.IncrementEndpoints")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+next:
+	for _, usage := range usages {
+		for i, existing := range r.store.records.endpointUsages {
+			if existing.ClientID == usage.ClientID && existing.Method == usage.Method && existing.Route == usage.Route && existing.Day.Equal(usage.Day) {
+				r.store.records.endpointUsages[i].Calls += usage.Calls
+				if usage.LastSeenAt.After(existing.LastSeenAt) {
+					r.store.records.endpointUsages[i].LastSeenAt = usage.LastSeenAt
+				}
+				continue next
+			}
+		}
+		r.store.records.endpointUsages = append(r.store.records.endpointUsages, usage)
+	}
+	return nil
+}
+
+func (r *tokenUsageRepository) IncrementScopes(ctx context.Context, usages []domain.TokenUsageScope) error {
+	unlock, err := r.store.begin(ctx, "TokenUsageRepository.IncrementScopes")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+next:
+	for _, usage := range usages {
+		for i, existing := range r.store.records.scopeUsages {
+			if existing.ClientID == usage.ClientID && existing.Scope == usage.Scope && existing.Day.Equal(usage.Day) {
+				r.store.records.scopeUsages[i].GrantedCalls += usage.GrantedCalls
+				r.store.records.scopeUsages[i].UsedCalls += usage.UsedCalls
+				r.store.records.scopeUsages[i].LastUsedAt = latest(existing.LastUsedAt, usage.LastUsedAt)
+				continue next
+			}
+		}
+		r.store.records.scopeUsages = append(r.store.records.scopeUsages, usage)
+	}
+	return nil
+}
+
+func (r *tokenUsageRepository) ListEndpoints(ctx context.Context, clientID string, from time.Time, to time.Time) ([]domain.TokenUsageEndpoint, error) {
+	unlock, err := r.store.begin(ctx, "TokenUsageRepository.ListEndpoints")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	usages := []domain.TokenUsageEndpoint{}
+next:
+	for _, usage := range r.store.records.endpointUsages {
+		if clientID != "" && usage.ClientID != clientID || usage.Day.Before(from) || usage.Day.After(to) {
+			continue
+		}
+		for i, sum := range usages {
+			if sum.ClientID == usage.ClientID && sum.PrincipalType == usage.PrincipalType && sum.Method == usage.Method && sum.Route == usage.Route {
+				usages[i].Calls += usage.Calls
+				if usage.LastSeenAt.After(sum.LastSeenAt) {
+					usages[i].LastSeenAt = usage.LastSeenAt
+				}
+				continue next
+			}
+		}
+		usage.Day = time.Time{}
+		usages = append(usages, usage)
+	}
+	sort.SliceStable(usages, func(i, j int) bool {
+		if usages[i].ClientID != usages[j].ClientID {
+			return usages[i].ClientID < usages[j].ClientID
+		}
+		return usages[i].Calls > usages[j].Calls
+	})
+	return usages, nil
+}
+
+func (r *tokenUsageRepository) ListScopes(ctx context.Context, clientID string, from time.Time, to time.Time) ([]domain.TokenUsageScope, error) {
+	unlock, err := r.store.begin(ctx, "TokenUsageRepository.ListScopes")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	usages := []domain.TokenUsageScope{}
+next:
+	for _, usage := range r.store.records.scopeUsages {
+		if clientID != "" && usage.ClientID != clientID || usage.Day.Before(from) || usage.Day.After(to) {
+			continue
+		}
+		for i, sum := range usages {
+			if sum.ClientID == usage.ClientID && sum.Scope == usage.Scope {
+				usages[i].GrantedCalls += usage.GrantedCalls
+				usages[i].UsedCalls += usage.UsedCalls
+				usages[i].LastUsedAt = latest(sum.LastUsedAt, usage.LastUsedAt)
+				continue next
+			}
+		}
+		usage.Day = time.Time{}
+		usages = append(usages, usage)
+	}
+	sort.SliceStable(usages, func(i, j int) bool {
+		if usages[i].ClientID != usages[j].ClientID {
+			return usages[i].ClientID < usages[j].ClientID
+		}
+		return usages[i].Scope < usages[j].Scope
+	})
+	return usages, nil
+}
+
+// latest returns the latest of the nullable times, nil when both are
+func latest(a, b *time.Time) *time.Time {
+	if a == nil || b != nil && b.After(*a) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,236 @@
+package memory
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/shared/ierr"
+	"sort"
+)
+
+type userRepository struct {
+	store *Store
+}
+
+func (r *userRepository) GetByID(ctx context.Context, userID string) (domain.User, error) {
+	unlock, err := r.store.begin(ctx, "UserRepository.GetByID")
+	if err != nil {
+		return domain.User{}, err
+	}
+	defer unlock()
+
+	if i := r.index(func(user domain.User) bool { return user.ID == userID }); i >= 0 {
+		return r.store.records.users[i], nil
+	}
+	return domain.User{}, ierr.ErrResourceNotFound
+}
+
+func (r *userRepository) GetByUsername(ctx context.Context, username string) (domain.User, error) {
+	unlock, err := r.store.begin(ctx, "UserRepository.GetByUsername")
+	if err != nil {
+		return domain.User{}, err
+	}
+	defer unlock()
+
+	if i := r.index(func(user domain.User) bool { return user.Username == username }); i >= 0 {
+		return r.store.records.users[i], nil
+	}
+	return domain.User{}, ierr.ErrResourceNotFound
+}
+
+func (r *userRepository) ListByIDs(ctx context.Context, userIDs []string) ([]domain.User, error) {
+	unlock, err := r.store.begin(ctx, "UserRepository.ListByIDs")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	users := []domain.User{}
+	for _, user := range r.store.records.users {
+		if contains(userIDs, user.ID) {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func (r *userRepository) ListByEmail(ctx context.Context, email string) ([]domain.User, error) {
+	unlock, err := r.store.begin(ctx, "UserRepository.ListByEmail")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	users := []domain.User{}
+	for _, user := range r.store.records.users {
+		if user.Email != nil && *user.Email == email {
+			users = append(users, user)
+		}
+	}
+	sort.SliceStable(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users, nil
+}
+
+func (r *userRepository) List(ctx context.Context, afterID string, limit int) ([]domain.User, error) {
+	unlock, err := r.store.begin(ctx, "UserRepository.List")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	users := []domain.User{}
+	for _, user := range r.store.records.users {
+		if afterID == "" || user.ID > afterID {
+			users = append(users, user)
+		}
+	}
+	sort.SliceStable(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	from, to := bounds(len(users), limit, 0)
+	return users[from:to], nil
+}
+
+func (r *userRepository) IsUserExistByID(ctx context.Context, userID string) (bool, error) {
+	unlock, err := r.store.begin(ctx, "UserRepository.IsUserExistByID")
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	return r.index(func(user domain.User) bool { return user.ID == userID }) >= 0, nil
+}
+
+func (r *userRepository) IsUserExistByUsername(ctx context.Context, username string) (bool, error) {
+	unlock, err := r.store.begin(ctx, "UserRepository.IsUserExistByUsername")
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	return r.index(func(user domain.User) bool { return user.Username == username }) >= 0, nil
+}
+
+func (r *userRepository) Create(ctx context.Context, user domain.User) error {
+	unlock, err := r.store.begin(ctx, "UserRepository.Create")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if r.index(func(existing domain.User) bool { return existing.ID == user.ID || existing.Username == user.Username }) >= 0 {
+		return ierr.ErrUserAlreadyRegistered
+	}
+	user.ID = r.store.nextID(user.ID)
+	r.store.records.users = append(r.store.records.users, user)
+	return nil
+}
+
+// Update writes the non zero fields of the user only, like OmitZero of the SQL repository
+func (r *userRepository) Update(ctx context.Context, userID string, user domain.User) error {
+	unlock, err := r.store.begin(ctx, "UserRepository.Update")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if i := r.index(func(existing domain.User) bool { return existing.ID == userID }); i >= 0 {
+		r.store.records.users[i] = mergeUser(r.store.records.users[i], user)
+	}
+	return nil
+}
+
+func (r *userRepository) SetActive(ctx context.Context, userID string, active bool) error {
+	unlock, err := r.store.begin(ctx, "UserRepository.SetActive")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if i := r.index(func(user domain.User) bool { return user.ID == userID }); i >= 0 {
+		r.store.records.users[i].IsActive = active
+		r.store.records.users[i].UpdatedAt = r.store.now()
+	}
+	return nil
+}
+
+func (r *userRepository) ClearRefreshToken(ctx context.Context, userID string, hashedToken string) (bool, error) {
+	unlock, err := r.store.begin(ctx, "UserRepository.ClearRefreshToken")
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	i := r.index(func(user domain.User) bool {
+		return user.ID == userID && user.RefreshToken != nil && *user.RefreshToken == hashedToken
+	})
+	if i < 0 {
+		return false, nil
+	}
+	r.store.records.users[i].RefreshToken = nil
+	return true, nil
+}
+
+func (r *userRepository) ReplacePassword(ctx context.Context, userID string, hashedPwd string, newHashedPwd string) (bool, error) {
+	unlock, err := r.store.begin(ctx, "UserRepository.ReplacePassword")
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	i := r.index(func(user domain.User) bool { return user.ID == userID && user.Password == hashedPwd })
+	if i < 0 {
+		return false, nil
+	}
+	r.store.records.users[i].Password = newHashedPwd
+	r.store.records.users[i].UpdatedAt = r.store.now()
+	return true, nil
+}
+
+// index returns the position of the first user matching, -1 when none does; the records must be locked
+func (r *userRepository) index(match func(domain.User) bool) int {
+	for i, user := range r.store.records.users {
+		if match(user) {
+			return i
+		}
+	}
+	return -1
+}
+
+// mergeUser applies the non zero fields of the update to the user, like OmitZero of the SQL repository
+func mergeUser(user, update domain.User) domain.User {
+	if update.Username != "" {
+		user.Username = update.Username
+	}
+	if update.Password != "" {
+		user.Password = update.Password
+	}
+	if update.FullName != nil {
+		user.FullName = update.FullName
+	}
+	if update.Email != nil {
+		user.Email = update.Email
+	}
+	if update.VerifiedEmail != nil {
+		user.VerifiedEmail = update.VerifiedEmail
+	}
+	if update.Phone != nil {
+		user.Phone = update.Phone
+	}
+	if update.RefreshToken != nil {
+		user.RefreshToken = update.RefreshToken
+	}
+	if update.IsActive {
+		user.IsActive = true
+	}
+	if update.MaxSessions != nil {
+		user.MaxSessions = update.MaxSessions
+	}
+	if update.CompromisedAt != nil {
+		user.CompromisedAt = update.CompromisedAt
+	}
+	if !update.CreatedAt.IsZero() {
+		user.CreatedAt = update.CreatedAt
+	}
+	if !update.UpdatedAt.IsZero() {
+		user.UpdatedAt = update.UpdatedAt
+	}
+	return user
+}
@@ -0,0 +1,241 @@
+package memory
+
+import (
+	"go-hex/internal/domain"
+	"go-hex/pkg/metrics"
+	"go-hex/pkg/times"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	userCacheLookups = metrics.NewCounterVec(prometheus.CounterOpts{
+		Name: "user_cache_lookups_total",
+		Help: "Number of lookups of the user cache, by lookup (user or roles) and result (hit or miss).",
+	}, "lookup", "result")
+	userCacheAdmissions = metrics.NewCounterVec(prometheus.CounterOpts{
+		Name: "user_cache_admissions_total",
+		Help: "Number of users offered to the user cache, by result (admitted, rejected by the LFU policy, or stale).",
+	}, "result")
+	userCacheEvictions = metrics.NewCounter(prometheus.CounterOpts{
+		Name: "user_cache_evictions_total",
+		Help: "Number of users evicted from the user cache to admit a more frequent one.",
+	})
+	userCacheEntries = metrics.NewGauge(prometheus.GaugeOpts{
+		Name: "user_cache_entries",
+		Help: "Number of users in the user cache.",
+	})
+)
+
+// Results of the admission of a user to the cache
+const (
+	AdmissionAdmitted = "admitted"
+	AdmissionRejected = "rejected" // the user is less frequent than every cached user
+	AdmissionStale    = "stale"    // the user was written while it was loaded
+)
+
+// UserCache holds the users and the elevations granting their roles for TTL, up to size users.
+// The frequency of every user is counted as it is touched, and a user is admitted to a full cache only when
+// it is more frequent than the least frequent user cached, which is evicted (LFU admission). The counts are
+// halved every 10 times size touches, so that the users not logging in anymore leave room for the new ones.
+type UserCache struct {
+	size int
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu          sync.Mutex
+	entries     map[string]*userCacheEntry
+	usernames   map[string]string // username to user id
+	frequencies map[string]int
+	touches     int
+	version     uint64
+}
+
+type userCacheEntry struct {
+	user       domain.User
+	elevations []domain.Elevation
+	loadedAt   time.Time
+}
+
+// NewUserCache creates an empty cache of size users, each held for ttl
+func NewUserCache(size int, ttl time.Duration) *UserCache {
+	return &UserCache{
+		size:        size,
+		ttl:         ttl,
+		now:         times.Now,
+		entries:     map[string]*userCacheEntry{},
+		usernames:   map[string]string{},
+		frequencies: map[string]int{},
+	}
+}
+
+// Touch counts a use of the user, e.g. a login, and returns whether it is cached and whether its entry
+// is due for a reload, past half its ttl
+func (c *UserCache) Touch(userID string) (cached bool, due bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.frequencies[userID]++
+	c.touches++
+	if c.touches >= 10*c.size {
+		c.touches = 0
+		for id, n := range c.frequencies {
+			if n /= 2; n == 0 {
+				delete(c.frequencies, id)
+			} else {
+				c.frequencies[id] = n
+			}
+		}
+	}
+
+	entry := c.fresh(userID)
+	if entry == nil {
+		return false, true
+	}
+	return true, c.now().Sub(entry.loadedAt) >= c.ttl/2
+}
+
+// Version returns the version of the cache, taken before loading a user to admit it
+func (c *UserCache) Version() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.version
+}
+
+// Admit offers the user, loaded at the given version, with its active elevations to the cache.
+// The user is refused when a user was invalidated since the version, as it may have been loaded before the write.
+func (c *UserCache) Admit(version uint64, user domain.User, elevations []domain.Elevation) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if version != c.version {
+		userCacheAdmissions.WithLabelValues(AdmissionStale).Inc()
+		return AdmissionStale
+	}
+
+	if _, ok := c.entries[user.ID]; !ok && len(c.entries) >= c.size {
+		// the expired entries are evicted first, then the least frequent one if the user is more frequent
+		victim, victimFrequency := "", 0
+		for id, entry := range c.entries {
+			if c.expired(entry) {
+				victim = id
+				break
+			}
+			if n := c.frequencies[id]; victim == "" || n < victimFrequency {
+				victim, victimFrequency = id, n
+			}
+		}
+		if entry := c.entries[victim]; !c.expired(entry) && c.frequencies[user.ID] <= victimFrequency {
+			userCacheAdmissions.WithLabelValues(AdmissionRejected).Inc()
+			return AdmissionRejected
+		}
+		c.remove(victim)
+		userCacheEvictions.Inc()
+	}
+
+	c.remove(user.ID)
+	c.entries[user.ID] = &userCacheEntry{user, elevations, c.now()}
+	c.usernames[user.Username] = user.ID
+	userCacheEntries.Set(float64(len(c.entries)))
+	userCacheAdmissions.WithLabelValues(AdmissionAdmitted).Inc()
+	return AdmissionAdmitted
+}
+
+// Invalidate removes the user from the cache, it is cached again by its next warming
+func (c *UserCache) Invalidate(userID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.version++
+	c.remove(userID)
+	userCacheEntries.Set(float64(len(c.entries)))
+}
+
+// Contains returns whether the user is cached and not expired
+func (c *UserCache) Contains(userID string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.fresh(userID) != nil
+}
+
+// user returns the cached user with the specified ID
+func (c *UserCache) user(userID string) (domain.User, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := c.fresh(userID)
+	userCacheLookups.WithLabelValues("user", hitOrMiss(entry != nil)).Inc()
+	if entry == nil {
+		return domain.User{}, false
+	}
+	return entry.user, true
+}
+
+// userByUsername returns the cached user with the specified username
+func (c *UserCache) userByUsername(username string) (domain.User, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var entry *userCacheEntry
+	if id, ok := c.usernames[username]; ok {
+		entry = c.fresh(id)
+	}
+	userCacheLookups.WithLabelValues("user", hitOrMiss(entry != nil)).Inc()
+	if entry == nil {
+		return domain.User{}, false
+	}
+	return entry.user, true
+}
+
+// activeElevations returns the cached elevations of the user granting their role at the specified time
+func (c *UserCache) activeElevations(userID string, at time.Time) ([]domain.Elevation, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := c.fresh(userID)
+	userCacheLookups.WithLabelValues("roles", hitOrMiss(entry != nil)).Inc()
+	if entry == nil {
+		return nil, false
+	}
+	elevations := []domain.Elevation{}
+	for _, elevation := range entry.elevations {
+		if elevation.IsActiveAt(at) {
+			elevations = append(elevations, elevation)
+		}
+	}
+	return elevations, true
+}
+
+// fresh returns the entry of the user unless it is missing or expired, the caller holds the lock
+func (c *UserCache) fresh(userID string) *userCacheEntry {
+	entry, ok := c.entries[userID]
+	if !ok || c.expired(entry) {
+		return nil
+	}
+	return entry
+}
+
+func (c *UserCache) expired(entry *userCacheEntry) bool {
+	return entry != nil && c.now().Sub(entry.loadedAt) >= c.ttl
+}
+
+// remove removes the entry of the user, the caller holds the lock
+func (c *UserCache) remove(userID string) {
+	entry, ok := c.entries[userID]
+	if !ok {
+		return
+	}
+	if c.usernames[entry.user.Username] == userID {
+		delete(c.usernames, entry.user.Username)
+	}
+	delete(c.entries, userID)
+}
+
+func hitOrMiss(hit bool) string {
+	if hit {
+		return "hit"
+	}
+	return "miss"
+}
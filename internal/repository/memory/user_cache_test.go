@@ -0,0 +1,108 @@
+package memory
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/port"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserCacheAdmission(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	cache := NewUserCache(2, time.Minute)
+	cache.now = func() time.Time { return now }
+	user := func(id string) domain.User { return domain.User{ID: id, Username: id + "@example.com"} }
+
+	for i := 0; i < 3; i++ {
+		cache.Touch("alice")
+	}
+	cache.Touch("bob")
+	cache.Touch("bob")
+	cache.Touch("carol")
+	assert.Equal(t, AdmissionAdmitted, cache.Admit(cache.Version(), user("alice"), nil))
+	assert.Equal(t, AdmissionAdmitted, cache.Admit(cache.Version(), user("bob"), nil))
+
+	// carol logs in less than bob, the least frequent user cached
+	assert.Equal(t, AdmissionRejected, cache.Admit(cache.Version(), user("carol"), nil))
+	cache.Touch("carol")
+	cache.Touch("carol")
+	assert.Equal(t, AdmissionAdmitted, cache.Admit(cache.Version(), user("carol"), nil))
+	assert.False(t, cache.Contains("bob"))
+	assert.True(t, cache.Contains("alice"))
+
+	cached, due := cache.Touch("carol")
+	assert.True(t, cached)
+	assert.False(t, due)
+	now = now.Add(30 * time.Second)
+	_, due = cache.Touch("carol")
+	assert.True(t, due, "the entry is reloaded past half its ttl")
+
+	// an expired entry leaves room whatever its frequency
+	now = now.Add(time.Minute)
+	assert.False(t, cache.Contains("alice"))
+	assert.Equal(t, AdmissionAdmitted, cache.Admit(cache.Version(), user("dave"), nil))
+}
+
+func TestUserCacheInvalidate(t *testing.T) {
+	cache := NewUserCache(10, time.Minute)
+	alice := domain.User{ID: "alice", Username: "alice@example.com"}
+
+	// a user written while it was loaded is not admitted
+	version := cache.Version()
+	cache.Invalidate("alice")
+	assert.Equal(t, AdmissionStale, cache.Admit(version, alice, nil))
+
+	require.Equal(t, AdmissionAdmitted, cache.Admit(cache.Version(), alice, nil))
+	user, ok := cache.userByUsername("alice@example.com")
+	assert.True(t, ok)
+	assert.Equal(t, alice, user)
+
+	cache.Invalidate("alice")
+	_, ok = cache.userByUsername("alice@example.com")
+	assert.False(t, ok)
+	assert.Empty(t, cache.usernames)
+}
+
+func TestRepositoryRegistry(t *testing.T) {
+	ctx := context.Background()
+	ends := time.Now().Add(time.Hour)
+	cache := NewUserCache(10, time.Minute)
+	cache.Admit(cache.Version(), domain.User{ID: "alice", Username: "alice@example.com", IsActive: true},
+		[]domain.Elevation{{UserID: "alice", Role: "operator", Status: domain.ElevationStatusApproved, EndsAt: &ends}})
+	store := NewStore()
+	require.NoError(t, store.GetUserRepository().Create(ctx, domain.User{ID: "alice", Username: "alice@example.com", IsActive: true}))
+	reads := 0
+	store.SetHook(func(ctx context.Context, op string) error {
+		if op == "UserRepository.GetByUsername" {
+			reads++
+		}
+		return nil
+	})
+	registry := NewRepositoryRegistry(store, cache)
+
+	user, err := registry.GetUserRepository().GetByUsername(ctx, "alice@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", user.ID)
+	assert.Equal(t, 0, reads)
+
+	elevations, err := registry.GetElevationRepository().ListActiveByUserID(ctx, "alice", time.Now())
+	require.NoError(t, err)
+	assert.Len(t, elevations, 1)
+	elevations, _ = registry.GetElevationRepository().ListActiveByUserID(ctx, "alice", ends)
+	assert.Empty(t, elevations, "the elevations ended are not answered")
+
+	// the transactions read the data source and their writes invalidate the cache
+	_, err = registry.DoInTransaction(ctx, func(ctx context.Context, repoRegistry port.RepositoryRegistry) (interface{}, error) {
+		if _, err := repoRegistry.GetUserRepository().GetByUsername(ctx, "alice@example.com"); err != nil {
+			return nil, err
+		}
+		return nil, repoRegistry.GetUserRepository().SetActive(ctx, "alice", false)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, reads)
+	assert.False(t, cache.Contains("alice"))
+}
@@ -0,0 +1,58 @@
+package memory
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/shared/ierr"
+	"time"
+)
+
+type userIdentityRepository struct {
+	store *Store
+}
+
+func (r *userIdentityRepository) Get(ctx context.Context, provider string, subject string) (domain.UserIdentity, error) {
+	unlock, err := r.store.begin(ctx, "UserIdentityRepository.Get")
+	if err != nil {
+		return domain.UserIdentity{}, err
+	}
+	defer unlock()
+
+	for _, identity := range r.store.records.userIdentities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return identity, nil
+		}
+	}
+	return domain.UserIdentity{}, ierr.ErrResourceNotFound
+}
+
+func (r *userIdentityRepository) Create(ctx context.Context, identity domain.UserIdentity) error {
+	unlock, err := r.store.begin(ctx, "UserIdentityRepository.Create")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for _, existing := range r.store.records.userIdentities {
+		if existing.Provider == identity.Provider && existing.Subject == identity.Subject {
+			return ierr.ErrConflict
+		}
+	}
+	r.store.records.userIdentities = append(r.store.records.userIdentities, identity)
+	return nil
+}
+
+func (r *userIdentityRepository) Touch(ctx context.Context, provider string, subject string, at time.Time) error {
+	unlock, err := r.store.begin(ctx, "UserIdentityRepository.Touch")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for i, identity := range r.store.records.userIdentities {
+		if identity.Provider == provider && identity.Subject == subject {
+			r.store.records.userIdentities[i].LastLoginAt = at
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package memory
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/shared/ierr"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+type userSyncRepository struct {
+	store *Store
+}
+
+func (r *userSyncRepository) ListLinks(ctx context.Context, source string) ([]domain.UserSyncLink, error) {
+	unlock, err := r.store.begin(ctx, "UserSyncRepository.ListLinks")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	links := []domain.UserSyncLink{}
+	for _, link := range r.store.records.userSyncLinks {
+		if link.Source == source {
+			links = append(links, link)
+		}
+	}
+	return links, nil
+}
+
+func (r *userSyncRepository) SaveLink(ctx context.Context, link domain.UserSyncLink) error {
+	unlock, err := r.store.begin(ctx, "UserSyncRepository.SaveLink")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for i, existing := range r.store.records.userSyncLinks {
+		if existing.Source == link.Source && existing.ExternalID == link.ExternalID {
+			r.store.records.userSyncLinks[i] = link
+			return nil
+		}
+	}
+	r.store.records.userSyncLinks = append(r.store.records.userSyncLinks, link)
+	return nil
+}
+
+func (r *userSyncRepository) CreateRun(ctx context.Context, run domain.UserSyncRun) error {
+	unlock, err := r.store.begin(ctx, "UserSyncRepository.CreateRun")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for _, existing := range r.store.records.userSyncRuns {
+		if existing.ID == run.ID {
+			return errors.Wrap(ierr.ErrConflict, "cannot create user sync run")
+		}
+	}
+	run.ID = r.store.nextID(run.ID)
+	r.store.records.userSyncRuns = append(r.store.records.userSyncRuns, run)
+	return nil
+}
+
+func (r *userSyncRepository) GetRun(ctx context.Context, id string) (domain.UserSyncRun, error) {
+	unlock, err := r.store.begin(ctx, "UserSyncRepository.GetRun")
+	if err != nil {
+		return domain.UserSyncRun{}, err
+	}
+	defer unlock()
+
+	for _, run := range r.store.records.userSyncRuns {
+		if run.ID == id {
+			return run, nil
+		}
+	}
+	return domain.UserSyncRun{}, ierr.ErrResourceNotFound
+}
+
+func (r *userSyncRepository) ListRuns(ctx context.Context, source string, limit int) ([]domain.UserSyncRun, error) {
+	unlock, err := r.store.begin(ctx, "UserSyncRepository.ListRuns")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	runs := []domain.UserSyncRun{}
+	for _, run := range r.store.records.userSyncRuns {
+		if source == "" || run.Source == source {
+			run.Changes = nil
+			runs = append(runs, run)
+		}
+	}
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].StartedAt.After(runs[j].StartedAt) })
+	from, to := bounds(len(runs), limit, 0)
+	return runs[from:to], nil
+}
@@ -0,0 +1,68 @@
+// Package mongo stores the users in a MongoDB collection.
+//
+// Each user is a document keyed by its ID:
+//
+//	{ _id, username, password, full_name, email, verified_email, phone, refresh_token, is_active, max_sessions, compromised_at, created_at, updated_at }
+//
+// The usernames are unique through the unique index of the collection, created by EnsureIndexes with the index
+// of the email addresses. The nullable fields of the users are left out of their documents when nil.
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+const (
+	usersCollection = "users"
+
+	indexUsername = "username_unique"
+	indexEmail    = "email"
+
+	// connectTimeout bounds the connection to the deployment
+	connectTimeout = 10 * time.Second
+)
+
+// NewClient connects to the MongoDB deployment of the uri, e.g. mongodb://localhost:27017
+func NewClient(ctx context.Context, uri string) (*mongo.Client, error) {
+	ctx, cancel := context.WithTimeout(ctx, connectTimeout)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot connect to mongodb")
+	}
+	return client, nil
+}
+
+// Users returns the collection of the users in the database
+func Users(client *mongo.Client, database string) *mongo.Collection {
+	return client.Database(database).Collection(usersCollection)
+}
+
+// EnsureIndexes creates the indexes of the users which do not exist yet: the unique index of the usernames and
+// the index of the email addresses, looked up by the social logins
+func EnsureIndexes(ctx context.Context, users *mongo.Collection) error {
+	_, err := users.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: fieldUsername, Value: 1}}, Options: options.Index().SetName(indexUsername).SetUnique(true)},
+		{Keys: bson.D{{Key: fieldEmail, Value: 1}}, Options: options.Index().SetName(indexEmail)},
+	})
+	if err != nil {
+		return errors.Wrap(err, "cannot create user indexes")
+	}
+	return nil
+}
+
+// Ping checks that the primary of the deployment answers
+func Ping(ctx context.Context, client *mongo.Client) error {
+	if err := client.Ping(ctx, readpref.Primary()); err != nil {
+		return errors.Wrap(err, "cannot ping mongodb")
+	}
+	return nil
+}
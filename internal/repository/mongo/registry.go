@@ -0,0 +1,34 @@
+package mongo
+
+import (
+	"context"
+	"go-hex/internal/repository/port"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// RepositoryRegistry serves the users from MongoDB and the other repositories from sql.
+// The MongoDB writes are not part of the SQL transactions: they are applied immediately and are not rolled back.
+type RepositoryRegistry struct {
+	port.RepositoryRegistry
+	users   *mongo.Collection
+	timeout time.Duration
+}
+
+// NewRepositoryRegistry creates a registry storing the users in the given collection
+func NewRepositoryRegistry(sql port.RepositoryRegistry, users *mongo.Collection, timeout time.Duration) port.RepositoryRegistry {
+	return &RepositoryRegistry{sql, users, timeout}
+}
+
+// DoInTransaction runs txFunc in a transaction of the sql registry, the users used by txFunc keep being served
+// from MongoDB.
+func (r *RepositoryRegistry) DoInTransaction(ctx context.Context, txFunc port.InTransaction) (out interface{}, err error) {
+	return r.RepositoryRegistry.DoInTransaction(ctx, func(ctx context.Context, repoRegistry port.RepositoryRegistry) (interface{}, error) {
+		return txFunc(ctx, &RepositoryRegistry{repoRegistry, r.users, r.timeout})
+	})
+}
+
+func (r *RepositoryRegistry) GetUserRepository() port.UserRepository {
+	return NewUserRepository(r.users, r.timeout)
+}
@@ -0,0 +1,375 @@
+package mongo
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/times"
+	"go-hex/shared/ierr"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	fieldID           = "_id"
+	fieldUsername     = "username"
+	fieldPassword     = "password"
+	fieldFullName     = "full_name"
+	fieldEmail        = "email"
+	fieldVerified     = "verified_email"
+	fieldPhone        = "phone"
+	fieldRefreshToken = "refresh_token"
+	fieldIsActive     = "is_active"
+	fieldMaxSessions  = "max_sessions"
+	fieldCompromised  = "compromised_at"
+	fieldCreatedAt    = "created_at"
+	fieldUpdatedAt    = "updated_at"
+)
+
+// userDocument is the document of a user in the collection
+type userDocument struct {
+	ID            string     `bson:"_id"`
+	Username      string     `bson:"username"`
+	Password      string     `bson:"password"`
+	FullName      *string    `bson:"full_name,omitempty"`
+	Email         *string    `bson:"email,omitempty"`
+	VerifiedEmail *string    `bson:"verified_email,omitempty"`
+	Phone         *string    `bson:"phone,omitempty"`
+	RefreshToken  *string    `bson:"refresh_token,omitempty"`
+	IsActive      bool       `bson:"is_active"`
+	MaxSessions   *int       `bson:"max_sessions,omitempty"`
+	CompromisedAt *time.Time `bson:"compromised_at,omitempty"`
+	CreatedAt     time.Time  `bson:"created_at"`
+	UpdatedAt     time.Time  `bson:"updated_at"`
+}
+
+func newUserDocument(user domain.User) userDocument {
+	return userDocument{
+		ID:            user.ID,
+		Username:      user.Username,
+		Password:      user.Password,
+		FullName:      user.FullName,
+		Email:         user.Email,
+		VerifiedEmail: user.VerifiedEmail,
+		Phone:         user.Phone,
+		RefreshToken:  user.RefreshToken,
+		IsActive:      user.IsActive,
+		MaxSessions:   user.MaxSessions,
+		CompromisedAt: user.CompromisedAt,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+	}
+}
+
+// user returns the user of the document, MongoDB keeping the times in milliseconds in UTC
+func (d userDocument) user() domain.User {
+	return domain.User{
+		ID:            d.ID,
+		Username:      d.Username,
+		Password:      d.Password,
+		FullName:      d.FullName,
+		Email:         d.Email,
+		VerifiedEmail: d.VerifiedEmail,
+		Phone:         d.Phone,
+		RefreshToken:  d.RefreshToken,
+		IsActive:      d.IsActive,
+		MaxSessions:   d.MaxSessions,
+		CompromisedAt: d.CompromisedAt,
+		CreatedAt:     d.CreatedAt,
+		UpdatedAt:     d.UpdatedAt,
+	}
+}
+
+// UserRepository encapsulates the logic to access users from a MongoDB collection.
+type UserRepository struct {
+	users   *mongo.Collection
+	timeout time.Duration
+}
+
+// NewUserRepository creates a new user repository, each operation bounded by the timeout when positive
+func NewUserRepository(users *mongo.Collection, timeout time.Duration) *UserRepository {
+	return &UserRepository{users, timeout}
+}
+
+// GetByID returns the user with the specified user ID.
+func (r *UserRepository) GetByID(ctx context.Context, userID string) (domain.User, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	return r.findOne(ctx, bson.D{{Key: fieldID, Value: userID}})
+}
+
+// GetByUsername returns the user with the specified username.
+func (r *UserRepository) GetByUsername(ctx context.Context, username string) (domain.User, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	return r.findOne(ctx, bson.D{{Key: fieldUsername, Value: username}})
+}
+
+// ListByIDs returns the users with the specified IDs, the IDs without user are skipped.
+func (r *UserRepository) ListByIDs(ctx context.Context, userIDs []string) ([]domain.User, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	if len(userIDs) == 0 {
+		return []domain.User{}, nil
+	}
+	return r.find(ctx, bson.D{{Key: fieldID, Value: bson.D{{Key: "$in", Value: userIDs}}}}, options.Find())
+}
+
+// ListByEmail returns the users with the specified email address.
+func (r *UserRepository) ListByEmail(ctx context.Context, email string) ([]domain.User, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	return r.find(ctx, bson.D{{Key: fieldEmail, Value: email}}, options.Find())
+}
+
+// List returns a page of users ordered by ID, starting after the user with the specified ID when set.
+func (r *UserRepository) List(ctx context.Context, afterID string, limit int) ([]domain.User, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	filter := bson.D{}
+	if afterID != "" {
+		filter = bson.D{{Key: fieldID, Value: bson.D{{Key: "$gt", Value: afterID}}}}
+	}
+	return r.find(ctx, filter, options.Find().SetSort(bson.D{{Key: fieldID, Value: 1}}).SetLimit(int64(limit)))
+}
+
+// IsUserExistByID checks wether user exists
+func (r *UserRepository) IsUserExistByID(ctx context.Context, userID string) (bool, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	return r.exists(ctx, bson.D{{Key: fieldID, Value: userID}})
+}
+
+// IsUserExistByUsername checks whether user exists by username
+func (r *UserRepository) IsUserExistByUsername(ctx context.Context, username string) (bool, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	return r.exists(ctx, bson.D{{Key: fieldUsername, Value: username}})
+}
+
+// Create saves a new user in the storage.
+// It returns ierr.ErrUserAlreadyRegistered when the username is taken, which the unique index guarantees.
+func (r *UserRepository) Create(ctx context.Context, user domain.User) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	_, err := r.users.InsertOne(ctx, newUserDocument(user))
+	if err != nil {
+		if isDuplicateKey(err, indexUsername) {
+			return ierr.ErrUserAlreadyRegistered
+		}
+		if mongo.IsDuplicateKeyError(err) {
+			return errors.Wrap(ierr.ErrConflict, "user already exists")
+		}
+		return errors.Wrap(err, "cannot create user")
+	}
+	return nil
+}
+
+// Update updates the user with given ID in the storage.
+// Like the SQL repository only the non zero fields are written, and an unknown user updates nothing.
+func (r *UserRepository) Update(ctx context.Context, userID string, user domain.User) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	set := nonZeroFields(user)
+	if len(set) == 0 {
+		return nil
+	}
+	_, err := r.users.UpdateOne(ctx, bson.D{{Key: fieldID, Value: userID}}, bson.D{{Key: "$set", Value: set}})
+	if err != nil {
+		if isDuplicateKey(err, indexUsername) {
+			return ierr.ErrUserAlreadyRegistered
+		}
+		return errors.Wrap(err, "cannot update user")
+	}
+	return nil
+}
+
+// SetActive activates or deactivates the user with given ID, Update cannot deactivate a user as it skips the zero fields.
+func (r *UserRepository) SetActive(ctx context.Context, userID string, active bool) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	_, err := r.users.UpdateOne(ctx, bson.D{{Key: fieldID, Value: userID}}, bson.D{
+		{Key: "$set", Value: bson.D{{Key: fieldIsActive, Value: active}, {Key: fieldUpdatedAt, Value: times.Now()}}},
+	})
+	if err != nil {
+		return errors.Wrap(err, "cannot set user active")
+	}
+	return nil
+}
+
+// ClearRefreshToken clears the refresh token of the user if it still equals the given hashed token.
+// It returns false when the token has already been cleared or replaced.
+func (r *UserRepository) ClearRefreshToken(ctx context.Context, userID string, hashedToken string) (bool, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res, err := r.users.UpdateOne(ctx, bson.D{{Key: fieldID, Value: userID}, {Key: fieldRefreshToken, Value: hashedToken}}, bson.D{
+		{Key: "$unset", Value: bson.D{{Key: fieldRefreshToken, Value: ""}}},
+		{Key: "$set", Value: bson.D{{Key: fieldUpdatedAt, Value: times.Now()}}},
+	})
+	if err != nil {
+		return false, errors.Wrap(err, "cannot clear refresh token")
+	}
+	return res.MatchedCount == 1, nil
+}
+
+// ReplacePassword replaces the hashed password of the user if it still equals the given hashed password.
+// It returns false when the password has been changed meanwhile.
+func (r *UserRepository) ReplacePassword(ctx context.Context, userID string, hashedPwd string, newHashedPwd string) (bool, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	res, err := r.users.UpdateOne(ctx, bson.D{{Key: fieldID, Value: userID}, {Key: fieldPassword, Value: hashedPwd}}, bson.D{
+		{Key: "$set", Value: bson.D{{Key: fieldPassword, Value: newHashedPwd}, {Key: fieldUpdatedAt, Value: times.Now()}}},
+	})
+	if err != nil {
+		return false, errors.Wrap(err, "cannot replace password")
+	}
+	return res.MatchedCount == 1, nil
+}
+
+// withTimeout bounds an operation by the timeout of the repository, within the deadline of the context
+func (r *UserRepository) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, r.timeout)
+}
+
+func (r *UserRepository) findOne(ctx context.Context, filter bson.D) (domain.User, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var doc userDocument
+	err := r.users.FindOne(ctx, filter).Decode(&doc)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return domain.User{}, ierr.ErrResourceNotFound
+		}
+		return domain.User{}, errors.Wrap(err, "cannot get user")
+	}
+	return doc.user(), nil
+}
+
+func (r *UserRepository) find(ctx context.Context, filter bson.D, opts *options.FindOptions) ([]domain.User, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	cursor, err := r.users.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list users")
+	}
+	var docs []userDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, errors.Wrap(err, "cannot list users")
+	}
+
+	users := make([]domain.User, 0, len(docs))
+	for _, doc := range docs {
+		users = append(users, doc.user())
+	}
+	return users, nil
+}
+
+func (r *UserRepository) exists(ctx context.Context, filter bson.D) (bool, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	count, err := r.users.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, errors.Wrap(err, "cannot check user")
+	}
+	return count > 0, nil
+}
+
+// nonZeroFields returns the fields of the non zero values of the user, like OmitZero of the SQL repository
+func nonZeroFields(user domain.User) bson.D {
+	var set bson.D
+	if user.Username != "" {
+		set = append(set, bson.E{Key: fieldUsername, Value: user.Username})
+	}
+	if user.Password != "" {
+		set = append(set, bson.E{Key: fieldPassword, Value: user.Password})
+	}
+	if user.FullName != nil {
+		set = append(set, bson.E{Key: fieldFullName, Value: *user.FullName})
+	}
+	if user.Email != nil {
+		set = append(set, bson.E{Key: fieldEmail, Value: *user.Email})
+	}
+	if user.VerifiedEmail != nil {
+		set = append(set, bson.E{Key: fieldVerified, Value: *user.VerifiedEmail})
+	}
+	if user.Phone != nil {
+		set = append(set, bson.E{Key: fieldPhone, Value: *user.Phone})
+	}
+	if user.RefreshToken != nil {
+		set = append(set, bson.E{Key: fieldRefreshToken, Value: *user.RefreshToken})
+	}
+	if user.IsActive {
+		set = append(set, bson.E{Key: fieldIsActive, Value: true})
+	}
+	if user.MaxSessions != nil {
+		set = append(set, bson.E{Key: fieldMaxSessions, Value: *user.MaxSessions})
+	}
+	if user.CompromisedAt != nil {
+		set = append(set, bson.E{Key: fieldCompromised, Value: *user.CompromisedAt})
+	}
+	if !user.CreatedAt.IsZero() {
+		set = append(set, bson.E{Key: fieldCreatedAt, Value: user.CreatedAt})
+	}
+	if !user.UpdatedAt.IsZero() {
+		set = append(set, bson.E{Key: fieldUpdatedAt, Value: user.UpdatedAt})
+	}
+	return set
+}
+
+// isDuplicateKey checks whether the write was refused by the unique index
+func isDuplicateKey(err error, index string) bool {
+	var writeErr mongo.WriteException
+	if errors.As(err, &writeErr) {
+		for _, e := range writeErr.WriteErrors {
+			if e.Code == 11000 && strings.Contains(e.Message, index) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package mongo
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/shared/ierr"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestUserDocument(t *testing.T) {
+	email, maxSessions := "jane@example.com", 3
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	user := domain.User{ID: "u1", Username: "jane", Password: "hash", Email: &email, IsActive: true, MaxSessions: &maxSessions, CreatedAt: now, UpdatedAt: now}
+
+	b, err := bson.Marshal(newUserDocument(user))
+	require.NoError(t, err)
+	var raw bson.M
+	require.NoError(t, bson.Unmarshal(b, &raw))
+	assert.Equal(t, "u1", raw["_id"])
+	assert.NotContains(t, raw, "full_name", "the nil fields are left out")
+	assert.NotContains(t, raw, "refresh_token")
+
+	var doc userDocument
+	require.NoError(t, bson.Unmarshal(b, &doc))
+	assert.Equal(t, user, doc.user())
+}
+
+func TestNonZeroFields(t *testing.T) {
+	name := "Jane Doe"
+	assert.Empty(t, nonZeroFields(domain.User{}))
+	assert.Equal(t, bson.D{{Key: fieldPassword, Value: "hash"}, {Key: fieldFullName, Value: name}},
+		nonZeroFields(domain.User{Password: "hash", FullName: &name}))
+}
+
+func TestUserRepository(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+	ctx := context.Background()
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+
+	mt.Run("get by username", func(mt *mtest.T) {
+		repo := NewUserRepository(mt.Coll, time.Second)
+		mt.AddMockResponses(mtest.CreateCursorResponse(1, "db.users", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: "u1"}, {Key: "username", Value: "jane"}, {Key: "is_active", Value: true}, {Key: "created_at", Value: now},
+		}))
+		user, err := repo.GetByUsername(ctx, "jane")
+		require.NoError(t, err)
+		assert.Equal(t, domain.User{ID: "u1", Username: "jane", IsActive: true, CreatedAt: now}, user)
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.users", mtest.FirstBatch))
+		_, err = repo.GetByUsername(ctx, "john")
+		assert.Equal(t, ierr.ErrResourceNotFound, err)
+	})
+
+	mt.Run("create with a taken username", func(mt *mtest.T) {
+		repo := NewUserRepository(mt.Coll, time.Second)
+		mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{
+			Code: 11000, Message: "E11000 duplicate key error collection: db.users index: username_unique dup key: { username: \"jane\" }",
+		}))
+		assert.Equal(t, ierr.ErrUserAlreadyRegistered, repo.Create(ctx, domain.User{ID: "u2", Username: "jane"}))
+
+		mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{
+			Code: 11000, Message: "E11000 duplicate key error collection: db.users index: _id_ dup key: { _id: \"u1\" }",
+		}))
+		assert.Equal(t, ierr.ErrConflict, errors.Cause(repo.Create(ctx, domain.User{ID: "u1", Username: "john"})))
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		assert.NoError(t, repo.Create(ctx, domain.User{ID: "u3", Username: "john"}))
+	})
+
+	mt.Run("compare and swap", func(mt *mtest.T) {
+		repo := NewUserRepository(mt.Coll, time.Second)
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 0}, {Key: "nModified", Value: 0}})
+		cleared, err := repo.ClearRefreshToken(ctx, "u1", "token-hash")
+		require.NoError(t, err)
+		assert.False(t, cleared, "the token was replaced")
+
+		mt.ClearEvents()
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}})
+		replaced, err := repo.ReplacePassword(ctx, "u1", "old-hash", "new-hash")
+		require.NoError(t, err)
+		assert.True(t, replaced)
+
+		update := mt.GetStartedEvent().Command.Lookup("updates").Array().Index(0).Value().Document()
+		assert.Equal(t, "old-hash", update.Lookup("q", "password").StringValue(), "the password is only replaced when unchanged")
+		assert.Equal(t, "new-hash", update.Lookup("u", "$set", "password").StringValue())
+	})
+
+	mt.Run("timeout", func(mt *mtest.T) {
+		repo := NewUserRepository(mt.Coll, time.Nanosecond)
+		_, err := repo.GetByID(ctx, "u1")
+		assert.True(t, mongo.IsTimeout(errors.Cause(err)) || errors.Is(err, context.DeadlineExceeded), err)
+	})
+}
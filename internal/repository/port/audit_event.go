@@ -0,0 +1,18 @@
+package port
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"time"
+)
+
+// AuditRepository stores the audit trail of the users.
+type AuditRepository interface {
+	// Record saves the audit events in a single statement.
+	Record(ctx context.Context, auditEvents []domain.AuditEvent) error
+	// List returns the audit events created in [from, to) by the actor and of the action, any when empty,
+	// the newest first, skipping the offset newest ones.
+	List(ctx context.Context, actorID string, action string, from time.Time, to time.Time, limit int, offset int) ([]domain.AuditEvent, error)
+	// DeleteExpired deletes the audit events created before the specified time, except the ones whose actor or subject is a held user.
+	DeleteExpired(ctx context.Context, before time.Time, heldUserIDs []string) (int64, error)
+}
@@ -0,0 +1,23 @@
+package port
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"time"
+)
+
+// BreakGlassAccountRepository encapsulates the logic to access break-glass accounts from the data source.
+type BreakGlassAccountRepository interface {
+	// Seal saves a sealed break-glass account, replacing the previous seal and activation of the user.
+	Seal(ctx context.Context, account domain.BreakGlassAccount) error
+	// GetByUserID returns the break-glass account of the specified user.
+	GetByUserID(ctx context.Context, userID string) (domain.BreakGlassAccount, error)
+	// Activate records the activation of a sealed break-glass account.
+	// It returns false when the account is not sealed anymore.
+	Activate(ctx context.Context, account domain.BreakGlassAccount) (bool, error)
+	// Revoke ends the activation of an active break-glass account.
+	// It returns false when the account is not active anymore.
+	Revoke(ctx context.Context, userID string, at time.Time) (bool, error)
+	// ListExpired returns the active break-glass accounts whose activation expired before the specified time.
+	ListExpired(ctx context.Context, before time.Time) ([]domain.BreakGlassAccount, error)
+}
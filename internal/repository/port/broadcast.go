@@ -0,0 +1,24 @@
+package port
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"time"
+)
+
+// BroadcastRepository encapsulates the logic to access the broadcasts and their deliveries from the data source.
+type BroadcastRepository interface {
+	// Create saves a new broadcast in the storage.
+	Create(ctx context.Context, broadcast domain.Broadcast) error
+	// GetByID returns the broadcast with the specified ID.
+	GetByID(ctx context.Context, id string) (domain.Broadcast, error)
+	// ListActive returns the broadcasts not expired at the specified time, from the oldest.
+	ListActive(ctx context.Context, now time.Time) ([]domain.Broadcast, error)
+	// RecordDelivery records the delivery of a broadcast to a session.
+	// It returns false when the broadcast was already delivered to the session.
+	RecordDelivery(ctx context.Context, delivery domain.BroadcastDelivery) (bool, error)
+	// CountDeliveries returns the number of sessions the broadcast was delivered to.
+	CountDeliveries(ctx context.Context, broadcastID string) (int, error)
+	// ListDeliveries returns a page of the deliveries of the broadcast, the newest first.
+	ListDeliveries(ctx context.Context, broadcastID string, limit int, offset int) ([]domain.BroadcastDelivery, error)
+}
@@ -0,0 +1,17 @@
+package port
+
+import "context"
+
+type bypassCacheKey struct{}
+
+// BypassCache returns a context whose reads are served by the data source instead of the caches of the registry,
+// for the reads which must see the writes of the other instances, such as before a security decision
+func BypassCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassCacheKey{}, true)
+}
+
+// CacheBypassed checks whether the reads of the context bypass the caches, see BypassCache
+func CacheBypassed(ctx context.Context) bool {
+	bypassed, _ := ctx.Value(bypassCacheKey{}).(bool)
+	return bypassed
+}
@@ -0,0 +1,15 @@
+package port
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"time"
+)
+
+// DeploymentStatsRepository stores the per minute stats of the versions of the api analyzed by the rollouts
+type DeploymentStatsRepository interface {
+	// Add adds the counts of the stats to the ones stored for their version and minute
+	Add(ctx context.Context, stats []domain.DeploymentStats) error
+	// List returns the stats of the version whose minute is between from and to included
+	List(ctx context.Context, version string, from, to time.Time) ([]domain.DeploymentStats, error)
+}
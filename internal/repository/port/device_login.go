@@ -0,0 +1,27 @@
+package port
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"time"
+)
+
+// DeviceLoginRepository encapsulates the logic to access device logins from the data source.
+type DeviceLoginRepository interface {
+	// Create saves a new device login in the storage.
+	Create(ctx context.Context, deviceLogin domain.DeviceLogin) error
+	// GetByDeviceCode returns the device login with the specified hashed device code.
+	GetByDeviceCode(ctx context.Context, hashedDeviceCode string) (domain.DeviceLogin, error)
+	// GetByUserCode returns the device login with the specified user code.
+	GetByUserCode(ctx context.Context, userCode string) (domain.DeviceLogin, error)
+	// Decide approves or denies a pending device login on behalf of the user.
+	// It returns false when the device login is not pending anymore.
+	Decide(ctx context.Context, deviceLoginID string, userID string, status string) (bool, error)
+	// UpdateStatus moves the device login from one status to another.
+	// It returns false when the device login is not in the expected status anymore.
+	UpdateStatus(ctx context.Context, deviceLoginID string, from string, to string) (bool, error)
+	// UpdatePolledAt records the last time the device polled the device login.
+	UpdatePolledAt(ctx context.Context, deviceLoginID string, polledAt time.Time) error
+	// DeleteExpired deletes the device logins expired before the specified time, except the ones of the held users.
+	DeleteExpired(ctx context.Context, before time.Time, heldUserIDs []string) (int64, error)
+}
@@ -0,0 +1,24 @@
+package port
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"time"
+)
+
+// ElevationRepository encapsulates the logic to access elevations from the data source.
+type ElevationRepository interface {
+	// Create saves a new elevation in the storage.
+	Create(ctx context.Context, elevation domain.Elevation) error
+	// GetByID returns the elevation with the specified ID.
+	GetByID(ctx context.Context, elevationID string) (domain.Elevation, error)
+	// ListByUserID returns the elevations requested by the specified user, the newest first.
+	ListByUserID(ctx context.Context, userID string) ([]domain.Elevation, error)
+	// ListPending returns the pending and unexpired elevations, the oldest first.
+	ListPending(ctx context.Context) ([]domain.Elevation, error)
+	// ListActiveByUserID returns the approved elevations of the specified user granting their role at the specified time.
+	ListActiveByUserID(ctx context.Context, userID string, at time.Time) ([]domain.Elevation, error)
+	// Decide records the decision of a pending and unexpired elevation.
+	// It returns false when the elevation is not pending anymore or expired.
+	Decide(ctx context.Context, elevation domain.Elevation) (bool, error)
+}
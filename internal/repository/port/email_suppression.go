@@ -0,0 +1,19 @@
+package port
+
+import (
+	"context"
+	"go-hex/internal/domain"
+)
+
+// EmailSuppressionRepository encapsulates the logic to access the email suppressions from the data source.
+type EmailSuppressionRepository interface {
+	// Record saves the suppression of an address, or counts one more feedback and replaces
+	// the reason, the provider and the detail of the suppression when the address is already suppressed.
+	Record(ctx context.Context, suppression domain.EmailSuppression) error
+	// GetByAddress returns the suppression of the address.
+	GetByAddress(ctx context.Context, address string) (domain.EmailSuppression, error)
+	// List returns the suppressions, the most recently updated first.
+	List(ctx context.Context, limit int, offset int) ([]domain.EmailSuppression, error)
+	// Delete deletes the suppression of the address.
+	Delete(ctx context.Context, address string) error
+}
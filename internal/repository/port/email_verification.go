@@ -0,0 +1,18 @@
+package port
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"time"
+)
+
+// EmailVerificationRepository encapsulates the logic to access the email verification tokens from the data source.
+type EmailVerificationRepository interface {
+	// Create saves a new email verification token.
+	Create(ctx context.Context, verification domain.EmailVerification) error
+	// GetByTokenHash returns the email verification with the specified token hash.
+	GetByTokenHash(ctx context.Context, tokenHash string) (domain.EmailVerification, error)
+	// Verify marks the email verification as verified if it is still usable at the specified time.
+	// It returns false when it was already verified or expired.
+	Verify(ctx context.Context, verificationID string, at time.Time) (bool, error)
+}
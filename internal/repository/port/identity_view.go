@@ -0,0 +1,16 @@
+package port
+
+import (
+	"context"
+	"go-hex/internal/domain"
+)
+
+// IdentityViewRepository stores the identity views of the users read to issue their tokens.
+type IdentityViewRepository interface {
+	// Get returns the view of the specified user, ierr.ErrResourceNotFound when it is not stored.
+	Get(ctx context.Context, userID string) (domain.IdentityView, error)
+	// Put stores the view of its user, replacing the previous one.
+	Put(ctx context.Context, view domain.IdentityView) error
+	// Invalidate removes the view of the specified user, it is built again by its next read.
+	Invalidate(ctx context.Context, userID string) error
+}
@@ -0,0 +1,22 @@
+package port
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"time"
+)
+
+// LegalHoldRepository encapsulates the logic to access the legal holds from the data source.
+type LegalHoldRepository interface {
+	// Create saves a new legal hold in the storage.
+	Create(ctx context.Context, hold domain.LegalHold) error
+	// GetByID returns the legal hold with the specified ID.
+	GetByID(ctx context.Context, id string) (domain.LegalHold, error)
+	// ListByUserID returns the legal holds of a user, released or not, the newest first.
+	ListByUserID(ctx context.Context, userID string) ([]domain.LegalHold, error)
+	// ListHeldUserIDs returns the ids of the users with a legal hold not released yet.
+	ListHeldUserIDs(ctx context.Context) ([]string, error)
+	// Release releases the legal hold on behalf of an admin.
+	// It returns false when the legal hold was already released.
+	Release(ctx context.Context, id string, releasedBy string, reason string, releasedAt time.Time) (bool, error)
+}
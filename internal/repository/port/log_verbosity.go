@@ -0,0 +1,19 @@
+package port
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"time"
+)
+
+// LogVerbosityRepository encapsulates the logic to access the log verbosities from the data source.
+type LogVerbosityRepository interface {
+	// Create saves a new log verbosity in the storage.
+	Create(ctx context.Context, verbosity domain.LogVerbosity) error
+	// ListActive returns the log verbosities not expired at the specified time, from the oldest.
+	ListActive(ctx context.Context, now time.Time) ([]domain.LogVerbosity, error)
+	// Delete deletes the log verbosity with the specified id.
+	Delete(ctx context.Context, id string) error
+	// DeleteExpired deletes the log verbosities expired before the specified time.
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
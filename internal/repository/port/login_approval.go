@@ -0,0 +1,22 @@
+package port
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"time"
+)
+
+// LoginApprovalRepository encapsulates the logic to access login approvals from the data source.
+type LoginApprovalRepository interface {
+	// Create saves a new login approval in the storage.
+	Create(ctx context.Context, approval domain.LoginApproval) error
+	// GetByID returns the login approval with the specified ID.
+	GetByID(ctx context.Context, approvalID string) (domain.LoginApproval, error)
+	// ListPendingByUserID returns the pending and unexpired login approvals of the specified user.
+	ListPendingByUserID(ctx context.Context, userID string) ([]domain.LoginApproval, error)
+	// UpdateStatus moves the login approval from one status to another.
+	// It returns false when the approval is not in the expected status anymore.
+	UpdateStatus(ctx context.Context, approvalID string, from string, to string) (bool, error)
+	// DeleteExpired deletes the login approvals expired before the specified time, except the ones of the held users.
+	DeleteExpired(ctx context.Context, before time.Time, heldUserIDs []string) (int64, error)
+}
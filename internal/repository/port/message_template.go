@@ -0,0 +1,17 @@
+package port
+
+import (
+	"context"
+	"go-hex/internal/domain"
+)
+
+// MessageTemplateRepository encapsulates the logic to access the message template overrides from the data source.
+type MessageTemplateRepository interface {
+	// Create saves a new version of a message template in the storage,
+	// it returns ierr.ErrConflict when the version already exists.
+	Create(ctx context.Context, tmpl domain.MessageTemplate) error
+	// GetLatest returns the latest version of the message template of a channel.
+	GetLatest(ctx context.Context, name string, channel string) (domain.MessageTemplate, error)
+	// ListVersions returns the versions of the message template of a channel, the latest first.
+	ListVersions(ctx context.Context, name string, channel string) ([]domain.MessageTemplate, error)
+}
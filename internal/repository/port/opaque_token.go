@@ -0,0 +1,18 @@
+package port
+
+import (
+	"context"
+	"time"
+)
+
+// OpaqueTokenRepository stores the signed access tokens referenced by the opaque access tokens handed to the clients,
+// by the digest of the opaque tokens so that the store does not hold usable tokens.
+type OpaqueTokenRepository interface {
+	// Put stores the access token referenced by the opaque token of the specified digest until it expires.
+	Put(ctx context.Context, digest string, accessToken string, expiresAt time.Time) error
+	// Get returns the access token referenced by the opaque token of the specified digest.
+	// It returns ierr.ErrResourceNotFound when the opaque token is unknown, expired or deleted.
+	Get(ctx context.Context, digest string) (string, error)
+	// Delete removes the opaque token of the specified digest, it stops working at once.
+	Delete(ctx context.Context, digest string) error
+}
@@ -0,0 +1,20 @@
+package port
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"time"
+)
+
+// PasswordResetRepository encapsulates the logic to access the password reset tokens from the data source.
+type PasswordResetRepository interface {
+	// Create saves a new password reset token.
+	Create(ctx context.Context, reset domain.PasswordReset) error
+	// GetByTokenHash returns the password reset with the specified token hash.
+	GetByTokenHash(ctx context.Context, tokenHash string) (domain.PasswordReset, error)
+	// Use marks the password reset as used if it is still usable at the specified time.
+	// It returns false when it was already used or expired.
+	Use(ctx context.Context, resetID string, at time.Time) (bool, error)
+	// InvalidateByUserID marks the unused password resets of the specified user as used.
+	InvalidateByUserID(ctx context.Context, userID string, at time.Time) error
+}
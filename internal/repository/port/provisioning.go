@@ -0,0 +1,30 @@
+package port
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"time"
+)
+
+// ProvisioningRepository encapsulates the logic to access the states, the error queue and the runs of the provisioning from the data source.
+type ProvisioningRepository interface {
+	// ListStates returns the accounts last pushed to the specified connector.
+	ListStates(ctx context.Context, connector string) ([]domain.ProvisioningState, error)
+	// SaveState saves the account last pushed for a user, replacing the previous one.
+	SaveState(ctx context.Context, state domain.ProvisioningState) error
+	// ListErrors returns the queued errors of the specified connector, of every connector when empty, the oldest first.
+	ListErrors(ctx context.Context, connector string) ([]domain.ProvisioningError, error)
+	// SaveError queues the error of a push, replacing the previous error of the user.
+	SaveError(ctx context.Context, e domain.ProvisioningError) error
+	// DeleteError removes the error of a user from the queue.
+	DeleteError(ctx context.Context, connector string, userID string) error
+	// RetryError resets the attempts of a queued error, so that the push is retried at the next run after the specified time.
+	// It returns false when the user has no queued error.
+	RetryError(ctx context.Context, connector string, userID string, at time.Time) (bool, error)
+	// CreateRun saves the report of a run.
+	CreateRun(ctx context.Context, run domain.ProvisioningRun) error
+	// GetRun returns the run with the specified ID.
+	GetRun(ctx context.Context, id string) (domain.ProvisioningRun, error)
+	// ListRuns returns the latest runs without their drifts, the newest first, of every connector when connector is empty.
+	ListRuns(ctx context.Context, connector string, limit int) ([]domain.ProvisioningRun, error)
+}
@@ -0,0 +1,18 @@
+package port
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"time"
+)
+
+// RefreshTokenRepository encapsulates the logic to access the refresh tokens of the sessions from the data source.
+type RefreshTokenRepository interface {
+	// Create saves a new refresh token in the storage.
+	Create(ctx context.Context, token domain.RefreshToken) error
+	// GetByID returns the refresh token with the specified ID.
+	GetByID(ctx context.Context, tokenID string) (domain.RefreshToken, error)
+	// Rotate records that the refresh token was exchanged for the specified one.
+	// It returns false when the token was already rotated.
+	Rotate(ctx context.Context, tokenID string, replacedBy string, at time.Time) (bool, error)
+}
@@ -9,4 +9,26 @@ type InTransaction func(ctx context.Context, repoRegistry RepositoryRegistry) (i
 type RepositoryRegistry interface {
 	DoInTransaction(ctx context.Context, txFunc InTransaction) (out interface{}, err error)
 	GetUserRepository() UserRepository
+	GetSessionRepository() SessionRepository
+	GetLoginApprovalRepository() LoginApprovalRepository
+	GetDeviceLoginRepository() DeviceLoginRepository
+	GetServiceAccountRepository() ServiceAccountRepository
+	GetTokenUsageRepository() TokenUsageRepository
+	GetLogVerbosityRepository() LogVerbosityRepository
+	GetLegalHoldRepository() LegalHoldRepository
+	GetBroadcastRepository() BroadcastRepository
+	GetEmailSuppressionRepository() EmailSuppressionRepository
+	GetSMSMessageRepository() SMSMessageRepository
+	GetMessageTemplateRepository() MessageTemplateRepository
+	GetElevationRepository() ElevationRepository
+	GetBreakGlassAccountRepository() BreakGlassAccountRepository
+	GetRefreshTokenRepository() RefreshTokenRepository
+	GetUserSyncRepository() UserSyncRepository
+	GetProvisioningRepository() ProvisioningRepository
+	GetPasswordResetRepository() PasswordResetRepository
+	GetEmailVerificationRepository() EmailVerificationRepository
+	GetRoleRepository() RoleRepository
+	GetUserIdentityRepository() UserIdentityRepository
+	GetAuditRepository() AuditRepository
+	GetTenantSettingsRepository() TenantSettingsRepository
 }
@@ -0,0 +1,30 @@
+package port
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"time"
+)
+
+// RoleRepository encapsulates the logic to access roles and their assignments from the data source.
+type RoleRepository interface {
+	// List returns every role with its permissions, by name.
+	List(ctx context.Context) ([]domain.Role, error)
+	// ListByNames returns the roles with the specified names with their permissions, the unknown names are ignored.
+	ListByNames(ctx context.Context, names []string) ([]domain.Role, error)
+	// ListByUserID returns the roles assigned to the specified user and granted at the specified time with their
+	// permissions, by name. The time-boxed roles are left out outside of their window.
+	ListByUserID(ctx context.Context, userID string, at time.Time) ([]domain.Role, error)
+	// ListAssignments returns the assignments of the roles of the specified user with their time window, by role.
+	ListAssignments(ctx context.Context, userID string) ([]domain.UserRole, error)
+	// Assign assigns a role to a user, assigning an already assigned role replaces its time window.
+	Assign(ctx context.Context, assignment domain.UserRole) error
+	// Unassign removes a role from a user.
+	// It returns ierr.ErrResourceNotFound when the role is not assigned to the user.
+	Unassign(ctx context.Context, userID string, role string) error
+	// ListExpired returns the time-boxed assignments of every user whose window ended before the given time.
+	ListExpired(ctx context.Context, before time.Time) ([]domain.UserRole, error)
+	// DeleteExpired removes the expired assignment unless it was assigned again with another window since it was
+	// listed. It returns false when the assignment was not removed.
+	DeleteExpired(ctx context.Context, assignment domain.UserRole) (bool, error)
+}
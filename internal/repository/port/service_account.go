@@ -0,0 +1,66 @@
+package port
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"time"
+)
+
+// ServiceAccountRepository encapsulates the logic to access service accounts from the data source.
+type ServiceAccountRepository interface {
+	// Create saves a new service account in the storage.
+	Create(ctx context.Context, account domain.ServiceAccount) error
+	// GetByID returns the service account with the specified ID.
+	GetByID(ctx context.Context, accountID string) (domain.ServiceAccount, error)
+	// List returns every service account ordered by name.
+	List(ctx context.Context) ([]domain.ServiceAccount, error)
+	// UpdatePublicKey replaces the public key verifying the assertions of the service account.
+	UpdatePublicKey(ctx context.Context, accountID string, publicKey string) error
+	// UpdateActive enables or disables the service account.
+	UpdateActive(ctx context.Context, accountID string, isActive bool) error
+	// UpdateLastUsedAt records the last time the service account obtained a token.
+	UpdateLastUsedAt(ctx context.Context, accountID string, lastUsedAt time.Time) error
+	// ListRoles returns the roles bound to the service account.
+	ListRoles(ctx context.Context, accountID string) ([]domain.ServiceAccountRole, error)
+	// BindRole binds a role to the service account, binding an already bound role replaces its time window.
+	BindRole(ctx context.Context, role domain.ServiceAccountRole) error
+	// UnbindRole removes a role from the service account.
+	UnbindRole(ctx context.Context, accountID string, role string) error
+	// ListExpiredRoles returns the time-boxed roles of every service account whose window ended before the given time.
+	ListExpiredRoles(ctx context.Context, before time.Time) ([]domain.ServiceAccountRole, error)
+	// DeleteExpiredRole removes the expired role unless it was bound again with another window since it was listed.
+	// It returns false when the role was not removed.
+	DeleteExpiredRole(ctx context.Context, role domain.ServiceAccountRole) (bool, error)
+	// RecordAssertion stores the identifier of a consumed assertion.
+	// It returns false when the assertion has already been used.
+	RecordAssertion(ctx context.Context, assertion domain.ServiceAccountAssertion) (bool, error)
+	// DeleteExpiredAssertions deletes the consumed assertions expired before the specified time.
+	DeleteExpiredAssertions(ctx context.Context, before time.Time) (int64, error)
+	// RecordAuditEvents saves entries of the audit trails of service accounts in a single statement.
+	RecordAuditEvents(ctx context.Context, auditEvents []domain.ServiceAccountAuditEvent) error
+	// ListAuditEvents returns the latest entries of the audit trail of a service account, the newest first,
+	// skipping the offset newest ones.
+	ListAuditEvents(ctx context.Context, accountID string, limit int, offset int) ([]domain.ServiceAccountAuditEvent, error)
+	// ListChainedAuditEvents returns the audit events of the hash chain following the specified position, in the chain order.
+	ListChainedAuditEvents(ctx context.Context, afterSeq int64, limit int) ([]domain.ServiceAccountAuditEvent, error)
+	// GetAuditChainHead returns the head of the hash chain of the audit events.
+	// The head is locked for update when called inside a transaction.
+	GetAuditChainHead(ctx context.Context, name string) (domain.AuditChainHead, error)
+	// UpdateAuditChainHead moves the head of the hash chain of the audit events.
+	UpdateAuditChainHead(ctx context.Context, head domain.AuditChainHead) error
+	// ListAuditEventsCreatedBetween returns the audit events created in [from, to), from the oldest, skipping the offset oldest ones.
+	ListAuditEventsCreatedBetween(ctx context.Context, from time.Time, to time.Time, limit int, offset int) ([]domain.ServiceAccountAuditEvent, error)
+	// GetOldestAuditEvent returns the oldest audit event of the service accounts.
+	GetOldestAuditEvent(ctx context.Context) (domain.ServiceAccountAuditEvent, error)
+	// GetLastAuditEventBefore returns the chained audit event with the highest position such that
+	// it and all the events preceding it in the chain were created before the specified time.
+	GetLastAuditEventBefore(ctx context.Context, before time.Time) (domain.ServiceAccountAuditEvent, error)
+	// DeleteAuditEvents deletes the chained audit events up to the specified position, and the events
+	// recorded before the chaining which were created before the specified time, except the ones whose
+	// actor or subject is a held user.
+	DeleteAuditEvents(ctx context.Context, maxSeq int64, before time.Time, heldUserIDs []string) (int64, error)
+	// GetLastAuditArchive returns the export of the most recent day of audit events.
+	GetLastAuditArchive(ctx context.Context) (domain.ServiceAccountAuditArchive, error)
+	// RecordAuditArchive saves the export of a day of audit events.
+	RecordAuditArchive(ctx context.Context, archive domain.ServiceAccountAuditArchive) error
+}
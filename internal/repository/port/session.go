@@ -0,0 +1,35 @@
+package port
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"time"
+)
+
+// SessionRepository encapsulates the logic to access sessions from the data source.
+type SessionRepository interface {
+	// Create saves a new session in the storage.
+	Create(ctx context.Context, session domain.Session) error
+	// GetByID returns the session with the specified session ID.
+	GetByID(ctx context.Context, sessionID string) (domain.Session, error)
+	// ListActiveByUserID returns the active sessions of the specified user ordered from the oldest.
+	// The sessions are locked for update when called inside a transaction.
+	ListActiveByUserID(ctx context.Context, userID string) ([]domain.Session, error)
+	// CountActiveByUserID returns the number of active sessions of the specified user.
+	CountActiveByUserID(ctx context.Context, userID string) (int, error)
+	// Touch records the use of the session, when its refresh token was last issued.
+	Touch(ctx context.Context, sessionID string, at time.Time) error
+	// ClearRefreshToken clears the hashed refresh token of the session if it still equals the given hash.
+	// Only the refresh tokens issued before their rotation was recorded are stored as a hash.
+	// It returns false when the token has already been cleared.
+	ClearRefreshToken(ctx context.Context, sessionID string, hashedRefreshToken string) (bool, error)
+	// Revoke revokes the session with the specified session ID, the revoked sessions keep no refresh token.
+	Revoke(ctx context.Context, sessionID string) error
+	// RevokeByUserID revokes all active sessions of the specified user.
+	RevokeByUserID(ctx context.Context, userID string) error
+	// RevokeByUpstreamSessionID revokes the active sessions started with the specified upstream sid.
+	// A non empty upstream subject must match as well.
+	RevokeByUpstreamSessionID(ctx context.Context, upstreamSessionID string, upstreamSubject string) error
+	// RevokeByUpstreamSubject revokes the active sessions started with the specified upstream sub.
+	RevokeByUpstreamSubject(ctx context.Context, upstreamSubject string) error
+}
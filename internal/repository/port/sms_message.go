@@ -0,0 +1,15 @@
+package port
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"time"
+)
+
+// SMSMessageRepository encapsulates the logic to access the text messages from the data source.
+type SMSMessageRepository interface {
+	// Create saves a new text message in the storage.
+	Create(ctx context.Context, msg domain.SMSMessage) error
+	// UpdateStatus updates the delivery status of the message with the specified id at the provider.
+	UpdateStatus(ctx context.Context, provider string, providerMessageID string, status string, errorCode string, updatedAt time.Time) error
+}
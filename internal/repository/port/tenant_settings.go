@@ -0,0 +1,18 @@
+package port
+
+import (
+	"context"
+	"go-hex/internal/domain"
+)
+
+// TenantSettingsRepository encapsulates the logic to access the settings of the tenants from the data source.
+type TenantSettingsRepository interface {
+	// Get returns the settings of the tenant.
+	Get(ctx context.Context, tenantID string) (domain.TenantSettings, error)
+	// List returns the settings of the tenants, ordered by tenant.
+	List(ctx context.Context, limit int, offset int) ([]domain.TenantSettings, error)
+	// Upsert saves the settings of a tenant, replacing every setting when the tenant already has settings.
+	Upsert(ctx context.Context, settings domain.TenantSettings) error
+	// Delete deletes the settings of the tenant.
+	Delete(ctx context.Context, tenantID string) error
+}
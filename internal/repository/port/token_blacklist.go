@@ -0,0 +1,14 @@
+package port
+
+import (
+	"context"
+	"time"
+)
+
+// TokenBlacklistRepository records the access tokens revoked before they expire, by their jti.
+type TokenBlacklistRepository interface {
+	// Revoke records the token with the specified ID as revoked until it expires.
+	Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error
+	// IsRevoked returns whether the token with the specified ID has been revoked.
+	IsRevoked(ctx context.Context, tokenID string) (bool, error)
+}
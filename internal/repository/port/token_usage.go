@@ -0,0 +1,21 @@
+package port
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"time"
+)
+
+// TokenUsageRepository encapsulates the logic to access the token usage analytics from the data source.
+type TokenUsageRepository interface {
+	// IncrementEndpoints adds the given calls to the daily endpoint aggregates.
+	IncrementEndpoints(ctx context.Context, usages []domain.TokenUsageEndpoint) error
+	// IncrementScopes adds the given calls to the daily scope aggregates.
+	IncrementScopes(ctx context.Context, usages []domain.TokenUsageScope) error
+	// ListEndpoints returns the endpoint usages between the given days summed per client and endpoint.
+	// An empty client id returns every client.
+	ListEndpoints(ctx context.Context, clientID string, from time.Time, to time.Time) ([]domain.TokenUsageEndpoint, error)
+	// ListScopes returns the scope usages between the given days summed per client and scope.
+	// An empty client id returns every client.
+	ListScopes(ctx context.Context, clientID string, from time.Time, to time.Time) ([]domain.TokenUsageScope, error)
+}
@@ -11,10 +11,27 @@ type UserRepository interface {
 	GetByID(ctx context.Context, userID string) (domain.User, error)
 	// GetByUsername returns the user with the specified username.
 	GetByUsername(ctx context.Context, username string) (domain.User, error)
+	// ListByIDs returns the users with the specified IDs, the IDs without user are skipped.
+	ListByIDs(ctx context.Context, userIDs []string) ([]domain.User, error)
+	// ListByEmail returns the users with the specified email address.
+	ListByEmail(ctx context.Context, email string) ([]domain.User, error)
+	// List returns a page of users in a stable order, starting after the user with the specified ID when set.
+	List(ctx context.Context, afterID string, limit int) ([]domain.User, error)
 	// IsUserExistByID checks wether user exists by id
 	IsUserExistByID(ctx context.Context, userID string) (bool, error)
 	// IsUserExistByUsername checks wether user exists by username
 	IsUserExistByUsername(ctx context.Context, username string) (exist bool, err error)
+	// Create saves a new user in the storage.
+	// It returns ierr.ErrUserAlreadyRegistered when the username is taken.
+	Create(ctx context.Context, user domain.User) error
 	// Update updates the user with given ID in the storage.
 	Update(ctx context.Context, userID string, user domain.User) error
+	// SetActive activates or deactivates the user with given ID, Update cannot deactivate a user as it skips the zero fields.
+	SetActive(ctx context.Context, userID string, active bool) error
+	// ClearRefreshToken clears the refresh token of the user if it still equals the given hashed token.
+	// It returns false when the token has already been cleared or replaced.
+	ClearRefreshToken(ctx context.Context, userID string, hashedToken string) (bool, error)
+	// ReplacePassword replaces the hashed password of the user if it still equals the given hashed password.
+	// It returns false when the password has been changed meanwhile.
+	ReplacePassword(ctx context.Context, userID string, hashedPwd string, newHashedPwd string) (bool, error)
 }
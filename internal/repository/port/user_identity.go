@@ -0,0 +1,19 @@
+package port
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"time"
+)
+
+// UserIdentityRepository encapsulates the logic to access the social login identities of the users from the data source.
+type UserIdentityRepository interface {
+	// Get returns the identity of the specified account at the provider.
+	// It returns ierr.ErrResourceNotFound when the account is not linked.
+	Get(ctx context.Context, provider string, subject string) (domain.UserIdentity, error)
+	// Create links an account at a provider to a user.
+	// It returns ierr.ErrConflict when the account is already linked.
+	Create(ctx context.Context, identity domain.UserIdentity) error
+	// Touch records a login of the identity.
+	Touch(ctx context.Context, provider string, subject string, at time.Time) error
+}
@@ -0,0 +1,20 @@
+package port
+
+import (
+	"context"
+	"go-hex/internal/domain"
+)
+
+// UserSyncRepository encapsulates the logic to access the links and the runs of the user sync from the data source.
+type UserSyncRepository interface {
+	// ListLinks returns the links of the users synced from the specified source.
+	ListLinks(ctx context.Context, source string) ([]domain.UserSyncLink, error)
+	// SaveLink saves the link of a user, replacing the previous link of the same external identity.
+	SaveLink(ctx context.Context, link domain.UserSyncLink) error
+	// CreateRun saves the history of a sync run.
+	CreateRun(ctx context.Context, run domain.UserSyncRun) error
+	// GetRun returns the sync run with the specified ID.
+	GetRun(ctx context.Context, id string) (domain.UserSyncRun, error)
+	// ListRuns returns the latest sync runs without their changes, the newest first, of every source when source is empty.
+	ListRuns(ctx context.Context, source string, limit int) ([]domain.UserSyncRun, error)
+}
@@ -0,0 +1,182 @@
+// Package redis stores the records only needed until they expire in Redis, so that every instance of the api
+// sees them. It speaks the subset of the RESP protocol used by the repositories.
+package redis
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// maxIdleConns is the number of connections kept open between the commands
+const maxIdleConns = 16
+
+// Error is an error replied by Redis
+type Error string
+
+func (e Error) Error() string {
+	return "redis: " + string(e)
+}
+
+// Client sends the commands to a Redis server over a pool of connections
+type Client struct {
+	address  string
+	password string
+	db       int
+	timeout  time.Duration
+	idle     chan *conn
+}
+
+type conn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// NewClient creates a client of the Redis server at the address, authenticated with the password when not empty.
+// The commands time out after the timeout unless their context expires first.
+func NewClient(address, password string, db int, timeout time.Duration) *Client {
+	return &Client{address, password, db, timeout, make(chan *conn, maxIdleConns)}
+}
+
+// Do sends the command and returns its reply: a string, an int64, nil, a slice of replies or an Error
+func (c *Client) Do(ctx context.Context, args ...string) (interface{}, error) {
+	cn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := cn.do(ctx, c.timeout, args...)
+	if _, ok := err.(Error); err != nil && !ok {
+		// the connection may hold a partial reply, it is not reused
+		cn.Close()
+		return nil, errors.Wrapf(err, "cannot send redis command %s", args[0])
+	}
+	c.put(cn)
+	return reply, err
+}
+
+// Close closes the idle connections
+func (c *Client) Close() error {
+	for {
+		select {
+		case cn := <-c.idle:
+			cn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+func (c *Client) get(ctx context.Context) (*conn, error) {
+	select {
+	case cn := <-c.idle:
+		return cn, nil
+	default:
+	}
+
+	dialer := net.Dialer{Timeout: c.timeout}
+	nc, err := dialer.DialContext(ctx, "tcp", c.address)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot connect to redis")
+	}
+	cn := &conn{nc, bufio.NewReader(nc)}
+	if c.password != "" {
+		if _, err := cn.do(ctx, c.timeout, "AUTH", c.password); err != nil {
+			cn.Close()
+			return nil, errors.Wrap(err, "cannot authenticate to redis")
+		}
+	}
+	if c.db != 0 {
+		if _, err := cn.do(ctx, c.timeout, "SELECT", strconv.Itoa(c.db)); err != nil {
+			cn.Close()
+			return nil, errors.Wrap(err, "cannot select redis database")
+		}
+	}
+	return cn, nil
+}
+
+func (c *Client) put(cn *conn) {
+	select {
+	case c.idle <- cn:
+	default:
+		cn.Close()
+	}
+}
+
+func (cn *conn) do(ctx context.Context, timeout time.Duration, args ...string) (interface{}, error) {
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := cn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	buf := []byte("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		buf = append(buf, "$"+strconv.Itoa(len(arg))+"\r\n"+arg+"\r\n"...)
+	}
+	if _, err := cn.Write(buf); err != nil {
+		return nil, err
+	}
+	return readReply(cn.reader)
+}
+
+// readReply reads a RESP reply
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("invalid redis reply %q", line)
+	}
+	kind, value := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return value, nil
+	case '-':
+		return nil, Error(value)
+	case ':':
+		return strconv.ParseInt(value, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		return string(data[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		res := make([]interface{}, n)
+		for i := range res {
+			if res[i], err = readReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return res, nil
+	}
+	return nil, fmt.Errorf("invalid redis reply %q", line)
+}
+
+// Ping checks that the server answers the commands
+func Ping(ctx context.Context, client *Client) error {
+	_, err := client.Do(ctx, "PING")
+	if err != nil {
+		return errors.Wrap(err, "cannot ping redis")
+	}
+	return nil
+}
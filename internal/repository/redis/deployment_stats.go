@@ -0,0 +1,111 @@
+package redis
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/otel"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// deploymentStatsPrefix prefixes the keys of the deployment stats, deployment_stats:<version>:<minute>
+const deploymentStatsPrefix = "deployment_stats:"
+
+// Fields of the hashes of the deployment stats, the latency buckets are latency:<index>
+const (
+	fieldRequests      = "requests"
+	fieldErrors        = "errors"
+	fieldLatencyPrefix = "latency:"
+)
+
+// DeploymentStatsRepository stores the deployment stats in Redis, a hash per version and minute expiring after
+// retention. The stats of every instance are added up, so that the analysis covers all the instances of a version.
+type DeploymentStatsRepository struct {
+	client    *Client
+	retention time.Duration
+}
+
+// NewDeploymentStatsRepository creates a store of the deployment stats kept by the client for retention
+func NewDeploymentStatsRepository(client *Client, retention time.Duration) port.DeploymentStatsRepository {
+	return &DeploymentStatsRepository{client, retention}
+}
+
+// Add adds the counts of the stats to the ones stored for their version and minute
+func (r *DeploymentStatsRepository) Add(ctx context.Context, stats []domain.DeploymentStats) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	for _, s := range stats {
+		key := deploymentStatsKey(s.Version, s.Minute)
+		counts := map[string]uint64{fieldRequests: s.Requests, fieldErrors: s.Errors}
+		for i, count := range s.Latencies {
+			counts[fieldLatencyPrefix+strconv.Itoa(i)] = count
+		}
+		for field, count := range counts {
+			if count == 0 {
+				continue
+			}
+			if _, err := r.client.Do(ctx, "HINCRBY", key, field, strconv.FormatUint(count, 10)); err != nil {
+				return errors.Wrap(err, "cannot add deployment stats")
+			}
+		}
+		if _, err := r.client.Do(ctx, "PEXPIRE", key, strconv.FormatInt(r.retention.Milliseconds(), 10)); err != nil {
+			return errors.Wrap(err, "cannot expire deployment stats")
+		}
+	}
+	return nil
+}
+
+// List returns the stats of the version whose minute is between from and to included
+func (r *DeploymentStatsRepository) List(ctx context.Context, version string, from, to time.Time) ([]domain.DeploymentStats, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	res := []domain.DeploymentStats{}
+	for minute := from.Truncate(time.Minute); !minute.After(to); minute = minute.Add(time.Minute) {
+		if minute.Before(from) {
+			continue
+		}
+		reply, err := r.client.Do(ctx, "HGETALL", deploymentStatsKey(version, minute))
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot list deployment stats")
+		}
+		values, _ := reply.([]interface{})
+		if len(values) == 0 {
+			continue
+		}
+		stats := domain.NewDeploymentStats(version, minute)
+		for i := 0; i+1 < len(values); i += 2 {
+			field, _ := values[i].(string)
+			value, _ := values[i+1].(string)
+			count, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return nil, errors.Wrapf(err, "cannot decode deployment stats field %s", field)
+			}
+			switch field {
+			case fieldRequests:
+				stats.Requests = count
+			case fieldErrors:
+				stats.Errors = count
+			default:
+				index, err := strconv.Atoi(strings.TrimPrefix(field, fieldLatencyPrefix))
+				if err == nil && strings.HasPrefix(field, fieldLatencyPrefix) && index >= 0 && index < len(stats.Latencies) {
+					stats.Latencies[index] = count
+				}
+			}
+		}
+		res = append(res, stats)
+	}
+	return res, nil
+}
+
+// deploymentStatsKey returns the key of the stats of the version during the minute
+func deploymentStatsKey(version string, minute time.Time) string {
+	return deploymentStatsPrefix + version + ":" + strconv.FormatInt(minute.Unix(), 10)
+}
@@ -0,0 +1,45 @@
+package redis
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeploymentStatsRepository(t *testing.T) {
+	server, address := newFakeServer(t)
+	client := NewClient(address, "", 0, time.Second)
+	defer client.Close()
+	repo := NewDeploymentStatsRepository(client, time.Hour)
+	ctx := context.Background()
+
+	minute := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	stats := domain.NewDeploymentStats("v1.3.0", minute)
+	stats.Observe(true, false, 0)
+	stats.Observe(false, true, 80*time.Millisecond)
+
+	// the stats of two instances are added up
+	require.NoError(t, repo.Add(ctx, []domain.DeploymentStats{stats}))
+	require.NoError(t, repo.Add(ctx, []domain.DeploymentStats{stats}))
+
+	got, err := repo.List(ctx, "v1.3.0", minute.Add(-time.Minute), minute.Add(time.Minute))
+	require.NoError(t, err)
+	if assert.Len(t, got, 1) {
+		assert.True(t, minute.Equal(got[0].Minute))
+		assert.Equal(t, uint64(4), got[0].Requests)
+		assert.Equal(t, uint64(2), got[0].Errors)
+		assert.Equal(t, uint64(2), got[0].Latencies[domain.LatencyBucket(80*time.Millisecond)])
+	}
+
+	got, err = repo.List(ctx, "v1.2.0", minute, minute)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	assert.Contains(t, server.commands, []string{"PEXPIRE", "deployment_stats:v1.3.0:1792065600", "3600000"})
+}
@@ -0,0 +1,80 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/otel"
+	"go-hex/shared/ierr"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// identityViewPrefix prefixes the keys of the identity views
+const identityViewPrefix = "identity_view:"
+
+// IdentityViewRepository stores the identity views in Redis as JSON, in keys expiring after ttl.
+// The views, and their invalidations, are shared by every instance.
+type IdentityViewRepository struct {
+	client *Client
+	ttl    time.Duration
+}
+
+// NewIdentityViewRepository creates a store of the identity views kept by the client for ttl
+func NewIdentityViewRepository(client *Client, ttl time.Duration) port.IdentityViewRepository {
+	return &IdentityViewRepository{client, ttl}
+}
+
+// Get returns the view of the specified user, ierr.ErrResourceNotFound when it is not stored.
+func (r *IdentityViewRepository) Get(ctx context.Context, userID string) (domain.IdentityView, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	view := domain.IdentityView{}
+	reply, err := r.client.Do(ctx, "GET", identityViewPrefix+userID)
+	if err != nil {
+		return view, errors.Wrap(err, "cannot get identity view")
+	}
+	value, ok := reply.(string)
+	if !ok {
+		return view, ierr.ErrResourceNotFound
+	}
+	if err := json.Unmarshal([]byte(value), &view); err != nil {
+		return view, errors.Wrap(err, "cannot decode identity view")
+	}
+	return view, nil
+}
+
+// Put stores the view of its user, replacing the previous one.
+func (r *IdentityViewRepository) Put(ctx context.Context, view domain.IdentityView) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	value, err := json.Marshal(view)
+	if err != nil {
+		return errors.Wrap(err, "cannot encode identity view")
+	}
+	_, err = r.client.Do(ctx, "SET", identityViewPrefix+view.UserID, string(value), "PX", strconv.FormatInt(r.ttl.Milliseconds(), 10))
+	if err != nil {
+		return errors.Wrap(err, "cannot put identity view")
+	}
+	return nil
+}
+
+// Invalidate removes the view of the specified user, it is built again by its next read.
+func (r *IdentityViewRepository) Invalidate(ctx context.Context, userID string) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.client.Do(ctx, "DEL", identityViewPrefix+userID)
+	if err != nil {
+		return errors.Wrap(err, "cannot invalidate identity view")
+	}
+	return nil
+}
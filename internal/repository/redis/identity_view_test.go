@@ -0,0 +1,47 @@
+package redis
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/shared/ierr"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdentityViewRepository(t *testing.T) {
+	server, address := newFakeServer(t)
+	client := NewClient(address, "", 0, time.Second)
+	defer client.Close()
+	repo := NewIdentityViewRepository(client, 30*time.Second)
+	ctx := context.Background()
+
+	_, err := repo.Get(ctx, "u1")
+	assert.Equal(t, ierr.ErrResourceNotFound, err)
+
+	endsAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	view := domain.IdentityView{
+		UserID:     "u1",
+		Elevations: []domain.Elevation{{ID: "e1", UserID: "u1", Role: "admin", Status: domain.ElevationStatusApproved, EndsAt: &endsAt}},
+		BuiltAt:    time.Now().UTC().Truncate(time.Second),
+	}
+	require.NoError(t, repo.Put(ctx, view))
+	got, err := repo.Get(ctx, "u1")
+	require.NoError(t, err)
+	assert.Equal(t, "u1", got.UserID)
+	assert.Nil(t, got.BreakGlass)
+	if assert.Len(t, got.ActiveElevations(time.Now()), 1) {
+		assert.True(t, endsAt.Equal(*got.Elevations[0].EndsAt))
+	}
+
+	require.NoError(t, repo.Invalidate(ctx, "u1"))
+	_, err = repo.Get(ctx, "u1")
+	assert.Equal(t, ierr.ErrResourceNotFound, err)
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	assert.Equal(t, []string{"SET", "identity_view:u1"}, server.commands[1][:2])
+	assert.Equal(t, []string{"PX", "30000"}, server.commands[1][3:])
+}
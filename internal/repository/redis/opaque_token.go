@@ -0,0 +1,74 @@
+package redis
+
+import (
+	"context"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/times"
+	"go-hex/shared/ierr"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// opaqueTokenPrefix prefixes the keys of the opaque tokens
+const opaqueTokenPrefix = "opaque_token:"
+
+// OpaqueTokenRepository stores the opaque tokens in Redis, as keys expiring with their access tokens.
+// The tokens are resolved, and deleted, by every instance.
+type OpaqueTokenRepository struct {
+	client *Client
+}
+
+// NewOpaqueTokenRepository creates a store of the opaque tokens kept by the client
+func NewOpaqueTokenRepository(client *Client) port.OpaqueTokenRepository {
+	return &OpaqueTokenRepository{client}
+}
+
+// Put stores the access token referenced by the opaque token of the specified digest until it expires.
+func (r *OpaqueTokenRepository) Put(ctx context.Context, digest string, accessToken string, expiresAt time.Time) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	ttl := expiresAt.Sub(times.Now()).Milliseconds()
+	if ttl <= 0 {
+		return nil
+	}
+	_, err := r.client.Do(ctx, "SET", opaqueTokenPrefix+digest, accessToken, "PX", strconv.FormatInt(ttl, 10))
+	if err != nil {
+		return errors.Wrap(err, "cannot put opaque token")
+	}
+	return nil
+}
+
+// Get returns the access token referenced by the opaque token of the specified digest.
+func (r *OpaqueTokenRepository) Get(ctx context.Context, digest string) (string, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	reply, err := r.client.Do(ctx, "GET", opaqueTokenPrefix+digest)
+	if err != nil {
+		return "", errors.Wrap(err, "cannot get opaque token")
+	}
+	accessToken, ok := reply.(string)
+	if !ok {
+		return "", ierr.ErrResourceNotFound
+	}
+	return accessToken, nil
+}
+
+// Delete removes the opaque token of the specified digest.
+func (r *OpaqueTokenRepository) Delete(ctx context.Context, digest string) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.client.Do(ctx, "DEL", opaqueTokenPrefix+digest)
+	if err != nil {
+		return errors.Wrap(err, "cannot delete opaque token")
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package redis
+
+import (
+	"context"
+	"go-hex/shared/ierr"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpaqueTokenRepository(t *testing.T) {
+	server, address := newFakeServer(t)
+	client := NewClient(address, "secret", 0, time.Second)
+	defer client.Close()
+	repo := NewOpaqueTokenRepository(client)
+	ctx := context.Background()
+
+	require.NoError(t, repo.Put(ctx, "d1", "access-token", time.Now().Add(time.Minute)))
+	require.NoError(t, repo.Put(ctx, "d2", "expired-token", time.Now().Add(-time.Minute)))
+
+	accessToken, err := repo.Get(ctx, "d1")
+	assert.NoError(t, err)
+	assert.Equal(t, "access-token", accessToken)
+	_, err = repo.Get(ctx, "d2")
+	assert.Equal(t, ierr.ErrResourceNotFound, err, "an expired token is not stored")
+
+	require.NoError(t, repo.Delete(ctx, "d1"))
+	_, err = repo.Get(ctx, "d1")
+	assert.Equal(t, ierr.ErrResourceNotFound, err)
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	set := server.commands[1]
+	assert.Equal(t, []string{"SET", "opaque_token:d1", "access-token", "PX"}, set[:4])
+	ttl, _ := strconv.Atoi(set[4])
+	assert.InDelta(t, time.Minute.Milliseconds(), ttl, 1000)
+}
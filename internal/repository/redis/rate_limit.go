@@ -0,0 +1,72 @@
+package redis
+
+import (
+	"context"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/ratelimit"
+	"go-hex/pkg/times"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// rateLimitPrefix prefixes the keys of the token buckets
+const rateLimitPrefix = "rate_limit:"
+
+// takeScript refills the bucket of KEYS[1] at ARGV[1] tokens per minute up to the time ARGV[2] in milliseconds and
+// takes a token, it returns whether it was taken, the whole tokens left and the wait in milliseconds for the next
+// one. The bucket expires once refilled, when it is the same as a new one.
+const takeScript = `
+local capacity = tonumber(ARGV[1])
+local now = tonumber(ARGV[2])
+local perMs = capacity / 60000
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'at')
+local tokens = tonumber(bucket[1]) or capacity
+local at = tonumber(bucket[2]) or now
+if now > at then
+  tokens = math.min(capacity, tokens + (now - at) * perMs)
+  at = now
+end
+local allowed, wait = 0, 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+else
+  wait = math.ceil((1 - tokens) / perMs)
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'at', tostring(at))
+redis.call('PEXPIRE', KEYS[1], math.ceil((capacity - tokens) / perMs) + 1)
+return {allowed, math.floor(tokens), wait}
+`
+
+// RateLimitStore holds the token buckets in Redis, shared by every instance, each take is atomic
+type RateLimitStore struct {
+	client *Client
+}
+
+// NewRateLimitStore creates a store of the buckets kept by the client
+func NewRateLimitStore(client *Client) ratelimit.Store {
+	return &RateLimitStore{client}
+}
+
+// Take implements ratelimit.Store
+func (s *RateLimitStore) Take(ctx context.Context, key string, perMinute int) (ratelimit.Decision, error) {
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	now := strconv.FormatInt(times.Now().UnixMilli(), 10)
+	reply, err := s.client.Do(ctx, "EVAL", takeScript, "1", rateLimitPrefix+key, strconv.Itoa(perMinute), now)
+	if err != nil {
+		return ratelimit.Decision{}, errors.Wrap(err, "cannot take rate limit token")
+	}
+
+	values, _ := reply.([]interface{})
+	if len(values) != 3 {
+		return ratelimit.Decision{}, errors.Errorf("invalid rate limit reply %v", reply)
+	}
+	allowed, _ := values[0].(int64)
+	remaining, _ := values[1].(int64)
+	wait, _ := values[2].(int64)
+	return ratelimit.Decision{Allowed: allowed == 1, Remaining: int(remaining), RetryAfter: time.Duration(wait) * time.Millisecond}, nil
+}
@@ -0,0 +1,35 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitStore(t *testing.T) {
+	server, address := newFakeServer(t)
+	client := NewClient(address, "", 0, time.Second)
+	defer client.Close()
+	store := NewRateLimitStore(client)
+	ctx := context.Background()
+
+	decision, err := store.Take(ctx, "login:ip:10.0.0.1", 2)
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed)
+	assert.Equal(t, 1, decision.Remaining)
+	decision, err = store.Take(ctx, "login:ip:10.0.0.1", 2)
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed)
+	decision, err = store.Take(ctx, "login:ip:10.0.0.1", 2)
+	require.NoError(t, err)
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, 30*time.Second, decision.RetryAfter)
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	command := server.commands[0]
+	assert.Equal(t, []string{"EVAL", takeScript, "1", "rate_limit:login:ip:10.0.0.1", "2"}, command[:5], "one atomic script per take")
+}
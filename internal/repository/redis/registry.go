@@ -0,0 +1,196 @@
+package redis
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/metrics"
+	"go-hex/pkg/otel"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// userPrefix prefixes the keys of the cached users
+const userPrefix = "user:"
+
+var userCacheLookups = metrics.NewCounterVec(prometheus.CounterOpts{
+	Name: "redis_user_cache_lookups_total",
+	Help: "Number of lookups of the users cached in Redis, by result (hit, miss or error).",
+}, "result")
+
+// RepositoryRegistry serves the users read by ID from Redis, where they are kept for ttl and shared by every
+// instance, and the rest from the decorated registry. The writes of the users invalidate them, so that the
+// other instances read them again from the data source.
+//
+// The transactions read through the cache, and their writes invalidate the users again once committed, so that
+// a user cached by a concurrent read before the commit is not kept. The reads of a context bypassing the caches
+// (port.BypassCache) are served by the data source. Redis failing, the users are read from the data source,
+// but a write whose user cannot be invalidated fails, so that a deactivated user is never served from the cache.
+type RepositoryRegistry struct {
+	port.RepositoryRegistry
+	client *Client
+	ttl    time.Duration
+	tx     *invalidations
+}
+
+// invalidations are the users written by a transaction
+type invalidations struct {
+	mu      sync.Mutex
+	userIDs []string
+}
+
+// NewRepositoryRegistry creates a registry caching the users of next in the client for ttl
+func NewRepositoryRegistry(next port.RepositoryRegistry, client *Client, ttl time.Duration) port.RepositoryRegistry {
+	return &RepositoryRegistry{next, client, ttl, nil}
+}
+
+// DoInTransaction runs txFunc in a transaction of the decorated registry, the users written by txFunc are
+// invalidated again once it is committed.
+func (r *RepositoryRegistry) DoInTransaction(ctx context.Context, txFunc port.InTransaction) (out interface{}, err error) {
+	tx := &invalidations{}
+	out, err = r.RepositoryRegistry.DoInTransaction(ctx, func(ctx context.Context, repoRegistry port.RepositoryRegistry) (interface{}, error) {
+		return txFunc(ctx, &RepositoryRegistry{repoRegistry, r.client, r.ttl, tx})
+	})
+	if err != nil {
+		return out, err
+	}
+	repo := &cachedUserRepository{client: r.client}
+	for _, userID := range tx.userIDs {
+		if err := repo.invalidate(ctx, userID); err != nil {
+			return out, err
+		}
+	}
+	return out, nil
+}
+
+func (r *RepositoryRegistry) GetUserRepository() port.UserRepository {
+	return &cachedUserRepository{r.RepositoryRegistry.GetUserRepository(), r.client, r.ttl, r.tx}
+}
+
+type cachedUserRepository struct {
+	port.UserRepository
+	client *Client
+	ttl    time.Duration
+	tx     *invalidations
+}
+
+func (r *cachedUserRepository) GetByID(ctx context.Context, userID string) (domain.User, error) {
+	if r.tx != nil || port.CacheBypassed(ctx) {
+		return r.UserRepository.GetByID(ctx, userID)
+	}
+
+	user, ok, err := r.get(ctx, userID)
+	switch {
+	case err != nil:
+		userCacheLookups.WithLabelValues("error").Inc()
+	case ok:
+		userCacheLookups.WithLabelValues("hit").Inc()
+		return user, nil
+	default:
+		userCacheLookups.WithLabelValues("miss").Inc()
+	}
+
+	user, err = r.UserRepository.GetByID(ctx, userID)
+	if err != nil {
+		return user, err
+	}
+	// the user is served by the data source even when it cannot be cached
+	_ = r.put(ctx, user)
+	return user, nil
+}
+
+func (r *cachedUserRepository) Update(ctx context.Context, userID string, user domain.User) error {
+	if err := r.UserRepository.Update(ctx, userID, user); err != nil {
+		return err
+	}
+	return r.written(ctx, userID)
+}
+
+func (r *cachedUserRepository) SetActive(ctx context.Context, userID string, active bool) error {
+	if err := r.UserRepository.SetActive(ctx, userID, active); err != nil {
+		return err
+	}
+	return r.written(ctx, userID)
+}
+
+func (r *cachedUserRepository) ClearRefreshToken(ctx context.Context, userID string, hashedToken string) (bool, error) {
+	cleared, err := r.UserRepository.ClearRefreshToken(ctx, userID, hashedToken)
+	if err != nil || !cleared {
+		return cleared, err
+	}
+	return cleared, r.written(ctx, userID)
+}
+
+func (r *cachedUserRepository) ReplacePassword(ctx context.Context, userID string, hashedPwd string, newHashedPwd string) (bool, error) {
+	replaced, err := r.UserRepository.ReplacePassword(ctx, userID, hashedPwd, newHashedPwd)
+	if err != nil || !replaced {
+		return replaced, err
+	}
+	return replaced, r.written(ctx, userID)
+}
+
+// written invalidates the written user, and again once the transaction writing it is committed
+func (r *cachedUserRepository) written(ctx context.Context, userID string) error {
+	if r.tx != nil {
+		r.tx.mu.Lock()
+		r.tx.userIDs = append(r.tx.userIDs, userID)
+		r.tx.mu.Unlock()
+	}
+	return r.invalidate(ctx, userID)
+}
+
+// get returns the cached user, false when it is not cached.
+// The users are encoded with gob rather than JSON, which leaves out the password and the other hidden fields.
+func (r *cachedUserRepository) get(ctx context.Context, userID string) (domain.User, bool, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	var user domain.User
+	reply, err := r.client.Do(ctx, "GET", userPrefix+userID)
+	if err != nil {
+		return user, false, errors.Wrap(err, "cannot get cached user")
+	}
+	value, ok := reply.(string)
+	if !ok {
+		return user, false, nil
+	}
+	if err := gob.NewDecoder(bytes.NewBufferString(value)).Decode(&user); err != nil {
+		return user, false, errors.Wrap(err, "cannot decode cached user")
+	}
+	return user, true, nil
+}
+
+func (r *cachedUserRepository) put(ctx context.Context, user domain.User) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	var value bytes.Buffer
+	if err := gob.NewEncoder(&value).Encode(user); err != nil {
+		return errors.Wrap(err, "cannot encode cached user")
+	}
+	_, err := r.client.Do(ctx, "SET", userPrefix+user.ID, value.String(), "PX", strconv.FormatInt(r.ttl.Milliseconds(), 10))
+	if err != nil {
+		return errors.Wrap(err, "cannot cache user")
+	}
+	return nil
+}
+
+func (r *cachedUserRepository) invalidate(ctx context.Context, userID string) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.client.Do(ctx, "DEL", userPrefix+userID)
+	if err != nil {
+		return errors.Wrap(err, "cannot invalidate cached user")
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package redis
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/memory"
+	"go-hex/internal/repository/port"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepositoryRegistryCachesUsers(t *testing.T) {
+	server, address := newFakeServer(t)
+	client := NewClient(address, "", 0, time.Second)
+	defer client.Close()
+	ctx := context.Background()
+	store := memory.NewStore()
+	jane := domain.User{ID: "u1", Username: "jane", Password: "hashed", IsActive: true}
+	require.NoError(t, store.GetUserRepository().Create(ctx, jane))
+	var reads int32
+	store.SetHook(func(ctx context.Context, op string) error {
+		if op == "UserRepository.GetByID" {
+			atomic.AddInt32(&reads, 1)
+		}
+		return nil
+	})
+	registry := NewRepositoryRegistry(store, client, time.Minute)
+
+	// the second read is served by the cache, with the fields hidden from JSON
+	for i := 0; i < 2; i++ {
+		user, err := registry.GetUserRepository().GetByID(ctx, "u1")
+		require.NoError(t, err)
+		assert.Equal(t, jane, user)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&reads))
+
+	_, err := registry.GetUserRepository().GetByID(port.BypassCache(ctx), "u1")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&reads), "read from the data source")
+
+	// a write invalidates the user
+	require.NoError(t, registry.GetUserRepository().SetActive(ctx, "u1", false))
+	user, err := registry.GetUserRepository().GetByID(ctx, "u1")
+	require.NoError(t, err)
+	assert.False(t, user.IsActive)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&reads))
+
+	// the users written by a transaction are invalidated again once committed
+	deletes := server.count("DEL")
+	_, err = registry.DoInTransaction(ctx, func(ctx context.Context, repoRegistry port.RepositoryRegistry) (interface{}, error) {
+		return nil, repoRegistry.GetUserRepository().SetActive(ctx, "u1", true)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, deletes+2, server.count("DEL"))
+}
@@ -0,0 +1,56 @@
+package redis
+
+import (
+	"context"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/times"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// tokenBlacklistPrefix prefixes the keys of the revoked tokens
+const tokenBlacklistPrefix = "token_blacklist:"
+
+// TokenBlacklistRepository records the revoked tokens in Redis, as keys expiring with the tokens
+type TokenBlacklistRepository struct {
+	client *Client
+}
+
+// NewTokenBlacklistRepository creates a blacklist stored by the client
+func NewTokenBlacklistRepository(client *Client) port.TokenBlacklistRepository {
+	return &TokenBlacklistRepository{client}
+}
+
+// Revoke records the token with the specified ID as revoked until it expires.
+func (r *TokenBlacklistRepository) Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	ttl := expiresAt.Sub(times.Now()).Milliseconds()
+	if ttl <= 0 {
+		return nil
+	}
+	_, err := r.client.Do(ctx, "SET", tokenBlacklistPrefix+tokenID, "1", "PX", strconv.FormatInt(ttl, 10))
+	if err != nil {
+		return errors.Wrap(err, "cannot revoke token")
+	}
+	return nil
+}
+
+// IsRevoked returns whether the token with the specified ID has been revoked.
+func (r *TokenBlacklistRepository) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	reply, err := r.client.Do(ctx, "EXISTS", tokenBlacklistPrefix+tokenID)
+	if err != nil {
+		return false, errors.Wrap(err, "cannot check token revocation")
+	}
+	count, _ := reply.(int64)
+	return count > 0, nil
+}
@@ -0,0 +1,172 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeServer answers the commands of the repositories from a map, ignoring the expiries
+type fakeServer struct {
+	mu       sync.Mutex
+	keys     map[string]string
+	hashes   map[string]map[string]int64
+	commands [][]string
+}
+
+func newFakeServer(t *testing.T) (*fakeServer, string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	server := &fakeServer{keys: map[string]string{}, hashes: map[string]map[string]int64{}}
+	go func() {
+		for {
+			nc, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(nc)
+		}
+	}()
+	return server, listener.Addr().String()
+}
+
+func (s *fakeServer) serve(nc net.Conn) {
+	defer nc.Close()
+	r := bufio.NewReader(nc)
+	for {
+		reply, err := readReply(r)
+		if err != nil {
+			return
+		}
+		var args []string
+		for _, arg := range reply.([]interface{}) {
+			args = append(args, arg.(string))
+		}
+
+		s.mu.Lock()
+		s.commands = append(s.commands, args)
+		var answer string
+		switch args[0] {
+		case "AUTH":
+			if args[1] == "secret" {
+				answer = "+OK\r\n"
+			} else {
+				answer = "-WRONGPASS invalid password\r\n"
+			}
+		case "PING":
+			answer = "+PONG\r\n"
+		case "SET":
+			s.keys[args[1]] = args[2]
+			answer = "+OK\r\n"
+		case "GET":
+			if value, ok := s.keys[args[1]]; ok {
+				answer = "$" + strconv.Itoa(len(value)) + "\r\n" + value + "\r\n"
+			} else {
+				answer = "$-1\r\n"
+			}
+		case "DEL":
+			_, ok := s.keys[args[1]]
+			delete(s.keys, args[1])
+			answer = ":" + strconv.Itoa(map[bool]int{true: 1, false: 0}[ok]) + "\r\n"
+		case "EXISTS":
+			_, ok := s.keys[args[1]]
+			answer = ":" + strconv.Itoa(map[bool]int{true: 1, false: 0}[ok]) + "\r\n"
+		case "HINCRBY":
+			if s.hashes[args[1]] == nil {
+				s.hashes[args[1]] = map[string]int64{}
+			}
+			increment, _ := strconv.ParseInt(args[3], 10, 64)
+			s.hashes[args[1]][args[2]] += increment
+			answer = ":" + strconv.FormatInt(s.hashes[args[1]][args[2]], 10) + "\r\n"
+		case "HGETALL":
+			hash := s.hashes[args[1]]
+			answer = "*" + strconv.Itoa(2*len(hash)) + "\r\n"
+			for field, value := range hash {
+				v := strconv.FormatInt(value, 10)
+				answer += "$" + strconv.Itoa(len(field)) + "\r\n" + field + "\r\n$" + strconv.Itoa(len(v)) + "\r\n" + v + "\r\n"
+			}
+		case "EVAL":
+			// takes the tokens of the rate limit buckets without refilling them
+			if s.hashes[args[3]] == nil {
+				s.hashes[args[3]] = map[string]int64{}
+			}
+			capacity, _ := strconv.ParseInt(args[4], 10, 64)
+			s.hashes[args[3]]["taken"]++
+			if taken := s.hashes[args[3]]["taken"]; taken <= capacity {
+				answer = "*3\r\n:1\r\n:" + strconv.FormatInt(capacity-taken, 10) + "\r\n:0\r\n"
+			} else {
+				answer = "*3\r\n:0\r\n:0\r\n:" + strconv.FormatInt(60000/capacity, 10) + "\r\n"
+			}
+		case "PEXPIRE":
+			answer = ":1\r\n"
+		default:
+			answer = "-ERR unknown command\r\n"
+		}
+		s.mu.Unlock()
+		nc.Write([]byte(answer))
+	}
+}
+
+// count returns the number of commands received with the name
+func (s *fakeServer) count(name string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	count := 0
+	for _, args := range s.commands {
+		if args[0] == name {
+			count++
+		}
+	}
+	return count
+}
+
+func TestTokenBlacklistRepository(t *testing.T) {
+	server, address := newFakeServer(t)
+	client := NewClient(address, "secret", 0, time.Second)
+	defer client.Close()
+	repo := NewTokenBlacklistRepository(client)
+	ctx := context.Background()
+
+	require.NoError(t, Ping(ctx, client))
+	require.NoError(t, repo.Revoke(ctx, "t1", time.Now().Add(time.Minute)))
+	require.NoError(t, repo.Revoke(ctx, "t2", time.Now().Add(-time.Minute)))
+
+	revoked, err := repo.IsRevoked(ctx, "t1")
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+	revoked, err = repo.IsRevoked(ctx, "t2")
+	assert.NoError(t, err)
+	assert.False(t, revoked, "an expired token is not recorded")
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	assert.Equal(t, []string{"AUTH", "secret"}, server.commands[0], "authenticated once by connection")
+	set := server.commands[2]
+	assert.Equal(t, []string{"SET", "token_blacklist:t1", "1", "PX"}, set[:4])
+	ttl, _ := strconv.Atoi(set[4])
+	assert.InDelta(t, time.Minute.Milliseconds(), ttl, 1000)
+	assert.Len(t, server.commands, 5)
+}
+
+func TestClientReplies(t *testing.T) {
+	_, address := newFakeServer(t)
+
+	_, err := NewClient(address, "wrong", 0, time.Second).Do(context.Background(), "PING")
+	assert.Error(t, err)
+
+	client := NewClient(address, "secret", 0, time.Second)
+	_, err = client.Do(context.Background(), "FLUSHALL")
+	assert.Equal(t, Error("ERR unknown command"), err)
+	reply, err := client.Do(context.Background(), "PING")
+	assert.NoError(t, err, "the connection is reused after an error reply")
+	assert.Equal(t, "PONG", reply)
+}
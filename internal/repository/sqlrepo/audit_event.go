@@ -0,0 +1,93 @@
+package sqlrepo
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/sqlrepo/column"
+	"go-hex/pkg/otel"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/uptrace/bun"
+)
+
+// AuditRepository encapsulates the logic to access the audit trail of the users from the data source.
+type AuditRepository struct {
+	db DBI
+}
+
+// NewAuditRepository creates a new audit repository
+func NewAuditRepository(db DBI) *AuditRepository {
+	return &AuditRepository{db}
+}
+
+// Record saves the audit events in a single statement.
+func (r *AuditRepository) Record(ctx context.Context, auditEvents []domain.AuditEvent) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	if len(auditEvents) == 0 {
+		return nil
+	}
+
+	_, err := r.db.NewInsert().
+		Model(&auditEvents).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot record audit events")
+	}
+	return nil
+}
+
+// List returns the audit events created in [from, to) by the actor and of the action, any when empty,
+// the newest first, skipping the offset newest ones.
+func (r *AuditRepository) List(ctx context.Context, actorID string, action string, from time.Time, to time.Time, limit int, offset int) ([]domain.AuditEvent, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	auditEvents := []domain.AuditEvent{}
+	query := r.db.
+		NewSelect().
+		Model(&auditEvents).
+		Where("?>=?", column.AuditEvent.CreatedAt, from).
+		Where("?<?", column.AuditEvent.CreatedAt, to)
+	if actorID != "" {
+		query = query.Where("?=?", column.AuditEvent.ActorID, actorID)
+	}
+	if action != "" {
+		query = query.Where("?=?", column.AuditEvent.Action, action)
+	}
+	err := query.
+		OrderExpr("? DESC, ? DESC", column.AuditEvent.CreatedAt, column.AuditEvent.ID).
+		Limit(limit).
+		Offset(offset).
+		Scan(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list audit events")
+	}
+
+	return auditEvents, nil
+}
+
+// DeleteExpired deletes the audit events created before the specified time, except the ones whose actor or subject is a held user.
+func (r *AuditRepository) DeleteExpired(ctx context.Context, before time.Time, heldUserIDs []string) (int64, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	q := r.db.NewDelete().
+		Model((*domain.AuditEvent)(nil)).
+		Where("?<?", column.AuditEvent.CreatedAt, before)
+	if len(heldUserIDs) > 0 {
+		q.Where("? NOT IN (?)", column.AuditEvent.ActorID, bun.In(heldUserIDs)).
+			Where("? NOT IN (?)", column.AuditEvent.SubjectID, bun.In(heldUserIDs))
+	}
+
+	res, err := q.Exec(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "cannot delete expired audit events")
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,143 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/sqlrepo/column"
+	"go-hex/pkg/otel"
+	"go-hex/shared/ierr"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// BreakGlassAccountRepository encapsulates the logic to access break-glass accounts from the data source.
+type BreakGlassAccountRepository struct {
+	db DBI
+}
+
+// NewBreakGlassAccountRepository creates a new break-glass account repository
+func NewBreakGlassAccountRepository(db DBI) *BreakGlassAccountRepository {
+	return &BreakGlassAccountRepository{db}
+}
+
+// Seal saves a sealed break-glass account, replacing the previous seal and activation of the user.
+func (r *BreakGlassAccountRepository) Seal(ctx context.Context, account domain.BreakGlassAccount) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.db.NewInsert().
+		Model(&account).
+		On("?", upsert(column.BreakGlassAccount.UserID)).
+		Set("? = ?", column.BreakGlassAccount.Status, inserted(column.BreakGlassAccount.Status)).
+		Set("? = ?", column.BreakGlassAccount.Custodians, inserted(column.BreakGlassAccount.Custodians)).
+		Set("? = ?", column.BreakGlassAccount.SealedAt, inserted(column.BreakGlassAccount.SealedAt)).
+		Set("? = ?", column.BreakGlassAccount.ActivatedBy, inserted(column.BreakGlassAccount.ActivatedBy)).
+		Set("? = ?", column.BreakGlassAccount.Reason, inserted(column.BreakGlassAccount.Reason)).
+		Set("? = ?", column.BreakGlassAccount.ActivatedAt, inserted(column.BreakGlassAccount.ActivatedAt)).
+		Set("? = ?", column.BreakGlassAccount.ExpiresAt, inserted(column.BreakGlassAccount.ExpiresAt)).
+		Set("? = ?", column.BreakGlassAccount.RevokedAt, inserted(column.BreakGlassAccount.RevokedAt)).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot seal break-glass account")
+	}
+	return nil
+}
+
+// GetByUserID returns the break-glass account of the specified user.
+func (r *BreakGlassAccountRepository) GetByUserID(ctx context.Context, userID string) (domain.BreakGlassAccount, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	var account domain.BreakGlassAccount
+	err := r.db.
+		NewSelect().
+		Model(&account).
+		Where("?=?", column.BreakGlassAccount.UserID, userID).
+		Scan(ctx)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return domain.BreakGlassAccount{}, ierr.ErrResourceNotFound
+		}
+		return domain.BreakGlassAccount{}, errors.Wrap(err, "cannot get break-glass account")
+	}
+
+	return account, nil
+}
+
+// Activate records the activation of a sealed break-glass account.
+// It returns false when the account is not sealed anymore.
+func (r *BreakGlassAccountRepository) Activate(ctx context.Context, account domain.BreakGlassAccount) (bool, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	res, err := r.db.NewUpdate().
+		Model((*domain.BreakGlassAccount)(nil)).
+		Set("?=?", column.BreakGlassAccount.Status, domain.BreakGlassStatusActive).
+		Set("?=?", column.BreakGlassAccount.ActivatedBy, account.ActivatedBy).
+		Set("?=?", column.BreakGlassAccount.Reason, account.Reason).
+		Set("?=?", column.BreakGlassAccount.ActivatedAt, account.ActivatedAt).
+		Set("?=?", column.BreakGlassAccount.ExpiresAt, account.ExpiresAt).
+		Where("?=?", column.BreakGlassAccount.UserID, account.UserID).
+		Where("?=?", column.BreakGlassAccount.Status, domain.BreakGlassStatusSealed).
+		Exec(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "cannot activate break-glass account")
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "cannot activate break-glass account")
+	}
+	return affected == 1, nil
+}
+
+// Revoke ends the activation of an active break-glass account.
+// It returns false when the account is not active anymore.
+func (r *BreakGlassAccountRepository) Revoke(ctx context.Context, userID string, at time.Time) (bool, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	res, err := r.db.NewUpdate().
+		Model((*domain.BreakGlassAccount)(nil)).
+		Set("?=?", column.BreakGlassAccount.Status, domain.BreakGlassStatusSpent).
+		Set("?=?", column.BreakGlassAccount.RevokedAt, at).
+		Where("?=?", column.BreakGlassAccount.UserID, userID).
+		Where("?=?", column.BreakGlassAccount.Status, domain.BreakGlassStatusActive).
+		Exec(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "cannot revoke break-glass account")
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "cannot revoke break-glass account")
+	}
+	return affected == 1, nil
+}
+
+// ListExpired returns the active break-glass accounts whose activation expired before the specified time.
+func (r *BreakGlassAccountRepository) ListExpired(ctx context.Context, before time.Time) ([]domain.BreakGlassAccount, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	accounts := []domain.BreakGlassAccount{}
+	err := r.db.
+		NewSelect().
+		Model(&accounts).
+		Where("?=?", column.BreakGlassAccount.Status, domain.BreakGlassStatusActive).
+		Where("?<=?", column.BreakGlassAccount.ExpiresAt, before).
+		Scan(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list expired break-glass accounts")
+	}
+
+	return accounts, nil
+}
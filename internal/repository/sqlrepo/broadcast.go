@@ -0,0 +1,134 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/sqlrepo/column"
+	"go-hex/pkg/otel"
+	"go-hex/shared/ierr"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// BroadcastRepository encapsulates the logic to access the broadcasts and their deliveries from the data source.
+type BroadcastRepository struct {
+	db DBI
+}
+
+// NewBroadcastRepository creates a new broadcast repository
+func NewBroadcastRepository(db DBI) *BroadcastRepository {
+	return &BroadcastRepository{db}
+}
+
+// Create saves a new broadcast in the storage.
+func (r *BroadcastRepository) Create(ctx context.Context, broadcast domain.Broadcast) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.db.NewInsert().
+		Model(&broadcast).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot create broadcast")
+	}
+	return nil
+}
+
+// GetByID returns the broadcast with the specified ID.
+func (r *BroadcastRepository) GetByID(ctx context.Context, id string) (domain.Broadcast, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	var broadcast domain.Broadcast
+	err := r.db.NewSelect().
+		Model(&broadcast).
+		Where("?=?", column.Broadcast.ID, id).
+		Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return broadcast, ierr.ErrResourceNotFound
+		}
+		return broadcast, errors.Wrap(err, "cannot get broadcast")
+	}
+	return broadcast, nil
+}
+
+// ListActive returns the broadcasts not expired at the specified time, from the oldest.
+func (r *BroadcastRepository) ListActive(ctx context.Context, now time.Time) ([]domain.Broadcast, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	broadcasts := []domain.Broadcast{}
+	err := r.db.NewSelect().
+		Model(&broadcasts).
+		Where("?>?", column.Broadcast.ExpiresAt, now).
+		OrderExpr("? ASC", column.Broadcast.CreatedAt).
+		Scan(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list broadcasts")
+	}
+	return broadcasts, nil
+}
+
+// RecordDelivery records the delivery of a broadcast to a session.
+// It returns false when the broadcast was already delivered to the session.
+func (r *BroadcastRepository) RecordDelivery(ctx context.Context, delivery domain.BroadcastDelivery) (bool, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	res, err := r.db.NewInsert().
+		Model(&delivery).
+		Ignore().
+		Exec(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "cannot record broadcast delivery")
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "cannot record broadcast delivery")
+	}
+	return affected == 1, nil
+}
+
+// CountDeliveries returns the number of sessions the broadcast was delivered to.
+func (r *BroadcastRepository) CountDeliveries(ctx context.Context, broadcastID string) (int, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	count, err := r.db.NewSelect().
+		Model((*domain.BroadcastDelivery)(nil)).
+		Where("?=?", column.BroadcastDelivery.BroadcastID, broadcastID).
+		Count(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "cannot count broadcast deliveries")
+	}
+	return count, nil
+}
+
+// ListDeliveries returns a page of the deliveries of the broadcast, the newest first.
+func (r *BroadcastRepository) ListDeliveries(ctx context.Context, broadcastID string, limit int, offset int) ([]domain.BroadcastDelivery, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	deliveries := []domain.BroadcastDelivery{}
+	err := r.db.NewSelect().
+		Model(&deliveries).
+		Where("?=?", column.BroadcastDelivery.BroadcastID, broadcastID).
+		OrderExpr("? DESC, ? DESC", column.BroadcastDelivery.DeliveredAt, column.BroadcastDelivery.SessionID).
+		Limit(limit).
+		Offset(offset).
+		Scan(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list broadcast deliveries")
+	}
+	return deliveries, nil
+}
@@ -0,0 +1,79 @@
+// Package column provides the typed columns of the domain entities.
+// The columns are generated from the bun schema of the entities, so that renaming a field
+// of an entity breaks the build of the queries still referencing the old column.
+package column
+
+//go:generate go run ./gen -out columns_gen.go
+
+import (
+	"go-hex/shared/ierr"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/uptrace/bun/schema"
+)
+
+// Column is a column of an entity, it is formatted as an identifier in the queries like bun.Ident.
+type Column string
+
+var _ schema.QueryAppender = Column("")
+
+// AppendQuery implements schema.QueryAppender
+func (c Column) AppendQuery(fmter schema.Formatter, b []byte) ([]byte, error) {
+	return fmter.AppendIdent(b, string(c)), nil
+}
+
+// Set whitelists the columns a request is allowed to filter or sort on, indexed by name.
+type Set map[string]Column
+
+// NewSet creates a set of the given columns
+func NewSet(columns ...Column) Set {
+	s := make(Set, len(columns))
+	for _, c := range columns {
+		s[string(c)] = c
+	}
+	return s
+}
+
+// Lookup returns the column with the given name, ierr.ErrUnknownColumn is returned when it is not whitelisted
+func (s Set) Lookup(name string) (Column, error) {
+	c, ok := s[name]
+	if !ok {
+		return "", errors.Wrapf(ierr.ErrUnknownColumn, "unknown column %q", name)
+	}
+	return c, nil
+}
+
+// Order sorts the results on a column
+type Order struct {
+	Column Column
+	Desc   bool
+}
+
+// AppendQuery implements schema.QueryAppender
+func (o Order) AppendQuery(fmter schema.Formatter, b []byte) ([]byte, error) {
+	b = fmter.AppendIdent(b, string(o.Column))
+	if o.Desc {
+		return append(b, " DESC"...), nil
+	}
+	return append(b, " ASC"...), nil
+}
+
+// ParseOrder parses a comma separated list of columns, each column prefixed by - being sorted descending,
+// e.g. -created_at,name. Every column must be whitelisted by the set.
+func (s Set) ParseOrder(value string) ([]Order, error) {
+	var orders []Order
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		desc := strings.HasPrefix(item, "-")
+		c, err := s.Lookup(strings.TrimPrefix(item, "-"))
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, Order{Column: c, Desc: desc})
+	}
+	return orders, nil
+}
@@ -0,0 +1,46 @@
+package column
+
+import (
+	"go-hex/shared/ierr"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/uptrace/bun/dialect/mysqldialect"
+	"github.com/uptrace/bun/schema"
+)
+
+func TestParseOrder(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		value   string
+		want    []Order
+		wantErr error
+	}{
+		{name: "empty", value: "", want: nil},
+		{name: "ascending", value: "created_at", want: []Order{{Column: Session.CreatedAt}}},
+		{name: "descending and ascending", value: "-expires_at, id", want: []Order{{Column: Session.ExpiresAt, Desc: true}, {Column: Session.ID}}},
+		{name: "column not exposed", value: "refresh_token", wantErr: ierr.ErrUnknownColumn},
+		{name: "unknown column", value: "-created_at,1;DROP TABLE sessions", wantErr: ierr.ErrUnknownColumn},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SessionExposed.ParseOrder(tt.value)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, errors.Cause(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAppendQuery(t *testing.T) {
+	fmter := schema.NewFormatter(mysqldialect.New())
+
+	assert.Equal(t, "`user_id` = 'u1'", fmter.FormatQuery("? = ?", Session.UserID, "u1"))
+	assert.Equal(t, "`created_at` DESC, `id` ASC", fmter.FormatQuery("?, ?", Order{Column: Session.CreatedAt, Desc: true}, Order{Column: Session.ID}))
+}
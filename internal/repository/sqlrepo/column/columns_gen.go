@@ -0,0 +1,855 @@
+// Code generated by go generate; DO NOT EDIT.
+
+package column
+
+// AuditChainHead lists the columns of the audit_chain_heads table.
+var AuditChainHead = struct {
+	Name       Column
+	Seq        Column
+	Hash       Column
+	PrunedSeq  Column
+	PrunedHash Column
+	UpdatedAt  Column
+}{
+	Name:       "name",
+	Seq:        "seq",
+	Hash:       "hash",
+	PrunedSeq:  "pruned_seq",
+	PrunedHash: "pruned_hash",
+	UpdatedAt:  "updated_at",
+}
+
+// AuditChainHeadExposed whitelists the columns of AuditChainHead exposed by the API.
+var AuditChainHeadExposed = NewSet(AuditChainHead.Name, AuditChainHead.Seq, AuditChainHead.Hash, AuditChainHead.PrunedSeq, AuditChainHead.PrunedHash, AuditChainHead.UpdatedAt)
+
+// AuditEvent lists the columns of the audit_events table.
+var AuditEvent = struct {
+	ID         Column
+	Action     Column
+	Outcome    Column
+	ActorType  Column
+	ActorID    Column
+	SubjectID  Column
+	IPAddress  Column
+	UserAgent  Column
+	Attributes Column
+	CreatedAt  Column
+}{
+	ID:         "id",
+	Action:     "action",
+	Outcome:    "outcome",
+	ActorType:  "actor_type",
+	ActorID:    "actor_id",
+	SubjectID:  "subject_id",
+	IPAddress:  "ip_address",
+	UserAgent:  "user_agent",
+	Attributes: "attributes",
+	CreatedAt:  "created_at",
+}
+
+// AuditEventExposed whitelists the columns of AuditEvent exposed by the API.
+var AuditEventExposed = NewSet(AuditEvent.ID, AuditEvent.Action, AuditEvent.Outcome, AuditEvent.ActorType, AuditEvent.ActorID, AuditEvent.SubjectID, AuditEvent.IPAddress, AuditEvent.UserAgent, AuditEvent.Attributes, AuditEvent.CreatedAt)
+
+// BreakGlassAccount lists the columns of the break_glass_accounts table.
+var BreakGlassAccount = struct {
+	UserID      Column
+	Status      Column
+	Custodians  Column
+	SealedAt    Column
+	ActivatedBy Column
+	Reason      Column
+	ActivatedAt Column
+	ExpiresAt   Column
+	RevokedAt   Column
+}{
+	UserID:      "user_id",
+	Status:      "status",
+	Custodians:  "custodians",
+	SealedAt:    "sealed_at",
+	ActivatedBy: "activated_by",
+	Reason:      "reason",
+	ActivatedAt: "activated_at",
+	ExpiresAt:   "expires_at",
+	RevokedAt:   "revoked_at",
+}
+
+// BreakGlassAccountExposed whitelists the columns of BreakGlassAccount exposed by the API.
+var BreakGlassAccountExposed = NewSet(BreakGlassAccount.UserID, BreakGlassAccount.Status, BreakGlassAccount.Custodians, BreakGlassAccount.SealedAt, BreakGlassAccount.ActivatedBy, BreakGlassAccount.Reason, BreakGlassAccount.ActivatedAt, BreakGlassAccount.ExpiresAt, BreakGlassAccount.RevokedAt)
+
+// Broadcast lists the columns of the broadcasts table.
+var Broadcast = struct {
+	ID        Column
+	Kind      Column
+	Message   Column
+	UserIDs   Column
+	Roles     Column
+	CreatedBy Column
+	CreatedAt Column
+	ExpiresAt Column
+}{
+	ID:        "id",
+	Kind:      "kind",
+	Message:   "message",
+	UserIDs:   "user_i_ds",
+	Roles:     "roles",
+	CreatedBy: "created_by",
+	CreatedAt: "created_at",
+	ExpiresAt: "expires_at",
+}
+
+// BroadcastExposed whitelists the columns of Broadcast exposed by the API.
+var BroadcastExposed = NewSet(Broadcast.ID, Broadcast.Kind, Broadcast.Message, Broadcast.UserIDs, Broadcast.Roles, Broadcast.CreatedBy, Broadcast.CreatedAt, Broadcast.ExpiresAt)
+
+// BroadcastDelivery lists the columns of the broadcast_deliveries table.
+var BroadcastDelivery = struct {
+	BroadcastID Column
+	SessionID   Column
+	UserID      Column
+	DeliveredAt Column
+}{
+	BroadcastID: "broadcast_id",
+	SessionID:   "session_id",
+	UserID:      "user_id",
+	DeliveredAt: "delivered_at",
+}
+
+// BroadcastDeliveryExposed whitelists the columns of BroadcastDelivery exposed by the API.
+var BroadcastDeliveryExposed = NewSet(BroadcastDelivery.BroadcastID, BroadcastDelivery.SessionID, BroadcastDelivery.UserID, BroadcastDelivery.DeliveredAt)
+
+// DeviceLogin lists the columns of the device_logins table.
+var DeviceLogin = struct {
+	ID           Column
+	DeviceCode   Column
+	UserCode     Column
+	UserID       Column
+	Status       Column
+	CreatedAt    Column
+	ExpiresAt    Column
+	DecidedAt    Column
+	LastPolledAt Column
+}{
+	ID:           "id",
+	DeviceCode:   "device_code",
+	UserCode:     "user_code",
+	UserID:       "user_id",
+	Status:       "status",
+	CreatedAt:    "created_at",
+	ExpiresAt:    "expires_at",
+	DecidedAt:    "decided_at",
+	LastPolledAt: "last_polled_at",
+}
+
+// DeviceLoginExposed whitelists the columns of DeviceLogin exposed by the API.
+var DeviceLoginExposed = NewSet(DeviceLogin.ID, DeviceLogin.UserCode, DeviceLogin.Status, DeviceLogin.CreatedAt, DeviceLogin.ExpiresAt)
+
+// Elevation lists the columns of the elevations table.
+var Elevation = struct {
+	ID          Column
+	UserID      Column
+	Role        Column
+	Reason      Column
+	Duration    Column
+	Status      Column
+	RequestedAt Column
+	ExpiresAt   Column
+	DecidedBy   Column
+	DecidedAt   Column
+	Comment     Column
+	EndsAt      Column
+}{
+	ID:          "id",
+	UserID:      "user_id",
+	Role:        "role",
+	Reason:      "reason",
+	Duration:    "duration",
+	Status:      "status",
+	RequestedAt: "requested_at",
+	ExpiresAt:   "expires_at",
+	DecidedBy:   "decided_by",
+	DecidedAt:   "decided_at",
+	Comment:     "comment",
+	EndsAt:      "ends_at",
+}
+
+// ElevationExposed whitelists the columns of Elevation exposed by the API.
+var ElevationExposed = NewSet(Elevation.ID, Elevation.UserID, Elevation.Role, Elevation.Reason, Elevation.Duration, Elevation.Status, Elevation.RequestedAt, Elevation.ExpiresAt, Elevation.DecidedBy, Elevation.DecidedAt, Elevation.Comment, Elevation.EndsAt)
+
+// EmailSuppression lists the columns of the email_suppressions table.
+var EmailSuppression = struct {
+	Address   Column
+	Reason    Column
+	Provider  Column
+	Detail    Column
+	Feedbacks Column
+	CreatedAt Column
+	UpdatedAt Column
+}{
+	Address:   "address",
+	Reason:    "reason",
+	Provider:  "provider",
+	Detail:    "detail",
+	Feedbacks: "feedbacks",
+	CreatedAt: "created_at",
+	UpdatedAt: "updated_at",
+}
+
+// EmailSuppressionExposed whitelists the columns of EmailSuppression exposed by the API.
+var EmailSuppressionExposed = NewSet(EmailSuppression.Address, EmailSuppression.Reason, EmailSuppression.Provider, EmailSuppression.Detail, EmailSuppression.Feedbacks, EmailSuppression.CreatedAt, EmailSuppression.UpdatedAt)
+
+// EmailVerification lists the columns of the email_verifications table.
+var EmailVerification = struct {
+	ID         Column
+	UserID     Column
+	Email      Column
+	TokenHash  Column
+	CreatedAt  Column
+	ExpiresAt  Column
+	VerifiedAt Column
+}{
+	ID:         "id",
+	UserID:     "user_id",
+	Email:      "email",
+	TokenHash:  "token_hash",
+	CreatedAt:  "created_at",
+	ExpiresAt:  "expires_at",
+	VerifiedAt: "verified_at",
+}
+
+// EmailVerificationExposed whitelists the columns of EmailVerification exposed by the API.
+var EmailVerificationExposed = NewSet(EmailVerification.ID, EmailVerification.UserID, EmailVerification.Email, EmailVerification.CreatedAt, EmailVerification.ExpiresAt, EmailVerification.VerifiedAt)
+
+// LegalHold lists the columns of the legal_holds table.
+var LegalHold = struct {
+	ID            Column
+	UserID        Column
+	Reason        Column
+	PlacedBy      Column
+	PlacedAt      Column
+	ReleasedBy    Column
+	ReleaseReason Column
+	ReleasedAt    Column
+}{
+	ID:            "id",
+	UserID:        "user_id",
+	Reason:        "reason",
+	PlacedBy:      "placed_by",
+	PlacedAt:      "placed_at",
+	ReleasedBy:    "released_by",
+	ReleaseReason: "release_reason",
+	ReleasedAt:    "released_at",
+}
+
+// LegalHoldExposed whitelists the columns of LegalHold exposed by the API.
+var LegalHoldExposed = NewSet(LegalHold.ID, LegalHold.UserID, LegalHold.Reason, LegalHold.PlacedBy, LegalHold.PlacedAt, LegalHold.ReleasedBy, LegalHold.ReleaseReason, LegalHold.ReleasedAt)
+
+// LogVerbosity lists the columns of the log_verbosities table.
+var LogVerbosity = struct {
+	ID               Column
+	UserID           Column
+	RequestIDPattern Column
+	Level            Column
+	Reason           Column
+	CreatedAt        Column
+	ExpiresAt        Column
+}{
+	ID:               "id",
+	UserID:           "user_id",
+	RequestIDPattern: "request_id_pattern",
+	Level:            "level",
+	Reason:           "reason",
+	CreatedAt:        "created_at",
+	ExpiresAt:        "expires_at",
+}
+
+// LogVerbosityExposed whitelists the columns of LogVerbosity exposed by the API.
+var LogVerbosityExposed = NewSet(LogVerbosity.ID, LogVerbosity.UserID, LogVerbosity.RequestIDPattern, LogVerbosity.Level, LogVerbosity.Reason, LogVerbosity.CreatedAt, LogVerbosity.ExpiresAt)
+
+// LoginApproval lists the columns of the login_approvals table.
+var LoginApproval = struct {
+	ID           Column
+	UserID       Column
+	ClientSecret Column
+	Number       Column
+	Choices      Column
+	Status       Column
+	IPAddress    Column
+	UserAgent    Column
+	CreatedAt    Column
+	ExpiresAt    Column
+	DecidedAt    Column
+}{
+	ID:           "id",
+	UserID:       "user_id",
+	ClientSecret: "client_secret",
+	Number:       "number",
+	Choices:      "choices",
+	Status:       "status",
+	IPAddress:    "ip_address",
+	UserAgent:    "user_agent",
+	CreatedAt:    "created_at",
+	ExpiresAt:    "expires_at",
+	DecidedAt:    "decided_at",
+}
+
+// LoginApprovalExposed whitelists the columns of LoginApproval exposed by the API.
+var LoginApprovalExposed = NewSet(LoginApproval.ID, LoginApproval.Status, LoginApproval.IPAddress, LoginApproval.UserAgent, LoginApproval.CreatedAt, LoginApproval.ExpiresAt)
+
+// MessageTemplate lists the columns of the message_templates table.
+var MessageTemplate = struct {
+	ID        Column
+	Name      Column
+	Channel   Column
+	Version   Column
+	Subject   Column
+	Body      Column
+	Reset     Column
+	CreatedBy Column
+	CreatedAt Column
+}{
+	ID:        "id",
+	Name:      "name",
+	Channel:   "channel",
+	Version:   "version",
+	Subject:   "subject",
+	Body:      "body",
+	Reset:     "reset",
+	CreatedBy: "created_by",
+	CreatedAt: "created_at",
+}
+
+// MessageTemplateExposed whitelists the columns of MessageTemplate exposed by the API.
+var MessageTemplateExposed = NewSet(MessageTemplate.ID, MessageTemplate.Name, MessageTemplate.Channel, MessageTemplate.Version, MessageTemplate.Subject, MessageTemplate.Body, MessageTemplate.Reset, MessageTemplate.CreatedBy, MessageTemplate.CreatedAt)
+
+// Permission lists the columns of the permissions table.
+var Permission = struct {
+	Name        Column
+	Description Column
+}{
+	Name:        "name",
+	Description: "description",
+}
+
+// PermissionExposed whitelists the columns of Permission exposed by the API.
+var PermissionExposed = NewSet(Permission.Name, Permission.Description)
+
+// PasswordReset lists the columns of the password_resets table.
+var PasswordReset = struct {
+	ID        Column
+	UserID    Column
+	TokenHash Column
+	Channel   Column
+	CreatedAt Column
+	ExpiresAt Column
+	UsedAt    Column
+}{
+	ID:        "id",
+	UserID:    "user_id",
+	TokenHash: "token_hash",
+	Channel:   "channel",
+	CreatedAt: "created_at",
+	ExpiresAt: "expires_at",
+	UsedAt:    "used_at",
+}
+
+// PasswordResetExposed whitelists the columns of PasswordReset exposed by the API.
+var PasswordResetExposed = NewSet(PasswordReset.ID, PasswordReset.UserID, PasswordReset.Channel, PasswordReset.CreatedAt, PasswordReset.ExpiresAt, PasswordReset.UsedAt)
+
+// ProvisioningError lists the columns of the provisioning_errors table.
+var ProvisioningError = struct {
+	Connector     Column
+	UserID        Column
+	Error         Column
+	Attempts      Column
+	NextAttemptAt Column
+	CreatedAt     Column
+	UpdatedAt     Column
+}{
+	Connector:     "connector",
+	UserID:        "user_id",
+	Error:         "error",
+	Attempts:      "attempts",
+	NextAttemptAt: "next_attempt_at",
+	CreatedAt:     "created_at",
+	UpdatedAt:     "updated_at",
+}
+
+// ProvisioningErrorExposed whitelists the columns of ProvisioningError exposed by the API.
+var ProvisioningErrorExposed = NewSet(ProvisioningError.Connector, ProvisioningError.UserID, ProvisioningError.Error, ProvisioningError.Attempts, ProvisioningError.NextAttemptAt, ProvisioningError.CreatedAt, ProvisioningError.UpdatedAt)
+
+// ProvisioningRun lists the columns of the provisioning_runs table.
+var ProvisioningRun = struct {
+	ID          Column
+	Connector   Column
+	Status      Column
+	Pushed      Column
+	Deactivated Column
+	Failed      Column
+	Deferred    Column
+	Unchanged   Column
+	Missing     Column
+	Orphaned    Column
+	Mismatched  Column
+	Drifts      Column
+	Error       Column
+	StartedAt   Column
+	FinishedAt  Column
+}{
+	ID:          "id",
+	Connector:   "connector",
+	Status:      "status",
+	Pushed:      "pushed",
+	Deactivated: "deactivated",
+	Failed:      "failed",
+	Deferred:    "deferred",
+	Unchanged:   "unchanged",
+	Missing:     "missing",
+	Orphaned:    "orphaned",
+	Mismatched:  "mismatched",
+	Drifts:      "drifts",
+	Error:       "error",
+	StartedAt:   "started_at",
+	FinishedAt:  "finished_at",
+}
+
+// ProvisioningRunExposed whitelists the columns of ProvisioningRun exposed by the API.
+var ProvisioningRunExposed = NewSet(ProvisioningRun.ID, ProvisioningRun.Connector, ProvisioningRun.Status, ProvisioningRun.Pushed, ProvisioningRun.Deactivated, ProvisioningRun.Failed, ProvisioningRun.Deferred, ProvisioningRun.Unchanged, ProvisioningRun.Missing, ProvisioningRun.Orphaned, ProvisioningRun.Mismatched, ProvisioningRun.Drifts, ProvisioningRun.Error, ProvisioningRun.StartedAt, ProvisioningRun.FinishedAt)
+
+// ProvisioningState lists the columns of the provisioning_states table.
+var ProvisioningState = struct {
+	Connector  Column
+	UserID     Column
+	AccountKey Column
+	Hash       Column
+	Active     Column
+	PushedAt   Column
+}{
+	Connector:  "connector",
+	UserID:     "user_id",
+	AccountKey: "account_key",
+	Hash:       "hash",
+	Active:     "active",
+	PushedAt:   "pushed_at",
+}
+
+// ProvisioningStateExposed whitelists the columns of ProvisioningState exposed by the API.
+var ProvisioningStateExposed = NewSet(ProvisioningState.Connector, ProvisioningState.UserID, ProvisioningState.AccountKey, ProvisioningState.Hash, ProvisioningState.Active, ProvisioningState.PushedAt)
+
+// RefreshToken lists the columns of the refresh_tokens table.
+var RefreshToken = struct {
+	ID         Column
+	SessionID  Column
+	IssuedAt   Column
+	ExpiresAt  Column
+	RotatedAt  Column
+	ReplacedBy Column
+}{
+	ID:         "id",
+	SessionID:  "session_id",
+	IssuedAt:   "issued_at",
+	ExpiresAt:  "expires_at",
+	RotatedAt:  "rotated_at",
+	ReplacedBy: "replaced_by",
+}
+
+// RefreshTokenExposed whitelists the columns of RefreshToken exposed by the API.
+var RefreshTokenExposed = NewSet(RefreshToken.ID, RefreshToken.SessionID, RefreshToken.IssuedAt, RefreshToken.ExpiresAt, RefreshToken.RotatedAt, RefreshToken.ReplacedBy)
+
+// Role lists the columns of the roles table.
+var Role = struct {
+	Name        Column
+	Description Column
+	CreatedAt   Column
+}{
+	Name:        "name",
+	Description: "description",
+	CreatedAt:   "created_at",
+}
+
+// RoleExposed whitelists the columns of Role exposed by the API.
+var RoleExposed = NewSet(Role.Name, Role.Description, Role.CreatedAt)
+
+// RolePermission lists the columns of the role_permissions table.
+var RolePermission = struct {
+	Role       Column
+	Permission Column
+}{
+	Role:       "role",
+	Permission: "permission",
+}
+
+// RolePermissionExposed whitelists the columns of RolePermission exposed by the API.
+var RolePermissionExposed = NewSet(RolePermission.Role, RolePermission.Permission)
+
+// SMSMessage lists the columns of the sms_messages table.
+var SMSMessage = struct {
+	ID                Column
+	UserID            Column
+	Provider          Column
+	ProviderMessageID Column
+	Route             Column
+	Attempts          Column
+	Segments          Column
+	Cost              Column
+	Status            Column
+	ErrorCode         Column
+	CreatedAt         Column
+	UpdatedAt         Column
+}{
+	ID:                "id",
+	UserID:            "user_id",
+	Provider:          "provider",
+	ProviderMessageID: "provider_message_id",
+	Route:             "route",
+	Attempts:          "attempts",
+	Segments:          "segments",
+	Cost:              "cost",
+	Status:            "status",
+	ErrorCode:         "error_code",
+	CreatedAt:         "created_at",
+	UpdatedAt:         "updated_at",
+}
+
+// SMSMessageExposed whitelists the columns of SMSMessage exposed by the API.
+var SMSMessageExposed = NewSet(SMSMessage.ID, SMSMessage.UserID, SMSMessage.Provider, SMSMessage.ProviderMessageID, SMSMessage.Route, SMSMessage.Attempts, SMSMessage.Segments, SMSMessage.Cost, SMSMessage.Status, SMSMessage.ErrorCode, SMSMessage.CreatedAt, SMSMessage.UpdatedAt)
+
+// ServiceAccount lists the columns of the service_accounts table.
+var ServiceAccount = struct {
+	ID          Column
+	Name        Column
+	Description Column
+	PublicKey   Column
+	IsActive    Column
+	CreatedAt   Column
+	UpdatedAt   Column
+	LastUsedAt  Column
+}{
+	ID:          "id",
+	Name:        "name",
+	Description: "description",
+	PublicKey:   "public_key",
+	IsActive:    "is_active",
+	CreatedAt:   "created_at",
+	UpdatedAt:   "updated_at",
+	LastUsedAt:  "last_used_at",
+}
+
+// ServiceAccountExposed whitelists the columns of ServiceAccount exposed by the API.
+var ServiceAccountExposed = NewSet(ServiceAccount.ID, ServiceAccount.Name, ServiceAccount.Description, ServiceAccount.IsActive, ServiceAccount.CreatedAt, ServiceAccount.UpdatedAt, ServiceAccount.LastUsedAt)
+
+// ServiceAccountAssertion lists the columns of the service_account_assertions table.
+var ServiceAccountAssertion = struct {
+	JTI              Column
+	ServiceAccountID Column
+	ExpiresAt        Column
+}{
+	JTI:              "jti",
+	ServiceAccountID: "service_account_id",
+	ExpiresAt:        "expires_at",
+}
+
+// ServiceAccountAssertionExposed whitelists the columns of ServiceAccountAssertion exposed by the API.
+var ServiceAccountAssertionExposed = NewSet(ServiceAccountAssertion.JTI, ServiceAccountAssertion.ServiceAccountID, ServiceAccountAssertion.ExpiresAt)
+
+// ServiceAccountAuditArchive lists the columns of the service_account_audit_archives table.
+var ServiceAccountAuditArchive = struct {
+	Day        Column
+	Object     Column
+	Events     Column
+	Checksum   Column
+	ArchivedAt Column
+}{
+	Day:        "day",
+	Object:     "object",
+	Events:     "events",
+	Checksum:   "checksum",
+	ArchivedAt: "archived_at",
+}
+
+// ServiceAccountAuditArchiveExposed whitelists the columns of ServiceAccountAuditArchive exposed by the API.
+var ServiceAccountAuditArchiveExposed = NewSet(ServiceAccountAuditArchive.Day, ServiceAccountAuditArchive.Object, ServiceAccountAuditArchive.Events, ServiceAccountAuditArchive.Checksum, ServiceAccountAuditArchive.ArchivedAt)
+
+// ServiceAccountAuditEvent lists the columns of the service_account_audit_events table.
+var ServiceAccountAuditEvent = struct {
+	ID               Column
+	ServiceAccountID Column
+	Event            Column
+	ActorType        Column
+	ActorID          Column
+	SubjectID        Column
+	Attributes       Column
+	CreatedAt        Column
+	Seq              Column
+	PrevHash         Column
+	Hash             Column
+}{
+	ID:               "id",
+	ServiceAccountID: "service_account_id",
+	Event:            "event",
+	ActorType:        "actor_type",
+	ActorID:          "actor_id",
+	SubjectID:        "subject_id",
+	Attributes:       "attributes",
+	CreatedAt:        "created_at",
+	Seq:              "seq",
+	PrevHash:         "prev_hash",
+	Hash:             "hash",
+}
+
+// ServiceAccountAuditEventExposed whitelists the columns of ServiceAccountAuditEvent exposed by the API.
+var ServiceAccountAuditEventExposed = NewSet(ServiceAccountAuditEvent.ID, ServiceAccountAuditEvent.ServiceAccountID, ServiceAccountAuditEvent.Event, ServiceAccountAuditEvent.ActorType, ServiceAccountAuditEvent.ActorID, ServiceAccountAuditEvent.SubjectID, ServiceAccountAuditEvent.Attributes, ServiceAccountAuditEvent.CreatedAt, ServiceAccountAuditEvent.Seq, ServiceAccountAuditEvent.PrevHash, ServiceAccountAuditEvent.Hash)
+
+// ServiceAccountRole lists the columns of the service_account_roles table.
+var ServiceAccountRole = struct {
+	ServiceAccountID Column
+	Role             Column
+	CreatedAt        Column
+	StartsAt         Column
+	EndsAt           Column
+}{
+	ServiceAccountID: "service_account_id",
+	Role:             "role",
+	CreatedAt:        "created_at",
+	StartsAt:         "starts_at",
+	EndsAt:           "ends_at",
+}
+
+// ServiceAccountRoleExposed whitelists the columns of ServiceAccountRole exposed by the API.
+var ServiceAccountRoleExposed = NewSet(ServiceAccountRole.Role, ServiceAccountRole.CreatedAt, ServiceAccountRole.StartsAt, ServiceAccountRole.EndsAt)
+
+// Session lists the columns of the sessions table.
+var Session = struct {
+	ID                Column
+	UserID            Column
+	RefreshToken      Column
+	UpstreamSessionID Column
+	UpstreamSubject   Column
+	IPAddress         Column
+	UserAgent         Column
+	ClientApp         Column
+	ClientVersion     Column
+	ClientPlatform    Column
+	CreatedAt         Column
+	LastUsedAt        Column
+	ExpiresAt         Column
+	RevokedAt         Column
+}{
+	ID:                "id",
+	UserID:            "user_id",
+	RefreshToken:      "refresh_token",
+	UpstreamSessionID: "upstream_session_id",
+	UpstreamSubject:   "upstream_subject",
+	IPAddress:         "ip_address",
+	UserAgent:         "user_agent",
+	ClientApp:         "client_app",
+	ClientVersion:     "client_version",
+	ClientPlatform:    "client_platform",
+	CreatedAt:         "created_at",
+	LastUsedAt:        "last_used_at",
+	ExpiresAt:         "expires_at",
+	RevokedAt:         "revoked_at",
+}
+
+// SessionExposed whitelists the columns of Session exposed by the API.
+var SessionExposed = NewSet(Session.ID, Session.IPAddress, Session.UserAgent, Session.ClientApp, Session.ClientVersion, Session.ClientPlatform, Session.CreatedAt, Session.LastUsedAt, Session.ExpiresAt)
+
+// TenantSettings lists the columns of the tenant_settings table.
+var TenantSettings = struct {
+	TenantID               Column
+	TokenExpiration        Column
+	RefreshTokenExpiration Column
+	LoginApprovalRequired  Column
+	PasswordMinLength      Column
+	PasswordMinClasses     Column
+	AppName                Column
+	LogoURL                Column
+	PrimaryColor           Column
+	AccentColor            Column
+	SupportEmail           Column
+	UpdatedBy              Column
+	CreatedAt              Column
+	UpdatedAt              Column
+}{
+	TenantID:               "tenant_id",
+	TokenExpiration:        "token_expiration",
+	RefreshTokenExpiration: "refresh_token_expiration",
+	LoginApprovalRequired:  "login_approval_required",
+	PasswordMinLength:      "password_min_length",
+	PasswordMinClasses:     "password_min_classes",
+	AppName:                "app_name",
+	LogoURL:                "logo_url",
+	PrimaryColor:           "primary_color",
+	AccentColor:            "accent_color",
+	SupportEmail:           "support_email",
+	UpdatedBy:              "updated_by",
+	CreatedAt:              "created_at",
+	UpdatedAt:              "updated_at",
+}
+
+// TenantSettingsExposed whitelists the columns of TenantSettings exposed by the API.
+var TenantSettingsExposed = NewSet(TenantSettings.TenantID, TenantSettings.TokenExpiration, TenantSettings.RefreshTokenExpiration, TenantSettings.LoginApprovalRequired, TenantSettings.PasswordMinLength, TenantSettings.PasswordMinClasses, TenantSettings.AppName, TenantSettings.LogoURL, TenantSettings.PrimaryColor, TenantSettings.AccentColor, TenantSettings.SupportEmail, TenantSettings.UpdatedBy, TenantSettings.CreatedAt, TenantSettings.UpdatedAt)
+
+// TokenUsageEndpoint lists the columns of the token_usage_endpoints table.
+var TokenUsageEndpoint = struct {
+	ClientID      Column
+	PrincipalType Column
+	Method        Column
+	Route         Column
+	Day           Column
+	Calls         Column
+	LastSeenAt    Column
+}{
+	ClientID:      "client_id",
+	PrincipalType: "principal_type",
+	Method:        "method",
+	Route:         "route",
+	Day:           "day",
+	Calls:         "calls",
+	LastSeenAt:    "last_seen_at",
+}
+
+// TokenUsageEndpointExposed whitelists the columns of TokenUsageEndpoint exposed by the API.
+var TokenUsageEndpointExposed = NewSet(TokenUsageEndpoint.ClientID, TokenUsageEndpoint.PrincipalType, TokenUsageEndpoint.Method, TokenUsageEndpoint.Route, TokenUsageEndpoint.Calls, TokenUsageEndpoint.LastSeenAt)
+
+// TokenUsageScope lists the columns of the token_usage_scopes table.
+var TokenUsageScope = struct {
+	ClientID     Column
+	Scope        Column
+	Day          Column
+	GrantedCalls Column
+	UsedCalls    Column
+	LastUsedAt   Column
+}{
+	ClientID:     "client_id",
+	Scope:        "scope",
+	Day:          "day",
+	GrantedCalls: "granted_calls",
+	UsedCalls:    "used_calls",
+	LastUsedAt:   "last_used_at",
+}
+
+// TokenUsageScopeExposed whitelists the columns of TokenUsageScope exposed by the API.
+var TokenUsageScopeExposed = NewSet(TokenUsageScope.ClientID, TokenUsageScope.Scope, TokenUsageScope.GrantedCalls, TokenUsageScope.UsedCalls, TokenUsageScope.LastUsedAt)
+
+// User lists the columns of the users table.
+var User = struct {
+	ID            Column
+	Username      Column
+	Password      Column
+	FullName      Column
+	Email         Column
+	VerifiedEmail Column
+	Phone         Column
+	RefreshToken  Column
+	IsActive      Column
+	MaxSessions   Column
+	CompromisedAt Column
+	CreatedAt     Column
+	UpdatedAt     Column
+}{
+	ID:            "id",
+	Username:      "username",
+	Password:      "password",
+	FullName:      "full_name",
+	Email:         "email",
+	VerifiedEmail: "verified_email",
+	Phone:         "phone",
+	RefreshToken:  "refresh_token",
+	IsActive:      "is_active",
+	MaxSessions:   "max_sessions",
+	CompromisedAt: "compromised_at",
+	CreatedAt:     "created_at",
+	UpdatedAt:     "updated_at",
+}
+
+// UserExposed whitelists the columns of User exposed by the API.
+var UserExposed = NewSet(User.ID, User.Username, User.FullName, User.Email, User.Phone, User.CompromisedAt)
+
+// UserIdentity lists the columns of the user_identities table.
+var UserIdentity = struct {
+	Provider    Column
+	Subject     Column
+	UserID      Column
+	Email       Column
+	CreatedAt   Column
+	LastLoginAt Column
+}{
+	Provider:    "provider",
+	Subject:     "subject",
+	UserID:      "user_id",
+	Email:       "email",
+	CreatedAt:   "created_at",
+	LastLoginAt: "last_login_at",
+}
+
+// UserIdentityExposed whitelists the columns of UserIdentity exposed by the API.
+var UserIdentityExposed = NewSet(UserIdentity.Provider, UserIdentity.Subject, UserIdentity.UserID, UserIdentity.Email, UserIdentity.CreatedAt, UserIdentity.LastLoginAt)
+
+// UserRole lists the columns of the user_roles table.
+var UserRole = struct {
+	UserID     Column
+	Role       Column
+	AssignedBy Column
+	CreatedAt  Column
+	StartsAt   Column
+	EndsAt     Column
+}{
+	UserID:     "user_id",
+	Role:       "role",
+	AssignedBy: "assigned_by",
+	CreatedAt:  "created_at",
+	StartsAt:   "starts_at",
+	EndsAt:     "ends_at",
+}
+
+// UserRoleExposed whitelists the columns of UserRole exposed by the API.
+var UserRoleExposed = NewSet(UserRole.UserID, UserRole.Role, UserRole.AssignedBy, UserRole.CreatedAt, UserRole.StartsAt, UserRole.EndsAt)
+
+// UserSyncLink lists the columns of the user_sync_links table.
+var UserSyncLink = struct {
+	Source     Column
+	ExternalID Column
+	UserID     Column
+	SyncedAt   Column
+}{
+	Source:     "source",
+	ExternalID: "external_id",
+	UserID:     "user_id",
+	SyncedAt:   "synced_at",
+}
+
+// UserSyncLinkExposed whitelists the columns of UserSyncLink exposed by the API.
+var UserSyncLinkExposed = NewSet(UserSyncLink.Source, UserSyncLink.ExternalID, UserSyncLink.UserID, UserSyncLink.SyncedAt)
+
+// UserSyncRun lists the columns of the user_sync_runs table.
+var UserSyncRun = struct {
+	ID          Column
+	Source      Column
+	DryRun      Column
+	Status      Column
+	Created     Column
+	Updated     Column
+	Deactivated Column
+	Skipped     Column
+	Unchanged   Column
+	Changes     Column
+	Error       Column
+	StartedAt   Column
+	FinishedAt  Column
+}{
+	ID:          "id",
+	Source:      "source",
+	DryRun:      "dry_run",
+	Status:      "status",
+	Created:     "created",
+	Updated:     "updated",
+	Deactivated: "deactivated",
+	Skipped:     "skipped",
+	Unchanged:   "unchanged",
+	Changes:     "changes",
+	Error:       "error",
+	StartedAt:   "started_at",
+	FinishedAt:  "finished_at",
+}
+
+// UserSyncRunExposed whitelists the columns of UserSyncRun exposed by the API.
+var UserSyncRunExposed = NewSet(UserSyncRun.ID, UserSyncRun.Source, UserSyncRun.DryRun, UserSyncRun.Status, UserSyncRun.Created, UserSyncRun.Updated, UserSyncRun.Deactivated, UserSyncRun.Skipped, UserSyncRun.Unchanged, UserSyncRun.Changes, UserSyncRun.Error, UserSyncRun.StartedAt, UserSyncRun.FinishedAt)
@@ -0,0 +1,115 @@
+// Command gen generates the typed columns of the domain entities from their bun schema.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go-hex/internal/domain"
+	"go/format"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/uptrace/bun/dialect/mysqldialect"
+	"github.com/uptrace/bun/schema"
+)
+
+// models lists the entities stored by the repositories
+var models = []interface{}{
+	domain.AuditChainHead{},
+	domain.AuditEvent{},
+	domain.BreakGlassAccount{},
+	domain.Broadcast{},
+	domain.BroadcastDelivery{},
+	domain.DeviceLogin{},
+	domain.Elevation{},
+	domain.EmailSuppression{},
+	domain.EmailVerification{},
+	domain.LegalHold{},
+	domain.LogVerbosity{},
+	domain.LoginApproval{},
+	domain.MessageTemplate{},
+	domain.Permission{},
+	domain.PasswordReset{},
+	domain.ProvisioningError{},
+	domain.ProvisioningRun{},
+	domain.ProvisioningState{},
+	domain.RefreshToken{},
+	domain.Role{},
+	domain.RolePermission{},
+	domain.SMSMessage{},
+	domain.ServiceAccount{},
+	domain.ServiceAccountAssertion{},
+	domain.ServiceAccountAuditArchive{},
+	domain.ServiceAccountAuditEvent{},
+	domain.ServiceAccountRole{},
+	domain.Session{},
+	domain.TenantSettings{},
+	domain.TokenUsageEndpoint{},
+	domain.TokenUsageScope{},
+	domain.User{},
+	domain.UserIdentity{},
+	domain.UserRole{},
+	domain.UserSyncLink{},
+	domain.UserSyncRun{},
+}
+
+func main() {
+	out := flag.String("out", "columns_gen.go", "generated file")
+	flag.Parse()
+
+	src, err := generate()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// generate returns the formatted source declaring the columns of every model
+func generate() ([]byte, error) {
+
+	tables := mysqldialect.New().Tables()
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by go generate; DO NOT EDIT.\n\n")
+	buf.WriteString("package column\n")
+
+	for _, model := range models {
+		typ := reflect.TypeOf(model)
+		table := tables.Get(typ)
+
+		var fields, values, exposed []string
+		for i := 0; i < typ.NumField(); i++ {
+			structField := typ.Field(i)
+			field := fieldByGoName(table.Fields, structField.Name)
+			if field == nil {
+				continue
+			}
+			fields = append(fields, fmt.Sprintf("\t%s Column\n", field.GoName))
+			values = append(values, fmt.Sprintf("\t%s: %q,\n", field.GoName, field.Name))
+			if name, _, _ := strings.Cut(structField.Tag.Get("json"), ","); name != "-" {
+				exposed = append(exposed, fmt.Sprintf("%s.%s", typ.Name(), field.GoName))
+			}
+		}
+
+		fmt.Fprintf(&buf, "\n// %s lists the columns of the %s table.\n", typ.Name(), table.Name)
+		fmt.Fprintf(&buf, "var %s = struct {\n%s}{\n%s}\n", typ.Name(), strings.Join(fields, ""), strings.Join(values, ""))
+		fmt.Fprintf(&buf, "\n// %sExposed whitelists the columns of %s exposed by the API.\n", typ.Name(), typ.Name())
+		fmt.Fprintf(&buf, "var %sExposed = NewSet(%s)\n", typ.Name(), strings.Join(exposed, ", "))
+	}
+
+	return format.Source(buf.Bytes())
+}
+
+func fieldByGoName(fields []*schema.Field, name string) *schema.Field {
+	for _, field := range fields {
+		if field.GoName == name {
+			return field
+		}
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGeneratedColumnsUpToDate fails when an entity changed without running go generate
+func TestGeneratedColumnsUpToDate(t *testing.T) {
+	want, err := generate()
+	assert.NoError(t, err)
+
+	got, err := os.ReadFile("../columns_gen.go")
+	assert.NoError(t, err)
+	assert.Equal(t, string(want), string(got), "run go generate ./internal/repository/sqlrepo/column")
+}
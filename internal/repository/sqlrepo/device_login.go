@@ -0,0 +1,170 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/sqlrepo/column"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/times"
+	"go-hex/shared/ierr"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/uptrace/bun"
+)
+
+// DeviceLoginRepository encapsulates the logic to access device logins from the data source.
+type DeviceLoginRepository struct {
+	db DBI
+}
+
+// NewDeviceLoginRepository creates a new device login repository
+func NewDeviceLoginRepository(db DBI) *DeviceLoginRepository {
+	return &DeviceLoginRepository{db}
+}
+
+// Create saves a new device login in the storage.
+func (r *DeviceLoginRepository) Create(ctx context.Context, deviceLogin domain.DeviceLogin) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.db.NewInsert().
+		Model(&deviceLogin).
+		Exec(ctx)
+	if err != nil {
+		if isDuplicateEntry(err) {
+			return ierr.ErrConflict
+		}
+		return errors.Wrap(err, "cannot create device login")
+	}
+	return nil
+}
+
+// GetByDeviceCode returns the device login with the specified hashed device code.
+func (r *DeviceLoginRepository) GetByDeviceCode(ctx context.Context, hashedDeviceCode string) (domain.DeviceLogin, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	return r.get(ctx, column.DeviceLogin.DeviceCode, hashedDeviceCode)
+}
+
+// GetByUserCode returns the device login with the specified user code.
+func (r *DeviceLoginRepository) GetByUserCode(ctx context.Context, userCode string) (domain.DeviceLogin, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	return r.get(ctx, column.DeviceLogin.UserCode, userCode)
+}
+
+func (r *DeviceLoginRepository) get(ctx context.Context, by column.Column, value string) (domain.DeviceLogin, error) {
+
+	var deviceLogin domain.DeviceLogin
+	err := r.db.
+		NewSelect().
+		Model(&deviceLogin).
+		Where("?=?", by, value).
+		Scan(ctx)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return domain.DeviceLogin{}, ierr.ErrResourceNotFound
+		}
+		return domain.DeviceLogin{}, errors.Wrap(err, "cannot get device login")
+	}
+
+	return deviceLogin, nil
+}
+
+// Decide approves or denies a pending device login on behalf of the user.
+// It returns false when the device login is not pending anymore.
+func (r *DeviceLoginRepository) Decide(ctx context.Context, deviceLoginID string, userID string, status string) (bool, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	res, err := r.db.NewUpdate().
+		Model((*domain.DeviceLogin)(nil)).
+		Set("?=?", column.DeviceLogin.Status, status).
+		Set("?=?", column.DeviceLogin.UserID, userID).
+		Set("?=?", column.DeviceLogin.DecidedAt, times.Now()).
+		Where("?=?", column.DeviceLogin.ID, deviceLoginID).
+		Where("?=?", column.DeviceLogin.Status, domain.DeviceLoginStatusPending).
+		Exec(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "cannot update device login")
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "cannot update device login")
+	}
+	return affected == 1, nil
+}
+
+// UpdateStatus moves the device login from one status to another.
+// It returns false when the device login is not in the expected status anymore.
+func (r *DeviceLoginRepository) UpdateStatus(ctx context.Context, deviceLoginID string, from string, to string) (bool, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	res, err := r.db.NewUpdate().
+		Model((*domain.DeviceLogin)(nil)).
+		Set("?=?", column.DeviceLogin.Status, to).
+		Where("?=?", column.DeviceLogin.ID, deviceLoginID).
+		Where("?=?", column.DeviceLogin.Status, from).
+		Exec(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "cannot update device login")
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "cannot update device login")
+	}
+	return affected == 1, nil
+}
+
+// UpdatePolledAt records the last time the device polled the device login.
+func (r *DeviceLoginRepository) UpdatePolledAt(ctx context.Context, deviceLoginID string, polledAt time.Time) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.db.NewUpdate().
+		Model((*domain.DeviceLogin)(nil)).
+		Set("?=?", column.DeviceLogin.LastPolledAt, polledAt).
+		Where("?=?", column.DeviceLogin.ID, deviceLoginID).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot update device login")
+	}
+	return nil
+}
+
+// DeleteExpired deletes the device logins expired before the specified time, except the ones of the held users.
+func (r *DeviceLoginRepository) DeleteExpired(ctx context.Context, before time.Time, heldUserIDs []string) (int64, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	q := r.db.NewDelete().
+		Model((*domain.DeviceLogin)(nil)).
+		Where("?<?", column.DeviceLogin.ExpiresAt, before)
+	if len(heldUserIDs) > 0 {
+		q.WhereGroup(" AND ", func(q *bun.DeleteQuery) *bun.DeleteQuery {
+			return q.Where("? IS NULL", column.DeviceLogin.UserID).
+				WhereOr("? NOT IN (?)", column.DeviceLogin.UserID, bun.In(heldUserIDs))
+		})
+	}
+
+	res, err := q.Exec(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "cannot delete expired device logins")
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,100 @@
+package sqlrepo
+
+import (
+	"go-hex/internal/repository/sqlrepo/column"
+	"go-hex/pkg/db"
+
+	"github.com/uptrace/bun/dialect"
+	"github.com/uptrace/bun/schema"
+)
+
+// The upserts are the only queries whose syntax differs between MySQL and PostgreSQL, the appenders below
+// render them for the dialect of the connection, as well as the follower reads of CockroachDB:
+//
+//	r.db.NewInsert().
+//		Model(&link).
+//		On("?", upsert(column.UserSyncLink.Source, column.UserSyncLink.ExternalID)).
+//		Set("? = ?", column.UserSyncLink.UserID, inserted(column.UserSyncLink.UserID))
+
+// upsert is the conflict clause of an insert updating the existing row.
+// MySQL detects the conflict on any unique key, PostgreSQL needs the key, the primary key of the table.
+func upsert(key ...column.Column) schema.QueryAppender {
+	return upsertClause(key)
+}
+
+type upsertClause []column.Column
+
+// AppendQuery implements schema.QueryAppender
+func (u upsertClause) AppendQuery(fmter schema.Formatter, b []byte) ([]byte, error) {
+	if !isPostgres(fmter) {
+		return append(b, "DUPLICATE KEY UPDATE"...), nil
+	}
+	b = append(b, "CONFLICT ("...)
+	for i, c := range u {
+		if i > 0 {
+			b = append(b, ", "...)
+		}
+		b = fmter.AppendIdent(b, string(c))
+	}
+	return append(b, ") DO UPDATE"...), nil
+}
+
+// inserted refers to the value an upsert inserts into the column.
+// The inserted values are referred to with VALUES() rather than the row alias of MySQL 8, which MariaDB does not support.
+func inserted(c column.Column) schema.QueryAppender {
+	return insertedValue(c)
+}
+
+type insertedValue column.Column
+
+// AppendQuery implements schema.QueryAppender
+func (c insertedValue) AppendQuery(fmter schema.Formatter, b []byte) ([]byte, error) {
+	if isPostgres(fmter) {
+		b = append(b, "EXCLUDED."...)
+		return fmter.AppendIdent(b, string(c)), nil
+	}
+	b = append(b, "VALUES("...)
+	b = fmter.AppendIdent(b, string(c))
+	return append(b, ')'), nil
+}
+
+// existing refers to the value of the column in the row an upsert updates.
+// PostgreSQL needs the column to be qualified by the alias of the table, the inserted row having the same columns.
+func existing(c column.Column) schema.QueryAppender {
+	return existingValue(c)
+}
+
+type existingValue column.Column
+
+// AppendQuery implements schema.QueryAppender
+func (c existingValue) AppendQuery(fmter schema.Formatter, b []byte) ([]byte, error) {
+	if isPostgres(fmter) {
+		return fmter.AppendQuery(b, "?TableAlias.?", column.Column(c)), nil
+	}
+	return fmter.AppendIdent(b, string(c)), nil
+}
+
+// followerRead is the clause of the read-only analytics queries reading the rows as of a few seconds ago, which
+// CockroachDB serves from the nearest replica without contending with the writes; the other servers read the current
+// rows. It follows the table of the query, which cannot run in a transaction:
+//
+//	r.db.NewSelect().
+//		Model(&usages).
+//		ModelTableExpr("?TableName AS ?TableAlias?", followerRead())
+func followerRead() schema.QueryAppender {
+	return followerReadClause{}
+}
+
+type followerReadClause struct{}
+
+// AppendQuery implements schema.QueryAppender
+func (followerReadClause) AppendQuery(fmter schema.Formatter, b []byte) ([]byte, error) {
+	if _, ok := fmter.Dialect().(*db.CockroachDialect); !ok {
+		return b, nil
+	}
+	return append(b, " AS OF SYSTEM TIME follower_read_timestamp()"...), nil
+}
+
+func isPostgres(fmter schema.Formatter) bool {
+	return fmter.Dialect().Name() == dialect.PG
+}
@@ -0,0 +1,90 @@
+package sqlrepo
+
+import (
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/sqlrepo/column"
+	"go-hex/pkg/db"
+	"go-hex/pkg/db/dbtest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/mysqldialect"
+	"github.com/uptrace/bun/dialect/pgdialect"
+	"github.com/uptrace/bun/schema"
+)
+
+func TestUpsertDialects(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect schema.Dialect
+		want    string
+	}{
+		{
+			name:    "mysql",
+			dialect: mysqldialect.New(),
+			want: "ON DUPLICATE KEY UPDATE `granted_calls` = `granted_calls` + VALUES(`granted_calls`), " +
+				"`last_used_at` = COALESCE(GREATEST(`last_used_at`, VALUES(`last_used_at`)), `last_used_at`, VALUES(`last_used_at`))",
+		},
+		{
+			name:    "postgres",
+			dialect: pgdialect.New(),
+			want: `ON CONFLICT ("client_id", "scope", "day") DO UPDATE SET "granted_calls" = "token_usage_scope"."granted_calls" + EXCLUDED."granted_calls", ` +
+				`"last_used_at" = COALESCE(GREATEST("token_usage_scope"."last_used_at", EXCLUDED."last_used_at"), "token_usage_scope"."last_used_at", EXCLUDED."last_used_at")`,
+		},
+		{
+			name:    "cockroachdb",
+			dialect: db.NewCockroachDialect(),
+			want:    `ON CONFLICT ("client_id", "scope", "day") DO UPDATE SET "granted_calls" = "token_usage_scope"."granted_calls" + EXCLUDED."granted_calls"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bunDB := bun.NewDB(dbtest.NewBlockingDriver().DB(), tt.dialect)
+			defer bunDB.Close()
+
+			usages := []domain.TokenUsageScope{{ClientID: "client-1", Scope: "users:read", GrantedCalls: 1}}
+			query := bunDB.NewInsert().
+				Model(&usages).
+				On("?", upsert(column.TokenUsageScope.ClientID, column.TokenUsageScope.Scope, column.TokenUsageScope.Day)).
+				Set("? = ? + ?", column.TokenUsageScope.GrantedCalls, existing(column.TokenUsageScope.GrantedCalls), inserted(column.TokenUsageScope.GrantedCalls)).
+				Set("? = COALESCE(GREATEST(?, ?), ?, ?)", column.TokenUsageScope.LastUsedAt, existing(column.TokenUsageScope.LastUsedAt), inserted(column.TokenUsageScope.LastUsedAt), existing(column.TokenUsageScope.LastUsedAt), inserted(column.TokenUsageScope.LastUsedAt)).
+				String()
+
+			assert.Contains(t, query, tt.want)
+		})
+	}
+}
+
+func TestFollowerReadDialects(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect schema.Dialect
+		want    string
+	}{
+		{name: "mysql", dialect: mysqldialect.New(), want: "FROM `token_usage_scopes` AS `token_usage_scope` WHERE"},
+		{name: "postgres", dialect: pgdialect.New(), want: `FROM "token_usage_scopes" AS "token_usage_scope" WHERE`},
+		{
+			name:    "cockroachdb",
+			dialect: db.NewCockroachDialect(),
+			want:    `FROM "token_usage_scopes" AS "token_usage_scope" AS OF SYSTEM TIME follower_read_timestamp() WHERE`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bunDB := bun.NewDB(dbtest.NewBlockingDriver().DB(), tt.dialect)
+			defer bunDB.Close()
+
+			usages := []domain.TokenUsageScope{}
+			query := bunDB.NewSelect().
+				Model(&usages).
+				ModelTableExpr("?TableName AS ?TableAlias?", followerRead()).
+				Where("? = ?", column.TokenUsageScope.ClientID, "client-1").
+				String()
+
+			assert.Contains(t, query, tt.want)
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/sqlrepo/column"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/times"
+	"go-hex/shared/ierr"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ElevationRepository encapsulates the logic to access elevations from the data source.
+type ElevationRepository struct {
+	db DBI
+}
+
+// NewElevationRepository creates a new elevation repository
+func NewElevationRepository(db DBI) *ElevationRepository {
+	return &ElevationRepository{db}
+}
+
+// Create saves a new elevation in the storage.
+func (r *ElevationRepository) Create(ctx context.Context, elevation domain.Elevation) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.db.NewInsert().
+		Model(&elevation).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot create elevation")
+	}
+	return nil
+}
+
+// GetByID returns the elevation with the specified ID.
+func (r *ElevationRepository) GetByID(ctx context.Context, elevationID string) (domain.Elevation, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	var elevation domain.Elevation
+	err := r.db.
+		NewSelect().
+		Model(&elevation).
+		Where("?=?", column.Elevation.ID, elevationID).
+		Scan(ctx)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return domain.Elevation{}, ierr.ErrResourceNotFound
+		}
+		return domain.Elevation{}, errors.Wrap(err, "cannot get elevation")
+	}
+
+	return elevation, nil
+}
+
+// ListByUserID returns the elevations requested by the specified user, the newest first.
+func (r *ElevationRepository) ListByUserID(ctx context.Context, userID string) ([]domain.Elevation, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	elevations := []domain.Elevation{}
+	err := r.db.
+		NewSelect().
+		Model(&elevations).
+		Where("?=?", column.Elevation.UserID, userID).
+		OrderExpr("? DESC", column.Elevation.RequestedAt).
+		Scan(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list elevations")
+	}
+
+	return elevations, nil
+}
+
+// ListPending returns the pending and unexpired elevations, the oldest first.
+func (r *ElevationRepository) ListPending(ctx context.Context) ([]domain.Elevation, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	elevations := []domain.Elevation{}
+	err := r.db.
+		NewSelect().
+		Model(&elevations).
+		Where("?=?", column.Elevation.Status, domain.ElevationStatusPending).
+		Where("?>?", column.Elevation.ExpiresAt, times.Now()).
+		OrderExpr("? ASC", column.Elevation.RequestedAt).
+		Scan(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list pending elevations")
+	}
+
+	return elevations, nil
+}
+
+// ListActiveByUserID returns the approved elevations of the specified user granting their role at the specified time.
+func (r *ElevationRepository) ListActiveByUserID(ctx context.Context, userID string, at time.Time) ([]domain.Elevation, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	elevations := []domain.Elevation{}
+	err := r.db.
+		NewSelect().
+		Model(&elevations).
+		Where("?=?", column.Elevation.UserID, userID).
+		Where("?=?", column.Elevation.Status, domain.ElevationStatusApproved).
+		Where("?>?", column.Elevation.EndsAt, at).
+		OrderExpr("? ASC", column.Elevation.EndsAt).
+		Scan(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list active elevations")
+	}
+
+	return elevations, nil
+}
+
+// Decide records the decision of a pending and unexpired elevation.
+// It returns false when the elevation is not pending anymore or expired.
+func (r *ElevationRepository) Decide(ctx context.Context, elevation domain.Elevation) (bool, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	res, err := r.db.NewUpdate().
+		Model((*domain.Elevation)(nil)).
+		Set("?=?", column.Elevation.Status, elevation.Status).
+		Set("?=?", column.Elevation.DecidedBy, elevation.DecidedBy).
+		Set("?=?", column.Elevation.DecidedAt, elevation.DecidedAt).
+		Set("?=?", column.Elevation.Comment, elevation.Comment).
+		Set("?=?", column.Elevation.EndsAt, elevation.EndsAt).
+		Where("?=?", column.Elevation.ID, elevation.ID).
+		Where("?=?", column.Elevation.Status, domain.ElevationStatusPending).
+		Where("?>?", column.Elevation.ExpiresAt, elevation.DecidedAt).
+		Exec(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "cannot decide elevation")
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "cannot decide elevation")
+	}
+	return affected == 1, nil
+}
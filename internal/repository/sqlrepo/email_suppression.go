@@ -0,0 +1,107 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/sqlrepo/column"
+	"go-hex/pkg/otel"
+	"go-hex/shared/ierr"
+
+	"github.com/pkg/errors"
+)
+
+// EmailSuppressionRepository encapsulates the logic to access the email suppressions from the data source.
+type EmailSuppressionRepository struct {
+	db DBI
+}
+
+// NewEmailSuppressionRepository creates a new email suppression repository
+func NewEmailSuppressionRepository(db DBI) *EmailSuppressionRepository {
+	return &EmailSuppressionRepository{db}
+}
+
+// Record saves the suppression of an address, or counts one more feedback and replaces
+// the reason, the provider and the detail of the suppression when the address is already suppressed.
+func (r *EmailSuppressionRepository) Record(ctx context.Context, suppression domain.EmailSuppression) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.db.NewInsert().
+		Model(&suppression).
+		On("?", upsert(column.EmailSuppression.Address)).
+		Set("? = ?", column.EmailSuppression.Reason, inserted(column.EmailSuppression.Reason)).
+		Set("? = ?", column.EmailSuppression.Provider, inserted(column.EmailSuppression.Provider)).
+		Set("? = ?", column.EmailSuppression.Detail, inserted(column.EmailSuppression.Detail)).
+		Set("? = ? + 1", column.EmailSuppression.Feedbacks, existing(column.EmailSuppression.Feedbacks)).
+		Set("? = ?", column.EmailSuppression.UpdatedAt, inserted(column.EmailSuppression.UpdatedAt)).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot record email suppression")
+	}
+	return nil
+}
+
+// GetByAddress returns the suppression of the address.
+func (r *EmailSuppressionRepository) GetByAddress(ctx context.Context, address string) (domain.EmailSuppression, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	var suppression domain.EmailSuppression
+	err := r.db.NewSelect().
+		Model(&suppression).
+		Where("?=?", column.EmailSuppression.Address, address).
+		Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return suppression, ierr.ErrResourceNotFound
+		}
+		return suppression, errors.Wrap(err, "cannot get email suppression")
+	}
+	return suppression, nil
+}
+
+// List returns the suppressions, the most recently updated first.
+func (r *EmailSuppressionRepository) List(ctx context.Context, limit int, offset int) ([]domain.EmailSuppression, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	suppressions := []domain.EmailSuppression{}
+	err := r.db.NewSelect().
+		Model(&suppressions).
+		OrderExpr("? DESC, ?", column.EmailSuppression.UpdatedAt, column.EmailSuppression.Address).
+		Limit(limit).
+		Offset(offset).
+		Scan(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list email suppressions")
+	}
+	return suppressions, nil
+}
+
+// Delete deletes the suppression of the address.
+func (r *EmailSuppressionRepository) Delete(ctx context.Context, address string) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	res, err := r.db.NewDelete().
+		Model((*domain.EmailSuppression)(nil)).
+		Where("?=?", column.EmailSuppression.Address, address).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot delete email suppression")
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "cannot delete email suppression")
+	}
+	if affected == 0 {
+		return ierr.ErrResourceNotFound
+	}
+	return nil
+}
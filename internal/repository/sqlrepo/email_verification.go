@@ -0,0 +1,86 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/sqlrepo/column"
+	"go-hex/pkg/otel"
+	"go-hex/shared/ierr"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// EmailVerificationRepository encapsulates the logic to access the email verification tokens from the data source.
+type EmailVerificationRepository struct {
+	db DBI
+}
+
+// NewEmailVerificationRepository creates a new email verification repository
+func NewEmailVerificationRepository(db DBI) *EmailVerificationRepository {
+	return &EmailVerificationRepository{db}
+}
+
+// Create saves a new email verification token.
+func (r *EmailVerificationRepository) Create(ctx context.Context, verification domain.EmailVerification) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.db.NewInsert().
+		Model(&verification).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot create email verification")
+	}
+	return nil
+}
+
+// GetByTokenHash returns the email verification with the specified token hash.
+func (r *EmailVerificationRepository) GetByTokenHash(ctx context.Context, tokenHash string) (domain.EmailVerification, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	var verification domain.EmailVerification
+	err := r.db.
+		NewSelect().
+		Model(&verification).
+		Where("?=?", column.EmailVerification.TokenHash, tokenHash).
+		Scan(ctx)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return domain.EmailVerification{}, ierr.ErrResourceNotFound
+		}
+		return domain.EmailVerification{}, errors.Wrap(err, "cannot get email verification")
+	}
+
+	return verification, nil
+}
+
+// Verify marks the email verification as verified if it is still usable at the specified time.
+// It returns false when it was already verified or expired.
+func (r *EmailVerificationRepository) Verify(ctx context.Context, verificationID string, at time.Time) (bool, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	res, err := r.db.NewUpdate().
+		Model((*domain.EmailVerification)(nil)).
+		Set("?=?", column.EmailVerification.VerifiedAt, at).
+		Where("?=?", column.EmailVerification.ID, verificationID).
+		Where("? IS NULL", column.EmailVerification.VerifiedAt).
+		Where("?>?", column.EmailVerification.ExpiresAt, at).
+		Exec(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "cannot verify email verification")
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "cannot verify email verification")
+	}
+	return affected == 1, nil
+}
@@ -0,0 +1,55 @@
+package sqlrepo
+
+import (
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/uptrace/bun"
+)
+
+const (
+	// mysqlErrDuplicateEntry is the MySQL error number of a unique key violation
+	mysqlErrDuplicateEntry = 1062
+	// mysqlErrLockDeadlock is the MySQL error number of a transaction rolled back to resolve a deadlock
+	mysqlErrLockDeadlock = 1213
+	// pqErrUniqueViolation is the PostgreSQL error code of a unique key violation
+	pqErrUniqueViolation = "23505"
+	// pqErrDeadlockDetected is the PostgreSQL error code of a transaction rolled back to resolve a deadlock
+	pqErrDeadlockDetected = "40P01"
+	// pqErrSerializationFailure is the PostgreSQL error code of a transaction rolled back to serialize it with the
+	// concurrent ones, which CockroachDB reports for any conflict between transactions
+	pqErrSerializationFailure = "40001"
+)
+
+// DBI is a DB interface implemented by *DB and *Tx.
+type DBI interface {
+	NewValues(model interface{}) *bun.ValuesQuery
+	NewSelect() *bun.SelectQuery
+	NewInsert() *bun.InsertQuery
+	NewUpdate() *bun.UpdateQuery
+	NewDelete() *bun.DeleteQuery
+	NewCreateTable() *bun.CreateTableQuery
+	NewDropTable() *bun.DropTableQuery
+	NewCreateIndex() *bun.CreateIndexQuery
+	NewDropIndex() *bun.DropIndexQuery
+	NewTruncateTable() *bun.TruncateTableQuery
+	NewAddColumn() *bun.AddColumnQuery
+	NewDropColumn() *bun.DropColumnQuery
+}
+
+// isDuplicateEntry checks whether the error is a unique key violation.
+func isDuplicateEntry(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	var pqErr *pq.Error
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrDuplicateEntry ||
+		errors.As(err, &pqErr) && pqErr.Code == pqErrUniqueViolation
+}
+
+// isRetryable checks whether the transaction was rolled back by the server to resolve a deadlock or a serialization
+// failure, so that it succeeds when run again.
+func isRetryable(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	var pqErr *pq.Error
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrLockDeadlock ||
+		errors.As(err, &pqErr) && (pqErr.Code == pqErrDeadlockDetected || pqErr.Code == pqErrSerializationFailure)
+}
@@ -0,0 +1,151 @@
+//go:build integration
+
+package sqlrepo
+
+import (
+	"context"
+	"fmt"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/migrations"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/db"
+	"go-hex/shared/ierr"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+)
+
+// The integration tests run the repositories against the database given by the DB_* variables,
+// once per server:
+//
+//	DB_DRIVER=mysql DB_HOST=127.0.0.1 DB_PORT=3306 ... go test -tags integration ./internal/repository/sqlrepo
+//	DB_DRIVER=postgres DB_HOST=127.0.0.1 DB_PORT=5432 ... go test -tags integration ./internal/repository/sqlrepo
+//
+// The migrations are applied first, the records created use unique keys so that the database can be reused.
+func openIntegrationDB(t *testing.T) *bun.DB {
+	if os.Getenv("DB_HOST") == "" {
+		t.Skip("DB_HOST is not set")
+	}
+
+	var driver configs.DatabaseDriver
+	require.NoError(t, driver.Decode(getenv("DB_DRIVER", configs.DatabaseDriverMySQL)))
+	bunDB, err := db.NewBunConn("test", os.Getenv("DB_HOST"), os.Getenv("DB_PORT"), os.Getenv("DB_USERNAME"), os.Getenv("DB_PASSWORD"), os.Getenv("DB_NAME"),
+		db.WithDriver(driver))
+	require.NoError(t, err)
+	t.Cleanup(func() { bunDB.Close() })
+
+	_, err = migrations.NewRunner(bunDB).Up()
+	require.NoError(t, err)
+	return bunDB
+}
+
+func getenv(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func unique(prefix string) string {
+	return fmt.Sprintf("%s-%d", prefix, time.Now().UnixNano())
+}
+
+func TestIntegrationUserConflict(t *testing.T) {
+	ctx := context.Background()
+	repo := NewUserRepository(openIntegrationDB(t))
+	now := time.Now().UTC().Truncate(time.Second)
+
+	user := domain.User{ID: unique("user"), Username: unique("alice"), Password: "hash", IsActive: true, CreatedAt: now, UpdatedAt: now}
+	require.NoError(t, repo.Create(ctx, user))
+	assert.Equal(t, ierr.ErrUserAlreadyRegistered, repo.Create(ctx, domain.User{ID: unique("user"), Username: user.Username, CreatedAt: now, UpdatedAt: now}))
+
+	got, err := repo.GetByUsername(ctx, user.Username)
+	if assert.NoError(t, err) {
+		assert.Equal(t, user.ID, got.ID)
+		assert.True(t, got.IsActive)
+		assert.True(t, now.Equal(got.CreatedAt))
+	}
+}
+
+func TestIntegrationRoleAssignIgnoresDuplicates(t *testing.T) {
+	ctx := context.Background()
+	bunDB := openIntegrationDB(t)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	user := domain.User{ID: unique("user"), Username: unique("bob"), CreatedAt: now, UpdatedAt: now}
+	require.NoError(t, NewUserRepository(bunDB).Create(ctx, user))
+
+	repo := NewRoleRepository(bunDB)
+	assert.NoError(t, repo.Assign(ctx, domain.UserRole{UserID: user.ID, Role: "user", CreatedAt: now}))
+	assert.NoError(t, repo.Assign(ctx, domain.UserRole{UserID: user.ID, Role: "user", CreatedAt: now}))
+	roles, err := repo.ListByUserID(ctx, user.ID)
+	assert.NoError(t, err)
+	assert.Len(t, roles, 1)
+}
+
+func TestIntegrationUpserts(t *testing.T) {
+	ctx := context.Background()
+	bunDB := openIntegrationDB(t)
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+	seen := day.Add(time.Hour)
+
+	usages := NewTokenUsageRepository(bunDB)
+	clientID := unique("client")
+	endpoint := domain.TokenUsageEndpoint{ClientID: clientID, PrincipalType: "user", Method: "GET", Route: "/users", Day: day, Calls: 2, LastSeenAt: seen}
+	require.NoError(t, usages.IncrementEndpoints(ctx, []domain.TokenUsageEndpoint{endpoint}))
+	endpoint.Calls, endpoint.LastSeenAt = 3, seen.Add(-time.Minute)
+	require.NoError(t, usages.IncrementEndpoints(ctx, []domain.TokenUsageEndpoint{endpoint}))
+	require.NoError(t, usages.IncrementScopes(ctx, []domain.TokenUsageScope{{ClientID: clientID, Scope: "users:read", Day: day, GrantedCalls: 1, LastUsedAt: &seen}}))
+	require.NoError(t, usages.IncrementScopes(ctx, []domain.TokenUsageScope{{ClientID: clientID, Scope: "users:read", Day: day, GrantedCalls: 1}}))
+
+	endpoints, err := usages.ListEndpoints(ctx, clientID, day, day)
+	if assert.NoError(t, err) && assert.Len(t, endpoints, 1) {
+		assert.Equal(t, int64(5), endpoints[0].Calls)
+		assert.True(t, seen.Equal(endpoints[0].LastSeenAt), "the latest call is kept")
+	}
+	scopes, err := usages.ListScopes(ctx, clientID, day, day)
+	if assert.NoError(t, err) && assert.Len(t, scopes, 1) {
+		assert.Equal(t, int64(2), scopes[0].GrantedCalls)
+		if assert.NotNil(t, scopes[0].LastUsedAt, "a missing last use keeps the previous one") {
+			assert.True(t, seen.Equal(*scopes[0].LastUsedAt))
+		}
+	}
+
+	suppressions := NewEmailSuppressionRepository(bunDB)
+	address := unique("carol") + "@example.com"
+	require.NoError(t, suppressions.Record(ctx, domain.EmailSuppression{Address: address, Reason: "bounce", Provider: "ses", Feedbacks: 1, CreatedAt: day, UpdatedAt: day}))
+	require.NoError(t, suppressions.Record(ctx, domain.EmailSuppression{Address: address, Reason: "complaint", Provider: "sendgrid", Feedbacks: 1, CreatedAt: seen, UpdatedAt: seen}))
+	suppression, err := suppressions.GetByAddress(ctx, address)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "complaint", suppression.Reason)
+		assert.Equal(t, 2, suppression.Feedbacks)
+		assert.True(t, day.Equal(suppression.CreatedAt), "the creation is kept")
+	}
+	assert.NoError(t, suppressions.Delete(ctx, address))
+}
+
+func TestIntegrationTransactionRollback(t *testing.T) {
+	ctx := context.Background()
+	bunDB := openIntegrationDB(t)
+	now := time.Now().UTC().Truncate(time.Second)
+	username := unique("dave")
+
+	failure := errors.New("failure")
+	_, err := NewRepositoryRegistry(bunDB).DoInTransaction(ctx, func(ctx context.Context, registry port.RepositoryRegistry) (interface{}, error) {
+		if err := registry.GetUserRepository().Create(ctx, domain.User{ID: unique("user"), Username: username, CreatedAt: now, UpdatedAt: now}); err != nil {
+			return nil, err
+		}
+		return nil, failure
+	})
+	assert.Equal(t, failure, err)
+
+	exists, err := NewUserRepository(bunDB).IsUserExistByUsername(ctx, username)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
@@ -0,0 +1,121 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/sqlrepo/column"
+	"go-hex/pkg/otel"
+	"go-hex/shared/ierr"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// LegalHoldRepository encapsulates the logic to access the legal holds from the data source.
+type LegalHoldRepository struct {
+	db DBI
+}
+
+// NewLegalHoldRepository creates a new legal hold repository
+func NewLegalHoldRepository(db DBI) *LegalHoldRepository {
+	return &LegalHoldRepository{db}
+}
+
+// Create saves a new legal hold in the storage.
+func (r *LegalHoldRepository) Create(ctx context.Context, hold domain.LegalHold) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.db.NewInsert().
+		Model(&hold).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot create legal hold")
+	}
+	return nil
+}
+
+// GetByID returns the legal hold with the specified ID.
+func (r *LegalHoldRepository) GetByID(ctx context.Context, id string) (domain.LegalHold, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	var hold domain.LegalHold
+	err := r.db.NewSelect().
+		Model(&hold).
+		Where("?=?", column.LegalHold.ID, id).
+		Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return hold, ierr.ErrResourceNotFound
+		}
+		return hold, errors.Wrap(err, "cannot get legal hold")
+	}
+	return hold, nil
+}
+
+// ListByUserID returns the legal holds of a user, released or not, the newest first.
+func (r *LegalHoldRepository) ListByUserID(ctx context.Context, userID string) ([]domain.LegalHold, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	holds := []domain.LegalHold{}
+	err := r.db.NewSelect().
+		Model(&holds).
+		Where("?=?", column.LegalHold.UserID, userID).
+		OrderExpr("? DESC", column.LegalHold.PlacedAt).
+		Scan(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list legal holds")
+	}
+	return holds, nil
+}
+
+// ListHeldUserIDs returns the ids of the users with a legal hold not released yet.
+func (r *LegalHoldRepository) ListHeldUserIDs(ctx context.Context) ([]string, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	userIDs := []string{}
+	err := r.db.NewSelect().
+		Model((*domain.LegalHold)(nil)).
+		Distinct().
+		ColumnExpr("?", column.LegalHold.UserID).
+		Where("? IS NULL", column.LegalHold.ReleasedAt).
+		Scan(ctx, &userIDs)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list held users")
+	}
+	return userIDs, nil
+}
+
+// Release releases the legal hold on behalf of an admin.
+// It returns false when the legal hold was already released.
+func (r *LegalHoldRepository) Release(ctx context.Context, id string, releasedBy string, reason string, releasedAt time.Time) (bool, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	res, err := r.db.NewUpdate().
+		Model((*domain.LegalHold)(nil)).
+		Set("?=?", column.LegalHold.ReleasedBy, releasedBy).
+		Set("?=?", column.LegalHold.ReleaseReason, reason).
+		Set("?=?", column.LegalHold.ReleasedAt, releasedAt).
+		Where("?=?", column.LegalHold.ID, id).
+		Where("? IS NULL", column.LegalHold.ReleasedAt).
+		Exec(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "cannot release legal hold")
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "cannot release legal hold")
+	}
+	return affected == 1, nil
+}
@@ -0,0 +1,95 @@
+package sqlrepo
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/sqlrepo/column"
+	"go-hex/pkg/otel"
+	"go-hex/shared/ierr"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// LogVerbosityRepository encapsulates the logic to access the log verbosities from the data source.
+type LogVerbosityRepository struct {
+	db DBI
+}
+
+// NewLogVerbosityRepository creates a new log verbosity repository
+func NewLogVerbosityRepository(db DBI) *LogVerbosityRepository {
+	return &LogVerbosityRepository{db}
+}
+
+// Create saves a new log verbosity in the storage.
+func (r *LogVerbosityRepository) Create(ctx context.Context, verbosity domain.LogVerbosity) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.db.NewInsert().
+		Model(&verbosity).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot create log verbosity")
+	}
+	return nil
+}
+
+// ListActive returns the log verbosities not expired at the specified time, from the oldest.
+func (r *LogVerbosityRepository) ListActive(ctx context.Context, now time.Time) ([]domain.LogVerbosity, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	verbosities := []domain.LogVerbosity{}
+	err := r.db.NewSelect().
+		Model(&verbosities).
+		Where("?>?", column.LogVerbosity.ExpiresAt, now).
+		OrderExpr("? ASC", column.LogVerbosity.CreatedAt).
+		Scan(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list log verbosities")
+	}
+	return verbosities, nil
+}
+
+// Delete deletes the log verbosity with the specified id.
+func (r *LogVerbosityRepository) Delete(ctx context.Context, id string) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	res, err := r.db.NewDelete().
+		Model((*domain.LogVerbosity)(nil)).
+		Where("?=?", column.LogVerbosity.ID, id).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot delete log verbosity")
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "cannot delete log verbosity")
+	}
+	if affected == 0 {
+		return ierr.ErrResourceNotFound
+	}
+	return nil
+}
+
+// DeleteExpired deletes the log verbosities expired before the specified time.
+func (r *LogVerbosityRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	res, err := r.db.NewDelete().
+		Model((*domain.LogVerbosity)(nil)).
+		Where("?<?", column.LogVerbosity.ExpiresAt, before).
+		Exec(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "cannot delete expired log verbosities")
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,130 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/sqlrepo/column"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/times"
+	"go-hex/shared/ierr"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/uptrace/bun"
+)
+
+// LoginApprovalRepository encapsulates the logic to access login approvals from the data source.
+type LoginApprovalRepository struct {
+	db DBI
+}
+
+// NewLoginApprovalRepository creates a new login approval repository
+func NewLoginApprovalRepository(db DBI) *LoginApprovalRepository {
+	return &LoginApprovalRepository{db}
+}
+
+// Create saves a new login approval in the storage.
+func (r *LoginApprovalRepository) Create(ctx context.Context, approval domain.LoginApproval) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.db.NewInsert().
+		Model(&approval).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot create login approval")
+	}
+	return nil
+}
+
+// GetByID returns the login approval with the specified ID.
+func (r *LoginApprovalRepository) GetByID(ctx context.Context, approvalID string) (domain.LoginApproval, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	var approval domain.LoginApproval
+	err := r.db.
+		NewSelect().
+		Model(&approval).
+		Where("?=?", column.LoginApproval.ID, approvalID).
+		Scan(ctx)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return domain.LoginApproval{}, ierr.ErrResourceNotFound
+		}
+		return domain.LoginApproval{}, errors.Wrap(err, "cannot get login approval")
+	}
+
+	return approval, nil
+}
+
+// ListPendingByUserID returns the pending and unexpired login approvals of the specified user.
+func (r *LoginApprovalRepository) ListPendingByUserID(ctx context.Context, userID string) ([]domain.LoginApproval, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	approvals := []domain.LoginApproval{}
+	err := r.db.
+		NewSelect().
+		Model(&approvals).
+		Where("?=?", column.LoginApproval.UserID, userID).
+		Where("?=?", column.LoginApproval.Status, domain.LoginApprovalStatusPending).
+		Where("?>?", column.LoginApproval.ExpiresAt, times.Now()).
+		OrderExpr("? DESC", column.LoginApproval.CreatedAt).
+		Scan(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list login approvals")
+	}
+
+	return approvals, nil
+}
+
+// UpdateStatus moves the login approval from one status to another.
+// It returns false when the approval is not in the expected status anymore.
+func (r *LoginApprovalRepository) UpdateStatus(ctx context.Context, approvalID string, from string, to string) (bool, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	res, err := r.db.NewUpdate().
+		Model((*domain.LoginApproval)(nil)).
+		Set("?=?", column.LoginApproval.Status, to).
+		Set("?=?", column.LoginApproval.DecidedAt, times.Now()).
+		Where("?=?", column.LoginApproval.ID, approvalID).
+		Where("?=?", column.LoginApproval.Status, from).
+		Exec(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "cannot update login approval")
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "cannot update login approval")
+	}
+	return affected == 1, nil
+}
+
+// DeleteExpired deletes the login approvals expired before the specified time, except the ones of the held users.
+func (r *LoginApprovalRepository) DeleteExpired(ctx context.Context, before time.Time, heldUserIDs []string) (int64, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	q := r.db.NewDelete().
+		Model((*domain.LoginApproval)(nil)).
+		Where("?<?", column.LoginApproval.ExpiresAt, before)
+	if len(heldUserIDs) > 0 {
+		q.Where("? NOT IN (?)", column.LoginApproval.UserID, bun.In(heldUserIDs))
+	}
+
+	res, err := q.Exec(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "cannot delete expired login approvals")
+	}
+	return res.RowsAffected()
+}
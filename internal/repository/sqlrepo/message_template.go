@@ -0,0 +1,83 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/sqlrepo/column"
+	"go-hex/pkg/otel"
+	"go-hex/shared/ierr"
+
+	"github.com/pkg/errors"
+)
+
+// MessageTemplateRepository encapsulates the logic to access the message template overrides from the data source.
+type MessageTemplateRepository struct {
+	db DBI
+}
+
+// NewMessageTemplateRepository creates a new message template repository
+func NewMessageTemplateRepository(db DBI) *MessageTemplateRepository {
+	return &MessageTemplateRepository{db}
+}
+
+// Create saves a new version of a message template in the storage,
+// it returns ierr.ErrConflict when the version already exists.
+func (r *MessageTemplateRepository) Create(ctx context.Context, tmpl domain.MessageTemplate) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.db.NewInsert().
+		Model(&tmpl).
+		Exec(ctx)
+	if err != nil {
+		if isDuplicateEntry(err) {
+			return ierr.ErrConflict
+		}
+		return errors.Wrap(err, "cannot create message template")
+	}
+	return nil
+}
+
+// GetLatest returns the latest version of the message template of a channel.
+func (r *MessageTemplateRepository) GetLatest(ctx context.Context, name string, channel string) (domain.MessageTemplate, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	var tmpl domain.MessageTemplate
+	err := r.db.NewSelect().
+		Model(&tmpl).
+		Where("?=?", column.MessageTemplate.Name, name).
+		Where("?=?", column.MessageTemplate.Channel, channel).
+		OrderExpr("? DESC", column.MessageTemplate.Version).
+		Limit(1).
+		Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return tmpl, ierr.ErrResourceNotFound
+		}
+		return tmpl, errors.Wrap(err, "cannot get message template")
+	}
+	return tmpl, nil
+}
+
+// ListVersions returns the versions of the message template of a channel, the latest first.
+func (r *MessageTemplateRepository) ListVersions(ctx context.Context, name string, channel string) ([]domain.MessageTemplate, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	tmpls := []domain.MessageTemplate{}
+	err := r.db.NewSelect().
+		Model(&tmpls).
+		Where("?=?", column.MessageTemplate.Name, name).
+		Where("?=?", column.MessageTemplate.Channel, channel).
+		OrderExpr("? DESC", column.MessageTemplate.Version).
+		Scan(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list message templates")
+	}
+	return tmpls, nil
+}
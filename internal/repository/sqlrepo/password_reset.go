@@ -0,0 +1,104 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/sqlrepo/column"
+	"go-hex/pkg/otel"
+	"go-hex/shared/ierr"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// PasswordResetRepository encapsulates the logic to access the password reset tokens from the data source.
+type PasswordResetRepository struct {
+	db DBI
+}
+
+// NewPasswordResetRepository creates a new password reset repository
+func NewPasswordResetRepository(db DBI) *PasswordResetRepository {
+	return &PasswordResetRepository{db}
+}
+
+// Create saves a new password reset token.
+func (r *PasswordResetRepository) Create(ctx context.Context, reset domain.PasswordReset) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.db.NewInsert().
+		Model(&reset).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot create password reset")
+	}
+	return nil
+}
+
+// GetByTokenHash returns the password reset with the specified token hash.
+func (r *PasswordResetRepository) GetByTokenHash(ctx context.Context, tokenHash string) (domain.PasswordReset, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	var reset domain.PasswordReset
+	err := r.db.
+		NewSelect().
+		Model(&reset).
+		Where("?=?", column.PasswordReset.TokenHash, tokenHash).
+		Scan(ctx)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return domain.PasswordReset{}, ierr.ErrResourceNotFound
+		}
+		return domain.PasswordReset{}, errors.Wrap(err, "cannot get password reset")
+	}
+
+	return reset, nil
+}
+
+// Use marks the password reset as used if it is still usable at the specified time.
+// It returns false when it was already used or expired.
+func (r *PasswordResetRepository) Use(ctx context.Context, resetID string, at time.Time) (bool, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	res, err := r.db.NewUpdate().
+		Model((*domain.PasswordReset)(nil)).
+		Set("?=?", column.PasswordReset.UsedAt, at).
+		Where("?=?", column.PasswordReset.ID, resetID).
+		Where("? IS NULL", column.PasswordReset.UsedAt).
+		Where("?>?", column.PasswordReset.ExpiresAt, at).
+		Exec(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "cannot use password reset")
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "cannot use password reset")
+	}
+	return affected == 1, nil
+}
+
+// InvalidateByUserID marks the unused password resets of the specified user as used.
+func (r *PasswordResetRepository) InvalidateByUserID(ctx context.Context, userID string, at time.Time) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.db.NewUpdate().
+		Model((*domain.PasswordReset)(nil)).
+		Set("?=?", column.PasswordReset.UsedAt, at).
+		Where("?=?", column.PasswordReset.UserID, userID).
+		Where("? IS NULL", column.PasswordReset.UsedAt).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot invalidate password resets")
+	}
+	return nil
+}
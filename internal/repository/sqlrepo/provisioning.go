@@ -0,0 +1,199 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/sqlrepo/column"
+	"go-hex/pkg/otel"
+	"go-hex/shared/ierr"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ProvisioningRepository encapsulates the logic to access the states, the error queue and the runs of the provisioning from the data source.
+type ProvisioningRepository struct {
+	db DBI
+}
+
+// NewProvisioningRepository creates a new provisioning repository
+func NewProvisioningRepository(db DBI) *ProvisioningRepository {
+	return &ProvisioningRepository{db}
+}
+
+// ListStates returns the accounts last pushed to the specified connector.
+func (r *ProvisioningRepository) ListStates(ctx context.Context, connector string) ([]domain.ProvisioningState, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	states := []domain.ProvisioningState{}
+	err := r.db.NewSelect().
+		Model(&states).
+		Where("?=?", column.ProvisioningState.Connector, connector).
+		Scan(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list provisioning states")
+	}
+	return states, nil
+}
+
+// SaveState saves the account last pushed for a user, replacing the previous one.
+func (r *ProvisioningRepository) SaveState(ctx context.Context, state domain.ProvisioningState) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.db.NewInsert().
+		Model(&state).
+		On("?", upsert(column.ProvisioningState.Connector, column.ProvisioningState.UserID)).
+		Set("? = ?", column.ProvisioningState.AccountKey, inserted(column.ProvisioningState.AccountKey)).
+		Set("? = ?", column.ProvisioningState.Hash, inserted(column.ProvisioningState.Hash)).
+		Set("? = ?", column.ProvisioningState.Active, inserted(column.ProvisioningState.Active)).
+		Set("? = ?", column.ProvisioningState.PushedAt, inserted(column.ProvisioningState.PushedAt)).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot save provisioning state")
+	}
+	return nil
+}
+
+// ListErrors returns the queued errors of the specified connector, of every connector when empty, the oldest first.
+func (r *ProvisioningRepository) ListErrors(ctx context.Context, connector string) ([]domain.ProvisioningError, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	queued := []domain.ProvisioningError{}
+	query := r.db.NewSelect().Model(&queued)
+	if connector != "" {
+		query = query.Where("?=?", column.ProvisioningError.Connector, connector)
+	}
+	err := query.
+		OrderExpr("? ASC", column.ProvisioningError.CreatedAt).
+		Scan(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list provisioning errors")
+	}
+	return queued, nil
+}
+
+// SaveError queues the error of a push, replacing the previous error of the user.
+func (r *ProvisioningRepository) SaveError(ctx context.Context, e domain.ProvisioningError) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.db.NewInsert().
+		Model(&e).
+		On("?", upsert(column.ProvisioningError.Connector, column.ProvisioningError.UserID)).
+		Set("? = ?", column.ProvisioningError.Error, inserted(column.ProvisioningError.Error)).
+		Set("? = ?", column.ProvisioningError.Attempts, inserted(column.ProvisioningError.Attempts)).
+		Set("? = ?", column.ProvisioningError.NextAttemptAt, inserted(column.ProvisioningError.NextAttemptAt)).
+		Set("? = ?", column.ProvisioningError.UpdatedAt, inserted(column.ProvisioningError.UpdatedAt)).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot save provisioning error")
+	}
+	return nil
+}
+
+// DeleteError removes the error of a user from the queue.
+func (r *ProvisioningRepository) DeleteError(ctx context.Context, connector string, userID string) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.db.NewDelete().
+		Model((*domain.ProvisioningError)(nil)).
+		Where("?=?", column.ProvisioningError.Connector, connector).
+		Where("?=?", column.ProvisioningError.UserID, userID).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot delete provisioning error")
+	}
+	return nil
+}
+
+// RetryError resets the attempts of a queued error, so that the push is retried at the next run after the specified time.
+// It returns false when the user has no queued error.
+func (r *ProvisioningRepository) RetryError(ctx context.Context, connector string, userID string, at time.Time) (bool, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	res, err := r.db.NewUpdate().
+		Model((*domain.ProvisioningError)(nil)).
+		Set("?=0", column.ProvisioningError.Attempts).
+		Set("?=?", column.ProvisioningError.NextAttemptAt, at).
+		Set("?=?", column.ProvisioningError.UpdatedAt, at).
+		Where("?=?", column.ProvisioningError.Connector, connector).
+		Where("?=?", column.ProvisioningError.UserID, userID).
+		Exec(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "cannot retry provisioning error")
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "cannot retry provisioning error")
+	}
+	return affected == 1, nil
+}
+
+// CreateRun saves the report of a run.
+func (r *ProvisioningRepository) CreateRun(ctx context.Context, run domain.ProvisioningRun) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.db.NewInsert().Model(&run).Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot create provisioning run")
+	}
+	return nil
+}
+
+// GetRun returns the run with the specified ID.
+func (r *ProvisioningRepository) GetRun(ctx context.Context, id string) (domain.ProvisioningRun, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	var run domain.ProvisioningRun
+	err := r.db.NewSelect().
+		Model(&run).
+		Where("?=?", column.ProvisioningRun.ID, id).
+		Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return domain.ProvisioningRun{}, ierr.ErrResourceNotFound
+		}
+		return domain.ProvisioningRun{}, errors.Wrap(err, "cannot get provisioning run")
+	}
+	return run, nil
+}
+
+// ListRuns returns the latest runs without their drifts, the newest first, of every connector when connector is empty.
+func (r *ProvisioningRepository) ListRuns(ctx context.Context, connector string, limit int) ([]domain.ProvisioningRun, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	runs := []domain.ProvisioningRun{}
+	query := r.db.NewSelect().
+		Model(&runs).
+		ExcludeColumn(string(column.ProvisioningRun.Drifts))
+	if connector != "" {
+		query = query.Where("?=?", column.ProvisioningRun.Connector, connector)
+	}
+	err := query.
+		OrderExpr("? DESC", column.ProvisioningRun.StartedAt).
+		Limit(limit).
+		Scan(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list provisioning runs")
+	}
+	return runs, nil
+}
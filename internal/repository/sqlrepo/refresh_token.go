@@ -0,0 +1,86 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/sqlrepo/column"
+	"go-hex/pkg/otel"
+	"go-hex/shared/ierr"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RefreshTokenRepository encapsulates the logic to access the refresh tokens of the sessions from the data source.
+type RefreshTokenRepository struct {
+	db DBI
+}
+
+// NewRefreshTokenRepository creates a new refresh token repository
+func NewRefreshTokenRepository(db DBI) *RefreshTokenRepository {
+	return &RefreshTokenRepository{db}
+}
+
+// Create saves a new refresh token in the storage.
+func (r *RefreshTokenRepository) Create(ctx context.Context, token domain.RefreshToken) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.db.NewInsert().
+		Model(&token).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot create refresh token")
+	}
+	return nil
+}
+
+// GetByID returns the refresh token with the specified ID.
+func (r *RefreshTokenRepository) GetByID(ctx context.Context, tokenID string) (domain.RefreshToken, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	var token domain.RefreshToken
+	err := r.db.
+		NewSelect().
+		Model(&token).
+		Where("?=?", column.RefreshToken.ID, tokenID).
+		Scan(ctx)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return domain.RefreshToken{}, ierr.ErrResourceNotFound
+		}
+		return domain.RefreshToken{}, errors.Wrap(err, "cannot get refresh token")
+	}
+
+	return token, nil
+}
+
+// Rotate records that the refresh token was exchanged for the specified one.
+// It returns false when the token was already rotated.
+func (r *RefreshTokenRepository) Rotate(ctx context.Context, tokenID string, replacedBy string, at time.Time) (bool, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	res, err := r.db.NewUpdate().
+		Model((*domain.RefreshToken)(nil)).
+		Set("?=?", column.RefreshToken.RotatedAt, at).
+		Set("?=?", column.RefreshToken.ReplacedBy, replacedBy).
+		Where("?=?", column.RefreshToken.ID, tokenID).
+		Where("? IS NULL", column.RefreshToken.RotatedAt).
+		Exec(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "cannot rotate refresh token")
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "cannot rotate refresh token")
+	}
+	return affected == 1, nil
+}
@@ -0,0 +1,251 @@
+package sqlrepo
+
+import (
+	"context"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/otel"
+	"math/rand"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+const (
+	// txMaxAttempts bounds the attempts of a transaction rolled back by a deadlock or a serialization failure
+	txMaxAttempts = 3
+	// txRetryBackoff is the base delay before retrying a transaction, it grows with the attempts
+	txRetryBackoff = 20 * time.Millisecond
+)
+
+type RepositoryRegistry struct {
+	db         *bun.DB
+	dbExecutor DBI
+}
+
+func NewRepositoryRegistry(db *bun.DB) port.RepositoryRegistry {
+	repo := &RepositoryRegistry{
+		db: db,
+	}
+	return repo
+}
+
+// DoInTransaction runs txFunc in a transaction, committed when txFunc succeeds and rolled back otherwise.
+// A transaction chosen as the victim of a deadlock or rolled back by a serialization failure, as CockroachDB does on
+// any conflict, is retried from the start up to txMaxAttempts times, txFunc must therefore only have database side
+// effects. Nested calls join the outer transaction.
+func (r *RepositoryRegistry) DoInTransaction(ctx context.Context, txFunc port.InTransaction) (out interface{}, err error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	for attempt := 1; ; attempt++ {
+		out, err = r.doInTransaction(ctx, txFunc)
+		if err == nil || r.dbExecutor != nil || attempt >= txMaxAttempts || !isRetryable(err) {
+			return
+		}
+
+		// the jitter keeps the transactions which conflicted together from colliding again
+		backoff := time.Duration(attempt)*txRetryBackoff + time.Duration(rand.Int63n(int64(txRetryBackoff)))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+	}
+}
+
+func (r *RepositoryRegistry) doInTransaction(ctx context.Context, txFunc port.InTransaction) (out interface{}, err error) {
+
+	var tx bun.Tx
+	registry := r
+	if r.dbExecutor == nil {
+		tx, err = r.db.BeginTx(ctx, nil)
+		if err != nil {
+			return
+		}
+
+		defer func() {
+			if p := recover(); p != nil {
+				_ = tx.Rollback()
+				panic(p) // re-throw panic after Rollback
+			} else if err != nil {
+				rErr := tx.Rollback() // err is non-nil; don't change it
+				if rErr != nil {
+					err = rErr
+				}
+			} else {
+				err = tx.Commit() // err is nil; if Commit returns error update err
+			}
+		}()
+
+		registry = &RepositoryRegistry{
+			db:         r.db,
+			dbExecutor: tx,
+		}
+	}
+
+	out, err = txFunc(ctx, registry)
+
+	return
+}
+
+func (r *RepositoryRegistry) GetUserRepository() port.UserRepository {
+	if r.dbExecutor != nil {
+		return NewUserRepository(r.dbExecutor)
+	}
+	return NewUserRepository(r.db)
+}
+
+func (r *RepositoryRegistry) GetSessionRepository() port.SessionRepository {
+	if r.dbExecutor != nil {
+		return NewSessionRepository(r.dbExecutor)
+	}
+	return NewSessionRepository(r.db)
+}
+
+func (r *RepositoryRegistry) GetLoginApprovalRepository() port.LoginApprovalRepository {
+	if r.dbExecutor != nil {
+		return NewLoginApprovalRepository(r.dbExecutor)
+	}
+	return NewLoginApprovalRepository(r.db)
+}
+
+func (r *RepositoryRegistry) GetDeviceLoginRepository() port.DeviceLoginRepository {
+	if r.dbExecutor != nil {
+		return NewDeviceLoginRepository(r.dbExecutor)
+	}
+	return NewDeviceLoginRepository(r.db)
+}
+
+func (r *RepositoryRegistry) GetServiceAccountRepository() port.ServiceAccountRepository {
+	if r.dbExecutor != nil {
+		return NewServiceAccountRepository(r.dbExecutor)
+	}
+	return NewServiceAccountRepository(r.db)
+}
+
+func (r *RepositoryRegistry) GetTokenUsageRepository() port.TokenUsageRepository {
+	if r.dbExecutor != nil {
+		return NewTokenUsageRepository(r.dbExecutor)
+	}
+	return NewTokenUsageRepository(r.db)
+}
+
+func (r *RepositoryRegistry) GetLogVerbosityRepository() port.LogVerbosityRepository {
+	if r.dbExecutor != nil {
+		return NewLogVerbosityRepository(r.dbExecutor)
+	}
+	return NewLogVerbosityRepository(r.db)
+}
+
+func (r *RepositoryRegistry) GetLegalHoldRepository() port.LegalHoldRepository {
+	if r.dbExecutor != nil {
+		return NewLegalHoldRepository(r.dbExecutor)
+	}
+	return NewLegalHoldRepository(r.db)
+}
+
+func (r *RepositoryRegistry) GetBroadcastRepository() port.BroadcastRepository {
+	if r.dbExecutor != nil {
+		return NewBroadcastRepository(r.dbExecutor)
+	}
+	return NewBroadcastRepository(r.db)
+}
+
+func (r *RepositoryRegistry) GetEmailSuppressionRepository() port.EmailSuppressionRepository {
+	if r.dbExecutor != nil {
+		return NewEmailSuppressionRepository(r.dbExecutor)
+	}
+	return NewEmailSuppressionRepository(r.db)
+}
+
+func (r *RepositoryRegistry) GetMessageTemplateRepository() port.MessageTemplateRepository {
+	if r.dbExecutor != nil {
+		return NewMessageTemplateRepository(r.dbExecutor)
+	}
+	return NewMessageTemplateRepository(r.db)
+}
+
+func (r *RepositoryRegistry) GetSMSMessageRepository() port.SMSMessageRepository {
+	if r.dbExecutor != nil {
+		return NewSMSMessageRepository(r.dbExecutor)
+	}
+	return NewSMSMessageRepository(r.db)
+}
+
+func (r *RepositoryRegistry) GetElevationRepository() port.ElevationRepository {
+	if r.dbExecutor != nil {
+		return NewElevationRepository(r.dbExecutor)
+	}
+	return NewElevationRepository(r.db)
+}
+
+func (r *RepositoryRegistry) GetBreakGlassAccountRepository() port.BreakGlassAccountRepository {
+	if r.dbExecutor != nil {
+		return NewBreakGlassAccountRepository(r.dbExecutor)
+	}
+	return NewBreakGlassAccountRepository(r.db)
+}
+
+func (r *RepositoryRegistry) GetRefreshTokenRepository() port.RefreshTokenRepository {
+	if r.dbExecutor != nil {
+		return NewRefreshTokenRepository(r.dbExecutor)
+	}
+	return NewRefreshTokenRepository(r.db)
+}
+
+func (r *RepositoryRegistry) GetUserSyncRepository() port.UserSyncRepository {
+	if r.dbExecutor != nil {
+		return NewUserSyncRepository(r.dbExecutor)
+	}
+	return NewUserSyncRepository(r.db)
+}
+
+func (r *RepositoryRegistry) GetProvisioningRepository() port.ProvisioningRepository {
+	if r.dbExecutor != nil {
+		return NewProvisioningRepository(r.dbExecutor)
+	}
+	return NewProvisioningRepository(r.db)
+}
+
+func (r *RepositoryRegistry) GetPasswordResetRepository() port.PasswordResetRepository {
+	if r.dbExecutor != nil {
+		return NewPasswordResetRepository(r.dbExecutor)
+	}
+	return NewPasswordResetRepository(r.db)
+}
+
+func (r *RepositoryRegistry) GetEmailVerificationRepository() port.EmailVerificationRepository {
+	if r.dbExecutor != nil {
+		return NewEmailVerificationRepository(r.dbExecutor)
+	}
+	return NewEmailVerificationRepository(r.db)
+}
+
+func (r *RepositoryRegistry) GetRoleRepository() port.RoleRepository {
+	if r.dbExecutor != nil {
+		return NewRoleRepository(r.dbExecutor)
+	}
+	return NewRoleRepository(r.db)
+}
+
+func (r *RepositoryRegistry) GetUserIdentityRepository() port.UserIdentityRepository {
+	if r.dbExecutor != nil {
+		return NewUserIdentityRepository(r.dbExecutor)
+	}
+	return NewUserIdentityRepository(r.db)
+}
+
+func (r *RepositoryRegistry) GetAuditRepository() port.AuditRepository {
+	if r.dbExecutor != nil {
+		return NewAuditRepository(r.dbExecutor)
+	}
+	return NewAuditRepository(r.db)
+}
+
+func (r *RepositoryRegistry) GetTenantSettingsRepository() port.TenantSettingsRepository {
+	if r.dbExecutor != nil {
+		return NewTenantSettingsRepository(r.dbExecutor)
+	}
+	return NewTenantSettingsRepository(r.db)
+}
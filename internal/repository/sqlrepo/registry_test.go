@@ -0,0 +1,73 @@
+package sqlrepo
+
+import (
+	"context"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/db/dbtest"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/mysqldialect"
+)
+
+func TestDoInTransactionRetriesAbortedTransactions(t *testing.T) {
+
+	deadlock := errors.Wrap(&mysql.MySQLError{Number: mysqlErrLockDeadlock, Message: "Deadlock found when trying to get lock"}, "cannot revoke session")
+	pqDeadlock := errors.Wrap(&pq.Error{Code: pqErrDeadlockDetected, Message: "deadlock detected"}, "cannot revoke session")
+	serialization := errors.Wrap(&pq.Error{Code: pqErrSerializationFailure, Message: "restart transaction: TransactionRetryWithProtoRefreshError"}, "cannot revoke session")
+	other := errors.New("cannot create session")
+
+	tests := []struct {
+		name         string
+		errs         []error
+		wantAttempts int
+		wantErr      error
+	}{
+		{name: "success", errs: []error{nil}, wantAttempts: 1},
+		{name: "deadlock then success", errs: []error{deadlock, nil}, wantAttempts: 2},
+		{name: "postgres deadlock then success", errs: []error{pqDeadlock, nil}, wantAttempts: 2},
+		{name: "serialization failure then success", errs: []error{serialization, nil}, wantAttempts: 2},
+		{name: "deadlock on every attempt", errs: []error{deadlock, deadlock, deadlock, nil}, wantAttempts: txMaxAttempts, wantErr: deadlock},
+		{name: "other error", errs: []error{other, nil}, wantAttempts: 1, wantErr: other},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bunDB := bun.NewDB(dbtest.NewBlockingDriver().DB(), mysqldialect.New())
+			defer bunDB.Close()
+
+			attempts := 0
+			_, err := NewRepositoryRegistry(bunDB).DoInTransaction(context.Background(), func(ctx context.Context, repoRegistry port.RepositoryRegistry) (interface{}, error) {
+				attempts++
+				return nil, tt.errs[attempts-1]
+			})
+
+			assert.Equal(t, tt.wantAttempts, attempts)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestDoInTransactionNestedDoesNotRetry(t *testing.T) {
+	bunDB := bun.NewDB(dbtest.NewBlockingDriver().DB(), mysqldialect.New())
+	defer bunDB.Close()
+
+	deadlock := &mysql.MySQLError{Number: mysqlErrLockDeadlock}
+	outer, inner := 0, 0
+	_, err := NewRepositoryRegistry(bunDB).DoInTransaction(context.Background(), func(ctx context.Context, repoRegistry port.RepositoryRegistry) (interface{}, error) {
+		outer++
+		// the nested transaction fails the outer one, which is retried as a whole
+		return repoRegistry.DoInTransaction(ctx, func(ctx context.Context, repoRegistry port.RepositoryRegistry) (interface{}, error) {
+			inner++
+			return nil, deadlock
+		})
+	})
+
+	assert.Equal(t, deadlock, err)
+	assert.Equal(t, txMaxAttempts, outer)
+	assert.Equal(t, txMaxAttempts, inner)
+}
@@ -0,0 +1,230 @@
+package sqlrepo
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/sqlrepo/column"
+	"go-hex/pkg/otel"
+	"go-hex/shared/ierr"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/uptrace/bun"
+)
+
+// RoleRepository encapsulates the logic to access roles and their assignments from the data source.
+type RoleRepository struct {
+	db DBI
+}
+
+// NewRoleRepository creates a new role repository
+func NewRoleRepository(db DBI) *RoleRepository {
+	return &RoleRepository{db}
+}
+
+// List returns every role with its permissions, by name.
+func (r *RoleRepository) List(ctx context.Context) ([]domain.Role, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	roles := []domain.Role{}
+	err := r.db.
+		NewSelect().
+		Model(&roles).
+		OrderExpr("? ASC", column.Role.Name).
+		Scan(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list roles")
+	}
+
+	return r.withPermissions(ctx, roles)
+}
+
+// ListByNames returns the roles with the specified names with their permissions, the unknown names are ignored.
+func (r *RoleRepository) ListByNames(ctx context.Context, names []string) ([]domain.Role, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	roles := []domain.Role{}
+	if len(names) == 0 {
+		return roles, nil
+	}
+	err := r.db.
+		NewSelect().
+		Model(&roles).
+		Where("? IN (?)", column.Role.Name, bun.In(names)).
+		OrderExpr("? ASC", column.Role.Name).
+		Scan(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list roles")
+	}
+
+	return r.withPermissions(ctx, roles)
+}
+
+// ListByUserID returns the roles assigned to the specified user and granted at the specified time with their
+// permissions, by name. The time-boxed roles are left out outside of their window.
+func (r *RoleRepository) ListByUserID(ctx context.Context, userID string, at time.Time) ([]domain.Role, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	assignments := []domain.UserRole{}
+	err := r.db.
+		NewSelect().
+		Model(&assignments).
+		Where("?=?", column.UserRole.UserID, userID).
+		Where("(? IS NULL OR ? <= ?)", column.UserRole.StartsAt, column.UserRole.StartsAt, at).
+		Where("(? IS NULL OR ? > ?)", column.UserRole.EndsAt, column.UserRole.EndsAt, at).
+		Scan(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list user roles")
+	}
+
+	names := make([]string, 0, len(assignments))
+	for _, assignment := range assignments {
+		names = append(names, assignment.Role)
+	}
+	return r.ListByNames(ctx, names)
+}
+
+// ListAssignments returns the assignments of the roles of the specified user with their time window, by role.
+func (r *RoleRepository) ListAssignments(ctx context.Context, userID string) ([]domain.UserRole, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	assignments := []domain.UserRole{}
+	err := r.db.
+		NewSelect().
+		Model(&assignments).
+		Where("?=?", column.UserRole.UserID, userID).
+		OrderExpr("? ASC", column.UserRole.Role).
+		Scan(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list user roles")
+	}
+	return assignments, nil
+}
+
+// Assign assigns a role to a user, assigning an already assigned role replaces its time window.
+func (r *RoleRepository) Assign(ctx context.Context, assignment domain.UserRole) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.db.NewInsert().
+		Model(&assignment).
+		On("?", upsert(column.UserRole.UserID, column.UserRole.Role)).
+		Set("? = ?", column.UserRole.StartsAt, inserted(column.UserRole.StartsAt)).
+		Set("? = ?", column.UserRole.EndsAt, inserted(column.UserRole.EndsAt)).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot assign role")
+	}
+	return nil
+}
+
+// Unassign removes a role from a user.
+// It returns ierr.ErrResourceNotFound when the role is not assigned to the user.
+func (r *RoleRepository) Unassign(ctx context.Context, userID string, role string) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	res, err := r.db.NewDelete().
+		Model((*domain.UserRole)(nil)).
+		Where("?=?", column.UserRole.UserID, userID).
+		Where("?=?", column.UserRole.Role, role).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot unassign role")
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "cannot unassign role")
+	}
+	if affected == 0 {
+		return ierr.ErrResourceNotFound
+	}
+	return nil
+}
+
+// ListExpired returns the time-boxed assignments of every user whose window ended before the given time.
+func (r *RoleRepository) ListExpired(ctx context.Context, before time.Time) ([]domain.UserRole, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	assignments := []domain.UserRole{}
+	err := r.db.NewSelect().
+		Model(&assignments).
+		Where("? <= ?", column.UserRole.EndsAt, before).
+		OrderExpr("? ASC", column.UserRole.EndsAt).
+		Scan(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list expired user roles")
+	}
+	return assignments, nil
+}
+
+// DeleteExpired removes the expired assignment unless it was assigned again with another window since it was
+// listed. It returns false when the assignment was not removed.
+func (r *RoleRepository) DeleteExpired(ctx context.Context, assignment domain.UserRole) (bool, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	res, err := r.db.NewDelete().
+		Model((*domain.UserRole)(nil)).
+		Where("?=?", column.UserRole.UserID, assignment.UserID).
+		Where("?=?", column.UserRole.Role, assignment.Role).
+		Where("?=?", column.UserRole.EndsAt, assignment.EndsAt).
+		Exec(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "cannot delete expired user role")
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "cannot delete expired user role")
+	}
+	return affected > 0, nil
+}
+
+// withPermissions reads the permissions of the roles
+func (r *RoleRepository) withPermissions(ctx context.Context, roles []domain.Role) ([]domain.Role, error) {
+	if len(roles) == 0 {
+		return roles, nil
+	}
+
+	names := make([]string, 0, len(roles))
+	for _, role := range roles {
+		names = append(names, role.Name)
+	}
+
+	grants := []domain.RolePermission{}
+	err := r.db.
+		NewSelect().
+		Model(&grants).
+		Where("? IN (?)", column.RolePermission.Role, bun.In(names)).
+		OrderExpr("? ASC", column.RolePermission.Permission).
+		Scan(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list role permissions")
+	}
+
+	permissions := map[string][]string{}
+	for _, grant := range grants {
+		permissions[grant.Role] = append(permissions[grant.Role], grant.Permission)
+	}
+	for i := range roles {
+		roles[i].Permissions = permissions[roles[i].Name]
+		if roles[i].Permissions == nil {
+			roles[i].Permissions = []string{}
+		}
+	}
+	return roles, nil
+}
@@ -0,0 +1,527 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/sqlrepo/column"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/times"
+	"go-hex/shared/ierr"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/uptrace/bun"
+)
+
+// ServiceAccountRepository encapsulates the logic to access service accounts from the data source.
+type ServiceAccountRepository struct {
+	db DBI
+}
+
+// NewServiceAccountRepository creates a new service account repository
+func NewServiceAccountRepository(db DBI) *ServiceAccountRepository {
+	return &ServiceAccountRepository{db}
+}
+
+// Create saves a new service account in the storage.
+func (r *ServiceAccountRepository) Create(ctx context.Context, account domain.ServiceAccount) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.db.NewInsert().
+		Model(&account).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot create service account")
+	}
+	return nil
+}
+
+// GetByID returns the service account with the specified ID.
+func (r *ServiceAccountRepository) GetByID(ctx context.Context, accountID string) (domain.ServiceAccount, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	var account domain.ServiceAccount
+	err := r.db.
+		NewSelect().
+		Model(&account).
+		Where("?=?", column.ServiceAccount.ID, accountID).
+		Scan(ctx)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return domain.ServiceAccount{}, ierr.ErrResourceNotFound
+		}
+		return domain.ServiceAccount{}, errors.Wrap(err, "cannot get service account")
+	}
+
+	return account, nil
+}
+
+// List returns every service account ordered by name.
+func (r *ServiceAccountRepository) List(ctx context.Context) ([]domain.ServiceAccount, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	accounts := []domain.ServiceAccount{}
+	err := r.db.
+		NewSelect().
+		Model(&accounts).
+		OrderExpr("? ASC", column.ServiceAccount.Name).
+		Scan(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list service accounts")
+	}
+
+	return accounts, nil
+}
+
+// UpdatePublicKey replaces the public key verifying the assertions of the service account.
+func (r *ServiceAccountRepository) UpdatePublicKey(ctx context.Context, accountID string, publicKey string) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.db.NewUpdate().
+		Model((*domain.ServiceAccount)(nil)).
+		Set("?=?", column.ServiceAccount.PublicKey, publicKey).
+		Set("?=?", column.ServiceAccount.UpdatedAt, times.Now()).
+		Where("?=?", column.ServiceAccount.ID, accountID).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot update service account public key")
+	}
+	return nil
+}
+
+// UpdateActive enables or disables the service account.
+func (r *ServiceAccountRepository) UpdateActive(ctx context.Context, accountID string, isActive bool) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.db.NewUpdate().
+		Model((*domain.ServiceAccount)(nil)).
+		Set("?=?", column.ServiceAccount.IsActive, isActive).
+		Set("?=?", column.ServiceAccount.UpdatedAt, times.Now()).
+		Where("?=?", column.ServiceAccount.ID, accountID).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot update service account status")
+	}
+	return nil
+}
+
+// UpdateLastUsedAt records the last time the service account obtained a token.
+func (r *ServiceAccountRepository) UpdateLastUsedAt(ctx context.Context, accountID string, lastUsedAt time.Time) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.db.NewUpdate().
+		Model((*domain.ServiceAccount)(nil)).
+		Set("?=?", column.ServiceAccount.LastUsedAt, lastUsedAt).
+		Where("?=?", column.ServiceAccount.ID, accountID).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot update service account last used at")
+	}
+	return nil
+}
+
+// ListRoles returns the roles bound to the service account.
+func (r *ServiceAccountRepository) ListRoles(ctx context.Context, accountID string) ([]domain.ServiceAccountRole, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	roles := []domain.ServiceAccountRole{}
+	err := r.db.
+		NewSelect().
+		Model(&roles).
+		Where("?=?", column.ServiceAccountRole.ServiceAccountID, accountID).
+		OrderExpr("? ASC", column.ServiceAccountRole.Role).
+		Scan(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list service account roles")
+	}
+
+	return roles, nil
+}
+
+// BindRole binds a role to the service account, binding an already bound role replaces its time window.
+func (r *ServiceAccountRepository) BindRole(ctx context.Context, role domain.ServiceAccountRole) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.db.NewInsert().
+		Model(&role).
+		On("?", upsert(column.ServiceAccountRole.ServiceAccountID, column.ServiceAccountRole.Role)).
+		Set("? = ?", column.ServiceAccountRole.StartsAt, inserted(column.ServiceAccountRole.StartsAt)).
+		Set("? = ?", column.ServiceAccountRole.EndsAt, inserted(column.ServiceAccountRole.EndsAt)).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot bind service account role")
+	}
+	return nil
+}
+
+// UnbindRole removes a role from the service account.
+func (r *ServiceAccountRepository) UnbindRole(ctx context.Context, accountID string, role string) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.db.NewDelete().
+		Model((*domain.ServiceAccountRole)(nil)).
+		Where("?=?", column.ServiceAccountRole.ServiceAccountID, accountID).
+		Where("?=?", column.ServiceAccountRole.Role, role).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot unbind service account role")
+	}
+	return nil
+}
+
+// ListExpiredRoles returns the time-boxed roles of every service account whose window ended before the given time.
+func (r *ServiceAccountRepository) ListExpiredRoles(ctx context.Context, before time.Time) ([]domain.ServiceAccountRole, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	roles := []domain.ServiceAccountRole{}
+	err := r.db.NewSelect().
+		Model(&roles).
+		Where("? <= ?", column.ServiceAccountRole.EndsAt, before).
+		OrderExpr("? ASC", column.ServiceAccountRole.EndsAt).
+		Scan(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list expired service account roles")
+	}
+	return roles, nil
+}
+
+// DeleteExpiredRole removes the expired role unless it was bound again with another window since it was listed.
+// It returns false when the role was not removed.
+func (r *ServiceAccountRepository) DeleteExpiredRole(ctx context.Context, role domain.ServiceAccountRole) (bool, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	res, err := r.db.NewDelete().
+		Model((*domain.ServiceAccountRole)(nil)).
+		Where("?=?", column.ServiceAccountRole.ServiceAccountID, role.ServiceAccountID).
+		Where("?=?", column.ServiceAccountRole.Role, role.Role).
+		Where("?=?", column.ServiceAccountRole.EndsAt, role.EndsAt).
+		Exec(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "cannot delete expired service account role")
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "cannot delete expired service account role")
+	}
+	return affected > 0, nil
+}
+
+// RecordAssertion stores the identifier of a consumed assertion.
+// It returns false when the assertion has already been used.
+func (r *ServiceAccountRepository) RecordAssertion(ctx context.Context, assertion domain.ServiceAccountAssertion) (bool, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	res, err := r.db.NewInsert().
+		Model(&assertion).
+		Ignore().
+		Exec(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "cannot record service account assertion")
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "cannot record service account assertion")
+	}
+	return affected == 1, nil
+}
+
+// DeleteExpiredAssertions deletes the consumed assertions expired before the specified time,
+// they cannot be replayed anymore once expired.
+func (r *ServiceAccountRepository) DeleteExpiredAssertions(ctx context.Context, before time.Time) (int64, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	res, err := r.db.NewDelete().
+		Model((*domain.ServiceAccountAssertion)(nil)).
+		Where("?<?", column.ServiceAccountAssertion.ExpiresAt, before).
+		Exec(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "cannot delete expired service account assertions")
+	}
+	return res.RowsAffected()
+}
+
+// RecordAuditEvents saves entries of the audit trails of service accounts in a single statement.
+func (r *ServiceAccountRepository) RecordAuditEvents(ctx context.Context, auditEvents []domain.ServiceAccountAuditEvent) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	if len(auditEvents) == 0 {
+		return nil
+	}
+
+	_, err := r.db.NewInsert().
+		Model(&auditEvents).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot record service account audit events")
+	}
+	return nil
+}
+
+// ListAuditEvents returns the latest entries of the audit trail of a service account, the newest first,
+// skipping the offset newest ones.
+func (r *ServiceAccountRepository) ListAuditEvents(ctx context.Context, accountID string, limit int, offset int) ([]domain.ServiceAccountAuditEvent, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	auditEvents := []domain.ServiceAccountAuditEvent{}
+	err := r.db.
+		NewSelect().
+		Model(&auditEvents).
+		Where("?=?", column.ServiceAccountAuditEvent.ServiceAccountID, accountID).
+		OrderExpr("? DESC, ? DESC", column.ServiceAccountAuditEvent.CreatedAt, column.ServiceAccountAuditEvent.ID).
+		Limit(limit).
+		Offset(offset).
+		Scan(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list service account audit events")
+	}
+
+	return auditEvents, nil
+}
+
+// ListChainedAuditEvents returns the audit events of the hash chain following the specified position, in the chain order.
+func (r *ServiceAccountRepository) ListChainedAuditEvents(ctx context.Context, afterSeq int64, limit int) ([]domain.ServiceAccountAuditEvent, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	auditEvents := []domain.ServiceAccountAuditEvent{}
+	err := r.db.
+		NewSelect().
+		Model(&auditEvents).
+		Where("?>?", column.ServiceAccountAuditEvent.Seq, afterSeq).
+		OrderExpr("? ASC", column.ServiceAccountAuditEvent.Seq).
+		Limit(limit).
+		Scan(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list chained service account audit events")
+	}
+
+	return auditEvents, nil
+}
+
+// GetAuditChainHead returns the head of the hash chain of the audit events.
+// The head is locked for update when called inside a transaction.
+func (r *ServiceAccountRepository) GetAuditChainHead(ctx context.Context, name string) (domain.AuditChainHead, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	var head domain.AuditChainHead
+	err := r.db.
+		NewSelect().
+		Model(&head).
+		Where("?=?", column.AuditChainHead.Name, name).
+		For("UPDATE").
+		Scan(ctx)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return domain.AuditChainHead{}, ierr.ErrResourceNotFound
+		}
+		return domain.AuditChainHead{}, errors.Wrap(err, "cannot get audit chain head")
+	}
+
+	return head, nil
+}
+
+// UpdateAuditChainHead moves the head of the hash chain of the audit events.
+func (r *ServiceAccountRepository) UpdateAuditChainHead(ctx context.Context, head domain.AuditChainHead) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.db.NewUpdate().
+		Model((*domain.AuditChainHead)(nil)).
+		Set("?=?", column.AuditChainHead.Seq, head.Seq).
+		Set("?=?", column.AuditChainHead.Hash, head.Hash).
+		Set("?=?", column.AuditChainHead.PrunedSeq, head.PrunedSeq).
+		Set("?=?", column.AuditChainHead.PrunedHash, head.PrunedHash).
+		Set("?=?", column.AuditChainHead.UpdatedAt, head.UpdatedAt).
+		Where("?=?", column.AuditChainHead.Name, head.Name).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot update audit chain head")
+	}
+	return nil
+}
+
+// ListAuditEventsCreatedBetween returns the audit events created in [from, to), from the oldest, skipping the offset oldest ones.
+func (r *ServiceAccountRepository) ListAuditEventsCreatedBetween(ctx context.Context, from time.Time, to time.Time, limit int, offset int) ([]domain.ServiceAccountAuditEvent, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	auditEvents := []domain.ServiceAccountAuditEvent{}
+	err := r.db.
+		NewSelect().
+		Model(&auditEvents).
+		Where("?>=?", column.ServiceAccountAuditEvent.CreatedAt, from).
+		Where("?<?", column.ServiceAccountAuditEvent.CreatedAt, to).
+		OrderExpr("? ASC, ? ASC, ? ASC", column.ServiceAccountAuditEvent.CreatedAt, column.ServiceAccountAuditEvent.Seq, column.ServiceAccountAuditEvent.ID).
+		Limit(limit).
+		Offset(offset).
+		Scan(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list service account audit events")
+	}
+
+	return auditEvents, nil
+}
+
+// GetOldestAuditEvent returns the oldest audit event of the service accounts.
+func (r *ServiceAccountRepository) GetOldestAuditEvent(ctx context.Context) (domain.ServiceAccountAuditEvent, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	var auditEvent domain.ServiceAccountAuditEvent
+	err := r.db.
+		NewSelect().
+		Model(&auditEvent).
+		OrderExpr("? ASC", column.ServiceAccountAuditEvent.CreatedAt).
+		Limit(1).
+		Scan(ctx)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return domain.ServiceAccountAuditEvent{}, ierr.ErrResourceNotFound
+		}
+		return domain.ServiceAccountAuditEvent{}, errors.Wrap(err, "cannot get service account audit event")
+	}
+
+	return auditEvent, nil
+}
+
+// GetLastAuditEventBefore returns the chained audit event with the highest position such that
+// it and all the events preceding it in the chain were created before the specified time.
+func (r *ServiceAccountRepository) GetLastAuditEventBefore(ctx context.Context, before time.Time) (domain.ServiceAccountAuditEvent, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	firstAfter := r.db.
+		NewSelect().
+		Model((*domain.ServiceAccountAuditEvent)(nil)).
+		ColumnExpr("MIN(?)", column.ServiceAccountAuditEvent.Seq).
+		Where("?>=?", column.ServiceAccountAuditEvent.CreatedAt, before)
+
+	var auditEvent domain.ServiceAccountAuditEvent
+	err := r.db.
+		NewSelect().
+		Model(&auditEvent).
+		Where("? IS NOT NULL", column.ServiceAccountAuditEvent.Seq).
+		Where("?<COALESCE((?), ?+1)", column.ServiceAccountAuditEvent.Seq, firstAfter, column.ServiceAccountAuditEvent.Seq).
+		OrderExpr("? DESC", column.ServiceAccountAuditEvent.Seq).
+		Limit(1).
+		Scan(ctx)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return domain.ServiceAccountAuditEvent{}, ierr.ErrResourceNotFound
+		}
+		return domain.ServiceAccountAuditEvent{}, errors.Wrap(err, "cannot get service account audit event")
+	}
+
+	return auditEvent, nil
+}
+
+// DeleteAuditEvents deletes the chained audit events up to the specified position, and the events
+// recorded before the chaining which were created before the specified time, except the ones whose
+// actor or subject is a held user.
+func (r *ServiceAccountRepository) DeleteAuditEvents(ctx context.Context, maxSeq int64, before time.Time, heldUserIDs []string) (int64, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	q := r.db.NewDelete().
+		Model((*domain.ServiceAccountAuditEvent)(nil)).
+		WhereGroup(" AND ", func(q *bun.DeleteQuery) *bun.DeleteQuery {
+			return q.Where("?<=?", column.ServiceAccountAuditEvent.Seq, maxSeq).
+				WhereGroup(" OR ", func(q *bun.DeleteQuery) *bun.DeleteQuery {
+					return q.Where("? IS NULL", column.ServiceAccountAuditEvent.Seq).
+						Where("?<?", column.ServiceAccountAuditEvent.CreatedAt, before)
+				})
+		})
+	if len(heldUserIDs) > 0 {
+		q.Where("? NOT IN (?)", column.ServiceAccountAuditEvent.ActorID, bun.In(heldUserIDs)).
+			Where("? NOT IN (?)", column.ServiceAccountAuditEvent.SubjectID, bun.In(heldUserIDs))
+	}
+
+	res, err := q.Exec(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "cannot delete service account audit events")
+	}
+	return res.RowsAffected()
+}
+
+// GetLastAuditArchive returns the export of the most recent day of audit events.
+func (r *ServiceAccountRepository) GetLastAuditArchive(ctx context.Context) (domain.ServiceAccountAuditArchive, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	var archive domain.ServiceAccountAuditArchive
+	err := r.db.
+		NewSelect().
+		Model(&archive).
+		OrderExpr("? DESC", column.ServiceAccountAuditArchive.Day).
+		Limit(1).
+		Scan(ctx)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return domain.ServiceAccountAuditArchive{}, ierr.ErrResourceNotFound
+		}
+		return domain.ServiceAccountAuditArchive{}, errors.Wrap(err, "cannot get service account audit archive")
+	}
+
+	return archive, nil
+}
+
+// RecordAuditArchive saves the export of a day of audit events.
+func (r *ServiceAccountRepository) RecordAuditArchive(ctx context.Context, archive domain.ServiceAccountAuditArchive) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.db.NewInsert().
+		Model(&archive).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot record service account audit archive")
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/sqlrepo/column"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/times"
+	"go-hex/shared/ierr"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// SessionRepository encapsulates the logic to access sessions from the data source.
+type SessionRepository struct {
+	db DBI
+}
+
+// NewSessionRepository creates a new session repository
+func NewSessionRepository(db DBI) *SessionRepository {
+	return &SessionRepository{db}
+}
+
+// Create saves a new session in the storage.
+func (r *SessionRepository) Create(ctx context.Context, session domain.Session) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.db.NewInsert().
+		Model(&session).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot create session")
+	}
+	return nil
+}
+
+// GetByID returns the session with the specified session ID.
+func (r *SessionRepository) GetByID(ctx context.Context, sessionID string) (domain.Session, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	var session domain.Session
+	err := r.db.
+		NewSelect().
+		Model(&session).
+		Where("?=?", column.Session.ID, sessionID).
+		Scan(ctx)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return domain.Session{}, ierr.ErrResourceNotFound
+		}
+		return domain.Session{}, errors.Wrap(err, "cannot get session")
+	}
+
+	return session, nil
+}
+
+// ListActiveByUserID returns the active sessions of the specified user ordered from the oldest.
+// The sessions are locked for update when called inside a transaction.
+func (r *SessionRepository) ListActiveByUserID(ctx context.Context, userID string) ([]domain.Session, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	var sessions []domain.Session
+	err := r.db.
+		NewSelect().
+		Model(&sessions).
+		Where("?=?", column.Session.UserID, userID).
+		Where("? IS NULL", column.Session.RevokedAt).
+		Where("?>?", column.Session.ExpiresAt, times.Now()).
+		OrderExpr("? ASC", column.Session.CreatedAt).
+		For("UPDATE").
+		Scan(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list sessions")
+	}
+
+	return sessions, nil
+}
+
+// CountActiveByUserID returns the number of active sessions of the specified user.
+func (r *SessionRepository) CountActiveByUserID(ctx context.Context, userID string) (int, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	count, err := r.db.
+		NewSelect().
+		Model((*domain.Session)(nil)).
+		Where("?=?", column.Session.UserID, userID).
+		Where("? IS NULL", column.Session.RevokedAt).
+		Where("?>?", column.Session.ExpiresAt, times.Now()).
+		Count(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "cannot count sessions")
+	}
+
+	return count, nil
+}
+
+// Touch records the use of the session, when its refresh token was last issued.
+func (r *SessionRepository) Touch(ctx context.Context, sessionID string, at time.Time) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.db.NewUpdate().
+		Model((*domain.Session)(nil)).
+		Set("?=?", column.Session.LastUsedAt, at).
+		Where("?=?", column.Session.ID, sessionID).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot update session")
+	}
+	return nil
+}
+
+// ClearRefreshToken clears the hashed refresh token of the session if it still equals the given hash.
+// It returns false when the token has already been cleared.
+func (r *SessionRepository) ClearRefreshToken(ctx context.Context, sessionID string, hashedRefreshToken string) (bool, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	res, err := r.db.NewUpdate().
+		Model((*domain.Session)(nil)).
+		Set("?=NULL", column.Session.RefreshToken).
+		Where("?=?", column.Session.ID, sessionID).
+		Where("?=?", column.Session.RefreshToken, hashedRefreshToken).
+		Exec(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "cannot clear refresh token")
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "cannot clear refresh token")
+	}
+	return affected > 0, nil
+}
+
+// Revoke revokes the session with the specified session ID.
+func (r *SessionRepository) Revoke(ctx context.Context, sessionID string) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.db.NewUpdate().
+		Model((*domain.Session)(nil)).
+		Set("?=?", column.Session.RevokedAt, times.Now()).
+		Set("? = NULL", column.Session.RefreshToken).
+		Where("?=?", column.Session.ID, sessionID).
+		Where("? IS NULL", column.Session.RevokedAt).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot revoke session")
+	}
+	return nil
+}
+
+// RevokeByUserID revokes all active sessions of the specified user.
+func (r *SessionRepository) RevokeByUserID(ctx context.Context, userID string) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.db.NewUpdate().
+		Model((*domain.Session)(nil)).
+		Set("?=?", column.Session.RevokedAt, times.Now()).
+		Set("? = NULL", column.Session.RefreshToken).
+		Where("?=?", column.Session.UserID, userID).
+		Where("? IS NULL", column.Session.RevokedAt).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot revoke sessions")
+	}
+	return nil
+}
+
+// RevokeByUpstreamSessionID revokes the active sessions started with the specified upstream sid.
+// A non empty upstream subject must match as well.
+func (r *SessionRepository) RevokeByUpstreamSessionID(ctx context.Context, upstreamSessionID string, upstreamSubject string) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	query := r.db.NewUpdate().
+		Model((*domain.Session)(nil)).
+		Set("?=?", column.Session.RevokedAt, times.Now()).
+		Set("? = NULL", column.Session.RefreshToken).
+		Where("?=?", column.Session.UpstreamSessionID, upstreamSessionID).
+		Where("? IS NULL", column.Session.RevokedAt)
+	if upstreamSubject != "" {
+		query = query.Where("?=?", column.Session.UpstreamSubject, upstreamSubject)
+	}
+
+	_, err := query.Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot revoke sessions")
+	}
+	return nil
+}
+
+// RevokeByUpstreamSubject revokes the active sessions started with the specified upstream sub.
+func (r *SessionRepository) RevokeByUpstreamSubject(ctx context.Context, upstreamSubject string) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.db.NewUpdate().
+		Model((*domain.Session)(nil)).
+		Set("?=?", column.Session.RevokedAt, times.Now()).
+		Set("? = NULL", column.Session.RefreshToken).
+		Where("?=?", column.Session.UpstreamSubject, upstreamSubject).
+		Where("? IS NULL", column.Session.RevokedAt).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot revoke sessions")
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package sqlrepo
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/sqlrepo/column"
+	"go-hex/pkg/otel"
+	"go-hex/shared/ierr"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// SMSMessageRepository encapsulates the logic to access the text messages from the data source.
+type SMSMessageRepository struct {
+	db DBI
+}
+
+// NewSMSMessageRepository creates a new text message repository
+func NewSMSMessageRepository(db DBI) *SMSMessageRepository {
+	return &SMSMessageRepository{db}
+}
+
+// Create saves a new text message in the storage.
+func (r *SMSMessageRepository) Create(ctx context.Context, msg domain.SMSMessage) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.db.NewInsert().
+		Model(&msg).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot create sms message")
+	}
+	return nil
+}
+
+// UpdateStatus updates the delivery status of the message with the specified id at the provider.
+func (r *SMSMessageRepository) UpdateStatus(ctx context.Context, provider string, providerMessageID string, status string, errorCode string, updatedAt time.Time) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	res, err := r.db.NewUpdate().
+		Model((*domain.SMSMessage)(nil)).
+		Set("?=?", column.SMSMessage.Status, status).
+		Set("?=?", column.SMSMessage.ErrorCode, errorCode).
+		Set("?=?", column.SMSMessage.UpdatedAt, updatedAt).
+		Where("?=?", column.SMSMessage.Provider, provider).
+		Where("?=?", column.SMSMessage.ProviderMessageID, providerMessageID).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot update sms message status")
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "cannot update sms message status")
+	}
+	if affected == 0 {
+		return ierr.ErrResourceNotFound
+	}
+	return nil
+}
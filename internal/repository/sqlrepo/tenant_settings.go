@@ -0,0 +1,113 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/sqlrepo/column"
+	"go-hex/pkg/otel"
+	"go-hex/shared/ierr"
+
+	"github.com/pkg/errors"
+)
+
+// TenantSettingsRepository encapsulates the logic to access the settings of the tenants from the data source.
+type TenantSettingsRepository struct {
+	db DBI
+}
+
+// NewTenantSettingsRepository creates a new tenant settings repository
+func NewTenantSettingsRepository(db DBI) *TenantSettingsRepository {
+	return &TenantSettingsRepository{db}
+}
+
+// Get returns the settings of the tenant.
+func (r *TenantSettingsRepository) Get(ctx context.Context, tenantID string) (domain.TenantSettings, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	var settings domain.TenantSettings
+	err := r.db.NewSelect().
+		Model(&settings).
+		Where("?=?", column.TenantSettings.TenantID, tenantID).
+		Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return settings, ierr.ErrResourceNotFound
+		}
+		return settings, errors.Wrap(err, "cannot get tenant settings")
+	}
+	return settings, nil
+}
+
+// List returns the settings of the tenants, ordered by tenant.
+func (r *TenantSettingsRepository) List(ctx context.Context, limit int, offset int) ([]domain.TenantSettings, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	settings := []domain.TenantSettings{}
+	err := r.db.NewSelect().
+		Model(&settings).
+		OrderExpr("?", column.TenantSettings.TenantID).
+		Limit(limit).
+		Offset(offset).
+		Scan(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list tenant settings")
+	}
+	return settings, nil
+}
+
+// Upsert saves the settings of a tenant, replacing every setting when the tenant already has settings.
+func (r *TenantSettingsRepository) Upsert(ctx context.Context, settings domain.TenantSettings) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.db.NewInsert().
+		Model(&settings).
+		On("?", upsert(column.TenantSettings.TenantID)).
+		Set("? = ?", column.TenantSettings.TokenExpiration, inserted(column.TenantSettings.TokenExpiration)).
+		Set("? = ?", column.TenantSettings.RefreshTokenExpiration, inserted(column.TenantSettings.RefreshTokenExpiration)).
+		Set("? = ?", column.TenantSettings.LoginApprovalRequired, inserted(column.TenantSettings.LoginApprovalRequired)).
+		Set("? = ?", column.TenantSettings.PasswordMinLength, inserted(column.TenantSettings.PasswordMinLength)).
+		Set("? = ?", column.TenantSettings.PasswordMinClasses, inserted(column.TenantSettings.PasswordMinClasses)).
+		Set("? = ?", column.TenantSettings.AppName, inserted(column.TenantSettings.AppName)).
+		Set("? = ?", column.TenantSettings.LogoURL, inserted(column.TenantSettings.LogoURL)).
+		Set("? = ?", column.TenantSettings.PrimaryColor, inserted(column.TenantSettings.PrimaryColor)).
+		Set("? = ?", column.TenantSettings.AccentColor, inserted(column.TenantSettings.AccentColor)).
+		Set("? = ?", column.TenantSettings.SupportEmail, inserted(column.TenantSettings.SupportEmail)).
+		Set("? = ?", column.TenantSettings.UpdatedBy, inserted(column.TenantSettings.UpdatedBy)).
+		Set("? = ?", column.TenantSettings.UpdatedAt, inserted(column.TenantSettings.UpdatedAt)).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot save tenant settings")
+	}
+	return nil
+}
+
+// Delete deletes the settings of the tenant.
+func (r *TenantSettingsRepository) Delete(ctx context.Context, tenantID string) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	res, err := r.db.NewDelete().
+		Model((*domain.TenantSettings)(nil)).
+		Where("?=?", column.TenantSettings.TenantID, tenantID).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot delete tenant settings")
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "cannot delete tenant settings")
+	}
+	if affected == 0 {
+		return ierr.ErrResourceNotFound
+	}
+	return nil
+}
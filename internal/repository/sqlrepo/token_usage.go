@@ -0,0 +1,128 @@
+package sqlrepo
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/sqlrepo/column"
+	"go-hex/pkg/otel"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// TokenUsageRepository encapsulates the logic to access the token usage analytics from the data source.
+type TokenUsageRepository struct {
+	db DBI
+}
+
+// NewTokenUsageRepository creates a new token usage repository
+func NewTokenUsageRepository(db DBI) *TokenUsageRepository {
+	return &TokenUsageRepository{db}
+}
+
+// IncrementEndpoints adds the given calls to the daily endpoint aggregates.
+func (r *TokenUsageRepository) IncrementEndpoints(ctx context.Context, usages []domain.TokenUsageEndpoint) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	if len(usages) == 0 {
+		return nil
+	}
+
+	_, err := r.db.NewInsert().
+		Model(&usages).
+		On("?", upsert(column.TokenUsageEndpoint.ClientID, column.TokenUsageEndpoint.Method, column.TokenUsageEndpoint.Route, column.TokenUsageEndpoint.Day)).
+		Set("? = ? + ?", column.TokenUsageEndpoint.Calls, existing(column.TokenUsageEndpoint.Calls), inserted(column.TokenUsageEndpoint.Calls)).
+		Set("? = GREATEST(?, ?)", column.TokenUsageEndpoint.LastSeenAt, existing(column.TokenUsageEndpoint.LastSeenAt), inserted(column.TokenUsageEndpoint.LastSeenAt)).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot increment token usage endpoints")
+	}
+	return nil
+}
+
+// IncrementScopes adds the given calls to the daily scope aggregates.
+func (r *TokenUsageRepository) IncrementScopes(ctx context.Context, usages []domain.TokenUsageScope) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	if len(usages) == 0 {
+		return nil
+	}
+
+	_, err := r.db.NewInsert().
+		Model(&usages).
+		On("?", upsert(column.TokenUsageScope.ClientID, column.TokenUsageScope.Scope, column.TokenUsageScope.Day)).
+		Set("? = ? + ?", column.TokenUsageScope.GrantedCalls, existing(column.TokenUsageScope.GrantedCalls), inserted(column.TokenUsageScope.GrantedCalls)).
+		Set("? = ? + ?", column.TokenUsageScope.UsedCalls, existing(column.TokenUsageScope.UsedCalls), inserted(column.TokenUsageScope.UsedCalls)).
+		// MySQL returns NULL when any argument of GREATEST is NULL, PostgreSQL ignores them
+		Set("? = COALESCE(GREATEST(?, ?), ?, ?)", column.TokenUsageScope.LastUsedAt, existing(column.TokenUsageScope.LastUsedAt), inserted(column.TokenUsageScope.LastUsedAt), existing(column.TokenUsageScope.LastUsedAt), inserted(column.TokenUsageScope.LastUsedAt)).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot increment token usage scopes")
+	}
+	return nil
+}
+
+// ListEndpoints returns the endpoint usages between the given days summed per client and endpoint.
+// An empty client id returns every client. CockroachDB answers the usages of a few seconds ago, see followerRead.
+func (r *TokenUsageRepository) ListEndpoints(ctx context.Context, clientID string, from time.Time, to time.Time) ([]domain.TokenUsageEndpoint, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	usages := []domain.TokenUsageEndpoint{}
+	query := r.db.
+		NewSelect().
+		Model(&usages).
+		ModelTableExpr("?TableName AS ?TableAlias?", followerRead()).
+		Column("client_id", "principal_type", "method", "route").
+		ColumnExpr("SUM(?) AS ?", column.TokenUsageEndpoint.Calls, column.TokenUsageEndpoint.Calls).
+		ColumnExpr("MAX(?) AS ?", column.TokenUsageEndpoint.LastSeenAt, column.TokenUsageEndpoint.LastSeenAt).
+		Where("? BETWEEN ? AND ?", column.TokenUsageEndpoint.Day, from, to).
+		Group("client_id", "principal_type", "method", "route").
+		OrderExpr("? ASC, ? DESC", column.TokenUsageEndpoint.ClientID, column.TokenUsageEndpoint.Calls)
+	if clientID != "" {
+		query = query.Where("?=?", column.TokenUsageEndpoint.ClientID, clientID)
+	}
+
+	err := query.Scan(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list token usage endpoints")
+	}
+
+	return usages, nil
+}
+
+// ListScopes returns the scope usages between the given days summed per client and scope.
+// An empty client id returns every client. CockroachDB answers the usages of a few seconds ago, see followerRead.
+func (r *TokenUsageRepository) ListScopes(ctx context.Context, clientID string, from time.Time, to time.Time) ([]domain.TokenUsageScope, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	usages := []domain.TokenUsageScope{}
+	query := r.db.
+		NewSelect().
+		Model(&usages).
+		ModelTableExpr("?TableName AS ?TableAlias?", followerRead()).
+		Column("client_id", "scope").
+		ColumnExpr("SUM(?) AS ?", column.TokenUsageScope.GrantedCalls, column.TokenUsageScope.GrantedCalls).
+		ColumnExpr("SUM(?) AS ?", column.TokenUsageScope.UsedCalls, column.TokenUsageScope.UsedCalls).
+		ColumnExpr("MAX(?) AS ?", column.TokenUsageScope.LastUsedAt, column.TokenUsageScope.LastUsedAt).
+		Where("? BETWEEN ? AND ?", column.TokenUsageScope.Day, from, to).
+		Group("client_id", "scope").
+		OrderExpr("? ASC, ? ASC", column.TokenUsageScope.ClientID, column.TokenUsageScope.Scope)
+	if clientID != "" {
+		query = query.Where("?=?", column.TokenUsageScope.ClientID, clientID)
+	}
+
+	err := query.Scan(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list token usage scopes")
+	}
+
+	return usages, nil
+}
@@ -0,0 +1,273 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/sqlrepo/column"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/times"
+	"go-hex/shared/ierr"
+
+	"github.com/pkg/errors"
+	"github.com/uptrace/bun"
+)
+
+// UserRepository encapsulates the logic to access users from the data source.
+type UserRepository struct {
+	db DBI
+}
+
+// NewUserRepository creates a new user repository
+func NewUserRepository(db DBI) *UserRepository {
+	return &UserRepository{db}
+}
+
+// GetByID returns the user with the specified user ID.
+func (r *UserRepository) GetByID(ctx context.Context, userID string) (domain.User, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	user := domain.User{ID: userID}
+	err := r.db.
+		NewSelect().
+		Model(&user).
+		Where("?=?", column.User.ID, userID).
+		Scan(ctx)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return domain.User{}, ierr.ErrResourceNotFound
+		}
+		return domain.User{}, errors.Wrap(err, "cannot get user")
+	}
+
+	return user, nil
+}
+
+// GetByUsername returns the user with the specified username.
+func (r *UserRepository) GetByUsername(ctx context.Context, username string) (domain.User, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	var user domain.User
+
+	err := r.db.
+		NewSelect().
+		Model(&user).
+		Where("?=?", column.User.Username, username).
+		Scan(ctx)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return domain.User{}, ierr.ErrResourceNotFound
+		}
+		return domain.User{}, errors.Wrap(err, "cannot get user")
+	}
+
+	return user, nil
+}
+
+// ListByIDs returns the users with the specified IDs, the IDs without user are skipped.
+func (r *UserRepository) ListByIDs(ctx context.Context, userIDs []string) ([]domain.User, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	users := []domain.User{}
+	if len(userIDs) == 0 {
+		return users, nil
+	}
+	err := r.db.
+		NewSelect().
+		Model(&users).
+		Where("? IN (?)", column.User.ID, bun.In(userIDs)).
+		Scan(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list users")
+	}
+	return users, nil
+}
+
+// ListByEmail returns the users with the specified email address.
+func (r *UserRepository) ListByEmail(ctx context.Context, email string) ([]domain.User, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	users := []domain.User{}
+	err := r.db.
+		NewSelect().
+		Model(&users).
+		Where("?=?", column.User.Email, email).
+		OrderExpr("? ASC", column.User.ID).
+		Scan(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list users")
+	}
+	return users, nil
+}
+
+// List returns a page of users in a stable order, starting after the user with the specified ID when set.
+func (r *UserRepository) List(ctx context.Context, afterID string, limit int) ([]domain.User, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	users := []domain.User{}
+	query := r.db.NewSelect().Model(&users)
+	if afterID != "" {
+		query = query.Where("?>?", column.User.ID, afterID)
+	}
+	err := query.
+		OrderExpr("? ASC", column.User.ID).
+		Limit(limit).
+		Scan(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list users")
+	}
+	return users, nil
+}
+
+// IsUserExistByID checks wether user exists
+func (r *UserRepository) IsUserExistByID(ctx context.Context, userID string) (bool, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	user := domain.User{ID: userID}
+	exist, err := r.db.
+		NewSelect().
+		Model(&user).
+		Where("?=?", column.User.ID, userID).
+		Exists(ctx)
+
+	if err != nil {
+		return false, errors.Wrap(err, "cannot check user")
+	}
+	return exist, nil
+}
+
+// IsUserExistByUsername checks whether user exists by username
+func (r *UserRepository) IsUserExistByUsername(ctx context.Context, username string) (bool, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	user := domain.User{}
+	exist, err := r.db.
+		NewSelect().
+		Model(&user).
+		Where("?=?", column.User.Username, username).
+		Exists(ctx)
+
+	if err != nil {
+		return false, errors.Wrap(err, "cannot check user")
+	}
+	return exist, nil
+}
+
+// Create saves a new user in the storage.
+// It returns ierr.ErrUserAlreadyRegistered when the username is taken.
+func (r *UserRepository) Create(ctx context.Context, user domain.User) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.db.NewInsert().
+		Model(&user).
+		Exec(ctx)
+	if err != nil {
+		if isDuplicateEntry(err) {
+			return ierr.ErrUserAlreadyRegistered
+		}
+		return errors.Wrap(err, "cannot create user")
+	}
+	return nil
+}
+
+// Update updates the user with given ID in the storage.
+func (r *UserRepository) Update(ctx context.Context, userID string, user domain.User) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.db.NewUpdate().
+		Model(&user).
+		OmitZero().
+		Where("?=?", column.User.ID, userID).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot update user")
+	}
+	return nil
+}
+
+// SetActive activates or deactivates the user with given ID, Update cannot deactivate a user as it skips the zero fields.
+func (r *UserRepository) SetActive(ctx context.Context, userID string, active bool) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.db.NewUpdate().
+		Model((*domain.User)(nil)).
+		Set("?=?", column.User.IsActive, active).
+		Set("?=?", column.User.UpdatedAt, times.Now()).
+		Where("?=?", column.User.ID, userID).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot set user active")
+	}
+	return nil
+}
+
+// ClearRefreshToken clears the refresh token of the user if it still equals the given hashed token.
+// It returns false when the token has already been cleared or replaced.
+func (r *UserRepository) ClearRefreshToken(ctx context.Context, userID string, hashedToken string) (bool, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	res, err := r.db.NewUpdate().
+		Model((*domain.User)(nil)).
+		Set("?=NULL", column.User.RefreshToken).
+		Where("?=?", column.User.ID, userID).
+		Where("?=?", column.User.RefreshToken, hashedToken).
+		Exec(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "cannot clear refresh token")
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "cannot clear refresh token")
+	}
+	return affected > 0, nil
+}
+
+// ReplacePassword replaces the hashed password of the user if it still equals the given hashed password.
+// It returns false when the password has been changed meanwhile.
+func (r *UserRepository) ReplacePassword(ctx context.Context, userID string, hashedPwd string, newHashedPwd string) (bool, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	res, err := r.db.NewUpdate().
+		Model((*domain.User)(nil)).
+		Set("?=?", column.User.Password, newHashedPwd).
+		Set("?=?", column.User.UpdatedAt, times.Now()).
+		Where("?=?", column.User.ID, userID).
+		Where("?=?", column.User.Password, hashedPwd).
+		Exec(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "cannot replace password")
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "cannot replace password")
+	}
+	return affected > 0, nil
+}
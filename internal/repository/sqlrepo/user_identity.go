@@ -0,0 +1,85 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/sqlrepo/column"
+	"go-hex/pkg/otel"
+	"go-hex/shared/ierr"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// UserIdentityRepository encapsulates the logic to access the social login identities of the users from the data source.
+type UserIdentityRepository struct {
+	db DBI
+}
+
+// NewUserIdentityRepository creates a new user identity repository
+func NewUserIdentityRepository(db DBI) *UserIdentityRepository {
+	return &UserIdentityRepository{db}
+}
+
+// Get returns the identity of the specified account at the provider.
+// It returns ierr.ErrResourceNotFound when the account is not linked.
+func (r *UserIdentityRepository) Get(ctx context.Context, provider string, subject string) (domain.UserIdentity, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	var identity domain.UserIdentity
+	err := r.db.
+		NewSelect().
+		Model(&identity).
+		Where("?=?", column.UserIdentity.Provider, provider).
+		Where("?=?", column.UserIdentity.Subject, subject).
+		Scan(ctx)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return domain.UserIdentity{}, ierr.ErrResourceNotFound
+		}
+		return domain.UserIdentity{}, errors.Wrap(err, "cannot get user identity")
+	}
+
+	return identity, nil
+}
+
+// Create links an account at a provider to a user.
+// It returns ierr.ErrConflict when the account is already linked.
+func (r *UserIdentityRepository) Create(ctx context.Context, identity domain.UserIdentity) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.db.NewInsert().
+		Model(&identity).
+		Exec(ctx)
+	if err != nil {
+		if isDuplicateEntry(err) {
+			return ierr.ErrConflict
+		}
+		return errors.Wrap(err, "cannot create user identity")
+	}
+	return nil
+}
+
+// Touch records a login of the identity.
+func (r *UserIdentityRepository) Touch(ctx context.Context, provider string, subject string, at time.Time) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.db.NewUpdate().
+		Model((*domain.UserIdentity)(nil)).
+		Set("?=?", column.UserIdentity.LastLoginAt, at).
+		Where("?=?", column.UserIdentity.Provider, provider).
+		Where("?=?", column.UserIdentity.Subject, subject).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot update user identity last login at")
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/sqlrepo/column"
+	"go-hex/pkg/otel"
+	"go-hex/shared/ierr"
+
+	"github.com/pkg/errors"
+)
+
+// UserSyncRepository encapsulates the logic to access the links and the runs of the user sync from the data source.
+type UserSyncRepository struct {
+	db DBI
+}
+
+// NewUserSyncRepository creates a new user sync repository
+func NewUserSyncRepository(db DBI) *UserSyncRepository {
+	return &UserSyncRepository{db}
+}
+
+// ListLinks returns the links of the users synced from the specified source.
+func (r *UserSyncRepository) ListLinks(ctx context.Context, source string) ([]domain.UserSyncLink, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	links := []domain.UserSyncLink{}
+	err := r.db.NewSelect().
+		Model(&links).
+		Where("?=?", column.UserSyncLink.Source, source).
+		Scan(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list user sync links")
+	}
+	return links, nil
+}
+
+// SaveLink saves the link of a user, replacing the previous link of the same external identity.
+func (r *UserSyncRepository) SaveLink(ctx context.Context, link domain.UserSyncLink) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.db.NewInsert().
+		Model(&link).
+		On("?", upsert(column.UserSyncLink.Source, column.UserSyncLink.ExternalID)).
+		Set("? = ?", column.UserSyncLink.UserID, inserted(column.UserSyncLink.UserID)).
+		Set("? = ?", column.UserSyncLink.SyncedAt, inserted(column.UserSyncLink.SyncedAt)).
+		Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot save user sync link")
+	}
+	return nil
+}
+
+// CreateRun saves the history of a sync run.
+func (r *UserSyncRepository) CreateRun(ctx context.Context, run domain.UserSyncRun) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	_, err := r.db.NewInsert().Model(&run).Exec(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cannot create user sync run")
+	}
+	return nil
+}
+
+// GetRun returns the sync run with the specified ID.
+func (r *UserSyncRepository) GetRun(ctx context.Context, id string) (domain.UserSyncRun, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	var run domain.UserSyncRun
+	err := r.db.NewSelect().
+		Model(&run).
+		Where("?=?", column.UserSyncRun.ID, id).
+		Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return domain.UserSyncRun{}, ierr.ErrResourceNotFound
+		}
+		return domain.UserSyncRun{}, errors.Wrap(err, "cannot get user sync run")
+	}
+	return run, nil
+}
+
+// ListRuns returns the latest sync runs without their changes, the newest first, of every source when source is empty.
+func (r *UserSyncRepository) ListRuns(ctx context.Context, source string, limit int) ([]domain.UserSyncRun, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	runs := []domain.UserSyncRun{}
+	query := r.db.NewSelect().
+		Model(&runs).
+		ExcludeColumn(string(column.UserSyncRun.Changes))
+	if source != "" {
+		query = query.Where("?=?", column.UserSyncRun.Source, source)
+	}
+	err := query.
+		OrderExpr("? DESC", column.UserSyncRun.StartedAt).
+		Limit(limit).
+		Scan(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list user sync runs")
+	}
+	return runs, nil
+}
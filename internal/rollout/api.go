@@ -0,0 +1,88 @@
+package rollout
+
+import (
+	"go-hex/configs"
+	"go-hex/middleware"
+	"go-hex/shared/ierr"
+	"go-hex/shared/response"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RegisterAPI registers a new deployment analysis api
+func RegisterAPI(r echo.Group, cfg *configs.Config, service ServicePort) {
+	handler := handler{cfg, service}
+
+	// Internal endpoints, called by the rollout controllers
+	internal := r.Group("/internal/deployments", middleware.InternalAPI(cfg.InternalAPI.User, cfg.InternalAPI.Password))
+	internal.GET("/analysis", handler.analyze)
+	internal.POST("/analysis/gate", handler.gate)
+}
+
+type handler struct {
+	cfg     *configs.Config
+	service ServicePort
+}
+
+// analyze godoc
+// @Router /internal/deployments/analysis [get]
+// @Tags Deployments
+// @Summary Analyze a deployment
+// @Description Error rate and latency percentiles of the requests served by a version over the window, compared with its baseline, and the verdict of the analysis: promote, rollback or inconclusive. The stats are added up across the instances when Redis is configured. Consumed by the web metrics of Argo Rollouts, e.g. with the success condition result.verdict != "rollback".
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Param version query string false "version label of the build, defaults to the version of the instance"
+// @Param baseline query string false "version label of the baseline, no comparison when empty"
+// @Param window query string false "window ending now, from 1m to 1h, defaults to 5m"
+// @Success 200 {object} response.Response{data=ResponseAnalysis} "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) analyze(c echo.Context) error {
+	var req RequestAnalysis
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.Analyze(c.Request().Context(), req)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessOK(c, res)
+}
+
+// gate godoc
+// @Router /internal/deployments/analysis/gate [post]
+// @Tags Deployments
+// @Summary Gate a deployment
+// @Description Analyze the version requested by the metadata of a Flagger webhook, answered with 200 when the canary can be promoted and 412 otherwise, halting the rollout
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Param body body RequestGate true "Flagger webhook"
+// @Success 200 {object} response.Response{data=ResponseAnalysis} "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 412 {object} response.ErrorResponse "the canary cannot be promoted, with the reasons"
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) gate(c echo.Context) error {
+	var req RequestGate
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.Analyze(c.Request().Context(), req.Metadata)
+	if err != nil {
+		return err
+	}
+	if res.Verdict != VerdictPromote {
+		message := res.Verdict + ": " + strings.Join(res.Reasons, ", ")
+		return response.HTTPError(ierr.ErrCanaryRejected, http.StatusPreconditionFailed, ierr.ErrCanaryRejected.Code, message)
+	}
+
+	return response.SuccessOK(c, res)
+}
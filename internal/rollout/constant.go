@@ -0,0 +1,18 @@
+package rollout
+
+import "time"
+
+// Verdicts of the deployment analysis
+const (
+	VerdictPromote      = "promote"      // the canary is within the thresholds, the rollout can proceed
+	VerdictRollback     = "rollback"     // the canary fails or is slower than allowed, the rollout must be aborted
+	VerdictInconclusive = "inconclusive" // too few requests to decide, the analysis must be run again later
+)
+
+const (
+	// defaultWindow is the window analyzed when the request does not set one
+	defaultWindow = 5 * time.Minute
+	// minWindow and maxWindow bound the windows analyzed, the stats are counted per minute
+	minWindow = time.Minute
+	maxWindow = time.Hour
+)
@@ -0,0 +1,77 @@
+package rollout
+
+import (
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pkg/errors"
+)
+
+// RequestAnalysis request query
+type RequestAnalysis struct {
+	Version  string `query:"version" json:"version" example:"v1.3.0"`   // defaults to the version of the instance
+	Baseline string `query:"baseline" json:"baseline" example:"v1.2.0"` // no comparison when empty
+	Window   string `query:"window" json:"window" example:"5m"`         // defaults to 5m
+}
+
+func (r *RequestAnalysis) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Version, validation.Length(0, 100)),
+		validation.Field(&r.Baseline, validation.Length(0, 100)),
+		validation.Field(&r.Window, validation.By(isWindow)),
+	)
+}
+
+// RequestGate is the payload of the Flagger webhooks, the analysis is requested by their metadata
+type RequestGate struct {
+	Name      string          `json:"name" example:"go-hex"`
+	Namespace string          `json:"namespace" example:"auth"`
+	Phase     string          `json:"phase" example:"Progressing"`
+	Metadata  RequestAnalysis `json:"metadata"`
+}
+
+// ResponseAnalysis struct
+type ResponseAnalysis struct {
+	Version  string    `json:"version" example:"v1.3.0"`
+	Baseline string    `json:"baseline,omitempty" example:"v1.2.0"`
+	Window   string    `json:"window" example:"5m"`
+	From     time.Time `json:"from"`
+	To       time.Time `json:"to"`
+	Canary   Summary   `json:"canary"`
+	// BaselineSummary and the deltas are only set when a baseline is requested
+	BaselineSummary   *Summary `json:"baseline_summary,omitempty"`
+	ErrorRateDelta    *float64 `json:"error_rate_delta,omitempty" example:"0.002"`
+	LatencyP95Delta   *float64 `json:"latency_p95_delta_ms,omitempty" example:"12.5"`
+	Verdict           string   `json:"verdict" example:"promote"`
+	Reasons           []string `json:"reasons"`
+	MinRequests       int      `json:"min_requests" example:"100"`
+	MaxErrorRate      float64  `json:"max_error_rate" example:"0.01"`
+	MaxErrorRateDelta float64  `json:"max_error_rate_delta" example:"0.005"`
+	MaxLatencyDelta   int      `json:"max_latency_delta_ms" example:"100"`
+}
+
+// Summary sums up the requests served by a version over the window
+type Summary struct {
+	Requests   uint64  `json:"requests" example:"1200"`
+	Errors     uint64  `json:"errors" example:"3"`
+	ErrorRate  float64 `json:"error_rate" example:"0.0025"`
+	LatencyP50 float64 `json:"latency_p50_ms" example:"42.1"`
+	LatencyP95 float64 `json:"latency_p95_ms" example:"180.3"`
+	LatencyP99 float64 `json:"latency_p99_ms" example:"410.7"`
+}
+
+// isWindow checks that the window is a duration between minWindow and maxWindow, e.g. 5m
+func isWindow(value interface{}) error {
+	s, _ := value.(string)
+	if s == "" {
+		return nil
+	}
+	window, err := time.ParseDuration(s)
+	if err != nil {
+		return errors.New("must be a duration such as 5m")
+	}
+	if window < minWindow || window > maxWindow {
+		return errors.Errorf("must be between %s and %s", minWindow, maxWindow)
+	}
+	return nil
+}
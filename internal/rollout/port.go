@@ -0,0 +1,11 @@
+package rollout
+
+import (
+	"context"
+)
+
+// ServicePort encapsulates the deployment analysis logic.
+type ServicePort interface {
+	// Analyze compares the error rate and the latency of a version with the thresholds and its baseline
+	Analyze(ctx context.Context, req RequestAnalysis) (ResponseAnalysis, error)
+}
@@ -0,0 +1,109 @@
+package rollout
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/slo"
+	"sync"
+	"time"
+)
+
+// Recorder counts the outcomes of the requests served by the version of the instance per minute. The counts are
+// kept in memory and added to the repository periodically, so that recording never adds a round trip to the request.
+type Recorder struct {
+	repo    port.DeploymentStatsRepository
+	log     logger.Logger
+	version string
+	ignore  func(route string) bool
+
+	mu      sync.Mutex
+	minutes map[int64]*domain.DeploymentStats
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewRecorder creates a recorder of the requests of the version, except the ones of the routes ignored, flushing
+// its counts every flushInterval until it is closed. A zero flush interval disables the recording.
+func NewRecorder(repo port.DeploymentStatsRepository, log logger.Logger, version string, ignore func(route string) bool, flushInterval time.Duration) *Recorder {
+	r := &Recorder{
+		repo:    repo,
+		log:     log,
+		version: version,
+		ignore:  ignore,
+		minutes: map[int64]*domain.DeploymentStats{},
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	if flushInterval <= 0 {
+		r.repo = nil
+		close(r.done)
+		return r
+	}
+	go r.run(flushInterval)
+	return r
+}
+
+// Record counts the outcome of a request of the endpoint, its class and latency, as the slo tracker does
+func (r *Recorder) Record(method, route, class string, latency time.Duration, at time.Time) {
+	if r.repo == nil || (r.ignore != nil && r.ignore(route)) {
+		return
+	}
+
+	minute := at.UTC().Truncate(time.Minute)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats, ok := r.minutes[minute.Unix()]
+	if !ok {
+		created := domain.NewDeploymentStats(r.version, minute)
+		stats = &created
+		r.minutes[minute.Unix()] = stats
+	}
+	stats.Observe(class == slo.ClassDependencyError || class == slo.ClassInternalError, class == slo.ClassSuccess, latency)
+}
+
+// Close flushes the remaining counts and stops the recorder
+func (r *Recorder) Close() {
+	close(r.stop)
+	<-r.done
+}
+
+func (r *Recorder) run(flushInterval time.Duration) {
+	defer close(r.done)
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.flush()
+		case <-r.stop:
+			r.flush()
+			return
+		}
+	}
+}
+
+// flush adds the counts collected since the last flush to the repository.
+// The counts are dropped when they cannot be added, the analysis is best effort.
+func (r *Recorder) flush() {
+	r.mu.Lock()
+	stats := make([]domain.DeploymentStats, 0, len(r.minutes))
+	for _, minute := range r.minutes {
+		stats = append(stats, *minute)
+	}
+	r.minutes = map[int64]*domain.DeploymentStats{}
+	r.mu.Unlock()
+
+	if len(stats) == 0 {
+		return
+	}
+	if err := r.repo.Add(context.Background(), stats); err != nil {
+		r.log.WithParam("type", "deploy_analysis").Error(err)
+	}
+}
@@ -0,0 +1,59 @@
+package rollout
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/slo"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingDeploymentStatsRepository struct {
+	port.DeploymentStatsRepository
+	stats []domain.DeploymentStats
+}
+
+func (r *recordingDeploymentStatsRepository) Add(ctx context.Context, stats []domain.DeploymentStats) error {
+	r.stats = append(r.stats, stats...)
+	return nil
+}
+
+func TestRecorderCountsTheRequestsPerMinute(t *testing.T) {
+	repo := &recordingDeploymentStatsRepository{}
+	ignore := func(route string) bool { return strings.HasPrefix(route, "/internal/") }
+	recorder := NewRecorder(repo, logger.New("test", "test"), "v1.3.0", ignore, time.Hour)
+
+	at := time.Date(2026, 10, 15, 12, 0, 30, 0, time.UTC)
+	recorder.Record("POST", "/auth/login", slo.ClassSuccess, 80*time.Millisecond, at)
+	recorder.Record("POST", "/auth/login", slo.ClassClientError, time.Millisecond, at)
+	recorder.Record("POST", "/auth/login", slo.ClassDependencyError, time.Second, at)
+	recorder.Record("GET", "/me", slo.ClassSuccess, 20*time.Millisecond, at.Add(time.Minute))
+	recorder.Record("GET", "/internal/users", slo.ClassInternalError, time.Millisecond, at)
+	recorder.Close()
+
+	if assert.Len(t, repo.stats, 2) {
+		byMinute := map[time.Time]domain.DeploymentStats{}
+		for _, s := range repo.stats {
+			assert.Equal(t, "v1.3.0", s.Version)
+			byMinute[s.Minute] = s
+		}
+		first := byMinute[at.Truncate(time.Minute)]
+		assert.Equal(t, uint64(3), first.Requests)
+		assert.Equal(t, uint64(1), first.Errors, "only the errors of the service and its dependencies are counted")
+		assert.Equal(t, uint64(1), first.Latencies[domain.LatencyBucket(80*time.Millisecond)], "only the latency of the successful requests is counted")
+		assert.Equal(t, uint64(1), byMinute[at.Add(time.Minute).Truncate(time.Minute)].Requests)
+	}
+}
+
+func TestRecorderDisabled(t *testing.T) {
+	repo := &recordingDeploymentStatsRepository{}
+	recorder := NewRecorder(repo, logger.New("test", "test"), "v1.3.0", nil, 0)
+	recorder.Record("POST", "/auth/login", slo.ClassSuccess, time.Millisecond, time.Now())
+	recorder.Close()
+	assert.Empty(t, repo.stats)
+}
@@ -0,0 +1,175 @@
+package rollout
+
+import (
+	"context"
+	"fmt"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/times"
+	"time"
+)
+
+// Service encapsulates the deployment analysis logic.
+type Service struct {
+	cfg     *configs.Config
+	stats   port.DeploymentStatsRepository
+	version string
+}
+
+// NewService creates and returns a new deployment analysis service, analyzing the version of the instance by default
+func NewService(cfg *configs.Config, stats port.DeploymentStatsRepository, version string) *Service {
+	return &Service{cfg, stats, version}
+}
+
+// Analyze sums up the requests served by the version over the window ending now, and by its baseline when requested.
+// The version is rolled back when its error rate exceeds the maximum, or when its error rate or its p95 latency
+// exceed the ones of its baseline by more than the allowed deltas. The analysis is inconclusive while the version,
+// or its baseline, served fewer requests than the minimum.
+func (s *Service) Analyze(ctx context.Context, req RequestAnalysis) (ResponseAnalysis, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	var res ResponseAnalysis
+
+	err := req.Validate()
+	if err != nil {
+		return res, err
+	}
+	if req.Version == "" {
+		req.Version = s.version
+	}
+	window := defaultWindow
+	if req.Window != "" {
+		window, _ = time.ParseDuration(req.Window)
+	} else {
+		req.Window = defaultWindow.String()
+	}
+
+	// the window covers the minutes ending with the current one, which is still counted
+	to := times.Now().UTC().Truncate(time.Minute)
+	from := to.Add(-window.Truncate(time.Minute) + time.Minute)
+
+	cfg := s.cfg.DeployAnalysis
+	res = ResponseAnalysis{
+		Version:           req.Version,
+		Baseline:          req.Baseline,
+		Window:            req.Window,
+		From:              from,
+		To:                to.Add(time.Minute),
+		Reasons:           []string{},
+		MinRequests:       cfg.MinRequests,
+		MaxErrorRate:      cfg.MaxErrorRate,
+		MaxErrorRateDelta: cfg.MaxErrorRateDelta,
+		MaxLatencyDelta:   cfg.MaxLatencyDelta,
+	}
+
+	res.Canary, err = s.summarize(ctx, req.Version, from, to)
+	if err != nil {
+		return res, err
+	}
+	if req.Baseline != "" {
+		baseline, err := s.summarize(ctx, req.Baseline, from, to)
+		if err != nil {
+			return res, err
+		}
+		errorRateDelta := res.Canary.ErrorRate - baseline.ErrorRate
+		latencyDelta := res.Canary.LatencyP95 - baseline.LatencyP95
+		res.BaselineSummary, res.ErrorRateDelta, res.LatencyP95Delta = &baseline, &errorRateDelta, &latencyDelta
+	}
+
+	res.Verdict, res.Reasons = s.judge(res)
+	return res, nil
+}
+
+// judge returns the verdict of the analysis and the reasons of a rollback or of an inconclusive analysis
+func (s *Service) judge(res ResponseAnalysis) (string, []string) {
+	cfg := s.cfg.DeployAnalysis
+
+	reasons := []string{}
+	if res.Canary.Requests < uint64(cfg.MinRequests) {
+		reasons = append(reasons, fmt.Sprintf("version %s served %d requests, fewer than %d", res.Version, res.Canary.Requests, cfg.MinRequests))
+	}
+	if res.BaselineSummary != nil && res.BaselineSummary.Requests < uint64(cfg.MinRequests) {
+		reasons = append(reasons, fmt.Sprintf("baseline %s served %d requests, fewer than %d", res.Baseline, res.BaselineSummary.Requests, cfg.MinRequests))
+	}
+	if len(reasons) > 0 {
+		return VerdictInconclusive, reasons
+	}
+
+	if res.Canary.ErrorRate > cfg.MaxErrorRate {
+		reasons = append(reasons, fmt.Sprintf("error rate %.4f exceeds %.4f", res.Canary.ErrorRate, cfg.MaxErrorRate))
+	}
+	if res.ErrorRateDelta != nil && *res.ErrorRateDelta > cfg.MaxErrorRateDelta {
+		reasons = append(reasons, fmt.Sprintf("error rate exceeds the baseline by %.4f, more than %.4f", *res.ErrorRateDelta, cfg.MaxErrorRateDelta))
+	}
+	if res.LatencyP95Delta != nil && *res.LatencyP95Delta > float64(cfg.MaxLatencyDelta) {
+		reasons = append(reasons, fmt.Sprintf("p95 latency exceeds the baseline by %.1fms, more than %dms", *res.LatencyP95Delta, cfg.MaxLatencyDelta))
+	}
+	if len(reasons) > 0 {
+		return VerdictRollback, reasons
+	}
+	return VerdictPromote, reasons
+}
+
+// summarize sums up the stats of the version between the minutes from and to included
+func (s *Service) summarize(ctx context.Context, version string, from, to time.Time) (Summary, error) {
+
+	stats, err := s.stats.List(ctx, version, from, to)
+	if err != nil {
+		return Summary{}, err
+	}
+	sum := domain.NewDeploymentStats(version, from)
+	for _, minute := range stats {
+		sum.Add(minute)
+	}
+
+	res := Summary{
+		Requests:   sum.Requests,
+		Errors:     sum.Errors,
+		LatencyP50: quantile(0.5, sum.Latencies),
+		LatencyP95: quantile(0.95, sum.Latencies),
+		LatencyP99: quantile(0.99, sum.Latencies),
+	}
+	if sum.Requests > 0 {
+		res.ErrorRate = float64(sum.Errors) / float64(sum.Requests)
+	}
+	return res, nil
+}
+
+// quantile estimates the quantile of the latencies counted in the buckets, in milliseconds, interpolating linearly
+// within the bucket holding it as Prometheus does. The quantile falling in the last bucket is its lower bound.
+func quantile(q float64, buckets []uint64) float64 {
+	var total uint64
+	for _, count := range buckets {
+		total += count
+	}
+	if total == 0 {
+		return 0
+	}
+
+	rank := q * float64(total)
+	var cumulative uint64
+	for i, count := range buckets {
+		if count == 0 || float64(cumulative+count) < rank {
+			cumulative += count
+			continue
+		}
+		if i == len(domain.DeploymentLatencyBounds) {
+			break
+		}
+		var lower float64
+		if i > 0 {
+			lower = millis(domain.DeploymentLatencyBounds[i-1])
+		}
+		upper := millis(domain.DeploymentLatencyBounds[i])
+		return lower + (upper-lower)*(rank-float64(cumulative))/float64(count)
+	}
+	return millis(domain.DeploymentLatencyBounds[len(domain.DeploymentLatencyBounds)-1])
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,101 @@
+package rollout
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/memory"
+	"go-hex/internal/repository/port"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newConfig() *configs.Config {
+	cfg := &configs.Config{}
+	cfg.DeployAnalysis.MinRequests = 100
+	cfg.DeployAnalysis.MaxErrorRate = 0.01
+	cfg.DeployAnalysis.MaxErrorRateDelta = 0.005
+	cfg.DeployAnalysis.MaxLatencyDelta = 100
+	return cfg
+}
+
+// addStats adds requests of the version during the current minute, failed ones and successful ones answered after latency
+func addStats(t *testing.T, repo port.DeploymentStatsRepository, version string, failed, success int, latency time.Duration) {
+	stats := domain.NewDeploymentStats(version, time.Now().UTC().Truncate(time.Minute))
+	for i := 0; i < failed; i++ {
+		stats.Observe(true, false, 0)
+	}
+	for i := 0; i < success; i++ {
+		stats.Observe(false, true, latency)
+	}
+	require.NoError(t, repo.Add(context.Background(), []domain.DeploymentStats{stats}))
+}
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		name    string
+		canary  [2]int
+		latency time.Duration
+		verdict string
+	}{
+		{"promoted", [2]int{1, 999}, 40 * time.Millisecond, VerdictPromote},
+		{"too few requests", [2]int{0, 50}, 40 * time.Millisecond, VerdictInconclusive},
+		{"failing", [2]int{20, 980}, 40 * time.Millisecond, VerdictRollback},
+		{"slower than the baseline", [2]int{1, 999}, 400 * time.Millisecond, VerdictRollback},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := memory.NewDeploymentStatsRepository(time.Hour)
+			addStats(t, repo, "v1.3.0", tt.canary[0], tt.canary[1], tt.latency)
+			addStats(t, repo, "v1.2.0", 1, 999, 40*time.Millisecond)
+
+			res, err := NewService(newConfig(), repo, "v1.3.0").Analyze(context.Background(), RequestAnalysis{Baseline: "v1.2.0"})
+			require.NoError(t, err)
+			assert.Equal(t, tt.verdict, res.Verdict, res.Reasons)
+			assert.Equal(t, "v1.3.0", res.Version, "the version of the instance is analyzed by default")
+			assert.Equal(t, "5m0s", res.Window)
+			assert.Equal(t, uint64(tt.canary[0]+tt.canary[1]), res.Canary.Requests)
+			if assert.NotNil(t, res.BaselineSummary) {
+				assert.Equal(t, uint64(1000), res.BaselineSummary.Requests)
+			}
+		})
+	}
+}
+
+func TestAnalyzeWithoutBaseline(t *testing.T) {
+	repo := memory.NewDeploymentStatsRepository(time.Hour)
+	addStats(t, repo, "v1.3.0", 5, 995, 400*time.Millisecond)
+
+	res, err := NewService(newConfig(), repo, "v1.2.0").Analyze(context.Background(), RequestAnalysis{Version: "v1.3.0", Window: "10m"})
+	require.NoError(t, err)
+	assert.Equal(t, VerdictPromote, res.Verdict, "the latency is only compared with a baseline")
+	assert.Nil(t, res.BaselineSummary)
+	assert.Nil(t, res.LatencyP95Delta)
+	assert.InDelta(t, 0.005, res.Canary.ErrorRate, 1e-9)
+	assert.Equal(t, 10*time.Minute, res.To.Sub(res.From))
+}
+
+func TestAnalyzeRejectsInvalidWindow(t *testing.T) {
+	service := NewService(newConfig(), memory.NewDeploymentStatsRepository(time.Hour), "v1.3.0")
+	for _, window := range []string{"5", "30s", "2h"} {
+		_, err := service.Analyze(context.Background(), RequestAnalysis{Window: window})
+		assert.Error(t, err, window)
+	}
+}
+
+func TestQuantile(t *testing.T) {
+	buckets := make([]uint64, len(domain.DeploymentLatencyBounds)+1)
+	assert.Equal(t, float64(0), quantile(0.5, buckets))
+
+	// 100 requests between 50ms and 100ms
+	buckets[domain.LatencyBucket(80*time.Millisecond)] = 100
+	assert.InDelta(t, 75, quantile(0.5, buckets), 1e-9)
+	assert.InDelta(t, 97.5, quantile(0.95, buckets), 1e-9)
+
+	// the slowest requests are reported at the last bound
+	buckets[len(buckets)-1] = 900
+	assert.Equal(t, float64(10000), quantile(0.99, buckets))
+}
@@ -0,0 +1,339 @@
+package serviceaccount
+
+import (
+	"go-hex/configs"
+	"go-hex/middleware"
+	"go-hex/shared/ierr"
+	"go-hex/shared/response"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+// RegisterAPI registers a new service account api
+func RegisterAPI(r echo.Group, cfg *configs.Config, service ServicePort) {
+	handler := handler{cfg, service}
+
+	r.POST("/service-accounts/token", handler.issueToken)
+
+	// Internal endpoints
+	internal := r.Group("/internal/service-accounts", middleware.InternalAPI(cfg.InternalAPI.User, cfg.InternalAPI.Password))
+	internal.POST("", handler.create)
+	internal.GET("", handler.list)
+	internal.GET("/:id", handler.get)
+	internal.GET("/:id/audit", handler.listAuditEvents)
+	internal.POST("/:id/keys", handler.rotateKey)
+	internal.POST("/:id/enable", handler.enable)
+	internal.POST("/:id/disable", handler.disable)
+	internal.POST("/:id/roles", handler.bindRole)
+	internal.DELETE("/:id/roles/:role", handler.unbindRole)
+}
+
+type handler struct {
+	cfg     *configs.Config
+	service ServicePort
+}
+
+// issueToken godoc
+// @Router /service-accounts/token [post]
+// @Tags Service Account
+// @Summary Issue a service account token
+// @Description Issue a short-lived access token with the client credentials grant, authenticated by a JWT assertion signed with the service account private key (private_key_jwt)
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param grant_type formData string true "client_credentials"
+// @Param client_assertion_type formData string true "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+// @Param client_assertion formData string true "RS256 signed JWT with iss and sub set to the service account id, aud, jti and exp"
+// @Success 200 {object} response.Response{data=ResponseToken} "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 403 {object} response.ErrorResponse403
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) issueToken(c echo.Context) error {
+	var req RequestToken
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.IssueToken(c.Request().Context(), req)
+	if err != nil {
+		switch errors.Cause(err) {
+		case ierr.ErrUnsupportedGrantType:
+			return response.ErrBadRequest(err)
+		case ierr.ErrInvalidClientAssertion:
+			return response.ErrUnauthorized(err)
+		case ierr.ErrServiceAccountDisabled:
+			return response.ErrForbidden(err)
+		}
+		return err
+	}
+
+	c.Response().Header().Set(echo.HeaderCacheControl, "no-store")
+	return response.SuccessOK(c, res, "token issued")
+}
+
+// create godoc
+// @Router /internal/service-accounts [post]
+// @Tags Service Account
+// @Summary Create a service account
+// @Description Create a service account, a key pair is generated when no public key is given and the private key is only returned once
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Param payload body RequestCreateServiceAccount false " "
+// @Success 201 {object} response.Response{data=ResponseCreateServiceAccount} "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) create(c echo.Context) error {
+	var req RequestCreateServiceAccount
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.Create(c.Request().Context(), req)
+	if err != nil {
+		if errors.Cause(err) == ierr.ErrInvalidPublicKey {
+			return response.ErrBadRequest(err)
+		}
+		return err
+	}
+
+	return response.SuccessCreated(c, res, "service account created")
+}
+
+// list godoc
+// @Router /internal/service-accounts [get]
+// @Tags Service Account
+// @Summary List service accounts
+// @Description List service accounts with their roles
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Success 200 {object} response.Response{data=[]ResponseServiceAccount} "Success"
+// @failure 401 {object} response.ErrorResponse401
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) list(c echo.Context) error {
+	res, err := h.service.List(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessOK(c, res)
+}
+
+// get godoc
+// @Router /internal/service-accounts/{id} [get]
+// @Tags Service Account
+// @Summary Get a service account
+// @Description Get a service account with its roles
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Param id path string true "service account id"
+// @Success 200 {object} response.Response{data=ResponseServiceAccount} "Success"
+// @failure 401 {object} response.ErrorResponse401
+// @failure 404 {object} response.ErrorResponse404
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) get(c echo.Context) error {
+	var req RequestServiceAccountID
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.Get(c.Request().Context(), req)
+	if err != nil {
+		if errors.Cause(err) == ierr.ErrResourceNotFound {
+			return response.ErrNotFound(err)
+		}
+		return err
+	}
+
+	return response.SuccessOK(c, res)
+}
+
+// listAuditEvents godoc
+// @Router /internal/service-accounts/{id}/audit [get]
+// @Tags Service Account
+// @Summary Get the audit trail of a service account
+// @Description Get the changes made to a service account and the tokens issued to it, the newest first
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Param id path string true "service account id"
+// @Param limit query int false "maximum number of events, 100 by default and up to 1000"
+// @Param offset query int false "number of newer events to skip"
+// @Success 200 {object} response.Response{data=[]domain.ServiceAccountAuditEvent} "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 404 {object} response.ErrorResponse404
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) listAuditEvents(c echo.Context) error {
+	var req RequestListAuditEvents
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.ListAuditEvents(c.Request().Context(), req)
+	if err != nil {
+		if errors.Cause(err) == ierr.ErrResourceNotFound {
+			return response.ErrNotFound(err)
+		}
+		return err
+	}
+
+	return response.SuccessOK(c, response.Page{Items: res.Events, Offset: res.Offset, Limit: res.Limit, More: res.More})
+}
+
+// rotateKey godoc
+// @Router /internal/service-accounts/{id}/keys [post]
+// @Tags Service Account
+// @Summary Rotate the key of a service account
+// @Description Replace the public key of a service account, a key pair is generated when no public key is given
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Param id path string true "service account id"
+// @Param payload body RequestRotateKey false " "
+// @Success 200 {object} response.Response{data=ResponseCreateServiceAccount} "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 404 {object} response.ErrorResponse404
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) rotateKey(c echo.Context) error {
+	var req RequestRotateKey
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.RotateKey(c.Request().Context(), req)
+	if err != nil {
+		switch errors.Cause(err) {
+		case ierr.ErrInvalidPublicKey:
+			return response.ErrBadRequest(err)
+		case ierr.ErrResourceNotFound:
+			return response.ErrNotFound(err)
+		}
+		return err
+	}
+
+	return response.SuccessOK(c, res, "service account key rotated")
+}
+
+// enable godoc
+// @Router /internal/service-accounts/{id}/enable [post]
+// @Tags Service Account
+// @Summary Enable a service account
+// @Description Enable a service account
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Param id path string true "service account id"
+// @Success 200 {object} response.Response "Success"
+// @failure 401 {object} response.ErrorResponse401
+// @failure 404 {object} response.ErrorResponse404
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) enable(c echo.Context) error {
+	return h.setActive(c, true)
+}
+
+// disable godoc
+// @Router /internal/service-accounts/{id}/disable [post]
+// @Tags Service Account
+// @Summary Disable a service account
+// @Description Disable a service account, it cannot obtain new tokens anymore
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Param id path string true "service account id"
+// @Success 200 {object} response.Response "Success"
+// @failure 401 {object} response.ErrorResponse401
+// @failure 404 {object} response.ErrorResponse404
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) disable(c echo.Context) error {
+	return h.setActive(c, false)
+}
+
+func (h handler) setActive(c echo.Context, isActive bool) error {
+	var req RequestServiceAccountID
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	err := h.service.SetActive(c.Request().Context(), req, isActive)
+	if err != nil {
+		if errors.Cause(err) == ierr.ErrResourceNotFound {
+			return response.ErrNotFound(err)
+		}
+		return err
+	}
+
+	if isActive {
+		return response.SuccessOK(c, nil, "service account enabled")
+	}
+	return response.SuccessOK(c, nil, "service account disabled")
+}
+
+// bindRole godoc
+// @Router /internal/service-accounts/{id}/roles [post]
+// @Tags Service Account
+// @Summary Bind a role to a service account
+// @Description Bind a role to a service account, the role is granted to the tokens issued afterwards. A role bound with starts_at and/or ends_at is only granted within its window, binding the role again replaces its window.
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Param id path string true "service account id"
+// @Param payload body RequestServiceAccountRole false " "
+// @Success 200 {object} response.Response "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 404 {object} response.ErrorResponse404
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) bindRole(c echo.Context) error {
+	var req RequestServiceAccountRole
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	err := h.service.BindRole(c.Request().Context(), req)
+	if err != nil {
+		if errors.Cause(err) == ierr.ErrResourceNotFound {
+			return response.ErrNotFound(err)
+		}
+		return err
+	}
+
+	return response.SuccessOK(c, nil, "role bound")
+}
+
+// unbindRole godoc
+// @Router /internal/service-accounts/{id}/roles/{role} [delete]
+// @Tags Service Account
+// @Summary Unbind a role from a service account
+// @Description Unbind a role from a service account
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Param id path string true "service account id"
+// @Param role path string true "role"
+// @Success 200 {object} response.Response "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 404 {object} response.ErrorResponse404
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) unbindRole(c echo.Context) error {
+	var req RequestServiceAccountRole
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	err := h.service.UnbindRole(c.Request().Context(), req)
+	if err != nil {
+		if errors.Cause(err) == ierr.ErrResourceNotFound {
+			return response.ErrNotFound(err)
+		}
+		return err
+	}
+
+	return response.SuccessOK(c, nil, "role unbound")
+}
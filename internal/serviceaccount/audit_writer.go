@@ -0,0 +1,98 @@
+package serviceaccount
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/batch"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/metrics"
+	"go-hex/pkg/times"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ErrAuditWriterClosed is returned when an audit event is written after the writer was closed
+var ErrAuditWriterClosed = errors.New("audit writer is closed")
+
+var auditMetrics = batch.Metrics{
+	Buffered: metrics.NewGauge(prometheus.GaugeOpts{
+		Name: "audit_events_buffered",
+		Help: "Number of audit events waiting to be written.",
+	}),
+	Written: metrics.NewCounter(prometheus.CounterOpts{
+		Name: "audit_events_written_total",
+		Help: "Number of audit events written to the database.",
+	}),
+	Lost: metrics.NewCounterVec(prometheus.CounterOpts{
+		Name: "audit_events_lost_total",
+		Help: "Number of audit events that were never written, by reason.",
+	}, "reason"),
+}
+
+// AuditWriter buffers the audit events and writes them in batches with a batch.Writer, once the batch is full
+// or every flush interval, so that auditing does not add an insert to every request.
+// Every batch is linked to the hash chain of the audit events in the transaction writing it.
+// When the buffer is full the backpressure policy either blocks the writer or drops the event,
+// the events dropped or failing to be written are counted in audit_events_lost_total.
+type AuditWriter struct {
+	writer *batch.Writer[domain.ServiceAccountAuditEvent]
+}
+
+// NewAuditWriter creates a writer buffering up to bufferSize events until it is closed
+func NewAuditWriter(repoRegitry port.RepositoryRegistry, log logger.Logger, bufferSize, batchSize int, flushInterval time.Duration, backpressure configs.AuditBackpressure) *AuditWriter {
+	flush := func(ctx context.Context, auditEvents []domain.ServiceAccountAuditEvent) error {
+		return chainAuditEvents(ctx, repoRegitry, auditEvents)
+	}
+	return &AuditWriter{
+		writer: batch.NewWriter("audit", log, auditMetrics, bufferSize, batchSize, flushInterval,
+			backpressure == configs.AuditBackpressureDrop, flush),
+	}
+}
+
+// Write queues the audit event. With the block policy it waits for room in the buffer
+// and fails when ctx is done first, with the drop policy a full buffer discards the event.
+func (w *AuditWriter) Write(ctx context.Context, auditEvent domain.ServiceAccountAuditEvent) error {
+	err := w.writer.Write(ctx, auditEvent)
+	if err == batch.ErrWriterClosed {
+		return ErrAuditWriterClosed
+	}
+	return err
+}
+
+// Close stops accepting events and returns once the buffered events were written
+func (w *AuditWriter) Close() {
+	w.writer.Close()
+}
+
+// Buffered returns the number of audit events waiting to be written
+func (w *AuditWriter) Buffered() int {
+	return w.writer.Buffered()
+}
+
+// chainAuditEvents links the batch to the hash chain and writes it with the new head of the chain
+func chainAuditEvents(ctx context.Context, repoRegitry port.RepositoryRegistry, auditEvents []domain.ServiceAccountAuditEvent) error {
+	_, err := repoRegitry.DoInTransaction(ctx, func(ctx context.Context, repoRegistry port.RepositoryRegistry) (interface{}, error) {
+		repoServiceAccount := repoRegistry.GetServiceAccountRepository()
+		head, err := repoServiceAccount.GetAuditChainHead(ctx, domain.AuditChainServiceAccounts)
+		if err != nil {
+			return nil, err
+		}
+
+		err = head.Chain(auditEvents)
+		if err != nil {
+			return nil, err
+		}
+		head.UpdatedAt = times.Now()
+
+		err = repoServiceAccount.RecordAuditEvents(ctx, auditEvents)
+		if err != nil {
+			return nil, err
+		}
+		return nil, repoServiceAccount.UpdateAuditChainHead(ctx, head)
+	})
+	return err
+}
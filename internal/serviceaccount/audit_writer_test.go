@@ -0,0 +1,125 @@
+package serviceaccount
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/memory"
+	"go-hex/pkg/logger"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newAuditStore returns a store counting the batches of audit events recorded, each waiting for block when set
+func newAuditStore(block chan struct{}) (*memory.Store, *int32) {
+	store := memory.NewStore()
+	batches := new(int32)
+	store.SetHook(func(ctx context.Context, op string) error {
+		if op == "ServiceAccountRepository.RecordAuditEvents" {
+			if block != nil {
+				<-block
+			}
+			atomic.AddInt32(batches, 1)
+		}
+		return nil
+	})
+	return store, batches
+}
+
+// chained lists the audit events recorded, in the order of the chain
+func chained(t *testing.T, store *memory.Store) []domain.ServiceAccountAuditEvent {
+	t.Helper()
+	auditEvents, err := store.GetServiceAccountRepository().ListChainedAuditEvents(context.Background(), 0, 0)
+	require.NoError(t, err)
+	return auditEvents
+}
+
+func TestAuditWriterBatches(t *testing.T) {
+	store, batches := newAuditStore(nil)
+	w := NewAuditWriter(store, logger.New("test", "test"), 10, 2, time.Hour, configs.AuditBackpressureBlock)
+
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, w.Write(context.Background(), domain.ServiceAccountAuditEvent{}))
+	}
+	// the last event is only written by the flush on close
+	w.Close()
+
+	assert.Equal(t, int32(3), atomic.LoadInt32(batches))
+	assert.Len(t, chained(t, store), 5)
+	assert.Equal(t, ErrAuditWriterClosed, w.Write(context.Background(), domain.ServiceAccountAuditEvent{}))
+}
+
+func TestAuditWriterFlushInterval(t *testing.T) {
+	store, batches := newAuditStore(nil)
+	w := NewAuditWriter(store, logger.New("test", "test"), 10, 100, 10*time.Millisecond, configs.AuditBackpressureBlock)
+	defer w.Close()
+
+	assert.NoError(t, w.Write(context.Background(), domain.ServiceAccountAuditEvent{}))
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(batches) == 1 }, time.Second, 5*time.Millisecond)
+}
+
+func TestAuditWriterBackpressure(t *testing.T) {
+
+	tests := []struct {
+		name         string
+		backpressure configs.AuditBackpressure
+		wantErr      bool
+	}{
+		{name: "drop discards the event", backpressure: configs.AuditBackpressureDrop, wantErr: false},
+		{name: "block waits until the request is cancelled", backpressure: configs.AuditBackpressureBlock, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			block := make(chan struct{})
+			store, batches := newAuditStore(block)
+			w := NewAuditWriter(store, logger.New("test", "test"), 1, 1, time.Hour, tt.backpressure)
+
+			// the first event is held by the blocked repository, the second one fills the buffer
+			assert.NoError(t, w.Write(context.Background(), domain.ServiceAccountAuditEvent{ID: "written"}))
+			assert.Eventually(t, func() bool { return w.Buffered() == 0 }, time.Second, time.Millisecond)
+			assert.NoError(t, w.Write(context.Background(), domain.ServiceAccountAuditEvent{ID: "buffered"}))
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			err := w.Write(ctx, domain.ServiceAccountAuditEvent{ID: "overflow"})
+			assert.Equal(t, tt.wantErr, err != nil)
+
+			close(block)
+			w.Close()
+			assert.Equal(t, int32(2), atomic.LoadInt32(batches))
+			ids := []string{}
+			for _, auditEvent := range chained(t, store) {
+				ids = append(ids, auditEvent.ID)
+			}
+			assert.Equal(t, []string{"written", "buffered"}, ids)
+		})
+	}
+}
+
+func TestAuditWriterChainsBatches(t *testing.T) {
+	store, _ := newAuditStore(nil)
+	w := NewAuditWriter(store, logger.New("test", "test"), 10, 2, time.Hour, configs.AuditBackpressureBlock)
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, w.Write(context.Background(), domain.ServiceAccountAuditEvent{CreatedAt: time.Now()}))
+	}
+	w.Close()
+
+	prevHash := ""
+	var seq int64
+	for _, auditEvent := range chained(t, store) {
+		seq++
+		assert.Equal(t, seq, *auditEvent.Seq)
+		assert.Equal(t, prevHash, auditEvent.PrevHash)
+		prevHash = auditEvent.Hash
+	}
+	head, err := store.GetServiceAccountRepository().GetAuditChainHead(context.Background(), domain.AuditChainServiceAccounts)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), head.Seq)
+	assert.Equal(t, prevHash, head.Hash)
+}
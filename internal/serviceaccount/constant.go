@@ -0,0 +1,26 @@
+package serviceaccount
+
+const (
+	// ActorInternalAPI is the actor of the changes made through the internal api
+	ActorInternalAPI = "internal_api"
+	// ActorScheduler is the actor of the changes made by the scheduled jobs
+	ActorScheduler = "scheduler"
+
+	GrantTypeClientCredentials   = "client_credentials"
+	ClientAssertionTypeJWTBearer = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+	tokenTypeAccess = "access"
+
+	// bounds of the number of audit events returned at once
+	defaultAuditEventsLimit = 100
+	maxAuditEventsLimit     = 1000
+)
+
+// Categories of the authentication failures recorded on the spans
+const (
+	failureUnsupportedGrantType = "unsupported_grant_type"
+	failureInvalidSignature     = "invalid_assertion_signature"
+	failureInvalidClaims        = "invalid_assertion_claims"
+	failureDisabledAccount      = "disabled_service_account"
+	failureReplayedAssertion    = "replayed_assertion"
+)
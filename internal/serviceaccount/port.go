@@ -0,0 +1,35 @@
+package serviceaccount
+
+import (
+	"context"
+	"go-hex/internal/domain"
+)
+
+// ServicePort encapsulates the service account logic.
+type ServicePort interface {
+	// Create registers a new service account
+	Create(ctx context.Context, req RequestCreateServiceAccount) (ResponseCreateServiceAccount, error)
+	// List returns every service account
+	List(ctx context.Context) ([]ResponseServiceAccount, error)
+	// Get returns the service account with the specified ID
+	Get(ctx context.Context, req RequestServiceAccountID) (ResponseServiceAccount, error)
+	// ListAuditEvents returns the audit trail of the service account
+	ListAuditEvents(ctx context.Context, req RequestListAuditEvents) (ResponseAuditEvents, error)
+	// RotateKey replaces the key pair of the service account
+	RotateKey(ctx context.Context, req RequestRotateKey) (ResponseCreateServiceAccount, error)
+	// SetActive enables or disables the service account
+	SetActive(ctx context.Context, req RequestServiceAccountID, isActive bool) error
+	// BindRole binds a role to the service account
+	BindRole(ctx context.Context, req RequestServiceAccountRole) error
+	// UnbindRole removes a role from the service account
+	UnbindRole(ctx context.Context, req RequestServiceAccountRole) error
+	// ExpireRoles removes the time-boxed roles whose window ended and returns how many were removed
+	ExpireRoles(ctx context.Context) (int, error)
+	// IssueToken issues a short-lived access token in exchange of a signed JWT assertion
+	IssueToken(ctx context.Context, req RequestToken) (ResponseToken, error)
+}
+
+// AuditRecorder persists the audit events of the service accounts.
+type AuditRecorder interface {
+	Write(ctx context.Context, auditEvent domain.ServiceAccountAuditEvent) error
+}
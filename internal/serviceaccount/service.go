@@ -0,0 +1,664 @@
+package serviceaccount
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/auth"
+	"go-hex/pkg/event"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/times"
+	"go-hex/pkg/utils"
+	"go-hex/shared/ierr"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pkg/errors"
+)
+
+// Service encapsulates the service account logic.
+type Service struct {
+	cfg         *configs.Config
+	repoRegitry port.RepositoryRegistry
+	events      event.Bus
+	audits      AuditRecorder
+	signer      *auth.Signer
+}
+
+// NewService creates and returns a new service account service
+func NewService(cfg *configs.Config, repoRegitry port.RepositoryRegistry, events event.Bus, audits AuditRecorder) *Service {
+	return &Service{cfg, repoRegitry, events, audits, cfg.JWTKeys().Signer()}
+}
+
+// Create registers a new service account with its roles.
+// A key pair is generated when no public key is given, the private key is returned once and never stored.
+func (s *Service) Create(ctx context.Context, req RequestCreateServiceAccount) (ResponseCreateServiceAccount, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	var res ResponseCreateServiceAccount
+
+	err := req.Validate()
+	if err != nil {
+		return res, err
+	}
+
+	publicKey, privateKey, err := resolveKeyPair(req.PublicKey)
+	if err != nil {
+		return res, err
+	}
+
+	now := times.Now()
+	account := domain.ServiceAccount{
+		ID:          utils.GenerateID(),
+		Name:        req.Name,
+		Description: req.Description,
+		PublicKey:   publicKey,
+		IsActive:    true,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	_, err = s.repoRegitry.DoInTransaction(ctx, func(ctx context.Context, repoRegistry port.RepositoryRegistry) (interface{}, error) {
+		repoServiceAccount := repoRegistry.GetServiceAccountRepository()
+		err := repoServiceAccount.Create(ctx, account)
+		if err != nil {
+			return nil, err
+		}
+		for _, role := range req.Roles {
+			err = repoServiceAccount.BindRole(ctx, domain.ServiceAccountRole{
+				ServiceAccountID: account.ID,
+				Role:             role,
+				CreatedAt:        now,
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return res, err
+	}
+
+	err = s.publish(ctx, domain.EventServiceAccountCreated, account.ID, map[string]interface{}{
+		"name":  account.Name,
+		"roles": req.Roles,
+	})
+	if err != nil {
+		return res, err
+	}
+
+	roles := req.Roles
+	if roles == nil {
+		roles = []string{}
+	}
+	return ResponseCreateServiceAccount{
+		ServiceAccount: ResponseServiceAccount{account, roles, []domain.ServiceAccountRole{}},
+		PrivateKey:     privateKey,
+	}, nil
+}
+
+// List returns every service account with its roles.
+func (s *Service) List(ctx context.Context) ([]ResponseServiceAccount, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	repoServiceAccount := s.repoRegitry.GetServiceAccountRepository()
+	accounts, err := repoServiceAccount.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]ResponseServiceAccount, 0, len(accounts))
+	for _, account := range accounts {
+		item, err := s.response(ctx, account)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, item)
+	}
+	return res, nil
+}
+
+// Get returns the service account with the specified ID.
+func (s *Service) Get(ctx context.Context, req RequestServiceAccountID) (ResponseServiceAccount, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	var res ResponseServiceAccount
+
+	err := req.Validate()
+	if err != nil {
+		return res, err
+	}
+
+	account, err := s.repoRegitry.GetServiceAccountRepository().GetByID(ctx, req.ID)
+	if err != nil {
+		return res, err
+	}
+
+	return s.response(ctx, account)
+}
+
+// ListAuditEvents returns a page of the audit trail of the service account, the newest first.
+func (s *Service) ListAuditEvents(ctx context.Context, req RequestListAuditEvents) (ResponseAuditEvents, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return ResponseAuditEvents{}, err
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultAuditEventsLimit
+	}
+
+	repoServiceAccount := s.repoRegitry.GetServiceAccountRepository()
+	_, err = repoServiceAccount.GetByID(ctx, req.ID)
+	if err != nil {
+		return ResponseAuditEvents{}, err
+	}
+
+	// one more event is read to know whether another page follows
+	auditEvents, err := repoServiceAccount.ListAuditEvents(ctx, req.ID, req.Limit+1, req.Offset)
+	if err != nil {
+		return ResponseAuditEvents{}, err
+	}
+
+	res := ResponseAuditEvents{Events: auditEvents, Offset: req.Offset, Limit: req.Limit}
+	if len(auditEvents) > req.Limit {
+		res.Events = auditEvents[:req.Limit]
+		res.More = true
+	}
+	return res, nil
+}
+
+// RotateKey replaces the key pair of the service account.
+// The assertions signed with the previous key are rejected right away.
+func (s *Service) RotateKey(ctx context.Context, req RequestRotateKey) (ResponseCreateServiceAccount, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	var res ResponseCreateServiceAccount
+
+	err := req.Validate()
+	if err != nil {
+		return res, err
+	}
+
+	publicKey, privateKey, err := resolveKeyPair(req.PublicKey)
+	if err != nil {
+		return res, err
+	}
+
+	before, err := s.Get(ctx, RequestServiceAccountID{ID: req.ID})
+	if err != nil {
+		return res, err
+	}
+
+	err = s.repoRegitry.GetServiceAccountRepository().UpdatePublicKey(ctx, req.ID, publicKey)
+	if err != nil {
+		return res, err
+	}
+
+	account, err := s.publishChanges(ctx, domain.EventServiceAccountKeyRotated, before, nil)
+	if err != nil {
+		return res, err
+	}
+	return ResponseCreateServiceAccount{
+		ServiceAccount: account,
+		PrivateKey:     privateKey,
+	}, nil
+}
+
+// SetActive enables or disables the service account.
+// A disabled service account cannot obtain new tokens, the tokens already issued expire on their own.
+func (s *Service) SetActive(ctx context.Context, req RequestServiceAccountID, isActive bool) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return err
+	}
+
+	before, err := s.Get(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	err = s.repoRegitry.GetServiceAccountRepository().UpdateActive(ctx, req.ID, isActive)
+	if err != nil {
+		return err
+	}
+
+	eventName := domain.EventServiceAccountDisabled
+	if isActive {
+		eventName = domain.EventServiceAccountEnabled
+	}
+	_, err = s.publishChanges(ctx, eventName, before, nil)
+	return err
+}
+
+// BindRole binds a role to the service account.
+func (s *Service) BindRole(ctx context.Context, req RequestServiceAccountRole) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return err
+	}
+
+	before, err := s.Get(ctx, RequestServiceAccountID{ID: req.ID})
+	if err != nil {
+		return err
+	}
+
+	now := times.Now()
+	if req.EndsAt != nil && !req.EndsAt.After(now) {
+		return validation.Errors{"ends_at": errors.New("must be in the future")}
+	}
+
+	err = s.repoRegitry.GetServiceAccountRepository().BindRole(ctx, domain.ServiceAccountRole{
+		ServiceAccountID: req.ID,
+		Role:             req.Role,
+		CreatedAt:        now,
+		StartsAt:         req.StartsAt,
+		EndsAt:           req.EndsAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	attributes := map[string]interface{}{"role": req.Role}
+	if req.StartsAt != nil {
+		attributes["starts_at"] = req.StartsAt
+	}
+	if req.EndsAt != nil {
+		attributes["ends_at"] = req.EndsAt
+	}
+	_, err = s.publishChanges(ctx, domain.EventServiceAccountRoleBound, before, attributes)
+	return err
+}
+
+// UnbindRole removes a role from the service account.
+func (s *Service) UnbindRole(ctx context.Context, req RequestServiceAccountRole) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return err
+	}
+
+	before, err := s.Get(ctx, RequestServiceAccountID{ID: req.ID})
+	if err != nil {
+		return err
+	}
+
+	err = s.repoRegitry.GetServiceAccountRepository().UnbindRole(ctx, req.ID, req.Role)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.publishChanges(ctx, domain.EventServiceAccountRoleUnbound, before, map[string]interface{}{"role": req.Role})
+	return err
+}
+
+// ExpireRoles removes the time-boxed roles whose window ended and records their expiry in the audit trail of
+// their service account. The roles are no longer granted once their window ended, removing them only tidies
+// the bindings, so a role bound again in the meantime is kept.
+func (s *Service) ExpireRoles(ctx context.Context) (int, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	repoServiceAccount := s.repoRegitry.GetServiceAccountRepository()
+	roles, err := repoServiceAccount.ListExpiredRoles(ctx, times.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, role := range roles {
+		removed, err := repoServiceAccount.DeleteExpiredRole(ctx, role)
+		if err != nil {
+			return expired, err
+		}
+		if !removed {
+			continue
+		}
+		expired++
+
+		attributes := map[string]interface{}{"role": role.Role, "ends_at": role.EndsAt}
+		if role.StartsAt != nil {
+			attributes["starts_at"] = role.StartsAt
+		}
+		err = s.audit(ctx, domain.ServiceAccountAuditEvent{
+			ServiceAccountID: role.ServiceAccountID,
+			Event:            domain.EventServiceAccountRoleExpired,
+			ActorType:        ActorScheduler,
+			ActorID:          ActorScheduler,
+			SubjectID:        role.ServiceAccountID,
+			Attributes:       attributes,
+		})
+		if err != nil {
+			return expired, err
+		}
+	}
+	return expired, nil
+}
+
+// IssueToken issues a short-lived access token to a service account authenticated
+// with a JWT assertion signed by its private key (private_key_jwt, RFC 7523).
+// The assertion must be issued by and for the service account, target this server,
+// be short-lived and can only be used once.
+func (s *Service) IssueToken(ctx context.Context, req RequestToken) (ResponseToken, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	var res ResponseToken
+
+	err := req.Validate()
+	if err != nil {
+		return res, err
+	}
+	if req.GrantType != GrantTypeClientCredentials {
+		return res, otel.AuthFailed(ctx, failureUnsupportedGrantType, ierr.ErrUnsupportedGrantType)
+	}
+
+	repoServiceAccount := s.repoRegitry.GetServiceAccountRepository()
+
+	var account domain.ServiceAccount
+	var loadErr error
+	token, err := jwt.Parse(req.ClientAssertion, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, errors.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		claims := token.Claims.(jwt.MapClaims)
+		subject, _ := claims["sub"].(string)
+		if subject == "" {
+			return nil, errors.New("missing sub claim")
+		}
+
+		var err error
+		account, err = repoServiceAccount.GetByID(ctx, subject)
+		if err != nil {
+			if err != ierr.ErrResourceNotFound {
+				loadErr = err
+			}
+			return nil, err
+		}
+		return jwt.ParseRSAPublicKeyFromPEM([]byte(account.PublicKey))
+	})
+	if loadErr != nil {
+		return res, loadErr
+	}
+	if err != nil {
+		return res, otel.AuthFailed(ctx, failureInvalidSignature, errors.Wrap(ierr.ErrInvalidClientAssertion, err.Error()))
+	}
+
+	claims := token.Claims.(jwt.MapClaims)
+	now := times.Now()
+	expiresAt, err := s.validateAssertion(account, claims, now)
+	if err != nil {
+		return res, otel.AuthFailed(ctx, failureInvalidClaims, err)
+	}
+
+	if !account.IsActive {
+		return res, otel.AuthFailed(ctx, failureDisabledAccount, ierr.ErrServiceAccountDisabled)
+	}
+
+	ok, err := repoServiceAccount.RecordAssertion(ctx, domain.ServiceAccountAssertion{
+		JTI:              claims["jti"].(string),
+		ServiceAccountID: account.ID,
+		ExpiresAt:        expiresAt,
+	})
+	if err != nil {
+		return res, err
+	}
+	if !ok {
+		return res, otel.AuthFailed(ctx, failureReplayedAssertion, errors.Wrap(ierr.ErrInvalidClientAssertion, "assertion has already been used"))
+	}
+
+	bindings, err := repoServiceAccount.ListRoles(ctx, account.ID)
+	if err != nil {
+		return res, err
+	}
+	roles := grantedRoles(bindings, now)
+
+	// the token carries the roles granted now, and expires once the window of one of them ends
+	accessTokenID := utils.GenerateID()
+	tokenExpiresAt := now.Add(time.Duration(s.cfg.ServiceAccount.TokenExpiration) * time.Minute)
+	for _, binding := range bindings {
+		if binding.IsActiveAt(now) && binding.EndsAt != nil && binding.EndsAt.Before(tokenExpiresAt) {
+			tokenExpiresAt = *binding.EndsAt
+		}
+	}
+	accessToken, err := s.signer.Sign(jwt.MapClaims{
+		"id":             account.ID,
+		"username":       account.Name,
+		"roles":          roles,
+		"principal_type": domain.PrincipalTypeServiceAccount,
+		"jti":            accessTokenID,
+		"iat":            now.Unix(),
+		"exp":            tokenExpiresAt.Unix(),
+		"token_type":     tokenTypeAccess,
+	})
+	if err != nil {
+		return res, errors.Wrap(err, "cannot generate token")
+	}
+
+	err = repoServiceAccount.UpdateLastUsedAt(ctx, account.ID, now)
+	if err != nil {
+		return res, err
+	}
+
+	err = s.audit(ctx, domain.ServiceAccountAuditEvent{
+		ServiceAccountID: account.ID,
+		Event:            domain.EventServiceAccountTokenIssued,
+		ActorType:        domain.PrincipalTypeServiceAccount,
+		ActorID:          account.ID,
+		SubjectID:        accessTokenID,
+		Attributes: map[string]interface{}{
+			"assertion_jti": claims["jti"],
+			"roles":         roles,
+			"expires_at":    tokenExpiresAt,
+		},
+	})
+	if err != nil {
+		return res, err
+	}
+
+	return ResponseToken{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(tokenExpiresAt.Sub(now).Seconds()),
+		ExpiresAt:   tokenExpiresAt.Format(time.RFC3339),
+	}, nil
+}
+
+// validateAssertion checks the claims of a client assertion once its signature is verified
+// and returns its expiration time.
+func (s *Service) validateAssertion(account domain.ServiceAccount, claims jwt.MapClaims, now time.Time) (time.Time, error) {
+
+	if issuer, _ := claims["iss"].(string); issuer != account.ID {
+		return time.Time{}, errors.Wrap(ierr.ErrInvalidClientAssertion, "iss must be the service account id")
+	}
+
+	if !s.hasValidAudience(claims["aud"]) {
+		return time.Time{}, errors.Wrap(ierr.ErrInvalidClientAssertion, "aud does not target this server")
+	}
+
+	if jti, _ := claims["jti"].(string); jti == "" {
+		return time.Time{}, errors.Wrap(ierr.ErrInvalidClientAssertion, "missing jti claim")
+	}
+
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return time.Time{}, errors.Wrap(ierr.ErrInvalidClientAssertion, "missing exp claim")
+	}
+	expiresAt := time.Unix(int64(exp), 0)
+	maxAge := time.Duration(s.cfg.ServiceAccount.AssertionMaxAge) * time.Second
+	if expiresAt.Sub(now) > maxAge {
+		return time.Time{}, errors.Wrap(ierr.ErrInvalidClientAssertion, "assertion lifetime is too long")
+	}
+	if iat, ok := claims["iat"].(float64); ok && now.Sub(time.Unix(int64(iat), 0)) > maxAge {
+		return time.Time{}, errors.Wrap(ierr.ErrInvalidClientAssertion, "assertion is too old")
+	}
+
+	return expiresAt, nil
+}
+
+// hasValidAudience accepts the token endpoint or the issuer as audience, as a string or a list.
+func (s *Service) hasValidAudience(aud interface{}) bool {
+	accepted := map[string]bool{}
+	if s.cfg.ServiceAccount.TokenAudience != "" {
+		accepted[s.cfg.ServiceAccount.TokenAudience] = true
+	}
+	if s.cfg.OIDC.Issuer != "" {
+		accepted[s.cfg.OIDC.Issuer] = true
+		accepted[strings.TrimSuffix(s.cfg.OIDC.Issuer, "/")+"/service-accounts/token"] = true
+	}
+
+	switch val := aud.(type) {
+	case string:
+		return accepted[val]
+	case []interface{}:
+		for _, item := range val {
+			if str, ok := item.(string); ok && accepted[str] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// response returns the service account with the roles granted now and its time-boxed roles granted now or later
+func (s *Service) response(ctx context.Context, account domain.ServiceAccount) (ResponseServiceAccount, error) {
+	bindings, err := s.repoRegitry.GetServiceAccountRepository().ListRoles(ctx, account.ID)
+	if err != nil {
+		return ResponseServiceAccount{}, err
+	}
+
+	now := times.Now()
+	res := ResponseServiceAccount{account, grantedRoles(bindings, now), []domain.ServiceAccountRole{}}
+	for _, binding := range bindings {
+		if binding.IsTimeBoxed() && (binding.EndsAt == nil || binding.EndsAt.After(now)) {
+			res.ScheduledRoles = append(res.ScheduledRoles, binding)
+		}
+	}
+	return res, nil
+}
+
+// grantedRoles returns the names of the roles granted at the given time
+func grantedRoles(bindings []domain.ServiceAccountRole, at time.Time) []string {
+	roles := make([]string, 0, len(bindings))
+	for _, binding := range bindings {
+		if binding.IsActiveAt(at) {
+			roles = append(roles, binding.Role)
+		}
+	}
+	return roles
+}
+
+// publish records a change made to a service account through the internal api
+func (s *Service) publish(ctx context.Context, name string, accountID string, attributes map[string]interface{}) error {
+	return s.audit(ctx, domain.ServiceAccountAuditEvent{
+		ServiceAccountID: accountID,
+		Event:            name,
+		ActorType:        ActorInternalAPI,
+		ActorID:          ActorInternalAPI,
+		SubjectID:        accountID,
+		Attributes:       attributes,
+	})
+}
+
+// publishChanges records a change made to a service account through the internal api together with the
+// diff of the service account, masking its key, and returns the service account once changed.
+func (s *Service) publishChanges(ctx context.Context, name string, before ResponseServiceAccount, attributes map[string]interface{}) (ResponseServiceAccount, error) {
+	after, err := s.Get(ctx, RequestServiceAccountID{ID: before.ID})
+	if err != nil {
+		return after, err
+	}
+
+	if attributes == nil {
+		attributes = map[string]interface{}{}
+	}
+	attributes["diff"] = domain.Diff(before, after)
+	return after, s.publish(ctx, name, before.ID, attributes)
+}
+
+// audit queues the event for the audit trail of the service account, then publishes it to the event bus.
+func (s *Service) audit(ctx context.Context, auditEvent domain.ServiceAccountAuditEvent) error {
+	if auditEvent.Attributes == nil {
+		auditEvent.Attributes = map[string]interface{}{}
+	}
+	auditEvent.ID = utils.GenerateID()
+	auditEvent.CreatedAt = times.Now()
+
+	err := s.audits.Write(ctx, auditEvent)
+	if err != nil {
+		return err
+	}
+
+	attributes := map[string]interface{}{"actor_type": auditEvent.ActorType}
+	for key, value := range auditEvent.Attributes {
+		attributes[key] = value
+	}
+	s.events.Publish(ctx, event.Event{
+		Name:       auditEvent.Event,
+		ActorID:    auditEvent.ActorID,
+		SubjectID:  auditEvent.SubjectID,
+		Attributes: attributes,
+	})
+	return nil
+}
+
+// resolveKeyPair validates the given PEM encoded RSA public key.
+// When none is given, a new key pair is generated and the PEM encoded private key is returned as well.
+func resolveKeyPair(publicKey string) (string, string, error) {
+	if publicKey != "" {
+		key, err := jwt.ParseRSAPublicKeyFromPEM([]byte(publicKey))
+		if err != nil || key.N.BitLen() < 2048 {
+			return "", "", ierr.ErrInvalidPublicKey
+		}
+		return publicKey, "", nil
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return "", "", errors.Wrap(err, "cannot generate key pair")
+	}
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", "", errors.Wrap(err, "cannot encode private key")
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return "", "", errors.Wrap(err, "cannot encode public key")
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER})),
+		nil
+}
@@ -0,0 +1,196 @@
+package serviceaccount
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/memory"
+	"go-hex/pkg/event"
+	"go-hex/shared/ierr"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestService() *Service {
+	cfg := &configs.Config{}
+	cfg.OIDC.Issuer = "https://auth.example.com/"
+	cfg.ServiceAccount.TokenAudience = "https://api.example.com/token"
+	cfg.ServiceAccount.AssertionMaxAge = 300
+	return NewService(cfg, nil, event.New(), nil)
+}
+
+func TestHasValidAudience(t *testing.T) {
+	svc := newTestService()
+
+	tests := []struct {
+		name string
+		aud  interface{}
+		want bool
+	}{
+		{name: "configured audience", aud: "https://api.example.com/token", want: true},
+		{name: "issuer", aud: "https://auth.example.com/", want: true},
+		{name: "token endpoint of the issuer", aud: "https://auth.example.com/service-accounts/token", want: true},
+		{name: "list containing an accepted audience", aud: []interface{}{"https://other.example.com", "https://api.example.com/token"}, want: true},
+		{name: "list without accepted audience", aud: []interface{}{"https://other.example.com", 42}, want: false},
+		{name: "other audience", aud: "https://other.example.com", want: false},
+		{name: "issuer without trailing slash", aud: "https://auth.example.com", want: false},
+		{name: "missing audience", aud: nil, want: false},
+		{name: "empty audience", aud: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, svc.hasValidAudience(tt.aud))
+		})
+	}
+}
+
+func TestValidateAssertion(t *testing.T) {
+	svc := newTestService()
+	account := domain.ServiceAccount{ID: "sa-1"}
+	now := time.Unix(1700000000, 0)
+
+	validClaims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss": "sa-1",
+			"sub": "sa-1",
+			"aud": "https://api.example.com/token",
+			"jti": "assertion-1",
+			"iat": float64(now.Unix()),
+			"exp": float64(now.Add(time.Minute).Unix()),
+		}
+	}
+
+	tests := []struct {
+		name          string
+		mutate        func(claims jwt.MapClaims)
+		wantExpiresAt time.Time
+		wantErr       error
+	}{
+		{
+			name:          "valid assertion",
+			wantExpiresAt: now.Add(time.Minute),
+		},
+		{
+			name:          "without iat",
+			mutate:        func(claims jwt.MapClaims) { delete(claims, "iat") },
+			wantExpiresAt: now.Add(time.Minute),
+		},
+		{
+			name:    "issuer is another account",
+			mutate:  func(claims jwt.MapClaims) { claims["iss"] = "sa-2" },
+			wantErr: ierr.ErrInvalidClientAssertion,
+		},
+		{
+			name:    "wrong audience",
+			mutate:  func(claims jwt.MapClaims) { claims["aud"] = "https://other.example.com" },
+			wantErr: ierr.ErrInvalidClientAssertion,
+		},
+		{
+			name:    "missing jti",
+			mutate:  func(claims jwt.MapClaims) { delete(claims, "jti") },
+			wantErr: ierr.ErrInvalidClientAssertion,
+		},
+		{
+			name:    "missing exp",
+			mutate:  func(claims jwt.MapClaims) { delete(claims, "exp") },
+			wantErr: ierr.ErrInvalidClientAssertion,
+		},
+		{
+			name:    "lifetime too long",
+			mutate:  func(claims jwt.MapClaims) { claims["exp"] = float64(now.Add(time.Hour).Unix()) },
+			wantErr: ierr.ErrInvalidClientAssertion,
+		},
+		{
+			name:    "issued too long ago",
+			mutate:  func(claims jwt.MapClaims) { claims["iat"] = float64(now.Add(-time.Hour).Unix()) },
+			wantErr: ierr.ErrInvalidClientAssertion,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := validClaims()
+			if tt.mutate != nil {
+				tt.mutate(claims)
+			}
+
+			expiresAt, err := svc.validateAssertion(account, claims, now)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, errors.Cause(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, tt.wantExpiresAt.Equal(expiresAt))
+		})
+	}
+}
+
+type fakeAuditRecorder struct {
+	auditEvents []domain.ServiceAccountAuditEvent
+}
+
+func (r *fakeAuditRecorder) Write(ctx context.Context, auditEvent domain.ServiceAccountAuditEvent) error {
+	r.auditEvents = append(r.auditEvents, auditEvent)
+	return nil
+}
+
+func TestGrantedRoles(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	bindings := []domain.ServiceAccountRole{
+		{Role: "billing:read"},
+		{Role: "billing:write", StartsAt: &past, EndsAt: &future},
+		{Role: "oncall:admin", StartsAt: &future},
+		{Role: "oncall:previous", EndsAt: &past},
+		{Role: "oncall:ending", EndsAt: &now},
+	}
+
+	assert.Equal(t, []string{"billing:read", "billing:write"}, grantedRoles(bindings, now))
+	assert.Equal(t, []string{"billing:read", "oncall:admin"}, grantedRoles(bindings, future))
+}
+
+func TestExpireRoles(t *testing.T) {
+	past, future := time.Now().Add(-time.Minute), time.Now().Add(time.Hour)
+	store := memory.NewStore()
+	repo := store.GetServiceAccountRepository()
+	for _, role := range []domain.ServiceAccountRole{
+		{ServiceAccountID: "sa-1", Role: "billing:read"},
+		{ServiceAccountID: "sa-1", Role: "oncall:admin", EndsAt: &past},
+		{ServiceAccountID: "sa-2", Role: "oncall:admin", EndsAt: &future},
+	} {
+		require.NoError(t, repo.BindRole(context.Background(), role))
+	}
+	audits := &fakeAuditRecorder{}
+	svc := NewService(&configs.Config{}, store, event.New(), audits)
+
+	expired, err := svc.ExpireRoles(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, expired)
+	roles, err := repo.ListRoles(context.Background(), "sa-1")
+	require.NoError(t, err)
+	if assert.Len(t, roles, 1) {
+		assert.Equal(t, "billing:read", roles[0].Role)
+	}
+	if assert.Len(t, audits.auditEvents, 1) {
+		assert.Equal(t, domain.EventServiceAccountRoleExpired, audits.auditEvents[0].Event)
+		assert.Equal(t, "sa-1", audits.auditEvents[0].ServiceAccountID)
+		assert.Equal(t, ActorScheduler, audits.auditEvents[0].ActorType)
+		assert.Equal(t, "oncall:admin", audits.auditEvents[0].Attributes["role"])
+	}
+}
+
+func TestRequestServiceAccountRoleWindow(t *testing.T) {
+	start := time.Now()
+	end := start.Add(-time.Hour)
+	req := RequestServiceAccountRole{ID: "sa-1", Role: "oncall:admin", StartsAt: &start, EndsAt: &end}
+	assert.Error(t, req.Validate())
+
+	end = start.Add(7 * 24 * time.Hour)
+	assert.NoError(t, req.Validate())
+}
@@ -0,0 +1,86 @@
+package siem
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-hex/pkg/event"
+	"strings"
+)
+
+// cefVersion is the version of the CEF format written
+const cefVersion = 0
+
+// attributes mapped to the CEF extension keys, the other attributes are written as JSON in cs2
+var cefKeys = []struct {
+	attribute string
+	key       string
+}{
+	{"username", "suser"},
+	{"ip_address", "src"},
+	{"user_agent", "requestClientApplication"},
+	{"reason", "reason"},
+}
+
+var (
+	cefHeaderEscaper    = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\r", " ", "\n", " ")
+	cefExtensionEscaper = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\r", `\r`, "\n", `\n`)
+)
+
+// CEF formats the security events in the ArcSight Common Event Format:
+// CEF:Version|Device Vendor|Device Product|Device Version|Signature ID|Name|Severity|Extension
+type CEF struct {
+	vendor  string
+	product string
+	version string
+}
+
+// NewCEF creates a formatter identifying the events as sent by the given device
+func NewCEF(vendor, product, version string) CEF {
+	return CEF{vendor, product, version}
+}
+
+// Format returns the CEF record of the event
+func (f CEF) Format(e event.Event) string {
+
+	header := []string{
+		fmt.Sprintf("CEF:%d", cefVersion),
+		cefHeaderEscaper.Replace(f.vendor),
+		cefHeaderEscaper.Replace(f.product),
+		cefHeaderEscaper.Replace(f.version),
+		cefHeaderEscaper.Replace(e.Name),
+		cefHeaderEscaper.Replace(e.Name),
+		fmt.Sprint(Severity(e)),
+	}
+
+	extension := []string{fmt.Sprintf("rt=%d", e.OccurredAt.UnixNano()/1e6)}
+	add := func(key, value string) {
+		if value != "" {
+			extension = append(extension, key+"="+cefExtensionEscaper.Replace(value))
+		}
+	}
+	add("suid", e.ActorID)
+	if e.SubjectID != "" {
+		add("cs1Label", "subject_id")
+		add("cs1", e.SubjectID)
+	}
+
+	rest := make(map[string]interface{}, len(e.Attributes))
+	for k, v := range e.Attributes {
+		rest[k] = v
+	}
+	for _, mapping := range cefKeys {
+		if v, ok := rest[mapping.attribute]; ok {
+			add(mapping.key, fmt.Sprint(v))
+			delete(rest, mapping.attribute)
+		}
+	}
+	if len(rest) > 0 {
+		// encoding/json sorts the keys, so that the records of an event type always read the same
+		if b, err := json.Marshal(rest); err == nil {
+			add("cs2Label", "attributes")
+			add("cs2", string(b))
+		}
+	}
+
+	return strings.Join(header, "|") + "|" + strings.Join(extension, " ")
+}
@@ -0,0 +1,52 @@
+package siem
+
+import (
+	"go-hex/internal/domain"
+	"go-hex/pkg/event"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCEFFormat(t *testing.T) {
+	occurredAt := time.Date(2026, 10, 14, 8, 30, 0, 0, time.UTC)
+	cef := NewCEF(Vendor, "go|hex", "1.0")
+
+	tests := []struct {
+		name string
+		e    event.Event
+		want string
+	}{
+		{
+			name: "login failed",
+			e: event.Event{
+				Name:       domain.EventLoginFailed,
+				SubjectID:  "john",
+				OccurredAt: occurredAt,
+				Attributes: map[string]interface{}{
+					"username":   "john",
+					"ip_address": "10.0.0.1",
+					"code":       "400021",
+					"reason":     "invalid username or password",
+				},
+			},
+			want: `CEF:0|go-hex|go\|hex|1.0|auth.login_failed|auth.login_failed|5|rt=1791966600000 cs1Label=subject_id cs1=john suser=john src=10.0.0.1 reason=invalid username or password cs2Label=attributes cs2={"code":"400021"}`,
+		},
+		{
+			name: "extension escaping",
+			e: event.Event{
+				Name:       domain.EventServiceAccountRoleBound,
+				ActorID:    "admin",
+				OccurredAt: occurredAt,
+				Attributes: map[string]interface{}{"reason": "a=b\\c\nd"},
+			},
+			want: `CEF:0|go-hex|go\|hex|1.0|service_account.role_bound|service_account.role_bound|7|rt=1791966600000 suid=admin reason=a\=b\\c\nd`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, cef.Format(tt.e))
+		})
+	}
+}
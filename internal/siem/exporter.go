@@ -0,0 +1,180 @@
+package siem
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/metrics"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	siemExported = metrics.NewCounterVec(prometheus.CounterOpts{
+		Name: "siem_events_exported_total",
+		Help: "Number of security events delivered to the SIEM, by destination.",
+	}, "sink")
+	siemLost = metrics.NewCounterVec(prometheus.CounterOpts{
+		Name: "siem_events_lost_total",
+		Help: "Number of security events that were never delivered to the SIEM, by destination and reason.",
+	}, "sink", "reason")
+)
+
+// Exporter buffers the security events published on the event bus and sends them to every sink in batches,
+// once the batch is full or every flush interval. Exporting never slows the requests down: the events
+// published while the buffer is full are dropped, and counted in siem_events_lost_total with the batches
+// failing to be delivered.
+type Exporter struct {
+	sinks     []Sink
+	log       logger.Logger
+	names     map[string]bool
+	batchSize int
+	timeout   time.Duration
+
+	mu     sync.RWMutex
+	closed bool
+	events chan event.Event
+	done   chan struct{}
+}
+
+// NewExporter creates an exporter of the given events, DefaultEvents when none is given, buffering up to
+// bufferSize events until it is closed. Each batch is given timeout to be delivered to each sink.
+func NewExporter(log logger.Logger, names []string, bufferSize, batchSize int, flushInterval, timeout time.Duration, sinks ...Sink) *Exporter {
+	if len(names) == 0 {
+		names = DefaultEvents
+	}
+	x := &Exporter{
+		sinks:     sinks,
+		log:       log,
+		names:     make(map[string]bool, len(names)),
+		batchSize: batchSize,
+		timeout:   timeout,
+		events:    make(chan event.Event, bufferSize),
+		done:      make(chan struct{}),
+	}
+	for _, name := range names {
+		x.names[name] = true
+	}
+	go x.run(flushInterval)
+	return x
+}
+
+// NewConfiguredExporter creates an exporter of the configured events to the configured destinations, the
+// application name and version identify the sender in the exported records.
+func NewConfiguredExporter(cfg *configs.Config, log logger.Logger, version string) *Exporter {
+	timeout := time.Duration(cfg.SIEM.Timeout) * time.Second
+	var sinks []Sink
+	if cfg.SIEM.SyslogAddress != "" {
+		cef := NewCEF(Vendor, cfg.Server.NAME, version)
+		sinks = append(sinks, NewSyslogSink(cfg.SIEM.SyslogNetwork, cfg.SIEM.SyslogAddress, cfg.Server.NAME, cef, timeout))
+	}
+	if cfg.SIEM.HECURL != "" {
+		sinks = append(sinks, NewHECSink(cfg.SIEM.HECURL, cfg.SIEM.HECToken, cfg.SIEM.HECIndex, cfg.SIEM.HECSourceType, cfg.Server.NAME, timeout))
+	}
+	return NewExporter(log, cfg.SIEM.Events, cfg.SIEM.BufferSize, cfg.SIEM.BatchSize, time.Duration(cfg.SIEM.FlushInterval)*time.Millisecond, timeout, sinks...)
+}
+
+// Subscribe subscribes the exporter to the events of the bus, nothing is subscribed without a sink
+func (x *Exporter) Subscribe(bus event.Bus) {
+	if len(x.sinks) == 0 {
+		return
+	}
+	bus.Subscribe(event.All, x.Handle)
+}
+
+// Handle queues the event when it is exported, it implements event.Handler
+func (x *Exporter) Handle(ctx context.Context, e event.Event) {
+	if !x.names[e.Name] {
+		return
+	}
+
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	if x.closed {
+		x.lose("closed", 1)
+		return
+	}
+	select {
+	case x.events <- e:
+	default:
+		x.lose("buffer_full", 1)
+	}
+}
+
+// Close stops accepting events and returns once the buffered events were sent
+func (x *Exporter) Close() {
+	x.mu.Lock()
+	if !x.closed {
+		x.closed = true
+		close(x.events)
+	}
+	x.mu.Unlock()
+	<-x.done
+
+	for _, sink := range x.sinks {
+		if closer, ok := sink.(interface{ Close() error }); ok {
+			_ = closer.Close()
+		}
+	}
+}
+
+// Buffered returns the number of security events waiting to be sent
+func (x *Exporter) Buffered() int {
+	return len(x.events)
+}
+
+func (x *Exporter) run(flushInterval time.Duration) {
+	defer close(x.done)
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]event.Event, 0, x.batchSize)
+	for {
+		select {
+		case e, ok := <-x.events:
+			if !ok {
+				x.flush(batch)
+				return
+			}
+			batch = append(batch, e)
+			if len(batch) >= x.batchSize {
+				x.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			x.flush(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+// flush sends the batch to every sink, a sink failing to receive it does not hold the other ones back
+func (x *Exporter) flush(batch []event.Event) {
+	if len(batch) == 0 {
+		return
+	}
+
+	for _, sink := range x.sinks {
+		ctx, cancel := context.WithTimeout(context.Background(), x.timeout)
+		err := sink.Send(ctx, batch)
+		cancel()
+		if err != nil {
+			siemLost.WithLabelValues(sink.Name(), "send_failed").Add(float64(len(batch)))
+			x.log.WithParams(logger.Params{"type": "siem", "sink": sink.Name(), "events": len(batch)}).Error(err)
+			continue
+		}
+		siemExported.WithLabelValues(sink.Name()).Add(float64(len(batch)))
+	}
+}
+
+func (x *Exporter) lose(reason string, count int) {
+	for _, sink := range x.sinks {
+		siemLost.WithLabelValues(sink.Name(), reason).Add(float64(count))
+	}
+	x.log.WithParams(logger.Params{"type": "siem", "reason": reason}).Warn("security event lost")
+}
@@ -0,0 +1,105 @@
+package siem
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExporterHEC(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests int
+		received []hecEvent
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Splunk secret", r.Header.Get("Authorization"))
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		dec := json.NewDecoder(r.Body)
+		for dec.More() {
+			var e hecEvent
+			if assert.NoError(t, dec.Decode(&e)) {
+				received = append(received, e)
+			}
+		}
+		w.Write([]byte(`{"text":"Success","code":0}`))
+	}))
+	defer srv.Close()
+
+	sink := NewHECSink(srv.URL, "secret", "security", "go-hex:security", "go-hex", time.Second)
+	x := NewExporter(logger.New("test", "test"), nil, 10, 2, time.Hour, time.Second, sink)
+	bus := event.New()
+	x.Subscribe(bus)
+
+	bus.Publish(context.Background(), event.Event{Name: domain.EventLoginFailed, SubjectID: "john"})
+	bus.Publish(context.Background(), event.Event{Name: "user.updated"})
+	bus.Publish(context.Background(), event.Event{Name: domain.EventServiceAccountRoleBound, ActorID: "admin"})
+	bus.Publish(context.Background(), event.Event{Name: domain.EventLoginSucceeded, ActorID: "user-1"})
+	x.Close()
+
+	// the full batch was sent at once and the remaining event on close, the other events are not exported
+	assert.Equal(t, 2, requests)
+	if assert.Len(t, received, 3) {
+		assert.Equal(t, domain.EventLoginFailed, received[0].Event.Name)
+		assert.Equal(t, "security", received[0].Index)
+		assert.Equal(t, 7, received[1].Event.Severity)
+		assert.Equal(t, domain.EventLoginSucceeded, received[2].Event.Name)
+	}
+}
+
+func TestExporterSyslog(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer ln.Close()
+
+	lines := make(chan string, 10)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	cef := NewCEF(Vendor, "go-hex", "1.0")
+	sink := NewSyslogSink(configs.SyslogNetworkTCP, ln.Addr().String(), "go-hex", cef, time.Second)
+	x := NewExporter(logger.New("test", "test"), []string{domain.EventLoginFailed}, 10, 10, time.Hour, time.Second, sink)
+	x.Handle(context.Background(), event.Event{Name: domain.EventLoginFailed, OccurredAt: time.Now()})
+	x.Handle(context.Background(), event.Event{Name: domain.EventLoginSucceeded, OccurredAt: time.Now()})
+	x.Close()
+
+	select {
+	case line := <-lines:
+		// authpriv facility with the warning severity
+		assert.True(t, strings.HasPrefix(line, "<84>1 "), line)
+		assert.Contains(t, line, " go-hex - - - CEF:0|go-hex|go-hex|1.0|auth.login_failed|")
+	case <-time.After(time.Second):
+		t.Fatal("no syslog message received")
+	}
+	select {
+	case line := <-lines:
+		t.Fatalf("unexpected syslog message %s", line)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
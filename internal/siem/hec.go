@@ -0,0 +1,96 @@
+package siem
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"go-hex/pkg/event"
+	"go-hex/pkg/otel"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// hecEvent is an event of the Splunk HTTP Event Collector
+type hecEvent struct {
+	Time       float64     `json:"time"`
+	Host       string      `json:"host,omitempty"`
+	Source     string      `json:"source,omitempty"`
+	SourceType string      `json:"sourcetype,omitempty"`
+	Index      string      `json:"index,omitempty"`
+	Event      hecSecurity `json:"event"`
+}
+
+type hecSecurity struct {
+	event.Event
+	Severity int `json:"severity"`
+}
+
+// HECSink posts the security events to a Splunk HTTP Event Collector, a batch is sent in a single request
+type HECSink struct {
+	url        string
+	token      string
+	index      string
+	sourceType string
+	source     string
+	host       string
+	client     *http.Client
+}
+
+// NewHECSink creates a sink posting the events to the event endpoint of the collector
+func NewHECSink(url, token, index, sourceType, source string, timeout time.Duration) *HECSink {
+	host, _ := os.Hostname()
+	return &HECSink{url, token, index, sourceType, source, host, &http.Client{Timeout: timeout}}
+}
+
+// Name returns the name of the destination
+func (s *HECSink) Name() string {
+	return "splunk_hec"
+}
+
+// Send posts the batch, the events are concatenated in the body as expected by the collector
+func (s *HECSink) Send(ctx context.Context, events []event.Event) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, e := range events {
+		err := enc.Encode(hecEvent{
+			Time:       float64(e.OccurredAt.UnixNano()/1e6) / 1e3,
+			Host:       s.host,
+			Source:     s.source,
+			SourceType: s.sourceType,
+			Index:      s.index,
+			Event:      hecSecurity{e, Severity(e)},
+		})
+		if err != nil {
+			return errors.Wrap(err, "cannot marshal hec event")
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, &body)
+	if err != nil {
+		return errors.Wrap(err, "cannot create hec request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Splunk "+s.token)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "cannot send hec events")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		// the collector explains the rejection in {"text":..., "code":...}
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return errors.Errorf("hec responded with status %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	return nil
+}
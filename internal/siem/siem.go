@@ -0,0 +1,105 @@
+// Package siem exports the security events of the event bus to the SIEM of the organization.
+package siem
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/pkg/event"
+)
+
+// Vendor is the device vendor of the CEF records
+const Vendor = "go-hex"
+
+// Sink delivers the security events to a single destination.
+type Sink interface {
+	// Name returns the name of the destination, used in the metrics and the logs.
+	Name() string
+	// Send delivers the batch, a batch failing to be delivered is not retried.
+	Send(ctx context.Context, events []event.Event) error
+}
+
+// DefaultEvents are the security events exported when no event is configured
+var DefaultEvents = []string{
+	domain.EventLoginSucceeded,
+	domain.EventLoginFailed,
+	domain.EventUserSignedUp,
+	domain.EventUserRegistered,
+	domain.EventUserVerified,
+	domain.EventSignupRejected,
+	domain.EventSessionEvicted,
+	domain.EventSessionRevoked,
+	domain.EventRefreshTokenReused,
+	domain.EventLoginApprovalRequested,
+	domain.EventLoginApprovalApproved,
+	domain.EventLoginApprovalDenied,
+	domain.EventDeviceLoginApproved,
+	domain.EventDeviceLoginDenied,
+	domain.EventServiceAccountCreated,
+	domain.EventServiceAccountKeyRotated,
+	domain.EventServiceAccountEnabled,
+	domain.EventServiceAccountDisabled,
+	domain.EventServiceAccountRoleBound,
+	domain.EventServiceAccountRoleUnbound,
+	domain.EventLegalHoldPlaced,
+	domain.EventLegalHoldReleased,
+	domain.EventElevationRequested,
+	domain.EventElevationApproved,
+	domain.EventElevationDenied,
+	domain.EventBreakGlassSealed,
+	domain.EventBreakGlassActivated,
+	domain.EventBreakGlassRejected,
+	domain.EventBreakGlassLogin,
+	domain.EventBreakGlassRevoked,
+	domain.EventUserSyncCompleted,
+	domain.EventProvisioningCompleted,
+	domain.EventPasswordResetRequested,
+	domain.EventPasswordResetCompleted,
+	domain.EventRoleAssigned,
+	domain.EventRoleUnassigned,
+	domain.EventSocialAccountLinked,
+}
+
+// severities rate the security events from 0 (lowest) to 10 (highest), as expected by CEF
+var severities = map[string]int{
+	domain.EventLoginFailed:               5,
+	domain.EventSignupRejected:            4,
+	domain.EventSessionEvicted:            4,
+	domain.EventSessionRevoked:            4,
+	domain.EventRefreshTokenReused:        9,
+	domain.EventLoginApprovalDenied:       6,
+	domain.EventDeviceLoginDenied:         5,
+	domain.EventServiceAccountCreated:     5,
+	domain.EventServiceAccountKeyRotated:  6,
+	domain.EventServiceAccountEnabled:     5,
+	domain.EventServiceAccountDisabled:    6,
+	domain.EventServiceAccountRoleBound:   7,
+	domain.EventServiceAccountRoleUnbound: 6,
+	domain.EventLegalHoldPlaced:           5,
+	domain.EventLegalHoldReleased:         5,
+	domain.EventElevationRequested:        5,
+	domain.EventElevationApproved:         8,
+	domain.EventElevationDenied:           4,
+	domain.EventBreakGlassSealed:          6,
+	domain.EventBreakGlassActivated:       10,
+	domain.EventBreakGlassRejected:        9,
+	domain.EventBreakGlassLogin:           10,
+	domain.EventBreakGlassRevoked:         7,
+	domain.EventUserSyncCompleted:         5,
+	domain.EventProvisioningCompleted:     4,
+	domain.EventPasswordResetRequested:    4,
+	domain.EventPasswordResetCompleted:    6,
+	domain.EventRoleAssigned:              7,
+	domain.EventRoleUnassigned:            6,
+	domain.EventSocialAccountLinked:       6,
+}
+
+// defaultSeverity rates the events missing from severities
+const defaultSeverity = 3
+
+// Severity returns the severity of the event, from 0 to 10
+func Severity(e event.Event) int {
+	if severity, ok := severities[e.Name]; ok {
+		return severity
+	}
+	return defaultSeverity
+}
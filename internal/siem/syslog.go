@@ -0,0 +1,138 @@
+package siem
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"go-hex/configs"
+	"go-hex/pkg/event"
+	"go-hex/pkg/otel"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// facilityAuthPriv is the syslog facility of the security messages
+const facilityAuthPriv = 10
+
+// SyslogSink sends the security events as CEF records in RFC 5424 syslog messages.
+// The connection is kept open between the batches and dialed again once a write failed.
+type SyslogSink struct {
+	network  configs.SyslogNetwork
+	address  string
+	hostname string
+	appName  string
+	format   CEF
+	timeout  time.Duration
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewSyslogSink creates a sink sending the events to the syslog server listening on address
+func NewSyslogSink(network configs.SyslogNetwork, address, appName string, format CEF, timeout time.Duration) *SyslogSink {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+	return &SyslogSink{network: network, address: address, hostname: hostname, appName: appName, format: format, timeout: timeout}
+}
+
+// Name returns the name of the destination
+func (s *SyslogSink) Name() string {
+	return "syslog"
+}
+
+// Send writes a syslog message per event
+func (s *SyslogSink) Send(ctx context.Context, events []event.Event) error {
+
+	_, span := otel.Start(ctx)
+	defer span.End()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range events {
+		err := s.write(ctx, s.message(e))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the connection to the syslog server
+func (s *SyslogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// message returns the syslog message of the event, newline terminated on the stream networks
+func (s *SyslogSink) message(e event.Event) []byte {
+	msg := fmt.Sprintf("<%d>1 %s %s %s - - - %s",
+		facilityAuthPriv*8+syslogSeverity(Severity(e)),
+		e.OccurredAt.UTC().Format(time.RFC3339Nano),
+		s.hostname,
+		s.appName,
+		s.format.Format(e),
+	)
+	if s.network != configs.SyslogNetworkUDP {
+		msg += "\n"
+	}
+	return []byte(msg)
+}
+
+// write writes the message, dialing again once when the connection was closed by the server
+func (s *SyslogSink) write(ctx context.Context, msg []byte) error {
+	for attempt := 0; ; attempt++ {
+		if s.conn == nil {
+			conn, err := s.dial(ctx)
+			if err != nil {
+				return errors.Wrap(err, "cannot connect to syslog server")
+			}
+			s.conn = conn
+		}
+
+		_ = s.conn.SetWriteDeadline(time.Now().Add(s.timeout))
+		_, err := s.conn.Write(msg)
+		if err == nil {
+			return nil
+		}
+		s.conn.Close()
+		s.conn = nil
+		if attempt > 0 {
+			return errors.Wrap(err, "cannot write to syslog server")
+		}
+	}
+}
+
+func (s *SyslogSink) dial(ctx context.Context) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: s.timeout}
+	if s.network == configs.SyslogNetworkTLS {
+		return (&tls.Dialer{NetDialer: dialer}).DialContext(ctx, "tcp", s.address)
+	}
+	return dialer.DialContext(ctx, string(s.network), s.address)
+}
+
+// syslogSeverity maps a CEF severity to the syslog severity of the message
+func syslogSeverity(severity int) int {
+	switch {
+	case severity >= 9:
+		return 2 // critical
+	case severity >= 7:
+		return 3 // error
+	case severity >= 4:
+		return 4 // warning
+	}
+	return 6 // informational
+}
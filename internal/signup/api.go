@@ -0,0 +1,67 @@
+package signup
+
+import (
+	"go-hex/configs"
+	"go-hex/middleware"
+	"go-hex/shared/ierr"
+	"go-hex/shared/response"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+// RegisterAPI registers a new signup api
+func RegisterAPI(r echo.Group, cfg *configs.Config, service ServicePort) {
+	handler := handler{cfg, service}
+
+	r.POST("/auth/signup", handler.signup, middleware.MinDuration(cfg.EnumerationMinDuration()))
+}
+
+type handler struct {
+	cfg     *configs.Config
+	service ServicePort
+}
+
+// signup godoc
+// @Router /auth/signup [post]
+// @Tags Auth
+// @Summary Sign up
+// @Description Register a new user, inactive until it confirms its email address with the emailed token. The signups are rejected from the IP addresses signing up too often, and for the disposable email addresses or the domains without MX record when these checks are enabled.
+// @Description In strict enumeration mode, the signups answer 202 without the user, whether the username or the email address is already registered or not.
+// @Accept json
+// @Produce json
+// @Param payload body RequestSignup true " "
+// @Success 201 {object} response.Response{data=domain.User} "Success"
+// @Success 202 {object} response.Response "Accepted, in strict enumeration mode"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 403 {object} response.ErrorResponse403
+// @failure 429 {object} response.ErrorResponse429
+// @failure 500 {object} response.ErrorResponse500
+// @failure 503 {object} response.ErrorResponse503
+func (h handler) signup(c echo.Context) error {
+	var req RequestSignup
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+	req.IPAddress = c.RealIP()
+
+	res, err := h.service.Signup(c.Request().Context(), req)
+	if err != nil {
+		switch errors.Cause(err) {
+		case ierr.ErrSignupRejected, ierr.ErrUserAlreadyRegistered, ierr.ErrPasswordTooShort, ierr.ErrPasswordTooSimple, ierr.ErrPasswordTooCommon, ierr.ErrPasswordContainsUsername:
+			return response.ErrBadRequest(err)
+		case ierr.ErrSignupDisabled:
+			return response.ErrForbidden(err)
+		case ierr.ErrTooManyRequests:
+			return response.HTTPError(err, http.StatusTooManyRequests, ierr.ErrTooManyRequests.Code, ierr.ErrTooManyRequests.Message)
+		}
+		return err
+	}
+
+	if h.cfg.Enumeration.Strict {
+		// the same answer whether the account was created or already existed
+		return response.Success(c, http.StatusAccepted, nil, "signup accepted")
+	}
+	return response.SuccessCreated(c, res, "user signed up, the email address must be verified to log in")
+}
@@ -0,0 +1,18 @@
+package signup
+
+// Names of the checks of the signup gate, labelling the rejections in the metrics and the events
+const (
+	CheckDisposableEmail = "disposable_email"
+	CheckVelocity        = "velocity"
+	CheckMX              = "mx"
+
+	// CheckAlreadyRegistered labels the signups of a registered username or email address, which are only
+	// published as rejected in strict enumeration mode since the client is told otherwise
+	CheckAlreadyRegistered = "already_registered"
+)
+
+const (
+	// maxVelocityEntries is the number of IP addresses tracked by the velocity check above which
+	// the addresses without attempt within the window are forgotten
+	maxVelocityEntries = 100000
+)
@@ -0,0 +1,55 @@
+package signup
+
+import (
+	"context"
+	_ "embed"
+	"go-hex/shared/ierr"
+	"strings"
+)
+
+// disposableDomainsFile lists the domains of the disposable email providers, one per line
+//
+//go:embed disposable_domains.txt
+var disposableDomainsFile string
+
+// DisposableEmailCheck rejects the email addresses of the disposable email providers,
+// and of their subdomains.
+type DisposableEmailCheck struct {
+	domains map[string]bool
+}
+
+// NewDisposableEmailCheck creates a check rejecting the embedded list of domains and the given ones
+func NewDisposableEmailCheck(domains []string) *DisposableEmailCheck {
+	c := &DisposableEmailCheck{domains: map[string]bool{}}
+	for _, line := range strings.Split(disposableDomainsFile, "\n") {
+		c.add(line)
+	}
+	for _, domain := range domains {
+		c.add(domain)
+	}
+	return c
+}
+
+func (c *DisposableEmailCheck) add(domain string) {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	if domain != "" && !strings.HasPrefix(domain, "#") {
+		c.domains[domain] = true
+	}
+}
+
+// Name implements Check
+func (c *DisposableEmailCheck) Name() string {
+	return CheckDisposableEmail
+}
+
+// Check rejects the attempt with ierr.ErrSignupRejected when its domain or one of its parents is disposable
+func (c *DisposableEmailCheck) Check(ctx context.Context, attempt Attempt) error {
+	domain := attempt.Domain
+	for domain != "" {
+		if c.domains[domain] {
+			return ierr.ErrSignupRejected
+		}
+		_, domain, _ = strings.Cut(domain, ".")
+	}
+	return nil
+}
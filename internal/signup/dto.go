@@ -0,0 +1,36 @@
+package signup
+
+import (
+	"net/mail"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pkg/errors"
+)
+
+// RequestSignup request body
+type RequestSignup struct {
+	Username  string `json:"username" example:"jane"`
+	Password  string `json:"password" example:"password1234"`
+	FullName  string `json:"full_name" example:"Jane Doe"`
+	Email     string `json:"email" example:"jane@example.com"`
+	IPAddress string `json:"-"`
+}
+
+func (r *RequestSignup) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Username, validation.Required, validation.Length(3, 50)),
+		validation.Field(&r.Password, validation.Required, validation.Length(8, 72)), // bcrypt ignores the bytes after the 72th
+		validation.Field(&r.FullName, validation.Length(0, 255)),
+		validation.Field(&r.Email, validation.Required, validation.Length(0, 255), validation.By(isEmail)),
+	)
+}
+
+// isEmail checks that the value is a bare email address, without a display name
+func isEmail(value interface{}) error {
+	email, _ := value.(string)
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email {
+		return errors.New("must be a valid email address")
+	}
+	return nil
+}
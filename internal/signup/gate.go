@@ -0,0 +1,86 @@
+package signup
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/metrics"
+	"go-hex/shared/ierr"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	signupRejected = metrics.NewCounterVec(prometheus.CounterOpts{
+		Name: "signup_rejected_total",
+		Help: "Number of signups rejected by the signup gate, by check.",
+	}, "check")
+	signupCheckErrors = metrics.NewCounterVec(prometheus.CounterOpts{
+		Name: "signup_check_errors_total",
+		Help: "Number of signup checks which could not decide and let the signup through, by check.",
+	}, "check")
+)
+
+// Attempt is a signup submitted to the gate
+type Attempt struct {
+	Email     string // normalized
+	Domain    string // domain of the email address
+	IPAddress string
+}
+
+// Check is a check of the signup gate.
+type Check interface {
+	// Name names the check in the metrics and the events
+	Name() string
+	// Check returns an ierr.Error when the attempt is rejected, the other errors mean that the check could not decide.
+	Check(ctx context.Context, attempt Attempt) error
+}
+
+// Gate runs the checks of the signup in order, the first check rejecting the signup rejects it.
+// A check which cannot decide, e.g. a DNS lookup timing out, is logged and lets the signup through
+// so that an outage of its dependency does not close the signup.
+type Gate struct {
+	log    logger.Logger
+	checks []Check
+}
+
+// NewGate creates a gate running the given checks in order
+func NewGate(log logger.Logger, checks ...Check) *Gate {
+	return &Gate{log, checks}
+}
+
+// ConfiguredChecks returns the checks enabled by the configuration. The velocity check comes first,
+// so that the attempts it rejects cost no DNS lookup.
+func ConfiguredChecks(cfg *configs.Config) []Check {
+	var checks []Check
+	if cfg.Signup.VelocityLimit > 0 {
+		checks = append(checks, NewVelocityCheck(cfg.Signup.VelocityLimit, time.Duration(cfg.Signup.VelocityWindow)*time.Second))
+	}
+	if cfg.Signup.DisposableEmailCheck {
+		checks = append(checks, NewDisposableEmailCheck(cfg.Signup.DisposableEmailDomains))
+	}
+	if cfg.Signup.MXCheck {
+		checks = append(checks, NewMXCheck(time.Duration(cfg.Signup.MXTimeout)*time.Second))
+	}
+	return checks
+}
+
+// Allow runs the checks on the attempt. It returns the name of the check rejecting the attempt with its error,
+// or no error when every check let it through.
+func (g *Gate) Allow(ctx context.Context, attempt Attempt) (string, error) {
+	for _, check := range g.checks {
+		err := check.Check(ctx, attempt)
+		if err == nil {
+			continue
+		}
+		if _, ok := errors.Cause(err).(ierr.Error); ok {
+			signupRejected.WithLabelValues(check.Name()).Inc()
+			return check.Name(), err
+		}
+		signupCheckErrors.WithLabelValues(check.Name()).Inc()
+		g.log.With(ctx).WithParams(logger.Params{"type": "signup", "check": check.Name()}).Warn(err)
+	}
+	return "", nil
+}
@@ -0,0 +1,53 @@
+package signup
+
+import (
+	"context"
+	"go-hex/shared/ierr"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// mxResolver looks up the MX records of a domain, implemented by net.Resolver
+type mxResolver interface {
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+}
+
+// MXCheck rejects the email addresses whose domain cannot receive emails: the domains which do not exist,
+// have no MX record or publish a null MX (RFC 7505).
+type MXCheck struct {
+	resolver mxResolver
+	timeout  time.Duration
+}
+
+// NewMXCheck creates a check looking up the MX records with the resolver of the host within the timeout
+func NewMXCheck(timeout time.Duration) *MXCheck {
+	return &MXCheck{net.DefaultResolver, timeout}
+}
+
+// Name implements Check
+func (c *MXCheck) Name() string {
+	return CheckMX
+}
+
+// Check rejects the attempt with ierr.ErrSignupRejected when its domain has no usable MX record.
+// A lookup failing otherwise, e.g. timing out, cannot decide.
+func (c *MXCheck) Check(ctx context.Context, attempt Attempt) error {
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	records, err := c.resolver.LookupMX(ctx, attempt.Domain)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return ierr.ErrSignupRejected
+		}
+		return errors.Wrapf(err, "cannot lookup the mx records of %s", attempt.Domain)
+	}
+	if len(records) == 0 || (len(records) == 1 && records[0].Host == ".") {
+		return ierr.ErrSignupRejected
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+package signup
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/internal/user"
+)
+
+// ServicePort encapsulates the public signup logic.
+type ServicePort interface {
+	// Signup registers a new user once the checks of the signup gate allowed it
+	Signup(ctx context.Context, req RequestSignup) (domain.User, error)
+}
+
+// Registrar registers the users, inactive until they confirm their email address with the token sent to it, e.g. the
+// user service.
+type Registrar interface {
+	Register(ctx context.Context, req user.RequestRegister) (user.ResponseRegister, error)
+}
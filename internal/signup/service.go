@@ -0,0 +1,107 @@
+package signup
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/deliverability"
+	"go-hex/internal/domain"
+	"go-hex/internal/user"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/otel"
+	"go-hex/shared/ierr"
+	"strings"
+)
+
+// Service encapsulates the public signup logic. The signups go through the checks of the gate first, the
+// rejected ones are counted, logged and published on the event bus so that the abuses reach the security events.
+// The users are then registered by the registrar, like the users of the internal api.
+type Service struct {
+	cfg       *configs.Config
+	log       logger.Logger
+	events    event.Bus
+	gate      *Gate
+	registrar Registrar
+}
+
+// NewService creates and returns a new signup service
+func NewService(cfg *configs.Config, log logger.Logger, events event.Bus, gate *Gate, registrar Registrar) *Service {
+	return &Service{cfg, log, events, gate, registrar}
+}
+
+// Signup registers a new user once the checks of the signup gate allowed it. The user is inactive, and its email
+// address unverified, until it confirms the address with the token emailed to it, so that an address cannot be
+// claimed by signing up with it.
+// In strict enumeration mode, the user is not returned and a registered username or email address is not an error.
+func (s *Service) Signup(ctx context.Context, req RequestSignup) (domain.User, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	if !s.cfg.Signup.Enabled {
+		return domain.User{}, ierr.ErrSignupDisabled
+	}
+
+	err := req.Validate()
+	if err != nil {
+		return domain.User{}, err
+	}
+	err = configs.FromContext(ctx, s.cfg).PasswordValidator().Validate(req.Password, req.Username)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	email := deliverability.NormalizeAddress(req.Email)
+	attempt := Attempt{Email: email, Domain: email[strings.LastIndex(email, "@")+1:], IPAddress: req.IPAddress}
+	check, err := s.gate.Allow(ctx, attempt)
+	if err != nil {
+		if check != "" {
+			s.reject(ctx, check, req.Username, attempt, err)
+		}
+		return domain.User{}, err
+	}
+
+	res, err := s.registrar.Register(ctx, user.RequestRegister{Username: req.Username, Password: req.Password, FullName: req.FullName, Email: email})
+	if err == ierr.ErrUserAlreadyRegistered && s.cfg.Enumeration.Strict {
+		// answered as a signup, so that the existing accounts cannot be told apart
+		s.reject(ctx, CheckAlreadyRegistered, req.Username, attempt, err)
+		return domain.User{}, nil
+	}
+	if err != nil {
+		return domain.User{}, err
+	}
+	registered := res.User
+
+	s.events.Publish(ctx, event.Event{
+		Name:      domain.EventUserSignedUp,
+		ActorID:   registered.ID,
+		SubjectID: registered.ID,
+		Attributes: map[string]interface{}{
+			"username":     registered.Username,
+			"email_domain": attempt.Domain,
+			"ip_address":   attempt.IPAddress,
+		},
+	})
+
+	if s.cfg.Enumeration.Strict {
+		return domain.User{}, nil
+	}
+	return registered, nil
+}
+
+// reject records the rejection of a signup by a check of the gate
+func (s *Service) reject(ctx context.Context, check string, username string, attempt Attempt, err error) {
+	otel.Event(ctx, otel.EventSignupRejected, otel.AttributeSignupCheck.String(check))
+	s.log.With(ctx).WithParams(logger.Params{"type": "signup", "check": check, "email_domain": attempt.Domain, "ip_address": attempt.IPAddress}).Warn("signup rejected")
+	s.events.Publish(ctx, event.Event{
+		Name:      domain.EventSignupRejected,
+		SubjectID: username,
+		Attributes: map[string]interface{}{
+			"username":     username,
+			"check":        check,
+			"email_domain": attempt.Domain,
+			"ip_address":   attempt.IPAddress,
+			"reason":       err.Error(),
+		},
+	})
+}
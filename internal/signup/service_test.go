@@ -0,0 +1,193 @@
+package signup
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/notification"
+	"go-hex/internal/repository/memory"
+	"go-hex/internal/user"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/password"
+	"go-hex/shared/ierr"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// users lists the users registered in the store
+func users(t *testing.T, store *memory.Store) []domain.User {
+	t.Helper()
+	registered, err := store.GetUserRepository().List(context.Background(), "", 0)
+	require.NoError(t, err)
+	return registered
+}
+
+type fakeResolver struct {
+	records []*net.MX
+	err     error
+}
+
+func (r fakeResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	return r.records, r.err
+}
+
+func newTestService(checks ...Check) (*Service, *memory.Store, *[]event.Event) {
+	cfg := &configs.Config{}
+	cfg.Signup.Enabled = true
+
+	store := memory.NewStore()
+	events := event.New()
+	published := &[]event.Event{}
+	events.Subscribe(event.All, func(ctx context.Context, e event.Event) {
+		*published = append(*published, e)
+	})
+	log := logger.New("test", "test")
+	registrar := user.NewService(cfg, store, log, event.New(), notification.NewDispatcher(), password.NewPool(cfg.PasswordHasher(), 1, time.Second))
+	return NewService(cfg, log, events, NewGate(log, checks...), registrar), store, published
+}
+
+func TestSignup(t *testing.T) {
+	svc, store, published := newTestService(NewDisposableEmailCheck(nil))
+
+	signedUp, err := svc.Signup(context.Background(), RequestSignup{Username: "jane", Password: "password1234", Email: "Jane@Example.com", IPAddress: "10.0.0.1"})
+	assert.NoError(t, err)
+	assert.False(t, signedUp.IsActive, "inactive until the address is verified")
+	assert.False(t, signedUp.IsEmailVerified())
+	assert.Equal(t, "jane@example.com", signedUp.GetEmail())
+	registered, err := store.GetUserRepository().GetByID(context.Background(), signedUp.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "jane", registered.Username, "registered by the registrar")
+
+	_, err = svc.Signup(context.Background(), RequestSignup{Username: "jane", Password: "password1234", Email: "jane@example.org", IPAddress: "10.0.0.1"})
+	assert.Equal(t, ierr.ErrUserAlreadyRegistered, err)
+
+	if assert.Len(t, *published, 1) {
+		assert.Equal(t, domain.EventUserSignedUp, (*published)[0].Name)
+		assert.Equal(t, "example.com", (*published)[0].Attributes["email_domain"])
+	}
+}
+
+func TestSignupStrictEnumerationAnswersTheSame(t *testing.T) {
+	svc, store, published := newTestService()
+	svc.cfg.Enumeration.Strict = true
+
+	created, err := svc.Signup(context.Background(), RequestSignup{Username: "jane", Password: "password1234", Email: "jane@example.com"})
+	assert.NoError(t, err)
+	existing, err := svc.Signup(context.Background(), RequestSignup{Username: "jane", Password: "password5678", Email: "jane@example.org"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, created, existing)
+	assert.Len(t, users(t, store), 1)
+	if assert.Len(t, *published, 2) {
+		assert.Equal(t, domain.EventUserSignedUp, (*published)[0].Name)
+		assert.Equal(t, domain.EventSignupRejected, (*published)[1].Name)
+		assert.Equal(t, CheckAlreadyRegistered, (*published)[1].Attributes["check"])
+	}
+}
+
+func TestSignupDisabled(t *testing.T) {
+	svc, store, _ := newTestService()
+	svc.cfg.Signup.Enabled = false
+
+	_, err := svc.Signup(context.Background(), RequestSignup{Username: "jane", Password: "password1234", Email: "jane@example.com"})
+	assert.Equal(t, ierr.ErrSignupDisabled, err)
+	assert.Empty(t, users(t, store))
+}
+
+func TestSignupRejected(t *testing.T) {
+	svc, store, published := newTestService(NewDisposableEmailCheck([]string{"Throwaway.test"}))
+
+	tests := []struct {
+		name  string
+		email string
+	}{
+		{"embedded domain", "jane@mailinator.com"},
+		{"subdomain", "jane@eu.mailinator.com"},
+		{"configured domain", "jane@throwaway.test"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.Signup(context.Background(), RequestSignup{Username: "jane", Password: "password1234", Email: tt.email})
+			assert.Equal(t, ierr.ErrSignupRejected, err)
+		})
+	}
+	assert.Empty(t, users(t, store))
+
+	if assert.Len(t, *published, len(tests)) {
+		assert.Equal(t, domain.EventSignupRejected, (*published)[0].Name)
+		assert.Equal(t, CheckDisposableEmail, (*published)[0].Attributes["check"])
+	}
+}
+
+func TestVelocityCheck(t *testing.T) {
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	check := NewVelocityCheck(2, time.Hour)
+	check.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, check.Check(context.Background(), Attempt{IPAddress: "10.0.0.1"}))
+		now = now.Add(time.Minute)
+	}
+	err := check.Check(context.Background(), Attempt{IPAddress: "10.0.0.1"})
+	assert.Equal(t, ierr.ErrTooManyRequests, errors.Cause(err))
+	until, _ := ierr.LockedUntil(err)
+	assert.Equal(t, time.Date(2026, 10, 14, 13, 0, 0, 0, time.UTC), until, "until the first attempt leaves the window")
+	assert.NoError(t, check.Check(context.Background(), Attempt{IPAddress: "10.0.0.2"}))
+
+	// the first attempt leaves the window
+	now = now.Add(time.Hour - 2*time.Minute)
+	assert.NoError(t, check.Check(context.Background(), Attempt{IPAddress: "10.0.0.1"}))
+	err = check.Check(context.Background(), Attempt{IPAddress: "10.0.0.1"})
+	assert.Equal(t, ierr.ErrTooManyRequests, errors.Cause(err))
+	until, _ = ierr.LockedUntil(err)
+	assert.Equal(t, time.Date(2026, 10, 14, 13, 1, 0, 0, time.UTC), until)
+}
+
+func TestMXCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		resolver fakeResolver
+		rejected bool
+		err      bool
+	}{
+		{"mx records", fakeResolver{records: []*net.MX{{Host: "mx.example.com.", Pref: 10}}}, false, false},
+		{"no mx record", fakeResolver{}, true, false},
+		{"null mx", fakeResolver{records: []*net.MX{{Host: ".", Pref: 0}}}, true, false},
+		{"unknown domain", fakeResolver{err: &net.DNSError{Err: "no such host", IsNotFound: true}}, true, false},
+		{"lookup timeout", fakeResolver{err: &net.DNSError{Err: "i/o timeout", IsTimeout: true}}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := &MXCheck{tt.resolver, time.Second}
+			err := check.Check(context.Background(), Attempt{Domain: "example.com"})
+			assert.Equal(t, tt.rejected, err == ierr.ErrSignupRejected, "%v", err)
+			assert.Equal(t, tt.err, err != nil && !tt.rejected, "%v", err)
+		})
+	}
+}
+
+type failingCheck struct{}
+
+func (failingCheck) Name() string { return "failing" }
+
+func (failingCheck) Check(ctx context.Context, attempt Attempt) error {
+	return errors.New("dependency down")
+}
+
+func TestGateSkipsUndecidedChecks(t *testing.T) {
+	gate := NewGate(logger.New("test", "test"), failingCheck{}, NewDisposableEmailCheck(nil))
+
+	check, err := gate.Allow(context.Background(), Attempt{Domain: "example.com"})
+	assert.NoError(t, err)
+	assert.Empty(t, check)
+
+	check, err = gate.Allow(context.Background(), Attempt{Domain: "yopmail.com"})
+	assert.Equal(t, ierr.ErrSignupRejected, err)
+	assert.Equal(t, CheckDisposableEmail, check)
+}
@@ -0,0 +1,71 @@
+package signup
+
+import (
+	"context"
+	"go-hex/pkg/times"
+	"go-hex/shared/ierr"
+	"sync"
+	"time"
+)
+
+// VelocityCheck limits the signups of an IP address within a sliding window.
+// The attempts are counted in memory, so the limit applies to each instance of the api.
+type VelocityCheck struct {
+	limit  int
+	window time.Duration
+	now    func() time.Time
+
+	mu       sync.Mutex
+	attempts map[string][]time.Time // by IP address, the oldest first
+}
+
+// NewVelocityCheck creates a check letting through limit signups per IP address within the window
+func NewVelocityCheck(limit int, window time.Duration) *VelocityCheck {
+	return &VelocityCheck{limit: limit, window: window, now: times.Now, attempts: map[string][]time.Time{}}
+}
+
+// Name implements Check
+func (c *VelocityCheck) Name() string {
+	return CheckVelocity
+}
+
+// Check rejects the attempt with ierr.ErrTooManyRequests when the IP address reached the limit within the window,
+// limited until the attempt leaving the window lets the next one through.
+// The attempts let through are counted whether or not the next checks reject them.
+func (c *VelocityCheck) Check(ctx context.Context, attempt Attempt) error {
+
+	now := c.now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	recent := c.recent(attempt.IPAddress, now)
+	if len(recent) >= c.limit {
+		c.attempts[attempt.IPAddress] = recent
+		// the next attempt is let through once enough of the recent ones left the window
+		return ierr.Limited(ierr.ErrTooManyRequests, recent[len(recent)-c.limit].Add(c.window))
+	}
+	if len(recent) == 0 && len(c.attempts) >= maxVelocityEntries {
+		c.sweep(now)
+	}
+	c.attempts[attempt.IPAddress] = append(recent, now)
+	return nil
+}
+
+// recent returns the attempts of the IP address within the window
+func (c *VelocityCheck) recent(ip string, now time.Time) []time.Time {
+	attempts := c.attempts[ip]
+	for len(attempts) > 0 && !attempts[0].After(now.Add(-c.window)) {
+		attempts = attempts[1:]
+	}
+	return attempts
+}
+
+// sweep forgets the IP addresses without attempt within the window
+func (c *VelocityCheck) sweep(now time.Time) {
+	for ip := range c.attempts {
+		if len(c.recent(ip, now)) == 0 {
+			delete(c.attempts, ip)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package sms
+
+import (
+	"go-hex/configs"
+	"go-hex/shared/ierr"
+	"go-hex/shared/response"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+// headerTwilioSignature is the header of the signature of the Twilio callbacks
+const headerTwilioSignature = "X-Twilio-Signature"
+
+// RegisterAPI registers a new text message api
+func RegisterAPI(r echo.Group, cfg *configs.Config, service ServicePort) {
+	handler := handler{cfg, service}
+
+	// Webhooks of the sms providers, authenticated by their signature
+	r.POST("/webhooks/sms/twilio", handler.twilio)
+}
+
+type handler struct {
+	cfg     *configs.Config
+	service ServicePort
+}
+
+// twilio godoc
+// @Router /webhooks/sms/twilio [post]
+// @Tags SMS
+// @Summary Receive the Twilio status callbacks
+// @Description Receive the status callbacks of the text messages sent through Twilio, signed with the auth token of the account, and record their final delivery status
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param X-Twilio-Signature header string true "signature of the callback"
+// @Param MessageSid formData string true "id of the message"
+// @Param MessageStatus formData string true "status of the message"
+// @Param ErrorCode formData string false "error code of the undelivered messages"
+// @Success 200 {object} response.Response "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) twilio(c echo.Context) error {
+	req := c.Request()
+	req.Body = http.MaxBytesReader(c.Response(), req.Body, maxCallbackSize)
+	if err := req.ParseForm(); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	err := h.service.HandleTwilioStatus(req.Context(), RequestTwilioStatus{
+		Form:      req.PostForm,
+		Signature: req.Header.Get(headerTwilioSignature),
+	})
+	if err != nil {
+		if errors.Cause(err) == ierr.ErrInvalidWebhook {
+			return response.ErrUnauthorized(err)
+		}
+		return err
+	}
+
+	return response.SuccessOK(c, nil, "status recorded")
+}
@@ -0,0 +1,21 @@
+package sms
+
+import (
+	"go-hex/internal/domain"
+	"regexp"
+)
+
+// maxCallbackSize bounds the body of a status callback, in bytes
+const maxCallbackSize = 64 << 10
+
+// e164 matches the phone numbers in the E.164 format
+var e164 = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+// twilioStatuses maps the final statuses of the Twilio status callbacks to the statuses of the messages,
+// the intermediate statuses are ignored so that a late callback does not regress the status
+var twilioStatuses = map[string]string{
+	"delivered":   domain.SMSStatusDelivered,
+	"read":        domain.SMSStatusDelivered,
+	"undelivered": domain.SMSStatusUndelivered,
+	"failed":      domain.SMSStatusFailed,
+}
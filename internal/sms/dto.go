@@ -0,0 +1,9 @@
+package sms
+
+import "net/url"
+
+// RequestTwilioStatus request of a Twilio status callback
+type RequestTwilioStatus struct {
+	Form      url.Values
+	Signature string
+}
@@ -0,0 +1,14 @@
+package sms
+
+import (
+	"context"
+	"go-hex/internal/domain"
+)
+
+// ServicePort encapsulates the text message logic.
+type ServicePort interface {
+	// Send sends the body to the E.164 phone number of a user through the providers of its route
+	Send(ctx context.Context, userID string, to string, body string) (domain.SMSMessage, error)
+	// HandleTwilioStatus records the delivery status posted by Twilio
+	HandleTwilioStatus(ctx context.Context, req RequestTwilioStatus) error
+}
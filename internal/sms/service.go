@@ -0,0 +1,217 @@
+package sms
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/notification"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/metrics"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/times"
+	"go-hex/pkg/utils"
+	"go-hex/shared/ierr"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	smsMessages = metrics.NewCounterVec(prometheus.CounterOpts{
+		Name: "sms_messages_total",
+		Help: "Number of text messages sent or failed, by provider, route and outcome.",
+	}, "provider", "route", "outcome")
+	smsFailovers = metrics.NewCounterVec(prometheus.CounterOpts{
+		Name: "sms_failovers_total",
+		Help: "Number of text messages failed over to the next provider of their route, by provider failing.",
+	}, "provider")
+	smsSegments = metrics.NewCounterVec(prometheus.CounterOpts{
+		Name: "sms_segments_total",
+		Help: "Number of segments of the text messages sent, by provider and route.",
+	}, "provider", "route")
+	smsCost = metrics.NewCounterVec(prometheus.CounterOpts{
+		Name: "sms_cost_usd_total",
+		Help: "Cost in USD of the text messages sent from the configured prices, by provider and route.",
+	}, "provider", "route")
+	smsDeliveries = metrics.NewCounterVec(prometheus.CounterOpts{
+		Name: "sms_delivery_status_total",
+		Help: "Number of final delivery statuses reported by the providers, by provider and status.",
+	}, "provider", "status")
+)
+
+// Service sends the text messages through the providers of the route of the recipient, the longest calling
+// code prefix of the routes matching the phone number. A provider failing or not answering within the timeout
+// fails over to the next provider of the route. Every message is recorded without its phone number, with its
+// cost from the configured prices, and its delivery status is updated from the callbacks of the providers.
+// It is the notifier of the sms channel.
+type Service struct {
+	repoRegitry port.RepositoryRegistry
+	log         logger.Logger
+	routes      configs.SMSRoutes
+	prices      configs.SMSPrices
+	timeout     time.Duration
+	providers   map[string]Provider
+}
+
+// NewService creates and returns a new text message service sending through the given providers,
+// the providers of the routes which are not given are skipped
+func NewService(repoRegitry port.RepositoryRegistry, log logger.Logger, routes configs.SMSRoutes, prices configs.SMSPrices, timeout time.Duration, providers ...Provider) *Service {
+	s := &Service{repoRegitry, log, routes, prices, timeout, map[string]Provider{}}
+	for _, provider := range providers {
+		s.providers[provider.Name()] = provider
+	}
+	return s
+}
+
+// Channel returns the channel the service delivers to.
+func (s *Service) Channel() notification.Channel {
+	return notification.ChannelSMS
+}
+
+// Notify sends the body of the message to its recipient.
+func (s *Service) Notify(ctx context.Context, msg notification.Message) error {
+	_, err := s.Send(ctx, msg.UserID, msg.To, msg.Body)
+	return err
+}
+
+// Send sends the body to the E.164 phone number of a user through the providers of its route
+func (s *Service) Send(ctx context.Context, userID string, to string, body string) (domain.SMSMessage, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	if !e164.MatchString(to) {
+		return domain.SMSMessage{}, ierr.ErrInvalidPhoneNumber
+	}
+	route := s.route(to)
+	if route == "" {
+		return domain.SMSMessage{}, errors.Errorf("no sms route for %s", to[:2])
+	}
+
+	msg := domain.SMSMessage{
+		ID:       utils.GenerateID(),
+		UserID:   userID,
+		Route:    route,
+		Segments: Segments(body),
+		Status:   domain.SMSStatusFailed,
+	}
+
+	var lastErr error
+	for _, name := range s.routes[route] {
+		provider, ok := s.providers[name]
+		if !ok {
+			continue
+		}
+		if msg.Attempts > 0 {
+			smsFailovers.WithLabelValues(msg.Provider).Inc()
+		}
+		msg.Attempts++
+		msg.Provider = name
+
+		attemptCtx, cancel := context.WithTimeout(ctx, s.timeout)
+		id, err := provider.Send(attemptCtx, to, body)
+		cancel()
+		if err == nil {
+			msg.ProviderMessageID, msg.Status, msg.ErrorCode = id, domain.SMSStatusSent, ""
+			break
+		}
+
+		lastErr = err
+		msg.ErrorCode = errorCode(err)
+		s.log.WithParams(logger.Params{"type": "sms", "provider": name, "route": route, "error": err.Error()}).Warn("sms provider failed")
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	if msg.Attempts == 0 {
+		return msg, errors.Errorf("no provider of the sms route %s is configured", route)
+	}
+
+	now := times.Now()
+	msg.CreatedAt, msg.UpdatedAt = now, now
+	if msg.Status == domain.SMSStatusSent {
+		msg.Cost = s.price(msg.Provider, to) * float64(msg.Segments)
+		smsMessages.WithLabelValues(msg.Provider, route, "sent").Inc()
+		smsSegments.WithLabelValues(msg.Provider, route).Add(float64(msg.Segments))
+		smsCost.WithLabelValues(msg.Provider, route).Add(msg.Cost)
+	} else {
+		msg.Provider = ""
+		smsMessages.WithLabelValues("", route, "failed").Inc()
+	}
+
+	if err := s.repoRegitry.GetSMSMessageRepository().Create(ctx, msg); err != nil {
+		s.log.WithParams(logger.Params{"type": "sms", "sms_id": msg.ID, "error": err.Error()}).Error("cannot record sms message")
+	}
+	if msg.Status != domain.SMSStatusSent {
+		return msg, errors.Wrap(lastErr, "cannot send sms through any provider")
+	}
+	return msg, nil
+}
+
+// HandleTwilioStatus records the delivery status posted by Twilio
+func (s *Service) HandleTwilioStatus(ctx context.Context, req RequestTwilioStatus) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	twilio, ok := s.providers[configs.SMSProviderTwilio].(*TwilioProvider)
+	if !ok || !twilio.VerifyCallback(req.Form, req.Signature) {
+		return ierr.ErrInvalidWebhook
+	}
+
+	status, ok := twilioStatuses[req.Form.Get("MessageStatus")]
+	if !ok {
+		return nil
+	}
+	smsDeliveries.WithLabelValues(configs.SMSProviderTwilio, status).Inc()
+
+	err := s.repoRegitry.GetSMSMessageRepository().UpdateStatus(ctx, configs.SMSProviderTwilio, req.Form.Get("MessageSid"), status, req.Form.Get("ErrorCode"), times.Now())
+	if errors.Cause(err) == ierr.ErrResourceNotFound {
+		// sent by another environment sharing the account, or already updated
+		return nil
+	}
+	return err
+}
+
+// route returns the longest prefix of the routes matching the phone number, or the default route
+func (s *Service) route(to string) string {
+	route := ""
+	for prefix := range s.routes {
+		if strings.HasPrefix(to, prefix) && len(prefix) > len(route) {
+			route = prefix
+		}
+	}
+	if route == "" {
+		if _, ok := s.routes[configs.SMSRouteDefault]; ok {
+			return configs.SMSRouteDefault
+		}
+	}
+	return route
+}
+
+// price returns the configured price of a segment sent by the provider to the phone number,
+// the price of the longest prefix matching the number or else the default price
+func (s *Service) price(provider string, to string) float64 {
+	prefix := configs.SMSRouteDefault
+	for p := range s.prices[provider] {
+		if strings.HasPrefix(to, p) && (prefix == configs.SMSRouteDefault || len(p) > len(prefix)) {
+			prefix = p
+		}
+	}
+	return s.prices[provider][prefix]
+}
+
+// errorCode returns the error code of the provider, or the kind of the failure
+func errorCode(err error) string {
+	var providerErr ProviderError
+	switch {
+	case errors.As(err, &providerErr):
+		return providerErr.Code
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	}
+	return "error"
+}
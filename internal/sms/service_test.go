@@ -0,0 +1,175 @@
+package sms
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/memory"
+	"go-hex/pkg/logger"
+	"go-hex/shared/ierr"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeProvider struct {
+	name  string
+	err   error
+	delay time.Duration
+	sent  []string
+}
+
+func (p *fakeProvider) Name() string {
+	return p.name
+}
+
+func (p *fakeProvider) Send(ctx context.Context, to string, body string) (string, error) {
+	select {
+	case <-time.After(p.delay):
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	if p.err != nil {
+		return "", p.err
+	}
+	p.sent = append(p.sent, to)
+	return p.name + "-id", nil
+}
+
+func TestSendFailsOver(t *testing.T) {
+	store := memory.NewStore()
+	twilio := &fakeProvider{name: configs.SMSProviderTwilio, err: ProviderError{Code: "30003", Message: "unreachable"}}
+	sns := &fakeProvider{name: configs.SMSProviderSNS}
+	routes := configs.SMSRoutes{"+1": {configs.SMSProviderTwilio, configs.SMSProviderSNS}, "*": {configs.SMSProviderSNS}}
+	prices := configs.SMSPrices{configs.SMSProviderSNS: {"*": 0.05, "+1": 0.01}}
+	svc := NewService(store, logger.New("test", "test"), routes, prices, time.Second, twilio, sns)
+
+	msg, err := svc.Send(context.Background(), "u1", "+14155550100", "hello")
+	assert.NoError(t, err)
+	assert.Equal(t, configs.SMSProviderSNS, msg.Provider)
+	assert.Equal(t, "sns-id", msg.ProviderMessageID)
+	assert.Equal(t, "+1", msg.Route)
+	assert.Equal(t, 2, msg.Attempts)
+	assert.Equal(t, domain.SMSStatusSent, msg.Status)
+	assert.Empty(t, msg.ErrorCode)
+	assert.InDelta(t, 0.01, msg.Cost, 1e-9)
+	assert.Len(t, store.SMSMessages(), 1)
+
+	// the numbers matching no other prefix take the default route
+	msg, err = svc.Send(context.Background(), "u1", "+447700900123", "hello")
+	assert.NoError(t, err)
+	assert.Equal(t, configs.SMSRouteDefault, msg.Route)
+	assert.Equal(t, 1, msg.Attempts)
+	assert.InDelta(t, 0.05, msg.Cost, 1e-9)
+}
+
+func TestSendTimesOut(t *testing.T) {
+	store := memory.NewStore()
+	twilio := &fakeProvider{name: configs.SMSProviderTwilio, delay: time.Second}
+	routes := configs.SMSRoutes{"*": {configs.SMSProviderTwilio}}
+	svc := NewService(store, logger.New("test", "test"), routes, nil, 10*time.Millisecond, twilio)
+
+	msg, err := svc.Send(context.Background(), "u1", "+14155550100", "hello")
+	assert.Error(t, err)
+	assert.Equal(t, domain.SMSStatusFailed, msg.Status)
+	assert.Equal(t, "timeout", msg.ErrorCode)
+	if messages := store.SMSMessages(); assert.Len(t, messages, 1) {
+		assert.Equal(t, domain.SMSStatusFailed, messages[0].Status)
+	}
+
+	_, err = svc.Send(context.Background(), "u1", "0415555", "hello")
+	assert.Equal(t, ierr.ErrInvalidPhoneNumber, errors.Cause(err))
+}
+
+func TestHandleTwilioStatus(t *testing.T) {
+	store := memory.NewStore()
+	assert.NoError(t, store.GetSMSMessageRepository().Create(context.Background(), domain.SMSMessage{ID: "m1", Provider: configs.SMSProviderTwilio, ProviderMessageID: "SM1", Status: domain.SMSStatusSent}))
+	twilio := NewTwilioProvider("AC1", "token", "+15005550006", "https://example.com/webhooks/sms/twilio")
+	svc := NewService(store, logger.New("test", "test"), configs.SMSRoutes{}, nil, time.Second, twilio)
+
+	form := url.Values{"MessageSid": {"SM1"}, "MessageStatus": {"undelivered"}, "ErrorCode": {"30005"}}
+	err := svc.HandleTwilioStatus(context.Background(), RequestTwilioStatus{Form: form, Signature: "invalid"})
+	assert.Equal(t, ierr.ErrInvalidWebhook, errors.Cause(err))
+
+	err = svc.HandleTwilioStatus(context.Background(), RequestTwilioStatus{Form: form, Signature: twilioSignature("token", "https://example.com/webhooks/sms/twilio", form)})
+	assert.NoError(t, err)
+	if messages := store.SMSMessages(); assert.Len(t, messages, 1) {
+		assert.Equal(t, domain.SMSStatusUndelivered, messages[0].Status)
+		assert.Equal(t, "30005", messages[0].ErrorCode)
+	}
+
+	// the intermediate statuses and the unknown messages are ignored
+	form = url.Values{"MessageSid": {"SM2"}, "MessageStatus": {"delivered"}}
+	assert.NoError(t, svc.HandleTwilioStatus(context.Background(), RequestTwilioStatus{Form: form, Signature: twilioSignature("token", "https://example.com/webhooks/sms/twilio", form)}))
+}
+
+func TestSegments(t *testing.T) {
+	assert.Equal(t, 1, Segments("hello"))
+	assert.Equal(t, 1, Segments(strings.Repeat("a", 160)))
+	assert.Equal(t, 2, Segments(strings.Repeat("a", 161)))
+	assert.Equal(t, 1, Segments("héllo wörld"))
+	assert.Equal(t, 1, Segments(strings.Repeat("{", 80)))
+	assert.Equal(t, 2, Segments(strings.Repeat("{", 81)))
+	assert.Equal(t, 1, Segments(strings.Repeat("世", 70)))
+	assert.Equal(t, 2, Segments(strings.Repeat("世", 71)))
+}
+
+func TestTwilioSend(t *testing.T) {
+	var form url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		assert.Equal(t, "AC1", user)
+		assert.Equal(t, "token", pass)
+		assert.Equal(t, "/Accounts/AC1/Messages.json", r.URL.Path)
+		assert.NoError(t, r.ParseForm())
+		form = r.PostForm
+		if form.Get("To") == "+15005550001" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":21211,"message":"invalid To number"}`))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"sid":"SM1","status":"queued"}`))
+	}))
+	defer srv.Close()
+
+	twilio := NewTwilioProvider("AC1", "token", "MG1", "https://example.com/webhooks/sms/twilio")
+	twilio.baseURL = srv.URL
+
+	id, err := twilio.Send(context.Background(), "+14155550100", "hello")
+	assert.NoError(t, err)
+	assert.Equal(t, "SM1", id)
+	assert.Equal(t, "MG1", form.Get("MessagingServiceSid"))
+	assert.Equal(t, "https://example.com/webhooks/sms/twilio", form.Get("StatusCallback"))
+
+	_, err = twilio.Send(context.Background(), "+15005550001", "hello")
+	var providerErr ProviderError
+	if assert.True(t, errors.As(err, &providerErr)) {
+		assert.Equal(t, "21211", providerErr.Code)
+	}
+}
+
+func twilioSignature(authToken string, callbackURL string, form url.Values) string {
+	keys := make([]string, 0, len(form))
+	for key := range form {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	data := callbackURL
+	for _, key := range keys {
+		data += key + form.Get(key)
+	}
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(data))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,72 @@
+// Package sms sends the text messages through the SMS providers, routed by the calling code of the recipient
+// and failing over to the next provider of the route when a provider fails or times out.
+package sms
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+)
+
+// Provider sends the text messages through an SMS provider
+type Provider interface {
+	// Name returns the name of the provider, as used in the routes
+	Name() string
+	// Send sends the body to the E.164 phone number and returns the id of the message at the provider
+	Send(ctx context.Context, to string, body string) (string, error)
+}
+
+// ProviderError is the failure of a provider with its error code
+type ProviderError struct {
+	Code    string
+	Message string
+}
+
+func (e ProviderError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// gsm7 lists the characters of the GSM 03.38 basic character set, and gsm7Extended the ones taking two septets
+const (
+	gsm7 = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+		"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+	gsm7Extended = "^{}\\[~]|€\f"
+)
+
+// Segments returns the number of segments the body is billed for: 160 characters or 153 per segment
+// of a concatenated message in the GSM 7-bit alphabet, 70 or 67 otherwise.
+func Segments(body string) int {
+	septets, unicode := 0, false
+	for _, r := range body {
+		switch {
+		case strings.ContainsRune(gsm7, r):
+			septets++
+		case strings.ContainsRune(gsm7Extended, r):
+			septets += 2
+		default:
+			unicode = true
+		}
+	}
+
+	single, multi, length := 160, 153, septets
+	if unicode {
+		single, multi, length = 70, 67, utf16Length(body)
+	}
+	if length <= single {
+		return 1
+	}
+	return (length + multi - 1) / multi
+}
+
+// utf16Length returns the number of UCS-2 code units of the body
+func utf16Length(body string) int {
+	length := 0
+	for _, r := range body {
+		if r >= 0x10000 && r <= utf8.MaxRune {
+			length += 2
+		} else {
+			length++
+		}
+	}
+	return length
+}
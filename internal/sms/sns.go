@@ -0,0 +1,73 @@
+package sms
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/pkg/otel"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sns/types"
+	"github.com/aws/smithy-go"
+	"github.com/pkg/errors"
+)
+
+// SNSClient is the part of the SNS API used by the provider
+type SNSClient interface {
+	Publish(ctx context.Context, params *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error)
+}
+
+// SNSProvider sends the text messages through AWS SNS as transactional messages. SNS reports the
+// delivery status to CloudWatch Logs only, so the messages sent through SNS remain sent.
+type SNSProvider struct {
+	client   SNSClient
+	senderID string
+}
+
+// NewSNSClient creates a SNS client from the default AWS configuration chain
+func NewSNSClient(ctx context.Context, region string) (*sns.Client, error) {
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+	if err != nil {
+		return nil, err
+	}
+	return sns.NewFromConfig(cfg), nil
+}
+
+// NewSNSProvider creates a new SNS provider, the sender id is optional and not supported by every country
+func NewSNSProvider(client SNSClient, senderID string) *SNSProvider {
+	return &SNSProvider{client, senderID}
+}
+
+// Name returns the name of the provider, as used in the routes
+func (p *SNSProvider) Name() string {
+	return configs.SMSProviderSNS
+}
+
+// Send sends the body to the E.164 phone number and returns the id of the message
+func (p *SNSProvider) Send(ctx context.Context, to string, body string) (string, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	attributes := map[string]types.MessageAttributeValue{
+		"AWS.SNS.SMS.SMSType": {DataType: aws.String("String"), StringValue: aws.String("Transactional")},
+	}
+	if p.senderID != "" {
+		attributes["AWS.SNS.SMS.SenderID"] = types.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(p.senderID)}
+	}
+
+	out, err := p.client.Publish(ctx, &sns.PublishInput{
+		PhoneNumber:       aws.String(to),
+		Message:           aws.String(body),
+		MessageAttributes: attributes,
+	})
+	if err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) {
+			return "", ProviderError{apiErr.ErrorCode(), apiErr.ErrorMessage()}
+		}
+		return "", errors.Wrap(err, "cannot publish sns message")
+	}
+	return aws.ToString(out.MessageId), nil
+}
@@ -0,0 +1,119 @@
+package sms
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"go-hex/configs"
+	"go-hex/pkg/otel"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// twilioBaseURL is the base url of the Twilio REST API
+const twilioBaseURL = "https://api.twilio.com/2010-04-01"
+
+// twilioMessage is the message resource of Twilio, see https://www.twilio.com/docs/sms/api/message-resource
+type twilioMessage struct {
+	SID          string `json:"sid"`
+	Status       string `json:"status"`
+	Code         int    `json:"code"` // error code of a failed request
+	Message      string `json:"message"`
+	ErrorCode    *int   `json:"error_code"`
+	ErrorMessage string `json:"error_message"`
+}
+
+// TwilioProvider sends the text messages through Twilio, from a phone number or a messaging service,
+// and asks Twilio to post their delivery status to the status callback url.
+type TwilioProvider struct {
+	baseURL        string
+	accountSID     string
+	authToken      string
+	from           string
+	statusCallback string
+	client         *http.Client
+}
+
+// NewTwilioProvider creates a new Twilio provider
+func NewTwilioProvider(accountSID, authToken, from, statusCallback string) *TwilioProvider {
+	return &TwilioProvider{twilioBaseURL, accountSID, authToken, from, statusCallback, &http.Client{}}
+}
+
+// Name returns the name of the provider, as used in the routes
+func (p *TwilioProvider) Name() string {
+	return configs.SMSProviderTwilio
+}
+
+// Send sends the body to the E.164 phone number and returns the sid of the message
+func (p *TwilioProvider) Send(ctx context.Context, to string, body string) (string, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	form := url.Values{"To": {to}, "Body": {body}}
+	if strings.HasPrefix(p.from, "MG") {
+		form.Set("MessagingServiceSid", p.from)
+	} else {
+		form.Set("From", p.from)
+	}
+	if p.statusCallback != "" {
+		form.Set("StatusCallback", p.statusCallback)
+	}
+
+	u := p.baseURL + "/Accounts/" + url.PathEscape(p.accountSID) + "/Messages.json"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", errors.Wrap(err, "cannot create twilio request")
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(p.accountSID, p.authToken)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", errors.Wrap(err, "cannot send twilio message")
+	}
+	defer resp.Body.Close()
+
+	var msg twilioMessage
+	if err := json.NewDecoder(resp.Body).Decode(&msg); err != nil {
+		return "", errors.Wrapf(err, "cannot decode twilio response of status %d", resp.StatusCode)
+	}
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return "", ProviderError{strconv.Itoa(msg.Code), msg.Message}
+	}
+	if msg.ErrorCode != nil {
+		return "", ProviderError{strconv.Itoa(*msg.ErrorCode), msg.ErrorMessage}
+	}
+	return msg.SID, nil
+}
+
+// VerifyCallback checks the X-Twilio-Signature of a status callback posted to the status callback url,
+// see https://www.twilio.com/docs/usage/security#validating-requests
+func (p *TwilioProvider) VerifyCallback(form url.Values, signature string) bool {
+	keys := make([]string, 0, len(form))
+	for key := range form {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(p.statusCallback)
+	for _, key := range keys {
+		for _, value := range form[key] {
+			b.WriteString(key)
+			b.WriteString(value)
+		}
+	}
+
+	mac := hmac.New(sha1.New, []byte(p.authToken))
+	mac.Write([]byte(b.String()))
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	return p.statusCallback != "" && hmac.Equal([]byte(expected), []byte(signature))
+}
@@ -0,0 +1,170 @@
+package tenant
+
+import (
+	"go-hex/configs"
+	"go-hex/middleware"
+	"go-hex/shared/ierr"
+	"go-hex/shared/response"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+// RegisterAPI registers a new tenant settings api
+func RegisterAPI(r echo.Group, cfg *configs.Config, service ServicePort) {
+	handler := handler{cfg, service}
+
+	// Public endpoints, read by the hosted pages
+	r.GET("/branding", handler.branding)
+
+	// Internal endpoints, also reachable by the service accounts holding the admin scope
+	internal := r.Group("/internal/tenants",
+		middleware.InternalAPIOrRole(cfg.InternalAPI.User, cfg.InternalAPI.Password, cfg.JWTKeys(), ScopeAdmin),
+	)
+	internal.GET("", handler.list)
+	internal.GET("/:id/settings", handler.get)
+	internal.PUT("/:id/settings", handler.put)
+	internal.DELETE("/:id/settings", handler.delete)
+}
+
+type handler struct {
+	cfg     *configs.Config
+	service ServicePort
+}
+
+// list godoc
+// @Router /internal/tenants [get]
+// @Tags Tenants
+// @Summary List the tenant settings
+// @Description List the settings of the tenants overriding the configuration, ordered by tenant
+// @Produce json
+// @Security BasicAuth
+// @Security BearerToken
+// @Param limit query int false "page size, 100 by default and 1000 at most"
+// @Param offset query int false "offset of the page"
+// @Success 200 {object} response.Response{data=[]domain.TenantSettings} "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 403 {object} response.ErrorResponse403
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) list(c echo.Context) error {
+	var req RequestListTenants
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.List(c.Request().Context(), req)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessOK(c, response.Page{Items: res.Settings, Offset: res.Offset, Limit: res.Limit, More: res.More})
+}
+
+// get godoc
+// @Router /internal/tenants/{id}/settings [get]
+// @Tags Tenants
+// @Summary Get the settings of a tenant
+// @Description Get the settings overridden for a tenant and the effective value of every setting
+// @Produce json
+// @Security BasicAuth
+// @Security BearerToken
+// @Param id path string true "tenant id"
+// @Success 200 {object} response.Response{data=ResponseSettings} "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 403 {object} response.ErrorResponse403
+// @failure 404 {object} response.ErrorResponse404
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) get(c echo.Context) error {
+	var req RequestTenant
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.Get(c.Request().Context(), req)
+	if err != nil {
+		return settingsError(err)
+	}
+
+	return response.SuccessOK(c, res)
+}
+
+// put godoc
+// @Router /internal/tenants/{id}/settings [put]
+// @Tags Tenants
+// @Summary Replace the settings of a tenant
+// @Description Replace the settings overridden for a tenant, the settings left out inherit the configured value. The change is recorded in the audit trail.
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Security BearerToken
+// @Param id path string true "tenant id"
+// @Param body body RequestPutSettings true "settings of the tenant"
+// @Success 200 {object} response.Response{data=ResponseSettings} "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 403 {object} response.ErrorResponse403
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) put(c echo.Context) error {
+	var req RequestPutSettings
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.Put(c.Request().Context(), req)
+	if err != nil {
+		return settingsError(err)
+	}
+
+	return response.SuccessOK(c, res)
+}
+
+// delete godoc
+// @Router /internal/tenants/{id}/settings [delete]
+// @Tags Tenants
+// @Summary Delete the settings of a tenant
+// @Description Delete the settings overridden for a tenant, its requests use the configuration as is. The change is recorded in the audit trail.
+// @Produce json
+// @Security BasicAuth
+// @Security BearerToken
+// @Param id path string true "tenant id"
+// @Success 200 {object} response.Response "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 403 {object} response.ErrorResponse403
+// @failure 404 {object} response.ErrorResponse404
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) delete(c echo.Context) error {
+	var req RequestTenant
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	err := h.service.Delete(c.Request().Context(), req)
+	if err != nil {
+		return settingsError(err)
+	}
+
+	return response.SuccessOK(c, nil, "tenant settings deleted")
+}
+
+// branding godoc
+// @Router /branding [get]
+// @Tags Tenants
+// @Summary Get the branding
+// @Description Get the product name, logo, colors and support address of the tenant of the request, or the configured ones without tenant, for the hosted verification, consent and device login pages to render them
+// @Produce json
+// @Success 200 {object} response.Response{data=ResponseBranding} "Success"
+// @failure 400 {object} response.ErrorResponse400
+func (h handler) branding(c echo.Context) error {
+	return response.SuccessOK(c, h.service.Branding(c.Request().Context()))
+}
+
+// settingsError answers the errors of the settings of a tenant
+func settingsError(err error) error {
+	if errors.Cause(err) == ierr.ErrResourceNotFound {
+		return response.ErrNotFound(err)
+	}
+	return err
+}
@@ -0,0 +1,18 @@
+package tenant
+
+import "regexp"
+
+const (
+	// ScopeAdmin is the role a service account needs to manage the settings of the tenants
+	ScopeAdmin = "tenants:admin"
+
+	defaultLimit = 100
+	maxLimit     = 1000
+
+	// maxCachedTenants bounds the effective configurations cached by the resolver, the tenant header being
+	// chosen by the clients when the gateway does not set it
+	maxCachedTenants = 10000
+)
+
+// tenantID matches the IDs of the tenants
+var tenantID = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,99}$`)
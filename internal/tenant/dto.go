@@ -0,0 +1,133 @@
+package tenant
+
+import (
+	"errors"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"net/mail"
+	"net/url"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+var tenantIDRules = []validation.Rule{validation.Required, validation.Match(tenantID)}
+
+// RequestTenant request params
+type RequestTenant struct {
+	TenantID string `json:"-" param:"id"`
+}
+
+func (r *RequestTenant) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.TenantID, tenantIDRules...),
+	)
+}
+
+// RequestListTenants request params
+type RequestListTenants struct {
+	Limit  int `json:"-" query:"limit" example:"100"`
+	Offset int `json:"-" query:"offset" example:"0"`
+}
+
+func (r *RequestListTenants) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Limit, validation.Min(0), validation.Max(maxLimit)),
+		validation.Field(&r.Offset, validation.Min(0)),
+	)
+}
+
+// RequestPutSettings request body, the settings left out inherit the configured value
+type RequestPutSettings struct {
+	TenantID               string  `json:"-" param:"id"`
+	TokenExpiration        *int    `json:"token_expiration" example:"15"`          // in minutes
+	RefreshTokenExpiration *int    `json:"refresh_token_expiration" example:"0"`   // in minutes, 0 keeps the refresh tokens valid until rotated
+	LoginApprovalRequired  *bool   `json:"login_approval_required" example:"true"` // enforces the approval of the logins from a new device
+	PasswordMinLength      *int    `json:"password_min_length" example:"12"`
+	PasswordMinClasses     *int    `json:"password_min_classes" example:"3"` // among lowercase, uppercase, digits and symbols
+	AppName                *string `json:"app_name" example:"Acme"`          // named in the messages sent to the users
+	LogoURL                *string `json:"logo_url" example:"https://cdn.example.com/acme.png"`
+	PrimaryColor           *string `json:"primary_color" example:"#1a73e8"`
+	AccentColor            *string `json:"accent_color" example:"#fbbc04"`
+	SupportEmail           *string `json:"support_email" example:"support@acme.example.com"` // named in the footer of the emails
+	UpdatedBy              string  `json:"updated_by" example:"jane.doe@example.com"`        // admin updating the settings
+}
+
+func (r *RequestPutSettings) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.TenantID, tenantIDRules...),
+		validation.Field(&r.TokenExpiration, validation.NilOrNotEmpty, validation.Min(1)),
+		validation.Field(&r.RefreshTokenExpiration, validation.Min(0)),
+		validation.Field(&r.PasswordMinLength, validation.NilOrNotEmpty, validation.Min(1), validation.Max(128)),
+		validation.Field(&r.PasswordMinClasses, validation.Min(0), validation.Max(4)),
+		validation.Field(&r.AppName, validation.NilOrNotEmpty, validation.Length(1, 100)),
+		validation.Field(&r.LogoURL, validation.NilOrNotEmpty, validation.Length(1, 2048), validation.By(isHTTPSURL)),
+		validation.Field(&r.PrimaryColor, validation.NilOrNotEmpty, validation.Match(configs.BrandColor)),
+		validation.Field(&r.AccentColor, validation.NilOrNotEmpty, validation.Match(configs.BrandColor)),
+		validation.Field(&r.SupportEmail, validation.NilOrNotEmpty, validation.Length(1, 255), validation.By(isEmail)),
+		validation.Field(&r.UpdatedBy, validation.Required, validation.Length(1, 100)),
+	)
+}
+
+func isHTTPSURL(value interface{}) error {
+	value, _ = validation.Indirect(value)
+	text, _ := value.(string)
+	if text == "" {
+		return nil
+	}
+	u, err := url.Parse(text)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return errors.New("must be an absolute https url")
+	}
+	return nil
+}
+
+func isEmail(value interface{}) error {
+	value, _ = validation.Indirect(value)
+	email, _ := value.(string)
+	if email == "" {
+		return nil
+	}
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email {
+		return errors.New("must be a valid email address")
+	}
+	return nil
+}
+
+// ResponseSettings is the settings of a tenant and the configuration they result in
+type ResponseSettings struct {
+	Overrides domain.TenantSettings `json:"overrides"`
+	Effective EffectiveSettings     `json:"effective"`
+}
+
+// EffectiveSettings is the value applying to the requests of a tenant for every setting, overridden or inherited
+type EffectiveSettings struct {
+	TokenExpiration        int    `json:"token_expiration" example:"15"`
+	RefreshTokenExpiration int    `json:"refresh_token_expiration" example:"43200"`
+	LoginApprovalRequired  bool   `json:"login_approval_required" example:"true"`
+	PasswordMinLength      int    `json:"password_min_length" example:"12"`
+	PasswordMinClasses     int    `json:"password_min_classes" example:"3"`
+	AppName                string `json:"app_name" example:"Acme"`
+	LogoURL                string `json:"logo_url" example:"https://cdn.example.com/acme.png"`
+	PrimaryColor           string `json:"primary_color" example:"#1a73e8"`
+	AccentColor            string `json:"accent_color" example:"#fbbc04"`
+	SupportEmail           string `json:"support_email" example:"support@acme.example.com"`
+}
+
+// ResponseBranding is the branding of the hosted pages, of the tenant of the request
+type ResponseBranding struct {
+	ProductName  string `json:"product_name" example:"Acme"`
+	LogoURL      string `json:"logo_url" example:"https://cdn.example.com/acme.png"`
+	PrimaryColor string `json:"primary_color" example:"#1a73e8"`
+	AccentColor  string `json:"accent_color" example:"#fbbc04"`
+	SupportEmail string `json:"support_email" example:"support@acme.example.com"`
+}
+
+// ResponseTenants is a page of the settings of the tenants
+type ResponseTenants struct {
+	Settings []domain.TenantSettings
+	Offset   int
+	Limit    int
+	// More tells whether other tenants follow
+	More bool
+}
@@ -0,0 +1,19 @@
+package tenant
+
+import (
+	"context"
+)
+
+// ServicePort encapsulates the tenant settings logic.
+type ServicePort interface {
+	// List returns the settings of the tenants, ordered by tenant
+	List(ctx context.Context, req RequestListTenants) (ResponseTenants, error)
+	// Get returns the settings of a tenant and the configuration they result in
+	Get(ctx context.Context, req RequestTenant) (ResponseSettings, error)
+	// Put replaces the settings of a tenant
+	Put(ctx context.Context, req RequestPutSettings) (ResponseSettings, error)
+	// Branding returns the branding of the tenant of the request
+	Branding(ctx context.Context) ResponseBranding
+	// Delete deletes the settings of a tenant, its requests use the configuration as is
+	Delete(ctx context.Context, req RequestTenant) error
+}
@@ -0,0 +1,136 @@
+package tenant
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/times"
+	"go-hex/shared/ctxutil"
+	"go-hex/shared/ierr"
+	"go-hex/shared/response"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+// Resolver resolves the effective configuration of the tenants, the configuration overridden by their settings.
+// It is cached for ttl, the changes of the instance invalidating it at once and the changes of the other
+// instances being seen once it expires. A tenant without settings uses the configuration as is.
+// The configuration overridden is the one of the request when it carries one, as reloaded, cfg otherwise.
+type Resolver struct {
+	cfg         *configs.Config
+	repoRegitry port.RepositoryRegistry
+	log         logger.Logger
+	ttl         time.Duration
+
+	mu      sync.Mutex
+	entries map[string]resolved
+}
+
+// resolved is the effective configuration of a tenant, cached until expiresAt or until base is reloaded
+type resolved struct {
+	cfg       *configs.Config
+	base      *configs.Config
+	expiresAt time.Time
+}
+
+// NewResolver creates a resolver of the effective configuration of the tenants, cached for ttl, 0 disables the cache
+func NewResolver(cfg *configs.Config, repoRegitry port.RepositoryRegistry, log logger.Logger, ttl time.Duration) *Resolver {
+	return &Resolver{cfg: cfg, repoRegitry: repoRegitry, log: log, ttl: ttl, entries: map[string]resolved{}}
+}
+
+// Subscribe invalidates the effective configuration of the tenants whose settings change
+func (r *Resolver) Subscribe(events event.Bus) {
+	events.Subscribe(domain.EventTenantSettingsUpdated, r.Handle)
+	events.Subscribe(domain.EventTenantSettingsDeleted, r.Handle)
+}
+
+// Handle invalidates the effective configuration of the subject tenant of the event
+func (r *Resolver) Handle(ctx context.Context, e event.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.entries, e.SubjectID)
+}
+
+// Resolve returns the effective configuration of the tenant. The settings cannot be read, the expired
+// configuration of the tenant is used when cached, so that the tenant keeps its enforced settings.
+func (r *Resolver) Resolve(ctx context.Context, id string) (*configs.Config, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	now := times.Now()
+	base := configs.FromContext(ctx, r.cfg)
+	r.mu.Lock()
+	entry, ok := r.entries[id]
+	r.mu.Unlock()
+	if ok && entry.base == base && now.Before(entry.expiresAt) {
+		return entry.cfg, nil
+	}
+
+	settings, err := r.repoRegitry.GetTenantSettingsRepository().Get(ctx, id)
+	switch {
+	case errors.Cause(err) == ierr.ErrResourceNotFound:
+		settings = domain.TenantSettings{TenantID: id}
+	case err != nil && ok:
+		r.log.With(ctx).WithParams(logger.Params{"tenant_id": id}).Error(errors.Wrap(err, "cannot read tenant settings, using the expired ones"))
+		return entry.cfg, nil
+	case err != nil:
+		return nil, err
+	}
+
+	cfg := apply(base, settings)
+	if r.ttl > 0 {
+		r.put(id, resolved{cfg, base, now.Add(r.ttl)}, now)
+	}
+	return cfg, nil
+}
+
+// put caches the effective configuration of a tenant, unless maxCachedTenants are cached and none expired
+func (r *Resolver) put(id string, entry resolved, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.entries[id]; !ok && len(r.entries) >= maxCachedTenants {
+		for cached, e := range r.entries {
+			if !now.Before(e.expiresAt) {
+				delete(r.entries, cached)
+			}
+		}
+		if len(r.entries) >= maxCachedTenants {
+			return
+		}
+	}
+	r.entries[id] = entry
+}
+
+// Middleware carries the effective configuration of the tenant named by the header in the context of the
+// request, read with configs.FromContext. The requests without the header use the configuration as is.
+func (r *Resolver) Middleware(header string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			id := c.Request().Header.Get(header)
+			if header == "" || id == "" {
+				return next(c)
+			}
+			if !tenantID.MatchString(id) {
+				return response.HTTPError(errors.Errorf("invalid tenant %q", id), http.StatusBadRequest, ierr.ErrBadRequest.Code, "invalid tenant header")
+			}
+
+			cfg, err := r.Resolve(c.Request().Context(), id)
+			if err != nil {
+				return err
+			}
+			ctx := ctxutil.WithTenant(c.Request().Context(), id)
+			c.SetRequest(c.Request().WithContext(configs.WithContext(ctx, cfg)))
+			return next(c)
+		}
+	}
+}
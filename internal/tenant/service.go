@@ -0,0 +1,257 @@
+package tenant
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/times"
+	"go-hex/shared/ierr"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Service manages the settings of the tenants, which override the configuration for their requests.
+// Every change is published with the settings it changed, from and to their previous value, for the audit trail.
+type Service struct {
+	cfg         *configs.Config
+	repoRegitry port.RepositoryRegistry
+	log         logger.Logger
+	events      event.Bus
+}
+
+// NewService creates and returns a new tenant settings service
+func NewService(cfg *configs.Config, repoRegitry port.RepositoryRegistry, log logger.Logger, events event.Bus) *Service {
+	return &Service{cfg, repoRegitry, log, events}
+}
+
+// List returns the settings of the tenants, ordered by tenant
+func (s *Service) List(ctx context.Context, req RequestListTenants) (ResponseTenants, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return ResponseTenants{}, err
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultLimit
+	}
+
+	// one more tenant is read to know whether another page follows
+	settings, err := s.repoRegitry.GetTenantSettingsRepository().List(ctx, req.Limit+1, req.Offset)
+	if err != nil {
+		return ResponseTenants{}, err
+	}
+
+	res := ResponseTenants{Settings: settings, Offset: req.Offset, Limit: req.Limit}
+	if len(settings) > req.Limit {
+		res.Settings = settings[:req.Limit]
+		res.More = true
+	}
+	return res, nil
+}
+
+// Get returns the settings of a tenant and the configuration they result in
+func (s *Service) Get(ctx context.Context, req RequestTenant) (ResponseSettings, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return ResponseSettings{}, err
+	}
+
+	settings, err := s.repoRegitry.GetTenantSettingsRepository().Get(ctx, req.TenantID)
+	if err != nil {
+		return ResponseSettings{}, err
+	}
+	return s.response(settings), nil
+}
+
+// Put replaces the settings of a tenant, the settings left out inherit the configured value
+func (s *Service) Put(ctx context.Context, req RequestPutSettings) (ResponseSettings, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return ResponseSettings{}, err
+	}
+
+	now := times.Now()
+	settings := domain.TenantSettings{
+		TenantID:               req.TenantID,
+		TokenExpiration:        req.TokenExpiration,
+		RefreshTokenExpiration: req.RefreshTokenExpiration,
+		LoginApprovalRequired:  req.LoginApprovalRequired,
+		PasswordMinLength:      req.PasswordMinLength,
+		PasswordMinClasses:     req.PasswordMinClasses,
+		AppName:                req.AppName,
+		LogoURL:                req.LogoURL,
+		PrimaryColor:           req.PrimaryColor,
+		AccentColor:            req.AccentColor,
+		SupportEmail:           req.SupportEmail,
+		UpdatedBy:              req.UpdatedBy,
+		CreatedAt:              now,
+		UpdatedAt:              now,
+	}
+
+	repo := s.repoRegitry.GetTenantSettingsRepository()
+	previous, err := repo.Get(ctx, req.TenantID)
+	switch {
+	case err == nil:
+		settings.CreatedAt = previous.CreatedAt
+	case errors.Cause(err) != ierr.ErrResourceNotFound:
+		return ResponseSettings{}, err
+	}
+
+	err = repo.Upsert(ctx, settings)
+	if err != nil {
+		return ResponseSettings{}, err
+	}
+
+	s.publish(ctx, domain.EventTenantSettingsUpdated, settings.TenantID, req.UpdatedBy, changes(previous, settings))
+	return s.response(settings), nil
+}
+
+// Branding returns the branding of the tenant of the request, the configured one without tenant
+func (s *Service) Branding(ctx context.Context) ResponseBranding {
+	cfg := configs.FromContext(ctx, s.cfg)
+	return ResponseBranding{
+		ProductName:  cfg.Server.NAME,
+		LogoURL:      cfg.Branding.LogoURL,
+		PrimaryColor: cfg.Branding.PrimaryColor,
+		AccentColor:  cfg.Branding.AccentColor,
+		SupportEmail: cfg.Branding.SupportEmail,
+	}
+}
+
+// Delete deletes the settings of a tenant, its requests use the configuration as is
+func (s *Service) Delete(ctx context.Context, req RequestTenant) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return err
+	}
+
+	repo := s.repoRegitry.GetTenantSettingsRepository()
+	previous, err := repo.Get(ctx, req.TenantID)
+	if err != nil {
+		return err
+	}
+	err = repo.Delete(ctx, req.TenantID)
+	if err != nil {
+		return err
+	}
+
+	s.publish(ctx, domain.EventTenantSettingsDeleted, req.TenantID, "", changes(previous, domain.TenantSettings{}))
+	return nil
+}
+
+func (s *Service) response(settings domain.TenantSettings) ResponseSettings {
+	cfg := apply(s.cfg, settings)
+	return ResponseSettings{
+		Overrides: settings,
+		Effective: EffectiveSettings{
+			TokenExpiration:        cfg.JWT.TokenExpiration,
+			RefreshTokenExpiration: cfg.JWT.RefreshTokenExpiration,
+			LoginApprovalRequired:  cfg.LoginApproval.Enabled,
+			PasswordMinLength:      cfg.PasswordPolicy.MinLength,
+			PasswordMinClasses:     cfg.PasswordPolicy.MinClasses,
+			AppName:                cfg.Server.NAME,
+			LogoURL:                cfg.Branding.LogoURL,
+			PrimaryColor:           cfg.Branding.PrimaryColor,
+			AccentColor:            cfg.Branding.AccentColor,
+			SupportEmail:           cfg.Branding.SupportEmail,
+		},
+	}
+}
+
+// publish publishes a change of the settings of a tenant, the actor being the principal of the request
+func (s *Service) publish(ctx context.Context, name string, id string, updatedBy string, changed map[string]interface{}) {
+	s.log.With(ctx).WithParams(logger.Params{"type": "tenant_settings", "event": name, "tenant_id": id, "updated_by": updatedBy}).Info("tenant settings changed")
+
+	attributes := map[string]interface{}{"changes": changed}
+	if updatedBy != "" {
+		attributes["updated_by"] = updatedBy
+	}
+	s.events.Publish(ctx, event.Event{
+		Name:       name,
+		SubjectID:  id,
+		Attributes: attributes,
+	})
+}
+
+// apply returns a copy of the configuration overridden by the settings of a tenant
+func apply(cfg *configs.Config, settings domain.TenantSettings) *configs.Config {
+	effective := *cfg
+	if settings.TokenExpiration != nil {
+		effective.JWT.TokenExpiration = *settings.TokenExpiration
+	}
+	if settings.RefreshTokenExpiration != nil {
+		effective.JWT.RefreshTokenExpiration = *settings.RefreshTokenExpiration
+	}
+	if settings.LoginApprovalRequired != nil {
+		effective.LoginApproval.Enabled = *settings.LoginApprovalRequired
+	}
+	if settings.PasswordMinLength != nil {
+		effective.PasswordPolicy.MinLength = *settings.PasswordMinLength
+	}
+	if settings.PasswordMinClasses != nil {
+		effective.PasswordPolicy.MinClasses = *settings.PasswordMinClasses
+	}
+	if settings.AppName != nil {
+		effective.Server.NAME = *settings.AppName
+	}
+	if settings.LogoURL != nil {
+		effective.Branding.LogoURL = *settings.LogoURL
+	}
+	if settings.PrimaryColor != nil {
+		effective.Branding.PrimaryColor = *settings.PrimaryColor
+	}
+	if settings.AccentColor != nil {
+		effective.Branding.AccentColor = *settings.AccentColor
+	}
+	if settings.SupportEmail != nil {
+		effective.Branding.SupportEmail = *settings.SupportEmail
+	}
+	return &effective
+}
+
+// changes returns the settings differing between previous and next, by their JSON name, with their value in
+// each, nil when inherited
+func changes(previous, next domain.TenantSettings) map[string]interface{} {
+	res := map[string]interface{}{}
+	p, n := reflect.ValueOf(previous), reflect.ValueOf(next)
+	for i := 0; i < p.NumField(); i++ {
+		if p.Field(i).Kind() != reflect.Ptr {
+			continue
+		}
+		from, to := setting(p.Field(i)), setting(n.Field(i))
+		if reflect.DeepEqual(from, to) {
+			continue
+		}
+		name := strings.Split(p.Type().Field(i).Tag.Get("json"), ",")[0]
+		res[name] = map[string]interface{}{"from": from, "to": to}
+	}
+	return res
+}
+
+func setting(v reflect.Value) interface{} {
+	if v.IsNil() {
+		return nil
+	}
+	return v.Elem().Interface()
+}
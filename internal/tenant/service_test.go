@@ -0,0 +1,243 @@
+package tenant
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/memory"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/shared/ierr"
+	"go-hex/shared/response"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countGets returns a hook counting the reads of the tenant settings
+func countGets(gets *int) memory.Hook {
+	return func(ctx context.Context, op string) error {
+		if op == "TenantSettingsRepository.Get" {
+			*gets++
+		}
+		return nil
+	}
+}
+
+func testConfig() *configs.Config {
+	cfg := &configs.Config{}
+	cfg.Server.NAME = "go-hex"
+	cfg.JWT.TokenExpiration = 15
+	cfg.JWT.RefreshTokenExpiration = 43200
+	cfg.PasswordPolicy.MinLength = 8
+	cfg.PasswordPolicy.MinClasses = 1
+	cfg.Branding.PrimaryColor = "#1a73e8"
+	return cfg
+}
+
+func intPtr(i int) *int { return &i }
+
+func boolPtr(b bool) *bool { return &b }
+
+func strPtr(s string) *string { return &s }
+
+func TestServicePut(t *testing.T) {
+	store := memory.NewStore()
+	bus := event.New()
+	published := []event.Event{}
+	bus.Subscribe(domain.EventTenantSettingsUpdated, func(ctx context.Context, e event.Event) { published = append(published, e) })
+	bus.Subscribe(domain.EventTenantSettingsDeleted, func(ctx context.Context, e event.Event) { published = append(published, e) })
+	service := NewService(testConfig(), store, logger.New("test", "test"), bus)
+
+	res, err := service.Put(context.Background(), RequestPutSettings{
+		TenantID:              "acme",
+		TokenExpiration:       intPtr(5),
+		LoginApprovalRequired: boolPtr(true),
+		UpdatedBy:             "jane.doe@example.com",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, EffectiveSettings{
+		TokenExpiration:        5,
+		RefreshTokenExpiration: 43200,
+		LoginApprovalRequired:  true,
+		PasswordMinLength:      8,
+		PasswordMinClasses:     1,
+		AppName:                "go-hex",
+		PrimaryColor:           "#1a73e8",
+	}, res.Effective)
+
+	// the settings left out inherit the configured value again
+	_, err = service.Put(context.Background(), RequestPutSettings{TenantID: "acme", PasswordMinLength: intPtr(12), UpdatedBy: "jane.doe@example.com"})
+	require.NoError(t, err)
+	settings, err := store.GetTenantSettingsRepository().Get(context.Background(), "acme")
+	require.NoError(t, err)
+	assert.Nil(t, settings.TokenExpiration)
+
+	err = service.Delete(context.Background(), RequestTenant{TenantID: "acme"})
+	require.NoError(t, err)
+	tenants, err := store.GetTenantSettingsRepository().List(context.Background(), 0, 0)
+	require.NoError(t, err)
+	assert.Empty(t, tenants)
+
+	if assert.Len(t, published, 3) {
+		assert.Equal(t, "acme", published[0].SubjectID)
+		assert.Equal(t, map[string]interface{}{
+			"token_expiration":        map[string]interface{}{"from": nil, "to": 5},
+			"login_approval_required": map[string]interface{}{"from": nil, "to": true},
+		}, published[0].Attributes["changes"])
+		assert.Equal(t, map[string]interface{}{
+			"token_expiration":        map[string]interface{}{"from": 5, "to": nil},
+			"login_approval_required": map[string]interface{}{"from": true, "to": nil},
+			"password_min_length":     map[string]interface{}{"from": nil, "to": 12},
+		}, published[1].Attributes["changes"])
+		assert.Equal(t, domain.EventTenantSettingsDeleted, published[2].Name)
+		assert.Equal(t, map[string]interface{}{
+			"password_min_length": map[string]interface{}{"from": 12, "to": nil},
+		}, published[2].Attributes["changes"])
+	}
+
+	err = service.Delete(context.Background(), RequestTenant{TenantID: "acme"})
+	assert.Equal(t, ierr.ErrResourceNotFound, errors.Cause(err))
+}
+
+func TestServiceBranding(t *testing.T) {
+	cfg := testConfig()
+	service := NewService(cfg, memory.NewStore(), logger.New("test", "test"), event.New())
+
+	res, err := service.Put(context.Background(), RequestPutSettings{
+		TenantID:     "acme",
+		AppName:      strPtr("Acme"),
+		LogoURL:      strPtr("https://cdn.example.com/acme.png"),
+		SupportEmail: strPtr("support@acme.example.com"),
+		UpdatedBy:    "jane.doe@example.com",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "https://cdn.example.com/acme.png", res.Effective.LogoURL)
+
+	assert.Equal(t, ResponseBranding{ProductName: "go-hex", PrimaryColor: "#1a73e8"}, service.Branding(context.Background()))
+
+	ctx := configs.WithContext(context.Background(), apply(cfg, domain.TenantSettings{
+		AppName:      res.Overrides.AppName,
+		LogoURL:      res.Overrides.LogoURL,
+		SupportEmail: res.Overrides.SupportEmail,
+	}))
+	assert.Equal(t, ResponseBranding{
+		ProductName:  "Acme",
+		LogoURL:      "https://cdn.example.com/acme.png",
+		PrimaryColor: "#1a73e8",
+		SupportEmail: "support@acme.example.com",
+	}, service.Branding(ctx))
+}
+
+func TestServicePutValidation(t *testing.T) {
+	service := NewService(testConfig(), memory.NewStore(), logger.New("test", "test"), event.New())
+
+	tests := []struct {
+		name string
+		req  RequestPutSettings
+	}{
+		{name: "invalid tenant", req: RequestPutSettings{TenantID: "../acme", UpdatedBy: "jane"}},
+		{name: "zero token expiration", req: RequestPutSettings{TenantID: "acme", TokenExpiration: intPtr(0), UpdatedBy: "jane"}},
+		{name: "too many classes", req: RequestPutSettings{TenantID: "acme", PasswordMinClasses: intPtr(5), UpdatedBy: "jane"}},
+		{name: "missing updated_by", req: RequestPutSettings{TenantID: "acme"}},
+		{name: "named color", req: RequestPutSettings{TenantID: "acme", PrimaryColor: strPtr("blue"), UpdatedBy: "jane"}},
+		{name: "plain http logo", req: RequestPutSettings{TenantID: "acme", LogoURL: strPtr("http://cdn.example.com/acme.png"), UpdatedBy: "jane"}},
+		{name: "invalid support email", req: RequestPutSettings{TenantID: "acme", SupportEmail: strPtr("support"), UpdatedBy: "jane"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.Put(context.Background(), tt.req)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestResolver(t *testing.T) {
+	store := memory.NewStore()
+	require.NoError(t, store.GetTenantSettingsRepository().Upsert(context.Background(), domain.TenantSettings{TenantID: "acme", TokenExpiration: intPtr(5)}))
+	var gets int
+	store.SetHook(countGets(&gets))
+	cfg := testConfig()
+	bus := event.New()
+	resolver := NewResolver(cfg, store, logger.New("test", "test"), time.Minute)
+	resolver.Subscribe(bus)
+
+	effective, err := resolver.Resolve(context.Background(), "acme")
+	require.NoError(t, err)
+	assert.Equal(t, 5, effective.JWT.TokenExpiration)
+	assert.Equal(t, 15, cfg.JWT.TokenExpiration)
+
+	// a tenant without settings uses the configuration as is
+	effective, err = resolver.Resolve(context.Background(), "globex")
+	require.NoError(t, err)
+	assert.Equal(t, 15, effective.JWT.TokenExpiration)
+
+	_, _ = resolver.Resolve(context.Background(), "acme")
+	assert.Equal(t, 2, gets)
+
+	// the changes invalidate the tenant
+	require.NoError(t, store.GetTenantSettingsRepository().Upsert(context.Background(), domain.TenantSettings{TenantID: "acme", TokenExpiration: intPtr(30)}))
+	bus.Publish(context.Background(), event.Event{Name: domain.EventTenantSettingsUpdated, SubjectID: "acme"})
+	effective, err = resolver.Resolve(context.Background(), "acme")
+	require.NoError(t, err)
+	assert.Equal(t, 30, effective.JWT.TokenExpiration)
+
+	// the reloaded configuration of the request is overridden, the cached one being resolved again
+	reloaded := *cfg
+	reloaded.JWT.RefreshTokenExpiration = 60
+	effective, err = resolver.Resolve(configs.WithContext(context.Background(), &reloaded), "acme")
+	require.NoError(t, err)
+	assert.Equal(t, 30, effective.JWT.TokenExpiration)
+	assert.Equal(t, 60, effective.JWT.RefreshTokenExpiration)
+	assert.Equal(t, 4, gets)
+}
+
+func TestResolverMiddleware(t *testing.T) {
+	store := memory.NewStore()
+	require.NoError(t, store.GetTenantSettingsRepository().Upsert(context.Background(), domain.TenantSettings{TenantID: "acme", LoginApprovalRequired: boolPtr(true)}))
+	cfg := testConfig()
+	resolver := NewResolver(cfg, store, logger.New("test", "test"), time.Minute)
+
+	tests := []struct {
+		name       string
+		tenant     string
+		wantStatus int
+		wantMFA    bool
+	}{
+		{name: "without tenant", wantStatus: http.StatusOK},
+		{name: "with tenant", tenant: "acme", wantStatus: http.StatusOK, wantMFA: true},
+		{name: "invalid tenant", tenant: "acme/../globex", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.HTTPErrorHandler = func(err error, c echo.Context) {
+				if res, ok := err.(response.ErrorResponse); ok {
+					_ = c.NoContent(res.HTTPCode)
+				}
+			}
+			var mfa bool
+			e.GET("/", func(c echo.Context) error {
+				mfa = configs.FromContext(c.Request().Context(), cfg).LoginApproval.Enabled
+				return c.NoContent(http.StatusOK)
+			}, resolver.Middleware("X-Tenant-ID"))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.tenant != "" {
+				req.Header.Set("X-Tenant-ID", tt.tenant)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantMFA, mfa)
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package graphql
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/auth"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/port"
+	"go-hex/internal/signup"
+	"go-hex/middleware"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/ratelimit"
+	"go-hex/shared/ierr"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// maxComplexity bounds the number of fields resolved by a query
+const maxComplexity = 100
+
+type contextKey string
+
+const (
+	contextKeyLoader contextKey = "graphql_loader"
+	contextKeyClient contextKey = "graphql_client"
+)
+
+// client is the client of the request, the resolvers do not see the echo context
+type client struct {
+	ipAddress string
+	userAgent string
+	info      domain.ClientInfo
+}
+
+// RegisterAPI registers POST /graphql, which logs in the users holding an access token before the resolvers
+// run, the resolvers requiring a logged in user check it themselves. The login mutation takes the login rate limits
+// of the store, like the REST logins.
+func RegisterAPI(r echo.Group, cfg *configs.Config, authService auth.ServicePort, signupService signup.ServicePort, repoRegistry port.RepositoryRegistry, limits ratelimit.Store, log logger.Logger) {
+
+	server := handler.New(NewExecutableSchema(Config{Resolvers: &Resolver{cfg: cfg, limits: limits, auth: authService, signup: signupService}}))
+	server.AddTransport(transport.POST{})
+	server.Use(extension.FixedComplexityLimit(maxComplexity))
+	if !cfg.Server.ENV.IsProd() {
+		server.Use(extension.Introspection{})
+	}
+	server.AroundFields(presentErrors(log))
+
+	r.POST("/graphql", func(c echo.Context) error {
+		ctx := c.Request().Context()
+		ctx = context.WithValue(ctx, contextKeyLoader, NewUserLoader(ctx, repoRegistry.GetUserRepository()))
+		ctx = context.WithValue(ctx, contextKeyClient, client{ipAddress: c.RealIP(), userAgent: c.Request().UserAgent(), info: middleware.ClientInfo(c.Request())})
+		server.ServeHTTP(c.Response(), c.Request().WithContext(ctx))
+		return nil
+	}, middleware.MayLoggedIn(cfg.JWTKeys()))
+}
+
+// presentErrors answers the errors of the resolvers with their message and code in the extensions, like the
+// REST routes, the internal errors are logged and answered without their message
+func presentErrors(log logger.Logger) graphql.FieldMiddleware {
+	return func(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+
+		res, err := next(ctx)
+		if err == nil {
+			return res, nil
+		}
+		var gqlErr *gqlerror.Error
+		if errors.As(err, &gqlErr) {
+			return res, err
+		}
+
+		code, message := ierr.ErrInternal.Code, ierr.ErrInternal.Message
+		switch cause := errors.Cause(err).(type) {
+		case ierr.Error:
+			code, message = cause.Code, cause.Message
+		case validation.Errors, validation.Error:
+			code, message = ierr.ErrBadRequest.Code, cause.Error()
+		}
+		if strings.HasPrefix(code, "5") {
+			log.With(ctx).Error(err)
+		}
+
+		return res, &gqlerror.Error{
+			Message:    message,
+			Path:       graphql.GetPath(ctx),
+			Extensions: map[string]interface{}{"code": code},
+		}
+	}
+}
+
+// loaderFromContext returns the user loader of the request
+func loaderFromContext(ctx context.Context) *UserLoader {
+	return ctx.Value(contextKeyLoader).(*UserLoader)
+}
+
+// clientFromContext returns the client of the request
+func clientFromContext(ctx context.Context) client {
+	c, _ := ctx.Value(contextKeyClient).(client)
+	return c
+}
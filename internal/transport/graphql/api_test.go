@@ -0,0 +1,161 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"go-hex/configs"
+	"go-hex/internal/auth"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/memory"
+	"go-hex/internal/signup"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/ratelimit"
+	"go-hex/shared/ierr"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAuthService struct {
+	auth.ServicePort
+}
+
+func (s fakeAuthService) Login(ctx context.Context, req auth.RequestLogin) (auth.ResponseLogin, error) {
+	if req.Password != "password1234" {
+		return auth.ResponseLogin{}, ierr.ErrInvalidCreds
+	}
+	return auth.ResponseLogin{AccessToken: "access", RefreshToken: "refresh", ExpiresAt: req.UserAgent}, nil
+}
+
+type fakeSignupService struct {
+	signup.ServicePort
+}
+
+// Signup answers like the service in strict enumeration mode, an empty user for a new and an existing account
+func (s fakeSignupService) Signup(ctx context.Context, req signup.RequestSignup) (domain.User, error) {
+	return domain.User{}, nil
+}
+
+func TestAPI(t *testing.T) {
+	cfg := &configs.Config{}
+	cfg.JWT.SigningKey = "secret"
+	store := memory.NewStore()
+	require.NoError(t, store.GetUserRepository().Create(context.Background(), domain.User{ID: "u1", Username: "jane"}))
+	var batches int32
+	store.SetHook(func(ctx context.Context, op string) error {
+		if op == "UserRepository.ListByIDs" {
+			atomic.AddInt32(&batches, 1)
+		}
+		return nil
+	})
+	router := echo.New()
+	RegisterAPI(*router.Group(""), cfg, fakeAuthService{}, fakeSignupService{}, store, ratelimit.NewMemoryStore(), logger.New("test", "test"))
+
+	query := func(query, token string) map[string]interface{} {
+		body, _ := json.Marshal(map[string]string{"query": query})
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set("User-Agent", "test-agent")
+		if token != "" {
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var res map[string]interface{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		return res
+	}
+
+	res := query(`mutation { login(input: {username: "jane", password: "password1234"}) { accessToken expiresAt approval { id } } }`, "")
+	assert.Equal(t, map[string]interface{}{"login": map[string]interface{}{"accessToken": "access", "expiresAt": "test-agent", "approval": nil}}, res["data"])
+
+	// the errors of the services are answered with their code
+	res = query(`mutation { login(input: {username: "jane", password: "wrong"}) { accessToken } }`, "")
+	errs := res["errors"].([]interface{})
+	require.Len(t, errs, 1)
+	assert.Equal(t, ierr.ErrInvalidCreds.Message, errs[0].(map[string]interface{})["message"])
+	assert.Equal(t, map[string]interface{}{"code": ierr.ErrInvalidCreds.Code}, errs[0].(map[string]interface{})["extensions"])
+
+	// me requires the access token of a user
+	res = query(`{ me { id } }`, "")
+	assert.Equal(t, ierr.ErrUnauthorized.Code, res["errors"].([]interface{})[0].(map[string]interface{})["extensions"].(map[string]interface{})["code"])
+
+	token, err := cfg.JWTKeys().Signer().Sign(jwt.MapClaims{"id": "u1", "token_type": "access"})
+	require.NoError(t, err)
+	res = query(`{ me { id username } alias: me { fullName } }`, token)
+	assert.Equal(t, map[string]interface{}{"me": map[string]interface{}{"id": "u1", "username": "jane"}, "alias": map[string]interface{}{"fullName": nil}}, res["data"])
+	assert.Equal(t, int32(1), atomic.LoadInt32(&batches), "the lookups of a request must be batched")
+}
+
+func TestAPILimitsLogins(t *testing.T) {
+	cfg := &configs.Config{}
+	cfg.JWT.SigningKey = "secret"
+	cfg.RateLimit.LoginPerUsername = 2
+	router := echo.New()
+	RegisterAPI(*router.Group(""), cfg, fakeAuthService{}, fakeSignupService{}, memory.NewStore(), ratelimit.NewMemoryStore(), logger.New("test", "test"))
+
+	login := func(username string) map[string]interface{} {
+		body, _ := json.Marshal(map[string]string{"query": `mutation { login(input: {username: "` + username + `", password: "wrong"}) { accessToken } }`})
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var res map[string]interface{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		return res["errors"].([]interface{})[0].(map[string]interface{})["extensions"].(map[string]interface{})
+	}
+
+	// the login mutation shares the login limits of the routes, per username in lower case
+	assert.Equal(t, ierr.ErrInvalidCreds.Code, login("jane")["code"])
+	assert.Equal(t, ierr.ErrInvalidCreds.Code, login("Jane")["code"])
+	assert.Equal(t, ierr.ErrTooManyRequests.Code, login("JANE")["code"])
+	assert.Equal(t, ierr.ErrInvalidCreds.Code, login("john")["code"])
+}
+
+func TestAPIStrictEnumerationAnswersTheSame(t *testing.T) {
+	cfg := &configs.Config{}
+	cfg.JWT.SigningKey = "secret"
+	cfg.Enumeration.Strict = true
+	cfg.Enumeration.MinDuration = 100
+	router := echo.New()
+	RegisterAPI(*router.Group(""), cfg, fakeAuthService{}, fakeSignupService{}, memory.NewStore(), ratelimit.NewMemoryStore(), logger.New("test", "test"))
+
+	query := func(query string) map[string]interface{} {
+		body, _ := json.Marshal(map[string]string{"query": query})
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+
+		// the logins and the signups are answered once the minimum duration elapsed
+		start := time.Now()
+		router.ServeHTTP(rec, req)
+		assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var res map[string]interface{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		return res
+	}
+
+	unknown := query(`mutation { login(input: {username: "john", password: "wrong"}) { accessToken } }`)
+	known := query(`mutation { login(input: {username: "jane", password: "wrong"}) { accessToken } }`)
+	assert.Equal(t, unknown, known)
+
+	// the signups answer no user, whether the account was created or already existed
+	created := query(`mutation { register(input: {username: "jane", password: "password1234", fullName: "", email: "jane@example.com"}) { message user { id } } }`)
+	existing := query(`mutation { register(input: {username: "jane", password: "password5678", fullName: "", email: "jane@example.org"}) { message user { id } } }`)
+	assert.Equal(t, map[string]interface{}{"register": map[string]interface{}{"message": "signup accepted", "user": nil}}, created["data"])
+	assert.Equal(t, created, existing)
+}
@@ -0,0 +1,112 @@
+package graphql
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/port"
+	"go-hex/shared/ierr"
+	"sync"
+	"time"
+)
+
+// Defaults of the batches of a UserLoader
+const (
+	loaderWait     = 2 * time.Millisecond
+	loaderMaxBatch = 100
+)
+
+// userResult is the user loaded for an ID, done is closed once it is loaded
+type userResult struct {
+	user domain.User
+	err  error
+	done chan struct{}
+}
+
+// UserLoader batches the lookups of the users by ID resolved during a request into one ListByIDs of the
+// repository, and caches the users for the rest of the request. The lookups requested within wait of the first
+// one are batched, up to maxBatch IDs. A loader is created for every request, so that a user is never served
+// from the cache of another request.
+type UserLoader struct {
+	ctx      context.Context
+	users    port.UserRepository
+	wait     time.Duration
+	maxBatch int
+
+	mu      sync.Mutex
+	results map[string]*userResult
+	batch   []string
+}
+
+// NewUserLoader creates the loader of a request, the users are read with the context of the request
+func NewUserLoader(ctx context.Context, users port.UserRepository) *UserLoader {
+	return &UserLoader{
+		ctx:      ctx,
+		users:    users,
+		wait:     loaderWait,
+		maxBatch: loaderMaxBatch,
+		results:  map[string]*userResult{},
+	}
+}
+
+// Load returns the user with the ID, it returns ierr.ErrResourceNotFound when there is no such user
+func (l *UserLoader) Load(userID string) (domain.User, error) {
+
+	l.mu.Lock()
+	result, ok := l.results[userID]
+	if !ok {
+		result = &userResult{done: make(chan struct{})}
+		l.results[userID] = result
+		l.batch = append(l.batch, userID)
+		switch {
+		case len(l.batch) >= l.maxBatch:
+			batch := l.batch
+			l.batch = nil
+			go l.fetch(batch)
+		case len(l.batch) == 1:
+			time.AfterFunc(l.wait, l.flush)
+		}
+	}
+	l.mu.Unlock()
+
+	<-result.done
+	return result.user, result.err
+}
+
+// flush fetches the lookups batched since the first one
+func (l *UserLoader) flush() {
+	l.mu.Lock()
+	batch := l.batch
+	l.batch = nil
+	l.mu.Unlock()
+
+	if len(batch) > 0 {
+		l.fetch(batch)
+	}
+}
+
+// fetch reads the users of the batch and completes their lookups
+func (l *UserLoader) fetch(batch []string) {
+
+	users, err := l.users.ListByIDs(l.ctx, batch)
+	found := make(map[string]domain.User, len(users))
+	for _, user := range users {
+		found[user.ID] = user
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, userID := range batch {
+		result := l.results[userID]
+		switch user, ok := found[userID]; {
+		case err != nil:
+			result.err = err
+			// a failed lookup is not cached
+			delete(l.results, userID)
+		case ok:
+			result.user = user
+		default:
+			result.err = ierr.ErrResourceNotFound
+		}
+		close(result.done)
+	}
+}
@@ -0,0 +1,76 @@
+package graphql
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/port"
+	"go-hex/shared/ierr"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeUserRepository records the batches of IDs listed
+type fakeUserRepository struct {
+	port.UserRepository
+	users map[string]domain.User
+
+	mu      sync.Mutex
+	batches [][]string
+}
+
+func (r *fakeUserRepository) ListByIDs(ctx context.Context, userIDs []string) ([]domain.User, error) {
+	r.mu.Lock()
+	r.batches = append(r.batches, userIDs)
+	r.mu.Unlock()
+
+	var users []domain.User
+	for _, userID := range userIDs {
+		if user, ok := r.users[userID]; ok {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func TestUserLoader(t *testing.T) {
+	users := &fakeUserRepository{users: map[string]domain.User{
+		"u1": {ID: "u1", Username: "jane"},
+		"u2": {ID: "u2", Username: "john"},
+	}}
+	loader := NewUserLoader(context.Background(), users)
+
+	// the concurrent lookups are batched, the same ID is listed once
+	var wg sync.WaitGroup
+	for _, userID := range []string{"u1", "u2", "u1"} {
+		wg.Add(1)
+		go func(userID string) {
+			defer wg.Done()
+			user, err := loader.Load(userID)
+			require.NoError(t, err)
+			assert.Equal(t, userID, user.ID)
+		}(userID)
+	}
+	wg.Wait()
+	require.Len(t, users.batches, 1)
+	assert.ElementsMatch(t, []string{"u1", "u2"}, users.batches[0])
+
+	// the users loaded are cached for the request
+	_, err := loader.Load("u2")
+	require.NoError(t, err)
+	assert.Len(t, users.batches, 1)
+
+	_, err = loader.Load("u3")
+	assert.Equal(t, ierr.ErrResourceNotFound, err)
+
+	// a full batch is listed without waiting
+	loader = NewUserLoader(context.Background(), users)
+	loader.maxBatch = 1
+	loader.wait = time.Hour
+	_, err = loader.Load("u1")
+	require.NoError(t, err)
+	assert.Len(t, users.batches, 3)
+}
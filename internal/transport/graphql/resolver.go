@@ -0,0 +1,29 @@
+// Package graphql serves the users over GraphQL, its executable schema is generated by gqlgen from schema.graphqls.
+// The resolvers share the services of the REST routes, the users are read through a UserLoader batching the
+// lookups of a request.
+package graphql
+
+//go:generate go run github.com/99designs/gqlgen@v0.17.2 generate
+
+import (
+	"go-hex/configs"
+	"go-hex/internal/auth"
+	"go-hex/internal/domain"
+	"go-hex/internal/signup"
+	"go-hex/pkg/ratelimit"
+)
+
+// Resolver resolves the queries and the mutations of the schema, the logins share the rate limits of the REST
+// routes
+type Resolver struct {
+	cfg    *configs.Config
+	limits ratelimit.Store
+	auth   auth.ServicePort
+	signup signup.ServicePort
+}
+
+// Signup is the answer of the register mutation, the user is nil in strict enumeration mode
+type Signup struct {
+	Message string
+	User    *domain.User
+}
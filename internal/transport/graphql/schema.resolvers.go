@@ -0,0 +1,81 @@
+package graphql
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/auth"
+	"go-hex/internal/domain"
+	"go-hex/internal/signup"
+	pkgauth "go-hex/pkg/auth"
+	"go-hex/pkg/ratelimit"
+	"go-hex/pkg/times"
+	"go-hex/shared/ierr"
+)
+
+func (r *mutationResolver) Login(ctx context.Context, input auth.RequestLogin) (*auth.ResponseLogin, error) {
+	client := clientFromContext(ctx)
+	input.IPAddress = client.ipAddress
+	input.UserAgent = client.userAgent
+	input.Client = client.info
+
+	limit := configs.FromContext(ctx, r.cfg).RateLimit
+	if err := ratelimit.TakeLogin(ctx, r.limits, input.IPAddress, input.Username, limit.LoginPerIP, limit.LoginPerUsername); err != nil {
+		return nil, err
+	}
+	// in strict enumeration mode, a login takes as long whether the account exists or not
+	defer times.Envelope(ctx, r.cfg.EnumerationMinDuration())()
+
+	res, err := r.auth.Login(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (r *mutationResolver) RefreshToken(ctx context.Context, refreshToken string) (*auth.ResponseLogin, error) {
+	res, err := r.auth.RefreshToken(ctx, auth.RequestRefreshToken{RefreshToken: refreshToken})
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (r *mutationResolver) Register(ctx context.Context, input signup.RequestSignup) (*Signup, error) {
+	input.IPAddress = clientFromContext(ctx).ipAddress
+	defer times.Envelope(ctx, r.cfg.EnumerationMinDuration())()
+
+	user, err := r.signup.Signup(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if r.cfg.Enumeration.Strict {
+		// the same answer whether the account was created or already existed
+		return &Signup{Message: "signup accepted"}, nil
+	}
+	return &Signup{Message: "user signed up, the email address must be verified to log in", User: &user}, nil
+}
+
+func (r *queryResolver) Me(ctx context.Context) (*domain.User, error) {
+	userID := pkgauth.GetLoggedInUser(ctx).ID
+	if userID == "" {
+		return nil, ierr.ErrUnauthorized
+	}
+
+	user, err := loaderFromContext(ctx).Load(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Mutation returns MutationResolver implementation.
+func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
+
+// Query returns QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+type mutationResolver struct{ *Resolver }
+type queryResolver struct{ *Resolver }
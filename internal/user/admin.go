@@ -0,0 +1,140 @@
+package user
+
+import (
+	"context"
+	"go-hex/internal/deliverability"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/event"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/times"
+)
+
+// GetByID returns the user with the deliverability of its email.
+func (s Service) GetByID(ctx context.Context, req RequestUserID) (ResponseUser, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return ResponseUser{}, err
+	}
+
+	user, err := s.repoRegitry.GetUserRepository().GetByID(ctx, req.ID)
+	if err != nil {
+		return ResponseUser{}, err
+	}
+	return s.responseUser(ctx, user)
+}
+
+// List returns a page of users in a stable order, NextAfterID continues with the next page.
+func (s Service) List(ctx context.Context, req RequestListUsers) (ResponseListUsers, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return ResponseListUsers{}, err
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultListLimit
+	}
+
+	users, err := s.repoRegitry.GetUserRepository().List(ctx, req.AfterID, req.Limit)
+	if err != nil {
+		return ResponseListUsers{}, err
+	}
+	res := ResponseListUsers{Users: users}
+	if len(users) == req.Limit {
+		res.NextAfterID = users[len(users)-1].ID
+	}
+	return res, nil
+}
+
+// Update updates the full name, the email address and the phone number of the user, the empty fields are left unchanged.
+// The event records the diff of the user, with the email address and the phone number masked.
+func (s Service) Update(ctx context.Context, req RequestUpdateUser) (ResponseUser, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return ResponseUser{}, err
+	}
+
+	repoUser := s.repoRegitry.GetUserRepository()
+	user, err := repoUser.GetByID(ctx, req.ID)
+	if err != nil {
+		return ResponseUser{}, err
+	}
+
+	before := user
+	var fields []string
+	update := domain.User{UpdatedAt: times.Now()}
+	if req.FullName != "" {
+		update.FullName, user.FullName = &req.FullName, &req.FullName
+		fields = append(fields, "full_name")
+	}
+	if req.Email != "" {
+		email := deliverability.NormalizeAddress(req.Email)
+		update.Email, user.Email = &email, &email
+		fields = append(fields, "email")
+	}
+	if req.Phone != "" {
+		update.Phone, user.Phone = &req.Phone, &req.Phone
+		fields = append(fields, "phone")
+	}
+	if len(fields) == 0 {
+		return s.responseUser(ctx, user)
+	}
+
+	err = repoUser.Update(ctx, req.ID, update)
+	if err != nil {
+		return ResponseUser{}, err
+	}
+
+	s.events.Publish(ctx, event.Event{
+		Name:       domain.EventUserUpdated,
+		SubjectID:  user.ID,
+		Attributes: map[string]interface{}{"fields": fields, "diff": domain.Diff(before, user)},
+	})
+	return s.responseUser(ctx, user)
+}
+
+// Deactivate deactivates the user and revokes its sessions, the user is kept with its records.
+func (s Service) Deactivate(ctx context.Context, req RequestUserID) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return err
+	}
+
+	user, err := s.repoRegitry.GetUserRepository().GetByID(ctx, req.ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.repoRegitry.DoInTransaction(ctx, func(ctx context.Context, repoRegistry port.RepositoryRegistry) (interface{}, error) {
+		err := repoRegistry.GetUserRepository().SetActive(ctx, user.ID, false)
+		if err != nil {
+			return nil, err
+		}
+		return nil, repoRegistry.GetSessionRepository().RevokeByUserID(ctx, user.ID)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.events.Publish(ctx, event.Event{
+		Name:       domain.EventUserDeactivated,
+		SubjectID:  user.ID,
+		Attributes: map[string]interface{}{"username": user.Username},
+	})
+	return nil
+}
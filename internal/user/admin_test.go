@@ -0,0 +1,64 @@
+package user
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/memory"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdmin(t *testing.T) {
+	store := memory.NewStore()
+	phone := "+14155550102"
+	for _, user := range []domain.User{{ID: "u1", Username: "jane"}, {ID: "u2", Username: "john"}, {ID: "u3", Username: "joan", Phone: &phone}} {
+		require.NoError(t, store.GetUserRepository().Create(context.Background(), user))
+	}
+	events := event.New()
+	var published []event.Event
+	events.Subscribe(domain.EventUserUpdated, func(ctx context.Context, e event.Event) {
+		published = append(published, e)
+	})
+	svc := NewService(&configs.Config{}, store, logger.New("test", "test"), events, nil, nil)
+	ctx := context.Background()
+
+	// the pages continue after the last user of the previous one
+	page, err := svc.List(ctx, RequestListUsers{Limit: 2})
+	require.NoError(t, err)
+	assert.Len(t, page.Users, 2)
+	assert.Equal(t, "u2", page.NextAfterID)
+	page, err = svc.List(ctx, RequestListUsers{AfterID: page.NextAfterID, Limit: 2})
+	require.NoError(t, err)
+	assert.Len(t, page.Users, 1)
+	assert.Empty(t, page.NextAfterID)
+
+	// the empty fields are left unchanged
+	res, err := svc.Update(ctx, RequestUpdateUser{ID: "u1", Phone: "+14155550100"})
+	require.NoError(t, err)
+	assert.Equal(t, "+14155550100", res.GetPhone())
+	assert.Nil(t, getUser(t, store, "u1").FullName)
+
+	// the event records the diff of the user, the phone number masked
+	_, err = svc.Update(ctx, RequestUpdateUser{ID: "u2", FullName: "John Doe", Phone: "+14155550101"})
+	require.NoError(t, err)
+	if assert.Len(t, published, 2) {
+		assert.Equal(t, map[string]domain.FieldChange{"phone": {Before: nil, After: domain.Masked}}, published[0].Attributes["diff"])
+		assert.Equal(t, map[string]domain.FieldChange{
+			"full_name": {Before: nil, After: "John Doe"},
+			"phone":     {Before: nil, After: domain.Masked},
+		}, published[1].Attributes["diff"])
+	}
+	_, err = svc.Update(ctx, RequestUpdateUser{ID: "u3", Phone: "+14155550103"})
+	require.NoError(t, err)
+	if assert.Len(t, published, 3) {
+		assert.Equal(t, map[string]domain.FieldChange{"phone": {Before: domain.Masked, After: domain.Masked}}, published[2].Attributes["diff"])
+	}
+
+	_, err = svc.Update(ctx, RequestUpdateUser{ID: "u1", Phone: "0415"})
+	assert.Error(t, err)
+}
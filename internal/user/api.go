@@ -3,19 +3,28 @@ package user
 import (
 	"go-hex/configs"
 	"go-hex/middleware"
+	"go-hex/shared/ierr"
 	"go-hex/shared/response"
 
 	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
 )
 
 // RegisterAPI registers a new user api
 func RegisterAPI(r echo.Group, cfg *configs.Config, service ServicePort) {
 	handler := handler{cfg, service}
 
+	r.POST("/users/verify", handler.verify)
+
+	// Internal endpoints
+	internal := r.Group("/internal/users", middleware.InternalAPI(cfg.InternalAPI.User, cfg.InternalAPI.Password))
+	internal.POST("", handler.register)
+
 	// Private endpoint
-	r.Use(middleware.MustLoggedIn(cfg.JWT.SigningKey))
+	r.Use(middleware.MustLoggedIn(cfg.JWTKeys()))
 
 	r.GET("/me", handler.get)
+	r.GET("/userinfo", handler.userInfo)
 }
 
 type handler struct {
@@ -31,13 +40,93 @@ type handler struct {
 // @Accept json
 // @Produce json
 // @Security BearerToken
-// @Success 200 {object} response.Response{data=domain.User} "Success"
+// @Success 200 {object} response.Response{data=ResponseUser} "Success"
 // @failure 500 {object} response.ErrorResponse500
 func (h handler) get(c echo.Context) error {
 	ctx := c.Request().Context()
-	user, err := h.service.Get(ctx)
+	res, err := h.service.Get(ctx)
+	if err != nil {
+		return err
+	}
+	return response.SuccessOK(c, res)
+}
+
+// userInfo godoc
+// @Router /userinfo [get]
+// @Tags User
+// @Summary Get the claims of the user
+// @Description Get the claims of the logged in user, named after the standard claims of OpenID Connect, for the services receiving the subject-only access tokens
+// @Accept json
+// @Produce json
+// @Security BearerToken
+// @Success 200 {object} response.Response{data=ResponseUserInfo} "Success"
+// @failure 401 {object} response.ErrorResponse401
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) userInfo(c echo.Context) error {
+	res, err := h.service.UserInfo(c.Request().Context())
+	if err != nil {
+		return err
+	}
+	return response.SuccessOK(c, res)
+}
+
+// register godoc
+// @Router /internal/users [post]
+// @Tags User
+// @Summary Register a user
+// @Description Create an inactive user and email it a one-time token confirming its address, the user is activated once confirmed
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Param payload body RequestRegister true " "
+// @Success 201 {object} response.Response{data=ResponseRegister} "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) register(c echo.Context) error {
+	var req RequestRegister
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.Register(c.Request().Context(), req)
+	if err != nil {
+		switch errors.Cause(err) {
+		case ierr.ErrUserAlreadyRegistered, ierr.ErrPasswordTooShort, ierr.ErrPasswordTooSimple, ierr.ErrPasswordTooCommon, ierr.ErrPasswordContainsUsername, ierr.ErrRedirectNotAllowed:
+			return response.ErrBadRequest(err)
+		}
+		return err
+	}
+	return response.SuccessCreated(c, res, "user registered")
+}
+
+// verify godoc
+// @Router /users/verify [post]
+// @Tags User
+// @Summary Verify an email address
+// @Description Confirm the email address of a registered user with the token emailed at its registration, and activate the user
+// @Accept json
+// @Produce json
+// @Param payload body RequestVerify true " "
+// @Success 200 {object} response.Response "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 403 {object} response.ErrorResponse403
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) verify(c echo.Context) error {
+	var req RequestVerify
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	err := h.service.Verify(c.Request().Context(), req)
 	if err != nil {
+		switch errors.Cause(err) {
+		case ierr.ErrInvalidToken:
+			return response.ErrBadRequest(err)
+		case ierr.ErrExpiredToken:
+			return response.ErrForbidden(err)
+		}
 		return err
 	}
-	return response.SuccessOK(c, user)
+	return response.SuccessOK(c, nil, "email verified")
 }
@@ -1,6 +1,26 @@
 package user
 
+import "regexp"
+
 // Constant
 const (
 	ExpirationTokenHours int = 24
 )
+
+// verificationTokenSize is the number of random bytes of the email verification tokens
+const verificationTokenSize = 32
+
+// Statuses of the email address of a user
+const (
+	EmailStatusDeliverable   = "deliverable"
+	EmailStatusUndeliverable = "undeliverable" // a permanent bounce or a complaint suppressed the address
+)
+
+// Limits of the pages of users
+const (
+	defaultListLimit = 100
+	maxListLimit     = 1000
+)
+
+// e164 matches the phone numbers in the E.164 format
+var e164 = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
@@ -1 +1,133 @@
 package user
+
+import (
+	"go-hex/internal/domain"
+	"go-hex/shared/pb"
+	"net/mail"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+)
+
+// ResponseUser struct
+type ResponseUser struct {
+	domain.User
+	EmailStatus string `json:"email_status,omitempty" example:"deliverable"` // empty when the user has no email
+}
+
+// ToProto implements response.ProtoMapper
+func (r ResponseUser) ToProto() proto.Message {
+	res := &pb.User{
+		Id:          r.ID,
+		Username:    r.Username,
+		Email:       r.GetEmail(),
+		Phone:       r.GetPhone(),
+		EmailStatus: r.EmailStatus,
+	}
+	if r.FullName != nil {
+		res.FullName = *r.FullName
+	}
+	return res
+}
+
+// ResponseUserInfo struct, the claims of the user named after the standard claims of OpenID Connect
+type ResponseUserInfo struct {
+	Subject           string `json:"sub" example:"1f7a6f2e-3c3e-4a8e-9d4b-8f5d1f2a9c10"`
+	PreferredUsername string `json:"preferred_username" example:"jane"`
+	Name              string `json:"name,omitempty" example:"Jane Doe"`
+	Email             string `json:"email,omitempty" example:"jane@example.com"`
+	PhoneNumber       string `json:"phone_number,omitempty" example:"+14155550100"`
+}
+
+// RequestRegister request body
+type RequestRegister struct {
+	Username string `json:"username" example:"jane"`
+	Password string `json:"password" example:"password1234"`
+	FullName string `json:"full_name" example:"Jane Doe"`
+	Email    string `json:"email" example:"jane@example.com"`
+	ClientID string `json:"client_id" example:"web"`
+	ReturnTo string `json:"return_to" example:"https://app.example.com/welcome"` // passed on to the verify page when allowed for the client
+}
+
+func (r *RequestRegister) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Username, validation.Required, validation.Length(3, 50)),
+		validation.Field(&r.Password, validation.Required, validation.Length(8, 72)), // bcrypt ignores the bytes after the 72th
+		validation.Field(&r.FullName, validation.Length(0, 255)),
+		validation.Field(&r.Email, validation.Required, validation.Length(0, 255), validation.By(isEmail)),
+	)
+}
+
+// ResponseRegister struct
+type ResponseRegister struct {
+	domain.User
+	VerificationSent bool `json:"verification_sent" example:"true"` // false when the email failed to be sent
+}
+
+// RequestVerify request body
+type RequestVerify struct {
+	Token string `json:"token" example:"Zk4rT8vB1nQ6wX3yM9cL2hF7jD5sA0pE4gU8iO1kR6t"`
+}
+
+func (r *RequestVerify) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Token, validation.Required),
+	)
+}
+
+// isEmail checks that the value is a bare email address, without a display name
+func isEmail(value interface{}) error {
+	email, _ := value.(string)
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email {
+		return errors.New("must be a valid email address")
+	}
+	return nil
+}
+
+// RequestUserID request params
+type RequestUserID struct {
+	ID string `json:"-" param:"id"`
+}
+
+func (r *RequestUserID) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.ID, validation.Required),
+	)
+}
+
+// RequestListUsers request params, the page starts after the user AfterID
+type RequestListUsers struct {
+	AfterID string `json:"-" query:"after_id"`
+	Limit   int    `json:"-" query:"limit" example:"100"`
+}
+
+func (r *RequestListUsers) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Limit, validation.Min(0), validation.Max(maxListLimit)),
+	)
+}
+
+// ResponseListUsers struct
+type ResponseListUsers struct {
+	Users       []domain.User `json:"users"`
+	NextAfterID string        `json:"next_after_id,omitempty"` // empty on the last page
+}
+
+// RequestUpdateUser request body, the empty fields are left unchanged
+type RequestUpdateUser struct {
+	ID       string `json:"-" param:"id"`
+	FullName string `json:"full_name" example:"Jane Doe"`
+	Email    string `json:"email" example:"jane@example.com"`
+	Phone    string `json:"phone" example:"+14155550100"`
+}
+
+func (r *RequestUpdateUser) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.ID, validation.Required),
+		validation.Field(&r.FullName, validation.Length(0, 255)),
+		validation.Field(&r.Email, validation.Length(0, 255), validation.When(r.Email != "", validation.By(isEmail))),
+		validation.Field(&r.Phone, validation.Match(e164)),
+	)
+}
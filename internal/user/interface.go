@@ -1,12 +1,29 @@
 package user
 
-import (
-	"context"
-	"go-hex/internal/domain"
-)
+import "context"
 
 // ServicePort encapsulates usecase logic for users.
 type ServicePort interface {
-	// Get returns the user with the specified user ID or username.
-	Get(ctx context.Context) (domain.User, error)
+	// Get returns the logged in user with the deliverability of its email.
+	Get(ctx context.Context) (ResponseUser, error)
+	// UserInfo returns the claims of the logged in user, for the services receiving subject-only tokens.
+	UserInfo(ctx context.Context) (ResponseUserInfo, error)
+	// Register creates an inactive user and emails it a one-time token confirming its address.
+	Register(ctx context.Context, req RequestRegister) (ResponseRegister, error)
+	// Verify confirms the email address of a registered user with its token and activates the user.
+	Verify(ctx context.Context, req RequestVerify) error
+	// GetByID returns a user with the deliverability of its email.
+	GetByID(ctx context.Context, req RequestUserID) (ResponseUser, error)
+	// List returns a page of users.
+	List(ctx context.Context, req RequestListUsers) (ResponseListUsers, error)
+	// Update updates the profile of a user.
+	Update(ctx context.Context, req RequestUpdateUser) (ResponseUser, error)
+	// Deactivate deactivates a user and revokes its sessions.
+	Deactivate(ctx context.Context, req RequestUserID) error
+}
+
+// PasswordHasher hashes the passwords on the bounded workers shared with the logins, e.g. a password.Pool, so that a
+// flood of registrations cannot take every CPU away from the other requests.
+type PasswordHasher interface {
+	HashAndSalt(ctx context.Context, pwd []byte) (string, error)
 }
@@ -0,0 +1,216 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"go-hex/configs"
+	"go-hex/internal/catalog"
+	"go-hex/internal/deliverability"
+	"go-hex/internal/domain"
+	"go-hex/internal/notification"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/event"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/password"
+	"go-hex/pkg/times"
+	"go-hex/pkg/utils"
+	"go-hex/shared/ierr"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Register creates an inactive user and emails it a one-time token confirming its address, the user is activated
+// by Verify. A failure to send the token is logged and answered with VerificationSent false.
+func (s Service) Register(ctx context.Context, req RequestRegister) (ResponseRegister, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return ResponseRegister{}, err
+	}
+	err = configs.FromContext(ctx, s.cfg).PasswordValidator().Validate(req.Password, req.Username)
+	if err != nil {
+		return ResponseRegister{}, err
+	}
+	if req.ReturnTo != "" {
+		req.ReturnTo, err = configs.FromContext(ctx, s.cfg).Redirect.Allowlist.Check(req.ClientID, req.ReturnTo)
+		if err != nil {
+			return ResponseRegister{}, err
+		}
+	}
+
+	repoUser := s.repoRegitry.GetUserRepository()
+	exist, err := repoUser.IsUserExistByUsername(ctx, req.Username)
+	if err != nil {
+		return ResponseRegister{}, err
+	}
+	if exist {
+		return ResponseRegister{}, ierr.ErrUserAlreadyRegistered
+	}
+
+	hash, err := s.passwords.HashAndSalt(ctx, []byte(req.Password))
+	if err != nil {
+		if err == password.ErrPoolSaturated {
+			return ResponseRegister{}, errors.Wrap(ierr.ErrServiceUnavailable, err.Error())
+		}
+		return ResponseRegister{}, err
+	}
+	token, err := randomToken()
+	if err != nil {
+		return ResponseRegister{}, err
+	}
+
+	now := times.Now()
+	email := deliverability.NormalizeAddress(req.Email)
+	user := domain.User{
+		ID:        utils.GenerateID(),
+		Username:  req.Username,
+		Password:  hash,
+		Email:     &email,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if req.FullName != "" {
+		user.FullName = &req.FullName
+	}
+	verification := domain.EmailVerification{
+		ID:        utils.GenerateID(),
+		UserID:    user.ID,
+		Email:     email,
+		TokenHash: utils.HashSHA256(token),
+		CreatedAt: now,
+		ExpiresAt: now.Add(time.Duration(s.cfg.Registration.TokenExpiration) * time.Minute),
+	}
+	_, err = s.repoRegitry.DoInTransaction(ctx, func(ctx context.Context, repoRegistry port.RepositoryRegistry) (interface{}, error) {
+		// the username may have been taken since the check, Create answers ierr.ErrUserAlreadyRegistered then
+		if err := repoRegistry.GetUserRepository().Create(ctx, user); err != nil {
+			return nil, err
+		}
+		return nil, repoRegistry.GetEmailVerificationRepository().Create(ctx, verification)
+	})
+	if err != nil {
+		return ResponseRegister{}, err
+	}
+
+	s.events.Publish(ctx, event.Event{
+		Name:      domain.EventUserRegistered,
+		SubjectID: user.ID,
+		Attributes: map[string]interface{}{
+			"username":   user.Username,
+			"expires_at": verification.ExpiresAt.Format(time.RFC3339),
+		},
+	})
+
+	res := ResponseRegister{User: user}
+	err = s.sendVerification(ctx, user, token, verification.ExpiresAt, req.ReturnTo)
+	if err != nil {
+		s.log.With(ctx).WithParam("user_id", user.ID).Error(errors.Wrap(err, "cannot send email verification"))
+		return res, nil
+	}
+	res.VerificationSent = true
+	return res, nil
+}
+
+// Verify confirms the email address of a registered user with the token sent by Register, and activates the user.
+// The token is used once. The address stays verified until it is changed.
+func (s Service) Verify(ctx context.Context, req RequestVerify) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return err
+	}
+
+	verification, err := s.repoRegitry.GetEmailVerificationRepository().GetByTokenHash(ctx, utils.HashSHA256(req.Token))
+	if err != nil {
+		if err == ierr.ErrResourceNotFound {
+			return ierr.ErrInvalidToken
+		}
+		return err
+	}
+	now := times.Now()
+	if !verification.IsUsableAt(now) {
+		return ierr.ErrExpiredToken
+	}
+
+	_, err = s.repoRegitry.DoInTransaction(ctx, func(ctx context.Context, repoRegistry port.RepositoryRegistry) (interface{}, error) {
+		verified, err := repoRegistry.GetEmailVerificationRepository().Verify(ctx, verification.ID, now)
+		if err != nil {
+			return nil, err
+		}
+		if !verified {
+			return nil, ierr.ErrExpiredToken
+		}
+		// the address is verified as it was sent the token, the social logins are then linked to the user by it
+		err = repoRegistry.GetUserRepository().Update(ctx, verification.UserID, domain.User{VerifiedEmail: &verification.Email, UpdatedAt: now})
+		if err != nil {
+			return nil, err
+		}
+		return nil, repoRegistry.GetUserRepository().SetActive(ctx, verification.UserID, true)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.events.Publish(ctx, event.Event{
+		Name:      domain.EventUserVerified,
+		ActorID:   verification.UserID,
+		SubjectID: verification.UserID,
+		Attributes: map[string]interface{}{
+			"email_domain": verification.Email[strings.LastIndex(verification.Email, "@")+1:],
+		},
+	})
+	return nil
+}
+
+// sendVerification emails the token confirming the address of the user, the verify page going on to returnTo when given
+func (s Service) sendVerification(ctx context.Context, user domain.User, token string, expiresAt time.Time, returnTo string) error {
+	verifyURL := ""
+	if s.cfg.Registration.VerifyURL != "" {
+		u, err := url.Parse(s.cfg.Registration.VerifyURL)
+		if err != nil {
+			return errors.Wrap(err, "invalid verify url")
+		}
+		query := u.Query()
+		query.Set("token", token)
+		if returnTo != "" {
+			query.Set("return_to", returnTo)
+		}
+		u.RawQuery = query.Encode()
+		verifyURL = u.String()
+	}
+	return s.notifier.Send(ctx, notification.ChannelEmail, notification.Message{
+		UserID: user.ID,
+		To:     user.GetEmail(),
+		Title:  "Confirm your email address",
+		Body:   "Confirm your email address with the token " + token + " before " + expiresAt.Format(time.RFC3339) + " to activate your account.",
+		Data: map[string]string{
+			"type": "email_verification",
+		},
+		Template: catalog.MessageEmailVerification,
+		Variables: map[string]string{
+			"AppName":   configs.FromContext(ctx, s.cfg).Server.NAME,
+			"Username":  user.Username,
+			"Token":     token,
+			"VerifyURL": verifyURL,
+			"ExpiresAt": expiresAt.Format(time.RFC3339),
+		},
+	})
+}
+
+// randomToken returns a random one-time token, only its hash is stored
+func randomToken() (string, error) {
+	b := make([]byte, verificationTokenSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "cannot generate verification token")
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,122 @@
+package user
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/notification"
+	"go-hex/internal/repository/memory"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/password"
+	"go-hex/shared/ierr"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// getUser reads the user from the store
+func getUser(t *testing.T, store *memory.Store, userID string) domain.User {
+	t.Helper()
+	user, err := store.GetUserRepository().GetByID(context.Background(), userID)
+	require.NoError(t, err)
+	return user
+}
+
+// recordingNotifier records the emails sent
+type recordingNotifier struct {
+	sent *[]notification.Message
+}
+
+func (n recordingNotifier) Channel() notification.Channel {
+	return notification.ChannelEmail
+}
+
+func (n recordingNotifier) Notify(ctx context.Context, msg notification.Message) error {
+	*n.sent = append(*n.sent, msg)
+	return nil
+}
+
+func TestRegisterAndVerify(t *testing.T) {
+	store := memory.NewStore()
+	cfg := &configs.Config{}
+	cfg.Registration.TokenExpiration = 60
+	cfg.Registration.VerifyURL = "https://example.com/verify"
+	events := event.New()
+	var published []string
+	events.Subscribe(event.All, func(ctx context.Context, e event.Event) {
+		published = append(published, e.Name)
+	})
+	var emails []notification.Message
+	svc := NewService(cfg, store, logger.New("test", "test"), events, notification.NewDispatcher(recordingNotifier{&emails}), password.NewPool(cfg.PasswordHasher(), 1, time.Second))
+	ctx := context.Background()
+
+	res, err := svc.Register(ctx, RequestRegister{Username: "jane", Password: "password1234", Email: "Jane@Example.com"})
+	require.NoError(t, err)
+	assert.True(t, res.VerificationSent)
+	user := getUser(t, store, res.ID)
+	assert.False(t, user.IsActive, "inactive until verified")
+	assert.True(t, password.ComparePasswords(user.Password, []byte("password1234")))
+	assert.Equal(t, "jane@example.com", user.GetEmail())
+	assert.False(t, user.IsEmailVerified())
+
+	_, err = svc.Register(ctx, RequestRegister{Username: "jane", Password: "password1234", Email: "jane@example.com"})
+	assert.Equal(t, ierr.ErrUserAlreadyRegistered, err)
+
+	require.Len(t, emails, 1)
+	assert.Equal(t, "jane@example.com", emails[0].To)
+	token := emails[0].Variables["Token"]
+	verifyURL, err := url.Parse(emails[0].Variables["VerifyURL"])
+	require.NoError(t, err)
+	assert.Equal(t, token, verifyURL.Query().Get("token"))
+	_, err = store.GetEmailVerificationRepository().GetByTokenHash(ctx, token)
+	assert.Equal(t, ierr.ErrResourceNotFound, err, "only the hash of the token is stored")
+
+	assert.Equal(t, ierr.ErrInvalidToken, svc.Verify(ctx, RequestVerify{Token: "unknown"}))
+	require.NoError(t, svc.Verify(ctx, RequestVerify{Token: token}))
+	user = getUser(t, store, res.ID)
+	assert.True(t, user.IsActive)
+	assert.True(t, user.IsEmailVerified())
+	assert.Equal(t, ierr.ErrExpiredToken, svc.Verify(ctx, RequestVerify{Token: token}), "the token is used once")
+	assert.Equal(t, []string{domain.EventUserRegistered, domain.EventUserVerified}, published)
+}
+
+func TestRegisterReturnTo(t *testing.T) {
+	store := memory.NewStore()
+	cfg := &configs.Config{}
+	cfg.Registration.VerifyURL = "https://example.com/verify"
+	require.NoError(t, cfg.Redirect.Allowlist.Decode("web=https://app.example.com/*"))
+	var emails []notification.Message
+	svc := NewService(cfg, store, logger.New("test", "test"), event.New(), notification.NewDispatcher(recordingNotifier{&emails}), password.NewPool(cfg.PasswordHasher(), 1, time.Second))
+	ctx := context.Background()
+
+	_, err := svc.Register(ctx, RequestRegister{Username: "jane", Password: "password1234", Email: "jane@example.com", ClientID: "web", ReturnTo: "https://app.example.com.evil.com/welcome"})
+	assert.Equal(t, ierr.ErrRedirectNotAllowed, errors.Cause(err))
+	exists, err := store.GetUserRepository().IsUserExistByUsername(ctx, "jane")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	_, err = svc.Register(ctx, RequestRegister{Username: "jane", Password: "password1234", Email: "jane@example.com", ClientID: "web", ReturnTo: "https://app.example.com/welcome"})
+	require.NoError(t, err)
+	require.Len(t, emails, 1)
+	verifyURL, err := url.Parse(emails[0].Variables["VerifyURL"])
+	require.NoError(t, err)
+	assert.Equal(t, "https://app.example.com/welcome", verifyURL.Query().Get("return_to"))
+}
+
+func TestVerifyExpiredToken(t *testing.T) {
+	store := memory.NewStore()
+	require.NoError(t, store.GetUserRepository().Create(context.Background(), domain.User{ID: "u1", Username: "jane"}))
+	require.NoError(t, store.GetEmailVerificationRepository().Create(context.Background(), domain.EmailVerification{
+		ID: "v1", UserID: "u1", Email: "jane@example.com", TokenHash: "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08", ExpiresAt: time.Now().Add(-time.Minute),
+	}))
+	svc := NewService(&configs.Config{}, store, logger.New("test", "test"), event.New(), notification.NewDispatcher(), nil)
+
+	// the hash of "test"
+	assert.Equal(t, ierr.ErrExpiredToken, svc.Verify(context.Background(), RequestVerify{Token: "test"}))
+	assert.False(t, getUser(t, store, "u1").IsActive)
+}
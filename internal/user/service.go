@@ -3,31 +3,88 @@ package user
 import (
 	"context"
 	"go-hex/configs"
+	"go-hex/internal/deliverability"
 	"go-hex/internal/domain"
+	"go-hex/internal/notification"
 	"go-hex/internal/repository/port"
 	"go-hex/pkg/auth"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
 	"go-hex/pkg/otel"
+	"go-hex/shared/ierr"
+
+	"github.com/pkg/errors"
 )
 
 // Service encapsulates the user logic.
 type Service struct {
 	cfg         *configs.Config
 	repoRegitry port.RepositoryRegistry
+	log         logger.Logger
+	events      event.Bus
+	notifier    *notification.Dispatcher
+	passwords   PasswordHasher
 }
 
-// NewService creates and returns a new user service
-func NewService(cfg *configs.Config, repoRegitry port.RepositoryRegistry) Service {
-	return Service{cfg, repoRegitry}
+// NewService creates and returns a new user service, hashing the passwords of the registrations with passwords
+func NewService(cfg *configs.Config, repoRegitry port.RepositoryRegistry, log logger.Logger, events event.Bus, notifier *notification.Dispatcher, passwords PasswordHasher) Service {
+	return Service{cfg, repoRegitry, log, events, notifier, passwords}
 }
 
-// Get returns the user with the specified user ID or username.
-func (s Service) Get(ctx context.Context) (domain.User, error) {
+// Get returns the logged in user with the deliverability of its email.
+func (s Service) Get(ctx context.Context) (ResponseUser, error) {
 
 	ctx, span := otel.Start(ctx)
 	defer span.End()
 
-	user := auth.GetLoggedInUser(ctx)
+	loggedIn := auth.GetLoggedInUser(ctx)
 
 	repoUser := s.repoRegitry.GetUserRepository()
-	return repoUser.GetByID(ctx, user.ID)
+	user, err := repoUser.GetByID(ctx, loggedIn.ID)
+	if err != nil {
+		return ResponseUser{}, err
+	}
+	return s.responseUser(ctx, user)
+}
+
+// responseUser returns the user with the deliverability of its email
+func (s Service) responseUser(ctx context.Context, user domain.User) (ResponseUser, error) {
+
+	res := ResponseUser{User: user}
+	if user.GetEmail() == "" {
+		return res, nil
+	}
+	res.EmailStatus = EmailStatusDeliverable
+	_, err := s.repoRegitry.GetEmailSuppressionRepository().GetByAddress(ctx, deliverability.NormalizeAddress(user.GetEmail()))
+	if err == nil {
+		res.EmailStatus = EmailStatusUndeliverable
+	} else if errors.Cause(err) != ierr.ErrResourceNotFound {
+		return ResponseUser{}, err
+	}
+	return res, nil
+}
+
+// UserInfo returns the claims of the logged in user left out of the subject-only access tokens, named after the
+// standard claims of OpenID Connect.
+func (s Service) UserInfo(ctx context.Context) (ResponseUserInfo, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	user, err := s.repoRegitry.GetUserRepository().GetByID(ctx, auth.GetLoggedInUser(ctx).ID)
+	if err != nil {
+		return ResponseUserInfo{}, err
+	}
+
+	res := ResponseUserInfo{Subject: user.ID, PreferredUsername: user.Username}
+	if user.FullName != nil {
+		res.Name = *user.FullName
+	}
+	if user.Email != nil {
+		res.Email = *user.Email
+	}
+	if user.Phone != nil {
+		res.PhoneNumber = *user.Phone
+	}
+	return res, nil
 }
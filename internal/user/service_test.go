@@ -0,0 +1,31 @@
+package user
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/memory"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/shared/ctxutil"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserInfo(t *testing.T) {
+	fullName, email := "Jane Doe", "jane@example.com"
+	store := memory.NewStore()
+	require.NoError(t, store.GetUserRepository().Create(context.Background(), domain.User{ID: "u1", Username: "jane", FullName: &fullName, Email: &email, IsActive: true}))
+	svc := NewService(&configs.Config{}, store, logger.New("test", "test"), event.New(), nil, nil)
+
+	// the subject-only tokens only carry the id of the user
+	token := &jwt.Token{Claims: jwt.MapClaims{"id": "u1", "token_type": "access"}}
+	ctx := ctxutil.WithPrincipal(context.Background(), token)
+
+	res, err := svc.UserInfo(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, ResponseUserInfo{Subject: "u1", PreferredUsername: "jane", Name: "Jane Doe", Email: "jane@example.com"}, res)
+}
@@ -0,0 +1,116 @@
+package usersync
+
+import (
+	"go-hex/configs"
+	"go-hex/middleware"
+	"go-hex/shared/ierr"
+	"go-hex/shared/response"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+// RegisterAPI registers a new user sync api
+func RegisterAPI(r echo.Group, cfg *configs.Config, service ServicePort) {
+	handler := handler{cfg, service}
+
+	// Internal endpoints
+	internal := r.Group("/internal", middleware.InternalAPI(cfg.InternalAPI.User, cfg.InternalAPI.Password))
+	internal.POST("/user-sync/runs", handler.sync)
+	internal.GET("/user-sync/runs", handler.listRuns)
+	internal.GET("/user-sync/runs/:id", handler.getRun)
+}
+
+type handler struct {
+	cfg     *configs.Config
+	service ServicePort
+}
+
+// sync godoc
+// @Router /internal/user-sync/runs [post]
+// @Tags User Sync
+// @Summary Sync the users from a source
+// @Description Create, update and deactivate the users linked to a source from its records, a dry run only answers the changes
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Param payload body RequestSync true " "
+// @Success 201 {object} response.Response{data=domain.UserSyncRun} "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) sync(c echo.Context) error {
+	var req RequestSync
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.Sync(c.Request().Context(), req)
+	if err != nil {
+		switch errors.Cause(err) {
+		case ierr.ErrUserSyncSourceUnknown, ierr.ErrUserSyncSourceEmpty:
+			return response.ErrBadRequest(err)
+		}
+		return err
+	}
+
+	return response.SuccessCreated(c, res, "users synced")
+}
+
+// listRuns godoc
+// @Router /internal/user-sync/runs [get]
+// @Tags User Sync
+// @Summary List the user sync runs
+// @Description List the latest user sync runs without their changes, the newest first
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Param source query string false "source, every source when empty" Enums(sftp, hr_api)
+// @Param limit query int false "number of runs, 20 by default and 100 at most"
+// @Success 200 {object} response.Response{data=[]domain.UserSyncRun} "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) listRuns(c echo.Context) error {
+	var req RequestListRuns
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.ListRuns(c.Request().Context(), req)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessOK(c, res)
+}
+
+// getRun godoc
+// @Router /internal/user-sync/runs/{id} [get]
+// @Tags User Sync
+// @Summary Get a user sync run
+// @Description Get a user sync run with the diff of every user it created, updated, deactivated or skipped
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Param id path string true "run id"
+// @Success 200 {object} response.Response{data=domain.UserSyncRun} "Success"
+// @failure 401 {object} response.ErrorResponse401
+// @failure 404 {object} response.ErrorResponse404
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) getRun(c echo.Context) error {
+	var req RequestRunID
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.GetRun(c.Request().Context(), req)
+	if err != nil {
+		if errors.Cause(err) == ierr.ErrResourceNotFound {
+			return response.ErrNotFound(err)
+		}
+		return err
+	}
+
+	return response.SuccessOK(c, res)
+}
@@ -0,0 +1,25 @@
+package usersync
+
+// Names of the sources
+const (
+	SourceSFTP  = "sftp"   // CSV file read over SFTP
+	SourceHRAPI = "hr_api" // REST HR API answering the users in JSON
+)
+
+const (
+	// unusablePassword is the password hash of the created users, no password matches it
+	// so that they cannot log in with a password until they reset it
+	unusablePassword = "!"
+	// maxUsernameLength is the length of the username column
+	maxUsernameLength = 50
+	// maxRunChanges bounds the changes recorded by a run, the changes past it are applied and counted only
+	maxRunChanges = 1000
+	// defaultRunsLimit is the number of runs listed when the request sets no limit
+	defaultRunsLimit = 20
+	// maxRunsLimit bounds the number of runs listed at once
+	maxRunsLimit = 100
+	// maxFileSize bounds the size of the CSV file read over SFTP
+	maxFileSize = 64 << 20
+	// maxHRPages bounds the pages of users read from the HR API, in case its next links loop
+	maxHRPages = 1000
+)
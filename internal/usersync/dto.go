@@ -0,0 +1,41 @@
+package usersync
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// RequestSync request body
+type RequestSync struct {
+	Source string `json:"source" example:"hr_api"`
+	DryRun bool   `json:"dry_run" example:"true"` // computes the changes without applying them
+}
+
+func (r *RequestSync) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Source, validation.Required, validation.In(SourceSFTP, SourceHRAPI)),
+	)
+}
+
+// RequestRunID request params
+type RequestRunID struct {
+	ID string `json:"-" param:"id"`
+}
+
+func (r *RequestRunID) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.ID, validation.Required),
+	)
+}
+
+// RequestListRuns request params
+type RequestListRuns struct {
+	Source string `json:"-" query:"source" example:"hr_api"` // every source when empty
+	Limit  int    `json:"-" query:"limit" example:"20"`
+}
+
+func (r *RequestListRuns) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.Source, validation.In(SourceSFTP, SourceHRAPI)),
+		validation.Field(&r.Limit, validation.Min(0), validation.Max(maxRunsLimit)),
+	)
+}
@@ -0,0 +1,118 @@
+package usersync
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// HRAPISource reads the users from a REST HR API. The API answers either an array of users, or a page
+// of users {"data": [...], "next": "..."} where next links to the following page, absolute or relative.
+// The fields of the users are read as strings, the nested objects and arrays are ignored.
+type HRAPISource struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// NewHRAPISource creates a source reading the users at url, authenticated by the bearer token when set
+func NewHRAPISource(url string, token string) *HRAPISource {
+	return &HRAPISource{url, token, &http.Client{}}
+}
+
+// Name returns the name of the source
+func (s *HRAPISource) Name() string {
+	return SourceHRAPI
+}
+
+// Fetch reads every page of users
+func (s *HRAPISource) Fetch(ctx context.Context) ([]Record, error) {
+	var records []Record
+	next := s.url
+	for page := 0; next != ""; page++ {
+		if page == maxHRPages {
+			return nil, fmt.Errorf("cannot read hr api: more than %d pages", maxHRPages)
+		}
+		users, link, err := s.fetchPage(ctx, next)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, users...)
+
+		next = ""
+		if link != "" {
+			base, _ := url.Parse(s.url)
+			ref, err := url.Parse(link)
+			if err != nil {
+				return nil, errors.Wrap(err, "invalid hr api next page")
+			}
+			next = base.ResolveReference(ref).String()
+		}
+	}
+	return records, nil
+}
+
+// fetchPage reads a page of users and returns the link to the next page, empty on the last page
+func (s *HRAPISource) fetchPage(ctx context.Context, pageURL string) ([]Record, string, error) {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "cannot create hr api request")
+	}
+	req.Header.Set("Accept", "application/json")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "cannot call hr api")
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("cannot call hr api: status %d", res.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxFileSize))
+	if err != nil {
+		return nil, "", errors.Wrap(err, "cannot read hr api response")
+	}
+
+	var page struct {
+		Data []map[string]interface{} `json:"data"`
+		Next string                   `json:"next"`
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = decoder.Decode(&page.Data)
+	} else {
+		err = decoder.Decode(&page)
+	}
+	if err != nil {
+		return nil, "", errors.Wrap(err, "cannot decode hr api response")
+	}
+
+	records := make([]Record, 0, len(page.Data))
+	for _, user := range page.Data {
+		record := make(Record, len(user))
+		for field, value := range user {
+			switch v := value.(type) {
+			case string:
+				record[field] = v
+			case json.Number:
+				record[field] = v.String()
+			case bool:
+				record[field] = strconv.FormatBool(v)
+			}
+		}
+		records = append(records, record)
+	}
+	return records, page.Next, nil
+}
@@ -0,0 +1,58 @@
+package usersync
+
+import (
+	"errors"
+	"fmt"
+	"go-hex/configs"
+	"strings"
+)
+
+// identity is a user of a source once its record is mapped, the empty fields are left unchanged
+type identity struct {
+	ExternalID string
+	Username   string
+	FullName   string
+	Email      string
+	Phone      string
+	Active     bool
+}
+
+// mapRecord maps a record to the identity of a user with the rules of the mapping
+func mapRecord(mapping configs.UserSyncMapping, record Record) (identity, error) {
+	value := func(field string) string {
+		rule, ok := mapping[field]
+		if !ok {
+			return ""
+		}
+		v := strings.TrimSpace(record[rule.Source])
+		switch rule.Transform {
+		case "lower":
+			v = strings.ToLower(v)
+		case "upper":
+			v = strings.ToUpper(v)
+		}
+		return v
+	}
+
+	id := identity{
+		ExternalID: value(configs.UserSyncFieldExternalID),
+		Username:   value(configs.UserSyncFieldUsername),
+		FullName:   value(configs.UserSyncFieldFullName),
+		Email:      value(configs.UserSyncFieldEmail),
+		Phone:      value(configs.UserSyncFieldPhone),
+		Active:     true,
+	}
+	if rule, ok := mapping[configs.UserSyncFieldActive]; ok {
+		id.Active = strings.EqualFold(strings.TrimSpace(record[rule.Source]), rule.Equals)
+	}
+
+	switch {
+	case id.ExternalID == "":
+		return id, errors.New("missing external id")
+	case id.Username == "":
+		return id, errors.New("missing username")
+	case len(id.Username) > maxUsernameLength:
+		return id, fmt.Errorf("username longer than %d characters", maxUsernameLength)
+	}
+	return id, nil
+}
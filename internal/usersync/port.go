@@ -0,0 +1,18 @@
+package usersync
+
+import (
+	"context"
+	"go-hex/internal/domain"
+)
+
+// ServicePort encapsulates the user sync logic.
+type ServicePort interface {
+	// Sync reconciles the users with a source, the changes of a dry run are only returned
+	Sync(ctx context.Context, req RequestSync) (domain.UserSyncRun, error)
+	// SyncAll reconciles the users with every configured source
+	SyncAll(ctx context.Context) error
+	// GetRun returns a sync run with its changes
+	GetRun(ctx context.Context, req RequestRunID) (domain.UserSyncRun, error)
+	// ListRuns returns the latest sync runs without their changes, the newest first
+	ListRuns(ctx context.Context, req RequestListRuns) ([]domain.UserSyncRun, error)
+}
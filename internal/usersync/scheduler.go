@@ -0,0 +1,27 @@
+package usersync
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/pkg/logger"
+	"sync"
+
+	"github.com/go-co-op/gocron"
+)
+
+// RegisterScheduler schedules the sync of the users from every configured source with the configured cron pattern.
+func RegisterScheduler(cfg *configs.Config, log logger.Logger, service ServicePort, cron *gocron.Scheduler, wg *sync.WaitGroup) {
+
+	_, err := cron.Cron(cfg.Scheduler.UserSyncPattern).SingletonMode().Do(func() {
+		wg.Add(1)
+		defer wg.Done()
+
+		err := service.SyncAll(context.Background())
+		if err != nil {
+			log.WithStack(err).Error(err)
+		}
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,423 @@
+package usersync
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/times"
+	"go-hex/pkg/utils"
+	"go-hex/shared/ierr"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Service encapsulates the user sync logic. The users of a source are linked to their external identity
+// when they are created, then only the linked users are updated from the source, and deactivated once
+// they are missing from it. A user already registered with the same username is skipped rather than
+// linked, so that a source cannot take over a local account. Every run is kept as the sync history.
+type Service struct {
+	cfg         *configs.Config
+	repoRegitry port.RepositoryRegistry
+	log         logger.Logger
+	events      event.Bus
+	sources     map[string]Source
+}
+
+// NewService creates and returns a new user sync service of the given sources
+func NewService(cfg *configs.Config, repoRegitry port.RepositoryRegistry, log logger.Logger, events event.Bus, sources ...Source) *Service {
+	bySource := make(map[string]Source, len(sources))
+	for _, source := range sources {
+		bySource[source.Name()] = source
+	}
+	return &Service{cfg, repoRegitry, log, events, bySource}
+}
+
+// Sync reconciles the users with a source, the changes of a dry run are only returned
+func (s *Service) Sync(ctx context.Context, req RequestSync) (domain.UserSyncRun, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return domain.UserSyncRun{}, err
+	}
+
+	source, ok := s.sources[req.Source]
+	if !ok {
+		return domain.UserSyncRun{}, ierr.ErrUserSyncSourceUnknown
+	}
+	return s.run(ctx, source, req.DryRun)
+}
+
+// SyncAll reconciles the users with every configured source
+func (s *Service) SyncAll(ctx context.Context) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	var failed error
+	for _, source := range s.sources {
+		_, err := s.run(ctx, source, false)
+		if err != nil {
+			failed = err
+		}
+	}
+	return failed
+}
+
+// GetRun returns a sync run with its changes
+func (s *Service) GetRun(ctx context.Context, req RequestRunID) (domain.UserSyncRun, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return domain.UserSyncRun{}, err
+	}
+
+	return s.repoRegitry.GetUserSyncRepository().GetRun(ctx, req.ID)
+}
+
+// ListRuns returns the latest sync runs without their changes, the newest first
+func (s *Service) ListRuns(ctx context.Context, req RequestListRuns) ([]domain.UserSyncRun, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return nil, err
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultRunsLimit
+	}
+
+	return s.repoRegitry.GetUserSyncRepository().ListRuns(ctx, req.Source, req.Limit)
+}
+
+// run reconciles the users with the source and records the run, failed or not
+func (s *Service) run(ctx context.Context, source Source, dryRun bool) (domain.UserSyncRun, error) {
+
+	run := domain.UserSyncRun{
+		ID:        utils.GenerateID(),
+		Source:    source.Name(),
+		DryRun:    dryRun,
+		Status:    domain.UserSyncRunSucceeded,
+		StartedAt: times.Now(),
+	}
+	err := s.reconcile(ctx, source, &run)
+	run.FinishedAt = times.Now()
+	if err != nil {
+		run.Status = domain.UserSyncRunFailed
+		reason := err.Error()
+		run.Error = &reason
+	}
+
+	if rErr := s.repoRegitry.GetUserSyncRepository().CreateRun(ctx, run); rErr != nil {
+		if err != nil {
+			s.log.WithStack(rErr).Error(rErr)
+			return run, err
+		}
+		return run, rErr
+	}
+
+	params := logger.Params{"type": "user_sync", "run": run.ID, "source": run.Source, "dry_run": run.DryRun, "status": run.Status,
+		"created": run.Created, "updated": run.Updated, "deactivated": run.Deactivated, "skipped": run.Skipped}
+	if err != nil {
+		s.log.WithParams(params).WithStack(err).Error("user sync failed")
+		otel.RecordErrorChain(ctx, err)
+	} else {
+		s.log.WithParams(params).Info("users synced")
+	}
+	if !dryRun {
+		s.events.Publish(ctx, event.Event{
+			Name:    domain.EventUserSyncCompleted,
+			ActorID: "user-sync:" + run.Source,
+			Attributes: map[string]interface{}{
+				"run_id":      run.ID,
+				"status":      run.Status,
+				"created":     run.Created,
+				"updated":     run.Updated,
+				"deactivated": run.Deactivated,
+				"skipped":     run.Skipped,
+			},
+		})
+	}
+	return run, err
+}
+
+// reconcile creates the users missing from the linked users, updates the linked users which differ from
+// their record and deactivates the linked users missing from the source
+func (s *Service) reconcile(ctx context.Context, source Source, run *domain.UserSyncRun) error {
+
+	fetchCtx, cancel := context.WithTimeout(ctx, time.Duration(s.cfg.UserSync.Timeout)*time.Second)
+	records, err := source.Fetch(fetchCtx)
+	cancel()
+	if err != nil {
+		return err
+	}
+	// an empty source is rather a broken export than the departure of every user
+	if len(records) == 0 {
+		return ierr.ErrUserSyncSourceEmpty
+	}
+
+	links, err := s.repoRegitry.GetUserSyncRepository().ListLinks(ctx, source.Name())
+	if err != nil {
+		return err
+	}
+	linked := make(map[string]domain.UserSyncLink, len(links))
+	for _, link := range links {
+		linked[link.ExternalID] = link
+	}
+
+	seen := make(map[string]bool, len(records))
+	for _, record := range records {
+		id, err := mapRecord(s.cfg.UserSync.Mapping, record)
+		if err == nil && seen[id.ExternalID] {
+			err = errors.New("duplicate external id")
+		}
+		if err != nil {
+			addChange(run, skipped(id, err.Error()))
+			continue
+		}
+		seen[id.ExternalID] = true
+
+		if link, ok := linked[id.ExternalID]; ok {
+			err = s.update(ctx, run, link, id)
+		} else {
+			err = s.create(ctx, run, id)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, link := range links {
+		if seen[link.ExternalID] {
+			continue
+		}
+		err = s.deactivate(ctx, run, link)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// create creates the user of an identity missing from the linked users and links it
+func (s *Service) create(ctx context.Context, run *domain.UserSyncRun, id identity) error {
+
+	// the users left are not created, they are only deactivated once linked
+	if !id.Active {
+		run.Unchanged++
+		return nil
+	}
+
+	exist, err := s.repoRegitry.GetUserRepository().IsUserExistByUsername(ctx, id.Username)
+	if err != nil {
+		return err
+	}
+	if exist {
+		addChange(run, skipped(id, "username is registered by a user not linked to the source"))
+		return nil
+	}
+
+	now := times.Now()
+	user := domain.User{
+		ID:        utils.GenerateID(),
+		Username:  id.Username,
+		Password:  unusablePassword,
+		FullName:  optional(id.FullName),
+		Email:     optional(id.Email),
+		Phone:     optional(id.Phone),
+		IsActive:  true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	change := domain.UserSyncChange{Action: domain.UserSyncActionCreate, ExternalID: id.ExternalID, Username: id.Username, Fields: diff(domain.User{}, id)}
+
+	if !run.DryRun {
+		_, err = s.repoRegitry.DoInTransaction(ctx, func(ctx context.Context, repoRegistry port.RepositoryRegistry) (interface{}, error) {
+			err := repoRegistry.GetUserRepository().Create(ctx, user)
+			if err != nil {
+				return nil, err
+			}
+			return nil, repoRegistry.GetUserSyncRepository().SaveLink(ctx, domain.UserSyncLink{Source: run.Source, ExternalID: id.ExternalID, UserID: user.ID, SyncedAt: now})
+		})
+		if errors.Cause(err) == ierr.ErrUserAlreadyRegistered {
+			addChange(run, skipped(id, "username is registered by a user not linked to the source"))
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		change.UserID = user.ID
+	}
+
+	run.Created++
+	addChange(run, change)
+	return nil
+}
+
+// update applies the fields of an identity which differ from its linked user
+func (s *Service) update(ctx context.Context, run *domain.UserSyncRun, link domain.UserSyncLink, id identity) error {
+
+	repoUser := s.repoRegitry.GetUserRepository()
+	user, err := repoUser.GetByID(ctx, link.UserID)
+	if errors.Cause(err) == ierr.ErrResourceNotFound {
+		return s.create(ctx, run, id)
+	}
+	if err != nil {
+		return err
+	}
+
+	fields := diff(user, id)
+	if len(fields) == 0 {
+		run.Unchanged++
+		return nil
+	}
+	if user.Username != id.Username {
+		exist, err := repoUser.IsUserExistByUsername(ctx, id.Username)
+		if err != nil {
+			return err
+		}
+		if exist {
+			addChange(run, skipped(id, "username is registered by another user"))
+			return nil
+		}
+	}
+
+	if !run.DryRun {
+		now := times.Now()
+		update := domain.User{UpdatedAt: now}
+		for _, field := range fields {
+			switch field.Field {
+			case configs.UserSyncFieldUsername:
+				update.Username = id.Username
+			case configs.UserSyncFieldFullName:
+				update.FullName = optional(id.FullName)
+			case configs.UserSyncFieldEmail:
+				update.Email = optional(id.Email)
+			case configs.UserSyncFieldPhone:
+				update.Phone = optional(id.Phone)
+			}
+		}
+		_, err = s.repoRegitry.DoInTransaction(ctx, func(ctx context.Context, repoRegistry port.RepositoryRegistry) (interface{}, error) {
+			err := repoRegistry.GetUserRepository().Update(ctx, user.ID, update)
+			if err != nil {
+				return nil, err
+			}
+			if user.IsActive != id.Active {
+				err = setActive(ctx, repoRegistry, user.ID, id.Active)
+				if err != nil {
+					return nil, err
+				}
+			}
+			link.SyncedAt = now
+			return nil, repoRegistry.GetUserSyncRepository().SaveLink(ctx, link)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	run.Updated++
+	addChange(run, domain.UserSyncChange{Action: domain.UserSyncActionUpdate, ExternalID: id.ExternalID, UserID: user.ID, Username: id.Username, Fields: fields})
+	return nil
+}
+
+// deactivate deactivates a linked user missing from the source
+func (s *Service) deactivate(ctx context.Context, run *domain.UserSyncRun, link domain.UserSyncLink) error {
+
+	user, err := s.repoRegitry.GetUserRepository().GetByID(ctx, link.UserID)
+	if errors.Cause(err) == ierr.ErrResourceNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !user.IsActive {
+		run.Unchanged++
+		return nil
+	}
+
+	if !run.DryRun {
+		_, err = s.repoRegitry.DoInTransaction(ctx, func(ctx context.Context, repoRegistry port.RepositoryRegistry) (interface{}, error) {
+			err := setActive(ctx, repoRegistry, user.ID, false)
+			if err != nil {
+				return nil, err
+			}
+			link.SyncedAt = times.Now()
+			return nil, repoRegistry.GetUserSyncRepository().SaveLink(ctx, link)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	run.Deactivated++
+	addChange(run, domain.UserSyncChange{Action: domain.UserSyncActionDeactivate, ExternalID: link.ExternalID, UserID: user.ID, Username: user.Username,
+		Fields: []domain.UserSyncFieldChange{{Field: configs.UserSyncFieldActive, From: "true", To: "false"}}})
+	return nil
+}
+
+// setActive activates or deactivates a user, the sessions of a deactivated user are revoked
+func setActive(ctx context.Context, repoRegistry port.RepositoryRegistry, userID string, active bool) error {
+	err := repoRegistry.GetUserRepository().SetActive(ctx, userID, active)
+	if err != nil || active {
+		return err
+	}
+	return repoRegistry.GetSessionRepository().RevokeByUserID(ctx, userID)
+}
+
+// diff returns the fields of the user which differ from the identity, the empty fields of the identity are left unchanged
+func diff(user domain.User, id identity) []domain.UserSyncFieldChange {
+	var fields []domain.UserSyncFieldChange
+	compare := func(field, from, to string) {
+		if to != "" && from != to {
+			fields = append(fields, domain.UserSyncFieldChange{Field: field, From: from, To: to})
+		}
+	}
+	compare(configs.UserSyncFieldUsername, user.Username, id.Username)
+	compare(configs.UserSyncFieldFullName, value(user.FullName), id.FullName)
+	compare(configs.UserSyncFieldEmail, user.GetEmail(), id.Email)
+	compare(configs.UserSyncFieldPhone, user.GetPhone(), id.Phone)
+	compare(configs.UserSyncFieldActive, strconv.FormatBool(user.IsActive), strconv.FormatBool(id.Active))
+	return fields
+}
+
+// addChange counts a skipped change and records the change, up to maxRunChanges
+func addChange(run *domain.UserSyncRun, change domain.UserSyncChange) {
+	if change.Action == domain.UserSyncActionSkip {
+		run.Skipped++
+	}
+	if len(run.Changes) < maxRunChanges {
+		run.Changes = append(run.Changes, change)
+	}
+}
+
+func skipped(id identity, reason string) domain.UserSyncChange {
+	return domain.UserSyncChange{Action: domain.UserSyncActionSkip, ExternalID: id.ExternalID, Username: id.Username, Reason: reason}
+}
+
+func optional(v string) *string {
+	if v == "" {
+		return nil
+	}
+	return &v
+}
+
+func value(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
@@ -0,0 +1,153 @@
+package usersync
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/memory"
+	"go-hex/pkg/event"
+	"go-hex/pkg/logger"
+	"go-hex/shared/ierr"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSource struct {
+	records []Record
+}
+
+func (fakeSource) Name() string {
+	return SourceHRAPI
+}
+
+func (s *fakeSource) Fetch(ctx context.Context) ([]Record, error) {
+	return s.records, nil
+}
+
+func newTestService(t *testing.T, source Source) (*Service, *memory.Store) {
+	cfg := &configs.Config{}
+	cfg.UserSync.Timeout = 5
+	require.NoError(t, cfg.UserSync.Mapping.Decode("external_id:employee_id,username:email|lower,full_name:name,email:email|lower,active:status=active"))
+
+	ctx := context.Background()
+	store := memory.NewStore()
+	for _, user := range []domain.User{
+		{ID: "local", Username: "admin@example.com", IsActive: true},
+		{ID: "alice", Username: "alice@example.com", FullName: optional("Alice"), Email: optional("alice@example.com"), IsActive: true},
+		{ID: "bob", Username: "bob@example.com", IsActive: true},
+	} {
+		require.NoError(t, store.GetUserRepository().Create(ctx, user))
+		require.NoError(t, store.GetSessionRepository().Create(ctx, domain.Session{UserID: user.ID, ExpiresAt: time.Now().Add(time.Hour)}))
+	}
+	for _, link := range []domain.UserSyncLink{
+		{Source: SourceHRAPI, ExternalID: "E1", UserID: "alice"},
+		{Source: SourceHRAPI, ExternalID: "E2", UserID: "bob"},
+	} {
+		require.NoError(t, store.GetUserSyncRepository().SaveLink(ctx, link))
+	}
+	return NewService(cfg, store, logger.New("test", "test"), event.New(), source), store
+}
+
+// users returns the users of the store by ID
+func users(t *testing.T, store *memory.Store) map[string]domain.User {
+	t.Helper()
+	list, err := store.GetUserRepository().List(context.Background(), "", 0)
+	require.NoError(t, err)
+	res := map[string]domain.User{}
+	for _, user := range list {
+		res[user.ID] = user
+	}
+	return res
+}
+
+// links returns the links of the HR API source by external ID
+func links(t *testing.T, store *memory.Store) map[string]domain.UserSyncLink {
+	t.Helper()
+	list, err := store.GetUserSyncRepository().ListLinks(context.Background(), SourceHRAPI)
+	require.NoError(t, err)
+	res := map[string]domain.UserSyncLink{}
+	for _, link := range list {
+		res[link.ExternalID] = link
+	}
+	return res
+}
+
+// runs returns the runs of the HR API source, the latest first
+func runs(t *testing.T, store *memory.Store) []domain.UserSyncRun {
+	t.Helper()
+	list, err := store.GetUserSyncRepository().ListRuns(context.Background(), SourceHRAPI, 0)
+	require.NoError(t, err)
+	return list
+}
+
+func TestSync(t *testing.T) {
+	ctx := context.Background()
+	source := &fakeSource{records: []Record{
+		{"employee_id": "E1", "email": "Alice@Example.com", "name": "Alice Liddell", "status": "active"},
+		{"employee_id": "E3", "email": "carol@example.com", "name": "Carol", "status": "active"},
+		{"employee_id": "E4", "email": "ADMIN@example.com", "name": "Mallory", "status": "active"},
+		{"employee_id": "E5", "email": "dave@example.com", "status": "terminated"},
+		{"employee_id": "E3", "email": "carol2@example.com", "status": "active"},
+		{"employee_id": "", "email": "nobody@example.com", "status": "active"},
+	}}
+	svc, store := newTestService(t, source)
+
+	dry, err := svc.Sync(ctx, RequestSync{Source: SourceHRAPI, DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, domain.UserSyncRunSucceeded, dry.Status)
+	assert.Equal(t, [5]int{1, 1, 1, 3, 1}, [5]int{dry.Created, dry.Updated, dry.Deactivated, dry.Skipped, dry.Unchanged})
+	assert.Len(t, users(t, store), 3, "a dry run must not apply its changes")
+	assert.True(t, users(t, store)["bob"].IsActive)
+
+	run, err := svc.Sync(ctx, RequestSync{Source: SourceHRAPI})
+	require.NoError(t, err)
+	assert.Equal(t, [5]int{1, 1, 1, 3, 1}, [5]int{run.Created, run.Updated, run.Deactivated, run.Skipped, run.Unchanged})
+	assert.Len(t, runs(t, store), 2)
+
+	synced := users(t, store)
+	assert.Equal(t, "Alice Liddell", *synced["alice"].FullName)
+	assert.False(t, synced["bob"].IsActive)
+	for userID, revoked := range map[string]bool{"alice": false, "bob": true} {
+		sessions, err := store.GetSessionRepository().ListActiveByUserID(ctx, userID)
+		require.NoError(t, err)
+		assert.Equal(t, revoked, len(sessions) == 0, userID)
+	}
+	assert.Equal(t, "admin@example.com", synced["local"].Username, "a source must not take over a local account")
+
+	carol := synced[links(t, store)["E3"].UserID]
+	assert.Equal(t, "carol@example.com", carol.Username)
+	assert.True(t, carol.IsActive)
+	assert.Equal(t, unusablePassword, carol.Password)
+	_, linked := links(t, store)["E5"]
+	assert.False(t, linked, "the users left are not created")
+
+	for _, change := range run.Changes {
+		if change.Action == domain.UserSyncActionUpdate {
+			assert.Equal(t, []domain.UserSyncFieldChange{{Field: "full_name", From: "Alice", To: "Alice Liddell"}}, change.Fields)
+		}
+	}
+
+	again, err := svc.Sync(ctx, RequestSync{Source: SourceHRAPI})
+	require.NoError(t, err)
+	assert.Equal(t, [5]int{0, 0, 0, 3, 4}, [5]int{again.Created, again.Updated, again.Deactivated, again.Skipped, again.Unchanged})
+}
+
+func TestSyncFailures(t *testing.T) {
+	ctx := context.Background()
+	svc, store := newTestService(t, &fakeSource{})
+
+	_, err := svc.Sync(ctx, RequestSync{Source: SourceSFTP})
+	assert.Equal(t, ierr.ErrUserSyncSourceUnknown, errors.Cause(err))
+
+	run, err := svc.Sync(ctx, RequestSync{Source: SourceHRAPI})
+	assert.Equal(t, ierr.ErrUserSyncSourceEmpty, errors.Cause(err))
+	assert.Equal(t, domain.UserSyncRunFailed, run.Status)
+	assert.True(t, users(t, store)["alice"].IsActive, "an empty source must not deactivate the users")
+	if failed := runs(t, store); assert.Len(t, failed, 1) {
+		assert.Equal(t, ierr.ErrUserSyncSourceEmpty.Error(), *failed[0].Error)
+	}
+}
@@ -0,0 +1,250 @@
+package usersync
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+)
+
+// SFTP v3 packet types, see draft-ietf-secsh-filexfer-02
+const (
+	sftpInit    = 1
+	sftpVersion = 2
+	sftpOpen    = 3
+	sftpClose   = 4
+	sftpRead    = 5
+	sftpStatus  = 101
+	sftpHandle  = 102
+	sftpData    = 103
+
+	sftpProtocolVersion = 3
+	sftpOpenRead        = 0x1
+	sftpStatusEOF       = 1
+	// sftpChunkSize is the size of the reads, the servers answer at least 32KB
+	sftpChunkSize = 32 << 10
+	// sftpMaxPacket bounds the size of the packets answered by the server
+	sftpMaxPacket = 256 << 10
+)
+
+// SFTPSource reads the users from a CSV file on an SFTP server. The file is downloaded
+// entirely at each sync, its first row names the fields of the records.
+type SFTPSource struct {
+	address string
+	path    string
+	config  *ssh.ClientConfig
+}
+
+// NewSFTPSource creates a source reading the CSV file at path, the server must present the given host key
+func NewSFTPSource(address, user, password, hostKey, path string) (*SFTPSource, error) {
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(hostKey))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid sftp host key")
+	}
+	config := &ssh.ClientConfig{
+		User:            user,
+		Auth:            []ssh.AuthMethod{ssh.Password(password)},
+		HostKeyCallback: ssh.FixedHostKey(key),
+	}
+	return &SFTPSource{address, path, config}, nil
+}
+
+// Name returns the name of the source
+func (s *SFTPSource) Name() string {
+	return SourceSFTP
+}
+
+// Fetch downloads the CSV file and reads its records
+func (s *SFTPSource) Fetch(ctx context.Context) ([]Record, error) {
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", s.address)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot connect to sftp server")
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	c, chans, reqs, err := ssh.NewClientConn(conn, s.address, s.config)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot connect to sftp server")
+	}
+	client := ssh.NewClient(c, chans, reqs)
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot open sftp session")
+	}
+	defer session.Close()
+
+	w, err := session.StdinPipe()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot open sftp session")
+	}
+	r, err := session.StdoutPipe()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot open sftp session")
+	}
+	if err := session.RequestSubsystem("sftp"); err != nil {
+		return nil, errors.Wrap(err, "cannot start sftp subsystem")
+	}
+
+	data, err := readSFTPFile(struct {
+		io.Reader
+		io.Writer
+	}{r, w}, s.path)
+	if err != nil {
+		return nil, err
+	}
+	return parseCSV(data)
+}
+
+// readSFTPFile downloads the file at path with the SFTP protocol spoken over rw
+func readSFTPFile(rw io.ReadWriter, path string) ([]byte, error) {
+
+	err := writeSFTPPacket(rw, sftpInit, appendUint32(nil, sftpProtocolVersion))
+	if err != nil {
+		return nil, err
+	}
+	typ, _, err := readSFTPPacket(rw)
+	if err != nil {
+		return nil, err
+	}
+	if typ != sftpVersion {
+		return nil, fmt.Errorf("unexpected sftp packet %d, expected version", typ)
+	}
+
+	var id uint32 = 1
+	open := appendUint32(nil, id)
+	open = appendString(open, path)
+	open = appendUint32(open, sftpOpenRead)
+	open = appendUint32(open, 0) // no attributes
+	handle, err := sftpRequest(rw, id, sftpOpen, open, sftpHandle)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot open %s", path)
+	}
+	handle, _, err = readString(handle)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	for {
+		id++
+		read := appendUint32(nil, id)
+		read = appendString(read, string(handle))
+		read = appendUint64(read, uint64(len(data)))
+		read = appendUint32(read, sftpChunkSize)
+		chunk, err := sftpRequest(rw, id, sftpRead, read, sftpData)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot read %s", path)
+		}
+		chunk, _, err = readString(chunk)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, chunk...)
+		if len(data) > maxFileSize {
+			return nil, fmt.Errorf("cannot read %s: larger than %d bytes", path, maxFileSize)
+		}
+	}
+
+	id++
+	closing := appendUint32(nil, id)
+	closing = appendString(closing, string(handle))
+	_, err = sftpRequest(rw, id, sftpClose, closing, sftpStatus)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot close %s", path)
+	}
+	return data, nil
+}
+
+// sftpRequest sends a request and returns the payload of its response after the request id.
+// A status response ends the request: io.EOF for the end of file, nil when the status was expected, an error otherwise.
+func sftpRequest(rw io.ReadWriter, id uint32, typ byte, payload []byte, expected byte) ([]byte, error) {
+	err := writeSFTPPacket(rw, typ, payload)
+	if err != nil {
+		return nil, err
+	}
+	resType, res, err := readSFTPPacket(rw)
+	if err != nil {
+		return nil, err
+	}
+	if len(res) < 4 || binary.BigEndian.Uint32(res) != id {
+		return nil, fmt.Errorf("unexpected sftp response, expected request %d", id)
+	}
+	res = res[4:]
+
+	switch resType {
+	case expected:
+		return res, nil
+	case sftpStatus:
+		if len(res) < 4 {
+			return nil, errors.New("short sftp status")
+		}
+		code := binary.BigEndian.Uint32(res)
+		if code == sftpStatusEOF {
+			return nil, io.EOF
+		}
+		message, _, _ := readString(res[4:])
+		return nil, fmt.Errorf("sftp status %d: %s", code, message)
+	}
+	return nil, fmt.Errorf("unexpected sftp packet %d", resType)
+}
+
+func writeSFTPPacket(w io.Writer, typ byte, payload []byte) error {
+	packet := appendUint32(make([]byte, 0, 5+len(payload)), uint32(1+len(payload)))
+	packet = append(packet, typ)
+	packet = append(packet, payload...)
+	_, err := w.Write(packet)
+	return errors.Wrap(err, "cannot write sftp packet")
+}
+
+func readSFTPPacket(r io.Reader) (byte, []byte, error) {
+	var length [4]byte
+	if _, err := io.ReadFull(r, length[:]); err != nil {
+		return 0, nil, errors.Wrap(err, "cannot read sftp packet")
+	}
+	n := binary.BigEndian.Uint32(length[:])
+	if n == 0 || n > sftpMaxPacket {
+		return 0, nil, fmt.Errorf("invalid sftp packet length %d", n)
+	}
+	packet := make([]byte, n)
+	if _, err := io.ReadFull(r, packet); err != nil {
+		return 0, nil, errors.Wrap(err, "cannot read sftp packet")
+	}
+	return packet[0], packet[1:], nil
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	return appendUint32(appendUint32(b, uint32(v>>32)), uint32(v))
+}
+
+func appendString(b []byte, s string) []byte {
+	return append(appendUint32(b, uint32(len(s))), s...)
+}
+
+// readString reads a length prefixed string and returns the rest of b
+func readString(b []byte) ([]byte, []byte, error) {
+	if len(b) < 4 {
+		return nil, nil, errors.New("short sftp string")
+	}
+	n := binary.BigEndian.Uint32(b)
+	if uint32(len(b)-4) < n {
+		return nil, nil, errors.New("short sftp string")
+	}
+	return b[4 : 4+n], b[4+n:], nil
+}
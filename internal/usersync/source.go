@@ -0,0 +1,75 @@
+package usersync
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"go-hex/configs"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Record is a user read from a source, by field of the source
+type Record map[string]string
+
+// Source reads the users of an external system, e.g. the employees of the HR system
+type Source interface {
+	// Name returns the name of the source, the users are linked to their identity in the source by it
+	Name() string
+	// Fetch reads every user of the source
+	Fetch(ctx context.Context) ([]Record, error)
+}
+
+// ConfiguredSources returns the sources enabled by the configuration
+func ConfiguredSources(cfg *configs.Config) ([]Source, error) {
+	var sources []Source
+	if cfg.UserSync.SFTPAddress != "" {
+		source, err := NewSFTPSource(cfg.UserSync.SFTPAddress, cfg.UserSync.SFTPUser, cfg.UserSync.SFTPPassword, cfg.UserSync.SFTPHostKey, cfg.UserSync.SFTPPath)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+	if cfg.UserSync.HRURL != "" {
+		sources = append(sources, NewHRAPISource(cfg.UserSync.HRURL, cfg.UserSync.HRToken))
+	}
+	return sources, nil
+}
+
+// parseCSV reads the records of a CSV file, its first row names the fields
+func parseCSV(data []byte) ([]Record, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "cannot read csv header")
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+
+	var records []Record
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot read csv record")
+		}
+		record := make(Record, len(header))
+		for i, value := range row {
+			if i < len(header) {
+				record[header[i]] = value
+			}
+		}
+		records = append(records, record)
+	}
+}
@@ -0,0 +1,125 @@
+package usersync
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"go-hex/configs"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serveSFTP answers the requests of readSFTPFile for a single file, in chunks of 7 bytes
+func serveSFTP(t *testing.T, conn net.Conn, path string, content []byte) {
+	defer conn.Close()
+	var handle string
+	for {
+		typ, payload, err := readSFTPPacket(conn)
+		if err != nil {
+			return
+		}
+		if typ == sftpInit {
+			_ = writeSFTPPacket(conn, sftpVersion, appendUint32(nil, sftpProtocolVersion))
+			continue
+		}
+		id := binary.BigEndian.Uint32(payload)
+		res := appendUint32(nil, id)
+		switch typ {
+		case sftpOpen:
+			name, _, _ := readString(payload[4:])
+			if string(name) != path {
+				_ = writeSFTPPacket(conn, sftpStatus, appendString(appendUint32(res, 2), "no such file"))
+				continue
+			}
+			handle = "h1"
+			_ = writeSFTPPacket(conn, sftpHandle, appendString(res, handle))
+		case sftpRead:
+			h, rest, _ := readString(payload[4:])
+			assert.Equal(t, handle, string(h))
+			offset := binary.BigEndian.Uint64(rest)
+			if offset >= uint64(len(content)) {
+				_ = writeSFTPPacket(conn, sftpStatus, appendString(appendUint32(res, sftpStatusEOF), "eof"))
+				continue
+			}
+			end := offset + 7
+			if end > uint64(len(content)) {
+				end = uint64(len(content))
+			}
+			_ = writeSFTPPacket(conn, sftpData, appendString(res, string(content[offset:end])))
+		case sftpClose:
+			_ = writeSFTPPacket(conn, sftpStatus, appendString(appendUint32(res, 0), "ok"))
+		}
+	}
+}
+
+func TestReadSFTPFile(t *testing.T) {
+	content := []byte("\xef\xbb\xbfid, username,full_name\n1,alice,\"Liddell, Alice\"\n2,bob\n")
+
+	client, server := net.Pipe()
+	go serveSFTP(t, server, "/exports/users.csv", content)
+	data, err := readSFTPFile(client, "/exports/users.csv")
+	require.NoError(t, err)
+	assert.Equal(t, content, data)
+
+	records, err := parseCSV(data)
+	require.NoError(t, err)
+	assert.Equal(t, []Record{
+		{"id": "1", "username": "alice", "full_name": "Liddell, Alice"},
+		{"id": "2", "username": "bob"},
+	}, records)
+
+	client, server = net.Pipe()
+	go serveSFTP(t, server, "/exports/users.csv", content)
+	_, err = readSFTPFile(client, "/missing.csv")
+	assert.EqualError(t, err, "cannot open /missing.csv: sftp status 2: no such file")
+}
+
+func TestHRAPISource(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("page") == "2" {
+			fmt.Fprint(w, `{"data": [{"id": 2, "username": "bob", "active": false, "manager": {"id": 1}}]}`)
+			return
+		}
+		fmt.Fprint(w, `{"data": [{"id": 1, "username": "alice", "phone": null}], "next": "/users?page=2"}`)
+	}))
+	defer srv.Close()
+
+	records, err := NewHRAPISource(srv.URL+"/users", "secret").Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []Record{
+		{"id": "1", "username": "alice"},
+		{"id": "2", "username": "bob", "active": "false"},
+	}, records)
+
+	_, err = NewHRAPISource(srv.URL+"/users", "wrong").Fetch(context.Background())
+	assert.EqualError(t, err, "cannot call hr api: status 401")
+}
+
+func TestMapRecord(t *testing.T) {
+	var mapping configs.UserSyncMapping
+	require.NoError(t, mapping.Decode("external_id:id,username:mail|lower,active:state=Active"))
+
+	id, err := mapRecord(mapping, Record{"id": " 7 ", "mail": "Bob@Example.com", "state": "active"})
+	require.NoError(t, err)
+	assert.Equal(t, identity{ExternalID: "7", Username: "bob@example.com", Active: true}, id)
+
+	id, err = mapRecord(mapping, Record{"id": "8", "mail": "eve@example.com", "state": "left"})
+	require.NoError(t, err)
+	assert.False(t, id.Active)
+
+	_, err = mapRecord(mapping, Record{"id": "9"})
+	assert.EqualError(t, err, "missing username")
+
+	for _, invalid := range []string{"username:mail", "external_id:id", "external_id:id,username:mail|title", "external_id:id,username:mail,active:state", "external_id:id,username:mail,role:title"} {
+		assert.Error(t, mapping.Decode(invalid), invalid)
+	}
+}
@@ -0,0 +1,104 @@
+package verbosity
+
+import (
+	"go-hex/configs"
+	"go-hex/middleware"
+	"go-hex/shared/ierr"
+	"go-hex/shared/response"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+// RegisterAPI registers a new log verbosity api
+func RegisterAPI(r echo.Group, cfg *configs.Config, service ServicePort) {
+	handler := handler{cfg, service}
+
+	// Internal endpoints
+	internal := r.Group("/internal/log-verbosities", middleware.InternalAPI(cfg.InternalAPI.User, cfg.InternalAPI.Password))
+	internal.POST("", handler.create)
+	internal.GET("", handler.list)
+	internal.DELETE("/:id", handler.delete)
+}
+
+type handler struct {
+	cfg     *configs.Config
+	service ServicePort
+}
+
+// create godoc
+// @Router /internal/log-verbosities [post]
+// @Tags Log Verbosity
+// @Summary Raise the log level of some requests
+// @Description Raise the log level of the requests of a user, or of the requests whose id matches a pattern, until the ttl elapsed
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Param payload body RequestCreateLogVerbosity true " "
+// @Success 201 {object} response.Response{data=domain.LogVerbosity} "Success"
+// @failure 400 {object} response.ErrorResponse400
+// @failure 401 {object} response.ErrorResponse401
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) create(c echo.Context) error {
+	var req RequestCreateLogVerbosity
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	res, err := h.service.Create(c.Request().Context(), req)
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessCreated(c, res, "log verbosity raised")
+}
+
+// list godoc
+// @Router /internal/log-verbosities [get]
+// @Tags Log Verbosity
+// @Summary List the log verbosities
+// @Description List the log verbosities which did not expire yet
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Success 200 {object} response.Response{data=[]domain.LogVerbosity} "Success"
+// @failure 401 {object} response.ErrorResponse401
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) list(c echo.Context) error {
+	res, err := h.service.List(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessOK(c, res)
+}
+
+// delete godoc
+// @Router /internal/log-verbosities/{id} [delete]
+// @Tags Log Verbosity
+// @Summary Restore the log level of some requests
+// @Description Delete a log verbosity before it expires
+// @Accept json
+// @Produce json
+// @Security BasicAuth
+// @Param id path string true "log verbosity id"
+// @Success 200 {object} response.Response "Success"
+// @failure 401 {object} response.ErrorResponse401
+// @failure 404 {object} response.ErrorResponse404
+// @failure 500 {object} response.ErrorResponse500
+func (h handler) delete(c echo.Context) error {
+	var req RequestLogVerbosityID
+	if err := c.Bind(&req); err != nil {
+		return response.ErrBadRequest(err)
+	}
+
+	err := h.service.Delete(c.Request().Context(), req)
+	if err != nil {
+		if errors.Cause(err) == ierr.ErrResourceNotFound {
+			return response.ErrNotFound(err)
+		}
+		return err
+	}
+
+	return response.SuccessOK(c, nil, "log verbosity restored")
+}
@@ -0,0 +1,9 @@
+package verbosity
+
+const (
+	// maxTTL bounds the lifetime of a log verbosity, in seconds
+	maxTTL = 24 * 60 * 60
+)
+
+// levels lists the levels a log verbosity can raise the logs to
+var levels = []interface{}{"info", "debug", "trace"}
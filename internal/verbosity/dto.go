@@ -0,0 +1,46 @@
+package verbosity
+
+import (
+	"regexp"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pkg/errors"
+)
+
+// RequestCreateLogVerbosity request body, either the user id or the request id pattern is required
+type RequestCreateLogVerbosity struct {
+	UserID           string `json:"user_id" example:"1f7a6f2e-3c3e-4a8e-9d4b-8f5d1f2a9c10"`
+	RequestIDPattern string `json:"request_id_pattern" example:"^3fa85f64-"` // regular expression matching the request ids
+	Level            string `json:"level" example:"debug"`
+	TTL              int    `json:"ttl" example:"900"` // in seconds
+	Reason           string `json:"reason" example:"INC-1234 refresh failures"`
+}
+
+func (r *RequestCreateLogVerbosity) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.UserID, validation.When(r.RequestIDPattern == "", validation.Required).Else(validation.Empty), validation.Length(0, 36)),
+		validation.Field(&r.RequestIDPattern, validation.Length(0, 255), validation.By(isRegexp)),
+		validation.Field(&r.Level, validation.Required, validation.In(levels...)),
+		validation.Field(&r.TTL, validation.Required, validation.Min(1), validation.Max(maxTTL)),
+		validation.Field(&r.Reason, validation.Required, validation.Length(1, 255)),
+	)
+}
+
+func isRegexp(value interface{}) error {
+	pattern, _ := value.(string)
+	if _, err := regexp.Compile(pattern); err != nil {
+		return errors.New("must be a valid regular expression")
+	}
+	return nil
+}
+
+// RequestLogVerbosityID request params
+type RequestLogVerbosityID struct {
+	ID string `json:"-" param:"id"`
+}
+
+func (r *RequestLogVerbosityID) Validate() error {
+	return validation.ValidateStruct(r,
+		validation.Field(&r.ID, validation.Required),
+	)
+}
@@ -0,0 +1,16 @@
+package verbosity
+
+import (
+	"context"
+	"go-hex/internal/domain"
+)
+
+// ServicePort encapsulates the log verbosity logic.
+type ServicePort interface {
+	// Create raises the log level of the requests of a user or matching a request id pattern until the ttl elapsed
+	Create(ctx context.Context, req RequestCreateLogVerbosity) (domain.LogVerbosity, error)
+	// List returns the log verbosities which did not expire yet
+	List(ctx context.Context) ([]domain.LogVerbosity, error)
+	// Delete restores the log level of the requests raised by a log verbosity before it expires
+	Delete(ctx context.Context, req RequestLogVerbosityID) error
+}
@@ -0,0 +1,127 @@
+package verbosity
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/internal/repository/port"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/times"
+	"go-hex/pkg/utils"
+	"regexp"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Service encapsulates the log verbosity logic. The verbosities are stored so that every instance
+// applies them: the instance serving a change applies it at once, the others at their next Sync.
+type Service struct {
+	repoRegitry port.RepositoryRegistry
+	log         logger.Logger
+}
+
+// NewService creates and returns a new log verbosity service
+func NewService(repoRegitry port.RepositoryRegistry, log logger.Logger) *Service {
+	return &Service{repoRegitry, log}
+}
+
+// Create raises the log level of the requests of a user or matching a request id pattern until the ttl elapsed
+func (s *Service) Create(ctx context.Context, req RequestCreateLogVerbosity) (domain.LogVerbosity, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return domain.LogVerbosity{}, err
+	}
+
+	now := times.Now()
+	verbosity := domain.LogVerbosity{
+		ID:        utils.GenerateID(),
+		Level:     req.Level,
+		Reason:    req.Reason,
+		CreatedAt: now,
+		ExpiresAt: now.Add(time.Duration(req.TTL) * time.Second),
+	}
+	if req.UserID != "" {
+		verbosity.UserID = &req.UserID
+	} else {
+		verbosity.RequestIDPattern = &req.RequestIDPattern
+	}
+
+	err = s.repoRegitry.GetLogVerbosityRepository().Create(ctx, verbosity)
+	if err != nil {
+		return domain.LogVerbosity{}, err
+	}
+
+	s.log.WithParams(logger.Params{"type": "log_verbosity", "verbosity": verbosity}).Info("log verbosity raised")
+	return verbosity, s.Sync(ctx)
+}
+
+// List returns the log verbosities which did not expire yet
+func (s *Service) List(ctx context.Context) ([]domain.LogVerbosity, error) {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	return s.repoRegitry.GetLogVerbosityRepository().ListActive(ctx, times.Now())
+}
+
+// Delete restores the log level of the requests raised by a log verbosity before it expires
+func (s *Service) Delete(ctx context.Context, req RequestLogVerbosityID) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	err := req.Validate()
+	if err != nil {
+		return err
+	}
+
+	err = s.repoRegitry.GetLogVerbosityRepository().Delete(ctx, req.ID)
+	if err != nil {
+		return err
+	}
+
+	s.log.WithParams(logger.Params{"type": "log_verbosity", "id": req.ID}).Info("log verbosity restored")
+	return s.Sync(ctx)
+}
+
+// Sync applies the stored log verbosities to the logger of this instance
+func (s *Service) Sync(ctx context.Context) error {
+
+	ctx, span := otel.Start(ctx)
+	defer span.End()
+
+	verbosities, err := s.repoRegitry.GetLogVerbosityRepository().ListActive(ctx, times.Now())
+	if err != nil {
+		return err
+	}
+	logger.SetOverrides(overrides(verbosities))
+	return nil
+}
+
+// overrides converts the log verbosities to logger overrides, the invalid ones are skipped
+func overrides(verbosities []domain.LogVerbosity) []logger.Override {
+	res := make([]logger.Override, 0, len(verbosities))
+	for _, verbosity := range verbosities {
+		level, err := logrus.ParseLevel(verbosity.Level)
+		if err != nil {
+			continue
+		}
+		override := logger.Override{Level: level, ExpiresAt: verbosity.ExpiresAt}
+		if verbosity.UserID != nil {
+			override.UserID = *verbosity.UserID
+		} else if verbosity.RequestIDPattern != nil {
+			if override.RequestIDPattern, err = regexp.Compile(*verbosity.RequestIDPattern); err != nil {
+				continue
+			}
+		} else {
+			continue
+		}
+		res = append(res, override)
+	}
+	return res
+}
@@ -0,0 +1,53 @@
+package verbosity
+
+import (
+	"go-hex/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestCreateLogVerbosityValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     RequestCreateLogVerbosity
+		wantErr bool
+	}{
+		{"user", RequestCreateLogVerbosity{UserID: "user-1", Level: "debug", TTL: 900, Reason: "INC-1"}, false},
+		{"request id pattern", RequestCreateLogVerbosity{RequestIDPattern: "^3fa85f64-", Level: "trace", TTL: 900, Reason: "INC-1"}, false},
+		{"no selector", RequestCreateLogVerbosity{Level: "debug", TTL: 900, Reason: "INC-1"}, true},
+		{"both selectors", RequestCreateLogVerbosity{UserID: "user-1", RequestIDPattern: "^3fa85f64-", Level: "debug", TTL: 900, Reason: "INC-1"}, true},
+		{"invalid pattern", RequestCreateLogVerbosity{RequestIDPattern: "(", Level: "debug", TTL: 900, Reason: "INC-1"}, true},
+		{"unknown level", RequestCreateLogVerbosity{UserID: "user-1", Level: "verbose", TTL: 900, Reason: "INC-1"}, true},
+		{"ttl too long", RequestCreateLogVerbosity{UserID: "user-1", Level: "debug", TTL: maxTTL + 1, Reason: "INC-1"}, true},
+		{"no reason", RequestCreateLogVerbosity{UserID: "user-1", Level: "debug", TTL: 900}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			assert.Equal(t, tt.wantErr, err != nil, err)
+		})
+	}
+}
+
+func TestOverrides(t *testing.T) {
+	userID, pattern, invalid := "user-1", "^3fa85f64-", "("
+	expiresAt := time.Now().Add(time.Minute)
+
+	got := overrides([]domain.LogVerbosity{
+		{ID: "v1", UserID: &userID, Level: "debug", ExpiresAt: expiresAt},
+		{ID: "v2", RequestIDPattern: &pattern, Level: "trace", ExpiresAt: expiresAt},
+		{ID: "v3", RequestIDPattern: &invalid, Level: "debug", ExpiresAt: expiresAt},
+		{ID: "v4", UserID: &userID, Level: "verbose", ExpiresAt: expiresAt},
+	})
+
+	if assert.Len(t, got, 2) {
+		assert.Equal(t, userID, got[0].UserID)
+		assert.Equal(t, logrus.DebugLevel, got[0].Level)
+		assert.Equal(t, pattern, got[1].RequestIDPattern.String())
+		assert.Equal(t, logrus.TraceLevel, got[1].Level)
+	}
+}
@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"go-hex/pkg/metrics"
+	"go-hex/shared/ierr"
+	"go-hex/shared/response"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// defaultRouteGroup is the name of the limiter of the routes outside of every group
+const defaultRouteGroup = "default"
+
+var (
+	adaptiveLimitGauge = metrics.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "adaptive_concurrency_limit",
+		Help: "Number of requests allowed in flight per route group.",
+	}, "group")
+	adaptiveLimitRejected = metrics.NewCounterVec(prometheus.CounterOpts{
+		Name: "adaptive_concurrency_rejected_requests_total",
+		Help: "Number of requests shed because their route group reached its concurrency limit.",
+	}, "group")
+)
+
+// AdaptiveLimitConfig configures the additive increase / multiplicative decrease of the concurrency limits.
+type AdaptiveLimitConfig struct {
+	// InitialLimit, MinLimit and MaxLimit bound the number of requests in flight per route group
+	InitialLimit int
+	MinLimit     int
+	MaxLimit     int
+	// LatencyTarget is the latency above which a request is considered a sign of overload
+	LatencyTarget time.Duration
+	// Backoff is the factor applied to the limit on overload, between 0 and 1
+	Backoff float64
+}
+
+// aimdLimit is the concurrency limit of a route group.
+// The limit grows by one per window of successful requests and is multiplied by the backoff
+// when a request fails or exceeds the latency target.
+type aimdLimit struct {
+	cfg AdaptiveLimitConfig
+
+	mu       sync.Mutex
+	limit    float64
+	inFlight int
+}
+
+func newAIMDLimit(cfg AdaptiveLimitConfig) *aimdLimit {
+	return &aimdLimit{cfg: cfg, limit: float64(cfg.InitialLimit)}
+}
+
+// acquire takes a slot when the limit is not reached
+func (l *aimdLimit) acquire() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.inFlight >= int(l.limit) {
+		return false
+	}
+	l.inFlight++
+	return true
+}
+
+// release frees the slot and adjusts the limit from the outcome of the request, it returns the new limit
+func (l *aimdLimit) release(latency time.Duration, failed bool) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inFlight--
+	if failed || latency > l.cfg.LatencyTarget {
+		l.limit *= l.cfg.Backoff
+	} else {
+		l.limit += 1 / l.limit
+	}
+
+	if l.limit < float64(l.cfg.MinLimit) {
+		l.limit = float64(l.cfg.MinLimit)
+	}
+	if l.limit > float64(l.cfg.MaxLimit) {
+		l.limit = float64(l.cfg.MaxLimit)
+	}
+	return int(l.limit)
+}
+
+// AdaptiveLimit sheds the requests of a route group once it has as many requests in flight as its limit,
+// rejecting them early with service unavailable instead of letting the server collapse under overload.
+// Every route group, and the routes outside of every group, has its own limit adjusted from the latency
+// and the server errors of its requests.
+func AdaptiveLimit(cfg AdaptiveLimitConfig, groups ...RouteGroup) echo.MiddlewareFunc {
+
+	limits := make([]*aimdLimit, len(groups)+1)
+	names := make([]string, len(groups)+1)
+	for i := range limits {
+		limits[i] = newAIMDLimit(cfg)
+		names[i] = defaultRouteGroup
+		if i < len(groups) {
+			names[i] = groups[i].Name
+		}
+		adaptiveLimitGauge.WithLabelValues(names[i]).Set(float64(cfg.InitialLimit))
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			i := matchGroup(groups, c.Path())
+			if i < 0 {
+				i = len(groups)
+			}
+			limit := limits[i]
+
+			if !limit.acquire() {
+				adaptiveLimitRejected.WithLabelValues(names[i]).Inc()
+				c.Response().Header().Set(echo.HeaderRetryAfter, "1")
+				return response.HTTPError(ierr.ErrServiceUnavailable, http.StatusServiceUnavailable, ierr.ErrServiceUnavailable.Code, ierr.ErrServiceUnavailable.Message)
+			}
+
+			start := time.Now()
+			err := next(c)
+
+			current := limit.release(time.Since(start), isServerError(c, err))
+			adaptiveLimitGauge.WithLabelValues(names[i]).Set(float64(current))
+			return err
+		}
+	}
+}
+
+// isServerError checks whether the request failed because of the server.
+// The errors are written by the error handler after the middlewares, so the status is taken from the error.
+func isServerError(c echo.Context, err error) bool {
+	if err == nil {
+		return c.Response().Status >= http.StatusInternalServerError
+	}
+	if res, ok := err.(response.ErrorResponse); ok {
+		return res.HTTPCode >= http.StatusInternalServerError && res.HTTPCode != http.StatusServiceUnavailable
+	}
+	return true
+}
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"errors"
+	"go-hex/shared/ierr"
+	"go-hex/shared/response"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func testAdaptiveLimitConfig() AdaptiveLimitConfig {
+	return AdaptiveLimitConfig{
+		InitialLimit:  4,
+		MinLimit:      2,
+		MaxLimit:      5,
+		LatencyTarget: 100 * time.Millisecond,
+		Backoff:       0.5,
+	}
+}
+
+func TestAIMDLimitRejectsAboveTheLimit(t *testing.T) {
+	limit := newAIMDLimit(testAdaptiveLimitConfig())
+
+	for i := 0; i < 4; i++ {
+		assert.True(t, limit.acquire())
+	}
+	assert.False(t, limit.acquire())
+
+	limit.release(time.Millisecond, false)
+	assert.True(t, limit.acquire())
+}
+
+func TestAIMDLimitRelease(t *testing.T) {
+	tests := []struct {
+		name    string
+		latency time.Duration
+		failed  bool
+		times   int
+		want    int
+	}{
+		{name: "fast successes increase the limit", latency: time.Millisecond, times: 5, want: 5},
+		{name: "the limit never exceeds the maximum", latency: time.Millisecond, times: 50, want: 5},
+		{name: "a slow request halves the limit", latency: time.Second, times: 1, want: 2},
+		{name: "a server error halves the limit", latency: time.Millisecond, failed: true, times: 1, want: 2},
+		{name: "the limit never drops below the minimum", latency: time.Second, times: 5, want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit := newAIMDLimit(testAdaptiveLimitConfig())
+
+			var got int
+			for i := 0; i < tt.times; i++ {
+				assert.True(t, limit.acquire())
+				got = limit.release(tt.latency, tt.failed)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestIsServerError(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		err    error
+		want   bool
+	}{
+		{name: "success", status: http.StatusOK, want: false},
+		{name: "written server error", status: http.StatusBadGateway, want: true},
+		{name: "client error", err: response.ErrBadRequest(ierr.ErrBadRequest), want: false},
+		{name: "shed request", err: response.HTTPError(ierr.ErrServiceUnavailable, http.StatusServiceUnavailable, ierr.ErrServiceUnavailable.Code, ierr.ErrServiceUnavailable.Message), want: false},
+		{name: "internal error response", err: response.HTTPError(ierr.ErrInternal, http.StatusInternalServerError, ierr.ErrInternal.Code, ierr.ErrInternal.Message), want: true},
+		{name: "unexpected error", err: errors.New("boom"), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+			if tt.status != 0 {
+				c.Response().WriteHeader(tt.status)
+			}
+			assert.Equal(t, tt.want, isServerError(c, tt.err))
+		})
+	}
+}
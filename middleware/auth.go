@@ -2,26 +2,31 @@ package middleware
 
 import (
 	"context"
+	"crypto/subtle"
+	"go-hex/internal/domain"
 	"go-hex/pkg/auth"
+	"go-hex/pkg/logger"
+	"go-hex/shared/ctxutil"
 	"go-hex/shared/ierr"
 	"go-hex/shared/response"
 	"net/http"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
 )
 
 // VerifyJWT is a JWT middleware that verify the logged in user and set user context if verified.
 // And will set user context to nil if not
-func VerifyJWT(signingKey string) echo.MiddlewareFunc {
+func VerifyJWT(keys *auth.Keys) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 
-			token, err := auth.VerifyTokenFromRequest(c, signingKey)
+			token, err := auth.VerifyTokenFromRequest(c, keys)
 			if err != nil {
 				return next(c)
 			}
-			c.Set("user", token)
+			c.SetRequest(c.Request().WithContext(ctxutil.WithPrincipal(c.Request().Context(), token)))
 
 			return next(c)
 		}
@@ -30,36 +35,224 @@ func VerifyJWT(signingKey string) echo.MiddlewareFunc {
 
 // MustLoggedIn is a JWT middleware that verify the logged in user and set user context if verified.
 // And will set user context if not
-func MustLoggedIn(signingKey string) echo.MiddlewareFunc {
+func MustLoggedIn(keys *auth.Keys) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			token, err := auth.VerifyTokenFromRequest(c, signingKey)
+			token, err := auth.VerifyTokenFromRequest(c, keys)
 			if err != nil {
+				if isExpired(err) {
+					return response.ErrUnauthorized(ierr.ErrExpiredToken)
+				}
 				return response.HTTPError(err, http.StatusUnauthorized, ierr.ErrUnauthorized.Code, ierr.ErrUnauthorized.Message)
 			}
 
+			if !isUserAccessToken(token) {
+				return response.ErrUnauthorized(ierr.ErrUnauthorized)
+			}
+
+			ctx := ctxutil.WithPrincipal(c.Request().Context(), token)
+			ctx = logger.WithUserID(ctx, auth.GetLoggedInUser(ctx).ID)
+			r := c.Request().WithContext(ctx)
+			c.SetRequest(r)
+
+			if _, ok := ctxutil.Principal(ctx); ok {
+				return next(c)
+			}
+
+			// else return unauthorized
+			return response.ErrUnauthorized(ierr.ErrUnauthorized)
+
+		}
+	}
+}
+
+// MayLoggedIn sets the logged in user like MustLoggedIn when the request carries the access token of a user,
+// the requests without it are let through without logged in user, for the routes serving both.
+func MayLoggedIn(keys *auth.Keys) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			token, err := auth.VerifyTokenFromRequest(c, keys)
+			if err != nil || !isUserAccessToken(token) {
+				return next(c)
+			}
+
+			ctx := ctxutil.WithPrincipal(c.Request().Context(), token)
+			ctx = logger.WithUserID(ctx, auth.GetLoggedInUser(ctx).ID)
+			c.SetRequest(c.Request().WithContext(ctx))
+			return next(c)
+		}
+	}
+}
+
+// isExpired checks whether the token was rejected only because it expired
+func isExpired(err error) bool {
+	var verr *jwt.ValidationError
+	return errors.As(err, &verr) && verr.Errors == jwt.ValidationErrorExpired
+}
+
+// isUserAccessToken checks that the token is an access token of a user, service account tokens are signed with
+// the same key but are not meant for the user routes
+func isUserAccessToken(token *jwt.Token) bool {
+	claims := token.Claims.(jwt.MapClaims)
+
+	var tokenType string
+	if val, ok := claims["token_type"].(string); ok {
+		tokenType = val
+	}
+	if tokenType != "access" {
+		return false
+	}
+
+	principalType, _ := claims["principal_type"].(string)
+	return principalType != domain.PrincipalTypeServiceAccount
+}
+
+// SessionVerifier checks whether the session of an access token is still active.
+type SessionVerifier interface {
+	IsSessionActive(ctx context.Context, sessionID string) (bool, error)
+}
+
+// VerifySession rejects the requests carrying an access token whose session has been revoked,
+// e.g. by a logout, so that the token stops working before it expires.
+// Tokens without session (sid claim) and invalid tokens are left to the other middlewares.
+func VerifySession(keys *auth.Keys, sessions SessionVerifier) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			token, err := auth.VerifyTokenFromRequest(c, keys)
+			if err != nil {
+				return next(c)
+			}
+
+			claims := token.Claims.(jwt.MapClaims)
+			tokenType, _ := claims["token_type"].(string)
+			sessionID, _ := claims["sid"].(string)
+			if tokenType != "access" || sessionID == "" {
+				return next(c)
+			}
+
+			active, err := sessions.IsSessionActive(c.Request().Context(), sessionID)
+			if err != nil {
+				return err
+			}
+			if !active {
+				return response.ErrUnauthorized(ierr.ErrTokenRevoked)
+			}
+			return next(c)
+		}
+	}
+}
+
+// TokenBlacklist checks whether an access token has been revoked before it expires.
+type TokenBlacklist interface {
+	IsRevoked(ctx context.Context, tokenID string) (bool, error)
+}
+
+// RejectRevokedTokens rejects the requests carrying an access token revoked by its jti claim, e.g. by a logout.
+// Tokens without jti and invalid tokens are left to the other middlewares.
+func RejectRevokedTokens(keys *auth.Keys, blacklist TokenBlacklist) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			token, err := auth.VerifyTokenFromRequest(c, keys)
+			if err != nil {
+				return next(c)
+			}
+
 			claims := token.Claims.(jwt.MapClaims)
+			tokenType, _ := claims["token_type"].(string)
+			tokenID, _ := claims["jti"].(string)
+			if tokenType != "access" || tokenID == "" {
+				return next(c)
+			}
 
-			var tokenType string
-			if val, ok := claims["token_type"].(string); ok {
-				tokenType = val
+			revoked, err := blacklist.IsRevoked(c.Request().Context(), tokenID)
+			if err != nil {
+				return err
+			}
+			if revoked {
+				return response.ErrUnauthorized(ierr.ErrTokenRevoked)
+			}
+			return next(c)
+		}
+	}
+}
+
+// OpaqueTokenResolver returns the signed access token referenced by an opaque access token.
+type OpaqueTokenResolver interface {
+	ResolveAccessToken(ctx context.Context, opaqueToken string) (string, error)
+}
+
+// ResolveOpaqueTokens replaces the opaque access token of the request by the signed token it references, so that
+// the other middlewares verify it as any signed token, and keeps the opaque token in the context.
+// An unknown, expired or deleted opaque token is removed from the request, which is then anonymous.
+// It runs before the routing (echo.Pre), ahead of every middleware reading the token.
+func ResolveOpaqueTokens(resolver OpaqueTokenResolver) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			opaqueToken, ok := auth.OpaqueTokenFromRequest(c)
+			if !ok {
+				return next(c)
 			}
 
+			r := c.Request()
+			accessToken, err := resolver.ResolveAccessToken(r.Context(), opaqueToken)
+			if err != nil {
+				if errors.Cause(err) != ierr.ErrResourceNotFound {
+					return err
+				}
+				r.Header.Del(echo.HeaderAuthorization)
+				return next(c)
+			}
+
+			r.Header.Set(echo.HeaderAuthorization, "Bearer "+accessToken)
+			c.SetRequest(r.WithContext(ctxutil.WithOpaqueToken(r.Context(), opaqueToken)))
+			return next(c)
+		}
+	}
+}
+
+// InternalAPIOrRole accepts either the internal api credentials or an access token holding the role, so that
+// the backend services can call the internal routes with their own identity. The roles are held by the service
+// accounts and by the users whose elevation to the role has been approved.
+func InternalAPIOrRole(user, password string, keys *auth.Keys, role string) echo.MiddlewareFunc {
+	internalAPI := InternalAPI(user, password)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		basicAuth := internalAPI(next)
+		return func(c echo.Context) error {
+			token, err := auth.VerifyTokenFromRequest(c, keys)
+			if err != nil {
+				return basicAuth(c)
+			}
+
+			claims := token.Claims.(jwt.MapClaims)
+			tokenType, _ := claims["token_type"].(string)
 			if tokenType != "access" {
 				return response.ErrUnauthorized(ierr.ErrUnauthorized)
 			}
 
-			ctx := context.WithValue(c.Request().Context(), auth.ContextKeyUser, token)
-			r := c.Request().WithContext(ctx)
-			c.SetRequest(r)
+			roles, _ := claims["roles"].([]interface{})
+			for _, item := range roles {
+				if val, ok := item.(string); ok && val == role {
+					return next(c)
+				}
+			}
+			return response.ErrForbidden(ierr.ErrForbidden)
+		}
+	}
+}
 
-			if ctx.Value(auth.ContextKeyUser) != nil {
+// InternalAPI is a basic auth middleware protecting the internal endpoints with the internal api credentials.
+func InternalAPI(user, password string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			username, pwd, ok := c.Request().BasicAuth()
+			if ok &&
+				subtle.ConstantTimeCompare([]byte(username), []byte(user)) == 1 &&
+				subtle.ConstantTimeCompare([]byte(pwd), []byte(password)) == 1 {
 				return next(c)
 			}
 
-			// else return unauthorized
+			c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Basic realm="internal"`)
 			return response.ErrUnauthorized(ierr.ErrUnauthorized)
-
 		}
 	}
 }
@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"context"
+	"go-hex/pkg/auth"
+	"go-hex/shared/ctxutil"
+	"go-hex/shared/ierr"
+	"go-hex/shared/response"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTokenBlacklist map[string]bool
+
+func (b fakeTokenBlacklist) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	return b[tokenID], nil
+}
+
+func TestRejectRevokedTokens(t *testing.T) {
+	keys := auth.NewHS256Keys("secret")
+	router := echo.New()
+	router.HTTPErrorHandler = func(err error, c echo.Context) {
+		_ = c.NoContent(err.(response.ErrorResponse).StatusCode())
+	}
+	router.Use(RejectRevokedTokens(keys, fakeTokenBlacklist{"revoked": true}))
+	router.GET("/me", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+
+	serve := func(claims jwt.MapClaims) int {
+		claims["exp"] = time.Now().Add(time.Minute).Unix()
+		token, err := keys.Signer().Sign(claims)
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, serve(jwt.MapClaims{"token_type": "access", "jti": "revoked"}))
+	assert.Equal(t, http.StatusOK, serve(jwt.MapClaims{"token_type": "access", "jti": "active"}))
+	assert.Equal(t, http.StatusOK, serve(jwt.MapClaims{"token_type": "access"}), "the tokens without jti are left to the session check")
+}
+
+type fakeOpaqueTokenResolver map[string]string
+
+func (r fakeOpaqueTokenResolver) ResolveAccessToken(ctx context.Context, opaqueToken string) (string, error) {
+	accessToken, ok := r[opaqueToken]
+	if !ok {
+		return "", ierr.ErrResourceNotFound
+	}
+	return accessToken, nil
+}
+
+func TestResolveOpaqueTokens(t *testing.T) {
+	keys := auth.NewHS256Keys("secret")
+	accessToken, err := keys.Signer().Sign(jwt.MapClaims{"id": "u1", "token_type": "access", "exp": time.Now().Add(time.Minute).Unix()})
+	require.NoError(t, err)
+
+	router := echo.New()
+	router.HTTPErrorHandler = func(err error, c echo.Context) {
+		_ = c.NoContent(err.(response.ErrorResponse).StatusCode())
+	}
+	router.Pre(ResolveOpaqueTokens(fakeOpaqueTokenResolver{"oat_known": accessToken}))
+	router.GET("/me", func(c echo.Context) error {
+		opaqueToken, _ := ctxutil.OpaqueToken(c.Request().Context())
+		return c.String(http.StatusOK, auth.GetLoggedInUser(c.Request().Context()).ID+" "+opaqueToken)
+	}, MustLoggedIn(keys))
+
+	serve := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve("oat_known")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "u1 oat_known", rec.Body.String())
+	assert.Equal(t, http.StatusUnauthorized, serve("oat_unknown").Code)
+	rec = serve(accessToken)
+	assert.Equal(t, http.StatusOK, rec.Code, "the signed tokens are left as is")
+	assert.Equal(t, "u1 ", rec.Body.String())
+}
+
+func TestMustLoggedInReason(t *testing.T) {
+	keys := auth.NewHS256Keys("secret")
+	router := echo.New()
+	router.HTTPErrorHandler = func(err error, c echo.Context) {
+		_ = c.String(err.(response.ErrorResponse).StatusCode(), err.(response.ErrorResponse).Reason)
+	}
+	router.GET("/me", func(c echo.Context) error { return c.NoContent(http.StatusOK) }, MustLoggedIn(keys))
+
+	serve := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	sign := func(keys *auth.Keys, exp time.Duration) string {
+		token, err := keys.Signer().Sign(jwt.MapClaims{"id": "u1", "token_type": "access", "exp": time.Now().Add(exp).Unix()})
+		require.NoError(t, err)
+		return token
+	}
+
+	assert.Equal(t, http.StatusOK, serve(sign(keys, time.Minute)).Code)
+	rec := serve(sign(keys, -time.Minute))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, response.ReasonTokenExpired, rec.Body.String())
+	rec = serve(sign(auth.NewHS256Keys("other"), -time.Minute))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, response.ReasonInvalidToken, rec.Body.String(), "an expired token with a wrong signature is invalid")
+}
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"go-hex/pkg/metrics"
+	"go-hex/shared/ierr"
+	"go-hex/shared/response"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	bulkheadInFlight = metrics.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "bulkhead_in_flight_requests",
+		Help: "Number of requests being handled per bulkhead.",
+	}, "bulkhead")
+	bulkheadRejected = metrics.NewCounterVec(prometheus.CounterOpts{
+		Name: "bulkhead_rejected_requests_total",
+		Help: "Number of requests rejected because their bulkhead was full.",
+	}, "bulkhead")
+)
+
+// BulkheadPartition bounds the number of requests of a route group handled concurrently.
+// A zero limit leaves the group unbounded.
+type BulkheadPartition struct {
+	RouteGroup
+	Limit int
+}
+
+// Bulkhead isolates the route groups from each other by giving each of them its own pool of slots,
+// so that heavy admin exports cannot exhaust the capacity needed by the login and refresh endpoints.
+// A request waits up to queueTimeout for a slot of its group and is rejected with service unavailable otherwise.
+// The requests of the routes outside of every group are not bounded.
+func Bulkhead(queueTimeout time.Duration, partitions ...BulkheadPartition) echo.MiddlewareFunc {
+
+	groups := make([]RouteGroup, len(partitions))
+	slots := make([]chan struct{}, len(partitions))
+	for i, partition := range partitions {
+		groups[i] = partition.RouteGroup
+		if partition.Limit > 0 {
+			slots[i] = make(chan struct{}, partition.Limit)
+		}
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			i := matchGroup(groups, c.Path())
+			if i < 0 || slots[i] == nil {
+				return next(c)
+			}
+			name := groups[i].Name
+
+			if !acquire(c, slots[i], queueTimeout) {
+				bulkheadRejected.WithLabelValues(name).Inc()
+				c.Response().Header().Set(echo.HeaderRetryAfter, "1")
+				return response.HTTPError(ierr.ErrServiceUnavailable, http.StatusServiceUnavailable, ierr.ErrServiceUnavailable.Code, ierr.ErrServiceUnavailable.Message)
+			}
+			defer func() { <-slots[i] }()
+
+			bulkheadInFlight.WithLabelValues(name).Inc()
+			defer bulkheadInFlight.WithLabelValues(name).Dec()
+
+			return next(c)
+		}
+	}
+}
+
+// acquire takes a slot, waiting up to timeout for one to be released or until the request is cancelled
+func acquire(c echo.Context, slots chan struct{}, timeout time.Duration) bool {
+	select {
+	case slots <- struct{}{}:
+		return true
+	default:
+	}
+	if timeout <= 0 {
+		return false
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-c.Request().Context().Done():
+		return false
+	}
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"go-hex/shared/response"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBulkheadIsolatesRouteGroups(t *testing.T) {
+	admin := RouteGroup{Name: "admin", Prefixes: []string{"/internal/"}}
+	login := RouteGroup{Name: "auth", Prefixes: []string{"/auth/"}}
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	router := echo.New()
+	router.HTTPErrorHandler = func(err error, c echo.Context) {
+		_ = c.NoContent(err.(response.ErrorResponse).StatusCode())
+	}
+	router.Use(Bulkhead(10*time.Millisecond,
+		BulkheadPartition{RouteGroup: admin, Limit: 1},
+		BulkheadPartition{RouteGroup: login, Limit: 1},
+	))
+	router.GET("/internal/export", func(c echo.Context) error {
+		started <- struct{}{}
+		<-release
+		return c.NoContent(http.StatusOK)
+	})
+	router.POST("/auth/login", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+	router.GET("/health", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+		return rec
+	}
+
+	// a slow export holds the only admin slot
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/internal/export").Code)
+	}()
+	<-started
+
+	rejected := serve(http.MethodGet, "/internal/export")
+	assert.Equal(t, http.StatusServiceUnavailable, rejected.Code)
+	assert.Equal(t, "1", rejected.Header().Get(echo.HeaderRetryAfter))
+
+	assert.Equal(t, http.StatusOK, serve(http.MethodPost, "/auth/login").Code)
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/health").Code)
+
+	close(release)
+	wg.Wait()
+	assert.Equal(t, http.StatusOK, serve(http.MethodPost, "/auth/login").Code)
+}
+
+func TestRouteGroupMatch(t *testing.T) {
+	group := RouteGroup{Name: "admin", Prefixes: []string{"/internal/", "/metrics"}}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{path: "/internal/service-accounts", want: true},
+		{path: "/metrics", want: true},
+		{path: "/internal", want: false},
+		{path: "/auth/login", want: false},
+		{path: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.want, group.Match(tt.path))
+		})
+	}
+}
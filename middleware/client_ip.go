@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"net"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+// IPExtractor returns the extractor of the IP address of the clients, read by RealIP to key the rate limits and the
+// signup velocity. The address is read from X-Forwarded-For only through the proxies of the trusted CIDR ranges, the
+// rightmost untrusted address being the client, and is the address of the connection without trusted proxies, so that
+// the clients cannot choose their address by sending the headers themselves.
+func IPExtractor(trustedProxies []string) (echo.IPExtractor, error) {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect(), nil
+	}
+
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, cidr := range trustedProxies {
+		_, ipRange, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid trusted proxy %q", cidr)
+		}
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+	return echo.ExtractIPFromXFFHeader(options...), nil
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"go-hex/configs"
+	"go-hex/pkg/ratelimit"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIPExtractor(t *testing.T) {
+	request := func(remoteAddr, forwardedFor string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set(echo.HeaderXForwardedFor, forwardedFor)
+		req.Header.Set(echo.HeaderXRealIP, "192.0.2.1")
+		return req
+	}
+
+	direct, err := IPExtractor(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "203.0.113.7", direct(request("203.0.113.7:1234", "198.51.100.1")), "the headers are ignored without trusted proxies")
+
+	proxied, err := IPExtractor([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+	assert.Equal(t, "198.51.100.2", proxied(request("10.0.0.5:1234", "198.51.100.1, 198.51.100.2, 10.0.0.4")), "the rightmost untrusted address")
+	assert.Equal(t, "203.0.113.7", proxied(request("203.0.113.7:1234", "198.51.100.1")), "an untrusted peer is the client")
+	assert.Equal(t, "192.168.1.5", proxied(request("192.168.1.5:1234", "198.51.100.1")), "the private networks are not trusted by default")
+
+	_, err = IPExtractor([]string{"10.0.0.0"})
+	assert.Error(t, err)
+}
+
+func TestLimitLoginsIgnoresForgedForwardedFor(t *testing.T) {
+	logins := RouteGroup{Name: "login", Prefixes: []string{"/auth/login"}}
+	cfg := &configs.Config{}
+	cfg.RateLimit.LoginPerIP = 2
+	router := newRateLimitedRouter(LimitLogins(ratelimit.NewMemoryStore(), logins, cfg))
+	router.IPExtractor, _ = IPExtractor(nil)
+
+	login := func(forwardedFor string) int {
+		req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(`{"username":"`+forwardedFor+`"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(echo.HeaderXForwardedFor, forwardedFor)
+		req.Header.Set(echo.HeaderXRealIP, forwardedFor)
+		req.RemoteAddr = "203.0.113.7:1234"
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, login("198.51.100.1"))
+	assert.Equal(t, http.StatusOK, login("198.51.100.2"))
+	assert.Equal(t, http.StatusTooManyRequests, login("198.51.100.3"), "the key is the address of the connection, whatever the headers")
+}
+
+func TestRateLimitIgnoresForgedForwardedFor(t *testing.T) {
+	router := newRateLimitedRouter(RateLimit(1))
+	router.IPExtractor, _ = IPExtractor([]string{"10.0.0.0/8"})
+
+	serve := func(remoteAddr, forwardedFor string) int {
+		req := httptest.NewRequest(http.MethodGet, "/users", nil)
+		req.Header.Set(echo.HeaderXForwardedFor, forwardedFor)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve("203.0.113.7:1234", "198.51.100.1"))
+	assert.Equal(t, http.StatusTooManyRequests, serve("203.0.113.7:1234", "198.51.100.2"), "a client outside of the proxies cannot forge its address")
+	assert.Equal(t, http.StatusOK, serve("10.0.0.5:1234", "198.51.100.1"), "the address forwarded by a trusted proxy")
+	assert.Equal(t, http.StatusTooManyRequests, serve("10.0.0.6:1234", "198.51.100.2, 198.51.100.1"), "the rightmost address forwarded, whatever the client prepended")
+}
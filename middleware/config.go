@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"go-hex/configs"
+
+	"github.com/labstack/echo/v4"
+)
+
+// CurrentConfig carries the current configuration in the context of the request, read with configs.FromContext,
+// so that a request sees the reloaded variables from its start to its end
+func CurrentConfig(current func() *configs.Config) echo.MiddlewareFunc {
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.SetRequest(c.Request().WithContext(configs.WithContext(c.Request().Context(), current())))
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"go-hex/shared/ctxutil"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Locale carries the locale preferred by the caller in the context of the request, read by ctxutil.Locale, the one
+// of highest quality of its Accept-Language header
+func Locale() echo.MiddlewareFunc {
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if locale, ok := ctxutil.PreferredLocale(c.Request().Header.Get("Accept-Language")); ok {
+				c.SetRequest(c.Request().WithContext(ctxutil.WithLocale(c.Request().Context(), locale)))
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"go-hex/pkg/times"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// MinDuration delays the responses until the given duration elapsed since the request was received, so that
+// the time taken by a response does not tell which path handled the request, e.g. whether an account exists.
+// A request cancelled meanwhile is answered at once. A zero duration disables it.
+func MinDuration(d time.Duration) echo.MiddlewareFunc {
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		if d <= 0 {
+			return next
+		}
+		return func(c echo.Context) error {
+			// the error is answered by the error handler once returned, so both are delayed
+			defer times.Envelope(c.Request().Context(), d)()
+			return next(c)
+		}
+	}
+}
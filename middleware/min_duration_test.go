@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMinDuration(t *testing.T) {
+	router := echo.New()
+	router.POST("/login", func(c echo.Context) error {
+		if c.QueryParam("known") == "" {
+			return echo.NewHTTPError(http.StatusUnauthorized, "invalid credentials")
+		}
+		time.Sleep(20 * time.Millisecond)
+		return errors.New("invalid credentials")
+	}, MinDuration(100*time.Millisecond))
+
+	for _, target := range []string{"/login", "/login?known=1"} {
+		start := time.Now()
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, target, nil))
+		elapsed := time.Since(start)
+		assert.GreaterOrEqual(t, elapsed, 100*time.Millisecond, target)
+		assert.Less(t, elapsed, 400*time.Millisecond, target)
+	}
+}
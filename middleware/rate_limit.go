@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"go-hex/pkg/ratelimit"
+
+	"github.com/labstack/echo/v4"
+)
+
+// policyIP is the policy limiting the requests per IP address of a route
+const policyIP = "ip"
+
+// RateLimit limits the requests of an IP address to the given number per minute, in a token bucket of the memory of
+// the instance per route, the requests above the limit are rejected with too many requests until the next token.
+// The address is the RealIP of the router, read from X-Forwarded-For through the trusted proxies of IPExtractor only.
+// A zero limit disables it.
+func RateLimit(perMinute int) echo.MiddlewareFunc {
+
+	if perMinute <= 0 {
+		return func(next echo.HandlerFunc) echo.HandlerFunc {
+			return next
+		}
+	}
+
+	store := ratelimit.NewMemoryStore()
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if err := take(c, store, policyIP, "ip:"+c.RealIP(), perMinute); err != nil {
+				return err
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"go-hex/configs"
+	"go-hex/pkg/auth"
+	"go-hex/pkg/ratelimit"
+	"go-hex/shared/ierr"
+	"go-hex/shared/response"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+// The policies limiting the requests, labelling the metrics
+const (
+	policyIdentity = "identity"
+	policyRoute    = "route"
+)
+
+// maxLoginBodySize is the size of the largest login body, in bytes, far above a username and a password
+const maxLoginBodySize = 8 << 10
+
+// LimitLogins limits the login attempts of the routes of the group per IP address and per username, read from the
+// JSON or form body of the request, in attempts per minute. The limits are read from the configuration of the
+// request, so that they are reloaded, and a zero limit disables its policy.
+func LimitLogins(store ratelimit.Store, logins RouteGroup, cfg *configs.Config) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if c.Request().Method != http.MethodPost || !logins.Match(c.Path()) {
+				return next(c)
+			}
+			limit := configs.FromContext(c.Request().Context(), cfg).RateLimit
+			if err := take(c, store, ratelimit.PolicyLoginIP, "login:ip:"+c.RealIP(), limit.LoginPerIP); err != nil {
+				return err
+			}
+			username, err := loginUsername(c)
+			if err != nil {
+				return response.ErrBadRequest(err)
+			}
+			if username != "" {
+				if err := take(c, store, ratelimit.PolicyLoginUsername, "login:username:"+username, limit.LoginPerUsername); err != nil {
+					return err
+				}
+			}
+			return next(c)
+		}
+	}
+}
+
+// LimitIdentities limits the requests of the identity of the access token, a user or a service account, to the limit
+// per identity over every route and to the limit of the policy of the route, read from the configuration of the
+// request. The anonymous requests are left to the other limits.
+func LimitIdentities(store ratelimit.Store, keys *auth.Keys, cfg *configs.Config) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			token, err := auth.VerifyTokenFromRequest(c, keys)
+			if err != nil {
+				return next(c)
+			}
+			claims := token.Claims.(jwt.MapClaims)
+			tokenType, _ := claims["token_type"].(string)
+			identity, _ := claims["id"].(string)
+			if tokenType != "access" || identity == "" {
+				return next(c)
+			}
+
+			limit := configs.FromContext(c.Request().Context(), cfg).RateLimit
+			if err := take(c, store, policyIdentity, "identity:"+identity, limit.PerIdentity); err != nil {
+				return err
+			}
+			route := ratelimit.PolicyKey(c.Request().Method, c.Path())
+			if err := take(c, store, policyRoute, "route:"+route+":"+identity, limit.Routes[route]); err != nil {
+				return err
+			}
+			return next(c)
+		}
+	}
+}
+
+// take takes a token of the bucket of the key with ratelimit.Take, the request is rejected with too many requests
+// until the next token when there is none, answered in locked_until and Retry-After
+func take(c echo.Context, store ratelimit.Store, policy, key string, perMinute int) error {
+	if err := ratelimit.Take(c.Request().Context(), store, policy, key, perMinute); err != nil {
+		return response.HTTPError(err, http.StatusTooManyRequests, ierr.ErrTooManyRequests.Code, ierr.ErrTooManyRequests.Message)
+	}
+	return nil
+}
+
+// loginUsername returns the username of the login request in lower case, the body is left for the handler.
+// The body is read before the authentication, so it is refused above maxLoginBodySize rather than read in memory.
+func loginUsername(c echo.Context) (string, error) {
+	r := c.Request()
+	if r.Body == nil {
+		return "", nil
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(c.Response(), r.Body, maxLoginBodySize))
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return "", errors.Wrap(err, "cannot read login")
+	}
+
+	var username string
+	if strings.HasPrefix(r.Header.Get(echo.HeaderContentType), echo.MIMEApplicationForm) {
+		values, _ := url.ParseQuery(string(body))
+		username = values.Get("username")
+	} else {
+		var req struct {
+			Username string `json:"username"`
+		}
+		_ = json.Unmarshal(body, &req)
+		username = req.Username
+	}
+	return strings.ToLower(strings.TrimSpace(username)), nil
+}
@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/pkg/auth"
+	"go-hex/pkg/ratelimit"
+	"go-hex/shared/response"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingStore struct{}
+
+func (failingStore) Take(ctx context.Context, key string, perMinute int) (ratelimit.Decision, error) {
+	return ratelimit.Decision{}, errors.New("connection refused")
+}
+
+func newRateLimitedRouter(middlewares ...echo.MiddlewareFunc) *echo.Echo {
+	router := echo.New()
+	router.HTTPErrorHandler = func(err error, c echo.Context) {
+		resp := err.(response.ErrorResponse)
+		if retryAfter := resp.RetryAfter(); retryAfter != "" {
+			c.Response().Header().Set(echo.HeaderRetryAfter, retryAfter)
+		}
+		_ = c.NoContent(resp.StatusCode())
+	}
+	router.Use(middlewares...)
+	echoBody := func(c echo.Context) error {
+		return c.String(http.StatusOK, c.FormValue("username"))
+	}
+	router.POST("/auth/login", func(c echo.Context) error {
+		var req struct {
+			Username string `json:"username"`
+		}
+		if err := c.Bind(&req); err != nil {
+			return err
+		}
+		return c.String(http.StatusOK, req.Username)
+	})
+	router.POST("/hosted/login", echoBody)
+	router.GET("/users", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+	router.POST("/users", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+	return router
+}
+
+func TestLimitLogins(t *testing.T) {
+	logins := RouteGroup{Name: "login", Prefixes: []string{"/auth/login", "/hosted/login"}}
+	cfg := &configs.Config{}
+	cfg.RateLimit.LoginPerIP, cfg.RateLimit.LoginPerUsername = 3, 2
+	router := newRateLimitedRouter(LimitLogins(ratelimit.NewMemoryStore(), logins, cfg))
+
+	login := func(ip, username string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(`{"username":"`+username+`","password":"secret"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(echo.HeaderXRealIP, ip)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := login("10.0.0.1", "jane")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "jane", rec.Body.String(), "the body is left for the handler")
+	assert.Equal(t, http.StatusOK, login("10.0.0.2", "Jane").Code)
+	rec = login("10.0.0.3", " JANE ")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code, "limited per username from any address")
+	assert.Equal(t, "30", rec.Header().Get(echo.HeaderRetryAfter))
+
+	assert.Equal(t, http.StatusOK, login("10.0.0.1", "john").Code)
+	assert.Equal(t, http.StatusOK, login("10.0.0.1", "joe").Code)
+	rec = login("10.0.0.1", "jim")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code, "limited per address with any username")
+	assert.Equal(t, "20", rec.Header().Get(echo.HeaderRetryAfter))
+
+	form := url.Values{"username": {"jim"}, "password": {"secret"}}
+	req := httptest.NewRequest(http.MethodPost, "/hosted/login", strings.NewReader(form.Encode()))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	req.Header.Set(echo.HeaderXRealIP, "10.0.0.4")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, "jim", rec.Body.String(), "the form is left for the handler")
+
+	req = httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(`{"username":"jim","password":"`+strings.Repeat("a", maxLoginBodySize)+`"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(echo.HeaderXRealIP, "10.0.0.5")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "the body is not read beyond the limit")
+}
+
+func TestLimitIdentities(t *testing.T) {
+	keys := auth.NewHS256Keys("secret")
+	cfg := &configs.Config{}
+	cfg.RateLimit.PerIdentity, cfg.RateLimit.Routes = 3, ratelimit.Policies{"POST /users": 1}
+	router := newRateLimitedRouter(LimitIdentities(ratelimit.NewMemoryStore(), keys, cfg))
+
+	sign := func(claims jwt.MapClaims) string {
+		claims["exp"] = time.Now().Add(time.Minute).Unix()
+		token, err := keys.Signer().Sign(claims)
+		require.NoError(t, err)
+		return token
+	}
+	serve := func(method, token string) int {
+		req := httptest.NewRequest(method, "/users", nil)
+		if token != "" {
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	jane := sign(jwt.MapClaims{"id": "u1", "token_type": "access"})
+	john := sign(jwt.MapClaims{"id": "u2", "token_type": "access"})
+
+	assert.Equal(t, http.StatusOK, serve(http.MethodPost, jane))
+	assert.Equal(t, http.StatusTooManyRequests, serve(http.MethodPost, jane), "limited by the policy of the route")
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, jane))
+	assert.Equal(t, http.StatusTooManyRequests, serve(http.MethodGet, jane), "limited over every route")
+	assert.Equal(t, http.StatusOK, serve(http.MethodPost, john), "every identity has its buckets")
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, http.StatusOK, serve(http.MethodGet, ""), "the anonymous requests are not limited")
+	}
+
+	// the limits are read from the configuration of the request, as reloaded
+	reloaded := &configs.Config{}
+	reloaded.RateLimit.PerIdentity = 10
+	router = newRateLimitedRouter(CurrentConfig(func() *configs.Config { return reloaded }), LimitIdentities(ratelimit.NewMemoryStore(), keys, cfg))
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, http.StatusOK, serve(http.MethodPost, jane), "limited by the reloaded configuration")
+	}
+
+	cfg.RateLimit.PerIdentity = 1
+	router = newRateLimitedRouter(LimitIdentities(failingStore{}, keys, cfg))
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, jane))
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, jane), "the requests are let through when the store fails")
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"go-hex/pkg/logger"
+	"go-hex/pkg/otel"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RequestLog carries the logger of the request in its context, read by logger.FromContext with the method and the
+// route of the request, and logs the summary of every request once it is handled, with its status, latency and sizes.
+// The summaries of the server errors are logged at the error level, the others at the info level, so that the
+// requests raised to the debug level by a log verbosity are logged whatever the steady state level.
+func RequestLog(log logger.Logger) echo.MiddlewareFunc {
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+
+			start := time.Now()
+			r := c.Request()
+			route := otel.Route(c)
+			l := log.WithParams(logger.Params{"method": r.Method, "route": route})
+			c.SetRequest(r.WithContext(logger.NewContext(r.Context(), l)))
+
+			err := next(c)
+			status := responseStatus(c, err)
+
+			// the request context knows the logged in user once the route middlewares ran
+			summary := logger.FromContext(c.Request().Context(), l).WithParams(logger.Params{
+				"type":      "access",
+				"uri":       r.RequestURI,
+				"status":    status,
+				"latency":   time.Since(start).String(),
+				"remote_ip": c.RealIP(),
+				"bytes_in":  r.ContentLength,
+				"bytes_out": c.Response().Size,
+			})
+			if err != nil {
+				summary = summary.WithParam("error", err.Error())
+			}
+			if status >= http.StatusInternalServerError {
+				summary.Errorf("%s %s %d", r.Method, route, status)
+			} else {
+				summary.Infof("%s %s %d", r.Method, route, status)
+			}
+			return err
+		}
+	}
+}
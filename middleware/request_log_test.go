@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"go-hex/pkg/logger"
+	"go-hex/shared/ierr"
+	"go-hex/shared/response"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestLog(t *testing.T) {
+	out := &bytes.Buffer{}
+	log := logger.New("test", "test")
+	logger.SetOutput(out)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	defer logger.SetFormatter(&logrus.TextFormatter{})
+
+	e := echo.New()
+	e.Use(RequestIDContext(), RequestLog(log))
+	e.GET("/users/:id", func(c echo.Context) error {
+		logger.FromContext(c.Request().Context(), nil).Info("getting user")
+		if c.Param("id") == "u2" {
+			return response.ErrNotFound(ierr.ErrResourceNotFound)
+		}
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/u1", nil))
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/u2", nil))
+
+	var entries []map[string]interface{}
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+	require.Len(t, entries, 4)
+
+	// the logs of the handler carry the fields of the request
+	assert.Equal(t, "getting user", entries[0]["msg"])
+	assert.Equal(t, "/users/:id", entries[0]["route"])
+	assert.Equal(t, http.MethodGet, entries[0]["method"])
+	assert.NotEmpty(t, entries[0]["request_id"])
+
+	assert.Equal(t, "GET /users/:id 204", entries[1]["msg"])
+	assert.Equal(t, "access", entries[1]["type"])
+	assert.Equal(t, "/users/u1", entries[1]["uri"])
+	assert.Equal(t, float64(http.StatusNoContent), entries[1]["status"])
+	assert.Equal(t, entries[0]["request_id"], entries[1]["request_id"])
+	assert.Equal(t, "info", entries[1]["level"])
+
+	assert.Equal(t, float64(http.StatusNotFound), entries[3]["status"])
+	assert.Equal(t, ierr.ErrResourceNotFound.Message, entries[3]["error"])
+	assert.NotEqual(t, entries[1]["request_id"], entries[3]["request_id"])
+}
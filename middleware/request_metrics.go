@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"go-hex/pkg/metrics"
+	"go-hex/pkg/otel"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var requestDuration = metrics.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "http_request_duration_seconds",
+	Help:    "Duration of the requests of the observed routes, the buckets link to the trace of a representative request.",
+	Buckets: []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+}, "route", "method", "status")
+
+// statusCoder is an error answered with its own status code, like response.ErrorResponse
+type statusCoder interface {
+	StatusCode() int
+}
+
+// RequestMetrics observes the duration of the requests of the route group in http_request_duration_seconds.
+// The observations of the sampled requests carry the trace_id of their trace as an exemplar, so that
+// a latency spike leads to the traces of the requests behind it. It must run after HandlerTracing.
+// The exemplars are only exposed to the scrapers negotiating the OpenMetrics format.
+func RequestMetrics(routes RouteGroup) echo.MiddlewareFunc {
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !routes.Match(c.Path()) {
+				return next(c)
+			}
+
+			start := time.Now()
+			err := next(c)
+			elapsed := time.Since(start).Seconds()
+
+			observer := requestDuration.WithLabelValues(otel.Route(c), c.Request().Method, strconv.Itoa(responseStatus(c, err)))
+			if sc := trace.SpanContextFromContext(c.Request().Context()); sc.IsSampled() {
+				observer.(prometheus.ExemplarObserver).ObserveWithExemplar(elapsed, prometheus.Labels{"trace_id": sc.TraceID().String()})
+			} else {
+				observer.Observe(elapsed)
+			}
+			return err
+		}
+	}
+}
+
+// responseStatus returns the status of the response, the error handler answers the errors after the middlewares ran
+func responseStatus(c echo.Context, err error) int {
+	if err == nil {
+		return c.Response().Status
+	}
+	if e, ok := err.(statusCoder); ok {
+		return e.StatusCode()
+	}
+	if e, ok := err.(*echo.HTTPError); ok {
+		return e.Code
+	}
+	return http.StatusInternalServerError
+}
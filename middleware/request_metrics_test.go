@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"go-hex/pkg/metrics"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestRequestMetricsExemplars(t *testing.T) {
+
+	otel.SetTracerProvider(tracesdk.NewTracerProvider(tracesdk.WithSampler(tracesdk.AlwaysSample())))
+	defer otel.SetTracerProvider(trace.NewNoopTracerProvider())
+
+	var traceID string
+	router := echo.New()
+	router.Use(HandlerTracing("test"))
+	router.Use(RequestMetrics(RouteGroup{Name: "observed", Prefixes: []string{"/auth/login"}}))
+	router.POST("/auth/login", func(c echo.Context) error {
+		traceID = trace.SpanContextFromContext(c.Request().Context()).TraceID().String()
+		return echo.NewHTTPError(http.StatusUnauthorized)
+	})
+	router.GET("/internal/export", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/auth/login", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/internal/export", nil))
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text; version=0.0.1")
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, req)
+	body := rec.Body.String()
+
+	assert.NotEmpty(t, traceID)
+	assert.Contains(t, body, `http_request_duration_seconds_count{method="POST",route="/auth/login",status="401"} 1`)
+	assert.Contains(t, body, `# {trace_id="`+traceID+`"}`)
+	assert.False(t, strings.Contains(body, `route="/internal/export"`))
+}
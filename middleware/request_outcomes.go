@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"go-hex/pkg/metrics"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/slo"
+	"go-hex/shared/ierr"
+	"go-hex/shared/response"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/smithy-go"
+	"github.com/go-sql-driver/mysql"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var requestOutcomes = metrics.NewCounterVec(prometheus.CounterOpts{
+	Name: "http_request_outcomes_total",
+	Help: "Number of requests per route and outcome, classified as success, client_error, dependency_error or internal_error with a reason.",
+}, "route", "method", "class", "reason")
+
+// Reasons of the dependency and internal errors, the other reasons are named after the status of the response
+const (
+	reasonTimeout    = "timeout"
+	reasonCancelled  = "cancelled"
+	reasonOverloaded = "overloaded"
+	reasonDatabase   = "database"
+	reasonDynamoDB   = "dynamodb"
+	reasonNetwork    = "network"
+	reasonUnhandled  = "unhandled"
+)
+
+// OutcomeRecorder records the class and the latency of the outcome of the requests, as slo.Tracker does
+type OutcomeRecorder interface {
+	Record(method, route, class string, latency time.Duration, at time.Time)
+}
+
+// RequestOutcomes classifies the outcome of every request in http_request_outcomes_total and records it in the
+// recorders, such as the tracker computing the SLIs of the endpoints. It must run before Recover, whose recovered
+// panics are answered with an internal error.
+func RequestOutcomes(recorders ...OutcomeRecorder) echo.MiddlewareFunc {
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+
+			start := time.Now()
+			err := next(c)
+			elapsed := time.Since(start)
+
+			class, reason := classifyOutcome(responseStatus(c, err), err)
+			route := otel.Route(c)
+			requestOutcomes.WithLabelValues(route, c.Request().Method, class, reason).Inc()
+			for _, recorder := range recorders {
+				recorder.Record(c.Request().Method, route, class, elapsed, start)
+			}
+			return err
+		}
+	}
+}
+
+// classifyOutcome returns the class of the outcome of a request and its reason
+func classifyOutcome(status int, err error) (class, reason string) {
+
+	// the errors answered by the handlers carry the error of the service
+	cause := err
+	if res, ok := err.(response.ErrorResponse); ok && res.Internal != nil {
+		cause = res.Internal
+	}
+	cause = errors.Cause(cause)
+
+	switch {
+	case status < http.StatusBadRequest:
+		return slo.ClassSuccess, "ok"
+	case errors.Is(cause, context.Canceled):
+		// the client went away before the response
+		return slo.ClassClientError, reasonCancelled
+	case status < http.StatusInternalServerError:
+		return slo.ClassClientError, statusReason(status)
+	}
+
+	var netErr net.Error
+	var mysqlErr *mysql.MySQLError
+	var smithyErr *smithy.OperationError
+	switch {
+	case errors.Is(cause, context.DeadlineExceeded) || (errors.As(cause, &netErr) && netErr.Timeout()) || status == http.StatusGatewayTimeout:
+		return slo.ClassDependencyError, reasonTimeout
+	case errors.Is(cause, ierr.ErrServiceUnavailable) || status == http.StatusServiceUnavailable:
+		return slo.ClassDependencyError, reasonOverloaded
+	case errors.As(cause, &mysqlErr) || errors.Is(cause, driver.ErrBadConn) || errors.Is(cause, sql.ErrConnDone) || errors.Is(cause, mysql.ErrInvalidConn):
+		return slo.ClassDependencyError, reasonDatabase
+	case errors.As(cause, &smithyErr):
+		return slo.ClassDependencyError, reasonDynamoDB
+	case errors.As(cause, &netErr):
+		return slo.ClassDependencyError, reasonNetwork
+	}
+	return slo.ClassInternalError, reasonUnhandled
+}
+
+// statusReason names the reason after the status, bad_request for 400
+func statusReason(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return strconv.Itoa(status)
+	}
+	return strings.ReplaceAll(strings.ToLower(text), " ", "_")
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"context"
+	"database/sql/driver"
+	"go-hex/pkg/slo"
+	"go-hex/shared/ierr"
+	"go-hex/shared/response"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyOutcome(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		err    error
+		class  string
+		reason string
+	}{
+		{name: "success", status: http.StatusOK, class: slo.ClassSuccess, reason: "ok"},
+		{name: "unauthorized", status: http.StatusUnauthorized, err: response.ErrUnauthorized(ierr.ErrInvalidCreds), class: slo.ClassClientError, reason: "unauthorized"},
+		{name: "rate limited", status: http.StatusTooManyRequests, err: echo.ErrTooManyRequests, class: slo.ClassClientError, reason: "too_many_requests"},
+		{name: "cancelled", status: http.StatusInternalServerError, err: errors.Wrap(context.Canceled, "cannot get user"), class: slo.ClassClientError, reason: "cancelled"},
+		{name: "timeout", status: http.StatusInternalServerError, err: response.ErrInternalServerError(errors.Wrap(context.DeadlineExceeded, "cannot get user")), class: slo.ClassDependencyError, reason: "timeout"},
+		{name: "overloaded", status: http.StatusServiceUnavailable, err: response.HTTPError(ierr.ErrServiceUnavailable, http.StatusServiceUnavailable, ierr.ErrServiceUnavailable.Code, ierr.ErrServiceUnavailable.Message), class: slo.ClassDependencyError, reason: "overloaded"},
+		{name: "database", status: http.StatusInternalServerError, err: errors.Wrap(&mysql.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded"}, "cannot update user"), class: slo.ClassDependencyError, reason: "database"},
+		{name: "bad connection", status: http.StatusInternalServerError, err: errors.Wrap(driver.ErrBadConn, "cannot get user"), class: slo.ClassDependencyError, reason: "database"},
+		{name: "unhandled", status: http.StatusInternalServerError, err: errors.New("nil pointer"), class: slo.ClassInternalError, reason: "unhandled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			class, reason := classifyOutcome(tt.status, tt.err)
+			assert.Equal(t, tt.class, class)
+			assert.Equal(t, tt.reason, reason)
+		})
+	}
+}
+
+func TestRequestOutcomes(t *testing.T) {
+	tracker := slo.NewTracker(func(route string) slo.Objective {
+		return slo.Objective{Availability: 0.999, Latency: 0.99, LatencyThreshold: time.Second}
+	})
+	router := echo.New()
+	router.Use(RequestOutcomes(tracker))
+	router.GET("/users/:id", func(c echo.Context) error {
+		if c.Param("id") == "broken" {
+			return errors.New("unexpected")
+		}
+		return c.NoContent(http.StatusOK)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/u1", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/broken", nil))
+
+	report := tracker.Report(time.Now())
+	require.Len(t, report.Endpoints, 1)
+	assert.Equal(t, "/users/:id", report.Endpoints[0].Route)
+	assert.Equal(t, uint64(2), report.Endpoints[0].Windows[0].Requests)
+	assert.InDelta(t, 0.5, report.Endpoints[0].Windows[0].Availability.Ratio, 1e-9)
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import "strings"
+
+// RouteGroup is a named set of routes matched by the prefix of their path,
+// it lets the traffic of different kinds of clients be isolated from each other.
+type RouteGroup struct {
+	Name     string
+	Prefixes []string
+}
+
+// Match checks whether the route path belongs to the group
+func (g RouteGroup) Match(path string) bool {
+	for _, prefix := range g.Prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGroup returns the index of the first group matching the route path, -1 when none does
+func matchGroup(groups []RouteGroup, path string) int {
+	for i, group := range groups {
+		if group.Match(path) {
+			return i
+		}
+	}
+	return -1
+}
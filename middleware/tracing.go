@@ -2,16 +2,18 @@ package middleware
 
 import (
 	"bytes"
-	"fmt"
-	"go-hex/pkg/logger"
+	"go-hex/pkg/otel"
+	"go-hex/pkg/scrub"
 	"go-hex/pkg/utils"
+	"go-hex/shared/ctxutil"
 	"io/ioutil"
-	"regexp"
 
 	"github.com/labstack/echo/v4"
-	"go.opentelemetry.io/otel"
+	gootel "go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // HandlerTracing is a middleware for logging opentelemetry
@@ -21,16 +23,17 @@ func HandlerTracing(appName string) echo.MiddlewareFunc {
 		return func(c echo.Context) error {
 
 			r := c.Request()
-			if utils.StringInSlice(c.Path(), []string{"/health", "/ping", "/swagger/*"}) { // exceptional don't start span
+			if utils.StringInSlice(c.Path(), []string{"/health", "/healthz", "/readyz", "/ping", "/swagger/*"}) { // exceptional don't start span
 				return next(c)
 			}
 
 			// extract request header into context
-			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx := gootel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
 
-			// start span
-			operation := fmt.Sprintf("[API] %s %s", c.Request().Method, c.Path())
-			ctx, span := otel.Tracer(appName).Start(ctx, operation)
+			// start span, named after the template of the route rather than the IDs of the path
+			route := otel.Route(c)
+			ctx, span := gootel.Tracer(appName).Start(ctx, otel.HTTPSpanName(r.Method, route), trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(semconv.HTTPMethodKey.String(r.Method), semconv.HTTPRouteKey.String(route)))
 			defer span.End()
 
 			c.SetRequest(r.WithContext(ctx))
@@ -38,13 +41,13 @@ func HandlerTracing(appName string) echo.MiddlewareFunc {
 			// log param path
 			if len(c.ParamNames()) > 0 {
 				for i, param := range c.ParamNames() {
-					span.SetAttributes(attribute.String(param, c.ParamValues()[i]))
+					span.SetAttributes(attribute.String(param, scrub.String(c.ParamValues()[i])))
 				}
 			}
 
 			// log query string
 			if c.QueryString() != "" {
-				span.SetAttributes(attribute.String("query", c.QueryString()))
+				span.SetAttributes(attribute.String("query", scrub.Query(c.QueryString())))
 			}
 
 			// log body
@@ -53,13 +56,15 @@ func HandlerTracing(appName string) echo.MiddlewareFunc {
 				span.SetAttributes(attribute.String("body", body))
 			}
 
-			span.SetAttributes(attribute.String("request_id", logger.GetRequestID(ctx)))
+			requestID, _ := ctxutil.RequestID(ctx)
+			span.SetAttributes(attribute.String("request_id", requestID))
 
 			return next(c)
 		}
 	}
 }
 
+// dumpBody returns the request body with its personal data and credentials masked
 func dumpBody(c echo.Context) string {
 
 	// Request
@@ -67,10 +72,6 @@ func dumpBody(c echo.Context) string {
 
 	c.Request().Body = ioutil.NopCloser(bytes.NewBuffer(reqBody)) // Reset
 
-	ra, err := regexp.Compile("\"([a-zA-Z]*(pass|secure|token|authorization|refresh_token|access_token)[a-zA-Z]*)\"\\s?:\\s?\"([^\"]+)\"")
-	if err == nil {
-		return ra.ReplaceAllString(string(reqBody), "\"$1\":\"[REDACTED]\"")
-	}
-	return string(reqBody)
+	return string(scrub.JSON(reqBody))
 
 }
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"go-hex/internal/domain"
+	"go-hex/pkg/otel"
+	"go-hex/shared/ierr"
+	"go-hex/shared/response"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/mod/semver"
+)
+
+// Headers of the build of the application and of the version of the clients
+const (
+	HeaderAppVersion       = "X-App-Version"
+	HeaderAppCommit        = "X-App-Commit"
+	HeaderClientType       = "X-Client-Type"
+	HeaderClientVersion    = "X-Client-Version"
+	HeaderClientMinVersion = "X-Client-Min-Version"
+	HeaderClientInfo       = "X-Client-Info"
+)
+
+// AppVersion answers the version and the commit of the application in the headers of every response
+func AppVersion(version string, commit string) echo.MiddlewareFunc {
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Response().Header().Set(HeaderAppVersion, version)
+			if commit != "" {
+				c.Response().Header().Set(HeaderAppCommit, commit)
+			}
+			return next(c)
+		}
+	}
+}
+
+// ClientVersionGate rejects with upgrade required the requests of the clients older than the minimum
+// version of their type, the client types being case insensitive. A client of a gated type without
+// a valid version is considered too old, the clients of the other types are let through. The type and
+// the version of the client are recorded on the span of the request.
+func ClientVersionGate(minVersions map[string]string) echo.MiddlewareFunc {
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+
+			clientType := strings.ToLower(c.Request().Header.Get(HeaderClientType))
+			version := c.Request().Header.Get(HeaderClientVersion)
+			if clientType != "" {
+				trace.SpanFromContext(c.Request().Context()).SetAttributes(
+					otel.AttributeClientType.String(clientType),
+					otel.AttributeClientVersion.String(version),
+				)
+			}
+
+			minVersion, ok := minVersions[clientType]
+			if !ok || clientType == "" {
+				return next(c)
+			}
+			if v := canonicalVersion(version); semver.IsValid(v) && semver.Compare(v, canonicalVersion(minVersion)) >= 0 {
+				return next(c)
+			}
+
+			c.Response().Header().Set(HeaderClientMinVersion, minVersion)
+			err := errors.Errorf("%s client version %q is older than %s", clientType, version, minVersion)
+			return response.HTTPError(err, http.StatusUpgradeRequired, ierr.ErrUpgradeRequired.Code, ierr.ErrUpgradeRequired.Message)
+		}
+	}
+}
+
+// ClientInfo returns the client app of the request from its X-Client-Info header,
+// e.g. app=checkout; version=3.2.1; platform=ios
+func ClientInfo(r *http.Request) domain.ClientInfo {
+	return domain.ParseClientInfo(r.Header.Get(HeaderClientInfo))
+}
+
+// canonicalVersion prefixes a semantic version with the v expected by semver
+func canonicalVersion(version string) string {
+	return "v" + strings.TrimPrefix(strings.TrimSpace(version), "v")
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"go-hex/shared/response"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientVersionGate(t *testing.T) {
+	router := echo.New()
+	router.HTTPErrorHandler = func(err error, c echo.Context) {
+		_ = c.NoContent(err.(response.ErrorResponse).StatusCode())
+	}
+	router.Use(AppVersion("v1.2.3", "4f89869"))
+	router.Use(ClientVersionGate(map[string]string{"ios": "2.3.0", "android": "v2.1.0"}))
+	router.GET("/me", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+
+	serve := func(clientType, version string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set(HeaderClientType, clientType)
+		req.Header.Set(HeaderClientVersion, version)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		clientType string
+		version    string
+		status     int
+	}{
+		{"ios", "2.3.0", http.StatusOK},
+		{"iOS", "v2.4.1", http.StatusOK},
+		{"android", "2.1.0", http.StatusOK},
+		{"ios", "2.2.9", http.StatusUpgradeRequired},
+		{"android", "2.0.10", http.StatusUpgradeRequired},
+		{"ios", "", http.StatusUpgradeRequired},
+		{"ios", "latest", http.StatusUpgradeRequired},
+		{"web", "0.1.0", http.StatusOK},
+		{"", "", http.StatusOK},
+	}
+	for _, test := range tests {
+		rec := serve(test.clientType, test.version)
+		assert.Equal(t, test.status, rec.Code, "%s %s", test.clientType, test.version)
+		assert.Equal(t, "v1.2.3", rec.Header().Get(HeaderAppVersion))
+		assert.Equal(t, "4f89869", rec.Header().Get(HeaderAppCommit))
+		if test.status == http.StatusUpgradeRequired {
+			assert.NotEmpty(t, rec.Header().Get(HeaderClientMinVersion))
+		}
+	}
+}
@@ -2,22 +2,19 @@ package auth
 
 import (
 	"context"
+	"go-hex/shared/ctxutil"
+	"time"
 
 	"github.com/dgrijalva/jwt-go"
 )
 
-type ContextUser string
-
-const ContextKeyUser ContextUser = "user"
-
-// GetLoggedInUser returns logged in user crm
+// GetLoggedInUser returns logged in user crm, read from the principal of the context
 func GetLoggedInUser(ctx context.Context) User {
 
-	v := ctx.Value(ContextKeyUser)
-	if v == nil {
+	loggedInUser, ok := ctxutil.Principal(ctx)
+	if !ok {
 		return User{}
 	}
-	loggedInUser := v.(*jwt.Token)
 
 	claims := loggedInUser.Claims.(jwt.MapClaims)
 
@@ -36,10 +33,42 @@ func GetLoggedInUser(ctx context.Context) User {
 		role = val
 	}
 
+	var sessionID string
+	if val, ok := claims["sid"].(string); ok {
+		sessionID = val
+	}
+
+	var tokenID string
+	if val, ok := claims["jti"].(string); ok {
+		tokenID = val
+	}
+
+	var expiresAt time.Time
+	if val, ok := claims["exp"].(float64); ok {
+		expiresAt = time.Unix(int64(val), 0).UTC()
+	}
+
 	return User{
-		ID:       id,
-		Username: username,
-		Role:     role,
+		ID:          id,
+		Username:    username,
+		Role:        role,
+		SessionID:   sessionID,
+		TokenID:     tokenID,
+		ExpiresAt:   expiresAt,
+		Roles:       stringsClaim(claims, "roles"),
+		Permissions: stringsClaim(claims, "permissions"),
 	}
 
 }
+
+// stringsClaim returns the strings of a list claim, decoded by jwt as []interface{}
+func stringsClaim(claims jwt.MapClaims, name string) []string {
+	values := []string{}
+	items, _ := claims[name].([]interface{})
+	for _, item := range items {
+		if val, ok := item.(string); ok {
+			values = append(values, val)
+		}
+	}
+	return values
+}
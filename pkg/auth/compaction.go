@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"encoding/base64"
+	"sort"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/pkg/errors"
+)
+
+// claimRoleBitmap is the claim of the roles of the catalog granted to the token
+const claimRoleBitmap = "rb"
+
+// Compaction shrinks the roles and permissions claims of the access tokens, so that they stay under the cookie and
+// header limits as the roles grow. The compacted claims are expanded back by Keys.Verify, so the services verifying
+// the tokens with the keys of the configuration read the same roles and permissions as before.
+type Compaction struct {
+	// Permissions drops the permissions granted by a wildcard of the token and groups the actions of each resource,
+	// users:read and users:write are embedded as users:read,write
+	Permissions bool
+	// RoleCatalog encodes the roles it lists as a bitmap of their positions in the rb claim, the other roles stay in
+	// the roles claim. The issued tokens reference the positions, so the roles are appended and never reordered.
+	RoleCatalog []string
+}
+
+// Compact replaces the roles and permissions claims, lists of strings, by their compacted form
+func (c Compaction) Compact(claims jwt.MapClaims) {
+
+	if permissions, ok := claims["permissions"].([]string); ok && c.Permissions {
+		claims["permissions"] = compactPermissions(permissions)
+	}
+
+	roles, ok := claims["roles"].([]string)
+	if !ok || len(c.RoleCatalog) == 0 {
+		return
+	}
+	positions := make(map[string]int, len(c.RoleCatalog))
+	for i, role := range c.RoleCatalog {
+		positions[role] = i
+	}
+	var bitmap []byte
+	var others []string
+	for _, role := range roles {
+		i, ok := positions[role]
+		if !ok {
+			others = append(others, role)
+			continue
+		}
+		for len(bitmap) <= i/8 {
+			bitmap = append(bitmap, 0)
+		}
+		bitmap[i/8] |= 1 << (i % 8)
+	}
+
+	delete(claims, "roles")
+	if len(others) > 0 {
+		claims["roles"] = others
+	}
+	if len(bitmap) > 0 {
+		claims[claimRoleBitmap] = base64.RawURLEncoding.EncodeToString(bitmap)
+	}
+}
+
+// compactPermissions drops the permissions granted by the wildcards and groups the actions by resource
+func compactPermissions(permissions []string) []string {
+
+	wildcards := map[string]bool{}
+	for _, permission := range permissions {
+		if permission == "*" {
+			return []string{"*"}
+		}
+		if resource, action, ok := cutPermission(permission); ok && action == "*" {
+			wildcards[resource] = true
+		}
+	}
+
+	var resources []string
+	actions := map[string][]string{}
+	var res []string
+	for _, permission := range permissions {
+		resource, action, ok := cutPermission(permission)
+		if !ok {
+			res = append(res, permission)
+			continue
+		}
+		if wildcards[resource] && action != "*" {
+			continue
+		}
+		if _, ok := actions[resource]; !ok {
+			resources = append(resources, resource)
+		}
+		actions[resource] = append(actions[resource], action)
+	}
+	for _, resource := range resources {
+		res = append(res, resource+":"+strings.Join(actions[resource], ","))
+	}
+	sort.Strings(res)
+	return res
+}
+
+// expand replaces the compacted roles and permissions claims, as decoded by jwt, by their lists of strings
+func (c Compaction) expand(claims jwt.MapClaims) error {
+
+	if items, ok := claims["permissions"].([]interface{}); ok {
+		var permissions []interface{}
+		for _, item := range items {
+			permission, ok := item.(string)
+			resource, actions, grouped := cutPermission(permission)
+			if !ok || !grouped || !strings.Contains(actions, ",") {
+				permissions = append(permissions, item)
+				continue
+			}
+			for _, action := range strings.Split(actions, ",") {
+				permissions = append(permissions, resource+":"+action)
+			}
+		}
+		claims["permissions"] = permissions
+	}
+
+	value, ok := claims[claimRoleBitmap]
+	if !ok {
+		return nil
+	}
+	encoded, _ := value.(string)
+	bitmap, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return errors.Wrap(err, "invalid role bitmap")
+	}
+	roles, _ := claims["roles"].([]interface{})
+	for i := 0; i < len(bitmap)*8; i++ {
+		if bitmap[i/8]&(1<<(i%8)) == 0 {
+			continue
+		}
+		if i >= len(c.RoleCatalog) {
+			return errors.Errorf("role bitmap references the position %d, out of the role catalog", i)
+		}
+		roles = append(roles, c.RoleCatalog[i])
+	}
+	sort.Slice(roles, func(i, j int) bool {
+		a, _ := roles[i].(string)
+		b, _ := roles[j].(string)
+		return a < b
+	})
+	claims["roles"] = roles
+	delete(claims, claimRoleBitmap)
+	return nil
+}
+
+// cutPermission splits a resource:action permission
+func cutPermission(permission string) (resource, action string, ok bool) {
+	i := strings.LastIndex(permission, ":")
+	if i < 0 {
+		return "", "", false
+	}
+	return permission[:i], permission[i+1:], true
+}
@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompaction(t *testing.T) {
+	compaction := Compaction{Permissions: true, RoleCatalog: []string{"admin", "user", "support"}}
+	keys := NewHS256Keys("secret").WithCompaction(compaction)
+
+	claims := jwt.MapClaims{
+		"id":          "u1",
+		"roles":       []string{"auditor", "support", "user"},
+		"permissions": []string{"audit:read", "profile:read", "profile:write", "users:*", "users:read"},
+	}
+	keys.Compaction().Compact(claims)
+	assert.Equal(t, []string{"auditor"}, claims["roles"])
+	assert.Equal(t, []string{"audit:read", "profile:read,write", "users:*"}, claims["permissions"])
+	assert.Equal(t, "Bg", claims["rb"])
+
+	token, err := keys.Signer().Sign(claims)
+	require.NoError(t, err)
+	verified, err := keys.Verify(token)
+	require.NoError(t, err)
+	expanded := verified.Claims.(jwt.MapClaims)
+	assert.Equal(t, []string{"auditor", "support", "user"}, stringsClaim(expanded, "roles"))
+	assert.Equal(t, []string{"audit:read", "profile:read", "profile:write", "users:*"}, stringsClaim(expanded, "permissions"))
+	assert.NotContains(t, expanded, "rb")
+
+	// the wildcard grants every permission
+	claims = jwt.MapClaims{"permissions": []string{"*", "users:read"}}
+	compaction.Compact(claims)
+	assert.Equal(t, []string{"*"}, claims["permissions"])
+
+	// a bitmap referencing a role out of the catalog is refused
+	token, err = keys.Signer().Sign(jwt.MapClaims{"id": "u1", "rb": "CA"})
+	require.NoError(t, err)
+	_, err = keys.Verify(token)
+	assert.Error(t, err)
+
+	// the tokens without compacted claims are verified unchanged
+	token, err = NewHS256Keys("secret").Signer().Sign(jwt.MapClaims{"id": "u1", "roles": []string{"user"}, "permissions": []string{"users:read"}})
+	require.NoError(t, err)
+	verified, err = keys.Verify(token)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user"}, stringsClaim(verified.Claims.(jwt.MapClaims), "roles"))
+	assert.Equal(t, []string{"users:read"}, stringsClaim(verified.Claims.(jwt.MapClaims), "permissions"))
+}
@@ -0,0 +1,127 @@
+// Package jwks publishes the public keys verifying the tokens as a JSON Web Key Set (RFC 7517),
+// so that the other services verify the tokens without sharing a secret and pick up the rotated keys.
+package jwks
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"fmt"
+	"go-hex/pkg/auth"
+	"math/big"
+	"net/http"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo/v4"
+)
+
+// MaxAge is the number of seconds the clients may cache the key set.
+// A rotated key is published as previous key for longer than the tokens it signed live, so the
+// clients refreshing the set on an unknown kid never miss a key.
+const MaxAge = 300
+
+// Set is a JSON Web Key Set
+type Set struct {
+	Keys []Key `json:"keys"`
+}
+
+// Key is a public JSON Web Key
+type Key struct {
+	KeyType   string `json:"kty" example:"EC"`
+	Use       string `json:"use" example:"sig"`
+	Algorithm string `json:"alg" example:"ES256"`
+	KeyID     string `json:"kid" example:"p1Qw0vQn3ZsS1w8lWuYV6c2l9hYbJkX1G8o5q7nDk2A"`
+	N         string `json:"n,omitempty"`   // modulus of a RSA key
+	E         string `json:"e,omitempty"`   // exponent of a RSA key
+	Curve     string `json:"crv,omitempty"` // curve of an EC key
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+// NewSet returns the key set of the public keys, the active one first then the previous ones
+func NewSet(keys *auth.Keys) (Set, error) {
+	res := Set{Keys: []Key{}}
+	for _, key := range keys.PublicKeys() {
+		jwk, err := NewKey(key)
+		if err != nil {
+			return Set{}, err
+		}
+		res.Keys = append(res.Keys, jwk)
+	}
+	return res, nil
+}
+
+// NewKey returns the JSON Web Key of a RSA or P-256 public key
+func NewKey(key auth.PublicKey) (Key, error) {
+
+	res := Key{Use: "sig", Algorithm: key.Method.Alg(), KeyID: key.KeyID}
+	switch k := key.Key.(type) {
+	case *rsa.PublicKey:
+		res.KeyType = "RSA"
+		res.N = jwt.EncodeSegment(k.N.Bytes())
+		res.E = jwt.EncodeSegment(big.NewInt(int64(k.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		res.KeyType = "EC"
+		res.Curve = k.Curve.Params().Name
+		res.X = jwt.EncodeSegment(k.X.FillBytes(make([]byte, size)))
+		res.Y = jwt.EncodeSegment(k.Y.FillBytes(make([]byte, size)))
+	default:
+		return Key{}, fmt.Errorf("unsupported public key type %T", key.Key)
+	}
+	return res, nil
+}
+
+// PublicKey returns the RSA or P-256 public key of the JSON Web Key, e.g. read from the key set of a provider
+func (k Key) PublicKey() (crypto.PublicKey, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := jwt.DecodeSegment(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus: %w", err)
+		}
+		e, err := jwt.DecodeSegment(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent: %w", err)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		if k.Curve != elliptic.P256().Params().Name {
+			return nil, fmt.Errorf("unsupported curve %q", k.Curve)
+		}
+		x, err := jwt.DecodeSegment(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x: %w", err)
+		}
+		y, err := jwt.DecodeSegment(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid y: %w", err)
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil, fmt.Errorf("point is not on the curve")
+		}
+		return key, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.KeyType)
+}
+
+// Handler answers the key set of the keys, empty when the tokens are signed with the shared secret
+// @Router /.well-known/jwks.json [get]
+// @Tags Auth
+// @Summary Get the public keys verifying the tokens
+// @Description Answer the JSON Web Key Set of the active and previous public keys verifying the access tokens, by their kid. The set is empty when the tokens are signed with the shared secret (HS256).
+// @Produce json
+// @Success 200 {object} jwks.Set "Success"
+func Handler(keys *auth.Keys) echo.HandlerFunc {
+	// the keys are loaded with the configuration, so the set is built once
+	set, err := NewSet(keys)
+	return func(c echo.Context) error {
+		if err != nil {
+			return err
+		}
+		c.Response().Header().Set(echo.HeaderCacheControl, fmt.Sprintf("public, max-age=%d", MaxAge))
+		return c.JSON(http.StatusOK, set)
+	}
+}
@@ -0,0 +1,87 @@
+package jwks
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"go-hex/pkg/auth"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	keys, err := auth.NewAsymmetricKeys(jwt.SigningMethodES256, ecKey, "", "")
+	require.NoError(t, err)
+	previous, err := auth.NewPublicKey(rsaKey.Public(), "")
+	require.NoError(t, err)
+	keys = keys.WithPrevious(previous)
+
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil), rec)
+	require.NoError(t, Handler(keys)(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "public, max-age=300", rec.Header().Get(echo.HeaderCacheControl))
+
+	var set Set
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &set))
+	require.Len(t, set.Keys, 2)
+
+	active := set.Keys[0]
+	assert.Equal(t, Key{KeyType: "EC", Use: "sig", Algorithm: "ES256", KeyID: keys.KeyID(), Curve: "P-256", X: active.X, Y: active.Y}, active)
+	x, _ := jwt.DecodeSegment(active.X)
+	y, _ := jwt.DecodeSegment(active.Y)
+	assert.Equal(t, ecKey.X, new(big.Int).SetBytes(x))
+	assert.Equal(t, ecKey.Y, new(big.Int).SetBytes(y))
+
+	rotated := set.Keys[1]
+	assert.Equal(t, "RSA", rotated.KeyType)
+	assert.Equal(t, "RS256", rotated.Algorithm)
+	assert.Equal(t, previous.KeyID, rotated.KeyID)
+	n, _ := jwt.DecodeSegment(rotated.N)
+	assert.Equal(t, rsaKey.N, new(big.Int).SetBytes(n))
+	assert.Equal(t, "AQAB", rotated.E)
+}
+
+func TestHandlerWithSharedSecret(t *testing.T) {
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil), rec)
+	require.NoError(t, Handler(auth.NewHS256Keys("secret"))(c))
+	assert.JSONEq(t, `{"keys":[]}`, rec.Body.String())
+}
+
+func TestKeyPublicKey(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	for _, public := range []interface{}{ecKey.Public(), rsaKey.Public()} {
+		key, err := auth.NewPublicKey(public, "")
+		require.NoError(t, err)
+		jwk, err := NewKey(key)
+		require.NoError(t, err)
+
+		parsed, err := jwk.PublicKey()
+		require.NoError(t, err)
+		assert.Equal(t, public, parsed)
+	}
+
+	_, err = Key{KeyType: "EC", Curve: "P-256", X: "AQ", Y: "AQ"}.PublicKey()
+	assert.Error(t, err, "the point is not on the curve")
+	_, err = Key{KeyType: "oct"}.PublicKey()
+	assert.Error(t, err)
+}
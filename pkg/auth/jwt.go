@@ -1,7 +1,6 @@
 package auth
 
 import (
-	"fmt"
 	"strings"
 
 	"github.com/dgrijalva/jwt-go"
@@ -9,20 +8,14 @@ import (
 )
 
 // VerifyTokenFromRequest verifies token from the request
-func VerifyTokenFromRequest(c echo.Context, signingKey string) (*jwt.Token, error) {
+func VerifyTokenFromRequest(c echo.Context, keys *Keys) (*jwt.Token, error) {
 	tokenString := extractToken(c)
-	return VerifyToken(tokenString, signingKey)
+	return VerifyToken(tokenString, keys)
 }
 
-// VerifyToken verifies the given token
-func VerifyToken(tokenString, signingKey string) (*jwt.Token, error) {
-	return jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		//Make sure that the token method conform to "SigningMethodHMAC"
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(signingKey), nil
-	})
+// VerifyToken verifies the given token with the keys
+func VerifyToken(tokenString string, keys *Keys) (*jwt.Token, error) {
+	return keys.Verify(tokenString)
 }
 
 func extractToken(c echo.Context) string {
@@ -0,0 +1,263 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"fmt"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/pkg/errors"
+)
+
+// Keys signs the tokens with the configured algorithm and verifies them.
+// With RS256 or ES256 the tokens are signed with a private key and verified with its public key, which
+// can be distributed to the other services, and carry the key ID in their kid header. The tokens signed
+// with the shared secret (HS256) are verified as well, so that the tokens issued before switching to an
+// asymmetric algorithm stay valid until they expire.
+// The public keys of the previous private keys keep verifying the tokens they signed after a rotation.
+// With a rotation of the secrets, the secret is replaced by the keys of the rotation, see WithRotation.
+type Keys struct {
+	method     jwt.SigningMethod
+	keyID      string
+	privateKey interface{}
+	publicKey  crypto.PublicKey
+	secret     []byte
+	previous   []PublicKey
+	rotation   *Rotation
+	compaction Compaction
+}
+
+// PublicKey is a public key verifying the tokens signed with its private key
+type PublicKey struct {
+	KeyID  string
+	Method jwt.SigningMethod
+	Key    crypto.PublicKey
+}
+
+// NewPublicKey creates a public key verifying the tokens, a RSA key for RS256 and a P-256 key for ES256.
+// An empty key ID defaults to a digest of the key, as for NewAsymmetricKeys.
+func NewPublicKey(key crypto.PublicKey, keyID string) (PublicKey, error) {
+
+	var method jwt.SigningMethod
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		method = jwt.SigningMethodRS256
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return PublicKey{}, errors.New("unsupported ECDSA curve: expected P-256")
+		}
+		method = jwt.SigningMethodES256
+	default:
+		return PublicKey{}, errors.Errorf("unsupported public key type %T", key)
+	}
+
+	if keyID == "" {
+		var err error
+		keyID, err = keyIDOf(key)
+		if err != nil {
+			return PublicKey{}, err
+		}
+	}
+	return PublicKey{KeyID: keyID, Method: method, Key: key}, nil
+}
+
+// NewHS256Keys creates keys signing and verifying the tokens with the shared secret
+func NewHS256Keys(secret string) *Keys {
+	return &Keys{method: jwt.SigningMethodHS256, privateKey: []byte(secret), secret: []byte(secret)}
+}
+
+// NewAsymmetricKeys creates keys signing the tokens with the private key, a RSA key for RS256 and a P-256
+// key for ES256. An empty key ID defaults to a digest of the public key. The tokens signed with the secret,
+// when not empty, keep being verified.
+func NewAsymmetricKeys(method jwt.SigningMethod, privateKey crypto.Signer, keyID string, secret string) (*Keys, error) {
+
+	switch method {
+	case jwt.SigningMethodRS256:
+		if _, ok := privateKey.(*rsa.PrivateKey); !ok {
+			return nil, errors.Errorf("%s requires a RSA private key", method.Alg())
+		}
+	case jwt.SigningMethodES256:
+		key, ok := privateKey.(*ecdsa.PrivateKey)
+		if !ok || key.Curve != elliptic.P256() {
+			return nil, errors.Errorf("%s requires a P-256 ECDSA private key", method.Alg())
+		}
+	default:
+		return nil, errors.Errorf("unsupported asymmetric signing method: %s", method.Alg())
+	}
+
+	publicKey := privateKey.Public()
+	if keyID == "" {
+		var err error
+		keyID, err = keyIDOf(publicKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Keys{method: method, keyID: keyID, privateKey: privateKey, publicKey: publicKey, secret: []byte(secret)}, nil
+}
+
+// keyIDOf returns the base64url SHA-256 of the DER public key
+func keyIDOf(publicKey crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", errors.Wrap(err, "cannot marshal the public key")
+	}
+	sum := sha256.Sum256(der)
+	return jwt.EncodeSegment(sum[:]), nil
+}
+
+// WithPrevious returns the keys verifying the tokens signed with the previous keys as well, until they expire.
+// A previous key with the ID of the active key is ignored.
+func (k *Keys) WithPrevious(previous ...PublicKey) *Keys {
+	res := *k
+	res.previous = nil
+	for _, key := range previous {
+		if key.KeyID != k.keyID {
+			res.previous = append(res.previous, key)
+		}
+	}
+	return &res
+}
+
+// WithRotation returns the keys reading the secrets from the rotation: the HS256 tokens are signed with its active
+// key, and the HMAC tokens are verified with its key of their kid header, or with its keys without ID
+func (k *Keys) WithRotation(rotation *Rotation) *Keys {
+	res := *k
+	res.rotation = rotation
+	return &res
+}
+
+// WithCompaction returns the keys expanding the claims compacted by the compaction when verifying the tokens
+func (k *Keys) WithCompaction(compaction Compaction) *Keys {
+	res := *k
+	res.compaction = compaction
+	return &res
+}
+
+// Compaction returns the compaction of the claims of the access tokens
+func (k *Keys) Compaction() Compaction {
+	return k.compaction
+}
+
+// Method returns the method signing the tokens
+func (k *Keys) Method() jwt.SigningMethod {
+	return k.method
+}
+
+// KeyID returns the ID of the key signing the tokens, for HS256 the one of the active key of the rotation
+func (k *Keys) KeyID() string {
+	if _, ok := k.method.(*jwt.SigningMethodHMAC); ok {
+		if k.rotation == nil {
+			return ""
+		}
+		return k.rotation.ActiveKeyID()
+	}
+	return k.keyID
+}
+
+// PublicKey returns the public key verifying the tokens, nil for HS256
+func (k *Keys) PublicKey() crypto.PublicKey {
+	return k.publicKey
+}
+
+// PublicKeys returns the keys verifying the tokens, the active one first then the previous ones,
+// none for HS256 when no previous key is configured
+func (k *Keys) PublicKeys() []PublicKey {
+	var res []PublicKey
+	if k.publicKey != nil {
+		res = append(res, PublicKey{KeyID: k.keyID, Method: k.method, Key: k.publicKey})
+	}
+	return append(res, k.previous...)
+}
+
+// Signer returns a signer of the tokens
+func (k *Keys) Signer() *Signer {
+	if _, ok := k.method.(*jwt.SigningMethodHMAC); ok && k.rotation != nil {
+		return &Signer{method: k.method, rotation: k.rotation}
+	}
+	// the method and the key were checked together, so this cannot fail
+	s, _ := newSigner(k.method, k.privateKey, k.keyID)
+	return s
+}
+
+// Verify parses the token and verifies its signature with the key of its algorithm.
+// The token is verified with the active key, or with the previous key of its kid header, the HMAC tokens with the
+// secret of their kid header, see WithRotation. The algorithm of the token must be the one of the key or HMAC, so
+// that a token cannot pick the key verifying it.
+// The compacted claims of the verified token are expanded.
+func (k *Keys) Verify(tokenString string) (*jwt.Token, error) {
+	token, err := k.parse(tokenString)
+	if err != nil {
+		return token, err
+	}
+	if claims, ok := token.Claims.(jwt.MapClaims); ok {
+		if err := k.compaction.expand(claims); err != nil {
+			return nil, err
+		}
+	}
+	return token, nil
+}
+
+func (k *Keys) parse(tokenString string) (*jwt.Token, error) {
+	var secrets [][]byte
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+			kid, _ := token.Header["kid"].(string)
+			secrets = k.secrets(kid)
+			switch {
+			case len(secrets) > 0:
+				return secrets[0], nil
+			case kid != "":
+				return nil, fmt.Errorf("unknown key id: %v", kid)
+			}
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return k.publicKeyOf(token)
+	})
+
+	// the tokens without kid may have been signed with any of the secrets without ID, e.g. before a rotation of the
+	// secret
+	var verr *jwt.ValidationError
+	for i := 1; i < len(secrets) && errors.As(err, &verr) && verr.Errors&jwt.ValidationErrorSignatureInvalid != 0; i++ {
+		secret := secrets[i]
+		token, err = jwt.Parse(tokenString, func(*jwt.Token) (interface{}, error) { return secret, nil })
+	}
+	return token, err
+}
+
+// secrets returns the secrets verifying the HMAC tokens of the key ID, the ones of the rotation when the keys have one
+func (k *Keys) secrets(kid string) [][]byte {
+	if k.rotation != nil {
+		return k.rotation.secrets(kid)
+	}
+	if kid != "" || len(k.secret) == 0 {
+		return nil
+	}
+	return [][]byte{k.secret}
+}
+
+// publicKeyOf returns the public key verifying the token, the active one or the previous one of its kid header
+func (k *Keys) publicKeyOf(token *jwt.Token) (interface{}, error) {
+	key := PublicKey{KeyID: k.keyID, Method: k.method, Key: k.publicKey}
+	if kid, ok := token.Header["kid"]; ok && kid != k.keyID {
+		found := false
+		for _, previous := range k.previous {
+			if kid == previous.KeyID {
+				key, found = previous, true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown key id: %v", kid)
+		}
+	}
+	if key.Key == nil || token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.Key, nil
+}
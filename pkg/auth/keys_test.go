@@ -0,0 +1,227 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAsymmetricKeys(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		method jwt.SigningMethod
+		key    crypto.Signer
+	}{
+		{"RS256", jwt.SigningMethodRS256, rsaKey},
+		{"ES256", jwt.SigningMethodES256, ecKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := NewAsymmetricKeys(tt.method, tt.key, "", benchmarkSigningKey)
+			require.NoError(t, err)
+			assert.NotEmpty(t, keys.KeyID())
+
+			access, _ := loginClaims()
+			token, err := keys.Signer().Sign(access)
+			require.NoError(t, err)
+
+			parsed, err := VerifyToken(token, keys)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.method.Alg(), parsed.Header["alg"])
+				assert.Equal(t, keys.KeyID(), parsed.Header["kid"])
+			}
+
+			// the public key alone verifies the token
+			_, err = jwt.Parse(token, func(*jwt.Token) (interface{}, error) { return keys.PublicKey(), nil })
+			assert.NoError(t, err)
+
+			// the tokens signed with the shared secret before the switch stay valid
+			legacy, err := NewHS256Signer(benchmarkSigningKey).Sign(access)
+			require.NoError(t, err)
+			_, err = VerifyToken(legacy, keys)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAsymmetricKeysRejectForgedTokens(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keys, err := NewAsymmetricKeys(jwt.SigningMethodRS256, rsaKey, "key-1", "")
+	require.NoError(t, err)
+
+	claims := jwt.MapClaims{"id": "u1", "token_type": "access", "exp": time.Now().Add(time.Hour).Unix()}
+
+	// without a shared secret the HMAC tokens are rejected, whatever their key
+	hmacToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("guessed"))
+	require.NoError(t, err)
+	_, err = VerifyToken(hmacToken, keys)
+	assert.Error(t, err)
+
+	// a token of another key is rejected
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	other, err := NewAsymmetricKeys(jwt.SigningMethodRS256, otherKey, "key-2", "")
+	require.NoError(t, err)
+	token, err := other.Signer().Sign(claims)
+	require.NoError(t, err)
+	_, err = VerifyToken(token, keys)
+	assert.Error(t, err)
+}
+
+func TestNewAsymmetricKeysChecksTheKeyType(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	_, err = NewAsymmetricKeys(jwt.SigningMethodES256, rsaKey, "", "")
+	assert.Error(t, err)
+	_, err = NewAsymmetricKeys(jwt.SigningMethodES256, ecKey, "", "")
+	assert.Error(t, err)
+	_, err = NewAsymmetricKeys(jwt.SigningMethodRS256, ecKey, "", "")
+	assert.Error(t, err)
+}
+
+func TestKeysWithPrevious(t *testing.T) {
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	newKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	unknownKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	old, err := NewAsymmetricKeys(jwt.SigningMethodRS256, oldKey, "", "")
+	require.NoError(t, err)
+	unknown, err := NewAsymmetricKeys(jwt.SigningMethodES256, unknownKey, "", "")
+	require.NoError(t, err)
+	previous, err := NewPublicKey(oldKey.Public(), "")
+	require.NoError(t, err)
+	assert.Equal(t, old.KeyID(), previous.KeyID)
+
+	keys, err := NewAsymmetricKeys(jwt.SigningMethodES256, newKey, "", "")
+	require.NoError(t, err)
+	keys = keys.WithPrevious(previous)
+	if assert.Len(t, keys.PublicKeys(), 2) {
+		assert.Equal(t, keys.KeyID(), keys.PublicKeys()[0].KeyID)
+		assert.Equal(t, previous, keys.PublicKeys()[1])
+	}
+
+	access, _ := loginClaims()
+	rotated, err := old.Signer().Sign(access)
+	require.NoError(t, err)
+	_, err = keys.Verify(rotated)
+	assert.NoError(t, err, "the tokens of the previous key stay valid")
+
+	forged, err := unknown.Signer().Sign(access)
+	require.NoError(t, err)
+	_, err = keys.Verify(forged)
+	assert.Error(t, err, "the tokens of an unknown key are rejected")
+
+	// a token of the previous key cannot be verified with another algorithm
+	header := jwt.NewWithClaims(jwt.SigningMethodES256, access)
+	header.Header["kid"] = previous.KeyID
+	mismatched, err := header.SignedString(newKey)
+	require.NoError(t, err)
+	_, err = keys.Verify(mismatched)
+	assert.Error(t, err)
+}
+
+func TestKeysWithRotation(t *testing.T) {
+	rotation := NewRotation(SigningKey{Secret: []byte("first-signing-key")}, nil)
+	keys := NewHS256Keys("first-signing-key").WithRotation(rotation)
+	signer := keys.Signer()
+
+	access, _ := loginClaims()
+	first, err := signer.Sign(access)
+	require.NoError(t, err)
+
+	assert.False(t, rotation.Update(SigningKey{Secret: []byte("first-signing-key")}, nil), "the keys are unchanged")
+	assert.True(t, rotation.Update(SigningKey{Secret: []byte("second-signing-key")}, nil))
+	second, err := signer.Sign(access)
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second, "the tokens are signed with the active key")
+
+	_, err = jwt.Parse(second, func(*jwt.Token) (interface{}, error) { return []byte("second-signing-key"), nil })
+	assert.NoError(t, err)
+	_, err = keys.Verify(second)
+	assert.NoError(t, err)
+	_, err = keys.Verify(first)
+	assert.NoError(t, err, "the tokens of the replaced key stay valid")
+
+	rotation.Update(SigningKey{Secret: []byte("third-signing-key")}, nil)
+	_, err = keys.Verify(second)
+	assert.NoError(t, err)
+	_, err = keys.Verify(first)
+	assert.Error(t, err, "the tokens of the keys replaced before the last update are rejected")
+}
+
+func TestKeysWithRotationOfKeyIDs(t *testing.T) {
+	legacy := SigningKey{Secret: []byte("legacy-signing-key")}
+	first := SigningKey{ID: "2026-10-01", Secret: []byte("first-signing-key")}
+	second := SigningKey{ID: "2026-10-15", Secret: []byte("second-signing-key")}
+
+	rotation := NewRotation(first, []SigningKey{legacy})
+	keys := NewHS256Keys("legacy-signing-key").WithRotation(rotation)
+	assert.Equal(t, "2026-10-01", keys.KeyID())
+
+	access, _ := loginClaims()
+	old, err := NewHS256Keys("legacy-signing-key").Signer().Sign(access)
+	require.NoError(t, err)
+	signedFirst, err := keys.Signer().Sign(access)
+	require.NoError(t, err)
+	parsed, err := keys.Verify(signedFirst)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "2026-10-01", parsed.Header["kid"], "the tokens carry the ID of the active key")
+	}
+
+	first.ExpiresAt = time.Now().Add(time.Hour)
+	rotation.Update(second, []SigningKey{first, legacy})
+	assert.Equal(t, "2026-10-15", keys.KeyID())
+	signedSecond, err := keys.Signer().Sign(access)
+	require.NoError(t, err)
+	for _, token := range []string{old, signedFirst, signedSecond} {
+		_, err = keys.Verify(token)
+		assert.NoError(t, err, "the keys of the set verify the tokens until they expire")
+	}
+
+	first.ExpiresAt = time.Now().Add(-time.Second)
+	rotation.Update(second, []SigningKey{first, legacy})
+	_, err = keys.Verify(signedFirst)
+	assert.Error(t, err, "an expired key no longer verifies the tokens")
+
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, access)
+	forged.Header["kid"] = "2026-10-15"
+	token, err := forged.SignedString([]byte("first-signing-key"))
+	require.NoError(t, err)
+	_, err = keys.Verify(token)
+	assert.Error(t, err, "a token is verified with the key of its kid header alone")
+
+	forged.Header["kid"] = "unknown"
+	token, err = forged.SignedString([]byte("second-signing-key"))
+	require.NoError(t, err)
+	_, err = keys.Verify(token)
+	assert.EqualError(t, err, "unknown key id: unknown")
+}
+
+func TestNewPublicKey(t *testing.T) {
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	_, err = NewPublicKey(p384.Public(), "")
+	assert.Error(t, err)
+	_, err = NewPublicKey([]byte("secret"), "")
+	assert.Error(t, err)
+}
@@ -1,8 +1,15 @@
 package auth
 
+import "time"
+
 // User represents a user domain.
 type User struct {
-	ID       string `json:"id"`
-	Username string `json:"username"`
-	Role     string `json:"role"`
+	ID          string    `json:"id"`
+	Username    string    `json:"username"`
+	Role        string    `json:"role"`
+	SessionID   string    `json:"session_id"`
+	TokenID     string    `json:"token_id"`    // jti of the access token
+	ExpiresAt   time.Time `json:"expires_at"`  // expiry of the access token
+	Roles       []string  `json:"roles"`       // roles granted by the access token
+	Permissions []string  `json:"permissions"` // permissions of the roles, checked by authz
 }
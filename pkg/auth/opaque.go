@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+// OpaqueTokenPrefix prefixes the opaque access tokens, telling them from the signed ones
+const OpaqueTokenPrefix = "oat_"
+
+// NewOpaqueToken generates a random opaque access token
+func NewOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "cannot generate opaque token")
+	}
+	return OpaqueTokenPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// IsOpaqueToken tells whether the token is an opaque access token
+func IsOpaqueToken(token string) bool {
+	return strings.HasPrefix(token, OpaqueTokenPrefix)
+}
+
+// OpaqueTokenDigest returns the hex SHA-256 of the opaque token, which stores it
+func OpaqueTokenDigest(token string) string {
+	digest := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(digest[:])
+}
+
+// OpaqueTokenFromRequest returns the opaque access token of the request, false when it carries none
+func OpaqueTokenFromRequest(c echo.Context) (string, bool) {
+	token := extractToken(c)
+	return token, IsOpaqueToken(token)
+}
@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"bytes"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// SigningKey is a shared secret (HS256) of a rotation, identified by the kid header of the tokens it signs when its
+// ID is not empty
+type SigningKey struct {
+	ID     string
+	Secret []byte
+	// ExpiresAt is the end of the grace period of a retired key, which no longer verifies the tokens once it is over,
+	// the key never expires when it is zero
+	ExpiresAt time.Time
+}
+
+func (k SigningKey) equal(other SigningKey) bool {
+	return k.ID == other.ID && bytes.Equal(k.Secret, other.Secret) && k.ExpiresAt.Equal(other.ExpiresAt)
+}
+
+// Rotation is the set of the shared secrets (HS256) as they are rotated. The tokens are signed with the active key,
+// and verified with the key of their kid header, or with the keys without ID when they have none, until it expires.
+type Rotation struct {
+	mu     sync.RWMutex
+	active SigningKey
+	others []SigningKey
+	// retired is the active key replaced by the last update without being kept, see Update
+	retired *SigningKey
+	signer  *Signer
+}
+
+// NewRotation creates a rotation signing with the active key, the other keys only verifying the tokens
+func NewRotation(active SigningKey, others []SigningKey) *Rotation {
+	r := &Rotation{}
+	r.set(active, others)
+	return r
+}
+
+// Update replaces the keys of the rotation, it returns false when they are unchanged. The active key replaced keeps
+// verifying the tokens it signed until the next update when it is not one of the keys, so that the tokens signed
+// before a rotation without key IDs, such as the one of JWT_SIGNING_KEY, stay valid.
+func (r *Rotation) Update(active SigningKey, others []SigningKey) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.active.equal(active) && equalKeys(r.others, others) {
+		return false
+	}
+
+	r.retired = nil
+	if previous := r.active; !previous.equal(active) {
+		kept := false
+		for _, key := range others {
+			kept = kept || (key.ID == previous.ID && bytes.Equal(key.Secret, previous.Secret))
+		}
+		if !kept {
+			r.retired = &previous
+		}
+	}
+	r.set(active, others)
+	return true
+}
+
+func (r *Rotation) set(active SigningKey, others []SigningKey) {
+	r.active = active
+	r.others = append([]SigningKey(nil), others...)
+	// HS256 is always available and the key is a []byte, so this cannot fail
+	r.signer, _ = newSigner(jwt.SigningMethodHS256, active.Secret, active.ID)
+}
+
+// ActiveKeyID returns the ID of the key signing the tokens, empty when it has none
+func (r *Rotation) ActiveKeyID() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.active.ID
+}
+
+// secrets returns the secrets of the keys of the ID which are not expired, the active one first
+func (r *Rotation) secrets(id string) [][]byte {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := append([]SigningKey{r.active}, r.others...)
+	if r.retired != nil {
+		keys = append(keys, *r.retired)
+	}
+	now := time.Now()
+	var res [][]byte
+	for _, key := range keys {
+		if key.ID == id && len(key.Secret) > 0 && (key.ExpiresAt.IsZero() || now.Before(key.ExpiresAt)) {
+			res = append(res, key.Secret)
+		}
+	}
+	return res
+}
+
+// currentSigner returns the signer of the active key
+func (r *Rotation) currentSigner() *Signer {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.signer
+}
+
+func equalKeys(left, right []SigningKey) bool {
+	if len(left) != len(right) {
+		return false
+	}
+	for i := range left {
+		if !left[i].equal(right[i]) {
+			return false
+		}
+	}
+	return true
+}
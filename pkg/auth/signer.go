@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"encoding/base64"
+	"encoding/json"
+	"hash"
+	"sync"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Signer signs JWT tokens with a key that is parsed once.
+// The encoded header is cached, claim serialization buffers are pooled and,
+// for HMAC methods, keyed hashers are reused instead of being rebuilt on every token.
+// The signer of a rotation signs with the signer of its current secret.
+type Signer struct {
+	method   jwt.SigningMethod
+	key      interface{}
+	header   []byte
+	hashers  *sync.Pool
+	rotation *Rotation
+}
+
+var claimBuffers = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// NewSigner creates a signer for the given method and its already parsed key
+func NewSigner(method jwt.SigningMethod, key interface{}) (*Signer, error) {
+	return newSigner(method, key, "")
+}
+
+// newSigner creates a signer setting the kid header of the tokens when the key ID is not empty
+func newSigner(method jwt.SigningMethod, key interface{}, keyID string) (*Signer, error) {
+
+	fields := map[string]interface{}{
+		"typ": "JWT",
+		"alg": method.Alg(),
+	}
+	if keyID != "" {
+		fields["kid"] = keyID
+	}
+	header, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Signer{
+		method: method,
+		key:    key,
+		header: []byte(jwt.EncodeSegment(header)),
+	}
+
+	if m, ok := method.(*jwt.SigningMethodHMAC); ok {
+		secret, ok := key.([]byte)
+		if !ok {
+			return nil, jwt.ErrInvalidKeyType
+		}
+		if !m.Hash.Available() {
+			return nil, jwt.ErrHashUnavailable
+		}
+		s.hashers = &sync.Pool{
+			New: func() interface{} { return hmac.New(m.Hash.New, secret) },
+		}
+	}
+
+	return s, nil
+}
+
+// NewHS256Signer creates a HS256 signer from the signing key
+func NewHS256Signer(signingKey string) *Signer {
+	// HS256 is always available and the key is a []byte, so this cannot fail
+	s, _ := NewSigner(jwt.SigningMethodHS256, []byte(signingKey))
+	return s
+}
+
+// Sign serializes the claims and returns the signed token.
+// The result is identical to jwt.NewWithClaims(method, claims).SignedString(key).
+func (s *Signer) Sign(claims jwt.Claims) (string, error) {
+	if s.rotation != nil {
+		return s.rotation.currentSigner().Sign(claims)
+	}
+
+	buf := claimBuffers.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer claimBuffers.Put(buf)
+
+	err := json.NewEncoder(buf).Encode(claims)
+	if err != nil {
+		return "", err
+	}
+	// the encoder terminates every value with a newline
+	payload := bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+
+	payloadLen := base64.RawURLEncoding.EncodedLen(len(payload))
+	token := make([]byte, len(s.header)+1+payloadLen, len(s.header)+1+payloadLen+1+base64.RawURLEncoding.EncodedLen(64))
+	n := copy(token, s.header)
+	token[n] = '.'
+	base64.RawURLEncoding.Encode(token[n+1:], payload)
+
+	if s.hashers == nil {
+		signature, err := s.method.Sign(string(token), s.key)
+		if err != nil {
+			return "", err
+		}
+		return string(append(append(token, '.'), signature...)), nil
+	}
+
+	h := s.hashers.Get().(hash.Hash)
+	defer s.hashers.Put(h)
+	h.Reset()
+	h.Write(token)
+
+	var sum [64]byte
+	signature := h.Sum(sum[:0])
+
+	signingLen := len(token)
+	token = append(token, '.')
+	token = token[:signingLen+1+base64.RawURLEncoding.EncodedLen(len(signature))]
+	base64.RawURLEncoding.Encode(token[signingLen+1:], signature)
+
+	return string(token), nil
+}
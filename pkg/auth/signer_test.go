@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+const benchmarkSigningKey = "benchmark-signing-key"
+
+// loginClaims mirrors the access and refresh claims issued on every login
+func loginClaims() (jwt.MapClaims, jwt.MapClaims) {
+	return jwt.MapClaims{
+		"id":         "01GB2QKZ5X3ZJXK8V1M6D7Y9QF",
+		"username":   "john.doe",
+		"sid":        "01GB2QM0B1DRTQ4SPG8X6W3ZJA",
+		"exp":        time.Now().Add(time.Hour).Unix(),
+		"token_type": "access_token",
+	}, jwt.MapClaims{
+		"id":         "01GB2QKZ5X3ZJXK8V1M6D7Y9QF",
+		"sid":        "01GB2QM0B1DRTQ4SPG8X6W3ZJA",
+		"exp":        time.Now().AddDate(1000, 0, 0).Unix(),
+		"token_type": "refresh_token",
+	}
+}
+
+func TestSignerMatchesJWT(t *testing.T) {
+
+	access, refresh := loginClaims()
+
+	tests := []struct {
+		name   string
+		method jwt.SigningMethod
+		claims jwt.Claims
+	}{
+		{"HS256 access", jwt.SigningMethodHS256, access},
+		{"HS256 refresh", jwt.SigningMethodHS256, refresh},
+		{"HS512 standard claims", jwt.SigningMethodHS512, jwt.StandardClaims{Subject: "sub", ExpiresAt: time.Now().Add(time.Hour).Unix()}},
+		{"HTML characters", jwt.SigningMethodHS256, jwt.MapClaims{"username": "<a&b>"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, err := NewSigner(tt.method, []byte(benchmarkSigningKey))
+			if err != nil {
+				t.Fatalf("NewSigner() error = %v", err)
+			}
+
+			got, err := signer.Sign(tt.claims)
+			if err != nil {
+				t.Fatalf("Sign() error = %v", err)
+			}
+			want, err := jwt.NewWithClaims(tt.method, tt.claims).SignedString([]byte(benchmarkSigningKey))
+			if err != nil {
+				t.Fatalf("SignedString() error = %v", err)
+			}
+			if got != want {
+				t.Errorf("Sign() = %v, want %v", got, want)
+			}
+
+			if _, err := VerifyToken(got, NewHS256Keys(benchmarkSigningKey)); err != nil {
+				t.Errorf("VerifyToken() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestNewSignerRejectsInvalidKey(t *testing.T) {
+	if _, err := NewSigner(jwt.SigningMethodHS256, benchmarkSigningKey); err != jwt.ErrInvalidKeyType {
+		t.Errorf("NewSigner() error = %v, want %v", err, jwt.ErrInvalidKeyType)
+	}
+}
+
+func TestSignerConcurrentUse(t *testing.T) {
+
+	signer := NewHS256Signer(benchmarkSigningKey)
+	access, _ := loginClaims()
+	want, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, access).SignedString([]byte(benchmarkSigningKey))
+
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		go func() {
+			for j := 0; j < 100; j++ {
+				got, err := signer.Sign(access)
+				if err == nil && got != want {
+					err = jwt.ErrSignatureInvalid
+				}
+				if err != nil {
+					errs <- err
+					return
+				}
+			}
+			errs <- nil
+		}()
+	}
+	for i := 0; i < 8; i++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("Sign() error = %v", err)
+		}
+	}
+}
+
+// BenchmarkLoginTokensJWT signs the login token pair the way the services did before the signer
+func BenchmarkLoginTokensJWT(b *testing.B) {
+
+	access, refresh := loginClaims()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := jwt.NewWithClaims(jwt.SigningMethodHS256, access).SignedString([]byte(benchmarkSigningKey)); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := jwt.NewWithClaims(jwt.SigningMethodHS256, refresh).SignedString([]byte(benchmarkSigningKey)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkLoginTokensSigner signs the login token pair with the cached signer
+func BenchmarkLoginTokensSigner(b *testing.B) {
+
+	signer := NewHS256Signer(benchmarkSigningKey)
+	access, refresh := loginClaims()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := signer.Sign(access); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := signer.Sign(refresh); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLoginTokensSignerParallel(b *testing.B) {
+
+	signer := NewHS256Signer(benchmarkSigningKey)
+	access, refresh := loginClaims()
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := signer.Sign(access); err != nil {
+				b.Fatal(err)
+			}
+			if _, err := signer.Sign(refresh); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,57 @@
+// Package authz checks the permissions granted to the logged in user by the roles of its access token,
+// either by the routes with the Require middleware or by the services with Check.
+package authz
+
+import (
+	"context"
+	"go-hex/pkg/auth"
+	"go-hex/shared/ierr"
+	"go-hex/shared/response"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// all grants every permission
+const all = "*"
+
+// Require is a middleware rejecting the requests of the logged in users not granted the permission,
+// it runs after middleware.MustLoggedIn which sets the logged in user.
+func Require(permission string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if err := Check(c.Request().Context(), permission); err != nil {
+				if err == ierr.ErrUnauthorized {
+					return response.ErrUnauthorized(err)
+				}
+				return response.ErrForbidden(err)
+			}
+			return next(c)
+		}
+	}
+}
+
+// Check checks whether the logged in user is granted the permission, it returns ierr.ErrUnauthorized without
+// logged in user and ierr.ErrForbidden when the permission is not granted.
+func Check(ctx context.Context, permission string) error {
+	user := auth.GetLoggedInUser(ctx)
+	if user.ID == "" {
+		return ierr.ErrUnauthorized
+	}
+	if !Grants(user.Permissions, permission) {
+		return ierr.ErrForbidden
+	}
+	return nil
+}
+
+// Grants checks whether the granted permissions grant the required one: the same permission, * granting every
+// permission, or resource:* granting every action on the resource.
+func Grants(granted []string, required string) bool {
+	resource, _, _ := strings.Cut(required, ":")
+	for _, permission := range granted {
+		if permission == all || permission == required || permission == resource+":"+all {
+			return true
+		}
+	}
+	return false
+}
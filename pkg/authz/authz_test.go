@@ -0,0 +1,81 @@
+package authz
+
+import (
+	"context"
+	"go-hex/shared/ctxutil"
+	"go-hex/shared/ierr"
+	"go-hex/shared/response"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGrants(t *testing.T) {
+	tests := []struct {
+		name     string
+		granted  []string
+		required string
+		want     bool
+	}{
+		{"same permission", []string{"users:read", "users:write"}, "users:write", true},
+		{"every permission", []string{"*"}, "users:write", true},
+		{"every action of the resource", []string{"users:*"}, "users:write", true},
+		{"another action", []string{"users:read"}, "users:write", false},
+		{"every action of another resource", []string{"roles:*"}, "users:write", false},
+		{"no permission", nil, "users:write", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Grants(tt.granted, tt.required))
+		})
+	}
+}
+
+func TestRequire(t *testing.T) {
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		status int
+	}{
+		{"granted", withPermissions("users:write"), http.StatusOK},
+		{"not granted", withPermissions("users:read"), http.StatusForbidden},
+		{"not logged in", context.Background(), http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.HTTPErrorHandler = func(err error, c echo.Context) {
+				_ = c.NoContent(err.(response.ErrorResponse).StatusCode())
+			}
+			e.GET("/users", func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			}, Require("users:write"))
+
+			req := httptest.NewRequest(http.MethodGet, "/users", nil).WithContext(tt.ctx)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.status, rec.Code)
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	assert.NoError(t, Check(withPermissions("*"), "roles:write"))
+	assert.Equal(t, ierr.ErrForbidden, Check(withPermissions("profile:read"), "roles:write"))
+	assert.Equal(t, ierr.ErrUnauthorized, Check(context.Background(), "roles:write"))
+}
+
+// withPermissions returns a context logged in with an access token granting the permissions
+func withPermissions(permissions ...string) context.Context {
+	granted := []interface{}{}
+	for _, permission := range permissions {
+		granted = append(granted, permission)
+	}
+	token := &jwt.Token{Claims: jwt.MapClaims{"id": "u1", "permissions": granted}}
+	return ctxutil.WithPrincipal(context.Background(), token)
+}
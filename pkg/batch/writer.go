@@ -0,0 +1,154 @@
+package batch
+
+import (
+	"context"
+	"go-hex/pkg/logger"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ErrWriterClosed is returned when an item is written after the writer was closed
+var ErrWriterClosed = errors.New("batch writer is closed")
+
+// FlushFunc writes a batch of items, the whole batch is lost when it fails
+type FlushFunc[T any] func(ctx context.Context, batch []T) error
+
+// Metrics are the metrics a writer reports its items to, created by the package of the writer so that they keep its names
+type Metrics struct {
+	Buffered prometheus.Gauge
+	Written  prometheus.Counter
+	Lost     *prometheus.CounterVec // by reason: closed, buffer_full, cancelled or write_failed
+}
+
+// Writer buffers the items and flushes them in batches, once the batch is full or every flush interval,
+// so that writing an item does not add a round trip to the caller. When the buffer is full the writer either
+// blocks the caller or drops the item, the items dropped or failing to be flushed are counted as lost.
+type Writer[T any] struct {
+	name         string
+	log          logger.Logger
+	metrics      Metrics
+	flush        FlushFunc[T]
+	batchSize    int
+	dropWhenFull bool
+
+	mu     sync.RWMutex
+	closed bool
+	items  chan T
+	done   chan struct{}
+}
+
+// NewWriter creates a writer buffering up to bufferSize items until it is closed. The name is the type of its logs.
+func NewWriter[T any](name string, log logger.Logger, metrics Metrics, bufferSize, batchSize int, flushInterval time.Duration, dropWhenFull bool, flush FlushFunc[T]) *Writer[T] {
+	w := &Writer[T]{
+		name:         name,
+		log:          log,
+		metrics:      metrics,
+		flush:        flush,
+		batchSize:    batchSize,
+		dropWhenFull: dropWhenFull,
+		items:        make(chan T, bufferSize),
+		done:         make(chan struct{}),
+	}
+	go w.run(flushInterval)
+	return w
+}
+
+// Write queues the item. Blocking, it waits for room in the buffer and fails when ctx is done first,
+// dropping, a full buffer discards the item.
+func (w *Writer[T]) Write(ctx context.Context, item T) error {
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		w.lose("closed", 1)
+		return ErrWriterClosed
+	}
+
+	if w.dropWhenFull {
+		select {
+		case w.items <- item:
+		default:
+			w.lose("buffer_full", 1)
+			return nil
+		}
+	} else {
+		select {
+		case w.items <- item:
+		case <-ctx.Done():
+			w.lose("cancelled", 1)
+			return errors.Wrapf(ctx.Err(), "cannot buffer %s item", w.name)
+		}
+	}
+
+	w.metrics.Buffered.Inc()
+	return nil
+}
+
+// Close stops accepting items and returns once the buffered items were flushed
+func (w *Writer[T]) Close() {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.items)
+	}
+	w.mu.Unlock()
+	<-w.done
+}
+
+// Buffered returns the number of items waiting to be flushed
+func (w *Writer[T]) Buffered() int {
+	return len(w.items)
+}
+
+func (w *Writer[T]) run(flushInterval time.Duration) {
+	defer close(w.done)
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]T, 0, w.batchSize)
+	for {
+		select {
+		case item, ok := <-w.items:
+			if !ok {
+				w.write(batch)
+				return
+			}
+			batch = append(batch, item)
+			if len(batch) >= w.batchSize {
+				w.write(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			w.write(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+// write flushes the batch, the items of a batch failing to be flushed are counted as lost
+func (w *Writer[T]) write(batch []T) {
+	if len(batch) == 0 {
+		return
+	}
+	w.metrics.Buffered.Sub(float64(len(batch)))
+
+	err := w.flush(context.Background(), batch)
+	if err != nil {
+		w.lose("write_failed", len(batch))
+		w.log.WithParams(logger.Params{"type": w.name, "items": len(batch)}).Error(err)
+		return
+	}
+	w.metrics.Written.Add(float64(len(batch)))
+}
+
+func (w *Writer[T]) lose(reason string, count int) {
+	w.metrics.Lost.WithLabelValues(reason).Add(float64(count))
+	if reason != "write_failed" {
+		w.log.WithParams(logger.Params{"type": w.name, "reason": reason}).Warn("item lost")
+	}
+}
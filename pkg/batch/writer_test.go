@@ -0,0 +1,124 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"go-hex/pkg/logger"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func newMetrics() Metrics {
+	return Metrics{
+		Buffered: prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_buffered"}),
+		Written:  prometheus.NewCounter(prometheus.CounterOpts{Name: "test_written_total"}),
+		Lost:     prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_lost_total"}, []string{"reason"}),
+	}
+}
+
+// flushed collects the batches flushed, each waiting for block when set
+type flushed struct {
+	mu      sync.Mutex
+	block   chan struct{}
+	err     error
+	batches [][]string
+}
+
+func (f *flushed) flush(ctx context.Context, batch []string) error {
+	if f.block != nil {
+		<-f.block
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.batches = append(f.batches, append([]string(nil), batch...))
+	return f.err
+}
+
+func (f *flushed) get() [][]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.batches
+}
+
+func TestWriterBatches(t *testing.T) {
+	f := &flushed{}
+	metrics := newMetrics()
+	w := NewWriter("test", logger.New("test", "test"), metrics, 10, 2, time.Hour, false, f.flush)
+
+	for _, item := range []string{"a", "b", "c", "d", "e"} {
+		assert.NoError(t, w.Write(context.Background(), item))
+	}
+	// the last item is only flushed on close
+	w.Close()
+
+	assert.Equal(t, [][]string{{"a", "b"}, {"c", "d"}, {"e"}}, f.get())
+	assert.Equal(t, float64(5), testutil.ToFloat64(metrics.Written))
+	assert.Equal(t, float64(0), testutil.ToFloat64(metrics.Buffered))
+
+	assert.Equal(t, ErrWriterClosed, w.Write(context.Background(), "f"))
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.Lost.WithLabelValues("closed")))
+}
+
+func TestWriterFlushInterval(t *testing.T) {
+	f := &flushed{}
+	w := NewWriter("test", logger.New("test", "test"), newMetrics(), 10, 100, 10*time.Millisecond, false, f.flush)
+	defer w.Close()
+
+	assert.NoError(t, w.Write(context.Background(), "a"))
+	assert.Eventually(t, func() bool { return len(f.get()) == 1 }, time.Second, 5*time.Millisecond)
+}
+
+func TestWriterFlushFailed(t *testing.T) {
+	f := &flushed{err: errors.New("database down")}
+	metrics := newMetrics()
+	w := NewWriter("test", logger.New("test", "test"), metrics, 10, 2, time.Hour, false, f.flush)
+
+	for _, item := range []string{"a", "b", "c"} {
+		assert.NoError(t, w.Write(context.Background(), item))
+	}
+	w.Close()
+
+	assert.Equal(t, float64(0), testutil.ToFloat64(metrics.Written))
+	assert.Equal(t, float64(3), testutil.ToFloat64(metrics.Lost.WithLabelValues("write_failed")))
+}
+
+func TestWriterBackpressure(t *testing.T) {
+
+	tests := []struct {
+		name         string
+		dropWhenFull bool
+		wantErr      bool
+		wantReason   string
+	}{
+		{name: "drop discards the item", dropWhenFull: true, wantErr: false, wantReason: "buffer_full"},
+		{name: "block waits until the context is done", dropWhenFull: false, wantErr: true, wantReason: "cancelled"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &flushed{block: make(chan struct{})}
+			metrics := newMetrics()
+			w := NewWriter("test", logger.New("test", "test"), metrics, 1, 1, time.Hour, tt.dropWhenFull, f.flush)
+
+			// the first item is held by the blocked flush, the second one fills the buffer
+			assert.NoError(t, w.Write(context.Background(), "written"))
+			assert.Eventually(t, func() bool { return w.Buffered() == 0 }, time.Second, time.Millisecond)
+			assert.NoError(t, w.Write(context.Background(), "buffered"))
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			err := w.Write(ctx, "overflow")
+			assert.Equal(t, tt.wantErr, err != nil)
+
+			close(f.block)
+			w.Close()
+			assert.Equal(t, [][]string{{"written"}, {"buffered"}}, f.get())
+			assert.Equal(t, float64(1), testutil.ToFloat64(metrics.Lost.WithLabelValues(tt.wantReason)))
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package db
+
+import "github.com/uptrace/bun/dialect/pgdialect"
+
+// CockroachDialect is the PostgreSQL dialect of the connections to CockroachDB, which speaks PostgreSQL.
+// The queries are those of PostgreSQL, the repositories tell CockroachDB apart by its dialect to use its own features,
+// such as the follower reads of the analytics queries.
+type CockroachDialect struct {
+	*pgdialect.Dialect
+}
+
+// NewCockroachDialect creates the dialect of the connections to CockroachDB
+func NewCockroachDialect() *CockroachDialect {
+	return &CockroachDialect{pgdialect.New()}
+}
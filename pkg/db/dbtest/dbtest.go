@@ -0,0 +1,159 @@
+// Package dbtest provides a database/sql driver whose statements block until their context is done.
+// It is used to prove that the cancellation of a request reaches the statements it issued.
+// The version probe run by the bun dialects is answered right away.
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Version is returned to the version probe of the bun dialects
+const Version = "8.0.0"
+
+// BlockingDriver is a connector whose statements never complete on their own.
+type BlockingDriver struct {
+	mu        sync.Mutex
+	inFlight  int
+	started   int
+	cancelled int
+	startedCh chan struct{}
+}
+
+// NewBlockingDriver creates a blocking driver
+func NewBlockingDriver() *BlockingDriver {
+	return &BlockingDriver{startedCh: make(chan struct{}, 100)}
+}
+
+// DB opens a *sql.DB on top of the driver
+func (d *BlockingDriver) DB() *sql.DB {
+	return sql.OpenDB(d)
+}
+
+// InFlight returns the number of statements still running
+func (d *BlockingDriver) InFlight() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.inFlight
+}
+
+// Started returns the number of statements sent to the driver
+func (d *BlockingDriver) Started() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.started
+}
+
+// Cancelled returns the number of statements stopped by their context
+func (d *BlockingDriver) Cancelled() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.cancelled
+}
+
+// WaitStarted blocks until a statement is sent to the driver
+func (d *BlockingDriver) WaitStarted() {
+	<-d.startedCh
+}
+
+// Connect implements driver.Connector
+func (d *BlockingDriver) Connect(context.Context) (driver.Conn, error) {
+	return &conn{d}, nil
+}
+
+// Driver implements driver.Connector
+func (d *BlockingDriver) Driver() driver.Driver {
+	return drv{d}
+}
+
+func (d *BlockingDriver) block(ctx context.Context) error {
+	d.mu.Lock()
+	d.inFlight++
+	d.started++
+	d.mu.Unlock()
+	d.startedCh <- struct{}{}
+
+	<-ctx.Done()
+
+	d.mu.Lock()
+	d.inFlight--
+	d.cancelled++
+	d.mu.Unlock()
+	return ctx.Err()
+}
+
+type drv struct {
+	d *BlockingDriver
+}
+
+func (d drv) Open(string) (driver.Conn, error) {
+	return &conn{d.d}, nil
+}
+
+type conn struct {
+	d *BlockingDriver
+}
+
+func (c *conn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("dbtest: prepared statements are not supported")
+}
+
+func (c *conn) Close() error {
+	return nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return tx{}, nil
+}
+
+func (c *conn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	return tx{}, nil
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if strings.EqualFold(strings.TrimSpace(query), "SELECT version()") {
+		return &versionRows{}, nil
+	}
+	return nil, c.d.block(ctx)
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return nil, c.d.block(ctx)
+}
+
+type tx struct{}
+
+func (tx) Commit() error {
+	return nil
+}
+
+func (tx) Rollback() error {
+	return nil
+}
+
+// versionRows answers the version probe with a single row
+type versionRows struct {
+	done bool
+}
+
+func (r *versionRows) Columns() []string {
+	return []string{"version()"}
+}
+
+func (r *versionRows) Close() error {
+	return nil
+}
+
+func (r *versionRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = Version
+	return nil
+}
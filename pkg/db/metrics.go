@@ -0,0 +1,42 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"go-hex/pkg/metrics"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/uptrace/bun"
+)
+
+var queryDuration = metrics.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "db_query_duration_seconds",
+	Help:    "Duration of the statements of the repositories, by table, operation and result (ok or error).",
+	Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+}, "table", "operation", "result")
+
+// QueryMetricsHook observes the duration of every statement built with bun in db_query_duration_seconds,
+// so that the repositories are instrumented without measuring each of their queries.
+// The raw statements are observed under the raw table, a row not found is not an error.
+type QueryMetricsHook struct{}
+
+// BeforeQuery implements bun.QueryHook
+func (QueryMetricsHook) BeforeQuery(ctx context.Context, event *bun.QueryEvent) context.Context {
+	return ctx
+}
+
+// AfterQuery implements bun.QueryHook
+func (QueryMetricsHook) AfterQuery(ctx context.Context, event *bun.QueryEvent) {
+	table := "raw"
+	if event.IQuery != nil && event.IQuery.GetTableName() != "" {
+		table = strings.Trim(event.IQuery.GetTableName(), "`")
+	}
+	result := "ok"
+	if event.Err != nil && !errors.Is(event.Err, sql.ErrNoRows) {
+		result = "error"
+	}
+	queryDuration.WithLabelValues(table, strings.ToLower(event.Operation()), result).Observe(time.Since(event.StartTime).Seconds())
+}
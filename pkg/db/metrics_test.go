@@ -0,0 +1,29 @@
+package db
+
+import (
+	"context"
+	"go-hex/pkg/db/dbtest"
+	"go-hex/pkg/metrics"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/mysqldialect"
+)
+
+func TestQueryMetricsHook(t *testing.T) {
+	drv := dbtest.NewBlockingDriver()
+	db := bun.NewDB(drv.DB(), mysqldialect.New())
+	db.AddQueryHook(NewStatementTimeoutHook(time.Minute, map[string]time.Duration{"users.select": 10 * time.Millisecond}))
+	db.AddQueryHook(QueryMetricsHook{})
+
+	var model timeoutModel
+	err := db.NewSelect().Model(&model).Scan(context.Background())
+	assert.Error(t, err)
+
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Contains(t, rec.Body.String(), `db_query_duration_seconds_count{operation="select",result="error",table="users"} 1`)
+}
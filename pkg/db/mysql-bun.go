@@ -4,24 +4,104 @@ import (
 	"database/sql"
 	"fmt"
 	"go-hex/configs"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/pkg/errors"
 	"github.com/uptrace/bun"
 	"github.com/uptrace/bun/dialect/mysqldialect"
+	"github.com/uptrace/bun/dialect/pgdialect"
 	"github.com/uptrace/bun/extra/bundebug"
 )
 
-func NewBunMySQLConn(env configs.Env, host string, port string, user, password, dbName string) (*bun.DB, error) {
+// Option configures a bun connection
+type Option func(o *options)
 
-	connection := fmt.Sprintf("%s:%s@(%s:%s)/%s?parseTime=true&multiStatements=true", user, password, host, port, dbName)
-	sqldb, err := sql.Open("mysql", connection)
-	if err != nil {
-		return nil, errors.Wrap(err, "cannot open connection")
+type options struct {
+	timeouts *StatementTimeoutHook
+	driver   configs.DatabaseDriver
+	pool     Pool
+}
+
+// Pool tunes the connection pool of database/sql, the zero values keep its defaults
+type Pool struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+// WithDriver selects the database server, MySQL by default.
+// MySQL and MariaDB share the queries and migrations but not the variable bounding the statements on the server,
+// PostgreSQL has its own migrations and the upserts of the repositories are written for both dialects.
+// CockroachDB shares the queries and the migrations of PostgreSQL.
+func WithDriver(driver configs.DatabaseDriver) Option {
+	return func(o *options) {
+		o.driver = driver
 	}
+}
 
-	// Create a Bun db on top of it.
-	db := bun.NewDB(sqldb, mysqldialect.New())
+// WithStatementTimeout bounds the duration of the statements, see NewStatementTimeoutHook.
+// The longest timeout is also enforced by the server (max_execution_time) so that a statement
+// whose client went away cannot keep running forever.
+func WithStatementTimeout(timeout time.Duration, operations map[string]time.Duration) Option {
+	return func(o *options) {
+		o.timeouts = NewStatementTimeoutHook(timeout, operations)
+	}
+}
+
+// WithPool tunes the connection pool
+func WithPool(pool Pool) Option {
+	return func(o *options) {
+		o.pool = pool
+	}
+}
+
+// NewBunConn opens a bun connection to the database server selected by WithDriver
+func NewBunConn(env configs.Env, host string, port string, user, password, dbName string, opts ...Option) (*bun.DB, error) {
+
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var db *bun.DB
+	if o.driver.IsPostgres() {
+		sqldb, err := sql.Open("postgres", postgresDataSourceName(host, port, user, password, dbName, o))
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot open connection")
+		}
+		if o.driver.IsCockroachDB() {
+			db = bun.NewDB(sqldb, NewCockroachDialect())
+		} else {
+			db = bun.NewDB(sqldb, pgdialect.New())
+		}
+	} else {
+		sqldb, err := sql.Open("mysql", dataSourceName(host, port, user, password, dbName, o))
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot open connection")
+		}
+		db = bun.NewDB(sqldb, mysqldialect.New())
+	}
+
+	if o.pool.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(o.pool.MaxOpenConns)
+	}
+	if o.pool.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(o.pool.MaxIdleConns)
+	}
+	if o.pool.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(o.pool.ConnMaxLifetime)
+	}
+	if o.pool.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(o.pool.ConnMaxIdleTime)
+	}
+
+	db.AddQueryHook(QueryMetricsHook{})
+
+	if o.timeouts != nil {
+		db.AddQueryHook(o.timeouts)
+	}
 
 	if env.IsLocal() {
 		// Print all queries to stdout.
@@ -30,3 +110,16 @@ func NewBunMySQLConn(env configs.Env, host string, port string, user, password,
 
 	return db, nil
 }
+
+func dataSourceName(host string, port string, user, password, dbName string, o options) string {
+	connection := fmt.Sprintf("%s:%s@(%s:%s)/%s?parseTime=true&multiStatements=true", user, password, host, port, dbName)
+	if o.timeouts == nil || o.timeouts.maxTimeout() <= 0 {
+		return connection
+	}
+	if o.driver.IsMariaDB() {
+		// MariaDB bounds every statement, in seconds
+		return connection + fmt.Sprintf("&max_statement_time=%g", o.timeouts.maxTimeout().Seconds())
+	}
+	// MySQL only bounds the SELECT statements, in milliseconds
+	return connection + fmt.Sprintf("&max_execution_time=%d", o.timeouts.maxTimeout().Milliseconds())
+}
@@ -0,0 +1,37 @@
+package db
+
+import (
+	"go-hex/configs"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDataSourceName(t *testing.T) {
+	timeouts := NewStatementTimeoutHook(1500*time.Millisecond, nil)
+
+	tests := []struct {
+		name string
+		o    options
+		want string
+	}{
+		{name: "without timeout", o: options{}, want: "user:pass@(db:3306)/app?parseTime=true&multiStatements=true"},
+		{name: "mysql", o: options{timeouts: timeouts}, want: "user:pass@(db:3306)/app?parseTime=true&multiStatements=true&max_execution_time=1500"},
+		{name: "mariadb", o: options{timeouts: timeouts, driver: configs.DatabaseDriverMariaDB}, want: "user:pass@(db:3306)/app?parseTime=true&multiStatements=true&max_statement_time=1.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, dataSourceName("db", "3306", "user", "pass", "app", tt.o))
+		})
+	}
+}
+
+func TestPostgresDataSourceName(t *testing.T) {
+	timeouts := NewStatementTimeoutHook(1500*time.Millisecond, nil)
+
+	assert.Equal(t, "postgres://user:pass@db:5432/app?sslmode=disable", postgresDataSourceName("db", "5432", "user", "pass", "app", options{}))
+	assert.Equal(t, "postgres://user:p%40ss%2Fword@db:5432/app?sslmode=disable&statement_timeout=1500",
+		postgresDataSourceName("db", "5432", "user", "p@ss/word", "app", options{timeouts: timeouts}), "the credentials are escaped")
+}
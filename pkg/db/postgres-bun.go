@@ -0,0 +1,22 @@
+package db
+
+import (
+	"fmt"
+	"net/url"
+)
+
+func postgresDataSourceName(host string, port string, user, password, dbName string, o options) string {
+	query := url.Values{"sslmode": {"disable"}}
+	if o.timeouts != nil && o.timeouts.maxTimeout() > 0 {
+		// PostgreSQL bounds every statement, in milliseconds
+		query.Set("statement_timeout", fmt.Sprintf("%d", o.timeouts.maxTimeout().Milliseconds()))
+	}
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(user, password),
+		Host:     host + ":" + port,
+		Path:     "/" + dbName,
+		RawQuery: query.Encode(),
+	}
+	return dsn.String()
+}
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type cancelKey struct{}
+
+// StatementTimeoutHook bounds the duration of the statements built with bun.
+// The deadline is added to the context of the statement so that the driver cancels it
+// once reached, in the same way as when the request context is cancelled.
+// Transaction control statements (BEGIN, COMMIT, ROLLBACK) are never bounded
+// since the transaction would be rolled back as soon as their context is done.
+type StatementTimeoutHook struct {
+	timeout    time.Duration
+	operations map[string]time.Duration
+}
+
+// NewStatementTimeoutHook creates a hook applying the default timeout to every statement
+// unless an operation timeout is configured for its table and operation, e.g. "users.select".
+// A zero timeout does not bound the statements.
+func NewStatementTimeoutHook(timeout time.Duration, operations map[string]time.Duration) *StatementTimeoutHook {
+	normalized := make(map[string]time.Duration, len(operations))
+	for key, val := range operations {
+		normalized[strings.ToLower(key)] = val
+	}
+	return &StatementTimeoutHook{timeout, normalized}
+}
+
+// Timeout returns the timeout of the given operation on the given table.
+func (h *StatementTimeoutHook) Timeout(table, operation string) time.Duration {
+	if val, ok := h.operations[strings.ToLower(table+"."+operation)]; ok {
+		return val
+	}
+	return h.timeout
+}
+
+// BeforeQuery implements bun.QueryHook
+func (h *StatementTimeoutHook) BeforeQuery(ctx context.Context, event *bun.QueryEvent) context.Context {
+	if event.IQuery == nil {
+		return ctx
+	}
+
+	timeout := h.Timeout(event.IQuery.GetTableName(), event.IQuery.Operation())
+	if timeout <= 0 {
+		return ctx
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	if event.Stash == nil {
+		event.Stash = map[interface{}]interface{}{}
+	}
+	event.Stash[cancelKey{}] = cancel
+	return ctx
+}
+
+// AfterQuery implements bun.QueryHook
+func (h *StatementTimeoutHook) AfterQuery(ctx context.Context, event *bun.QueryEvent) {
+	if cancel, ok := event.Stash[cancelKey{}].(context.CancelFunc); ok {
+		cancel()
+	}
+}
+
+// maxTimeout returns the longest configured timeout, zero when a statement can be unbounded.
+func (h *StatementTimeoutHook) maxTimeout() time.Duration {
+	if h.timeout <= 0 {
+		return 0
+	}
+	longest := h.timeout
+	for _, val := range h.operations {
+		if val <= 0 {
+			return 0
+		}
+		if val > longest {
+			longest = val
+		}
+	}
+	return longest
+}
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"context"
+	"go-hex/pkg/db/dbtest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/mysqldialect"
+)
+
+type timeoutModel struct {
+	bun.BaseModel `bun:"table:users"`
+	ID            string
+}
+
+func TestStatementTimeoutHookTimeout(t *testing.T) {
+	hook := NewStatementTimeoutHook(time.Second, map[string]time.Duration{"Users.Select": 10 * time.Millisecond})
+
+	assert.Equal(t, 10*time.Millisecond, hook.Timeout("users", "SELECT"))
+	assert.Equal(t, time.Second, hook.Timeout("users", "UPDATE"))
+	assert.Equal(t, time.Second, hook.maxTimeout())
+	assert.Equal(t, time.Duration(0), NewStatementTimeoutHook(time.Second, map[string]time.Duration{"users.select": 0}).maxTimeout())
+}
+
+func TestStatementTimeoutHookCancelsStatement(t *testing.T) {
+	drv := dbtest.NewBlockingDriver()
+	db := bun.NewDB(drv.DB(), mysqldialect.New())
+	db.AddQueryHook(NewStatementTimeoutHook(time.Minute, map[string]time.Duration{"users.select": 20 * time.Millisecond}))
+
+	var model timeoutModel
+	err := db.NewSelect().Model(&model).Scan(context.Background())
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 1, drv.Cancelled())
+	assert.Equal(t, 0, drv.InFlight())
+}
+
+func TestContextCancellationCancelsStatement(t *testing.T) {
+	drv := dbtest.NewBlockingDriver()
+	db := bun.NewDB(drv.DB(), mysqldialect.New())
+	db.AddQueryHook(NewStatementTimeoutHook(time.Minute, nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		drv.WaitStarted()
+		cancel()
+	}()
+
+	var model timeoutModel
+	err := db.NewSelect().Model(&model).Scan(ctx)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, drv.Cancelled())
+	assert.Equal(t, 0, drv.InFlight())
+}
+
+func TestStatementTimeoutHookKeepsTransactionOpen(t *testing.T) {
+	drv := dbtest.NewBlockingDriver()
+	db := bun.NewDB(drv.DB(), mysqldialect.New())
+	db.AddQueryHook(NewStatementTimeoutHook(time.Millisecond, nil))
+
+	tx, err := db.BeginTx(context.Background(), nil)
+	assert.NoError(t, err)
+
+	time.Sleep(5 * time.Millisecond)
+	assert.NoError(t, tx.Commit())
+}
@@ -0,0 +1,140 @@
+// Package errchain walks the chain of the causes of an error, wrapped by github.com/pkg/errors or by fmt.Errorf with
+// %w, so that the logs and the traces tell what failed at every level and the error reporter groups the errors by the
+// stacks they were created and wrapped at.
+package errchain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Link is a level of the chain adding its message, outermost first, with the fingerprint of the stack it was created
+// or wrapped at when it has one
+type Link struct {
+	Message     string `json:"message"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// Chain is the chain of the causes of an error
+type Chain struct {
+	Links []Link `json:"links"`
+	// Type is the Go type of the root cause, e.g. *mysql.MySQLError
+	Type string `json:"type"`
+	// Fingerprints are the distinct fingerprints of the links, outermost first, an error wrapped twice at the same
+	// stack having it once
+	Fingerprints []string `json:"fingerprints,omitempty"`
+	// Fingerprint groups the errors of the same stacks and root type, or of the same root type and message when no
+	// level has a stack
+	Fingerprint string `json:"fingerprint"`
+}
+
+type causer interface {
+	Cause() error
+}
+
+type wrapper interface {
+	Unwrap() error
+}
+
+type stackTracer interface {
+	StackTrace() errors.StackTrace
+}
+
+// Walk returns the chain of the causes of the error, empty for a nil error. The wrappers adding no message, such as
+// errors.WithStack, are merged into the level they wrap, their stack being used when the level has none.
+func Walk(err error) Chain {
+	var chain Chain
+	if err == nil {
+		return chain
+	}
+
+	var pending errors.StackTrace
+	seen := map[string]bool{}
+	for err != nil {
+		next := unwrap(err)
+
+		var stack errors.StackTrace
+		if st, ok := err.(stackTracer); ok {
+			stack = st.StackTrace()
+		}
+		message := ownMessage(err, next)
+		if message == "" && next != nil {
+			// a wrapper adding no message, the stack of the innermost one is the one of the level it wraps, unless
+			// that one has its own
+			if stack != nil {
+				pending = stack
+			}
+			err = next
+			continue
+		}
+		if stack == nil {
+			stack = pending
+		}
+		pending = nil
+
+		link := Link{Message: message, Fingerprint: fingerprint(stack)}
+		chain.Links = append(chain.Links, link)
+		if link.Fingerprint != "" && !seen[link.Fingerprint] {
+			seen[link.Fingerprint] = true
+			chain.Fingerprints = append(chain.Fingerprints, link.Fingerprint)
+		}
+
+		if next == nil {
+			chain.Type = fmt.Sprintf("%T", err)
+			if len(chain.Fingerprints) == 0 {
+				chain.Fingerprint = digest(chain.Type, err.Error())
+			}
+		}
+		err = next
+	}
+	if chain.Fingerprint == "" {
+		chain.Fingerprint = digest(append([]string{chain.Type}, chain.Fingerprints...)...)
+	}
+	return chain
+}
+
+// unwrap returns the cause of the error, by its Cause of github.com/pkg/errors or else by its Unwrap
+func unwrap(err error) error {
+	switch e := err.(type) {
+	case causer:
+		return e.Cause()
+	case wrapper:
+		return e.Unwrap()
+	}
+	return nil
+}
+
+// ownMessage returns the message the error adds to its cause, "cannot get user" for "cannot get user: no rows"
+func ownMessage(err, cause error) string {
+	message := err.Error()
+	if cause == nil {
+		return message
+	}
+	causeMessage := cause.Error()
+	if message == causeMessage {
+		return ""
+	}
+	return strings.TrimSuffix(message, ": "+causeMessage)
+}
+
+// fingerprint returns the digest of the functions of the stack, so that it holds while the lines of the functions move
+func fingerprint(stack errors.StackTrace) string {
+	if len(stack) == 0 {
+		return ""
+	}
+	functions := make([]string, len(stack))
+	for i, frame := range stack {
+		// %+s formats the function and its file, on two lines
+		functions[i], _, _ = strings.Cut(fmt.Sprintf("%+s", frame), "\n")
+	}
+	return digest(functions...)
+}
+
+func digest(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\n")))
+	return hex.EncodeToString(sum[:8])
+}
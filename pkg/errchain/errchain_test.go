@@ -0,0 +1,40 @@
+package errchain
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var errNoRows = fmt.Errorf("no rows")
+
+func getUser() error {
+	return errors.Wrap(errNoRows, "cannot get user")
+}
+
+func login() error {
+	return fmt.Errorf("cannot log in: %w", errors.WithStack(getUser()))
+}
+
+func TestWalk(t *testing.T) {
+	chain := Walk(login())
+	if assert.Len(t, chain.Links, 3) {
+		assert.Equal(t, "cannot log in", chain.Links[0].Message)
+		assert.Empty(t, chain.Links[0].Fingerprint, "fmt.Errorf has no stack")
+		assert.Equal(t, "cannot get user", chain.Links[1].Message)
+		assert.NotEmpty(t, chain.Links[1].Fingerprint, "the stack of errors.WithStack is merged into the level it wraps")
+		assert.Equal(t, Link{Message: "no rows"}, chain.Links[2])
+	}
+	assert.Equal(t, "*errors.errorString", chain.Type)
+	assert.Equal(t, []string{chain.Links[1].Fingerprint}, chain.Fingerprints)
+	assert.Equal(t, chain.Fingerprint, Walk(login()).Fingerprint, "the errors of the same stacks are grouped")
+	assert.NotEqual(t, chain.Fingerprint, Walk(getUser()).Fingerprint)
+
+	// without stack, the errors are grouped by the type and the message of their root cause
+	assert.Equal(t, Walk(fmt.Errorf("a: %w", errNoRows)).Fingerprint, Walk(fmt.Errorf("b: %w", errNoRows)).Fingerprint)
+	assert.NotEqual(t, Walk(errNoRows).Fingerprint, Walk(fmt.Errorf("timeout")).Fingerprint)
+
+	assert.Equal(t, Chain{}, Walk(nil))
+}
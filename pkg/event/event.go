@@ -0,0 +1,68 @@
+// Package event provides a lightweight in-process publish/subscribe bus for domain events.
+package event
+
+import (
+	"context"
+	"go-hex/pkg/times"
+	"sync"
+	"time"
+)
+
+// All subscribes a handler to every published event.
+const All = "*"
+
+// Event represents something that happened in the domain.
+type Event struct {
+	Name       string                 `json:"name"`
+	ActorID    string                 `json:"actor_id,omitempty"`
+	SubjectID  string                 `json:"subject_id,omitempty"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+	OccurredAt time.Time              `json:"occurred_at"`
+}
+
+// Handler handles a published event.
+// Handlers are called synchronously, so the ones doing IO should hand the event off to a worker.
+type Handler func(ctx context.Context, e Event)
+
+// Bus publishes events to the subscribed handlers.
+type Bus interface {
+	// Publish publishes the event to every handler subscribed to its name.
+	Publish(ctx context.Context, e Event)
+	// Subscribe registers a handler for the given event name, use All to receive every event.
+	Subscribe(name string, h Handler)
+}
+
+type bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+// New creates a new in-process event bus
+func New() Bus {
+	return &bus{handlers: map[string][]Handler{}}
+}
+
+// Publish publishes the event to every handler subscribed to its name.
+func (b *bus) Publish(ctx context.Context, e Event) {
+
+	if e.OccurredAt.IsZero() {
+		e.OccurredAt = times.Now()
+	}
+
+	b.mu.RLock()
+	handlers := make([]Handler, 0, len(b.handlers[e.Name])+len(b.handlers[All]))
+	handlers = append(handlers, b.handlers[e.Name]...)
+	handlers = append(handlers, b.handlers[All]...)
+	b.mu.RUnlock()
+
+	for _, h := range handlers {
+		h(ctx, e)
+	}
+}
+
+// Subscribe registers a handler for the given event name, use All to receive every event.
+func (b *bus) Subscribe(name string, h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[name] = append(b.handlers[name], h)
+}
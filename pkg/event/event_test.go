@@ -0,0 +1,27 @@
+package event
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBusPublish(t *testing.T) {
+	bus := New()
+
+	var named, all []string
+	bus.Subscribe("session.evicted", func(ctx context.Context, e Event) {
+		named = append(named, e.Name)
+	})
+	bus.Subscribe(All, func(ctx context.Context, e Event) {
+		all = append(all, e.Name)
+		assert.False(t, e.OccurredAt.IsZero())
+	})
+
+	bus.Publish(context.Background(), Event{Name: "session.evicted"})
+	bus.Publish(context.Background(), Event{Name: "user.updated"})
+
+	assert.Equal(t, []string{"session.evicted"}, named)
+	assert.Equal(t, []string{"session.evicted", "user.updated"}, all)
+}
@@ -0,0 +1,141 @@
+// Package health checks the dependencies of the api for its readiness probe.
+//
+// The repositories, the caches and the external providers register the check of their dependency in a Registry.
+// A critical dependency is one the api cannot serve without, e.g. its database: when it is down, the instance is not
+// ready. The other dependencies only degrade some features, e.g. a social provider, and are reported without
+// failing the readiness.
+package health
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// The statuses of the dependencies and of the report
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusDegraded = "degraded" // a dependency which is not critical is down
+)
+
+// Func checks a dependency, it returns once checked or once ctx is done
+type Func func(ctx context.Context) error
+
+type check struct {
+	name     string
+	fn       Func
+	critical bool
+}
+
+// Result is the outcome of the check of a dependency
+type Result struct {
+	Name      string  `json:"name" example:"database"`
+	Status    string  `json:"status" example:"up" enums:"up,down"`
+	Critical  bool    `json:"critical" example:"true"`
+	LatencyMs float64 `json:"latency_ms" example:"1.2"`
+	Err       error   `json:"-"` // not answered to the probes, it may disclose the addresses of the dependencies
+}
+
+// Report is the outcome of the checks of every dependency, in their order of registration
+type Report struct {
+	Status string   `json:"status" example:"up" enums:"up,degraded,down"`
+	Checks []Result `json:"checks"`
+}
+
+// Registry holds the checks of the dependencies
+type Registry struct {
+	mu     sync.Mutex
+	checks []check
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register registers the check of a dependency the api cannot serve without
+func (r *Registry) Register(name string, fn Func) {
+	r.add(check{name, fn, true})
+}
+
+// RegisterOptional registers the check of a dependency the api serves without, some of its features degraded
+func (r *Registry) RegisterOptional(name string, fn Func) {
+	r.add(check{name, fn, false})
+}
+
+func (r *Registry) add(c check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks = append(r.checks, c)
+}
+
+// Check runs the checks concurrently, so that a slow dependency does not delay the others, each until ctx is done.
+// The report is down when a critical dependency is down, degraded when another one is.
+func (r *Registry) Check(ctx context.Context) Report {
+	r.mu.Lock()
+	checks := r.checks
+	r.mu.Unlock()
+
+	report := Report{Status: StatusUp, Checks: make([]Result, len(checks))}
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c check) {
+			defer wg.Done()
+			start := time.Now()
+			err := c.fn(ctx)
+			if err == nil && ctx.Err() != nil {
+				// a check ignoring ctx is down once it returns too late
+				err = ctx.Err()
+			}
+			report.Checks[i] = Result{
+				Name:      c.name,
+				Status:    StatusUp,
+				Critical:  c.critical,
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1e3,
+				Err:       err,
+			}
+			if err != nil {
+				report.Checks[i].Status = StatusDown
+			}
+		}(i, c)
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		switch {
+		case result.Status == StatusUp:
+		case result.Critical:
+			report.Status = StatusDown
+		case report.Status == StatusUp:
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+// HTTP checks an HTTP dependency by getting the url, e.g. the discovery document of an OpenID provider,
+// it is up when answering a 2xx
+func HTTP(client *http.Client, url string) Func {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return errors.Wrap(err, "cannot create health check request")
+		}
+		res, err := client.Do(req)
+		if err != nil {
+			return errors.Wrapf(err, "cannot get %s", url)
+		}
+		defer res.Body.Close()
+		_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 1<<16))
+		if res.StatusCode < 200 || res.StatusCode > 299 {
+			return errors.Errorf("%s answered %d", url, res.StatusCode)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,57 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	up := func(ctx context.Context) error { return nil }
+	down := func(ctx context.Context) error { return errors.New("connection refused") }
+	hanging := func(ctx context.Context) error {
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	}
+
+	checks := NewRegistry()
+	checks.Register("database", up)
+	checks.RegisterOptional("oidc", down)
+	report := checks.Check(context.Background())
+	assert.Equal(t, StatusDegraded, report.Status, "an optional dependency down degrades")
+	if assert.Len(t, report.Checks, 2) {
+		assert.Equal(t, Result{Name: "database", Status: StatusUp, Critical: true, LatencyMs: report.Checks[0].LatencyMs}, report.Checks[0])
+		assert.Equal(t, StatusDown, report.Checks[1].Status)
+		assert.False(t, report.Checks[1].Critical)
+		assert.EqualError(t, report.Checks[1].Err, "connection refused")
+	}
+
+	checks.Register("redis", hanging)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	report = checks.Check(ctx)
+	assert.Equal(t, StatusDown, report.Status, "a critical dependency too slow is down")
+	assert.ErrorIs(t, report.Checks[2].Err, context.DeadlineExceeded)
+
+	assert.Equal(t, Report{Status: StatusUp, Checks: []Result{}}, NewRegistry().Check(context.Background()))
+}
+
+func TestHTTP(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/.well-known/openid-configuration", r.URL.Path)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	check := HTTP(server.Client(), server.URL+"/.well-known/openid-configuration")
+	assert.NoError(t, check(context.Background()))
+
+	status = http.StatusBadGateway
+	assert.Error(t, check(context.Background()))
+}
@@ -2,11 +2,15 @@ package logger
 
 import (
 	"context"
+	"go-hex/pkg/errchain"
+	"go-hex/shared/ctxutil"
 	"io"
 	"net/http"
+	"sync/atomic"
 
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Params type, used to pass to `WithParams`.
@@ -32,13 +36,19 @@ type Logger interface {
 
 type logger struct {
 	*logrus.Entry
+	// raised is the most verbose level enabled for the request of the logger besides the steady state level
+	raised logrus.Level
 }
 
 var logStore *logger
 
 // New returns a new wrapper log
 func New(serviceName, serviceVersion string) Logger {
-	logStore = &logger{logrus.New().WithFields(logrus.Fields{"service": serviceName, "version": serviceVersion})}
+	l := logrus.New()
+	// the levels are filtered by the wrapper, see SetLevel and SetOverrides
+	l.SetLevel(logrus.TraceLevel)
+	l.AddHook(scrubHook{})
+	logStore = &logger{l.WithFields(logrus.Fields{"service": serviceName, "version": serviceVersion}), logrus.PanicLevel}
 	return logStore
 }
 
@@ -52,47 +62,120 @@ func SetFormatter(formatter logrus.Formatter) {
 	logStore.Logger.SetFormatter(formatter)
 }
 
-// SetLevel sets the logger level.
+// SetLevel sets the steady state logger level.
 func SetLevel(level logrus.Level) {
-	logStore.Logger.SetLevel(logrus.InfoLevel)
+	atomic.StoreUint32(&verbosities.level, uint32(level))
 }
 
-// With reads requestId and correlationId from context and adds to log field
+// With reads requestId, correlationId, the tenant, the logged in user and the trace from context and adds to log field.
+// The logs are raised to the level of the overrides matching the request.
 func (l *logger) With(ctx context.Context) Logger {
 
-	var le *logrus.Entry
+	le := l.Entry
+	raised := l.raised
 	if ctx != nil {
-		if id, ok := ctx.Value(requestIDKey).(string); ok {
-			le = l.WithField("request_id", id)
+		if id, ok := ctxutil.RequestID(ctx); ok {
+			le = le.WithField("request_id", id)
 		}
-		if id, ok := ctx.Value(correlationIDKey).(string); ok {
-			le = l.WithField("correlation_id", id)
+		if id, ok := ctxutil.CorrelationID(ctx); ok {
+			le = le.WithField("correlation_id", id)
+		}
+		if id, ok := ctxutil.Tenant(ctx); ok {
+			le = le.WithField("tenant_id", id)
+		}
+		if id, ok := ctx.Value(userIDKey).(string); ok && id != "" {
+			le = le.WithField("user_id", id)
+		}
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			le = le.WithFields(logrus.Fields{"trace_id": sc.TraceID().String(), "span_id": sc.SpanID().String()})
+		}
+		if level := verbosities.raised(ctx); level > raised {
+			raised = level
 		}
 	}
-	return &logger{le}
+	return &logger{le, raised}
 
 }
 
+// WithStack adds the stack the error was created at, its chain of causes and the fingerprint grouping it
 func (l *logger) WithStack(err error) Logger {
 
 	stack := MarshalStack(err)
-	return &logger{l.WithField("stack", stack)}
+	le := l.WithField("stack", stack)
+	if err != nil {
+		chain := errchain.Walk(err)
+		le = le.WithFields(logrus.Fields{"error_chain": chain.Links, "error_type": chain.Type, "error_fingerprint": chain.Fingerprint})
+	}
+	return &logger{le, l.raised}
 }
 
 func (l *logger) WithParam(key string, value interface{}) Logger {
 
-	return &logger{l.WithField(key, value)}
+	return &logger{l.WithField(key, value), l.raised}
 }
 
 func (l *logger) WithParams(params Params) Logger {
-	return &logger{l.WithFields(logrus.Fields(params))}
+	return &logger{l.WithFields(logrus.Fields(params)), l.raised}
+}
+
+// enabled checks whether the level is logged in steady state or raised for the request of the logger
+func (l *logger) enabled(level logrus.Level) bool {
+	return level <= l.raised || verbosities.enabled(level)
+}
+
+func (l *logger) Errorf(format string, args ...interface{}) {
+	if l.enabled(logrus.ErrorLevel) {
+		l.Entry.Errorf(format, args...)
+	}
+}
+
+func (l *logger) Error(args ...interface{}) {
+	if l.enabled(logrus.ErrorLevel) {
+		l.Entry.Error(args...)
+	}
+}
+
+func (l *logger) Warnf(format string, args ...interface{}) {
+	if l.enabled(logrus.WarnLevel) {
+		l.Entry.Warnf(format, args...)
+	}
+}
+
+func (l *logger) Warn(args ...interface{}) {
+	if l.enabled(logrus.WarnLevel) {
+		l.Entry.Warn(args...)
+	}
+}
+
+func (l *logger) Infof(format string, args ...interface{}) {
+	if l.enabled(logrus.InfoLevel) {
+		l.Entry.Infof(format, args...)
+	}
+}
+
+func (l *logger) Info(args ...interface{}) {
+	if l.enabled(logrus.InfoLevel) {
+		l.Entry.Info(args...)
+	}
+}
+
+func (l *logger) Debugf(format string, args ...interface{}) {
+	if l.enabled(logrus.DebugLevel) {
+		l.Entry.Debugf(format, args...)
+	}
+}
+
+func (l *logger) Debug(args ...interface{}) {
+	if l.enabled(logrus.DebugLevel) {
+		l.Entry.Debug(args...)
+	}
 }
 
 type contextKey int
 
 const (
-	requestIDKey contextKey = iota
-	correlationIDKey
+	userIDKey contextKey = iota
+	loggerKey
 )
 
 // RequestIDHeader is the name of the HTTP Header which contains the request id.
@@ -107,20 +190,25 @@ func WithRequest(ctx context.Context, req *http.Request) context.Context {
 		id = uuid.New().String()
 		req.Header.Set(RequestIDHeader, id)
 	}
-	ctx = context.WithValue(ctx, requestIDKey, id)
+	ctx = ctxutil.WithRequestID(ctx, id)
 	if id := getCorrelationID(req); id != "" {
-		ctx = context.WithValue(ctx, correlationIDKey, id)
+		ctx = ctxutil.WithCorrelationID(ctx, id)
 	}
 	return ctx
 }
 
-// GetRequestID returns a request ID from the given context if one is present.
-// Returns the empty string if a request ID cannot be found.
-func GetRequestID(ctx context.Context) string {
-	if reqID, ok := ctx.Value(requestIDKey).(string); ok {
-		return reqID
+// NewContext returns a context carrying the logger of its request, read by FromContext
+func NewContext(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, l)
+}
+
+// FromContext returns the logger of the request of the context with the fields of the context, see With, or the
+// fallback with them when the context carries none, e.g. in a job
+func FromContext(ctx context.Context, fallback Logger) Logger {
+	if l, ok := ctx.Value(loggerKey).(Logger); ok {
+		return l.With(ctx)
 	}
-	return ""
+	return fallback.With(ctx)
 }
 
 // getCorrelationID extracts the correlation ID from the HTTP request
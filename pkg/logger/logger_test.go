@@ -2,9 +2,19 @@ package logger
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-hex/shared/ctxutil"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func BenchmarkLogStack(b *testing.B) {
@@ -19,3 +29,67 @@ func BenchmarkLogStack(b *testing.B) {
 		out.Reset()
 	}
 }
+
+func TestWithStack(t *testing.T) {
+	out := &bytes.Buffer{}
+	log := New("test", "test")
+	SetOutput(out)
+	SetFormatter(&logrus.JSONFormatter{})
+	defer SetFormatter(&logrus.TextFormatter{})
+
+	err := errors.Wrap(fmt.Errorf("no user jane@example.com"), "cannot get user")
+	log.WithStack(err).Error(err)
+
+	var entry struct {
+		Chain []struct {
+			Message     string `json:"message"`
+			Fingerprint string `json:"fingerprint"`
+		} `json:"error_chain"`
+		Type        string `json:"error_type"`
+		Fingerprint string `json:"error_fingerprint"`
+	}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &entry))
+	if assert.Len(t, entry.Chain, 2) {
+		assert.Equal(t, "cannot get user", entry.Chain[0].Message)
+		assert.NotEmpty(t, entry.Chain[0].Fingerprint)
+		assert.Equal(t, "no user j***@example.com", entry.Chain[1].Message, "the messages are scrubbed")
+	}
+	assert.Equal(t, "*errors.errorString", entry.Type)
+	assert.NotEmpty(t, entry.Fingerprint)
+}
+
+func TestFromContext(t *testing.T) {
+	out := &bytes.Buffer{}
+	log := New("test", "test")
+	SetOutput(out)
+	SetFormatter(&logrus.JSONFormatter{})
+	defer SetFormatter(&logrus.TextFormatter{})
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID}))
+	ctx = WithRequest(ctx, httptest.NewRequest(http.MethodGet, "/me", nil))
+	ctx = ctxutil.WithTenant(ctx, "acme")
+	ctx = NewContext(ctx, log.WithParam("route", "/me"))
+	// the user is known once the request carries the logger, e.g. set by the middleware of the route
+	ctx = WithUserID(ctx, "u1")
+
+	FromContext(ctx, nil).Info("logged")
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &entry))
+	assert.Equal(t, "/me", entry["route"])
+	requestID, _ := ctxutil.RequestID(ctx)
+	assert.Equal(t, requestID, entry["request_id"])
+	assert.Equal(t, "u1", entry["user_id"])
+	assert.Equal(t, "acme", entry["tenant_id"])
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", entry["trace_id"])
+	assert.Equal(t, "00f067aa0ba902b7", entry["span_id"])
+
+	out.Reset()
+	FromContext(WithUserID(context.Background(), "u2"), log).Info("logged")
+	entry = nil
+	require.NoError(t, json.Unmarshal(out.Bytes(), &entry))
+	assert.Equal(t, "u2", entry["user_id"], "the fallback logs with the fields of the context")
+	assert.NotContains(t, entry, "route")
+	assert.NotContains(t, entry, "trace_id")
+}
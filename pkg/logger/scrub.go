@@ -0,0 +1,26 @@
+package logger
+
+import (
+	"go-hex/pkg/scrub"
+
+	"github.com/sirupsen/logrus"
+)
+
+// scrubHook masks the personal data and the credentials of the fields and the message of every log
+type scrubHook struct{}
+
+// Levels implements logrus.Hook
+func (scrubHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook, the fields are copied so that the entries of the callers are left untouched
+func (scrubHook) Fire(entry *logrus.Entry) error {
+	data := make(logrus.Fields, len(entry.Data))
+	for key, value := range entry.Data {
+		data[key] = scrub.Field(key, value)
+	}
+	entry.Data = data
+	entry.Message = scrub.String(entry.Message)
+	return nil
+}
@@ -0,0 +1,31 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScrubHook(t *testing.T) {
+	out := &bytes.Buffer{}
+	log := New("test", "test")
+	SetOutput(out)
+	SetFormatter(&logrus.JSONFormatter{})
+	defer SetFormatter(&logrus.TextFormatter{})
+
+	params := Params{"email": "jane@example.com", "client_secret": "s3cr3t", "user_id": "user-1"}
+	log.WithParams(params).Error("cannot notify jane@example.com at +62 812 3456 7890")
+
+	var line map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &line))
+	assert.Equal(t, "cannot notify j***@example.com at ***90", line["msg"])
+	assert.Equal(t, "j***@example.com", line["email"])
+	assert.Equal(t, "[REDACTED]", line["client_secret"])
+	assert.Equal(t, "user-1", line["user_id"])
+	// the fields of the caller are left untouched
+	assert.Equal(t, "jane@example.com", params["email"])
+}
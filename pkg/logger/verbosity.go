@@ -0,0 +1,102 @@
+package logger
+
+import (
+	"context"
+	"go-hex/shared/ctxutil"
+	"hash/fnv"
+	"math"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Override raises the level of the logs written for the requests of a user, or for the requests
+// whose id matches a pattern, until it expires.
+type Override struct {
+	UserID           string
+	RequestIDPattern *regexp.Regexp
+	Level            logrus.Level
+	ExpiresAt        time.Time
+}
+
+// matches checks whether the override applies to the request of the user at the given time
+func (o Override) matches(userID, requestID string, now time.Time) bool {
+	if !now.Before(o.ExpiresAt) {
+		return false
+	}
+	if o.UserID != "" {
+		return o.UserID == userID
+	}
+	return o.RequestIDPattern != nil && requestID != "" && o.RequestIDPattern.MatchString(requestID)
+}
+
+// verbosity decides which levels are logged: the steady state level applies to every log,
+// the overrides and the debug sampling raise it for the logs of a request.
+type verbosity struct {
+	level      uint32 // logrus.Level
+	sampleRate uint64 // math.Float64bits of the share of the requests logged at the debug level
+
+	mu        sync.RWMutex
+	overrides []Override
+}
+
+var verbosities = &verbosity{level: uint32(logrus.InfoLevel)}
+
+// SetOverrides replaces the verbosity overrides, the expired ones are ignored
+func SetOverrides(overrides []Override) {
+	verbosities.mu.Lock()
+	defer verbosities.mu.Unlock()
+	verbosities.overrides = overrides
+}
+
+// SetDebugSampleRate sets the share of the requests whose debug logs are written in steady state.
+// The requests are sampled by request id, so that a sampled request is logged entirely.
+func SetDebugSampleRate(rate float64) {
+	atomic.StoreUint64(&verbosities.sampleRate, math.Float64bits(rate))
+}
+
+// enabled checks whether the level is logged in steady state
+func (v *verbosity) enabled(level logrus.Level) bool {
+	return level <= logrus.Level(atomic.LoadUint32(&v.level))
+}
+
+// raised returns the most verbose level enabled for the logs of the request of the context,
+// logrus.PanicLevel is returned when neither an override nor the debug sampling applies.
+func (v *verbosity) raised(ctx context.Context) logrus.Level {
+
+	requestID, _ := ctxutil.RequestID(ctx)
+	userID, _ := ctx.Value(userIDKey).(string)
+
+	level := logrus.PanicLevel
+	if requestID != "" && sampled(requestID, math.Float64frombits(atomic.LoadUint64(&v.sampleRate))) {
+		level = logrus.DebugLevel
+	}
+
+	now := time.Now()
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	for _, o := range v.overrides {
+		if o.Level > level && o.matches(userID, requestID, now) {
+			level = o.Level
+		}
+	}
+	return level
+}
+
+// sampled checks whether the request id falls in the sampled share of the request ids
+func sampled(requestID string, rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	h := fnv.New32a()
+	h.Write([]byte(requestID))
+	return float64(h.Sum32()) < rate*math.MaxUint32
+}
+
+// WithUserID returns a context whose logs can be raised by the overrides of the user
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
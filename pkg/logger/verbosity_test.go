@@ -0,0 +1,78 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go-hex/shared/ctxutil"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerbosityOverrides(t *testing.T) {
+	out := &bytes.Buffer{}
+	log := New("test", "test")
+	SetOutput(out)
+	defer SetOverrides(nil)
+
+	SetOverrides([]Override{
+		{UserID: "user-1", Level: logrus.DebugLevel, ExpiresAt: time.Now().Add(time.Minute)},
+		{RequestIDPattern: regexp.MustCompile(`^trace-`), Level: logrus.TraceLevel, ExpiresAt: time.Now().Add(time.Minute)},
+		{UserID: "user-2", Level: logrus.DebugLevel, ExpiresAt: time.Now().Add(-time.Second)},
+	})
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want bool
+	}{
+		{"steady state", context.Background(), false},
+		{"raised user", WithUserID(context.Background(), "user-1"), true},
+		{"other user", WithUserID(context.Background(), "user-3"), false},
+		{"expired override", WithUserID(context.Background(), "user-2"), false},
+		{"matching request id", ctxutil.WithRequestID(context.Background(), "trace-42"), true},
+		{"other request id", ctxutil.WithRequestID(context.Background(), "req-42"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out.Reset()
+			log.With(tt.ctx).Debug("debug message")
+			assert.Equal(t, tt.want, out.Len() > 0)
+
+			out.Reset()
+			log.With(tt.ctx).Info("info message")
+			assert.True(t, out.Len() > 0)
+		})
+	}
+}
+
+func TestDebugSampling(t *testing.T) {
+	out := &bytes.Buffer{}
+	log := New("test", "test")
+	SetOutput(out)
+	defer SetDebugSampleRate(0)
+
+	ctx := ctxutil.WithRequestID(context.Background(), "req-42")
+
+	SetDebugSampleRate(1)
+	log.With(ctx).Debug("sampled")
+	assert.True(t, out.Len() > 0)
+
+	out.Reset()
+	SetDebugSampleRate(0)
+	log.With(ctx).Debug("not sampled")
+	assert.Zero(t, out.Len())
+
+	var n int
+	for i := 0; i < 1000; i++ {
+		if sampled(fmt.Sprintf("req-%d", i), 0.25) {
+			n++
+		}
+	}
+	assert.InDelta(t, 250, n, 75)
+}
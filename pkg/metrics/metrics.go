@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// registry holds every collector exposed by Handler.
+// A dedicated registry is used instead of the prometheus default one so that
+// importing a library never leaks its collectors into the exposed metrics.
+var registry = newRegistry()
+
+func newRegistry() *prometheus.Registry {
+	r := prometheus.NewRegistry()
+	r.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return r
+}
+
+// Register registers the given collectors, it panics when a collector is registered twice.
+func Register(cs ...prometheus.Collector) {
+	registry.MustRegister(cs...)
+}
+
+// NewCounterVec creates and registers a counter vector.
+func NewCounterVec(opts prometheus.CounterOpts, labels ...string) *prometheus.CounterVec {
+	c := prometheus.NewCounterVec(opts, labels)
+	Register(c)
+	return c
+}
+
+// NewHistogramVec creates and registers a histogram vector.
+func NewHistogramVec(opts prometheus.HistogramOpts, labels ...string) *prometheus.HistogramVec {
+	h := prometheus.NewHistogramVec(opts, labels)
+	Register(h)
+	return h
+}
+
+// NewGaugeVec creates and registers a gauge vector.
+func NewGaugeVec(opts prometheus.GaugeOpts, labels ...string) *prometheus.GaugeVec {
+	g := prometheus.NewGaugeVec(opts, labels)
+	Register(g)
+	return g
+}
+
+// NewCounter creates and registers a counter.
+func NewCounter(opts prometheus.CounterOpts) prometheus.Counter {
+	c := prometheus.NewCounter(opts)
+	Register(c)
+	return c
+}
+
+// NewHistogram creates and registers a histogram.
+func NewHistogram(opts prometheus.HistogramOpts) prometheus.Histogram {
+	h := prometheus.NewHistogram(opts)
+	Register(h)
+	return h
+}
+
+// NewGauge creates and registers a gauge.
+func NewGauge(opts prometheus.GaugeOpts) prometheus.Gauge {
+	g := prometheus.NewGauge(opts)
+	Register(g)
+	return g
+}
+
+// Handler returns the handler exposing the registered metrics in the prometheus format,
+// or in the OpenMetrics format exposing the exemplars when the scraper negotiates it.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{EnableOpenMetrics: true})
+}
@@ -0,0 +1,35 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler(t *testing.T) {
+	counter := NewCounterVec(prometheus.CounterOpts{
+		Name: "metrics_test_total",
+		Help: "Counter used by the tests.",
+	}, "label")
+	counter.WithLabelValues("value").Add(2)
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	body := rec.Body.String()
+	assert.Equal(t, 200, rec.Code)
+	assert.True(t, strings.Contains(body, `metrics_test_total{label="value"} 2`))
+	assert.True(t, strings.Contains(body, "go_goroutines"))
+}
+
+func TestRegisterTwice(t *testing.T) {
+	opts := prometheus.CounterOpts{Name: "metrics_test_twice_total", Help: "Counter used by the tests."}
+	NewCounterVec(opts, "label")
+
+	assert.Panics(t, func() {
+		NewCounterVec(opts, "label")
+	})
+}
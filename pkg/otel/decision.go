@@ -0,0 +1,48 @@
+package otel
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Names of the span events recording the decisions of the domain logic
+const (
+	EventAuthFailed            = "auth.failed"
+	EventLoginApprovalRequired = "auth.login_approval_required"
+	EventLoginApprovalDecided  = "auth.login_approval_decided"
+	EventLegacyRefreshToken    = "auth.legacy_refresh_token"
+	EventSessionLimitReached   = "session.limit_reached"
+	EventPasswordPoolSaturated = "password_pool.saturated"
+	EventSignupRejected        = "signup.rejected"
+)
+
+// Attributes of the decision events
+const (
+	AttributeFailureReason      = attribute.Key("auth.failure_reason")
+	AttributeActiveSessions     = attribute.Key("session.active")
+	AttributeSessionLimit       = attribute.Key("session.limit")
+	AttributeSessionLimitPolicy = attribute.Key("session.limit_policy")
+	AttributeSessionsEvicted    = attribute.Key("session.evicted")
+	AttributeApprovalStatus     = attribute.Key("login_approval.status")
+	AttributeClientType         = attribute.Key("client.type")
+	AttributeClientVersion      = attribute.Key("client.version")
+	AttributeSignupCheck        = attribute.Key("signup.check")
+)
+
+// Event records a decision of the domain logic as an event of the span of the context,
+// so that the trace tells why the request behaved as it did.
+func Event(ctx context.Context, name string, attrs ...attribute.KeyValue) {
+	trace.SpanFromContext(ctx).AddEvent(name, trace.WithAttributes(attrs...))
+}
+
+// AuthFailed records the category of an authentication failure on the span of the context
+// and returns err. The category is also set as an attribute of the span so that the traces
+// can be searched by it; it never holds the credentials or the identity of the caller.
+func AuthFailed(ctx context.Context, reason string, err error) error {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(AttributeFailureReason.String(reason))
+	span.AddEvent(EventAuthFailed, trace.WithAttributes(AttributeFailureReason.String(reason)))
+	return err
+}
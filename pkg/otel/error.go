@@ -0,0 +1,96 @@
+package otel
+
+import (
+	"context"
+	"fmt"
+	"go-hex/pkg/errchain"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+)
+
+// AttributeGRPCStatusCode is the gRPC code answered by a call
+const AttributeGRPCStatusCode = attribute.Key("rpc.grpc.status_code")
+
+// EventErrorCause is the event of a level of the chain of the causes of an error
+const EventErrorCause = "error.cause"
+
+// Attributes of the chain of the causes of an error
+const (
+	AttributeErrorType        = attribute.Key("error.type")
+	AttributeErrorFingerprint = attribute.Key("error.fingerprint")
+	AttributeErrorMessage     = attribute.Key("error.message")
+	AttributeErrorDepth       = attribute.Key("error.depth")
+)
+
+// stackTracer is an error of github.com/pkg/errors carrying the stack where it was created
+type stackTracer interface {
+	StackTrace() errors.StackTrace
+}
+
+// RecordHTTPError records the error answered with the HTTP status on the span of the context, see recordError.
+// The 5xx are the faults of the server.
+func RecordHTTPError(ctx context.Context, err error, status int) {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(semconv.HTTPStatusCodeKey.Int(status))
+	recordError(span, err, status >= 500)
+}
+
+// RecordGRPCError records the error answered with the gRPC code on the span of the context, see recordError.
+// The codes of the faults of the server are the ones of the OpenTelemetry conventions, e.g. Internal or Unavailable.
+func RecordGRPCError(ctx context.Context, err error, code codes.Code) {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(AttributeGRPCStatusCode.Int(int(code)))
+	switch code {
+	case codes.Unknown, codes.DeadlineExceeded, codes.Unimplemented, codes.Internal, codes.Unavailable, codes.DataLoss:
+		recordError(span, err, true)
+	default:
+		recordError(span, err, false)
+	}
+}
+
+// recordError records the cause of the error as an event of the span, with its message and its stack when it has one.
+// Only the faults of the server set the status of the span to error, the errors of the clients, such as a validation
+// error or a missing resource, being the expected answer of their requests.
+func recordError(span trace.Span, err error, fault bool) {
+	if err == nil {
+		return
+	}
+	cause := errors.Cause(err)
+	span.RecordError(cause)
+	recordChain(span, err)
+	span.SetAttributes(attribute.String("error_message", cause.Error()))
+	if _, ok := err.(stackTracer); ok {
+		span.SetAttributes(attribute.String("stack_trace", fmt.Sprintf("%+v", err)))
+	}
+	if fault {
+		span.SetAttributes(attribute.Bool("error", true))
+		span.SetStatus(otelcodes.Error, err.Error())
+	}
+}
+
+// RecordErrorChain records the chain of the causes of the error on the span of the context, for the errors which are
+// not answered, such as the ones of a background job
+func RecordErrorChain(ctx context.Context, err error) {
+	if err != nil {
+		recordChain(trace.SpanFromContext(ctx), err)
+	}
+}
+
+// recordChain records every level of the chain of the causes of the error as an event, outermost first, with the
+// fingerprint of its stack, and the type of the root cause and the fingerprint grouping the error on the span
+func recordChain(span trace.Span, err error) {
+	chain := errchain.Walk(err)
+	span.SetAttributes(AttributeErrorType.String(chain.Type), AttributeErrorFingerprint.String(chain.Fingerprint))
+	for i, link := range chain.Links {
+		attrs := []attribute.KeyValue{AttributeErrorMessage.String(link.Message), AttributeErrorDepth.Int(i)}
+		if link.Fingerprint != "" {
+			attrs = append(attrs, AttributeErrorFingerprint.String(link.Fingerprint))
+		}
+		span.AddEvent(EventErrorCause, trace.WithAttributes(attrs...))
+	}
+}
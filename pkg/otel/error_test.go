@@ -0,0 +1,57 @@
+package otel
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"google.golang.org/grpc/codes"
+)
+
+func TestRecordError(t *testing.T) {
+	recorder := useRecorder(t)
+	record := func(fn func(ctx context.Context)) {
+		ctx, span := otel.Tracer("test").Start(context.Background(), "test")
+		fn(ctx)
+		span.End()
+	}
+
+	cause := errors.New("no rows")
+	record(func(ctx context.Context) {
+		RecordHTTPError(ctx, errors.Wrap(cause, "cannot get user"), http.StatusNotFound)
+	})
+	record(func(ctx context.Context) {
+		RecordHTTPError(ctx, errors.Wrap(cause, "cannot get user"), http.StatusInternalServerError)
+	})
+	record(func(ctx context.Context) { RecordGRPCError(ctx, cause, codes.InvalidArgument) })
+	record(func(ctx context.Context) { RecordGRPCError(ctx, cause, codes.Unavailable) })
+
+	spans := recorder.Ended()
+	if !assert.Len(t, spans, 4) {
+		return
+	}
+	assert.Equal(t, otelcodes.Unset, spans[0].Status().Code, "the errors of the clients are not faults")
+	assert.Contains(t, spans[0].Attributes(), attribute.Int("http.status_code", http.StatusNotFound))
+	assert.Contains(t, spans[0].Attributes(), attribute.String("error_message", "no rows"))
+	assert.Equal(t, "exception", spans[0].Events()[0].Name)
+	if events := spans[0].Events(); assert.Len(t, events, 3, "the exception and a cause per level") {
+		assert.Equal(t, EventErrorCause, events[1].Name)
+		assert.Contains(t, events[1].Attributes, AttributeErrorMessage.String("cannot get user"))
+		assert.Contains(t, events[2].Attributes, AttributeErrorMessage.String("no rows"))
+		assert.Contains(t, events[2].Attributes, AttributeErrorDepth.Int(1))
+	}
+	assert.Contains(t, spans[0].Attributes(), AttributeErrorType.String("*errors.fundamental"))
+
+	assert.Equal(t, otelcodes.Error, spans[1].Status().Code)
+	assert.Equal(t, "cannot get user: no rows", spans[1].Status().Description)
+	assert.Contains(t, spans[1].Attributes(), attribute.Bool("error", true))
+
+	assert.Equal(t, otelcodes.Unset, spans[2].Status().Code)
+	assert.Contains(t, spans[2].Attributes(), AttributeGRPCStatusCode.Int(int(codes.InvalidArgument)))
+	assert.Equal(t, otelcodes.Error, spans[3].Status().Code)
+}
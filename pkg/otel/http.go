@@ -0,0 +1,28 @@
+package otel
+
+import (
+	"reflect"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RouteUnmatched is the route of the requests matching no route, whose path could be anything
+const RouteUnmatched = "unmatched"
+
+// notFound is the handler echo routes the requests matching no route to
+var notFound = reflect.ValueOf(echo.NotFoundHandler).Pointer()
+
+// Route returns the template of the route matched by the request, e.g. /users/:id, rather than its path, so that the
+// spans and the metrics of a route do not have a name per ID. It is RouteUnmatched when no route matches, echo then
+// leaving the path of the request as the one of the context.
+func Route(c echo.Context) string {
+	if c.Handler() == nil || reflect.ValueOf(c.Handler()).Pointer() == notFound {
+		return RouteUnmatched
+	}
+	return c.Path()
+}
+
+// HTTPSpanName returns the name of the span of a request to the route, e.g. [API] GET /users/:id
+func HTTPSpanName(method, route string) string {
+	return "[API] " + method + " " + route
+}
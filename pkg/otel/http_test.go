@@ -0,0 +1,35 @@
+package otel
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoute(t *testing.T) {
+	router := echo.New()
+	var route string
+	router.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			route = Route(c)
+			return next(c)
+		}
+	})
+	router.GET("/users/:id", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+
+	for path, expected := range map[string]string{
+		"/users/42":           "/users/:id",
+		"/wp-admin/setup.php": RouteUnmatched,
+	} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, expected, route, path)
+	}
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/users/42", nil))
+	assert.Equal(t, "/users/:id", route, "a method not allowed matches the route")
+
+	assert.Equal(t, "[API] GET /users/:id", HTTPSpanName(http.MethodGet, "/users/:id"))
+}
@@ -14,11 +14,17 @@ import (
 	"go.opentelemetry.io/otel/trace"
 )
 
+// Attributes of the resource describing the build of the application
+const (
+	AttributeServiceCommit    = attribute.Key("service.commit")
+	AttributeServiceBuildDate = attribute.Key("service.build_date")
+)
+
 // tracerProvider returns an OpenTelemetry TracerProvider configured to use
 // the Jaeger exporter that will send spans to the provided url. The returned
 // TracerProvider will also use a Resource configured with all the information
-// about the application.
-func tracerProvider(url string, service string, version string, env string, sampled bool) (*tracesdk.TracerProvider, error) {
+// about the application, completed by the given attributes.
+func tracerProvider(url string, service string, version string, env string, sampled bool, attrs ...attribute.KeyValue) (*tracesdk.TracerProvider, error) {
 	// Create the Jaeger exporter
 	exp, err := jaeger.New(jaeger.WithCollectorEndpoint(jaeger.WithEndpoint(url)))
 	if err != nil {
@@ -31,20 +37,23 @@ func tracerProvider(url string, service string, version string, env string, samp
 	tp := tracesdk.NewTracerProvider(
 		tracesdk.WithSampler(sampler),
 		// Always be sure to batch in production.
-		tracesdk.WithBatcher(exp),
+		tracesdk.WithBatcher(scrubExporter{exp}),
 		// Record information about this application in a Resource.
 		tracesdk.WithResource(resource.NewWithAttributes(
 			semconv.SchemaURL,
-			semconv.ServiceNameKey.String(service),
-			semconv.ServiceVersionKey.String(version),
-			attribute.String("environment", env),
+			append([]attribute.KeyValue{
+				semconv.ServiceNameKey.String(service),
+				semconv.ServiceVersionKey.String(version),
+				attribute.String("environment", env),
+			}, attrs...)...,
 		)),
 	)
 	return tp, nil
 }
 
-func SetTraceProvider(url string, service string, version string, env string, sampled bool) error {
-	tp, err := tracerProvider(url, service, version, env, sampled)
+// SetTraceProvider sets the global TracerProvider, the attributes are added to the resource of the spans
+func SetTraceProvider(url string, service string, version string, env string, sampled bool, attrs ...attribute.KeyValue) error {
+	tp, err := tracerProvider(url, service, version, env, sampled, attrs...)
 	if err != nil {
 		return err
 	}
@@ -52,12 +61,33 @@ func SetTraceProvider(url string, service string, version string, env string, sa
 	return nil
 }
 
+// Shutdown sends the spans still batched by the global TracerProvider set by SetTraceProvider and stops it
+func Shutdown(ctx context.Context) error {
+	if tp, ok := otel.GetTracerProvider().(*tracesdk.TracerProvider); ok {
+		return tp.Shutdown(ctx)
+	}
+	return nil
+}
+
+// Start starts a span named after its caller, e.g. service.Login for the Login method of the service of the auth
+// package, from the tracer of the package of the caller
 func Start(ctx context.Context) (context.Context, trace.Span) {
+	pkg, operation := caller(2)
+	return otel.Tracer(pkg).Start(ctx, operation)
+}
+
+// StartOperation starts a span named operation from the tracer of the package of its caller, for the operations
+// named better than by their caller, such as a goroutine which Start would name after its closure
+func StartOperation(ctx context.Context, operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	pkg, _ := caller(2)
+	return otel.Tracer(pkg).Start(ctx, operation, trace.WithAttributes(attrs...))
+}
 
-	c, _, _, _ := runtime.Caller(1)
+// caller returns the package and the function, without the package and the receiver markers, of the caller skipped
+func caller(skip int) (string, string) {
+	c, _, _, _ := runtime.Caller(skip)
 	f := runtime.FuncForPC(c).Name()
 	fs := strings.SplitN(f, ".", 2)
 	replacer := strings.NewReplacer("(", "", ")", "", "*", "")
-	operation := replacer.Replace(fs[1])
-	return otel.Tracer(fs[0]).Start(ctx, operation)
+	return fs[0], replacer.Replace(fs[1])
 }
@@ -0,0 +1,45 @@
+package otel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type notifier struct{}
+
+func (*notifier) notify(ctx context.Context) {
+	_, span := Start(ctx)
+	span.End()
+
+	func() {
+		_, span := StartOperation(ctx, "notifier.deliver")
+		span.End()
+	}()
+}
+
+// useRecorder records the spans of the global TracerProvider for the test
+func useRecorder(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func TestStart(t *testing.T) {
+	recorder := useRecorder(t)
+
+	(&notifier{}).notify(context.Background())
+
+	spans := recorder.Ended()
+	if assert.Len(t, spans, 2) {
+		assert.Equal(t, "notifier.notify", spans[0].Name(), "named after its caller")
+		assert.Equal(t, "notifier.deliver", spans[1].Name(), "named explicitly rather than after the closure")
+		assert.Equal(t, "go-hex/pkg/otel", spans[1].InstrumentationLibrary().Name)
+	}
+}
@@ -0,0 +1,57 @@
+package otel
+
+import (
+	"context"
+	"go-hex/pkg/scrub"
+
+	"go.opentelemetry.io/otel/attribute"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// scrubExporter masks the personal data and the credentials of the span attributes, events
+// and status before they leave the process. The recorded spans are read-only, so the masked
+// spans are rebuilt as snapshots of tracetest which is the exported way to do it.
+type scrubExporter struct {
+	tracesdk.SpanExporter
+}
+
+// ExportSpans implements tracesdk.SpanExporter
+func (e scrubExporter) ExportSpans(ctx context.Context, spans []tracesdk.ReadOnlySpan) error {
+	scrubbed := make([]tracesdk.ReadOnlySpan, len(spans))
+	for i, span := range spans {
+		stub := tracetest.SpanStubFromReadOnlySpan(span)
+		stub.Attributes = scrubAttributes(stub.Attributes)
+		for j := range stub.Events {
+			stub.Events[j].Attributes = scrubAttributes(stub.Events[j].Attributes)
+		}
+		stub.Status.Description = scrub.String(stub.Status.Description)
+		scrubbed[i] = stub.Snapshot()
+	}
+	return e.SpanExporter.ExportSpans(ctx, scrubbed)
+}
+
+// scrubAttributes masks the string attributes by their key and by pattern
+func scrubAttributes(attrs []attribute.KeyValue) []attribute.KeyValue {
+	if len(attrs) == 0 {
+		return attrs
+	}
+	res := make([]attribute.KeyValue, len(attrs))
+	for i, attr := range attrs {
+		switch attr.Value.Type() {
+		case attribute.STRING:
+			if masked, ok := scrub.Field(string(attr.Key), attr.Value.AsString()).(string); ok {
+				attr = attr.Key.String(masked)
+			}
+		case attribute.STRINGSLICE:
+			switch masked := scrub.Field(string(attr.Key), attr.Value.AsStringSlice()).(type) {
+			case []string:
+				attr = attr.Key.StringSlice(masked)
+			case string:
+				attr = attr.Key.String(masked)
+			}
+		}
+		res[i] = attr
+	}
+	return res
+}
@@ -0,0 +1,43 @@
+package otel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestScrubExporter(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := tracesdk.NewTracerProvider(tracesdk.WithSyncer(scrubExporter{exporter}))
+
+	_, span := tp.Tracer("test").Start(context.Background(), "[API] POST /auth/login")
+	span.SetAttributes(
+		attribute.String("username", "jane@example.com"),
+		attribute.String("query", "email=jane@example.com"),
+		attribute.String("refresh_token", "eyJhbGciOiJIUzI1NiJ9.eyJpZCI6IjEifQ.c2ln"),
+		attribute.StringSlice("emails", []string{"jane@example.com"}),
+		attribute.Int("status", 401),
+	)
+	span.AddEvent("notification.failed", trace.WithAttributes(attribute.String("phone", "081234567890")))
+	span.SetStatus(codes.Error, "no user jane@example.com")
+	span.End()
+
+	spans := exporter.GetSpans()
+	if assert.Len(t, spans, 1) {
+		assert.Equal(t, []attribute.KeyValue{
+			attribute.String("username", "j***@example.com"),
+			attribute.String("query", "email=j***@example.com"),
+			attribute.String("refresh_token", "[REDACTED]"),
+			attribute.StringSlice("emails", []string{"j***@example.com"}),
+			attribute.Int("status", 401),
+		}, spans[0].Attributes)
+		assert.Equal(t, []attribute.KeyValue{attribute.String("phone", "***90")}, spans[0].Events[0].Attributes)
+		assert.Equal(t, "no user j***@example.com", spans[0].Status.Description)
+	}
+}
@@ -0,0 +1,29 @@
+package password
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// fingerprintPrefix marks the fingerprints among the bcrypt hashes stored in the same columns
+const fingerprintPrefix = "hmac-sha256:"
+
+// Fingerprint returns the HMAC-SHA256 fingerprint of a high entropy secret, e.g. a refresh token, with the key.
+// Unlike bcrypt it is not salted nor slow, so it must not be used for the passwords chosen by the users.
+func Fingerprint(key []byte, secret []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(secret)
+	return fingerprintPrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// IsFingerprint reports whether the hash is a fingerprint, otherwise it is a bcrypt hash
+func IsFingerprint(hashed string) bool {
+	return strings.HasPrefix(hashed, fingerprintPrefix)
+}
+
+// CompareFingerprint compares the fingerprint with the secret in constant time
+func CompareFingerprint(key []byte, fingerprint string, secret []byte) bool {
+	return hmac.Equal([]byte(fingerprint), []byte(Fingerprint(key, secret)))
+}
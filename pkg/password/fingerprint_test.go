@@ -0,0 +1,21 @@
+package password
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFingerprint(t *testing.T) {
+	key := []byte("fingerprint-key")
+	fingerprint := Fingerprint(key, []byte("refresh-token"))
+
+	assert.True(t, IsFingerprint(fingerprint))
+	assert.True(t, CompareFingerprint(key, fingerprint, []byte("refresh-token")))
+	assert.False(t, CompareFingerprint(key, fingerprint, []byte("other-token")))
+	assert.False(t, CompareFingerprint([]byte("other-key"), fingerprint, []byte("refresh-token")))
+
+	hashed, err := HashAndSalt([]byte("refresh-token"))
+	assert.NoError(t, err)
+	assert.False(t, IsFingerprint(hashed), "a bcrypt hash is not a fingerprint")
+}
@@ -0,0 +1,119 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Hasher hashes the passwords with one algorithm and its parameters.
+// The hashes are prefixed by their algorithm, so that ComparePasswords verifies the hashes of every algorithm.
+type Hasher interface {
+	// Hash returns the salted hash of the password
+	Hash(pwd []byte) (string, error)
+	// NeedsRehash tells whether the hash was computed with another algorithm or other parameters than the hasher's
+	NeedsRehash(hashedPwd string) bool
+}
+
+// prefixes of the hashes of each algorithm
+const (
+	argon2idPrefix = "$argon2id$"
+	bcryptPrefix   = "$2"
+)
+
+// Bcrypt hashes the passwords with bcrypt, its hashes are prefixed by $2a$, $2b$ or $2y$.
+// A cost lower than bcrypt.MinCost hashes with bcrypt.DefaultCost.
+type Bcrypt struct {
+	Cost int
+}
+
+// Hash implements Hasher
+func (h Bcrypt) Hash(pwd []byte) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword(pwd, h.Cost)
+	if err != nil {
+		return "", errors.Wrap(err, "cannot generate hash")
+	}
+	return string(hash), nil
+}
+
+// NeedsRehash implements Hasher, the bcrypt hashes of a lower cost than the hasher's are rehashed
+func (h Bcrypt) NeedsRehash(hashedPwd string) bool {
+	if !strings.HasPrefix(hashedPwd, bcryptPrefix) {
+		return true
+	}
+	cost, err := bcrypt.Cost([]byte(hashedPwd))
+	return err != nil || cost < h.Cost
+}
+
+// Argon2id hashes the passwords with Argon2id, its hashes are encoded as $argon2id$v=19$m=<memory>,t=<time>,p=<threads>$<salt>$<key>
+type Argon2id struct {
+	Memory     uint32 // in KiB
+	Time       uint32 // number of passes over the memory
+	Threads    uint8
+	SaltLength uint32
+	KeyLength  uint32
+}
+
+// Hash implements Hasher
+func (h Argon2id) Hash(pwd []byte) (string, error) {
+	salt := make([]byte, h.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", errors.Wrap(err, "cannot generate salt")
+	}
+	key := argon2.IDKey(pwd, salt, h.Time, h.Memory, h.Threads, h.KeyLength)
+	return encodeArgon2id(h, salt, key), nil
+}
+
+// NeedsRehash implements Hasher
+func (h Argon2id) NeedsRehash(hashedPwd string) bool {
+	params, salt, key, err := decodeArgon2id(hashedPwd)
+	if err != nil {
+		return true
+	}
+	return params.Memory != h.Memory || params.Time != h.Time || params.Threads != h.Threads ||
+		uint32(len(salt)) != h.SaltLength || uint32(len(key)) != h.KeyLength
+}
+
+func encodeArgon2id(h Argon2id, salt, key []byte) string {
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version, h.Memory, h.Time, h.Threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+}
+
+func decodeArgon2id(hashedPwd string) (params Argon2id, salt, key []byte, err error) {
+	parts := strings.Split(hashedPwd, "$")
+	if len(parts) != 6 || "$"+parts[1]+"$" != argon2idPrefix {
+		return Argon2id{}, nil, nil, errors.New("invalid argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return Argon2id{}, nil, nil, errors.Errorf("unsupported argon2id version %q", parts[2])
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Threads); err != nil {
+		return Argon2id{}, nil, nil, errors.Wrap(err, "invalid argon2id parameters")
+	}
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return Argon2id{}, nil, nil, errors.Wrap(err, "invalid argon2id salt")
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return Argon2id{}, nil, nil, errors.Wrap(err, "invalid argon2id key")
+	}
+	params.SaltLength, params.KeyLength = uint32(len(salt)), uint32(len(key))
+	return params, salt, key, nil
+}
+
+// compareArgon2id compares the Argon2id hash with the plain password, with the parameters encoded in the hash
+func compareArgon2id(hashedPwd string, plainPwd []byte) bool {
+	params, salt, key, err := decodeArgon2id(hashedPwd)
+	if err != nil || params.Time == 0 || params.Threads == 0 || params.KeyLength == 0 {
+		return false
+	}
+	other := argon2.IDKey(plainPwd, salt, params.Time, params.Memory, params.Threads, params.KeyLength)
+	return subtle.ConstantTimeCompare(key, other) == 1
+}
@@ -0,0 +1,45 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestArgon2id(t *testing.T) {
+	h := Argon2id{Memory: 64, Time: 1, Threads: 2, SaltLength: 16, KeyLength: 32}
+
+	hashed, err := h.Hash([]byte("password1234"))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hashed, "$argon2id$v=19$m=64,t=1,p=2$"), hashed)
+
+	other, err := h.Hash([]byte("password1234"))
+	require.NoError(t, err)
+	assert.NotEqual(t, hashed, other, "the hashes are salted")
+
+	assert.True(t, ComparePasswords(hashed, []byte("password1234")))
+	assert.False(t, ComparePasswords(hashed, []byte("wrong")))
+	assert.False(t, ComparePasswords("$argon2id$v=19$m=64,t=1,p=2$invalid", []byte("password1234")))
+	assert.False(t, ComparePasswords(strings.Replace(hashed, "v=19", "v=16", 1), []byte("password1234")))
+}
+
+func TestNeedsRehash(t *testing.T) {
+	argon2id := Argon2id{Memory: 64, Time: 1, Threads: 1, SaltLength: 16, KeyLength: 32}
+	argon2idHash, err := argon2id.Hash([]byte("password1234"))
+	require.NoError(t, err)
+	bcryptHash, err := HashAndSalt([]byte("password1234"))
+	require.NoError(t, err)
+
+	assert.False(t, argon2id.NeedsRehash(argon2idHash))
+	assert.True(t, argon2id.NeedsRehash(bcryptHash), "bcrypt hashes are upgraded")
+	stronger := argon2id
+	stronger.Time = 2
+	assert.True(t, stronger.NeedsRehash(argon2idHash), "the hashes of other parameters are upgraded")
+
+	assert.False(t, Bcrypt{Cost: bcrypt.MinCost}.NeedsRehash(bcryptHash))
+	assert.True(t, Bcrypt{Cost: bcrypt.MinCost + 1}.NeedsRehash(bcryptHash), "the hashes of a lower cost are upgraded")
+	assert.True(t, Bcrypt{Cost: bcrypt.MinCost}.NeedsRehash(argon2idHash))
+}
@@ -1,11 +1,14 @@
 package password
 
 import (
+	"strings"
+
 	"github.com/pkg/errors"
 	"golang.org/x/crypto/bcrypt"
 )
 
-// HashAndSalt return hashed password
+// HashAndSalt return hashed password, hashed with bcrypt at its minimum cost.
+// The services hash the passwords with the configured Hasher instead.
 func HashAndSalt(pwd []byte) (string, error) {
 
 	// Use GenerateFromPassword to hash & salt pwd.
@@ -20,8 +23,12 @@ func HashAndSalt(pwd []byte) (string, error) {
 	return string(hash), nil
 }
 
-// ComparePasswords compares between hashed password and plain password
+// ComparePasswords compares between hashed password and plain password, hashed with any of the supported algorithms
 func ComparePasswords(hashedPwd string, plainPwd []byte) bool {
+	if strings.HasPrefix(hashedPwd, argon2idPrefix) {
+		return compareArgon2id(hashedPwd, plainPwd)
+	}
+
 	// Since we'll be getting the hashed password from the DB it
 	// will be a string so we'll need to convert it to a byte slice
 	byteHash := []byte(hashedPwd)
@@ -0,0 +1,88 @@
+// Package policy validates the passwords chosen by the users against a configurable policy.
+package policy
+
+import (
+	_ "embed"
+	"go-hex/shared/ierr"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+//go:embed common.txt
+var commonList string
+
+// common holds the lowercased common passwords of the embedded list
+var common = func() map[string]struct{} {
+	passwords := map[string]struct{}{}
+	for _, password := range strings.Fields(commonList) {
+		passwords[strings.ToLower(password)] = struct{}{}
+	}
+	return passwords
+}()
+
+// Policy validates the passwords chosen by the users, a zero rule is not enforced
+type Policy struct {
+	MinLength        int  // in characters
+	MinClasses       int  // among the lowercase letters, the uppercase letters, the digits and the symbols
+	BanCommon        bool // rejects the passwords of the embedded list of common passwords, whatever their case
+	DisallowUsername bool // rejects the passwords containing the username, or the local part of an email username
+}
+
+// Validate returns the ierr error of the first rule the password of the user breaks, nil when it follows the policy
+func (p Policy) Validate(password, username string) error {
+	if utf8.RuneCountInString(password) < p.MinLength {
+		return ierr.ErrPasswordTooShort
+	}
+	if classes(password) < p.MinClasses {
+		return ierr.ErrPasswordTooSimple
+	}
+	if p.BanCommon && IsCommon(password) {
+		return ierr.ErrPasswordTooCommon
+	}
+	if p.DisallowUsername && containsUsername(password, username) {
+		return ierr.ErrPasswordContainsUsername
+	}
+	return nil
+}
+
+// IsCommon tells whether the password is in the embedded list of common passwords, whatever its case
+func IsCommon(password string) bool {
+	_, ok := common[strings.ToLower(password)]
+	return ok
+}
+
+// classes counts the character classes of the password
+func classes(password string) int {
+	var lower, upper, digit, symbol int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+	return lower + upper + digit + symbol
+}
+
+// containsUsername tells whether the password contains the username, or the local part of an email username,
+// whatever their case. The usernames shorter than 3 characters are ignored.
+func containsUsername(password, username string) bool {
+	password = strings.ToLower(password)
+	username = strings.ToLower(username)
+	names := []string{username}
+	if at := strings.LastIndex(username, "@"); at > 0 {
+		names = append(names, username[:at])
+	}
+	for _, name := range names {
+		if utf8.RuneCountInString(name) >= 3 && strings.Contains(password, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+package policy
+
+import (
+	"go-hex/shared/ierr"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicy(t *testing.T) {
+	p := Policy{MinLength: 10, MinClasses: 3, BanCommon: true, DisallowUsername: true}
+
+	tests := []struct {
+		name     string
+		password string
+		username string
+		want     error
+	}{
+		{"valid", "Correct-horse-7", "jane", nil},
+		{"too short", "Sh0rt!", "jane", ierr.ErrPasswordTooShort},
+		{"characters counted rather than bytes", "Pässwörd-1", "jane", nil},
+		{"too simple", "correcthorsebattery", "jane", ierr.ErrPasswordTooSimple},
+		{"too common whatever its case", "Password1234", "jane", ierr.ErrPasswordTooCommon},
+		{"containing the username", "My-Jane-Password-1", "jane", ierr.ErrPasswordContainsUsername},
+		{"containing the local part of the email username", "Jane.Doe-2026!", "jane.doe@example.com", ierr.ErrPasswordContainsUsername},
+		{"short usernames ignored", "Correct-horse-7", "co", nil},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, p.Validate(tt.password, tt.username), tt.name)
+	}
+
+	assert.NoError(t, Policy{}.Validate("password1234", "password"), "a zero rule is not enforced")
+	assert.True(t, IsCommon("QWERTY"))
+}
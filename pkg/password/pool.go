@@ -0,0 +1,125 @@
+package password
+
+import (
+	"context"
+	"go-hex/pkg/metrics"
+	"runtime"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ErrPoolSaturated is returned when no worker picked up a password operation within the queue timeout
+var ErrPoolSaturated = errors.New("password pool is saturated")
+
+var (
+	poolBusyWorkers = metrics.NewGauge(prometheus.GaugeOpts{
+		Name: "password_pool_busy_workers",
+		Help: "Number of password pool workers running a hash or a compare.",
+	})
+	poolWorkers = metrics.NewGauge(prometheus.GaugeOpts{
+		Name: "password_pool_workers",
+		Help: "Number of password pool workers.",
+	})
+	poolQueueWait = metrics.NewHistogram(prometheus.HistogramOpts{
+		Name:    "password_pool_queue_wait_seconds",
+		Help:    "Time spent by password operations waiting for a worker.",
+		Buckets: []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1},
+	})
+	poolRejected = metrics.NewCounter(prometheus.CounterOpts{
+		Name: "password_pool_rejected_total",
+		Help: "Number of password operations rejected because every worker was busy.",
+	})
+)
+
+// Pool runs the password hashes and compares on a bounded number of workers,
+// so that a login storm cannot take every CPU away from the other handlers.
+// An operation waits up to the queue timeout for a free worker and fails with ErrPoolSaturated otherwise.
+type Pool struct {
+	hasher       Hasher
+	jobs         chan func()
+	queueTimeout time.Duration
+}
+
+// NewPool starts a pool of the given number of workers, one per CPU when workers is not positive, hashing with the hasher
+func NewPool(hasher Hasher, workers int, queueTimeout time.Duration) *Pool {
+
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	p := &Pool{
+		hasher:       hasher,
+		jobs:         make(chan func()),
+		queueTimeout: queueTimeout,
+	}
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+	poolWorkers.Add(float64(workers))
+
+	return p
+}
+
+func (p *Pool) work() {
+	for job := range p.jobs {
+		poolBusyWorkers.Inc()
+		job()
+		poolBusyWorkers.Dec()
+	}
+}
+
+// run hands the job to a free worker and waits for its completion.
+// Once picked up the job always runs to completion, neither bcrypt nor Argon2id can be interrupted.
+func (p *Pool) run(ctx context.Context, job func()) error {
+
+	done := make(chan struct{})
+	task := func() {
+		defer close(done)
+		job()
+	}
+
+	start := time.Now()
+	timer := time.NewTimer(p.queueTimeout)
+	defer timer.Stop()
+
+	select {
+	case p.jobs <- task:
+		poolQueueWait.Observe(time.Since(start).Seconds())
+	case <-timer.C:
+		poolRejected.Inc()
+		return ErrPoolSaturated
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	<-done
+	return nil
+}
+
+// HashAndSalt returns the password hashed by the hasher of the pool, computed by a worker of the pool
+func (p *Pool) HashAndSalt(ctx context.Context, pwd []byte) (hash string, err error) {
+
+	runErr := p.run(ctx, func() {
+		hash, err = p.hasher.Hash(pwd)
+	})
+	if runErr != nil {
+		return "", runErr
+	}
+	return hash, err
+}
+
+// ComparePasswords compares between hashed password and plain password on a worker of the pool
+func (p *Pool) ComparePasswords(ctx context.Context, hashedPwd string, plainPwd []byte) (match bool, err error) {
+
+	err = p.run(ctx, func() {
+		match = ComparePasswords(hashedPwd, plainPwd)
+	})
+	return match, err
+}
+
+// NeedsRehash tells whether the hashed password should be hashed again by the hasher of the pool
+func (p *Pool) NeedsRehash(hashedPwd string) bool {
+	return p.hasher.NeedsRehash(hashedPwd)
+}
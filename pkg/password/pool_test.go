@@ -0,0 +1,47 @@
+package password
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPool(t *testing.T) {
+	p := NewPool(Bcrypt{Cost: bcrypt.MinCost}, 2, time.Second)
+
+	hashed, err := p.HashAndSalt(context.Background(), []byte("password1234"))
+	assert.NoError(t, err)
+
+	match, err := p.ComparePasswords(context.Background(), hashed, []byte("password1234"))
+	assert.NoError(t, err)
+	assert.True(t, match)
+
+	match, err = p.ComparePasswords(context.Background(), hashed, []byte("wrong"))
+	assert.NoError(t, err)
+	assert.False(t, match)
+}
+
+func TestPoolSaturated(t *testing.T) {
+	p := NewPool(Bcrypt{Cost: bcrypt.MinCost}, 1, 10*time.Millisecond)
+
+	// occupy the only worker until the end of the test
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go p.run(context.Background(), func() {
+		close(started)
+		<-release
+	})
+	<-started
+	defer close(release)
+
+	_, err := p.HashAndSalt(context.Background(), []byte("password1234"))
+	assert.Equal(t, ErrPoolSaturated, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = p.ComparePasswords(ctx, "", nil)
+	assert.Equal(t, context.Canceled, err)
+}
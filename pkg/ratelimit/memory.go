@@ -0,0 +1,52 @@
+package ratelimit
+
+import (
+	"context"
+	"go-hex/pkg/times"
+	"sync"
+	"time"
+)
+
+// sweepInterval is the interval between the removals of the idle buckets
+const sweepInterval = time.Minute
+
+// MemoryStore holds the buckets in the memory of the instance, each instance limits the requests it serves
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	sweptAt time.Time
+}
+
+// NewMemoryStore creates an empty store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]*bucket{}, sweptAt: times.Now()}
+}
+
+// Take implements Store, a new bucket is full
+func (s *MemoryStore) Take(ctx context.Context, key string, perMinute int) (Decision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := times.Now()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(perMinute), at: now}
+		s.buckets[key] = b
+	}
+	return b.take(perMinute, now), nil
+}
+
+// sweep removes the buckets refilled since their last take, they are the same as new ones
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.sweptAt) < sweepInterval {
+		return
+	}
+	s.sweptAt = now
+	for key, b := range s.buckets {
+		if b.tokens+now.Sub(b.at).Minutes()*float64(b.perMinute) >= float64(b.perMinute) {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+// Package ratelimit limits the requests with token buckets, one per key, e.g. the IP address of the logins or the
+// identity of the access tokens.
+//
+// A bucket holds up to the limit per minute, taken by the requests and refilled continuously at the limit, so that
+// a client may spend its whole minute at once and then waits for the tokens to come back one by one. The buckets
+// live in a Store, the memory of the instance or Redis to share them between the instances.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Decision is the outcome of the take of a token
+type Decision struct {
+	Allowed    bool
+	Remaining  int           // the whole tokens left in the bucket
+	RetryAfter time.Duration // the wait for the next token when not allowed
+}
+
+// Store takes the tokens of the buckets
+type Store interface {
+	// Take takes a token from the bucket of the key, refilled at perMinute tokens per minute
+	Take(ctx context.Context, key string, perMinute int) (Decision, error)
+}
+
+// bucket is a token bucket, its tokens are those at the time of its last take
+type bucket struct {
+	tokens    float64
+	at        time.Time
+	perMinute int
+}
+
+// take refills the bucket up to now and takes a token when there is a whole one
+func (b *bucket) take(perMinute int, now time.Time) Decision {
+	b.perMinute = perMinute
+	capacity := float64(perMinute)
+	perSecond := capacity / 60
+	if elapsed := now.Sub(b.at).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(capacity, b.tokens+elapsed*perSecond)
+	}
+	b.at = now
+
+	if b.tokens < 1 {
+		wait := (1 - b.tokens) / perSecond
+		return Decision{RetryAfter: time.Duration(math.Ceil(wait * float64(time.Second)))}
+	}
+	b.tokens--
+	return Decision{Allowed: true, Remaining: int(b.tokens)}
+}
+
+// Policies are the limits per minute of the routes, by method and route path, e.g. POST /users=30,GET /users=120
+type Policies map[string]int
+
+// Decode implements envconfig.Decoder
+func (p *Policies) Decode(value string) error {
+	policies := Policies{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		route, limit, ok := strings.Cut(entry, "=")
+		method, path, _ := strings.Cut(strings.TrimSpace(route), " ")
+		perMinute, err := strconv.Atoi(strings.TrimSpace(limit))
+		if !ok || !isMethod(method) || !strings.HasPrefix(path, "/") || err != nil || perMinute <= 0 {
+			return fmt.Errorf("invalid rate limit policy %q: expected METHOD /path=requests per minute, e.g. POST /users=30", entry)
+		}
+		policies[PolicyKey(method, path)] = perMinute
+	}
+	*p = policies
+	return nil
+}
+
+// String returns the policies in their decoded form, sorted by route
+func (p Policies) String() string {
+	routes := make([]string, 0, len(p))
+	for route := range p {
+		routes = append(routes, route)
+	}
+	sort.Strings(routes)
+
+	entries := make([]string, 0, len(routes))
+	for _, route := range routes {
+		entries = append(entries, route+"="+strconv.Itoa(p[route]))
+	}
+	return strings.Join(entries, ",")
+}
+
+// PolicyKey returns the key of the policy of the route
+func PolicyKey(method, path string) string {
+	return method + " " + path
+}
+
+func isMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
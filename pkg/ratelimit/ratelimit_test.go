@@ -0,0 +1,56 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBucketTake(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	b := &bucket{tokens: 3, at: now}
+
+	for remaining := 2; remaining >= 0; remaining-- {
+		assert.Equal(t, Decision{Allowed: true, Remaining: remaining}, b.take(3, now), "the burst is the limit per minute")
+	}
+	assert.Equal(t, Decision{RetryAfter: 20 * time.Second}, b.take(3, now), "a token comes back every 20s")
+	assert.Equal(t, Decision{RetryAfter: 5 * time.Second}, b.take(3, now.Add(15*time.Second)))
+	assert.Equal(t, Decision{Allowed: true}, b.take(3, now.Add(20*time.Second)))
+	assert.Equal(t, Decision{Allowed: true, Remaining: 2}, b.take(3, now.Add(time.Hour)), "the bucket is refilled up to the limit")
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		decision, err := store.Take(ctx, "jane", 2)
+		require.NoError(t, err)
+		assert.True(t, decision.Allowed)
+	}
+	decision, err := store.Take(ctx, "jane", 2)
+	require.NoError(t, err)
+	assert.False(t, decision.Allowed)
+	assert.InDelta(t, 30*time.Second, decision.RetryAfter, float64(time.Second))
+
+	decision, err = store.Take(ctx, "john", 2)
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed, "every key has its bucket")
+
+	store.sweep(store.sweptAt.Add(2 * time.Minute))
+	assert.Empty(t, store.buckets, "the refilled buckets are removed")
+}
+
+func TestPoliciesDecode(t *testing.T) {
+	var policies Policies
+	require.NoError(t, policies.Decode("POST /users=30, GET /users/:id=120,"))
+	assert.Equal(t, Policies{"POST /users": 30, "GET /users/:id": 120}, policies)
+	assert.Equal(t, "GET /users/:id=120,POST /users=30", policies.String())
+
+	for _, value := range []string{"POST /users", "post /users=30", "POST users=30", "POST /users=0", "POST /users=many"} {
+		assert.Error(t, policies.Decode(value), value)
+	}
+}
@@ -0,0 +1,61 @@
+package ratelimit
+
+import (
+	"context"
+	"go-hex/pkg/metrics"
+	"go-hex/pkg/times"
+	"go-hex/shared/ierr"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// The policies limiting the logins, labelling the metrics
+const (
+	PolicyLoginIP       = "login_ip"
+	PolicyLoginUsername = "login_username"
+)
+
+var (
+	limitedRequests = metrics.NewCounterVec(prometheus.CounterOpts{
+		Name: "rate_limited_requests_total",
+		Help: "Number of requests rejected with too many requests per rate limit policy.",
+	}, "policy")
+	limitErrors = metrics.NewCounterVec(prometheus.CounterOpts{
+		Name: "rate_limit_errors_total",
+		Help: "Number of requests let through because the rate limit store failed, per rate limit policy.",
+	}, "policy")
+)
+
+// Take takes a token of the bucket of the key, it answers ierr.ErrTooManyRequests limited until the next token when
+// there is none (ierr.LockedUntil). The requests are let through when the store fails, and a zero limit disables the
+// policy.
+func Take(ctx context.Context, store Store, policy, key string, perMinute int) error {
+	if perMinute <= 0 {
+		return nil
+	}
+	decision, err := store.Take(ctx, key, perMinute)
+	if err != nil {
+		limitErrors.WithLabelValues(policy).Inc()
+		return nil
+	}
+	if decision.Allowed {
+		return nil
+	}
+
+	limitedRequests.WithLabelValues(policy).Inc()
+	return ierr.Limited(ierr.ErrTooManyRequests, times.Now().Add(decision.RetryAfter))
+}
+
+// TakeLogin takes the tokens of a login attempt of the IP address and of the username, compared in lower case, so
+// that every transport reaching the logins shares the same buckets. An empty username is only limited per address.
+func TakeLogin(ctx context.Context, store Store, ipAddress, username string, perIP, perUsername int) error {
+	if err := Take(ctx, store, PolicyLoginIP, "login:ip:"+ipAddress, perIP); err != nil {
+		return err
+	}
+	username = strings.ToLower(strings.TrimSpace(username))
+	if username == "" {
+		return nil
+	}
+	return Take(ctx, store, PolicyLoginUsername, "login:username:"+username, perUsername)
+}
@@ -0,0 +1,197 @@
+// Package redirect validates the URLs the users are sent back to, the redirect_uri of the social logins, the
+// post-logout redirect of the hosted pages and the return_to of the links of the emails, against the allowlist of
+// each client, so that the service cannot be turned into an open redirect.
+//
+// A rule is an absolute URL matched in one of three ways:
+//   - exact, https://app.example.com/callback matches that URL only, with the same query
+//   - prefix, https://app.example.com/account/* matches /account and every path below it, with any query
+//   - wildcard, https://*.example.com/callback matches a single subdomain label, e.g. eu.example.com
+//
+// The wildcard host combines with a prefix path. The scheme, the host and the port are always compared exactly,
+// case insensitively for the host, and the URLs with credentials, a fragment or dot segments are rejected.
+package redirect
+
+import (
+	"fmt"
+	"go-hex/shared/ierr"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Rule is a URL allowed as a redirect
+type Rule struct {
+	scheme string
+	host   string // lower case, *. prefixed for a wildcard subdomain
+	port   string
+	path   string
+	query  string
+	prefix bool
+}
+
+// ParseRule parses an exact, prefix or wildcard rule
+func ParseRule(raw string) (Rule, error) {
+	u, err := url.Parse(raw)
+	if err != nil || !u.IsAbs() || u.Host == "" {
+		return Rule{}, fmt.Errorf("invalid redirect rule %q: expected an absolute url", raw)
+	}
+	if u.User != nil || u.Fragment != "" {
+		return Rule{}, fmt.Errorf("invalid redirect rule %q: credentials and fragments are not allowed", raw)
+	}
+
+	rule := Rule{scheme: strings.ToLower(u.Scheme), host: strings.ToLower(u.Hostname()), port: u.Port(), path: u.Path, query: u.RawQuery}
+	if strings.HasSuffix(rule.path, "/*") {
+		rule.path, rule.prefix = strings.TrimSuffix(rule.path, "/*"), true
+		if u.RawQuery != "" {
+			return Rule{}, fmt.Errorf("invalid redirect rule %q: a prefix rule has no query", raw)
+		}
+	}
+	if strings.Contains(rule.path, "*") || strings.Contains(strings.TrimPrefix(rule.host, "*."), "*") {
+		return Rule{}, fmt.Errorf("invalid redirect rule %q: * only ends the path or starts the host", raw)
+	}
+	if strings.HasPrefix(rule.host, "*.") && strings.Count(rule.host, ".") < 2 {
+		return Rule{}, fmt.Errorf("invalid redirect rule %q: the wildcard covers a subdomain of a registered domain", raw)
+	}
+	if hasDotSegment(rule.path) {
+		return Rule{}, fmt.Errorf("invalid redirect rule %q: dot segments are not allowed", raw)
+	}
+	return rule, nil
+}
+
+// Match checks whether the rule allows the URL, which is expected to be validated by Parse
+func (r Rule) Match(u *url.URL) bool {
+	if strings.ToLower(u.Scheme) != r.scheme || u.Port() != r.port || !r.matchHost(strings.ToLower(u.Hostname())) {
+		return false
+	}
+	if !r.prefix {
+		return u.Path == r.path && u.RawQuery == r.query
+	}
+	return u.Path == r.path || strings.HasPrefix(u.Path, r.path+"/")
+}
+
+func (r Rule) matchHost(host string) bool {
+	if !strings.HasPrefix(r.host, "*.") {
+		return host == r.host
+	}
+	label := strings.TrimSuffix(host, r.host[1:])
+	return label != host && label != "" && !strings.Contains(label, ".")
+}
+
+// String returns the rule as configured
+func (r Rule) String() string {
+	host := r.host
+	if r.port != "" {
+		host += ":" + r.port
+	}
+	u := url.URL{Scheme: r.scheme, Host: host, Path: r.path, RawQuery: r.query}
+	if r.prefix {
+		return u.String() + "/*"
+	}
+	return u.String()
+}
+
+// Parse parses a URL given by a client, rejecting the URLs no rule can allow
+func Parse(raw string) (*url.URL, error) {
+	if strings.ContainsAny(raw, "\\ \t\r\n") {
+		return nil, errors.Wrap(ierr.ErrRedirectNotAllowed, "invalid characters")
+	}
+	u, err := url.Parse(raw)
+	if err != nil || !u.IsAbs() || u.Host == "" {
+		return nil, errors.Wrap(ierr.ErrRedirectNotAllowed, "not an absolute url")
+	}
+	if u.User != nil || u.Fragment != "" || strings.Contains(raw, "#") {
+		return nil, errors.Wrap(ierr.ErrRedirectNotAllowed, "credentials or fragment")
+	}
+	// an encoded slash or dot would be read as a separator by some servers, e.g. /account/..%2f..%2fadmin
+	if u.RawPath != "" || hasDotSegment(u.Path) || strings.Contains(u.Path, "//") {
+		return nil, errors.Wrap(ierr.ErrRedirectNotAllowed, "ambiguous path")
+	}
+	return u, nil
+}
+
+// Allowlist are the redirect rules by client id, decoded from a comma separated list of client_id=rule|rule entries,
+// e.g. web=https://app.example.com/*|https://*.preview.example.com/*,ios=com.example.app://oauth/callback
+type Allowlist map[string][]Rule
+
+// Decode implements envconfig.Decoder
+func (a *Allowlist) Decode(value string) error {
+	allowlist := Allowlist{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		clientID, rules, ok := strings.Cut(entry, "=")
+		clientID = strings.TrimSpace(clientID)
+		if !ok || clientID == "" {
+			return fmt.Errorf("invalid redirect allowlist entry %q: expected client_id=rule|rule", entry)
+		}
+		for _, raw := range strings.Split(rules, "|") {
+			rule, err := ParseRule(strings.TrimSpace(raw))
+			if err != nil {
+				return errors.Wrapf(err, "invalid redirect allowlist of client %q", clientID)
+			}
+			allowlist[clientID] = append(allowlist[clientID], rule)
+		}
+	}
+	*a = allowlist
+	return nil
+}
+
+// String returns the allowlist in its decoded form, sorted by client id
+func (a Allowlist) String() string {
+	clientIDs := make([]string, 0, len(a))
+	for clientID := range a {
+		clientIDs = append(clientIDs, clientID)
+	}
+	sort.Strings(clientIDs)
+
+	entries := make([]string, 0, len(clientIDs))
+	for _, clientID := range clientIDs {
+		rules := make([]string, 0, len(a[clientID]))
+		for _, rule := range a[clientID] {
+			rules = append(rules, rule.String())
+		}
+		entries = append(entries, clientID+"="+strings.Join(rules, "|"))
+	}
+	return strings.Join(entries, ",")
+}
+
+// Check returns the URL when a rule of the client allows it, ierr.ErrRedirectNotAllowed otherwise.
+// The unknown clients are allowed nothing.
+func (a Allowlist) Check(clientID string, raw string) (string, error) {
+	u, err := Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	for _, rule := range a[clientID] {
+		if rule.Match(u) {
+			return u.String(), nil
+		}
+	}
+	return "", errors.Wrapf(ierr.ErrRedirectNotAllowed, "%s is not allowed for client %q", u.Redacted(), clientID)
+}
+
+// Local returns the path when it is base or below it and stays on the same site once followed by a browser,
+// base otherwise. It validates the return paths of the pages of the service itself.
+func Local(path string, base string) string {
+	if path != base && !strings.HasPrefix(path, base+"/") && !strings.HasPrefix(path, base+"?") {
+		return base
+	}
+	if strings.Contains(path, "//") || strings.Contains(path, `\`) || hasDotSegment(strings.SplitN(path, "?", 2)[0]) {
+		return base
+	}
+	return path
+}
+
+// hasDotSegment checks whether the path has a . or .. segment, which a browser resolves before requesting it
+func hasDotSegment(path string) bool {
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "." || segment == ".." {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,240 @@
+// Package scrub masks the personal data and the credentials written to the logs and the traces.
+// The values are recognized by the name of their field (password, refresh_token, email...)
+// and by pattern (emails, JWTs, bearer credentials and phone numbers) wherever they appear.
+package scrub
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces the credentials
+const Redacted = "[REDACTED]"
+
+var (
+	emailPattern  = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	jwtPattern    = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	bearerPattern = regexp.MustCompile(`(?i)\b(bearer|basic)\s+[A-Za-z0-9._~+/=-]+`)
+	// international numbers, and the local mobile numbers starting with 08
+	phonePattern = regexp.MustCompile(`\+\d{1,3}[\s.-]?\(?\d{1,4}\)?(?:[\s.-]?\d{2,4}){2,4}\b|\b08\d{8,11}\b`)
+)
+
+// secretFields are the names of the fields holding credentials, their values are redacted
+var secretFields = map[string]bool{
+	"password":         true,
+	"passwd":           true,
+	"pwd":              true,
+	"passphrase":       true,
+	"otp":              true,
+	"secret":           true,
+	"token":            true,
+	"authorization":    true,
+	"cookie":           true,
+	"set_cookie":       true,
+	"client_assertion": true,
+	"private_key":      true,
+	"api_key":          true,
+	"signing_key":      true,
+}
+
+// secretSuffixes are the suffixes of the names of the other fields holding credentials
+var secretSuffixes = []string{"_password", "_secret", "_token"}
+
+// emailFields and phoneFields are the names of the fields holding personal data, their values are masked
+var (
+	emailFields = map[string]bool{"email": true, "email_address": true}
+	phoneFields = map[string]bool{"phone": true, "phone_number": true, "mobile": true, "msisdn": true}
+)
+
+// String masks the emails, the tokens and the phone numbers found in s
+func String(s string) string {
+	s = jwtPattern.ReplaceAllString(s, Redacted)
+	s = bearerPattern.ReplaceAllString(s, "$1 "+Redacted)
+	s = emailPattern.ReplaceAllStringFunc(s, maskEmail)
+	s = phonePattern.ReplaceAllStringFunc(s, maskPhone)
+	return s
+}
+
+// Field masks the value of the named field, by its name first and by pattern otherwise.
+// Maps, slices and structs are scrubbed field by field, the structs are returned as maps.
+func Field(name string, value interface{}) interface{} {
+	key := normalize(name)
+	if isSecret(key) {
+		if value == nil {
+			return nil
+		}
+		return Redacted
+	}
+	if s, ok := value.(string); ok {
+		switch {
+		case emailFields[key]:
+			return maskEmail(s)
+		case phoneFields[key]:
+			return maskPhone(s)
+		}
+	}
+	return Value(value)
+}
+
+// Value masks a value by pattern, the fields of the maps and the structs are masked by name too
+func Value(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return String(v)
+	case []byte:
+		return string(JSON(v))
+	case error:
+		return String(v.Error())
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			res[key] = Field(key, item)
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i, item := range v {
+			res[i] = Value(item)
+		}
+		return res
+	case []string:
+		res := make([]string, len(v))
+		for i, item := range v {
+			res[i] = String(item)
+		}
+		return res
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, json.Number:
+		return v
+	}
+
+	switch reflect.Indirect(reflect.ValueOf(value)).Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		// the other composite values are scrubbed as their JSON encoding
+		b, err := json.Marshal(value)
+		if err != nil {
+			return value
+		}
+		var decoded interface{}
+		if err := decode(b, &decoded); err != nil {
+			return value
+		}
+		return Value(decoded)
+	}
+	return value
+}
+
+// JSON masks the fields of a JSON document, a body which is not JSON is masked by pattern
+func JSON(b []byte) []byte {
+	var decoded interface{}
+	if err := decode(b, &decoded); err != nil {
+		return []byte(String(string(b)))
+	}
+	res, err := json.Marshal(Value(decoded))
+	if err != nil {
+		return []byte(String(string(b)))
+	}
+	return res
+}
+
+// Query masks the parameters of a query string
+func Query(query string) string {
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return String(query)
+	}
+	for key, items := range values {
+		for i, item := range items {
+			if masked, ok := Field(key, item).(string); ok {
+				items[i] = masked
+			}
+		}
+		values[key] = items
+	}
+	return values.Encode()
+}
+
+func decode(b []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// normalize lowercases the field name and turns camelCase and kebab-case into snake_case
+func normalize(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			if i > 0 && name[i-1] >= 'a' && name[i-1] <= 'z' {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r - 'A' + 'a')
+		case r == '-':
+			b.WriteByte('_')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func isSecret(key string) bool {
+	if secretFields[key] {
+		return true
+	}
+	for _, suffix := range secretSuffixes {
+		if strings.HasSuffix(key, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// maskEmail keeps the first character of the local part and the domain: j***@example.com
+func maskEmail(email string) string {
+	at := strings.LastIndexByte(email, '@')
+	if at <= 0 {
+		return Redacted
+	}
+	return email[:1] + "***" + email[at:]
+}
+
+// maskPhone keeps the last two digits of the number: ***89
+func maskPhone(phone string) string {
+	var digits []byte
+	for i := 0; i < len(phone); i++ {
+		if phone[i] >= '0' && phone[i] <= '9' {
+			digits = append(digits, phone[i])
+		}
+	}
+	if len(digits) <= 2 {
+		return Redacted
+	}
+	return "***" + string(digits[len(digits)-2:])
+}
+
+// writer masks by pattern the lines written to the underlying writer
+type writer struct {
+	w io.Writer
+}
+
+// NewWriter returns a writer masking by pattern what is written to w, for the logs which are not written
+// by the logger like the access logs. Each write is expected to hold whole lines.
+func NewWriter(w io.Writer) io.Writer {
+	return writer{w}
+}
+
+// Write implements io.Writer
+func (w writer) Write(p []byte) (int, error) {
+	if _, err := w.w.Write([]byte(String(string(p)))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
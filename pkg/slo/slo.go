@@ -0,0 +1,223 @@
+// Package slo computes the availability and latency SLIs of the endpoints and the burn rates of their error budgets.
+package slo
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Classes of the request outcomes
+const (
+	ClassSuccess         = "success"          // answered with a 1xx, 2xx or 3xx status
+	ClassClientError     = "client_error"     // refused because of the request, it does not consume the error budget
+	ClassDependencyError = "dependency_error" // failed by the database, the cache or an overloaded dependency
+	ClassInternalError   = "internal_error"   // failed by the service itself
+)
+
+// SLIs of the endpoints
+const (
+	SLIAvailability = "availability" // ratio of the requests not failed by the service or its dependencies
+	SLILatency      = "latency"      // ratio of the successful requests answered under the latency threshold
+)
+
+// Windows are the windows of the SLIs and burn rates, those of the multiwindow burn-rate alerts
+var Windows = []time.Duration{5 * time.Minute, time.Hour, 6 * time.Hour}
+
+// resolution is the duration of the buckets the outcomes are counted in
+const resolution = time.Minute
+
+// bucketCount is the number of buckets covering the largest window
+const bucketCount = int(6 * time.Hour / resolution)
+
+// Objective is the objective of an endpoint
+type Objective struct {
+	Availability     float64       // target ratio of the requests not failed by the service, e.g. 0.999
+	Latency          float64       // target ratio of the successful requests answered under the threshold, e.g. 0.99
+	LatencyThreshold time.Duration // latency of a fast request
+}
+
+// bucket counts the outcomes of the requests of an endpoint during a minute
+type bucket struct {
+	minute  int64
+	total   uint64
+	failed  uint64 // dependency and internal errors
+	success uint64
+	fast    uint64 // successful requests answered under the latency threshold
+}
+
+type endpoint struct {
+	method    string
+	route     string
+	objective Objective
+	buckets   [bucketCount]bucket
+}
+
+// Tracker counts the request outcomes of the endpoints over the windows, in the memory of the instance.
+// It is a prometheus collector exposing the SLIs and burn rates of the endpoints.
+type Tracker struct {
+	objectives func(route string) Objective
+
+	mu        sync.Mutex
+	endpoints map[string]*endpoint
+}
+
+// NewTracker creates a tracker, the objective of each endpoint is returned by objectives from its route
+func NewTracker(objectives func(route string) Objective) *Tracker {
+	return &Tracker{objectives: objectives, endpoints: map[string]*endpoint{}}
+}
+
+// Record counts the outcome of a request of the endpoint, its class and latency
+func (t *Tracker) Record(method, route, class string, latency time.Duration, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := method + " " + route
+	e, ok := t.endpoints[key]
+	if !ok {
+		e = &endpoint{method: method, route: route, objective: t.objectives(route)}
+		t.endpoints[key] = e
+	}
+
+	minute := at.Unix() / int64(resolution/time.Second)
+	b := &e.buckets[minute%int64(bucketCount)]
+	if b.minute != minute {
+		*b = bucket{minute: minute}
+	}
+	b.total++
+	switch class {
+	case ClassDependencyError, ClassInternalError:
+		b.failed++
+	case ClassSuccess:
+		b.success++
+		if latency <= e.objective.LatencyThreshold {
+			b.fast++
+		}
+	}
+}
+
+// Report is the SLO report of the endpoints of the instance
+type Report struct {
+	GeneratedAt time.Time        `json:"generated_at"`
+	Endpoints   []EndpointReport `json:"endpoints"`
+}
+
+// EndpointReport is the SLO report of an endpoint
+type EndpointReport struct {
+	Method                 string         `json:"method" example:"POST"`
+	Route                  string         `json:"route" example:"/auth/login"`
+	AvailabilityTarget     float64        `json:"availability_target" example:"0.999"`
+	LatencyTarget          float64        `json:"latency_target" example:"0.99"`
+	LatencyThresholdMillis int64          `json:"latency_threshold_ms" example:"300"`
+	Windows                []WindowReport `json:"windows"`
+}
+
+// WindowReport are the SLIs of an endpoint over a window
+type WindowReport struct {
+	Window       string    `json:"window" example:"1h"`
+	Requests     uint64    `json:"requests"`
+	Availability SLIReport `json:"availability"`
+	Latency      SLIReport `json:"latency"`
+}
+
+// SLIReport is a SLI with the burn rate of its error budget, 1 consumes the budget in the period of the objective
+type SLIReport struct {
+	Ratio    float64 `json:"ratio" example:"0.9995"`
+	BurnRate float64 `json:"burn_rate" example:"0.5"`
+}
+
+// Report computes the SLIs of every endpoint over the windows ending at now, by method and route
+func (t *Tracker) Report(now time.Time) Report {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	res := Report{GeneratedAt: now, Endpoints: make([]EndpointReport, 0, len(t.endpoints))}
+	current := now.Unix() / int64(resolution/time.Second)
+	for _, e := range t.endpoints {
+		report := EndpointReport{
+			Method:                 e.method,
+			Route:                  e.route,
+			AvailabilityTarget:     e.objective.Availability,
+			LatencyTarget:          e.objective.Latency,
+			LatencyThresholdMillis: e.objective.LatencyThreshold.Milliseconds(),
+		}
+		for _, window := range Windows {
+			var sum bucket
+			since := current - int64(window/resolution)
+			for _, b := range e.buckets {
+				if b.minute > since && b.minute <= current {
+					sum.total += b.total
+					sum.failed += b.failed
+					sum.success += b.success
+					sum.fast += b.fast
+				}
+			}
+			report.Windows = append(report.Windows, WindowReport{
+				Window:       windowName(window),
+				Requests:     sum.total,
+				Availability: newSLIReport(sum.total-sum.failed, sum.total, e.objective.Availability),
+				Latency:      newSLIReport(sum.fast, sum.success, e.objective.Latency),
+			})
+		}
+		res.Endpoints = append(res.Endpoints, report)
+	}
+
+	sort.Slice(res.Endpoints, func(i, j int) bool {
+		if res.Endpoints[i].Route != res.Endpoints[j].Route {
+			return res.Endpoints[i].Route < res.Endpoints[j].Route
+		}
+		return res.Endpoints[i].Method < res.Endpoints[j].Method
+	})
+	return res
+}
+
+// newSLIReport computes the ratio of the good events, 1 without any event, and the burn rate of the target
+func newSLIReport(good, valid uint64, target float64) SLIReport {
+	if valid == 0 {
+		return SLIReport{Ratio: 1}
+	}
+	ratio := float64(good) / float64(valid)
+	res := SLIReport{Ratio: ratio}
+	if target < 1 {
+		res.BurnRate = (1 - ratio) / (1 - target)
+	}
+	return res
+}
+
+var (
+	sliDesc = prometheus.NewDesc("slo_sli_ratio",
+		"Ratio of the good requests of the endpoint over the window, per SLI.",
+		[]string{"method", "route", "sli", "window"}, nil)
+	burnRateDesc = prometheus.NewDesc("slo_error_budget_burn_rate",
+		"Rate at which the endpoint consumes its error budget over the window, 1 consumes it in the period of the objective.",
+		[]string{"method", "route", "sli", "window"}, nil)
+)
+
+// Describe implements prometheus.Collector
+func (t *Tracker) Describe(ch chan<- *prometheus.Desc) {
+	ch <- sliDesc
+	ch <- burnRateDesc
+}
+
+// Collect implements prometheus.Collector, the SLIs are computed when scraped
+func (t *Tracker) Collect(ch chan<- prometheus.Metric) {
+	for _, e := range t.Report(time.Now()).Endpoints {
+		for _, w := range e.Windows {
+			for sli, report := range map[string]SLIReport{SLIAvailability: w.Availability, SLILatency: w.Latency} {
+				ch <- prometheus.MustNewConstMetric(sliDesc, prometheus.GaugeValue, report.Ratio, e.Method, e.Route, sli, w.Window)
+				ch <- prometheus.MustNewConstMetric(burnRateDesc, prometheus.GaugeValue, report.BurnRate, e.Method, e.Route, sli, w.Window)
+			}
+		}
+	}
+}
+
+// windowName names the window in hours or minutes, 1h or 5m
+func windowName(window time.Duration) string {
+	if window%time.Hour == 0 {
+		return fmt.Sprintf("%dh", window/time.Hour)
+	}
+	return fmt.Sprintf("%dm", window/time.Minute)
+}
@@ -0,0 +1,59 @@
+package slo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracker(t *testing.T) {
+	tracker := NewTracker(func(route string) Objective {
+		return Objective{Availability: 0.99, Latency: 0.9, LatencyThreshold: 100 * time.Millisecond}
+	})
+	now := time.Date(2026, 10, 15, 12, 0, 30, 0, time.UTC)
+
+	// two hours ago, out of the short windows
+	for i := 0; i < 10; i++ {
+		tracker.Record("POST", "/auth/login", ClassInternalError, time.Millisecond, now.Add(-2*time.Hour))
+	}
+	// the last minute: 96 fast successes, 2 slow ones, a client error and a dependency error
+	for i := 0; i < 96; i++ {
+		tracker.Record("POST", "/auth/login", ClassSuccess, 50*time.Millisecond, now)
+	}
+	tracker.Record("POST", "/auth/login", ClassSuccess, time.Second, now)
+	tracker.Record("POST", "/auth/login", ClassSuccess, time.Second, now)
+	tracker.Record("POST", "/auth/login", ClassClientError, time.Millisecond, now)
+	tracker.Record("POST", "/auth/login", ClassDependencyError, time.Second, now)
+	// an endpoint without failure
+	tracker.Record("GET", "/health", ClassSuccess, time.Millisecond, now)
+
+	report := tracker.Report(now)
+	require.Len(t, report.Endpoints, 2)
+	assert.Equal(t, "/auth/login", report.Endpoints[0].Route)
+	assert.Equal(t, "/health", report.Endpoints[1].Route)
+
+	windows := report.Endpoints[0].Windows
+	require.Len(t, windows, 3)
+	assert.Equal(t, "5m", windows[0].Window)
+	assert.Equal(t, uint64(100), windows[0].Requests)
+	assert.InDelta(t, 0.99, windows[0].Availability.Ratio, 1e-9)
+	assert.InDelta(t, 1, windows[0].Availability.BurnRate, 1e-9)
+	assert.InDelta(t, 96.0/98, windows[0].Latency.Ratio, 1e-9)
+	assert.InDelta(t, (1-96.0/98)/0.1, windows[0].Latency.BurnRate, 1e-9)
+
+	// the failures of two hours ago are counted in the 6h window only
+	assert.Equal(t, "1h", windows[1].Window)
+	assert.Equal(t, uint64(100), windows[1].Requests)
+	assert.Equal(t, "6h", windows[2].Window)
+	assert.Equal(t, uint64(110), windows[2].Requests)
+	assert.InDelta(t, 99.0/110, windows[2].Availability.Ratio, 1e-9)
+
+	assert.Equal(t, SLIReport{Ratio: 1}, report.Endpoints[1].Windows[0].Availability)
+
+	// the buckets are reused once the largest window passed
+	report = tracker.Report(now.Add(7 * time.Hour))
+	assert.Equal(t, uint64(0), report.Endpoints[0].Windows[2].Requests)
+	assert.Equal(t, SLIReport{Ratio: 1}, report.Endpoints[0].Windows[2].Latency)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"time"
 
@@ -11,13 +12,31 @@ import (
 	"github.com/pkg/errors"
 	"golang.org/x/oauth2/google"
 	"golang.org/x/oauth2/jwt"
+	"google.golang.org/api/googleapi"
 )
 
+// ErrObjectExists is returned by Put when the object already exists and the options only allow to create it
+var ErrObjectExists = errors.New("object already exists")
+
 type IStorage interface {
 	Upload(bucket string, object string, file io.Reader) (string, error)
 	SignedURL(bucket string, object string) (string, error)
 	GetFile(bucket string, fileName string) ([]byte, error)
 	MakePublic(bucket string, object string) (string, error)
+	Put(ctx context.Context, bucket string, object string, file io.Reader, opts PutOptions) error
+}
+
+// PutOptions are the attributes of an object written by Put
+type PutOptions struct {
+	ContentType string
+	Metadata    map[string]string
+	// CreateOnly fails the write when the object already exists instead of replacing it
+	CreateOnly bool
+	// TemporaryHold and EventBasedHold lock the object, it cannot be deleted or replaced until the hold is removed
+	TemporaryHold  bool
+	EventBasedHold bool
+	// CRC32C, when set, is checked by the storage against the received object and the write fails on a mismatch
+	CRC32C uint32
 }
 
 // Storage is a storage client
@@ -69,6 +88,42 @@ func (c *Storage) Upload(bucket string, object string, file io.Reader) (string,
 	return object, err
 }
 
+// Put writes the object to the bucket with the given attributes
+func (c *Storage) Put(ctx context.Context, bucket string, object string, file io.Reader, opts PutOptions) error {
+
+	handle := c.client.Bucket(bucket).Object(object)
+	if opts.CreateOnly {
+		handle = handle.If(storage.Conditions{DoesNotExist: true})
+	}
+
+	// cancelling the context aborts the upload, so that a failed copy does not leave a truncated object
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	wc := handle.NewWriter(ctx)
+	wc.ContentType = opts.ContentType
+	wc.Metadata = opts.Metadata
+	wc.TemporaryHold = opts.TemporaryHold
+	wc.EventBasedHold = opts.EventBasedHold
+	if opts.CRC32C != 0 {
+		wc.CRC32C = opts.CRC32C
+		wc.SendCRC32C = true
+	}
+	if _, err := io.Copy(wc, file); err != nil {
+		cancel()
+		_ = wc.Close()
+		return errors.Wrap(err, "cannot write object")
+	}
+	if err := wc.Close(); err != nil {
+		var apiErr *googleapi.Error
+		if errors.As(err, &apiErr) && apiErr.Code == http.StatusPreconditionFailed {
+			return ErrObjectExists
+		}
+		return errors.Wrap(err, "cannot write object")
+	}
+	return nil
+}
+
 // SignedURL signs url and returns signed url
 func (c *Storage) SignedURL(bucket string, object string) (string, error) {
 
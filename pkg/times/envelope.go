@@ -0,0 +1,23 @@
+package times
+
+import (
+	"context"
+	"time"
+)
+
+// Envelope starts a timing envelope of d, the returned function waits until d elapsed since Envelope was called, or
+// until ctx is done, so that the paths of a call take as long, e.g. whether an account exists. A zero duration
+// does not wait.
+func Envelope(ctx context.Context, d time.Duration) (wait func()) {
+	if d <= 0 {
+		return func() {}
+	}
+	timer := time.NewTimer(d)
+	return func() {
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+	}
+}
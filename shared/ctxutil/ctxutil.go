@@ -0,0 +1,101 @@
+// Package ctxutil carries the values of a request in its context: the principal, the tenant, the locale and the
+// request and correlation IDs. Each value has its own unexported key type, so that it can only be set and read
+// through the typed functions of the package, and Detach carries them over to the work outliving the request.
+package ctxutil
+
+import (
+	"context"
+
+	"github.com/dgrijalva/jwt-go"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type (
+	principalKey     struct{}
+	opaqueTokenKey   struct{}
+	tenantKey        struct{}
+	localeKey        struct{}
+	requestIDKey     struct{}
+	correlationIDKey struct{}
+)
+
+// WithPrincipal returns a context carrying the verified token of the caller, a user or a service account
+func WithPrincipal(ctx context.Context, token *jwt.Token) context.Context {
+	return context.WithValue(ctx, principalKey{}, token)
+}
+
+// Principal returns the verified token of the caller, false when the request is anonymous
+func Principal(ctx context.Context) (*jwt.Token, bool) {
+	token, ok := ctx.Value(principalKey{}).(*jwt.Token)
+	return token, ok && token != nil
+}
+
+// WithOpaqueToken returns a context carrying the opaque access token of the request, resolved to the signed token
+// of its principal
+func WithOpaqueToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, opaqueTokenKey{}, token)
+}
+
+// OpaqueToken returns the opaque access token of the request, false when it was authenticated otherwise
+func OpaqueToken(ctx context.Context) (string, bool) {
+	token, ok := ctx.Value(opaqueTokenKey{}).(string)
+	return token, ok
+}
+
+// WithTenant returns a context carrying the ID of the tenant of the request
+func WithTenant(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+// Tenant returns the ID of the tenant of the request, false when the request names none
+func Tenant(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(tenantKey{}).(string)
+	return id, ok
+}
+
+// WithLocale returns a context carrying the locale preferred by the caller, a BCP 47 language tag such as fr-CA
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// Locale returns the locale preferred by the caller, false when the request tells none
+func Locale(ctx context.Context) (string, bool) {
+	locale, ok := ctx.Value(localeKey{}).(string)
+	return locale, ok
+}
+
+// WithRequestID returns a context carrying the ID of the request
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the ID of the request, false outside of a request
+func RequestID(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok
+}
+
+// WithCorrelationID returns a context carrying the correlation ID sent by the caller, shared by the requests of a
+// flow across the services
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, id)
+}
+
+// CorrelationID returns the correlation ID sent by the caller, false when it sent none
+func CorrelationID(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(correlationIDKey{}).(string)
+	return id, ok
+}
+
+// Detach returns a context carrying the values of the request of the context and its span, for the work going on
+// once the request is answered, e.g. in a goroutine: it is neither cancelled nor bounded by the deadline of the
+// request. The spans started from it continue the trace of the request.
+func Detach(ctx context.Context) context.Context {
+	detached := trace.ContextWithSpanContext(context.Background(), trace.SpanContextFromContext(ctx))
+	for _, key := range []interface{}{principalKey{}, opaqueTokenKey{}, tenantKey{}, localeKey{}, requestIDKey{}, correlationIDKey{}} {
+		if value := ctx.Value(key); value != nil {
+			detached = context.WithValue(detached, key, value)
+		}
+	}
+	return detached
+}
@@ -0,0 +1,91 @@
+package ctxutil
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestValues(t *testing.T) {
+	ctx := context.Background()
+	_, ok := Principal(ctx)
+	assert.False(t, ok)
+	_, ok = Tenant(ctx)
+	assert.False(t, ok)
+	_, ok = Locale(ctx)
+	assert.False(t, ok)
+	_, ok = RequestID(ctx)
+	assert.False(t, ok)
+
+	token := &jwt.Token{Claims: jwt.MapClaims{"id": "u1"}}
+	ctx = WithPrincipal(ctx, token)
+	ctx = WithOpaqueToken(ctx, "oat_abc")
+	ctx = WithTenant(ctx, "acme")
+	ctx = WithLocale(ctx, "fr-CA")
+	ctx = WithRequestID(ctx, "req-42")
+	ctx = WithCorrelationID(ctx, "flow-7")
+
+	principal, ok := Principal(ctx)
+	assert.True(t, ok)
+	assert.Same(t, token, principal)
+	opaqueToken, _ := OpaqueToken(ctx)
+	assert.Equal(t, "oat_abc", opaqueToken)
+	tenant, _ := Tenant(ctx)
+	assert.Equal(t, "acme", tenant)
+	locale, _ := Locale(ctx)
+	assert.Equal(t, "fr-CA", locale)
+	requestID, _ := RequestID(ctx)
+	assert.Equal(t, "req-42", requestID)
+	correlationID, _ := CorrelationID(ctx)
+	assert.Equal(t, "flow-7", correlationID)
+
+	_, ok = Principal(WithPrincipal(context.Background(), nil))
+	assert.False(t, ok, "a nil token is no principal")
+}
+
+func TestDetach(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	span := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID})
+
+	ctx, cancel := context.WithTimeout(trace.ContextWithSpanContext(context.Background(), span), time.Minute)
+	ctx = WithTenant(WithRequestID(ctx, "req-42"), "acme")
+	detached := Detach(ctx)
+	cancel()
+
+	assert.Error(t, ctx.Err())
+	assert.NoError(t, detached.Err(), "the detached context outlives the request")
+	_, hasDeadline := detached.Deadline()
+	assert.False(t, hasDeadline)
+
+	requestID, _ := RequestID(detached)
+	assert.Equal(t, "req-42", requestID)
+	tenant, _ := Tenant(detached)
+	assert.Equal(t, "acme", tenant)
+	_, ok := Principal(detached)
+	assert.False(t, ok, "only the values of the request are carried")
+	assert.Equal(t, span, trace.SpanContextFromContext(detached))
+}
+
+func TestPreferredLocale(t *testing.T) {
+	tests := []struct {
+		header string
+		locale string
+	}{
+		{"fr-CA, fr;q=0.9, en;q=0.8", "fr-CA"},
+		{"en;q=0.5, de;q=0.7", "de"},
+		{"*, es;q=0.4", "es"},
+		{"en-US", "en-US"},
+		{"en;q=0, <script>", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		locale, ok := PreferredLocale(tt.header)
+		assert.Equal(t, tt.locale, locale, tt.header)
+		assert.Equal(t, tt.locale != "", ok, tt.header)
+	}
+}
@@ -0,0 +1,31 @@
+package ctxutil
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// languageTag matches the BCP 47 language tags, loosely: a language and its subtags
+var languageTag = regexp.MustCompile(`^[A-Za-z]{1,8}(-[A-Za-z0-9]{1,8})*$`)
+
+// PreferredLocale returns the locale of highest quality of an Accept-Language header, e.g. fr-CA for
+// "fr-CA, fr;q=0.9, en;q=0.8", false when it names none. The wildcard and the invalid tags are ignored.
+func PreferredLocale(acceptLanguage string) (string, bool) {
+	locale, best := "", 0.0
+	for _, item := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(item), ";")
+		quality := 1.0
+		if params = strings.TrimSpace(params); strings.HasPrefix(params, "q=") {
+			parsed, err := strconv.ParseFloat(strings.TrimPrefix(params, "q="), 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if quality > best && languageTag.MatchString(tag) {
+			locale, best = tag, quality
+		}
+	}
+	return locale, locale != ""
+}
@@ -0,0 +1,80 @@
+package ierr
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"google.golang.org/grpc/codes"
+)
+
+// Category groups the errors by what the clients can do about them
+type Category string
+
+// Categories of the errors
+const (
+	CategoryInternal       Category = "internal"       // a failure of the service, which may be retried
+	CategoryUnavailable    Category = "unavailable"    // the service is saturated, retried after a delay
+	CategoryValidation     Category = "validation"     // the request is invalid, it must be fixed
+	CategoryAuthentication Category = "authentication" // the credentials are missing, invalid or expired
+	CategoryPermission     Category = "permission"     // the caller is not allowed the request
+	CategoryNotFound       Category = "not_found"
+	CategoryConflict       Category = "conflict"   // the request conflicts with an existing resource
+	CategoryState          Category = "state"      // the resource is not in a state allowing the request
+	CategoryRateLimit      Category = "rate_limit" // the request exceeds a limit, retried after a delay
+	CategoryClient         Category = "client"     // the client, its version or its media types, is not supported
+)
+
+// Definition is the definition of an error in the catalog
+type Definition struct {
+	Code     string   `json:"code" example:"400027"`
+	Message  string   `json:"message" example:"token is invalid"`
+	Category Category `json:"category" example:"authentication"`
+	// HTTPStatus is the status answering the error by default, the status prefixing its code
+	HTTPStatus int `json:"http_status" example:"400"`
+	// GRPCCode is the code of the status answering the error over gRPC, GRPCStatus is its name
+	GRPCCode    codes.Code `json:"-"`
+	GRPCStatus  string     `json:"grpc_code" example:"Unauthenticated"`
+	Description string     `json:"description"`
+}
+
+// definitions are the errors defined, by code
+var definitions = map[string]Definition{}
+
+// define registers the error of the code in the catalog, it panics when the code is not six digits prefixed with an
+// HTTP status or is already defined, as a code identifies a single error
+func define(code, message string, category Category, grpcCode codes.Code, description string) Error {
+	status, err := strconv.Atoi(code)
+	if err != nil || len(code) != 6 || status < 100000 {
+		panic(fmt.Sprintf("invalid error code %q: expected six digits prefixed with an HTTP status", code))
+	}
+	if defined, ok := definitions[code]; ok {
+		panic(fmt.Sprintf("error code %s is already defined by %q", code, defined.Message))
+	}
+	definitions[code] = Definition{
+		Code:        code,
+		Message:     message,
+		Category:    category,
+		HTTPStatus:  status / 1000,
+		GRPCCode:    grpcCode,
+		GRPCStatus:  grpcCode.String(),
+		Description: description,
+	}
+	return Error{Code: code, Message: message}
+}
+
+// Lookup returns the definition of the code of the error, false when it is not defined
+func Lookup(err Error) (Definition, bool) {
+	definition, ok := definitions[err.Code]
+	return definition, ok
+}
+
+// Catalog returns the definitions of every error, by code
+func Catalog() []Definition {
+	res := make([]Definition, 0, len(definitions))
+	for _, definition := range definitions {
+		res = append(res, definition)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Code < res[j].Code })
+	return res
+}
@@ -0,0 +1,45 @@
+package ierr
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+)
+
+func TestCatalog(t *testing.T) {
+	catalog := Catalog()
+	assert.Len(t, catalog, len(definitions))
+	for i, definition := range catalog {
+		if i > 0 {
+			assert.Less(t, catalog[i-1].Code, definition.Code, "the catalog is sorted by code")
+		}
+		assert.NotEmpty(t, http.StatusText(definition.HTTPStatus), definition.Code)
+		assert.NotEmpty(t, definition.Category, definition.Code)
+		assert.NotEmpty(t, definition.Description, definition.Code)
+		assert.Equal(t, definition.GRPCCode.String(), definition.GRPCStatus, definition.Code)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	definition, ok := Lookup(ErrExpiredToken)
+	assert.True(t, ok)
+	assert.Equal(t, ErrExpiredToken.Message, definition.Message)
+	assert.Equal(t, CategoryAuthentication, definition.Category)
+	assert.Equal(t, http.StatusBadRequest, definition.HTTPStatus)
+	assert.Equal(t, codes.Unauthenticated, definition.GRPCCode)
+
+	definition, ok = Lookup(ErrTooManyRequests)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusTooManyRequests, definition.HTTPStatus)
+
+	_, ok = Lookup(Error{Code: "400999", Message: "undefined"})
+	assert.False(t, ok)
+}
+
+func TestDefineRejectsInvalidCodes(t *testing.T) {
+	assert.Panics(t, func() { define("400020", "duplicate", CategoryConflict, codes.AlreadyExists, "") }, "a code is defined once")
+	assert.Panics(t, func() { define("4000", "short", CategoryValidation, codes.InvalidArgument, "") })
+	assert.Panics(t, func() { define("40000a", "not numeric", CategoryValidation, codes.InvalidArgument, "") })
+}
@@ -1,6 +1,10 @@
 package ierr
 
-import "fmt"
+import (
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+)
 
 type Error struct {
 	Code    string `json:"code,omitempty"`
@@ -11,24 +15,127 @@ func (e Error) Error() string {
 	return fmt.Sprintf("%s: %s", e.Code, e.Message)
 }
 
+// The codes are stable: an error keeps its code once released and a code is never reused, the clients handle the
+// errors by their code. Every error is defined with its category, its gRPC code and its description, see Catalog.
 var (
-	ErrInternal         = Error{Code: "500000", Message: "we encountered an error while processing your request (internal server error)"}
-	ErrResourceNotFound = Error{Code: "404000", Message: "the requested resource was not found"}
-	ErrBadRequest       = Error{Code: "400000", Message: "your request is in a bad format"}
-	ErrUnauthorized     = Error{Code: "401000", Message: "you are not authorized to perform the requested action"}
-	ErrForbidden        = Error{Code: "403000", Message: "you don't have access to this resource"}
+	ErrInternal = define("500000", "we encountered an error while processing your request (internal server error)", CategoryInternal, codes.Internal,
+		"An unexpected failure of the service, answered without its details. The request may be retried.")
+	ErrResourceNotFound = define("404000", "the requested resource was not found", CategoryNotFound, codes.NotFound,
+		"The resource of the request, or the endpoint, does not exist.")
+	ErrBadRequest = define("400000", "your request is in a bad format", CategoryValidation, codes.InvalidArgument,
+		"The request cannot be decoded or fails the validation of its fields, the message names the invalid fields.")
+	ErrUnauthorized = define("401000", "you are not authorized to perform the requested action", CategoryAuthentication, codes.Unauthenticated,
+		"The request has no valid credentials. The reason of the response tells whether to refresh the token or to log in again.")
+	ErrForbidden = define("403000", "you don't have access to this resource", CategoryPermission, codes.PermissionDenied,
+		"The caller is authenticated but not granted the permission or the scope of the request.")
+	ErrNotAcceptable = define("406000", "the response cannot be encoded in the accepted media type", CategoryClient, codes.FailedPrecondition,
+		"None of the media types of the Accept header is supported, see the media types of GET /capabilities.")
+	ErrConflict = define("409000", "the resource already exists", CategoryConflict, codes.AlreadyExists,
+		"The resource created conflicts with an existing one.")
+	ErrCanaryRejected = define("412000", "the canary did not pass the deployment analysis", CategoryState, codes.FailedPrecondition,
+		"The canary of the rollout is rejected by the analysis of its metrics and cannot be promoted.")
+	ErrUnsupportedMedia = define("415000", "the request body cannot be decoded from its media type", CategoryClient, codes.FailedPrecondition,
+		"The Content-Type of the request body is not supported.")
+	ErrUpgradeRequired = define("426000", "this version of the client is no longer supported, please upgrade", CategoryClient, codes.FailedPrecondition,
+		"The version of the client is below the minimum version of its platform, see the client minimum versions of GET /capabilities.")
+	ErrTooManyRequests = define("429000", "too many requests, please try again later", CategoryRateLimit, codes.ResourceExhausted,
+		"The request exceeds a rate limit or a lockout. It may be retried after the Retry-After header, or the locked_until field.")
+	ErrServiceUnavailable = define("503000", "the service is overloaded, please try again later", CategoryUnavailable, codes.Unavailable,
+		"The service or one of its dependencies is saturated. The request may be retried after the Retry-After header.")
 )
 
 var (
-	ErrUserAlreadyRegistered = Error{Code: "400020", Message: "you're already registered"}
-	ErrInvalidCreds          = Error{Code: "400021", Message: "invalid username or password"}
-	ErrUserIsNotActive       = Error{Code: "400022", Message: "user is not active"}
-	ErrPinAlreadySet         = Error{Code: "400023", Message: "pin already set"}
-	ErrPinIsNotSet           = Error{Code: "400024", Message: "pin is not set"}
-	ErrWrongOTP              = Error{Code: "400025", Message: "wrong OTP code"}
-	ErrExpiredOTP            = Error{Code: "400026", Message: "expired OTP code"}
-	ErrInvalidToken          = Error{Code: "400027", Message: "token is invalid"}
-	ErrExpiredToken          = Error{Code: "400028", Message: "token has expired"}
-	ErrEmailAlreadyVerified  = Error{Code: "400029", Message: "email has been verified"}
-	ErrInvalidPhoneNumber    = Error{Code: "400030", Message: "phone number is invalid"}
+	ErrUserAlreadyRegistered = define("400020", "you're already registered", CategoryConflict, codes.AlreadyExists,
+		"A user with the username, the email or the phone number of the registration already exists.")
+	ErrInvalidCreds = define("400021", "invalid username or password", CategoryAuthentication, codes.Unauthenticated,
+		"The username or the password of the login is wrong.")
+	ErrUserIsNotActive = define("400022", "user is not active", CategoryState, codes.FailedPrecondition,
+		"The user is deactivated or locked and cannot log in.")
+	ErrPinAlreadySet = define("400023", "pin already set", CategoryConflict, codes.InvalidArgument,
+		"The pin of the user is already set, it can only be changed.")
+	ErrPinIsNotSet = define("400024", "pin is not set", CategoryState, codes.InvalidArgument,
+		"The user has no pin to verify yet.")
+	ErrWrongOTP = define("400025", "wrong OTP code", CategoryValidation, codes.InvalidArgument,
+		"The OTP code does not match the one sent.")
+	ErrExpiredOTP = define("400026", "expired OTP code", CategoryState, codes.InvalidArgument,
+		"The OTP code has expired, a new one must be requested.")
+	ErrInvalidToken = define("400027", "token is invalid", CategoryAuthentication, codes.Unauthenticated,
+		"The token is malformed, is not signed by the service, or is not of the expected kind.")
+	ErrExpiredToken = define("400028", "token has expired", CategoryAuthentication, codes.Unauthenticated,
+		"The token has expired, the access tokens are renewed with the refresh token.")
+	ErrEmailAlreadyVerified = define("400029", "email has been verified", CategoryConflict, codes.InvalidArgument,
+		"The email address of the user is already verified.")
+	ErrInvalidPhoneNumber = define("400030", "phone number is invalid", CategoryValidation, codes.InvalidArgument,
+		"The phone number is not a valid E.164 number.")
+	ErrSessionLimitReached = define("400031", "maximum number of active sessions reached", CategoryRateLimit, codes.ResourceExhausted,
+		"The user has the maximum number of active sessions, one of them must be logged out first.")
+	ErrLoginApprovalPending = define("400032", "login is waiting for approval", CategoryState, codes.InvalidArgument,
+		"The login waits for its approval from a device of the user, the token is polled until then.")
+	ErrLoginApprovalDenied = define("400033", "login has been denied", CategoryPermission, codes.InvalidArgument,
+		"The login has been denied from a device of the user.")
+	ErrLoginApprovalExpired = define("400034", "login approval has expired", CategoryState, codes.InvalidArgument,
+		"The login has not been approved in time, the user must log in again.")
+	ErrDeviceLoginPending = define("400035", "device login is waiting for approval", CategoryState, codes.InvalidArgument,
+		"The device login waits for its decision, the device keeps polling.")
+	ErrDeviceLoginSlowDown = define("400036", "device is polling too frequently", CategoryRateLimit, codes.InvalidArgument,
+		"The device polls faster than its interval, it must increase the interval before polling again.")
+	ErrDeviceLoginDenied = define("400037", "device login has been denied", CategoryPermission, codes.InvalidArgument,
+		"The device login has been denied by the user.")
+	ErrDeviceLoginExpired = define("400038", "device login has expired", CategoryState, codes.InvalidArgument,
+		"The device login has not been decided in time, the device must start a new one.")
+	ErrInvalidClientAssertion = define("400039", "client assertion is invalid", CategoryAuthentication, codes.InvalidArgument,
+		"The client assertion of the service account is not signed by one of its keys, or its claims are invalid.")
+	ErrUnsupportedGrantType = define("400040", "grant type is not supported", CategoryValidation, codes.InvalidArgument,
+		"The grant type of the token request is not supported.")
+	ErrInvalidPublicKey = define("400041", "public key is invalid", CategoryValidation, codes.InvalidArgument,
+		"The public key is not a PEM encoded RSA key of at least 2048 bits.")
+	ErrServiceAccountDisabled = define("400042", "service account is disabled", CategoryState, codes.InvalidArgument,
+		"The service account is disabled and cannot get tokens.")
+	ErrUnknownColumn = define("400043", "cannot filter or sort on the requested field", CategoryValidation, codes.InvalidArgument,
+		"The field of the filter or of the sort of the list is not one of its filterable or sortable fields.")
+	ErrUserUnderLegalHold = define("400044", "user is under legal hold", CategoryState, codes.InvalidArgument,
+		"The user is under a legal hold and cannot be deleted or anonymized until it is released.")
+	ErrLegalHoldReleased = define("400045", "legal hold has already been released", CategoryState, codes.InvalidArgument,
+		"The legal hold has already been released.")
+	ErrEmailUndeliverable = define("400046", "email address is undeliverable", CategoryValidation, codes.InvalidArgument,
+		"The email address is suppressed after a bounce or a complaint, no email is sent to it.")
+	ErrInvalidWebhook = define("400047", "webhook signature is invalid", CategoryAuthentication, codes.InvalidArgument,
+		"The signature of the webhook does not match the one of its provider.")
+	ErrElevationNotPending = define("400048", "elevation request has already been decided or has expired", CategoryState, codes.InvalidArgument,
+		"The elevation request is no longer pending and cannot be decided.")
+	ErrBreakGlassNotSealed = define("400049", "break-glass account is not sealed", CategoryState, codes.InvalidArgument,
+		"The break-glass account must be sealed before it is activated.")
+	ErrBreakGlassNotActive = define("400050", "break-glass account is not active", CategoryState, codes.InvalidArgument,
+		"The break-glass account is not active and cannot be revoked.")
+	ErrSignupRejected = define("400051", "email address cannot be used to sign up", CategoryPermission, codes.InvalidArgument,
+		"The email address is rejected by the checks of the signup, e.g. its domain has no MX record.")
+	ErrSignupDisabled = define("400052", "signup is disabled", CategoryState, codes.InvalidArgument,
+		"The public signup is disabled in the deployment, see the signup capability of GET /capabilities.")
+	ErrUserSyncSourceUnknown = define("400053", "user sync source is not configured", CategoryValidation, codes.InvalidArgument,
+		"The source of the user sync is not one of the configured sources.")
+	ErrUserSyncSourceEmpty = define("400054", "user sync source returned no user", CategoryState, codes.InvalidArgument,
+		"The source of the user sync returned no user, the sync is aborted rather than deactivating every user.")
+	ErrConnectorUnknown = define("400055", "provisioning connector is not configured", CategoryValidation, codes.InvalidArgument,
+		"The connector of the provisioning is not one of the configured connectors.")
+	ErrPasswordResetNoAddress = define("400056", "user has no address to receive the password reset", CategoryState, codes.InvalidArgument,
+		"The user has neither an email address nor a phone number to receive the password reset.")
+	ErrSocialProviderUnknown = define("400057", "social login provider is not configured", CategoryValidation, codes.InvalidArgument,
+		"The provider of the social login is not one of the configured providers.")
+	ErrSocialAccountUnlinked = define("400058", "social account is not linked to a user", CategoryAuthentication, codes.InvalidArgument,
+		"The account of the social provider is not linked to a user of the service, nor to the user with its email address as the user did not verify it. The user links the account once logged in.")
+	// ErrPassword* are the violations of the password policy
+	ErrPasswordTooShort = define("400059", "password is too short", CategoryValidation, codes.InvalidArgument,
+		"The password is shorter than the minimum length of the password policy.")
+	ErrPasswordTooSimple = define("400060", "password does not mix enough kinds of characters", CategoryValidation, codes.InvalidArgument,
+		"The password mixes fewer kinds of characters, among lower case, upper case, digits and symbols, than the password policy requires.")
+	ErrPasswordTooCommon = define("400061", "password is too common", CategoryValidation, codes.InvalidArgument,
+		"The password is one of the common passwords rejected by the password policy.")
+	ErrPasswordContainsUsername = define("400062", "password contains the username", CategoryValidation, codes.InvalidArgument,
+		"The password contains the username of the user.")
+	ErrRedirectNotAllowed = define("400063", "redirect uri is not allowed for the client", CategoryValidation, codes.InvalidArgument,
+		"The redirect URI is not one of the redirect URIs registered for the client.")
+	ErrTokenRevoked = define("400064", "token has been revoked", CategoryAuthentication, codes.InvalidArgument,
+		"The token has been revoked, by a logout or by an administrator, the user must log in again.")
+	ErrRoleElevationOnly = define("403001", "role is only granted by an elevation", CategoryPermission, codes.PermissionDenied,
+		"The role is one of the sensitive roles granted for a bounded time by an approved elevation request, it cannot be assigned to a user.")
 )
@@ -0,0 +1,41 @@
+package ierr
+
+import (
+	"errors"
+	"time"
+)
+
+// LimitedError refuses a request until a time, e.g. a rate limit until the next token of its bucket.
+// errors.Cause returns its Error, so that the handlers match it as the Error alone.
+type LimitedError struct {
+	Err   Error
+	Until time.Time
+}
+
+// Limited returns the error refusing the request until the time
+func Limited(err Error, until time.Time) error {
+	return LimitedError{Err: err, Until: until}
+}
+
+func (e LimitedError) Error() string {
+	return e.Err.Error()
+}
+
+// Cause implements the causer of github.com/pkg/errors
+func (e LimitedError) Cause() error {
+	return e.Err
+}
+
+// Unwrap implements the wrapper of errors
+func (e LimitedError) Unwrap() error {
+	return e.Err
+}
+
+// LockedUntil returns the time until which the error refuses the request, false when it is not limited
+func LockedUntil(err error) (time.Time, bool) {
+	var limited LimitedError
+	if !errors.As(err, &limited) {
+		return time.Time{}, false
+	}
+	return limited.Until, true
+}
@@ -0,0 +1,5 @@
+// Package pb provides the protobuf messages of the DTOs answered to the clients accepting application/x-protobuf,
+// and the gRPC services served by transport/grpc.
+package pb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative auth.proto response.proto user.proto auth_service.proto user_service.proto
@@ -0,0 +1,234 @@
+package response
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go-hex/configs"
+	"io"
+	"net/http"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Codec encodes the responses and decodes the requests of the API following the configured
+// naming strategy and envelope, so that the DTOs keep a single set of snake_case tags.
+type Codec struct {
+	camelCase    bool
+	dataEnvelope bool
+}
+
+var _ echo.JSONSerializer = (*Codec)(nil)
+
+// NewCodec creates a codec with the given naming strategy and envelope
+func NewCodec(naming configs.JSONNaming, envelope configs.JSONEnvelope) *Codec {
+	return &Codec{
+		camelCase:    naming.IsCamelCase(),
+		dataEnvelope: envelope == configs.JSONEnvelopeData,
+	}
+}
+
+// dataEnvelope wraps a successful response in the data envelope
+type dataEnvelope struct {
+	Data interface{}   `json:"data"`
+	Meta *envelopeMeta `json:"meta,omitempty"`
+}
+
+type envelopeMeta struct {
+	Message string    `json:"message"`
+	Page    *pageMeta `json:"page,omitempty"`
+}
+
+// errorEnvelope wraps an error response in the data envelope
+type errorEnvelope struct {
+	Error envelopeError `json:"error"`
+}
+
+type envelopeError struct {
+	Code        string     `json:"code,omitempty"`
+	Message     string     `json:"message"`
+	Reason      string     `json:"reason,omitempty"`
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+}
+
+// Serialize implements echo.JSONSerializer
+func (c *Codec) Serialize(ctx echo.Context, i interface{}, indent string) error {
+	return c.write(ctx, c.envelope(i), indent)
+}
+
+// write writes the document following the naming strategy
+func (c *Codec) write(ctx echo.Context, document interface{}, indent string) error {
+
+	b, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+	if c.camelCase {
+		if b, err = renameKeys(b, snakeToCamel); err != nil {
+			return err
+		}
+	}
+
+	var out bytes.Buffer
+	if indent != "" {
+		if err := json.Indent(&out, b, "", indent); err != nil {
+			return err
+		}
+	} else {
+		out.Write(b)
+	}
+	out.WriteByte('\n')
+
+	_, err = ctx.Response().Write(out.Bytes())
+	return err
+}
+
+// Deserialize implements echo.JSONSerializer
+func (c *Codec) Deserialize(ctx echo.Context, i interface{}) error {
+
+	if !c.camelCase {
+		return decodeError(json.NewDecoder(ctx.Request().Body).Decode(i))
+	}
+
+	body, err := io.ReadAll(ctx.Request().Body)
+	if err != nil {
+		return err
+	}
+	b, err := renameKeys(body, camelToSnake)
+	if err != nil {
+		return decodeError(err)
+	}
+	return decodeError(json.Unmarshal(b, i))
+}
+
+// envelope returns the response value wrapped in the configured envelope
+func (c *Codec) envelope(i interface{}) interface{} {
+	if !c.dataEnvelope {
+		// the legacy envelope answers the items of a page only
+		if res, ok := i.(Response); ok {
+			if page, ok := res.Data.(Page); ok {
+				res.Data = page.Items
+				return res
+			}
+		}
+		return i
+	}
+	switch res := i.(type) {
+	case Response:
+		if page, ok := res.Data.(Page); ok {
+			return dataEnvelope{Data: page.Items, Meta: &envelopeMeta{Message: res.Message, Page: page.meta()}}
+		}
+		return dataEnvelope{Data: res.Data, Meta: &envelopeMeta{Message: res.Message}}
+	case ErrorResponse:
+		return errorEnvelope{Error: envelopeError{Code: res.ErrorCode, Message: res.Message, Reason: res.Reason, LockedUntil: res.LockedUntil}}
+	}
+	return dataEnvelope{Data: i}
+}
+
+// decodeError answers the malformed requests with 400 like echo.DefaultJSONSerializer
+func decodeError(err error) error {
+	if ute, ok := err.(*json.UnmarshalTypeError); ok {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Unmarshal type error: expected=%v, got=%v, field=%v, offset=%v", ute.Type, ute.Value, ute.Field, ute.Offset)).SetInternal(err)
+	} else if se, ok := err.(*json.SyntaxError); ok {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Syntax error: offset=%v, error=%v", se.Offset, se.Error())).SetInternal(err)
+	}
+	return err
+}
+
+// renameKeys rewrites the object keys of the JSON document, keeping the order of the fields
+func renameKeys(b []byte, rename func(string) string) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var out bytes.Buffer
+	if err := renameValue(dec, &out, rename); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func renameValue(dec *json.Decoder, out *bytes.Buffer, rename func(string) string) error {
+
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch tok := tok.(type) {
+	case json.Delim:
+		out.WriteRune(rune(tok))
+		for first := true; dec.More(); first = false {
+			if !first {
+				out.WriteByte(',')
+			}
+			if tok == '{' {
+				key, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				writeJSON(out, rename(key.(string)))
+				out.WriteByte(':')
+			}
+			if err := renameValue(dec, out, rename); err != nil {
+				return err
+			}
+		}
+		end, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		out.WriteRune(rune(end.(json.Delim)))
+	case json.Number:
+		out.WriteString(tok.String())
+	default:
+		writeJSON(out, tok)
+	}
+	return nil
+}
+
+func writeJSON(out *bytes.Buffer, v interface{}) {
+	// strings, booleans and null cannot fail to be encoded
+	b, _ := json.Marshal(v)
+	out.Write(b)
+}
+
+// snakeToCamel renames full_name to fullName, leading underscores like in _links are kept
+func snakeToCamel(name string) string {
+	var b []byte
+	for i := 0; i < len(name); i++ {
+		if name[i] == '_' && i > 0 && i+1 < len(name) && name[i+1] >= 'a' && name[i+1] <= 'z' {
+			if b == nil {
+				b = []byte(name[:i])
+			}
+			i++
+			b = append(b, name[i]-'a'+'A')
+			continue
+		}
+		if b != nil {
+			b = append(b, name[i])
+		}
+	}
+	if b == nil {
+		return name
+	}
+	return string(b)
+}
+
+// camelToSnake renames fullName to full_name, the acronyms stay together: userID becomes user_id
+func camelToSnake(name string) string {
+	runes := []rune(name)
+	var b []byte
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (!unicode.IsUpper(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) && runes[i-1] != '_' {
+				b = append(b, '_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b = utf8.AppendRune(b, r)
+	}
+	return string(b)
+}
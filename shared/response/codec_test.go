@@ -0,0 +1,113 @@
+package response
+
+import (
+	"go-hex/configs"
+	"go-hex/shared/ierr"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type codecDTO struct {
+	AccessToken string `json:"access_token"`
+	FullName    string `json:"full_name"`
+	Links       string `json:"_links,omitempty"`
+}
+
+func serialize(t *testing.T, codec *Codec, i interface{}) string {
+	e := echo.New()
+	e.JSONSerializer = codec
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	require.NoError(t, c.JSON(http.StatusOK, i))
+	return rec.Body.String()
+}
+
+func TestCodecSerialize(t *testing.T) {
+
+	res := Response{Success: true, Message: "token issued", Data: codecDTO{AccessToken: "token", FullName: "Jane", Links: "kept"}}
+	errRes := ErrorResponse{Success: false, Message: "invalid token", ErrorCode: "401001"}
+
+	tests := []struct {
+		name     string
+		naming   configs.JSONNaming
+		envelope configs.JSONEnvelope
+		value    interface{}
+		want     string
+	}{
+		{
+			name: "legacy snake_case", naming: configs.JSONNamingSnakeCase, envelope: configs.JSONEnvelopeLegacy, value: res,
+			want: `{"success":true,"message":"token issued","data":{"access_token":"token","full_name":"Jane","_links":"kept"}}`,
+		},
+		{
+			name: "legacy camelCase", naming: configs.JSONNamingCamelCase, envelope: configs.JSONEnvelopeLegacy, value: errRes,
+			want: `{"success":false,"message":"invalid token","errorCode":"401001"}`,
+		},
+		{
+			name: "data envelope camelCase", naming: configs.JSONNamingCamelCase, envelope: configs.JSONEnvelopeData, value: res,
+			want: `{"data":{"accessToken":"token","fullName":"Jane","_links":"kept"},"meta":{"message":"token issued"}}`,
+		},
+		{
+			name: "data envelope error", naming: configs.JSONNamingSnakeCase, envelope: configs.JSONEnvelopeData, value: errRes,
+			want: `{"error":{"code":"401001","message":"invalid token"}}`,
+		},
+		{
+			name: "data envelope error with reason", naming: configs.JSONNamingSnakeCase, envelope: configs.JSONEnvelopeData, value: ErrUnauthorized(ierr.ErrExpiredToken),
+			want: `{"error":{"code":"400028","message":"token has expired","reason":"token_expired"}}`,
+		},
+		{
+			name: "data envelope error locked until", naming: configs.JSONNamingCamelCase, envelope: configs.JSONEnvelopeData,
+			value: HTTPError(ierr.Limited(ierr.ErrTooManyRequests, time.Date(2026, 10, 15, 12, 0, 30, 0, time.UTC)), http.StatusTooManyRequests, ierr.ErrTooManyRequests.Code, ierr.ErrTooManyRequests.Message),
+			want:  `{"error":{"code":"429000","message":"too many requests, please try again later","lockedUntil":"2026-10-15T12:00:30Z"}}`,
+		},
+		{
+			name: "data envelope of a plain value", naming: configs.JSONNamingSnakeCase, envelope: configs.JSONEnvelopeData, value: map[string]int{"count": 1},
+			want: `{"data":{"count":1}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want+"\n", serialize(t, NewCodec(tt.naming, tt.envelope), tt.value))
+		})
+	}
+}
+
+func TestCodecDeserialize(t *testing.T) {
+
+	e := echo.New()
+	e.JSONSerializer = NewCodec(configs.JSONNamingCamelCase, configs.JSONEnvelopeLegacy)
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"accessToken":"token","full_name":"Jane"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	c := e.NewContext(req, httptest.NewRecorder())
+
+	var dto codecDTO
+	require.NoError(t, c.Bind(&dto))
+	assert.Equal(t, codecDTO{AccessToken: "token", FullName: "Jane"}, dto)
+
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"accessToken":`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	c = e.NewContext(req, httptest.NewRecorder())
+	err := c.Bind(&dto)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code)
+}
+
+func TestCamelToSnake(t *testing.T) {
+	for name, want := range map[string]string{
+		"fullName":     "full_name",
+		"userID":       "user_id",
+		"HTTPCode":     "http_code",
+		"already_done": "already_done",
+		"_links":       "_links",
+	} {
+		assert.Equal(t, want, camelToSnake(name), name)
+	}
+}
@@ -0,0 +1,151 @@
+package response
+
+import (
+	"go-hex/shared/ierr"
+	"go-hex/shared/pb"
+	"io"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// Media types of the binary encodings
+const (
+	MediaTypeMsgPack  = "application/msgpack"
+	MediaTypeProtobuf = "application/x-protobuf"
+)
+
+// Encoding is a wire encoding other than JSON, used both for the responses and the request bodies
+type Encoding interface {
+	// MediaType is the media type negotiated through the Accept and Content-Type headers
+	MediaType() string
+	// Encode writes the response value
+	Encode(w io.Writer, i interface{}) error
+	// Decode reads the request body into the request DTO
+	Decode(r io.Reader, i interface{}) error
+}
+
+// ProtoMapper is a response DTO answered as a protobuf message
+type ProtoMapper interface {
+	ToProto() proto.Message
+}
+
+// ProtoRequest is a request DTO read from a protobuf message
+type ProtoRequest interface {
+	// NewProto returns an empty message of the request
+	NewProto() proto.Message
+	// FromProto fills the request from the decoded message
+	FromProto(m proto.Message)
+}
+
+// MsgPack encodes the responses in MessagePack. The values are encoded with their json tags
+// inside the envelope of the codec, the naming strategy of the codec only applies to JSON.
+type MsgPack struct {
+	codec *Codec
+}
+
+var _ Encoding = MsgPack{}
+
+// NewMsgPack creates the MessagePack encoding enveloping the responses like the codec
+func NewMsgPack(codec *Codec) MsgPack {
+	return MsgPack{codec}
+}
+
+// MediaType implements Encoding
+func (MsgPack) MediaType() string {
+	return MediaTypeMsgPack
+}
+
+// Encode implements Encoding
+func (e MsgPack) Encode(w io.Writer, i interface{}) error {
+	enc := msgpack.NewEncoder(w)
+	enc.SetCustomStructTag("json")
+	enc.UseCompactInts(true)
+	return enc.Encode(e.codec.envelope(i))
+}
+
+// Decode implements Encoding
+func (MsgPack) Decode(r io.Reader, i interface{}) error {
+	dec := msgpack.NewDecoder(r)
+	dec.SetCustomStructTag("json")
+	if err := dec.Decode(i); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "cannot decode msgpack body: "+err.Error()).SetInternal(err)
+	}
+	return nil
+}
+
+// Protobuf encodes the responses as a pb.Response holding the message of the DTO, only the DTOs
+// implementing ProtoMapper can be answered and only the requests implementing ProtoRequest can be read.
+type Protobuf struct{}
+
+var _ Encoding = Protobuf{}
+
+// MediaType implements Encoding
+func (Protobuf) MediaType() string {
+	return MediaTypeProtobuf
+}
+
+// Encode implements Encoding
+func (Protobuf) Encode(w io.Writer, i interface{}) error {
+
+	var res pb.Response
+	switch v := i.(type) {
+	case ErrorResponse:
+		res.Message = v.Message
+		res.ErrorCode = v.ErrorCode
+	case Response:
+		res.Success = v.Success
+		res.Message = v.Message
+		if mapper, ok := v.Data.(ProtoMapper); ok {
+			data, err := anypb.New(mapper.ToProto())
+			if err != nil {
+				return err
+			}
+			res.Data = data
+		} else if !isEmptyData(v.Data) {
+			return HTTPError(errors.Errorf("%T has no protobuf message", v.Data), http.StatusNotAcceptable, ierr.ErrNotAcceptable.Code, ierr.ErrNotAcceptable.Message)
+		}
+	default:
+		return HTTPError(errors.Errorf("%T has no protobuf message", i), http.StatusNotAcceptable, ierr.ErrNotAcceptable.Code, ierr.ErrNotAcceptable.Message)
+	}
+
+	b, err := proto.Marshal(&res)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// Decode implements Encoding
+func (Protobuf) Decode(r io.Reader, i interface{}) error {
+
+	req, ok := i.(ProtoRequest)
+	if !ok {
+		return HTTPError(errors.Errorf("%T has no protobuf message", i), http.StatusUnsupportedMediaType, ierr.ErrUnsupportedMedia.Code, ierr.ErrUnsupportedMedia.Message)
+	}
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	m := req.NewProto()
+	if err := proto.Unmarshal(b, m); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "cannot decode protobuf body: "+err.Error()).SetInternal(err)
+	}
+	req.FromProto(m)
+	return nil
+}
+
+// isEmptyData checks whether the response has no data, Success answers an empty map then
+func isEmptyData(data interface{}) bool {
+	if data == nil {
+		return true
+	}
+	m, ok := data.(map[string]interface{})
+	return ok && len(m) == 0
+}
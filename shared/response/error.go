@@ -3,6 +3,7 @@ package response
 import (
 	"go-hex/shared/ierr"
 	"net/http"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -13,7 +14,10 @@ type ErrorResponse struct {
 	Success   bool   `json:"success" example:"false"`
 	Message   string `json:"message"`
 	ErrorCode string `json:"error_code,omitempty"`
-	Internal  error  `json:"-"`
+	Reason    string `json:"reason,omitempty"` // the reason of the 401 and 403 responses
+	// LockedUntil is the time until which the request is refused, by a rate limit or a lockout
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+	Internal    error      `json:"-"`
 }
 
 // Error is required by the error interface.
@@ -76,10 +80,12 @@ func ErrUnauthorized(err error) ErrorResponse {
 	}
 
 	return ErrorResponse{
-		HTTPCode:  http.StatusUnauthorized,
-		Message:   errorMessage,
-		ErrorCode: errorCode,
-		Internal:  err,
+		HTTPCode:    http.StatusUnauthorized,
+		Message:     errorMessage,
+		ErrorCode:   errorCode,
+		Reason:      reason(http.StatusUnauthorized, originalErr),
+		LockedUntil: lockedUntil(err),
+		Internal:    err,
 	}
 }
 
@@ -103,10 +109,12 @@ func ErrForbidden(err error) ErrorResponse {
 	}
 
 	return ErrorResponse{
-		HTTPCode:  http.StatusForbidden,
-		Message:   errorMessage,
-		ErrorCode: errorCode,
-		Internal:  err,
+		HTTPCode:    http.StatusForbidden,
+		Message:     errorMessage,
+		ErrorCode:   errorCode,
+		Reason:      reason(http.StatusForbidden, originalErr),
+		LockedUntil: lockedUntil(err),
+		Internal:    err,
 	}
 }
 
@@ -157,10 +165,11 @@ func ErrBadRequest(err error) ErrorResponse {
 	}
 
 	return ErrorResponse{
-		HTTPCode:  http.StatusBadRequest,
-		Message:   errorMessage,
-		ErrorCode: errorCode,
-		Internal:  err,
+		HTTPCode:    http.StatusBadRequest,
+		Message:     errorMessage,
+		ErrorCode:   errorCode,
+		LockedUntil: lockedUntil(err),
+		Internal:    err,
 	}
 }
 
@@ -171,9 +180,11 @@ func HTTPError(err error, statusCode int, errorCode string, message string) Erro
 	}
 
 	return ErrorResponse{
-		HTTPCode:  statusCode,
-		Message:   message,
-		ErrorCode: errorCode,
-		Internal:  err,
+		HTTPCode:    statusCode,
+		Message:     message,
+		ErrorCode:   errorCode,
+		Reason:      reason(statusCode, err),
+		LockedUntil: lockedUntil(err),
+		Internal:    err,
 	}
 }
@@ -0,0 +1,92 @@
+package response
+
+import "net/http"
+
+// HAL renders the responses as HAL documents (https://stateless.group/hal_specification.html):
+// the resources keep their fields next to their _links, the collections are answered in _embedded
+// under their resource type. HAL defines no errors, they are answered in the envelope of the codec.
+type HAL struct {
+	codec *Codec
+}
+
+var _ Format = HAL{}
+
+// NewHAL creates the HAL format, its errors are rendered by the codec
+func NewHAL(codec *Codec) HAL {
+	return HAL{codec}
+}
+
+type halLink struct {
+	Href string `json:"href"`
+}
+
+// MediaType implements Format
+func (HAL) MediaType() string {
+	return MediaTypeHAL
+}
+
+// Document implements Format
+func (f HAL) Document(req *http.Request, i interface{}) (interface{}, error) {
+
+	res, ok := i.(Response)
+	if !ok {
+		return f.codec.envelope(i), nil
+	}
+
+	links := map[string]string{"self": selfLink(req)}
+	data := res.Data
+	if page, ok := data.(Page); ok {
+		data = page.Items
+		links = page.links(req)
+	}
+
+	if resource, ok := data.(Resource); ok {
+		return f.resource(resource, selfLink(req))
+	}
+
+	doc := map[string]interface{}{}
+	if typ, items, ok := resources(data); ok {
+		embedded := make([]map[string]interface{}, 0, len(items))
+		for _, item := range items {
+			object, err := f.resource(item, "")
+			if err != nil {
+				return nil, err
+			}
+			embedded = append(embedded, object)
+		}
+		doc["_embedded"] = map[string]interface{}{typ: embedded}
+	} else if data != nil {
+		fields, err := objectFields(data)
+		if err != nil {
+			return nil, err
+		}
+		if fields == nil {
+			doc["result"] = data
+		}
+		for name, value := range fields {
+			doc[name] = value
+		}
+	}
+	doc["_links"] = halLinks(links)
+	return doc, nil
+}
+
+func (HAL) resource(resource Resource, self string) (map[string]interface{}, error) {
+	fields, err := attributes(resource)
+	if err != nil {
+		return nil, err
+	}
+	fields["id"] = resource.ResourceID()
+	if links := resourceLinks(resource, self); len(links) > 0 {
+		fields["_links"] = halLinks(links)
+	}
+	return fields, nil
+}
+
+func halLinks(links map[string]string) map[string]halLink {
+	out := make(map[string]halLink, len(links))
+	for rel, href := range links {
+		out[rel] = halLink{href}
+	}
+	return out
+}
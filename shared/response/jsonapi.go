@@ -0,0 +1,117 @@
+package response
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// JSONAPI renders the responses as JSON:API documents (https://jsonapi.org): the resources become
+// resource objects with their links, the other data is answered in the meta of the document.
+type JSONAPI struct{}
+
+var _ Format = JSONAPI{}
+
+type jsonapiDocument struct {
+	Data   interface{}       `json:"data,omitempty"`
+	Errors []jsonapiError    `json:"errors,omitempty"`
+	Links  map[string]string `json:"links,omitempty"`
+	Meta   *jsonapiMeta      `json:"meta,omitempty"`
+}
+
+type jsonapiResource struct {
+	Type       string                 `json:"type"`
+	ID         string                 `json:"id"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+	Links      map[string]string      `json:"links,omitempty"`
+}
+
+type jsonapiError struct {
+	Status string            `json:"status"`
+	Code   string            `json:"code,omitempty"`
+	Detail string            `json:"detail"`
+	Meta   *jsonapiErrorMeta `json:"meta,omitempty"`
+}
+
+type jsonapiErrorMeta struct {
+	Reason      string     `json:"reason,omitempty"`
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+}
+
+type jsonapiMeta struct {
+	Message string      `json:"message,omitempty"`
+	Page    *pageMeta   `json:"page,omitempty"`
+	Result  interface{} `json:"result,omitempty"`
+}
+
+// MediaType implements Format
+func (JSONAPI) MediaType() string {
+	return MediaTypeJSONAPI
+}
+
+// Document implements Format
+func (f JSONAPI) Document(req *http.Request, i interface{}) (interface{}, error) {
+
+	switch res := i.(type) {
+	case ErrorResponse:
+		jsonapiErr := jsonapiError{
+			Status: strconv.Itoa(res.HTTPCode),
+			Code:   res.ErrorCode,
+			Detail: res.Message,
+		}
+		if res.Reason != "" || res.LockedUntil != nil {
+			jsonapiErr.Meta = &jsonapiErrorMeta{Reason: res.Reason, LockedUntil: res.LockedUntil}
+		}
+		return jsonapiDocument{Errors: []jsonapiError{jsonapiErr}}, nil
+	case Response:
+		doc := jsonapiDocument{
+			Links: map[string]string{"self": selfLink(req)},
+			Meta:  &jsonapiMeta{Message: res.Message},
+		}
+		data := res.Data
+		if page, ok := data.(Page); ok {
+			data = page.Items
+			doc.Links = page.links(req)
+			doc.Meta.Page = page.meta()
+		}
+
+		if resource, ok := data.(Resource); ok {
+			object, err := f.resource(resource, selfLink(req))
+			if err != nil {
+				return nil, err
+			}
+			doc.Data = object
+		} else if _, items, ok := resources(data); ok {
+			objects := make([]jsonapiResource, 0, len(items))
+			for _, item := range items {
+				object, err := f.resource(item, "")
+				if err != nil {
+					return nil, err
+				}
+				objects = append(objects, object)
+			}
+			doc.Data = objects
+		} else {
+			doc.Meta.Result = data
+		}
+		return doc, nil
+	}
+	return jsonapiDocument{Meta: &jsonapiMeta{Result: i}}, nil
+}
+
+func (JSONAPI) resource(resource Resource, self string) (jsonapiResource, error) {
+	fields, err := attributes(resource)
+	if err != nil {
+		return jsonapiResource{}, err
+	}
+	links := resourceLinks(resource, self)
+	if len(links) == 0 {
+		links = nil
+	}
+	return jsonapiResource{
+		Type:       resource.ResourceType(),
+		ID:         resource.ResourceID(),
+		Attributes: fields,
+		Links:      links,
+	}, nil
+}
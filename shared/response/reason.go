@@ -0,0 +1,84 @@
+package response
+
+import (
+	"fmt"
+	"go-hex/shared/ierr"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// The reasons of the 401 and 403 responses, answered in their reason field and in their WWW-Authenticate header
+// so that the clients react to them without parsing the messages
+const (
+	ReasonInvalidToken       = "invalid_token"
+	ReasonInvalidCredentials = "invalid_credentials"
+	ReasonTokenExpired       = "token_expired"
+	ReasonTokenRevoked       = "token_revoked"
+	ReasonInsufficientScope  = "insufficient_scope"
+	ReasonMFARequired        = "mfa_required"
+	ReasonAccountLocked      = "account_locked"
+	ReasonForbidden          = "forbidden"
+)
+
+// reasons are the reasons of the errors answered with a 401 or a 403, the others get the reason of their status
+var reasons = map[ierr.Error]string{
+	ierr.ErrInvalidToken:           ReasonInvalidToken,
+	ierr.ErrInvalidCreds:           ReasonInvalidCredentials,
+	ierr.ErrSocialAccountUnlinked:  ReasonInvalidCredentials,
+	ierr.ErrExpiredToken:           ReasonTokenExpired,
+	ierr.ErrTokenRevoked:           ReasonTokenRevoked,
+	ierr.ErrForbidden:              ReasonInsufficientScope,
+	ierr.ErrLoginApprovalPending:   ReasonMFARequired,
+	ierr.ErrUserIsNotActive:        ReasonAccountLocked,
+	ierr.ErrServiceAccountDisabled: ReasonAccountLocked,
+}
+
+// bearerErrors are the error codes of RFC 6750 and RFC 9470 challenging the bearer tokens of the reasons,
+// the reasons of the other 403 are insufficient_scope and those of the other 401 invalid_token
+var bearerErrors = map[string]string{
+	ReasonInsufficientScope: "insufficient_scope",
+	ReasonMFARequired:       "insufficient_user_authentication",
+}
+
+// reason returns the reason of the error answered with the status, empty unless a 401 or a 403
+func reason(statusCode int, err error) string {
+	if statusCode != http.StatusUnauthorized && statusCode != http.StatusForbidden {
+		return ""
+	}
+	if val, ok := errors.Cause(err).(ierr.Error); ok {
+		if reason, ok := reasons[val]; ok {
+			return reason
+		}
+	}
+	if statusCode == http.StatusForbidden {
+		return ReasonForbidden
+	}
+	return ReasonInvalidToken
+}
+
+// Challenge returns the WWW-Authenticate header of the 401 and 403 responses, a Bearer challenge with the error
+// code of RFC 6750, the message and the reason, e.g.
+//
+//	Bearer error="invalid_token", error_description="token has expired", reason="token_expired"
+//
+// It is empty for the other responses.
+func (e ErrorResponse) Challenge() string {
+	if e.Reason == "" {
+		return ""
+	}
+	code, ok := bearerErrors[e.Reason]
+	if !ok {
+		code = "invalid_token"
+		if e.HTTPCode == http.StatusForbidden {
+			code = "insufficient_scope"
+		}
+	}
+	return fmt.Sprintf(`Bearer error="%s", error_description="%s", reason="%s"`, code, quoted(e.Message), e.Reason)
+}
+
+// quoted escapes the quotes and backslashes of a quoted-string
+func quoted(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
@@ -0,0 +1,77 @@
+package response
+
+import (
+	"go-hex/shared/ierr"
+	"net/http"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReason(t *testing.T) {
+
+	tests := []struct {
+		name          string
+		res           ErrorResponse
+		wantReason    string
+		wantChallenge string
+	}{
+		{
+			name:          "expired token",
+			res:           ErrUnauthorized(ierr.ErrExpiredToken),
+			wantReason:    ReasonTokenExpired,
+			wantChallenge: `Bearer error="invalid_token", error_description="token has expired", reason="token_expired"`,
+		},
+		{
+			name:          "revoked token wrapped",
+			res:           ErrUnauthorized(errors.Wrap(ierr.ErrTokenRevoked, "session ended")),
+			wantReason:    ReasonTokenRevoked,
+			wantChallenge: `Bearer error="invalid_token", error_description="token has been revoked", reason="token_revoked"`,
+		},
+		{
+			name:          "permission not granted",
+			res:           ErrForbidden(ierr.ErrForbidden),
+			wantReason:    ReasonInsufficientScope,
+			wantChallenge: `Bearer error="insufficient_scope", error_description="you don't have access to this resource", reason="insufficient_scope"`,
+		},
+		{
+			name:          "login waiting for approval",
+			res:           ErrForbidden(ierr.ErrLoginApprovalPending),
+			wantReason:    ReasonMFARequired,
+			wantChallenge: `Bearer error="insufficient_user_authentication", error_description="login is waiting for approval", reason="mfa_required"`,
+		},
+		{
+			name:          "inactive user",
+			res:           ErrForbidden(ierr.ErrUserIsNotActive),
+			wantReason:    ReasonAccountLocked,
+			wantChallenge: `Bearer error="insufficient_scope", error_description="user is not active", reason="account_locked"`,
+		},
+		{
+			name:          "invalid token",
+			res:           HTTPError(errors.New("signature is invalid"), http.StatusUnauthorized, ierr.ErrUnauthorized.Code, `not "authorized"`),
+			wantReason:    ReasonInvalidToken,
+			wantChallenge: `Bearer error="invalid_token", error_description="not \"authorized\"", reason="invalid_token"`,
+		},
+		{
+			name:       "other forbidden error",
+			res:        ErrForbidden(ierr.ErrSessionLimitReached),
+			wantReason: ReasonForbidden,
+		},
+		{
+			name: "not a 401 or 403",
+			res:  ErrBadRequest(ierr.ErrExpiredToken),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantReason, tt.res.Reason)
+			if tt.wantChallenge != "" {
+				assert.Equal(t, tt.wantChallenge, tt.res.Challenge())
+			}
+			if tt.wantReason == "" {
+				assert.Empty(t, tt.res.Challenge())
+			}
+		})
+	}
+}
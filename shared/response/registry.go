@@ -0,0 +1,160 @@
+package response
+
+import (
+	"bytes"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Media types of the output formats
+const (
+	MediaTypeJSON    = echo.MIMEApplicationJSON
+	MediaTypeJSONAPI = "application/vnd.api+json"
+	MediaTypeHAL     = "application/hal+json"
+)
+
+// Format renders the response values as the documents of a media type
+type Format interface {
+	// MediaType is the media type negotiated through the Accept header
+	MediaType() string
+	// Document returns the document answering the response value to the request
+	Document(req *http.Request, i interface{}) (interface{}, error)
+}
+
+// Registry negotiates the output format of each response from its Accept header, the plain JSON
+// format of the codec is answered when no registered format is accepted. Every format is written
+// by the codec, so that the naming strategy applies to all of them. The registered encodings
+// replace JSON altogether: they write the responses accepting them and read the request bodies of their content type.
+type Registry struct {
+	codec     *Codec
+	binder    echo.DefaultBinder
+	formats   map[string]Format
+	encodings map[string]Encoding
+}
+
+var (
+	_ echo.JSONSerializer = (*Registry)(nil)
+	_ echo.Binder         = (*Registry)(nil)
+)
+
+// NewRegistry creates a registry answering the given formats besides the plain JSON of the codec
+func NewRegistry(codec *Codec, formats ...Format) *Registry {
+	r := &Registry{
+		codec:     codec,
+		formats:   make(map[string]Format, len(formats)),
+		encodings: map[string]Encoding{},
+	}
+	for _, format := range formats {
+		r.formats[format.MediaType()] = format
+	}
+	return r
+}
+
+// WithEncodings registers the given encodings besides JSON
+func (r *Registry) WithEncodings(encodings ...Encoding) *Registry {
+	for _, encoding := range encodings {
+		r.encodings[encoding.MediaType()] = encoding
+	}
+	return r
+}
+
+// Serialize implements echo.JSONSerializer
+func (r *Registry) Serialize(ctx echo.Context, i interface{}, indent string) error {
+
+	mediaType := r.negotiate(ctx.Request().Header.Get(echo.HeaderAccept))
+	if encoding, ok := r.encodings[mediaType]; ok {
+		ctx.Response().Header().Set(echo.HeaderContentType, mediaType)
+		var buf bytes.Buffer
+		if err := encoding.Encode(&buf, i); err != nil {
+			return err
+		}
+		_, err := ctx.Response().Write(buf.Bytes())
+		return err
+	}
+
+	format, ok := r.formats[mediaType]
+	if !ok {
+		return r.codec.Serialize(ctx, i, indent)
+	}
+	document, err := format.Document(ctx.Request(), i)
+	if err != nil {
+		return err
+	}
+	ctx.Response().Header().Set(echo.HeaderContentType, mediaType)
+	return r.codec.write(ctx, document, indent)
+}
+
+// Deserialize implements echo.JSONSerializer
+func (r *Registry) Deserialize(ctx echo.Context, i interface{}) error {
+	return r.codec.Deserialize(ctx, i)
+}
+
+// Bind implements echo.Binder, the request bodies in a registered encoding are read by the encoding
+// and the other ones by echo.DefaultBinder.
+func (r *Registry) Bind(i interface{}, ctx echo.Context) error {
+
+	req := ctx.Request()
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get(echo.HeaderContentType))
+	encoding, ok := r.encodings[mediaType]
+	if !ok || req.ContentLength == 0 {
+		return r.binder.Bind(i, ctx)
+	}
+
+	if err := r.binder.BindPathParams(ctx, i); err != nil {
+		return err
+	}
+	if req.Method == http.MethodGet || req.Method == http.MethodDelete || req.Method == http.MethodHead {
+		if err := r.binder.BindQueryParams(ctx, i); err != nil {
+			return err
+		}
+	}
+	return encoding.Decode(req.Body, i)
+}
+
+// negotiate returns the registered media type with the highest quality in the Accept header,
+// an empty media type is returned when plain JSON or no registered media type is preferred.
+func (r *Registry) negotiate(accept string) string {
+
+	type acceptedType struct {
+		mediaType string
+		quality   float64
+	}
+
+	var accepted []acceptedType
+	for _, item := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(item))
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		if quality > 0 {
+			accepted = append(accepted, acceptedType{mediaType, quality})
+		}
+	}
+	sort.SliceStable(accepted, func(i, j int) bool {
+		return accepted[i].quality > accepted[j].quality
+	})
+
+	for _, a := range accepted {
+		if a.mediaType == MediaTypeJSON {
+			return ""
+		}
+		if _, ok := r.formats[a.mediaType]; ok {
+			return a.mediaType
+		}
+		if _, ok := r.encodings[a.mediaType]; ok {
+			return a.mediaType
+		}
+	}
+	return ""
+}
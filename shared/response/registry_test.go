@@ -0,0 +1,108 @@
+package response
+
+import (
+	"go-hex/configs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testResource struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func (r testResource) ResourceType() string { return "things" }
+func (r testResource) ResourceID() string   { return r.ID }
+func (r testResource) ResourceLinks() map[string]string {
+	return map[string]string{"self": "/things/" + r.ID}
+}
+
+func negotiated(t *testing.T, accept, target string, i interface{}) (string, string) {
+	codec := NewCodec(configs.JSONNamingSnakeCase, configs.JSONEnvelopeLegacy)
+	e := echo.New()
+	e.JSONSerializer = NewRegistry(codec, JSONAPI{}, NewHAL(codec))
+
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	req.Header.Set(echo.HeaderAccept, accept)
+	rec := httptest.NewRecorder()
+	require.NoError(t, e.NewContext(req, rec).JSON(http.StatusOK, i))
+	return rec.Header().Get(echo.HeaderContentType), rec.Body.String()
+}
+
+func TestRegistryNegotiate(t *testing.T) {
+
+	r := NewRegistry(NewCodec(configs.JSONNamingSnakeCase, configs.JSONEnvelopeLegacy), JSONAPI{})
+
+	assert.Empty(t, r.negotiate(""))
+	assert.Empty(t, r.negotiate("*/*"))
+	assert.Empty(t, r.negotiate("application/hal+json"))
+	assert.Empty(t, r.negotiate("application/json, application/vnd.api+json"))
+	assert.Equal(t, MediaTypeJSONAPI, r.negotiate("application/vnd.api+json"))
+	assert.Equal(t, MediaTypeJSONAPI, r.negotiate("application/json;q=0.5, application/vnd.api+json"))
+	assert.Empty(t, r.negotiate("application/vnd.api+json;q=0, application/json"))
+}
+
+func TestRegistrySerialize(t *testing.T) {
+
+	page := Response{Success: true, Message: "Success", Data: Page{
+		Items:  []testResource{{ID: "1", Name: "first"}},
+		Offset: 10,
+		Limit:  10,
+		More:   true,
+	}}
+
+	tests := []struct {
+		name            string
+		accept          string
+		value           interface{}
+		wantContentType string
+		want            string
+	}{
+		{
+			name: "plain json answers the items of a page", accept: "application/json", value: page,
+			wantContentType: echo.MIMEApplicationJSONCharsetUTF8,
+			want:            `{"success":true,"message":"Success","data":[{"id":"1","name":"first"}]}`,
+		},
+		{
+			name: "json:api resource", accept: MediaTypeJSONAPI, value: Response{Success: true, Message: "Success", Data: testResource{ID: "1", Name: "first"}},
+			wantContentType: MediaTypeJSONAPI,
+			want:            `{"data":{"type":"things","id":"1","attributes":{"name":"first"},"links":{"self":"/things/1"}},"links":{"self":"/things?limit=10"},"meta":{"message":"Success"}}`,
+		},
+		{
+			name: "json:api page", accept: MediaTypeJSONAPI, value: page,
+			wantContentType: MediaTypeJSONAPI,
+			want: `{"data":[{"type":"things","id":"1","attributes":{"name":"first"},"links":{"self":"/things/1"}}],` +
+				`"links":{"next":"/things?limit=10\u0026offset=20","prev":"/things?limit=10\u0026offset=0","self":"/things?limit=10\u0026offset=10"},` +
+				`"meta":{"message":"Success","page":{"offset":10,"limit":10,"more":true}}}`,
+		},
+		{
+			name: "json:api error", accept: MediaTypeJSONAPI, value: ErrorResponse{HTTPCode: http.StatusNotFound, Message: "resource not found", ErrorCode: "404000"},
+			wantContentType: MediaTypeJSONAPI,
+			want:            `{"errors":[{"status":"404","code":"404000","detail":"resource not found"}]}`,
+		},
+		{
+			name: "hal page", accept: MediaTypeHAL, value: page,
+			wantContentType: MediaTypeHAL,
+			want: `{"_embedded":{"things":[{"_links":{"self":{"href":"/things/1"}},"id":"1","name":"first"}]},` +
+				`"_links":{"next":{"href":"/things?limit=10\u0026offset=20"},"prev":{"href":"/things?limit=10\u0026offset=0"},"self":{"href":"/things?limit=10\u0026offset=10"}}}`,
+		},
+		{
+			name: "hal plain data", accept: MediaTypeHAL, value: Response{Success: true, Message: "Success", Data: map[string]string{"count": "1"}},
+			wantContentType: MediaTypeHAL,
+			want:            `{"_links":{"self":{"href":"/things?limit=10"}},"count":"1"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contentType, body := negotiated(t, tt.accept, "/things?limit=10", tt.value)
+			assert.Equal(t, tt.wantContentType, contentType)
+			assert.Equal(t, tt.want+"\n", body)
+		})
+	}
+}
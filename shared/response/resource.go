@@ -0,0 +1,140 @@
+package response
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+)
+
+// Resource is a response value identified by a type and an ID, the hypermedia formats
+// render it as a resource object instead of a plain JSON object.
+type Resource interface {
+	ResourceType() string
+	ResourceID() string
+}
+
+// ResourceLinker is a resource linking to itself and to its related resources, by relation
+type ResourceLinker interface {
+	ResourceLinks() map[string]string
+}
+
+// Page is a page of a collection, its items are answered as the data of the response
+// and the hypermedia formats link the neighbouring pages.
+type Page struct {
+	Items  interface{}
+	Offset int
+	Limit  int
+	// More tells whether another page follows this one
+	More bool
+}
+
+// pageMeta describes the page in the data envelope
+type pageMeta struct {
+	Offset int  `json:"offset"`
+	Limit  int  `json:"limit"`
+	More   bool `json:"more"`
+}
+
+func (p Page) meta() *pageMeta {
+	return &pageMeta{Offset: p.Offset, Limit: p.Limit, More: p.More}
+}
+
+// links returns the links to the current, previous and next pages
+func (p Page) links(req *http.Request) map[string]string {
+	links := map[string]string{"self": pageURL(req, p.Offset, p.Limit)}
+	if p.Offset > 0 {
+		prev := p.Offset - p.Limit
+		if prev < 0 {
+			prev = 0
+		}
+		links["prev"] = pageURL(req, prev, p.Limit)
+	}
+	if p.More {
+		links["next"] = pageURL(req, p.Offset+p.Limit, p.Limit)
+	}
+	return links
+}
+
+func pageURL(req *http.Request, offset, limit int) string {
+	u := url.URL{Path: req.URL.Path}
+	query := req.URL.Query()
+	query.Set("offset", strconv.Itoa(offset))
+	query.Set("limit", strconv.Itoa(limit))
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// selfLink returns the link to the requested URL
+func selfLink(req *http.Request) string {
+	return req.URL.RequestURI()
+}
+
+// resources returns the type and the resources of a slice, false is returned when the value is not a slice of resources
+func resources(i interface{}) (string, []Resource, bool) {
+	v := reflect.ValueOf(i)
+	if v.Kind() != reflect.Slice {
+		return "", nil, false
+	}
+	elem := reflect.Zero(v.Type().Elem())
+	if elem.Kind() == reflect.Ptr {
+		elem = reflect.New(elem.Type().Elem())
+	}
+	zero, ok := elem.Interface().(Resource)
+	if !ok {
+		return "", nil, false
+	}
+	items := make([]Resource, 0, v.Len())
+	for n := 0; n < v.Len(); n++ {
+		items = append(items, v.Index(n).Interface().(Resource))
+	}
+	return zero.ResourceType(), items, true
+}
+
+// attributes returns the fields of the resource by name, without its ID
+func attributes(resource Resource) (map[string]interface{}, error) {
+	fields, err := objectFields(resource)
+	if err != nil {
+		return nil, err
+	}
+	if fields == nil {
+		fields = map[string]interface{}{}
+	}
+	delete(fields, "id")
+	return fields, nil
+}
+
+// objectFields returns the fields of the value encoded as a JSON object by name, nil is returned for the other values
+func objectFields(v interface{}) (map[string]interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 || b[0] != '{' {
+		return nil, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var fields map[string]interface{}
+	if err := dec.Decode(&fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// resourceLinks returns the links of the resource, the self link defaults to the given one
+func resourceLinks(resource Resource, self string) map[string]string {
+	links := map[string]string{}
+	if linker, ok := resource.(ResourceLinker); ok {
+		for rel, href := range linker.ResourceLinks() {
+			links[rel] = href
+		}
+	}
+	if _, ok := links["self"]; !ok && self != "" {
+		links["self"] = self
+	}
+	return links
+}
@@ -0,0 +1,29 @@
+package response
+
+import (
+	"go-hex/pkg/times"
+	"go-hex/shared/ierr"
+	"math"
+	"strconv"
+	"time"
+)
+
+// lockedUntil returns the time until which the error refuses the request, nil when it is not limited
+func lockedUntil(err error) *time.Time {
+	until, ok := ierr.LockedUntil(err)
+	if !ok {
+		return nil
+	}
+	until = until.UTC()
+	return &until
+}
+
+// RetryAfter returns the seconds until the request is allowed again, rounded up, for the Retry-After header.
+// It is empty when the request is not refused until a time.
+func (e ErrorResponse) RetryAfter() string {
+	if e.LockedUntil == nil {
+		return ""
+	}
+	seconds := math.Ceil(e.LockedUntil.Sub(times.Now()).Seconds())
+	return strconv.Itoa(int(math.Max(seconds, 0)))
+}
@@ -0,0 +1,28 @@
+package response
+
+import (
+	"go-hex/shared/ierr"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockedUntil(t *testing.T) {
+	until := time.Now().Add(90*time.Second + 100*time.Millisecond)
+	res := HTTPError(ierr.Limited(ierr.ErrTooManyRequests, until), http.StatusTooManyRequests, ierr.ErrTooManyRequests.Code, ierr.ErrTooManyRequests.Message)
+	if assert.NotNil(t, res.LockedUntil) {
+		assert.True(t, until.Equal(*res.LockedUntil))
+	}
+	assert.Equal(t, "91", res.RetryAfter(), "rounded up to the second")
+
+	res = ErrBadRequest(errors.WithStack(ierr.Limited(ierr.ErrDeviceLoginSlowDown, time.Now().Add(-time.Second))))
+	assert.Equal(t, ierr.ErrDeviceLoginSlowDown.Code, res.ErrorCode)
+	assert.Equal(t, "0", res.RetryAfter(), "never negative")
+
+	res = ErrBadRequest(ierr.ErrDeviceLoginSlowDown)
+	assert.Nil(t, res.LockedUntil)
+	assert.Empty(t, res.RetryAfter())
+}
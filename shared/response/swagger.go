@@ -12,6 +12,7 @@ type ErrorResponse401 struct {
 	Success   bool   `json:"success" example:"false"`
 	Message   string `json:"message" example:"you are not authorized to perform the requested action"`
 	ErrorCode string `json:"error_code,omitempty" example:"00003"`
+	Reason    string `json:"reason,omitempty" example:"token_expired" enums:"invalid_token,invalid_credentials,token_expired,token_revoked,mfa_required,account_locked"`
 } //@name Unauthorized
 
 // ErrorResponse403 example for swagger doc
@@ -19,6 +20,7 @@ type ErrorResponse403 struct {
 	Success   bool   `json:"success" example:"false"`
 	Message   string `json:"message" example:"you don't have access to this resource"`
 	ErrorCode string `json:"error_code,omitempty" example:"00004"`
+	Reason    string `json:"reason,omitempty" example:"insufficient_scope" enums:"forbidden,insufficient_scope,mfa_required,account_locked"`
 } //@name Forbidden
 
 // ErrorResponseWrongOTPCode example for swagger doc
@@ -35,9 +37,31 @@ type ErrorResponse404 struct {
 	ErrorCode string `json:"error_code,omitempty" example:"00001"`
 } //@name Not Found
 
+// ErrorResponse409 example for swagger doc
+type ErrorResponse409 struct {
+	Success   bool   `json:"success" example:"false"`
+	Message   string `json:"message" example:"the resource already exists"`
+	ErrorCode string `json:"error_code,omitempty" example:"409000"`
+} //@name Conflict
+
+// ErrorResponse429 example for swagger doc
+type ErrorResponse429 struct {
+	Success     bool   `json:"success" example:"false"`
+	Message     string `json:"message" example:"too many requests, please try again later"`
+	ErrorCode   string `json:"error_code,omitempty" example:"429000"`
+	LockedUntil string `json:"locked_until,omitempty" example:"2026-10-15T12:00:30Z"`
+} //@name Too Many Requests
+
 // ErrorResponse500 example for swagger doc
 type ErrorResponse500 struct {
 	Success   bool   `json:"success" example:"false"`
 	Message   string `json:"message" example:"we encountered an error while processing your request (internal server error)"`
 	ErrorCode string `json:"error_code,omitempty" example:"00000"`
 } //@name Internal Server Error
+
+// ErrorResponse503 example for swagger doc
+type ErrorResponse503 struct {
+	Success   bool   `json:"success" example:"false"`
+	Message   string `json:"message" example:"the service is overloaded, please try again later"`
+	ErrorCode string `json:"error_code,omitempty" example:"503000"`
+} //@name Service Unavailable
@@ -0,0 +1,83 @@
+package grpc
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/auth"
+	"go-hex/internal/domain"
+	"go-hex/pkg/times"
+	"go-hex/shared/pb"
+	"net"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// authServer serves the auth service, like the routes of /auth
+type authServer struct {
+	pb.UnimplementedAuthServiceServer
+	cfg     *configs.Config
+	service auth.ServicePort
+}
+
+// Login logs in a user, it answers the approval instead of the tokens when the login has to be approved from
+// another device. In strict enumeration mode, it takes as long whether the account exists or not.
+func (s authServer) Login(ctx context.Context, req *pb.LoginRequest) (*pb.LoginResponse, error) {
+	defer times.Envelope(ctx, s.cfg.EnumerationMinDuration())()
+
+	res, err := s.service.Login(ctx, auth.RequestLogin{
+		Username:  req.GetUsername(),
+		Password:  req.GetPassword(),
+		IPAddress: peerIP(ctx),
+		UserAgent: userAgent(ctx),
+		Client:    clientInfo(ctx),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.Approval != nil {
+		return &pb.LoginResponse{Result: &pb.LoginResponse_Approval{Approval: res.Approval.ToProto().(*pb.LoginApproval)}}, nil
+	}
+	return &pb.LoginResponse{Result: &pb.LoginResponse_Login{Login: res.ToProto().(*pb.Login)}}, nil
+}
+
+// RefreshToken rotates the refresh token
+func (s authServer) RefreshToken(ctx context.Context, req *pb.RefreshTokenRequest) (*pb.Login, error) {
+
+	res, err := s.service.RefreshToken(ctx, auth.RequestRefreshToken{RefreshToken: req.GetRefreshToken()})
+	if err != nil {
+		return nil, err
+	}
+	return res.ToProto().(*pb.Login), nil
+}
+
+// peerIP returns the IP address of the client, empty when unknown
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return ""
+	}
+	return host
+}
+
+// userAgent returns the user agent of the client
+func userAgent(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get("user-agent"); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// clientInfo returns the client app of the x-client-info metadata, as the X-Client-Info header of the routes
+func clientInfo(ctx context.Context) domain.ClientInfo {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get("x-client-info"); len(values) > 0 {
+		return domain.ParseClientInfo(values[0])
+	}
+	return domain.ClientInfo{}
+}
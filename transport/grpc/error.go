@@ -0,0 +1,75 @@
+package grpc
+
+import (
+	"context"
+	"go-hex/pkg/times"
+	"go-hex/shared/ierr"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pkg/errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// toStatus maps an error to its gRPC status, the code of the ierr.Error is set as the reason of its ErrorInfo.
+// internal tells whether the error is an internal error, answered without its message.
+func toStatus(err error) (st *status.Status, internal bool) {
+
+	if st, ok := status.FromError(err); ok {
+		return st, false
+	}
+
+	cause := errors.Cause(err)
+	switch {
+	case errors.Is(cause, context.DeadlineExceeded):
+		return status.New(codes.DeadlineExceeded, cause.Error()), false
+	case errors.Is(cause, context.Canceled):
+		return status.New(codes.Canceled, cause.Error()), false
+	}
+
+	switch e := cause.(type) {
+	case validation.Errors:
+		return withReason(status.New(codes.InvalidArgument, e.Error()), ierr.ErrBadRequest.Code), false
+	case validation.Error:
+		return withReason(status.New(codes.InvalidArgument, e.Error()), ierr.ErrBadRequest.Code), false
+	case ierr.Error:
+		// the errors are answered with the gRPC code of their definition
+		if definition, ok := ierr.Lookup(e); ok && definition.GRPCCode != codes.Internal {
+			// the locked accounts and the calls refused by LimitLogins tell until when they are refused
+			if until, limited := ierr.LockedUntil(err); limited {
+				return withLockedUntil(status.New(definition.GRPCCode, e.Message), e.Code, until), false
+			}
+			return withReason(status.New(definition.GRPCCode, e.Message), e.Code), false
+		}
+	}
+	return withReason(status.New(codes.Internal, ierr.ErrInternal.Message), ierr.ErrInternal.Code), true
+}
+
+// withReason adds the code of the error to the details of the status
+func withReason(st *status.Status, code string) *status.Status {
+	detailed, err := st.WithDetails(&errdetails.ErrorInfo{Reason: code, Domain: "go-hex"})
+	if err != nil {
+		return st
+	}
+	return detailed
+}
+
+// withLockedUntil adds the code of the error and the time until which it refuses the call to the details of the
+// status, in the locked_until metadata of its ErrorInfo in RFC 3339, with the delay until then in a RetryInfo
+func withLockedUntil(st *status.Status, code string, until time.Time) *status.Status {
+	delay := until.Sub(times.Now())
+	if delay < 0 {
+		delay = 0
+	}
+	detailed, err := st.WithDetails(
+		&errdetails.ErrorInfo{Reason: code, Domain: "go-hex", Metadata: map[string]string{"locked_until": until.UTC().Format(time.RFC3339Nano)}},
+		&errdetails.RetryInfo{RetryDelay: durationpb.New(delay)},
+	)
+	if err != nil {
+		return st
+	}
+	return detailed
+}
@@ -0,0 +1,201 @@
+package grpc
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/auth"
+	"go-hex/internal/domain"
+	pkgauth "go-hex/pkg/auth"
+	"go-hex/pkg/authz"
+	"go-hex/pkg/logger"
+	pkgotel "go-hex/pkg/otel"
+	"go-hex/pkg/ratelimit"
+	"go-hex/shared/ctxutil"
+	"go-hex/shared/ierr"
+	"go-hex/shared/pb"
+	"net/http"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// permissionPublic is the permission of the methods called without access token
+const permissionPublic = "public"
+
+// methodPermissions declares the permission of every method, like permissions.yaml for the routes,
+// the methods missing from it are refused
+var methodPermissions = map[string]string{
+	"/gohex.v1.AuthService/Login":        permissionPublic,
+	"/gohex.v1.AuthService/RefreshToken": permissionPublic,
+	"/gohex.v1.UserService/CreateUser":   "users:write",
+	"/gohex.v1.UserService/GetUser":      "users:read",
+	"/gohex.v1.UserService/ListUsers":    "users:read",
+	"/gohex.v1.UserService/UpdateUser":   "users:write",
+	"/gohex.v1.UserService/DeleteUser":   "users:write",
+}
+
+// Tracing starts the span of the call from the trace context of its metadata, and sets the request ID of the logs
+func Tracing(appName string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+
+		ctx, span := otel.Tracer(appName).Start(ctx, "[GRPC] "+info.FullMethod, trace.WithSpanKind(trace.SpanKindServer))
+		defer span.End()
+
+		// the request ID, the correlation ID and the locale are read from the metadata as from the headers
+		header := http.Header{}
+		for key, values := range md {
+			for _, value := range values {
+				header.Add(key, value)
+			}
+		}
+		ctx = logger.WithRequest(ctx, &http.Request{Header: header})
+		if locale, ok := ctxutil.PreferredLocale(header.Get("Accept-Language")); ok {
+			ctx = ctxutil.WithLocale(ctx, locale)
+		}
+		requestID, _ := ctxutil.RequestID(ctx)
+		span.SetAttributes(attribute.String("rpc.system", "grpc"), attribute.String("rpc.method", info.FullMethod),
+			attribute.String("request_id", requestID))
+
+		res, err := handler(ctx, req)
+		if err != nil {
+			pkgotel.RecordGRPCError(ctx, err, status.Code(err))
+		}
+		return res, err
+	}
+}
+
+// CurrentConfig carries the current configuration in the context of the call, read with configs.FromContext,
+// as the middleware of the routes does
+func CurrentConfig(current func() *configs.Config) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(configs.WithContext(ctx, current()), req)
+	}
+}
+
+// MapErrors answers the errors of the services with their gRPC status, the internal errors are logged
+func MapErrors(log logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		res, err := handler(ctx, req)
+		if err == nil {
+			return res, nil
+		}
+		st, internal := toStatus(err)
+		if internal {
+			log.With(ctx).WithParam("method", info.FullMethod).Error(err)
+		}
+		return nil, st.Err()
+	}
+}
+
+// LimitLogins limits the login calls per IP address of the peer and per username, in the buckets and with the limits
+// of the configuration of the call shared with the login routes. It runs within MapErrors, which answers the refused
+// calls with ResourceExhausted and the time until the next attempt.
+func LimitLogins(store ratelimit.Store, cfg *configs.Config) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+		login, ok := req.(*pb.LoginRequest)
+		if !ok || info.FullMethod != "/gohex.v1.AuthService/Login" {
+			return handler(ctx, req)
+		}
+		limit := configs.FromContext(ctx, cfg).RateLimit
+		if err := ratelimit.TakeLogin(ctx, store, peerIP(ctx), login.GetUsername(), limit.LoginPerIP, limit.LoginPerUsername); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// Authorize authenticates the calls with the access token of their authorization metadata, opaque or signed, and
+// checks that it is granted the permission of the method. The token must be active, neither revoked by a logout nor
+// by its session, and belong to a user, as for the routes of the logged in users.
+func Authorize(keys *pkgauth.Keys, service auth.ServicePort) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+		permission, ok := methodPermissions[info.FullMethod]
+		if !ok {
+			return nil, ierr.ErrForbidden
+		}
+		if permission == permissionPublic {
+			return handler(ctx, req)
+		}
+
+		tokenString := bearerToken(ctx)
+		if tokenString == "" {
+			return nil, ierr.ErrUnauthorized
+		}
+		introspection, err := service.Introspect(ctx, auth.RequestIntrospect{Token: tokenString})
+		if err != nil {
+			return nil, err
+		}
+		if !introspection.Active || introspection.PrincipalType != domain.PrincipalTypeUser {
+			return nil, ierr.ErrUnauthorized
+		}
+
+		if pkgauth.IsOpaqueToken(tokenString) {
+			tokenString, err = service.ResolveAccessToken(ctx, tokenString)
+			if err != nil {
+				return nil, err
+			}
+		}
+		token, err := pkgauth.VerifyToken(tokenString, keys)
+		if err != nil {
+			return nil, ierr.ErrUnauthorized
+		}
+		if _, ok := token.Claims.(jwt.MapClaims); !ok {
+			return nil, ierr.ErrUnauthorized
+		}
+
+		ctx = ctxutil.WithPrincipal(ctx, token)
+		ctx = logger.WithUserID(ctx, introspection.Subject)
+		if err := authz.Check(ctx, permission); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// bearerToken returns the token of the authorization metadata, "Bearer <token>"
+func bearerToken(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get("authorization") {
+		if scheme, token, ok := strings.Cut(value, " "); ok && strings.EqualFold(scheme, "Bearer") {
+			return token
+		}
+	}
+	return ""
+}
+
+// metadataCarrier reads the trace context from the metadata of a call
+type metadataCarrier metadata.MD
+
+// Get implements propagation.TextMapCarrier
+func (c metadataCarrier) Get(key string) string {
+	values := metadata.MD(c).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// Set implements propagation.TextMapCarrier
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+// Keys implements propagation.TextMapCarrier
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,31 @@
+// Package grpc serves the auth and user services over gRPC, alongside the HTTP api.
+// The messages and services are defined in shared/pb, with the DTOs answered in protobuf over HTTP.
+package grpc
+
+import (
+	"go-hex/configs"
+	"go-hex/internal/auth"
+	"go-hex/internal/user"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/ratelimit"
+	"go-hex/shared/pb"
+
+	"google.golang.org/grpc"
+)
+
+// NewServer creates the gRPC server of the auth and user services.
+// Every call is traced, sees the current configuration, is authenticated and authorized by the permission of its
+// method, and its errors are mapped to the gRPC status codes. The logins take the login rate limits of limits.
+func NewServer(cfg *configs.Config, current func() *configs.Config, authService auth.ServicePort, userService user.ServicePort, limits ratelimit.Store, log logger.Logger) *grpc.Server {
+
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		Tracing(cfg.Server.NAME),
+		CurrentConfig(current),
+		MapErrors(log),
+		LimitLogins(limits, cfg),
+		Authorize(cfg.JWTKeys(), authService),
+	))
+	pb.RegisterAuthServiceServer(server, authServer{cfg: cfg, service: authService})
+	pb.RegisterUserServiceServer(server, userServer{service: userService})
+	return server
+}
@@ -0,0 +1,239 @@
+package grpc
+
+import (
+	"context"
+	"go-hex/configs"
+	"go-hex/internal/auth"
+	"go-hex/internal/domain"
+	"go-hex/internal/user"
+	"go-hex/pkg/logger"
+	"go-hex/pkg/ratelimit"
+	"go-hex/shared/ierr"
+	"go-hex/shared/pb"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type fakeAuthService struct {
+	auth.ServicePort
+	login auth.ResponseLogin
+	err   error
+}
+
+func (s fakeAuthService) Login(ctx context.Context, req auth.RequestLogin) (auth.ResponseLogin, error) {
+	if req.Username != "admin" || req.Password != "password1234" {
+		return auth.ResponseLogin{}, ierr.ErrInvalidCreds
+	}
+	return s.login, s.err
+}
+
+func (s fakeAuthService) Introspect(ctx context.Context, req auth.RequestIntrospect) (auth.ResponseIntrospect, error) {
+	return auth.ResponseIntrospect{Active: true, Subject: "u1", PrincipalType: domain.PrincipalTypeUser}, nil
+}
+
+type fakeUserService struct {
+	user.ServicePort
+}
+
+func (s fakeUserService) GetByID(ctx context.Context, req user.RequestUserID) (user.ResponseUser, error) {
+	if req.ID != "u1" {
+		return user.ResponseUser{}, ierr.ErrResourceNotFound
+	}
+	return user.ResponseUser{User: domain.User{ID: "u1", Username: "admin"}}, nil
+}
+
+func dial(t *testing.T, cfg *configs.Config, authService auth.ServicePort) *grpc.ClientConn {
+	listener := bufconn.Listen(1024 * 1024)
+	server := NewServer(cfg, func() *configs.Config { return cfg }, authService, fakeUserService{}, ratelimit.NewMemoryStore(), logger.New("test", "test"))
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet", grpc.WithInsecure(),
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestServer(t *testing.T) {
+	cfg := &configs.Config{}
+	cfg.JWT.SigningKey = "secret"
+	conn := dial(t, cfg, fakeAuthService{login: auth.ResponseLogin{AccessToken: "access", RefreshToken: "refresh"}})
+	authClient := pb.NewAuthServiceClient(conn)
+	userClient := pb.NewUserServiceClient(conn)
+	ctx := context.Background()
+
+	// the login is public
+	res, err := authClient.Login(ctx, &pb.LoginRequest{Username: "admin", Password: "password1234"})
+	require.NoError(t, err)
+	assert.Equal(t, "access", res.GetLogin().GetAccessToken())
+
+	// the errors of the services are mapped with their code
+	_, err = authClient.Login(ctx, &pb.LoginRequest{Username: "admin", Password: "wrong"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	details := status.Convert(err).Details()
+	require.Len(t, details, 1)
+	assert.Equal(t, ierr.ErrInvalidCreds.Code, details[0].(*errdetails.ErrorInfo).Reason)
+
+	// the user service requires an access token granted the permission of the method
+	_, err = userClient.GetUser(ctx, &pb.GetUserRequest{Id: "u1"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	token := func(permissions ...string) context.Context {
+		signed, err := cfg.JWTKeys().Signer().Sign(jwt.MapClaims{"id": "u1", "username": "admin", "permissions": permissions})
+		require.NoError(t, err)
+		return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+signed)
+	}
+	_, err = userClient.GetUser(token("profile:read"), &pb.GetUserRequest{Id: "u1"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	u, err := userClient.GetUser(token("users:read"), &pb.GetUserRequest{Id: "u1"})
+	require.NoError(t, err)
+	assert.Equal(t, "admin", u.GetUsername())
+
+	_, err = userClient.GetUser(token("users:*"), &pb.GetUserRequest{Id: "u2"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestServerLimitsLogins(t *testing.T) {
+	cfg := &configs.Config{}
+	cfg.JWT.SigningKey = "secret"
+	cfg.RateLimit.LoginPerUsername = 2
+	authClient := pb.NewAuthServiceClient(dial(t, cfg, fakeAuthService{}))
+	ctx := context.Background()
+
+	// the logins share the login limits of the routes, per username in lower case
+	for _, username := range []string{"admin", "Admin"} {
+		_, err := authClient.Login(ctx, &pb.LoginRequest{Username: username, Password: "wrong"})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	}
+	_, err := authClient.Login(ctx, &pb.LoginRequest{Username: "ADMIN", Password: "password1234"})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// the refused logins tell until when and how long to wait for the next attempt, 2 per minute
+	if details := status.Convert(err).Details(); assert.Len(t, details, 2) {
+		info := details[0].(*errdetails.ErrorInfo)
+		assert.Equal(t, ierr.ErrTooManyRequests.Code, info.Reason)
+		until, err := time.Parse(time.RFC3339Nano, info.Metadata["locked_until"])
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(30*time.Second), until, 2*time.Second)
+		delay := details[1].(*errdetails.RetryInfo).RetryDelay.AsDuration()
+		assert.InDelta(t, 30*time.Second, delay, float64(2*time.Second))
+	}
+
+	_, err = authClient.Login(ctx, &pb.LoginRequest{Username: "root", Password: "wrong"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestServerStrictEnumerationAnswersTheSame(t *testing.T) {
+	cfg := &configs.Config{}
+	cfg.JWT.SigningKey = "secret"
+	cfg.Enumeration.Strict = true
+	cfg.Enumeration.MinDuration = 100
+	authClient := pb.NewAuthServiceClient(dial(t, cfg, fakeAuthService{}))
+
+	// the logins are answered once the minimum duration elapsed, whether the account exists or not
+	var answers []*status.Status
+	for _, username := range []string{"root", "admin"} {
+		start := time.Now()
+		_, err := authClient.Login(context.Background(), &pb.LoginRequest{Username: username, Password: "wrong"})
+		assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond, username)
+		answers = append(answers, status.Convert(err))
+	}
+	assert.Equal(t, codes.Unauthenticated, answers[0].Code())
+	assert.Equal(t, answers[0].Proto().String(), answers[1].Proto().String())
+}
+
+func TestLimitLogins(t *testing.T) {
+	cfg := &configs.Config{}
+	cfg.RateLimit.LoginPerIP = 1
+	mapErrors, limit := MapErrors(logger.New("test", "test")), LimitLogins(ratelimit.NewMemoryStore(), cfg)
+	interceptor := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return mapErrors(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return limit(ctx, req, info, handler)
+		})
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: "/gohex.v1.AuthService/Login"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return &pb.LoginResponse{}, nil }
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 4242}})
+
+	_, err := interceptor(ctx, &pb.LoginRequest{Username: "admin"}, info, handler)
+	require.NoError(t, err)
+
+	// the address is limited whatever the username, the other methods are not limited
+	_, err = interceptor(ctx, &pb.LoginRequest{Username: "root"}, info, handler)
+	st := status.Convert(err)
+	assert.Equal(t, codes.ResourceExhausted, st.Code())
+	if details := st.Details(); assert.Len(t, details, 2) {
+		assert.NotEmpty(t, details[0].(*errdetails.ErrorInfo).Metadata["locked_until"])
+		delay := details[1].(*errdetails.RetryInfo).RetryDelay.AsDuration()
+		assert.InDelta(t, time.Minute, delay, float64(2*time.Second))
+	}
+	_, err = interceptor(ctx, &pb.RefreshTokenRequest{}, &grpc.UnaryServerInfo{FullMethod: "/gohex.v1.AuthService/RefreshToken"}, handler)
+	assert.NoError(t, err)
+}
+
+func TestToStatus(t *testing.T) {
+	tests := []struct {
+		err      error
+		code     codes.Code
+		internal bool
+	}{
+		{ierr.ErrUserAlreadyRegistered, codes.AlreadyExists, false},
+		{ierr.ErrUserIsNotActive, codes.FailedPrecondition, false},
+		{ierr.ErrSessionLimitReached, codes.ResourceExhausted, false},
+		{ierr.ErrEmailAlreadyVerified, codes.InvalidArgument, false},
+		{ierr.ErrForbidden, codes.PermissionDenied, false},
+		{ierr.ErrTooManyRequests, codes.ResourceExhausted, false},
+		{ierr.ErrServiceUnavailable, codes.Unavailable, false},
+		{ierr.ErrUnsupportedMedia, codes.FailedPrecondition, false},
+		{context.DeadlineExceeded, codes.DeadlineExceeded, false},
+		{ierr.ErrInternal, codes.Internal, true},
+		{assert.AnError, codes.Internal, true},
+	}
+	for _, tt := range tests {
+		st, internal := toStatus(tt.err)
+		assert.Equal(t, tt.code, st.Code(), tt.err.Error())
+		assert.Equal(t, tt.internal, internal, tt.err.Error())
+	}
+
+	// the internal errors are answered without their message
+	st, _ := toStatus(assert.AnError)
+	assert.Equal(t, ierr.ErrInternal.Message, st.Message())
+
+	// the limited errors tell until when and how long to wait
+	until := time.Now().Add(30 * time.Second)
+	st, _ = toStatus(errors.WithStack(ierr.Limited(ierr.ErrTooManyRequests, until)))
+	assert.Equal(t, codes.ResourceExhausted, st.Code())
+	if details := st.Details(); assert.Len(t, details, 2) {
+		info := details[0].(*errdetails.ErrorInfo)
+		assert.Equal(t, ierr.ErrTooManyRequests.Code, info.Reason)
+		assert.Equal(t, until.UTC().Format(time.RFC3339Nano), info.Metadata["locked_until"])
+		delay := details[1].(*errdetails.RetryInfo).RetryDelay.AsDuration()
+		assert.InDelta(t, 30*time.Second, delay, float64(time.Second))
+	}
+}
+
+func TestMethodPermissions(t *testing.T) {
+	// every method registered declares its permission, the others are refused
+	cfg := &configs.Config{}
+	server := NewServer(cfg, func() *configs.Config { return cfg }, fakeAuthService{}, fakeUserService{}, ratelimit.NewMemoryStore(), logger.New("test", "test"))
+	for service, info := range server.GetServiceInfo() {
+		for _, method := range info.Methods {
+			assert.Contains(t, methodPermissions, "/"+service+"/"+method.Name)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package grpc
+
+import (
+	"context"
+	"go-hex/internal/domain"
+	"go-hex/internal/user"
+	"go-hex/shared/pb"
+)
+
+// userServer serves the user service, like the routes of /users
+type userServer struct {
+	pb.UnimplementedUserServiceServer
+	service user.ServicePort
+}
+
+// CreateUser registers a user
+func (s userServer) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.CreateUserResponse, error) {
+
+	res, err := s.service.Register(ctx, user.RequestRegister{
+		Username: req.GetUsername(),
+		Password: req.GetPassword(),
+		FullName: req.GetFullName(),
+		Email:    req.GetEmail(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CreateUserResponse{User: toProtoUser(res.User), VerificationSent: res.VerificationSent}, nil
+}
+
+// GetUser returns a user
+func (s userServer) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.User, error) {
+
+	res, err := s.service.GetByID(ctx, user.RequestUserID{ID: req.GetId()})
+	if err != nil {
+		return nil, err
+	}
+	return res.ToProto().(*pb.User), nil
+}
+
+// ListUsers returns a page of users
+func (s userServer) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+
+	res, err := s.service.List(ctx, user.RequestListUsers{AfterID: req.GetAfterId(), Limit: int(req.GetLimit())})
+	if err != nil {
+		return nil, err
+	}
+	users := make([]*pb.User, 0, len(res.Users))
+	for _, u := range res.Users {
+		users = append(users, toProtoUser(u))
+	}
+	return &pb.ListUsersResponse{Users: users, NextAfterId: res.NextAfterID}, nil
+}
+
+// UpdateUser updates the profile of a user
+func (s userServer) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.User, error) {
+
+	res, err := s.service.Update(ctx, user.RequestUpdateUser{
+		ID:       req.GetId(),
+		FullName: req.GetFullName(),
+		Email:    req.GetEmail(),
+		Phone:    req.GetPhone(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.ToProto().(*pb.User), nil
+}
+
+// DeleteUser deactivates a user
+func (s userServer) DeleteUser(ctx context.Context, req *pb.DeleteUserRequest) (*pb.DeleteUserResponse, error) {
+
+	if err := s.service.Deactivate(ctx, user.RequestUserID{ID: req.GetId()}); err != nil {
+		return nil, err
+	}
+	return &pb.DeleteUserResponse{}, nil
+}
+
+// toProtoUser maps a user without the deliverability of its email
+func toProtoUser(u domain.User) *pb.User {
+	return user.ResponseUser{User: u}.ToProto().(*pb.User)
+}